@@ -0,0 +1,12 @@
+package api
+
+import "time"
+
+// Clock provides the current time and sleeps, so the time seen by the bot can be controlled in tests
+type Clock interface {
+	// Now returns the current time
+	Now() time.Time
+
+	// Sleep pauses for the duration
+	Sleep(d time.Duration)
+}
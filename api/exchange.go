@@ -39,6 +39,7 @@ type Ticker struct {
 	AskPrice  *model.Number
 	BidPrice  *model.Number
 	LastPrice *model.Number
+	Timestamp *model.Timestamp // can be nil when the exchange does not return the time of the ticker
 }
 
 // TradesResult is the result of a GetTrades call
@@ -95,6 +96,27 @@ type Constrainable interface {
 	OverrideOrderConstraints(pair *model.TradingPair, override *model.OrderConstraintsOverride)
 }
 
+// TradingFeeFetcher is implemented by exchanges that can report the fee rates charged on trades
+type TradingFeeFetcher interface {
+	// GetTakerFee returns the taker fee as a fraction of the trade, e.g. 0.001 for 0.1%
+	GetTakerFee(pair *model.TradingPair) (float64, error)
+}
+
+// APIKeyPermissions are the permissions granted to an exchange API key
+type APIKeyPermissions struct {
+	Key          string // masked so it can be logged
+	CanRead      bool
+	CanTrade     bool
+	CanWithdraw  bool
+	IPRestricted *bool // nil when the exchange does not report it
+}
+
+// APIKeyPermissionsFetcher is implemented by exchanges that can report the permissions of their API keys
+type APIKeyPermissionsFetcher interface {
+	// GetAPIKeyPermissions returns the permissions of every API key used by the exchange
+	GetAPIKeyPermissions() ([]APIKeyPermissions, error)
+}
+
 // OrderbookFetcher extracts out the method that should go into ExchangeShim for now
 type OrderbookFetcher interface {
 	GetOrderBook(pair *model.TradingPair, maxCount int32) (*model.OrderBook, error)
@@ -123,6 +145,7 @@ type TradeAPI interface {
 type PrepareDepositResult struct {
 	Fee      *model.Number // fee that will be deducted from your deposit, i.e. amount available is depositAmount - fee
 	Address  string        // address you should send the funds to
+	Memo     string        // memo you should attach when sending the funds, empty if the address does not need a memo
 	ExpireTs int64         // expire time as a unix timestamp, 0 if it does not expire
 }
 
@@ -183,14 +206,16 @@ type WithdrawAPI interface {
 			asset - asset you want to withdraw
 			amountToWithdraw - amount you want deducted from your account (fees will be deducted from here, use GetWithdrawInfo for fee estimate)
 			address - address you want to withdraw to
+			memo - memo (or tag) that the address needs to credit the withdrawal, such as the Memo of the PrepareDepositResult of another exchange, empty for none
 		Output:
 		    WithdrawFunds - result of the withdrawal
-			error - any error
+			error - ErrWithdrawMemoRequired, or any other error
 	*/
 	WithdrawFunds(
 		asset model.Asset,
 		amountToWithdraw *model.Number,
 		address string,
+		memo string,
 	) (*WithdrawFunds, error)
 }
 
@@ -210,6 +235,14 @@ func MakeErrWithdrawAmountInvalid(amountToWithdraw *model.Number, fee *model.Num
 	return fmt.Errorf("amountToWithdraw is invalid: %s, fee: %s", amountToWithdraw.AsString(), fee.AsString())
 }
 
+// ErrWithdrawMemoRequired error type
+type ErrWithdrawMemoRequired error
+
+// MakeErrWithdrawMemoRequired is a factory method
+func MakeErrWithdrawMemoRequired(asset string) ErrWithdrawMemoRequired {
+	return fmt.Errorf("withdrawals of %s need a memo since its addresses are shared by the accounts of an exchange, pass the memo of the address (any memo is ignored by an address that does not need one)", asset)
+}
+
 // Exchange is the interface we use as a generic API for all crypto exchanges
 type Exchange interface {
 	Account
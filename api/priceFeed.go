@@ -1,12 +1,22 @@
 package api
 
-import "log"
+import (
+	"log"
+	"time"
+)
 
 // PriceFeed allows you to fetch the price of a feed
 type PriceFeed interface {
 	GetPrice() (float64, error)
 }
 
+// TimestampedPriceFeed is a PriceFeed that also returns the time at which its price was last updated by the source, which is used to reject
+// stale prices. Feeds that do not implement this interface are treated as if their price was updated when it was fetched
+type TimestampedPriceFeed interface {
+	PriceFeed
+	GetPriceWithTimestamp() (float64, time.Time, error)
+}
+
 // TODO this should be structured as a specific impl. of the PriceFeed interface
 // FeedPair is the struct representing a price feed for a trading pair
 type FeedPair struct {
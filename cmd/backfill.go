@@ -0,0 +1,300 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stellar/go/clients/horizonclient"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/plugins"
+	"github.com/stellar/kelp/support/database"
+	"github.com/stellar/kelp/support/postgresdb"
+	"github.com/stellar/kelp/support/sdk"
+	"github.com/stellar/kelp/support/toml"
+	"github.com/stellar/kelp/support/utils"
+	"github.com/stellar/kelp/trader"
+)
+
+const backfillExamples = `  kelp backfill --botConf ./path/trader.cfg --from 2023-01-01
+  kelp backfill --botConf ./path/trader.cfg --from 2023-01-01 --to 2023-06-30
+  kelp backfill --botConf ./path/trader1.cfg --botConf ./path/trader2.cfg --from 2023-01-01`
+
+var backfillCmd = &cobra.Command{
+	Use:   "backfill",
+	Short: "Loads the trade history of the bot's market from the trading exchange into the db",
+	Long: `Loads the trade history of the bot's market from the trading exchange into the db, so the volume filters and the reports of the GUI
+include the trades that were made before the bot started writing its trades to the db.
+
+The trader config needs POSTGRES_DB and DB_OVERRIDE__ACCOUNT_ID to be set, the trades are written with the same market and account as the bot
+writes them. Trades that are already in the db are skipped, so the backfill can be run again for an overlapping date range. Pass --botConf
+once for every market that should be backfilled.
+
+Trades on SDEX are fetched from horizon starting at the first trade of the trading account since horizon cannot look up trades by date, so the
+backfill of an account with a long history can take a while. The inventory lots are not rebuilt from the backfilled trades.`,
+	Example: backfillExamples,
+}
+
+// backfillPageDelay is how long we wait between fetching pages of trades to stay below the rate limits of horizon and the exchanges
+const backfillPageDelay = time.Second
+
+func init() {
+	botConfigPaths := backfillCmd.Flags().StringArrayP("botConf", "c", []string{}, "(required) trading bot's basic config file path, can be repeated to backfill several markets")
+	profile := backfillCmd.Flags().String("profile", "", "name of the profile section in the bot config files to use, such as testnet or pubnet")
+	fromDate := backfillCmd.Flags().String("from", "", "(required) date (UTC) in the format YYYY-MM-DD of the first day to backfill")
+	toDate := backfillCmd.Flags().String("to", "", "date (UTC) in the format YYYY-MM-DD of the last day to backfill, defaults to today")
+	nonceDir := backfillCmd.Flags().String("nonce-dir", "", "directory where the last nonce of every exchange API key is persisted, needs to be the same as the one used by the bot (defaults to ~/.kelp/nonces)")
+	for _, flag := range []string{"botConf", "from"} {
+		e := backfillCmd.MarkFlagRequired(flag)
+		if e != nil {
+			panic(e)
+		}
+	}
+
+	backfillCmd.Run = func(ccmd *cobra.Command, args []string) {
+		checkInitRootFlags()
+
+		from, to, e := parseBackfillDateRange(*fromDate, *toDate, time.Now().UTC())
+		if e != nil {
+			log.Fatal(e)
+		}
+		_, e = setExchangeNonceRegistry(*nonceDir)
+		if e != nil {
+			log.Fatal(e)
+		}
+
+		for _, botConfigPath := range *botConfigPaths {
+			var botConfig trader.BotConfig
+			e := toml.ReadConfig(botConfigPath, *profile, &botConfig)
+			utils.CheckConfigError(botConfig, e, botConfigPath)
+			botConfig.LoadSecretsFromEnv()
+			e = botConfig.Init()
+			if e != nil {
+				log.Fatal(e)
+			}
+
+			e = backfillMarket(botConfig, from, to)
+			if e != nil {
+				log.Fatal(fmt.Errorf("could not backfill trades of bot config '%s': %s", botConfigPath, e))
+			}
+		}
+	}
+}
+
+// parseBackfillDateRange returns the start (inclusive) and end (exclusive) of the days to backfill, the to date defaults to today
+func parseBackfillDateRange(fromDate string, toDate string, now time.Time) (time.Time, time.Time, error) {
+	from, e := time.Parse("2006-01-02", fromDate)
+	if e != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid from date '%s', needs to be in the format YYYY-MM-DD: %s", fromDate, e)
+	}
+	to := now.Truncate(24 * time.Hour)
+	if toDate != "" {
+		to, e = time.Parse("2006-01-02", toDate)
+		if e != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid to date '%s', needs to be in the format YYYY-MM-DD: %s", toDate, e)
+		}
+	}
+	if to.Before(from) {
+		return time.Time{}, time.Time{}, fmt.Errorf("from date (%s) needs to be on or before the to date (%s)", fromDate, to.Format("2006-01-02"))
+	}
+	return from, to.AddDate(0, 0, 1), nil
+}
+
+// backfillMarket writes the trades of the market of the bot config in the date range [from, to) to the db of the bot config
+func backfillMarket(botConfig trader.BotConfig, from time.Time, to time.Time) error {
+	if botConfig.PostgresDbConfig == nil {
+		utils.PrintErrorHintf("POSTGRES_DB needs to be set in the trader.cfg file to backfill trades")
+		return fmt.Errorf("invalid trader.cfg config, need to set POSTGRES_DB")
+	}
+	if botConfig.DbOverrideAccountID == "" {
+		utils.PrintErrorHintf("DB_OVERRIDE__ACCOUNT_ID needs to be set in the trader.cfg file so the backfilled trades are assigned to the same account_id as the trades of the bot")
+		return fmt.Errorf("invalid trader.cfg config, need to set DB_OVERRIDE__ACCOUNT_ID")
+	}
+	if *rootCcxtRestURL == "" && botConfig.CcxtRestURL != nil {
+		e := sdk.SetBaseURL(*botConfig.CcxtRestURL)
+		if e != nil {
+			return fmt.Errorf("unable to set CCXT-rest URL to '%s': %s", *botConfig.CcxtRestURL, e)
+		}
+	}
+
+	db, e := database.ConnectInitializedDatabase(botConfig.PostgresDbConfig, upgradeScripts, version)
+	if e != nil {
+		return fmt.Errorf("problem encountered while initializing the db: %s", e)
+	}
+	defer db.Close()
+
+	fetcher, tradingPair, assetDisplayFn, e := makeBackfillTradeFetcher(botConfig)
+	if e != nil {
+		return e
+	}
+	b := &tradeBackfiller{
+		fetcher:   fetcher,
+		pair:      *tradingPair,
+		from:      from,
+		to:        to,
+		inserter:  plugins.MakeFillDBWriter(db, assetDisplayFn, botConfig.TradingExchangeName(), botConfig.DbOverrideAccountID).(*plugins.FillDBWriter),
+		pageDelay: backfillPageDelay,
+	}
+	log.Printf("backfilling trades of %s on %s from %s to %s (exclusive)\n", tradingPair, botConfig.TradingExchangeName(),
+		from.Format(postgresdb.DateFormatString), to.Format(postgresdb.DateFormatString))
+	stats, e := b.run(backfillStartCursor(botConfig, from))
+	log.Printf("backfill of %s on %s: %s\n", tradingPair, botConfig.TradingExchangeName(), stats)
+	return e
+}
+
+// makeBackfillTradeFetcher makes the fetcher of the trade history of the trading exchange, it does not need the strategy or any of the
+// machinery that is used to place orders
+func makeBackfillTradeFetcher(botConfig trader.BotConfig) (api.TradeFetcher, *model.TradingPair, model.AssetDisplayFn, error) {
+	assetBase := botConfig.AssetBase()
+	assetQuote := botConfig.AssetQuote()
+	tradingPair := &model.TradingPair{
+		Base:  model.Asset(utils.Asset2CodeString(assetBase)),
+		Quote: model.Asset(utils.Asset2CodeString(assetQuote)),
+	}
+
+	if !botConfig.IsTradingSdex() {
+		exchangeParams := []api.ExchangeParam{}
+		for _, param := range botConfig.ExchangeParams {
+			exchangeParams = append(exchangeParams, api.ExchangeParam{
+				Param: param.Param,
+				Value: param.Value,
+			})
+		}
+		exchangeHeaders := []api.ExchangeHeader{}
+		for _, header := range botConfig.ExchangeHeaders {
+			exchangeHeaders = append(exchangeHeaders, api.ExchangeHeader{
+				Header: header.Header,
+				Value:  header.Value,
+			})
+		}
+
+		exchangeAPI, e := plugins.MakeTradingExchange(botConfig.TradingExchange, botConfig.ExchangeAPIKeys.ToExchangeAPIKeys(), exchangeParams, exchangeHeaders, false)
+		if e != nil {
+			return nil, nil, nil, fmt.Errorf("unable to make trading exchange: %s", e)
+		}
+		return exchangeAPI, tradingPair, model.MakePassthroughAssetDisplayFn(), nil
+	}
+
+	client := &horizonclient.Client{
+		HorizonURL: botConfig.HorizonURL,
+		HTTP:       http.DefaultClient,
+		AppName:    "kelp--cli--backfill",
+		AppVersion: version,
+	}
+	sdexAssetMap := map[model.Asset]hProtocol.Asset{
+		tradingPair.Base:  assetBase,
+		tradingPair.Quote: assetQuote,
+	}
+	// the sdex instance is only used to fetch trades so it does not need a thread tracker, operational buffers or a fee function
+	sdex := plugins.MakeSDEX(
+		client,
+		plugins.MakeIEIF(true),
+		nil,
+		botConfig.SourceSecretSeed,
+		botConfig.TradingSecretSeed,
+		botConfig.SourceAccount(),
+		botConfig.TradingAccount(),
+		utils.ParseNetwork(botConfig.HorizonURL),
+		nil,
+		0,
+		0,
+		false,
+		tradingPair,
+		sdexAssetMap,
+		nil,
+	)
+	return sdex, tradingPair, model.MakeSdexMappedAssetDisplayFn(sdexAssetMap), nil
+}
+
+// backfillStartCursor returns the cursor from where the trade history is fetched, in the same format as the cursor that the trading exchange
+// returns from GetLatestTradeCursor
+func backfillStartCursor(botConfig trader.BotConfig, from time.Time) interface{} {
+	if botConfig.IsTradingSdex() {
+		// horizon pages through trades by paging token, so we start from the first trade of the account and skip the trades before from
+		return nil
+	}
+	if botConfig.TradingExchange == "kraken" {
+		return strconv.FormatInt(from.Unix(), 10)
+	}
+	return strconv.FormatInt(from.UnixNano()/int64(time.Millisecond), 10)
+}
+
+// backfillStats counts the trades that were handled by a tradeBackfiller
+type backfillStats struct {
+	numFetched  int
+	numBefore   int // trades before the start of the date range
+	numInserted int
+	numExisting int // trades that were already in the db
+}
+
+// String is the Stringer method
+func (s backfillStats) String() string {
+	return fmt.Sprintf("fetched %d trades, skipped %d trades before the start date, inserted %d trades, skipped %d trades that were already in the db",
+		s.numFetched, s.numBefore, s.numInserted, s.numExisting)
+}
+
+// tradeInserter writes a trade to the db and returns false when the trade was already in the db
+type tradeInserter interface {
+	InsertFill(trade model.Trade) (bool, error)
+}
+
+// tradeBackfiller pages through the trade history of a market and hands the trades in the date range [from, to) to the inserter. Trades are
+// returned in ascending order by all trade fetchers, so the backfill stops at the first trade on or after to
+type tradeBackfiller struct {
+	fetcher   api.TradeFetcher
+	pair      model.TradingPair
+	from      time.Time
+	to        time.Time
+	inserter  tradeInserter
+	pageDelay time.Duration
+}
+
+func (b *tradeBackfiller) run(startCursor interface{}) (backfillStats, error) {
+	stats := backfillStats{}
+	cursor := startCursor
+	for {
+		result, e := b.fetcher.GetTradeHistory(b.pair, cursor, nil)
+		if e != nil {
+			return stats, fmt.Errorf("could not fetch trades from cursor '%v': %s", cursor, e)
+		}
+
+		for _, trade := range result.Trades {
+			stats.numFetched++
+			if trade.Timestamp == nil {
+				return stats, fmt.Errorf("cannot backfill a trade without a timestamp: %s", trade)
+			}
+			tradeTime := time.Unix(0, trade.Timestamp.AsInt64()*int64(time.Millisecond))
+			if tradeTime.Before(b.from) {
+				stats.numBefore++
+				continue
+			}
+			if !tradeTime.Before(b.to) {
+				return stats, nil
+			}
+
+			inserted, e := b.inserter.InsertFill(trade)
+			if e != nil {
+				return stats, fmt.Errorf("could not insert trade: %s", e)
+			}
+			if inserted {
+				stats.numInserted++
+			} else {
+				stats.numExisting++
+			}
+		}
+
+		// the fetchers return the cursor that they were called with when there are no more trades
+		if len(result.Trades) == 0 || reflect.DeepEqual(result.Cursor, cursor) {
+			return stats, nil
+		}
+		log.Printf("backfill progress: %s, continuing from cursor '%v'\n", stats, result.Cursor)
+		cursor = result.Cursor
+		time.Sleep(b.pageDelay)
+	}
+}
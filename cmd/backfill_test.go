@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseBackfillDateRange(t *testing.T) {
+	now := time.Date(2023, 3, 10, 15, 4, 5, 0, time.UTC)
+	testCases := []struct {
+		fromDate string
+		toDate   string
+		wantFrom time.Time
+		wantTo   time.Time
+		wantErr  bool
+	}{
+		{"2023-01-01", "", time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2023, 3, 11, 0, 0, 0, 0, time.UTC), false},
+		{"2023-01-01", "2023-01-31", time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC), false},
+		{"2023-01-31", "2023-01-31", time.Date(2023, 1, 31, 0, 0, 0, 0, time.UTC), time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC), false},
+		{"2023-02-01", "2023-01-31", time.Time{}, time.Time{}, true},
+		{"2023/01/01", "", time.Time{}, time.Time{}, true},
+		{"2023-01-01", "31-01-2023", time.Time{}, time.Time{}, true},
+	}
+
+	for _, k := range testCases {
+		t.Run(fmt.Sprintf("%s/%s", k.fromDate, k.toDate), func(t *testing.T) {
+			from, to, e := parseBackfillDateRange(k.fromDate, k.toDate, now)
+			if k.wantErr {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, k.wantFrom, from)
+			assert.Equal(t, k.wantTo, to)
+		})
+	}
+}
+
+// pagedTradeFetcher returns one page of trades per call, the cursor is the index of the next page
+type pagedTradeFetcher struct {
+	pages   [][]model.Trade
+	cursors []interface{}
+}
+
+func (f *pagedTradeFetcher) GetTradeHistory(pair model.TradingPair, maybeCursorStart interface{}, maybeCursorEnd interface{}) (*api.TradeHistoryResult, error) {
+	f.cursors = append(f.cursors, maybeCursorStart)
+	page := 0
+	if maybeCursorStart != nil {
+		page = maybeCursorStart.(int)
+	}
+	if page >= len(f.pages) {
+		return &api.TradeHistoryResult{Cursor: maybeCursorStart, Trades: []model.Trade{}}, nil
+	}
+	return &api.TradeHistoryResult{Cursor: page + 1, Trades: f.pages[page]}, nil
+}
+
+type recordingTradeInserter struct {
+	existing map[string]bool
+	txIDs    []string
+}
+
+func (h *recordingTradeInserter) InsertFill(trade model.Trade) (bool, error) {
+	h.txIDs = append(h.txIDs, trade.TransactionID.String())
+	return !h.existing[trade.TransactionID.String()], nil
+}
+
+func makeBackfillTestTrade(txID string, day int) model.Trade {
+	return model.Trade{
+		Order: model.Order{
+			Timestamp: model.MakeTimestampFromTime(time.Date(2023, 1, day, 12, 0, 0, 0, time.UTC)),
+		},
+		TransactionID: model.MakeTransactionID(txID),
+	}
+}
+
+func TestTradeBackfillerRun(t *testing.T) {
+	pages := [][]model.Trade{
+		{makeBackfillTestTrade("a", 1), makeBackfillTestTrade("b", 2)},
+		{makeBackfillTestTrade("c", 3), makeBackfillTestTrade("d", 4)},
+		{makeBackfillTestTrade("e", 5)},
+	}
+	testCases := []struct {
+		name        string
+		to          time.Time
+		existing    map[string]bool
+		wantTxIDs   []string
+		wantCursors []interface{}
+		wantStats   backfillStats
+	}{
+		{
+			name:        "until the end of the history",
+			to:          time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC),
+			wantTxIDs:   []string{"b", "c", "d", "e"},
+			wantCursors: []interface{}{nil, 1, 2, 3},
+			wantStats:   backfillStats{numFetched: 5, numBefore: 1, numInserted: 4},
+		}, {
+			name:        "stops at the end date",
+			to:          time.Date(2023, 1, 4, 0, 0, 0, 0, time.UTC),
+			wantTxIDs:   []string{"b", "c"},
+			wantCursors: []interface{}{nil, 1},
+			wantStats:   backfillStats{numFetched: 4, numBefore: 1, numInserted: 2},
+		}, {
+			name:        "trades already in the db are not counted as inserted",
+			to:          time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC),
+			existing:    map[string]bool{"b": true, "c": true},
+			wantTxIDs:   []string{"b", "c", "d", "e"},
+			wantCursors: []interface{}{nil, 1, 2, 3},
+			wantStats:   backfillStats{numFetched: 5, numBefore: 1, numInserted: 2, numExisting: 2},
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			fetcher := &pagedTradeFetcher{pages: pages}
+			inserter := &recordingTradeInserter{existing: k.existing}
+			b := &tradeBackfiller{
+				fetcher:  fetcher,
+				pair:     model.TradingPair{Base: model.XLM, Quote: model.USD},
+				from:     time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC),
+				to:       k.to,
+				inserter: inserter,
+			}
+
+			stats, e := b.run(nil)
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, k.wantTxIDs, inserter.txIDs)
+			assert.Equal(t, k.wantCursors, fetcher.cursors)
+			assert.Equal(t, k.wantStats, stats)
+		})
+	}
+}
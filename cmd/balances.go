@@ -0,0 +1,295 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	"github.com/stellar/go/clients/horizonclient"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/plugins"
+	"github.com/stellar/kelp/support/assetdisplay"
+	"github.com/stellar/kelp/support/sdk"
+	"github.com/stellar/kelp/support/toml"
+	"github.com/stellar/kelp/support/utils"
+	"github.com/stellar/kelp/trader"
+)
+
+const balancesExamples = `  kelp balances --botConf ./path/trader.cfg
+  kelp balances --botConf ./path/trader.cfg --json
+  kelp balances --botConf ./path/trader.cfg --profile pubnet`
+
+var balancesCmd = &cobra.Command{
+	Use:     "balances",
+	Short:   "Prints the balances of the bot's SDEX account and trading exchange",
+	Example: balancesExamples,
+}
+
+// sdexReserves is the breakdown of the minimum balance of XLM that the SDEX account needs to maintain
+type sdexReserves struct {
+	SubentryCount     int32   `json:"subentry_count"`
+	NumSponsoring     uint32  `json:"num_sponsoring"` // entries of other accounts and claimable balances that this account pays the reserve for
+	NumSponsored      uint32  `json:"num_sponsored"`  // entries of this account that another account pays the reserve for
+	AccountReserve    float64 `json:"account_reserve"`
+	SubentriesReserve float64 `json:"subentries_reserve"`
+	SponsoringReserve float64 `json:"sponsoring_reserve"`
+	SponsoredReserve  float64 `json:"sponsored_reserve"` // paid by the sponsors so it is subtracted from the min reserve
+	MinReserve        float64 `json:"min_reserve"`
+}
+
+// balanceRow is the balance of a single asset on SDEX and on the trading exchange, fields are nil when the asset is not held there
+type balanceRow struct {
+	Asset                  string   `json:"asset"`
+	SdexBalance            *float64 `json:"sdex_balance"`
+	SdexBuyingLiabilities  *float64 `json:"sdex_buying_liabilities"`
+	SdexSellingLiabilities *float64 `json:"sdex_selling_liabilities"`
+	SdexReserve            *float64 `json:"sdex_reserve"`
+	SdexAvailable          *float64 `json:"sdex_available"` // balance that is not locked by reserves or selling liabilities
+	ExchangeBalance        *float64 `json:"exchange_balance"`
+	DisplayDecimals        int      `json:"display_decimals"` // from the stellar.toml of the issuer, see assetdisplay.Load
+}
+
+// balancesReport is what the balances command prints
+type balancesReport struct {
+	TradingAccount  string       `json:"trading_account"`
+	TradingExchange string       `json:"trading_exchange"`
+	SdexReserves    sdexReserves `json:"sdex_reserves"`
+	Balances        []balanceRow `json:"balances"`
+}
+
+func init() {
+	botConfigPath := balancesCmd.Flags().StringP("botConf", "c", "", "(required) trading bot's basic config file path")
+	profile := balancesCmd.Flags().String("profile", "", "name of the profile section in the bot config file to use, such as testnet or pubnet")
+	asJSON := balancesCmd.Flags().Bool("json", false, "print the balances as JSON instead of a table")
+	e := balancesCmd.MarkFlagRequired("botConf")
+	if e != nil {
+		panic(e)
+	}
+
+	balancesCmd.Run = func(ccmd *cobra.Command, args []string) {
+		checkInitRootFlags()
+
+		var botConfig trader.BotConfig
+		e := toml.ReadConfig(*botConfigPath, *profile, &botConfig)
+		utils.CheckConfigError(botConfig, e, *botConfigPath)
+		botConfig.LoadSecretsFromEnv()
+		e = botConfig.Init()
+		if e != nil {
+			log.Fatal(e)
+		}
+		if *rootCcxtRestURL == "" && botConfig.CcxtRestURL != nil {
+			e = sdk.SetBaseURL(*botConfig.CcxtRestURL)
+			if e != nil {
+				log.Fatal(fmt.Errorf("unable to set CCXT-rest URL to '%s': %s", *botConfig.CcxtRestURL, e))
+			}
+		}
+
+		report, e := fetchBalancesReport(botConfig)
+		if e != nil {
+			log.Fatal(e)
+		}
+
+		if *asJSON {
+			reportJSON, e := json.MarshalIndent(report, "", "    ")
+			if e != nil {
+				log.Fatal(fmt.Errorf("unable to marshal balances as json: %s", e))
+			}
+			fmt.Println(string(reportJSON))
+			return
+		}
+		printBalancesReport(report)
+	}
+}
+
+func fetchBalancesReport(botConfig trader.BotConfig) (*balancesReport, error) {
+	client := &horizonclient.Client{
+		HorizonURL: botConfig.HorizonURL,
+		HTTP:       http.DefaultClient,
+		AppName:    "kelp--cli--balances",
+		AppVersion: version,
+	}
+	account, e := client.AccountDetail(horizonclient.AccountRequest{AccountID: botConfig.TradingAccount()})
+	if e != nil {
+		return nil, fmt.Errorf("unable to load SDEX account '%s': %s", botConfig.TradingAccount(), e)
+	}
+
+	// load the display precision of every asset held by the account before making the rows
+	for _, b := range account.Balances {
+		assetdisplay.Load(client, hProtocol.Asset(b.Asset))
+	}
+	reserves := makeSdexReserves(account.SubentryCount, account.NumSponsoring, account.NumSponsored)
+	rows, e := makeSdexBalanceRows(account.Balances, reserves)
+	if e != nil {
+		return nil, fmt.Errorf("unable to read balances of SDEX account '%s': %s", botConfig.TradingAccount(), e)
+	}
+
+	if !botConfig.IsTradingSdex() {
+		rows, e = addExchangeBalances(botConfig, rows)
+		if e != nil {
+			return nil, e
+		}
+	}
+
+	return &balancesReport{
+		TradingAccount:  botConfig.TradingAccount(),
+		TradingExchange: botConfig.TradingExchangeName(),
+		SdexReserves:    reserves,
+		Balances:        rows,
+	}, nil
+}
+
+func makeSdexReserves(subentryCount int32, numSponsoring uint32, numSponsored uint32) sdexReserves {
+	accountReserve := plugins.SdexMinReserve(0)
+	subentriesReserve := plugins.SdexMinReserve(subentryCount) - accountReserve
+	sponsoringReserve := plugins.SdexSponsoredMinReserve(0, numSponsoring, 0) - accountReserve
+	sponsoredReserve := accountReserve - plugins.SdexSponsoredMinReserve(0, 0, numSponsored)
+	return sdexReserves{
+		SubentryCount:     subentryCount,
+		NumSponsoring:     numSponsoring,
+		NumSponsored:      numSponsored,
+		AccountReserve:    accountReserve,
+		SubentriesReserve: subentriesReserve,
+		SponsoringReserve: sponsoringReserve,
+		SponsoredReserve:  sponsoredReserve,
+		MinReserve:        plugins.SdexSponsoredMinReserve(subentryCount, numSponsoring, numSponsored),
+	}
+}
+
+// makeSdexBalanceRows converts the balances of the SDEX account into rows, the reserve only applies to the native asset
+func makeSdexBalanceRows(balances []hProtocol.Balance, reserves sdexReserves) ([]balanceRow, error) {
+	rows := []balanceRow{}
+	for _, b := range balances {
+		asset := hProtocol.Asset(b.Asset)
+		balance, e := parseOptionalAmount(b.Balance)
+		if e != nil {
+			return nil, fmt.Errorf("cannot parse balance of asset %s: %s", utils.Asset2String(asset), e)
+		}
+		buyingLiabilities, e := parseOptionalAmount(b.BuyingLiabilities)
+		if e != nil {
+			return nil, fmt.Errorf("cannot parse buying liabilities of asset %s: %s", utils.Asset2String(asset), e)
+		}
+		sellingLiabilities, e := parseOptionalAmount(b.SellingLiabilities)
+		if e != nil {
+			return nil, fmt.Errorf("cannot parse selling liabilities of asset %s: %s", utils.Asset2String(asset), e)
+		}
+
+		reserve := 0.0
+		if asset.Type == utils.Native {
+			reserve = reserves.MinReserve
+		}
+		available := balance - reserve - sellingLiabilities
+		if available < 0.0 {
+			available = 0.0
+		}
+
+		rows = append(rows, balanceRow{
+			Asset:                  utils.Asset2String(asset),
+			SdexBalance:            &balance,
+			SdexBuyingLiabilities:  &buyingLiabilities,
+			SdexSellingLiabilities: &sellingLiabilities,
+			SdexReserve:            &reserve,
+			SdexAvailable:          &available,
+			DisplayDecimals:        assetdisplay.Lookup(asset).DisplayDecimals,
+		})
+	}
+	return rows, nil
+}
+
+// parseOptionalAmount parses an amount from horizon, where an empty string means zero
+func parseOptionalAmount(amount string) (float64, error) {
+	if amount == "" {
+		return 0.0, nil
+	}
+	return strconv.ParseFloat(amount, 64)
+}
+
+// addExchangeBalances adds the balances of the base and quote assets on the trading exchange, matching them to the rows of the same
+// assets on SDEX when the SDEX account holds them
+func addExchangeBalances(botConfig trader.BotConfig, rows []balanceRow) ([]balanceRow, error) {
+	exchangeAPI, e := plugins.MakeTradingExchange(
+		botConfig.TradingExchange,
+		botConfig.ExchangeAPIKeys.ToExchangeAPIKeys(),
+		botConfig.ExchangeParams.ToExchangeParams(),
+		botConfig.ExchangeHeaders.ToExchangeHeaders(),
+		false,
+	)
+	if e != nil {
+		return nil, fmt.Errorf("unable to make trading exchange '%s': %s", botConfig.TradingExchange, e)
+	}
+
+	assets := []hProtocol.Asset{botConfig.AssetBase(), botConfig.AssetQuote()}
+	exchangeAssets := []interface{}{}
+	for _, a := range assets {
+		exchangeAssets = append(exchangeAssets, model.Asset(utils.Asset2CodeString(a)))
+	}
+	balances, e := exchangeAPI.GetAccountBalances(exchangeAssets)
+	if e != nil {
+		return nil, fmt.Errorf("unable to fetch balances from trading exchange '%s': %s", botConfig.TradingExchange, e)
+	}
+
+	for i, a := range assets {
+		b, ok := balances[exchangeAssets[i]]
+		if !ok {
+			return nil, fmt.Errorf("trading exchange '%s' did not return a balance for asset %s", botConfig.TradingExchange, utils.Asset2CodeString(a))
+		}
+		exchangeBalance := b.AsFloat()
+
+		assetString := utils.Asset2String(a)
+		found := false
+		for j := range rows {
+			if rows[j].Asset == assetString {
+				rows[j].ExchangeBalance = &exchangeBalance
+				found = true
+				break
+			}
+		}
+		if !found {
+			rows = append(rows, balanceRow{
+				Asset:           assetString,
+				ExchangeBalance: &exchangeBalance,
+				DisplayDecimals: assetdisplay.Lookup(a).DisplayDecimals,
+			})
+		}
+	}
+	return rows, nil
+}
+
+func printBalancesReport(report *balancesReport) {
+	fmt.Printf("  Trading Account : %s\n", report.TradingAccount)
+	fmt.Printf("  Trading Exchange: %s\n", report.TradingExchange)
+	fmt.Printf("  SDEX Min Reserve: %.7f XLM = %.7f (account) + %.7f (%d subentries) + %.7f (%d sponsoring) - %.7f (%d sponsored)\n",
+		report.SdexReserves.MinReserve,
+		report.SdexReserves.AccountReserve,
+		report.SdexReserves.SubentriesReserve,
+		report.SdexReserves.SubentryCount,
+		report.SdexReserves.SponsoringReserve,
+		report.SdexReserves.NumSponsoring,
+		report.SdexReserves.SponsoredReserve,
+		report.SdexReserves.NumSponsored,
+	)
+	fmt.Println()
+	fmt.Printf("  %-24s\t%18s\t%18s\t%18s\t%18s\t%18s\t%18s\n", "Asset", "SDEX Balance", "Buying Liabilities", "Selling Liabilities", "Reserve", "SDEX Available", "Exchange Balance")
+	fmt.Printf("  ----------------------------------------------------------------------------------------------------------------------------------------------------------------\n")
+	for _, r := range report.Balances {
+		fmt.Printf("  %-24s\t%18s\t%18s\t%18s\t%18s\t%18s\t%18s\n",
+			r.Asset,
+			formatOptionalAmount(r.SdexBalance, r.DisplayDecimals),
+			formatOptionalAmount(r.SdexBuyingLiabilities, r.DisplayDecimals),
+			formatOptionalAmount(r.SdexSellingLiabilities, r.DisplayDecimals),
+			formatOptionalAmount(r.SdexReserve, r.DisplayDecimals),
+			formatOptionalAmount(r.SdexAvailable, r.DisplayDecimals),
+			formatOptionalAmount(r.ExchangeBalance, r.DisplayDecimals),
+		)
+	}
+}
+
+func formatOptionalAmount(amount *float64, displayDecimals int) string {
+	if amount == nil {
+		return "-"
+	}
+	return strconv.FormatFloat(*amount, 'f', displayDecimals, 64)
+}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"testing"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/protocols/horizon/base"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMakeSdexBalanceRows(t *testing.T) {
+	reserves := makeSdexReserves(4, 0, 0)
+	assert.Equal(t, 1.0, reserves.AccountReserve)
+	assert.Equal(t, 2.0, reserves.SubentriesReserve)
+	assert.Equal(t, 3.0, reserves.MinReserve)
+
+	balances := []hProtocol.Balance{
+		{
+			Balance:            "100.0000000",
+			BuyingLiabilities:  "5.0000000",
+			SellingLiabilities: "20.0000000",
+			Asset:              base.Asset{Type: "native"},
+		}, {
+			Balance:            "50.0000000",
+			Limit:              "1000.0000000",
+			BuyingLiabilities:  "0.0000000",
+			SellingLiabilities: "10.0000000",
+			Asset:              base.Asset{Type: "credit_alphanum4", Code: "USD", Issuer: "GBMMZMK2DC4FFP4CAI6KCVNCQ7WLO5A7DQU7EC7WGHRDQBZB763X4OQI"},
+		}, {
+			// liabilities can be omitted by horizon
+			Balance: "1.0000000",
+			Limit:   "1000.0000000",
+			Asset:   base.Asset{Type: "credit_alphanum4", Code: "EUR", Issuer: "GBMMZMK2DC4FFP4CAI6KCVNCQ7WLO5A7DQU7EC7WGHRDQBZB763X4OQI"},
+		},
+	}
+	rows, e := makeSdexBalanceRows(balances, reserves)
+	if !assert.NoError(t, e) || !assert.Equal(t, 3, len(rows)) {
+		return
+	}
+
+	assert.Equal(t, "native", rows[0].Asset)
+	assert.Equal(t, 100.0, *rows[0].SdexBalance)
+	assert.Equal(t, 5.0, *rows[0].SdexBuyingLiabilities)
+	assert.Equal(t, 20.0, *rows[0].SdexSellingLiabilities)
+	assert.Equal(t, 3.0, *rows[0].SdexReserve)
+	assert.Equal(t, 77.0, *rows[0].SdexAvailable)
+	assert.Nil(t, rows[0].ExchangeBalance)
+	assert.Equal(t, 7, rows[0].DisplayDecimals)
+
+	assert.Equal(t, "USD:GBMMZMK2DC4FFP4CAI6KCVNCQ7WLO5A7DQU7EC7WGHRDQBZB763X4OQI", rows[1].Asset)
+	assert.Equal(t, 0.0, *rows[1].SdexReserve)
+	assert.Equal(t, 40.0, *rows[1].SdexAvailable)
+
+	assert.Equal(t, 0.0, *rows[2].SdexBuyingLiabilities)
+	assert.Equal(t, 0.0, *rows[2].SdexSellingLiabilities)
+	assert.Equal(t, 1.0, *rows[2].SdexAvailable)
+
+	assert.Equal(t, "100.0000000", formatOptionalAmount(rows[0].SdexBalance, rows[0].DisplayDecimals))
+	assert.Equal(t, "40.00", formatOptionalAmount(rows[1].SdexAvailable, 2))
+	assert.Equal(t, "-", formatOptionalAmount(rows[1].ExchangeBalance, 2))
+
+	_, e = makeSdexBalanceRows([]hProtocol.Balance{{Balance: "abc", Asset: base.Asset{Type: "native"}}}, reserves)
+	assert.Error(t, e)
+}
+
+func TestMakeSdexReserves_Sponsorship(t *testing.T) {
+	// sponsoring 3 entries, such as claimable balances created by the account, and 2 of the 4 subentries are sponsored by another account
+	reserves := makeSdexReserves(4, 3, 2)
+	assert.Equal(t, 1.0, reserves.AccountReserve)
+	assert.Equal(t, 2.0, reserves.SubentriesReserve)
+	assert.Equal(t, 1.5, reserves.SponsoringReserve)
+	assert.Equal(t, 1.0, reserves.SponsoredReserve)
+	assert.Equal(t, 3.5, reserves.MinReserve)
+
+	// the account entry itself is sponsored
+	reserves = makeSdexReserves(0, 0, 2)
+	assert.Equal(t, 0.0, reserves.MinReserve)
+}
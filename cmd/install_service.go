@@ -0,0 +1,439 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/stellar/kelp/trader"
+)
+
+const installServiceExamples = `  kelp install-service --name kelp-gui -- server --enable-kaas --port 8000
+  kelp install-service --name kelp-buysell --user kelp -- trade -c ./trader.cfg -s buysell -f ./buysell.cfg
+  kelp install-service --name kelp-buysell --platform windows --winsw ./WinSW-x64.exe -- trade -c trader.cfg -s buysell -f buysell.cfg
+  kelp install-service --name kelp-buysell --dry-run -- trade -c ./trader.cfg -s buysell -f ./buysell.cfg`
+
+var installServiceCmd = &cobra.Command{
+	Use:   "install-service [flags] -- server|trade [args]",
+	Short: "Installs a systemd unit (Linux) or a Windows service wrapper that runs kelp server or a kelp trade invocation unattended",
+	Long: `Installs a systemd unit (Linux) or a Windows service wrapper that runs kelp server or a kelp trade invocation unattended.
+
+The arguments after -- are the kelp command that the service runs, which needs to be either "server" or "trade". Relative paths in
+these arguments are resolved against the working directory of the service.
+
+Secrets are kept in an env file of KEY=VALUE lines. The env file is created with a template that is only readable by the owner if it
+does not exist. systemd reads it with EnvironmentFile= before it switches to the --user of the service, so it can stay owned by root,
+and the Windows service passes it to kelp with the --env-file flag. The trade and balances commands use the ` + trader.EnvTradingSecretSeed + `
+and ` + trader.EnvSourceSecretSeed + ` variables when TRADING_SECRET_SEED and SOURCE_SECRET_SEED are left empty in the bot config file.
+
+The Windows service wrapper uses WinSW (https://github.com/winsw/winsw), which is not bundled with Kelp. Pass the path to the WinSW
+executable with --winsw to install the service, otherwise only the wrapper config is written.`,
+	Example: installServiceExamples,
+}
+
+type servicePlatform string
+
+// type of servicePlatform
+const (
+	servicePlatformSystemd servicePlatform = "systemd"
+	servicePlatformWindows servicePlatform = "windows"
+)
+
+// serviceSpec is what we need to generate the service definition for a kelp command
+type serviceSpec struct {
+	name        string
+	description string
+	kelpBinary  string
+	workingDir  string
+	envFile     string
+	user        string // only applies to systemd, empty runs as root
+	kelpArgs    []string
+}
+
+// execArgs are the arguments that the windows service passes to the kelp binary, systemd loads the env file itself with EnvironmentFile=
+func (s *serviceSpec) execArgs() []string {
+	return append([]string{"--env-file", s.envFile}, s.kelpArgs...)
+}
+
+func init() {
+	name := installServiceCmd.Flags().String("name", "", "(required) name of the service, such as kelp-buysell")
+	platform := installServiceCmd.Flags().String("platform", defaultServicePlatform(), "type of service to install, either \"systemd\" or \"windows\"")
+	envFile := installServiceCmd.Flags().String("env-file", "", "env file with the secrets for the service (default is /etc/kelp/<name>.env for systemd and <working-dir>\\<name>.env for windows)")
+	workingDir := installServiceCmd.Flags().String("working-dir", "", "working directory of the service (default is the current directory)")
+	user := installServiceCmd.Flags().String("user", "", "user that the systemd service runs as (default is root)")
+	outputDir := installServiceCmd.Flags().String("output-dir", "", "directory to write the service definition to (default is /etc/systemd/system for systemd and the working directory for windows)")
+	winsw := installServiceCmd.Flags().String("winsw", "", "path to the WinSW executable, used to install the windows service")
+	dryRun := installServiceCmd.Flags().Bool("dry-run", false, "print the service definition without writing or installing anything")
+	e := installServiceCmd.MarkFlagRequired("name")
+	if e != nil {
+		panic(e)
+	}
+
+	installServiceCmd.Run = func(ccmd *cobra.Command, args []string) {
+		e := validateServiceArgs(*name, args)
+		if e != nil {
+			log.Fatal(e)
+		}
+
+		p := servicePlatform(*platform)
+		if p != servicePlatformSystemd && p != servicePlatformWindows {
+			log.Fatal(fmt.Errorf("invalid platform '%s', needs to be either \"%s\" or \"%s\"", *platform, servicePlatformSystemd, servicePlatformWindows))
+		}
+
+		kelpBinary, e := os.Executable()
+		if e != nil {
+			log.Fatal(fmt.Errorf("could not find the path of the kelp binary: %s", e))
+		}
+		if *workingDir == "" {
+			*workingDir, e = os.Getwd()
+			if e != nil {
+				log.Fatal(fmt.Errorf("could not get the current directory: %s", e))
+			}
+		}
+		if *envFile == "" {
+			*envFile = defaultServiceEnvFile(p, *name, *workingDir)
+		}
+		if *outputDir == "" {
+			*outputDir = "/etc/systemd/system"
+			if p == servicePlatformWindows {
+				*outputDir = *workingDir
+			}
+		}
+		if args[0] == "server" && !containsArg(args, "--enable-kaas") {
+			log.Printf("warning: the server command tries to open a browser or electron window unless it runs with --enable-kaas\n")
+		}
+
+		spec := &serviceSpec{
+			name:        *name,
+			description: fmt.Sprintf("Kelp %s (%s)", args[0], *name),
+			kelpBinary:  kelpBinary,
+			workingDir:  *workingDir,
+			envFile:     *envFile,
+			user:        *user,
+			kelpArgs:    args,
+		}
+
+		var filename, contents string
+		if p == servicePlatformSystemd {
+			filename = filepath.Join(*outputDir, *name+".service")
+			contents = makeSystemdUnit(spec)
+		} else {
+			filename = filepath.Join(*outputDir, *name+".xml")
+			contents, e = makeWinswConfig(spec)
+			if e != nil {
+				log.Fatal(fmt.Errorf("could not make the WinSW config: %s", e))
+			}
+		}
+
+		if *dryRun {
+			fmt.Printf("# %s\n%s\n# %s\n%s", filename, contents, spec.envFile, makeServiceEnvFileTemplate())
+			return
+		}
+
+		e = writeServiceEnvFile(spec.envFile)
+		if e != nil {
+			log.Fatal(e)
+		}
+		e = ioutil.WriteFile(filename, []byte(contents), 0644)
+		if e != nil {
+			log.Fatal(fmt.Errorf("could not write the service definition to '%s': %s", filename, e))
+		}
+		log.Printf("wrote the service definition to %s\n", filename)
+
+		if p == servicePlatformSystemd {
+			installSystemdService(*name)
+		} else {
+			installWindowsService(*name, *outputDir, *winsw)
+		}
+	}
+}
+
+func defaultServicePlatform() string {
+	if runtime.GOOS == "windows" {
+		return string(servicePlatformWindows)
+	}
+	return string(servicePlatformSystemd)
+}
+
+func defaultServiceEnvFile(p servicePlatform, name string, workingDir string) string {
+	if p == servicePlatformWindows {
+		return filepath.Join(workingDir, name+".env")
+	}
+	return filepath.Join("/etc/kelp", name+".env")
+}
+
+// validateServiceArgs checks that the service runs a command that can be run unattended
+func validateServiceArgs(name string, kelpArgs []string) error {
+	if name == "" || strings.ContainsAny(name, " /\\\"'") {
+		return fmt.Errorf("invalid service name '%s', it cannot be empty or contain spaces, slashes or quotes", name)
+	}
+	if len(kelpArgs) == 0 {
+		return fmt.Errorf("missing the kelp command for the service, add it after -- like so: kelp install-service --name %s -- trade -c ./trader.cfg -s buysell -f ./buysell.cfg", name)
+	}
+	if kelpArgs[0] != "server" && kelpArgs[0] != "trade" {
+		return fmt.Errorf("invalid kelp command '%s' for the service, needs to be either \"server\" or \"trade\"", kelpArgs[0])
+	}
+	if containsArg(kelpArgs, "--env-file") {
+		return fmt.Errorf("cannot pass --env-file in the kelp command for the service, use the --env-file flag of install-service instead")
+	}
+	return nil
+}
+
+func containsArg(args []string, arg string) bool {
+	for _, a := range args {
+		if a == arg || strings.HasPrefix(a, arg+"=") {
+			return true
+		}
+	}
+	return false
+}
+
+// makeSystemdUnit makes the systemd unit file that runs the kelp command and restarts it when it fails. The env file is read by systemd
+// as root before it switches to the user of the service, so the user of the service does not need to be able to read it
+func makeSystemdUnit(s *serviceSpec) string {
+	execStart := []string{quoteSystemdArg(s.kelpBinary)}
+	for _, a := range s.kelpArgs {
+		execStart = append(execStart, quoteSystemdArg(a))
+	}
+
+	var b strings.Builder
+	b.WriteString("[Unit]\n")
+	b.WriteString(fmt.Sprintf("Description=%s\n", s.description))
+	b.WriteString("Wants=network-online.target\n")
+	b.WriteString("After=network-online.target\n")
+	b.WriteString("\n[Service]\n")
+	b.WriteString("Type=simple\n")
+	if s.user != "" {
+		b.WriteString(fmt.Sprintf("User=%s\n", s.user))
+	}
+	b.WriteString(fmt.Sprintf("WorkingDirectory=%s\n", quoteSystemdArg(s.workingDir)))
+	b.WriteString(fmt.Sprintf("EnvironmentFile=%s\n", strings.Replace(s.envFile, "%", "%%", -1)))
+	b.WriteString(fmt.Sprintf("ExecStart=%s\n", strings.Join(execStart, " ")))
+	b.WriteString("Restart=on-failure\n")
+	b.WriteString("RestartSec=10\n")
+	b.WriteString("\n[Install]\n")
+	b.WriteString("WantedBy=multi-user.target\n")
+	return b.String()
+}
+
+// quoteSystemdArg quotes an argument of a systemd command line, where % starts a specifier and needs to be escaped as %%
+func quoteSystemdArg(arg string) string {
+	arg = strings.Replace(arg, "%", "%%", -1)
+	if arg != "" && !strings.ContainsAny(arg, " \t\"'\\") {
+		return arg
+	}
+	arg = strings.Replace(arg, "\\", "\\\\", -1)
+	arg = strings.Replace(arg, "\"", "\\\"", -1)
+	return "\"" + arg + "\""
+}
+
+// winswConfig is the XML config of the WinSW service wrapper
+type winswConfig struct {
+	XMLName          xml.Name `xml:"service"`
+	ID               string   `xml:"id"`
+	Name             string   `xml:"name"`
+	Description      string   `xml:"description"`
+	Executable       string   `xml:"executable"`
+	Arguments        string   `xml:"arguments"`
+	WorkingDirectory string   `xml:"workingdirectory"`
+	OnFailure        struct {
+		Action string `xml:"action,attr"`
+		Delay  string `xml:"delay,attr"`
+	} `xml:"onfailure"`
+	LogMode string `xml:"logmode"`
+}
+
+// makeWinswConfig makes the WinSW config that runs the kelp command and restarts it when it fails
+func makeWinswConfig(s *serviceSpec) (string, error) {
+	args := []string{}
+	for _, a := range s.execArgs() {
+		args = append(args, quoteWindowsArg(a))
+	}
+
+	c := winswConfig{
+		ID:               s.name,
+		Name:             s.name,
+		Description:      s.description,
+		Executable:       s.kelpBinary,
+		Arguments:        strings.Join(args, " "),
+		WorkingDirectory: s.workingDir,
+		LogMode:          "roll",
+	}
+	c.OnFailure.Action = "restart"
+	c.OnFailure.Delay = "10 sec"
+
+	xmlBytes, e := xml.MarshalIndent(c, "", "  ")
+	if e != nil {
+		return "", fmt.Errorf("could not marshal xml: %s", e)
+	}
+	return string(xmlBytes) + "\n", nil
+}
+
+// quoteWindowsArg quotes an argument of a windows command line so it is parsed back as a single argument
+func quoteWindowsArg(arg string) string {
+	if arg != "" && !strings.ContainsAny(arg, " \t\"") {
+		return arg
+	}
+
+	var b bytes.Buffer
+	b.WriteByte('"')
+	numBackslashes := 0
+	for _, c := range arg {
+		switch c {
+		case '\\':
+			numBackslashes++
+		case '"':
+			// backslashes before a quote are escaped, as is the quote itself
+			b.WriteString(strings.Repeat("\\", 2*numBackslashes+1))
+			b.WriteRune(c)
+			numBackslashes = 0
+		default:
+			b.WriteString(strings.Repeat("\\", numBackslashes))
+			b.WriteRune(c)
+			numBackslashes = 0
+		}
+	}
+	// backslashes before the closing quote are escaped
+	b.WriteString(strings.Repeat("\\", 2*numBackslashes))
+	b.WriteByte('"')
+	return b.String()
+}
+
+func makeServiceEnvFileTemplate() string {
+	return fmt.Sprintf(`# environment variables for the kelp service, one KEY=VALUE per line
+# this file contains secrets and should only be readable by the user that runs the service
+#
+# secret seeds that are used when TRADING_SECRET_SEED and SOURCE_SECRET_SEED are left empty in the bot config file
+#%s=
+#%s=
+#
+# CoinMarketCap API key that is used by the "cmc" price feeds when CMC_API_KEY is left empty in the bot config file
+#%s=
+`, trader.EnvTradingSecretSeed, trader.EnvSourceSecretSeed, trader.EnvCmcAPIKey)
+}
+
+// writeServiceEnvFile creates the env file with a template that is only readable by the owner, an existing env file is kept as is
+func writeServiceEnvFile(envFile string) error {
+	info, e := os.Stat(envFile)
+	if e == nil {
+		if runtime.GOOS != "windows" && info.Mode().Perm()&0077 != 0 {
+			log.Printf("warning: the env file %s can be read by other users (mode %s), consider running chmod 600 on it\n", envFile, info.Mode().Perm())
+		}
+		log.Printf("using the existing env file %s\n", envFile)
+		return nil
+	}
+	if !os.IsNotExist(e) {
+		return fmt.Errorf("could not check the env file '%s': %s", envFile, e)
+	}
+
+	e = os.MkdirAll(filepath.Dir(envFile), 0755)
+	if e != nil {
+		return fmt.Errorf("could not make the directory of the env file '%s': %s", envFile, e)
+	}
+	e = ioutil.WriteFile(envFile, []byte(makeServiceEnvFileTemplate()), 0600)
+	if e != nil {
+		return fmt.Errorf("could not write the env file '%s': %s", envFile, e)
+	}
+	log.Printf("wrote the env file template to %s, add the secrets for the service there\n", envFile)
+	return nil
+}
+
+func installSystemdService(name string) {
+	for _, args := range [][]string{{"daemon-reload"}, {"enable", name}} {
+		output, e := exec.Command("systemctl", args...).CombinedOutput()
+		if e != nil {
+			log.Fatal(fmt.Errorf("could not run 'systemctl %s': %s\n%s", strings.Join(args, " "), e, string(output)))
+		}
+	}
+	log.Printf("installed and enabled the service, start it with 'systemctl start %s' and follow its logs with 'journalctl -u %s -f'\n", name, name)
+}
+
+func installWindowsService(name string, outputDir string, winsw string) {
+	if winsw == "" {
+		log.Printf("to install the service, copy the WinSW executable to %s and run '%s install'\n",
+			filepath.Join(outputDir, name+".exe"), filepath.Join(outputDir, name+".exe"))
+		return
+	}
+
+	// WinSW finds its config by looking for an xml file with the same name as the executable
+	wrapper := filepath.Join(outputDir, name+".exe")
+	wrapperBytes, e := ioutil.ReadFile(winsw)
+	if e != nil {
+		log.Fatal(fmt.Errorf("could not read the WinSW executable '%s': %s", winsw, e))
+	}
+	e = ioutil.WriteFile(wrapper, wrapperBytes, 0755)
+	if e != nil {
+		log.Fatal(fmt.Errorf("could not copy the WinSW executable to '%s': %s", wrapper, e))
+	}
+	output, e := exec.Command(wrapper, "install").CombinedOutput()
+	if e != nil {
+		log.Fatal(fmt.Errorf("could not run '%s install': %s\n%s", wrapper, e, string(output)))
+	}
+	log.Printf("installed the service, start it with '%s start'\n", wrapper)
+}
+
+// loadRootEnvFile sets the variables in the file passed with the root --env-file flag as environment variables
+func loadRootEnvFile() {
+	if rootEnvFile == nil || *rootEnvFile == "" {
+		return
+	}
+
+	envBytes, e := ioutil.ReadFile(*rootEnvFile)
+	if e != nil {
+		log.Fatal(fmt.Errorf("could not read env file '%s': %s", *rootEnvFile, e))
+	}
+	vars, e := parseEnvFile(string(envBytes))
+	if e != nil {
+		log.Fatal(fmt.Errorf("could not parse env file '%s': %s", *rootEnvFile, e))
+	}
+	for k, v := range vars {
+		// variables that are already set take precedence, like the EnvironmentFile of systemd
+		if _, ok := os.LookupEnv(k); ok {
+			continue
+		}
+		e = os.Setenv(k, v)
+		if e != nil {
+			log.Fatal(fmt.Errorf("could not set environment variable '%s' from env file: %s", k, e))
+		}
+	}
+}
+
+// parseEnvFile parses KEY=VALUE lines, ignoring empty lines and lines that start with #. Values can be wrapped in single or double quotes
+func parseEnvFile(contents string) (map[string]string, error) {
+	vars := map[string]string{}
+	scanner := bufio.NewScanner(strings.NewReader(contents))
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		parts := strings.SplitN(line, "=", 2)
+		key := strings.TrimSpace(parts[0])
+		if len(parts) != 2 || key == "" || strings.ContainsAny(key, " \t") {
+			return nil, fmt.Errorf("invalid line %d, needs to be of the form KEY=VALUE", lineNumber)
+		}
+		value := strings.TrimSpace(parts[1])
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		vars[key] = value
+	}
+	if e := scanner.Err(); e != nil {
+		return nil, fmt.Errorf("could not read lines: %s", e)
+	}
+	return vars, nil
+}
@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateServiceArgs(t *testing.T) {
+	testCases := []struct {
+		name     string
+		kelpArgs []string
+		wantErr  bool
+	}{
+		{name: "kelp-gui", kelpArgs: []string{"server", "--enable-kaas"}, wantErr: false},
+		{name: "kelp-buysell", kelpArgs: []string{"trade", "-c", "trader.cfg", "-s", "buysell", "-f", "buysell.cfg"}, wantErr: false},
+		{name: "kelp-buysell", kelpArgs: []string{}, wantErr: true},
+		{name: "kelp-buysell", kelpArgs: []string{"balances", "-c", "trader.cfg"}, wantErr: true},
+		{name: "kelp-buysell", kelpArgs: []string{"trade", "--env-file=secrets.env"}, wantErr: true},
+		{name: "", kelpArgs: []string{"server"}, wantErr: true},
+		{name: "kelp buysell", kelpArgs: []string{"server"}, wantErr: true},
+		{name: "../kelp", kelpArgs: []string{"server"}, wantErr: true},
+	}
+
+	for _, k := range testCases {
+		t.Run(fmt.Sprintf("%s_%v", k.name, k.kelpArgs), func(t *testing.T) {
+			e := validateServiceArgs(k.name, k.kelpArgs)
+			assert.Equal(t, k.wantErr, e != nil, fmt.Sprintf("error = %v", e))
+		})
+	}
+}
+
+func TestMakeSystemdUnit(t *testing.T) {
+	spec := &serviceSpec{
+		name:        "kelp-buysell",
+		description: "Kelp trade (kelp-buysell)",
+		kelpBinary:  "/opt/kelp/kelp",
+		workingDir:  "/home/kelp/my bots",
+		envFile:     "/etc/kelp/kelp-buysell.env",
+		user:        "kelp",
+		kelpArgs:    []string{"trade", "-c", "trader.cfg", "-s", "buysell", "-f", "buysell.cfg", "--log", "logs/100%"},
+	}
+
+	want := `[Unit]
+Description=Kelp trade (kelp-buysell)
+Wants=network-online.target
+After=network-online.target
+
+[Service]
+Type=simple
+User=kelp
+WorkingDirectory="/home/kelp/my bots"
+EnvironmentFile=/etc/kelp/kelp-buysell.env
+ExecStart=/opt/kelp/kelp trade -c trader.cfg -s buysell -f buysell.cfg --log logs/100%%
+Restart=on-failure
+RestartSec=10
+
+[Install]
+WantedBy=multi-user.target
+`
+	assert.Equal(t, want, makeSystemdUnit(spec))
+}
+
+func TestMakeWinswConfig(t *testing.T) {
+	spec := &serviceSpec{
+		name:        "kelp-gui",
+		description: "Kelp server (kelp-gui)",
+		kelpBinary:  `C:\kelp\kelp.exe`,
+		workingDir:  `C:\kelp`,
+		envFile:     `C:\kelp\kelp-gui.env`,
+		kelpArgs:    []string{"server", "--enable-kaas", "--guiconfig", `C:\kelp\my config.cfg`},
+	}
+
+	want := `<service>
+  <id>kelp-gui</id>
+  <name>kelp-gui</name>
+  <description>Kelp server (kelp-gui)</description>
+  <executable>C:\kelp\kelp.exe</executable>
+  <arguments>--env-file C:\kelp\kelp-gui.env server --enable-kaas --guiconfig &#34;C:\kelp\my config.cfg&#34;</arguments>
+  <workingdirectory>C:\kelp</workingdirectory>
+  <onfailure action="restart" delay="10 sec"></onfailure>
+  <logmode>roll</logmode>
+</service>
+`
+	actual, e := makeWinswConfig(spec)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, want, actual)
+}
+
+func TestQuoteSystemdArg(t *testing.T) {
+	testCases := []struct {
+		arg  string
+		want string
+	}{
+		{arg: "trader.cfg", want: "trader.cfg"},
+		{arg: "", want: `""`},
+		{arg: "my bots/trader.cfg", want: `"my bots/trader.cfg"`},
+		{arg: `say "hi"`, want: `"say \"hi\""`},
+		{arg: "50%", want: "50%%"},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.arg, func(t *testing.T) {
+			assert.Equal(t, k.want, quoteSystemdArg(k.arg))
+		})
+	}
+}
+
+func TestQuoteWindowsArg(t *testing.T) {
+	testCases := []struct {
+		arg  string
+		want string
+	}{
+		{arg: `C:\kelp\trader.cfg`, want: `C:\kelp\trader.cfg`},
+		{arg: "", want: `""`},
+		{arg: `C:\my bots\trader.cfg`, want: `"C:\my bots\trader.cfg"`},
+		{arg: `C:\my bots\`, want: `"C:\my bots\\"`},
+		{arg: `say "hi"`, want: `"say \"hi\""`},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.arg, func(t *testing.T) {
+			assert.Equal(t, k.want, quoteWindowsArg(k.arg))
+		})
+	}
+}
+
+func TestParseEnvFile(t *testing.T) {
+	contents := `# secrets for the bot
+KELP_TRADING_SECRET_SEED=SAOQ6IG2WWDEP47WEJNLIU27OBODMEWFDN6PVUR5KHYDOCVCL34J2CUD
+
+export KELP_SOURCE_SECRET_SEED="SDDAHRX2JB663N3OLKZIBZPF33ZEKMHARX362S737JEJS2AX3GJZY5LU"
+  PASSWORD = 'a b=c'
+EMPTY=
+`
+	vars, e := parseEnvFile(contents)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, map[string]string{
+		"KELP_TRADING_SECRET_SEED": "SAOQ6IG2WWDEP47WEJNLIU27OBODMEWFDN6PVUR5KHYDOCVCL34J2CUD",
+		"KELP_SOURCE_SECRET_SEED":  "SDDAHRX2JB663N3OLKZIBZPF33ZEKMHARX362S737JEJS2AX3GJZY5LU",
+		"PASSWORD":                 "a b=c",
+		"EMPTY":                    "",
+	}, vars)
+
+	_, e = parseEnvFile("KELP_TRADING_SECRET_SEED\n")
+	assert.Error(t, e)
+	_, e = parseEnvFile("=value\n")
+	assert.Error(t, e)
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"log"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stellar/kelp/plugins"
+	"github.com/stellar/kelp/support/database"
+	"github.com/stellar/kelp/support/toml"
+	"github.com/stellar/kelp/support/utils"
+)
+
+const portfolioExamples = `  kelp portfolio --portfolioConf ./path/portfolio.cfg
+  kelp portfolio --portfolioConf ./path/portfolio.cfg --once`
+
+var portfolioCmd = &cobra.Command{
+	Use:   "portfolio",
+	Short: "Assigns capital budgets to the bots of a portfolio",
+	Long: `Splits the capital of a portfolio into budgets for the bots that trade it and writes them to the db, adjusting the budgets by the
+realized P&L of every bot so bots that make money get more capital.
+
+Every bot enforces its budget with the portfolioBudget/<botID> filter in its trader config, which caps the net position in the base asset
+and the daily volume on each side of the bot based on its budget. The bots need to use the same POSTGRES_DB as the portfolio. A bot with the
+filter does not place offers until the portfolio manager has assigned it a budget. The realized P&L is read from the inventory lots, so it
+is only available for bots that set INVENTORY_LOT_METHOD.`,
+	Example: portfolioExamples,
+}
+
+func init() {
+	portfolioConfigPath := portfolioCmd.Flags().StringP("portfolioConf", "c", "", "(required) portfolio config file path")
+	once := portfolioCmd.Flags().Bool("once", false, "update the budgets once and exit instead of updating them every UPDATE_INTERVAL_SECONDS")
+	e := portfolioCmd.MarkFlagRequired("portfolioConf")
+	if e != nil {
+		panic(e)
+	}
+
+	portfolioCmd.Run = func(ccmd *cobra.Command, args []string) {
+		checkInitRootFlags()
+
+		var config plugins.PortfolioConfig
+		e := toml.ReadConfig(*portfolioConfigPath, "", &config)
+		utils.CheckConfigError(config, e, *portfolioConfigPath)
+		e = config.Validate()
+		if e != nil {
+			log.Fatalf("invalid portfolio config: %s", e)
+		}
+		log.Printf("portfolio config: %s\n", config)
+
+		db, e := database.ConnectInitializedDatabase(config.PostgresDbConfig, upgradeScripts, version)
+		if e != nil {
+			log.Fatalf("problem encountered while initializing the db: %s", e)
+		}
+		defer db.Close()
+
+		manager, e := plugins.MakePortfolioManager(db, &config)
+		if e != nil {
+			log.Fatal(e)
+		}
+
+		if *once {
+			_, e = manager.Update(time.Now())
+			if e != nil {
+				log.Fatalf("could not update the portfolio budgets: %s", e)
+			}
+			return
+		}
+		manager.Run()
+	}
+}
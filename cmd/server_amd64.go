@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"image"
 	"image/png"
@@ -9,8 +10,8 @@ import (
 	"log"
 	"net"
 	"net/http"
+	"net/url"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"runtime"
 	"strings"
@@ -35,11 +36,11 @@ import (
 	"github.com/stellar/kelp/gui"
 	"github.com/stellar/kelp/gui/backend"
 	"github.com/stellar/kelp/plugins"
+	"github.com/stellar/kelp/support/exchangeadapter"
 	"github.com/stellar/kelp/support/guiconfig"
 	"github.com/stellar/kelp/support/kelpos"
 	"github.com/stellar/kelp/support/logger"
 	"github.com/stellar/kelp/support/networking"
-	"github.com/stellar/kelp/support/prefs"
 	"github.com/stellar/kelp/support/sdk"
 	"github.com/stellar/kelp/support/utils"
 )
@@ -52,6 +53,7 @@ const kelpCcxtPath = "/ccxt"
 const ccxtDownloadBaseURL = "https://github.com/stellar/kelp/releases/download/ccxt-rest_v0.0.4"
 const ccxtBinaryName = "ccxt-rest"
 const ccxtWaitSeconds = 60
+const ccxtCommandTimeout = 5 * time.Minute
 const versionPlaceholder = "VERSION_PLACEHOLDER"
 const stringPlaceholder = "PLACEHOLDER_URL"
 const redirectPlaceholder = "REDIRECT_URL"
@@ -62,20 +64,22 @@ const readyStringIndicator = "Serving frontend and API server on HTTP port"
 const downloadCcxtUpdateIntervalLogMillis = 1000
 
 type serverInputOptions struct {
-	port              *uint16
-	ports             *uint16
-	dev               *bool
-	devAPIPort        *uint16
-	horizonTestnetURI *string
-	horizonPubnetURI  *string
-	noHeaders         *bool
-	verbose           *bool
-	noElectron        *bool
-	disablePubnet     *bool
-	enableKaas        *bool
-	tlsCertFile       *string
-	tlsKeyFile        *string
-	guiConfigPath     *string
+	port                  *uint16
+	ports                 *uint16
+	dev                   *bool
+	devAPIPort            *uint16
+	horizonTestnetURI     *string
+	horizonPubnetURI      *string
+	noHeaders             *bool
+	verbose               *bool
+	noElectron            *bool
+	disablePubnet         *bool
+	enableKaas            *bool
+	tlsCertFile           *string
+	tlsKeyFile            *string
+	guiConfigPath         *string
+	nativeExchangeAdapter *bool
+	grpcPort              *uint16
 }
 
 // checks for required flag on CLI
@@ -88,8 +92,8 @@ func requiredFlags(flag string) {
 
 // String is the stringer method impl.
 func (o serverInputOptions) String() string {
-	return fmt.Sprintf("serverInputOptions[port=%d, dev=%v, devAPIPort=%d, horizonTestnetURI='%s', horizonPubnetURI='%s', noHeaders=%v, verbose=%v, noElectron=%v, disablePubnet=%v, enableKaas=%v]",
-		*o.port, *o.dev, *o.devAPIPort, *o.horizonTestnetURI, *o.horizonPubnetURI, *o.noHeaders, *o.verbose, *o.noElectron, *o.disablePubnet, *o.enableKaas)
+	return fmt.Sprintf("serverInputOptions[port=%d, dev=%v, devAPIPort=%d, horizonTestnetURI='%s', horizonPubnetURI='%s', noHeaders=%v, verbose=%v, noElectron=%v, disablePubnet=%v, enableKaas=%v, nativeExchangeAdapter=%v]",
+		*o.port, *o.dev, *o.devAPIPort, *o.horizonTestnetURI, *o.horizonPubnetURI, *o.noHeaders, *o.verbose, *o.noElectron, *o.disablePubnet, *o.enableKaas, *o.nativeExchangeAdapter)
 }
 
 // function for reading custom config file and returning config struct with intilized value
@@ -123,12 +127,18 @@ func init() {
 	options.tlsCertFile = serverCmd.Flags().String("tls-cert-file", "", "path to TLS certificate file")
 	options.tlsKeyFile = serverCmd.Flags().String("tls-key-file", "", "path to TLS key file")
 	options.guiConfigPath = serverCmd.Flags().StringP("guiconfig", "c", "", "gui-config for auth0 and other basic config file path")
+	options.grpcPort = serverCmd.Flags().Uint16("grpc-port", 0, "port on which to serve the gRPC API, which mirrors the REST API (uses the same TLS cert and key as HTTPS), disabled when 0")
+	options.nativeExchangeAdapter = serverCmd.Flags().Bool("native-exchange-adapter", false, "serve market data for binance, coinbasepro, bitstamp, mexc and gateio with the built-in exchange adapter instead of downloading and running ccxt-rest (does not support trading on centralized exchanges)")
 
 	serverCmd.Run = func(ccmd *cobra.Command, args []string) {
 		isLocalMode := env == envDev
 		isLocalDevMode := isLocalMode && *options.dev
 		kos := kelpos.GetKelpOS()
-		var e error
+		stateStore, e := makeStateStore(kos.GetDotKelpWorkingDir().Join("state").Native())
+		if e != nil {
+			panic(e)
+		}
+		kos.SetStateStore(stateStore)
 		if isLocalMode {
 			wd, e := os.Getwd()
 			if e != nil {
@@ -291,7 +301,7 @@ func init() {
 			apiTestNet.AppVersion = version
 			apiPubNet.AppVersion = version
 
-			p := prefs.Make(prefsFilename)
+			p := makePrefs(stateStore)
 			if p.FirstTime() {
 				log.Printf("Kelp sets the `X-App-Name` and `X-App-Version` headers on requests made to Horizon. These headers help us track overall Kelp usage, so that we can learn about general usage patterns and adapt Kelp to be more useful in the future. Kelp also uses Amplitude for metric tracking. These can be turned off using the `--no-headers` flag. See `kelp trade --help` for more information.\n")
 				e := p.SetNotFirstTime()
@@ -312,7 +322,13 @@ func init() {
 			ccxtRunning := e == nil
 			log.Printf("checked if CCXT is already running, ccxtRunning = %v", ccxtRunning)
 
-			if !ccxtRunning {
+			if !ccxtRunning && *options.nativeExchangeAdapter {
+				// start the native exchange adapter before we make API server (which loads exchange list)
+				e = runNativeExchangeAdapter()
+				if e != nil {
+					panic(e)
+				}
+			} else if !ccxtRunning {
 				// start ccxt before we make API server (which loads exchange list)
 				ccxtGoos := runtime.GOOS
 				if ccxtGoos == "windows" {
@@ -343,13 +359,16 @@ func init() {
 					ccxtBundledZipPath := kos.GetBinDir().Join("ccxt").Join(filenameWithExt)
 					ccxtZipDestPath := ccxtDirPath.Join(filenameWithExt)
 					// no need to pass a userID since we are not running under the context of any user at this point
-					e = copyOrDownloadCcxtBinary(kos, "_", ccxtBundledZipPath, ccxtZipDestPath, filenameWithExt)
+					e = copyOrDownloadCcxtBinary(kos, "_", ccxtBundledZipPath, ccxtZipDestPath, filenameWithExt, nil)
 					if e != nil {
 						panic(e)
 					}
 
 					// no need to pass a userID since we are not running under the context of any user at this point
-					unzipCcxtFile(kos, "_", ccxtDirPath, ccxtBinPath, filenameWithExt)
+					e = unzipCcxtFile(kos, "_", ccxtDirPath, ccxtBinPath, filenameWithExt)
+					if e != nil {
+						log.Fatal(e)
+					}
 				}
 
 				// no need to pass a userID since we are not running under the context of any user at this point
@@ -426,6 +445,10 @@ func init() {
 		}
 
 		guiWebPath := kos.GetBinDir().Join("../gui/web")
+		e = registerJobTypes(s, kos, guiWebPath, isLocalMode)
+		if e != nil {
+			panic(e)
+		}
 		if isLocalDevMode {
 			// the frontend app checks the REACT_APP_API_PORT variable to be set when serving
 			os.Setenv("REACT_APP_API_PORT", fmt.Sprintf("%d", *options.devAPIPort))
@@ -466,6 +489,26 @@ func init() {
 		if e != nil {
 			log.Fatal(e)
 		}
+		if *options.grpcPort != 0 {
+			grpcS, e := backend.MakeGRPCServer(s, *options.tlsCertFile, *options.tlsKeyFile)
+			if e != nil {
+				log.Fatal(e)
+			}
+			e = threadTracker.TriggerGoroutine(func(inputs []interface{}) {
+				log.Printf("starting gRPC server on port %d (TLS enabled = %v)\n", *options.grpcPort, isTLS)
+				listener, e1 := net.Listen("tcp", fmt.Sprintf(":%d", *options.grpcPort))
+				if e1 != nil {
+					log.Fatal(fmt.Errorf("unable to listen on gRPC port %d: %s", *options.grpcPort, e1))
+				}
+				e1 = grpcS.Serve(listener)
+				if e1 != nil {
+					log.Fatal(e1)
+				}
+			}, nil)
+			if e != nil {
+				log.Fatal(e)
+			}
+		}
 		if isTLS {
 			// we want a new server to redirect traffic from http to https
 			httpRedirectMux := chi.NewRouter()
@@ -510,7 +553,7 @@ func setMiddleware(r *chi.Mux) {
 	r.Use(middleware.RealIP)
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
-	r.Use(middleware.Timeout(60 * time.Second))
+	// the request timeout is set on the routes by backend.SetRoutes since streams need to stay open
 }
 
 func copyCcxtFolder(
@@ -521,7 +564,8 @@ func copyCcxtFolder(
 ) error {
 	log.Printf("copying ccxt directory from %s to location %s ...", ccxtSourceDir.AsString(), ccxtDestDir.AsString())
 
-	cpCmd := fmt.Sprintf("cp -a %s %s", ccxtSourceDir.Unix(), ccxtDestDir.Unix())
+	cpCmd := kelpos.MakeCommand("cp", "-a", kelpos.CommandPath(ccxtSourceDir), kelpos.CommandPath(ccxtDestDir))
+	cpCmd.Timeout = ccxtCommandTimeout
 	_, e := kos.Blocking(userID, "cp-ccxt", cpCmd)
 	if e != nil {
 		return fmt.Errorf("unable to copy ccxt directory from %s to %s: %s", ccxtSourceDir.AsString(), ccxtDestDir.AsString(), e)
@@ -537,6 +581,7 @@ func copyOrDownloadCcxtBinary(
 	ccxtBundledZipPath *kelpos.OSPath,
 	ccxtZipDestPath *kelpos.OSPath,
 	filenameWithExt string,
+	reportProgress backend.JobProgressFn, // can be nil
 ) error {
 	if _, e := os.Stat(ccxtZipDestPath.Native()); !os.IsNotExist(e) {
 		return nil
@@ -545,7 +590,8 @@ func copyOrDownloadCcxtBinary(
 	if _, e := os.Stat(ccxtBundledZipPath.Native()); !os.IsNotExist(e) {
 		log.Printf("copying ccxt from %s to location %s ...", ccxtBundledZipPath.Unix(), ccxtZipDestPath.Unix())
 
-		cpCmd := fmt.Sprintf("cp %s %s", ccxtBundledZipPath.Unix(), ccxtZipDestPath.Unix())
+		cpCmd := kelpos.MakeCommand("cp", kelpos.CommandPath(ccxtBundledZipPath), kelpos.CommandPath(ccxtZipDestPath))
+		cpCmd.Timeout = ccxtCommandTimeout
 		_, e = kos.Blocking(userID, "cp-ccxt", cpCmd)
 		if e != nil {
 			return fmt.Errorf("unable to copy ccxt zip file from %s to %s: %s", ccxtBundledZipPath.Unix(), ccxtZipDestPath.Unix(), e)
@@ -573,6 +619,9 @@ func copyOrDownloadCcxtBinary(
 				100*(float64(completedBytes)/float64(sizeBytes)),
 				speedBytesPerSec,
 			)
+			if reportProgress != nil && sizeBytes > 0 {
+				reportProgress(completedBytes/sizeBytes, fmt.Sprintf("downloading %s", filenameWithExt))
+			}
 		},
 		func(filename string) {
 			log.Printf("  done\n")
@@ -591,20 +640,26 @@ func unzipCcxtFile(
 	ccxtDir *kelpos.OSPath,
 	ccxtBinPath *kelpos.OSPath,
 	filenameWithExt string,
-) {
+) error {
 	if _, e := os.Stat(ccxtDir.Native()); !os.IsNotExist(e) {
 		if _, e := os.Stat(ccxtBinPath.Native()); !os.IsNotExist(e) {
-			return
+			return nil
 		}
 	}
 
 	log.Printf("unzipping file %s ... ", filenameWithExt)
-	zipCmd := fmt.Sprintf("cd %s && unzip %s", ccxtDir.Unix(), filenameWithExt)
+	zipCmd := &kelpos.Command{
+		Binary:  "unzip",
+		Args:    []string{filenameWithExt},
+		Dir:     ccxtDir,
+		Timeout: ccxtCommandTimeout,
+	}
 	_, e := kos.Blocking(userID, "zip", zipCmd)
 	if e != nil {
-		log.Fatal(errors.Wrap(e, fmt.Sprintf("unable to unzip file %s in directory %s", filenameWithExt, ccxtDir.AsString())))
+		return errors.Wrap(e, fmt.Sprintf("unable to unzip file %s in directory %s", filenameWithExt, ccxtDir.AsString()))
 	}
 	log.Printf("done\n")
+	return nil
 }
 
 func runCcxtBinary(kos *kelpos.KelpOS, userID string, ccxtBinPath *kelpos.OSPath) error {
@@ -614,7 +669,7 @@ func runCcxtBinary(kos *kelpos.KelpOS, userID string, ccxtBinPath *kelpos.OSPath
 
 	log.Printf("running binary %s", ccxtBinPath.AsString())
 	// TODO CCXT should be run at the port specified by rootCcxtRestURL, currently it will default to port 3000 even if the config file specifies otherwise
-	_, e := kos.Background(userID, "ccxt-rest", ccxtBinPath.Unix())
+	_, e := kos.Background(userID, "ccxt-rest", kelpos.MakeCommand(kelpos.CommandPath(ccxtBinPath)))
 	if e != nil {
 		log.Fatal(errors.Wrap(e, fmt.Sprintf("unable to run ccxt file at location %s", ccxtBinPath.AsString())))
 	}
@@ -637,6 +692,41 @@ func runCcxtBinary(kos *kelpos.KelpOS, userID string, ccxtBinPath *kelpos.OSPath
 	return fmt.Errorf("waited for %d seconds but CCXT was still not running at URL %s", ccxtWaitSeconds, *rootCcxtRestURL)
 }
 
+// runNativeExchangeAdapter serves the ccxt-rest API with the built-in exchange adapter at the port specified by rootCcxtRestURL
+func runNativeExchangeAdapter() error {
+	ccxtURL, e := url.Parse(*rootCcxtRestURL)
+	if e != nil {
+		return fmt.Errorf("could not parse ccxt-rest URL '%s': %s", *rootCcxtRestURL, e)
+	}
+	port := ccxtURL.Port()
+	if port == "" {
+		return fmt.Errorf("ccxt-rest URL '%s' needs to specify a port to run the native exchange adapter", *rootCcxtRestURL)
+	}
+
+	log.Printf("starting native exchange adapter on port %s", port)
+	go func() {
+		e := http.ListenAndServe(":"+port, exchangeadapter.MakeServer(http.DefaultClient))
+		if e != nil {
+			log.Fatal(errors.Wrap(e, fmt.Sprintf("unable to run native exchange adapter on port %s", port)))
+		}
+	}()
+
+	log.Printf("waiting up to %d seconds for the native exchange adapter to start up ...", ccxtWaitSeconds)
+	for i := 0; i < ccxtWaitSeconds; i++ {
+		e := isCcxtUp(*rootCcxtRestURL)
+		if e == nil {
+			log.Printf("done, waited for ~%d seconds before the native exchange adapter was running\n", i)
+			return nil
+		}
+
+		// wait
+		log.Printf("native exchange adapter is not up, sleeping for 1 second (waited so far = %d seconds)\n", i)
+		time.Sleep(1 * time.Second)
+	}
+
+	return fmt.Errorf("waited for %d seconds but the native exchange adapter was still not running at URL %s", ccxtWaitSeconds, *rootCcxtRestURL)
+}
+
 func runAPIServerDevBlocking(s *backend.APIServer, frontendPort uint16, devAPIPort uint16) {
 	r := chi.NewRouter()
 	// Add CORS middleware around every request since both ports are different when running server in dev mode
@@ -653,26 +743,79 @@ func runAPIServerDevBlocking(s *backend.APIServer, frontendPort uint16, devAPIPo
 }
 
 func runWithYarn(kos *kelpos.KelpOS, port uint16, guiWebPath *kelpos.OSPath) {
-	// yarn requires the PORT variable to be set when serving
-	os.Setenv("PORT", fmt.Sprintf("%d", port))
-
 	log.Printf("Serving frontend via yarn on HTTP port: %d\n", port)
-	e := kos.StreamOutput(exec.Command("yarn", "--cwd", guiWebPath.Unix(), "start"))
+	e := kos.StreamOutput(context.Background(), &kelpos.Command{
+		Binary: "yarn",
+		Args:   []string{"--cwd", guiWebPath.Unix(), "start"},
+		// yarn requires the PORT variable to be set when serving
+		Env: []string{fmt.Sprintf("PORT=%d", port)},
+	})
 	if e != nil {
 		panic(e)
 	}
 }
 
 func generateStaticFiles(kos *kelpos.KelpOS, guiWebPath *kelpos.OSPath) {
+	e := buildStaticFiles(context.Background(), kos, guiWebPath)
+	if e != nil {
+		panic(e)
+	}
+}
+
+func buildStaticFiles(ctx context.Context, kos *kelpos.KelpOS, guiWebPath *kelpos.OSPath) error {
 	log.Printf("generating contents of %s/build ...\n", guiWebPath.Unix())
 
-	e := kos.StreamOutput(exec.Command("yarn", "--cwd", guiWebPath.Unix(), "build"))
+	e := kos.StreamOutput(ctx, kelpos.MakeCommand("yarn", "--cwd", guiWebPath.Unix(), "build"))
 	if e != nil {
-		panic(e)
+		return fmt.Errorf("unable to generate contents of %s/build: %s", guiWebPath.Unix(), e)
 	}
 
 	log.Printf("... finished generating contents of %s/build\n", guiWebPath.Unix())
 	log.Println()
+	return nil
+}
+
+// registerJobTypes registers the slow operations that the GUI can run in the background via the /jobs endpoints
+func registerJobTypes(s *backend.APIServer, kos *kelpos.KelpOS, guiWebPath *kelpos.OSPath, isLocalMode bool) error {
+	if runtime.GOOS != "windows" {
+		// ccxt is bundled as a folder on windows so there is nothing to download
+		e := s.RegisterJobType("ccxt_download", func(ctx context.Context, reportProgress backend.JobProgressFn) error {
+			ccxtDirPath := kos.GetDotKelpWorkingDir().Join(kelpCcxtPath)
+			ccxtFilenameNoExt := fmt.Sprintf("ccxt-rest_%s-x64", runtime.GOOS)
+			filenameWithExt := fmt.Sprintf("%s.zip", ccxtFilenameNoExt)
+			ccxtBinPath := ccxtDirPath.Join(ccxtFilenameNoExt).Join(ccxtBinaryName)
+
+			e := kos.Mkdir("_", ccxtDirPath)
+			if e != nil {
+				return fmt.Errorf("could not mkdir for ccxtDirPath: %s", e)
+			}
+			// the download cannot be interrupted so cancellation takes effect between steps
+			e = copyOrDownloadCcxtBinary(kos, "_", kos.GetBinDir().Join("ccxt").Join(filenameWithExt), ccxtDirPath.Join(filenameWithExt), filenameWithExt, reportProgress)
+			if e != nil {
+				return e
+			}
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			reportProgress(0.95, fmt.Sprintf("unzipping %s", filenameWithExt))
+			return unzipCcxtFile(kos, "_", ccxtDirPath, ccxtBinPath, filenameWithExt)
+		})
+		if e != nil {
+			return e
+		}
+	}
+
+	if isLocalMode {
+		e := s.RegisterJobType("generate_static_files", func(ctx context.Context, reportProgress backend.JobProgressFn) error {
+			reportProgress(0.0, fmt.Sprintf("generating contents of %s/build", guiWebPath.Unix()))
+			return buildStaticFiles(ctx, kos, guiWebPath)
+		})
+		if e != nil {
+			return e
+		}
+	}
+	return nil
 }
 
 func writeTrayIcon(kos *kelpos.KelpOS, trayIconPath *kelpos.OSPath, assetsDirPath *kelpos.OSPath) error {
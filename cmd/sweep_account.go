@@ -0,0 +1,434 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/stellar/go/clients/horizonclient"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/strkey"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/kelp/plugins"
+	"github.com/stellar/kelp/support/toml"
+	"github.com/stellar/kelp/support/utils"
+	"github.com/stellar/kelp/trader"
+)
+
+const sweepAccountExamples = `  kelp sweep-account --botConf ./path/trader.cfg --destination GABC... --dry-run
+  kelp sweep-account --botConf ./path/trader.cfg --destination GABC...
+  kelp sweep-account --botConf ./path/trader.cfg --destination GABC... --merge`
+
+var sweepAccountCmd = &cobra.Command{
+	Use:   "sweep-account",
+	Short: "Deletes all offers of the bot's trading account and sends its assets to a destination account to retire the bot",
+	Long: `Deletes all offers of the bot's trading account and sends its assets to a destination account to retire the bot.
+
+The sweep runs in steps, reloading the account from horizon before each step:
+  1. delete all offers of the trading account
+  2. send the balance of every non-native asset to the destination and remove the trustlines that are left with a zero balance
+  3. send the XLM that is not needed for the reserve to the destination, or merge the account into the destination with --merge
+
+Assets that the destination cannot receive because it does not have a trustline for them are left in the account along with their
+trustlines, which also prevents merging the account. Stop the bot before sweeping its account, otherwise it will place new offers.
+
+The plan is printed before anything is submitted, and the sweep needs to be confirmed. Merging the account needs a second confirmation
+since it cannot be undone.`,
+	Example: sweepAccountExamples,
+}
+
+// maxOpsPerTx is the max number of operations allowed in a transaction on the Stellar network
+const maxOpsPerTx = 100
+
+// liquidityPoolSharesAssetType is the asset type of a trustline to liquidity pool shares
+const liquidityPoolSharesAssetType = "liquidity_pool_shares"
+
+func init() {
+	botConfigPath := sweepAccountCmd.Flags().StringP("botConf", "c", "", "(required) trading bot's basic config file path")
+	profile := sweepAccountCmd.Flags().String("profile", "", "name of the profile section in the bot config file to use, such as testnet or pubnet")
+	destination := sweepAccountCmd.Flags().String("destination", "", "(required) account that receives the assets of the trading account")
+	merge := sweepAccountCmd.Flags().Bool("merge", false, "merge the trading account into the destination after sweeping it, which deletes the trading account")
+	baseFee := sweepAccountCmd.Flags().Int64("base-fee", 1000, "fee per operation in stroops")
+	dryRun := sweepAccountCmd.Flags().Bool("dry-run", false, "print the plan without submitting anything")
+	for _, flag := range []string{"botConf", "destination"} {
+		e := sweepAccountCmd.MarkFlagRequired(flag)
+		if e != nil {
+			panic(e)
+		}
+	}
+
+	sweepAccountCmd.Run = func(ccmd *cobra.Command, args []string) {
+		var botConfig trader.BotConfig
+		e := toml.ReadConfig(*botConfigPath, *profile, &botConfig)
+		utils.CheckConfigError(botConfig, e, *botConfigPath)
+		botConfig.LoadSecretsFromEnv()
+		e = botConfig.Init()
+		if e != nil {
+			log.Fatal(e)
+		}
+
+		if !strkey.IsValidEd25519PublicKey(*destination) {
+			log.Fatal(fmt.Errorf("invalid destination '%s', needs to be a Stellar account ID", *destination))
+		}
+		if *destination == botConfig.TradingAccount() {
+			log.Fatal(fmt.Errorf("destination cannot be the trading account"))
+		}
+		if *baseFee < txnbuild.MinBaseFee {
+			log.Fatal(fmt.Errorf("invalid base-fee, needs to be >= %d stroops; was %d", txnbuild.MinBaseFee, *baseFee))
+		}
+
+		s := &accountSweeper{
+			client: &horizonclient.Client{
+				HorizonURL: botConfig.HorizonURL,
+				HTTP:       http.DefaultClient,
+				AppName:    "kelp--cli--sweep-account",
+				AppVersion: version,
+			},
+			network:        utils.ParseNetwork(botConfig.HorizonURL),
+			tradingAccount: botConfig.TradingAccount(),
+			sourceAccount:  botConfig.SourceAccount(),
+			seeds:          []string{botConfig.TradingSecretSeed},
+			destination:    *destination,
+			baseFee:        *baseFee,
+			stdin:          bufio.NewReader(os.Stdin),
+		}
+		if s.sourceAccount == "" {
+			s.sourceAccount = s.tradingAccount
+		} else if s.sourceAccount != s.tradingAccount {
+			s.seeds = append(s.seeds, botConfig.SourceSecretSeed)
+		}
+
+		e = s.run(*merge, *dryRun)
+		if e != nil {
+			log.Fatal(e)
+		}
+	}
+}
+
+// accountSweeper submits the steps of the sweep, each step is submitted synchronously so the next step sees its effects
+type accountSweeper struct {
+	client         *horizonclient.Client
+	network        string
+	tradingAccount string
+	sourceAccount  string // pays the fees, same as the trading account when SOURCE_SECRET_SEED is not set
+	seeds          []string
+	destination    string
+	baseFee        int64
+	stdin          *bufio.Reader
+}
+
+func (s *accountSweeper) run(merge bool, dryRun bool) error {
+	account, e := s.loadAccount(s.tradingAccount)
+	if e != nil {
+		return e
+	}
+	destinationAccount, e := s.loadAccount(s.destination)
+	if e != nil {
+		return fmt.Errorf("the destination account needs to exist: %s", e)
+	}
+	offers, e := utils.LoadAllOffers(s.tradingAccount, s.client)
+	if e != nil {
+		return fmt.Errorf("unable to load offers of trading account '%s': %s", s.tradingAccount, e)
+	}
+
+	// the plan for the assets is made as if the offers were already deleted, the actual ops are made after the offers are deleted
+	assetOps, skipped := makeAssetSweepOps(account.Balances, destinationAccount, s.opSourceAccount())
+	fmt.Printf("Sweep plan for trading account %s (network: %s)\n", s.tradingAccount, s.network)
+	fmt.Printf("  1. delete %d offers\n", len(offers))
+	fmt.Printf("  2. send non-native assets to %s and remove their trustlines (%d operations)\n", s.destination, len(assetOps))
+	for _, reason := range skipped {
+		fmt.Printf("       - skipping %s\n", reason)
+	}
+	if merge {
+		fmt.Printf("  3. merge the trading account into %s, which sends all of its XLM and deletes the account\n", s.destination)
+	} else {
+		fmt.Printf("  3. send the XLM that is not needed for the reserve to %s\n", s.destination)
+	}
+	if merge && len(skipped) > 0 {
+		return fmt.Errorf("cannot merge the trading account because some of its trustlines cannot be removed, sweep the account without --merge first")
+	}
+	fmt.Println()
+	if dryRun {
+		fmt.Println("dry run, not submitting anything")
+		return nil
+	}
+
+	fmt.Println("Make sure that the bot using this account is stopped, otherwise it will place new offers while the account is being swept.")
+	if !s.confirm("Type 'sweep' to sweep the trading account: ", "sweep") {
+		return fmt.Errorf("sweep was not confirmed, nothing was submitted")
+	}
+
+	// step 1
+	deleteOps := []txnbuild.Operation{}
+	for _, offer := range offers {
+		deleteOps = append(deleteOps, &txnbuild.ManageSellOffer{
+			Selling:       utils.Asset2Asset(offer.Selling),
+			Buying:        utils.Asset2Asset(offer.Buying),
+			Amount:        "0",
+			Price:         offer.Price,
+			OfferID:       offer.ID,
+			SourceAccount: s.opSourceAccount(),
+		})
+	}
+	e = s.submitInBatches("delete offers", deleteOps)
+	if e != nil {
+		return e
+	}
+
+	// step 2
+	account, e = s.loadAccount(s.tradingAccount)
+	if e != nil {
+		return e
+	}
+	assetOps, skipped = makeAssetSweepOps(account.Balances, destinationAccount, s.opSourceAccount())
+	for _, reason := range skipped {
+		log.Printf("skipping %s\n", reason)
+	}
+	e = s.submitInBatches("send assets and remove trustlines", assetOps)
+	if e != nil {
+		return e
+	}
+
+	// step 3
+	account, e = s.loadAccount(s.tradingAccount)
+	if e != nil {
+		return e
+	}
+	if !merge {
+		feeStroops := int64(0)
+		if s.sourceAccount == s.tradingAccount {
+			feeStroops = s.baseFee
+		}
+		minReserve := plugins.SdexAccountMinReserve(*account)
+		amount, e := computeNativeSweepAmount(account.Balances, minReserve, feeStroops)
+		if e != nil {
+			return e
+		}
+		if amount <= 0.0 {
+			log.Printf("no XLM to send after keeping the reserve of %.7f XLM for %d subentries, %d sponsoring and %d sponsored entries\n",
+				minReserve, account.SubentryCount, account.NumSponsoring, account.NumSponsored)
+			return nil
+		}
+		return s.submitInBatches("send XLM", []txnbuild.Operation{&txnbuild.Payment{
+			Destination:   s.destination,
+			Amount:        strconv.FormatFloat(amount, 'f', 7, 64),
+			Asset:         txnbuild.NativeAsset{},
+			SourceAccount: s.opSourceAccount(),
+		}})
+	}
+
+	if account.SubentryCount > 0 {
+		return fmt.Errorf("cannot merge the trading account because it still has %d subentries (trustlines, offers, signers or data entries)", account.SubentryCount)
+	}
+	if account.NumSponsoring > 0 {
+		return fmt.Errorf("cannot merge the trading account because it still sponsors the reserves of %d entries (including claimable balances that it created)", account.NumSponsoring)
+	}
+	fmt.Printf("Merging deletes the trading account %s and cannot be undone.\n", s.tradingAccount)
+	if !s.confirm("Type the trading account ID to merge it: ", s.tradingAccount) {
+		return fmt.Errorf("merge was not confirmed, the trading account was swept but not merged")
+	}
+	return s.submitInBatches("merge account", []txnbuild.Operation{&txnbuild.AccountMerge{
+		Destination:   s.destination,
+		SourceAccount: s.opSourceAccount(),
+	}})
+}
+
+// opSourceAccount is the source account of the ops, which needs to be set when the fees are paid by a different account
+func (s *accountSweeper) opSourceAccount() string {
+	if s.sourceAccount == s.tradingAccount {
+		return ""
+	}
+	return s.tradingAccount
+}
+
+func (s *accountSweeper) confirm(prompt string, expected string) bool {
+	fmt.Print(prompt)
+	input, e := s.stdin.ReadString('\n')
+	if e != nil {
+		return false
+	}
+	return strings.TrimSpace(input) == expected
+}
+
+func (s *accountSweeper) loadAccount(accountID string) (*hProtocol.Account, error) {
+	account, e := s.client.AccountDetail(horizonclient.AccountRequest{AccountID: accountID})
+	if e != nil {
+		return nil, fmt.Errorf("unable to load account '%s': %s", accountID, e)
+	}
+	return &account, nil
+}
+
+// submitInBatches submits the ops in as many transactions as needed, waiting for each transaction to be included in a ledger
+func (s *accountSweeper) submitInBatches(description string, ops []txnbuild.Operation) error {
+	for start := 0; start < len(ops); start += maxOpsPerTx {
+		end := start + maxOpsPerTx
+		if end > len(ops) {
+			end = len(ops)
+		}
+
+		sourceAccount, e := s.loadAccount(s.sourceAccount)
+		if e != nil {
+			return e
+		}
+		tx, e := txnbuild.NewTransaction(
+			txnbuild.TransactionParams{
+				SourceAccount:        sourceAccount,
+				IncrementSequenceNum: true,
+				Operations:           ops[start:end],
+				BaseFee:              s.baseFee,
+				Timebounds:           txnbuild.NewTimeout(300),
+			},
+		)
+		if e != nil {
+			return fmt.Errorf("unable to make transaction to %s: %s", description, e)
+		}
+		tx, e = utils.SignWithSeed(tx, s.network, s.seeds...)
+		if e != nil {
+			return fmt.Errorf("unable to sign transaction to %s: %s", description, e)
+		}
+		txeB64, e := tx.Base64()
+		if e != nil {
+			return fmt.Errorf("unable to encode transaction to %s: %s", description, e)
+		}
+
+		resp, e := s.client.SubmitTransactionXDR(txeB64)
+		if e != nil {
+			if herr, ok := errors.Cause(e).(*horizonclient.Error); ok {
+				if rcs, e2 := herr.ResultCodes(); e2 == nil {
+					return fmt.Errorf("transaction to %s failed with tx code = %s, opcodes = %v: %s", description, rcs.TransactionCode, rcs.OperationCodes, e)
+				}
+			}
+			return fmt.Errorf("transaction to %s failed: %s", description, e)
+		}
+		log.Printf("%s: submitted %d operations, tx hash: %s\n", description, end-start, resp.Hash)
+	}
+	return nil
+}
+
+// makeAssetSweepOps makes the ops that send the balance of every non-native asset to the destination and remove the trustline. Assets that
+// cannot be swept are returned with the reason so they can be reported
+func makeAssetSweepOps(balances []hProtocol.Balance, destination *hProtocol.Account, opSourceAccount string) ([]txnbuild.Operation, []string) {
+	ops := []txnbuild.Operation{}
+	skipped := []string{}
+	for _, b := range balances {
+		if b.Asset.Type == utils.Native {
+			continue
+		}
+		if b.Asset.Type == liquidityPoolSharesAssetType {
+			skipped = append(skipped, fmt.Sprintf("liquidity pool shares of pool %s, withdraw from the pool to sweep them", b.LiquidityPoolId))
+			continue
+		}
+
+		asset := hProtocol.Asset(b.Asset)
+		balance, e := parseOptionalAmount(b.Balance)
+		if e != nil {
+			skipped = append(skipped, fmt.Sprintf("asset %s because its balance '%s' cannot be parsed: %s", utils.Asset2String(asset), b.Balance, e))
+			continue
+		}
+		buyingLiabilities, e := parseOptionalAmount(b.BuyingLiabilities)
+		if e != nil {
+			skipped = append(skipped, fmt.Sprintf("asset %s because its buying liabilities '%s' cannot be parsed: %s", utils.Asset2String(asset), b.BuyingLiabilities, e))
+			continue
+		}
+		sellingLiabilities, e := parseOptionalAmount(b.SellingLiabilities)
+		if e != nil {
+			skipped = append(skipped, fmt.Sprintf("asset %s because its selling liabilities '%s' cannot be parsed: %s", utils.Asset2String(asset), b.SellingLiabilities, e))
+			continue
+		}
+		if buyingLiabilities > 0.0 || sellingLiabilities > 0.0 {
+			// only happens in the plan since the offers are deleted before the actual ops are made
+			log.Printf("asset %s has liabilities from offers that will be deleted first\n", utils.Asset2String(asset))
+		}
+
+		creditAsset := txnbuild.CreditAsset{Code: asset.Code, Issuer: asset.Issuer}
+		if balance > 0.0 {
+			if ok, reason := canReceive(destination, asset, balance); !ok {
+				skipped = append(skipped, fmt.Sprintf("%s %s because %s", b.Balance, utils.Asset2String(asset), reason))
+				continue
+			}
+			ops = append(ops, &txnbuild.Payment{
+				Destination:   destination.AccountID,
+				Amount:        b.Balance,
+				Asset:         creditAsset,
+				SourceAccount: opSourceAccount,
+			})
+		}
+		ops = append(ops, &txnbuild.ChangeTrust{
+			Line:          creditAsset.MustToChangeTrustAsset(),
+			Limit:         "0",
+			SourceAccount: opSourceAccount,
+		})
+	}
+	return ops, skipped
+}
+
+// canReceive returns true if the account can receive a payment of the amount of the asset, which is when it is the issuer or when it has an
+// authorized trustline with enough room below its limit. Otherwise it returns the reason why the payment would fail
+func canReceive(account *hProtocol.Account, asset hProtocol.Asset, amount float64) (bool, string) {
+	if account.AccountID == asset.Issuer {
+		return true, ""
+	}
+	for _, b := range account.Balances {
+		if b.Asset.Type != asset.Type || b.Asset.Code != asset.Code || b.Asset.Issuer != asset.Issuer {
+			continue
+		}
+
+		if b.IsAuthorized != nil && !*b.IsAuthorized {
+			return false, "the trustline of the destination is not authorized by the issuer"
+		}
+		limit, e := parseOptionalAmount(b.Limit)
+		if e != nil {
+			return false, fmt.Sprintf("the limit '%s' of the trustline of the destination cannot be parsed: %s", b.Limit, e)
+		}
+		balance, e := parseOptionalAmount(b.Balance)
+		if e != nil {
+			return false, fmt.Sprintf("the balance '%s' of the destination cannot be parsed: %s", b.Balance, e)
+		}
+		buyingLiabilities, e := parseOptionalAmount(b.BuyingLiabilities)
+		if e != nil {
+			return false, fmt.Sprintf("the buying liabilities '%s' of the destination cannot be parsed: %s", b.BuyingLiabilities, e)
+		}
+		// the offers of the destination that buy the asset reserve room below the limit as well
+		available := limit - balance - buyingLiabilities
+		if amount > available {
+			return false, fmt.Sprintf("the trustline of the destination only has room for %.7f below its limit", math.Max(available, 0.0))
+		}
+		return true, ""
+	}
+	return false, "the destination does not have a trustline for it"
+}
+
+// computeNativeSweepAmount returns the amount of XLM that can be sent while keeping the min reserve and paying the fee of the payment,
+// rounded down to the precision of the network
+func computeNativeSweepAmount(balances []hProtocol.Balance, minReserve float64, feeStroops int64) (float64, error) {
+	for _, b := range balances {
+		if b.Asset.Type != utils.Native {
+			continue
+		}
+
+		balance, e := parseOptionalAmount(b.Balance)
+		if e != nil {
+			return 0.0, fmt.Errorf("cannot parse native balance '%s': %s", b.Balance, e)
+		}
+		sellingLiabilities, e := parseOptionalAmount(b.SellingLiabilities)
+		if e != nil {
+			return 0.0, fmt.Errorf("cannot parse native selling liabilities '%s': %s", b.SellingLiabilities, e)
+		}
+
+		amount := balance - minReserve - sellingLiabilities - float64(feeStroops)/1e7
+		// round down so we never try to send more than what is available
+		amount = math.Floor(amount*1e7+1e-6) / 1e7
+		if amount < 0.0 {
+			return 0.0, nil
+		}
+		return amount, nil
+	}
+	return 0.0, fmt.Errorf("account does not have a native balance")
+}
@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"testing"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/protocols/horizon/base"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/kelp/plugins"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testSweepIssuer      = "GBMMZMK2DC4FFP4CAI6KCVNCQ7WLO5A7DQU7EC7WGHRDQBZB763X4OQI"
+	testSweepDestination = "GAWZAUUSLHZCG4QMZL5BG6QKQWKEOWSEVRKCRNIZAH5BPJNZIJ3FRYKW"
+)
+
+func TestMakeAssetSweepOps(t *testing.T) {
+	usd := base.Asset{Type: "credit_alphanum4", Code: "USD", Issuer: testSweepIssuer}
+	eur := base.Asset{Type: "credit_alphanum4", Code: "EUR", Issuer: testSweepIssuer}
+	gbp := base.Asset{Type: "credit_alphanum4", Code: "GBP", Issuer: testSweepIssuer}
+	balances := []hProtocol.Balance{
+		{Balance: "100.0000000", Asset: base.Asset{Type: "native"}},
+		{Balance: "50.0000000", Limit: "1000.0000000", Asset: usd},
+		{Balance: "0.0000000", Limit: "1000.0000000", Asset: eur},
+		{Balance: "5.0000000", Limit: "1000.0000000", Asset: gbp},
+		{Balance: "10.0000000", Asset: base.Asset{Type: "liquidity_pool_shares"}, LiquidityPoolId: "abcdef"},
+	}
+	destination := &hProtocol.Account{
+		AccountID: testSweepDestination,
+		Balances: []hProtocol.Balance{
+			{Balance: "1.0000000", Asset: base.Asset{Type: "native"}},
+			{Balance: "0.0000000", Limit: "1000.0000000", Asset: usd},
+		},
+	}
+
+	ops, skipped := makeAssetSweepOps(balances, destination, "")
+	usdAsset := txnbuild.CreditAsset{Code: "USD", Issuer: testSweepIssuer}
+	eurAsset := txnbuild.CreditAsset{Code: "EUR", Issuer: testSweepIssuer}
+	assert.Equal(t, []txnbuild.Operation{
+		&txnbuild.Payment{Destination: testSweepDestination, Amount: "50.0000000", Asset: usdAsset},
+		&txnbuild.ChangeTrust{Line: usdAsset.MustToChangeTrustAsset(), Limit: "0"},
+		&txnbuild.ChangeTrust{Line: eurAsset.MustToChangeTrustAsset(), Limit: "0"},
+	}, ops)
+	// GBP cannot be received by the destination and liquidity pool shares need to be withdrawn first
+	assert.Equal(t, 2, len(skipped))
+
+	// the destination cannot receive the asset when its trustline is not authorized or does not have enough room below its limit
+	notAuthorized := false
+	destination.Balances[1].IsAuthorized = &notAuthorized
+	ops, skipped = makeAssetSweepOps(balances, destination, "")
+	assert.Equal(t, []txnbuild.Operation{
+		&txnbuild.ChangeTrust{Line: eurAsset.MustToChangeTrustAsset(), Limit: "0"},
+	}, ops)
+	assert.Equal(t, 3, len(skipped))
+	destination.Balances[1].IsAuthorized = nil
+	destination.Balances[1].Balance = "960.0000000"
+	ops, skipped = makeAssetSweepOps(balances, destination, "")
+	assert.Equal(t, 1, len(ops))
+	assert.Equal(t, 3, len(skipped))
+
+	// the issuer can always receive its own asset
+	destination.AccountID = testSweepIssuer
+	ops, skipped = makeAssetSweepOps(balances, destination, "")
+	assert.Equal(t, 6, len(ops))
+	assert.Equal(t, 1, len(skipped))
+}
+
+func TestComputeNativeSweepAmount(t *testing.T) {
+	testCases := []struct {
+		name          string
+		balance       string
+		liabilities   string
+		subentryCount int32
+		numSponsoring uint32
+		numSponsored  uint32
+		feeStroops    int64
+		want          float64
+	}{
+		{name: "no subentries", balance: "100.0000000", want: 99.0},
+		{name: "with fee", balance: "100.0000000", feeStroops: 1000, want: 98.9999},
+		{name: "with subentries and liabilities", balance: "100.0000000", liabilities: "10.0000000", subentryCount: 2, want: 88.0},
+		{name: "below reserve", balance: "0.5000000", want: 0.0},
+		{name: "sponsoring claimable balances", balance: "100.0000000", numSponsoring: 2, want: 98.0},
+		{name: "sponsored subentries", balance: "100.0000000", subentryCount: 2, numSponsored: 2, want: 99.0},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			balances := []hProtocol.Balance{{Balance: k.balance, SellingLiabilities: k.liabilities, Asset: base.Asset{Type: "native"}}}
+			minReserve := plugins.SdexSponsoredMinReserve(k.subentryCount, k.numSponsoring, k.numSponsored)
+			actual, e := computeNativeSweepAmount(balances, minReserve, k.feeStroops)
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.InDelta(t, k.want, actual, 0.00000001)
+		})
+	}
+
+	_, e := computeNativeSweepAmount([]hProtocol.Balance{}, 1.0, 0)
+	assert.Error(t, e)
+}
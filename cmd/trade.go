@@ -1,16 +1,21 @@
 package cmd
 
 import (
+	"bufio"
 	"database/sql"
 	"fmt"
 	"io"
 	"log"
+	"math/rand"
 	"net/http"
 	"os"
+	"os/signal"
+	"path/filepath"
 	"runtime"
 	"runtime/debug"
 	"runtime/pprof"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/denisbrodbeck/machineid"
@@ -19,18 +24,23 @@ import (
 
 	"github.com/stellar/go/clients/horizonclient"
 	hProtocol "github.com/stellar/go/protocols/horizon"
-	"github.com/stellar/go/support/config"
+	"github.com/stellar/go/txnbuild"
 	"github.com/stellar/kelp/api"
 	"github.com/stellar/kelp/kelpdb"
 	"github.com/stellar/kelp/model"
 	"github.com/stellar/kelp/plugins"
+	"github.com/stellar/kelp/support/assetdisplay"
 	"github.com/stellar/kelp/support/constants"
 	"github.com/stellar/kelp/support/database"
 	"github.com/stellar/kelp/support/logger"
 	"github.com/stellar/kelp/support/monitoring"
 	"github.com/stellar/kelp/support/networking"
-	"github.com/stellar/kelp/support/prefs"
+	"github.com/stellar/kelp/support/nonce"
+	"github.com/stellar/kelp/support/scheduler"
 	"github.com/stellar/kelp/support/sdk"
+	"github.com/stellar/kelp/support/statestore"
+	"github.com/stellar/kelp/support/timeseries"
+	"github.com/stellar/kelp/support/toml"
 	"github.com/stellar/kelp/support/utils"
 	"github.com/stellar/kelp/trader"
 )
@@ -59,6 +69,65 @@ var upgradeScripts = []*database.UpgradeScript{
 		kelpdb.SqlStrategyMirrorTradeTriggersTableCreate,
 		kelpdb.SqlTradesTableAlter2,
 	),
+	database.MakeUpgradeScript(7,
+		kelpdb.SqlTrailingStopMarksTableCreate,
+	),
+	database.MakeUpgradeScript(8,
+		kelpdb.SqlStrategyMirrorNettingPositionsTableCreate,
+	),
+	database.MakeUpgradeScript(9,
+		kelpdb.SqlTimeseriesPointsTableCreate,
+		kelpdb.SqlTimeseriesRollupsTableCreate,
+		kelpdb.SqlTimeseriesPointsIndexCreate,
+	),
+	database.MakeUpgradeScript(10,
+		kelpdb.SqlInventoryLotsTableCreate,
+		kelpdb.SqlInventoryLotClosuresTableCreate,
+		kelpdb.SqlInventoryLotsIndexCreate,
+		kelpdb.SqlInventoryLotClosuresIndexCreate,
+	),
+	database.MakeUpgradeScript(11,
+		kelpdb.SqlSpreadObligationSamplesTableCreate,
+	),
+	database.MakeUpgradeScript(12,
+		kelpdb.SqlBotQuotesTableCreate,
+	),
+	database.MakeUpgradeScript(13,
+		kelpdb.SqlLevelStatsTableCreate,
+	),
+	database.MakeUpgradeScript(14,
+		kelpdb.SqlOrderTracesTableCreate,
+		kelpdb.SqlOrderTracesIndexCreate,
+		kelpdb.SqlOrderTracesIndexCreate2,
+	),
+	database.MakeUpgradeScript(15,
+		kelpdb.SqlDecisionRecordsTableCreate,
+	),
+	database.MakeUpgradeScript(16,
+		kelpdb.SqlStrategyMirrorTradeTriggersTableAlter1,
+	),
+	database.MakeUpgradeScript(17,
+		kelpdb.SqlPortfolioBudgetsTableCreate,
+	),
+	database.MakeUpgradeScript(18,
+		kelpdb.SqlTrailingStopMarksTableAlter1,
+	),
+	database.MakeUpgradeScript(19,
+		kelpdb.SqlStrategyMirrorNettingPositionsTableAlter1,
+	),
+	database.MakeUpgradeScript(20,
+		kelpdb.SqlStrategyMirrorPendingOffsetsTableCreate,
+	),
+	database.MakeUpgradeScript(21,
+		kelpdb.SqlStrategyIcebergFillsTableCreate,
+	),
+	database.MakeUpgradeScript(22,
+		kelpdb.SqlDecisionRecordsTableAlter1,
+		kelpdb.SqlDecisionRecordsTableAlter2,
+	),
+	database.MakeUpgradeScript(23,
+		kelpdb.SqlDailyTradeStatsTableCreate,
+	),
 }
 
 const tradeExamples = `  kelp trade --botConf ./path/trader.cfg --strategy buysell --stratConf ./path/buysell.cfg
@@ -66,6 +135,29 @@ const tradeExamples = `  kelp trade --botConf ./path/trader.cfg --strategy buyse
 
 const prefsFilename = "kelp.prefs"
 
+// maintenance tasks that can be scheduled with MAINTENANCE_INTERVAL_SECONDS in the trader config, the intervals of the tasks that the
+// plugins register (plugins.ScheduledTasks) can be set there too
+const (
+	maintenanceTaskTimeseriesRetention = "timeseries_retention"
+	maintenanceTaskDailyAggregation    = "daily_aggregation"
+	maintenanceTaskLogRotation         = "log_rotation"
+	maintenanceTaskBalanceSnapshot     = "balance_snapshot"
+	maintenanceTaskMarketSnapshot      = "market_snapshot"
+	maintenanceTaskStaleOfferCleanup   = "stale_offer_cleanup"
+)
+
+// defaultTimeseriesRetentionInterval is how often we downsample and delete old timeseries data in the db unless it is configured
+const defaultTimeseriesRetentionInterval = time.Hour
+
+// defaultDailyAggregationInterval is how often we add up the trades of the market by day in the db unless it is configured
+const defaultDailyAggregationInterval = 24 * time.Hour
+
+// deleteOffersMaxAttempts is the number of times we reload the remaining offers and try to delete them before giving up
+const deleteOffersMaxAttempts = 5
+
+// runSummaryWebhookTimeout bounds the post of the run summary so a slow webhook cannot hold up the shutdown of the bot
+const runSummaryWebhookTimeout = 10 * time.Second
+
 var tradeCmd = &cobra.Command{
 	Use:     "trade",
 	Short:   "Trades against the Stellar universal marketplace using the specified strategy",
@@ -98,6 +190,7 @@ func logPanic(l logger.Logger, fatalOnError bool) {
 
 type inputs struct {
 	botConfigPath                 *string
+	profile                       *string
 	strategy                      *string
 	stratConfigPath               *string
 	operationalBuffer             *float64
@@ -110,6 +203,9 @@ type inputs struct {
 	guiUserID                     *string
 	cpuProfile                    *string
 	memProfile                    *string
+	faultInjection                *string
+	nonceDir                      *string
+	overrideMaxTxNotional         *bool
 }
 
 func validateCliParams(l logger.Logger, options inputs) {
@@ -149,11 +245,93 @@ func validateBotConfig(l logger.Logger, botConfig trader.BotConfig) {
 	validatePrecisionConfig(l, botConfig.IsTradingSdex(), botConfig.CentralizedVolumePrecisionOverride, "CENTRALIZED_VOLUME_PRECISION_OVERRIDE")
 	validatePrecisionConfig(l, botConfig.IsTradingSdex(), botConfig.CentralizedPricePrecisionOverride, "CENTRALIZED_PRICE_PRECISION_OVERRIDE")
 
+	if botConfig.SpreadObligationBps == 0 && botConfig.SpreadObligationMinDepth != 0 {
+		logger.Fatal(l, fmt.Errorf("need to specify SPREAD_OBLIGATION_BPS config param in trader config file when SPREAD_OBLIGATION_MIN_DEPTH is set"))
+	}
+
+	if (botConfig.BalanceAnomalyBaseTolerance == nil) != (botConfig.BalanceAnomalyQuoteTolerance == nil) {
+		logger.Fatal(l, fmt.Errorf("need to specify both BALANCE_ANOMALY_BASE_TOLERANCE and BALANCE_ANOMALY_QUOTE_TOLERANCE config params in trader config file to enable balance anomaly detection"))
+	}
+	if botConfig.BalanceAnomalyBaseTolerance != nil && !botConfig.SynchronizeStateLoadEnable {
+		// fills handled by a background fill tracker can land between the balance fetch and the check, which would be reported as an anomaly
+		logger.Fatal(l, fmt.Errorf("need to set SYNCHRONIZE_STATE_LOAD_ENABLE to true in trader config file when BALANCE_ANOMALY_BASE_TOLERANCE and BALANCE_ANOMALY_QUOTE_TOLERANCE are set"))
+	}
+
 	if botConfig.SleepMode != "" && botConfig.SleepMode != trader.SleepModeBegin.String() && botConfig.SleepMode != trader.SleepModeEnd.String() {
 		logger.Fatal(l, fmt.Errorf("SLEEP_MODE needs to be set to either '%s' or '%s'", trader.SleepModeBegin, trader.SleepModeEnd))
 	}
 }
 
+// makeTxNotionalGuard makes the guard that refuses transactions above MAX_TX_NOTIONAL, valuing the base asset with the configured feed and the
+// other assets with MAX_TX_NOTIONAL_ASSET_FEEDS. The asset that bids are quoted in (nil when it is the quote asset) is the quote asset of
+// another issuer so it is valued at par unless it has a feed
+func makeTxNotionalGuard(botConfig trader.BotConfig, override bool, bidAssetQuote *hProtocol.Asset) (*plugins.TxNotionalGuard, error) {
+	if botConfig.MaxTxNotional < 0 {
+		return nil, fmt.Errorf("MAX_TX_NOTIONAL cannot be negative, was %f", botConfig.MaxTxNotional)
+	}
+	if botConfig.MaxTxNotionalFeedType == "" || botConfig.MaxTxNotionalFeedURL == "" {
+		return nil, fmt.Errorf("need to specify MAX_TX_NOTIONAL_FEED_TYPE and MAX_TX_NOTIONAL_FEED_URL config params in trader config file when MAX_TX_NOTIONAL is set")
+	}
+
+	referenceFeed, e := plugins.MakePriceFeed(botConfig.MaxTxNotionalFeedType, botConfig.MaxTxNotionalFeedURL)
+	if e != nil {
+		return nil, fmt.Errorf("could not make the reference feed for MAX_TX_NOTIONAL: %s", e)
+	}
+	guard, e := plugins.MakeTxNotionalGuard(botConfig.MaxTxNotional, referenceFeed, botConfig.AssetBase(), botConfig.AssetQuote(), override)
+	if e != nil {
+		return nil, e
+	}
+
+	for assetString, feedSpec := range botConfig.MaxTxNotionalAssetFeeds {
+		asset, e := parseAssetString(assetString)
+		if e != nil {
+			return nil, fmt.Errorf("invalid asset in MAX_TX_NOTIONAL_ASSET_FEEDS: %s", e)
+		}
+		feedParts := strings.SplitN(feedSpec, "/", 2)
+		if len(feedParts) != 2 {
+			return nil, fmt.Errorf("the feed of asset '%s' in MAX_TX_NOTIONAL_ASSET_FEEDS needs to be of the form '<type>/<url>', was '%s'", assetString, feedSpec)
+		}
+		feed, e := plugins.MakePriceFeed(feedParts[0], feedParts[1])
+		if e != nil {
+			return nil, fmt.Errorf("could not make the feed of asset '%s' in MAX_TX_NOTIONAL_ASSET_FEEDS: %s", assetString, e)
+		}
+		e = guard.AddAssetFeed(asset, feed)
+		if e != nil {
+			return nil, fmt.Errorf("invalid MAX_TX_NOTIONAL_ASSET_FEEDS: %s", e)
+		}
+	}
+
+	if bidAssetQuote != nil {
+		if _, ok := botConfig.MaxTxNotionalAssetFeeds[utils.Asset2String(*bidAssetQuote)]; !ok {
+			parFeed, e := plugins.MakePriceFeed("fixed", "1.0")
+			if e != nil {
+				return nil, fmt.Errorf("could not make the feed of the bid asset: %s", e)
+			}
+			e = guard.AddAssetFeed(*bidAssetQuote, parFeed)
+			if e != nil {
+				return nil, fmt.Errorf("could not value the bid asset: %s", e)
+			}
+		}
+	}
+	return guard, nil
+}
+
+// parseAssetString parses an asset written as "native" or "<code>:<issuer>", which is the format of utils.Asset2String
+func parseAssetString(assetString string) (hProtocol.Asset, error) {
+	if assetString == utils.Native {
+		return utils.Asset2Asset2(txnbuild.NativeAsset{}), nil
+	}
+	parts := strings.Split(assetString, ":")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return hProtocol.Asset{}, fmt.Errorf("asset '%s' needs to be '%s' or of the form '<code>:<issuer>'", assetString, utils.Native)
+	}
+	asset, e := utils.ParseAsset(parts[0], parts[1])
+	if e != nil {
+		return hProtocol.Asset{}, fmt.Errorf("asset '%s': %s", assetString, e)
+	}
+	return *asset, nil
+}
+
 func validatePrecisionConfig(l logger.Logger, isTradingSdex bool, precisionField *int8, name string) {
 	if !isTradingSdex && precisionField != nil && *precisionField < 0 {
 		logger.Fatal(l, fmt.Errorf("need to specify non-negative %s config param in trader config file when not trading on SDEX", name))
@@ -167,6 +345,7 @@ func init() {
 	options.strategy = tradeCmd.Flags().StringP("strategy", "s", "", "(required) type of strategy to run")
 	options.stratConfigPath = tradeCmd.Flags().StringP("stratConf", "f", "", "strategy config file path")
 	// long-only flags
+	options.profile = tradeCmd.Flags().String("profile", "", "name of the profile section in the bot config file to use, such as testnet or pubnet")
 	options.operationalBuffer = tradeCmd.Flags().Float64("operationalBuffer", 20, "buffer of native XLM to maintain beyond minimum account balance requirement")
 	options.operationalBufferNonNativePct = tradeCmd.Flags().Float64("operationalBufferNonNativePct", 0.001, "buffer of non-native assets to maintain as a percentage (0.001 = 0.1%)")
 	options.simMode = tradeCmd.Flags().Bool("sim", false, "simulate the bot's actions without placing any trades")
@@ -177,6 +356,9 @@ func init() {
 	options.guiUserID = tradeCmd.Flags().String("gui-user-id", "", "specifies the guiUserID associated with this bot to use for metric tracking")
 	options.cpuProfile = tradeCmd.Flags().String("cpuprofile", "", "write cpu profile to `file`")
 	options.memProfile = tradeCmd.Flags().String("memprofile", "", "write memory profile to `file`")
+	options.nonceDir = tradeCmd.Flags().String("nonce-dir", "", "directory where the last nonce of every exchange API key is persisted so nonces never regress across restarts (defaults to ~/.kelp/nonces)")
+	options.overrideMaxTxNotional = tradeCmd.Flags().Bool("override-max-tx-notional", false, "submit transactions to horizon even when their notional is above MAX_TX_NOTIONAL in the trader config, logging a warning instead of refusing them")
+	options.faultInjection = tradeCmd.Flags().String("fault-injection", "", "inject latency, timeouts and errors into requests made to horizon and exchanges to rehearse degraded infrastructure, comma-separated key=value pairs (latency, jitter, timeout, timeout_rate, error_rate), e.g. 'latency=500ms,jitter=250ms,timeout_rate=0.05,error_rate=0.1'")
 
 	requiredFlag("botConf")
 	requiredFlag("strategy")
@@ -251,10 +433,11 @@ func makeFeeFn(l logger.Logger, botConfig trader.BotConfig, newClient *horizoncl
 	return feeFn
 }
 
-func readBotConfig(l logger.Logger, options inputs, botStartTime time.Time) trader.BotConfig {
+func readBotConfig(l logger.Logger, options inputs, stateStore statestore.Store, botStartTime time.Time) trader.BotConfig {
 	var botConfig trader.BotConfig
-	e := config.Read(*options.botConfigPath, &botConfig)
+	e := toml.ReadConfig(*options.botConfigPath, *options.profile, &botConfig)
 	utils.CheckConfigError(botConfig, e, *options.botConfigPath)
+	botConfig.LoadSecretsFromEnv()
 	e = botConfig.Init()
 	if e != nil {
 		logger.Fatal(l, e)
@@ -262,7 +445,11 @@ func readBotConfig(l logger.Logger, options inputs, botStartTime time.Time) trad
 
 	if *options.logPrefix != "" {
 		logFilename := makeLogFilename(*options.logPrefix, botConfig, botStartTime)
-		setLogFile(l, logFilename)
+		if dbStore, isDB := stateStore.(*statestore.DBStore); isDB {
+			setLogDB(l, dbStore, filepath.Base(logFilename))
+		} else {
+			setLogFile(l, logFilename)
+		}
 	}
 
 	l.Info(makeStartupMessage(options))
@@ -276,6 +463,43 @@ func readBotConfig(l logger.Logger, options inputs, botStartTime time.Time) trad
 	return botConfig
 }
 
+// checkAPIKeyPermissions warns about, or refuses to start with, exchange API keys that have more or fewer permissions than the bot needs.
+// It checks every trading exchange made since plugins.TrackTradingExchanges was called, including the exchanges made by the strategy.
+func checkAPIKeyPermissions(l logger.Logger, botConfig trader.BotConfig) {
+	check, e := plugins.ParseAPIKeyPermissionsCheck(botConfig.APIKeyPermissionsCheck)
+	if e != nil {
+		logger.Fatal(l, e)
+	}
+	if check == plugins.APIKeyPermissionsCheckOff {
+		return
+	}
+
+	required := plugins.RequiredAPIKeyPermissions{CanWithdraw: botConfig.APIKeyPermissionsWithdraw}
+	for _, x := range plugins.TrackedTradingExchanges() {
+		problems, e := plugins.CheckAPIKeyPermissions(x.Exchange, required)
+		if e != nil {
+			l.Infof("warning: could not check the permissions of the API keys of the '%s' exchange: %s\n", x.Name, e)
+			continue
+		}
+		if len(problems) == 0 {
+			l.Infof("the API keys of the '%s' exchange have exactly the permissions that the bot needs\n", x.Name)
+			continue
+		}
+
+		numBlocking := 0
+		for _, p := range problems {
+			l.Infof("warning: %s\n", p)
+			if p.Blocking {
+				numBlocking++
+			}
+		}
+		if check == plugins.APIKeyPermissionsCheckBlock && numBlocking > 0 {
+			logger.Fatal(l, fmt.Errorf("refusing to start because the API keys of the '%s' exchange have %d permission problems (API_KEY_PERMISSIONS_CHECK=%s)",
+				x.Name, numBlocking, check))
+		}
+	}
+}
+
 func makeExchangeShimSdex(
 	l logger.Logger,
 	botConfig trader.BotConfig,
@@ -386,6 +610,8 @@ func makeStrategy(
 	threadTracker *multithreading.ThreadTracker,
 	db *sql.DB,
 	metricsTracker *plugins.MetricsTracker,
+	runSummaryTracker *plugins.RunSummaryTracker,
+	clock api.Clock,
 ) api.Strategy {
 	// setting the temp hack variables for the sdex price feeds
 	e := plugins.SetPrivateSdexHack(client, plugins.MakeIEIF(true), network)
@@ -393,7 +619,7 @@ func makeStrategy(
 		l.Info("")
 		l.Errorf("%s", e)
 		// we want to delete all the offers and exit here since there is something wrong with our setup
-		deleteAllOffersAndExit(l, botConfig, client, sdex, exchangeShim, threadTracker, metricsTracker)
+		deleteAllOffersAndExit(l, botConfig, client, sdex, exchangeShim, threadTracker, metricsTracker, runSummaryTracker)
 	}
 
 	strategy, e := plugins.MakeStrategy(
@@ -411,12 +637,13 @@ func makeStrategy(
 		botConfig.IsTradingSdex(),
 		filterFactory,
 		db,
+		clock,
 	)
 	if e != nil {
 		l.Info("")
 		l.Errorf("%s", e)
 		// we want to delete all the offers and exit here since there is something wrong with our setup
-		deleteAllOffersAndExit(l, botConfig, client, sdex, exchangeShim, threadTracker, metricsTracker)
+		deleteAllOffersAndExit(l, botConfig, client, sdex, exchangeShim, threadTracker, metricsTracker, runSummaryTracker)
 	}
 	return strategy
 }
@@ -435,25 +662,35 @@ func makeBot(
 	threadTracker *multithreading.ThreadTracker,
 	options inputs,
 	metricsTracker *plugins.MetricsTracker,
+	runSummaryTracker *plugins.RunSummaryTracker,
+	balanceAnomalyDetector *plugins.BalanceAnomalyDetector,
+	spreadObligationTracker *plugins.SpreadObligationTracker,
+	orderTracer *plugins.OrderTracer,
+	decisionRecorder *plugins.DecisionRecorder,
+	tradeTap *plugins.TradeTap,
+	kelpMetrics monitoring.Metrics,
 	botStartTime time.Time,
+	clock api.Clock,
 ) *trader.Trader {
 	timeController := plugins.MakeIntervalTimeController(
 		time.Duration(botConfig.TickIntervalMillis)*time.Millisecond,
 		botConfig.MaxTickDelayMillis,
+		clock,
+		rand.New(rand.NewSource(clock.Now().UnixNano())),
 	)
 	submitMode, e := api.ParseSubmitMode(botConfig.SubmitMode)
 	if e != nil {
 		log.Println()
 		log.Println(e)
 		// we want to delete all the offers and exit here since there is something wrong with our setup
-		deleteAllOffersAndExit(l, botConfig, client, sdex, exchangeShim, threadTracker, metricsTracker)
+		deleteAllOffersAndExit(l, botConfig, client, sdex, exchangeShim, threadTracker, metricsTracker, runSummaryTracker)
 	}
 
 	if botConfig.SynchronizeStateLoadEnable && botConfig.SynchronizeStateLoadMaxRetries < 0 {
 		log.Println()
 		utils.PrintErrorHintf("SYNCHRONIZE_STATE_LOAD_MAX_RETRIES needs to be greater than or equal to 0 when SYNCHRONIZE_STATE_LOAD_ENABLE is set to true")
 		// we want to delete all the offers and exit here since there is something wrong with our setup
-		deleteAllOffersAndExit(l, botConfig, client, sdex, exchangeShim, threadTracker, metricsTracker)
+		deleteAllOffersAndExit(l, botConfig, client, sdex, exchangeShim, threadTracker, metricsTracker, runSummaryTracker)
 	}
 
 	assetBase := botConfig.AssetBase()
@@ -463,6 +700,9 @@ func makeBot(
 	if e != nil {
 		l.Infof("Unable to set up monitoring for alert type '%s' with the given API key\n", botConfig.AlertType)
 	}
+	plugins.SetPriceFeedAlert(alert)
+	// the alert is only created here so we pass it to the filters made below, such as the drawdown filter
+	filterFactory.Alert = alert
 
 	var valueBaseFeed api.PriceFeed
 	var valueQuoteFeed api.PriceFeed
@@ -472,7 +712,7 @@ func makeBot(
 			log.Println()
 			log.Println(e)
 			// we want to delete all the offers and exit here since there is something wrong with our setup
-			deleteAllOffersAndExit(l, botConfig, client, sdex, exchangeShim, threadTracker, metricsTracker)
+			deleteAllOffersAndExit(l, botConfig, client, sdex, exchangeShim, threadTracker, metricsTracker, runSummaryTracker)
 		}
 
 		valueQuoteFeed, e = parseValueFeed(botConfig.DollarValueFeedQuoteAsset)
@@ -480,22 +720,59 @@ func makeBot(
 			log.Println()
 			log.Println(e)
 			// we want to delete all the offers and exit here since there is something wrong with our setup
-			deleteAllOffersAndExit(l, botConfig, client, sdex, exchangeShim, threadTracker, metricsTracker)
+			deleteAllOffersAndExit(l, botConfig, client, sdex, exchangeShim, threadTracker, metricsTracker, runSummaryTracker)
 		}
 	}
 
 	// start make filters
+	// the filters are added in any order here and ResolveFilterChain orders them based on the FilterOrder declared by each filter
 	submitFilters := []plugins.SubmitFilter{}
+	// the pair whitelist filter runs first so no other filter or strategy op can place an offer outside of the whitelisted pairs
+	if whitelistedPairs, isWhitelistConfigured := botConfig.WhitelistedPairs(); isWhitelistConfigured {
+		whitelist, e := plugins.ParseWhitelistPairs(whitelistedPairs)
+		if e != nil {
+			log.Println()
+			log.Println(e)
+			// we want to delete all the offers and exit here since there is something wrong with our setup
+			deleteAllOffersAndExit(l, botConfig, client, sdex, exchangeShim, threadTracker, metricsTracker, runSummaryTracker)
+		}
+
+		if !plugins.IsPairWhitelisted(whitelist, assetBase, assetQuote) {
+			description := fmt.Sprintf("the configured pair %s/%s is not in the PAIR_WHITELIST for exchange '%s' and this account, whitelisted pairs: %v",
+				utils.Asset2CodeString(assetBase), utils.Asset2CodeString(assetQuote), botConfig.TradingExchangeName(), whitelist)
+			log.Println()
+			utils.PrintErrorHintf("%s", description)
+			e = alert.Trigger(description, nil)
+			if e != nil {
+				l.Infof("unable to trigger alert for pair whitelist: %s\n", e)
+			}
+			// we want to delete all the offers and exit here since there is something wrong with our setup
+			deleteAllOffersAndExit(l, botConfig, client, sdex, exchangeShim, threadTracker, metricsTracker, runSummaryTracker)
+		}
+		submitFilters = append(submitFilters, plugins.MakeFilterPairWhitelist(whitelist, alert))
+	}
 	if submitMode == api.SubmitModeMakerOnly {
+		crossingAction, e := plugins.ParseMakerModeCrossingAction(botConfig.MakerOnlyCrossingAction)
+		if e != nil {
+			log.Println()
+			log.Println(e)
+			// we want to delete all the offers and exit here since there is something wrong with our setup
+			deleteAllOffersAndExit(l, botConfig, client, sdex, exchangeShim, threadTracker, metricsTracker, runSummaryTracker)
+		}
 		submitFilters = append(submitFilters,
-			plugins.MakeFilterMakerMode(exchangeShim, sdex, tradingPair),
+			plugins.MakeFilterMakerMode(exchangeShim, sdex, tradingPair, crossingAction),
 		)
+	} else if botConfig.MakerOnlyCrossingAction != "" {
+		log.Println()
+		utils.PrintErrorHintf("MAKER_ONLY_CROSSING_ACTION can only be set when SUBMIT_MODE is \"maker_only\", remove it from the trader config file")
+		// we want to delete all the offers and exit here since there is something wrong with our setup
+		deleteAllOffersAndExit(l, botConfig, client, sdex, exchangeShim, threadTracker, metricsTracker, runSummaryTracker)
 	}
-	if len(botConfig.Filters) > 0 && *options.strategy != "sell" && *options.strategy != "sell_twap" && *options.strategy != "buy_twap" && *options.strategy != "delete" {
+	if botConfig.HasFilters() && *options.strategy != "sell" && *options.strategy != "sell_twap" && *options.strategy != "buy_twap" && *options.strategy != "vwap" && *options.strategy != "delete" {
 		log.Println()
-		utils.PrintErrorHintf("FILTERS currently only supported on 'sell', 'sell_twap', 'buy_twap', 'delete' strategies, remove FILTERS from the trader config file")
+		utils.PrintErrorHintf("FILTERS currently only supported on 'sell', 'sell_twap', 'buy_twap', 'vwap', 'delete' strategies, remove FILTERS and FILTER tables from the trader config file")
 		// we want to delete all the offers and exit here since there is something wrong with our setup
-		deleteAllOffersAndExit(l, botConfig, client, sdex, exchangeShim, threadTracker, metricsTracker)
+		deleteAllOffersAndExit(l, botConfig, client, sdex, exchangeShim, threadTracker, metricsTracker, runSummaryTracker)
 	}
 	for _, filterString := range botConfig.Filters {
 		filter, e := filterFactory.MakeFilter(filterString)
@@ -503,17 +780,55 @@ func makeBot(
 			log.Println()
 			log.Println(e)
 			// we want to delete all the offers and exit here since there is something wrong with our setup
-			deleteAllOffersAndExit(l, botConfig, client, sdex, exchangeShim, threadTracker, metricsTracker)
+			deleteAllOffersAndExit(l, botConfig, client, sdex, exchangeShim, threadTracker, metricsTracker, runSummaryTracker)
 		}
 		submitFilters = append(submitFilters, filter)
 	}
-	// exchange constraints filter is last so we catch any modifications made by previous filters. this ensures that the exchange is
-	// less likely to reject our updates
+	for _, filterConfig := range botConfig.FilterTables {
+		filter, e := filterFactory.MakeFilter(filterConfig.FilterString())
+		if e != nil {
+			log.Println()
+			log.Println(e)
+			// we want to delete all the offers and exit here since there is something wrong with our setup
+			deleteAllOffersAndExit(l, botConfig, client, sdex, exchangeShim, threadTracker, metricsTracker, runSummaryTracker)
+		}
+		submitFilters = append(submitFilters, plugins.MakeConfiguredFilter(filter, filterConfig.Name, filterConfig.Priority))
+	}
+	// exchange constraints filter runs after the filters that change ops so we catch any modifications made by them. this ensures that
+	// the exchange is less likely to reject our updates
 	submitFilters = append(submitFilters,
 		plugins.MakeFilterOrderConstraints(exchangeShim.GetOrderConstraints(tradingPair), assetBase, assetQuote),
 	)
+	// the trade tap filter runs after all other filters so it only records the offers that are submitted
+	if tradeTap != nil {
+		submitFilters = append(submitFilters, plugins.MakeFilterTradeTap(tradeTap))
+	}
+	submitFilters, e = plugins.ResolveFilterChain(submitFilters)
+	if e != nil {
+		log.Println()
+		log.Println(e)
+		// we want to delete all the offers and exit here since there is something wrong with our setup
+		deleteAllOffersAndExit(l, botConfig, client, sdex, exchangeShim, threadTracker, metricsTracker, runSummaryTracker)
+	}
+	// the filters only know about the trading pair so they see the bids quoted in the bid quote asset as bids quoted in the quote asset
+	if bidAssetQuote := plugins.GetBidAssetQuote(strategy); bidAssetQuote != nil {
+		submitFilters = plugins.WrapFiltersForBidAsset(submitFilters, assetBase, assetQuote, *bidAssetQuote)
+	}
+	// count the ops kept, modified and dropped by each filter, which are logged on every update and published on the /metrics endpoint
+	submitFilters = plugins.MakeFilterMetrics(kelpMetrics).Wrap(submitFilters)
+	if orderTracer != nil {
+		submitFilters = orderTracer.WrapFilters(submitFilters)
+	}
+	if decisionRecorder != nil {
+		submitFilters = decisionRecorder.WrapFilters(submitFilters)
+	}
 	// end make filters
 
+	// the fee is only bumped when trading on SDEX, where the FEE section is required
+	maxOpFeeStroops := uint64(0)
+	if botConfig.IsTradingSdex() && botConfig.Fee != nil {
+		maxOpFeeStroops = botConfig.Fee.MaxOpFeeStroops
+	}
 	return trader.MakeTrader(
 		client,
 		ieif,
@@ -533,11 +848,18 @@ func makeBot(
 		botConfig.DeleteCyclesThreshold,
 		submitMode,
 		submitFilters,
+		maxOpFeeStroops,
 		threadTracker,
 		options.fixedIterations,
 		dataKey,
 		alert,
 		metricsTracker,
+		runSummaryTracker,
+		balanceAnomalyDetector,
+		spreadObligationTracker,
+		orderTracer,
+		decisionRecorder,
+		clock,
 		botStartTime,
 	)
 }
@@ -567,7 +889,11 @@ func convertDeprecatedBotConfigValues(l logger.Logger, botConfig trader.BotConfi
 func runTradeCmd(options inputs) {
 	l := logger.MakeBasicLogger()
 	botStartTime := time.Now()
-	botConfig := readBotConfig(l, options, botStartTime)
+	stateStore, e := makeStateStore(".")
+	if e != nil {
+		logger.Fatal(l, e)
+	}
+	botConfig := readBotConfig(l, options, stateStore, botStartTime)
 	botConfig = convertDeprecatedBotConfigValues(l, botConfig)
 	l.Infof("Trading %s:%s for %s:%s\n", botConfig.AssetCodeA, botConfig.IssuerA, botConfig.AssetCodeB, botConfig.IssuerB)
 
@@ -620,7 +946,7 @@ func runTradeCmd(options inputs) {
 			botConfig.DollarValueFeedBaseAsset != "" && botConfig.DollarValueFeedQuoteAsset != "",
 			botConfig.AlertType,
 			int(botConfig.MonitoringPort) != 0,
-			len(botConfig.Filters) > 0,
+			botConfig.HasFilters(),
 			botConfig.PostgresDbConfig != nil,
 			*options.logPrefix != "",
 			*options.operationalBuffer,
@@ -638,6 +964,30 @@ func runTradeCmd(options inputs) {
 		l.Infof("metric - could not send startup event metric: %s", e)
 	}
 
+	runSummaryFilepath := botConfig.RunSummaryFile
+	// the run summary is only written next to the log file when the log is not kept in the state-db
+	if runSummaryFilepath == "" && *options.logPrefix != "" && logDBWriter == nil {
+		runSummaryFilepath = strings.TrimSuffix(makeLogFilename(*options.logPrefix, botConfig, botStartTime), ".log") + "_summary.json"
+	}
+	runSummaryTracker := plugins.MakeRunSummaryTracker(&http.Client{Timeout: runSummaryWebhookTimeout}, botStartTime, runSummaryFilepath, botConfig.RunSummaryWebhookURL)
+
+	var balanceAnomalyDetector *plugins.BalanceAnomalyDetector
+	if botConfig.BalanceAnomalyBaseTolerance != nil && botConfig.BalanceAnomalyQuoteTolerance != nil {
+		balanceAnomalyDetector, e = plugins.MakeBalanceAnomalyDetector(*botConfig.BalanceAnomalyBaseTolerance, *botConfig.BalanceAnomalyQuoteTolerance)
+		if e != nil {
+			logger.Fatal(l, fmt.Errorf("could not make balance anomaly detector: %s", e))
+		}
+	}
+
+	var tradeTap *plugins.TradeTap
+	if botConfig.TradeTap != "" {
+		tradeTap, e = plugins.MakeTradeTap(botConfig.TradeTap)
+		if e != nil {
+			logger.Fatal(l, fmt.Errorf("could not make trade tap: %s", e))
+		}
+		l.Infof("writing trade and offer events to the trade tap at '%s'\n", botConfig.TradeTap)
+	}
+
 	// --- start initialization of objects ----
 	threadTracker := multithreading.MakeThreadTracker()
 	assetBase := botConfig.AssetBase()
@@ -660,7 +1010,7 @@ func runTradeCmd(options inputs) {
 		}
 		client.AppVersion = version
 
-		p := prefs.Make(prefsFilename)
+		p := makePrefs(stateStore)
 		if p.FirstTime() {
 			log.Printf("Kelp sets the `X-App-Name` and `X-App-Version` headers on requests made to Horizon. These headers help us track overall Kelp usage, so that we can learn about general usage patterns and adapt Kelp to be more useful in the future. Kelp also uses Amplitude for metric tracking. These can be turned off using the `--no-headers` flag. See `kelp trade --help` for more information.\n")
 			e := p.SetNotFirstTime()
@@ -673,6 +1023,75 @@ func runTradeCmd(options inputs) {
 	}
 	log.Printf("using client.AppName = %s", client.AppName)
 
+	if *options.faultInjection != "" {
+		faultInjectionConfig, e := networking.ParseFaultInjectionConfig(*options.faultInjection)
+		if e != nil {
+			logger.Fatal(l, fmt.Errorf("invalid value for the fault-injection flag: %s", e))
+		}
+		l.Infof("fault injection is enabled, requests to horizon and exchanges will be degraded with %s\n", faultInjectionConfig)
+		faultInjector := networking.MakeFaultInjector(*faultInjectionConfig, time.Now().UnixNano())
+		client.HTTP = networking.MakeFaultInjectingHTTPClient(faultInjector, "horizon")
+		plugins.SetExchangeFaultInjector(faultInjector)
+	}
+
+	if len(botConfig.PairWhitelist) > 0 {
+		exchangeWhitelist := []plugins.ExchangePairWhitelist{}
+		for _, w := range botConfig.PairWhitelist {
+			pairs, e := plugins.ParseWhitelistPairs(w.Pairs)
+			if e != nil {
+				logger.Fatal(l, fmt.Errorf("invalid PAIR_WHITELIST for exchange '%s': %s", w.Exchange, e))
+			}
+			exchangeWhitelist = append(exchangeWhitelist, plugins.ExchangePairWhitelist{
+				Exchange: w.Exchange,
+				Account:  w.Account,
+				Pairs:    pairs,
+			})
+		}
+		whitelistAlert, e := monitoring.MakeAlert(botConfig.AlertType, botConfig.AlertAPIKey)
+		if e != nil {
+			l.Infof("Unable to set up monitoring for alert type '%s' with the given API key\n", botConfig.AlertType)
+		}
+		// every exchange we trade on directly, including the backing exchanges of the mirror strategy, refuses orders outside the whitelist
+		plugins.SetExchangePairWhitelist(exchangeWhitelist, whitelistAlert)
+	}
+	plugins.SetCMCConfig(botConfig.CmcAPIKey, botConfig.CmcSymbolMap)
+	plugins.SetFiatConfig(botConfig.FiatAPIKeys)
+	oracleSourceAccount := botConfig.OracleSourceAccount
+	if oracleSourceAccount == "" && botConfig.IsTradingSdex() {
+		oracleSourceAccount = botConfig.TradingAccount()
+	}
+	plugins.SetOracleConfig(botConfig.SorobanRPCURL, oracleSourceAccount)
+
+	if botConfig.FeedMaxStalenessSeconds != 0 || botConfig.FeedMaxChangeFraction != 0 || len(botConfig.FeedPriceBounds) > 0 {
+		feedValidationConfig, e := plugins.MakeFeedValidationConfig(
+			botConfig.FeedMaxStalenessSeconds,
+			botConfig.FeedMaxChangeFraction,
+			botConfig.FeedReanchorRejections,
+			botConfig.FeedReanchorSeconds,
+			botConfig.FeedPriceBounds,
+		)
+		if e != nil {
+			logger.Fatal(l, fmt.Errorf("invalid feed validation config: %s", e))
+		}
+		plugins.SetFeedValidationConfig(feedValidationConfig)
+		l.Infof("validating the prices of price feeds with %s\n", feedValidationConfig)
+	}
+
+	if botConfig.PriceFeedCacheSeconds < 0 {
+		logger.Fatal(l, fmt.Errorf("PRICE_FEED_CACHE_SECONDS cannot be negative, was %f", botConfig.PriceFeedCacheSeconds))
+	}
+	if botConfig.PriceFeedCacheSeconds > 0 {
+		priceFeedCacheTTL := time.Duration(botConfig.PriceFeedCacheSeconds * float64(time.Second))
+		plugins.SetPriceFeedCacheTTL(priceFeedCacheTTL)
+		l.Infof("sharing price feeds with the same type and url and caching their prices for %s\n", priceFeedCacheTTL)
+	}
+
+	nonceDir, e := setExchangeNonceRegistry(*options.nonceDir)
+	if e != nil {
+		logger.Fatal(l, e)
+	}
+	l.Infof("persisting exchange nonces in %s\n", nonceDir)
+
 	if *rootCcxtRestURL == "" && botConfig.CcxtRestURL != nil {
 		e := sdk.SetBaseURL(*botConfig.CcxtRestURL)
 		if e != nil {
@@ -690,6 +1109,9 @@ func runTradeCmd(options inputs) {
 	assetDisplayFn := model.MakePassthroughAssetDisplayFn()
 	if botConfig.IsTradingSdex() {
 		assetDisplayFn = model.MakeSdexMappedAssetDisplayFn(sdexAssetMap)
+		// load the display precision of the assets so amounts are logged with the number of decimals that the issuer uses
+		assetdisplay.Load(client, assetBase)
+		assetdisplay.Load(client, assetQuote)
 	}
 
 	var db *sql.DB
@@ -713,6 +1135,8 @@ func runTradeCmd(options inputs) {
 		}
 		log.Printf("made db instance with config: %s\n", botConfig.PostgresDbConfig.MakeConnectString())
 	}
+	// track the trading exchanges made from here on, including those made by the strategy, so the permissions of all their API keys are checked
+	plugins.TrackTradingExchanges()
 	exchangeShim, sdex := makeExchangeShimSdex(
 		l,
 		botConfig,
@@ -724,6 +1148,7 @@ func runTradeCmd(options inputs) {
 		tradingPair,
 		sdexAssetMap,
 	)
+	sdex.SetFeeChargedHandler(runSummaryTracker.RecordNetworkFee)
 	filterFactory := &plugins.FilterFactory{
 		ExchangeName:   botConfig.TradingExchangeName(),
 		TradingPair:    tradingPair,
@@ -731,6 +1156,7 @@ func runTradeCmd(options inputs) {
 		BaseAsset:      assetBase,
 		QuoteAsset:     assetQuote,
 		DB:             db,
+		AccountID:      botConfig.DbOverrideAccountID,
 	}
 	baseString, e := assetDisplayFn(tradingPair.Base)
 	if e != nil {
@@ -741,6 +1167,20 @@ func runTradeCmd(options inputs) {
 		logger.Fatal(l, fmt.Errorf("could not convert quote trading pair to string: %s", e))
 	}
 	marketID := plugins.MakeMarketID(botConfig.TradingExchangeName(), baseString, quoteString)
+	// the strategy and the trader share one clock so the timing of the bot can be controlled in one place
+	clock := plugins.MakeSystemClock()
+	// the decision recorder is set before the strategy is made so the price feeds made by the strategy report their prices to it
+	var decisionRecorder *plugins.DecisionRecorder
+	if botConfig.ExplainDecisions {
+		decisionRecorder, e = plugins.MakeDecisionRecorder(db, botConfig.DbOverrideAccountID, marketID, assetBase, assetQuote, plugins.MakeSystemClock())
+		if e != nil {
+			l.Info("")
+			l.Errorf("problem encountered while instantiating the decision recorder: %s", e)
+			deleteAllOffersAndExit(l, botConfig, client, sdex, exchangeShim, threadTracker, metricsTracker, runSummaryTracker)
+		}
+		plugins.SetDecisionRecorder(decisionRecorder)
+		l.Infof("explaining the decisions of every update cycle in the decision_records table\n")
+	}
 	strategy := makeStrategy(
 		l,
 		network,
@@ -758,7 +1198,39 @@ func runTradeCmd(options inputs) {
 		threadTracker,
 		db,
 		metricsTracker,
+		runSummaryTracker,
+		clock,
 	)
+	checkAPIKeyPermissions(l, botConfig)
+	// the guard is made after the strategy because it also values the asset that the strategy quotes bids in
+	if botConfig.MaxTxNotional != 0 {
+		txNotionalGuard, e := makeTxNotionalGuard(botConfig, *options.overrideMaxTxNotional, plugins.GetBidAssetQuote(strategy))
+		if e != nil {
+			logger.Fatal(l, e)
+		}
+		sdex.SetTxNotionalGuard(txNotionalGuard)
+		l.Infof("checking the notional of every transaction submitted to horizon with %s\n", txNotionalGuard)
+	}
+	var spreadObligationTracker *plugins.SpreadObligationTracker
+	if botConfig.SpreadObligationBps != 0 {
+		spreadObligationTracker, e = makeSpreadObligationTracker(botConfig, exchangeShim, tradingPair, db, marketID)
+		if e != nil {
+			l.Info("")
+			l.Errorf("problem encountered while instantiating the spread obligation tracker: %s", e)
+			deleteAllOffersAndExit(l, botConfig, client, sdex, exchangeShim, threadTracker, metricsTracker, runSummaryTracker)
+		}
+		l.Infof("tracking the time that quotes are within %.2f bps of the mid price with a depth of at least %f on each side\n", botConfig.SpreadObligationBps, botConfig.SpreadObligationMinDepth)
+	}
+	var orderTracer *plugins.OrderTracer
+	if botConfig.TraceOrders {
+		orderTracer, e = plugins.MakeOrderTracer(db, botConfig.DbOverrideAccountID, marketID, assetBase, assetQuote, plugins.MakeSystemClock())
+		if e != nil {
+			l.Info("")
+			l.Errorf("problem encountered while instantiating the order tracer: %s", e)
+			deleteAllOffersAndExit(l, botConfig, client, sdex, exchangeShim, threadTracker, metricsTracker, runSummaryTracker)
+		}
+		l.Infof("tracing the lifecycle of every offer with correlation IDs\n")
+	}
 	fillTracker := makeFillTracker(
 		l,
 		strategy,
@@ -772,7 +1244,25 @@ func runTradeCmd(options inputs) {
 		threadTracker,
 		botConfig.DbOverrideAccountID,
 		metricsTracker,
+		runSummaryTracker,
+		balanceAnomalyDetector,
+		tradeTap,
 	)
+	if orderTracer != nil {
+		if fillTracker != nil {
+			fillTracker.RegisterHandler(orderTracer)
+		} else {
+			l.Info("fill tracking is disabled so fills are not traced (set FILL_TRACKER_SLEEP_MILLIS to a non-zero value to trace fills)")
+		}
+	}
+	// the metrics recorder is made before the bot so the filters can publish their stats to it
+	var kelpMetrics monitoring.Metrics
+	if botConfig.MonitoringPort != 0 {
+		kelpMetrics, e = monitoring.MakeMetricsRecorder(nil)
+		if e != nil {
+			logger.Fatal(l, fmt.Errorf("unable to make metrics recorder for the /metrics endpoint: %s", e))
+		}
+	}
 	bot := makeBot(
 		l,
 		botConfig,
@@ -787,14 +1277,39 @@ func runTradeCmd(options inputs) {
 		threadTracker,
 		options,
 		metricsTracker,
+		runSummaryTracker,
+		balanceAnomalyDetector,
+		spreadObligationTracker,
+		orderTracer,
+		decisionRecorder,
+		tradeTap,
+		kelpMetrics,
 		botStartTime,
+		clock,
+	)
+	maintenanceScheduler, e := makeMaintenanceScheduler(
+		botConfig,
+		*options.logPrefix,
+		db,
+		sdex,
+		exchangeShim,
+		bot,
+		tradingPair,
+		assetBase,
+		assetQuote,
+		marketID,
+		plugins.ScheduledTasks(),
+		time.Now(),
 	)
+	if e != nil {
+		logger.Fatal(l, fmt.Errorf("unable to schedule maintenance tasks: %s", e))
+	}
 	// --- end initialization of objects ---
 	// --- start initialization of services ---
 	validateTrustlines(l, client, &botConfig)
 	if botConfig.MonitoringPort != 0 {
 		go func() {
-			e := startMonitoringServer(l, botConfig)
+			e := startMonitoringServer(l, botConfig, kelpMetrics, maintenanceScheduler)
 			if e != nil {
 				l.Info("")
 				l.Info("unable to start the monitoring server or problem encountered while running server:")
@@ -802,7 +1317,7 @@ func runTradeCmd(options inputs) {
 				// we want to delete all the offers and exit here because we don't want the bot to run if monitoring isn't working
 				// if monitoring is desired but not working properly, we want the bot to be shut down and guarantee that there
 				// aren't outstanding offers.
-				deleteAllOffersAndExit(l, botConfig, client, sdex, exchangeShim, threadTracker, metricsTracker)
+				deleteAllOffersAndExit(l, botConfig, client, sdex, exchangeShim, threadTracker, metricsTracker, runSummaryTracker)
 			}
 		}()
 	}
@@ -814,14 +1329,258 @@ func runTradeCmd(options inputs) {
 				l.Info("")
 				l.Errorf("problem encountered while running the fill tracker: %s", e)
 				// we want to delete all the offers and exit here because we don't want the bot to run if fill tracking isn't working
-				deleteAllOffersAndExit(l, botConfig, client, sdex, exchangeShim, threadTracker, metricsTracker)
+				deleteAllOffersAndExit(l, botConfig, client, sdex, exchangeShim, threadTracker, metricsTracker, runSummaryTracker)
 			}
 		}()
 	}
+	// write the run summary when the bot is stopped with a signal since that is how a bot is usually stopped
+	go func() {
+		signalChan := make(chan os.Signal, 1)
+		signal.Notify(signalChan, os.Interrupt, syscall.SIGTERM)
+		sig := <-signalChan
+		l.Infof("received signal '%s', exiting...\n", sig)
+		runSummaryTracker.Finish(fmt.Sprintf("received signal '%s'", sig))
+		flushLogDB()
+		// a signal is the expected way to stop the bot so this is a clean exit
+		os.Exit(0)
+	}()
+	if botConfig.ChurnReportIntervalSeconds > 0 {
+		churnReporter := plugins.MakeChurnReporter(runSummaryTracker, time.Duration(botConfig.ChurnReportIntervalSeconds)*time.Second, kelpMetrics)
+		go churnReporter.Run()
+	}
+	// control commands are read from stdin so the bot can be paused and resumed without restarting it, this is how the GUI controls a running bot
+	go readControlCommands(l, os.Stdin, bot)
+	l.Infof("running %d maintenance tasks\n", len(maintenanceScheduler.Statuses()))
+	go maintenanceScheduler.Run()
 	// --- end initialization of services ---
 
 	l.Info("Starting the trader bot...")
 	bot.Start()
+	runSummaryTracker.Finish("finished requested number of iterations")
+}
+
+// readControlCommands sends every line read from the reader to the bot as a control command until the reader is closed
+func readControlCommands(l logger.Logger, r io.Reader, bot *trader.Trader) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		command, e := trader.ParseControlCommand(line)
+		if e != nil {
+			l.Infof("ignoring input: %s\n", e)
+			continue
+		}
+
+		e = bot.SendControlCommand(command)
+		if e != nil {
+			l.Errorf("unable to send control command to bot: %s\n", e)
+			continue
+		}
+		l.Infof("queued control command '%s', it will be applied at the start of the next update cycle\n", command)
+	}
+	if e := scanner.Err(); e != nil {
+		l.Errorf("stopped reading control commands: %s\n", e)
+	}
+}
+
+// makeMaintenanceScheduler registers the periodic maintenance tasks set in MAINTENANCE_INTERVAL_SECONDS of the trader config and the tasks of
+// the plugins. Timeseries retention and daily aggregation always run when there is a db unless they are disabled with an interval of 0, and
+// they run once right away so a bot that is restarted often still gets to run them
+func makeMaintenanceScheduler(
+	botConfig trader.BotConfig,
+	logPrefix string,
+	db *sql.DB,
+	sdex *plugins.SDEX,
+	exchangeShim api.ExchangeShim,
+	bot *trader.Trader,
+	tradingPair *model.TradingPair,
+	assetBase hProtocol.Asset,
+	assetQuote hProtocol.Asset,
+	marketID string,
+	pluginTasks []plugins.ScheduledTask,
+	start time.Time,
+) (*scheduler.Scheduler, error) {
+	intervals := map[string]time.Duration{}
+	if db != nil {
+		intervals[maintenanceTaskTimeseriesRetention] = defaultTimeseriesRetentionInterval
+		intervals[maintenanceTaskDailyAggregation] = defaultDailyAggregationInterval
+	}
+	pluginTaskFns := map[string]scheduler.TaskFn{}
+	for _, t := range pluginTasks {
+		intervals[t.Name] = t.Interval
+		pluginTaskFns[t.Name] = t.Fn
+	}
+	for name, seconds := range botConfig.MaintenanceIntervalSeconds {
+		if seconds < 0 {
+			return nil, fmt.Errorf("interval of maintenance task '%s' cannot be negative, was %d", name, seconds)
+		}
+		intervals[name] = time.Duration(seconds) * time.Second
+	}
+
+	s := scheduler.MakeScheduler()
+	for name, interval := range intervals {
+		if interval == 0 {
+			continue
+		}
+
+		var fn scheduler.TaskFn
+		runAtStart := false
+		switch name {
+		case maintenanceTaskTimeseriesRetention, maintenanceTaskBalanceSnapshot, maintenanceTaskMarketSnapshot:
+			if db == nil {
+				return nil, fmt.Errorf("maintenance task '%s' needs POSTGRES_DB to be set in the trader config", name)
+			}
+			store, e := timeseries.MakeStore(db, timeseries.DefaultRetentionPolicies)
+			if e != nil {
+				return nil, fmt.Errorf("unable to make timeseries store: %s", e)
+			}
+			if name == maintenanceTaskTimeseriesRetention {
+				fn = store.ApplyRetention
+				runAtStart = true
+			} else if name == maintenanceTaskBalanceSnapshot {
+				fn = func(now time.Time) error {
+					return snapshotBalances(store, exchangeShim, []hProtocol.Asset{assetBase, assetQuote}, now)
+				}
+			} else {
+				marketLabel := utils.Asset2String(assetBase) + "/" + utils.Asset2String(assetQuote)
+				fn = func(now time.Time) error {
+					return snapshotMarket(store, exchangeShim, tradingPair, marketLabel, now)
+				}
+			}
+		case maintenanceTaskDailyAggregation:
+			if db == nil {
+				return nil, fmt.Errorf("maintenance task '%s' needs POSTGRES_DB to be set in the trader config", name)
+			}
+			fn = func(now time.Time) error {
+				return aggregateDailyTrades(db, marketID)
+			}
+			runAtStart = true
+		case maintenanceTaskLogRotation:
+			if logPrefix == "" {
+				return nil, fmt.Errorf("maintenance task '%s' needs the bot to log to a file with the --log flag", name)
+			}
+			fn = func(now time.Time) error {
+				return rotateLogFile(makeLogFilename(logPrefix, botConfig, now))
+			}
+		case maintenanceTaskStaleOfferCleanup:
+			maxAge := interval
+			fn = func(now time.Time) error {
+				return deleteStaleOffers(botConfig, sdex, exchangeShim, bot.LastSuccessfulUpdate(), maxAge, now)
+			}
+		default:
+			pluginFn, ok := pluginTaskFns[name]
+			if !ok {
+				return nil, fmt.Errorf("unknown maintenance task '%s' in MAINTENANCE_INTERVAL_SECONDS, needs to be one of %s, %s, %s, %s, %s, %s, or a task of the plugins that are enabled",
+					name, maintenanceTaskTimeseriesRetention, maintenanceTaskDailyAggregation, maintenanceTaskLogRotation, maintenanceTaskBalanceSnapshot, maintenanceTaskMarketSnapshot, maintenanceTaskStaleOfferCleanup)
+			}
+			fn = pluginFn
+		}
+
+		e := s.Register(name, interval, start, fn)
+		if e != nil {
+			return nil, e
+		}
+		if runAtStart {
+			e = s.RunNow(name, start)
+			if e != nil {
+				return nil, e
+			}
+		}
+	}
+	return s, nil
+}
+
+// aggregateDailyTrades adds up the trades of the market by day in the daily_trade_stats table
+func aggregateDailyTrades(db *sql.DB, marketID string) error {
+	result, e := db.Exec(kelpdb.SqlDailyTradeStatsRefresh, marketID)
+	if e != nil {
+		return fmt.Errorf("unable to aggregate the daily trades of market '%s': %s", marketID, e)
+	}
+	numRows, e := result.RowsAffected()
+	if e != nil {
+		return fmt.Errorf("unable to get the number of aggregated rows: %s", e)
+	}
+	log.Printf("aggregated the trades of market '%s' into %d rows of daily_trade_stats\n", marketID, numRows)
+	return nil
+}
+
+// deleteStaleOffers deletes the offers of the bot when it did not finish an update cycle successfully within maxAge, since those offers are no
+// longer kept in line with the market (e.g. because the update cycle is stuck or keeps failing). The trader loads the offers again in its
+// next update cycle so it continues from there when it recovers
+func deleteStaleOffers(botConfig trader.BotConfig, sdex *plugins.SDEX, exchangeShim api.ExchangeShim, lastSuccessfulUpdate time.Time, maxAge time.Duration, now time.Time) error {
+	age := now.Sub(lastSuccessfulUpdate)
+	if age <= maxAge {
+		return nil
+	}
+
+	offers, e := exchangeShim.LoadOffersHack()
+	if e != nil {
+		return fmt.Errorf("unable to load the offers to check for stale offers: %s", e)
+	}
+	sellingAOffers, buyingAOffers := utils.FilterOffers(offers, botConfig.AssetBase(), botConfig.AssetQuote())
+	allOffers := append(sellingAOffers, buyingAOffers...)
+	if len(allOffers) == 0 {
+		return nil
+	}
+
+	log.Printf("deleting %d stale offers because the last successful update cycle ended %s ago, which is more than %s\n", len(allOffers), age, maxAge)
+	chunks, e := utils.ChunkOps(sdex.DeleteAllOffers(allOffers), utils.MaxOpsPerTransaction)
+	if e != nil {
+		return fmt.Errorf("could not split delete operations into transactions: %s", e)
+	}
+	for _, chunk := range chunks {
+		e = submitDeleteOpsSynch(exchangeShim, chunk)
+		if e != nil {
+			return fmt.Errorf("unable to delete stale offers: %s", e)
+		}
+	}
+	return nil
+}
+
+// snapshotBalances records the balances of the assets in the inventory_history timeseries under the label of each asset
+func snapshotBalances(store *timeseries.Store, exchangeShim api.ExchangeShim, assets []hProtocol.Asset, now time.Time) error {
+	for _, asset := range assets {
+		balance, e := exchangeShim.GetBalanceHack(asset)
+		if e != nil {
+			return fmt.Errorf("unable to fetch balance of asset '%s': %s", utils.Asset2String(asset), e)
+		}
+		e = store.Append(timeseries.SeriesInventoryHistory, utils.Asset2String(asset), now, balance.Balance)
+		if e != nil {
+			return fmt.Errorf("unable to record balance of asset '%s': %s", utils.Asset2String(asset), e)
+		}
+	}
+	return nil
+}
+
+// snapshotMarket records the mid price of the market in the price_history timeseries and the spread between the top bid and ask in the
+// spread_analytics timeseries (in basis points of the mid price), both under the label of the market. Nothing is recorded when a side is empty.
+func snapshotMarket(store *timeseries.Store, exchangeShim api.ExchangeShim, tradingPair *model.TradingPair, label string, now time.Time) error {
+	ob, e := exchangeShim.GetOrderBook(tradingPair, 1)
+	if e != nil {
+		return fmt.Errorf("unable to fetch orderbook: %s", e)
+	}
+	topBid := ob.TopBid()
+	topAsk := ob.TopAsk()
+	if topBid == nil || topAsk == nil {
+		log.Printf("not recording market snapshot of '%s' because the orderbook has an empty side\n", label)
+		return nil
+	}
+
+	bid := topBid.Price.AsFloat()
+	ask := topAsk.Price.AsFloat()
+	mid := (bid + ask) / 2.0
+	e = store.Append(timeseries.SeriesPriceHistory, label, now, mid)
+	if e != nil {
+		return fmt.Errorf("unable to record mid price of '%s': %s", label, e)
+	}
+	e = store.Append(timeseries.SeriesSpreadAnalytics, label, now, (ask-bid)/mid*10000.0)
+	if e != nil {
+		return fmt.Errorf("unable to record spread of '%s': %s", label, e)
+	}
+	return nil
 }
 
 func getUserID(l logger.Logger, botConfig trader.BotConfig) (string, error) {
@@ -846,7 +1605,7 @@ func getUserID(l logger.Logger, botConfig trader.BotConfig) (string, error) {
 	return fmt.Sprint(userIDHashed), nil
 }
 
-func startMonitoringServer(l logger.Logger, botConfig trader.BotConfig) error {
+func startMonitoringServer(l logger.Logger, botConfig trader.BotConfig, kelpMetrics monitoring.Metrics, maintenanceScheduler *scheduler.Scheduler) error {
 	healthMetrics, e := monitoring.MakeMetricsRecorder(map[string]interface{}{"success": true})
 	if e != nil {
 		return fmt.Errorf("unable to make metrics recorder for the /health endpoint: %s", e)
@@ -856,10 +1615,6 @@ func startMonitoringServer(l logger.Logger, botConfig trader.BotConfig) error {
 		return fmt.Errorf("unable to make /health endpoint: %s", e)
 	}
 
-	kelpMetrics, e := monitoring.MakeMetricsRecorder(nil)
-	if e != nil {
-		return fmt.Errorf("unable to make metrics recorder for the /metrics endpoint: %s", e)
-	}
 	metricsAuth := networking.NoAuth
 	if botConfig.GoogleClientID != "" || botConfig.GoogleClientSecret != "" {
 		metricsAuth = networking.GoogleAuth
@@ -877,7 +1632,8 @@ func startMonitoringServer(l logger.Logger, botConfig trader.BotConfig) error {
 	for _, email := range strings.Split(botConfig.AcceptableEmails, ",") {
 		serverConfig.PermittedEmails[email] = true
 	}
-	server, e := networking.MakeServerWithGoogleAuth(serverConfig, []networking.Endpoint{healthEndpoint, metricsEndpoint})
+	maintenanceEndpoint := scheduler.MakeStatusEndpoint("/maintenance", maintenanceScheduler, metricsAuth)
+	server, e := networking.MakeServerWithGoogleAuth(serverConfig, []networking.Endpoint{healthEndpoint, metricsEndpoint, maintenanceEndpoint})
 	if e != nil {
 		return fmt.Errorf("unable to initialize the metrics server: %s", e)
 	}
@@ -899,13 +1655,16 @@ func makeFillTracker(
 	threadTracker *multithreading.ThreadTracker,
 	accountID string,
 	metricsTracker *plugins.MetricsTracker,
+	runSummaryTracker *plugins.RunSummaryTracker,
+	balanceAnomalyDetector *plugins.BalanceAnomalyDetector,
+	tradeTap *plugins.TradeTap,
 ) api.FillTracker {
 	strategyFillHandlers, e := strategy.GetFillHandlers()
 	if e != nil {
 		l.Info("")
 		l.Info("problem encountered while instantiating the fill tracker:")
 		l.Errorf("%s", e)
-		deleteAllOffersAndExit(l, botConfig, client, sdex, exchangeShim, threadTracker, metricsTracker)
+		deleteAllOffersAndExit(l, botConfig, client, sdex, exchangeShim, threadTracker, metricsTracker, runSummaryTracker)
 	}
 
 	fillTrackerEnabled := botConfig.SynchronizeStateLoadEnable || botConfig.FillTrackerSleepMillis != 0
@@ -913,8 +1672,16 @@ func makeFillTracker(
 		l.Info("")
 		l.Error("error: strategy has FillHandlers but fill tracking was disabled (set FILL_TRACKER_SLEEP_MILLIS to a non-zero value)")
 		// we want to delete all the offers and exit here because we don't want the bot to run if fill tracking isn't working
-		deleteAllOffersAndExit(l, botConfig, client, sdex, exchangeShim, threadTracker, metricsTracker)
+		deleteAllOffersAndExit(l, botConfig, client, sdex, exchangeShim, threadTracker, metricsTracker, runSummaryTracker)
+	} else if !botConfig.SynchronizeStateLoadEnable && balanceAnomalyDetector != nil {
+		l.Info("")
+		l.Error("error: balance anomaly detection needs synchronized state loading to be enabled (set SYNCHRONIZE_STATE_LOAD_ENABLE to true)")
+		// we want to delete all the offers and exit here because we cannot explain balance changes with fills that are not in sync with them
+		deleteAllOffersAndExit(l, botConfig, client, sdex, exchangeShim, threadTracker, metricsTracker, runSummaryTracker)
 	} else if !fillTrackerEnabled {
+		if tradeTap != nil {
+			l.Info("fill tracking is disabled so only offer events are written to the trade tap (set FILL_TRACKER_SLEEP_MILLIS to a non-zero value to include trades)")
+		}
 		return nil
 	}
 
@@ -927,7 +1694,7 @@ func makeFillTracker(
 			l.Info("")
 			l.Error(fmt.Sprintf("could not get last trade cursor from exchangeShim: %s", e))
 			// we want to delete all the offers and exit here because we don't want the bot to run if fill tracking isn't working correctly
-			deleteAllOffersAndExit(l, botConfig, client, sdex, exchangeShim, threadTracker, metricsTracker)
+			deleteAllOffersAndExit(l, botConfig, client, sdex, exchangeShim, threadTracker, metricsTracker, runSummaryTracker)
 		}
 		log.Printf("set latest trade cursor from where to start tracking fills (no override specified): %v\n", lastCursor)
 	} else {
@@ -937,12 +1704,42 @@ func makeFillTracker(
 	}
 
 	fillTracker := plugins.MakeFillTracker(tradingPair, threadTracker, exchangeShim, botConfig.FillTrackerSleepMillis, botConfig.FillTrackerDeleteCyclesThreshold, lastCursor)
+	if bidAssetQuote := plugins.GetBidAssetQuote(strategy); bidAssetQuote != nil {
+		// bids quoted in the bid quote asset are filled on a different market than the trading pair so track the fills of that market too
+		bidFillTrackable := plugins.MakeBidAssetFillTrackable(sdex, botConfig.AssetBase(), *bidAssetQuote)
+		bidLastCursor, e := bidFillTrackable.GetLatestTradeCursor()
+		if e != nil {
+			l.Info("")
+			l.Error(fmt.Sprintf("could not get last trade cursor of the bid market (%s): %s", utils.Asset2String(*bidAssetQuote), e))
+			// we want to delete all the offers and exit here because we don't want the bot to run if fill tracking isn't working correctly
+			deleteAllOffersAndExit(l, botConfig, client, sdex, exchangeShim, threadTracker, metricsTracker, runSummaryTracker)
+		}
+		log.Printf("set latest trade cursor from where to start tracking fills of the bid market (%s): %v\n", utils.Asset2String(*bidAssetQuote), bidLastCursor)
+		fillTracker.AddMarket("bid", bidFillTrackable, bidLastCursor)
+	}
 	fillLogger := plugins.MakeFillLogger()
 	fillTracker.RegisterHandler(fillLogger)
+	fillTracker.RegisterHandler(runSummaryTracker)
+	if balanceAnomalyDetector != nil {
+		fillTracker.RegisterHandler(balanceAnomalyDetector)
+	}
+	if tradeTap != nil {
+		fillTracker.RegisterHandler(tradeTap)
+	}
 	if db != nil {
 		fillDBWriter := plugins.MakeFillDBWriter(db, assetDisplayFn, botConfig.TradingExchangeName(), accountID)
 		fillTracker.RegisterHandler(fillDBWriter)
 	}
+	if botConfig.InventoryLotMethod != "" {
+		inventoryLedger, e := makeInventoryLedger(botConfig, assetDisplayFn, db, accountID)
+		if e != nil {
+			l.Info("")
+			l.Errorf("problem encountered while instantiating the inventory ledger: %s", e)
+			deleteAllOffersAndExit(l, botConfig, client, sdex, exchangeShim, threadTracker, metricsTracker, runSummaryTracker)
+		}
+		fillTracker.RegisterHandler(inventoryLedger)
+		l.Infof("tracking the cost basis of the inventory with %s lots\n", botConfig.InventoryLotMethod)
+	}
 	if strategyFillHandlers != nil {
 		for _, h := range strategyFillHandlers {
 			fillTracker.RegisterHandler(h)
@@ -952,6 +1749,62 @@ func makeFillTracker(
 	return fillTracker
 }
 
+// setExchangeNonceRegistry persists the exchange nonces in the nonceDir, or in ~/.kelp/nonces when it is empty, and returns the dir that is used
+func setExchangeNonceRegistry(nonceDir string) (string, error) {
+	if nonceDir == "" {
+		homeDir, e := os.UserHomeDir()
+		if e != nil {
+			return "", fmt.Errorf("could not find the home directory for the default nonce dir, set the nonce-dir flag instead: %s", e)
+		}
+		nonceDir = filepath.Join(homeDir, ".kelp", "nonces")
+	}
+	nonceRegistry, e := nonce.MakeRegistry(nonceDir)
+	if e != nil {
+		return "", fmt.Errorf("could not make the nonce registry: %s", e)
+	}
+	plugins.SetExchangeNonceRegistry(nonceRegistry)
+	return nonceDir, nil
+}
+
+func makeInventoryLedger(botConfig trader.BotConfig, assetDisplayFn model.AssetDisplayFn, db *sql.DB, accountID string) (*plugins.InventoryLedger, error) {
+	if db == nil {
+		utils.PrintErrorHintf("INVENTORY_LOT_METHOD needs the POSTGRES_DB to be enabled in the trader.cfg file so we can store the inventory lots")
+		return nil, fmt.Errorf("invalid trader.cfg config, need to set POSTGRES_DB when INVENTORY_LOT_METHOD is set")
+	}
+
+	method, e := plugins.ParseInventoryLotMethod(botConfig.InventoryLotMethod)
+	if e != nil {
+		return nil, fmt.Errorf("invalid INVENTORY_LOT_METHOD: %s", e)
+	}
+	return plugins.MakeInventoryLedger(db, assetDisplayFn, botConfig.TradingExchangeName(), accountID, method)
+}
+
+func makeSpreadObligationTracker(
+	botConfig trader.BotConfig,
+	exchangeShim api.ExchangeShim,
+	tradingPair *model.TradingPair,
+	db *sql.DB,
+	marketID string,
+) (*plugins.SpreadObligationTracker, error) {
+	if db == nil {
+		utils.PrintErrorHintf("SPREAD_OBLIGATION_BPS needs the POSTGRES_DB to be enabled in the trader.cfg file so we can store the spread obligation samples")
+		return nil, fmt.Errorf("invalid trader.cfg config, need to set POSTGRES_DB when SPREAD_OBLIGATION_BPS is set")
+	}
+
+	// a sample covers at most two update cycles so the time when the bot was not running is not counted
+	maxSampleDuration := 2 * (time.Duration(botConfig.TickIntervalMillis)*time.Millisecond + time.Duration(botConfig.MaxTickDelayMillis)*time.Millisecond)
+	return plugins.MakeSpreadObligationTracker(
+		db,
+		exchangeShim,
+		tradingPair,
+		botConfig.DbOverrideAccountID,
+		marketID,
+		botConfig.SpreadObligationBps,
+		botConfig.SpreadObligationMinDepth,
+		maxSampleDuration,
+	)
+}
+
 func validateTrustlines(l logger.Logger, client *horizonclient.Client, botConfig *trader.BotConfig) {
 	if !botConfig.IsTradingSdex() {
 		l.Info("no need to validate trustlines because we're not using SDEX as the trading exchange")
@@ -994,6 +1847,7 @@ func deleteAllOffersAndExit(
 	exchangeShim api.ExchangeShim,
 	threadTracker *multithreading.ThreadTracker,
 	metricsTracker *plugins.MetricsTracker,
+	runSummaryTracker *plugins.RunSummaryTracker,
 ) {
 	// synchronous event to guarantee execution. we want to know whenever we enter the delete all offers logic. this function
 	// waits for all threads to be synchronous, which is equivalent to sending synchronously. we use
@@ -1009,41 +1863,77 @@ func deleteAllOffersAndExit(
 	threadTracker.Wait()
 	l.Info("...all outstanding threads finished")
 
+	runSummaryTracker.Finish("deleted all offers because of an error in the bot setup or a background service")
+
 	l.Info("")
 	l.Info("deleting all offers and then exiting...")
 
-	offers, e := utils.LoadAllOffers(botConfig.TradingAccount(), client)
-	if e != nil {
-		logger.Fatal(l, e)
-		return
-	}
-	sellingAOffers, buyingAOffers := utils.FilterOffers(offers, botConfig.AssetBase(), botConfig.AssetQuote())
-	allOffers := append(sellingAOffers, buyingAOffers...)
+	// offers are deleted in chunks because a transaction can have at most utils.MaxOpsPerTransaction ops. If a chunk fails then we reload
+	// the offers and try again, which resumes from where we stopped since offers that were already deleted are no longer returned.
+	numDeleted := 0
+	for attempt := 1; attempt <= deleteOffersMaxAttempts; attempt++ {
+		offers, e := utils.LoadAllOffers(botConfig.TradingAccount(), client)
+		if e != nil {
+			l.Infof("attempt %d of %d: could not load offers to be deleted: %s\n", attempt, deleteOffersMaxAttempts, e)
+			continue
+		}
+		sellingAOffers, buyingAOffers := utils.FilterOffers(offers, botConfig.AssetBase(), botConfig.AssetQuote())
+		allOffers := append(sellingAOffers, buyingAOffers...)
+		if len(allOffers) == 0 {
+			if numDeleted == 0 {
+				logger.Fatal(l, fmt.Errorf("...nothing to delete, exiting"))
+				return
+			}
+			logger.Fatal(l, fmt.Errorf("...deleted all offers (%d offers), exiting", numDeleted))
+			return
+		}
 
-	dOps := sdex.DeleteAllOffers(allOffers)
-	l.Infof("created %d operations to delete offers\n", len(dOps))
+		dOps := sdex.DeleteAllOffers(allOffers)
+		chunks, e := utils.ChunkOps(dOps, utils.MaxOpsPerTransaction)
+		if e != nil {
+			logger.Fatal(l, fmt.Errorf("could not split delete operations into transactions: %s", e))
+			return
+		}
+		l.Infof("attempt %d of %d: created %d operations to delete offers, submitting in %d transactions\n", attempt, deleteOffersMaxAttempts, len(dOps), len(chunks))
 
-	if len(dOps) > 0 {
-		// to delete offers the submitMode doesn't matter, so use api.SubmitModeBoth as the default
-		e := exchangeShim.SubmitOpsSynch(api.ConvertOperation2TM(dOps), api.SubmitModeBoth, func(hash string, e error) {
+		failed := false
+		for i, chunk := range chunks {
+			e := submitDeleteOpsSynch(exchangeShim, chunk)
 			if e != nil {
-				logger.Fatal(l, e)
-				return
+				l.Infof("error when deleting offers in transaction %d of %d, will reload offers and retry: %s\n", i+1, len(chunks), e)
+				failed = true
+				break
 			}
-			logger.Fatal(l, fmt.Errorf("...deleted all offers, exiting"))
-		})
-		if e != nil {
-			logger.Fatal(l, e)
+			numDeleted += len(chunk)
+			l.Infof("deleted offers in transaction %d of %d (%d of %d remaining offers deleted in this attempt)\n", i+1, len(chunks), (i*utils.MaxOpsPerTransaction)+len(chunk), len(dOps))
+		}
+		if !failed {
+			logger.Fatal(l, fmt.Errorf("...deleted all offers (%d offers), exiting", numDeleted))
 			return
 		}
+	}
+	logger.Fatal(l, fmt.Errorf("could not delete all offers after %d attempts (deleted %d offers), exiting", deleteOffersMaxAttempts, numDeleted))
+}
 
-		for {
-			sleepSeconds := 10
-			l.Infof("sleeping for %d seconds until our deletion is confirmed and we exit...(should never reach this line since we submit delete ops synchronously)\n", sleepSeconds)
-			time.Sleep(time.Duration(sleepSeconds) * time.Second)
+// submitDeleteOpsSynch submits the ops synchronously and returns the error from the submission, which is either returned directly or passed to the callback
+func submitDeleteOpsSynch(exchangeShim api.ExchangeShim, ops []txnbuild.Operation) error {
+	callbackErrors := make(chan error, 1)
+	// to delete offers the submitMode doesn't matter, so use api.SubmitModeBoth as the default
+	e := exchangeShim.SubmitOpsSynch(api.ConvertOperation2TM(ops), api.SubmitModeBoth, func(hash string, e error) {
+		select {
+		case callbackErrors <- e:
+		default:
 		}
-	} else {
-		logger.Fatal(l, fmt.Errorf("...nothing to delete, exiting"))
+	})
+	if e != nil {
+		return e
+	}
+
+	select {
+	case e = <-callbackErrors:
+		return e
+	default:
+		return nil
 	}
 }
 
@@ -1055,12 +1945,69 @@ func setLogFile(l logger.Logger, filename string) {
 	}
 	mw := io.MultiWriter(os.Stdout, f)
 	log.SetOutput(mw)
+	logFile = f
 
 	l.Infof("logging to file: %s\n", filename)
 	// we want to create a deferred recovery function here that will log panics to the log file and then exit
 	defer logPanic(l, false)
 }
 
+// setLogDB logs to the kelp_logs table of the state-db (and stdout) instead of a file, so the bot does not need a writable local volume
+func setLogDB(l logger.Logger, store *statestore.DBStore, name string) {
+	logDBWriter = store.MakeLogWriter(name)
+	log.SetOutput(io.MultiWriter(os.Stdout, logDBWriter))
+	logger.AddFlushFn(flushLogDB)
+
+	l.Infof("logging to the kelp_logs table of the state-db with the name: %s\n", name)
+	// we want to create a deferred recovery function here that will log panics to the log and then exit
+	defer logPanic(l, false)
+}
+
+// flushLogDB writes the log lines that are buffered by the writer of setLogDB before the process exits
+func flushLogDB() {
+	if logDBWriter == nil {
+		return
+	}
+	e := logDBWriter.Flush()
+	if e != nil {
+		fmt.Fprintf(os.Stderr, "unable to flush the log lines to the state-db before exiting: %s\n", e)
+	}
+}
+
+// logFile is the file set by setLogFile, it is closed when the log is rotated
+var logFile *os.File
+
+// logDBWriter is the writer set by setLogDB
+var logDBWriter *statestore.DBLogWriter
+
+// rotateLogFile switches the log to a new file and closes the previous one, or switches to a new name when the log is kept in the state-db
+func rotateLogFile(filename string) error {
+	if logDBWriter != nil {
+		e := logDBWriter.SetName(filepath.Base(filename))
+		if e != nil {
+			log.Printf("unable to flush the log lines before rotating the log: %s\n", e)
+		}
+		log.Printf("rotated log to name: %s\n", filepath.Base(filename))
+		return nil
+	}
+
+	f, e := os.OpenFile(filename, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
+	if e != nil {
+		return fmt.Errorf("failed to open new log file: %s", e)
+	}
+	log.SetOutput(io.MultiWriter(os.Stdout, f))
+	log.Printf("rotated log to file: %s\n", filename)
+
+	if logFile != nil {
+		e = logFile.Close()
+		if e != nil {
+			log.Printf("unable to close previous log file: %s\n", e)
+		}
+	}
+	logFile = f
+	return nil
+}
+
 func makeLogFilename(logPrefix string, botConfig trader.BotConfig, botStartTime time.Time) string {
 	botStartStr := botStartTime.Format("20060102T150405MST")
 	if botConfig.IsTradingSdex() {
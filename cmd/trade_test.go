@@ -23,11 +23,26 @@ func TestTradeUpgradeScripts(t *testing.T) {
 	}
 
 	// assert current state of the database
-	assert.Equal(t, 4, database.GetNumTablesInDb(db))
+	assert.Equal(t, 19, database.GetNumTablesInDb(db))
 	assert.True(t, database.CheckTableExists(db, "db_version"))
 	assert.True(t, database.CheckTableExists(db, "markets"))
 	assert.True(t, database.CheckTableExists(db, "trades"))
 	assert.True(t, database.CheckTableExists(db, "strategy_mirror_trade_triggers"))
+	assert.True(t, database.CheckTableExists(db, "trailing_stop_marks"))
+	assert.True(t, database.CheckTableExists(db, "strategy_mirror_netting_positions"))
+	assert.True(t, database.CheckTableExists(db, "timeseries_points"))
+	assert.True(t, database.CheckTableExists(db, "timeseries_rollups"))
+	assert.True(t, database.CheckTableExists(db, "inventory_lots"))
+	assert.True(t, database.CheckTableExists(db, "inventory_lot_closures"))
+	assert.True(t, database.CheckTableExists(db, "spread_obligation_samples"))
+	assert.True(t, database.CheckTableExists(db, "bot_quotes"))
+	assert.True(t, database.CheckTableExists(db, "level_stats"))
+	assert.True(t, database.CheckTableExists(db, "order_traces"))
+	assert.True(t, database.CheckTableExists(db, "decision_records"))
+	assert.True(t, database.CheckTableExists(db, "portfolio_budgets"))
+	assert.True(t, database.CheckTableExists(db, "strategy_mirror_pending_offsets"))
+	assert.True(t, database.CheckTableExists(db, "strategy_iceberg_fills"))
+	assert.True(t, database.CheckTableExists(db, "daily_trade_stats"))
 
 	// check schema of db_version table
 	var columns []database.TableColumn
@@ -218,7 +233,770 @@ func TestTradeUpgradeScripts(t *testing.T) {
 
 	// check schema of strategy_mirror_trade_triggers table
 	columns = database.GetTableSchema(db, "strategy_mirror_trade_triggers")
-	assert.Equal(t, 4, len(columns), fmt.Sprintf("%v", columns))
+	assert.Equal(t, 5, len(columns), fmt.Sprintf("%v", columns))
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "market_id",
+		OrdinalPosition:        1,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "text",
+		CharacterMaximumLength: nil,
+	}, &columns[0])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "txid",
+		OrdinalPosition:        2,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "text",
+		CharacterMaximumLength: nil,
+	}, &columns[1])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "backing_market_id",
+		OrdinalPosition:        3,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "text",
+		CharacterMaximumLength: nil,
+	}, &columns[2])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "backing_order_id",
+		OrdinalPosition:        4,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "text",
+		CharacterMaximumLength: nil,
+	}, &columns[3])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "fx_rate",
+		OrdinalPosition:        5,
+		ColumnDefault:          nil,
+		IsNullable:             "YES",
+		DataType:               "double precision",
+		CharacterMaximumLength: nil,
+	}, &columns[4])
+	// check indexes of strategy_mirror_trade_triggers table
+	indexes = database.GetTableIndexes(db, "strategy_mirror_trade_triggers")
+	assert.Equal(t, 1, len(indexes))
+	database.AssertIndex(t, "strategy_mirror_trade_triggers", "strategy_mirror_trade_triggers_pkey", "CREATE UNIQUE INDEX strategy_mirror_trade_triggers_pkey ON public.strategy_mirror_trade_triggers USING btree (market_id, txid)", indexes)
+
+	// check schema of trailing_stop_marks table
+	columns = database.GetTableSchema(db, "trailing_stop_marks")
+	assert.Equal(t, 5, len(columns), fmt.Sprintf("%v", columns))
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "market_id",
+		OrdinalPosition:        1,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "text",
+		CharacterMaximumLength: nil,
+	}, &columns[0])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "price_feed",
+		OrdinalPosition:        2,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "text",
+		CharacterMaximumLength: nil,
+	}, &columns[1])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "high_water_mark",
+		OrdinalPosition:        3,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "double precision",
+		CharacterMaximumLength: nil,
+	}, &columns[2])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "date_updated_utc",
+		OrdinalPosition:        4,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "timestamp without time zone",
+		CharacterMaximumLength: nil,
+	}, &columns[3])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "stopped_utc",
+		OrdinalPosition:        5,
+		ColumnDefault:          nil,
+		IsNullable:             "YES",
+		DataType:               "timestamp without time zone",
+		CharacterMaximumLength: nil,
+	}, &columns[4])
+	// check indexes of trailing_stop_marks table
+	indexes = database.GetTableIndexes(db, "trailing_stop_marks")
+	assert.Equal(t, 1, len(indexes))
+	database.AssertIndex(t, "trailing_stop_marks", "trailing_stop_marks_pkey", "CREATE UNIQUE INDEX trailing_stop_marks_pkey ON public.trailing_stop_marks USING btree (market_id, price_feed)", indexes)
+
+	// check schema of strategy_mirror_netting_positions table
+	columns = database.GetTableSchema(db, "strategy_mirror_netting_positions")
+	assert.Equal(t, 5, len(columns), fmt.Sprintf("%v", columns))
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "netting_group",
+		OrdinalPosition:        1,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "text",
+		CharacterMaximumLength: nil,
+	}, &columns[0])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "backing_market_id",
+		OrdinalPosition:        2,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "text",
+		CharacterMaximumLength: nil,
+	}, &columns[1])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "net_base_volume",
+		OrdinalPosition:        3,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "double precision",
+		CharacterMaximumLength: nil,
+	}, &columns[2])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "date_updated_utc",
+		OrdinalPosition:        4,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "timestamp without time zone",
+		CharacterMaximumLength: nil,
+	}, &columns[3])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "net_quote_volume",
+		OrdinalPosition:        5,
+		ColumnDefault:          "0",
+		IsNullable:             "NO",
+		DataType:               "double precision",
+		CharacterMaximumLength: nil,
+	}, &columns[4])
+	// check indexes of strategy_mirror_netting_positions table
+	indexes = database.GetTableIndexes(db, "strategy_mirror_netting_positions")
+	assert.Equal(t, 1, len(indexes))
+	database.AssertIndex(t, "strategy_mirror_netting_positions", "strategy_mirror_netting_positions_pkey", "CREATE UNIQUE INDEX strategy_mirror_netting_positions_pkey ON public.strategy_mirror_netting_positions USING btree (netting_group, backing_market_id)", indexes)
+
+	// check schema of inventory_lots table
+	columns = database.GetTableSchema(db, "inventory_lots")
+	assert.Equal(t, 8, len(columns), fmt.Sprintf("%v", columns))
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "account_id",
+		OrdinalPosition:        1,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "text",
+		CharacterMaximumLength: nil,
+	}, &columns[0])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "market_id",
+		OrdinalPosition:        2,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "text",
+		CharacterMaximumLength: nil,
+	}, &columns[1])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "lot_txid",
+		OrdinalPosition:        3,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "text",
+		CharacterMaximumLength: nil,
+	}, &columns[2])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "date_opened_utc",
+		OrdinalPosition:        4,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "timestamp without time zone",
+		CharacterMaximumLength: nil,
+	}, &columns[3])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "side",
+		OrdinalPosition:        5,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "text",
+		CharacterMaximumLength: nil,
+	}, &columns[4])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "price",
+		OrdinalPosition:        6,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "double precision",
+		CharacterMaximumLength: nil,
+	}, &columns[5])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "base_volume",
+		OrdinalPosition:        7,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "double precision",
+		CharacterMaximumLength: nil,
+	}, &columns[6])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "remaining_base_volume",
+		OrdinalPosition:        8,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "double precision",
+		CharacterMaximumLength: nil,
+	}, &columns[7])
+	// check indexes of inventory_lots table
+	indexes = database.GetTableIndexes(db, "inventory_lots")
+	assert.Equal(t, 2, len(indexes))
+	database.AssertIndex(t, "inventory_lots", "inventory_lots_pkey", "CREATE UNIQUE INDEX inventory_lots_pkey ON public.inventory_lots USING btree (account_id, market_id, lot_txid)", indexes)
+	database.AssertIndex(t, "inventory_lots", "inventory_lots_amsd", "CREATE INDEX inventory_lots_amsd ON public.inventory_lots USING btree (account_id, market_id, side, date_opened_utc)", indexes)
+
+	// check schema of inventory_lot_closures table
+	columns = database.GetTableSchema(db, "inventory_lot_closures")
+	assert.Equal(t, 11, len(columns), fmt.Sprintf("%v", columns))
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "account_id",
+		OrdinalPosition:        1,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "text",
+		CharacterMaximumLength: nil,
+	}, &columns[0])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "market_id",
+		OrdinalPosition:        2,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "text",
+		CharacterMaximumLength: nil,
+	}, &columns[1])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "lot_txid",
+		OrdinalPosition:        3,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "text",
+		CharacterMaximumLength: nil,
+	}, &columns[2])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "closing_txid",
+		OrdinalPosition:        4,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "text",
+		CharacterMaximumLength: nil,
+	}, &columns[3])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "date_opened_utc",
+		OrdinalPosition:        5,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "timestamp without time zone",
+		CharacterMaximumLength: nil,
+	}, &columns[4])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "date_closed_utc",
+		OrdinalPosition:        6,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "timestamp without time zone",
+		CharacterMaximumLength: nil,
+	}, &columns[5])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "side",
+		OrdinalPosition:        7,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "text",
+		CharacterMaximumLength: nil,
+	}, &columns[6])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "base_volume",
+		OrdinalPosition:        8,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "double precision",
+		CharacterMaximumLength: nil,
+	}, &columns[7])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "open_price",
+		OrdinalPosition:        9,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "double precision",
+		CharacterMaximumLength: nil,
+	}, &columns[8])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "close_price",
+		OrdinalPosition:        10,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "double precision",
+		CharacterMaximumLength: nil,
+	}, &columns[9])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "realized_pnl",
+		OrdinalPosition:        11,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "double precision",
+		CharacterMaximumLength: nil,
+	}, &columns[10])
+	// check indexes of inventory_lot_closures table
+	indexes = database.GetTableIndexes(db, "inventory_lot_closures")
+	assert.Equal(t, 2, len(indexes))
+	database.AssertIndex(t, "inventory_lot_closures", "inventory_lot_closures_pkey", "CREATE UNIQUE INDEX inventory_lot_closures_pkey ON public.inventory_lot_closures USING btree (account_id, market_id, lot_txid, closing_txid)", indexes)
+	database.AssertIndex(t, "inventory_lot_closures", "inventory_lot_closures_amd", "CREATE INDEX inventory_lot_closures_amd ON public.inventory_lot_closures USING btree (account_id, market_id, date_closed_utc)", indexes)
+
+	// check schema of spread_obligation_samples table
+	columns = database.GetTableSchema(db, "spread_obligation_samples")
+	assert.Equal(t, 11, len(columns), fmt.Sprintf("%v", columns))
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "account_id",
+		OrdinalPosition:        1,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "text",
+		CharacterMaximumLength: nil,
+	}, &columns[0])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "market_id",
+		OrdinalPosition:        2,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "text",
+		CharacterMaximumLength: nil,
+	}, &columns[1])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "date_utc",
+		OrdinalPosition:        3,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "timestamp without time zone",
+		CharacterMaximumLength: nil,
+	}, &columns[2])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "duration_seconds",
+		OrdinalPosition:        4,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "double precision",
+		CharacterMaximumLength: nil,
+	}, &columns[3])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "reference_price",
+		OrdinalPosition:        5,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "double precision",
+		CharacterMaximumLength: nil,
+	}, &columns[4])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "max_spread_bps",
+		OrdinalPosition:        6,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "double precision",
+		CharacterMaximumLength: nil,
+	}, &columns[5])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "min_depth",
+		OrdinalPosition:        7,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "double precision",
+		CharacterMaximumLength: nil,
+	}, &columns[6])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "bid_depth",
+		OrdinalPosition:        8,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "double precision",
+		CharacterMaximumLength: nil,
+	}, &columns[7])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "ask_depth",
+		OrdinalPosition:        9,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "double precision",
+		CharacterMaximumLength: nil,
+	}, &columns[8])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "bid_met",
+		OrdinalPosition:        10,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "boolean",
+		CharacterMaximumLength: nil,
+	}, &columns[9])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "ask_met",
+		OrdinalPosition:        11,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "boolean",
+		CharacterMaximumLength: nil,
+	}, &columns[10])
+	// check indexes of spread_obligation_samples table
+	indexes = database.GetTableIndexes(db, "spread_obligation_samples")
+	assert.Equal(t, 1, len(indexes))
+	database.AssertIndex(t, "spread_obligation_samples", "spread_obligation_samples_pkey", "CREATE UNIQUE INDEX spread_obligation_samples_pkey ON public.spread_obligation_samples USING btree (account_id, market_id, date_utc)", indexes)
+
+	// check schema of bot_quotes table
+	columns = database.GetTableSchema(db, "bot_quotes")
+	assert.Equal(t, 6, len(columns), fmt.Sprintf("%v", columns))
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "bot_id",
+		OrdinalPosition:        1,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "text",
+		CharacterMaximumLength: nil,
+	}, &columns[0])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "base_asset",
+		OrdinalPosition:        2,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "text",
+		CharacterMaximumLength: nil,
+	}, &columns[1])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "quote_asset",
+		OrdinalPosition:        3,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "text",
+		CharacterMaximumLength: nil,
+	}, &columns[2])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "best_bid",
+		OrdinalPosition:        4,
+		ColumnDefault:          nil,
+		IsNullable:             "YES",
+		DataType:               "double precision",
+		CharacterMaximumLength: nil,
+	}, &columns[3])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "best_ask",
+		OrdinalPosition:        5,
+		ColumnDefault:          nil,
+		IsNullable:             "YES",
+		DataType:               "double precision",
+		CharacterMaximumLength: nil,
+	}, &columns[4])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "date_updated_utc",
+		OrdinalPosition:        6,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "timestamp without time zone",
+		CharacterMaximumLength: nil,
+	}, &columns[5])
+	// check indexes of bot_quotes table
+	indexes = database.GetTableIndexes(db, "bot_quotes")
+	assert.Equal(t, 1, len(indexes))
+	database.AssertIndex(t, "bot_quotes", "bot_quotes_pkey", "CREATE UNIQUE INDEX bot_quotes_pkey ON public.bot_quotes USING btree (bot_id)", indexes)
+
+	// check schema of level_stats table
+	columns = database.GetTableSchema(db, "level_stats")
+	assert.Equal(t, 11, len(columns), fmt.Sprintf("%v", columns))
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "account_id",
+		OrdinalPosition:        1,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "text",
+		CharacterMaximumLength: nil,
+	}, &columns[0])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "market_id",
+		OrdinalPosition:        2,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "text",
+		CharacterMaximumLength: nil,
+	}, &columns[1])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "date_utc",
+		OrdinalPosition:        3,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "date",
+		CharacterMaximumLength: nil,
+	}, &columns[2])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "side",
+		OrdinalPosition:        4,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "text",
+		CharacterMaximumLength: nil,
+	}, &columns[3])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "level",
+		OrdinalPosition:        5,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "integer",
+		CharacterMaximumLength: nil,
+	}, &columns[4])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "spread",
+		OrdinalPosition:        6,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "double precision",
+		CharacterMaximumLength: nil,
+	}, &columns[5])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "num_updates",
+		OrdinalPosition:        7,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "integer",
+		CharacterMaximumLength: nil,
+	}, &columns[6])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "num_fills",
+		OrdinalPosition:        8,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "integer",
+		CharacterMaximumLength: nil,
+	}, &columns[7])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "filled_base_volume",
+		OrdinalPosition:        9,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "double precision",
+		CharacterMaximumLength: nil,
+	}, &columns[8])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "filled_quote_volume",
+		OrdinalPosition:        10,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "double precision",
+		CharacterMaximumLength: nil,
+	}, &columns[9])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "spread_capture",
+		OrdinalPosition:        11,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "double precision",
+		CharacterMaximumLength: nil,
+	}, &columns[10])
+	// check indexes of level_stats table
+	indexes = database.GetTableIndexes(db, "level_stats")
+	assert.Equal(t, 1, len(indexes))
+	database.AssertIndex(t, "level_stats", "level_stats_pkey", "CREATE UNIQUE INDEX level_stats_pkey ON public.level_stats USING btree (account_id, market_id, date_utc, side, level)", indexes)
+
+	// check schema of order_traces table
+	columns = database.GetTableSchema(db, "order_traces")
+	assert.Equal(t, 10, len(columns), fmt.Sprintf("%v", columns))
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "account_id",
+		OrdinalPosition:        1,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "text",
+		CharacterMaximumLength: nil,
+	}, &columns[0])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "market_id",
+		OrdinalPosition:        2,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "text",
+		CharacterMaximumLength: nil,
+	}, &columns[1])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "correlation_id",
+		OrdinalPosition:        3,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "text",
+		CharacterMaximumLength: nil,
+	}, &columns[2])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "date_utc",
+		OrdinalPosition:        4,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "timestamp without time zone",
+		CharacterMaximumLength: nil,
+	}, &columns[3])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "stage",
+		OrdinalPosition:        5,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "text",
+		CharacterMaximumLength: nil,
+	}, &columns[4])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "side",
+		OrdinalPosition:        6,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "text",
+		CharacterMaximumLength: nil,
+	}, &columns[5])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "offer_id",
+		OrdinalPosition:        7,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "text",
+		CharacterMaximumLength: nil,
+	}, &columns[6])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "price",
+		OrdinalPosition:        8,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "double precision",
+		CharacterMaximumLength: nil,
+	}, &columns[7])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "amount",
+		OrdinalPosition:        9,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "double precision",
+		CharacterMaximumLength: nil,
+	}, &columns[8])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "detail",
+		OrdinalPosition:        10,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "text",
+		CharacterMaximumLength: nil,
+	}, &columns[9])
+	// check indexes of order_traces table
+	indexes = database.GetTableIndexes(db, "order_traces")
+	assert.Equal(t, 2, len(indexes))
+	database.AssertIndex(t, "order_traces", "order_traces_amcd", "CREATE INDEX order_traces_amcd ON public.order_traces USING btree (account_id, market_id, correlation_id, date_utc)", indexes)
+	database.AssertIndex(t, "order_traces", "order_traces_amo", "CREATE INDEX order_traces_amo ON public.order_traces USING btree (account_id, market_id, offer_id)", indexes)
+
+	// check schema of decision_records table
+	columns = database.GetTableSchema(db, "decision_records")
+	assert.Equal(t, 6, len(columns), fmt.Sprintf("%v", columns))
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "account_id",
+		OrdinalPosition:        1,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "text",
+		CharacterMaximumLength: nil,
+	}, &columns[0])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "market_id",
+		OrdinalPosition:        2,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "text",
+		CharacterMaximumLength: nil,
+	}, &columns[1])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "date_utc",
+		OrdinalPosition:        3,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "timestamp without time zone",
+		CharacterMaximumLength: nil,
+	}, &columns[2])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "outcome",
+		OrdinalPosition:        4,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "text",
+		CharacterMaximumLength: nil,
+	}, &columns[3])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "record",
+		OrdinalPosition:        5,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "text",
+		CharacterMaximumLength: nil,
+	}, &columns[4])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "seq",
+		OrdinalPosition:        6,
+		ColumnDefault:          "nextval('decision_records_seq_seq'::regclass)",
+		IsNullable:             "NO",
+		DataType:               "bigint",
+		CharacterMaximumLength: nil,
+	}, &columns[5])
+	// check indexes of decision_records table
+	indexes = database.GetTableIndexes(db, "decision_records")
+	assert.Equal(t, 1, len(indexes))
+	database.AssertIndex(t, "decision_records", "decision_records_pkey", "CREATE UNIQUE INDEX decision_records_pkey ON public.decision_records USING btree (account_id, market_id, date_utc, seq)", indexes)
+
+	// check schema of portfolio_budgets table
+	columns = database.GetTableSchema(db, "portfolio_budgets")
+	assert.Equal(t, 6, len(columns), fmt.Sprintf("%v", columns))
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "bot_id",
+		OrdinalPosition:        1,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "text",
+		CharacterMaximumLength: nil,
+	}, &columns[0])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "budget",
+		OrdinalPosition:        2,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "double precision",
+		CharacterMaximumLength: nil,
+	}, &columns[1])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "realized_pnl",
+		OrdinalPosition:        3,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "double precision",
+		CharacterMaximumLength: nil,
+	}, &columns[2])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "max_exposure_base",
+		OrdinalPosition:        4,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "double precision",
+		CharacterMaximumLength: nil,
+	}, &columns[3])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "max_daily_volume_quote",
+		OrdinalPosition:        5,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "double precision",
+		CharacterMaximumLength: nil,
+	}, &columns[4])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "date_updated_utc",
+		OrdinalPosition:        6,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "timestamp without time zone",
+		CharacterMaximumLength: nil,
+	}, &columns[5])
+	// check indexes of portfolio_budgets table
+	indexes = database.GetTableIndexes(db, "portfolio_budgets")
+	assert.Equal(t, 1, len(indexes))
+	database.AssertIndex(t, "portfolio_budgets", "portfolio_budgets_pkey", "CREATE UNIQUE INDEX portfolio_budgets_pkey ON public.portfolio_budgets USING btree (bot_id)", indexes)
+
+	// check schema of strategy_mirror_pending_offsets table
+	columns = database.GetTableSchema(db, "strategy_mirror_pending_offsets")
+	assert.Equal(t, 6, len(columns), fmt.Sprintf("%v", columns))
 	database.AssertTableColumnsEqual(t, &database.TableColumn{
 		ColumnName:             "market_id",
 		OrdinalPosition:        1,
@@ -236,7 +1014,7 @@ func TestTradeUpgradeScripts(t *testing.T) {
 		CharacterMaximumLength: nil,
 	}, &columns[1])
 	database.AssertTableColumnsEqual(t, &database.TableColumn{
-		ColumnName:             "backing_market_id",
+		ColumnName:             "action",
 		OrdinalPosition:        3,
 		ColumnDefault:          nil,
 		IsNullable:             "NO",
@@ -244,22 +1022,166 @@ func TestTradeUpgradeScripts(t *testing.T) {
 		CharacterMaximumLength: nil,
 	}, &columns[2])
 	database.AssertTableColumnsEqual(t, &database.TableColumn{
-		ColumnName:             "backing_order_id",
+		ColumnName:             "counter_price",
+		OrdinalPosition:        4,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "double precision",
+		CharacterMaximumLength: nil,
+	}, &columns[3])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "base_volume",
+		OrdinalPosition:        5,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "double precision",
+		CharacterMaximumLength: nil,
+	}, &columns[4])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "date_added_utc",
+		OrdinalPosition:        6,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "timestamp without time zone",
+		CharacterMaximumLength: nil,
+	}, &columns[5])
+	// check indexes of strategy_mirror_pending_offsets table
+	indexes = database.GetTableIndexes(db, "strategy_mirror_pending_offsets")
+	assert.Equal(t, 1, len(indexes))
+	database.AssertIndex(t, "strategy_mirror_pending_offsets", "strategy_mirror_pending_offsets_pkey", "CREATE UNIQUE INDEX strategy_mirror_pending_offsets_pkey ON public.strategy_mirror_pending_offsets USING btree (market_id, txid)", indexes)
+
+	// check schema of strategy_iceberg_fills table
+	columns = database.GetTableSchema(db, "strategy_iceberg_fills")
+	assert.Equal(t, 6, len(columns), fmt.Sprintf("%v", columns))
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "market_id",
+		OrdinalPosition:        1,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "text",
+		CharacterMaximumLength: nil,
+	}, &columns[0])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "txid",
+		OrdinalPosition:        2,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "text",
+		CharacterMaximumLength: nil,
+	}, &columns[1])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "side",
+		OrdinalPosition:        3,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "text",
+		CharacterMaximumLength: nil,
+	}, &columns[2])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "level",
+		OrdinalPosition:        4,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "integer",
+		CharacterMaximumLength: nil,
+	}, &columns[3])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "base_volume",
+		OrdinalPosition:        5,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "double precision",
+		CharacterMaximumLength: nil,
+	}, &columns[4])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "date_utc",
+		OrdinalPosition:        6,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "timestamp without time zone",
+		CharacterMaximumLength: nil,
+	}, &columns[5])
+	// check indexes of strategy_iceberg_fills table
+	indexes = database.GetTableIndexes(db, "strategy_iceberg_fills")
+	assert.Equal(t, 1, len(indexes))
+	database.AssertIndex(t, "strategy_iceberg_fills", "strategy_iceberg_fills_pkey", "CREATE UNIQUE INDEX strategy_iceberg_fills_pkey ON public.strategy_iceberg_fills USING btree (market_id, txid)", indexes)
+
+	// check schema of daily_trade_stats table
+	columns = database.GetTableSchema(db, "daily_trade_stats")
+	assert.Equal(t, 8, len(columns), fmt.Sprintf("%v", columns))
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "market_id",
+		OrdinalPosition:        1,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "text",
+		CharacterMaximumLength: nil,
+	}, &columns[0])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "account_id",
+		OrdinalPosition:        2,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "text",
+		CharacterMaximumLength: nil,
+	}, &columns[1])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "date_utc",
+		OrdinalPosition:        3,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "date",
+		CharacterMaximumLength: nil,
+	}, &columns[2])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "action",
 		OrdinalPosition:        4,
 		ColumnDefault:          nil,
 		IsNullable:             "NO",
 		DataType:               "text",
 		CharacterMaximumLength: nil,
 	}, &columns[3])
-	// check indexes of strategy_mirror_trade_triggers table
-	indexes = database.GetTableIndexes(db, "strategy_mirror_trade_triggers")
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "num_trades",
+		OrdinalPosition:        5,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "integer",
+		CharacterMaximumLength: nil,
+	}, &columns[4])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "base_volume",
+		OrdinalPosition:        6,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "double precision",
+		CharacterMaximumLength: nil,
+	}, &columns[5])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "counter_volume",
+		OrdinalPosition:        7,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "double precision",
+		CharacterMaximumLength: nil,
+	}, &columns[6])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "fee",
+		OrdinalPosition:        8,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "double precision",
+		CharacterMaximumLength: nil,
+	}, &columns[7])
+	// check indexes of daily_trade_stats table
+	indexes = database.GetTableIndexes(db, "daily_trade_stats")
 	assert.Equal(t, 1, len(indexes))
-	database.AssertIndex(t, "strategy_mirror_trade_triggers", "strategy_mirror_trade_triggers_pkey", "CREATE UNIQUE INDEX strategy_mirror_trade_triggers_pkey ON public.strategy_mirror_trade_triggers USING btree (market_id, txid)", indexes)
+	database.AssertIndex(t, "daily_trade_stats", "daily_trade_stats_pkey", "CREATE UNIQUE INDEX daily_trade_stats_pkey ON public.daily_trade_stats USING btree (market_id, account_id, date_utc, action)", indexes)
 
 	// check entries of db_version table
 	var allRows [][]interface{}
 	allRows = database.QueryAllRows(db, "db_version")
-	assert.Equal(t, 6, len(allRows))
+	assert.Equal(t, 23, len(allRows))
 	// first three code_version_string is nil becuase the field was not supported at the time when the upgrade script was run, and only in version 4 of
 	// the database do we add the field. See upgradeScripts and RunUpgradeScripts() for more details
 	database.ValidateDBVersionRow(t, allRows[0], 1, time.Now(), 1, 50, nil)
@@ -268,6 +1190,23 @@ func TestTradeUpgradeScripts(t *testing.T) {
 	database.ValidateDBVersionRow(t, allRows[3], 4, time.Now(), 1, 50, &codeVersionString)
 	database.ValidateDBVersionRow(t, allRows[4], 5, time.Now(), 2, 100, &codeVersionString)
 	database.ValidateDBVersionRow(t, allRows[5], 6, time.Now(), 2, 100, &codeVersionString)
+	database.ValidateDBVersionRow(t, allRows[6], 7, time.Now(), 1, 50, &codeVersionString)
+	database.ValidateDBVersionRow(t, allRows[7], 8, time.Now(), 1, 50, &codeVersionString)
+	database.ValidateDBVersionRow(t, allRows[8], 9, time.Now(), 3, 150, &codeVersionString)
+	database.ValidateDBVersionRow(t, allRows[9], 10, time.Now(), 4, 200, &codeVersionString)
+	database.ValidateDBVersionRow(t, allRows[10], 11, time.Now(), 1, 50, &codeVersionString)
+	database.ValidateDBVersionRow(t, allRows[11], 12, time.Now(), 1, 50, &codeVersionString)
+	database.ValidateDBVersionRow(t, allRows[12], 13, time.Now(), 1, 50, &codeVersionString)
+	database.ValidateDBVersionRow(t, allRows[13], 14, time.Now(), 3, 150, &codeVersionString)
+	database.ValidateDBVersionRow(t, allRows[14], 15, time.Now(), 1, 50, &codeVersionString)
+	database.ValidateDBVersionRow(t, allRows[15], 16, time.Now(), 1, 50, &codeVersionString)
+	database.ValidateDBVersionRow(t, allRows[16], 17, time.Now(), 1, 50, &codeVersionString)
+	database.ValidateDBVersionRow(t, allRows[17], 18, time.Now(), 1, 50, &codeVersionString)
+	database.ValidateDBVersionRow(t, allRows[18], 19, time.Now(), 1, 50, &codeVersionString)
+	database.ValidateDBVersionRow(t, allRows[19], 20, time.Now(), 1, 50, &codeVersionString)
+	database.ValidateDBVersionRow(t, allRows[20], 21, time.Now(), 1, 50, &codeVersionString)
+	database.ValidateDBVersionRow(t, allRows[21], 22, time.Now(), 2, 100, &codeVersionString)
+	database.ValidateDBVersionRow(t, allRows[22], 23, time.Now(), 1, 50, &codeVersionString)
 
 	// check entries of markets table
 	allRows = database.QueryAllRows(db, "markets")
@@ -280,4 +1219,56 @@ func TestTradeUpgradeScripts(t *testing.T) {
 	// check entries of strategy_mirror_trade_triggers table
 	allRows = database.QueryAllRows(db, "strategy_mirror_trade_triggers")
 	assert.Equal(t, 0, len(allRows))
+
+	// check entries of trailing_stop_marks table
+	allRows = database.QueryAllRows(db, "trailing_stop_marks")
+	assert.Equal(t, 0, len(allRows))
+
+	// check entries of strategy_mirror_netting_positions table
+	allRows = database.QueryAllRows(db, "strategy_mirror_netting_positions")
+	assert.Equal(t, 0, len(allRows))
+
+	// check entries of inventory_lots table
+	allRows = database.QueryAllRows(db, "inventory_lots")
+	assert.Equal(t, 0, len(allRows))
+
+	// check entries of inventory_lot_closures table
+	allRows = database.QueryAllRows(db, "inventory_lot_closures")
+	assert.Equal(t, 0, len(allRows))
+
+	// check entries of spread_obligation_samples table
+	allRows = database.QueryAllRows(db, "spread_obligation_samples")
+	assert.Equal(t, 0, len(allRows))
+
+	// check entries of bot_quotes table
+	allRows = database.QueryAllRows(db, "bot_quotes")
+	assert.Equal(t, 0, len(allRows))
+
+	// check entries of level_stats table
+	allRows = database.QueryAllRows(db, "level_stats")
+	assert.Equal(t, 0, len(allRows))
+
+	// check entries of order_traces table
+	allRows = database.QueryAllRows(db, "order_traces")
+	assert.Equal(t, 0, len(allRows))
+
+	// check entries of decision_records table
+	allRows = database.QueryAllRows(db, "decision_records")
+	assert.Equal(t, 0, len(allRows))
+
+	// check entries of portfolio_budgets table
+	allRows = database.QueryAllRows(db, "portfolio_budgets")
+	assert.Equal(t, 0, len(allRows))
+
+	// check entries of strategy_mirror_pending_offsets table
+	allRows = database.QueryAllRows(db, "strategy_mirror_pending_offsets")
+	assert.Equal(t, 0, len(allRows))
+
+	// check entries of strategy_iceberg_fills table
+	allRows = database.QueryAllRows(db, "strategy_iceberg_fills")
+	assert.Equal(t, 0, len(allRows))
+
+	// check entries of daily_trade_stats table
+	allRows = database.QueryAllRows(db, "daily_trade_stats")
+	assert.Equal(t, 0, len(allRows))
 }
@@ -8,6 +8,7 @@ require (
 	github.com/PagerDuty/go-pagerduty v0.0.0-20180821050606-635c5ce27149
 	github.com/adshao/go-binance/v2 v2.3.0
 	github.com/akavel/rsrc v0.9.0 // indirect
+	github.com/asaskevich/govalidator v0.0.0-20210307081110-f21760c49a8d
 	github.com/asticode/go-astilectron v0.8.1-0.20190411111508-8e68f812e8a2
 	github.com/asticode/go-astilectron-bootstrap v0.0.0-20190816065004-25b857285999
 	github.com/asticode/go-astilectron-bundler v0.0.0-20190426172205-155c2a10bbb1 // indirect
@@ -26,7 +27,7 @@ require (
 	github.com/google/go-querystring v1.0.1-0.20190318165438-c8c88dbee036 // indirect
 	github.com/google/uuid v1.2.0
 	github.com/gorilla/schema v1.1.1-0.20191101142538-61751c968743 // indirect
-	github.com/gorilla/websocket v1.4.3-0.20210424162022-e8629af678b7 // indirect
+	github.com/gorilla/websocket v1.4.3-0.20210424162022-e8629af678b7
 	github.com/hashicorp/hcl v1.0.1-0.20200422214639-569ae818ccb3 // indirect
 	github.com/julienschmidt/httprouter v1.3.1-0.20200114094804-8c9f31f047a3 // indirect
 	github.com/k0kubun/colorstring v0.0.0-20150214042306-9440f1994b88 // indirect
@@ -56,5 +57,7 @@ require (
 	github.com/stellar/go v0.0.0-20211007183021-ea18bbab9344
 	github.com/stretchr/testify v1.7.0
 	github.com/subosito/gotenv v1.2.1-0.20190917103637-de67a6614a4d // indirect
+	google.golang.org/grpc v1.33.2
+	google.golang.org/protobuf v1.25.0
 	gopkg.in/ini.v1 v1.55.0 // indirect
 )
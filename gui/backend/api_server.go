@@ -58,6 +58,10 @@ type APIServer struct {
 	metricsTracker       *plugins.MetricsTracker
 	kelpErrorsByUser     map[string]kelpErrorDataForUser
 	kelpErrorsByUserLock *sync.Mutex
+	jobs                 *jobManager
+	resourceMonitor      *resourceMonitor
+	restoredConfigsUsers map[string]bool
+	restoredConfigsLock  *sync.Mutex
 
 	cachedOptionsMetadata metadata
 	guiConfig			guiconfig.GUIConfig
@@ -105,6 +109,10 @@ func MakeAPIServer(
 		metricsTracker:        metricsTracker,
 		kelpErrorsByUser:      map[string]kelpErrorDataForUser{},
 		kelpErrorsByUserLock:  &sync.Mutex{},
+		jobs:                  makeJobManager(),
+		resourceMonitor:       makeResourceMonitor(guiConfig.ResourceLimits),
+		restoredConfigsUsers:  map[string]bool{},
+		restoredConfigsLock:   &sync.Mutex{},
 		guiConfig:			   guiConfig,
 	}, nil
 }
@@ -113,6 +121,10 @@ func (s *APIServer) botConfigsPathForUser(userID string) *kelpos.OSPath {
 	return s.botConfigsPath.Join(userID)
 }
 
+func (s *APIServer) archivedBotConfigsPathForUser(userID string) *kelpos.OSPath {
+	return s.botConfigsPathForUser(userID).Join(archivedBotsDirName)
+}
+
 func (s *APIServer) botLogsPathForUser(userID string) *kelpos.OSPath {
 	return s.botLogsPath.Join(userID)
 }
@@ -140,6 +152,8 @@ func (s *APIServer) kelpErrorsForUser(userID string) kelpErrorDataForUser {
 func (s *APIServer) InitBackend() error {
 	// do not do an initial load of bots into memory for now since it's based on the user context which we don't have right now
 	// and we don't want to do it for all users right now
+
+	go s.monitorResources()
 	return nil
 }
 
@@ -285,24 +299,22 @@ func (s *APIServer) writeJsonWithLog(w http.ResponseWriter, v interface{}, doLog
 	w.Write(marshalledJson)
 }
 
-func (s *APIServer) runKelpCommandBlocking(userID string, namespace string, cmd string) ([]byte, error) {
+func (s *APIServer) runKelpCommandBlocking(userID string, namespace string, args ...string) ([]byte, error) {
 	// There is a weird issue on windows where the absolute path for the kelp binary does not work on the release GUI
 	// version because of the unzipped directory name but it will work on the released cli version or if we change the
 	// name of the folder in which the GUI version is unzipped.
 	// To avoid these issues we only invoke with the binary name as opposed to the absolute path that contains the
 	// directory name. see start_bot.go for some experimentation with absolute and relative paths
-	cmdString := fmt.Sprintf("%s %s", s.kelpBinPath.Unix(), cmd)
-	return s.kos.Blocking(userID, namespace, cmdString)
+	return s.kos.Blocking(userID, namespace, kelpos.MakeCommand(kelpos.CommandPath(s.kelpBinPath), args...))
 }
 
-func (s *APIServer) runKelpCommandBackground(userID string, namespace string, cmd string) (*kelpos.Process, error) {
+func (s *APIServer) runKelpCommandBackground(userID string, namespace string, args ...string) (*kelpos.Process, error) {
 	// There is a weird issue on windows where the absolute path for the kelp binary does not work on the release GUI
 	// version because of the unzipped directory name but it will work on the released cli version or if we change the
 	// name of the folder in which the GUI version is unzipped.
 	// To avoid these issues we only invoke with the binary name as opposed to the absolute path that contains the
 	// directory name. see start_bot.go for some experimentation with absolute and relative paths
-	cmdString := fmt.Sprintf("%s %s", s.kelpBinPath.Unix(), cmd)
-	return s.kos.Background(userID, namespace, cmdString)
+	return s.kos.Background(userID, namespace, kelpos.MakeCommand(kelpos.CommandPath(s.kelpBinPath), args...))
 }
 
 func (s *APIServer) setupOpsDirectory(userID string) error {
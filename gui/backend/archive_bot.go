@@ -0,0 +1,236 @@
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/stellar/kelp/gui/model2"
+	"github.com/stellar/kelp/support/kelpos"
+)
+
+// archivedBotsDirName is the directory inside the configs directory of a user where the configs of archived bots are kept
+const archivedBotsDirName = "archived"
+
+// stopBotTimeout is how long we wait for a bot to stop before giving up on archiving it, a bot deletes its offers when it stops
+const stopBotTimeout = 2 * time.Minute
+
+type archiveBotRequest struct {
+	UserData UserData `json:"user_data"`
+	BotName  string   `json:"bot_name"`
+}
+
+// archiveBot stops a bot and moves its configs to the archive so it is hidden from the list of bots, unlike deleteBot nothing is removed
+// so the trade history of the bot can still be reported on and the bot can be restored later
+func (s *APIServer) archiveBot(w http.ResponseWriter, r *http.Request) {
+	req, e := readArchiveBotRequest(r)
+	if e != nil {
+		s.writeErrorJson(w, e.Error())
+		return
+	}
+
+	e = s.doArchiveBot(req.UserData, req.BotName)
+	if e != nil {
+		s.writeKelpError(req.UserData, w, makeKelpErrorResponseWrapper(
+			errorTypeBot,
+			req.BotName,
+			time.Now().UTC(),
+			errorLevelError,
+			fmt.Sprintf("could not archive bot: %s\n", e),
+		))
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *APIServer) doArchiveBot(userData UserData, botName string) error {
+	traderFilename := model2.GetBotFilenames(botName, buysell).Trader
+	if !fileExists(s.botConfigsPathForUser(userData.ID).Join(traderFilename)) {
+		return fmt.Errorf("there is no bot with the name '%s'", botName)
+	}
+	if fileExists(s.archivedBotConfigsPathForUser(userData.ID).Join(traderFilename)) {
+		return fmt.Errorf("there is already an archived bot with the name '%s', restore or rename it first", botName)
+	}
+
+	e := s.stopBotAndWait(userData, botName)
+	if e != nil {
+		return fmt.Errorf("could not stop bot: %s", e)
+	}
+	s.kos.BotDataForUser(userData.toUser()).SafeUnregisterBot(botName)
+
+	e = s.moveBotConfigs(userData.ID, botName, s.botConfigsPathForUser(userData.ID), s.archivedBotConfigsPathForUser(userData.ID))
+	if e != nil {
+		return e
+	}
+	s.saveBotConfigsToStore(userData.ID)
+	log.Printf("archived bot '%s'\n", botName)
+	return nil
+}
+
+// restoreBot moves the configs of an archived bot back to the list of bots, the bot is restored in the stopped state
+func (s *APIServer) restoreBot(w http.ResponseWriter, r *http.Request) {
+	req, e := readArchiveBotRequest(r)
+	if e != nil {
+		s.writeErrorJson(w, e.Error())
+		return
+	}
+
+	e = s.doRestoreBot(req.UserData, req.BotName)
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("could not restore bot '%s': %s", req.BotName, e))
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *APIServer) doRestoreBot(userData UserData, botName string) error {
+	traderFilename := model2.GetBotFilenames(botName, buysell).Trader
+	if !fileExists(s.archivedBotConfigsPathForUser(userData.ID).Join(traderFilename)) {
+		return fmt.Errorf("there is no archived bot with the name '%s'", botName)
+	}
+	if fileExists(s.botConfigsPathForUser(userData.ID).Join(traderFilename)) {
+		return fmt.Errorf("there is already a bot with the name '%s', delete or archive it first", botName)
+	}
+
+	e := s.moveBotConfigs(userData.ID, botName, s.archivedBotConfigsPathForUser(userData.ID), s.botConfigsPathForUser(userData.ID))
+	if e != nil {
+		return e
+	}
+	s.saveBotConfigsToStore(userData.ID)
+	// the bot is registered in the stopped state the next time the bots are listed
+	log.Printf("restored bot '%s'\n", botName)
+	return nil
+}
+
+type listArchivedBotsRequest struct {
+	UserData UserData `json:"user_data"`
+}
+
+func (s *APIServer) listArchivedBots(w http.ResponseWriter, r *http.Request) {
+	bodyBytes, e := ioutil.ReadAll(r.Body)
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("error when reading request input: %s\n", e))
+		return
+	}
+	var req listArchivedBotsRequest
+	e = json.Unmarshal(bodyBytes, &req)
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("error unmarshaling json: %s; bodyString = %s", e, string(bodyBytes)))
+		return
+	}
+	if strings.TrimSpace(req.UserData.ID) == "" {
+		s.writeErrorJson(w, fmt.Sprintf("cannot have empty userID"))
+		return
+	}
+
+	bots := []model2.Bot{}
+	archivePath := s.archivedBotConfigsPathForUser(req.UserData.ID)
+	if fileExists(archivePath) {
+		bots, e = s.listBotConfigs(req.UserData.ID, archivePath)
+		if e != nil {
+			s.writeErrorJson(w, fmt.Sprintf("error encountered while listing archived bots: %s", e))
+			return
+		}
+	}
+	s.writeJsonWithLog(w, bots, false)
+}
+
+func readArchiveBotRequest(r *http.Request) (*archiveBotRequest, error) {
+	bodyBytes, e := ioutil.ReadAll(r.Body)
+	if e != nil {
+		return nil, fmt.Errorf("error when reading request input: %s", e)
+	}
+	var req archiveBotRequest
+	e = json.Unmarshal(bodyBytes, &req)
+	if e != nil {
+		return nil, fmt.Errorf("error unmarshaling json: %s; bodyString = %s", e, string(bodyBytes))
+	}
+	if strings.TrimSpace(req.UserData.ID) == "" {
+		return nil, fmt.Errorf("cannot have empty userID")
+	}
+	if strings.TrimSpace(req.BotName) == "" {
+		return nil, fmt.Errorf("cannot have empty bot_name")
+	}
+	return &req, nil
+}
+
+// stopBotAndWait stops the bot if it is running and waits until it has stopped, returns an error if it has not stopped within stopBotTimeout
+func (s *APIServer) stopBotAndWait(userData UserData, botName string) error {
+	botState, e := s.doGetBotState(userData, botName)
+	if e != nil {
+		return fmt.Errorf("unable to get botState: %s", e)
+	}
+	if botState == kelpos.BotStateRunning {
+		e = s.doStopBot(userData, botName)
+		if e != nil {
+			return e
+		}
+	}
+
+	deadline := time.Now().Add(stopBotTimeout)
+	for {
+		botState, e := s.doGetBotState(userData, botName)
+		if e != nil {
+			return fmt.Errorf("unable to get botState: %s", e)
+		}
+		if botState == kelpos.BotStateStopped || botState == kelpos.BotStateInitializing {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("bot '%s' did not stop within %s, current botState: %s", botName, stopBotTimeout, botState)
+		}
+		log.Printf("waiting for bot '%s' to stop, current botState: %s\n", botName, botState)
+		time.Sleep(time.Second)
+	}
+}
+
+// moveBotConfigs moves the trader and strategy configs of a bot from one directory to another
+func (s *APIServer) moveBotConfigs(userID string, botName string, fromDir *kelpos.OSPath, toDir *kelpos.OSPath) error {
+	e := s.kos.Mkdir(userID, toDir)
+	if e != nil {
+		return fmt.Errorf("could not make directory (%s): %s", toDir.Native(), e)
+	}
+
+	// the "__" separator after the prefix keeps us from matching the configs of a bot whose name starts with the same words
+	filenames, e := listFilesWithPrefix(fromDir, model2.GetPrefix(botName)+"__")
+	if e != nil {
+		return fmt.Errorf("could not list bot configs: %s", e)
+	}
+	if len(filenames) == 0 {
+		return fmt.Errorf("no configs found for bot '%s' in directory (%s)", botName, fromDir.Native())
+	}
+	for _, filename := range filenames {
+		e = os.Rename(fromDir.Join(filename).Native(), toDir.Join(filename).Native())
+		if e != nil {
+			return fmt.Errorf("could not move bot config '%s': %s", filename, e)
+		}
+	}
+	return nil
+}
+
+// listFilesWithPrefix lists the names of the files in the directory that start with the prefix, sorted by name, the prefix is matched
+// as-is so a bot name is never interpreted as a glob pattern
+func listFilesWithPrefix(dirPath *kelpos.OSPath, prefix string) ([]string, error) {
+	infos, e := ioutil.ReadDir(dirPath.Native())
+	if e != nil {
+		return nil, fmt.Errorf("could not read directory (%s): %s", dirPath.Native(), e)
+	}
+
+	filenames := []string{}
+	for _, info := range infos {
+		if !info.IsDir() && strings.HasPrefix(info.Name(), prefix) {
+			filenames = append(filenames, info.Name())
+		}
+	}
+	return filenames, nil
+}
+
+func fileExists(path *kelpos.OSPath) bool {
+	_, e := os.Stat(path.Native())
+	return e == nil
+}
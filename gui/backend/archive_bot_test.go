@@ -0,0 +1,99 @@
+package backend
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stellar/kelp/gui/model2"
+	"github.com/stellar/kelp/support/kelpos"
+	"github.com/stretchr/testify/assert"
+)
+
+// makeTestArchiveServer makes an APIServer whose bot configs are in a temp dir, the caller needs to remove the dir
+func makeTestArchiveServer(t *testing.T) (*APIServer, string) {
+	tempDir, e := ioutil.TempDir("", "kelp_archive_bot")
+	if !assert.NoError(t, e) {
+		return nil, ""
+	}
+	base, e := kelpos.MakeOsPathBase()
+	if !assert.NoError(t, e) {
+		return nil, tempDir
+	}
+	botConfigsPath, e := base.MakeFromNativePath(tempDir)
+	if !assert.NoError(t, e) {
+		return nil, tempDir
+	}
+	return &APIServer{botConfigsPath: botConfigsPath, kos: &kelpos.KelpOS{}}, tempDir
+}
+
+func writeTestBotConfigs(t *testing.T, dirPath *kelpos.OSPath, botName string) {
+	if !assert.NoError(t, os.MkdirAll(dirPath.Native(), 0755)) {
+		return
+	}
+	filenames := model2.GetBotFilenames(botName, buysell)
+	for _, filename := range []string{filenames.Trader, filenames.Strategy} {
+		assert.NoError(t, ioutil.WriteFile(dirPath.Join(filename).Native(), []byte{}, 0644))
+	}
+}
+
+func TestArchiveAndRestoreBotConfigs(t *testing.T) {
+	s, tempDir := makeTestArchiveServer(t)
+	defer os.RemoveAll(tempDir)
+	if s == nil {
+		return
+	}
+	userData := UserData{ID: "user1"}
+	botsPath := s.botConfigsPathForUser(userData.ID)
+	archivePath := s.archivedBotConfigsPathForUser(userData.ID)
+	writeTestBotConfigs(t, botsPath, "Mary The Calm Whale")
+	// a bot whose name starts with the same words is not moved with it
+	writeTestBotConfigs(t, botsPath, "Mary The Calm Whale Two")
+
+	e := s.moveBotConfigs(userData.ID, "Mary The Calm Whale", botsPath, archivePath)
+	if !assert.NoError(t, e) {
+		return
+	}
+	filenames := model2.GetBotFilenames("Mary The Calm Whale", buysell)
+	assert.True(t, fileExists(archivePath.Join(filenames.Trader)))
+	assert.True(t, fileExists(archivePath.Join(filenames.Strategy)))
+	assert.False(t, fileExists(botsPath.Join(filenames.Trader)))
+	assert.True(t, fileExists(botsPath.Join(model2.GetBotFilenames("Mary The Calm Whale Two", buysell).Trader)))
+
+	// the archived bot cannot be restored over a bot with the same name
+	writeTestBotConfigs(t, botsPath, "Mary The Calm Whale")
+	assert.Error(t, s.doRestoreBot(userData, "Mary The Calm Whale"))
+	assert.NoError(t, os.Remove(botsPath.Join(filenames.Trader).Native()))
+	assert.NoError(t, os.Remove(botsPath.Join(filenames.Strategy).Native()))
+
+	e = s.doRestoreBot(userData, "Mary The Calm Whale")
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.True(t, fileExists(botsPath.Join(filenames.Trader)))
+	assert.False(t, fileExists(archivePath.Join(filenames.Trader)))
+
+	// there is nothing left to restore
+	assert.Error(t, s.doRestoreBot(userData, "Mary The Calm Whale"))
+}
+
+func TestGenerateBotNameSkipsArchivedBots(t *testing.T) {
+	s, tempDir := makeTestArchiveServer(t)
+	defer os.RemoveAll(tempDir)
+	if s == nil {
+		return
+	}
+	userData := UserData{ID: "user1"}
+	assert.NoError(t, os.MkdirAll(s.botConfigsPathForUser(userData.ID).Native(), 0755))
+
+	// the next first name is taken by an archived bot
+	takenName := names[idx_name]
+	writeTestBotConfigs(t, s.archivedBotConfigsPathForUser(userData.ID), takenName+" The Archived Bot")
+
+	botName, e := s.doGenerateBotName(userData)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.False(t, strings.HasPrefix(botName, takenName+" "), botName)
+}
@@ -89,7 +89,7 @@ func (s *APIServer) autogenerateBot(w http.ResponseWriter, r *http.Request) {
 	sampleBuysell := makeSampleBuysell()
 	strategyFilePath := s.botConfigsPathForUser(userID).Join(filenamePair.Strategy)
 	log.Printf("writing autogenerated strategy config to file: %s\n", strategyFilePath.AsString())
-	e = toml.WriteFile(strategyFilePath.Native(), sampleBuysell)
+	e = plugins.WriteStrategyConfig("buysell", strategyFilePath.Native(), sampleBuysell)
 	if e != nil {
 		s.writeKelpError(req.UserData, w, makeKelpErrorResponseWrapper(
 			errorTypeBot,
@@ -100,6 +100,7 @@ func (s *APIServer) autogenerateBot(w http.ResponseWriter, r *http.Request) {
 		))
 		return
 	}
+	s.saveBotConfigsToStore(userID)
 
 	// we only want to start initializing bot once it has been created, so we only advance state if everything is completed
 	go func() {
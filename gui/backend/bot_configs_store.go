@@ -0,0 +1,139 @@
+package backend
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/stellar/kelp/support/kelpos"
+	"github.com/stellar/kelp/support/statestore"
+)
+
+// botConfigsStoreKey is the first segment of the keys of the bot configs in the state store, the keys are
+// "bot_configs/<userID>/<filename>" and "bot_configs/<userID>/archived/<filename>" for archived bots
+const botConfigsStoreKey = "bot_configs"
+
+// botConfigsStore returns the state store where the bot configs are kept, or nil when the configs are only kept in the configs directory. The
+// configs are only copied to the state-db since the files of a local state store would be next to the configs directory anyway
+func (s *APIServer) botConfigsStore() statestore.Store {
+	if dbStore, isDB := s.kos.StateStore().(*statestore.DBStore); isDB {
+		return dbStore
+	}
+	return nil
+}
+
+// botConfigsDirs returns the directories of the configs of the user by the segment of their keys in the state store
+func (s *APIServer) botConfigsDirs(userID string) map[string]*kelpos.OSPath {
+	return map[string]*kelpos.OSPath{
+		fmt.Sprintf("%s/%s", botConfigsStoreKey, userID):                         s.botConfigsPathForUser(userID),
+		fmt.Sprintf("%s/%s/%s", botConfigsStoreKey, userID, archivedBotsDirName): s.archivedBotConfigsPathForUser(userID),
+	}
+}
+
+// saveBotConfigsToStore copies the config files of the user to the state store and removes the configs that were deleted, errors are only
+// logged since the files in the configs directory are the source of truth while the server is running
+func (s *APIServer) saveBotConfigsToStore(userID string) {
+	store := s.botConfigsStore()
+	if store == nil {
+		return
+	}
+
+	keys := map[string]bool{}
+	for prefix, dirPath := range s.botConfigsDirs(userID) {
+		// the directory of the archived bots only exists once a bot was archived
+		if !fileExists(dirPath) {
+			continue
+		}
+		filenames, e := listFilesWithPrefix(dirPath, "")
+		if e != nil {
+			log.Printf("unable to list the bot configs in '%s' to save them to the state store: %s\n", dirPath.AsString(), e)
+			return
+		}
+		for _, filename := range filenames {
+			if !strings.HasSuffix(filename, ".cfg") {
+				continue
+			}
+			key := prefix + "/" + filename
+			keys[key] = true
+			contents, e := ioutil.ReadFile(dirPath.Join(filename).Native())
+			if e != nil {
+				log.Printf("unable to read bot config '%s' to save it to the state store: %s\n", filename, e)
+				continue
+			}
+			e = store.Set(key, string(contents))
+			if e != nil {
+				log.Printf("unable to save bot config '%s' to the state store: %s\n", filename, e)
+			}
+		}
+	}
+
+	storedKeys, e := store.List(fmt.Sprintf("%s/%s", botConfigsStoreKey, userID))
+	if e != nil {
+		log.Printf("unable to list the bot configs in the state store: %s\n", e)
+		return
+	}
+	for _, key := range storedKeys {
+		if keys[key] {
+			continue
+		}
+		e = store.Delete(key)
+		if e != nil {
+			log.Printf("unable to delete bot config '%s' from the state store: %s\n", key, e)
+		}
+	}
+}
+
+// maybeRestoreBotConfigsFromStore writes the configs of the user from the state store to the configs directory once per run of the server,
+// so the bots are back after the server was restarted with an empty configs directory. Configs that exist in the directory are not replaced
+func (s *APIServer) maybeRestoreBotConfigsFromStore(userID string) error {
+	store := s.botConfigsStore()
+	if store == nil {
+		return nil
+	}
+	s.restoredConfigsLock.Lock()
+	defer s.restoredConfigsLock.Unlock()
+	if s.restoredConfigsUsers[userID] {
+		return nil
+	}
+
+	e := s.setupOpsDirectory(userID)
+	if e != nil {
+		return fmt.Errorf("error setting up the ops directory: %s", e)
+	}
+	for prefix, dirPath := range s.botConfigsDirs(userID) {
+		keys, e := store.List(prefix)
+		if e != nil {
+			return fmt.Errorf("unable to list the bot configs in the state store: %s", e)
+		}
+		for _, key := range keys {
+			filename := strings.TrimPrefix(key, prefix+"/")
+			// the keys of the archived configs are also under the prefix of the configs
+			if strings.Contains(filename, "/") {
+				continue
+			}
+			filePath := dirPath.Join(filename)
+			if fileExists(filePath) {
+				continue
+			}
+
+			contents, _, e := store.Get(key)
+			if e != nil {
+				return fmt.Errorf("unable to read bot config '%s' from the state store: %s", key, e)
+			}
+			e = os.MkdirAll(dirPath.Native(), 0755)
+			if e != nil {
+				return fmt.Errorf("unable to make the directory of bot config '%s': %s", filePath.AsString(), e)
+			}
+			e = ioutil.WriteFile(filePath.Native(), []byte(contents), 0644)
+			if e != nil {
+				return fmt.Errorf("unable to write bot config '%s': %s", filePath.AsString(), e)
+			}
+			log.Printf("restored bot config '%s' from the state store\n", filePath.AsString())
+		}
+	}
+
+	s.restoredConfigsUsers[userID] = true
+	return nil
+}
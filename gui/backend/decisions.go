@@ -0,0 +1,272 @@
+package backend
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stellar/kelp/queries"
+)
+
+// defaultDecisionsLimit is the number of decision records returned when the request does not set a limit
+const defaultDecisionsLimit = 20
+
+// maxDecisionsLimit is the max number of decision records returned by one request
+const maxDecisionsLimit = 500
+
+// streamDecisionsPollInterval is how often the db is polled for new decision records when streaming them
+const streamDecisionsPollInterval = 2 * time.Second
+
+type decisionsRequest struct {
+	UserData UserData `json:"user_data"`
+	BotName  string   `json:"bot_name"`
+	// After is an optional RFC3339 date, only the records written after it are returned, otherwise the latest records are returned
+	After string `json:"after"`
+	// AfterSeq is the optional seq of the last record that was received, it is needed to get the records written in the same instant as it,
+	// without it only the records written after the After date are returned
+	AfterSeq int64 `json:"after_seq"`
+	Limit    int   `json:"limit"`
+}
+
+// decisionsResponse is the response from the getDecisions request, records are sorted by date
+type decisionsResponse struct {
+	MarketID  string                      `json:"market_id"`
+	AccountID string                      `json:"account_id"`
+	Records   []queries.DecisionRecordRow `json:"records"`
+}
+
+// decisionsQuery is a parsed decisionsRequest
+type decisionsQuery struct {
+	marketID  string
+	accountID string
+	db        *sql.DB
+	query     *queries.DecisionRecordsQuery
+	after     time.Time
+	afterSeq  int64
+	limit     int
+}
+
+func (s *APIServer) getDecisions(w http.ResponseWriter, r *http.Request) {
+	dq, e := s.readDecisionsRequest(r)
+	if e != nil {
+		s.writeErrorJson(w, e.Error())
+		return
+	}
+	defer dq.db.Close()
+
+	records, e := dq.next()
+	if e != nil {
+		s.writeErrorJson(w, e.Error())
+		return
+	}
+	s.writeJsonWithLog(w, &decisionsResponse{
+		MarketID:  dq.marketID,
+		AccountID: dq.accountID,
+		Records:   records,
+	}, false)
+}
+
+// streamDecisions writes the decision records of a bot as server-sent events as they are written, starting with the records that match
+// the request, until the client disconnects. It is a GET request with the fields of the decisionsRequest as query params so it can be
+// opened with an EventSource, which resumes after the last event it received when it reconnects
+func (s *APIServer) streamDecisions(w http.ResponseWriter, r *http.Request) {
+	dq, e := s.readStreamDecisionsRequest(r)
+	if e != nil {
+		s.writeErrorJson(w, e.Error())
+		return
+	}
+	defer dq.db.Close()
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		s.writeErrorJson(w, "streaming is not supported by the connection")
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ticker := time.NewTicker(streamDecisionsPollInterval)
+	defer ticker.Stop()
+	for {
+		records, e := dq.next()
+		if e != nil {
+			log.Printf("error while streaming decision records for market '%s': %s\n", dq.marketID, e)
+			fmt.Fprintf(w, "event: error\ndata: %s\n\n", strings.ReplaceAll(e.Error(), "\n", " "))
+			flusher.Flush()
+			return
+		}
+		for _, record := range records {
+			recordBytes, e := json.Marshal(record)
+			if e != nil {
+				log.Printf("could not marshal decision record: %s\n", e)
+				continue
+			}
+			fmt.Fprintf(w, "id: %s\ndata: %s\n\n", decisionEventID(record), string(recordBytes))
+		}
+		if len(records) > 0 {
+			flusher.Flush()
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// readDecisionsRequest parses the request and opens the db of the bot, the caller needs to close the db
+func (s *APIServer) readDecisionsRequest(r *http.Request) (*decisionsQuery, error) {
+	bodyBytes, e := ioutil.ReadAll(r.Body)
+	if e != nil {
+		return nil, fmt.Errorf("error when reading request input: %s", e)
+	}
+	var req decisionsRequest
+	e = json.Unmarshal(bodyBytes, &req)
+	if e != nil {
+		return nil, fmt.Errorf("error unmarshaling json: %s; bodyString = %s", e, string(bodyBytes))
+	}
+	if strings.TrimSpace(req.UserData.ID) == "" {
+		return nil, fmt.Errorf("cannot have empty userID")
+	}
+
+	dq, e := s.makeDecisionsQuery(&req)
+	if e != nil {
+		return nil, fmt.Errorf("unable to get decisions for bot '%s': %s", req.BotName, e)
+	}
+	return dq, nil
+}
+
+// readStreamDecisionsRequest parses the query params of the streamDecisions request and opens the db of the bot, the caller needs to
+// close the db. The Last-Event-ID header that an EventSource sends when it reconnects takes the place of the after and after_seq params
+func (s *APIServer) readStreamDecisionsRequest(r *http.Request) (*decisionsQuery, error) {
+	params := r.URL.Query()
+	req := decisionsRequest{
+		UserData: UserData{ID: params.Get("user_id")},
+		BotName:  params.Get("bot_name"),
+		After:    params.Get("after"),
+	}
+	if strings.TrimSpace(req.UserData.ID) == "" {
+		return nil, fmt.Errorf("cannot have empty userID")
+	}
+
+	var e error
+	if v := params.Get("after_seq"); v != "" {
+		req.AfterSeq, e = strconv.ParseInt(v, 10, 64)
+		if e != nil {
+			return nil, fmt.Errorf("after_seq needs to be an integer: %s", e)
+		}
+	}
+	if v := params.Get("limit"); v != "" {
+		req.Limit, e = strconv.Atoi(v)
+		if e != nil {
+			return nil, fmt.Errorf("limit needs to be an integer: %s", e)
+		}
+	}
+	if lastEventID := r.Header.Get("Last-Event-ID"); lastEventID != "" {
+		req.After, req.AfterSeq, e = parseDecisionEventID(lastEventID)
+		if e != nil {
+			return nil, e
+		}
+	}
+
+	dq, e := s.makeDecisionsQuery(&req)
+	if e != nil {
+		return nil, fmt.Errorf("unable to get decisions for bot '%s': %s", req.BotName, e)
+	}
+	return dq, nil
+}
+
+// decisionEventID is the id of the server-sent event of a record, which is its date and seq
+func decisionEventID(record queries.DecisionRecordRow) string {
+	return fmt.Sprintf("%s,%d", record.DateUTC.Format(time.RFC3339Nano), record.Seq)
+}
+
+// parseDecisionEventID returns the date and seq of the id of a server-sent event that was made by decisionEventID
+func parseDecisionEventID(id string) (string, int64, error) {
+	parts := strings.Split(id, ",")
+	if len(parts) != 2 {
+		return "", 0, fmt.Errorf("invalid event id '%s', needs to be a date and a seq separated by a comma", id)
+	}
+	seq, e := strconv.ParseInt(parts[1], 10, 64)
+	if e != nil {
+		return "", 0, fmt.Errorf("invalid seq in event id '%s': %s", id, e)
+	}
+	return parts[0], seq, nil
+}
+
+func (s *APIServer) makeDecisionsQuery(req *decisionsRequest) (*decisionsQuery, error) {
+	var after time.Time
+	if strings.TrimSpace(req.After) != "" {
+		var e error
+		after, e = time.Parse(time.RFC3339Nano, strings.TrimSpace(req.After))
+		if e != nil {
+			return nil, fmt.Errorf("after needs to be an RFC3339 date: %s", e)
+		}
+	}
+	afterSeq := req.AfterSeq
+	if afterSeq == 0 {
+		// seqs start at 1 so this skips every record written in the instant of the after date
+		afterSeq = math.MaxInt64
+	}
+	limit := req.Limit
+	if limit == 0 {
+		limit = defaultDecisionsLimit
+	}
+	if limit < 0 || limit > maxDecisionsLimit {
+		return nil, fmt.Errorf("limit needs to be between 1 and %d but was %d", maxDecisionsLimit, limit)
+	}
+
+	botConfig, marketID, e := s.readBotConfigAndMarketID(req.UserData.ID, req.BotName)
+	if e != nil {
+		return nil, e
+	}
+	if botConfig.PostgresDbConfig == nil || !botConfig.ExplainDecisions {
+		return nil, fmt.Errorf("bot needs POSTGRES_DB and EXPLAIN_DECISIONS to be set in the trader config to explain decisions")
+	}
+	accountID := botConfig.DbOverrideAccountID
+
+	db, e := sql.Open("postgres", botConfig.PostgresDbConfig.MakeConnectString())
+	if e != nil {
+		return nil, fmt.Errorf("could not open database: %s", e)
+	}
+	query, e := queries.MakeDecisionRecordsQuery(db, accountID, marketID)
+	if e != nil {
+		db.Close()
+		return nil, fmt.Errorf("could not make DecisionRecords query: %s", e)
+	}
+
+	return &decisionsQuery{
+		marketID:  marketID,
+		accountID: accountID,
+		db:        db,
+		query:     query,
+		after:     after,
+		afterSeq:  afterSeq,
+		limit:     limit,
+	}, nil
+}
+
+// next returns the records after the last record that was returned, or the latest records on the first call without an after date
+func (dq *decisionsQuery) next() ([]queries.DecisionRecordRow, error) {
+	result, e := dq.query.QueryRow(dq.after, dq.afterSeq, dq.limit)
+	if e != nil {
+		return nil, fmt.Errorf("could not query decision records: %s", e)
+	}
+	records := result.([]queries.DecisionRecordRow)
+	if len(records) > 0 {
+		dq.after = records[len(records)-1].DateUTC
+		dq.afterSeq = records[len(records)-1].Seq
+	}
+	return records, nil
+}
@@ -0,0 +1,32 @@
+package backend
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/kelp/queries"
+)
+
+func TestDecisionEventID(t *testing.T) {
+	record := queries.DecisionRecordRow{
+		DateUTC: time.Date(2021, 1, 1, 0, 0, 0, 500, time.UTC),
+		Seq:     42,
+	}
+	id := decisionEventID(record)
+	assert.Equal(t, "2021-01-01T00:00:00.0000005Z,42", id)
+
+	// the id is sent back in the Last-Event-ID header when an EventSource reconnects
+	after, afterSeq, e := parseDecisionEventID(id)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, "2021-01-01T00:00:00.0000005Z", after)
+	assert.Equal(t, int64(42), afterSeq)
+
+	for _, invalid := range []string{"", "2021-01-01T00:00:00Z", "2021-01-01T00:00:00Z,", "2021-01-01T00:00:00Z,a", "a,1,2"} {
+		_, _, e = parseDecisionEventID(invalid)
+		assert.Error(t, e, invalid)
+	}
+}
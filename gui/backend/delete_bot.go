@@ -6,6 +6,7 @@ import (
 	"io/ioutil"
 	"log"
 	"net/http"
+	"os"
 	"strings"
 	"time"
 
@@ -89,19 +90,33 @@ func (s *APIServer) deleteBot(w http.ResponseWriter, r *http.Request) {
 
 	// delete configs
 	botPrefix := model2.GetPrefix(botName)
-	botConfigPath := s.botConfigsPathForUser(req.UserData.ID).Join(botPrefix)
-	_, e = s.kos.Blocking(req.UserData.ID, "rm", fmt.Sprintf("rm %s*", botConfigPath.Unix()))
+	configsDir := s.botConfigsPathForUser(req.UserData.ID)
+	filenames, e := listFilesWithPrefix(configsDir, botPrefix)
 	if e != nil {
 		s.writeKelpError(req.UserData, w, makeKelpErrorResponseWrapper(
 			errorTypeBot,
 			botName,
 			time.Now().UTC(),
 			errorLevelError,
-			fmt.Sprintf("could not run rm command for bot configs: %s\n", e),
+			fmt.Sprintf("could not list bot configs: %s\n", e),
 		))
 		return
 	}
+	for _, filename := range filenames {
+		e = os.Remove(configsDir.Join(filename).Native())
+		if e != nil {
+			s.writeKelpError(req.UserData, w, makeKelpErrorResponseWrapper(
+				errorTypeBot,
+				botName,
+				time.Now().UTC(),
+				errorLevelError,
+				fmt.Sprintf("could not remove bot config '%s': %s\n", filename, e),
+			))
+			return
+		}
+	}
 	log.Printf("removed bot configs for prefix '%s'\n", botPrefix)
+	s.saveBotConfigsToStore(req.UserData.ID)
 
 	w.WriteHeader(http.StatusOK)
 }
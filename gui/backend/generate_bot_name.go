@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/stellar/kelp/support/kelpos"
 	"github.com/stellar/kelp/support/utils"
 )
 
@@ -78,16 +79,22 @@ func (s *APIServer) doGenerateBotName(userData UserData) (string, error) {
 	return botName, nil
 }
 
+// prefixExists checks the configs of the bots and of the archived bots, so a new bot does not take the name of an archived bot which would
+// keep the archived bot from being restored
 func (s *APIServer) prefixExists(userData UserData, prefix string) (bool, error) {
-	command := fmt.Sprintf("ls %s | grep %s", s.botConfigsPathForUser(userData.ID).Unix(), prefix)
-	_, e := s.kos.Blocking(userData.ID, "prefix", command)
-	if e != nil {
-		if strings.Contains(e.Error(), "exit status 1") {
-			return false, nil
+	for _, dirPath := range []*kelpos.OSPath{s.botConfigsPathForUser(userData.ID), s.archivedBotConfigsPathForUser(userData.ID)} {
+		if !fileExists(dirPath) {
+			continue
+		}
+		filenames, e := listFilesWithPrefix(dirPath, prefix)
+		if e != nil {
+			return false, fmt.Errorf("error checking for prefix '%s': %s", prefix, e)
+		}
+		if len(filenames) > 0 {
+			return true, nil
 		}
-		return false, fmt.Errorf("error checking for prefix '%s': %s", prefix, e)
 	}
-	return true, nil
+	return false, nil
 }
 
 // https://www.ssa.gov/oact/babynames/decades/century.html
@@ -61,7 +61,7 @@ func (s *APIServer) getBotConfig(w http.ResponseWriter, r *http.Request) {
 	}
 	strategyFilePath := s.botConfigsPathForUser(req.UserData.ID).Join(filenamePair.Strategy)
 	var buysellConfig plugins.BuySellConfig
-	e = config.Read(strategyFilePath.Native(), &buysellConfig)
+	e = plugins.ReadStrategyConfig("buysell", strategyFilePath.Native(), &buysellConfig)
 	if e != nil {
 		s.writeKelpError(req.UserData, w, makeKelpErrorResponseWrapper(
 			errorTypeBot,
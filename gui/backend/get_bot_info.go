@@ -15,6 +15,7 @@ import (
 	"github.com/stellar/go/support/config"
 	"github.com/stellar/kelp/gui/model2"
 	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/support/assetdisplay"
 	"github.com/stellar/kelp/support/kelpos"
 	"github.com/stellar/kelp/support/utils"
 	"github.com/stellar/kelp/trader"
@@ -33,10 +34,14 @@ type botInfo struct {
 	AssetQuote     hProtocol.Asset    `json:"asset_quote"`
 	BalanceBase    float64            `json:"balance_base"`
 	BalanceQuote   float64            `json:"balance_quote"`
-	NumBids        int                `json:"num_bids"`
-	NumAsks        int                `json:"num_asks"`
-	SpreadValue    float64            `json:"spread_value"`
-	SpreadPercent  float64            `json:"spread_pct"`
+	// DisplayDecimalsBase and DisplayDecimalsQuote come from the stellar.toml of the issuers, see assetdisplay.Load. The balances are
+	// not rounded to them so the frontend can decide how to display them
+	DisplayDecimalsBase  int     `json:"display_decimals_base"`
+	DisplayDecimalsQuote int     `json:"display_decimals_quote"`
+	NumBids              int     `json:"num_bids"`
+	NumAsks              int     `json:"num_asks"`
+	SpreadValue          float64 `json:"spread_value"`
+	SpreadPercent        float64 `json:"spread_pct"`
 }
 
 type getBotInfoRequest struct {
@@ -68,48 +73,58 @@ func (s *APIServer) getBotInfo(w http.ResponseWriter, r *http.Request) {
 func (s *APIServer) runGetBotInfoDirect(w http.ResponseWriter, userData UserData, botName string) {
 	log.Printf("getBotInfo is invoking logic directly for botName: %s\n", botName)
 
-	botState, e := s.doGetBotState(userData, botName)
+	bi, e := s.doGetBotInfo(userData, botName)
 	if e != nil {
 		s.writeKelpError(userData, w, makeKelpErrorResponseWrapper(
 			errorTypeBot,
 			botName,
 			time.Now().UTC(),
 			errorLevelError,
-			fmt.Sprintf("cannot read bot state for bot '%s': %s\n", botName, e),
+			fmt.Sprintf("%s\n", e),
 		))
 		return
 	}
-	if botState == kelpos.BotStateInitializing {
+	if bi == nil {
 		log.Printf("bot state is initializing for bot '%s'\n", botName)
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("{}"))
 		return
 	}
 
+	marshalledJSON, e := json.MarshalIndent(bi, "", "  ")
+	if e != nil {
+		log.Printf("cannot marshall to json response (error=%s), botInfo: %+v\n", e, bi)
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte("{}"))
+		return
+	}
+	marshalledJSONString := string(marshalledJSON)
+	log.Printf("getBotInfo returned direct response for botName '%s': %s\n", botName, marshalledJSONString)
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(marshalledJSON)
+}
+
+// doGetBotInfo returns nil and no error when the bot is still initializing
+func (s *APIServer) doGetBotInfo(userData UserData, botName string) (*botInfo, error) {
+	botState, e := s.doGetBotState(userData, botName)
+	if e != nil {
+		return nil, fmt.Errorf("cannot read bot state for bot '%s': %s", botName, e)
+	}
+	if botState == kelpos.BotStateInitializing {
+		return nil, nil
+	}
+
 	filenamePair := model2.GetBotFilenames(botName, buysell)
 	traderFilePath := s.botConfigsPathForUser(userData.ID).Join(filenamePair.Trader)
 	var botConfig trader.BotConfig
 	e = config.Read(traderFilePath.Native(), &botConfig)
 	if e != nil {
-		s.writeKelpError(userData, w, makeKelpErrorResponseWrapper(
-			errorTypeBot,
-			botName,
-			time.Now().UTC(),
-			errorLevelError,
-			fmt.Sprintf("cannot read bot config at path '%s': %s\n", traderFilePath.AsString(), e),
-		))
-		return
+		return nil, fmt.Errorf("cannot read bot config at path '%s': %s", traderFilePath.AsString(), e)
 	}
 	e = botConfig.Init()
 	if e != nil {
-		s.writeKelpError(userData, w, makeKelpErrorResponseWrapper(
-			errorTypeBot,
-			botName,
-			time.Now().UTC(),
-			errorLevelError,
-			fmt.Sprintf("cannot init bot config at path '%s': %s\n", traderFilePath.AsString(), e),
-		))
-		return
+		return nil, fmt.Errorf("cannot init bot config at path '%s': %s", traderFilePath.AsString(), e)
 	}
 
 	assetBase := botConfig.AssetBase()
@@ -126,78 +141,36 @@ func (s *APIServer) runGetBotInfoDirect(w http.ResponseWriter, userData UserData
 
 	account, e := client.AccountDetail(horizonclient.AccountRequest{AccountID: botConfig.TradingAccount()})
 	if e != nil {
-		s.writeKelpError(userData, w, makeKelpErrorResponseWrapper(
-			errorTypeBot,
-			botName,
-			time.Now().UTC(),
-			errorLevelError,
-			fmt.Sprintf("cannot get account data for account '%s' for botName '%s': %s\n", botConfig.TradingAccount(), botName, e),
-		))
-		return
+		return nil, fmt.Errorf("cannot get account data for account '%s' for botName '%s': %s", botConfig.TradingAccount(), botName, e)
 	}
 	var balanceBase float64
 	if assetBase == utils.NativeAsset {
 		balanceBase, e = getNativeBalance(account)
 		if e != nil {
-			s.writeKelpError(userData, w, makeKelpErrorResponseWrapper(
-				errorTypeBot,
-				botName,
-				time.Now().UTC(),
-				errorLevelError,
-				fmt.Sprintf("error getting native balanceBase for account '%s' for botName '%s': %s\n", botConfig.TradingAccount(), botName, e),
-			))
-			return
+			return nil, fmt.Errorf("error getting native balanceBase for account '%s' for botName '%s': %s", botConfig.TradingAccount(), botName, e)
 		}
 	} else {
 		balanceBase, e = getCreditBalance(account, assetBase)
 		if e != nil {
-			s.writeKelpError(userData, w, makeKelpErrorResponseWrapper(
-				errorTypeBot,
-				botName,
-				time.Now().UTC(),
-				errorLevelError,
-				fmt.Sprintf("error getting credit balanceBase for account '%s' for botName '%s': %s\n", botConfig.TradingAccount(), botName, e),
-			))
-			return
+			return nil, fmt.Errorf("error getting credit balanceBase for account '%s' for botName '%s': %s", botConfig.TradingAccount(), botName, e)
 		}
 	}
 	var balanceQuote float64
 	if assetQuote == utils.NativeAsset {
 		balanceQuote, e = getNativeBalance(account)
 		if e != nil {
-			s.writeKelpError(userData, w, makeKelpErrorResponseWrapper(
-				errorTypeBot,
-				botName,
-				time.Now().UTC(),
-				errorLevelError,
-				fmt.Sprintf("error getting native balanceQuote for account '%s' for botName '%s': %s\n", botConfig.TradingAccount(), botName, e),
-			))
-			return
+			return nil, fmt.Errorf("error getting native balanceQuote for account '%s' for botName '%s': %s", botConfig.TradingAccount(), botName, e)
 		}
 	} else {
 		balanceQuote, e = getCreditBalance(account, assetQuote)
 		if e != nil {
-			s.writeKelpError(userData, w, makeKelpErrorResponseWrapper(
-				errorTypeBot,
-				botName,
-				time.Now().UTC(),
-				errorLevelError,
-				fmt.Sprintf("error getting credit balanceQuote for account '%s' for botName '%s': %s\n", botConfig.TradingAccount(), botName, e),
-			))
-			return
+			return nil, fmt.Errorf("error getting credit balanceQuote for account '%s' for botName '%s': %s", botConfig.TradingAccount(), botName, e)
 		}
 	}
 
 	offers, e := utils.LoadAllOffers(account.AccountID, client)
 	if e != nil {
-		s.writeKelpError(userData, w, makeKelpErrorResponseWrapper(
-			errorTypeBot,
-			botName,
-			time.Now().UTC(),
-			errorLevelError,
-			fmt.Sprintf("error getting offers for account '%s' for botName '%s': %s\n", botConfig.TradingAccount(), botName, e),
-		))
-		return
+		return nil, fmt.Errorf("error getting offers for account '%s' for botName '%s': %s", botConfig.TradingAccount(), botName, e)
 	}
 	sellingAOffers, buyingAOffers := utils.FilterOffers(offers, assetBase, assetQuote)
 	numBids := len(buyingAOffers)
@@ -213,14 +186,7 @@ func (s *APIServer) runGetBotInfoDirect(w http.ResponseWriter, userData UserData
 		Limit:              1,
 	})
 	if e != nil {
-		s.writeKelpError(userData, w, makeKelpErrorResponseWrapper(
-			errorTypeBot,
-			botName,
-			time.Now().UTC(),
-			errorLevelError,
-			fmt.Sprintf("error getting orderbook for assets (base=%v, quote=%v) for botName '%s': %s\n", assetBase, assetQuote, botName, e),
-		))
-		return
+		return nil, fmt.Errorf("error getting orderbook for assets (base=%v, quote=%v) for botName '%s': %s", assetBase, assetQuote, botName, e)
 	}
 	spread := -1.0
 	spreadPct := -1.0
@@ -233,34 +199,26 @@ func (s *APIServer) runGetBotInfoDirect(w http.ResponseWriter, userData UserData
 		spreadPct = 100.0 * spread / midPrice
 	}
 
-	bi := botInfo{
-		LastUpdated:    time.Now().UTC().Format("1/_2/2006 15:04:05 MST"),
-		TradingAccount: account.AccountID,
-		Strategy:       buysell,
-		IsTestnet:      strings.Contains(botConfig.HorizonURL, "test"),
-		TradingPair:    tradingPair,
-		AssetBase:      assetBase,
-		AssetQuote:     assetQuote,
-		BalanceBase:    balanceBase,
-		BalanceQuote:   balanceQuote,
-		NumBids:        numBids,
-		NumAsks:        numAsks,
-		SpreadValue:    model.NumberFromFloat(spread, 8).AsFloat(),
-		SpreadPercent:  model.NumberFromFloat(spreadPct, 8).AsFloat(),
-	}
-
-	marshalledJSON, e := json.MarshalIndent(bi, "", "  ")
-	if e != nil {
-		log.Printf("cannot marshall to json response (error=%s), botInfo: %+v\n", e, bi)
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte("{}"))
-		return
-	}
-	marshalledJSONString := string(marshalledJSON)
-	log.Printf("getBotInfo returned direct response for botName '%s': %s\n", botName, marshalledJSONString)
-
-	w.WriteHeader(http.StatusOK)
-	w.Write(marshalledJSON)
+	displayDecimalsBase := assetdisplay.Load(client, assetBase).DisplayDecimals
+	displayDecimalsQuote := assetdisplay.Load(client, assetQuote).DisplayDecimals
+
+	return &botInfo{
+		LastUpdated:          time.Now().UTC().Format("1/_2/2006 15:04:05 MST"),
+		TradingAccount:       account.AccountID,
+		Strategy:             buysell,
+		IsTestnet:            strings.Contains(botConfig.HorizonURL, "test"),
+		TradingPair:          tradingPair,
+		AssetBase:            assetBase,
+		AssetQuote:           assetQuote,
+		BalanceBase:          balanceBase,
+		BalanceQuote:         balanceQuote,
+		DisplayDecimalsBase:  displayDecimalsBase,
+		DisplayDecimalsQuote: displayDecimalsQuote,
+		NumBids:              numBids,
+		NumAsks:              numAsks,
+		SpreadValue:          model.NumberFromFloat(spread, 8).AsFloat(),
+		SpreadPercent:        model.NumberFromFloat(spreadPct, 8).AsFloat(),
+	}, nil
 }
 
 func getNativeBalance(account hProtocol.Account) (float64, error) {
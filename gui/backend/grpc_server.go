@@ -0,0 +1,258 @@
+package backend
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/kelp/gui/backend/kelprpc"
+	"github.com/stellar/kelp/queries"
+	"github.com/stellar/kelp/support/kelpos"
+)
+
+// defaultTradesPollInterval is how often the db is checked for new trades when the StreamTrades request does not set an interval
+const defaultTradesPollInterval = 5 * time.Second
+
+// tradesPageSize is the max number of trades that are read from the db in one query when streaming trades
+const tradesPageSize = 100
+
+// grpcServer serves the gRPC API by delegating to the same logic as the REST API
+type grpcServer struct {
+	kelprpc.UnimplementedKelpServer
+	s *APIServer
+}
+
+var _ kelprpc.KelpServer = &grpcServer{}
+
+// MakeGRPCServer makes a grpc.Server that serves the gRPC API of the APIServer, TLS is enabled when both the cert and key files are set.
+// The gRPC API does not support auth0 so it cannot be used when auth0 is enabled in the GUI config
+func MakeGRPCServer(s *APIServer, tlsCertFile string, tlsKeyFile string) (*grpc.Server, error) {
+	if s.guiConfig.Auth0Config != nil && s.guiConfig.Auth0Config.Auth0Enabled {
+		return nil, fmt.Errorf("the gRPC API cannot be used when auth0 is enabled in the GUI config")
+	}
+
+	opts := []grpc.ServerOption{}
+	if tlsCertFile != "" && tlsKeyFile != "" {
+		creds, e := credentials.NewServerTLSFromFile(tlsCertFile, tlsKeyFile)
+		if e != nil {
+			return nil, fmt.Errorf("unable to load TLS credentials for the gRPC server: %s", e)
+		}
+		opts = append(opts, grpc.Creds(creds))
+	}
+
+	grpcS := grpc.NewServer(opts...)
+	kelprpc.RegisterKelpServer(grpcS, &grpcServer{s: s})
+	return grpcS, nil
+}
+
+// userData validates the user_id of a request in the same way as the REST API does
+func (g *grpcServer) userData(userID string) (UserData, error) {
+	if strings.TrimSpace(userID) == "" {
+		return UserData{}, status.Error(codes.InvalidArgument, "cannot have empty userID")
+	}
+	return UserData{ID: userID}, nil
+}
+
+// ListBots impl.
+func (g *grpcServer) ListBots(ctx context.Context, req *kelprpc.ListBotsRequest) (*kelprpc.ListBotsResponse, error) {
+	userData, e := g.userData(req.UserId)
+	if e != nil {
+		return nil, e
+	}
+
+	bots, e := g.s.doListBots(userData)
+	if e != nil {
+		return nil, status.Errorf(codes.Internal, "error encountered while listing bots: %s", e)
+	}
+
+	resp := &kelprpc.ListBotsResponse{}
+	for _, b := range bots {
+		resp.Bots = append(resp.Bots, &kelprpc.Bot{
+			Name:     b.Name,
+			Strategy: b.Strategy,
+			Running:  b.Running,
+			Test:     b.Test,
+			Warnings: uint32(b.Warnings),
+			Errors:   uint32(b.Errors),
+		})
+	}
+	return resp, nil
+}
+
+// StartBot impl.
+func (g *grpcServer) StartBot(ctx context.Context, req *kelprpc.BotRequest) (*kelprpc.StartBotResponse, error) {
+	userData, e := g.userData(req.UserId)
+	if e != nil {
+		return nil, e
+	}
+
+	e = g.s.doStartBot(userData, req.BotName, buysell, nil, nil)
+	if e != nil {
+		return nil, status.Errorf(codes.Internal, "error starting bot: %s", e)
+	}
+
+	e = g.s.kos.BotDataForUser(userData.toUser()).AdvanceBotState(req.BotName, kelpos.BotStateStopped)
+	if e != nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "error advancing bot state: %s", e)
+	}
+	return &kelprpc.StartBotResponse{}, nil
+}
+
+// StopBot impl.
+func (g *grpcServer) StopBot(ctx context.Context, req *kelprpc.BotRequest) (*kelprpc.StopBotResponse, error) {
+	userData, e := g.userData(req.UserId)
+	if e != nil {
+		return nil, e
+	}
+
+	e = g.s.doStopBot(userData, req.BotName)
+	if e != nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "unable to stop bot: %s", e)
+	}
+	return &kelprpc.StopBotResponse{}, nil
+}
+
+// GetBotState impl.
+func (g *grpcServer) GetBotState(ctx context.Context, req *kelprpc.BotRequest) (*kelprpc.GetBotStateResponse, error) {
+	userData, e := g.userData(req.UserId)
+	if e != nil {
+		return nil, e
+	}
+
+	state, e := g.s.doGetBotState(userData, req.BotName)
+	if e != nil {
+		return nil, status.Errorf(codes.NotFound, "unable to get bot state: %s", e)
+	}
+	return &kelprpc.GetBotStateResponse{State: toRPCBotState(state)}, nil
+}
+
+// toRPCBotState converts the bot state, the values of the enum in kelp.proto match the order of the kelpos.BotState constants
+func toRPCBotState(state kelpos.BotState) kelprpc.BotState {
+	return kelprpc.BotState(state)
+}
+
+// GetBotInfo impl.
+func (g *grpcServer) GetBotInfo(ctx context.Context, req *kelprpc.BotRequest) (*kelprpc.GetBotInfoResponse, error) {
+	userData, e := g.userData(req.UserId)
+	if e != nil {
+		return nil, e
+	}
+
+	bi, e := g.s.doGetBotInfo(userData, req.BotName)
+	if e != nil {
+		return nil, status.Error(codes.Internal, e.Error())
+	}
+	if bi == nil {
+		return &kelprpc.GetBotInfoResponse{Initializing: true}, nil
+	}
+
+	return &kelprpc.GetBotInfoResponse{
+		LastUpdated:          bi.LastUpdated,
+		TradingAccount:       bi.TradingAccount,
+		Strategy:             bi.Strategy,
+		IsTestnet:            bi.IsTestnet,
+		AssetBase:            toRPCAsset(bi.AssetBase),
+		AssetQuote:           toRPCAsset(bi.AssetQuote),
+		BalanceBase:          bi.BalanceBase,
+		BalanceQuote:         bi.BalanceQuote,
+		DisplayDecimalsBase:  int32(bi.DisplayDecimalsBase),
+		DisplayDecimalsQuote: int32(bi.DisplayDecimalsQuote),
+		NumBids:              int32(bi.NumBids),
+		NumAsks:              int32(bi.NumAsks),
+		SpreadValue:          bi.SpreadValue,
+		SpreadPct:            bi.SpreadPercent,
+	}, nil
+}
+
+func toRPCAsset(asset hProtocol.Asset) *kelprpc.Asset {
+	return &kelprpc.Asset{
+		AssetType:   asset.Type,
+		AssetCode:   asset.Code,
+		AssetIssuer: asset.Issuer,
+	}
+}
+
+// StreamTrades impl. polls the trades table of the bot since the bot runs in a separate process and only shares its trades through the db
+func (g *grpcServer) StreamTrades(req *kelprpc.StreamTradesRequest, stream kelprpc.Kelp_StreamTradesServer) error {
+	userData, e := g.userData(req.UserId)
+	if e != nil {
+		return e
+	}
+
+	botConfig, marketID, e := g.s.readBotConfigAndMarketID(userData.ID, req.BotName)
+	if e != nil {
+		return status.Error(codes.NotFound, e.Error())
+	}
+	if botConfig.PostgresDbConfig == nil {
+		return status.Errorf(codes.FailedPrecondition, "bot needs POSTGRES_DB to be set in the trader config to stream trades")
+	}
+
+	db, e := sql.Open("postgres", botConfig.PostgresDbConfig.MakeConnectString())
+	if e != nil {
+		return status.Errorf(codes.Internal, "could not open database: %s", e)
+	}
+	defer db.Close()
+
+	tradesQuery, e := queries.MakeTradesAfter(db, botConfig.DbOverrideAccountID, marketID)
+	if e != nil {
+		return status.Errorf(codes.Internal, "could not make TradesAfter query: %s", e)
+	}
+
+	pollInterval := defaultTradesPollInterval
+	if req.PollIntervalMillis > 0 {
+		pollInterval = time.Duration(req.PollIntervalMillis) * time.Millisecond
+	}
+	afterDate := time.Unix(0, 0)
+	if req.AfterDateUnixMillis > 0 {
+		afterDate = time.Unix(0, req.AfterDateUnixMillis*int64(time.Millisecond))
+	}
+	afterTxID := req.AfterTxid
+	log.Printf("streaming trades over gRPC for bot '%s' (marketID=%s) after (%s, '%s')\n", req.BotName, marketID, afterDate.UTC().Format(time.RFC3339), afterTxID)
+
+	for {
+		tradesResult, e := tradesQuery.QueryRow(afterDate, afterTxID, tradesPageSize)
+		if e != nil {
+			return status.Errorf(codes.Internal, "could not query trades: %s", e)
+		}
+		trades := tradesResult.([]queries.TradeRecord)
+
+		for _, t := range trades {
+			e = stream.Send(&kelprpc.Trade{
+				Txid:           t.TxID,
+				DateUnixMillis: t.DateUTC.UnixNano() / int64(time.Millisecond),
+				Action:         t.Action,
+				Type:           t.Type,
+				CounterPrice:   t.CounterPrice,
+				BaseVolume:     t.BaseVolume,
+				CounterCost:    t.CounterCost,
+				Fee:            t.Fee,
+				OrderId:        t.OrderID,
+			})
+			if e != nil {
+				return e
+			}
+			afterDate = t.DateUTC
+			afterTxID = t.TxID
+		}
+
+		// read the next page right away when this page was full, otherwise wait for new trades
+		if len(trades) == tradesPageSize {
+			continue
+		}
+		select {
+		case <-stream.Context().Done():
+			log.Printf("stopped streaming trades over gRPC for bot '%s': %s\n", req.BotName, stream.Context().Err())
+			return nil
+		case <-time.After(pollInterval):
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package backend
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/kelp/gui/backend/kelprpc"
+	"github.com/stellar/kelp/support/kelpos"
+)
+
+func TestToRPCBotState(t *testing.T) {
+	testCases := []struct {
+		state kelpos.BotState
+		want  kelprpc.BotState
+	}{
+		{kelpos.BotStateInitializing, kelprpc.BotState_BOT_STATE_INITIALIZING},
+		{kelpos.BotStateStopped, kelprpc.BotState_BOT_STATE_STOPPED},
+		{kelpos.BotStateRunning, kelprpc.BotState_BOT_STATE_RUNNING},
+		{kelpos.BotStateStopping, kelprpc.BotState_BOT_STATE_STOPPING},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.state.String(), func(t *testing.T) {
+			assert.Equal(t, k.want, toRPCBotState(k.state))
+		})
+	}
+}
@@ -0,0 +1,256 @@
+package backend
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stellar/go/support/config"
+	"github.com/stellar/kelp/gui/model2"
+	"github.com/stellar/kelp/plugins"
+	"github.com/stellar/kelp/queries"
+	"github.com/stellar/kelp/support/assetdisplay"
+	"github.com/stellar/kelp/support/utils"
+	"github.com/stellar/kelp/trader"
+)
+
+type inventoryLotsRequest struct {
+	UserData UserData `json:"user_data"`
+	BotName  string   `json:"bot_name"`
+	// StartDate and EndDate are RFC3339 timestamps that limit the closures to the range [StartDate, EndDate), both are optional
+	StartDate string `json:"start_date"`
+	EndDate   string `json:"end_date"`
+}
+
+// inventoryLotsResponse is the response from the getInventoryLots request
+type inventoryLotsResponse struct {
+	MarketID         string                        `json:"market_id"`
+	AccountID        string                        `json:"account_id"`
+	OpenLots         []queries.InventoryLot        `json:"open_lots"`
+	OpenBaseVolume   float64                       `json:"open_base_volume"` // net inventory, negative when short
+	OpenCostBasis    float64                       `json:"open_cost_basis"`  // volume-weighted price of the open lots, 0 when there are no open lots
+	Closures         []queries.InventoryLotClosure `json:"closures"`
+	TotalRealizedPnL float64                       `json:"total_realized_pnl"`
+	// DisplayDecimalsBase is used for volumes and DisplayDecimalsQuote for amounts in the quote asset, prices are not rounded
+	DisplayDecimalsBase  int `json:"display_decimals_base"`
+	DisplayDecimalsQuote int `json:"display_decimals_quote"`
+}
+
+// nonSdexDisplayDecimals is the precision used for assets on centralized exchanges, which do not have a stellar.toml
+const nonSdexDisplayDecimals = 8
+
+func (s *APIServer) getInventoryLots(w http.ResponseWriter, r *http.Request) {
+	req, e := s.readInventoryLotsRequest(r)
+	if e != nil {
+		s.writeErrorJson(w, e.Error())
+		return
+	}
+
+	resp, e := s.doGetInventoryLots(req)
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("unable to get inventory lots for bot '%s': %s", req.BotName, e))
+		return
+	}
+	s.writeJsonWithLog(w, resp, false)
+}
+
+// exportInventoryLotClosures writes the closures as CSV, with one row per closed part of a lot, which can be used for tax reporting
+func (s *APIServer) exportInventoryLotClosures(w http.ResponseWriter, r *http.Request) {
+	req, e := s.readInventoryLotsRequest(r)
+	if e != nil {
+		s.writeErrorJson(w, e.Error())
+		return
+	}
+
+	resp, e := s.doGetInventoryLots(req)
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("unable to get inventory lots for bot '%s': %s", req.BotName, e))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s_inventory_lot_closures.csv\"", req.BotName))
+	w.WriteHeader(http.StatusOK)
+	e = writeInventoryLotClosuresCSV(w, resp.Closures, resp.DisplayDecimalsBase, resp.DisplayDecimalsQuote)
+	if e != nil {
+		// the header was already written so we can only log the error here
+		log.Printf("error while writing inventory lot closures as CSV for bot '%s': %s\n", req.BotName, e)
+	}
+}
+
+func (s *APIServer) readInventoryLotsRequest(r *http.Request) (*inventoryLotsRequest, error) {
+	bodyBytes, e := ioutil.ReadAll(r.Body)
+	if e != nil {
+		return nil, fmt.Errorf("error when reading request input: %s", e)
+	}
+	var req inventoryLotsRequest
+	e = json.Unmarshal(bodyBytes, &req)
+	if e != nil {
+		return nil, fmt.Errorf("error unmarshaling json: %s; bodyString = %s", e, string(bodyBytes))
+	}
+	if strings.TrimSpace(req.UserData.ID) == "" {
+		return nil, fmt.Errorf("cannot have empty userID")
+	}
+	return &req, nil
+}
+
+func (s *APIServer) doGetInventoryLots(req *inventoryLotsRequest) (*inventoryLotsResponse, error) {
+	start := time.Unix(0, 0)
+	if req.StartDate != "" {
+		t, e := time.Parse(time.RFC3339, req.StartDate)
+		if e != nil {
+			return nil, fmt.Errorf("invalid start_date '%s': %s", req.StartDate, e)
+		}
+		start = t
+	}
+	end := time.Now()
+	if req.EndDate != "" {
+		t, e := time.Parse(time.RFC3339, req.EndDate)
+		if e != nil {
+			return nil, fmt.Errorf("invalid end_date '%s': %s", req.EndDate, e)
+		}
+		end = t
+	}
+
+	botConfig, marketID, e := s.readBotConfigAndMarketID(req.UserData.ID, req.BotName)
+	if e != nil {
+		return nil, e
+	}
+	if botConfig.PostgresDbConfig == nil || botConfig.InventoryLotMethod == "" {
+		return nil, fmt.Errorf("bot needs POSTGRES_DB and INVENTORY_LOT_METHOD to be set in the trader config to track inventory lots")
+	}
+	accountID := botConfig.DbOverrideAccountID
+
+	db, e := sql.Open("postgres", botConfig.PostgresDbConfig.MakeConnectString())
+	if e != nil {
+		return nil, fmt.Errorf("could not open database: %s", e)
+	}
+	defer db.Close()
+
+	openLotsQuery, e := queries.MakeInventoryOpenLots(db, accountID, marketID)
+	if e != nil {
+		return nil, fmt.Errorf("could not make InventoryOpenLots query: %s", e)
+	}
+	openLotsResult, e := openLotsQuery.QueryRow()
+	if e != nil {
+		return nil, fmt.Errorf("could not query open lots: %s", e)
+	}
+	openLots := openLotsResult.([]queries.InventoryLot)
+
+	closuresQuery, e := queries.MakeInventoryLotClosures(db, accountID, marketID)
+	if e != nil {
+		return nil, fmt.Errorf("could not make InventoryLotClosures query: %s", e)
+	}
+	closuresResult, e := closuresQuery.QueryRow(start, end)
+	if e != nil {
+		return nil, fmt.Errorf("could not query lot closures: %s", e)
+	}
+	closures := closuresResult.([]queries.InventoryLotClosure)
+
+	resp := &inventoryLotsResponse{
+		MarketID:             marketID,
+		AccountID:            accountID,
+		OpenLots:             openLots,
+		Closures:             closures,
+		DisplayDecimalsBase:  nonSdexDisplayDecimals,
+		DisplayDecimalsQuote: nonSdexDisplayDecimals,
+	}
+	if botConfig.IsTradingSdex() {
+		client := s.apiPubNet
+		if strings.Contains(botConfig.HorizonURL, "test") {
+			client = s.apiTestNet
+		}
+		resp.DisplayDecimalsBase = assetdisplay.Load(client, botConfig.AssetBase()).DisplayDecimals
+		resp.DisplayDecimalsQuote = assetdisplay.Load(client, botConfig.AssetQuote()).DisplayDecimals
+	}
+	openCost := 0.0
+	openVolume := 0.0
+	for _, lot := range openLots {
+		openCost += lot.Price * lot.RemainingBaseVolume
+		openVolume += lot.RemainingBaseVolume
+		if lot.Side == queries.InventoryLotSideShort {
+			resp.OpenBaseVolume -= lot.RemainingBaseVolume
+		} else {
+			resp.OpenBaseVolume += lot.RemainingBaseVolume
+		}
+	}
+	if openVolume > 0 {
+		resp.OpenCostBasis = openCost / openVolume
+	}
+	for _, c := range closures {
+		resp.TotalRealizedPnL += c.RealizedPnL
+	}
+	return resp, nil
+}
+
+// readBotConfigAndMarketID reads the trader config of a bot and identifies its market in the same way as the bot does it when writing to the db,
+// the config of an archived bot is read when there is no active bot with the name so archived bots can still be reported on
+func (s *APIServer) readBotConfigAndMarketID(userID string, botName string) (*trader.BotConfig, string, error) {
+	filenamePair := model2.GetBotFilenames(botName, buysell)
+	traderFilePath := s.botConfigsPathForUser(userID).Join(filenamePair.Trader)
+	if archivedFilePath := s.archivedBotConfigsPathForUser(userID).Join(filenamePair.Trader); !fileExists(traderFilePath) && fileExists(archivedFilePath) {
+		traderFilePath = archivedFilePath
+	}
+	var botConfig trader.BotConfig
+	e := config.Read(traderFilePath.Native(), &botConfig)
+	if e != nil {
+		return nil, "", fmt.Errorf("cannot read bot config at path '%s': %s", traderFilePath.AsString(), e)
+	}
+	e = botConfig.Init()
+	if e != nil {
+		return nil, "", fmt.Errorf("cannot init bot config at path '%s': %s", traderFilePath.AsString(), e)
+	}
+
+	baseString := utils.Asset2CodeString(botConfig.AssetBase())
+	quoteString := utils.Asset2CodeString(botConfig.AssetQuote())
+	if botConfig.IsTradingSdex() {
+		baseString = utils.Asset2String(botConfig.AssetBase())
+		quoteString = utils.Asset2String(botConfig.AssetQuote())
+	}
+	marketID := plugins.MakeMarketID(botConfig.TradingExchangeName(), baseString, quoteString)
+	return &botConfig, marketID, nil
+}
+
+// writeInventoryLotClosuresCSV writes the closures with the acquisition cost and proceeds of each one, for a short lot the proceeds come
+// from the sale that opened the lot and the cost comes from the purchase that closed it
+func writeInventoryLotClosuresCSV(w http.ResponseWriter, closures []queries.InventoryLotClosure, baseDecimals int, quoteDecimals int) error {
+	csvWriter := csv.NewWriter(w)
+	e := csvWriter.Write([]string{"date_opened_utc", "date_closed_utc", "side", "lot_txid", "closing_txid", "base_volume", "open_price", "close_price", "cost", "proceeds", "realized_pnl"})
+	if e != nil {
+		return e
+	}
+
+	for _, c := range closures {
+		cost := c.OpenPrice * c.BaseVolume
+		proceeds := c.ClosePrice * c.BaseVolume
+		if c.Side == queries.InventoryLotSideShort {
+			cost, proceeds = proceeds, cost
+		}
+		e = csvWriter.Write([]string{
+			c.DateOpenedUTC.Format(time.RFC3339),
+			c.DateClosedUTC.Format(time.RFC3339),
+			c.Side.String(),
+			c.LotTxID,
+			c.ClosingTxID,
+			strconv.FormatFloat(c.BaseVolume, 'f', baseDecimals, 64),
+			// prices are written with full precision since the display decimals of the quote asset can round a small price down to 0
+			strconv.FormatFloat(c.OpenPrice, 'f', -1, 64),
+			strconv.FormatFloat(c.ClosePrice, 'f', -1, 64),
+			strconv.FormatFloat(cost, 'f', quoteDecimals, 64),
+			strconv.FormatFloat(proceeds, 'f', quoteDecimals, 64),
+			strconv.FormatFloat(c.RealizedPnL, 'f', quoteDecimals, 64),
+		})
+		if e != nil {
+			return e
+		}
+	}
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
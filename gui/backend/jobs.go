@@ -0,0 +1,244 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+)
+
+// finishedJobRetention is how long finished jobs are kept around so the GUI can read their final state
+const finishedJobRetention = 1 * time.Hour
+
+// JobStatus is the status of a job
+type JobStatus string
+
+// these are the statuses of a job
+const (
+	JobStatusRunning   JobStatus = "running"
+	JobStatusSucceeded JobStatus = "succeeded"
+	JobStatusFailed    JobStatus = "failed"
+	JobStatusCancelled JobStatus = "cancelled"
+)
+
+// JobProgressFn is used by a job to report its progress as a fraction in the range [0.0, 1.0] along with a message describing the current step
+type JobProgressFn func(progress float64, message string)
+
+// JobFn runs a long-running operation, it should stop and return ctx.Err() as soon as possible once ctx is done
+type JobFn func(ctx context.Context, reportProgress JobProgressFn) error
+
+// Job is a long-running operation that runs in the background so requests don't hang until the timeout middleware kicks in
+type Job struct {
+	ID         string     `json:"id"`
+	Type       string     `json:"type"`
+	Status     JobStatus  `json:"status"`
+	Progress   float64    `json:"progress"`
+	Message    string     `json:"message"`
+	Error      string     `json:"error,omitempty"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+
+	cancel context.CancelFunc
+}
+
+func (j *Job) isFinished() bool {
+	return j.Status != JobStatusRunning
+}
+
+// jobManager keeps track of the registered job types and the jobs that were started from them
+type jobManager struct {
+	lock     *sync.Mutex
+	jobTypes map[string]JobFn
+	jobs     map[string]*Job
+}
+
+func makeJobManager() *jobManager {
+	return &jobManager{
+		lock:     &sync.Mutex{},
+		jobTypes: map[string]JobFn{},
+		jobs:     map[string]*Job{},
+	}
+}
+
+func (m *jobManager) register(jobType string, fn JobFn) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if _, ok := m.jobTypes[jobType]; ok {
+		return fmt.Errorf("job type '%s' is already registered", jobType)
+	}
+	m.jobTypes[jobType] = fn
+	return nil
+}
+
+// start runs a new job of the given type in the background and returns a snapshot of it
+func (m *jobManager) start(jobType string) (Job, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	fn, ok := m.jobTypes[jobType]
+	if !ok {
+		return Job{}, fmt.Errorf("unknown job type '%s'", jobType)
+	}
+	m.pruneFinished(time.Now())
+
+	id, e := uuid.NewRandom()
+	if e != nil {
+		return Job{}, fmt.Errorf("unable to generate job id: %s", e)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	job := &Job{
+		ID:        id.String(),
+		Type:      jobType,
+		Status:    JobStatusRunning,
+		StartedAt: time.Now().UTC(),
+		cancel:    cancel,
+	}
+	m.jobs[job.ID] = job
+
+	go m.run(ctx, job, fn)
+	log.Printf("started job '%s' of type '%s'\n", job.ID, jobType)
+	return *job, nil
+}
+
+func (m *jobManager) run(ctx context.Context, job *Job, fn JobFn) {
+	e := fn(ctx, func(progress float64, message string) {
+		m.lock.Lock()
+		defer m.lock.Unlock()
+
+		job.Progress = progress
+		job.Message = message
+	})
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	finishedAt := time.Now().UTC()
+	job.FinishedAt = &finishedAt
+	if ctx.Err() == context.Canceled {
+		job.Status = JobStatusCancelled
+	} else if e != nil {
+		job.Status = JobStatusFailed
+		job.Error = e.Error()
+	} else {
+		job.Status = JobStatusSucceeded
+		job.Progress = 1.0
+	}
+	job.cancel()
+	log.Printf("job '%s' of type '%s' finished with status '%s' (error=%v)\n", job.ID, job.Type, job.Status, e)
+}
+
+// pruneFinished deletes finished jobs older than finishedJobRetention, needs to be called while holding the lock
+func (m *jobManager) pruneFinished(now time.Time) {
+	for id, job := range m.jobs {
+		if job.isFinished() && now.Sub(*job.FinishedAt) > finishedJobRetention {
+			delete(m.jobs, id)
+		}
+	}
+}
+
+func (m *jobManager) get(id string) (Job, bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	job, ok := m.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+	return *job, true
+}
+
+// list returns a snapshot of all the jobs, most recently started first
+func (m *jobManager) list() []Job {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	jobs := []Job{}
+	for _, job := range m.jobs {
+		jobs = append(jobs, *job)
+	}
+	sort.Slice(jobs, func(i int, j int) bool {
+		return jobs[i].StartedAt.After(jobs[j].StartedAt)
+	})
+	return jobs
+}
+
+// cancel requests the job to stop, the job is marked as cancelled once its JobFn returns
+func (m *jobManager) cancel(id string) (Job, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	job, ok := m.jobs[id]
+	if !ok {
+		return Job{}, fmt.Errorf("job '%s' does not exist", id)
+	}
+	if job.isFinished() {
+		return Job{}, fmt.Errorf("job '%s' has already finished with status '%s'", id, job.Status)
+	}
+	job.cancel()
+	return *job, nil
+}
+
+// RegisterJobType registers a long-running operation that can be started via the /jobs endpoints
+func (s *APIServer) RegisterJobType(jobType string, fn JobFn) error {
+	return s.jobs.register(jobType, fn)
+}
+
+type startJobRequest struct {
+	Type string `json:"type"`
+}
+
+func (s *APIServer) startJob(w http.ResponseWriter, r *http.Request) {
+	bodyBytes, e := ioutil.ReadAll(r.Body)
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("error reading request input: %s", e))
+		return
+	}
+
+	var req startJobRequest
+	e = json.Unmarshal(bodyBytes, &req)
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("error unmarshaling json: %s; bodyString = %s", e, string(bodyBytes)))
+		return
+	}
+
+	job, e := s.jobs.start(req.Type)
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("unable to start job: %s", e))
+		return
+	}
+	s.writeJson(w, job)
+}
+
+func (s *APIServer) listJobs(w http.ResponseWriter, r *http.Request) {
+	// progress is polled frequently so don't log the response
+	s.writeJsonWithLog(w, s.jobs.list(), false)
+}
+
+func (s *APIServer) getJob(w http.ResponseWriter, r *http.Request) {
+	jobID := chi.URLParam(r, "jobID")
+	job, ok := s.jobs.get(jobID)
+	if !ok {
+		s.writeErrorJson(w, fmt.Sprintf("job '%s' does not exist", jobID))
+		return
+	}
+	// progress is polled frequently so don't log the response
+	s.writeJsonWithLog(w, job, false)
+}
+
+func (s *APIServer) cancelJob(w http.ResponseWriter, r *http.Request) {
+	job, e := s.jobs.cancel(chi.URLParam(r, "jobID"))
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("unable to cancel job: %s", e))
+		return
+	}
+	s.writeJson(w, job)
+}
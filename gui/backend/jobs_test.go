@@ -0,0 +1,71 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func waitForJob(m *jobManager, id string) Job {
+	for i := 0; i < 100; i++ {
+		job, _ := m.get(id)
+		if job.isFinished() {
+			return job
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	job, _ := m.get(id)
+	return job
+}
+
+func TestJobManager(t *testing.T) {
+	m := makeJobManager()
+	assert.NoError(t, m.register("succeed", func(ctx context.Context, reportProgress JobProgressFn) error {
+		reportProgress(0.5, "halfway")
+		return nil
+	}))
+	assert.NoError(t, m.register("fail", func(ctx context.Context, reportProgress JobProgressFn) error {
+		return fmt.Errorf("something went wrong")
+	}))
+	assert.NoError(t, m.register("block", func(ctx context.Context, reportProgress JobProgressFn) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}))
+	assert.Error(t, m.register("succeed", nil))
+
+	_, e := m.start("unknown")
+	assert.Error(t, e)
+
+	job, e := m.start("succeed")
+	if !assert.NoError(t, e) {
+		return
+	}
+	job = waitForJob(m, job.ID)
+	assert.Equal(t, JobStatusSucceeded, job.Status)
+	assert.Equal(t, 1.0, job.Progress)
+	assert.Equal(t, "halfway", job.Message)
+	_, e = m.cancel(job.ID)
+	assert.Error(t, e)
+
+	job, e = m.start("fail")
+	if !assert.NoError(t, e) {
+		return
+	}
+	job = waitForJob(m, job.ID)
+	assert.Equal(t, JobStatusFailed, job.Status)
+	assert.Equal(t, "something went wrong", job.Error)
+
+	job, e = m.start("block")
+	if !assert.NoError(t, e) {
+		return
+	}
+	_, e = m.cancel(job.ID)
+	assert.NoError(t, e)
+	job = waitForJob(m, job.ID)
+	assert.Equal(t, JobStatusCancelled, job.Status)
+
+	assert.Equal(t, 3, len(m.list()))
+}
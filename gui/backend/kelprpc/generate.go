@@ -0,0 +1,8 @@
+// Package kelprpc contains the gRPC API of the Kelp GUI server, see kelp.proto.
+//
+// The *.pb.go files in this package are generated from kelp.proto and checked in, so building Kelp does not need protoc. After changing
+// kelp.proto regenerate them with `./scripts/build.sh --gen-grpc`, which installs protoc-gen-go v1.27.1 and protoc-gen-go-grpc v1.2.0 and
+// needs protoc on the PATH.
+package kelprpc
+
+//go:generate protoc -I . --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative kelp.proto
@@ -0,0 +1,1209 @@
+// kelp.proto defines the gRPC API of the Kelp GUI server, which mirrors the REST API under /api/v1 for programmatic control of bots.
+//
+// The Go code in this package is generated from this file and checked in, regenerate it with `./scripts/build.sh --gen-grpc` after changing
+// this file.
+// Clients for other languages can be generated from this file with protoc, for example a python client:
+//
+//   python -m grpc_tools.protoc -I gui/backend/kelprpc --python_out=. --grpc_python_out=. gui/backend/kelprpc/kelp.proto
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.27.1
+// 	protoc        (unknown)
+// source: kelp.proto
+
+package kelprpc
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type BotState int32
+
+const (
+	BotState_BOT_STATE_INITIALIZING BotState = 0
+	BotState_BOT_STATE_STOPPED      BotState = 1
+	BotState_BOT_STATE_RUNNING      BotState = 2
+	BotState_BOT_STATE_STOPPING     BotState = 3
+)
+
+// Enum value maps for BotState.
+var (
+	BotState_name = map[int32]string{
+		0: "BOT_STATE_INITIALIZING",
+		1: "BOT_STATE_STOPPED",
+		2: "BOT_STATE_RUNNING",
+		3: "BOT_STATE_STOPPING",
+	}
+	BotState_value = map[string]int32{
+		"BOT_STATE_INITIALIZING": 0,
+		"BOT_STATE_STOPPED":      1,
+		"BOT_STATE_RUNNING":      2,
+		"BOT_STATE_STOPPING":     3,
+	}
+)
+
+func (x BotState) Enum() *BotState {
+	p := new(BotState)
+	*p = x
+	return p
+}
+
+func (x BotState) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (BotState) Descriptor() protoreflect.EnumDescriptor {
+	return file_kelp_proto_enumTypes[0].Descriptor()
+}
+
+func (BotState) Type() protoreflect.EnumType {
+	return &file_kelp_proto_enumTypes[0]
+}
+
+func (x BotState) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use BotState.Descriptor instead.
+func (BotState) EnumDescriptor() ([]byte, []int) {
+	return file_kelp_proto_rawDescGZIP(), []int{0}
+}
+
+type ListBotsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	UserId string `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+}
+
+func (x *ListBotsRequest) Reset() {
+	*x = ListBotsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_kelp_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListBotsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListBotsRequest) ProtoMessage() {}
+
+func (x *ListBotsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_kelp_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListBotsRequest.ProtoReflect.Descriptor instead.
+func (*ListBotsRequest) Descriptor() ([]byte, []int) {
+	return file_kelp_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ListBotsRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+type Bot struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Name     string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Strategy string `protobuf:"bytes,2,opt,name=strategy,proto3" json:"strategy,omitempty"`
+	Running  bool   `protobuf:"varint,3,opt,name=running,proto3" json:"running,omitempty"`
+	Test     bool   `protobuf:"varint,4,opt,name=test,proto3" json:"test,omitempty"`
+	Warnings uint32 `protobuf:"varint,5,opt,name=warnings,proto3" json:"warnings,omitempty"`
+	Errors   uint32 `protobuf:"varint,6,opt,name=errors,proto3" json:"errors,omitempty"`
+}
+
+func (x *Bot) Reset() {
+	*x = Bot{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_kelp_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Bot) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Bot) ProtoMessage() {}
+
+func (x *Bot) ProtoReflect() protoreflect.Message {
+	mi := &file_kelp_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Bot.ProtoReflect.Descriptor instead.
+func (*Bot) Descriptor() ([]byte, []int) {
+	return file_kelp_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Bot) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Bot) GetStrategy() string {
+	if x != nil {
+		return x.Strategy
+	}
+	return ""
+}
+
+func (x *Bot) GetRunning() bool {
+	if x != nil {
+		return x.Running
+	}
+	return false
+}
+
+func (x *Bot) GetTest() bool {
+	if x != nil {
+		return x.Test
+	}
+	return false
+}
+
+func (x *Bot) GetWarnings() uint32 {
+	if x != nil {
+		return x.Warnings
+	}
+	return 0
+}
+
+func (x *Bot) GetErrors() uint32 {
+	if x != nil {
+		return x.Errors
+	}
+	return 0
+}
+
+type ListBotsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Bots []*Bot `protobuf:"bytes,1,rep,name=bots,proto3" json:"bots,omitempty"`
+}
+
+func (x *ListBotsResponse) Reset() {
+	*x = ListBotsResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_kelp_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListBotsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListBotsResponse) ProtoMessage() {}
+
+func (x *ListBotsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_kelp_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListBotsResponse.ProtoReflect.Descriptor instead.
+func (*ListBotsResponse) Descriptor() ([]byte, []int) {
+	return file_kelp_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ListBotsResponse) GetBots() []*Bot {
+	if x != nil {
+		return x.Bots
+	}
+	return nil
+}
+
+type BotRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	UserId  string `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	BotName string `protobuf:"bytes,2,opt,name=bot_name,json=botName,proto3" json:"bot_name,omitempty"`
+}
+
+func (x *BotRequest) Reset() {
+	*x = BotRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_kelp_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BotRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BotRequest) ProtoMessage() {}
+
+func (x *BotRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_kelp_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BotRequest.ProtoReflect.Descriptor instead.
+func (*BotRequest) Descriptor() ([]byte, []int) {
+	return file_kelp_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *BotRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *BotRequest) GetBotName() string {
+	if x != nil {
+		return x.BotName
+	}
+	return ""
+}
+
+type StartBotResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *StartBotResponse) Reset() {
+	*x = StartBotResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_kelp_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StartBotResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StartBotResponse) ProtoMessage() {}
+
+func (x *StartBotResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_kelp_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StartBotResponse.ProtoReflect.Descriptor instead.
+func (*StartBotResponse) Descriptor() ([]byte, []int) {
+	return file_kelp_proto_rawDescGZIP(), []int{4}
+}
+
+type StopBotResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *StopBotResponse) Reset() {
+	*x = StopBotResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_kelp_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StopBotResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StopBotResponse) ProtoMessage() {}
+
+func (x *StopBotResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_kelp_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StopBotResponse.ProtoReflect.Descriptor instead.
+func (*StopBotResponse) Descriptor() ([]byte, []int) {
+	return file_kelp_proto_rawDescGZIP(), []int{5}
+}
+
+type GetBotStateResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	State BotState `protobuf:"varint,1,opt,name=state,proto3,enum=kelp.v1.BotState" json:"state,omitempty"`
+}
+
+func (x *GetBotStateResponse) Reset() {
+	*x = GetBotStateResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_kelp_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetBotStateResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetBotStateResponse) ProtoMessage() {}
+
+func (x *GetBotStateResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_kelp_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetBotStateResponse.ProtoReflect.Descriptor instead.
+func (*GetBotStateResponse) Descriptor() ([]byte, []int) {
+	return file_kelp_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *GetBotStateResponse) GetState() BotState {
+	if x != nil {
+		return x.State
+	}
+	return BotState_BOT_STATE_INITIALIZING
+}
+
+type Asset struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	AssetType   string `protobuf:"bytes,1,opt,name=asset_type,json=assetType,proto3" json:"asset_type,omitempty"`
+	AssetCode   string `protobuf:"bytes,2,opt,name=asset_code,json=assetCode,proto3" json:"asset_code,omitempty"`
+	AssetIssuer string `protobuf:"bytes,3,opt,name=asset_issuer,json=assetIssuer,proto3" json:"asset_issuer,omitempty"`
+}
+
+func (x *Asset) Reset() {
+	*x = Asset{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_kelp_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Asset) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Asset) ProtoMessage() {}
+
+func (x *Asset) ProtoReflect() protoreflect.Message {
+	mi := &file_kelp_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Asset.ProtoReflect.Descriptor instead.
+func (*Asset) Descriptor() ([]byte, []int) {
+	return file_kelp_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Asset) GetAssetType() string {
+	if x != nil {
+		return x.AssetType
+	}
+	return ""
+}
+
+func (x *Asset) GetAssetCode() string {
+	if x != nil {
+		return x.AssetCode
+	}
+	return ""
+}
+
+func (x *Asset) GetAssetIssuer() string {
+	if x != nil {
+		return x.AssetIssuer
+	}
+	return ""
+}
+
+type GetBotInfoResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// initializing is true when the bot is still initializing, in which case none of the other fields are set
+	Initializing         bool    `protobuf:"varint,1,opt,name=initializing,proto3" json:"initializing,omitempty"`
+	LastUpdated          string  `protobuf:"bytes,2,opt,name=last_updated,json=lastUpdated,proto3" json:"last_updated,omitempty"`
+	TradingAccount       string  `protobuf:"bytes,3,opt,name=trading_account,json=tradingAccount,proto3" json:"trading_account,omitempty"`
+	Strategy             string  `protobuf:"bytes,4,opt,name=strategy,proto3" json:"strategy,omitempty"`
+	IsTestnet            bool    `protobuf:"varint,5,opt,name=is_testnet,json=isTestnet,proto3" json:"is_testnet,omitempty"`
+	AssetBase            *Asset  `protobuf:"bytes,6,opt,name=asset_base,json=assetBase,proto3" json:"asset_base,omitempty"`
+	AssetQuote           *Asset  `protobuf:"bytes,7,opt,name=asset_quote,json=assetQuote,proto3" json:"asset_quote,omitempty"`
+	BalanceBase          float64 `protobuf:"fixed64,8,opt,name=balance_base,json=balanceBase,proto3" json:"balance_base,omitempty"`
+	BalanceQuote         float64 `protobuf:"fixed64,9,opt,name=balance_quote,json=balanceQuote,proto3" json:"balance_quote,omitempty"`
+	DisplayDecimalsBase  int32   `protobuf:"varint,10,opt,name=display_decimals_base,json=displayDecimalsBase,proto3" json:"display_decimals_base,omitempty"`
+	DisplayDecimalsQuote int32   `protobuf:"varint,11,opt,name=display_decimals_quote,json=displayDecimalsQuote,proto3" json:"display_decimals_quote,omitempty"`
+	NumBids              int32   `protobuf:"varint,12,opt,name=num_bids,json=numBids,proto3" json:"num_bids,omitempty"`
+	NumAsks              int32   `protobuf:"varint,13,opt,name=num_asks,json=numAsks,proto3" json:"num_asks,omitempty"`
+	SpreadValue          float64 `protobuf:"fixed64,14,opt,name=spread_value,json=spreadValue,proto3" json:"spread_value,omitempty"`
+	SpreadPct            float64 `protobuf:"fixed64,15,opt,name=spread_pct,json=spreadPct,proto3" json:"spread_pct,omitempty"`
+}
+
+func (x *GetBotInfoResponse) Reset() {
+	*x = GetBotInfoResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_kelp_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetBotInfoResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetBotInfoResponse) ProtoMessage() {}
+
+func (x *GetBotInfoResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_kelp_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetBotInfoResponse.ProtoReflect.Descriptor instead.
+func (*GetBotInfoResponse) Descriptor() ([]byte, []int) {
+	return file_kelp_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *GetBotInfoResponse) GetInitializing() bool {
+	if x != nil {
+		return x.Initializing
+	}
+	return false
+}
+
+func (x *GetBotInfoResponse) GetLastUpdated() string {
+	if x != nil {
+		return x.LastUpdated
+	}
+	return ""
+}
+
+func (x *GetBotInfoResponse) GetTradingAccount() string {
+	if x != nil {
+		return x.TradingAccount
+	}
+	return ""
+}
+
+func (x *GetBotInfoResponse) GetStrategy() string {
+	if x != nil {
+		return x.Strategy
+	}
+	return ""
+}
+
+func (x *GetBotInfoResponse) GetIsTestnet() bool {
+	if x != nil {
+		return x.IsTestnet
+	}
+	return false
+}
+
+func (x *GetBotInfoResponse) GetAssetBase() *Asset {
+	if x != nil {
+		return x.AssetBase
+	}
+	return nil
+}
+
+func (x *GetBotInfoResponse) GetAssetQuote() *Asset {
+	if x != nil {
+		return x.AssetQuote
+	}
+	return nil
+}
+
+func (x *GetBotInfoResponse) GetBalanceBase() float64 {
+	if x != nil {
+		return x.BalanceBase
+	}
+	return 0
+}
+
+func (x *GetBotInfoResponse) GetBalanceQuote() float64 {
+	if x != nil {
+		return x.BalanceQuote
+	}
+	return 0
+}
+
+func (x *GetBotInfoResponse) GetDisplayDecimalsBase() int32 {
+	if x != nil {
+		return x.DisplayDecimalsBase
+	}
+	return 0
+}
+
+func (x *GetBotInfoResponse) GetDisplayDecimalsQuote() int32 {
+	if x != nil {
+		return x.DisplayDecimalsQuote
+	}
+	return 0
+}
+
+func (x *GetBotInfoResponse) GetNumBids() int32 {
+	if x != nil {
+		return x.NumBids
+	}
+	return 0
+}
+
+func (x *GetBotInfoResponse) GetNumAsks() int32 {
+	if x != nil {
+		return x.NumAsks
+	}
+	return 0
+}
+
+func (x *GetBotInfoResponse) GetSpreadValue() float64 {
+	if x != nil {
+		return x.SpreadValue
+	}
+	return 0
+}
+
+func (x *GetBotInfoResponse) GetSpreadPct() float64 {
+	if x != nil {
+		return x.SpreadPct
+	}
+	return 0
+}
+
+type StreamTradesRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	UserId  string `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	BotName string `protobuf:"bytes,2,opt,name=bot_name,json=botName,proto3" json:"bot_name,omitempty"`
+	// after_date_unix_millis and after_txid are the cursor of the last trade that the client has seen, leave them empty to start from the
+	// first trade of the bot
+	AfterDateUnixMillis int64  `protobuf:"varint,3,opt,name=after_date_unix_millis,json=afterDateUnixMillis,proto3" json:"after_date_unix_millis,omitempty"`
+	AfterTxid           string `protobuf:"bytes,4,opt,name=after_txid,json=afterTxid,proto3" json:"after_txid,omitempty"`
+	// poll_interval_millis is how often the db is checked for new trades, defaults to 5000
+	PollIntervalMillis uint32 `protobuf:"varint,5,opt,name=poll_interval_millis,json=pollIntervalMillis,proto3" json:"poll_interval_millis,omitempty"`
+}
+
+func (x *StreamTradesRequest) Reset() {
+	*x = StreamTradesRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_kelp_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StreamTradesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamTradesRequest) ProtoMessage() {}
+
+func (x *StreamTradesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_kelp_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamTradesRequest.ProtoReflect.Descriptor instead.
+func (*StreamTradesRequest) Descriptor() ([]byte, []int) {
+	return file_kelp_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *StreamTradesRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *StreamTradesRequest) GetBotName() string {
+	if x != nil {
+		return x.BotName
+	}
+	return ""
+}
+
+func (x *StreamTradesRequest) GetAfterDateUnixMillis() int64 {
+	if x != nil {
+		return x.AfterDateUnixMillis
+	}
+	return 0
+}
+
+func (x *StreamTradesRequest) GetAfterTxid() string {
+	if x != nil {
+		return x.AfterTxid
+	}
+	return ""
+}
+
+func (x *StreamTradesRequest) GetPollIntervalMillis() uint32 {
+	if x != nil {
+		return x.PollIntervalMillis
+	}
+	return 0
+}
+
+type Trade struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Txid           string  `protobuf:"bytes,1,opt,name=txid,proto3" json:"txid,omitempty"`
+	DateUnixMillis int64   `protobuf:"varint,2,opt,name=date_unix_millis,json=dateUnixMillis,proto3" json:"date_unix_millis,omitempty"`
+	Action         string  `protobuf:"bytes,3,opt,name=action,proto3" json:"action,omitempty"`
+	Type           string  `protobuf:"bytes,4,opt,name=type,proto3" json:"type,omitempty"`
+	CounterPrice   float64 `protobuf:"fixed64,5,opt,name=counter_price,json=counterPrice,proto3" json:"counter_price,omitempty"`
+	BaseVolume     float64 `protobuf:"fixed64,6,opt,name=base_volume,json=baseVolume,proto3" json:"base_volume,omitempty"`
+	CounterCost    float64 `protobuf:"fixed64,7,opt,name=counter_cost,json=counterCost,proto3" json:"counter_cost,omitempty"`
+	Fee            float64 `protobuf:"fixed64,8,opt,name=fee,proto3" json:"fee,omitempty"`
+	OrderId        string  `protobuf:"bytes,9,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+}
+
+func (x *Trade) Reset() {
+	*x = Trade{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_kelp_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Trade) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Trade) ProtoMessage() {}
+
+func (x *Trade) ProtoReflect() protoreflect.Message {
+	mi := &file_kelp_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Trade.ProtoReflect.Descriptor instead.
+func (*Trade) Descriptor() ([]byte, []int) {
+	return file_kelp_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *Trade) GetTxid() string {
+	if x != nil {
+		return x.Txid
+	}
+	return ""
+}
+
+func (x *Trade) GetDateUnixMillis() int64 {
+	if x != nil {
+		return x.DateUnixMillis
+	}
+	return 0
+}
+
+func (x *Trade) GetAction() string {
+	if x != nil {
+		return x.Action
+	}
+	return ""
+}
+
+func (x *Trade) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Trade) GetCounterPrice() float64 {
+	if x != nil {
+		return x.CounterPrice
+	}
+	return 0
+}
+
+func (x *Trade) GetBaseVolume() float64 {
+	if x != nil {
+		return x.BaseVolume
+	}
+	return 0
+}
+
+func (x *Trade) GetCounterCost() float64 {
+	if x != nil {
+		return x.CounterCost
+	}
+	return 0
+}
+
+func (x *Trade) GetFee() float64 {
+	if x != nil {
+		return x.Fee
+	}
+	return 0
+}
+
+func (x *Trade) GetOrderId() string {
+	if x != nil {
+		return x.OrderId
+	}
+	return ""
+}
+
+var File_kelp_proto protoreflect.FileDescriptor
+
+var file_kelp_proto_rawDesc = []byte{
+	0x0a, 0x0a, 0x6b, 0x65, 0x6c, 0x70, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x07, 0x6b, 0x65,
+	0x6c, 0x70, 0x2e, 0x76, 0x31, 0x22, 0x2a, 0x0a, 0x0f, 0x4c, 0x69, 0x73, 0x74, 0x42, 0x6f, 0x74,
+	0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x17, 0x0a, 0x07, 0x75, 0x73, 0x65, 0x72,
+	0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x75, 0x73, 0x65, 0x72, 0x49,
+	0x64, 0x22, 0x97, 0x01, 0x0a, 0x03, 0x42, 0x6f, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d,
+	0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x1a, 0x0a,
+	0x08, 0x73, 0x74, 0x72, 0x61, 0x74, 0x65, 0x67, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x08, 0x73, 0x74, 0x72, 0x61, 0x74, 0x65, 0x67, 0x79, 0x12, 0x18, 0x0a, 0x07, 0x72, 0x75, 0x6e,
+	0x6e, 0x69, 0x6e, 0x67, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x72, 0x75, 0x6e, 0x6e,
+	0x69, 0x6e, 0x67, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x65, 0x73, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28,
+	0x08, 0x52, 0x04, 0x74, 0x65, 0x73, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x77, 0x61, 0x72, 0x6e, 0x69,
+	0x6e, 0x67, 0x73, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x08, 0x77, 0x61, 0x72, 0x6e, 0x69,
+	0x6e, 0x67, 0x73, 0x12, 0x16, 0x0a, 0x06, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x73, 0x18, 0x06, 0x20,
+	0x01, 0x28, 0x0d, 0x52, 0x06, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x73, 0x22, 0x34, 0x0a, 0x10, 0x4c,
+	0x69, 0x73, 0x74, 0x42, 0x6f, 0x74, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12,
+	0x20, 0x0a, 0x04, 0x62, 0x6f, 0x74, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0c, 0x2e,
+	0x6b, 0x65, 0x6c, 0x70, 0x2e, 0x76, 0x31, 0x2e, 0x42, 0x6f, 0x74, 0x52, 0x04, 0x62, 0x6f, 0x74,
+	0x73, 0x22, 0x40, 0x0a, 0x0a, 0x42, 0x6f, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12,
+	0x17, 0x0a, 0x07, 0x75, 0x73, 0x65, 0x72, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x06, 0x75, 0x73, 0x65, 0x72, 0x49, 0x64, 0x12, 0x19, 0x0a, 0x08, 0x62, 0x6f, 0x74, 0x5f,
+	0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x62, 0x6f, 0x74, 0x4e,
+	0x61, 0x6d, 0x65, 0x22, 0x12, 0x0a, 0x10, 0x53, 0x74, 0x61, 0x72, 0x74, 0x42, 0x6f, 0x74, 0x52,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x11, 0x0a, 0x0f, 0x53, 0x74, 0x6f, 0x70, 0x42,
+	0x6f, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x3e, 0x0a, 0x13, 0x47, 0x65,
+	0x74, 0x42, 0x6f, 0x74, 0x53, 0x74, 0x61, 0x74, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x12, 0x27, 0x0a, 0x05, 0x73, 0x74, 0x61, 0x74, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e,
+	0x32, 0x11, 0x2e, 0x6b, 0x65, 0x6c, 0x70, 0x2e, 0x76, 0x31, 0x2e, 0x42, 0x6f, 0x74, 0x53, 0x74,
+	0x61, 0x74, 0x65, 0x52, 0x05, 0x73, 0x74, 0x61, 0x74, 0x65, 0x22, 0x68, 0x0a, 0x05, 0x41, 0x73,
+	0x73, 0x65, 0x74, 0x12, 0x1d, 0x0a, 0x0a, 0x61, 0x73, 0x73, 0x65, 0x74, 0x5f, 0x74, 0x79, 0x70,
+	0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x61, 0x73, 0x73, 0x65, 0x74, 0x54, 0x79,
+	0x70, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x61, 0x73, 0x73, 0x65, 0x74, 0x5f, 0x63, 0x6f, 0x64, 0x65,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x61, 0x73, 0x73, 0x65, 0x74, 0x43, 0x6f, 0x64,
+	0x65, 0x12, 0x21, 0x0a, 0x0c, 0x61, 0x73, 0x73, 0x65, 0x74, 0x5f, 0x69, 0x73, 0x73, 0x75, 0x65,
+	0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x61, 0x73, 0x73, 0x65, 0x74, 0x49, 0x73,
+	0x73, 0x75, 0x65, 0x72, 0x22, 0xc9, 0x04, 0x0a, 0x12, 0x47, 0x65, 0x74, 0x42, 0x6f, 0x74, 0x49,
+	0x6e, 0x66, 0x6f, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x22, 0x0a, 0x0c, 0x69,
+	0x6e, 0x69, 0x74, 0x69, 0x61, 0x6c, 0x69, 0x7a, 0x69, 0x6e, 0x67, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x08, 0x52, 0x0c, 0x69, 0x6e, 0x69, 0x74, 0x69, 0x61, 0x6c, 0x69, 0x7a, 0x69, 0x6e, 0x67, 0x12,
+	0x21, 0x0a, 0x0c, 0x6c, 0x61, 0x73, 0x74, 0x5f, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x64, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x6c, 0x61, 0x73, 0x74, 0x55, 0x70, 0x64, 0x61, 0x74,
+	0x65, 0x64, 0x12, 0x27, 0x0a, 0x0f, 0x74, 0x72, 0x61, 0x64, 0x69, 0x6e, 0x67, 0x5f, 0x61, 0x63,
+	0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x74, 0x72, 0x61,
+	0x64, 0x69, 0x6e, 0x67, 0x41, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x73,
+	0x74, 0x72, 0x61, 0x74, 0x65, 0x67, 0x79, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x73,
+	0x74, 0x72, 0x61, 0x74, 0x65, 0x67, 0x79, 0x12, 0x1d, 0x0a, 0x0a, 0x69, 0x73, 0x5f, 0x74, 0x65,
+	0x73, 0x74, 0x6e, 0x65, 0x74, 0x18, 0x05, 0x20, 0x01, 0x28, 0x08, 0x52, 0x09, 0x69, 0x73, 0x54,
+	0x65, 0x73, 0x74, 0x6e, 0x65, 0x74, 0x12, 0x2d, 0x0a, 0x0a, 0x61, 0x73, 0x73, 0x65, 0x74, 0x5f,
+	0x62, 0x61, 0x73, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0e, 0x2e, 0x6b, 0x65, 0x6c,
+	0x70, 0x2e, 0x76, 0x31, 0x2e, 0x41, 0x73, 0x73, 0x65, 0x74, 0x52, 0x09, 0x61, 0x73, 0x73, 0x65,
+	0x74, 0x42, 0x61, 0x73, 0x65, 0x12, 0x2f, 0x0a, 0x0b, 0x61, 0x73, 0x73, 0x65, 0x74, 0x5f, 0x71,
+	0x75, 0x6f, 0x74, 0x65, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0e, 0x2e, 0x6b, 0x65, 0x6c,
+	0x70, 0x2e, 0x76, 0x31, 0x2e, 0x41, 0x73, 0x73, 0x65, 0x74, 0x52, 0x0a, 0x61, 0x73, 0x73, 0x65,
+	0x74, 0x51, 0x75, 0x6f, 0x74, 0x65, 0x12, 0x21, 0x0a, 0x0c, 0x62, 0x61, 0x6c, 0x61, 0x6e, 0x63,
+	0x65, 0x5f, 0x62, 0x61, 0x73, 0x65, 0x18, 0x08, 0x20, 0x01, 0x28, 0x01, 0x52, 0x0b, 0x62, 0x61,
+	0x6c, 0x61, 0x6e, 0x63, 0x65, 0x42, 0x61, 0x73, 0x65, 0x12, 0x23, 0x0a, 0x0d, 0x62, 0x61, 0x6c,
+	0x61, 0x6e, 0x63, 0x65, 0x5f, 0x71, 0x75, 0x6f, 0x74, 0x65, 0x18, 0x09, 0x20, 0x01, 0x28, 0x01,
+	0x52, 0x0c, 0x62, 0x61, 0x6c, 0x61, 0x6e, 0x63, 0x65, 0x51, 0x75, 0x6f, 0x74, 0x65, 0x12, 0x32,
+	0x0a, 0x15, 0x64, 0x69, 0x73, 0x70, 0x6c, 0x61, 0x79, 0x5f, 0x64, 0x65, 0x63, 0x69, 0x6d, 0x61,
+	0x6c, 0x73, 0x5f, 0x62, 0x61, 0x73, 0x65, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x05, 0x52, 0x13, 0x64,
+	0x69, 0x73, 0x70, 0x6c, 0x61, 0x79, 0x44, 0x65, 0x63, 0x69, 0x6d, 0x61, 0x6c, 0x73, 0x42, 0x61,
+	0x73, 0x65, 0x12, 0x34, 0x0a, 0x16, 0x64, 0x69, 0x73, 0x70, 0x6c, 0x61, 0x79, 0x5f, 0x64, 0x65,
+	0x63, 0x69, 0x6d, 0x61, 0x6c, 0x73, 0x5f, 0x71, 0x75, 0x6f, 0x74, 0x65, 0x18, 0x0b, 0x20, 0x01,
+	0x28, 0x05, 0x52, 0x14, 0x64, 0x69, 0x73, 0x70, 0x6c, 0x61, 0x79, 0x44, 0x65, 0x63, 0x69, 0x6d,
+	0x61, 0x6c, 0x73, 0x51, 0x75, 0x6f, 0x74, 0x65, 0x12, 0x19, 0x0a, 0x08, 0x6e, 0x75, 0x6d, 0x5f,
+	0x62, 0x69, 0x64, 0x73, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x05, 0x52, 0x07, 0x6e, 0x75, 0x6d, 0x42,
+	0x69, 0x64, 0x73, 0x12, 0x19, 0x0a, 0x08, 0x6e, 0x75, 0x6d, 0x5f, 0x61, 0x73, 0x6b, 0x73, 0x18,
+	0x0d, 0x20, 0x01, 0x28, 0x05, 0x52, 0x07, 0x6e, 0x75, 0x6d, 0x41, 0x73, 0x6b, 0x73, 0x12, 0x21,
+	0x0a, 0x0c, 0x73, 0x70, 0x72, 0x65, 0x61, 0x64, 0x5f, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x0e,
+	0x20, 0x01, 0x28, 0x01, 0x52, 0x0b, 0x73, 0x70, 0x72, 0x65, 0x61, 0x64, 0x56, 0x61, 0x6c, 0x75,
+	0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x73, 0x70, 0x72, 0x65, 0x61, 0x64, 0x5f, 0x70, 0x63, 0x74, 0x18,
+	0x0f, 0x20, 0x01, 0x28, 0x01, 0x52, 0x09, 0x73, 0x70, 0x72, 0x65, 0x61, 0x64, 0x50, 0x63, 0x74,
+	0x22, 0xcf, 0x01, 0x0a, 0x13, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x54, 0x72, 0x61, 0x64, 0x65,
+	0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x17, 0x0a, 0x07, 0x75, 0x73, 0x65, 0x72,
+	0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x75, 0x73, 0x65, 0x72, 0x49,
+	0x64, 0x12, 0x19, 0x0a, 0x08, 0x62, 0x6f, 0x74, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x07, 0x62, 0x6f, 0x74, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x33, 0x0a, 0x16,
+	0x61, 0x66, 0x74, 0x65, 0x72, 0x5f, 0x64, 0x61, 0x74, 0x65, 0x5f, 0x75, 0x6e, 0x69, 0x78, 0x5f,
+	0x6d, 0x69, 0x6c, 0x6c, 0x69, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x13, 0x61, 0x66,
+	0x74, 0x65, 0x72, 0x44, 0x61, 0x74, 0x65, 0x55, 0x6e, 0x69, 0x78, 0x4d, 0x69, 0x6c, 0x6c, 0x69,
+	0x73, 0x12, 0x1d, 0x0a, 0x0a, 0x61, 0x66, 0x74, 0x65, 0x72, 0x5f, 0x74, 0x78, 0x69, 0x64, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x61, 0x66, 0x74, 0x65, 0x72, 0x54, 0x78, 0x69, 0x64,
+	0x12, 0x30, 0x0a, 0x14, 0x70, 0x6f, 0x6c, 0x6c, 0x5f, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x76, 0x61,
+	0x6c, 0x5f, 0x6d, 0x69, 0x6c, 0x6c, 0x69, 0x73, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x12,
+	0x70, 0x6f, 0x6c, 0x6c, 0x49, 0x6e, 0x74, 0x65, 0x72, 0x76, 0x61, 0x6c, 0x4d, 0x69, 0x6c, 0x6c,
+	0x69, 0x73, 0x22, 0x87, 0x02, 0x0a, 0x05, 0x54, 0x72, 0x61, 0x64, 0x65, 0x12, 0x12, 0x0a, 0x04,
+	0x74, 0x78, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x74, 0x78, 0x69, 0x64,
+	0x12, 0x28, 0x0a, 0x10, 0x64, 0x61, 0x74, 0x65, 0x5f, 0x75, 0x6e, 0x69, 0x78, 0x5f, 0x6d, 0x69,
+	0x6c, 0x6c, 0x69, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0e, 0x64, 0x61, 0x74, 0x65,
+	0x55, 0x6e, 0x69, 0x78, 0x4d, 0x69, 0x6c, 0x6c, 0x69, 0x73, 0x12, 0x16, 0x0a, 0x06, 0x61, 0x63,
+	0x74, 0x69, 0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x61, 0x63, 0x74, 0x69,
+	0x6f, 0x6e, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x79, 0x70, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x12, 0x23, 0x0a, 0x0d, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x65,
+	0x72, 0x5f, 0x70, 0x72, 0x69, 0x63, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x01, 0x52, 0x0c, 0x63,
+	0x6f, 0x75, 0x6e, 0x74, 0x65, 0x72, 0x50, 0x72, 0x69, 0x63, 0x65, 0x12, 0x1f, 0x0a, 0x0b, 0x62,
+	0x61, 0x73, 0x65, 0x5f, 0x76, 0x6f, 0x6c, 0x75, 0x6d, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x01,
+	0x52, 0x0a, 0x62, 0x61, 0x73, 0x65, 0x56, 0x6f, 0x6c, 0x75, 0x6d, 0x65, 0x12, 0x21, 0x0a, 0x0c,
+	0x63, 0x6f, 0x75, 0x6e, 0x74, 0x65, 0x72, 0x5f, 0x63, 0x6f, 0x73, 0x74, 0x18, 0x07, 0x20, 0x01,
+	0x28, 0x01, 0x52, 0x0b, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x65, 0x72, 0x43, 0x6f, 0x73, 0x74, 0x12,
+	0x10, 0x0a, 0x03, 0x66, 0x65, 0x65, 0x18, 0x08, 0x20, 0x01, 0x28, 0x01, 0x52, 0x03, 0x66, 0x65,
+	0x65, 0x12, 0x19, 0x0a, 0x08, 0x6f, 0x72, 0x64, 0x65, 0x72, 0x5f, 0x69, 0x64, 0x18, 0x09, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x07, 0x6f, 0x72, 0x64, 0x65, 0x72, 0x49, 0x64, 0x2a, 0x6c, 0x0a, 0x08,
+	0x42, 0x6f, 0x74, 0x53, 0x74, 0x61, 0x74, 0x65, 0x12, 0x1a, 0x0a, 0x16, 0x42, 0x4f, 0x54, 0x5f,
+	0x53, 0x54, 0x41, 0x54, 0x45, 0x5f, 0x49, 0x4e, 0x49, 0x54, 0x49, 0x41, 0x4c, 0x49, 0x5a, 0x49,
+	0x4e, 0x47, 0x10, 0x00, 0x12, 0x15, 0x0a, 0x11, 0x42, 0x4f, 0x54, 0x5f, 0x53, 0x54, 0x41, 0x54,
+	0x45, 0x5f, 0x53, 0x54, 0x4f, 0x50, 0x50, 0x45, 0x44, 0x10, 0x01, 0x12, 0x15, 0x0a, 0x11, 0x42,
+	0x4f, 0x54, 0x5f, 0x53, 0x54, 0x41, 0x54, 0x45, 0x5f, 0x52, 0x55, 0x4e, 0x4e, 0x49, 0x4e, 0x47,
+	0x10, 0x02, 0x12, 0x16, 0x0a, 0x12, 0x42, 0x4f, 0x54, 0x5f, 0x53, 0x54, 0x41, 0x54, 0x45, 0x5f,
+	0x53, 0x54, 0x4f, 0x50, 0x50, 0x49, 0x4e, 0x47, 0x10, 0x03, 0x32, 0xff, 0x02, 0x0a, 0x04, 0x4b,
+	0x65, 0x6c, 0x70, 0x12, 0x3f, 0x0a, 0x08, 0x4c, 0x69, 0x73, 0x74, 0x42, 0x6f, 0x74, 0x73, 0x12,
+	0x18, 0x2e, 0x6b, 0x65, 0x6c, 0x70, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x42, 0x6f,
+	0x74, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x19, 0x2e, 0x6b, 0x65, 0x6c, 0x70,
+	0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x42, 0x6f, 0x74, 0x73, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x12, 0x3a, 0x0a, 0x08, 0x53, 0x74, 0x61, 0x72, 0x74, 0x42, 0x6f, 0x74,
+	0x12, 0x13, 0x2e, 0x6b, 0x65, 0x6c, 0x70, 0x2e, 0x76, 0x31, 0x2e, 0x42, 0x6f, 0x74, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x19, 0x2e, 0x6b, 0x65, 0x6c, 0x70, 0x2e, 0x76, 0x31, 0x2e,
+	0x53, 0x74, 0x61, 0x72, 0x74, 0x42, 0x6f, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
+	0x12, 0x38, 0x0a, 0x07, 0x53, 0x74, 0x6f, 0x70, 0x42, 0x6f, 0x74, 0x12, 0x13, 0x2e, 0x6b, 0x65,
+	0x6c, 0x70, 0x2e, 0x76, 0x31, 0x2e, 0x42, 0x6f, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x1a, 0x18, 0x2e, 0x6b, 0x65, 0x6c, 0x70, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x74, 0x6f, 0x70, 0x42,
+	0x6f, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x40, 0x0a, 0x0b, 0x47, 0x65,
+	0x74, 0x42, 0x6f, 0x74, 0x53, 0x74, 0x61, 0x74, 0x65, 0x12, 0x13, 0x2e, 0x6b, 0x65, 0x6c, 0x70,
+	0x2e, 0x76, 0x31, 0x2e, 0x42, 0x6f, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1c,
+	0x2e, 0x6b, 0x65, 0x6c, 0x70, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x65, 0x74, 0x42, 0x6f, 0x74, 0x53,
+	0x74, 0x61, 0x74, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x3e, 0x0a, 0x0a,
+	0x47, 0x65, 0x74, 0x42, 0x6f, 0x74, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x13, 0x2e, 0x6b, 0x65, 0x6c,
+	0x70, 0x2e, 0x76, 0x31, 0x2e, 0x42, 0x6f, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
+	0x1b, 0x2e, 0x6b, 0x65, 0x6c, 0x70, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x65, 0x74, 0x42, 0x6f, 0x74,
+	0x49, 0x6e, 0x66, 0x6f, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x3e, 0x0a, 0x0c,
+	0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x54, 0x72, 0x61, 0x64, 0x65, 0x73, 0x12, 0x1c, 0x2e, 0x6b,
+	0x65, 0x6c, 0x70, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x54, 0x72, 0x61,
+	0x64, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0e, 0x2e, 0x6b, 0x65, 0x6c,
+	0x70, 0x2e, 0x76, 0x31, 0x2e, 0x54, 0x72, 0x61, 0x64, 0x65, 0x30, 0x01, 0x42, 0x2d, 0x5a, 0x2b,
+	0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x73, 0x74, 0x65, 0x6c, 0x6c,
+	0x61, 0x72, 0x2f, 0x6b, 0x65, 0x6c, 0x70, 0x2f, 0x67, 0x75, 0x69, 0x2f, 0x62, 0x61, 0x63, 0x6b,
+	0x65, 0x6e, 0x64, 0x2f, 0x6b, 0x65, 0x6c, 0x70, 0x72, 0x70, 0x63, 0x62, 0x06, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x33,
+}
+
+var (
+	file_kelp_proto_rawDescOnce sync.Once
+	file_kelp_proto_rawDescData = file_kelp_proto_rawDesc
+)
+
+func file_kelp_proto_rawDescGZIP() []byte {
+	file_kelp_proto_rawDescOnce.Do(func() {
+		file_kelp_proto_rawDescData = protoimpl.X.CompressGZIP(file_kelp_proto_rawDescData)
+	})
+	return file_kelp_proto_rawDescData
+}
+
+var file_kelp_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_kelp_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
+var file_kelp_proto_goTypes = []interface{}{
+	(BotState)(0),               // 0: kelp.v1.BotState
+	(*ListBotsRequest)(nil),     // 1: kelp.v1.ListBotsRequest
+	(*Bot)(nil),                 // 2: kelp.v1.Bot
+	(*ListBotsResponse)(nil),    // 3: kelp.v1.ListBotsResponse
+	(*BotRequest)(nil),          // 4: kelp.v1.BotRequest
+	(*StartBotResponse)(nil),    // 5: kelp.v1.StartBotResponse
+	(*StopBotResponse)(nil),     // 6: kelp.v1.StopBotResponse
+	(*GetBotStateResponse)(nil), // 7: kelp.v1.GetBotStateResponse
+	(*Asset)(nil),               // 8: kelp.v1.Asset
+	(*GetBotInfoResponse)(nil),  // 9: kelp.v1.GetBotInfoResponse
+	(*StreamTradesRequest)(nil), // 10: kelp.v1.StreamTradesRequest
+	(*Trade)(nil),               // 11: kelp.v1.Trade
+}
+var file_kelp_proto_depIdxs = []int32{
+	2,  // 0: kelp.v1.ListBotsResponse.bots:type_name -> kelp.v1.Bot
+	0,  // 1: kelp.v1.GetBotStateResponse.state:type_name -> kelp.v1.BotState
+	8,  // 2: kelp.v1.GetBotInfoResponse.asset_base:type_name -> kelp.v1.Asset
+	8,  // 3: kelp.v1.GetBotInfoResponse.asset_quote:type_name -> kelp.v1.Asset
+	1,  // 4: kelp.v1.Kelp.ListBots:input_type -> kelp.v1.ListBotsRequest
+	4,  // 5: kelp.v1.Kelp.StartBot:input_type -> kelp.v1.BotRequest
+	4,  // 6: kelp.v1.Kelp.StopBot:input_type -> kelp.v1.BotRequest
+	4,  // 7: kelp.v1.Kelp.GetBotState:input_type -> kelp.v1.BotRequest
+	4,  // 8: kelp.v1.Kelp.GetBotInfo:input_type -> kelp.v1.BotRequest
+	10, // 9: kelp.v1.Kelp.StreamTrades:input_type -> kelp.v1.StreamTradesRequest
+	3,  // 10: kelp.v1.Kelp.ListBots:output_type -> kelp.v1.ListBotsResponse
+	5,  // 11: kelp.v1.Kelp.StartBot:output_type -> kelp.v1.StartBotResponse
+	6,  // 12: kelp.v1.Kelp.StopBot:output_type -> kelp.v1.StopBotResponse
+	7,  // 13: kelp.v1.Kelp.GetBotState:output_type -> kelp.v1.GetBotStateResponse
+	9,  // 14: kelp.v1.Kelp.GetBotInfo:output_type -> kelp.v1.GetBotInfoResponse
+	11, // 15: kelp.v1.Kelp.StreamTrades:output_type -> kelp.v1.Trade
+	10, // [10:16] is the sub-list for method output_type
+	4,  // [4:10] is the sub-list for method input_type
+	4,  // [4:4] is the sub-list for extension type_name
+	4,  // [4:4] is the sub-list for extension extendee
+	0,  // [0:4] is the sub-list for field type_name
+}
+
+func init() { file_kelp_proto_init() }
+func file_kelp_proto_init() {
+	if File_kelp_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_kelp_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListBotsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_kelp_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Bot); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_kelp_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListBotsResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_kelp_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BotRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_kelp_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*StartBotResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_kelp_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*StopBotResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_kelp_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetBotStateResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_kelp_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Asset); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_kelp_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetBotInfoResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_kelp_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*StreamTradesRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_kelp_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Trade); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_kelp_proto_rawDesc,
+			NumEnums:      1,
+			NumMessages:   11,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_kelp_proto_goTypes,
+		DependencyIndexes: file_kelp_proto_depIdxs,
+		EnumInfos:         file_kelp_proto_enumTypes,
+		MessageInfos:      file_kelp_proto_msgTypes,
+	}.Build()
+	File_kelp_proto = out.File
+	file_kelp_proto_rawDesc = nil
+	file_kelp_proto_goTypes = nil
+	file_kelp_proto_depIdxs = nil
+}
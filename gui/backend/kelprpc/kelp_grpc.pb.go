@@ -0,0 +1,327 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.2.0
+// - protoc             (unknown)
+// source: kelp.proto
+
+package kelprpc
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// KelpClient is the client API for Kelp service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type KelpClient interface {
+	// ListBots lists the bots of the user, same as POST /api/v1/listBots
+	ListBots(ctx context.Context, in *ListBotsRequest, opts ...grpc.CallOption) (*ListBotsResponse, error)
+	// StartBot starts the bot with the buysell strategy, same as POST /api/v1/start
+	StartBot(ctx context.Context, in *BotRequest, opts ...grpc.CallOption) (*StartBotResponse, error)
+	// StopBot stops the bot and deletes its offers, same as POST /api/v1/stop
+	StopBot(ctx context.Context, in *BotRequest, opts ...grpc.CallOption) (*StopBotResponse, error)
+	// GetBotState returns the lifecycle state of the bot, same as POST /api/v1/getState
+	GetBotState(ctx context.Context, in *BotRequest, opts ...grpc.CallOption) (*GetBotStateResponse, error)
+	// GetBotInfo returns the balances, offers and spread of the bot, same as POST /api/v1/getBotInfo
+	GetBotInfo(ctx context.Context, in *BotRequest, opts ...grpc.CallOption) (*GetBotInfoResponse, error)
+	// StreamTrades sends the trades of the bot as they are saved in the POSTGRES_DB of the bot, starting after the cursor in the request.
+	// The stream stays open until the client cancels it
+	StreamTrades(ctx context.Context, in *StreamTradesRequest, opts ...grpc.CallOption) (Kelp_StreamTradesClient, error)
+}
+
+type kelpClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewKelpClient(cc grpc.ClientConnInterface) KelpClient {
+	return &kelpClient{cc}
+}
+
+func (c *kelpClient) ListBots(ctx context.Context, in *ListBotsRequest, opts ...grpc.CallOption) (*ListBotsResponse, error) {
+	out := new(ListBotsResponse)
+	err := c.cc.Invoke(ctx, "/kelp.v1.Kelp/ListBots", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kelpClient) StartBot(ctx context.Context, in *BotRequest, opts ...grpc.CallOption) (*StartBotResponse, error) {
+	out := new(StartBotResponse)
+	err := c.cc.Invoke(ctx, "/kelp.v1.Kelp/StartBot", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kelpClient) StopBot(ctx context.Context, in *BotRequest, opts ...grpc.CallOption) (*StopBotResponse, error) {
+	out := new(StopBotResponse)
+	err := c.cc.Invoke(ctx, "/kelp.v1.Kelp/StopBot", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kelpClient) GetBotState(ctx context.Context, in *BotRequest, opts ...grpc.CallOption) (*GetBotStateResponse, error) {
+	out := new(GetBotStateResponse)
+	err := c.cc.Invoke(ctx, "/kelp.v1.Kelp/GetBotState", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kelpClient) GetBotInfo(ctx context.Context, in *BotRequest, opts ...grpc.CallOption) (*GetBotInfoResponse, error) {
+	out := new(GetBotInfoResponse)
+	err := c.cc.Invoke(ctx, "/kelp.v1.Kelp/GetBotInfo", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kelpClient) StreamTrades(ctx context.Context, in *StreamTradesRequest, opts ...grpc.CallOption) (Kelp_StreamTradesClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Kelp_ServiceDesc.Streams[0], "/kelp.v1.Kelp/StreamTrades", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &kelpStreamTradesClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Kelp_StreamTradesClient interface {
+	Recv() (*Trade, error)
+	grpc.ClientStream
+}
+
+type kelpStreamTradesClient struct {
+	grpc.ClientStream
+}
+
+func (x *kelpStreamTradesClient) Recv() (*Trade, error) {
+	m := new(Trade)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// KelpServer is the server API for Kelp service.
+// All implementations must embed UnimplementedKelpServer
+// for forward compatibility
+type KelpServer interface {
+	// ListBots lists the bots of the user, same as POST /api/v1/listBots
+	ListBots(context.Context, *ListBotsRequest) (*ListBotsResponse, error)
+	// StartBot starts the bot with the buysell strategy, same as POST /api/v1/start
+	StartBot(context.Context, *BotRequest) (*StartBotResponse, error)
+	// StopBot stops the bot and deletes its offers, same as POST /api/v1/stop
+	StopBot(context.Context, *BotRequest) (*StopBotResponse, error)
+	// GetBotState returns the lifecycle state of the bot, same as POST /api/v1/getState
+	GetBotState(context.Context, *BotRequest) (*GetBotStateResponse, error)
+	// GetBotInfo returns the balances, offers and spread of the bot, same as POST /api/v1/getBotInfo
+	GetBotInfo(context.Context, *BotRequest) (*GetBotInfoResponse, error)
+	// StreamTrades sends the trades of the bot as they are saved in the POSTGRES_DB of the bot, starting after the cursor in the request.
+	// The stream stays open until the client cancels it
+	StreamTrades(*StreamTradesRequest, Kelp_StreamTradesServer) error
+	mustEmbedUnimplementedKelpServer()
+}
+
+// UnimplementedKelpServer must be embedded to have forward compatible implementations.
+type UnimplementedKelpServer struct {
+}
+
+func (UnimplementedKelpServer) ListBots(context.Context, *ListBotsRequest) (*ListBotsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListBots not implemented")
+}
+func (UnimplementedKelpServer) StartBot(context.Context, *BotRequest) (*StartBotResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method StartBot not implemented")
+}
+func (UnimplementedKelpServer) StopBot(context.Context, *BotRequest) (*StopBotResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method StopBot not implemented")
+}
+func (UnimplementedKelpServer) GetBotState(context.Context, *BotRequest) (*GetBotStateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBotState not implemented")
+}
+func (UnimplementedKelpServer) GetBotInfo(context.Context, *BotRequest) (*GetBotInfoResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBotInfo not implemented")
+}
+func (UnimplementedKelpServer) StreamTrades(*StreamTradesRequest, Kelp_StreamTradesServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamTrades not implemented")
+}
+func (UnimplementedKelpServer) mustEmbedUnimplementedKelpServer() {}
+
+// UnsafeKelpServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to KelpServer will
+// result in compilation errors.
+type UnsafeKelpServer interface {
+	mustEmbedUnimplementedKelpServer()
+}
+
+func RegisterKelpServer(s grpc.ServiceRegistrar, srv KelpServer) {
+	s.RegisterService(&Kelp_ServiceDesc, srv)
+}
+
+func _Kelp_ListBots_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListBotsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KelpServer).ListBots(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/kelp.v1.Kelp/ListBots",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KelpServer).ListBots(ctx, req.(*ListBotsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Kelp_StartBot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BotRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KelpServer).StartBot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/kelp.v1.Kelp/StartBot",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KelpServer).StartBot(ctx, req.(*BotRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Kelp_StopBot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BotRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KelpServer).StopBot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/kelp.v1.Kelp/StopBot",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KelpServer).StopBot(ctx, req.(*BotRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Kelp_GetBotState_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BotRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KelpServer).GetBotState(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/kelp.v1.Kelp/GetBotState",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KelpServer).GetBotState(ctx, req.(*BotRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Kelp_GetBotInfo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BotRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KelpServer).GetBotInfo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/kelp.v1.Kelp/GetBotInfo",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KelpServer).GetBotInfo(ctx, req.(*BotRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Kelp_StreamTrades_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamTradesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(KelpServer).StreamTrades(m, &kelpStreamTradesServer{stream})
+}
+
+type Kelp_StreamTradesServer interface {
+	Send(*Trade) error
+	grpc.ServerStream
+}
+
+type kelpStreamTradesServer struct {
+	grpc.ServerStream
+}
+
+func (x *kelpStreamTradesServer) Send(m *Trade) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// Kelp_ServiceDesc is the grpc.ServiceDesc for Kelp service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Kelp_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "kelp.v1.Kelp",
+	HandlerType: (*KelpServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListBots",
+			Handler:    _Kelp_ListBots_Handler,
+		},
+		{
+			MethodName: "StartBot",
+			Handler:    _Kelp_StartBot_Handler,
+		},
+		{
+			MethodName: "StopBot",
+			Handler:    _Kelp_StopBot_Handler,
+		},
+		{
+			MethodName: "GetBotState",
+			Handler:    _Kelp_GetBotState_Handler,
+		},
+		{
+			MethodName: "GetBotInfo",
+			Handler:    _Kelp_GetBotInfo_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamTrades",
+			Handler:       _Kelp_StreamTrades_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "kelp.proto",
+}
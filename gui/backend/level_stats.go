@@ -0,0 +1,174 @@
+package backend
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/stellar/kelp/queries"
+	"github.com/stellar/kelp/support/postgresdb"
+)
+
+// defaultLevelStatsDays is the number of days that are reported when the request does not specify a start_date
+const defaultLevelStatsDays = 7
+
+type levelStatsRequest struct {
+	UserData UserData `json:"user_data"`
+	BotName  string   `json:"bot_name"`
+	// StartDate and EndDate are dates in the format YYYY-MM-DD (UTC) that limit the report to the days in the range [StartDate, EndDate],
+	// both are optional and the report defaults to the last 7 days including today
+	StartDate string `json:"start_date"`
+	EndDate   string `json:"end_date"`
+}
+
+// levelStatsSummary is the activity of a level over all the days of the report
+type levelStatsSummary struct {
+	Side              string  `json:"side"`
+	Level             int     `json:"level"`
+	Spread            float64 `json:"spread"` // spread of the level on the last day that it was quoted
+	NumDays           int     `json:"num_days"`
+	NumUpdates        int64   `json:"num_updates"`
+	NumFills          int64   `json:"num_fills"`
+	FillRate          float64 `json:"fill_rate"` // fills per update cycle in which the level was quoted
+	FilledBaseVolume  float64 `json:"filled_base_volume"`
+	FilledQuoteVolume float64 `json:"filled_quote_volume"`
+	SpreadCapture     float64 `json:"spread_capture"`
+	SpreadCaptureBps  float64 `json:"spread_capture_bps"` // spread capture as a share of the filled quote volume
+}
+
+// levelStatsResponse is the response from the getLevelStats request. Every level that is placed holds a base reserve of XLM on the SDEX,
+// so levels that rarely fill or do not capture any spread are candidates to be pruned from the config
+type levelStatsResponse struct {
+	MarketID  string               `json:"market_id"`
+	AccountID string               `json:"account_id"`
+	StartDate string               `json:"start_date"`
+	EndDate   string               `json:"end_date"`
+	Levels    []*levelStatsSummary `json:"levels"`
+	Daily     []queries.LevelStats `json:"daily"`
+}
+
+func (s *APIServer) getLevelStats(w http.ResponseWriter, r *http.Request) {
+	bodyBytes, e := ioutil.ReadAll(r.Body)
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("error when reading request input: %s", e))
+		return
+	}
+	var req levelStatsRequest
+	e = json.Unmarshal(bodyBytes, &req)
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("error unmarshaling json: %s; bodyString = %s", e, string(bodyBytes)))
+		return
+	}
+	if strings.TrimSpace(req.UserData.ID) == "" {
+		s.writeErrorJson(w, "cannot have empty userID")
+		return
+	}
+
+	resp, e := s.doGetLevelStats(&req)
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("unable to get level stats for bot '%s': %s", req.BotName, e))
+		return
+	}
+	s.writeJsonWithLog(w, resp, false)
+}
+
+func (s *APIServer) doGetLevelStats(req *levelStatsRequest) (*levelStatsResponse, error) {
+	end := time.Now().UTC().Truncate(24 * time.Hour)
+	if req.EndDate != "" {
+		t, e := time.Parse("2006-01-02", req.EndDate)
+		if e != nil {
+			return nil, fmt.Errorf("invalid end_date '%s': %s", req.EndDate, e)
+		}
+		end = t
+	}
+	start := end.AddDate(0, 0, -(defaultLevelStatsDays - 1))
+	if req.StartDate != "" {
+		t, e := time.Parse("2006-01-02", req.StartDate)
+		if e != nil {
+			return nil, fmt.Errorf("invalid start_date '%s': %s", req.StartDate, e)
+		}
+		start = t
+	}
+	if end.Before(start) {
+		return nil, fmt.Errorf("start_date (%s) needs to be on or before end_date (%s)", start.Format("2006-01-02"), end.Format("2006-01-02"))
+	}
+
+	botConfig, marketID, e := s.readBotConfigAndMarketID(req.UserData.ID, req.BotName)
+	if e != nil {
+		return nil, e
+	}
+	if botConfig.PostgresDbConfig == nil {
+		return nil, fmt.Errorf("bot needs POSTGRES_DB to be set in the trader config and TRACK_LEVEL_STATS in the strategy config to track level stats")
+	}
+	accountID := botConfig.DbOverrideAccountID
+
+	db, e := sql.Open("postgres", botConfig.PostgresDbConfig.MakeConnectString())
+	if e != nil {
+		return nil, fmt.Errorf("could not open database: %s", e)
+	}
+	defer db.Close()
+
+	statsQuery, e := queries.MakeLevelStatsQuery(db, accountID, marketID)
+	if e != nil {
+		return nil, fmt.Errorf("could not make LevelStats query: %s", e)
+	}
+	// the end date of the query is exclusive
+	statsResult, e := statsQuery.QueryRow(start.Format(postgresdb.DateFormatString), end.AddDate(0, 0, 1).Format(postgresdb.DateFormatString))
+	if e != nil {
+		return nil, fmt.Errorf("could not query level stats: %s", e)
+	}
+	daily := statsResult.([]queries.LevelStats)
+
+	return &levelStatsResponse{
+		MarketID:  marketID,
+		AccountID: accountID,
+		StartDate: start.Format("2006-01-02"),
+		EndDate:   end.Format("2006-01-02"),
+		Levels:    summarizeLevelStats(daily),
+		Daily:     daily,
+	}, nil
+}
+
+// summarizeLevelStats adds up the daily stats of each level, daily needs to be sorted by date so the spread of the last day is used
+func summarizeLevelStats(daily []queries.LevelStats) []*levelStatsSummary {
+	summaries := []*levelStatsSummary{}
+	summaryByKey := map[string]*levelStatsSummary{}
+	for _, d := range daily {
+		key := fmt.Sprintf("%s/%d", d.Side, d.Level)
+		summary, ok := summaryByKey[key]
+		if !ok {
+			summary = &levelStatsSummary{Side: d.Side, Level: d.Level}
+			summaryByKey[key] = summary
+			summaries = append(summaries, summary)
+		}
+
+		summary.Spread = d.Spread
+		summary.NumDays++
+		summary.NumUpdates += d.NumUpdates
+		summary.NumFills += d.NumFills
+		summary.FilledBaseVolume += d.FilledBaseVolume
+		summary.FilledQuoteVolume += d.FilledQuoteVolume
+		summary.SpreadCapture += d.SpreadCapture
+	}
+
+	sort.Slice(summaries, func(i int, j int) bool {
+		if summaries[i].Side != summaries[j].Side {
+			return summaries[i].Side < summaries[j].Side
+		}
+		return summaries[i].Level < summaries[j].Level
+	})
+	for _, summary := range summaries {
+		if summary.NumUpdates > 0 {
+			summary.FillRate = float64(summary.NumFills) / float64(summary.NumUpdates)
+		}
+		if summary.FilledQuoteVolume > 0 {
+			summary.SpreadCaptureBps = 10000 * summary.SpreadCapture / summary.FilledQuoteVolume
+		}
+	}
+	return summaries
+}
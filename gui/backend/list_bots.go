@@ -52,18 +52,14 @@ func (s *APIServer) listBots(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *APIServer) doListBots(userData UserData) ([]model2.Bot, error) {
-	bots := []model2.Bot{}
-	resultBytes, e := s.kos.Blocking(userData.ID, "ls", fmt.Sprintf("ls %s | sort", s.botConfigsPathForUser(userData.ID).Unix()))
+	e := s.maybeRestoreBotConfigsFromStore(userData.ID)
 	if e != nil {
-		return bots, fmt.Errorf("error when listing bots: %s", e)
+		return nil, fmt.Errorf("unable to restore the bot configs from the state store: %s", e)
 	}
-	configFiles := string(resultBytes)
-	files := strings.Split(configFiles, "\n")
 
-	// run till one less than length of files because the last name will end in a newline
-	for i := 0; i < len(files)-1; i += 2 {
-		bot := model2.FromFilenames(files[i+1], files[i])
-		bots = append(bots, *bot)
+	bots, e := s.listBotConfigs(userData.ID, s.botConfigsPathForUser(userData.ID))
+	if e != nil {
+		return bots, e
 	}
 	log.Printf("bots available: %v", bots)
 
@@ -75,6 +71,12 @@ func (s *APIServer) doListBots(userData UserData) ([]model2.Bot, error) {
 		}
 
 		log.Printf("found bot '%s' with state '%s'\n", bot.Name, botState)
+		persistedState, found, e := ubd.PersistedBotState(bot.Name)
+		if e != nil {
+			log.Printf("unable to read persisted state of bot '%s': %s\n", bot.Name, e)
+		} else if found && persistedState == kelpos.BotStateRunning && botState == kelpos.BotStateStopped {
+			log.Printf("bot '%s' was running before the server was restarted but is not running anymore, start it again to resume trading\n", bot.Name)
+		}
 		// if page is reloaded then bot would already be registered, which is ok -- but we upsert here so it doesn't matter
 		if botState != kelpos.InitState() {
 			ubd.RegisterBotWithStateUpsert(&bot, botState)
@@ -83,3 +85,26 @@ func (s *APIServer) doListBots(userData UserData) ([]model2.Bot, error) {
 
 	return bots, nil
 }
+
+// listBotConfigs lists the bots that have configs in the directory, skipping anything that is not a config file such as the directory of
+// archived bots
+func (s *APIServer) listBotConfigs(userID string, dirPath *kelpos.OSPath) ([]model2.Bot, error) {
+	bots := []model2.Bot{}
+	filenames, e := listFilesWithPrefix(dirPath, "")
+	if e != nil {
+		return bots, fmt.Errorf("error when listing bots: %s", e)
+	}
+	files := []string{}
+	for _, f := range filenames {
+		if strings.HasSuffix(f, ".cfg") {
+			files = append(files, f)
+		}
+	}
+
+	// the strategy config of a bot sorts before its trader config
+	for i := 0; i < len(files)-1; i += 2 {
+		bot := model2.FromFilenames(files[i+1], files[i])
+		bots = append(bots, *bot)
+	}
+	return bots, nil
+}
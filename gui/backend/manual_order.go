@@ -0,0 +1,491 @@
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/support/config"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/gui/model2"
+	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/plugins"
+	"github.com/stellar/kelp/support/statestore"
+	"github.com/stellar/kelp/support/utils"
+	"github.com/stellar/kelp/trader"
+)
+
+// manualOrderAuditFilename is the file in the user's logs directory where every manual order request is recorded
+const manualOrderAuditFilename = "manual_orders_audit.log"
+
+type placeOrderRequest struct {
+	UserData UserData `json:"user_data"`
+	BotName  string   `json:"bot_name"`
+	Action   string   `json:"action"` // "buy" or "sell" the base asset
+	Price    float64  `json:"price"`  // units of quote asset per unit of base asset
+	Amount   float64  `json:"amount"` // units of base asset
+}
+
+type cancelOrderRequest struct {
+	UserData UserData `json:"user_data"`
+	BotName  string   `json:"bot_name"`
+	OrderID  string   `json:"order_id"` // offer ID on SDEX or the order ID on the trading exchange
+}
+
+// manualOrderResponse is the response from the placeOrder and cancelOrder requests
+type manualOrderResponse struct {
+	Exchange     string `json:"exchange"`
+	OrderID      string `json:"order_id,omitempty"`
+	TxHash       string `json:"tx_hash,omitempty"`
+	CancelResult string `json:"cancel_result,omitempty"`
+}
+
+// manualOrderAuditEntry is a single line in the manual order audit log
+type manualOrderAuditEntry struct {
+	Date     string  `json:"date"`
+	UserID   string  `json:"user_id"`
+	BotName  string  `json:"bot_name"`
+	Request  string  `json:"request"`
+	Exchange string  `json:"exchange"`
+	Action   string  `json:"action,omitempty"`
+	Price    float64 `json:"price,omitempty"`
+	Amount   float64 `json:"amount,omitempty"`
+	OrderID  string  `json:"order_id,omitempty"`
+	TxHash   string  `json:"tx_hash,omitempty"`
+	Error    string  `json:"error,omitempty"`
+}
+
+func (s *APIServer) placeOrder(w http.ResponseWriter, r *http.Request) {
+	bodyBytes, e := ioutil.ReadAll(r.Body)
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("error when reading request input: %s\n", e))
+		return
+	}
+	var req placeOrderRequest
+	e = json.Unmarshal(bodyBytes, &req)
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("error unmarshaling json: %s; bodyString = %s", e, string(bodyBytes)))
+		return
+	}
+	if strings.TrimSpace(req.UserData.ID) == "" {
+		s.writeErrorJson(w, fmt.Sprintf("cannot have empty userID"))
+		return
+	}
+	if req.Action != "buy" && req.Action != "sell" {
+		s.writeErrorJson(w, fmt.Sprintf("invalid action '%s', needs to be either 'buy' or 'sell'", req.Action))
+		return
+	}
+	if req.Price <= 0 || req.Amount <= 0 {
+		s.writeErrorJson(w, fmt.Sprintf("price and amount need to be positive, price = %f, amount = %f", req.Price, req.Amount))
+		return
+	}
+	botName := req.BotName
+
+	audit := manualOrderAuditEntry{
+		UserID:  req.UserData.ID,
+		BotName: botName,
+		Request: "placeOrder",
+		Action:  req.Action,
+		Price:   req.Price,
+		Amount:  req.Amount,
+	}
+	resp, e := s.doPlaceOrder(req)
+	if resp != nil {
+		audit.Exchange = resp.Exchange
+		audit.OrderID = resp.OrderID
+		audit.TxHash = resp.TxHash
+	}
+	s.writeManualOrderAudit(req.UserData, audit, e)
+	if e != nil {
+		s.writeKelpError(req.UserData, w, makeKelpErrorResponseWrapper(
+			errorTypeBot,
+			botName,
+			time.Now().UTC(),
+			errorLevelError,
+			fmt.Sprintf("unable to place manual order for bot '%s': %s\n", botName, e),
+		))
+		return
+	}
+	s.writeJson(w, resp)
+}
+
+func (s *APIServer) doPlaceOrder(req placeOrderRequest) (*manualOrderResponse, error) {
+	botConfig, e := s.readManualOrderBotConfig(req.UserData, req.BotName)
+	if e != nil {
+		return nil, e
+	}
+	resp := &manualOrderResponse{Exchange: botConfig.TradingExchangeName()}
+
+	if botConfig.IsTradingSdex() {
+		assetBase := botConfig.AssetBase()
+		assetQuote := botConfig.AssetQuote()
+		op := txnbuild.ManageSellOffer{
+			Selling: utils.Asset2Asset(assetBase),
+			Buying:  utils.Asset2Asset(assetQuote),
+			Amount:  fmt.Sprintf("%.7f", req.Amount),
+			Price:   fmt.Sprintf("%.7f", req.Price),
+		}
+		if req.Action == "buy" {
+			// offers on SDEX are always sell offers so we invert the buy order, the same way the SDEX plugin does it
+			op = txnbuild.ManageSellOffer{
+				Selling: utils.Asset2Asset(assetQuote),
+				Buying:  utils.Asset2Asset(assetBase),
+				Amount:  fmt.Sprintf("%.7f", req.Amount*req.Price),
+				Price:   fmt.Sprintf("%.7f", 1/req.Price),
+			}
+		}
+
+		resp.TxHash, e = s.submitManualOrderOp(botConfig, op)
+		if e != nil {
+			return resp, e
+		}
+		return resp, nil
+	}
+
+	exchangeAPI, e := makeManualOrderExchange(botConfig)
+	if e != nil {
+		return resp, e
+	}
+	tradingPair := manualOrderTradingPair(botConfig)
+	oc := exchangeAPI.GetOrderConstraints(tradingPair)
+	orderAction := model.OrderActionSell
+	if req.Action == "buy" {
+		orderAction = model.OrderActionBuy
+	}
+	txID, e := exchangeAPI.AddOrder(&model.Order{
+		Pair:        tradingPair,
+		OrderAction: orderAction,
+		OrderType:   model.OrderTypeLimit,
+		Price:       model.NumberFromFloat(req.Price, oc.PricePrecision),
+		Volume:      model.NumberFromFloat(req.Amount, oc.VolumePrecision),
+		Timestamp:   nil,
+	}, api.SubmitModeBoth)
+	if e != nil {
+		return resp, fmt.Errorf("could not add order on exchange '%s': %s", resp.Exchange, e)
+	}
+	if txID != nil {
+		resp.OrderID = txID.String()
+	}
+	return resp, nil
+}
+
+func (s *APIServer) cancelOrder(w http.ResponseWriter, r *http.Request) {
+	bodyBytes, e := ioutil.ReadAll(r.Body)
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("error when reading request input: %s\n", e))
+		return
+	}
+	var req cancelOrderRequest
+	e = json.Unmarshal(bodyBytes, &req)
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("error unmarshaling json: %s; bodyString = %s", e, string(bodyBytes)))
+		return
+	}
+	if strings.TrimSpace(req.UserData.ID) == "" {
+		s.writeErrorJson(w, fmt.Sprintf("cannot have empty userID"))
+		return
+	}
+	if strings.TrimSpace(req.OrderID) == "" {
+		s.writeErrorJson(w, fmt.Sprintf("cannot have empty order_id"))
+		return
+	}
+	botName := req.BotName
+
+	audit := manualOrderAuditEntry{
+		UserID:  req.UserData.ID,
+		BotName: botName,
+		Request: "cancelOrder",
+		OrderID: req.OrderID,
+	}
+	resp, e := s.doCancelOrder(req)
+	if resp != nil {
+		audit.Exchange = resp.Exchange
+		audit.TxHash = resp.TxHash
+	}
+	s.writeManualOrderAudit(req.UserData, audit, e)
+	if e != nil {
+		s.writeKelpError(req.UserData, w, makeKelpErrorResponseWrapper(
+			errorTypeBot,
+			botName,
+			time.Now().UTC(),
+			errorLevelError,
+			fmt.Sprintf("unable to cancel order '%s' for bot '%s': %s\n", req.OrderID, botName, e),
+		))
+		return
+	}
+	s.writeJson(w, resp)
+}
+
+func (s *APIServer) doCancelOrder(req cancelOrderRequest) (*manualOrderResponse, error) {
+	botConfig, e := s.readManualOrderBotConfig(req.UserData, req.BotName)
+	if e != nil {
+		return nil, e
+	}
+	resp := &manualOrderResponse{
+		Exchange: botConfig.TradingExchangeName(),
+		OrderID:  req.OrderID,
+	}
+
+	if botConfig.IsTradingSdex() {
+		offerID, e := strconv.ParseInt(req.OrderID, 10, 64)
+		if e != nil {
+			return resp, fmt.Errorf("could not parse order_id '%s' as an SDEX offer ID: %s", req.OrderID, e)
+		}
+
+		offers, e := utils.LoadAllOffers(botConfig.TradingAccount(), s.manualOrderClient(botConfig))
+		if e != nil {
+			return resp, fmt.Errorf("error getting offers for account '%s': %s", botConfig.TradingAccount(), e)
+		}
+		sellingAOffers, buyingAOffers := utils.FilterOffers(offers, botConfig.AssetBase(), botConfig.AssetQuote())
+		// only offers in the bot's market can be cancelled so we never touch offers placed for a different market from the same account
+		for _, offer := range append(sellingAOffers, buyingAOffers...) {
+			if offer.ID != offerID {
+				continue
+			}
+
+			op := utils.Offer2TxnBuildSellOffer(offer)
+			op.Amount = "0"
+			resp.TxHash, e = s.submitManualOrderOp(botConfig, op)
+			if e != nil {
+				return resp, e
+			}
+			resp.CancelResult = model.CancelResultCancelSuccessful.String()
+			return resp, nil
+		}
+		return resp, fmt.Errorf("offer with ID %d does not exist for account '%s' in the bot's market", offerID, botConfig.TradingAccount())
+	}
+
+	exchangeAPI, e := makeManualOrderExchange(botConfig)
+	if e != nil {
+		return resp, e
+	}
+	txID := model.TransactionID(req.OrderID)
+	result, e := exchangeAPI.CancelOrder(&txID, *manualOrderTradingPair(botConfig))
+	if e != nil {
+		return resp, fmt.Errorf("could not cancel order on exchange '%s': %s", resp.Exchange, e)
+	}
+	resp.CancelResult = result.String()
+	if result == model.CancelResultFailed {
+		return resp, fmt.Errorf("exchange '%s' reported that the cancellation failed", resp.Exchange)
+	}
+	return resp, nil
+}
+
+func (s *APIServer) readManualOrderBotConfig(userData UserData, botName string) (*trader.BotConfig, error) {
+	configsPath := s.botConfigsPathForUser(userData.ID)
+	filenames, e := listFilesWithPrefix(configsPath, model2.GetPrefix(botName)+"__")
+	if e != nil {
+		return nil, fmt.Errorf("cannot list config files of bot '%s': %s", botName, e)
+	}
+	strategy, e := botStrategyFromFilenames(botName, filenames)
+	if e != nil {
+		return nil, e
+	}
+
+	filenamePair := model2.GetBotFilenames(botName, strategy)
+	traderFilePath := configsPath.Join(filenamePair.Trader)
+	var botConfig trader.BotConfig
+	e = config.Read(traderFilePath.Native(), &botConfig)
+	if e != nil {
+		return nil, fmt.Errorf("cannot read bot config at path '%s': %s", traderFilePath.AsString(), e)
+	}
+	e = botConfig.Init()
+	if e != nil {
+		return nil, fmt.Errorf("cannot init bot config at path '%s': %s", traderFilePath.AsString(), e)
+	}
+	return &botConfig, nil
+}
+
+// botStrategyFromFilenames returns the strategy of the bot from the name of its strategy config file
+func botStrategyFromFilenames(botName string, filenames []string) (string, error) {
+	prefix := model2.GetPrefix(botName) + "__strategy_"
+	for _, f := range filenames {
+		if strings.HasPrefix(f, prefix) && strings.HasSuffix(f, ".cfg") {
+			return strings.TrimSuffix(strings.TrimPrefix(f, prefix), ".cfg"), nil
+		}
+	}
+	return "", fmt.Errorf("there is no strategy config file for the bot '%s'", botName)
+}
+
+// manualOrderClient returns a client for the horizon in the bot config, reusing the clients of the GUI when the bot uses the same horizon
+func (s *APIServer) manualOrderClient(botConfig *trader.BotConfig) *horizonclient.Client {
+	horizonURL := strings.TrimSuffix(botConfig.HorizonURL, "/")
+	for _, client := range []*horizonclient.Client{s.apiTestNet, s.apiPubNet} {
+		if client != nil && strings.TrimSuffix(client.HorizonURL, "/") == horizonURL {
+			return client
+		}
+	}
+	return &horizonclient.Client{
+		HorizonURL: botConfig.HorizonURL,
+		HTTP:       http.DefaultClient,
+	}
+}
+
+// manualOrderBaseFee returns the fee per operation in stroops from the FEE config of the bot, computed the same way as the bot computes it
+func manualOrderBaseFee(botConfig *trader.BotConfig, client *horizonclient.Client) (int64, error) {
+	if botConfig.Fee == nil {
+		return 0, fmt.Errorf("the FEE object needs to exist in the trader config file when trading on SDEX")
+	}
+
+	feeFn, e := plugins.SdexFeeFnFromStats(
+		botConfig.Fee.CapacityTrigger,
+		botConfig.Fee.Percentile,
+		botConfig.Fee.MaxOpFeeStroops,
+		client,
+	)
+	if e != nil {
+		return 0, fmt.Errorf("invalid FEE config: %s", e)
+	}
+	opFee, e := feeFn()
+	if e != nil {
+		return 0, fmt.Errorf("could not compute fee: %s", e)
+	}
+	return int64(opFee), nil
+}
+
+// submitManualOrderOp submits a transaction with the single op on behalf of the bot's trading account and returns the transaction hash
+func (s *APIServer) submitManualOrderOp(botConfig *trader.BotConfig, op txnbuild.ManageSellOffer) (string, error) {
+	client := s.manualOrderClient(botConfig)
+	// the network is whichever network the horizon in the bot config is connected to
+	root, e := client.Root()
+	if e != nil {
+		return "", fmt.Errorf("unable to load network of horizon '%s': %s", botConfig.HorizonURL, e)
+	}
+	activeNetwork := root.NetworkPassphrase
+	baseFee, e := manualOrderBaseFee(botConfig, client)
+	if e != nil {
+		return "", e
+	}
+
+	// fees and sequence numbers are paid by the source account when it is set, the same way the bot submits transactions
+	signers := []string{botConfig.TradingSecretSeed}
+	sourceAddress := botConfig.TradingAccount()
+	if botConfig.SourceAccount() != "" && botConfig.SourceAccount() != botConfig.TradingAccount() {
+		signers = append(signers, botConfig.SourceSecretSeed)
+		sourceAddress = botConfig.SourceAccount()
+		op.SourceAccount = botConfig.TradingAccount()
+	}
+
+	account, e := client.AccountDetail(horizonclient.AccountRequest{AccountID: sourceAddress})
+	if e != nil {
+		return "", fmt.Errorf("unable to load account for %s: %s", sourceAddress, e)
+	}
+
+	tx, e := txnbuild.NewTransaction(
+		txnbuild.TransactionParams{
+			SourceAccount:        &account,
+			Operations:           []txnbuild.Operation{&op},
+			Timebounds:           txnbuild.NewTimeout(300),
+			BaseFee:              baseFee,
+			IncrementSequenceNum: true,
+		},
+	)
+	if e != nil {
+		return "", fmt.Errorf("cannot make manual order transaction: %s", e)
+	}
+
+	for _, seed := range signers {
+		kp, e := keypair.Parse(seed)
+		if e != nil {
+			return "", fmt.Errorf("cannot parse seed required for signing: %s", e)
+		}
+
+		tx, e = tx.Sign(activeNetwork, kp.(*keypair.Full))
+		if e != nil {
+			return "", fmt.Errorf("cannot sign manual order transaction: %s", e)
+		}
+	}
+
+	txn64, e := tx.Base64()
+	if e != nil {
+		return "", fmt.Errorf("cannot convert manual order transaction to base64: %s", e)
+	}
+
+	txSuccess, e := client.SubmitTransactionXDR(txn64)
+	if e != nil {
+		if herr, ok := e.(*horizonclient.Error); ok {
+			return "", fmt.Errorf("horizon error when submitting manual order transaction: %s (%s)", *herr, txn64)
+		}
+		return "", fmt.Errorf("error when submitting manual order transaction: %s (%s)", e, txn64)
+	}
+	return txSuccess.Hash, nil
+}
+
+func makeManualOrderExchange(botConfig *trader.BotConfig) (api.Exchange, error) {
+	exchangeParams := []api.ExchangeParam{}
+	for _, param := range botConfig.ExchangeParams {
+		exchangeParams = append(exchangeParams, api.ExchangeParam{
+			Param: param.Param,
+			Value: param.Value,
+		})
+	}
+
+	exchangeHeaders := []api.ExchangeHeader{}
+	for _, header := range botConfig.ExchangeHeaders {
+		exchangeHeaders = append(exchangeHeaders, api.ExchangeHeader{
+			Header: header.Header,
+			Value:  header.Value,
+		})
+	}
+
+	exchangeAPI, e := plugins.MakeTradingExchange(botConfig.TradingExchange, botConfig.ExchangeAPIKeys.ToExchangeAPIKeys(), exchangeParams, exchangeHeaders, false)
+	if e != nil {
+		return nil, fmt.Errorf("unable to make trading exchange '%s': %s", botConfig.TradingExchange, e)
+	}
+	return exchangeAPI, nil
+}
+
+func manualOrderTradingPair(botConfig *trader.BotConfig) *model.TradingPair {
+	return &model.TradingPair{
+		Base:  model.Asset(utils.Asset2CodeString(botConfig.AssetBase())),
+		Quote: model.Asset(utils.Asset2CodeString(botConfig.AssetQuote())),
+	}
+}
+
+// writeManualOrderAudit logs the manual order request and appends it to the user's audit log, failures to write the audit log are only logged
+// because the order has already been placed or cancelled at this point
+func (s *APIServer) writeManualOrderAudit(userData UserData, entry manualOrderAuditEntry, opError error) {
+	entry.Date = time.Now().UTC().Format(time.RFC3339)
+	if opError != nil {
+		entry.Error = opError.Error()
+	}
+
+	entryBytes, e := json.Marshal(entry)
+	if e != nil {
+		log.Printf("unable to marshal manual order audit entry (error=%s): %+v\n", e, entry)
+		return
+	}
+	log.Printf("manual order audit: %s\n", string(entryBytes))
+
+	// the audit log is kept in the state-db instead of the logs directory when it is set
+	if dbStore, isDB := s.kos.StateStore().(*statestore.DBStore); isDB {
+		logName := userData.ID + "/" + manualOrderAuditFilename
+		e = dbStore.AppendLog(logName, []statestore.LogLine{{Time: time.Now(), Line: string(entryBytes)}})
+		if e != nil {
+			log.Printf("unable to write to manual order audit log '%s' in the state-db: %s\n", logName, e)
+		}
+		return
+	}
+
+	auditFilePath := s.botLogsPathForUser(userData.ID).Join(manualOrderAuditFilename)
+	f, e := os.OpenFile(auditFilePath.Native(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if e != nil {
+		log.Printf("unable to open manual order audit log at path '%s': %s\n", auditFilePath.AsString(), e)
+		return
+	}
+	defer f.Close()
+
+	_, e = f.Write(append(entryBytes, '\n'))
+	if e != nil {
+		log.Printf("unable to write to manual order audit log at path '%s': %s\n", auditFilePath.AsString(), e)
+	}
+}
@@ -0,0 +1,57 @@
+package backend
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/kelp/trader"
+)
+
+func TestBotStrategyFromFilenames(t *testing.T) {
+	filenames := []string{
+		"my_bot__strategy_mirror.cfg",
+		"my_bot__trader.cfg",
+		"my_bot_2__strategy_sell.cfg",
+		"my_bot_2__trader.cfg",
+	}
+
+	strategy, e := botStrategyFromFilenames("My Bot", filenames)
+	if assert.NoError(t, e) {
+		assert.Equal(t, "mirror", strategy)
+	}
+
+	strategy, e = botStrategyFromFilenames("My Bot 2", filenames)
+	if assert.NoError(t, e) {
+		assert.Equal(t, "sell", strategy)
+	}
+
+	_, e = botStrategyFromFilenames("Other Bot", filenames)
+	assert.Error(t, e)
+}
+
+func TestManualOrderClient(t *testing.T) {
+	testnet := &horizonclient.Client{HorizonURL: "https://horizon-testnet.stellar.org/", HTTP: http.DefaultClient}
+	pubnet := &horizonclient.Client{HorizonURL: "https://horizon.stellar.org", HTTP: http.DefaultClient}
+	s := &APIServer{apiTestNet: testnet, apiPubNet: pubnet}
+
+	assert.Equal(t, testnet, s.manualOrderClient(&trader.BotConfig{HorizonURL: "https://horizon-testnet.stellar.org"}))
+	assert.Equal(t, pubnet, s.manualOrderClient(&trader.BotConfig{HorizonURL: "https://horizon.stellar.org/"}))
+
+	// a bot on a private horizon gets a client for that horizon instead of a guess based on the URL
+	client := s.manualOrderClient(&trader.BotConfig{HorizonURL: "https://my-test-horizon.example.com"})
+	assert.Equal(t, "https://my-test-horizon.example.com", client.HorizonURL)
+}
+
+func TestManualOrderBaseFee(t *testing.T) {
+	client := &horizonclient.Client{HorizonURL: "https://horizon.stellar.org", HTTP: http.DefaultClient}
+
+	// the FEE config is required to trade on SDEX, the same as for the bot
+	_, e := manualOrderBaseFee(&trader.BotConfig{}, client)
+	assert.Error(t, e)
+
+	_, e = manualOrderBaseFee(&trader.BotConfig{Fee: &trader.FeeConfig{CapacityTrigger: 0.8, Percentile: 42, MaxOpFeeStroops: 5000}}, client)
+	assert.Error(t, e)
+}
@@ -0,0 +1,121 @@
+package backend
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/stellar/kelp/queries"
+)
+
+type orderTraceRequest struct {
+	UserData UserData `json:"user_data"`
+	BotName  string   `json:"bot_name"`
+	// exactly one of CorrelationID and OfferID needs to be set, the correlation ID is in the logs of the bot
+	CorrelationID string `json:"correlation_id"`
+	OfferID       string `json:"offer_id"`
+}
+
+// orderTrace is the lifecycle of the op with a correlation ID
+type orderTrace struct {
+	CorrelationID string                    `json:"correlation_id"`
+	Events        []queries.OrderTraceEvent `json:"events"`
+}
+
+// orderTraceResponse is the response from the getOrderTrace request, which has one trace for a correlation ID and one trace for every
+// update cycle that touched the offer for an offer ID
+type orderTraceResponse struct {
+	MarketID  string        `json:"market_id"`
+	AccountID string        `json:"account_id"`
+	Traces    []*orderTrace `json:"traces"`
+}
+
+func (s *APIServer) getOrderTrace(w http.ResponseWriter, r *http.Request) {
+	bodyBytes, e := ioutil.ReadAll(r.Body)
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("error when reading request input: %s", e))
+		return
+	}
+	var req orderTraceRequest
+	e = json.Unmarshal(bodyBytes, &req)
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("error unmarshaling json: %s; bodyString = %s", e, string(bodyBytes)))
+		return
+	}
+	if strings.TrimSpace(req.UserData.ID) == "" {
+		s.writeErrorJson(w, "cannot have empty userID")
+		return
+	}
+
+	resp, e := s.doGetOrderTrace(&req)
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("unable to get order trace for bot '%s': %s", req.BotName, e))
+		return
+	}
+	s.writeJsonWithLog(w, resp, false)
+}
+
+func (s *APIServer) doGetOrderTrace(req *orderTraceRequest) (*orderTraceResponse, error) {
+	correlationID := strings.TrimSpace(req.CorrelationID)
+	offerID := strings.TrimSpace(req.OfferID)
+	if (correlationID == "") == (offerID == "") {
+		return nil, fmt.Errorf("exactly one of correlation_id and offer_id needs to be set")
+	}
+
+	botConfig, marketID, e := s.readBotConfigAndMarketID(req.UserData.ID, req.BotName)
+	if e != nil {
+		return nil, e
+	}
+	if botConfig.PostgresDbConfig == nil || !botConfig.TraceOrders {
+		return nil, fmt.Errorf("bot needs POSTGRES_DB and TRACE_ORDERS to be set in the trader config to trace orders")
+	}
+	accountID := botConfig.DbOverrideAccountID
+
+	db, e := sql.Open("postgres", botConfig.PostgresDbConfig.MakeConnectString())
+	if e != nil {
+		return nil, fmt.Errorf("could not open database: %s", e)
+	}
+	defer db.Close()
+
+	tracesQuery, e := queries.MakeOrderTracesQuery(db, accountID, marketID, offerID != "")
+	if e != nil {
+		return nil, fmt.Errorf("could not make OrderTraces query: %s", e)
+	}
+	id := correlationID
+	if offerID != "" {
+		id = offerID
+	}
+	result, e := tracesQuery.QueryRow(id)
+	if e != nil {
+		return nil, fmt.Errorf("could not query order traces: %s", e)
+	}
+
+	return &orderTraceResponse{
+		MarketID:  marketID,
+		AccountID: accountID,
+		Traces:    groupOrderTraceEvents(result.([]queries.OrderTraceEvent)),
+	}, nil
+}
+
+// groupOrderTraceEvents groups the events by correlation ID in the order of the first event of each correlation ID, events need to be
+// sorted by date
+func groupOrderTraceEvents(events []queries.OrderTraceEvent) []*orderTrace {
+	traces := []*orderTrace{}
+	traceByID := map[string]*orderTrace{}
+	for _, ev := range events {
+		trace, ok := traceByID[ev.CorrelationID]
+		if !ok {
+			trace = &orderTrace{
+				CorrelationID: ev.CorrelationID,
+				Events:        []queries.OrderTraceEvent{},
+			}
+			traceByID[ev.CorrelationID] = trace
+			traces = append(traces, trace)
+		}
+		trace.Events = append(trace.Events, ev)
+	}
+	return traces
+}
@@ -0,0 +1,82 @@
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/stellar/kelp/support/kelpos"
+	"github.com/stellar/kelp/trader"
+)
+
+type pauseBotRequest struct {
+	UserData UserData `json:"user_data"`
+	BotName  string   `json:"bot_name"`
+}
+
+func (s *APIServer) pauseBot(w http.ResponseWriter, r *http.Request) {
+	s.sendBotControlCommand(w, r, trader.ControlCommandPause)
+}
+
+func (s *APIServer) resumeBot(w http.ResponseWriter, r *http.Request) {
+	s.sendBotControlCommand(w, r, trader.ControlCommandResume)
+}
+
+func (s *APIServer) sendBotControlCommand(w http.ResponseWriter, r *http.Request, command trader.ControlCommand) {
+	bodyBytes, e := ioutil.ReadAll(r.Body)
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("error when reading request input: %s\n", e))
+		return
+	}
+	var req pauseBotRequest
+	e = json.Unmarshal(bodyBytes, &req)
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("error unmarshaling json: %s; bodyString = %s", e, string(bodyBytes)))
+		return
+	}
+	if strings.TrimSpace(req.UserData.ID) == "" {
+		s.writeErrorJson(w, fmt.Sprintf("cannot have empty userID"))
+		return
+	}
+	botName := req.BotName
+
+	e = s.doSendBotControlCommand(req.UserData, botName, command)
+	if e != nil {
+		s.writeKelpError(req.UserData, w, makeKelpErrorResponseWrapper(
+			errorTypeBot,
+			botName,
+			time.Now().UTC(),
+			errorLevelWarning,
+			fmt.Sprintf("unable to send control command '%s' to bot: %s\n", command, e),
+		))
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// doSendBotControlCommand writes the command to the stdin of the running bot process, which applies it at the start of its next update cycle
+func (s *APIServer) doSendBotControlCommand(userData UserData, botName string, command trader.ControlCommand) error {
+	botState, e := s.doGetBotState(userData, botName)
+	if e != nil {
+		return fmt.Errorf("unable to get botState: %s", e)
+	}
+	if botState != kelpos.BotStateRunning {
+		return fmt.Errorf("bot needs to be in state '%s' but was in state '%s'", kelpos.BotStateRunning, botState)
+	}
+
+	p, exists := s.kos.GetProcess(userData.ID, botName)
+	if !exists {
+		return fmt.Errorf("could not find process for bot '%s'", botName)
+	}
+	_, e = p.Stdin.Write([]byte(string(command) + "\n"))
+	if e != nil {
+		return fmt.Errorf("could not write control command to process for bot '%s': %s", botName, e)
+	}
+
+	log.Printf("sent control command '%s' to bot '%s'\n", command, botName)
+	return nil
+}
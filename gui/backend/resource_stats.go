@@ -0,0 +1,243 @@
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stellar/kelp/support/guiconfig"
+	"github.com/stellar/kelp/support/kelpos"
+)
+
+// resourceCheckInterval is how often the resource usage of the processes started by the backend is sampled
+const resourceCheckInterval = 15 * time.Second
+
+// ccxtRestUserID and ccxtRestNamespace identify the ccxt-rest process started by the server command
+const ccxtRestUserID = "_"
+const ccxtRestNamespace = "ccxt-rest"
+
+// ProcessResourceStats is the resource usage of a bot or the ccxt-rest process, including all of its child processes
+type ProcessResourceStats struct {
+	Name         string    `json:"name"`
+	PID          int       `json:"pid"`
+	NumProcesses int       `json:"num_processes"`
+	CPUPercent   float64   `json:"cpu_percent"` // average over the last check interval, where 100 is one full core
+	MemoryMB     float64   `json:"memory_mb"`
+	NumRestarts  int       `json:"num_restarts"` // number of times the bot was restarted for exceeding a resource limit
+	SampledAt    time.Time `json:"sampled_at"`
+}
+
+type resourceStatsRequest struct {
+	UserData UserData `json:"user_data"`
+}
+
+type resourceStatsResponse struct {
+	Bots     []ProcessResourceStats          `json:"bots"`
+	CcxtRest *ProcessResourceStats           `json:"ccxt_rest"` // nil when ccxt-rest is not run by the server
+	Limits   *guiconfig.ResourceLimitsConfig `json:"limits"`    // nil when no limits are set
+}
+
+type resourceSample struct {
+	usage           kelpos.ProcessResourceUsage
+	stats           ProcessResourceStats
+	checksOverLimit uint32
+}
+
+// resourceMonitor keeps the latest resource usage of each registered process and decides when a process has exceeded its limits
+type resourceMonitor struct {
+	lock        *sync.Mutex
+	limits      *guiconfig.ResourceLimitsConfig
+	samples     map[string]*resourceSample // keyed by userID:namespace
+	numRestarts map[string]int             // keyed by userID:namespace, kept across restarts
+}
+
+func makeResourceMonitor(limits *guiconfig.ResourceLimitsConfig) *resourceMonitor {
+	return &resourceMonitor{
+		lock:        &sync.Mutex{},
+		limits:      limits,
+		samples:     map[string]*resourceSample{},
+		numRestarts: map[string]int{},
+	}
+}
+
+func resourceKey(userID string, namespace string) string {
+	return fmt.Sprintf("%s:%s", userID, namespace)
+}
+
+func (m *resourceMonitor) hasLimits() bool {
+	return m.limits != nil && (m.limits.MaxCPUPercent > 0 || m.limits.MaxMemoryMB > 0)
+}
+
+// update records the latest usages and returns the restartable processes that were over a limit for NumChecksBeforeRestart consecutive
+// checks. The CPU percent is computed from the difference in CPU time since the previous sample of the same process.
+func (m *resourceMonitor) update(now time.Time, usages []kelpos.ProcessResourceUsage, isRestartable func(u kelpos.ProcessResourceUsage) bool) []kelpos.ProcessResourceUsage {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	numChecksBeforeRestart := uint32(1)
+	if m.limits != nil && m.limits.NumChecksBeforeRestart > 0 {
+		numChecksBeforeRestart = m.limits.NumChecksBeforeRestart
+	}
+
+	toRestart := []kelpos.ProcessResourceUsage{}
+	newSamples := map[string]*resourceSample{}
+	for _, u := range usages {
+		key := resourceKey(u.UserID, u.Namespace)
+		sample := &resourceSample{
+			usage: u,
+			stats: ProcessResourceStats{
+				Name:         u.Namespace,
+				PID:          u.PID,
+				NumProcesses: u.NumProcesses,
+				MemoryMB:     float64(u.MemoryRSSBytes) / (1024 * 1024),
+				NumRestarts:  m.numRestarts[key],
+				SampledAt:    now,
+			},
+		}
+		if prev, ok := m.samples[key]; ok && prev.usage.PID == u.PID {
+			elapsedSeconds := now.Sub(prev.stats.SampledAt).Seconds()
+			if elapsedSeconds > 0 && u.CPUTimeSeconds >= prev.usage.CPUTimeSeconds {
+				sample.stats.CPUPercent = 100 * (u.CPUTimeSeconds - prev.usage.CPUTimeSeconds) / elapsedSeconds
+			}
+			sample.checksOverLimit = prev.checksOverLimit
+		}
+		newSamples[key] = sample
+
+		if !m.hasLimits() || !isRestartable(u) {
+			continue
+		}
+		cpuOverLimit := m.limits.MaxCPUPercent > 0 && sample.stats.CPUPercent > m.limits.MaxCPUPercent
+		memoryOverLimit := m.limits.MaxMemoryMB > 0 && sample.stats.MemoryMB > m.limits.MaxMemoryMB
+		if !cpuOverLimit && !memoryOverLimit {
+			sample.checksOverLimit = 0
+			continue
+		}
+		sample.checksOverLimit++
+		log.Printf("process '%s' (pid=%d) is over its resource limits (cpuPercent=%.2f, memoryMB=%.2f, limits=%+v) for %d of %d checks\n",
+			key, u.PID, sample.stats.CPUPercent, sample.stats.MemoryMB, *m.limits, sample.checksOverLimit, numChecksBeforeRestart)
+		if sample.checksOverLimit >= numChecksBeforeRestart {
+			sample.checksOverLimit = 0
+			m.numRestarts[key]++
+			sample.stats.NumRestarts = m.numRestarts[key]
+			toRestart = append(toRestart, u)
+		}
+	}
+	m.samples = newSamples
+	return toRestart
+}
+
+// get returns the latest stats of the process, or false if it was not sampled yet
+func (m *resourceMonitor) get(userID string, namespace string) (ProcessResourceStats, bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	sample, ok := m.samples[resourceKey(userID, namespace)]
+	if !ok {
+		return ProcessResourceStats{}, false
+	}
+	return sample.stats, true
+}
+
+// monitorResources samples the resource usage of all processes started by the backend and restarts bots that exceed the resource limits
+func (s *APIServer) monitorResources() {
+	if s.resourceMonitor.hasLimits() {
+		log.Printf("monitoring resource usage of bots every %s with limits %+v\n", resourceCheckInterval, *s.resourceMonitor.limits)
+	}
+
+	for {
+		usages, e := s.kos.RegisteredProcessesResourceUsage()
+		if e != nil {
+			log.Printf("unable to fetch resource usage of processes: %s\n", e)
+		} else {
+			toRestart := s.resourceMonitor.update(time.Now().UTC(), usages, s.isRunningBot)
+			for _, u := range toRestart {
+				s.restartBotForResourceLimits(u.UserID, u.Namespace)
+			}
+		}
+		time.Sleep(resourceCheckInterval)
+	}
+}
+
+func (s *APIServer) isRunningBot(u kelpos.ProcessResourceUsage) bool {
+	bot, e := s.kos.BotDataForUser(kelpos.MakeUser(u.UserID)).GetBot(u.Namespace)
+	return e == nil && bot.State == kelpos.BotStateRunning
+}
+
+// restartBotForResourceLimits stops the bot in the same way as the stop button, which deletes its offers, and starts it again once stopped
+func (s *APIServer) restartBotForResourceLimits(userID string, botName string) {
+	userData := UserData{ID: userID}
+	s.addKelpErrorToMap(userData, makeKelpErrorResponseWrapper(
+		errorTypeBot,
+		botName,
+		time.Now().UTC(),
+		errorLevelWarning,
+		"bot exceeded its resource limits and is being restarted",
+	).KelpError)
+
+	e := s.doStopBotWithCallback(userData, botName, func() {
+		eInner := s.doStartBot(userData, botName, "buysell", nil, nil)
+		if eInner == nil {
+			eInner = s.kos.BotDataForUser(userData.toUser()).AdvanceBotState(botName, kelpos.BotStateStopped)
+		}
+		if eInner != nil {
+			s.addKelpErrorToMap(userData, makeKelpErrorResponseWrapper(
+				errorTypeBot,
+				botName,
+				time.Now().UTC(),
+				errorLevelError,
+				fmt.Sprintf("error starting bot again after stopping it for exceeding its resource limits: %s", eInner),
+			).KelpError)
+			return
+		}
+		log.Printf("restarted bot '%s' after it exceeded its resource limits\n", botName)
+	})
+	if e != nil {
+		s.addKelpErrorToMap(userData, makeKelpErrorResponseWrapper(
+			errorTypeBot,
+			botName,
+			time.Now().UTC(),
+			errorLevelError,
+			fmt.Sprintf("error stopping bot that exceeded its resource limits: %s", e),
+		).KelpError)
+	}
+}
+
+func (s *APIServer) getResourceStats(w http.ResponseWriter, r *http.Request) {
+	bodyBytes, e := ioutil.ReadAll(r.Body)
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("error when reading request input: %s\n", e))
+		return
+	}
+	var req resourceStatsRequest
+	e = json.Unmarshal(bodyBytes, &req)
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("error unmarshaling json: %s; bodyString = %s", e, string(bodyBytes)))
+		return
+	}
+	if strings.TrimSpace(req.UserData.ID) == "" {
+		s.writeErrorJson(w, fmt.Sprintf("cannot have empty userID"))
+		return
+	}
+
+	resp := resourceStatsResponse{
+		Bots:   []ProcessResourceStats{},
+		Limits: s.resourceMonitor.limits,
+	}
+	botNames := s.kos.BotDataForUser(req.UserData.toUser()).RegisteredBots()
+	sort.Strings(botNames)
+	for _, botName := range botNames {
+		if stats, ok := s.resourceMonitor.get(req.UserData.ID, botName); ok {
+			resp.Bots = append(resp.Bots, stats)
+		}
+	}
+	if stats, ok := s.resourceMonitor.get(ccxtRestUserID, ccxtRestNamespace); ok {
+		resp.CcxtRest = &stats
+	}
+	s.writeJsonWithLog(w, resp, false)
+}
@@ -0,0 +1,68 @@
+package backend
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/kelp/support/guiconfig"
+	"github.com/stellar/kelp/support/kelpos"
+)
+
+func TestResourceMonitorUpdate(t *testing.T) {
+	m := makeResourceMonitor(&guiconfig.ResourceLimitsConfig{
+		MaxCPUPercent:          50,
+		MaxMemoryMB:            100,
+		NumChecksBeforeRestart: 2,
+	})
+	isRestartable := func(u kelpos.ProcessResourceUsage) bool {
+		return u.Namespace != ccxtRestNamespace
+	}
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	usage := func(namespace string, pid int, cpuTimeSeconds float64, memoryMB uint64) kelpos.ProcessResourceUsage {
+		return kelpos.ProcessResourceUsage{
+			UserID:         "user1",
+			Namespace:      namespace,
+			PID:            pid,
+			NumProcesses:   2,
+			CPUTimeSeconds: cpuTimeSeconds,
+			MemoryRSSBytes: memoryMB * 1024 * 1024,
+		}
+	}
+
+	// the first sample has no cpu percent since there is nothing to compare against
+	toRestart := m.update(now, []kelpos.ProcessResourceUsage{usage("bot1", 10, 100, 10), usage(ccxtRestNamespace, 20, 0, 500)}, isRestartable)
+	assert.Equal(t, 0, len(toRestart))
+	stats, ok := m.get("user1", "bot1")
+	if assert.True(t, ok) {
+		assert.Equal(t, 0.0, stats.CPUPercent)
+		assert.Equal(t, 10.0, stats.MemoryMB)
+	}
+
+	// bot1 uses 60% of a core over 10 seconds, which is over the limit for the first of 2 checks
+	now = now.Add(10 * time.Second)
+	toRestart = m.update(now, []kelpos.ProcessResourceUsage{usage("bot1", 10, 106, 10), usage(ccxtRestNamespace, 20, 0, 500)}, isRestartable)
+	assert.Equal(t, 0, len(toRestart))
+	stats, _ = m.get("user1", "bot1")
+	assert.InDelta(t, 60.0, stats.CPUPercent, 0.0000001)
+
+	// second consecutive check over the limit restarts bot1, ccxt-rest is over the memory limit but is not restartable
+	now = now.Add(10 * time.Second)
+	toRestart = m.update(now, []kelpos.ProcessResourceUsage{usage("bot1", 10, 112, 10), usage(ccxtRestNamespace, 20, 0, 500)}, isRestartable)
+	if assert.Equal(t, 1, len(toRestart)) {
+		assert.Equal(t, "bot1", toRestart[0].Namespace)
+	}
+	stats, _ = m.get("user1", "bot1")
+	assert.Equal(t, 1, stats.NumRestarts)
+
+	// the restarted bot has a new pid so its cpu percent starts over and it keeps the number of restarts
+	now = now.Add(10 * time.Second)
+	toRestart = m.update(now, []kelpos.ProcessResourceUsage{usage("bot1", 11, 1, 10)}, isRestartable)
+	assert.Equal(t, 0, len(toRestart))
+	stats, _ = m.get("user1", "bot1")
+	assert.Equal(t, 0.0, stats.CPUPercent)
+	assert.Equal(t, 1, stats.NumRestarts)
+	_, ok = m.get("user1", ccxtRestNamespace)
+	assert.False(t, ok)
+}
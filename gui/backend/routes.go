@@ -2,13 +2,27 @@ package backend
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
 )
 
+// requestTimeout is how long a request can take before its context is cancelled, it is not set on the streams since they are kept
+// open for as long as the client is connected
+const requestTimeout = 60 * time.Second
+
 // SetRoutes adds the handlers for the endpoints
 func SetRoutes(r *chi.Mux, s *APIServer) {
 	r.Route("/api/v1", func(r chi.Router) {
+		var streamRouter chi.Router = r
+		if s.guiConfig.Auth0Config != nil && s.guiConfig.Auth0Config.Auth0Enabled {
+			streamRouter = r.With(JWTMiddlewareVar.Handler)
+		}
+		streamRouter.Get("/streamDecisions", http.HandlerFunc(s.streamDecisions))
+
+		// every other route is served with the request timeout
+		r = r.With(middleware.Timeout(requestTimeout))
 		if !s.enableKaas {
 			// /quit is only enabled when we are not in KaaS mode
 			r.Get("/quit", http.HandlerFunc(s.quit))
@@ -32,13 +46,31 @@ func SetRoutes(r *chi.Mux, s *APIServer) {
 		router.Post("/removeKelpErrors", http.HandlerFunc(s.removeKelpErrors))
 		router.Post("/start", http.HandlerFunc(s.startBot))
 		router.Post("/stop", http.HandlerFunc(s.stopBot))
+		router.Post("/pauseBot", http.HandlerFunc(s.pauseBot))
+		router.Post("/resumeBot", http.HandlerFunc(s.resumeBot))
 		router.Post("/deleteBot", http.HandlerFunc(s.deleteBot))
+		router.Post("/archiveBot", http.HandlerFunc(s.archiveBot))
+		router.Post("/restoreBot", http.HandlerFunc(s.restoreBot))
+		router.Post("/listArchivedBots", http.HandlerFunc(s.listArchivedBots))
 		router.Post("/getState", http.HandlerFunc(s.getBotState))
 		router.Post("/getBotInfo", http.HandlerFunc(s.getBotInfo))
 		router.Post("/getBotConfig", http.HandlerFunc(s.getBotConfig))
 		router.Post("/fetchPrice", http.HandlerFunc(s.fetchPrice))
 		router.Post("/upsertBotConfig", http.HandlerFunc(s.upsertBotConfig))
 		router.Post("/sendMetricEvent", http.HandlerFunc(s.sendMetricEvent))
+		router.Post("/placeOrder", http.HandlerFunc(s.placeOrder))
+		router.Post("/cancelOrder", http.HandlerFunc(s.cancelOrder))
+		router.Post("/getInventoryLots", http.HandlerFunc(s.getInventoryLots))
+		router.Post("/exportInventoryLotClosures", http.HandlerFunc(s.exportInventoryLotClosures))
+		router.Post("/getSpreadObligations", http.HandlerFunc(s.getSpreadObligations))
+		router.Post("/getLevelStats", http.HandlerFunc(s.getLevelStats))
+		router.Post("/getOrderTrace", http.HandlerFunc(s.getOrderTrace))
+		router.Post("/getDecisions", http.HandlerFunc(s.getDecisions))
+		router.Post("/getResourceStats", http.HandlerFunc(s.getResourceStats))
+		router.Post("/jobs", http.HandlerFunc(s.startJob))
+		router.Get("/jobs", http.HandlerFunc(s.listJobs))
+		router.Get("/jobs/{jobID}", http.HandlerFunc(s.getJob))
+		router.Post("/jobs/{jobID}/cancel", http.HandlerFunc(s.cancelJob))
 	})
-	r.Get("/ping", http.HandlerFunc(s.ping))
+	r.With(middleware.Timeout(requestTimeout)).Get("/ping", http.HandlerFunc(s.ping))
 }
\ No newline at end of file
@@ -0,0 +1,132 @@
+package backend
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/stellar/kelp/queries"
+)
+
+// defaultSpreadObligationWindow is the time range that is reported when the request does not specify a start_date
+const defaultSpreadObligationWindow = 24 * time.Hour
+
+type spreadObligationsRequest struct {
+	UserData UserData `json:"user_data"`
+	BotName  string   `json:"bot_name"`
+	// StartDate and EndDate are RFC3339 timestamps that limit the report to the range [StartDate, EndDate), both are optional
+	StartDate string `json:"start_date"`
+	EndDate   string `json:"end_date"`
+}
+
+// spreadObligationsResponse is the response from the getSpreadObligations request. The percentages of the window count the time that the
+// bot was not running as time when the terms were not met, which is how uptime is usually measured in market-making agreements
+type spreadObligationsResponse struct {
+	MarketID              string                           `json:"market_id"`
+	AccountID             string                           `json:"account_id"`
+	StartDate             time.Time                        `json:"start_date"`
+	EndDate               time.Time                        `json:"end_date"`
+	MaxSpreadBps          float64                          `json:"max_spread_bps"`
+	MinDepth              float64                          `json:"min_depth"`
+	Summary               *queries.SpreadObligationSummary `json:"summary"`
+	PercentWindowMet      float64                          `json:"percent_window_met"` // both sides met the terms
+	PercentWindowBidMet   float64                          `json:"percent_window_bid_met"`
+	PercentWindowAskMet   float64                          `json:"percent_window_ask_met"`
+	PercentWindowCovered  float64                          `json:"percent_window_covered"`   // the bot was running
+	PercentCoveredTimeMet float64                          `json:"percent_covered_time_met"` // both sides met the terms while the bot was running
+}
+
+func (s *APIServer) getSpreadObligations(w http.ResponseWriter, r *http.Request) {
+	bodyBytes, e := ioutil.ReadAll(r.Body)
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("error when reading request input: %s", e))
+		return
+	}
+	var req spreadObligationsRequest
+	e = json.Unmarshal(bodyBytes, &req)
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("error unmarshaling json: %s; bodyString = %s", e, string(bodyBytes)))
+		return
+	}
+	if strings.TrimSpace(req.UserData.ID) == "" {
+		s.writeErrorJson(w, "cannot have empty userID")
+		return
+	}
+
+	resp, e := s.doGetSpreadObligations(&req)
+	if e != nil {
+		s.writeErrorJson(w, fmt.Sprintf("unable to get spread obligations for bot '%s': %s", req.BotName, e))
+		return
+	}
+	s.writeJsonWithLog(w, resp, false)
+}
+
+func (s *APIServer) doGetSpreadObligations(req *spreadObligationsRequest) (*spreadObligationsResponse, error) {
+	end := time.Now()
+	if req.EndDate != "" {
+		t, e := time.Parse(time.RFC3339, req.EndDate)
+		if e != nil {
+			return nil, fmt.Errorf("invalid end_date '%s': %s", req.EndDate, e)
+		}
+		end = t
+	}
+	start := end.Add(-defaultSpreadObligationWindow)
+	if req.StartDate != "" {
+		t, e := time.Parse(time.RFC3339, req.StartDate)
+		if e != nil {
+			return nil, fmt.Errorf("invalid start_date '%s': %s", req.StartDate, e)
+		}
+		start = t
+	}
+	if !start.Before(end) {
+		return nil, fmt.Errorf("start_date (%s) needs to be before end_date (%s)", start.Format(time.RFC3339), end.Format(time.RFC3339))
+	}
+
+	botConfig, marketID, e := s.readBotConfigAndMarketID(req.UserData.ID, req.BotName)
+	if e != nil {
+		return nil, e
+	}
+	if botConfig.PostgresDbConfig == nil || botConfig.SpreadObligationBps == 0 {
+		return nil, fmt.Errorf("bot needs POSTGRES_DB and SPREAD_OBLIGATION_BPS to be set in the trader config to track spread obligations")
+	}
+	accountID := botConfig.DbOverrideAccountID
+
+	db, e := sql.Open("postgres", botConfig.PostgresDbConfig.MakeConnectString())
+	if e != nil {
+		return nil, fmt.Errorf("could not open database: %s", e)
+	}
+	defer db.Close()
+
+	summaryQuery, e := queries.MakeSpreadObligationSummaryQuery(db, accountID, marketID)
+	if e != nil {
+		return nil, fmt.Errorf("could not make SpreadObligationSummary query: %s", e)
+	}
+	summaryResult, e := summaryQuery.QueryRow(start, end)
+	if e != nil {
+		return nil, fmt.Errorf("could not query spread obligation summary: %s", e)
+	}
+	summary := summaryResult.(*queries.SpreadObligationSummary)
+
+	windowSeconds := end.Sub(start).Seconds()
+	resp := &spreadObligationsResponse{
+		MarketID:             marketID,
+		AccountID:            accountID,
+		StartDate:            start.UTC(),
+		EndDate:              end.UTC(),
+		MaxSpreadBps:         botConfig.SpreadObligationBps,
+		MinDepth:             botConfig.SpreadObligationMinDepth,
+		Summary:              summary,
+		PercentWindowMet:     100 * summary.BothMetSeconds / windowSeconds,
+		PercentWindowBidMet:  100 * summary.BidMetSeconds / windowSeconds,
+		PercentWindowAskMet:  100 * summary.AskMetSeconds / windowSeconds,
+		PercentWindowCovered: 100 * summary.CoveredSeconds / windowSeconds,
+	}
+	if summary.CoveredSeconds > 0 {
+		resp.PercentCoveredTimeMet = 100 * summary.BothMetSeconds / summary.CoveredSeconds
+	}
+	return resp, nil
+}
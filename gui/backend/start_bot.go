@@ -127,26 +127,30 @@ func (s *APIServer) doStartBot(userData UserData, botName string, strategy strin
 	if s.enableKaas {
 		triggerMode = constants.TriggerKaas
 	}
-	command := fmt.Sprintf("trade -c %s -s %s -f %s -l %s --trigger %s --gui-user-id %s",
-		traderRelativeConfigPath.Unix(),
-		strategy,
-		stratRelativeConfigPath.Unix(),
-		logRelativePrefixPath.Unix(),
-		triggerMode,
-		userData.ID,
-	)
+	args := []string{
+		"trade",
+		"-c", traderRelativeConfigPath.Unix(),
+		"-s", strategy,
+		"-l", logRelativePrefixPath.Unix(),
+		"--trigger", triggerMode,
+		"--gui-user-id", userData.ID,
+	}
+	// the strategy config of the bot is not a config for the delete strategy, which deletes all offers when it does not have a config
+	if strategy != "delete" {
+		args = append(args, "-f", stratRelativeConfigPath.Unix())
+	}
 	if iterations != nil {
-		command = fmt.Sprintf("%s --iter %d", command, *iterations)
+		args = append(args, "--iter", fmt.Sprintf("%d", *iterations))
 	}
 	if s.noHeaders {
-		command = fmt.Sprintf("%s --no-headers", command)
+		args = append(args, "--no-headers")
 	}
 	if s.ccxtRestUrl != "" {
-		command = fmt.Sprintf("%s --ccxt-rest-url %s", command, s.ccxtRestUrl)
+		args = append(args, "--ccxt-rest-url", s.ccxtRestUrl)
 	}
-	log.Printf("run command for bot '%s': %s\n", botName, command)
+	log.Printf("run command for bot '%s': %v\n", botName, args)
 
-	p, e := s.runKelpCommandBackground(userData.ID, botName, command)
+	p, e := s.runKelpCommandBackground(userData.ID, botName, args...)
 	if e != nil {
 		return fmt.Errorf("could not start bot %s: %s", botName, e)
 	}
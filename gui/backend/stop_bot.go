@@ -50,6 +50,11 @@ func (s *APIServer) stopBot(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *APIServer) doStopBot(userData UserData, botName string) error {
+	return s.doStopBotWithCallback(userData, botName, nil)
+}
+
+// doStopBotWithCallback stops the bot and deletes its offers, maybeStoppedCallback is invoked once the bot is in the stopped state
+func (s *APIServer) doStopBotWithCallback(userData UserData, botName string, maybeStoppedCallback func()) error {
 	e := s.kos.BotDataForUser(userData.toUser()).AdvanceBotState(botName, kelpos.BotStateRunning)
 	if e != nil {
 		return fmt.Errorf("error advancing bot state: %s", e)
@@ -73,6 +78,10 @@ func (s *APIServer) doStopBot(userData UserData, botName string) error {
 				fmt.Sprintf("error running deleteFinishCallback when stopping bot: %s", eInner),
 			).KelpError)
 			log.Printf("error running deleteFinishCallback when stopping bot: %s", eInner)
+			return
+		}
+		if maybeStoppedCallback != nil {
+			maybeStoppedCallback()
 		}
 	})
 	if e != nil {
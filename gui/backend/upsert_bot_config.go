@@ -118,11 +118,12 @@ func (s *APIServer) upsertBotConfig(w http.ResponseWriter, r *http.Request) {
 	strategyFilePath := s.botConfigsPathForUser(userID).Join(filenamePair.Strategy)
 	strategyConfig := req.StrategyConfig
 	log.Printf("upsert strategy config to file: %s\n", strategyFilePath.AsString())
-	e = toml.WriteFile(strategyFilePath.Native(), &strategyConfig)
+	e = plugins.WriteStrategyConfig(req.Strategy, strategyFilePath.Native(), &strategyConfig)
 	if e != nil {
 		s.writeErrorJson(w, fmt.Sprintf("error writing strategy toml file for bot '%s': %s", req.Name, e))
 		return
 	}
+	s.saveBotConfigsToStore(userID)
 
 	// check if we need to create new funding accounts and new trustlines
 	s.reinitBotCheck(reqWrapper.UserData, req)
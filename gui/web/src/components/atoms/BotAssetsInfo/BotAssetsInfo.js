@@ -2,6 +2,17 @@ import React, { Component } from 'react';
 import styles from './BotAssetsInfo.module.scss';
 import InfoIcon from '../InfoIcon/InfoIcon';
 
+// formatBalance uses the display decimals of the asset, a negative balance means it is not loaded yet
+function formatBalance(balance, displayDecimals) {
+  if (balance < 0) {
+    return "?";
+  }
+  if (displayDecimals === undefined || displayDecimals === null) {
+    return balance;
+  }
+  return Number(balance).toFixed(displayDecimals);
+}
+
 class BotAssetsInfo extends Component {
   render() {
     const updatedDate = new Date(this.props.lastUpdated);
@@ -16,7 +27,7 @@ class BotAssetsInfo extends Component {
             <InfoIcon issuer={this.props.assetBaseIssuer}/>
           </div>
           <span className={styles.assetValue}> </span>
-          <span className={styles.assetValue}>{this.props.assetBaseBalance < 0 ? "?" : this.props.assetBaseBalance}</span>
+          <span className={styles.assetValue}>{formatBalance(this.props.assetBaseBalance, this.props.assetBaseDisplayDecimals)}</span>
         </div>
         <div className={styles.quoteAssetLine}>
           <span className={styles.assetCode}>{this.props.assetQuoteCode}</span>
@@ -25,7 +36,7 @@ class BotAssetsInfo extends Component {
             <InfoIcon issuer={this.props.assetQuoteIssuer}/>
           </div> 
           <span className={styles.assetValue}> </span>
-          <span className={styles.assetValue}>{this.props.assetQuoteBalance < 0 ? "?" : this.props.assetQuoteBalance}</span>
+          <span className={styles.assetValue}>{formatBalance(this.props.assetQuoteBalance, this.props.assetQuoteDisplayDecimals)}</span>
         </div>
         <div className={styles.lastUpdatedLine}>
             <span className={styles.lastUpdatedField}>Updated</span>
@@ -39,6 +39,8 @@ let defaultBotInfo = {
   },
   "balance_base": -1,
   "balance_quote": -1,
+  "display_decimals_base": 7,
+  "display_decimals_quote": 7,
   "num_bids": -1,
   "num_asks": -1,
   "spread_value": "?",
@@ -406,9 +408,11 @@ class BotCard extends Component {
               assetBaseCode={this.state.botInfo.trading_pair.Base}
               assetBaseIssuer={this.state.botInfo.asset_base.asset_issuer}
               assetBaseBalance={this.state.botInfo.balance_base}
+              assetBaseDisplayDecimals={this.state.botInfo.display_decimals_base}
               assetQuoteCode={this.state.botInfo.trading_pair.Quote}
               assetQuoteIssuer={this.state.botInfo.asset_quote.asset_issuer}
               assetQuoteBalance={this.state.botInfo.balance_quote}
+              assetQuoteDisplayDecimals={this.state.botInfo.display_decimals_quote}
               lastUpdated={this.state.botInfo.last_updated}
             />
           </div>
@@ -8,6 +8,26 @@ const SqlTradesTableCreate = "CREATE TABLE IF NOT EXISTS trades (market_id TEXT
 const SqlTradesTableAlter1 = "ALTER TABLE trades ADD COLUMN account_id TEXT"
 const SqlStrategyMirrorTradeTriggersTableCreate = "CREATE TABLE IF NOT EXISTS strategy_mirror_trade_triggers (market_id TEXT NOT NULL, txid TEXT NOT NULL, backing_market_id TEXT NOT NULL, backing_order_id TEXT NOT NULL, PRIMARY KEY (market_id, txid))"
 const SqlTradesTableAlter2 = "ALTER TABLE trades ADD COLUMN order_id TEXT"
+const SqlTrailingStopMarksTableCreate = "CREATE TABLE IF NOT EXISTS trailing_stop_marks (market_id TEXT NOT NULL, price_feed TEXT NOT NULL, high_water_mark DOUBLE PRECISION NOT NULL, date_updated_utc TIMESTAMP WITHOUT TIME ZONE NOT NULL, PRIMARY KEY (market_id, price_feed))"
+const SqlStrategyMirrorNettingPositionsTableCreate = "CREATE TABLE IF NOT EXISTS strategy_mirror_netting_positions (netting_group TEXT NOT NULL, backing_market_id TEXT NOT NULL, net_base_volume DOUBLE PRECISION NOT NULL, date_updated_utc TIMESTAMP WITHOUT TIME ZONE NOT NULL, PRIMARY KEY (netting_group, backing_market_id))"
+const SqlTimeseriesPointsTableCreate = "CREATE TABLE IF NOT EXISTS timeseries_points (series TEXT NOT NULL, label TEXT NOT NULL, date_utc TIMESTAMP WITHOUT TIME ZONE NOT NULL, value DOUBLE PRECISION NOT NULL, PRIMARY KEY (series, label, date_utc))"
+const SqlTimeseriesRollupsTableCreate = "CREATE TABLE IF NOT EXISTS timeseries_rollups (series TEXT NOT NULL, label TEXT NOT NULL, bucket_start_utc TIMESTAMP WITHOUT TIME ZONE NOT NULL, bucket_seconds INTEGER NOT NULL, min_value DOUBLE PRECISION NOT NULL, max_value DOUBLE PRECISION NOT NULL, avg_value DOUBLE PRECISION NOT NULL, num_points INTEGER NOT NULL, PRIMARY KEY (series, label, bucket_seconds, bucket_start_utc))"
+const SqlInventoryLotsTableCreate = "CREATE TABLE IF NOT EXISTS inventory_lots (account_id TEXT NOT NULL, market_id TEXT NOT NULL, lot_txid TEXT NOT NULL, date_opened_utc TIMESTAMP WITHOUT TIME ZONE NOT NULL, side TEXT NOT NULL, price DOUBLE PRECISION NOT NULL, base_volume DOUBLE PRECISION NOT NULL, remaining_base_volume DOUBLE PRECISION NOT NULL, PRIMARY KEY (account_id, market_id, lot_txid))"
+const SqlInventoryLotClosuresTableCreate = "CREATE TABLE IF NOT EXISTS inventory_lot_closures (account_id TEXT NOT NULL, market_id TEXT NOT NULL, lot_txid TEXT NOT NULL, closing_txid TEXT NOT NULL, date_opened_utc TIMESTAMP WITHOUT TIME ZONE NOT NULL, date_closed_utc TIMESTAMP WITHOUT TIME ZONE NOT NULL, side TEXT NOT NULL, base_volume DOUBLE PRECISION NOT NULL, open_price DOUBLE PRECISION NOT NULL, close_price DOUBLE PRECISION NOT NULL, realized_pnl DOUBLE PRECISION NOT NULL, PRIMARY KEY (account_id, market_id, lot_txid, closing_txid))"
+const SqlSpreadObligationSamplesTableCreate = "CREATE TABLE IF NOT EXISTS spread_obligation_samples (account_id TEXT NOT NULL, market_id TEXT NOT NULL, date_utc TIMESTAMP WITHOUT TIME ZONE NOT NULL, duration_seconds DOUBLE PRECISION NOT NULL, reference_price DOUBLE PRECISION NOT NULL, max_spread_bps DOUBLE PRECISION NOT NULL, min_depth DOUBLE PRECISION NOT NULL, bid_depth DOUBLE PRECISION NOT NULL, ask_depth DOUBLE PRECISION NOT NULL, bid_met BOOLEAN NOT NULL, ask_met BOOLEAN NOT NULL, PRIMARY KEY (account_id, market_id, date_utc))"
+const SqlBotQuotesTableCreate = "CREATE TABLE IF NOT EXISTS bot_quotes (bot_id TEXT NOT NULL, base_asset TEXT NOT NULL, quote_asset TEXT NOT NULL, best_bid DOUBLE PRECISION, best_ask DOUBLE PRECISION, date_updated_utc TIMESTAMP WITHOUT TIME ZONE NOT NULL, PRIMARY KEY (bot_id))"
+const SqlLevelStatsTableCreate = "CREATE TABLE IF NOT EXISTS level_stats (account_id TEXT NOT NULL, market_id TEXT NOT NULL, date_utc DATE NOT NULL, side TEXT NOT NULL, level INTEGER NOT NULL, spread DOUBLE PRECISION NOT NULL, num_updates INTEGER NOT NULL, num_fills INTEGER NOT NULL, filled_base_volume DOUBLE PRECISION NOT NULL, filled_quote_volume DOUBLE PRECISION NOT NULL, spread_capture DOUBLE PRECISION NOT NULL, PRIMARY KEY (account_id, market_id, date_utc, side, level))"
+const SqlOrderTracesTableCreate = "CREATE TABLE IF NOT EXISTS order_traces (account_id TEXT NOT NULL, market_id TEXT NOT NULL, correlation_id TEXT NOT NULL, date_utc TIMESTAMP WITHOUT TIME ZONE NOT NULL, stage TEXT NOT NULL, side TEXT NOT NULL, offer_id TEXT NOT NULL, price DOUBLE PRECISION NOT NULL, amount DOUBLE PRECISION NOT NULL, detail TEXT NOT NULL)"
+const SqlStrategyMirrorTradeTriggersTableAlter1 = "ALTER TABLE strategy_mirror_trade_triggers ADD COLUMN fx_rate DOUBLE PRECISION"
+const SqlDecisionRecordsTableCreate = "CREATE TABLE IF NOT EXISTS decision_records (account_id TEXT NOT NULL, market_id TEXT NOT NULL, date_utc TIMESTAMP WITHOUT TIME ZONE NOT NULL, outcome TEXT NOT NULL, record TEXT NOT NULL, PRIMARY KEY (account_id, market_id, date_utc))"
+const SqlTrailingStopMarksTableAlter1 = "ALTER TABLE trailing_stop_marks ADD COLUMN stopped_utc TIMESTAMP WITHOUT TIME ZONE"
+const SqlStrategyMirrorNettingPositionsTableAlter1 = "ALTER TABLE strategy_mirror_netting_positions ADD COLUMN net_quote_volume DOUBLE PRECISION NOT NULL DEFAULT 0"
+const SqlPortfolioBudgetsTableCreate = "CREATE TABLE IF NOT EXISTS portfolio_budgets (bot_id TEXT NOT NULL, budget DOUBLE PRECISION NOT NULL, realized_pnl DOUBLE PRECISION NOT NULL, max_exposure_base DOUBLE PRECISION NOT NULL, max_daily_volume_quote DOUBLE PRECISION NOT NULL, date_updated_utc TIMESTAMP WITHOUT TIME ZONE NOT NULL, PRIMARY KEY (bot_id))"
+const SqlStrategyMirrorPendingOffsetsTableCreate = "CREATE TABLE IF NOT EXISTS strategy_mirror_pending_offsets (market_id TEXT NOT NULL, txid TEXT NOT NULL, action TEXT NOT NULL, counter_price DOUBLE PRECISION NOT NULL, base_volume DOUBLE PRECISION NOT NULL, date_added_utc TIMESTAMP WITHOUT TIME ZONE NOT NULL, PRIMARY KEY (market_id, txid))"
+const SqlDecisionRecordsTableAlter1 = "ALTER TABLE decision_records ADD COLUMN seq BIGSERIAL NOT NULL"
+const SqlDecisionRecordsTableAlter2 = "ALTER TABLE decision_records DROP CONSTRAINT decision_records_pkey, ADD PRIMARY KEY (account_id, market_id, date_utc, seq)"
+const SqlStrategyIcebergFillsTableCreate = "CREATE TABLE IF NOT EXISTS strategy_iceberg_fills (market_id TEXT NOT NULL, txid TEXT NOT NULL, side TEXT NOT NULL, level INTEGER NOT NULL, base_volume DOUBLE PRECISION NOT NULL, date_utc TIMESTAMP WITHOUT TIME ZONE NOT NULL, PRIMARY KEY (market_id, txid))"
+const SqlDailyTradeStatsTableCreate = "CREATE TABLE IF NOT EXISTS daily_trade_stats (market_id TEXT NOT NULL, account_id TEXT NOT NULL, date_utc DATE NOT NULL, action TEXT NOT NULL, num_trades INTEGER NOT NULL, base_volume DOUBLE PRECISION NOT NULL, counter_volume DOUBLE PRECISION NOT NULL, fee DOUBLE PRECISION NOT NULL, PRIMARY KEY (market_id, account_id, date_utc, action))"
 
 /*
 	indexes
@@ -21,6 +41,17 @@ const SqlTradesIndexCreate2 = "CREATE INDEX IF NOT EXISTS trades_mdd ON trades (
 // of this unique index and we don't use this index for queries yet (we will later)
 const SqlTradesIndexCreate3 = "CREATE UNIQUE INDEX IF NOT EXISTS trades_amt ON trades (account_id, market_id, txid)"
 
+// retention deletes by series and date across all labels so we need an index that leads with the date within a series
+const SqlTimeseriesPointsIndexCreate = "CREATE INDEX IF NOT EXISTS timeseries_points_sd ON timeseries_points (series, date_utc)"
+
+// lots are consumed in order of the date they were opened and closures are exported by date range, so both tables need a date index
+const SqlInventoryLotsIndexCreate = "CREATE INDEX IF NOT EXISTS inventory_lots_amsd ON inventory_lots (account_id, market_id, side, date_opened_utc)"
+const SqlInventoryLotClosuresIndexCreate = "CREATE INDEX IF NOT EXISTS inventory_lot_closures_amd ON inventory_lot_closures (account_id, market_id, date_closed_utc)"
+
+// the lifecycle of an offer is looked up by its correlation ID or by the ID of the offer on the exchange
+const SqlOrderTracesIndexCreate = "CREATE INDEX IF NOT EXISTS order_traces_amcd ON order_traces (account_id, market_id, correlation_id, date_utc)"
+const SqlOrderTracesIndexCreate2 = "CREATE INDEX IF NOT EXISTS order_traces_amo ON order_traces (account_id, market_id, offer_id)"
+
 /*
 	insert statements
 */
@@ -30,11 +61,128 @@ const SqlMarketsInsertTemplate = "INSERT INTO markets (market_id, exchange_name,
 // SqlTradesInsertTemplate inserts into the trades table
 const SqlTradesInsertTemplate = "INSERT INTO trades (market_id, txid, date_utc, action, type, counter_price, base_volume, counter_cost, fee, account_id, order_id) VALUES ('%s', '%s', '%s', '%s', '%s', %.15f, %.15f, %.15f, %.15f, '%s', '%s')"
 
-// SqlStrategyMirrorTradeTriggersInsertTemplate inserts into the strategy_mirror_trade_triggers table
-const SqlStrategyMirrorTradeTriggersInsertTemplate = "INSERT INTO strategy_mirror_trade_triggers (market_id, txid, backing_market_id, backing_order_id) VALUES ('%s', '%s', '%s', '%s')"
+// SqlStrategyMirrorTradeTriggersInsertTemplate inserts into the strategy_mirror_trade_triggers table, the fx_rate is NULL when the backing
+// pair has the same quote asset as the primary pair
+const SqlStrategyMirrorTradeTriggersInsertTemplate = "INSERT INTO strategy_mirror_trade_triggers (market_id, txid, backing_market_id, backing_order_id, fx_rate) VALUES ('%s', '%s', '%s', '%s', %s)"
+
+// SqlTrailingStopMarksUpsertTemplate inserts or updates the high water mark in the trailing_stop_marks table
+const SqlTrailingStopMarksUpsertTemplate = "INSERT INTO trailing_stop_marks (market_id, price_feed, high_water_mark, date_updated_utc) VALUES ('%s', '%s', %.15f, '%s') ON CONFLICT (market_id, price_feed) DO UPDATE SET high_water_mark = EXCLUDED.high_water_mark, date_updated_utc = EXCLUDED.date_updated_utc"
+
+// SqlTrailingStopMarksLatchTemplate latches the trailing stop in the trailing_stop_marks table, it stays latched until the row is deleted
+const SqlTrailingStopMarksLatchTemplate = "UPDATE trailing_stop_marks SET stopped_utc = '%s' WHERE market_id = '%s' AND price_feed = '%s' AND stopped_utc IS NULL"
+
+// SqlStrategyMirrorNettingPositionsLockTemplate creates the row in the strategy_mirror_netting_positions table if needed and returns the net
+// position, the row stays locked until the enclosing transaction ends
+const SqlStrategyMirrorNettingPositionsLockTemplate = "INSERT INTO strategy_mirror_netting_positions (netting_group, backing_market_id, net_base_volume, net_quote_volume, date_updated_utc) VALUES ('%s', '%s', 0, 0, '%s') ON CONFLICT (netting_group, backing_market_id) DO UPDATE SET date_updated_utc = EXCLUDED.date_updated_utc RETURNING net_base_volume, net_quote_volume"
+
+// SqlStrategyMirrorNettingPositionsUpdateTemplate sets the net position in the strategy_mirror_netting_positions table
+const SqlStrategyMirrorNettingPositionsUpdateTemplate = "UPDATE strategy_mirror_netting_positions SET net_base_volume = %.15f, net_quote_volume = %.15f, date_updated_utc = '%s' WHERE netting_group = '%s' AND backing_market_id = '%s'"
+
+// SqlTimeseriesPointsUpsertTemplate inserts or replaces a point in the timeseries_points table
+const SqlTimeseriesPointsUpsertTemplate = "INSERT INTO timeseries_points (series, label, date_utc, value) VALUES ('%s', '%s', '%s', %.15f) ON CONFLICT (series, label, date_utc) DO UPDATE SET value = EXCLUDED.value"
+
+// SqlTimeseriesRollupsDownsampleTemplate aggregates the raw points of a series older than the cutoff into buckets in the timeseries_rollups table,
+// merging with any existing bucket so it is safe to run repeatedly
+const SqlTimeseriesRollupsDownsampleTemplate = "INSERT INTO timeseries_rollups (series, label, bucket_start_utc, bucket_seconds, min_value, max_value, avg_value, num_points) " +
+	"SELECT series, label, to_timestamp(floor(extract(epoch FROM date_utc) / %[2]d) * %[2]d) AT TIME ZONE 'UTC' AS bucket_start_utc, %[2]d, MIN(value), MAX(value), AVG(value), COUNT(*) " +
+	"FROM timeseries_points WHERE series = '%[1]s' AND date_utc < '%[3]s' GROUP BY series, label, bucket_start_utc " +
+	"ON CONFLICT (series, label, bucket_seconds, bucket_start_utc) DO UPDATE SET " +
+	"min_value = LEAST(timeseries_rollups.min_value, EXCLUDED.min_value), " +
+	"max_value = GREATEST(timeseries_rollups.max_value, EXCLUDED.max_value), " +
+	"avg_value = (timeseries_rollups.avg_value * timeseries_rollups.num_points + EXCLUDED.avg_value * EXCLUDED.num_points) / (timeseries_rollups.num_points + EXCLUDED.num_points), " +
+	"num_points = timeseries_rollups.num_points + EXCLUDED.num_points"
+
+// SqlInventoryLotsInsertTemplate inserts into the inventory_lots table
+const SqlInventoryLotsInsertTemplate = "INSERT INTO inventory_lots (account_id, market_id, lot_txid, date_opened_utc, side, price, base_volume, remaining_base_volume) VALUES ('%s', '%s', '%s', '%s', '%s', %.15f, %.15f, %.15f)"
+
+// SqlInventoryLotClosuresInsertTemplate inserts into the inventory_lot_closures table
+const SqlInventoryLotClosuresInsertTemplate = "INSERT INTO inventory_lot_closures (account_id, market_id, lot_txid, closing_txid, date_opened_utc, date_closed_utc, side, base_volume, open_price, close_price, realized_pnl) VALUES ('%s', '%s', '%s', '%s', '%s', '%s', '%s', %.15f, %.15f, %.15f, %.15f)"
+
+// SqlSpreadObligationSamplesInsertTemplate inserts into the spread_obligation_samples table, ignoring a second sample in the same second
+const SqlSpreadObligationSamplesInsertTemplate = "INSERT INTO spread_obligation_samples (account_id, market_id, date_utc, duration_seconds, reference_price, max_spread_bps, min_depth, bid_depth, ask_depth, bid_met, ask_met) VALUES ('%s', '%s', '%s', %.3f, %.15f, %.15f, %.15f, %.15f, %.15f, %t, %t) ON CONFLICT DO NOTHING"
+
+// SqlBotQuotesUpsertTemplate inserts or replaces the best bid and ask of a bot in the bot_quotes table, a NULL price means there is no offer on that side
+const SqlBotQuotesUpsertTemplate = "INSERT INTO bot_quotes (bot_id, base_asset, quote_asset, best_bid, best_ask, date_updated_utc) VALUES ('%s', '%s', '%s', %s, %s, '%s') ON CONFLICT (bot_id) DO UPDATE SET base_asset = EXCLUDED.base_asset, quote_asset = EXCLUDED.quote_asset, best_bid = EXCLUDED.best_bid, best_ask = EXCLUDED.best_ask, date_updated_utc = EXCLUDED.date_updated_utc"
+
+// SqlLevelStatsUpsertTemplate adds to the daily stats of a level in the level_stats table, the spread is replaced since the config of the level may have changed
+const SqlLevelStatsUpsertTemplate = "INSERT INTO level_stats (account_id, market_id, date_utc, side, level, spread, num_updates, num_fills, filled_base_volume, filled_quote_volume, spread_capture) " +
+	"VALUES ('%s', '%s', '%s', '%s', %d, %.15f, %d, %d, %.15f, %.15f, %.15f) " +
+	"ON CONFLICT (account_id, market_id, date_utc, side, level) DO UPDATE SET " +
+	"spread = EXCLUDED.spread, " +
+	"num_updates = level_stats.num_updates + EXCLUDED.num_updates, " +
+	"num_fills = level_stats.num_fills + EXCLUDED.num_fills, " +
+	"filled_base_volume = level_stats.filled_base_volume + EXCLUDED.filled_base_volume, " +
+	"filled_quote_volume = level_stats.filled_quote_volume + EXCLUDED.filled_quote_volume, " +
+	"spread_capture = level_stats.spread_capture + EXCLUDED.spread_capture"
+
+// SqlOrderTracesInsert inserts an event in the lifecycle of an offer into the order_traces table, it uses query args because the detail can
+// contain error messages
+const SqlOrderTracesInsert = "INSERT INTO order_traces (account_id, market_id, correlation_id, date_utc, stage, side, offer_id, price, amount, detail) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)"
+
+// SqlPortfolioBudgetsUpsertTemplate inserts or replaces the budget that the portfolio manager assigned to a bot in the portfolio_budgets table
+const SqlPortfolioBudgetsUpsertTemplate = "INSERT INTO portfolio_budgets (bot_id, budget, realized_pnl, max_exposure_base, max_daily_volume_quote, date_updated_utc) VALUES ('%s', %.15f, %.15f, %.15f, %.15f, '%s') ON CONFLICT (bot_id) DO UPDATE SET budget = EXCLUDED.budget, realized_pnl = EXCLUDED.realized_pnl, max_exposure_base = EXCLUDED.max_exposure_base, max_daily_volume_quote = EXCLUDED.max_daily_volume_quote, date_updated_utc = EXCLUDED.date_updated_utc"
+
+// SqlDecisionRecordsInsert inserts the explanation of an update cycle into the decision_records table, it uses query args because the record
+// is JSON that can contain error messages, the seq column keeps records written in the same instant apart
+const SqlDecisionRecordsInsert = "INSERT INTO decision_records (account_id, market_id, date_utc, outcome, record) VALUES ($1, $2, $3, $4, $5)"
+
+// SqlStrategyMirrorPendingOffsetsInsertTemplate inserts a trade whose offset is being aggregated into the strategy_mirror_pending_offsets table,
+// ignoring a trade that is already pending
+const SqlStrategyMirrorPendingOffsetsInsertTemplate = "INSERT INTO strategy_mirror_pending_offsets (market_id, txid, action, counter_price, base_volume, date_added_utc) VALUES ('%s', '%s', '%s', %.15f, %.15f, '%s') ON CONFLICT DO NOTHING"
+
+// SqlStrategyIcebergFillsInsertTemplate inserts a fill that was drawn from the hidden reserve of an iceberg level into the strategy_iceberg_fills
+// table, ignoring a fill that was already counted
+const SqlStrategyIcebergFillsInsertTemplate = "INSERT INTO strategy_iceberg_fills (market_id, txid, side, level, base_volume, date_utc) VALUES ('%s', '%s', '%s', %d, %.15f, '%s') ON CONFLICT DO NOTHING"
+
+// SqlDailyTradeStatsRefresh adds up the trades of a market by account, day and action into the daily_trade_stats table. It starts from the last
+// day that was aggregated, which is aggregated again because it was probably incomplete, so the trades of the days when the bot was not
+// running are included. Trades without an account are aggregated under an empty account_id
+const SqlDailyTradeStatsRefresh = "INSERT INTO daily_trade_stats (market_id, account_id, date_utc, action, num_trades, base_volume, counter_volume, fee) " +
+	"SELECT market_id, COALESCE(account_id, ''), DATE(date_utc), action, COUNT(*), SUM(base_volume), SUM(counter_cost), SUM(fee) FROM trades " +
+	"WHERE market_id = $1 AND DATE(date_utc) >= COALESCE((SELECT MAX(date_utc) FROM daily_trade_stats WHERE market_id = $1), DATE '1970-01-01') " +
+	"GROUP BY market_id, COALESCE(account_id, ''), DATE(date_utc), action " +
+	"ON CONFLICT (market_id, account_id, date_utc, action) DO UPDATE SET " +
+	"num_trades = EXCLUDED.num_trades, " +
+	"base_volume = EXCLUDED.base_volume, " +
+	"counter_volume = EXCLUDED.counter_volume, " +
+	"fee = EXCLUDED.fee"
+
+/*
+	update statements
+*/
+// SqlInventoryLotsUpdateRemainingTemplate sets the remaining base volume of a lot in the inventory_lots table
+const SqlInventoryLotsUpdateRemainingTemplate = "UPDATE inventory_lots SET remaining_base_volume = %.15f WHERE account_id = '%s' AND market_id = '%s' AND lot_txid = '%s'"
+
+/*
+	delete statements
+*/
+// SqlTimeseriesPointsDeleteTemplate deletes the raw points of a series older than the cutoff
+const SqlTimeseriesPointsDeleteTemplate = "DELETE FROM timeseries_points WHERE series = '%s' AND date_utc < '%s'"
+
+// SqlTimeseriesRollupsDeleteTemplate deletes the rollups of a series older than the cutoff
+const SqlTimeseriesRollupsDeleteTemplate = "DELETE FROM timeseries_rollups WHERE series = '%s' AND bucket_start_utc < '%s'"
+
+// SqlStrategyMirrorPendingOffsetsDeleteTemplate deletes the pending trades of a market that were offset in the given direction
+const SqlStrategyMirrorPendingOffsetsDeleteTemplate = "DELETE FROM strategy_mirror_pending_offsets WHERE market_id = '%s' AND action = '%s'"
+
+// SqlDecisionRecordsDelete deletes the decision records of a market older than the cutoff
+const SqlDecisionRecordsDelete = "DELETE FROM decision_records WHERE account_id = $1 AND market_id = $2 AND date_utc < $3"
 
 /*
 	queries
 */
 // SqlQueryMarketsById queries the markets table
 const SqlQueryMarketsById = "SELECT market_id, exchange_name, base, quote FROM markets WHERE market_id = $1 LIMIT 1"
+
+// SqlQueryTimeseriesPoints queries the raw points of a series and label in a time range
+const SqlQueryTimeseriesPoints = "SELECT date_utc, value FROM timeseries_points WHERE series = $1 AND label = $2 AND date_utc >= $3 AND date_utc < $4 ORDER BY date_utc ASC"
+
+// SqlQueryTimeseriesRollups queries the downsampled buckets of a series and label in a time range
+const SqlQueryTimeseriesRollups = "SELECT bucket_start_utc, min_value, max_value, avg_value, num_points FROM timeseries_rollups WHERE series = $1 AND label = $2 AND bucket_start_utc >= $3 AND bucket_start_utc < $4 ORDER BY bucket_start_utc ASC"
+
+// SqlQueryInventoryOpenLotsForUpdateTemplate queries the open lots on one side of the inventory and locks them until the transaction ends,
+// the %s is the sort direction of date_opened_utc (ASC for FIFO and DESC for LIFO)
+const SqlQueryInventoryOpenLotsForUpdateTemplate = "SELECT lot_txid, date_opened_utc, side, price, base_volume, remaining_base_volume FROM inventory_lots WHERE account_id = $1 AND market_id = $2 AND side = $3 AND remaining_base_volume > 0 ORDER BY date_opened_utc %[1]s, lot_txid %[1]s FOR UPDATE"
+
+// SqlQueryInventoryTradeProcessed checks whether a trade has already opened or closed a lot, so replayed fills are not counted twice
+const SqlQueryInventoryTradeProcessed = "SELECT EXISTS (SELECT 1 FROM inventory_lots WHERE account_id = $1 AND market_id = $2 AND lot_txid = $3) OR EXISTS (SELECT 1 FROM inventory_lot_closures WHERE account_id = $1 AND market_id = $2 AND closing_txid = $3)"
@@ -143,6 +143,64 @@ var Display = displayAssetConverter{}
 // CcxtAssetConverter is the asset converter for the CCXT exchange interface
 var CcxtAssetConverter = Display
 
+// overridingAssetConverter converts the assets in its overrides and passes all other assets through unchanged
+type overridingAssetConverter struct {
+	overrides *AssetConverter
+}
+
+// ensure that overridingAssetConverter implements AssetConverterInterface
+var _ AssetConverterInterface = overridingAssetConverter{}
+
+// makeOverridingAssetConverter is a factory method for overridingAssetConverter
+func makeOverridingAssetConverter(asset2String map[Asset]string) *overridingAssetConverter {
+	return &overridingAssetConverter{
+		overrides: makeAssetConverter(asset2String),
+	}
+}
+
+// ToString converts an asset to a string
+func (c overridingAssetConverter) ToString(a Asset) (string, error) {
+	if s, ok := c.overrides.asset2String[a]; ok {
+		return s, nil
+	}
+	return string(a), nil
+}
+
+// FromString converts from a string to an asset
+func (c overridingAssetConverter) FromString(s string) (Asset, error) {
+	if a, ok := c.overrides.string2Asset[s]; ok {
+		return a, nil
+	}
+	return Asset(s), nil
+}
+
+// MustFromString converts from a string to an asset, failing on errors
+func (c overridingAssetConverter) MustFromString(s string) Asset {
+	a, e := c.FromString(s)
+	if e != nil {
+		log.Fatal(fmt.Errorf("exiting on an error-enforced asset conversion: %s", e))
+	}
+	return a
+}
+
+// CcxtAssetConverterMexc is the asset converter for the CCXT integration of MEXC, where CCXT renames some of the exchange's asset codes.
+// Assets are specified using the exchange's own codes and are converted to the codes used by CCXT
+var CcxtAssetConverterMexc = makeOverridingAssetConverter(map[Asset]string{
+	"GAS":    "GASDAO",
+	"GASNEO": "GAS",
+	"GMT":    "GMT Token",
+	"STEPN":  "GMT",
+})
+
+// CcxtAssetConverterGateio is the asset converter for the CCXT integration of Gate.io, where CCXT renames some of the exchange's asset codes.
+// Assets are specified using the exchange's own codes and are converted to the codes used by CCXT
+var CcxtAssetConverterGateio = makeOverridingAssetConverter(map[Asset]string{
+	"BIFI":  "Bitcoin File",
+	"MPH":   "Morpher",
+	"88MPH": "MPH",
+	"TNC":   "Trinity Network Credit",
+})
+
 // KrakenAssetConverter is the asset converter for the Kraken exchange
 var KrakenAssetConverter = makeAssetConverter(map[Asset]string{
 	XLM:  "XXLM",
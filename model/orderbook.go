@@ -242,7 +242,9 @@ type Trade struct {
 	TransactionID *TransactionID
 	OrderID       string
 	Cost          *Number
-	Fee           *Number
+	Fee           *Number // in units of the quote asset
+	FeeAsset      *Asset  // asset the fee was charged in, nil when it was charged in the quote asset
+	FeeAssetCost  *Number // fee in units of FeeAsset, nil when FeeAsset is nil
 }
 
 // TradesByTsID implements sort.Interface for []Trade based on Timestamp and TransactionID
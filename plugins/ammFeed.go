@@ -0,0 +1,61 @@
+package plugins
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/kelp/api"
+)
+
+// ammFeed is a pricefeed from the spot price of the Stellar liquidity pool (AMM) of two assets
+type ammFeed struct {
+	sdexFeed *sdexFeed
+	poolID   txnbuild.LiquidityPoolId
+}
+
+// ensure that it implements PriceFeed
+var _ api.PriceFeed = &ammFeed{}
+
+// makeAMMFeed creates a price feed from buysell's url fields, the url has the same format as the sdex feed: CODE:ISSUER/CODE:ISSUER
+func makeAMMFeed(url string) (*ammFeed, error) {
+	sdexFeed, e := makeSDEXFeed(url)
+	if e != nil {
+		return nil, e
+	}
+	if sdexFeed.depthQuote > 0 {
+		return nil, fmt.Errorf("amm feed does not use an orderbook so it does not support a depth in the url '%s'", url)
+	}
+
+	_, poolID, e := makeLiquidityPoolParams(sdexFeed.assetBase, sdexFeed.assetQuote)
+	if e != nil {
+		return nil, e
+	}
+
+	return &ammFeed{
+		sdexFeed: sdexFeed,
+		poolID:   poolID,
+	}, nil
+}
+
+// GetPrice returns the spot price of the liquidity pool in units of the quote asset
+func (f *ammFeed) GetPrice() (float64, error) {
+	reserves, e := fetchLiquidityPoolReserves(f.sdexFeed.sdex, f.poolID, f.sdexFeed.assetBase, f.sdexFeed.assetQuote)
+	if e != nil {
+		return 0, fmt.Errorf("unable to get amm price: %s", e)
+	}
+
+	price, e := computeAMMSpotPrice(reserves)
+	if e != nil {
+		return 0, fmt.Errorf("unable to get amm price of liquidity pool '%s': %s", hex.EncodeToString(f.poolID[:]), e)
+	}
+	return price, nil
+}
+
+// computeAMMSpotPrice is the ratio of the reserves of a constant product pool, which is the price of an infinitesimally small trade
+func computeAMMSpotPrice(reserves *liquidityPoolReserves) (float64, error) {
+	if reserves.base <= 0 || reserves.quote <= 0 {
+		return 0, fmt.Errorf("the pool does not exist or has no reserves (base=%.7f, quote=%.7f)", reserves.base, reserves.quote)
+	}
+	return reserves.quote / reserves.base, nil
+}
@@ -0,0 +1,42 @@
+package plugins
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComputeAMMSpotPrice(t *testing.T) {
+	testCases := []struct {
+		reserves  liquidityPoolReserves
+		wantPrice float64
+		wantError bool
+	}{
+		{
+			reserves:  liquidityPoolReserves{base: 1000.0, quote: 250.0, totalShares: 500.0},
+			wantPrice: 0.25,
+		}, {
+			reserves:  liquidityPoolReserves{base: 2.5, quote: 10.0, totalShares: 5.0},
+			wantPrice: 4.0,
+		}, {
+			// pool does not exist yet
+			reserves:  liquidityPoolReserves{},
+			wantError: true,
+		}, {
+			reserves:  liquidityPoolReserves{base: 10.0, quote: 0.0},
+			wantError: true,
+		},
+	}
+
+	for _, k := range testCases {
+		price, e := computeAMMSpotPrice(&k.reserves)
+		if k.wantError {
+			assert.Error(t, e)
+			continue
+		}
+		if !assert.NoError(t, e) {
+			return
+		}
+		assert.InDelta(t, k.wantPrice, price, 0.0000001)
+	}
+}
@@ -0,0 +1,134 @@
+package plugins
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/stellar/kelp/api"
+)
+
+// APIKeyPermissionsCheck is what happens when the exchange API keys have more or fewer permissions than the bot needs
+type APIKeyPermissionsCheck string
+
+// type of APIKeyPermissionsCheck
+const (
+	// APIKeyPermissionsCheckOff does not check the permissions of the API keys
+	APIKeyPermissionsCheckOff APIKeyPermissionsCheck = "off"
+	// APIKeyPermissionsCheckWarn logs a warning for every problem with the permissions of the API keys
+	APIKeyPermissionsCheckWarn APIKeyPermissionsCheck = "warn"
+	// APIKeyPermissionsCheckBlock refuses to start the bot when the API keys have more or fewer permissions than the bot needs
+	APIKeyPermissionsCheckBlock APIKeyPermissionsCheck = "block"
+)
+
+// ParseAPIKeyPermissionsCheck converts a string to an APIKeyPermissionsCheck, defaulting to APIKeyPermissionsCheckWarn when unset
+func ParseAPIKeyPermissionsCheck(check string) (APIKeyPermissionsCheck, error) {
+	switch APIKeyPermissionsCheck(check) {
+	case "", APIKeyPermissionsCheckWarn:
+		return APIKeyPermissionsCheckWarn, nil
+	case APIKeyPermissionsCheckOff:
+		return APIKeyPermissionsCheckOff, nil
+	case APIKeyPermissionsCheckBlock:
+		return APIKeyPermissionsCheckBlock, nil
+	default:
+		return APIKeyPermissionsCheckWarn, fmt.Errorf("invalid API_KEY_PERMISSIONS_CHECK '%s', needs to be one of '%s', '%s', or '%s'",
+			check, APIKeyPermissionsCheckOff, APIKeyPermissionsCheckWarn, APIKeyPermissionsCheckBlock)
+	}
+}
+
+// APIKeyPermissionProblem is a difference between the permissions of an API key and the permissions that the bot needs
+type APIKeyPermissionProblem struct {
+	Key         string
+	Description string
+	Blocking    bool // false for problems that only increase the damage a leaked key can do without granting more permissions
+}
+
+// String is the Stringer method
+func (p APIKeyPermissionProblem) String() string {
+	return fmt.Sprintf("API key %s: %s", p.Key, p.Description)
+}
+
+// RequiredAPIKeyPermissions are the permissions that the configuration of the bot needs on the API keys of an exchange, reading balances,
+// orders and trades and placing and cancelling orders are always needed since only the keys of trading exchanges are loaded
+type RequiredAPIKeyPermissions struct {
+	CanWithdraw bool
+}
+
+// TradingExchange is an exchange that was made with API keys by MakeTradingExchange
+type TradingExchange struct {
+	Name     string
+	Exchange api.Exchange
+}
+
+// trackedTradingExchanges are the exchanges made by MakeTradingExchange after TrackTradingExchanges was called
+var trackedTradingExchanges []TradingExchange
+var isTrackingTradingExchanges bool
+var trackedTradingExchangesLock = &sync.Mutex{}
+
+// TrackTradingExchanges keeps every exchange that is made by MakeTradingExchange after this is called so the permissions of their API keys
+// can be checked once the bot is set up, this includes the exchanges made by strategies such as the backing exchanges of the mirror strategy
+func TrackTradingExchanges() {
+	trackedTradingExchangesLock.Lock()
+	defer trackedTradingExchangesLock.Unlock()
+	isTrackingTradingExchanges = true
+}
+
+// TrackedTradingExchanges returns the exchanges made by MakeTradingExchange since TrackTradingExchanges was called
+func TrackedTradingExchanges() []TradingExchange {
+	trackedTradingExchangesLock.Lock()
+	defer trackedTradingExchangesLock.Unlock()
+	return append([]TradingExchange{}, trackedTradingExchanges...)
+}
+
+func maybeTrackTradingExchange(name string, x api.Exchange) {
+	trackedTradingExchangesLock.Lock()
+	defer trackedTradingExchangesLock.Unlock()
+	if isTrackingTradingExchanges {
+		trackedTradingExchanges = append(trackedTradingExchanges, TradingExchange{Name: name, Exchange: x})
+	}
+}
+
+// CheckAPIKeyPermissions compares the permissions of the API keys of the exchange with the permissions that the configuration of the bot
+// needs, a key with more permissions than needed only increases the damage that a leaked key can do. Returns an error when the exchange
+// cannot report the permissions of its keys.
+func CheckAPIKeyPermissions(exchange api.Exchange, required RequiredAPIKeyPermissions) ([]APIKeyPermissionProblem, error) {
+	fetcher, ok := exchange.(api.APIKeyPermissionsFetcher)
+	if !ok {
+		return nil, fmt.Errorf("the exchange cannot report the permissions of its API keys")
+	}
+	permissions, e := fetcher.GetAPIKeyPermissions()
+	if e != nil {
+		return nil, fmt.Errorf("could not fetch the permissions of the API keys: %s", e)
+	}
+	return checkAPIKeyPermissions(permissions, required), nil
+}
+
+func checkAPIKeyPermissions(permissions []api.APIKeyPermissions, required RequiredAPIKeyPermissions) []APIKeyPermissionProblem {
+	problems := []APIKeyPermissionProblem{}
+	for _, p := range permissions {
+		if !p.CanRead {
+			problems = append(problems, APIKeyPermissionProblem{Key: p.Key, Description: "cannot read balances, orders and trades, which the bot needs", Blocking: true})
+		}
+		if !p.CanTrade {
+			problems = append(problems, APIKeyPermissionProblem{Key: p.Key, Description: "cannot place or cancel orders, which the bot needs", Blocking: true})
+		}
+		if required.CanWithdraw && !p.CanWithdraw {
+			problems = append(problems, APIKeyPermissionProblem{Key: p.Key, Description: "cannot withdraw funds, which API_KEY_PERMISSIONS_WITHDRAW needs", Blocking: true})
+		}
+		if !required.CanWithdraw && p.CanWithdraw {
+			problems = append(problems, APIKeyPermissionProblem{Key: p.Key, Description: "can withdraw funds but the bot is not configured to withdraw, disable withdrawals on this key", Blocking: true})
+		}
+		if p.IPRestricted != nil && !*p.IPRestricted {
+			problems = append(problems, APIKeyPermissionProblem{Key: p.Key, Description: "is not restricted to trusted IP addresses, consider restricting it to the IP addresses of the bot", Blocking: false})
+		}
+	}
+	return problems
+}
+
+// maskAPIKey hides all but the first and last 4 characters of the key so it can be logged
+func maskAPIKey(key string) string {
+	if len(key) <= 8 {
+		return strings.Repeat("*", len(key))
+	}
+	return key[:4] + strings.Repeat("*", len(key)-8) + key[len(key)-4:]
+}
@@ -0,0 +1,116 @@
+package plugins
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/kelp/api"
+)
+
+func TestParseAPIKeyPermissionsCheck(t *testing.T) {
+	for _, k := range []struct {
+		check string
+		want  APIKeyPermissionsCheck
+	}{
+		{"", APIKeyPermissionsCheckWarn},
+		{"warn", APIKeyPermissionsCheckWarn},
+		{"off", APIKeyPermissionsCheckOff},
+		{"block", APIKeyPermissionsCheckBlock},
+	} {
+		check, e := ParseAPIKeyPermissionsCheck(k.check)
+		if !assert.NoError(t, e) {
+			return
+		}
+		assert.Equal(t, k.want, check)
+	}
+
+	_, e := ParseAPIKeyPermissionsCheck("strict")
+	assert.Error(t, e)
+}
+
+func TestCheckAPIKeyPermissions(t *testing.T) {
+	ipRestricted := true
+	notIPRestricted := false
+	testCases := []struct {
+		name        string
+		permissions []api.APIKeyPermissions
+		required    RequiredAPIKeyPermissions
+		want        []APIKeyPermissionProblem
+	}{
+		{
+			name:        "minimal",
+			permissions: []api.APIKeyPermissions{{Key: "k1", CanRead: true, CanTrade: true, IPRestricted: &ipRestricted}},
+			want:        []APIKeyPermissionProblem{},
+		}, {
+			name:        "ip restriction not reported",
+			permissions: []api.APIKeyPermissions{{Key: "k1", CanRead: true, CanTrade: true}},
+			want:        []APIKeyPermissionProblem{},
+		}, {
+			name:        "withdraw enabled",
+			permissions: []api.APIKeyPermissions{{Key: "k1", CanRead: true, CanTrade: true, CanWithdraw: true}},
+			want: []APIKeyPermissionProblem{
+				{Key: "k1", Description: "can withdraw funds but the bot is not configured to withdraw, disable withdrawals on this key", Blocking: true},
+			},
+		}, {
+			name:        "withdraw enabled and needed",
+			permissions: []api.APIKeyPermissions{{Key: "k1", CanRead: true, CanTrade: true, CanWithdraw: true}},
+			required:    RequiredAPIKeyPermissions{CanWithdraw: true},
+			want:        []APIKeyPermissionProblem{},
+		}, {
+			name:        "withdraw needed but disabled",
+			permissions: []api.APIKeyPermissions{{Key: "k1", CanRead: true, CanTrade: true}},
+			required:    RequiredAPIKeyPermissions{CanWithdraw: true},
+			want: []APIKeyPermissionProblem{
+				{Key: "k1", Description: "cannot withdraw funds, which API_KEY_PERMISSIONS_WITHDRAW needs", Blocking: true},
+			},
+		}, {
+			name: "second key cannot trade and is not ip restricted",
+			permissions: []api.APIKeyPermissions{
+				{Key: "k1", CanRead: true, CanTrade: true},
+				{Key: "k2", CanRead: true, IPRestricted: &notIPRestricted},
+			},
+			want: []APIKeyPermissionProblem{
+				{Key: "k2", Description: "cannot place or cancel orders, which the bot needs", Blocking: true},
+				{Key: "k2", Description: "is not restricted to trusted IP addresses, consider restricting it to the IP addresses of the bot", Blocking: false},
+			},
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			assert.Equal(t, k.want, checkAPIKeyPermissions(k.permissions, k.required))
+		})
+	}
+}
+
+func TestCheckAPIKeyPermissions_Unsupported(t *testing.T) {
+	_, e := CheckAPIKeyPermissions(struct{ api.Exchange }{}, RequiredAPIKeyPermissions{})
+	assert.Error(t, e)
+}
+
+func TestMaskAPIKey(t *testing.T) {
+	assert.Equal(t, "", maskAPIKey(""))
+	assert.Equal(t, "*****", maskAPIKey("short"))
+	assert.Equal(t, "abcd****wxyz", maskAPIKey("abcd1234wxyz"))
+}
+
+func TestTrackTradingExchanges(t *testing.T) {
+	defer func() {
+		isTrackingTradingExchanges = false
+		trackedTradingExchanges = nil
+	}()
+
+	// exchanges are only tracked once tracking is turned on
+	maybeTrackTradingExchange("kraken", nil)
+	assert.Equal(t, 0, len(TrackedTradingExchanges()))
+
+	TrackTradingExchanges()
+	maybeTrackTradingExchange("kraken", nil)
+	maybeTrackTradingExchange("ccxt-binance", nil)
+	tracked := TrackedTradingExchanges()
+	if assert.Equal(t, 2, len(tracked)) {
+		assert.Equal(t, "kraken", tracked[0].Name)
+		assert.Equal(t, "ccxt-binance", tracked[1].Name)
+	}
+}
@@ -0,0 +1,129 @@
+package plugins
+
+import (
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+)
+
+// BalanceAnomaly describes a balance change that could not be explained by our own fills
+type BalanceAnomaly struct {
+	UnexplainedBaseDelta  float64
+	UnexplainedQuoteDelta float64
+}
+
+// String is the Stringer method
+func (a *BalanceAnomaly) String() string {
+	return fmt.Sprintf("BalanceAnomaly[unexplainedBaseDelta=%.8f, unexplainedQuoteDelta=%.8f]", a.UnexplainedBaseDelta, a.UnexplainedQuoteDelta)
+}
+
+// BalanceAnomalyDetector compares the balance changes we expect from our own fills against the actual balance changes of the account
+// every update cycle so we can get an early warning of unexplained outflows, such as when the trading keys are compromised
+type BalanceAnomalyDetector struct {
+	baseTolerance  float64 // allowed unexplained outflow of the base asset per cycle, such as network fees when the base asset is native
+	quoteTolerance float64 // allowed unexplained outflow of the quote asset per cycle, such as network fees when the quote asset is native
+
+	// initialized runtime vars
+	mutex *sync.Mutex
+
+	// uninitialized runtime vars
+	lastBaseBalance    *float64
+	lastQuoteBalance   *float64
+	expectedBaseDelta  float64
+	expectedQuoteDelta float64
+}
+
+var _ api.FillHandler = &BalanceAnomalyDetector{}
+
+// MakeBalanceAnomalyDetector is a factory method
+func MakeBalanceAnomalyDetector(baseTolerance float64, quoteTolerance float64) (*BalanceAnomalyDetector, error) {
+	if baseTolerance < 0.0 {
+		return nil, fmt.Errorf("invalid base tolerance, expected baseTolerance >= 0.0; was %f", baseTolerance)
+	}
+	if quoteTolerance < 0.0 {
+		return nil, fmt.Errorf("invalid quote tolerance, expected quoteTolerance >= 0.0; was %f", quoteTolerance)
+	}
+
+	return &BalanceAnomalyDetector{
+		baseTolerance:  baseTolerance,
+		quoteTolerance: quoteTolerance,
+		mutex:          &sync.Mutex{},
+	}, nil
+}
+
+// HandleFill impl.
+func (d *BalanceAnomalyDetector) HandleFill(trade model.Trade) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if trade.Volume == nil || trade.Price == nil {
+		return fmt.Errorf("trade with txid '%v' is missing the volume or price", trade.TransactionID)
+	}
+	baseVolume := trade.Volume.AsFloat()
+	quoteVolume := trade.Volume.Multiply(*trade.Price).AsFloat()
+	if trade.Cost != nil {
+		quoteVolume = trade.Cost.AsFloat()
+	}
+
+	if trade.OrderAction.IsBuy() {
+		d.expectedBaseDelta += baseVolume
+		d.expectedQuoteDelta -= quoteVolume
+	} else {
+		d.expectedBaseDelta -= baseVolume
+		d.expectedQuoteDelta += quoteVolume
+	}
+	if trade.FeeAsset == nil || (trade.Pair != nil && *trade.FeeAsset == trade.Pair.Quote) {
+		if trade.Fee != nil {
+			d.expectedQuoteDelta -= trade.Fee.AsFloat()
+		}
+	} else if trade.Pair != nil && *trade.FeeAsset == trade.Pair.Base {
+		if trade.FeeAssetCost != nil {
+			d.expectedBaseDelta -= trade.FeeAssetCost.AsFloat()
+		}
+	} else {
+		// fees charged in an asset outside the trading pair (such as an exchange token) are not paid from the balances we check
+		log.Printf("balanceAnomalyDetector: ignoring fee of trade with txid '%v' charged in %s\n", trade.TransactionID, *trade.FeeAsset)
+	}
+	return nil
+}
+
+// Check compares the actual balances against the balances we expect from the previous check and the fills since then, returning a non-nil
+// BalanceAnomaly when the outflow of either asset exceeds what we can explain. Inflows, such as deposits, are never considered anomalies.
+func (d *BalanceAnomalyDetector) Check(baseBalance float64, quoteBalance float64) *BalanceAnomaly {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	defer func() {
+		d.lastBaseBalance = &baseBalance
+		d.lastQuoteBalance = &quoteBalance
+		d.expectedBaseDelta = 0.0
+		d.expectedQuoteDelta = 0.0
+	}()
+
+	if d.lastBaseBalance == nil || d.lastQuoteBalance == nil {
+		log.Printf("balanceAnomalyDetector: recorded initial balances (base=%.8f, quote=%.8f)\n", baseBalance, quoteBalance)
+		return nil
+	}
+
+	unexplainedBaseDelta := (baseBalance - *d.lastBaseBalance) - d.expectedBaseDelta
+	unexplainedQuoteDelta := (quoteBalance - *d.lastQuoteBalance) - d.expectedQuoteDelta
+	log.Printf("balanceAnomalyDetector: expectedBaseDelta=%.8f, actualBaseDelta=%.8f, unexplainedBaseDelta=%.8f, expectedQuoteDelta=%.8f, actualQuoteDelta=%.8f, unexplainedQuoteDelta=%.8f\n",
+		d.expectedBaseDelta,
+		baseBalance-*d.lastBaseBalance,
+		unexplainedBaseDelta,
+		d.expectedQuoteDelta,
+		quoteBalance-*d.lastQuoteBalance,
+		unexplainedQuoteDelta,
+	)
+
+	if unexplainedBaseDelta >= -d.baseTolerance && unexplainedQuoteDelta >= -d.quoteTolerance {
+		return nil
+	}
+	return &BalanceAnomaly{
+		UnexplainedBaseDelta:  unexplainedBaseDelta,
+		UnexplainedQuoteDelta: unexplainedQuoteDelta,
+	}
+}
@@ -0,0 +1,133 @@
+package plugins
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/kelp/model"
+)
+
+func makeTestBalanceAnomalyTrade(action model.OrderAction, price float64, volume float64, fee float64) model.Trade {
+	return model.Trade{
+		Order: model.Order{
+			OrderAction: action,
+			Price:       model.NumberFromFloat(price, 7),
+			Volume:      model.NumberFromFloat(volume, 7),
+		},
+		TransactionID: model.MakeTransactionID("txid"),
+		Cost:          model.NumberFromFloat(price*volume, 7),
+		Fee:           model.NumberFromFloat(fee, 7),
+	}
+}
+
+func makeTestBalanceAnomalyTradeFeeAsset(action model.OrderAction, price float64, volume float64, feeQuote float64, feeAsset model.Asset, feeAssetCost float64) model.Trade {
+	trade := makeTestBalanceAnomalyTrade(action, price, volume, feeQuote)
+	trade.Pair = &model.TradingPair{Base: model.XLM, Quote: model.USDT}
+	trade.FeeAsset = &feeAsset
+	trade.FeeAssetCost = model.NumberFromFloat(feeAssetCost, 7)
+	return trade
+}
+
+func TestBalanceAnomalyDetectorCheck(t *testing.T) {
+	testCases := []struct {
+		name         string
+		trades       []model.Trade
+		baseBalance  float64
+		quoteBalance float64
+		wantAnomaly  bool
+	}{
+		{
+			name:         "no change",
+			trades:       []model.Trade{},
+			baseBalance:  100.0,
+			quoteBalance: 50.0,
+			wantAnomaly:  false,
+		}, {
+			name:         "change explained by sell fill",
+			trades:       []model.Trade{makeTestBalanceAnomalyTrade(model.OrderActionSell, 0.5, 10.0, 0.0)},
+			baseBalance:  90.0,
+			quoteBalance: 55.0,
+			wantAnomaly:  false,
+		}, {
+			name:         "change explained by buy fill with fee",
+			trades:       []model.Trade{makeTestBalanceAnomalyTrade(model.OrderActionBuy, 0.5, 10.0, 0.1)},
+			baseBalance:  110.0,
+			quoteBalance: 44.9,
+			wantAnomaly:  false,
+		}, {
+			name:         "change explained by buy fill with fee in base asset",
+			trades:       []model.Trade{makeTestBalanceAnomalyTradeFeeAsset(model.OrderActionBuy, 0.5, 10.0, 0.05, model.XLM, 0.1)},
+			baseBalance:  109.9,
+			quoteBalance: 45.0,
+			wantAnomaly:  false,
+		}, {
+			name:         "change explained by buy fill with fee in quote asset",
+			trades:       []model.Trade{makeTestBalanceAnomalyTradeFeeAsset(model.OrderActionBuy, 0.5, 10.0, 0.1, model.USDT, 0.1)},
+			baseBalance:  110.0,
+			quoteBalance: 44.9,
+			wantAnomaly:  false,
+		}, {
+			name:         "change explained by buy fill with fee in other asset",
+			trades:       []model.Trade{makeTestBalanceAnomalyTradeFeeAsset(model.OrderActionBuy, 0.5, 10.0, 0.05, model.Asset("BNB"), 0.001)},
+			baseBalance:  110.0,
+			quoteBalance: 45.0,
+			wantAnomaly:  false,
+		}, {
+			name:         "fee in other asset does not explain quote outflow",
+			trades:       []model.Trade{makeTestBalanceAnomalyTradeFeeAsset(model.OrderActionBuy, 0.5, 10.0, 0.05, model.Asset("BNB"), 0.001)},
+			baseBalance:  110.0,
+			quoteBalance: 44.95,
+			wantAnomaly:  true,
+		}, {
+			name:         "outflow within tolerance",
+			trades:       []model.Trade{},
+			baseBalance:  99.995,
+			quoteBalance: 50.0,
+			wantAnomaly:  false,
+		}, {
+			name:         "deposit is not an anomaly",
+			trades:       []model.Trade{},
+			baseBalance:  200.0,
+			quoteBalance: 150.0,
+			wantAnomaly:  false,
+		}, {
+			name:         "unexplained base outflow",
+			trades:       []model.Trade{},
+			baseBalance:  80.0,
+			quoteBalance: 50.0,
+			wantAnomaly:  true,
+		}, {
+			name:         "unexplained quote outflow with fill",
+			trades:       []model.Trade{makeTestBalanceAnomalyTrade(model.OrderActionSell, 0.5, 10.0, 0.0)},
+			baseBalance:  90.0,
+			quoteBalance: 45.0,
+			wantAnomaly:  true,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			d, e := MakeBalanceAnomalyDetector(0.01, 0.01)
+			if !assert.NoError(t, e) {
+				return
+			}
+
+			// the first check records the initial balances
+			assert.Nil(t, d.Check(100.0, 50.0))
+
+			for _, trade := range k.trades {
+				e = d.HandleFill(trade)
+				if !assert.NoError(t, e) {
+					return
+				}
+			}
+
+			anomaly := d.Check(k.baseBalance, k.quoteBalance)
+			assert.Equal(t, k.wantAnomaly, anomaly != nil)
+
+			// expected deltas are reset after each check so an unchanged balance is never an anomaly
+			assert.Nil(t, d.Check(k.baseBalance, k.quoteBalance))
+		})
+	}
+}
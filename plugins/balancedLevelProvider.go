@@ -3,6 +3,7 @@ package plugins
 import (
 	"fmt"
 	"log"
+	"math"
 	"math/rand"
 	"time"
 
@@ -27,6 +28,9 @@ type balancedLevelProvider struct {
 	carryoverInclusionProbability float64 // probability of including the carryover at a level that will be added
 	virtualBalanceBase            float64 // virtual balance to use so we can smoothen out the curve
 	virtualBalanceQuote           float64 // virtual balance to use so we can smoothen out the curve
+	maxExposureBase               float64 // cap on the base balance used to compute levels, 0 means no cap
+	maxExposureQuote              float64 // cap on the quote balance used to compute levels, 0 means no cap
+	rebalanceThreshold            float64 // relative drift of the portfolio ratio needed before levels are recomputed, 0 recomputes on every fill
 	orderConstraints              *model.OrderConstraints
 	shouldRefresh                 bool // boolean for whether to generate levels, starts true
 
@@ -35,6 +39,7 @@ type balancedLevelProvider struct {
 
 	// uninitialized
 	lastLevels []api.Level // keeps the levels generated on the previous run to use if no offers were taken
+	lastRatio  float64     // portfolio ratio (quote / base) that lastLevels were generated with
 }
 
 // ensure it implements LevelProvider
@@ -57,6 +62,9 @@ func makeBalancedLevelProvider(
 	carryoverInclusionProbability float64,
 	virtualBalanceBase float64,
 	virtualBalanceQuote float64,
+	maxExposureBase float64,
+	maxExposureQuote float64,
+	rebalanceThreshold float64,
 	orderConstraints *model.OrderConstraints,
 ) api.LevelProvider {
 	if minAmountSpread <= 0 {
@@ -75,6 +83,12 @@ func makeBalancedLevelProvider(
 	}
 	// carryoverInclusionProbability is a value between 0 and 1
 	validateSpread(carryoverInclusionProbability)
+	if maxExposureBase < 0 || maxExposureQuote < 0 {
+		log.Fatalf("maxExposureBase (%.7f) and maxExposureQuote (%.7f) need to be >= 0\n", maxExposureBase, maxExposureQuote)
+	}
+	if rebalanceThreshold < 0 {
+		log.Fatalf("rebalanceThreshold (%.7f) needs to be >= 0\n", rebalanceThreshold)
+	}
 
 	randGen := rand.New(rand.NewSource(time.Now().UnixNano()))
 	shouldRefresh := true
@@ -92,6 +106,9 @@ func makeBalancedLevelProvider(
 		carryoverInclusionProbability: carryoverInclusionProbability,
 		virtualBalanceBase:            virtualBalanceBase,
 		virtualBalanceQuote:           virtualBalanceQuote,
+		maxExposureBase:               maxExposureBase,
+		maxExposureQuote:              maxExposureQuote,
+		rebalanceThreshold:            rebalanceThreshold,
 		orderConstraints:              orderConstraints,
 		randGen:                       randGen,
 		shouldRefresh:                 shouldRefresh,
@@ -111,17 +128,55 @@ func (p *balancedLevelProvider) GetLevels(maxAssetBase float64, maxAssetQuote fl
 		return p.lastLevels, nil
 	}
 
-	levels, e := p.recomputeLevels(maxAssetBase, maxAssetQuote)
+	_maxAssetBase, _maxAssetQuote := p.applyExposureCaps(maxAssetBase+p.virtualBalanceBase, maxAssetQuote+p.virtualBalanceQuote)
+	if _maxAssetBase <= 0 || _maxAssetQuote <= 0 {
+		// the center price is the ratio of the two balances so there is no price to place offers at until both balances are positive,
+		// shouldRefresh stays set and lastLevels is cleared so the levels are recomputed as soon as that happens
+		log.Printf("cannot compute levels without a positive balance of both assets (base=%.7f, quote=%.7f), not placing any offers\n", _maxAssetBase, _maxAssetQuote)
+		p.lastLevels = nil
+		p.lastRatio = 0
+		return []api.Level{}, nil
+	}
+	ratio := _maxAssetQuote / _maxAssetBase
+	if p.lastLevels != nil && !p.hasDriftedBeyondThreshold(ratio) {
+		// shouldRefresh stays set so the drift is checked again on the next update
+		log.Printf("portfolio ratio (%.7f) is within the rebalance threshold (%.7f) of the ratio of the current levels (%.7f), leave levels as they are\n", ratio, p.rebalanceThreshold, p.lastRatio)
+		return p.lastLevels, nil
+	}
+
+	levels, e := p.recomputeLevels(_maxAssetBase, _maxAssetQuote)
 	if e != nil {
 		return nil, fmt.Errorf("unable to generate new levels: %s", e)
 	}
 
 	p.lastLevels = levels
+	p.lastRatio = ratio
 	p.shouldRefresh = false
 
 	return levels, nil
 }
 
+// applyExposureCaps scales down both balances by the same factor until neither exceeds its cap, which keeps the ratio of the balances
+// (and therefore the center price) unchanged while limiting the total amount placed on the orderbook
+func (p *balancedLevelProvider) applyExposureCaps(maxAssetBase float64, maxAssetQuote float64) (float64, float64) {
+	scale := 1.0
+	if p.maxExposureBase > 0 && maxAssetBase > p.maxExposureBase {
+		scale = math.Min(scale, p.maxExposureBase/maxAssetBase)
+	}
+	if p.maxExposureQuote > 0 && maxAssetQuote > p.maxExposureQuote {
+		scale = math.Min(scale, p.maxExposureQuote/maxAssetQuote)
+	}
+	return maxAssetBase * scale, maxAssetQuote * scale
+}
+
+// hasDriftedBeyondThreshold returns true if the ratio has moved away from the ratio of the current levels by more than the rebalance threshold
+func (p *balancedLevelProvider) hasDriftedBeyondThreshold(ratio float64) bool {
+	if p.rebalanceThreshold == 0 || p.lastRatio == 0 {
+		return true
+	}
+	return math.Abs(ratio/p.lastRatio-1) > p.rebalanceThreshold
+}
+
 func (p *balancedLevelProvider) computeNewLevelWithCarryover(level api.Level, amountCarryover float64) (api.Level, float64) {
 	// include a partial amount of the carryover
 	amountCarryoverToInclude := p.randGen.Float64() * amountCarryover
@@ -212,13 +267,18 @@ func (p *balancedLevelProvider) HandleFill(trade model.Trade) error {
 	return nil
 }
 
+// recomputeLevels expects balances that already include the virtual balances and exposure caps
 func (p *balancedLevelProvider) recomputeLevels(maxAssetBase float64, maxAssetQuote float64) ([]api.Level, error) {
-	_maxAssetBase := maxAssetBase + p.virtualBalanceBase
-	_maxAssetQuote := maxAssetQuote + p.virtualBalanceQuote
+	_maxAssetBase := maxAssetBase
+	_maxAssetQuote := maxAssetQuote
 	// represents the amount that was meant to be included in a previous level that we excluded because we skipped that level
 	amountCarryover := 0.0
 	levels := []api.Level{}
 	for i := int16(0); i < p.maxLevels; i++ {
+		if _maxAssetBase <= 0 || _maxAssetQuote <= 0 {
+			// the previous levels used up the balance so the price of any further level is undefined
+			break
+		}
 		level, e := p.getLevel(_maxAssetBase, _maxAssetQuote)
 		if e != nil {
 			return nil, e
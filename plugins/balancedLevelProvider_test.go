@@ -0,0 +1,105 @@
+package plugins
+
+import (
+	"testing"
+
+	"github.com/stellar/kelp/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func makeTestBalancedLevelProvider(maxExposureBase float64, maxExposureQuote float64, rebalanceThreshold float64) *balancedLevelProvider {
+	return makeBalancedLevelProvider(
+		0.001,
+		false,
+		0.0005,
+		0.0005,
+		2,
+		1.0,
+		10,
+		0.01,
+		0.01,
+		1.0,
+		0.0,
+		0.0,
+		maxExposureBase,
+		maxExposureQuote,
+		rebalanceThreshold,
+		model.MakeOrderConstraints(7, 7, 0.0000001),
+	).(*balancedLevelProvider)
+}
+
+func TestBalancedLevelProviderApplyExposureCaps(t *testing.T) {
+	testCases := []struct {
+		name             string
+		maxExposureBase  float64
+		maxExposureQuote float64
+		wantBase         float64
+		wantQuote        float64
+	}{
+		{name: "no caps", maxExposureBase: 0, maxExposureQuote: 0, wantBase: 1000, wantQuote: 500},
+		{name: "caps not reached", maxExposureBase: 2000, maxExposureQuote: 600, wantBase: 1000, wantQuote: 500},
+		{name: "base cap", maxExposureBase: 100, maxExposureQuote: 0, wantBase: 100, wantQuote: 50},
+		{name: "quote cap", maxExposureBase: 0, maxExposureQuote: 100, wantBase: 200, wantQuote: 100},
+		{name: "tighter cap wins", maxExposureBase: 400, maxExposureQuote: 100, wantBase: 200, wantQuote: 100},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			p := makeTestBalancedLevelProvider(k.maxExposureBase, k.maxExposureQuote, 0)
+			base, quote := p.applyExposureCaps(1000, 500)
+			assert.InDelta(t, k.wantBase, base, 0.0000001)
+			assert.InDelta(t, k.wantQuote, quote, 0.0000001)
+		})
+	}
+}
+
+func TestBalancedLevelProviderRebalanceThreshold(t *testing.T) {
+	p := makeTestBalancedLevelProvider(0, 0, 0.05)
+
+	levels1, e := p.GetLevels(1000, 500)
+	if !assert.NoError(t, e) || !assert.Equal(t, 2, len(levels1)) {
+		return
+	}
+	assert.InDelta(t, 0.5, p.lastRatio, 0.0000001)
+
+	// a fill that moves the ratio by less than the threshold keeps the levels
+	assert.NoError(t, p.HandleFill(model.Trade{}))
+	levels2, e := p.GetLevels(990, 505)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, levels1, levels2)
+	assert.True(t, p.shouldRefresh)
+
+	// once the ratio drifts beyond the threshold the levels are recomputed
+	levels3, e := p.GetLevels(900, 550)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.NotEqual(t, levels1, levels3)
+	assert.False(t, p.shouldRefresh)
+	assert.InDelta(t, 550.0/900.0, p.lastRatio, 0.0000001)
+}
+
+func TestBalancedLevelProviderEmptyBalance(t *testing.T) {
+	p := makeTestBalancedLevelProvider(0, 0, 0.05)
+
+	for _, balances := range [][]float64{{0, 500}, {1000, 0}, {0, 0}} {
+		levels, e := p.GetLevels(balances[0], balances[1])
+		if !assert.NoError(t, e) {
+			return
+		}
+		assert.Equal(t, 0, len(levels), "balances: %v", balances)
+		assert.True(t, p.shouldRefresh)
+	}
+
+	// levels are computed once both balances are positive
+	levels, e := p.GetLevels(1000, 500)
+	if !assert.NoError(t, e) || !assert.Equal(t, 2, len(levels)) {
+		return
+	}
+	for _, level := range levels {
+		assert.True(t, level.Price.AsFloat() > 0)
+		assert.True(t, level.Amount.AsFloat() > 0)
+	}
+}
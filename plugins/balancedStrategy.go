@@ -22,6 +22,9 @@ type balancedConfig struct {
 	CarryoverInclusionProbability float64 `valid:"-" toml:"CARRYOVER_INCLUSION_PROBABILITY"` // probability of including the carryover at a level that will be added
 	VirtualBalanceBase            float64 `valid:"-" toml:"VIRTUAL_BALANCE_BASE"`            // virtual balance to use so we can smoothen out the curve
 	VirtualBalanceQuote           float64 `valid:"-" toml:"VIRTUAL_BALANCE_QUOTE"`           // virtual balance to use so we can smoothen out the curve
+	MaxExposureBase               float64 `valid:"-" toml:"MAX_EXPOSURE_BASE"`               // cap on the base balance used to place offers, 0 means no cap
+	MaxExposureQuote              float64 `valid:"-" toml:"MAX_EXPOSURE_QUOTE"`              // cap on the quote balance used to place offers, 0 means no cap
+	RebalanceThreshold            float64 `valid:"-" toml:"REBALANCE_THRESHOLD"`             // relative drift of the portfolio ratio needed before offers are replaced
 }
 
 // String impl.
@@ -58,6 +61,9 @@ func makeBalancedStrategy(
 			config.CarryoverInclusionProbability,
 			config.VirtualBalanceBase,
 			config.VirtualBalanceQuote,
+			config.MaxExposureBase,
+			config.MaxExposureQuote,
+			config.RebalanceThreshold,
 			orderConstraints),
 		config.PriceTolerance,
 		config.AmountTolerance,
@@ -83,6 +89,9 @@ func makeBalancedStrategy(
 			config.CarryoverInclusionProbability,
 			config.VirtualBalanceQuote,
 			config.VirtualBalanceBase,
+			config.MaxExposureQuote,
+			config.MaxExposureBase,
+			config.RebalanceThreshold,
 			orderConstraints),
 		config.PriceTolerance,
 		config.AmountTolerance,
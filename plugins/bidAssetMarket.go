@@ -0,0 +1,137 @@
+package plugins
+
+import (
+	"fmt"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// GetBidAssetQuote returns the asset used to quote bids when the strategy quotes bids in a different asset than asks (BID_ASSET_CODE_B),
+// nil otherwise
+func GetBidAssetQuote(strategy api.Strategy) *hProtocol.Asset {
+	s, ok := strategy.(*composeStrategy)
+	if !ok {
+		return nil
+	}
+	return s.bidAssetQuote
+}
+
+// WrapFiltersForBidAsset wraps every filter so it sees the bids quoted in bidAssetQuote as bids quoted in assetQuote, since the filters only
+// know about the trading pair. All the bids in the filter chain are quoted in bidAssetQuote because the bids in assetQuote are deleted when
+// the existing offers are pruned, so the ops returned by the filters are translated back to bidAssetQuote.
+// This should be called after ResolveFilterChain
+func WrapFiltersForBidAsset(filters []SubmitFilter, assetBase hProtocol.Asset, assetQuote hProtocol.Asset, bidAssetQuote hProtocol.Asset) []SubmitFilter {
+	wrapped := []SubmitFilter{}
+	for _, filter := range filters {
+		wrapped = append(wrapped, &bidAssetFilter{
+			inner:         filter,
+			assetBase:     assetBase,
+			assetQuote:    assetQuote,
+			bidAssetQuote: bidAssetQuote,
+		})
+	}
+	return wrapped
+}
+
+// bidAssetFilter translates the bids between bidAssetQuote and assetQuote around the inner filter
+type bidAssetFilter struct {
+	inner         SubmitFilter
+	assetBase     hProtocol.Asset
+	assetQuote    hProtocol.Asset
+	bidAssetQuote hProtocol.Asset
+}
+
+var _ SubmitFilter = &bidAssetFilter{}
+var _ OrderedSubmitFilter = &bidAssetFilter{}
+
+// FilterOrder impl.
+func (f *bidAssetFilter) FilterOrder() FilterOrder {
+	return getFilterOrder(f.inner)
+}
+
+// Unwrap impl.
+func (f *bidAssetFilter) Unwrap() SubmitFilter {
+	return f.inner
+}
+
+// String is the Stringer method
+func (f *bidAssetFilter) String() string {
+	return filterLabel(f.inner)
+}
+
+// Apply impl.
+func (f *bidAssetFilter) Apply(ops []txnbuild.Operation, sellingOffers []hProtocol.Offer, buyingOffers []hProtocol.Offer) ([]txnbuild.Operation, error) {
+	quotedOps := translateBidOps(ops, f.bidAssetQuote, f.assetBase, f.assetQuote)
+	quotedBuyingOffers := []hProtocol.Offer{}
+	for _, offer := range buyingOffers {
+		if isSameAsset(offer.Selling, f.bidAssetQuote) && isSameAsset(offer.Buying, f.assetBase) {
+			offer.Selling = f.assetQuote
+		}
+		quotedBuyingOffers = append(quotedBuyingOffers, offer)
+	}
+
+	filteredOps, e := f.inner.Apply(quotedOps, sellingOffers, quotedBuyingOffers)
+	if e != nil {
+		return nil, e
+	}
+	return translateBidOps(filteredOps, f.assetQuote, f.assetBase, f.bidAssetQuote), nil
+}
+
+// translateBidOps replaces the selling asset of the ops that sell fromQuote for assetBase with toQuote, the other ops are returned as-is
+func translateBidOps(ops []txnbuild.Operation, fromQuote hProtocol.Asset, assetBase hProtocol.Asset, toQuote hProtocol.Asset) []txnbuild.Operation {
+	translated := []txnbuild.Operation{}
+	for _, op := range ops {
+		o, ok := op.(*txnbuild.ManageSellOffer)
+		if !ok || !isSameAsset(utils.Asset2Asset2(o.Selling), fromQuote) || !isSameAsset(utils.Asset2Asset2(o.Buying), assetBase) {
+			translated = append(translated, op)
+			continue
+		}
+
+		opCopy := *o
+		opCopy.Selling = utils.Asset2Asset(toQuote)
+		translated = append(translated, &opCopy)
+	}
+	return translated
+}
+
+// isSameAsset compares the code and issuer of two assets
+func isSameAsset(a hProtocol.Asset, b hProtocol.Asset) bool {
+	return utils.Asset2String(a) == utils.Asset2String(b)
+}
+
+// bidAssetFillTrackable fetches the trades of the trading account on the market of the bids quoted in bidAssetQuote. The trades are
+// reported with the trading pair of sdex since both quote assets are expected to be the same currency
+type bidAssetFillTrackable struct {
+	sdex          *SDEX
+	assetBase     hProtocol.Asset
+	bidAssetQuote hProtocol.Asset
+}
+
+var _ api.FillTrackable = &bidAssetFillTrackable{}
+
+// MakeBidAssetFillTrackable is a factory method for the FillTrackable of the market of the bids quoted in bidAssetQuote, which should be
+// added to the fill tracker of the trading pair with FillTracker.AddMarket
+func MakeBidAssetFillTrackable(sdex *SDEX, assetBase hProtocol.Asset, bidAssetQuote hProtocol.Asset) api.FillTrackable {
+	return &bidAssetFillTrackable{
+		sdex:          sdex,
+		assetBase:     assetBase,
+		bidAssetQuote: bidAssetQuote,
+	}
+}
+
+// GetTradeHistory impl.
+func (b *bidAssetFillTrackable) GetTradeHistory(pair model.TradingPair, maybeCursorStart interface{}, maybeCursorEnd interface{}) (*api.TradeHistoryResult, error) {
+	if pair != *b.sdex.pair {
+		return nil, fmt.Errorf("passed in pair (%s) did not match sdex.pair (%s)", pair.String(), b.sdex.pair.String())
+	}
+	return b.sdex.getTradeHistory(b.assetBase, b.bidAssetQuote, maybeCursorStart, maybeCursorEnd)
+}
+
+// GetLatestTradeCursor impl.
+func (b *bidAssetFillTrackable) GetLatestTradeCursor() (interface{}, error) {
+	return b.sdex.getLatestTradeCursor(b.assetBase, b.bidAssetQuote)
+}
@@ -0,0 +1,97 @@
+package plugins
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/protocols/horizon/base"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/support/utils"
+)
+
+var testBidQuoteAsset txnbuild.CreditAsset = txnbuild.CreditAsset{Code: "QUOTE", Issuer: "GCQCXCHVJCDPCQKHE4HBTKFEPJRHXGPBYPJB7YUOQ5ANGF4SCZ6AIGVQ"}
+
+func TestWrapFiltersForBidAsset(t *testing.T) {
+	assetBase := utils.Asset2Asset2(testBaseAsset)
+	assetQuote := utils.Asset2Asset2(testQuoteAsset)
+	bidAssetQuote := utils.Asset2Asset2(testBidQuoteAsset)
+
+	minPrice := 1.5
+	minPriceFilter, e := MakeFilterMinPrice(assetBase, assetQuote, &MinPriceFilterConfig{MinPrice: &minPrice})
+	if !assert.NoError(t, e) {
+		return
+	}
+	filters := []SubmitFilter{
+		minPriceFilter,
+		MakeFilterOrderConstraints(model.MakeOrderConstraints(7, 7, 5.0), assetBase, assetQuote),
+	}
+
+	makeOps := func() []txnbuild.Operation {
+		return []txnbuild.Operation{
+			// bid for 10 units of base at a price of 2.0
+			&txnbuild.ManageSellOffer{Selling: testBidQuoteAsset, Buying: testBaseAsset, Amount: "20.0000000", Price: "0.5000000"},
+			// bid below the min price
+			&txnbuild.ManageSellOffer{Selling: testBidQuoteAsset, Buying: testBaseAsset, Amount: "20.0000000", Price: "1.0000000"},
+			// bid below the min base volume
+			&txnbuild.ManageSellOffer{Selling: testBidQuoteAsset, Buying: testBaseAsset, Amount: "4.0000000", Price: "0.4000000"},
+			makeTestSellOp(0, "10.0000000", "2.0000000"),
+		}
+	}
+	// existing bid below the min price, which does not have an op so it should be deleted by the min price filter
+	buyingOffers := []hProtocol.Offer{{
+		ID:      5,
+		Selling: bidAssetQuote,
+		Buying:  assetBase,
+		Amount:  "5.0000000",
+		Price:   "1.0000000",
+		PriceR:  base.Price{N: 1, D: 1},
+	}}
+
+	// the filters cannot tell whether an op sells the bid quote asset when they are not wrapped
+	_, e = applyTestFilters(filters, makeOps(), buyingOffers)
+	assert.Error(t, e)
+
+	ops, e := applyTestFilters(WrapFiltersForBidAsset(filters, assetBase, assetQuote, bidAssetQuote), makeOps(), buyingOffers)
+	if !assert.NoError(t, e) {
+		return
+	}
+
+	bids := []txnbuild.ManageSellOffer{}
+	asks := []txnbuild.ManageSellOffer{}
+	for _, op := range ops {
+		mso := op.(*txnbuild.ManageSellOffer)
+		if isSameAsset(utils.Asset2Asset2(mso.Buying), assetBase) {
+			// bids are returned in the bid quote asset
+			assert.True(t, isSameAsset(utils.Asset2Asset2(mso.Selling), bidAssetQuote), "bid sells %s", mso.Selling.GetIssuer())
+			bids = append(bids, *mso)
+		} else {
+			asks = append(asks, *mso)
+		}
+	}
+	if !assert.Equal(t, 2, len(bids)) {
+		return
+	}
+	assert.Equal(t, int64(0), bids[0].OfferID)
+	assert.Equal(t, "0.5000000", bids[0].Price)
+	assert.Equal(t, "20.0000000", bids[0].Amount)
+	assert.Equal(t, int64(5), bids[1].OfferID)
+	assert.Equal(t, "0", bids[1].Amount)
+	if !assert.Equal(t, 1, len(asks)) {
+		return
+	}
+	assert.Equal(t, "2.0000000", asks[0].Price)
+}
+
+func applyTestFilters(filters []SubmitFilter, ops []txnbuild.Operation, buyingOffers []hProtocol.Offer) ([]txnbuild.Operation, error) {
+	var e error
+	for _, f := range filters {
+		ops, e = f.Apply(ops, []hProtocol.Offer{}, buyingOffers)
+		if e != nil {
+			return nil, e
+		}
+	}
+	return ops, nil
+}
@@ -346,6 +346,7 @@ func (beWs *binanceExchangeWs) GetTickerPrice(pairs []model.TradingPair) (map[mo
 			AskPrice:  model.NumberFromFloat(askPrice, getPrecision(ticker.AskPrice)),
 			BidPrice:  model.NumberFromFloat(bidPrice, getPrecision(ticker.BidPrice)),
 			LastPrice: model.NumberFromFloat(lastPrice, getPrecision(ticker.LastPrice)),
+			Timestamp: model.MakeTimestamp(ticker.Time),
 		}
 	}
 
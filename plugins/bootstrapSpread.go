@@ -0,0 +1,117 @@
+package plugins
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sync"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/queries"
+)
+
+// bootstrapSpread widens the spread of every level on a brand-new market that has no orderbook to price against, so the first offers
+// quoted from an external feed are placed far from the reference price. The spreads tighten linearly to the configured levels as the
+// market proves itself with realized fills and traded volume. Progress is the smaller of the progress towards the two targets, so
+// neither a single large fill nor a flurry of tiny fills tightens the spreads on its own.
+type bootstrapSpread struct {
+	initialMultiplier float64
+	targetFills       int     // 0 when only the volume target is used
+	targetBaseVolume  float64 // 0 when only the fills target is used
+
+	// uninitialized
+	lock       *sync.Mutex
+	fills      int
+	baseVolume float64
+}
+
+// ensure it implements FillHandler
+var _ api.FillHandler = &bootstrapSpread{}
+
+// makeBootstrapSpread is a factory method
+func makeBootstrapSpread(initialMultiplier float64, targetFills int, targetBaseVolume float64) (*bootstrapSpread, error) {
+	if initialMultiplier < 1.0 {
+		return nil, fmt.Errorf("BOOTSTRAP_SPREAD_MULTIPLIER needs to be >= 1.0 but was %f", initialMultiplier)
+	}
+	if targetFills < 0 || targetBaseVolume < 0 {
+		return nil, fmt.Errorf("BOOTSTRAP_TARGET_FILLS (%d) and BOOTSTRAP_TARGET_BASE_VOLUME (%f) cannot be negative", targetFills, targetBaseVolume)
+	}
+	if targetFills == 0 && targetBaseVolume == 0 {
+		return nil, fmt.Errorf("at least one of BOOTSTRAP_TARGET_FILLS and BOOTSTRAP_TARGET_BASE_VOLUME needs to be set when BOOTSTRAP_SPREAD_MULTIPLIER is set")
+	}
+
+	return &bootstrapSpread{
+		initialMultiplier: initialMultiplier,
+		targetFills:       targetFills,
+		targetBaseVolume:  targetBaseVolume,
+		lock:              &sync.Mutex{},
+	}, nil
+}
+
+// maybeMakeBootstrapSpread makes the bootstrapSpread of the strategy config when BOOTSTRAP_SPREAD_MULTIPLIER is set (non-zero), the
+// fills and volume already recorded in the db are counted so a restarted bot does not go back to the widest spreads
+func maybeMakeBootstrapSpread(config *BuySellConfig, strategyFactoryData strategyFactoryData) (*bootstrapSpread, error) {
+	if config.BootstrapSpreadMultiplier == 0 {
+		return nil, nil
+	}
+	b, e := makeBootstrapSpread(config.BootstrapSpreadMultiplier, config.BootstrapTargetFills, config.BootstrapTargetBaseVolume)
+	if e != nil {
+		return nil, e
+	}
+
+	if strategyFactoryData.db == nil {
+		log.Printf("bootstrap: no db is configured so the fills of previous runs are not counted, spreads start at %.2fx\n", b.initialMultiplier)
+		return b, nil
+	}
+	q, e := queries.MakeTradeTotals(strategyFactoryData.db, strategyFactoryData.marketID, strategyFactoryData.filterFactory.AccountID)
+	if e != nil {
+		return nil, fmt.Errorf("could not make the query for the trades of previous runs: %s", e)
+	}
+	result, e := q.QueryRow()
+	if e != nil {
+		return nil, fmt.Errorf("could not load the trades of previous runs: %s", e)
+	}
+	totals := result.(*queries.TradeTotalsResult)
+	b.add(totals.NumTrades, totals.TotalBaseVolume)
+	log.Printf("bootstrap: counted %d fills with a base volume of %.8f from previous runs, spread multiplier is %.4f\n", totals.NumTrades, totals.TotalBaseVolume, b.multiplier())
+	return b, nil
+}
+
+func (b *bootstrapSpread) add(fills int, baseVolume float64) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.fills += fills
+	b.baseVolume += baseVolume
+}
+
+// progress returns how far the market is through the bootstrap period, from 0.0 to 1.0. Must be called with the lock held.
+func (b *bootstrapSpread) progress() float64 {
+	progress := 1.0
+	if b.targetFills > 0 {
+		progress = math.Min(progress, float64(b.fills)/float64(b.targetFills))
+	}
+	if b.targetBaseVolume > 0 {
+		progress = math.Min(progress, b.baseVolume/b.targetBaseVolume)
+	}
+	return progress
+}
+
+// multiplier returns the factor that the spread of every level is multiplied by, a nil bootstrapSpread does not change the spreads
+func (b *bootstrapSpread) multiplier() float64 {
+	if b == nil {
+		return 1.0
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.initialMultiplier - (b.initialMultiplier-1.0)*b.progress()
+}
+
+// HandleFill impl
+func (b *bootstrapSpread) HandleFill(trade model.Trade) error {
+	b.add(1, trade.Volume.AsFloat())
+	log.Printf("bootstrap: counted fill of %.8f units of the base asset, spread multiplier is now %.4f\n", trade.Volume.AsFloat(), b.multiplier())
+	return nil
+}
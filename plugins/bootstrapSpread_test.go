@@ -0,0 +1,108 @@
+package plugins
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+)
+
+func TestBootstrapSpread_Multiplier(t *testing.T) {
+	testCases := []struct {
+		name             string
+		targetFills      int
+		targetBaseVolume float64
+		fills            int
+		baseVolume       float64
+		want             float64
+	}{
+		{name: "no fills", targetFills: 10, targetBaseVolume: 1000.0, want: 5.0},
+		{name: "halfway", targetFills: 10, targetBaseVolume: 1000.0, fills: 5, baseVolume: 500.0, want: 3.0},
+		{name: "volume lags", targetFills: 10, targetBaseVolume: 1000.0, fills: 10, baseVolume: 250.0, want: 4.0},
+		{name: "fills lag", targetFills: 10, targetBaseVolume: 1000.0, fills: 1, baseVolume: 5000.0, want: 4.6},
+		{name: "done", targetFills: 10, targetBaseVolume: 1000.0, fills: 20, baseVolume: 2000.0, want: 1.0},
+		{name: "only fills", targetFills: 4, fills: 1, baseVolume: 0.0, want: 4.0},
+		{name: "only volume", targetBaseVolume: 100.0, fills: 100, baseVolume: 75.0, want: 2.0},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			b, e := makeBootstrapSpread(5.0, k.targetFills, k.targetBaseVolume)
+			if !assert.NoError(t, e) {
+				return
+			}
+			b.add(k.fills, k.baseVolume)
+			assert.InDelta(t, k.want, b.multiplier(), 0.0000001)
+		})
+	}
+
+	var nilBootstrap *bootstrapSpread
+	assert.Equal(t, 1.0, nilBootstrap.multiplier())
+}
+
+func TestMakeBootstrapSpread_Errors(t *testing.T) {
+	for _, k := range []struct {
+		multiplier       float64
+		targetFills      int
+		targetBaseVolume float64
+	}{
+		{0.5, 10, 0},
+		{2.0, 0, 0},
+		{2.0, -1, 100.0},
+		{2.0, 10, -1.0},
+	} {
+		_, e := makeBootstrapSpread(k.multiplier, k.targetFills, k.targetBaseVolume)
+		assert.Error(t, e, "%v", k)
+	}
+}
+
+func TestBootstrapSpread_StaticLevels(t *testing.T) {
+	pf, e := MakeFeedPair("fixed", "1.0", "fixed", "1.0")
+	if !assert.NoError(t, e) {
+		return
+	}
+	b, e := makeBootstrapSpread(3.0, 2, 0)
+	if !assert.NoError(t, e) {
+		return
+	}
+	orderConstraints := model.MakeOrderConstraints(7, 5, 1.0)
+	feeds := map[string]*api.FeedPair{defaultLevelFeed: pf}
+	sellSide := makeStaticSpreadLevelProvider([]StaticLevel{{SPREAD: 0.01, AMOUNT: 1.0}}, 10.0, amountUnitBase, rateOffset{}, feeds, orderConstraints, false, b)
+	buySide := makeStaticSpreadLevelProvider([]StaticLevel{{SPREAD: 0.01, AMOUNT: 1.0}}, 10.0, amountUnitBase, rateOffset{}, feeds, orderConstraints, true, b)
+
+	// only one side counts the fills of the shared bootstrapSpread
+	sellHandlers, e := sellSide.GetFillHandlers()
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, 1, len(sellHandlers))
+	buyHandlers, e := buySide.GetFillHandlers()
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, 0, len(buyHandlers))
+
+	for _, k := range []struct {
+		numFills  int
+		wantPrice string
+	}{
+		{0, "1.0300000"},
+		{1, "1.0200000"},
+		{1, "1.0100000"},
+		{1, "1.0100000"},
+	} {
+		for i := 0; i < k.numFills; i++ {
+			e = sellHandlers[0].HandleFill(model.Trade{Order: model.Order{Volume: model.NumberFromFloat(1.0, 5)}})
+			if !assert.NoError(t, e) {
+				return
+			}
+		}
+		levels, e := sellSide.GetLevels(1000.0, 1000.0)
+		if !assert.NoError(t, e) {
+			return
+		}
+		assert.Equal(t, k.wantPrice, levels[0].Price.AsString())
+	}
+}
@@ -0,0 +1,292 @@
+package plugins
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/kelpdb"
+	"github.com/stellar/kelp/queries"
+	"github.com/stellar/kelp/support/postgresdb"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// botCoordinationFilter coordinates the bots that share a db so they do not trade against each other. It drops any offer that would cross
+// the best bid or ask of another bot that trades the same pair of assets in either direction (such as XLM/USDC and USDC/XLM), and publishes
+// the best bid and ask of this bot to the bot_quotes table once the ops of the update are submitted. It does not coordinate the hedges of
+// the bots, so bots that hedge their fills can still hedge flow that the other bots hedge as well
+type botCoordinationFilter struct {
+	name             string
+	configValue      string
+	botID            string
+	baseAsset        hProtocol.Asset
+	quoteAsset       hProtocol.Asset
+	staleDuration    time.Duration
+	db               *sql.DB
+	otherQuotesQuery api.Query
+	clock            api.Clock
+}
+
+// makeFilterBotCoordination makes a submit filter that keeps this bot from crossing the offers of the other bots, the quotes of bots that
+// have not updated them within staleDuration are ignored
+func makeFilterBotCoordination(
+	configValue string,
+	botID string,
+	baseAsset hProtocol.Asset,
+	quoteAsset hProtocol.Asset,
+	staleDuration time.Duration,
+	db *sql.DB,
+	clock api.Clock,
+) (SubmitFilter, error) {
+	if botID == "" {
+		return nil, fmt.Errorf("botID cannot be empty")
+	}
+	if staleDuration <= 0 {
+		return nil, fmt.Errorf("staleDuration needs to be greater than 0, was %s", staleDuration)
+	}
+
+	otherQuotesQuery, e := queries.MakeOtherBotQuotes(db, botID, utils.Asset2String(baseAsset), utils.Asset2String(quoteAsset))
+	if e != nil {
+		return nil, fmt.Errorf("could not make other bot quotes query: %s", e)
+	}
+
+	return &botCoordinationFilter{
+		name:             "botCoordinationFilter",
+		configValue:      configValue,
+		botID:            botID,
+		baseAsset:        baseAsset,
+		quoteAsset:       quoteAsset,
+		staleDuration:    staleDuration,
+		db:               db,
+		otherQuotesQuery: otherQuotesQuery,
+		clock:            clock,
+	}, nil
+}
+
+var _ SubmitFilter = &botCoordinationFilter{}
+var _ OrderedSubmitFilter = &botCoordinationFilter{}
+var _ SubmitListener = &botCoordinationFilter{}
+
+// FilterOrder impl.
+func (f *botCoordinationFilter) FilterOrder() FilterOrder {
+	// runs after the filters that change the price or amount of offers so it checks the prices of the offers that are placed
+	return FilterOrder{
+		ID:       filterIDBotCoordination,
+		Priority: FilterPriorityConstraints,
+		After:    append(append([]string{}, priceAndOrderSizeFilterIDs...), filterIDVolume, filterIDExposure, filterIDPortfolioBudget),
+	}
+}
+
+// Apply impl.
+func (f *botCoordinationFilter) Apply(ops []txnbuild.Operation, sellingOffers []hProtocol.Offer, buyingOffers []hProtocol.Offer) ([]txnbuild.Operation, error) {
+	result, e := f.otherQuotesQuery.QueryRow(f.clock.Now().Add(-f.staleDuration))
+	if e != nil {
+		return nil, fmt.Errorf("could not load the quotes of the other bots: %s", e)
+	}
+	otherQuotes, ok := result.([]queries.BotQuote)
+	if !ok {
+		return nil, fmt.Errorf("incorrect type returned from OtherBotQuotes query, expecting '[]queries.BotQuote' but was '%T'", result)
+	}
+
+	otherBestBid, otherBestAsk := mergeBotQuotes(utils.Asset2String(f.baseAsset), otherQuotes)
+	if otherBestBid != nil || otherBestAsk != nil {
+		log.Printf("botCoordinationFilter: best quotes of %d other bots: bid=%s, ask=%s\n", len(otherQuotes), utils.CheckedFloatPtr(otherBestBid), utils.CheckedFloatPtr(otherBestAsk))
+		innerFn := func(op *txnbuild.ManageSellOffer) (*txnbuild.ManageSellOffer, error) {
+			return f.botCoordinationFilterFn(otherBestBid, otherBestAsk, op)
+		}
+		ops, e = filterOps(f.name, f.baseAsset, f.quoteAsset, sellingOffers, buyingOffers, ops, innerFn)
+		if e != nil {
+			return nil, fmt.Errorf("could not apply filter: %s", e)
+		}
+	}
+	return ops, nil
+}
+
+// Submitted impl.
+func (f *botCoordinationFilter) Submitted(ops []txnbuild.Operation, sellingOffers []hProtocol.Offer, buyingOffers []hProtocol.Offer, e error) {
+	// the quotes are published after the submission so the other bots do not see offers that never made it to the book, and the existing
+	// offers are published when the submission failed since they are still on the book
+	if e != nil {
+		ops = []txnbuild.Operation{}
+	}
+	bestBid, bestAsk, e := bestQuotesAfterOps(f.baseAsset, f.quoteAsset, ops, sellingOffers, buyingOffers)
+	if e != nil {
+		log.Printf("botCoordinationFilter: could not compute the best quotes of this bot: %s\n", e)
+		return
+	}
+	e = f.publishQuotes(bestBid, bestAsk, f.clock.Now())
+	if e != nil {
+		log.Printf("botCoordinationFilter: could not publish the best quotes of this bot: %s\n", e)
+	}
+}
+
+// botCoordinationFilterFn drops offers that would cross the best bid or ask of the other bots
+func (f *botCoordinationFilter) botCoordinationFilterFn(otherBestBid *float64, otherBestAsk *float64, op *txnbuild.ManageSellOffer) (*txnbuild.ManageSellOffer, error) {
+	isSell, price, e := quoteOfOp(f.baseAsset, f.quoteAsset, op)
+	if e != nil {
+		return nil, e
+	}
+
+	if isSell && otherBestBid != nil && price <= *otherBestBid {
+		log.Printf("botCoordinationFilter: dropping sell offer at price %.10f because it would cross the bid of another bot at %.10f\n", price, *otherBestBid)
+		return nil, nil
+	}
+	if !isSell && otherBestAsk != nil && price >= *otherBestAsk {
+		log.Printf("botCoordinationFilter: dropping buy offer at price %.10f because it would cross the ask of another bot at %.10f\n", price, *otherBestAsk)
+		return nil, nil
+	}
+	return op, nil
+}
+
+// publishQuotes saves the best bid and ask of this bot so the other bots can read them
+func (f *botCoordinationFilter) publishQuotes(bestBid *float64, bestAsk *float64, now time.Time) error {
+	sqlUpsert := fmt.Sprintf(kelpdb.SqlBotQuotesUpsertTemplate,
+		f.botID,
+		utils.Asset2String(f.baseAsset),
+		utils.Asset2String(f.quoteAsset),
+		sqlFloatOrNull(bestBid),
+		sqlFloatOrNull(bestAsk),
+		now.UTC().Format(postgresdb.TimestampFormatString),
+	)
+	_, e := f.db.Exec(sqlUpsert)
+	if e != nil {
+		return fmt.Errorf("could not execute sql upsert statement (%s): %s", sqlUpsert, e)
+	}
+	return nil
+}
+
+// String is the Stringer method
+func (f *botCoordinationFilter) String() string {
+	return f.configValue
+}
+
+// mergeBotQuotes returns the highest bid and lowest ask of the quotes in units of the quote asset per unit of baseAsset, inverting the quotes of
+// bots that trade the flipped pair. A bid on the flipped pair buys baseAsset so it is an ask on this pair, and the other way around
+func mergeBotQuotes(baseAsset string, quotes []queries.BotQuote) (*float64, *float64) {
+	var bestBid *float64
+	var bestAsk *float64
+	for _, q := range quotes {
+		bid := q.BestBid
+		ask := q.BestAsk
+		if q.BaseAsset != baseAsset {
+			bid = invertPricePtr(q.BestAsk)
+			ask = invertPricePtr(q.BestBid)
+		}
+
+		if bid != nil && (bestBid == nil || *bid > *bestBid) {
+			bestBid = bid
+		}
+		if ask != nil && (bestAsk == nil || *ask < *bestAsk) {
+			bestAsk = ask
+		}
+	}
+	return bestBid, bestAsk
+}
+
+// bestQuotesAfterOps returns the highest bid and lowest ask of the offers that will be on the book once the ops are applied to the existing offers
+func bestQuotesAfterOps(
+	baseAsset hProtocol.Asset,
+	quoteAsset hProtocol.Asset,
+	ops []txnbuild.Operation,
+	sellingOffers []hProtocol.Offer,
+	buyingOffers []hProtocol.Offer,
+) (*float64, *float64, error) {
+	type quote struct {
+		isSell bool
+		price  float64
+	}
+	book := map[int64]quote{}
+	for _, o := range sellingOffers {
+		price, e := strconv.ParseFloat(o.Price, 64)
+		if e != nil {
+			return nil, nil, fmt.Errorf("could not parse price of selling offer %d: %s", o.ID, e)
+		}
+		book[o.ID] = quote{isSell: true, price: price}
+	}
+	for _, o := range buyingOffers {
+		price, e := strconv.ParseFloat(o.Price, 64)
+		if e != nil {
+			return nil, nil, fmt.Errorf("could not parse price of buying offer %d: %s", o.ID, e)
+		}
+		// buying offers sell the quote asset so their price is inverted
+		book[o.ID] = quote{isSell: false, price: 1 / price}
+	}
+
+	// new offers do not have an ID yet so we give them negative keys that cannot clash with existing offers
+	newOfferKey := int64(-1)
+	for _, op := range ops {
+		mso, ok := op.(*txnbuild.ManageSellOffer)
+		if !ok {
+			continue
+		}
+		amount, e := strconv.ParseFloat(mso.Amount, 64)
+		if e != nil {
+			return nil, nil, fmt.Errorf("could not parse amount of op: %s", e)
+		}
+		if amount == 0 {
+			delete(book, mso.OfferID)
+			continue
+		}
+		isSell, price, e := quoteOfOp(baseAsset, quoteAsset, mso)
+		if e != nil {
+			return nil, nil, e
+		}
+
+		key := mso.OfferID
+		if key == 0 {
+			key = newOfferKey
+			newOfferKey--
+		}
+		book[key] = quote{isSell: isSell, price: price}
+	}
+
+	var bestBid *float64
+	var bestAsk *float64
+	for _, q := range book {
+		price := q.price
+		if q.isSell && (bestAsk == nil || price < *bestAsk) {
+			bestAsk = &price
+		} else if !q.isSell && (bestBid == nil || price > *bestBid) {
+			bestBid = &price
+		}
+	}
+	return bestBid, bestAsk, nil
+}
+
+// quoteOfOp returns whether the op is a sell and its price in units of the quote asset per unit of the base asset
+func quoteOfOp(baseAsset hProtocol.Asset, quoteAsset hProtocol.Asset, op *txnbuild.ManageSellOffer) (bool, float64, error) {
+	isSell, e := utils.IsSelling(baseAsset, quoteAsset, op.Selling, op.Buying)
+	if e != nil {
+		return false, 0, fmt.Errorf("could not check whether the op was selling or buying: %s", e)
+	}
+	price, e := strconv.ParseFloat(op.Price, 64)
+	if e != nil {
+		return false, 0, fmt.Errorf("could not parse price of op: %s", e)
+	}
+	if !isSell {
+		// buy ops sell the quote asset so their price is inverted
+		price = 1 / price
+	}
+	return isSell, price, nil
+}
+
+func invertPricePtr(price *float64) *float64 {
+	if price == nil || *price == 0 {
+		return nil
+	}
+	inverted := 1 / *price
+	return &inverted
+}
+
+// sqlFloatOrNull formats a float for a sql statement, using NULL when it is nil
+func sqlFloatOrNull(value *float64) string {
+	if value == nil {
+		return "NULL"
+	}
+	return fmt.Sprintf("%.15f", *value)
+}
@@ -0,0 +1,231 @@
+package plugins
+
+import (
+	"testing"
+
+	"github.com/openlyinc/pointy"
+	"github.com/stretchr/testify/assert"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/kelp/queries"
+	"github.com/stellar/kelp/support/utils"
+)
+
+func TestMergeBotQuotes(t *testing.T) {
+	base := utils.Asset2String(utils.Asset2Asset2(testBaseAsset))
+	quote := utils.Asset2String(utils.Asset2Asset2(testQuoteAsset))
+
+	testCases := []struct {
+		name    string
+		quotes  []queries.BotQuote
+		wantBid *float64
+		wantAsk *float64
+	}{
+		{
+			name:    "no quotes",
+			quotes:  []queries.BotQuote{},
+			wantBid: nil,
+			wantAsk: nil,
+		}, {
+			name: "same pair",
+			quotes: []queries.BotQuote{
+				{BotID: "a", BaseAsset: base, QuoteAsset: quote, BestBid: pointy.Float64(0.9), BestAsk: pointy.Float64(1.2)},
+				{BotID: "b", BaseAsset: base, QuoteAsset: quote, BestBid: pointy.Float64(0.95), BestAsk: pointy.Float64(1.1)},
+			},
+			wantBid: pointy.Float64(0.95),
+			wantAsk: pointy.Float64(1.1),
+		}, {
+			name: "one sided quotes",
+			quotes: []queries.BotQuote{
+				{BotID: "a", BaseAsset: base, QuoteAsset: quote, BestBid: pointy.Float64(0.9)},
+				{BotID: "b", BaseAsset: base, QuoteAsset: quote, BestAsk: pointy.Float64(1.1)},
+			},
+			wantBid: pointy.Float64(0.9),
+			wantAsk: pointy.Float64(1.1),
+		}, {
+			name: "flipped pair",
+			quotes: []queries.BotQuote{
+				// a bid of 0.5 base per quote buys the base asset at 2.0, an ask of 0.8 base per quote sells the base asset at 1.25
+				{BotID: "a", BaseAsset: quote, QuoteAsset: base, BestBid: pointy.Float64(0.5), BestAsk: pointy.Float64(0.8)},
+			},
+			wantBid: pointy.Float64(1.25),
+			wantAsk: pointy.Float64(2.0),
+		}, {
+			name: "flipped pair with only a bid",
+			quotes: []queries.BotQuote{
+				{BotID: "a", BaseAsset: quote, QuoteAsset: base, BestBid: pointy.Float64(0.5)},
+				{BotID: "b", BaseAsset: base, QuoteAsset: quote, BestBid: pointy.Float64(0.9), BestAsk: pointy.Float64(2.5)},
+			},
+			wantBid: pointy.Float64(0.9),
+			wantAsk: pointy.Float64(2.0),
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			bid, ask := mergeBotQuotes(base, k.quotes)
+			assertFloatPtrInDelta(t, k.wantBid, bid)
+			assertFloatPtrInDelta(t, k.wantAsk, ask)
+		})
+	}
+}
+
+func TestBestQuotesAfterOps(t *testing.T) {
+	base := utils.Asset2Asset2(testBaseAsset)
+	quote := utils.Asset2Asset2(testQuoteAsset)
+	sellingOffers := []hProtocol.Offer{
+		{ID: 1, Price: "1.2"},
+		{ID: 2, Price: "1.3"},
+	}
+	buyingOffers := []hProtocol.Offer{
+		// price is in units of the base asset per unit of the quote asset, so this bids 0.8
+		{ID: 3, Price: "1.25"},
+	}
+
+	testCases := []struct {
+		name    string
+		ops     []txnbuild.Operation
+		wantBid *float64
+		wantAsk *float64
+	}{
+		{
+			name:    "no ops",
+			ops:     []txnbuild.Operation{},
+			wantBid: pointy.Float64(0.8),
+			wantAsk: pointy.Float64(1.2),
+		}, {
+			name: "delete best ask",
+			ops: []txnbuild.Operation{
+				&txnbuild.ManageSellOffer{Selling: testBaseAsset, Buying: testQuoteAsset, Amount: "0", Price: "1.2", OfferID: 1},
+			},
+			wantBid: pointy.Float64(0.8),
+			wantAsk: pointy.Float64(1.3),
+		}, {
+			name: "update ask and add bid",
+			ops: []txnbuild.Operation{
+				&txnbuild.ManageSellOffer{Selling: testBaseAsset, Buying: testQuoteAsset, Amount: "10", Price: "1.4", OfferID: 1},
+				&txnbuild.ManageSellOffer{Selling: testQuoteAsset, Buying: testBaseAsset, Amount: "10", Price: "1.1111111"},
+			},
+			wantBid: pointy.Float64(0.9),
+			wantAsk: pointy.Float64(1.3),
+		}, {
+			name: "delete all offers",
+			ops: []txnbuild.Operation{
+				&txnbuild.ManageSellOffer{Selling: testBaseAsset, Buying: testQuoteAsset, Amount: "0", Price: "1.2", OfferID: 1},
+				&txnbuild.ManageSellOffer{Selling: testBaseAsset, Buying: testQuoteAsset, Amount: "0", Price: "1.3", OfferID: 2},
+				&txnbuild.ManageSellOffer{Selling: testQuoteAsset, Buying: testBaseAsset, Amount: "0", Price: "1.25", OfferID: 3},
+			},
+			wantBid: nil,
+			wantAsk: nil,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			bid, ask, e := bestQuotesAfterOps(base, quote, k.ops, sellingOffers, buyingOffers)
+			if !assert.NoError(t, e) {
+				return
+			}
+			assertFloatPtrInDelta(t, k.wantBid, bid)
+			assertFloatPtrInDelta(t, k.wantAsk, ask)
+		})
+	}
+}
+
+func TestBotCoordinationFilterFn(t *testing.T) {
+	f := &botCoordinationFilter{
+		name:       "botCoordinationFilter",
+		baseAsset:  utils.Asset2Asset2(testBaseAsset),
+		quoteAsset: utils.Asset2Asset2(testQuoteAsset),
+	}
+
+	testCases := []struct {
+		name         string
+		otherBestBid *float64
+		otherBestAsk *float64
+		op           *txnbuild.ManageSellOffer
+		wantDropped  bool
+	}{
+		{
+			name:         "sell above other bid",
+			otherBestBid: pointy.Float64(1.0),
+			op:           &txnbuild.ManageSellOffer{Selling: testBaseAsset, Buying: testQuoteAsset, Amount: "10", Price: "1.1"},
+			wantDropped:  false,
+		}, {
+			name:         "sell at other bid",
+			otherBestBid: pointy.Float64(1.0),
+			op:           &txnbuild.ManageSellOffer{Selling: testBaseAsset, Buying: testQuoteAsset, Amount: "10", Price: "1.0"},
+			wantDropped:  true,
+		}, {
+			name:         "sell is not compared to other ask",
+			otherBestAsk: pointy.Float64(0.5),
+			op:           &txnbuild.ManageSellOffer{Selling: testBaseAsset, Buying: testQuoteAsset, Amount: "10", Price: "1.0"},
+			wantDropped:  false,
+		}, {
+			name:         "buy below other ask",
+			otherBestAsk: pointy.Float64(1.0),
+			// bids 0.8
+			op:          &txnbuild.ManageSellOffer{Selling: testQuoteAsset, Buying: testBaseAsset, Amount: "10", Price: "1.25"},
+			wantDropped: false,
+		}, {
+			name:         "buy above other ask",
+			otherBestAsk: pointy.Float64(1.0),
+			// bids 1.25
+			op:          &txnbuild.ManageSellOffer{Selling: testQuoteAsset, Buying: testBaseAsset, Amount: "10", Price: "0.8"},
+			wantDropped: true,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			actual, e := f.botCoordinationFilterFn(k.otherBestBid, k.otherBestAsk, k.op)
+			if !assert.NoError(t, e) {
+				return
+			}
+			if k.wantDropped {
+				assert.Nil(t, actual)
+			} else {
+				assert.Equal(t, k.op, actual)
+			}
+		})
+	}
+}
+
+func TestMakeFilterBotCoordination_Errors(t *testing.T) {
+	base := utils.Asset2Asset2(testBaseAsset)
+	quote := utils.Asset2Asset2(testQuoteAsset)
+
+	_, e := makeFilterBotCoordination("botCoordination//60", "", base, quote, 60e9, nil, nil)
+	assert.Error(t, e)
+
+	_, e = makeFilterBotCoordination("botCoordination/bot1/0", "bot1", base, quote, 0, nil, nil)
+	assert.Error(t, e)
+
+	// the db is required
+	_, e = makeFilterBotCoordination("botCoordination/bot1/60", "bot1", base, quote, 60e9, nil, nil)
+	assert.Error(t, e)
+}
+
+func TestFindSubmitListeners(t *testing.T) {
+	f := &botCoordinationFilter{name: "botCoordinationFilter"}
+	orderSize, e := makeFilterOrderSize("", utils.Asset2Asset2(testBaseAsset), utils.Asset2Asset2(testQuoteAsset), 10.0, 100.0, OrderSizeActionCap)
+	if !assert.NoError(t, e) {
+		return
+	}
+
+	// the quotes are published by the filter once the ops are submitted, so it needs to be found through the wrapper of the filter chain
+	listeners := FindSubmitListeners([]SubmitFilter{MakeConfiguredFilter(orderSize, "", nil), MakeConfiguredFilter(f, "", nil)})
+	assert.Equal(t, []SubmitListener{f}, listeners)
+}
+
+func assertFloatPtrInDelta(t *testing.T, want *float64, actual *float64) {
+	if want == nil {
+		assert.Nil(t, actual)
+		return
+	}
+	if !assert.NotNil(t, actual) {
+		return
+	}
+	assert.InDelta(t, *want, *actual, 0.0000001)
+}
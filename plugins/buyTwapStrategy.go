@@ -2,7 +2,7 @@ package plugins
 
 import (
 	"fmt"
-	"time"
+	"math/rand"
 
 	hProtocol "github.com/stellar/go/protocols/horizon"
 	"github.com/stellar/kelp/api"
@@ -18,6 +18,7 @@ func makeBuyTwapStrategy(
 	assetQuote *hProtocol.Asset,
 	filterFactory *FilterFactory,
 	config *sellTwapConfig,
+	clock api.Clock,
 ) (api.Strategy, error) {
 	startPf, e := MakePriceFeed(config.StartAskFeedType, config.StartAskFeedURL)
 	if e != nil {
@@ -34,6 +35,10 @@ func makeBuyTwapStrategy(
 	if e != nil {
 		return nil, fmt.Errorf("error when making dowFilter: %s", e)
 	}
+	holidayCalendar, e := makeHolidayCalendar(config.HolidayCalendar, config.HolidayCapMultiplier)
+	if e != nil {
+		return nil, fmt.Errorf("error when making the holiday calendar: %s", e)
+	}
 	levelProvider, e := makeSellTwapLevelProvider(
 		startPf,
 		offset,
@@ -44,8 +49,12 @@ func makeBuyTwapStrategy(
 		config.DistributeSurplusOverRemainingIntervalsPercentCeiling,
 		config.ExponentialSmoothingFactor,
 		config.MinChildOrderSizePercentOfParent,
-		time.Now().UnixNano(),
+		rand.New(rand.NewSource(clock.Now().UnixNano())),
+		clock,
 		true,
+		nil,
+		config.CarrySurplusAcrossDays,
+		holidayCalendar,
 	)
 	if e != nil {
 		return nil, fmt.Errorf("error when making a sellTwapLevelProvider: %s", e)
@@ -1,6 +1,7 @@
 package plugins
 
 import (
+	"database/sql"
 	"fmt"
 
 	hProtocol "github.com/stellar/go/protocols/horizon"
@@ -9,19 +10,40 @@ import (
 	"github.com/stellar/kelp/support/utils"
 )
 
+// LevelFeedConfig is a named price feed that levels can use with FEED instead of the price feed of DATA_FEED_A_URL and DATA_FEED_B_URL
+type LevelFeedConfig struct {
+	Name         string `valid:"-" toml:"NAME" json:"name"`
+	DataTypeA    string `valid:"-" toml:"DATA_TYPE_A" json:"data_type_a"`
+	DataFeedAURL string `valid:"-" toml:"DATA_FEED_A_URL" json:"data_feed_a_url"`
+	DataTypeB    string `valid:"-" toml:"DATA_TYPE_B" json:"data_type_b"`
+	DataFeedBURL string `valid:"-" toml:"DATA_FEED_B_URL" json:"data_feed_b_url"`
+}
+
 // BuySellConfig contains the configuration params for this strategy
 type BuySellConfig struct {
-	PriceTolerance         float64       `valid:"-" toml:"PRICE_TOLERANCE" json:"price_tolerance"`
-	AmountTolerance        float64       `valid:"-" toml:"AMOUNT_TOLERANCE" json:"amount_tolerance"`
-	RateOffsetPercent      float64       `valid:"-" toml:"RATE_OFFSET_PERCENT" json:"rate_offset_percent"`
-	RateOffset             float64       `valid:"-" toml:"RATE_OFFSET" json:"rate_offset"`
-	RateOffsetPercentFirst bool          `valid:"-" toml:"RATE_OFFSET_PERCENT_FIRST" json:"rate_offset_percent_first"`
-	AmountOfABase          float64       `valid:"-" toml:"AMOUNT_OF_A_BASE" json:"amount_of_a_base"` // the size of order to keep on either side
-	DataTypeA              string        `valid:"-" toml:"DATA_TYPE_A" json:"data_type_a"`
-	DataFeedAURL           string        `valid:"-" toml:"DATA_FEED_A_URL" json:"data_feed_a_url"`
-	DataTypeB              string        `valid:"-" toml:"DATA_TYPE_B" json:"data_type_b"`
-	DataFeedBURL           string        `valid:"-" toml:"DATA_FEED_B_URL" json:"data_feed_b_url"`
-	Levels                 []StaticLevel `valid:"-" toml:"LEVELS" json:"levels"`
+	PriceTolerance         float64           `valid:"-" toml:"PRICE_TOLERANCE" json:"price_tolerance"`
+	AmountTolerance        float64           `valid:"-" toml:"AMOUNT_TOLERANCE" json:"amount_tolerance"`
+	RateOffsetPercent      float64           `valid:"-" toml:"RATE_OFFSET_PERCENT" json:"rate_offset_percent"`
+	RateOffset             float64           `valid:"-" toml:"RATE_OFFSET" json:"rate_offset"`
+	RateOffsetPercentFirst bool              `valid:"-" toml:"RATE_OFFSET_PERCENT_FIRST" json:"rate_offset_percent_first"`
+	AmountOfABase          float64           `valid:"-" toml:"AMOUNT_OF_A_BASE" json:"amount_of_a_base"` // the size of order to keep on either side
+	AmountUnit             string            `valid:"-" toml:"AMOUNT_UNIT" json:"amount_unit"`
+	DataTypeA              string            `valid:"-" toml:"DATA_TYPE_A" json:"data_type_a"`
+	DataFeedAURL           string            `valid:"-" toml:"DATA_FEED_A_URL" json:"data_feed_a_url"`
+	DataTypeB              string            `valid:"-" toml:"DATA_TYPE_B" json:"data_type_b"`
+	DataFeedBURL           string            `valid:"-" toml:"DATA_FEED_B_URL" json:"data_feed_b_url"`
+	BidAssetCodeB          string            `valid:"-" toml:"BID_ASSET_CODE_B" json:"bid_asset_code_b"`
+	BidIssuerB             string            `valid:"-" toml:"BID_ISSUER_B" json:"bid_issuer_b"`
+	IcebergVisibleAmount   float64           `valid:"-" toml:"ICEBERG_VISIBLE_AMOUNT" json:"iceberg_visible_amount"`
+	TrackLevelStats        bool              `valid:"-" toml:"TRACK_LEVEL_STATS" json:"track_level_stats"`
+	Levels                 []StaticLevel     `valid:"-" toml:"LEVELS" json:"levels"`
+	BidLevels              []StaticLevel     `valid:"-" toml:"BID_LEVELS" json:"bid_levels"`
+	AskLevels              []StaticLevel     `valid:"-" toml:"ASK_LEVELS" json:"ask_levels"`
+	Feeds                  []LevelFeedConfig `valid:"-" toml:"FEEDS" json:"feeds"`
+
+	BootstrapSpreadMultiplier float64 `valid:"-" toml:"BOOTSTRAP_SPREAD_MULTIPLIER" json:"bootstrap_spread_multiplier"`
+	BootstrapTargetFills      int     `valid:"-" toml:"BOOTSTRAP_TARGET_FILLS" json:"bootstrap_target_fills"`
+	BootstrapTargetBaseVolume float64 `valid:"-" toml:"BOOTSTRAP_TARGET_BASE_VOLUME" json:"bootstrap_target_base_volume"`
 }
 
 // MakeBuysellConfig factory method
@@ -58,6 +80,63 @@ func (c BuySellConfig) String() string {
 	return utils.StructString(c, 0, nil)
 }
 
+// bidLevels returns the levels used for bids, which are the shared LEVELS unless BID_LEVELS is set
+func (c *BuySellConfig) bidLevels() []StaticLevel {
+	if len(c.BidLevels) > 0 {
+		return c.BidLevels
+	}
+	return c.Levels
+}
+
+// askLevels returns the levels used for asks, which are the shared LEVELS unless ASK_LEVELS is set
+func (c *BuySellConfig) askLevels() []StaticLevel {
+	if len(c.AskLevels) > 0 {
+		return c.AskLevels
+	}
+	return c.Levels
+}
+
+// makeLevelFeedPairs makes the price feeds of the levels on one side keyed by name, including the default feed of DATA_FEED_A_URL and
+// DATA_FEED_B_URL. The feeds are inverted for the buy side
+func makeLevelFeedPairs(config *BuySellConfig, isBuySide bool) (map[string]*api.FeedPair, error) {
+	feedConfigs := []LevelFeedConfig{{
+		Name:         defaultLevelFeed,
+		DataTypeA:    config.DataTypeA,
+		DataFeedAURL: config.DataFeedAURL,
+		DataTypeB:    config.DataTypeB,
+		DataFeedBURL: config.DataFeedBURL,
+	}}
+	for _, fc := range config.Feeds {
+		if fc.Name == defaultLevelFeed {
+			return nil, fmt.Errorf("the NAME of every entry in FEEDS needs to be set")
+		}
+		feedConfigs = append(feedConfigs, fc)
+	}
+
+	feeds := map[string]*api.FeedPair{}
+	for _, fc := range feedConfigs {
+		if _, exists := feeds[fc.Name]; exists {
+			return nil, fmt.Errorf("duplicate price feed named '%s' in FEEDS", fc.Name)
+		}
+
+		var pf *api.FeedPair
+		var e error
+		if isBuySide {
+			pf, e = MakeFeedPair(fc.DataTypeB, fc.DataFeedBURL, fc.DataTypeA, fc.DataFeedAURL)
+		} else {
+			pf, e = MakeFeedPair(fc.DataTypeA, fc.DataFeedAURL, fc.DataTypeB, fc.DataFeedBURL)
+		}
+		if e != nil {
+			if fc.Name == defaultLevelFeed {
+				return nil, e
+			}
+			return nil, fmt.Errorf("could not make the price feed '%s': %s", fc.Name, e)
+		}
+		feeds[fc.Name] = pf
+	}
+	return feeds, nil
+}
+
 // makeBuySellStrategy is a factory method
 func makeBuySellStrategy(
 	sdex *SDEX,
@@ -66,35 +145,74 @@ func makeBuySellStrategy(
 	assetBase *hProtocol.Asset,
 	assetQuote *hProtocol.Asset,
 	config *BuySellConfig,
+	levelStats *levelStatsRecorder,
+	bootstrap *bootstrapSpread,
+	db *sql.DB,
+	marketID string,
 ) (api.Strategy, error) {
+	levelAmountUnit, e := parseAmountUnit(config.AmountUnit)
+	if e != nil {
+		return nil, fmt.Errorf("cannot make the buysell strategy: %s", e)
+	}
+	if levelAmountUnit == amountUnitBaseBalancePercent {
+		// the buy side sells the quote asset so a percentage of the base balance has no meaning there
+		return nil, fmt.Errorf("cannot make the buysell strategy: AMOUNT_UNIT '%s' is only supported by the sell strategy", levelAmountUnit)
+	}
+
 	offsetSell := rateOffset{
 		percent:      config.RateOffsetPercent,
 		absolute:     config.RateOffset,
 		percentFirst: config.RateOffsetPercentFirst,
 	}
-	sellSideFeedPair, e := MakeFeedPair(
-		config.DataTypeA,
-		config.DataFeedAURL,
-		config.DataTypeB,
-		config.DataFeedBURL,
-	)
+	sellSideFeedPairs, e := makeLevelFeedPairs(config, false)
 	if e != nil {
 		return nil, fmt.Errorf("cannot make the buysell strategy because we could not make the sell side feed pair: %s", e)
 	}
+	e = validateLevelFeeds(config.askLevels(), sellSideFeedPairs)
+	if e != nil {
+		return nil, fmt.Errorf("cannot make the buysell strategy because of an invalid ask level: %s", e)
+	}
 	orderConstraints := sdex.GetOrderConstraints(pair)
+	bidAssetQuote, e := parseBidAssetQuote(sdex, assetBase, assetQuote, config)
+	if e != nil {
+		return nil, fmt.Errorf("cannot make the buysell strategy: %s", e)
+	}
+	if bootstrap != nil && levelStats != nil {
+		// the level stats derive the spread captured by a fill from the SPREAD of its level, which the bootstrap multiplier changes
+		return nil, fmt.Errorf("cannot make the buysell strategy: TRACK_LEVEL_STATS cannot be used with BOOTSTRAP_SPREAD_MULTIPLIER")
+	}
+	sellLevelsProvider, e := maybeWrapIcebergLevelProvider(
+		levelStats.wrap(
+			makeStaticSpreadLevelProvider(
+				config.askLevels(),
+				config.AmountOfABase,
+				levelAmountUnit,
+				offsetSell,
+				sellSideFeedPairs,
+				orderConstraints,
+				false,
+				bootstrap,
+			),
+			config.askLevels(),
+			false,
+		),
+		config.IcebergVisibleAmount,
+		levelAmountUnit,
+		false,
+		orderConstraints,
+		db,
+		marketID,
+	)
+	if e != nil {
+		return nil, fmt.Errorf("cannot make the buysell strategy: %s", e)
+	}
 	sellSideStrategy := makeSellSideStrategy(
 		sdex,
 		orderConstraints,
 		ieif,
 		assetBase,
 		assetQuote,
-		makeStaticSpreadLevelProvider(
-			config.Levels,
-			config.AmountOfABase,
-			offsetSell,
-			sellSideFeedPair,
-			orderConstraints,
-		),
+		sellLevelsProvider,
 		config.PriceTolerance,
 		config.AmountTolerance,
 		false,
@@ -106,34 +224,67 @@ func makeBuySellStrategy(
 		percentFirst: config.RateOffsetPercentFirst,
 		invert:       true,
 	}
-	buySideFeedPair, e := MakeFeedPair(
-		config.DataTypeB,
-		config.DataFeedBURL,
-		config.DataTypeA,
-		config.DataFeedAURL,
-	)
+	buySideFeedPairs, e := makeLevelFeedPairs(config, true)
 	if e != nil {
 		return nil, fmt.Errorf("cannot make the buysell strategy because we could not make the buy side feed pair: %s", e)
 	}
+	e = validateLevelFeeds(config.bidLevels(), buySideFeedPairs)
+	if e != nil {
+		return nil, fmt.Errorf("cannot make the buysell strategy because of an invalid bid level: %s", e)
+	}
 	// switch sides of base/quote here for buy side
+	buySideAssetQuote := assetQuote
+	if bidAssetQuote != nil {
+		buySideAssetQuote = bidAssetQuote
+	}
+	buyLevelsProvider, e := maybeWrapIcebergLevelProvider(
+		levelStats.wrap(
+			makeStaticSpreadLevelProvider(
+				config.bidLevels(),
+				config.AmountOfABase,
+				levelAmountUnit,
+				offsetBuy,
+				buySideFeedPairs,
+				orderConstraints,
+				true,
+				bootstrap,
+			),
+			config.bidLevels(),
+			true,
+		),
+		config.IcebergVisibleAmount,
+		levelAmountUnit,
+		true,
+		orderConstraints,
+		db,
+		marketID,
+	)
+	if e != nil {
+		return nil, fmt.Errorf("cannot make the buysell strategy: %s", e)
+	}
 	buySideStrategy := makeSellSideStrategy(
 		sdex,
 		orderConstraints,
 		ieif,
-		assetQuote,
+		buySideAssetQuote,
 		assetBase,
-		makeStaticSpreadLevelProvider(
-			config.Levels,
-			config.AmountOfABase,
-			offsetBuy,
-			buySideFeedPair,
-			orderConstraints,
-		),
+		buyLevelsProvider,
 		config.PriceTolerance,
 		config.AmountTolerance,
 		true,
 	)
 
+	if bidAssetQuote != nil {
+		return makeComposeStrategyWithBidAsset(
+			sdex,
+			ieif,
+			assetBase,
+			assetQuote,
+			bidAssetQuote,
+			buySideStrategy,
+			sellSideStrategy,
+		), nil
+	}
 	return makeComposeStrategy(
 		assetBase,
 		assetQuote,
@@ -141,3 +292,32 @@ func makeBuySellStrategy(
 		sellSideStrategy,
 	), nil
 }
+
+// parseBidAssetQuote returns the asset used to quote bids when it is different from the quote asset used for asks, nil otherwise.
+// It validates that the trading account has a trustline for this asset since the trader only validates the trustlines of its pair.
+func parseBidAssetQuote(sdex *SDEX, assetBase *hProtocol.Asset, assetQuote *hProtocol.Asset, config *BuySellConfig) (*hProtocol.Asset, error) {
+	if config.BidAssetCodeB == "" && config.BidIssuerB == "" {
+		return nil, nil
+	}
+	if config.BidAssetCodeB == "" {
+		return nil, fmt.Errorf("BID_ASSET_CODE_B needs to be set when BID_ISSUER_B is set")
+	}
+
+	bidAssetQuote, e := utils.ParseAsset(config.BidAssetCodeB, config.BidIssuerB)
+	if e != nil {
+		return nil, fmt.Errorf("invalid BID_ASSET_CODE_B and BID_ISSUER_B: %s", e)
+	}
+	if *bidAssetQuote == *assetBase {
+		return nil, fmt.Errorf("the bid quote asset (%s) cannot be the same as the base asset", utils.Asset2String(*bidAssetQuote))
+	}
+	if *bidAssetQuote == *assetQuote {
+		// nothing to do since both sides are already quoted in the same asset
+		return nil, nil
+	}
+
+	_, e = sdex.GetBalanceHack(*bidAssetQuote)
+	if e != nil {
+		return nil, fmt.Errorf("unable to find a trustline for the bid quote asset (%s) on the trading account: %s", utils.Asset2String(*bidAssetQuote), e)
+	}
+	return bidAssetQuote, nil
+}
@@ -0,0 +1,148 @@
+package plugins
+
+import (
+	"testing"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/kelp/support/utils"
+)
+
+func TestParseBidAssetQuote(t *testing.T) {
+	assetBase := &hProtocol.Asset{Type: utils.Native}
+	assetQuote := &hProtocol.Asset{Type: "credit_alphanum4", Code: "USD", Issuer: "GAXEMCEXBERNSRXOEKD4JAIKVECIXQCENHEBRVSPX2TTYZPMNEDSQCNQ"}
+	testCases := []struct {
+		name        string
+		code        string
+		issuer      string
+		wantErr     bool
+		wantNilBids bool
+	}{
+		{
+			name:        "not set",
+			wantNilBids: true,
+		}, {
+			name:    "issuer without code",
+			issuer:  "GBMMZMK2DC4FFP4CAI6KCVNCQ7WLO5A7DQU7EC7WGHRDQBZB763X4OQI",
+			wantErr: true,
+		}, {
+			name:    "code without issuer",
+			code:    "USD",
+			wantErr: true,
+		}, {
+			name:    "same as base asset",
+			code:    "XLM",
+			wantErr: true,
+		}, {
+			name:        "same as quote asset",
+			code:        "USD",
+			issuer:      "GAXEMCEXBERNSRXOEKD4JAIKVECIXQCENHEBRVSPX2TTYZPMNEDSQCNQ",
+			wantNilBids: true,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			// none of these cases need to check the trustline so we don't need an SDEX instance
+			bidAssetQuote, e := parseBidAssetQuote(nil, assetBase, assetQuote, &BuySellConfig{
+				BidAssetCodeB: k.code,
+				BidIssuerB:    k.issuer,
+			})
+			if k.wantErr {
+				assert.Error(t, e)
+				return
+			}
+			if assert.NoError(t, e) {
+				assert.Equal(t, k.wantNilBids, bidAssetQuote == nil)
+			}
+		})
+	}
+}
+
+func TestBuySellConfigSideLevels(t *testing.T) {
+	shared := []StaticLevel{{SPREAD: 0.01, AMOUNT: 100.0}}
+	bids := []StaticLevel{{SPREAD: 0.02, AMOUNT: 50.0}, {SPREAD: 0.03, AMOUNT: 50.0}}
+	asks := []StaticLevel{{SPREAD: 0.005, AMOUNT: 200.0}}
+	testCases := []struct {
+		name          string
+		config        BuySellConfig
+		wantBidLevels []StaticLevel
+		wantAskLevels []StaticLevel
+	}{
+		{
+			name:          "shared levels",
+			config:        BuySellConfig{Levels: shared},
+			wantBidLevels: shared,
+			wantAskLevels: shared,
+		}, {
+			name:          "bid levels override shared levels",
+			config:        BuySellConfig{Levels: shared, BidLevels: bids},
+			wantBidLevels: bids,
+			wantAskLevels: shared,
+		}, {
+			name:          "ask levels override shared levels",
+			config:        BuySellConfig{Levels: shared, AskLevels: asks},
+			wantBidLevels: shared,
+			wantAskLevels: asks,
+		}, {
+			name:          "both sides without shared levels",
+			config:        BuySellConfig{BidLevels: bids, AskLevels: asks},
+			wantBidLevels: bids,
+			wantAskLevels: asks,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			assert.Equal(t, k.wantBidLevels, k.config.bidLevels())
+			assert.Equal(t, k.wantAskLevels, k.config.askLevels())
+		})
+	}
+}
+
+func TestMakeLevelFeedPairs(t *testing.T) {
+	config := &BuySellConfig{
+		DataTypeA:    "fixed",
+		DataFeedAURL: "0.5",
+		DataTypeB:    "fixed",
+		DataFeedBURL: "1.0",
+		Feeds: []LevelFeedConfig{{
+			Name:         "fast",
+			DataTypeA:    "fixed",
+			DataFeedAURL: "0.25",
+			DataTypeB:    "fixed",
+			DataFeedBURL: "1.0",
+		}},
+	}
+
+	for _, k := range []struct {
+		isBuySide bool
+		want      map[string]float64
+	}{
+		{isBuySide: false, want: map[string]float64{defaultLevelFeed: 0.5, "fast": 0.25}},
+		// the buy side feeds are inverted
+		{isBuySide: true, want: map[string]float64{defaultLevelFeed: 2.0, "fast": 4.0}},
+	} {
+		feeds, e := makeLevelFeedPairs(config, k.isBuySide)
+		if !assert.NoError(t, e) || !assert.Equal(t, len(k.want), len(feeds)) {
+			return
+		}
+		for name, wantPrice := range k.want {
+			price, e := feeds[name].GetFeedPairPrice()
+			if assert.NoError(t, e) {
+				assert.InDelta(t, wantPrice, price, 0.0000001)
+			}
+		}
+	}
+
+	duplicate := *config
+	duplicate.Feeds = append(duplicate.Feeds, duplicate.Feeds[0])
+	_, e := makeLevelFeedPairs(&duplicate, false)
+	assert.Error(t, e)
+
+	unnamed := *config
+	unnamed.Feeds = []LevelFeedConfig{{DataTypeA: "fixed", DataFeedAURL: "1.0", DataTypeB: "fixed", DataFeedBURL: "1.0"}}
+	_, e = makeLevelFeedPairs(&unnamed, false)
+	assert.Error(t, e)
+}
@@ -0,0 +1,118 @@
+package plugins
+
+import (
+	"sync"
+	"time"
+
+	"github.com/stellar/kelp/api"
+)
+
+var priceFeedCacheLock = &sync.Mutex{}
+
+// priceFeedCacheTTL is how long the price of a feed is reused by every feed made with the same type and url, 0 disables the cache
+var priceFeedCacheTTL time.Duration
+
+// cachedFeeds are the feeds made since the cache was enabled keyed by <type>/<url>, so they are shared by all strategies and filters
+var cachedFeeds = map[string]*cachedFeed{}
+
+// SetPriceFeedCacheTTL shares the price feeds made by MakePriceFeed with the same type and url and reuses their price for the ttl, so a
+// remote API is called at most once per ttl no matter how many strategies and filters use the feed. A ttl of 0 disables the cache.
+func SetPriceFeedCacheTTL(ttl time.Duration) {
+	priceFeedCacheLock.Lock()
+	defer priceFeedCacheLock.Unlock()
+
+	priceFeedCacheTTL = ttl
+	cachedFeeds = map[string]*cachedFeed{}
+}
+
+// makeCachedPriceFeed returns the cached feed of the type and url when the cache is enabled, making it the first time it is asked for
+func makeCachedPriceFeed(feedType string, url string) (api.PriceFeed, error) {
+	name := feedType + "/" + url
+	priceFeedCacheLock.Lock()
+	ttl := priceFeedCacheTTL
+	feed, ok := cachedFeeds[name]
+	priceFeedCacheLock.Unlock()
+
+	if ttl == 0 {
+		return makePriceFeed(feedType, url)
+	}
+	if ok {
+		return feed, nil
+	}
+
+	// the lock is not held while making the feed because function feeds make their nested feeds through MakePriceFeed
+	pf, e := makePriceFeed(feedType, url)
+	if e != nil {
+		return nil, e
+	}
+
+	priceFeedCacheLock.Lock()
+	defer priceFeedCacheLock.Unlock()
+	if feed, ok := cachedFeeds[name]; ok {
+		return feed, nil
+	}
+	feed = makeCachedFeed(pf, ttl, MakeSystemClock())
+	cachedFeeds[name] = feed
+	return feed, nil
+}
+
+// cachedFeed returns the last price of the underlying feed until it is older than the ttl, errors are not cached so the next call fetches
+// the price again
+type cachedFeed struct {
+	feed  api.PriceFeed
+	ttl   time.Duration
+	clock api.Clock
+	mutex *sync.Mutex
+
+	// uninitialized
+	price     float64
+	ts        time.Time
+	fetchedAt *time.Time
+}
+
+// ensure that it implements TimestampedPriceFeed so the cached price keeps the timestamp of the underlying feed
+var _ api.TimestampedPriceFeed = &cachedFeed{}
+
+func makeCachedFeed(feed api.PriceFeed, ttl time.Duration, clock api.Clock) *cachedFeed {
+	return &cachedFeed{
+		feed:  feed,
+		ttl:   ttl,
+		clock: clock,
+		mutex: &sync.Mutex{},
+	}
+}
+
+// GetPrice impl
+func (f *cachedFeed) GetPrice() (float64, error) {
+	price, _, e := f.GetPriceWithTimestamp()
+	return price, e
+}
+
+// GetPriceWithTimestamp impl, the lock is held while fetching so concurrent callers wait for a single fetch instead of making their own
+func (f *cachedFeed) GetPriceWithTimestamp() (float64, time.Time, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	now := f.clock.Now()
+	if f.fetchedAt != nil && now.Sub(*f.fetchedAt) < f.ttl {
+		return f.price, f.ts, nil
+	}
+
+	var price float64
+	var ts time.Time
+	var e error
+	if tsFeed, ok := f.feed.(api.TimestampedPriceFeed); ok {
+		price, ts, e = tsFeed.GetPriceWithTimestamp()
+	} else {
+		price, e = f.feed.GetPrice()
+		ts = now
+	}
+	if e != nil {
+		return 0, time.Time{}, e
+	}
+
+	f.price = price
+	f.ts = ts
+	f.fetchedAt = &now
+	return price, ts, nil
+}
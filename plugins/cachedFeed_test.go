@@ -0,0 +1,107 @@
+package plugins
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type countingTestFeed struct {
+	prices []float64
+	calls  int
+}
+
+// GetPrice impl, fails when it runs out of prices
+func (f *countingTestFeed) GetPrice() (float64, error) {
+	f.calls++
+	if f.calls > len(f.prices) {
+		return 0, fmt.Errorf("no price")
+	}
+	return f.prices[f.calls-1], nil
+}
+
+func TestCachedFeed(t *testing.T) {
+	start := time.Unix(10000, 0)
+	clock := MakeManualClock(start)
+	underlying := &countingTestFeed{prices: []float64{1.0, 2.0}}
+	feed := makeCachedFeed(underlying, 5*time.Second, clock)
+
+	price, ts, e := feed.GetPriceWithTimestamp()
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, 1.0, price)
+	assert.Equal(t, start, ts)
+
+	// within the ttl the cached price and its timestamp are returned without calling the feed
+	clock.Advance(4 * time.Second)
+	price, ts, e = feed.GetPriceWithTimestamp()
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, 1.0, price)
+	assert.Equal(t, start, ts)
+	assert.Equal(t, 1, underlying.calls)
+
+	clock.Advance(time.Second)
+	price, e = feed.GetPrice()
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, 2.0, price)
+	assert.Equal(t, 2, underlying.calls)
+
+	// errors are not cached
+	clock.Advance(5 * time.Second)
+	_, e = feed.GetPrice()
+	assert.Error(t, e)
+	_, e = feed.GetPrice()
+	assert.Error(t, e)
+	assert.Equal(t, 4, underlying.calls)
+}
+
+func TestCachedFeed_KeepsTimestamp(t *testing.T) {
+	ts := time.Unix(5000, 0)
+	feed := makeCachedFeed(&timestampedTestFeed{price: 3.0, ts: ts}, time.Minute, MakeManualClock(time.Unix(10000, 0)))
+
+	price, gotTs, e := feed.GetPriceWithTimestamp()
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, 3.0, price)
+	assert.Equal(t, ts, gotTs)
+}
+
+func TestMakeCachedPriceFeed(t *testing.T) {
+	defer SetPriceFeedCacheTTL(0)
+
+	SetPriceFeedCacheTTL(0)
+	feedA, e := makeCachedPriceFeed("fixed", "1.0")
+	if !assert.NoError(t, e) {
+		return
+	}
+	_, ok := feedA.(*cachedFeed)
+	assert.False(t, ok)
+
+	SetPriceFeedCacheTTL(time.Minute)
+	feedA, e = makeCachedPriceFeed("fixed", "1.0")
+	if !assert.NoError(t, e) {
+		return
+	}
+	feedB, e := makeCachedPriceFeed("fixed", "1.0")
+	if !assert.NoError(t, e) {
+		return
+	}
+	feedC, e := makeCachedPriceFeed("fixed", "2.0")
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.True(t, feedA == feedB)
+	assert.False(t, feedA == feedC)
+
+	// function feeds make their nested feeds through the cache without deadlocking
+	_, e = makeCachedPriceFeed("function", "max(fixed/1.0,fixed/3.0)")
+	assert.NoError(t, e)
+}
@@ -3,8 +3,10 @@ package plugins
 import (
 	"fmt"
 	"log"
+	"math"
 	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/stellar/kelp/api"
@@ -18,6 +20,18 @@ const ccxtBalancePrecision = 10
 // ensure that ccxtExchange conforms to the Exchange interface
 var _ api.Exchange = ccxtExchange{}
 
+// ensure that ccxtExchange conforms to the TradingFeeFetcher interface
+var _ api.TradingFeeFetcher = ccxtExchange{}
+
+// ensure that ccxtExchange conforms to the APIKeyPermissionsFetcher interface
+var _ api.APIKeyPermissionsFetcher = ccxtExchange{}
+
+// ccxtAPIKeyPermissionsExchanges are the ccxt exchanges that can report the permissions of their API keys
+var ccxtAPIKeyPermissionsExchanges = map[string]bool{
+	"binance":   true,
+	"binanceus": true,
+}
+
 // ccxtExchangeSpecificParamFactory knows how to create the exchange-specific params for each exchange
 type ccxtExchangeSpecificParamFactory interface {
 	getInitParams() map[string]interface{}
@@ -30,6 +44,8 @@ type ccxtExchangeSpecificParamFactory interface {
 
 // ccxtExchange is the implementation for the CCXT REST library that supports many exchanges (https://github.com/franz-see/ccxt-rest, https://github.com/ccxt/ccxt/)
 type ccxtExchange struct {
+	exchangeName       string
+	maskedAPIKey       string
 	assetConverter     model.AssetConverterInterface
 	delimiter          string
 	ocOverridesHandler *OrderConstraintsOverridesHandler
@@ -77,13 +93,20 @@ func makeCcxtExchange(
 		return nil, fmt.Errorf("error making a ccxt exchange: %s", e)
 	}
 
+	assetConverter := model.CcxtAssetConverter
+	if ac, ok := ccxtAssetConverterMap["ccxt-"+exchangeName]; ok {
+		assetConverter = ac
+	}
+
 	ocOverridesHandler := MakeEmptyOrderConstraintsOverridesHandler()
 	if orderConstraintOverrides != nil {
 		ocOverridesHandler = MakeOrderConstraintsOverridesHandler(orderConstraintOverrides)
 	}
 
 	return ccxtExchange{
-		assetConverter:     model.CcxtAssetConverter,
+		exchangeName:       exchangeName,
+		maskedAPIKey:       maskAPIKey(apiKeys[0].Key),
+		assetConverter:     assetConverter,
 		delimiter:          "/",
 		ocOverridesHandler: ocOverridesHandler,
 		api:                c,
@@ -120,11 +143,16 @@ func (c ccxtExchange) GetTickerPrice(pairs []model.TradingPair) (map[model.Tradi
 		}
 
 		pricePrecision := c.GetOrderConstraints(&p).PricePrecision
-		priceResult[p] = api.Ticker{
+		ticker := api.Ticker{
 			AskPrice:  model.NumberFromFloat(askPrice, pricePrecision),
 			BidPrice:  model.NumberFromFloat(bidPrice, pricePrecision),
 			LastPrice: model.NumberFromFloat(lastPrice, pricePrecision),
 		}
+		// the timestamp is in millis and is null for some exchanges
+		if ts, ok := tickerMap["timestamp"].(float64); ok {
+			ticker.Timestamp = model.MakeTimestamp(int64(ts))
+		}
+		priceResult[p] = ticker
 	}
 
 	return priceResult, nil
@@ -143,6 +171,11 @@ func (c ccxtExchange) GetOrderConstraints(pair *model.TradingPair) *model.OrderC
 		panic(e)
 	}
 
+	// tick sizes that are not powers of 10 cannot be converted to decimal places when loading markets, so complete overrides take priority
+	if c.ocOverridesHandler.IsCompletelyOverriden(pair) {
+		return model.MakeOrderConstraintsFromOverride(c.ocOverridesHandler.Get(pair))
+	}
+
 	// load from CCXT's cache
 	ccxtMarket := c.api.GetMarket(pairString)
 	if ccxtMarket == nil {
@@ -157,6 +190,46 @@ func (c ccxtExchange) GetOrderConstraints(pair *model.TradingPair) *model.OrderC
 	return c.ocOverridesHandler.Apply(pair, oc)
 }
 
+// GetTakerFee impl, prefers the fee rate of the account and falls back to the default fee rate of the market
+func (c ccxtExchange) GetTakerFee(pair *model.TradingPair) (float64, error) {
+	pairString, e := pair.ToString(c.assetConverter, c.delimiter)
+	if e != nil {
+		return 0.0, fmt.Errorf("error converting pair to string: %s", e)
+	}
+
+	tradingFee, e := c.api.FetchTradingFee(pairString)
+	if e == nil {
+		return tradingFee.Taker, nil
+	}
+	log.Printf("unable to fetch trading fee for account, using the default taker fee of the market '%s' instead: %s\n", pairString, e)
+
+	ccxtMarket := c.api.GetMarket(pairString)
+	if ccxtMarket == nil {
+		return 0.0, fmt.Errorf("CCXT does not have fee data for the passed in market: %s", pairString)
+	}
+	return ccxtMarket.Taker, nil
+}
+
+// GetAPIKeyPermissions impl, only the binance exchanges report the permissions of their API keys
+func (c ccxtExchange) GetAPIKeyPermissions() ([]api.APIKeyPermissions, error) {
+	if !ccxtAPIKeyPermissionsExchanges[c.exchangeName] {
+		return nil, fmt.Errorf("the ccxt-%s exchange cannot report the permissions of its API keys", c.exchangeName)
+	}
+
+	restrictions, e := c.api.FetchBinanceAPIRestrictions()
+	if e != nil {
+		return nil, e
+	}
+	ipRestricted := restrictions.IPRestrict
+	return []api.APIKeyPermissions{{
+		Key:          c.maskedAPIKey,
+		CanRead:      restrictions.EnableReading,
+		CanTrade:     restrictions.EnableSpotAndMarginTrading,
+		CanWithdraw:  restrictions.EnableWithdrawals,
+		IPRestricted: &ipRestricted,
+	}}, nil
+}
+
 // OverrideOrderConstraints impl, can partially override values for specific pairs
 func (c ccxtExchange) OverrideOrderConstraints(pair *model.TradingPair, override *model.OrderConstraintsOverride) {
 	c.ocOverridesHandler.Upsert(pair, override)
@@ -263,6 +336,27 @@ func (c ccxtExchange) readOrders(orders []sdk.CcxtOrder, pair *model.TradingPair
 	return result
 }
 
+// ccxtTradeHistoryPageLimit is the number of trades fetched with each call to fetchMyTrades
+const ccxtTradeHistoryPageLimit = 50
+
+// ccxtTradeHistoryMaxPages bounds the number of pages fetched in a single GetTradeHistory call, the remaining trades are fetched on the next
+// call starting from the returned cursor
+const ccxtTradeHistoryMaxPages = 20
+
+// ccxtTradeHistoryCursor is the cursor of the trade history of exchanges that page by the timestamp of the trades. Since several trades can
+// share the millisecond of the last trade of a page (e.g. when one taker order fills many makers) and some of them may not fit in the page,
+// the cursor keeps that millisecond so the next page starts at it, along with the IDs of the trades in that millisecond that were already
+// returned so they are not returned again. It is formatted as the timestamp so it can be passed to ccxt as the since value.
+type ccxtTradeHistoryCursor struct {
+	timestampMillis int64
+	tradeIDs        []string
+}
+
+// String is the Stringer method
+func (c ccxtTradeHistoryCursor) String() string {
+	return strconv.FormatInt(c.timestampMillis, 10)
+}
+
 // GetTradeHistory impl
 func (c ccxtExchange) GetTradeHistory(pair model.TradingPair, maybeCursorStart interface{}, maybeCursorEnd interface{}) (*api.TradeHistoryResult, error) {
 	pairString, e := pair.ToString(c.assetConverter, c.delimiter)
@@ -270,9 +364,87 @@ func (c ccxtExchange) GetTradeHistory(pair model.TradingPair, maybeCursorStart i
 		return nil, fmt.Errorf("error converting pair to string: %s", e)
 	}
 
-	// TODO fix limit logic to check result so we get full history instead of just 50 trades
-	const limit = 50
-	tradesRaw, e := c.api.FetchMyTrades(pairString, limit, maybeCursorStart)
+	// the trades at the cursor that were returned by the previous call are fetched again so we skip them
+	seenTradeIDs := map[string]bool{}
+	if startCursor, ok := maybeCursorStart.(ccxtTradeHistoryCursor); ok {
+		for _, id := range startCursor.tradeIDs {
+			seenTradeIDs[id] = true
+		}
+	}
+
+	trades := []model.Trade{}
+	cursor := maybeCursorStart
+	for page := 0; page < ccxtTradeHistoryMaxPages; page++ {
+		pageTrades, e := c.fetchTradeHistoryPage(pair, pairString, cursor)
+		if e != nil {
+			return nil, e
+		}
+
+		newTrades := 0
+		for _, t := range pageTrades {
+			// pages overlap at the millisecond of the cursor so we skip trades that we have already read
+			if seenTradeIDs[t.TransactionID.String()] {
+				continue
+			}
+			seenTradeIDs[t.TransactionID.String()] = true
+			trades = append(trades, t)
+			newTrades++
+		}
+
+		// a full page without new trades means that more trades than fit in a page share the millisecond of the cursor, which cannot be
+		// paged through by timestamp, so we stop instead of fetching the same page again
+		if newTrades == 0 {
+			break
+		}
+		cursor, e = c.getTradeHistoryCursor(trades, cursor)
+		if e != nil {
+			return nil, fmt.Errorf("error getting cursor when fetching trade history: %s", e)
+		}
+		if len(pageTrades) < ccxtTradeHistoryPageLimit {
+			break
+		}
+	}
+
+	sort.Sort(model.TradesByTsID(trades))
+	return &api.TradeHistoryResult{
+		Cursor: cursor,
+		Trades: trades,
+	}, nil
+}
+
+// getTradeHistoryCursor returns the cursor that follows the trades, which are sorted by timestamp. The exchange-specific cursor is used when
+// there is one, otherwise the cursor is the millisecond of the last trade along with the IDs of all trades in that millisecond, including
+// the ones returned with the previous cursor when it is at the same millisecond.
+func (c ccxtExchange) getTradeHistoryCursor(trades []model.Trade, prevCursor interface{}) (interface{}, error) {
+	lastTrade := trades[len(trades)-1]
+	if c.esParamFactory != nil {
+		fetchedCursor, e := c.esParamFactory.getCursorFetchTrades(lastTrade)
+		if e != nil {
+			return nil, fmt.Errorf("tried to convert string cursor to int64 in exchange-specific getCursor method but returned an error: %s", e)
+		}
+		if fetchedCursor != nil {
+			return fetchedCursor, nil
+		}
+	}
+
+	cursor := ccxtTradeHistoryCursor{
+		timestampMillis: lastTrade.Order.Timestamp.AsInt64(),
+		tradeIDs:        []string{},
+	}
+	if prev, ok := prevCursor.(ccxtTradeHistoryCursor); ok && prev.timestampMillis == cursor.timestampMillis {
+		cursor.tradeIDs = append(cursor.tradeIDs, prev.tradeIDs...)
+	}
+	for _, t := range trades {
+		if t.Order.Timestamp.AsInt64() == cursor.timestampMillis {
+			cursor.tradeIDs = append(cursor.tradeIDs, t.TransactionID.String())
+		}
+	}
+	return cursor, nil
+}
+
+// fetchTradeHistoryPage fetches one page of the trade history starting at the cursor, sorted by timestamp
+func (c ccxtExchange) fetchTradeHistoryPage(pair model.TradingPair, pairString string, maybeCursor interface{}) ([]model.Trade, error) {
+	tradesRaw, e := c.api.FetchMyTrades(pairString, ccxtTradeHistoryPageLimit, maybeCursor)
 	if e != nil {
 		return nil, fmt.Errorf("error while fetching trade history for trading pair '%s': %s", pairString, e)
 	}
@@ -307,18 +479,7 @@ func (c ccxtExchange) GetTradeHistory(pair model.TradingPair, maybeCursorStart i
 	}
 
 	sort.Sort(model.TradesByTsID(trades))
-	cursor := maybeCursorStart
-	if len(trades) > 0 {
-		cursor, e = c.getCursor(trades)
-		if e != nil {
-			return nil, fmt.Errorf("error getting cursor when fetching trades: %s", e)
-		}
-	}
-
-	return &api.TradeHistoryResult{
-		Cursor: cursor,
-		Trades: trades,
-	}, nil
+	return trades, nil
 }
 
 // GetLatestTradeCursor impl.
@@ -335,8 +496,7 @@ func (c ccxtExchange) GetTrades(pair *model.TradingPair, maybeCursor interface{}
 		return nil, fmt.Errorf("error converting pair to string: %s", e)
 	}
 
-	// TODO use cursor when fetching trades
-	tradesRaw, e := c.api.FetchTrades(pairString)
+	tradesRaw, e := c.api.FetchTrades(pairString, maybeCursor)
 	if e != nil {
 		return nil, fmt.Errorf("error while fetching trades for trading pair '%s': %s", pairString, e)
 	}
@@ -348,6 +508,15 @@ func (c ccxtExchange) GetTrades(pair *model.TradingPair, maybeCursor interface{}
 		if e != nil {
 			return nil, fmt.Errorf("error while reading trade: %s", e)
 		}
+
+		// some exchanges ignore the since value and return their latest trades, so we skip trades that were returned before
+		isNew, e := c.isTradeAfterCursor(*t, maybeCursor)
+		if e != nil {
+			return nil, fmt.Errorf("error comparing trade to cursor: %s", e)
+		}
+		if !isNew {
+			continue
+		}
 		trades = append(trades, *t)
 	}
 
@@ -390,6 +559,28 @@ func (c ccxtExchange) getCursor(trades []model.Trade) (interface{}, error) {
 	return fetchedCursor, nil
 }
 
+// isTradeAfterCursor returns true when the trade was not returned in the call that returned the cursor, i.e. when the cursor that follows
+// the trade is after the passed in cursor. Cursors that are not numbers cannot be compared so trades are always considered new for them.
+func (c ccxtExchange) isTradeAfterCursor(trade model.Trade, maybeCursor interface{}) (bool, error) {
+	if maybeCursor == nil {
+		return true, nil
+	}
+	cursor, e := strconv.ParseInt(fmt.Sprintf("%v", maybeCursor), 10, 64)
+	if e != nil {
+		return true, nil
+	}
+
+	tradeCursor, e := c.getCursor([]model.Trade{trade})
+	if e != nil {
+		return false, fmt.Errorf("error getting cursor of trade: %s", e)
+	}
+	tradeCursorInt, e := strconv.ParseInt(fmt.Sprintf("%v", tradeCursor), 10, 64)
+	if e != nil {
+		return true, nil
+	}
+	return tradeCursorInt > cursor, nil
+}
+
 func (c ccxtExchange) readTrade(pair *model.TradingPair, pairString string, rawTrade sdk.CcxtTrade) (*model.Trade, error) {
 	if rawTrade.Symbol != pairString {
 		return nil, fmt.Errorf("expected '%s' for 'symbol' field, got: %s", pairString, rawTrade.Symbol)
@@ -413,7 +604,6 @@ func (c ccxtExchange) readTrade(pair *model.TradingPair, pairString string, rawT
 		},
 		TransactionID: model.MakeTransactionID(rawTrade.ID),
 		Cost:          model.NumberFromFloat(rawTrade.Cost, feecCostPrecision),
-		Fee:           model.NumberFromFloat(rawTrade.Fee.Cost, feecCostPrecision),
 		// OrderID read by calling function depending on override set for exchange params in "orderId" field of Info object
 	}
 
@@ -449,9 +639,52 @@ func (c ccxtExchange) readTrade(pair *model.TradingPair, pairString string, rawT
 		trade.Cost = model.NumberFromFloat(rawTrade.Price*rawTrade.Amount, feecCostPrecision)
 	}
 
+	feeQuote, e := c.readTradeFeeQuote(pair, rawTrade)
+	if e != nil {
+		return nil, fmt.Errorf("error while reading fee of trade: %s", e)
+	}
+	trade.Fee = model.NumberFromFloat(feeQuote, feecCostPrecision)
+	if rawTrade.Fee.Cost != 0.0 && rawTrade.Fee.Currency != "" {
+		feeAsset, e := c.assetConverter.FromString(rawTrade.Fee.Currency)
+		if e != nil {
+			// assets outside the trading pair may be unknown to the converter, they only need to be different from the assets of the pair
+			feeAsset = model.Asset(rawTrade.Fee.Currency)
+		}
+		trade.FeeAsset = &feeAsset
+		trade.FeeAssetCost = model.NumberFromFloat(rawTrade.Fee.Cost, feecCostPrecision)
+	}
+
 	return &trade, nil
 }
 
+// readTradeFeeQuote converts the fee of the trade to units of the quote asset, which is how fees are accounted for in model.Trade.
+// Fees paid in an asset outside the trading pair (such as an exchange token) are not paid from the balances of the pair and are reported as 0.
+func (c ccxtExchange) readTradeFeeQuote(pair *model.TradingPair, rawTrade sdk.CcxtTrade) (float64, error) {
+	if rawTrade.Fee.Cost == 0.0 || rawTrade.Fee.Currency == "" {
+		return rawTrade.Fee.Cost, nil
+	}
+
+	baseString, e := c.assetConverter.ToString(pair.Base)
+	if e != nil {
+		return 0.0, fmt.Errorf("error converting base asset to string: %s", e)
+	}
+	quoteString, e := c.assetConverter.ToString(pair.Quote)
+	if e != nil {
+		return 0.0, fmt.Errorf("error converting quote asset to string: %s", e)
+	}
+
+	switch rawTrade.Fee.Currency {
+	case quoteString:
+		return rawTrade.Fee.Cost, nil
+	case baseString:
+		return rawTrade.Fee.Cost * rawTrade.Price, nil
+	default:
+		log.Printf("fee of trade '%s' was paid in %s which is not an asset of the trading pair, fee of %f %s is not included in the trade\n",
+			rawTrade.ID, rawTrade.Fee.Currency, rawTrade.Fee.Cost, rawTrade.Fee.Currency)
+		return 0.0, nil
+	}
+}
+
 // GetOpenOrders impl
 func (c ccxtExchange) GetOpenOrders(pairs []*model.TradingPair) (map[model.TradingPair][]model.OpenOrder, error) {
 	pairStrings := []string{}
@@ -530,14 +763,18 @@ func (c ccxtExchange) AddOrder(order *model.Order, submitMode api.SubmitMode) (*
 		side = "buy"
 	}
 
+	// orders can be made with the precision of another market (such as when mirroring) so we convert them to the precision of this market,
+	// rounding the price away from the spread and truncating the volume so we never place more or at a worse price than what was asked for
+	price, volume := c.toMarketPrecision(order.Pair, order.OrderAction, order.Price, order.Volume)
+
 	log.Printf("ccxt is submitting order: pair=%s, orderAction=%s, orderType=%s, volume=%s, price=%s, submitMode=%s\n",
-		pairString, order.OrderAction.String(), order.OrderType.String(), order.Volume.AsString(), order.Price.AsString(), submitMode.String())
+		pairString, order.OrderAction.String(), order.OrderType.String(), volume.AsString(), price.AsString(), submitMode.String())
 
 	var maybeExchangeSpecificParams interface{}
 	if c.esParamFactory != nil {
 		maybeExchangeSpecificParams = c.esParamFactory.getParamsForAddOrder(submitMode)
 	}
-	ccxtOpenOrder, e := c.api.CreateLimitOrder(pairString, side, order.Volume.AsFloat(), order.Price.AsFloat(), maybeExchangeSpecificParams)
+	ccxtOpenOrder, e := c.api.CreateLimitOrder(pairString, side, volume.AsFloat(), price.AsFloat(), maybeExchangeSpecificParams)
 	if e != nil {
 		return nil, fmt.Errorf("error while creating limit order %s: %s", *order, e)
 	}
@@ -545,39 +782,167 @@ func (c ccxtExchange) AddOrder(order *model.Order, submitMode api.SubmitMode) (*
 	return model.MakeTransactionID(ccxtOpenOrder.ID), nil
 }
 
+// toMarketPrecision converts the price and volume of an order to the precision of the market. The price of buys is rounded down and the price
+// of sells is rounded up so the order does not cross further into the book than the price that was asked for, and the volume is truncated.
+func (c ccxtExchange) toMarketPrecision(pair *model.TradingPair, action model.OrderAction, price *model.Number, volume *model.Number) (*model.Number, *model.Number) {
+	oc := c.GetOrderConstraints(pair)
+	scale := math.Pow(10, float64(oc.PricePrecision))
+	scaledPrice := price.AsFloat() * scale
+	// the tolerance keeps prices that are already at the precision of the market (where the float can be slightly off) from moving by a tick
+	tolerance := math.Max(math.Abs(scaledPrice), 1) * 1e-12
+	if action.IsBuy() {
+		scaledPrice = math.Floor(scaledPrice + tolerance)
+	} else {
+		scaledPrice = math.Ceil(scaledPrice - tolerance)
+	}
+	return model.NumberFromFloat(scaledPrice/scale, oc.PricePrecision), model.NumberFromFloatRoundTruncate(volume.AsFloat(), oc.VolumePrecision)
+}
+
+// ccxtOrderNotFoundMessages are the parts of the errors from ccxt-rest that mean the order to cancel does not exist on the exchange
+var ccxtOrderNotFoundMessages = []string{
+	"ordernotfound",
+	"order not found",
+	"unknown order",
+	"order does not exist",
+}
+
+// isCcxtOrderNotFoundError returns true when the error from ccxt-rest means that the order does not exist on the exchange
+func isCcxtOrderNotFoundError(e error) bool {
+	msg := strings.ToLower(e.Error())
+	for _, m := range ccxtOrderNotFoundMessages {
+		if strings.Contains(msg, m) {
+			return true
+		}
+	}
+	return false
+}
+
 // CancelOrder impl
 func (c ccxtExchange) CancelOrder(txID *model.TransactionID, pair model.TradingPair) (model.CancelOrderResult, error) {
-	log.Printf("ccxt is canceling order: ID=%s, tradingPair: %s\n", txID.String(), pair.String())
+	// some exchanges require the symbol to cancel an order so we always pass it in
+	pairString, e := pair.ToString(c.assetConverter, c.delimiter)
+	if e != nil {
+		return model.CancelResultFailed, fmt.Errorf("error converting pair to string: %s", e)
+	}
+	log.Printf("ccxt is canceling order: ID=%s, tradingPair: %s\n", txID.String(), pairString)
 
-	resp, e := c.api.CancelOrder(txID.String(), pair.String())
+	resp, e := c.api.CancelOrder(txID.String(), pairString)
 	if e != nil {
-		return model.CancelResultFailed, e
+		if isCcxtOrderNotFoundError(e) {
+			// the order was already filled or canceled, which is not an error for the strategies
+			log.Printf("ccxt could not find order to cancel (ID=%s, tradingPair: %s): %s\n", txID.String(), pairString, e)
+			return model.CancelResultFailed, nil
+		}
+		return model.CancelResultFailed, fmt.Errorf("error while canceling order (ID=%s, tradingPair: %s): %s", txID.String(), pairString, e)
 	}
 
 	if resp == nil {
 		return model.CancelResultFailed, fmt.Errorf("response from CancelOrder was nil")
 	}
-	return model.CancelResultCancelSuccessful, nil
+	return ccxtCancelResult(resp.Status), nil
+}
+
+// ccxtCancelResult converts the status of the order returned by a cancel call, many exchanges do not return the status of the order in
+// which case the cancel call succeeding means that the order was canceled
+func ccxtCancelResult(status string) model.CancelOrderResult {
+	switch status {
+	case "open":
+		return model.CancelResultPending
+	case "closed":
+		// the order was filled before it could be canceled
+		return model.CancelResultFailed
+	default:
+		return model.CancelResultCancelSuccessful
+	}
 }
 
 // PrepareDeposit impl
 func (c ccxtExchange) PrepareDeposit(asset model.Asset, amount *model.Number) (*api.PrepareDepositResult, error) {
-	// TODO implement
-	return nil, nil
+	ccxtAsset, e := c.assetConverter.ToString(asset)
+	if e != nil {
+		return nil, fmt.Errorf("error converting asset to string: %s", e)
+	}
+
+	depositAddress, e := c.api.FetchDepositAddress(ccxtAsset)
+	if e != nil {
+		return nil, fmt.Errorf("error while fetching deposit address for asset '%s': %s", ccxtAsset, e)
+	}
+	if depositAddress.Address == "" {
+		return nil, fmt.Errorf("exchange did not return a deposit address for asset '%s'", ccxtAsset)
+	}
+
+	return &api.PrepareDepositResult{
+		// ccxt does not report deposit fees, which are charged by very few exchanges
+		Fee:      model.NumberConstants.Zero,
+		Address:  depositAddress.Address,
+		Memo:     depositAddress.Tag,
+		ExpireTs: 0,
+	}, nil
 }
 
 // GetWithdrawInfo impl
 func (c ccxtExchange) GetWithdrawInfo(asset model.Asset, amountToWithdraw *model.Number, address string) (*api.WithdrawInfo, error) {
-	// TODO implement
-	return nil, nil
+	ccxtAsset, e := c.assetConverter.ToString(asset)
+	if e != nil {
+		return nil, fmt.Errorf("error converting asset to string: %s", e)
+	}
+
+	currencies, e := c.api.FetchCurrencies()
+	if e != nil {
+		return nil, fmt.Errorf("error while fetching withdrawal details of asset '%s': %s", ccxtAsset, e)
+	}
+	currency, ok := currencies[ccxtAsset]
+	if !ok {
+		return nil, fmt.Errorf("exchange did not return withdrawal details for asset '%s'", ccxtAsset)
+	}
+
+	return makeCcxtWithdrawInfo(currency, amountToWithdraw)
+}
+
+// makeCcxtWithdrawInfo checks the amount to withdraw against the withdrawal limits of the currency and deducts the withdrawal fee
+func makeCcxtWithdrawInfo(currency sdk.CcxtCurrency, amountToWithdraw *model.Number) (*api.WithdrawInfo, error) {
+	if currency.Limits.Withdraw.Max > 0 && amountToWithdraw.AsFloat() > currency.Limits.Withdraw.Max {
+		return nil, api.MakeErrWithdrawAmountAboveLimit(amountToWithdraw, model.NumberFromFloat(currency.Limits.Withdraw.Max, ccxtBalancePrecision))
+	}
+
+	fee := model.NumberFromFloat(currency.Fee, ccxtBalancePrecision)
+	if fee.AsFloat() >= amountToWithdraw.AsFloat() || amountToWithdraw.AsFloat() < currency.Limits.Withdraw.Min {
+		return nil, api.MakeErrWithdrawAmountInvalid(amountToWithdraw, fee)
+	}
+
+	return &api.WithdrawInfo{AmountToReceive: amountToWithdraw.Subtract(*fee)}, nil
 }
 
-// WithdrawFunds impl
+// WithdrawFunds impl. Withdrawals without a memo are refused for assets that the exchange credits by memo (such as XLM), since the addresses
+// of those assets are usually shared by all the accounts of an exchange and a withdrawal without a memo to such an address is lost.
 func (c ccxtExchange) WithdrawFunds(
 	asset model.Asset,
 	amountToWithdraw *model.Number,
 	address string,
+	memo string,
 ) (*api.WithdrawFunds, error) {
-	// TODO implement
-	return nil, nil
+	ccxtAsset, e := c.assetConverter.ToString(asset)
+	if e != nil {
+		return nil, fmt.Errorf("error converting asset to string: %s", e)
+	}
+
+	if memo == "" {
+		depositAddress, e := c.api.FetchDepositAddress(ccxtAsset)
+		if e != nil {
+			return nil, fmt.Errorf("error while fetching deposit address to check whether withdrawals of asset '%s' need a memo: %s", ccxtAsset, e)
+		}
+		if depositAddress.Tag != "" {
+			return nil, api.MakeErrWithdrawMemoRequired(ccxtAsset)
+		}
+	}
+
+	log.Printf("ccxt is withdrawing funds: asset=%s, amount=%s, address=%s, memo=%s\n", ccxtAsset, amountToWithdraw.AsString(), address, memo)
+	withdrawal, e := c.api.Withdraw(ccxtAsset, amountToWithdraw.AsString(), address, memo)
+	if e != nil {
+		return nil, fmt.Errorf("error while withdrawing asset '%s': %s", ccxtAsset, e)
+	}
+
+	return &api.WithdrawFunds{
+		WithdrawalID: withdrawal.ID,
+	}, nil
 }
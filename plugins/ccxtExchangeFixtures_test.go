@@ -0,0 +1,210 @@
+package plugins
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/support/sdk"
+)
+
+// ccxtFixtureServer serves recorded ccxt-rest responses from the testdata directory and records the symbols requested for each method
+type ccxtFixtureServer struct {
+	exchangeName     string
+	mutex            *sync.Mutex
+	requestedSymbols map[string][]string
+}
+
+func (s *ccxtFixtureServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// paths are /exchanges, /exchanges/<exchangeName>, or /exchanges/<exchangeName>/<instanceName>/<method>
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case len(parts) == 1:
+		fmt.Fprintf(w, `["%s"]`, s.exchangeName)
+	case len(parts) == 2 && r.Method == "GET":
+		fmt.Fprint(w, `[]`)
+	case len(parts) == 2 && r.Method == "POST":
+		fmt.Fprint(w, `{"urls": {}}`)
+	case len(parts) == 4:
+		method := parts[3]
+		body, e := ioutil.ReadAll(r.Body)
+		if e != nil {
+			http.Error(w, e.Error(), http.StatusInternalServerError)
+			return
+		}
+		var args []string
+		if len(body) > 0 && json.Unmarshal(body, &args) == nil && len(args) > 0 {
+			s.mutex.Lock()
+			s.requestedSymbols[method] = append(s.requestedSymbols[method], args[0])
+			s.mutex.Unlock()
+		}
+
+		fixture, e := ioutil.ReadFile(filepath.Join("testdata", "ccxt", s.exchangeName, method+".json"))
+		if e != nil {
+			http.Error(w, fmt.Sprintf(`{"error": "no fixture for method '%s'"}`, method), http.StatusNotFound)
+			return
+		}
+		w.Write(fixture)
+	default:
+		http.Error(w, fmt.Sprintf(`{"error": "unexpected path '%s'"}`, r.URL.Path), http.StatusNotFound)
+	}
+}
+
+func makeFixtureCcxtExchange(t *testing.T, exchangeName string) (api.Exchange, *ccxtFixtureServer, func()) {
+	fs := &ccxtFixtureServer{
+		exchangeName:     exchangeName,
+		mutex:            &sync.Mutex{},
+		requestedSymbols: map[string][]string{},
+	}
+	ts := httptest.NewServer(fs)
+	originalBaseURL := sdk.GetBaseURL()
+	sdk.SetBaseURL(ts.URL)
+	cleanup := func() {
+		sdk.SetBaseURL(originalBaseURL)
+		ts.Close()
+	}
+
+	key := "ccxt-" + exchangeName
+	x, e := makeCcxtExchange(
+		exchangeName,
+		ccxtOrderConstraintOverridesMap[key],
+		[]api.ExchangeAPIKey{emptyAPIKey},
+		[]api.ExchangeParam{},
+		[]api.ExchangeHeader{},
+		false,
+		ccxtExchangeSpecificParamFactoryMap[key],
+	)
+	if !assert.NoError(t, e) {
+		cleanup()
+		t.FailNow()
+	}
+	return x, fs, cleanup
+}
+
+func TestCcxtExchangeFixtures(t *testing.T) {
+	xlmusdt := model.MakeTradingPair(model.XLM, model.USDT)
+	testCases := []struct {
+		exchangeName      string
+		wantConstraints   *model.OrderConstraints
+		wantBid           float64
+		wantAsk           float64
+		wantLast          float64
+		wantTopBid        float64
+		wantTopAsk        float64
+		wantTradeOrderIDs []string
+		quirkAsset        model.Asset
+		wantQuirkSymbol   string
+	}{
+		{
+			exchangeName:      "mexc",
+			wantConstraints:   model.MakeOrderConstraintsWithCost(5, 2, 1.0, 5.0),
+			wantBid:           0.11012,
+			wantAsk:           0.11018,
+			wantLast:          0.11015,
+			wantTopBid:        0.11012,
+			wantTopAsk:        0.11018,
+			wantTradeOrderIDs: []string{"C02__341758629384721408", "C02__341758629384721409"},
+			quirkAsset:        "GAS",
+			wantQuirkSymbol:   "GASDAO/USDT",
+		}, {
+			exchangeName:      "gateio",
+			wantConstraints:   model.MakeOrderConstraintsWithCost(5, 2, 1.0, 3.0),
+			wantBid:           0.11011,
+			wantAsk:           0.11019,
+			wantLast:          0.11016,
+			wantTopBid:        0.11011,
+			wantTopAsk:        0.11019,
+			wantTradeOrderIDs: []string{"2945812231", "2945812490"},
+			quirkAsset:        "MPH",
+			wantQuirkSymbol:   "Morpher/USDT",
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.exchangeName, func(t *testing.T) {
+			x, fs, cleanup := makeFixtureCcxtExchange(t, k.exchangeName)
+			defer cleanup()
+
+			// the recorded markets report precision as tick sizes so the values need to come from the overrides
+			assert.Equal(t, k.wantConstraints, x.GetOrderConstraints(xlmusdt))
+
+			tickers, e := x.GetTickerPrice([]model.TradingPair{*xlmusdt})
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, k.wantBid, tickers[*xlmusdt].BidPrice.AsFloat())
+			assert.Equal(t, k.wantAsk, tickers[*xlmusdt].AskPrice.AsFloat())
+			assert.Equal(t, k.wantLast, tickers[*xlmusdt].LastPrice.AsFloat())
+
+			ob, e := x.GetOrderBook(xlmusdt, 3)
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, 3, len(ob.Bids()))
+			assert.Equal(t, 3, len(ob.Asks()))
+			assert.Equal(t, k.wantTopBid, ob.TopBid().Price.AsFloat())
+			assert.Equal(t, k.wantTopAsk, ob.TopAsk().Price.AsFloat())
+
+			th, e := x.GetTradeHistory(*xlmusdt, nil, nil)
+			if !assert.NoError(t, e) {
+				return
+			}
+			orderIDs := []string{}
+			for _, trade := range th.Trades {
+				orderIDs = append(orderIDs, trade.OrderID)
+			}
+			assert.Equal(t, k.wantTradeOrderIDs, orderIDs)
+			assert.Equal(t, model.OrderActionBuy, th.Trades[0].OrderAction)
+			assert.Equal(t, model.OrderActionSell, th.Trades[1].OrderAction)
+
+			// assets that ccxt renames on this exchange are requested using the ccxt symbol
+			quirkPair := model.MakeTradingPair(k.quirkAsset, model.USDT)
+			_, e = x.GetTickerPrice([]model.TradingPair{*quirkPair})
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, []string{"XLM/USDT", k.wantQuirkSymbol}, fs.requestedSymbols["fetchTicker"])
+		})
+	}
+}
+
+func TestCcxtAssetConverterOverrides(t *testing.T) {
+	testCases := []struct {
+		converter  model.AssetConverterInterface
+		asset      model.Asset
+		wantString string
+	}{
+		{converter: model.CcxtAssetConverterMexc, asset: model.XLM, wantString: "XLM"},
+		{converter: model.CcxtAssetConverterMexc, asset: "GAS", wantString: "GASDAO"},
+		{converter: model.CcxtAssetConverterMexc, asset: "GASNEO", wantString: "GAS"},
+		{converter: model.CcxtAssetConverterGateio, asset: model.USDT, wantString: "USDT"},
+		{converter: model.CcxtAssetConverterGateio, asset: "MPH", wantString: "Morpher"},
+		{converter: model.CcxtAssetConverterGateio, asset: "88MPH", wantString: "MPH"},
+	}
+
+	for _, k := range testCases {
+		t.Run(string(k.asset)+"_"+k.wantString, func(t *testing.T) {
+			s, e := k.converter.ToString(k.asset)
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, k.wantString, s)
+
+			a, e := k.converter.FromString(s)
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, k.asset, a)
+		})
+	}
+}
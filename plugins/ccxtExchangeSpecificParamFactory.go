@@ -161,3 +161,97 @@ func (f *ccxtExchangeSpecificParamFactoryBitstamp) getCursorFetchTrades(t model.
 }
 
 var _ ccxtExchangeSpecificParamFactory = &ccxtExchangeSpecificParamFactoryBitstamp{}
+
+/****************************** MEXC ******************************/
+type ccxtExchangeSpecificParamFactoryMexc struct{}
+
+func (f *ccxtExchangeSpecificParamFactoryMexc) getInitParams() map[string]interface{} {
+	return map[string]interface{}{
+		"enableRateLimit": true,
+	}
+}
+
+func (f *ccxtExchangeSpecificParamFactoryMexc) getParamsForGetOrderBook() map[string]interface{} {
+	return nil
+}
+
+func (f *ccxtExchangeSpecificParamFactoryMexc) getParamsForAddOrder(submitMode api.SubmitMode) interface{} {
+	if submitMode == api.SubmitModeMakerOnly {
+		return map[string]interface{}{
+			"postOnly": true,
+		}
+	}
+	return nil
+}
+
+func (f *ccxtExchangeSpecificParamFactoryMexc) getParamsForGetTradeHistory() interface{} {
+	return map[string]interface{}{
+		"order_id": makeStringOrderIDReader("orderId"),
+	}
+}
+
+func (f *ccxtExchangeSpecificParamFactoryMexc) useSignToDenoteSideForTrades() bool {
+	return false
+}
+
+func (f *ccxtExchangeSpecificParamFactoryMexc) getCursorFetchTrades(t model.Trade) (interface{}, error) {
+	// not implemented so use default implementation
+	return nil, nil
+}
+
+var _ ccxtExchangeSpecificParamFactory = &ccxtExchangeSpecificParamFactoryMexc{}
+
+/****************************** GATEIO ******************************/
+type ccxtExchangeSpecificParamFactoryGateio struct{}
+
+func (f *ccxtExchangeSpecificParamFactoryGateio) getInitParams() map[string]interface{} {
+	return map[string]interface{}{
+		"enableRateLimit": true,
+	}
+}
+
+func (f *ccxtExchangeSpecificParamFactoryGateio) getParamsForGetOrderBook() map[string]interface{} {
+	return nil
+}
+
+func (f *ccxtExchangeSpecificParamFactoryGateio) getParamsForAddOrder(submitMode api.SubmitMode) interface{} {
+	if submitMode == api.SubmitModeMakerOnly {
+		return map[string]interface{}{
+			"postOnly": true,
+		}
+	}
+	return nil
+}
+
+func (f *ccxtExchangeSpecificParamFactoryGateio) getParamsForGetTradeHistory() interface{} {
+	return map[string]interface{}{
+		"order_id": makeStringOrderIDReader("order_id"),
+	}
+}
+
+func (f *ccxtExchangeSpecificParamFactoryGateio) useSignToDenoteSideForTrades() bool {
+	return false
+}
+
+func (f *ccxtExchangeSpecificParamFactoryGateio) getCursorFetchTrades(t model.Trade) (interface{}, error) {
+	// not implemented so use default implementation
+	return nil, nil
+}
+
+var _ ccxtExchangeSpecificParamFactory = &ccxtExchangeSpecificParamFactoryGateio{}
+
+// makeStringOrderIDReader reads the order ID from the raw trade info for exchanges that return the order ID as a string under the given key
+func makeStringOrderIDReader(key string) func(info interface{}) (string, error) {
+	return func(info interface{}) (string, error) {
+		rawInfo, ok := info.(map[string]interface{})
+		if !ok {
+			return "", fmt.Errorf("unable to convert input 'info' to a map[string]interface{}: %+v (type=%T)", info, info)
+		}
+
+		orderID, ok := rawInfo[key].(string)
+		if !ok {
+			return "", fmt.Errorf("unable to parse info[\"%s\"] as a string: %+v (type=%T)", key, rawInfo[key], rawInfo[key])
+		}
+		return orderID, nil
+	}
+}
@@ -13,6 +13,7 @@ import (
 
 	"github.com/stellar/kelp/api"
 	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/support/sdk"
 )
 
 type exchangeAuthData struct {
@@ -241,6 +242,113 @@ func validateTrades(t *testing.T, pair model.TradingPair, trades []model.Trade)
 	}
 }
 
+func TestReadTradeFeeQuote_Ccxt(t *testing.T) {
+	c := ccxtExchange{assetConverter: model.CcxtAssetConverter}
+	pair := &model.TradingPair{Base: model.XLM, Quote: model.USDT}
+	for _, kase := range []struct {
+		name     string
+		cost     float64
+		currency string
+		want     float64
+	}{
+		{name: "no fee", cost: 0.0, currency: "USDT", want: 0.0},
+		{name: "no currency", cost: 0.5, currency: "", want: 0.5},
+		{name: "quote", cost: 0.5, currency: "USDT", want: 0.5},
+		{name: "base", cost: 2.0, currency: "XLM", want: 0.5},
+		{name: "other asset", cost: 0.001, currency: "BNB", want: 0.0},
+	} {
+		t.Run(kase.name, func(t *testing.T) {
+			rawTrade := sdk.CcxtTrade{ID: "1", Price: 0.25}
+			rawTrade.Fee.Cost = kase.cost
+			rawTrade.Fee.Currency = kase.currency
+
+			fee, e := c.readTradeFeeQuote(pair, rawTrade)
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.InDelta(t, kase.want, fee, 0.0000001)
+		})
+	}
+}
+
+func TestIsTradeAfterCursor_Ccxt(t *testing.T) {
+	trade := model.Trade{
+		Order:         model.Order{Timestamp: model.MakeTimestamp(1000)},
+		TransactionID: model.MakeTransactionID("500"),
+	}
+	for _, kase := range []struct {
+		name           string
+		esParamFactory ccxtExchangeSpecificParamFactory
+		cursor         interface{}
+		want           bool
+	}{
+		{name: "no cursor", cursor: nil, want: true},
+		{name: "timestamp cursor before trade", cursor: "999", want: true},
+		{name: "timestamp cursor at trade", cursor: "1000", want: true},
+		{name: "timestamp cursor after trade", cursor: "1001", want: false},
+		{name: "trade id cursor before trade", esParamFactory: makeCcxtExchangeSpecificParamFactoryBinance(), cursor: "500", want: true},
+		{name: "trade id cursor after trade", esParamFactory: makeCcxtExchangeSpecificParamFactoryBinance(), cursor: "501", want: false},
+		{name: "cursor that is not a number", cursor: "abc", want: true},
+	} {
+		t.Run(kase.name, func(t *testing.T) {
+			c := ccxtExchange{esParamFactory: kase.esParamFactory}
+			isNew, e := c.isTradeAfterCursor(trade, kase.cursor)
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, kase.want, isNew)
+		})
+	}
+}
+
+func TestGetTradeHistoryCursor_Ccxt(t *testing.T) {
+	makeTrade := func(id string, timestampMillis int64) model.Trade {
+		return model.Trade{
+			Order:         model.Order{Timestamp: model.MakeTimestamp(timestampMillis)},
+			TransactionID: model.MakeTransactionID(id),
+		}
+	}
+	trades := []model.Trade{makeTrade("1", 999), makeTrade("2", 1000), makeTrade("3", 1000)}
+
+	for _, kase := range []struct {
+		name           string
+		esParamFactory ccxtExchangeSpecificParamFactory
+		prevCursor     interface{}
+		want           interface{}
+	}{
+		{
+			name:       "keeps the millisecond of the last trade",
+			prevCursor: nil,
+			want:       ccxtTradeHistoryCursor{timestampMillis: 1000, tradeIDs: []string{"2", "3"}},
+		}, {
+			name:       "adds the trades of the previous cursor at the same millisecond",
+			prevCursor: ccxtTradeHistoryCursor{timestampMillis: 1000, tradeIDs: []string{"0"}},
+			want:       ccxtTradeHistoryCursor{timestampMillis: 1000, tradeIDs: []string{"0", "2", "3"}},
+		}, {
+			name:       "drops the trades of the previous cursor at an earlier millisecond",
+			prevCursor: ccxtTradeHistoryCursor{timestampMillis: 999, tradeIDs: []string{"0"}},
+			want:       ccxtTradeHistoryCursor{timestampMillis: 1000, tradeIDs: []string{"2", "3"}},
+		}, {
+			name:           "exchange-specific cursor",
+			esParamFactory: makeCcxtExchangeSpecificParamFactoryBinance(),
+			prevCursor:     nil,
+			want:           "4",
+		},
+	} {
+		t.Run(kase.name, func(t *testing.T) {
+			c := ccxtExchange{esParamFactory: kase.esParamFactory}
+			cursor, e := c.getTradeHistoryCursor(trades, kase.prevCursor)
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, kase.want, cursor)
+		})
+	}
+
+	// the cursor is passed to ccxt as the since value
+	assert.Equal(t, "1000", fmt.Sprintf("%v", ccxtTradeHistoryCursor{timestampMillis: 1000, tradeIDs: []string{"2", "3"}}))
+}
+
 func TestGetLatestTradeCursor_Ccxt(t *testing.T) {
 	for exchangeName, authData := range supportedTradingExchanges {
 		t.Run(exchangeName, func(t *testing.T) {
@@ -556,6 +664,73 @@ func TestCancelOrder_Ccxt(t *testing.T) {
 	}
 }
 
+func TestIsCcxtOrderNotFoundError(t *testing.T) {
+	for _, kase := range []struct {
+		err  error
+		want bool
+	}{
+		{err: fmt.Errorf(`error canceling order: error in response, bodyString: {"error":"OrderNotFound","message":"binance {\"code\":-2011}"}`), want: true},
+		{err: fmt.Errorf(`error in response, bodyString: {"error":"kraken EOrder:Unknown order"}`), want: true},
+		{err: fmt.Errorf("could not execute http request: connection refused"), want: false},
+	} {
+		assert.Equal(t, kase.want, isCcxtOrderNotFoundError(kase.err), kase.err.Error())
+	}
+}
+
+func TestCcxtCancelResult(t *testing.T) {
+	assert.Equal(t, model.CancelResultCancelSuccessful, ccxtCancelResult(""))
+	assert.Equal(t, model.CancelResultCancelSuccessful, ccxtCancelResult("canceled"))
+	assert.Equal(t, model.CancelResultPending, ccxtCancelResult("open"))
+	assert.Equal(t, model.CancelResultFailed, ccxtCancelResult("closed"))
+}
+
+func TestMakeCcxtWithdrawInfo(t *testing.T) {
+	currency := sdk.CcxtCurrency{Code: "XLM", Fee: 0.01}
+	currency.Limits.Withdraw.Min = 1.0
+	currency.Limits.Withdraw.Max = 1000.0
+
+	info, e := makeCcxtWithdrawInfo(currency, model.NumberFromFloat(100.0, 7))
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.InDelta(t, 99.99, info.AmountToReceive.AsFloat(), 0.0000001)
+
+	_, e = makeCcxtWithdrawInfo(currency, model.NumberFromFloat(1001.0, 7))
+	assert.Error(t, e, "amount above the max withdrawal")
+	_, e = makeCcxtWithdrawInfo(currency, model.NumberFromFloat(0.5, 7))
+	assert.Error(t, e, "amount below the min withdrawal")
+
+	// exchanges that do not report a max withdrawal have no limit
+	currency.Limits.Withdraw.Max = 0
+	_, e = makeCcxtWithdrawInfo(currency, model.NumberFromFloat(5000.0, 7))
+	assert.NoError(t, e)
+}
+
+func TestToMarketPrecision_Ccxt(t *testing.T) {
+	shibPair := model.TradingPair{Base: model.Asset("SHIB"), Quote: model.USDT}
+	c := ccxtExchange{
+		assetConverter: model.CcxtAssetConverter,
+		delimiter:      "/",
+		ocOverridesHandler: MakeOrderConstraintsOverridesHandler(map[model.TradingPair]model.OrderConstraints{
+			shibPair: *model.MakeOrderConstraints(8, 0, 1.0),
+		}),
+	}
+
+	// the order was made with the precision of the sdex which would lose the significant digits of the price
+	price, volume := c.toMarketPrecision(&shibPair, model.OrderActionSell, model.NumberFromFloat(0.0000123426, 10), model.NumberFromFloat(1000000.9, 7))
+	assert.Equal(t, "0.00001235", price.AsString(), "sells are rounded up")
+	assert.Equal(t, "1000000", volume.AsString())
+
+	price, _ = c.toMarketPrecision(&shibPair, model.OrderActionBuy, model.NumberFromFloat(0.0000123456, 10), model.NumberFromFloat(1000000.9, 7))
+	assert.Equal(t, "0.00001234", price.AsString(), "buys are rounded down")
+
+	// prices at the precision of the market are unchanged
+	for _, action := range []model.OrderAction{model.OrderActionBuy, model.OrderActionSell} {
+		price, _ = c.toMarketPrecision(&shibPair, action, model.NumberFromFloat(0.00001234, 10), model.NumberFromFloat(1000000.9, 7))
+		assert.Equal(t, "0.00001234", price.AsString(), action.String())
+	}
+}
+
 func TestGetOrderConstraints_Ccxt_Precision(t *testing.T) {
 	// coinbasepro gives incorrect precision values so we do not test it here
 	testCases := []struct {
@@ -0,0 +1,109 @@
+package plugins
+
+import (
+	"log"
+	"time"
+
+	"github.com/stellar/kelp/support/monitoring"
+)
+
+// ChurnReport compares the number of offers that were created, modified, and deleted with the number of fills over an interval, along
+// with the fees spent, to quantify how much of the offer churn (and the network fees spent on it) actually resulted in trades
+type ChurnReport struct {
+	StartTime         time.Time `json:"start_time"`
+	EndTime           time.Time `json:"end_time"`
+	NumOffersCreated  int       `json:"num_offers_created"`
+	NumOffersModified int       `json:"num_offers_modified"`
+	NumOffersDeleted  int       `json:"num_offers_deleted"`
+	NumFills          int       `json:"num_fills"`
+	NetworkFees       float64   `json:"network_fees"` // in XLM
+	TradeFees         float64   `json:"trade_fees"`
+	// OfferOpsPerFill is the number of offers created, modified, or deleted for every fill, 0 when there were no fills
+	OfferOpsPerFill float64 `json:"offer_ops_per_fill"`
+	// NetworkFeesPerFill is the fee efficiency metric, i.e. the network fees (in XLM) spent for every fill, 0 when there were no fills
+	NetworkFeesPerFill float64 `json:"network_fees_per_fill"`
+}
+
+// makeChurnReport computes the report for the interval between the two snapshots of the run summary
+func makeChurnReport(prev RunSummary, prevTime time.Time, cur RunSummary, curTime time.Time) ChurnReport {
+	report := ChurnReport{
+		StartTime:         prevTime,
+		EndTime:           curTime,
+		NumOffersCreated:  cur.NumUpdateOpsCreate - prev.NumUpdateOpsCreate,
+		NumOffersModified: cur.NumUpdateOpsUpdate - prev.NumUpdateOpsUpdate,
+		NumOffersDeleted:  (cur.NumPruneOps + cur.NumUpdateOpsDelete) - (prev.NumPruneOps + prev.NumUpdateOpsDelete),
+		NumFills:          cur.NumFills - prev.NumFills,
+		NetworkFees:       cur.NetworkFees - prev.NetworkFees,
+		TradeFees:         cur.TotalFees - prev.TotalFees,
+	}
+	if report.NumFills > 0 {
+		numOfferOps := report.NumOffersCreated + report.NumOffersModified + report.NumOffersDeleted
+		report.OfferOpsPerFill = float64(numOfferOps) / float64(report.NumFills)
+		report.NetworkFeesPerFill = report.NetworkFees / float64(report.NumFills)
+	}
+	return report
+}
+
+// ChurnReporter periodically logs a ChurnReport for the last interval and for the run so far, and publishes them as metrics
+type ChurnReporter struct {
+	runSummaryTracker *RunSummaryTracker
+	interval          time.Duration
+	metrics           monitoring.Metrics // nil when the monitoring server is not running
+}
+
+// MakeChurnReporter is a factory method
+func MakeChurnReporter(runSummaryTracker *RunSummaryTracker, interval time.Duration, metrics monitoring.Metrics) *ChurnReporter {
+	return &ChurnReporter{
+		runSummaryTracker: runSummaryTracker,
+		interval:          interval,
+		metrics:           metrics,
+	}
+}
+
+// Run reports the churn every interval, it never returns so it should be run in a goroutine
+func (c *ChurnReporter) Run() {
+	start := c.runSummaryTracker.Snapshot()
+	prev := start
+	prevTime := start.StartTime
+	for {
+		time.Sleep(c.interval)
+
+		cur := c.runSummaryTracker.Snapshot()
+		curTime := time.Now()
+		c.report(makeChurnReport(prev, prevTime, cur, curTime), makeChurnReport(RunSummary{}, start.StartTime, cur, curTime))
+		prev = cur
+		prevTime = curTime
+	}
+}
+
+func (c *ChurnReporter) report(interval ChurnReport, total ChurnReport) {
+	log.Printf("churn report for the last %s: created=%d, modified=%d, deleted=%d, fills=%d, networkFees=%.7f XLM, tradeFees=%.8f, offerOpsPerFill=%.2f, networkFeesPerFill=%.7f XLM\n",
+		interval.EndTime.Sub(interval.StartTime).Round(time.Second),
+		interval.NumOffersCreated,
+		interval.NumOffersModified,
+		interval.NumOffersDeleted,
+		interval.NumFills,
+		interval.NetworkFees,
+		interval.TradeFees,
+		interval.OfferOpsPerFill,
+		interval.NetworkFeesPerFill,
+	)
+	log.Printf("churn report since start: created=%d, modified=%d, deleted=%d, fills=%d, networkFees=%.7f XLM, tradeFees=%.8f, offerOpsPerFill=%.2f, networkFeesPerFill=%.7f XLM\n",
+		total.NumOffersCreated,
+		total.NumOffersModified,
+		total.NumOffersDeleted,
+		total.NumFills,
+		total.NetworkFees,
+		total.TradeFees,
+		total.OfferOpsPerFill,
+		total.NetworkFeesPerFill,
+	)
+
+	if c.metrics == nil {
+		return
+	}
+	c.metrics.UpdateMetrics(map[string]interface{}{
+		"churn_report_interval": interval,
+		"churn_report_total":    total,
+	})
+}
@@ -0,0 +1,65 @@
+package plugins
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMakeChurnReport(t *testing.T) {
+	startTime := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	prevTime := startTime.Add(time.Hour)
+	curTime := startTime.Add(2 * time.Hour)
+	prev := RunSummary{
+		NumPruneOps:        1,
+		NumUpdateOpsDelete: 2,
+		NumUpdateOpsUpdate: 10,
+		NumUpdateOpsCreate: 4,
+		NumFills:           1,
+		TotalFees:          0.5,
+		NetworkFees:        0.01,
+	}
+	cur := RunSummary{
+		NumPruneOps:        3,
+		NumUpdateOpsDelete: 4,
+		NumUpdateOpsUpdate: 30,
+		NumUpdateOpsCreate: 8,
+		NumFills:           5,
+		TotalFees:          1.5,
+		NetworkFees:        0.03,
+	}
+
+	report := makeChurnReport(prev, prevTime, cur, curTime)
+	assert.Equal(t, prevTime, report.StartTime)
+	assert.Equal(t, curTime, report.EndTime)
+	assert.Equal(t, 4, report.NumOffersCreated)
+	assert.Equal(t, 20, report.NumOffersModified)
+	assert.Equal(t, 4, report.NumOffersDeleted)
+	assert.Equal(t, 4, report.NumFills)
+	assert.InDelta(t, 1.0, report.TradeFees, 1e-9)
+	assert.InDelta(t, 0.02, report.NetworkFees, 1e-9)
+	assert.InDelta(t, 7.0, report.OfferOpsPerFill, 1e-9)
+	assert.InDelta(t, 0.005, report.NetworkFeesPerFill, 1e-9)
+
+	// no fills leaves the per fill metrics at 0 instead of dividing by 0
+	report = makeChurnReport(cur, curTime, cur, curTime.Add(time.Hour))
+	assert.Equal(t, 0, report.NumFills)
+	assert.Equal(t, 0.0, report.OfferOpsPerFill)
+	assert.Equal(t, 0.0, report.NetworkFeesPerFill)
+}
+
+func TestRunSummaryTrackerNetworkFees(t *testing.T) {
+	r := MakeRunSummaryTracker(nil, time.Now(), "", "")
+	r.RecordNetworkFee(100)
+	r.RecordNetworkFee(250)
+	r.RecordError(RunSummaryErrorSubmitAsync)
+
+	snapshot := r.Snapshot()
+	assert.InDelta(t, 0.000035, snapshot.NetworkFees, 1e-12)
+	assert.Equal(t, 1, snapshot.ErrorCounts[RunSummaryErrorSubmitAsync])
+
+	// the snapshot is a copy that does not change with the tracker
+	r.RecordError(RunSummaryErrorSubmitAsync)
+	assert.Equal(t, 1, snapshot.ErrorCounts[RunSummaryErrorSubmitAsync])
+}
@@ -0,0 +1,75 @@
+package plugins
+
+import (
+	"sync"
+	"time"
+
+	"github.com/stellar/kelp/api"
+)
+
+// systemClock is the Clock backed by the system time
+type systemClock struct{}
+
+var _ api.Clock = systemClock{}
+
+// MakeSystemClock is a factory method for the Clock that uses the system time, which is what the bot uses outside of tests
+func MakeSystemClock() api.Clock {
+	return systemClock{}
+}
+
+// Now impl.
+func (c systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// Sleep impl.
+func (c systemClock) Sleep(d time.Duration) {
+	time.Sleep(d)
+}
+
+// ManualClock is a Clock whose time only moves when it is set or advanced, so tests can deterministically simulate day boundaries,
+// DST changes, and bucket cutovers. Sleeping advances the clock instead of blocking.
+type ManualClock struct {
+	mutex *sync.Mutex
+	now   time.Time
+}
+
+var _ api.Clock = &ManualClock{}
+
+// MakeManualClock is a factory method
+func MakeManualClock(now time.Time) *ManualClock {
+	return &ManualClock{
+		mutex: &sync.Mutex{},
+		now:   now,
+	}
+}
+
+// Now impl.
+func (c *ManualClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now
+}
+
+// Sleep impl.
+func (c *ManualClock) Sleep(d time.Duration) {
+	c.Advance(d)
+}
+
+// Set moves the clock to the time
+func (c *ManualClock) Set(now time.Time) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.now = now
+}
+
+// Advance moves the clock forward by the duration, negative durations are ignored just like with time.Sleep
+func (c *ManualClock) Advance(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.now = c.now.Add(d)
+}
@@ -0,0 +1,43 @@
+package plugins
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManualClock(t *testing.T) {
+	start := time.Date(2020, 3, 7, 23, 59, 59, 0, time.UTC)
+	c := MakeManualClock(start)
+	assert.Equal(t, start, c.Now())
+
+	// sleeping advances the clock without blocking, which lets tests cross a day boundary
+	c.Sleep(2 * time.Second)
+	assert.Equal(t, time.Date(2020, 3, 8, 0, 0, 1, 0, time.UTC), c.Now())
+	assert.Equal(t, time.Sunday, c.Now().Weekday())
+
+	// negative durations are ignored just like with time.Sleep
+	c.Advance(-time.Hour)
+	assert.Equal(t, time.Date(2020, 3, 8, 0, 0, 1, 0, time.UTC), c.Now())
+
+	// crossing the DST change in a local timezone keeps the elapsed duration exact
+	ny, e := time.LoadLocation("America/New_York")
+	if !assert.NoError(t, e) {
+		return
+	}
+	c.Set(time.Date(2020, 3, 8, 1, 30, 0, 0, ny))
+	c.Advance(time.Hour)
+	assert.Equal(t, "2020-03-08T03:30:00-04:00", c.Now().Format(time.RFC3339))
+}
+
+func TestIntervalTimeControllerSleepTimeWithClock(t *testing.T) {
+	lastUpdateTime := time.Date(2020, 3, 14, 15, 0, 0, 0, time.UTC)
+	c := MakeManualClock(lastUpdateTime.Add(1500 * time.Millisecond))
+	tc := MakeIntervalTimeController(5*time.Second, 0, c, nil)
+
+	assert.Equal(t, 3500*time.Millisecond, tc.SleepTime(lastUpdateTime))
+
+	c.Advance(4 * time.Second)
+	assert.Equal(t, -500*time.Millisecond, tc.SleepTime(lastUpdateTime))
+}
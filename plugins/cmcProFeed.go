@@ -0,0 +1,254 @@
+package plugins
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stellar/kelp/api"
+)
+
+// cmcProBaseURL is the base URL of the CoinMarketCap Pro API
+const cmcProBaseURL = "https://pro-api.coinmarketcap.com"
+
+// cmcProCacheDuration is how long a price fetched from CoinMarketCap is reused, CoinMarketCap only updates its quotes once a minute so this
+// saves API credits without making the price any older
+const cmcProCacheDuration = 60 * time.Second
+
+// cmcProIDPrefix marks a CoinMarketCap id instead of a symbol, ids are unique whereas many tokens share a symbol
+const cmcProIDPrefix = "id:"
+
+var cmcProConfigLock = &sync.Mutex{}
+
+// cmcProAPIKey and cmcProSymbolMap are used by every "cmc" price feed that is made after they are set with SetCMCConfig
+var cmcProAPIKey string
+var cmcProSymbolMap map[string]string
+
+// SetCMCConfig sets the API key and the symbol mapping used by the "cmc" price feeds. The symbol mapping maps the asset in the URL of the
+// feed to a CoinMarketCap symbol or to a CoinMarketCap id in the format "id:<id>", assets that are not in the mapping are used as the symbol
+func SetCMCConfig(apiKey string, symbolMap map[string]string) {
+	cmcProConfigLock.Lock()
+	defer cmcProConfigLock.Unlock()
+
+	cmcProAPIKey = apiKey
+	cmcProSymbolMap = symbolMap
+}
+
+/*
+example JSON returned by the /v2/cryptocurrency/quotes/latest endpoint of coinmarketcap for ?symbol=XLM&convert=USD, the data is keyed by
+the id instead of the symbol and is not a list when the query uses ?id=512
+{
+    "status": {
+        "error_code": 0,
+        "error_message": null
+    },
+    "data": {
+        "XLM": [
+            {
+                "id": 512,
+                "name": "Stellar",
+                "symbol": "XLM",
+                "quote": {
+                    "USD": {
+                        "price": 0.1131,
+                        "last_updated": "2021-10-07T18:30:02.000Z"
+                    }
+                }
+            }
+        ]
+    }
+}
+*/
+
+type cmcProResponse struct {
+	Status struct {
+		ErrorCode    int     `json:"error_code"`
+		ErrorMessage *string `json:"error_message"`
+	} `json:"status"`
+	Data map[string]json.RawMessage `json:"data"`
+}
+
+type cmcProCoin struct {
+	ID     int                        `json:"id"`
+	Symbol string                     `json:"symbol"`
+	Quote  map[string]cmcProCoinQuote `json:"quote"`
+}
+
+type cmcProCoinQuote struct {
+	Price       *float64   `json:"price"`
+	LastUpdated *time.Time `json:"last_updated"`
+}
+
+// cmcProFeed is a price feed for the CoinMarketCap Pro API, which needs an API key and has quotes for tokens that have no liquid exchange ticker
+type cmcProFeed struct {
+	baseURL       string
+	apiKey        string
+	queryKey      string // "symbol" or "id"
+	queryValue    string
+	quoteCurrency string
+	client        http.Client
+	clock         api.Clock
+
+	// uninitialized
+	lastPrice       float64
+	lastUpdatedTime time.Time
+	lastFetchTime   time.Time
+}
+
+// ensure that it implements TimestampedPriceFeed
+var _ api.TimestampedPriceFeed = &cmcProFeed{}
+
+// makeCmcProFeed makes the feed from a URL in the format <asset>/<quoteCurrency>, such as XLM/USD or id:512/EUR
+func makeCmcProFeed(feedURL string) (*cmcProFeed, error) {
+	cmcProConfigLock.Lock()
+	apiKey := cmcProAPIKey
+	symbolMap := cmcProSymbolMap
+	cmcProConfigLock.Unlock()
+
+	return makeCmcProFeedWithConfig(feedURL, cmcProBaseURL, apiKey, symbolMap, MakeSystemClock())
+}
+
+func makeCmcProFeedWithConfig(feedURL string, baseURL string, apiKey string, symbolMap map[string]string, clock api.Clock) (*cmcProFeed, error) {
+	if apiKey == "" {
+		return nil, fmt.Errorf("the \"cmc\" price feed needs CMC_API_KEY to be set in the trader config")
+	}
+
+	parts := strings.Split(feedURL, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("invalid format of cmc feed URL, needs to be <asset>/<quoteCurrency> (such as XLM/USD or id:512/USD): %s", feedURL)
+	}
+
+	asset := parts[0]
+	if mapped, ok := symbolMap[asset]; ok {
+		asset = mapped
+	}
+	queryKey := "symbol"
+	queryValue := asset
+	if strings.HasPrefix(asset, cmcProIDPrefix) {
+		queryKey = "id"
+		queryValue = strings.TrimPrefix(asset, cmcProIDPrefix)
+	}
+
+	return &cmcProFeed{
+		baseURL:       baseURL,
+		apiKey:        apiKey,
+		queryKey:      queryKey,
+		queryValue:    queryValue,
+		quoteCurrency: parts[1],
+		client:        http.Client{Timeout: 10 * time.Second},
+		clock:         clock,
+	}, nil
+}
+
+// GetPrice impl
+func (f *cmcProFeed) GetPrice() (float64, error) {
+	price, _, e := f.GetPriceWithTimestamp()
+	return price, e
+}
+
+// GetPriceWithTimestamp impl, the timestamp is the last_updated time of the quote on CoinMarketCap so a cached price keeps its original time
+func (f *cmcProFeed) GetPriceWithTimestamp() (float64, time.Time, error) {
+	now := f.clock.Now()
+	if !f.lastFetchTime.IsZero() && now.Sub(f.lastFetchTime) < cmcProCacheDuration {
+		return f.lastPrice, f.lastUpdatedTime, nil
+	}
+
+	price, lastUpdated, e := f.fetchPrice()
+	if e != nil {
+		return 0, time.Time{}, fmt.Errorf("cmc: %s", e)
+	}
+	if lastUpdated.IsZero() {
+		lastUpdated = now
+	}
+	f.lastPrice = price
+	f.lastUpdatedTime = lastUpdated
+	f.lastFetchTime = now
+	return price, lastUpdated, nil
+}
+
+// fetchPrice returns the price and its last_updated time, which is the zero time when the response does not have it
+func (f *cmcProFeed) fetchPrice() (float64, time.Time, error) {
+	query := url.Values{}
+	query.Set(f.queryKey, f.queryValue)
+	query.Set("convert", f.quoteCurrency)
+	reqURL := fmt.Sprintf("%s/v2/cryptocurrency/quotes/latest?%s", f.baseURL, query.Encode())
+
+	req, e := http.NewRequest("GET", reqURL, nil)
+	if e != nil {
+		return 0, time.Time{}, fmt.Errorf("could not make request: %s", e)
+	}
+	req.Header.Set("X-CMC_PRO_API_KEY", f.apiKey)
+	req.Header.Set("Accept", "application/json")
+
+	res, e := f.client.Do(req)
+	if e != nil {
+		return 0, time.Time{}, fmt.Errorf("could not fetch quote for %s=%s: %s", f.queryKey, f.queryValue, e)
+	}
+	defer res.Body.Close()
+
+	var resp cmcProResponse
+	e = json.NewDecoder(res.Body).Decode(&resp)
+	if e != nil {
+		return 0, time.Time{}, fmt.Errorf("could not decode response with status code %d: %s", res.StatusCode, e)
+	}
+	if resp.Status.ErrorCode != 0 || res.StatusCode != http.StatusOK {
+		errorMessage := ""
+		if resp.Status.ErrorMessage != nil {
+			errorMessage = *resp.Status.ErrorMessage
+		}
+		return 0, time.Time{}, fmt.Errorf("error response with status code %d and error code %d: %s", res.StatusCode, resp.Status.ErrorCode, errorMessage)
+	}
+
+	coin, e := f.selectCoin(resp.Data)
+	if e != nil {
+		return 0, time.Time{}, e
+	}
+	quote, ok := coin.Quote[f.quoteCurrency]
+	if !ok || quote.Price == nil {
+		return 0, time.Time{}, fmt.Errorf("no %s price for %s (id=%d)", f.quoteCurrency, coin.Symbol, coin.ID)
+	}
+	lastUpdated := time.Time{}
+	if quote.LastUpdated != nil {
+		lastUpdated = *quote.LastUpdated
+	}
+	return *quote.Price, lastUpdated, nil
+}
+
+// selectCoin picks the coin out of the data of the response, which is a list of all the coins with the symbol when querying by symbol
+func (f *cmcProFeed) selectCoin(data map[string]json.RawMessage) (*cmcProCoin, error) {
+	raw, ok := data[f.queryValue]
+	if !ok {
+		return nil, fmt.Errorf("no data for %s=%s", f.queryKey, f.queryValue)
+	}
+
+	var coin cmcProCoin
+	if f.queryKey == "id" {
+		e := json.Unmarshal(raw, &coin)
+		if e != nil {
+			return nil, fmt.Errorf("could not decode data for id=%s: %s", f.queryValue, e)
+		}
+		return &coin, nil
+	}
+
+	var coins []cmcProCoin
+	e := json.Unmarshal(raw, &coins)
+	if e != nil {
+		return nil, fmt.Errorf("could not decode data for symbol=%s: %s", f.queryValue, e)
+	}
+	if len(coins) == 0 {
+		return nil, fmt.Errorf("no coins with symbol=%s", f.queryValue)
+	}
+	if len(coins) > 1 {
+		ids := []string{}
+		for _, c := range coins {
+			ids = append(ids, fmt.Sprintf("%d", c.ID))
+		}
+		return nil, fmt.Errorf("symbol=%s matches %d coins (ids %s), map the asset to the id of the coin in CMC_SYMBOL_MAP, such as \"%s%s\"",
+			f.queryValue, len(coins), strings.Join(ids, ", "), cmcProIDPrefix, ids[0])
+	}
+	return &coins[0], nil
+}
@@ -0,0 +1,168 @@
+package plugins
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const cmcProTestAPIKey = "test-api-key"
+
+func makeCmcProTestServer(t *testing.T, numRequests *int, statusCode int, body string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*numRequests++
+		assert.Equal(t, "/v2/cryptocurrency/quotes/latest", r.URL.Path)
+		assert.Equal(t, cmcProTestAPIKey, r.Header.Get("X-CMC_PRO_API_KEY"))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(statusCode)
+		fmt.Fprint(w, body)
+	}))
+}
+
+func TestCmcProFeed_GetPrice(t *testing.T) {
+	bySymbol := `{"status":{"error_code":0,"error_message":null},"data":{"XLM":[{"id":512,"symbol":"XLM","quote":{"USD":{"price":0.25}}}]}}`
+	byID := `{"status":{"error_code":0,"error_message":null},"data":{"512":{"id":512,"symbol":"XLM","quote":{"EUR":{"price":0.2}}}}}`
+	ambiguous := `{"status":{"error_code":0,"error_message":null},"data":{"ABC":[{"id":1,"symbol":"ABC","quote":{}},{"id":2,"symbol":"ABC","quote":{}}]}}`
+	errorStatus := `{"status":{"error_code":1001,"error_message":"This API Key is invalid."},"data":null}`
+
+	testCases := []struct {
+		name          string
+		feedURL       string
+		symbolMap     map[string]string
+		statusCode    int
+		body          string
+		wantQuery     string
+		wantPrice     float64
+		wantErrSubstr string
+	}{
+		{
+			name:       "symbol",
+			feedURL:    "XLM/USD",
+			statusCode: http.StatusOK,
+			body:       bySymbol,
+			wantQuery:  "convert=USD&symbol=XLM",
+			wantPrice:  0.25,
+		}, {
+			name:       "id",
+			feedURL:    "id:512/EUR",
+			statusCode: http.StatusOK,
+			body:       byID,
+			wantQuery:  "convert=EUR&id=512",
+			wantPrice:  0.2,
+		}, {
+			name:       "mapped to id",
+			feedURL:    "stellar/EUR",
+			symbolMap:  map[string]string{"stellar": "id:512"},
+			statusCode: http.StatusOK,
+			body:       byID,
+			wantQuery:  "convert=EUR&id=512",
+			wantPrice:  0.2,
+		}, {
+			name:          "ambiguous symbol",
+			feedURL:       "ABC/USD",
+			statusCode:    http.StatusOK,
+			body:          ambiguous,
+			wantQuery:     "convert=USD&symbol=ABC",
+			wantErrSubstr: "matches 2 coins",
+		}, {
+			name:          "missing quote",
+			feedURL:       "XLM/EUR",
+			statusCode:    http.StatusOK,
+			body:          bySymbol,
+			wantQuery:     "convert=EUR&symbol=XLM",
+			wantErrSubstr: "no EUR price",
+		}, {
+			name:          "error status",
+			feedURL:       "XLM/USD",
+			statusCode:    http.StatusUnauthorized,
+			body:          errorStatus,
+			wantQuery:     "convert=USD&symbol=XLM",
+			wantErrSubstr: "This API Key is invalid.",
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			numRequests := 0
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				numRequests++
+				assert.Equal(t, "/v2/cryptocurrency/quotes/latest", r.URL.Path)
+				assert.Equal(t, k.wantQuery, r.URL.RawQuery)
+				assert.Equal(t, cmcProTestAPIKey, r.Header.Get("X-CMC_PRO_API_KEY"))
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(k.statusCode)
+				fmt.Fprint(w, k.body)
+			}))
+			defer ts.Close()
+
+			f, e := makeCmcProFeedWithConfig(k.feedURL, ts.URL, cmcProTestAPIKey, k.symbolMap, MakeManualClock(time.Unix(1000, 0)))
+			if !assert.NoError(t, e) {
+				return
+			}
+
+			price, e := f.GetPrice()
+			assert.Equal(t, 1, numRequests)
+			if k.wantErrSubstr != "" {
+				if assert.Error(t, e) {
+					assert.Contains(t, e.Error(), "cmc: ")
+					assert.Contains(t, e.Error(), k.wantErrSubstr)
+				}
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, k.wantPrice, price)
+		})
+	}
+}
+
+func TestCmcProFeed_Cache(t *testing.T) {
+	numRequests := 0
+	ts := makeCmcProTestServer(t, &numRequests, http.StatusOK,
+		`{"status":{"error_code":0,"error_message":null},"data":{"XLM":[{"id":512,"symbol":"XLM","quote":{"USD":{"price":0.25}}}]}}`)
+	defer ts.Close()
+
+	clock := MakeManualClock(time.Unix(1000, 0))
+	f, e := makeCmcProFeedWithConfig("XLM/USD", ts.URL, cmcProTestAPIKey, nil, clock)
+	if !assert.NoError(t, e) {
+		return
+	}
+
+	for _, k := range []struct {
+		now             time.Time
+		wantNumRequests int
+	}{
+		{time.Unix(1000, 0), 1},
+		{time.Unix(1059, 0), 1},
+		{time.Unix(1060, 0), 2},
+	} {
+		clock.Set(k.now)
+		price, e := f.GetPrice()
+		if !assert.NoError(t, e) {
+			return
+		}
+		assert.Equal(t, 0.25, price)
+		assert.Equal(t, k.wantNumRequests, numRequests, k.now.String())
+	}
+}
+
+func TestMakeCmcProFeed_Errors(t *testing.T) {
+	for _, k := range []struct {
+		feedURL string
+		apiKey  string
+	}{
+		{"XLM/USD", ""},
+		{"XLM", cmcProTestAPIKey},
+		{"XLM/", cmcProTestAPIKey},
+		{"/USD", cmcProTestAPIKey},
+		{"XLM/USD/extra", cmcProTestAPIKey},
+	} {
+		_, e := makeCmcProFeedWithConfig(k.feedURL, cmcProBaseURL, k.apiKey, nil, MakeSystemClock())
+		assert.Error(t, e, k.feedURL)
+	}
+}
@@ -2,6 +2,8 @@ package plugins
 
 import (
 	"fmt"
+	"log"
+	"sort"
 
 	"github.com/stellar/kelp/api"
 	"github.com/stellar/kelp/model"
@@ -9,6 +11,7 @@ import (
 	"github.com/stellar/kelp/stellargohorizonclientv300/build"
 	hProtocol "github.com/stellar/go/protocols/horizon"
 	"github.com/stellar/go/support/errors"
+	"github.com/stellar/go/txnbuild"
 	"github.com/stellar/kelp/support/utils"
 )
 
@@ -18,6 +21,12 @@ type composeStrategy struct {
 	assetQuote *hProtocol.Asset
 	buyStrat   api.SideStrategy
 	sellStrat  api.SideStrategy
+
+	// bidAssetQuote is the quote asset used by buyStrat when bids are quoted in a different asset than asks (such as a second issuer of
+	// the same currency), nil when both sides use assetQuote. The trader only knows about assetQuote so the bid market is loaded here.
+	bidAssetQuote *hProtocol.Asset
+	sdex          *SDEX
+	ieif          *IEIF
 }
 
 // ensure it implements Strategy
@@ -38,18 +47,92 @@ func makeComposeStrategy(
 	}
 }
 
+// makeComposeStrategyWithBidAsset is a factory method for a composeStrategy that quotes bids in bidAssetQuote and asks in assetQuote,
+// buyStrat should already be constructed with bidAssetQuote
+func makeComposeStrategyWithBidAsset(
+	sdex *SDEX,
+	ieif *IEIF,
+	assetBase *hProtocol.Asset,
+	assetQuote *hProtocol.Asset,
+	bidAssetQuote *hProtocol.Asset,
+	buyStrat api.SideStrategy,
+	sellStrat api.SideStrategy,
+) api.Strategy {
+	return &composeStrategy{
+		assetBase:     assetBase,
+		assetQuote:    assetQuote,
+		buyStrat:      buyStrat,
+		sellStrat:     sellStrat,
+		bidAssetQuote: bidAssetQuote,
+		sdex:          sdex,
+		ieif:          ieif,
+	}
+}
+
+// loadBidOffers loads the offers buying the base asset with bidAssetQuote, sorted in the same way as the trader sorts buyingAOffers
+func (s *composeStrategy) loadBidOffers() ([]hProtocol.Offer, error) {
+	offers, e := s.sdex.LoadOffersHack()
+	if e != nil {
+		return nil, fmt.Errorf("unable to load existing offers: %s", e)
+	}
+	_, bidOffers := utils.FilterOffers(offers, *s.assetBase, *s.bidAssetQuote)
+	sort.Sort(utils.ByPrice(bidOffers))
+	return bidOffers, nil
+}
+
 // PruneExistingOffers impl
 func (s *composeStrategy) PruneExistingOffers(buyingAOffers []hProtocol.Offer, sellingAOffers []hProtocol.Offer) ([]build.TransactionMutator, []hProtocol.Offer, []hProtocol.Offer) {
+	if s.bidAssetQuote != nil {
+		return s.pruneExistingOffersWithBidAsset(buyingAOffers, sellingAOffers)
+	}
+
 	pruneOps1, newBuyingAOffers := s.buyStrat.PruneExistingOffers(buyingAOffers)
 	pruneOps2, newSellingAOffers := s.sellStrat.PruneExistingOffers(sellingAOffers)
 	pruneOps1 = append(pruneOps1, pruneOps2...)
 	return pruneOps1, newBuyingAOffers, newSellingAOffers
 }
 
+// pruneExistingOffersWithBidAsset deletes any bids left in assetQuote and replaces buyingAOffers with the bids in bidAssetQuote, which are
+// returned to the trader so they are also deleted when the trader deletes all its offers
+func (s *composeStrategy) pruneExistingOffersWithBidAsset(buyingAOffers []hProtocol.Offer, sellingAOffers []hProtocol.Offer) ([]build.TransactionMutator, []hProtocol.Offer, []hProtocol.Offer) {
+	deleteOps := []txnbuild.Operation{}
+	for _, offer := range buyingAOffers {
+		dOp := s.sdex.DeleteOffer(offer)
+		deleteOps = append(deleteOps, &dOp)
+	}
+	pruneOps := api.ConvertOperation2TM(deleteOps)
+	if len(buyingAOffers) > 0 {
+		log.Printf("deleting %d bids in the ask quote asset (%s) since bids are quoted in %s\n", len(buyingAOffers), utils.Asset2String(*s.assetQuote), utils.Asset2String(*s.bidAssetQuote))
+	}
+
+	bidOffers, e := s.loadBidOffers()
+	if e != nil {
+		// we cannot return an error here so don't place any bids during this update since we don't know which ones already exist
+		log.Printf("unable to load bids in %s, not pruning bids: %s\n", utils.Asset2String(*s.bidAssetQuote), e)
+		bidOffers = nil
+	}
+	pruneOps1, newBuyingAOffers := s.buyStrat.PruneExistingOffers(bidOffers)
+	pruneOps2, newSellingAOffers := s.sellStrat.PruneExistingOffers(sellingAOffers)
+	pruneOps = append(pruneOps, pruneOps1...)
+	pruneOps = append(pruneOps, pruneOps2...)
+	return pruneOps, newBuyingAOffers, newSellingAOffers
+}
+
 // PreUpdate impl
 func (s *composeStrategy) PreUpdate(maxAssetBase float64, maxAssetQuote float64, trustBase float64, trustQuote float64) error {
+	maxBidAssetQuote, trustBidQuote := maxAssetQuote, trustQuote
+	if s.bidAssetQuote != nil {
+		// bids spend the inventory of bidAssetQuote which is tracked separately from the inventory of assetQuote used by asks
+		bidQuoteBalance, e := s.ieif.GetAssetBalance(*s.bidAssetQuote)
+		if e != nil {
+			return fmt.Errorf("unable to fetch balance of bid quote asset %s: %s", utils.Asset2String(*s.bidAssetQuote), e)
+		}
+		maxBidAssetQuote, trustBidQuote = bidQuoteBalance.Balance, bidQuoteBalance.Trust
+		log.Printf("(bid quote) asset=%s, max=%.8f, trust=%.8f\n", utils.Asset2String(*s.bidAssetQuote), maxBidAssetQuote, trustBidQuote)
+	}
+
 	// swap assets (base/quote) for buying strategy
-	e1 := s.buyStrat.PreUpdate(maxAssetQuote, maxAssetBase, trustQuote, trustBase)
+	e1 := s.buyStrat.PreUpdate(maxBidAssetQuote, maxAssetBase, trustBidQuote, trustBase)
 	// assets maintain same ordering for selling
 	e2 := s.sellStrat.PreUpdate(maxAssetBase, maxAssetQuote, trustBase, trustQuote)
 
@@ -72,6 +155,14 @@ func (s *composeStrategy) UpdateWithOps(
 	buyingAOffers []hProtocol.Offer,
 	sellingAOffers []hProtocol.Offer,
 ) ([]build.TransactionMutator, error) {
+	if s.bidAssetQuote != nil {
+		// the trader only resets the liabilities of the trading pair so do the same for the bid market before offers are updated
+		e := s.ieif.ResetCachedPairLiabilities(*s.assetBase, *s.bidAssetQuote)
+		if e != nil {
+			return []build.TransactionMutator{}, fmt.Errorf("unable to reset liabilities for bid quote asset %s: %s", utils.Asset2String(*s.bidAssetQuote), e)
+		}
+	}
+
 	// buy side, flip newTopBuyPrice because it will be inverted from this parent strategy's context of base/quote
 	buyOps, newTopBuyPriceInverted, e1 := s.buyStrat.UpdateWithOps(buyingAOffers)
 	newTopBuyPrice := model.InvertNumber(newTopBuyPriceInverted)
@@ -101,7 +192,21 @@ func (s *composeStrategy) UpdateWithOps(
 
 // PostUpdate impl
 func (s *composeStrategy) PostUpdate() error {
-	return nil
+	e1 := s.buyStrat.PostUpdate()
+	e2 := s.sellStrat.PostUpdate()
+
+	if e1 == nil && e2 == nil {
+		return nil
+	}
+
+	if e1 != nil && e2 != nil {
+		return fmt.Errorf("errors on both sides: buying (= %s) and selling (= %s)", e1, e2)
+	}
+
+	if e1 != nil {
+		return errors.Wrap(e1, "error in buying sub-strategy")
+	}
+	return errors.Wrap(e2, "error in selling sub-strategy")
 }
 
 // GetFillHandlers impl
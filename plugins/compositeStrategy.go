@@ -0,0 +1,298 @@
+package plugins
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sync"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/go/xdr"
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/stellargohorizonclientv300/build"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// compositeChildConfig contains the configuration params for a single child strategy of the composite strategy
+type compositeChildConfig struct {
+	Strategy     string  `valid:"-" toml:"STRATEGY"`
+	ConfigPath   string  `valid:"-" toml:"CONFIG_PATH"`
+	BalanceShare float64 `valid:"-" toml:"BALANCE_SHARE"`
+}
+
+// compositeConfig contains the configuration params for this strategy
+type compositeConfig struct {
+	Strategies []compositeChildConfig `valid:"-" toml:"STRATEGIES"`
+}
+
+// String impl.
+func (c compositeConfig) String() string {
+	return utils.StructString(c, 0, nil)
+}
+
+// computeCompositeBalanceShares returns the share of the balances given to each child strategy, children without a BALANCE_SHARE split
+// whatever is left over by the children that have one equally
+func computeCompositeBalanceShares(configs []compositeChildConfig) ([]float64, error) {
+	total := 0.0
+	numUnset := 0
+	for i, c := range configs {
+		if c.BalanceShare < 0.0 || c.BalanceShare > 1.0 {
+			return nil, fmt.Errorf("BALANCE_SHARE of child strategy at index %d needs to be between 0.0 and 1.0 but was %f", i, c.BalanceShare)
+		}
+		if c.BalanceShare == 0.0 {
+			numUnset++
+		}
+		total += c.BalanceShare
+	}
+	if total > 1.0+1e-9 {
+		return nil, fmt.Errorf("BALANCE_SHARE of all the child strategies adds up to %f, which is more than 1.0", total)
+	}
+
+	shares := []float64{}
+	for _, c := range configs {
+		share := c.BalanceShare
+		if share == 0.0 {
+			share = math.Max(1.0-total, 0.0) / float64(numUnset)
+		}
+		shares = append(shares, share)
+	}
+	return shares, nil
+}
+
+// compositePendingOffer is an offer created by a child strategy that does not have an offer ID until its transaction succeeds
+type compositePendingOffer struct {
+	childIndex int
+	isSell     bool
+	price      float64
+}
+
+// compositeStrategy runs an ordered list of child strategies on the same trading pair. Every offer is owned by exactly one child, and
+// each child only sees the offers it owns so the children never modify or delete each other's offers. Offers that are not owned by any
+// child, such as the offers left over from a previous run of the bot, are deleted. Each child is given its share of the balances.
+type compositeStrategy struct {
+	sdex          *SDEX
+	assetBase     *hProtocol.Asset
+	assetQuote    *hProtocol.Asset
+	names         []string
+	children      []api.Strategy
+	balanceShares []float64
+
+	// uninitialized
+	mutex         *sync.Mutex   // offers are created from the async callbacks of submitted transactions
+	offerOwners   map[int64]int // offer ID -> index of the owning child strategy
+	pendingOffers []compositePendingOffer
+}
+
+// ensure it implements Strategy
+var _ api.Strategy = &compositeStrategy{}
+
+// makeCompositeStrategy is a factory method for compositeStrategy
+func makeCompositeStrategy(
+	sdex *SDEX,
+	assetBase *hProtocol.Asset,
+	assetQuote *hProtocol.Asset,
+	names []string,
+	children []api.Strategy,
+	balanceShares []float64,
+) (api.Strategy, error) {
+	if len(children) == 0 {
+		return nil, fmt.Errorf("need at least one child strategy for the composite strategy")
+	}
+	if len(names) != len(children) {
+		return nil, fmt.Errorf("number of names (%d) does not match the number of child strategies (%d)", len(names), len(children))
+	}
+	if len(balanceShares) != len(children) {
+		return nil, fmt.Errorf("number of balance shares (%d) does not match the number of child strategies (%d)", len(balanceShares), len(children))
+	}
+
+	s := &compositeStrategy{
+		sdex:          sdex,
+		assetBase:     assetBase,
+		assetQuote:    assetQuote,
+		names:         names,
+		children:      children,
+		balanceShares: balanceShares,
+		mutex:         &sync.Mutex{},
+		offerOwners:   map[int64]int{},
+	}
+	sdex.SetOffersCreatedHandler(s.assignCreatedOffers)
+	return s, nil
+}
+
+// PruneExistingOffers impl
+func (s *compositeStrategy) PruneExistingOffers(buyingAOffers []hProtocol.Offer, sellingAOffers []hProtocol.Offer) ([]build.TransactionMutator, []hProtocol.Offer, []hProtocol.Offer) {
+	s.forgetRemovedOffers(buyingAOffers, sellingAOffers)
+	childBuyingAOffers, unownedBuyingAOffers := s.partition(buyingAOffers)
+	childSellingAOffers, unownedSellingAOffers := s.partition(sellingAOffers)
+
+	pruneOps := []build.TransactionMutator{}
+	newBuyingAOffers := []hProtocol.Offer{}
+	newSellingAOffers := []hProtocol.Offer{}
+	for i, child := range s.children {
+		childOps, childBuying, childSelling := child.PruneExistingOffers(childBuyingAOffers[i], childSellingAOffers[i])
+		pruneOps = append(pruneOps, childOps...)
+		newBuyingAOffers = append(newBuyingAOffers, childBuying...)
+		newSellingAOffers = append(newSellingAOffers, childSelling...)
+	}
+
+	unownedOffers := append(unownedBuyingAOffers, unownedSellingAOffers...)
+	if len(unownedOffers) > 0 {
+		log.Printf("compositeStrategy: deleting %d offers that are not owned by any child strategy\n", len(unownedOffers))
+		pruneOps = append(pruneOps, api.ConvertOperation2TM(s.sdex.DeleteAllOffers(unownedOffers))...)
+	}
+	return pruneOps, newBuyingAOffers, newSellingAOffers
+}
+
+// PreUpdate impl
+func (s *compositeStrategy) PreUpdate(maxAssetBase float64, maxAssetQuote float64, trustBase float64, trustQuote float64) error {
+	for i, child := range s.children {
+		// each child sees only its share of the balances so the children cannot commit the same funds
+		e := child.PreUpdate(maxAssetBase*s.balanceShares[i], maxAssetQuote*s.balanceShares[i], trustBase, trustQuote)
+		if e != nil {
+			return fmt.Errorf("error in child strategy at index %d (%s): %s", i, s.names[i], e)
+		}
+	}
+	return nil
+}
+
+// UpdateWithOps impl
+func (s *compositeStrategy) UpdateWithOps(buyingAOffers []hProtocol.Offer, sellingAOffers []hProtocol.Offer) ([]build.TransactionMutator, error) {
+	childBuyingAOffers, _ := s.partition(buyingAOffers)
+	childSellingAOffers, _ := s.partition(sellingAOffers)
+
+	ops := []build.TransactionMutator{}
+	pendingOffers := []compositePendingOffer{}
+	for i, child := range s.children {
+		childOps, e := child.UpdateWithOps(childBuyingAOffers[i], childSellingAOffers[i])
+		if e != nil {
+			return []build.TransactionMutator{}, fmt.Errorf("error in child strategy at index %d (%s): %s", i, s.names[i], e)
+		}
+		log.Printf("compositeStrategy: child strategy at index %d (%s) produced %d ops\n", i, s.names[i], len(childOps))
+
+		for _, mso := range api.ConvertTM2MSO(childOps) {
+			// modified offers keep their ID so they stay with the child that already owns them
+			if mso.OfferID != 0 || utils.AmountStringAsFloat(mso.Amount) == 0.0 {
+				continue
+			}
+			pendingOffers = append(pendingOffers, compositePendingOffer{
+				childIndex: i,
+				isSell:     s.isSellingBase(mso),
+				price:      utils.PriceAsFloat(mso.Price),
+			})
+		}
+		ops = append(ops, childOps...)
+	}
+
+	s.mutex.Lock()
+	s.pendingOffers = pendingOffers
+	s.mutex.Unlock()
+	return ops, nil
+}
+
+// PostUpdate impl
+func (s *compositeStrategy) PostUpdate() error {
+	for i, child := range s.children {
+		e := child.PostUpdate()
+		if e != nil {
+			return fmt.Errorf("error in child strategy at index %d (%s): %s", i, s.names[i], e)
+		}
+	}
+	return nil
+}
+
+// GetFillHandlers impl
+func (s *compositeStrategy) GetFillHandlers() ([]api.FillHandler, error) {
+	handlers := []api.FillHandler{}
+	for i, child := range s.children {
+		childHandlers, e := child.GetFillHandlers()
+		if e != nil {
+			return nil, fmt.Errorf("error while getting fill handlers for child strategy at index %d (%s): %s", i, s.names[i], e)
+		}
+		if childHandlers != nil {
+			handlers = append(handlers, childHandlers...)
+		}
+	}
+	return handlers, nil
+}
+
+// forgetRemovedOffers forgets the owners of offers that no longer exist
+func (s *compositeStrategy) forgetRemovedOffers(buyingAOffers []hProtocol.Offer, sellingAOffers []hProtocol.Offer) {
+	existingIDs := map[int64]bool{}
+	for _, offers := range [][]hProtocol.Offer{buyingAOffers, sellingAOffers} {
+		for _, o := range offers {
+			existingIDs[o.ID] = true
+		}
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for id := range s.offerOwners {
+		if !existingIDs[id] {
+			delete(s.offerOwners, id)
+		}
+	}
+}
+
+// assignCreatedOffers assigns the offers created by a submitted transaction to the child strategies that created them. The offer IDs come
+// from the transaction result, and each offer is matched to the pending offer on the same side with the closest price since the filters
+// may have adjusted the price of the op after the child strategy created it.
+func (s *compositeStrategy) assignCreatedOffers(offers []xdr.OfferEntry) {
+	baseAsset, e := utils.Asset2Asset(*s.assetBase).ToXDR()
+	if e != nil {
+		log.Printf("compositeStrategy: unable to convert base asset to xdr, not assigning %d created offers: %s\n", len(offers), e)
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, o := range offers {
+		isSell := o.Selling.Equals(baseAsset)
+		price := float64(o.Price.N) / float64(o.Price.D)
+
+		matchIndex := -1
+		for j, p := range s.pendingOffers {
+			if p.isSell != isSell {
+				continue
+			}
+			if matchIndex == -1 || math.Abs(p.price-price) < math.Abs(s.pendingOffers[matchIndex].price-price) {
+				matchIndex = j
+			}
+		}
+		if matchIndex == -1 {
+			log.Printf("compositeStrategy: created offer %d (isSell=%v, price=%.7f) does not match any pending offer of a child strategy\n", int64(o.OfferId), isSell, price)
+			continue
+		}
+
+		s.offerOwners[int64(o.OfferId)] = s.pendingOffers[matchIndex].childIndex
+		s.pendingOffers = append(s.pendingOffers[:matchIndex], s.pendingOffers[matchIndex+1:]...)
+	}
+}
+
+// partition splits the offers by owning child strategy, preserving the order of the offers, and returns the offers without an owner separately
+func (s *compositeStrategy) partition(offers []hProtocol.Offer) ([][]hProtocol.Offer, []hProtocol.Offer) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	childOffers := make([][]hProtocol.Offer, len(s.children))
+	for i := range childOffers {
+		childOffers[i] = []hProtocol.Offer{}
+	}
+
+	unownedOffers := []hProtocol.Offer{}
+	for _, o := range offers {
+		if i, ok := s.offerOwners[o.ID]; ok {
+			childOffers[i] = append(childOffers[i], o)
+		} else {
+			unownedOffers = append(unownedOffers, o)
+		}
+	}
+	return childOffers, unownedOffers
+}
+
+func (s *compositeStrategy) isSellingBase(mso *txnbuild.ManageSellOffer) bool {
+	if s.assetBase.Type == utils.Native {
+		return mso.Selling.IsNative()
+	}
+	return mso.Selling.GetCode() == s.assetBase.Code && mso.Selling.GetIssuer() == s.assetBase.Issuer
+}
@@ -0,0 +1,243 @@
+package plugins
+
+import (
+	"fmt"
+	"testing"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/go/xdr"
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/stellargohorizonclientv300/build"
+	"github.com/stellar/kelp/support/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+var compositeTestAssetBase = hProtocol.Asset{Type: utils.Native}
+var compositeTestAssetQuote = hProtocol.Asset{Type: "credit_alphanum4", Code: "USD", Issuer: "GAXEMCEXBERNSRXOEKD4JAIKVECIXQCENHEBRVSPX2TTYZPMNEDSQCNQ"}
+
+// recordingStrategy is a child strategy that records the offers it is given and creates a fixed set of offers on every update
+type recordingStrategy struct {
+	sellPrices []string
+	buyPrices  []string
+
+	prunedBuying   []int64
+	prunedSelling  []int64
+	updatedBuying  []int64
+	updatedSelling []int64
+}
+
+func (s *recordingStrategy) PruneExistingOffers(buyingAOffers []hProtocol.Offer, sellingAOffers []hProtocol.Offer) ([]build.TransactionMutator, []hProtocol.Offer, []hProtocol.Offer) {
+	s.prunedBuying = offerIDs(buyingAOffers)
+	s.prunedSelling = offerIDs(sellingAOffers)
+	return []build.TransactionMutator{}, buyingAOffers, sellingAOffers
+}
+
+func (s *recordingStrategy) PreUpdate(maxAssetA float64, maxAssetB float64, trustA float64, trustB float64) error {
+	return nil
+}
+
+func (s *recordingStrategy) UpdateWithOps(buyingAOffers []hProtocol.Offer, sellingAOffers []hProtocol.Offer) ([]build.TransactionMutator, error) {
+	s.updatedBuying = offerIDs(buyingAOffers)
+	s.updatedSelling = offerIDs(sellingAOffers)
+
+	ops := []txnbuild.Operation{}
+	for _, p := range s.sellPrices {
+		ops = append(ops, &txnbuild.ManageSellOffer{
+			Selling: utils.Asset2Asset(compositeTestAssetBase),
+			Buying:  utils.Asset2Asset(compositeTestAssetQuote),
+			Amount:  "10.0000000",
+			Price:   p,
+		})
+	}
+	for _, p := range s.buyPrices {
+		ops = append(ops, &txnbuild.ManageSellOffer{
+			Selling: utils.Asset2Asset(compositeTestAssetQuote),
+			Buying:  utils.Asset2Asset(compositeTestAssetBase),
+			Amount:  "10.0000000",
+			Price:   p,
+		})
+	}
+	return api.ConvertOperation2TM(ops), nil
+}
+
+func (s *recordingStrategy) PostUpdate() error {
+	return nil
+}
+
+func (s *recordingStrategy) GetFillHandlers() ([]api.FillHandler, error) {
+	return nil, nil
+}
+
+func offerIDs(offers []hProtocol.Offer) []int64 {
+	ids := []int64{}
+	for _, o := range offers {
+		ids = append(ids, o.ID)
+	}
+	return ids
+}
+
+func makeCompositeTestOffer(id int64, isSell bool, price string) hProtocol.Offer {
+	o := hProtocol.Offer{
+		ID:     id,
+		Amount: "10.0000000",
+		Price:  price,
+	}
+	if isSell {
+		o.Selling = compositeTestAssetBase
+		o.Buying = compositeTestAssetQuote
+	} else {
+		o.Selling = compositeTestAssetQuote
+		o.Buying = compositeTestAssetBase
+	}
+	return o
+}
+
+func makeCompositeTestCreatedOffer(t *testing.T, id int64, isSell bool, n int32, d int32) xdr.OfferEntry {
+	selling, buying := compositeTestAssetQuote, compositeTestAssetBase
+	if isSell {
+		selling, buying = compositeTestAssetBase, compositeTestAssetQuote
+	}
+	sellingXDR, e := utils.Asset2Asset(selling).ToXDR()
+	assert.NoError(t, e)
+	buyingXDR, e := utils.Asset2Asset(buying).ToXDR()
+	assert.NoError(t, e)
+
+	return xdr.OfferEntry{
+		OfferId: xdr.Int64(id),
+		Selling: sellingXDR,
+		Buying:  buyingXDR,
+		Amount:  xdr.Int64(100000000),
+		Price:   xdr.Price{N: xdr.Int32(n), D: xdr.Int32(d)},
+	}
+}
+
+func TestCompositeStrategyOwnership(t *testing.T) {
+	child1 := &recordingStrategy{sellPrices: []string{"1.5"}}
+	child2 := &recordingStrategy{sellPrices: []string{"2.0"}, buyPrices: []string{"2.0"}}
+	s, e := makeCompositeStrategy(&SDEX{}, &compositeTestAssetBase, &compositeTestAssetQuote, []string{"child1", "child2"}, []api.Strategy{child1, child2}, []float64{0.5, 0.5})
+	if !assert.NoError(t, e) {
+		return
+	}
+	cs := s.(*compositeStrategy)
+
+	// offers left over from a previous run are not owned by any child and are deleted
+	leftoverSelling := []hProtocol.Offer{makeCompositeTestOffer(1, true, "3.0000000")}
+	pruneOps, buying, selling := s.PruneExistingOffers([]hProtocol.Offer{}, leftoverSelling)
+	msos := api.ConvertTM2MSO(pruneOps)
+	if !assert.Equal(t, 1, len(msos)) {
+		return
+	}
+	assert.Equal(t, int64(1), msos[0].OfferID)
+	assert.Equal(t, 0.0, utils.AmountStringAsFloat(msos[0].Amount))
+	assert.Equal(t, 0, len(buying))
+	assert.Equal(t, 0, len(selling))
+	assert.Equal(t, []int64{}, child1.prunedSelling)
+	assert.Equal(t, []int64{}, child2.prunedSelling)
+
+	// the ops of all children are merged in order
+	ops, e := s.UpdateWithOps(buying, selling)
+	if !assert.NoError(t, e) {
+		return
+	}
+	msos = api.ConvertTM2MSO(ops)
+	prices := []string{}
+	for _, mso := range msos {
+		prices = append(prices, mso.Price)
+	}
+	assert.Equal(t, []string{"1.5000000", "2.0000000", "2.0000000"}, prices)
+
+	// the created offers are assigned to the child that created them, including when two children use the same price on different sides
+	// and when a filter adjusted the price of the op after the child created it
+	cs.assignCreatedOffers([]xdr.OfferEntry{
+		makeCompositeTestCreatedOffer(t, 10, true, 38, 25),
+		makeCompositeTestCreatedOffer(t, 11, true, 2, 1),
+		makeCompositeTestCreatedOffer(t, 12, false, 2, 1),
+	})
+	buyingAOffers := []hProtocol.Offer{makeCompositeTestOffer(12, false, "2.0000000")}
+	sellingAOffers := []hProtocol.Offer{
+		makeCompositeTestOffer(10, true, "1.5200000"),
+		makeCompositeTestOffer(11, true, "2.0000000"),
+	}
+	pruneOps, buying, selling = s.PruneExistingOffers(buyingAOffers, sellingAOffers)
+	assert.Equal(t, 0, len(pruneOps))
+	assert.Equal(t, []int64{}, child1.prunedBuying)
+	assert.Equal(t, []int64{10}, child1.prunedSelling)
+	assert.Equal(t, []int64{12}, child2.prunedBuying)
+	assert.Equal(t, []int64{11}, child2.prunedSelling)
+
+	_, e = s.UpdateWithOps(buying, selling)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, []int64{10}, child1.updatedSelling)
+	assert.Equal(t, []int64{11}, child2.updatedSelling)
+	assert.Equal(t, []int64{12}, child2.updatedBuying)
+
+	// offers that no longer exist are forgotten, and offers created in a later update are assigned by their ID
+	cs.assignCreatedOffers([]xdr.OfferEntry{makeCompositeTestCreatedOffer(t, 13, true, 3, 2)})
+	sellingAOffers = []hProtocol.Offer{
+		makeCompositeTestOffer(11, true, "2.0000000"),
+		makeCompositeTestOffer(13, true, "1.5000000"),
+	}
+	_, _, _ = s.PruneExistingOffers([]hProtocol.Offer{}, sellingAOffers)
+	assert.Equal(t, []int64{13}, child1.prunedSelling)
+	assert.Equal(t, []int64{11}, child2.prunedSelling)
+	assert.Equal(t, []int64{}, child2.prunedBuying)
+}
+
+type failingStrategy struct {
+	recordingStrategy
+}
+
+func (s *failingStrategy) UpdateWithOps(buyingAOffers []hProtocol.Offer, sellingAOffers []hProtocol.Offer) ([]build.TransactionMutator, error) {
+	return nil, fmt.Errorf("failed")
+}
+
+func TestCompositeStrategyChildError(t *testing.T) {
+	s, e := makeCompositeStrategy(&SDEX{}, &compositeTestAssetBase, &compositeTestAssetQuote, []string{"ok", "bad"}, []api.Strategy{&recordingStrategy{sellPrices: []string{"1.0"}}, &failingStrategy{}}, []float64{0.5, 0.5})
+	if !assert.NoError(t, e) {
+		return
+	}
+
+	ops, e := s.UpdateWithOps([]hProtocol.Offer{}, []hProtocol.Offer{})
+	assert.Error(t, e)
+	assert.Equal(t, 0, len(ops))
+
+	_, e = makeCompositeStrategy(&SDEX{}, &compositeTestAssetBase, &compositeTestAssetQuote, []string{}, []api.Strategy{}, []float64{})
+	assert.Error(t, e)
+}
+
+func TestComputeCompositeBalanceShares(t *testing.T) {
+	testCases := []struct {
+		name       string
+		shares     []float64
+		wantShares []float64
+		wantError  bool
+	}{
+		{name: "unset splits equally", shares: []float64{0, 0, 0, 0}, wantShares: []float64{0.25, 0.25, 0.25, 0.25}},
+		{name: "set shares", shares: []float64{0.7, 0.3}, wantShares: []float64{0.7, 0.3}},
+		{name: "unset splits the remainder", shares: []float64{0.5, 0, 0}, wantShares: []float64{0.5, 0.25, 0.25}},
+		{name: "more than the balances", shares: []float64{0.7, 0.4}, wantError: true},
+		{name: "negative", shares: []float64{-0.1, 0}, wantError: true},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			configs := []compositeChildConfig{}
+			for _, share := range k.shares {
+				configs = append(configs, compositeChildConfig{BalanceShare: share})
+			}
+
+			shares, e := computeCompositeBalanceShares(configs)
+			if k.wantError {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.InDeltaSlice(t, k.wantShares, shares, 0.0000001)
+		})
+	}
+}
@@ -0,0 +1,436 @@
+package plugins
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/kelpdb"
+)
+
+// the outcomes of an update cycle, which are the values of the outcome column in the decision_records table
+const (
+	decisionOutcomeUpdated  = "updated"   // ops were submitted to update the offers
+	decisionOutcomeNoChange = "no_change" // the offers on the orderbook already matched what the strategy wanted
+	decisionOutcomeSkipped  = "skipped"   // the strategy was not run, such as when the bot is paused
+	decisionOutcomeFailed   = "failed"    // the update cycle failed and the offers were deleted
+)
+
+// decisionRecordRetention is how long decision records are kept in the db, they are written on every update cycle so they are only
+// useful to explain recent decisions
+const decisionRecordRetention = 24 * time.Hour
+
+// decisionRecordPruneInterval is how often the decision records older than decisionRecordRetention are deleted
+const decisionRecordPruneInterval = time.Hour
+
+// DecisionRecord explains the decisions made in one update cycle, from the inputs of the strategy to the ops that were submitted
+type DecisionRecord struct {
+	DateUTC     time.Time          `json:"date_utc"`
+	Outcome     string             `json:"outcome"`
+	Balances    *DecisionBalances  `json:"balances,omitempty"`
+	Feeds       []DecisionFeed     `json:"feeds"`
+	StrategyOps []DecisionOp       `json:"strategy_ops"`
+	Filters     []DecisionFilter   `json:"filters"`
+	NumOpsOut   int                `json:"num_ops_submitted"`
+	Notes       []string           `json:"notes"`
+	Errors      []DecisionError    `json:"errors"`
+	Result      *UpdateLoopResult  `json:"result,omitempty"`
+	Counts      *DecisionOpsCounts `json:"counts,omitempty"`
+}
+
+// DecisionBalances are the balances available to the strategy in the update cycle
+type DecisionBalances struct {
+	Base  float64 `json:"base"`
+	Quote float64 `json:"quote"`
+}
+
+// DecisionFeed is a price that a price feed returned to the strategy, Error is set instead of Price when the feed failed
+type DecisionFeed struct {
+	Feed  string  `json:"feed"`
+	Price float64 `json:"price"`
+	Error string  `json:"error,omitempty"`
+}
+
+// DecisionOp is an op on an offer, the price is of the base asset in units of the quote asset and the amount is of the base asset
+type DecisionOp struct {
+	Action  string  `json:"action"` // create, update or delete
+	Side    string  `json:"side"`
+	OfferID string  `json:"offer_id,omitempty"`
+	Price   float64 `json:"price"`
+	Amount  float64 `json:"amount"`
+}
+
+// DecisionFilter is the outcome of a filter, which lists every op that the filter changed
+type DecisionFilter struct {
+	Filter  string           `json:"filter"`
+	NumIn   int              `json:"num_ops_in"`
+	NumOut  int              `json:"num_ops_out"`
+	Changes []DecisionChange `json:"changes"`
+}
+
+// DecisionChange is an op that a filter dropped, modified or added, with the reason for the change
+type DecisionChange struct {
+	Change string      `json:"change"` // dropped, modified or added
+	Reason string      `json:"reason"`
+	Before *DecisionOp `json:"before,omitempty"`
+	After  *DecisionOp `json:"after,omitempty"`
+}
+
+// DecisionError is an error that stopped the update cycle at a stage
+type DecisionError struct {
+	Stage string `json:"stage"`
+	Error string `json:"error"`
+}
+
+// DecisionOpsCounts counts the ops that the strategy decided on by type
+type DecisionOpsCounts struct {
+	NumPruneOps int `json:"num_prune_ops"`
+	NumDeletes  int `json:"num_deletes"`
+	NumUpdates  int `json:"num_updates"`
+	NumCreates  int `json:"num_creates"`
+}
+
+// DecisionRecorder collects a DecisionRecord in every update cycle and writes it to the decision_records table when the cycle ends, so
+// users can look up why the bot placed, changed or skipped offers. The price feeds report their prices to the recorder that is set with
+// SetDecisionRecorder and the filters report their changes when they are wrapped with WrapFilters.
+type DecisionRecorder struct {
+	db         *sql.DB
+	accountID  string
+	marketID   string
+	baseAsset  hProtocol.Asset
+	quoteAsset hProtocol.Asset
+	clock      api.Clock
+
+	// uninitialized
+	mutex   *sync.Mutex
+	current *DecisionRecord // nil outside of an update cycle
+}
+
+// MakeDecisionRecorder is a factory method
+func MakeDecisionRecorder(db *sql.DB, accountID string, marketID string, baseAsset hProtocol.Asset, quoteAsset hProtocol.Asset, clock api.Clock) (*DecisionRecorder, error) {
+	if db == nil {
+		return nil, fmt.Errorf("EXPLAIN_DECISIONS needs POSTGRES_DB to be set in the trader config")
+	}
+	if accountID == "" {
+		return nil, fmt.Errorf("EXPLAIN_DECISIONS needs DB_OVERRIDE__ACCOUNT_ID to be set in the trader config")
+	}
+
+	r := &DecisionRecorder{
+		db:         db,
+		accountID:  accountID,
+		marketID:   marketID,
+		baseAsset:  baseAsset,
+		quoteAsset: quoteAsset,
+		clock:      clock,
+		mutex:      &sync.Mutex{},
+	}
+	registerScheduledTask(ScheduledTask{
+		Name:     "decision_records_pruning",
+		Interval: decisionRecordPruneInterval,
+		Fn:       r.prune,
+	})
+	return r, nil
+}
+
+// StartCycle starts a new DecisionRecord, it should be called before every update cycle
+func (r *DecisionRecorder) StartCycle() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.current = &DecisionRecord{
+		DateUTC: r.clock.Now().UTC(),
+		Feeds:   []DecisionFeed{},
+		Filters: []DecisionFilter{},
+		Notes:   []string{},
+		Errors:  []DecisionError{},
+	}
+}
+
+// RecordBalances records the balances that are available to the strategy
+func (r *DecisionRecorder) RecordBalances(base float64, quote float64) {
+	r.withCurrent(func(rec *DecisionRecord) {
+		rec.Balances = &DecisionBalances{Base: base, Quote: quote}
+	})
+}
+
+// RecordFeed records the price returned by a price feed, or the error when it failed
+func (r *DecisionRecorder) RecordFeed(feed string, price float64, e error) {
+	r.withCurrent(func(rec *DecisionRecord) {
+		df := DecisionFeed{Feed: feed, Price: price}
+		if e != nil {
+			df.Error = e.Error()
+		}
+		rec.Feeds = append(rec.Feeds, df)
+	})
+}
+
+// RecordStrategyOps records the ops that the strategy decided on before they are passed to the filters
+func (r *DecisionRecorder) RecordStrategyOps(msos []*txnbuild.ManageSellOffer, counts DecisionOpsCounts) {
+	r.withCurrent(func(rec *DecisionRecord) {
+		rec.StrategyOps = []DecisionOp{}
+		for _, mso := range msos {
+			rec.StrategyOps = append(rec.StrategyOps, *r.decisionOp(mso))
+		}
+		rec.Counts = &counts
+	})
+}
+
+// RecordSubmitting records the number of ops returned by the last filter that are submitted
+func (r *DecisionRecorder) RecordSubmitting(numOps int) {
+	r.withCurrent(func(rec *DecisionRecord) {
+		rec.NumOpsOut = numOps
+	})
+}
+
+// Note records why the update cycle did something other than the usual, such as skipping the strategy
+func (r *DecisionRecorder) Note(format string, args ...interface{}) {
+	r.withCurrent(func(rec *DecisionRecord) {
+		rec.Notes = append(rec.Notes, fmt.Sprintf(format, args...))
+	})
+}
+
+// RecordError records the error that stopped the update cycle at a stage
+func (r *DecisionRecorder) RecordError(stage string, e error) {
+	r.withCurrent(func(rec *DecisionRecord) {
+		rec.Errors = append(rec.Errors, DecisionError{Stage: stage, Error: e.Error()})
+	})
+}
+
+// EndCycle writes the DecisionRecord of the update cycle to the db, errors are only logged because explaining decisions should never
+// stop the bot
+func (r *DecisionRecorder) EndCycle(result UpdateLoopResult) {
+	r.mutex.Lock()
+	rec := r.current
+	r.current = nil
+	r.mutex.Unlock()
+	if rec == nil {
+		return
+	}
+
+	rec.Result = &result
+	rec.Outcome = decisionOutcome(rec)
+	log.Printf("decision record: outcome=%s, feeds=%d, strategyOps=%d, filters=%d, submittedOps=%d, errors=%d\n",
+		rec.Outcome, len(rec.Feeds), len(rec.StrategyOps), len(rec.Filters), rec.NumOpsOut, len(rec.Errors))
+
+	recordBytes, e := json.Marshal(rec)
+	if e != nil {
+		log.Printf("could not marshal the decision record: %s\n", e)
+		return
+	}
+	_, e = r.db.Exec(kelpdb.SqlDecisionRecordsInsert, r.accountID, r.marketID, rec.DateUTC, rec.Outcome, string(recordBytes))
+	if e != nil {
+		log.Printf("could not write the decision record to the db: %s\n", e)
+	}
+}
+
+// prune deletes the decision records older than decisionRecordRetention
+func (r *DecisionRecorder) prune(now time.Time) error {
+	_, e := r.db.Exec(kelpdb.SqlDecisionRecordsDelete, r.accountID, r.marketID, now.Add(-decisionRecordRetention).UTC())
+	if e != nil {
+		return fmt.Errorf("could not delete the decision records older than %s: %s", decisionRecordRetention, e)
+	}
+	return nil
+}
+
+// decisionOutcome summarizes the update cycle of the record
+func decisionOutcome(rec *DecisionRecord) string {
+	if rec.Result != nil && !rec.Result.Success && len(rec.Errors) > 0 {
+		return decisionOutcomeFailed
+	}
+	if rec.StrategyOps == nil {
+		return decisionOutcomeSkipped
+	}
+	if len(rec.Errors) > 0 {
+		return decisionOutcomeFailed
+	}
+	if rec.NumOpsOut == 0 {
+		return decisionOutcomeNoChange
+	}
+	return decisionOutcomeUpdated
+}
+
+// withCurrent calls fn with the record of the current update cycle, prices fetched outside of an update cycle are not recorded
+func (r *DecisionRecorder) withCurrent(fn func(rec *DecisionRecord)) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.current == nil {
+		return
+	}
+	fn(r.current)
+}
+
+// decisionOp converts the op to a DecisionOp
+func (r *DecisionRecorder) decisionOp(mso *txnbuild.ManageSellOffer) *DecisionOp {
+	side, price, amount := sidePriceAmountOfOp(r.baseAsset, r.quoteAsset, mso)
+	op := &DecisionOp{
+		Action: "create",
+		Side:   side,
+		Price:  price,
+		Amount: amount,
+	}
+	if mso.OfferID != 0 {
+		op.OfferID = strconv.FormatInt(mso.OfferID, 10)
+		op.Action = "update"
+		if mso.Amount == "0" {
+			op.Action = "delete"
+		}
+	}
+	return op
+}
+
+// WrapFilters wraps every filter so the changes that it makes to the ops are recorded with the filter as the reason, it should be called
+// after FilterMetrics.Wrap so the filters are labelled with their position in the chain
+func (r *DecisionRecorder) WrapFilters(filters []SubmitFilter) []SubmitFilter {
+	wrapped := []SubmitFilter{}
+	for _, filter := range filters {
+		wrapped = append(wrapped, &explainedFilter{
+			inner:    filter,
+			label:    filterLabel(filter),
+			recorder: r,
+		})
+	}
+	return wrapped
+}
+
+// applyFilter records the outcome of a filter
+func (r *DecisionRecorder) applyFilter(label string, before []txnbuild.Operation, after []txnbuild.Operation) {
+	df := DecisionFilter{
+		Filter:  label,
+		NumIn:   len(before),
+		NumOut:  len(after),
+		Changes: []DecisionChange{},
+	}
+	m := matchOps(before, after)
+	// the modified ops are listed in the order they were returned by the filter
+	modifiedIndices := []int{}
+	for j := range m.modified {
+		modifiedIndices = append(modifiedIndices, j)
+	}
+	sort.Ints(modifiedIndices)
+	for _, j := range modifiedIndices {
+		i := m.modified[j]
+		df.Changes = append(df.Changes, DecisionChange{
+			Change: "modified",
+			Reason: fmt.Sprintf("filter %s changed the op", label),
+			Before: r.maybeDecisionOp(before[i]),
+			After:  r.maybeDecisionOp(after[j]),
+		})
+	}
+	for _, i := range m.dropped {
+		df.Changes = append(df.Changes, DecisionChange{
+			Change: "dropped",
+			Reason: fmt.Sprintf("filter %s dropped the op", label),
+			Before: r.maybeDecisionOp(before[i]),
+		})
+	}
+	for _, j := range m.added {
+		df.Changes = append(df.Changes, DecisionChange{
+			Change: "added",
+			Reason: fmt.Sprintf("filter %s added the op", label),
+			After:  r.maybeDecisionOp(after[j]),
+		})
+	}
+
+	r.withCurrent(func(rec *DecisionRecord) {
+		rec.Filters = append(rec.Filters, df)
+	})
+}
+
+// maybeDecisionOp converts the op to a DecisionOp, returns nil for ops that are not offers
+func (r *DecisionRecorder) maybeDecisionOp(op txnbuild.Operation) *DecisionOp {
+	mso, ok := op.(*txnbuild.ManageSellOffer)
+	if !ok {
+		return nil
+	}
+	return r.decisionOp(mso)
+}
+
+// explainedFilter records the changes that the inner filter makes to the ops
+type explainedFilter struct {
+	inner    SubmitFilter
+	label    string
+	recorder *DecisionRecorder
+}
+
+var _ SubmitFilter = &explainedFilter{}
+var _ OrderedSubmitFilter = &explainedFilter{}
+
+// FilterOrder impl.
+func (f *explainedFilter) FilterOrder() FilterOrder {
+	return getFilterOrder(f.inner)
+}
+
+// Unwrap impl.
+func (f *explainedFilter) Unwrap() SubmitFilter {
+	return f.inner
+}
+
+// Apply impl.
+func (f *explainedFilter) Apply(ops []txnbuild.Operation, sellingOffers []hProtocol.Offer, buyingOffers []hProtocol.Offer) ([]txnbuild.Operation, error) {
+	filteredOps, e := f.inner.Apply(ops, sellingOffers, buyingOffers)
+	if e != nil {
+		f.recorder.RecordError(fmt.Sprintf("filter %s", f.label), e)
+		return nil, e
+	}
+	f.recorder.applyFilter(f.label, ops, filteredOps)
+	return filteredOps, nil
+}
+
+// String is the Stringer method
+func (f *explainedFilter) String() string {
+	return f.label
+}
+
+var decisionRecorderLock = &sync.Mutex{}
+
+// decisionRecorder receives the prices of every price feed that is made after it is set with SetDecisionRecorder, it is nil when disabled
+var decisionRecorder *DecisionRecorder
+
+// SetDecisionRecorder sets the recorder that the price feeds made by MakePriceFeed report their prices to, nil disables it
+func SetDecisionRecorder(r *DecisionRecorder) {
+	decisionRecorderLock.Lock()
+	defer decisionRecorderLock.Unlock()
+
+	decisionRecorder = r
+}
+
+// withDecisionRecorder wraps the feed so it reports its prices to the decision recorder when one is set
+func withDecisionRecorder(feedType string, url string, feed api.PriceFeed) api.PriceFeed {
+	decisionRecorderLock.Lock()
+	r := decisionRecorder
+	decisionRecorderLock.Unlock()
+
+	if r == nil {
+		return feed
+	}
+	return &explainedFeed{
+		name:     feedType + "/" + url,
+		feed:     feed,
+		recorder: r,
+	}
+}
+
+// explainedFeed reports the prices of the inner feed to the decision recorder
+type explainedFeed struct {
+	name     string
+	feed     api.PriceFeed
+	recorder *DecisionRecorder
+}
+
+var _ api.PriceFeed = &explainedFeed{}
+
+// GetPrice impl.
+func (f *explainedFeed) GetPrice() (float64, error) {
+	price, e := f.feed.GetPrice()
+	f.recorder.RecordFeed(f.name, price, e)
+	return price, e
+}
@@ -0,0 +1,131 @@
+package plugins
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// makeTestDecisionRecorder makes a DecisionRecorder without a db, so EndCycle cannot be called on it
+func makeTestDecisionRecorder() *DecisionRecorder {
+	return &DecisionRecorder{
+		accountID:  "GACCOUNT",
+		marketID:   "market",
+		baseAsset:  hProtocol.Asset{Type: utils.Native},
+		quoteAsset: hProtocol.Asset{Type: "credit_alphanum4", Code: testQuoteAsset.Code, Issuer: testQuoteAsset.Issuer},
+		clock:      MakeManualClock(time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)),
+		mutex:      &sync.Mutex{},
+	}
+}
+
+func TestMakeDecisionRecorder_Errors(t *testing.T) {
+	_, e := MakeDecisionRecorder(nil, "GACCOUNT", "market", hProtocol.Asset{}, hProtocol.Asset{}, MakeSystemClock())
+	assert.Error(t, e)
+}
+
+func TestDecisionRecorder_Cycle(t *testing.T) {
+	r := makeTestDecisionRecorder()
+	pf, e := newFixedFeed("1.5")
+	if !assert.NoError(t, e) {
+		return
+	}
+	feed := &explainedFeed{name: "fixed/1.5", feed: pf, recorder: r}
+
+	// prices fetched outside of an update cycle are not recorded
+	_, e = feed.GetPrice()
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Nil(t, r.current)
+
+	r.StartCycle()
+	r.RecordBalances(100.0, 200.0)
+	_, e = feed.GetPrice()
+	if !assert.NoError(t, e) {
+		return
+	}
+	msos := []*txnbuild.ManageSellOffer{
+		makeTestSellOp(0, "10.0", "1.6"),
+		{Selling: testQuoteAsset, Buying: testBaseAsset, Amount: "20.0", Price: "0.5", OfferID: 7},
+	}
+	r.RecordStrategyOps(msos, DecisionOpsCounts{NumUpdates: 1, NumCreates: 1})
+
+	dropFirst := &testFnFilter{fn: func(ops []txnbuild.Operation) []txnbuild.Operation {
+		return ops[1:]
+	}}
+	filters := r.WrapFilters([]SubmitFilter{dropFirst})
+	ops, e := filters[0].Apply(api.ConvertMSO2Ops(msos), []hProtocol.Offer{}, []hProtocol.Offer{})
+	if !assert.NoError(t, e) {
+		return
+	}
+	r.RecordSubmitting(len(ops))
+
+	rec := r.current
+	assert.Equal(t, time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC), rec.DateUTC)
+	assert.Equal(t, &DecisionBalances{Base: 100.0, Quote: 200.0}, rec.Balances)
+	assert.Equal(t, []DecisionFeed{{Feed: "fixed/1.5", Price: 1.5}}, rec.Feeds)
+	assert.Equal(t, []DecisionOp{
+		{Action: "create", Side: orderTraceSideSell, Price: 1.6, Amount: 10.0},
+		{Action: "update", Side: orderTraceSideBuy, OfferID: "7", Price: 2.0, Amount: 10.0},
+	}, rec.StrategyOps)
+	if !assert.Equal(t, 1, len(rec.Filters)) {
+		return
+	}
+	label := fmt.Sprintf("%T", dropFirst)
+	assert.Equal(t, DecisionFilter{
+		Filter: label,
+		NumIn:  2,
+		NumOut: 1,
+		Changes: []DecisionChange{{
+			Change: "dropped",
+			Reason: fmt.Sprintf("filter %s dropped the op", label),
+			Before: &DecisionOp{Action: "create", Side: orderTraceSideSell, Price: 1.6, Amount: 10.0},
+		}},
+	}, rec.Filters[0])
+	assert.Equal(t, 1, rec.NumOpsOut)
+	assert.Equal(t, decisionOutcomeUpdated, decisionOutcome(rec))
+}
+
+func TestDecisionOutcome(t *testing.T) {
+	testCases := []struct {
+		name string
+		rec  *DecisionRecord
+		want string
+	}{
+		{
+			name: "skipped",
+			rec:  &DecisionRecord{Notes: []string{"bot is paused"}, Result: &UpdateLoopResult{Success: true}},
+			want: decisionOutcomeSkipped,
+		}, {
+			name: "failed before the strategy",
+			rec:  &DecisionRecord{Errors: []DecisionError{{Stage: "strategy PreUpdate"}}, Result: &UpdateLoopResult{Success: false}},
+			want: decisionOutcomeFailed,
+		}, {
+			name: "failed in a filter",
+			rec:  &DecisionRecord{StrategyOps: []DecisionOp{{}}, Errors: []DecisionError{{Stage: "filter"}}, Result: &UpdateLoopResult{Success: false}},
+			want: decisionOutcomeFailed,
+		}, {
+			name: "no change",
+			rec:  &DecisionRecord{StrategyOps: []DecisionOp{}, Result: &UpdateLoopResult{Success: true}},
+			want: decisionOutcomeNoChange,
+		}, {
+			name: "updated",
+			rec:  &DecisionRecord{StrategyOps: []DecisionOp{{}}, NumOpsOut: 1, Result: &UpdateLoopResult{Success: true}},
+			want: decisionOutcomeUpdated,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			assert.Equal(t, k.want, decisionOutcome(k.rec))
+		})
+	}
+}
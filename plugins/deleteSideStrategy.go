@@ -15,6 +15,10 @@ type deleteSideStrategy struct {
 	sdex       *SDEX
 	assetBase  *hProtocol.Asset
 	assetQuote *hProtocol.Asset
+	enabled    bool
+	isBuySide  bool
+	minPrice   float64 // in units of quote asset per base asset of the trading pair, 0 means there is no minimum
+	maxPrice   float64 // in units of quote asset per base asset of the trading pair, 0 means there is no maximum
 }
 
 // ensure it implements SideStrategy
@@ -25,23 +29,69 @@ func makeDeleteSideStrategy(
 	sdex *SDEX,
 	assetBase *hProtocol.Asset,
 	assetQuote *hProtocol.Asset,
+) api.SideStrategy {
+	return makeSelectiveDeleteSideStrategy(sdex, assetBase, assetQuote, true, false, 0, 0)
+}
+
+// makeSelectiveDeleteSideStrategy is a factory method for a deleteSideStrategy that only deletes offers within the price range,
+// a disabled side keeps all of its offers. isBuySide indicates that assetBase and assetQuote were switched for the buy side.
+func makeSelectiveDeleteSideStrategy(
+	sdex *SDEX,
+	assetBase *hProtocol.Asset,
+	assetQuote *hProtocol.Asset,
+	enabled bool,
+	isBuySide bool,
+	minPrice float64,
+	maxPrice float64,
 ) api.SideStrategy {
 	return &deleteSideStrategy{
 		sdex:       sdex,
 		assetBase:  assetBase,
 		assetQuote: assetQuote,
+		enabled:    enabled,
+		isBuySide:  isBuySide,
+		minPrice:   minPrice,
+		maxPrice:   maxPrice,
 	}
 }
 
 // PruneExistingOffers impl
 func (s *deleteSideStrategy) PruneExistingOffers(offers []hProtocol.Offer) ([]build.TransactionMutator, []hProtocol.Offer) {
-	log.Printf("deleteSideStrategy: deleting %d offers\n", len(offers))
 	pruneOps := []txnbuild.Operation{}
+	remainingOffers := []hProtocol.Offer{}
 	for i := 0; i < len(offers); i++ {
+		if !s.shouldDelete(offers[i]) {
+			remainingOffers = append(remainingOffers, offers[i])
+			continue
+		}
 		pOp := s.sdex.DeleteOffer(offers[i])
 		pruneOps = append(pruneOps, &pOp)
 	}
-	return api.ConvertOperation2TM(pruneOps), []hProtocol.Offer{}
+	log.Printf("deleteSideStrategy: deleting %d offers, keeping %d offers\n", len(pruneOps), len(remainingOffers))
+	return api.ConvertOperation2TM(pruneOps), remainingOffers
+}
+
+// shouldDelete checks whether the offer is on an enabled side and its price, converted to quote per base of the trading pair, is in range
+func (s *deleteSideStrategy) shouldDelete(offer hProtocol.Offer) bool {
+	if !s.enabled {
+		return false
+	}
+	if s.minPrice == 0 && s.maxPrice == 0 {
+		return true
+	}
+
+	price := float64(offer.PriceR.N) / float64(offer.PriceR.D)
+	if s.isBuySide {
+		// buy offers sell the quote asset so their price is in units of base asset per quote asset
+		price = float64(offer.PriceR.D) / float64(offer.PriceR.N)
+	}
+	if s.minPrice > 0 && price < s.minPrice {
+		return false
+	}
+	if s.maxPrice > 0 && price > s.maxPrice {
+		return false
+	}
+	return true
 }
 
 // PreUpdate impl
@@ -1,20 +1,74 @@
 package plugins
 
 import (
+	"fmt"
+
 	hProtocol "github.com/stellar/go/protocols/horizon"
 	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// deleteSide is the side of the orderbook that the delete strategy removes offers from
+type deleteSide string
+
+// type of deleteSide
+const (
+	deleteSideBoth deleteSide = "both"
+	deleteSideBuy  deleteSide = "buy"
+	deleteSideSell deleteSide = "sell"
 )
 
+// deleteConfig contains the optional configuration params for this strategy, an empty config deletes all offers
+type deleteConfig struct {
+	Side     string  `valid:"-" toml:"SIDE"`      // one of "both", "buy", or "sell", defaults to "both"
+	MinPrice float64 `valid:"-" toml:"MIN_PRICE"` // in units of quote asset per base asset, 0 means there is no minimum
+	MaxPrice float64 `valid:"-" toml:"MAX_PRICE"` // in units of quote asset per base asset, 0 means there is no maximum
+}
+
+// String impl.
+func (c deleteConfig) String() string {
+	return utils.StructString(c, 0, nil)
+}
+
+// parseDeleteSide converts the configured side, where an empty side means both
+func parseDeleteSide(side string) (deleteSide, error) {
+	switch deleteSide(side) {
+	case "", deleteSideBoth:
+		return deleteSideBoth, nil
+	case deleteSideBuy:
+		return deleteSideBuy, nil
+	case deleteSideSell:
+		return deleteSideSell, nil
+	}
+	return "", fmt.Errorf("invalid SIDE '%s', should be one of '%s', '%s', or '%s'", side, deleteSideBoth, deleteSideBuy, deleteSideSell)
+}
+
 // makeDeleteStrategy is a factory method
 func makeDeleteStrategy(
 	sdex *SDEX,
 	assetBase *hProtocol.Asset,
 	assetQuote *hProtocol.Asset,
-) api.Strategy {
+	config *deleteConfig,
+) (api.Strategy, error) {
+	if config == nil {
+		config = &deleteConfig{}
+	}
+	side, e := parseDeleteSide(config.Side)
+	if e != nil {
+		return nil, e
+	}
+	if config.MinPrice < 0 || config.MaxPrice < 0 {
+		return nil, fmt.Errorf("MIN_PRICE (%f) and MAX_PRICE (%f) cannot be negative", config.MinPrice, config.MaxPrice)
+	}
+	if config.MaxPrice > 0 && config.MinPrice > config.MaxPrice {
+		return nil, fmt.Errorf("MIN_PRICE (%f) cannot be greater than MAX_PRICE (%f)", config.MinPrice, config.MaxPrice)
+	}
+
 	return makeComposeStrategy(
 		assetBase,
 		assetQuote,
-		makeDeleteSideStrategy(sdex, assetQuote, assetBase), // switch sides of base/quote here for the buy side
-		makeDeleteSideStrategy(sdex, assetBase, assetQuote),
-	)
+		// switch sides of base/quote here for the buy side
+		makeSelectiveDeleteSideStrategy(sdex, assetQuote, assetBase, side != deleteSideSell, true, config.MinPrice, config.MaxPrice),
+		makeSelectiveDeleteSideStrategy(sdex, assetBase, assetQuote, side != deleteSideBuy, false, config.MinPrice, config.MaxPrice),
+	), nil
 }
@@ -0,0 +1,87 @@
+package plugins
+
+import (
+	"fmt"
+	"testing"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stretchr/testify/assert"
+)
+
+func makeTestOfferWithPrice(n int32, d int32) hProtocol.Offer {
+	offer := hProtocol.Offer{}
+	offer.PriceR.N = n
+	offer.PriceR.D = d
+	return offer
+}
+
+func TestParseDeleteSide(t *testing.T) {
+	testCases := []struct {
+		side    string
+		want    deleteSide
+		wantErr bool
+	}{
+		{side: "", want: deleteSideBoth},
+		{side: "both", want: deleteSideBoth},
+		{side: "buy", want: deleteSideBuy},
+		{side: "sell", want: deleteSideSell},
+		{side: "bids", wantErr: true},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.side, func(t *testing.T) {
+			side, e := parseDeleteSide(k.side)
+			if k.wantErr {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, k.want, side)
+		})
+	}
+}
+
+func TestDeleteSideStrategyShouldDelete(t *testing.T) {
+	testCases := []struct {
+		enabled   bool
+		isBuySide bool
+		minPrice  float64
+		maxPrice  float64
+		offer     hProtocol.Offer
+		want      bool
+	}{
+		// no price range deletes everything on an enabled side
+		{enabled: true, isBuySide: false, offer: makeTestOfferWithPrice(3, 1), want: true},
+		{enabled: false, isBuySide: false, offer: makeTestOfferWithPrice(3, 1), want: false},
+		// sell offers are priced in quote per base
+		{enabled: true, isBuySide: false, minPrice: 2.0, maxPrice: 4.0, offer: makeTestOfferWithPrice(3, 1), want: true},
+		{enabled: true, isBuySide: false, minPrice: 2.0, maxPrice: 4.0, offer: makeTestOfferWithPrice(5, 1), want: false},
+		{enabled: true, isBuySide: false, minPrice: 2.0, maxPrice: 4.0, offer: makeTestOfferWithPrice(1, 1), want: false},
+		{enabled: true, isBuySide: false, minPrice: 2.0, offer: makeTestOfferWithPrice(5, 1), want: true},
+		{enabled: true, isBuySide: false, maxPrice: 4.0, offer: makeTestOfferWithPrice(1, 1), want: true},
+		// buy offers are priced in base per quote so a price of 1/3 is 3 quote per base
+		{enabled: true, isBuySide: true, minPrice: 2.0, maxPrice: 4.0, offer: makeTestOfferWithPrice(1, 3), want: true},
+		{enabled: true, isBuySide: true, minPrice: 2.0, maxPrice: 4.0, offer: makeTestOfferWithPrice(3, 1), want: false},
+		{enabled: false, isBuySide: true, minPrice: 2.0, maxPrice: 4.0, offer: makeTestOfferWithPrice(1, 3), want: false},
+	}
+
+	for i, k := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			s := makeSelectiveDeleteSideStrategy(nil, nil, nil, k.enabled, k.isBuySide, k.minPrice, k.maxPrice).(*deleteSideStrategy)
+			assert.Equal(t, k.want, s.shouldDelete(k.offer))
+		})
+	}
+}
+
+func TestMakeDeleteStrategyInvalidConfig(t *testing.T) {
+	_, e := makeDeleteStrategy(nil, nil, nil, &deleteConfig{Side: "bids"})
+	assert.Error(t, e)
+
+	_, e = makeDeleteStrategy(nil, nil, nil, &deleteConfig{MinPrice: 2.0, MaxPrice: 1.0})
+	assert.Error(t, e)
+
+	_, e = makeDeleteStrategy(nil, nil, nil, &deleteConfig{MinPrice: -1.0})
+	assert.Error(t, e)
+}
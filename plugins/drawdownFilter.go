@@ -0,0 +1,289 @@
+package plugins
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/kelpdb"
+	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/queries"
+	"github.com/stellar/kelp/support/postgresdb"
+)
+
+// drawdownFilter is a circuit breaker that deletes all offers and halts trading for a cool-down period once the equity falls more than
+// maxDrawdownPercent below the highest equity seen so far (the peak). The equity is the configured capital plus the realized and unrealized
+// P&L of all trades in the trades table, where the net base position is marked to the price from the price feed
+type drawdownFilter struct {
+	name               string
+	configValue        string
+	baseAsset          hProtocol.Asset
+	quoteAsset         hProtocol.Asset
+	pf                 api.PriceFeed
+	maxDrawdownPercent float64
+	cooldown           time.Duration
+	capital            float64
+	db                 *sql.DB
+	marketID           string
+	peakKey            string
+	netTradeFlowsQuery api.Query
+	peakEquityQuery    api.Query
+	alert              api.Alert
+	clock              api.Clock
+
+	// uninitialized
+	peakEquity  *float64
+	haltedUntil *time.Time
+}
+
+// drawdownAlertDetails is sent with the alert when the circuit breaker trips
+type drawdownAlertDetails struct {
+	MarketID           string  `json:"market_id"`
+	Equity             float64 `json:"equity"`
+	PeakEquity         float64 `json:"peak_equity"`
+	Drawdown           float64 `json:"drawdown"`
+	MaxDrawdownPercent float64 `json:"max_drawdown_percent"`
+	HaltedUntil        string  `json:"halted_until"`
+}
+
+// makeFilterDrawdown makes a submit filter that acts as a drawdown circuit breaker based on the P&L of the trades and the value of the price feed
+func makeFilterDrawdown(
+	configValue string,
+	exchangeName string,
+	tradingPair *model.TradingPair,
+	assetDisplayFn model.AssetDisplayFn,
+	baseAsset hProtocol.Asset,
+	quoteAsset hProtocol.Asset,
+	db *sql.DB,
+	alert api.Alert,
+	maxDrawdownPercent float64,
+	cooldown time.Duration,
+	capital float64,
+	priceFeedKey string,
+	pf api.PriceFeed,
+) (SubmitFilter, error) {
+	if maxDrawdownPercent <= 0.0 || maxDrawdownPercent >= 1.0 {
+		return nil, fmt.Errorf("invalid max drawdown percent, expected 0.0 < maxDrawdownPercent < 1.0; was %f", maxDrawdownPercent)
+	}
+	if cooldown <= 0 {
+		return nil, fmt.Errorf("invalid cooldown, expected > 0; was %s", cooldown)
+	}
+	if capital <= 0.0 {
+		return nil, fmt.Errorf("invalid capital, expected > 0.0; was %f", capital)
+	}
+
+	// use assetDisplayFn to make baseAssetString and quoteAssetString because it is issuer independent for non-sdex exchanges keeping a consistent marketID
+	baseAssetString, e := assetDisplayFn(tradingPair.Base)
+	if e != nil {
+		return nil, fmt.Errorf("could not convert base asset (%s) from trading pair via the passed in assetDisplayFn: %s", string(tradingPair.Base), e)
+	}
+	quoteAssetString, e := assetDisplayFn(tradingPair.Quote)
+	if e != nil {
+		return nil, fmt.Errorf("could not convert quote asset (%s) from trading pair via the passed in assetDisplayFn: %s", string(tradingPair.Quote), e)
+	}
+	marketID := MakeMarketID(exchangeName, baseAssetString, quoteAssetString)
+
+	netTradeFlowsQuery, e := queries.MakeNetTradeFlowsForMarketIds(db, []string{marketID}, nil)
+	if e != nil {
+		return nil, fmt.Errorf("could not make net trade flows query: %s", e)
+	}
+	// the peak equity is a high water mark so we persist it in the trailing_stop_marks table, keyed by the capital and price feed because
+	// changing either of them changes the equity
+	peakKey := fmt.Sprintf("drawdown/%f/%s", capital, priceFeedKey)
+	peakEquityQuery, e := queries.MakeTrailingStopHighWaterMark(db, marketID, peakKey)
+	if e != nil {
+		return nil, fmt.Errorf("could not make peak equity query: %s", e)
+	}
+
+	return &drawdownFilter{
+		name:               "drawdownFilter",
+		configValue:        configValue,
+		baseAsset:          baseAsset,
+		quoteAsset:         quoteAsset,
+		pf:                 pf,
+		maxDrawdownPercent: maxDrawdownPercent,
+		cooldown:           cooldown,
+		capital:            capital,
+		db:                 db,
+		marketID:           marketID,
+		peakKey:            peakKey,
+		netTradeFlowsQuery: netTradeFlowsQuery,
+		peakEquityQuery:    peakEquityQuery,
+		alert:              alert,
+		clock:              MakeSystemClock(),
+	}, nil
+}
+
+var _ SubmitFilter = &drawdownFilter{}
+var _ OrderedSubmitFilter = &drawdownFilter{}
+
+// FilterOrder impl.
+func (f *drawdownFilter) FilterOrder() FilterOrder {
+	return FilterOrder{
+		ID:       filterIDDrawdown,
+		Priority: FilterPriorityRisk,
+	}
+}
+
+// Apply impl.
+func (f *drawdownFilter) Apply(ops []txnbuild.Operation, sellingOffers []hProtocol.Offer, buyingOffers []hProtocol.Offer) ([]txnbuild.Operation, error) {
+	now := f.clock.Now()
+	if f.haltedUntil != nil && now.Before(*f.haltedUntil) {
+		log.Printf("drawdownFilter: halted until %s, deleting all offers\n", f.haltedUntil.UTC().Format(time.RFC3339))
+		return f.deleteAllOffers(ops, sellingOffers, buyingOffers)
+	}
+
+	equity, e := f.currentEquity()
+	if e != nil {
+		return nil, fmt.Errorf("could not compute equity: %s", e)
+	}
+	e = f.loadPeakEquity()
+	if e != nil {
+		return nil, fmt.Errorf("could not load peak equity: %s", e)
+	}
+
+	isTripped, isPeakChanged := f.update(now, equity)
+	if isPeakChanged {
+		e = f.savePeakEquity(now)
+		if e != nil {
+			return nil, fmt.Errorf("could not save peak equity: %s", e)
+		}
+	}
+	drawdown := computeDrawdown(*f.peakEquity, equity)
+	log.Printf("drawdownFilter: equity=%.10f, peakEquity=%.10f, drawdown=%.4f, maxDrawdownPercent=%.4f, isTripped=%v\n", equity, *f.peakEquity, drawdown, f.maxDrawdownPercent, isTripped)
+	if !isTripped {
+		return ops, nil
+	}
+
+	description := fmt.Sprintf("drawdownFilter: drawdown of %.2f%% exceeds the max drawdown of %.2f%% on market %s (equity=%.10f, peakEquity=%.10f), deleting all offers and halting trading until %s",
+		drawdown*100, f.maxDrawdownPercent*100, f.marketID, equity, *f.peakEquity, f.haltedUntil.UTC().Format(time.RFC3339))
+	log.Println(description)
+	if f.alert != nil {
+		e = f.alert.Trigger(description, drawdownAlertDetails{
+			MarketID:           f.marketID,
+			Equity:             equity,
+			PeakEquity:         *f.peakEquity,
+			Drawdown:           drawdown,
+			MaxDrawdownPercent: f.maxDrawdownPercent,
+			HaltedUntil:        f.haltedUntil.UTC().Format(time.RFC3339),
+		})
+		if e != nil {
+			log.Printf("drawdownFilter: unable to trigger alert: %s\n", e)
+		}
+	}
+	return f.deleteAllOffers(ops, sellingOffers, buyingOffers)
+}
+
+// update advances the state of the circuit breaker with the equity at time now. It returns whether the circuit breaker tripped and whether
+// the peak equity changed. Once the cool-down period is over the peak is reset to the current equity so trading resumes from a new baseline
+func (f *drawdownFilter) update(now time.Time, equity float64) (bool /* isTripped */, bool /* isPeakChanged */) {
+	if f.haltedUntil != nil {
+		log.Printf("drawdownFilter: cool-down period ended at %s, resetting peak equity to the current equity %.10f\n", f.haltedUntil.UTC().Format(time.RFC3339), equity)
+		f.haltedUntil = nil
+		f.peakEquity = &equity
+		return false, true
+	}
+
+	isPeakChanged := false
+	if f.peakEquity == nil || equity > *f.peakEquity {
+		f.peakEquity = &equity
+		isPeakChanged = true
+	}
+
+	if computeDrawdown(*f.peakEquity, equity) < f.maxDrawdownPercent {
+		return false, isPeakChanged
+	}
+	haltedUntil := now.Add(f.cooldown)
+	f.haltedUntil = &haltedUntil
+	return true, isPeakChanged
+}
+
+// computeDrawdown returns the fraction by which the equity is below the peak equity, a non-positive peak counts as a full drawdown
+func computeDrawdown(peakEquity float64, equity float64) float64 {
+	if peakEquity <= 0.0 {
+		return 1.0
+	}
+	if equity >= peakEquity {
+		return 0.0
+	}
+	return (peakEquity - equity) / peakEquity
+}
+
+// currentEquity returns the capital plus the realized and unrealized P&L of all trades, in units of the quote asset
+func (f *drawdownFilter) currentEquity() (float64, error) {
+	price, e := f.pf.GetPrice()
+	if e != nil {
+		return 0, fmt.Errorf("could not get price from priceFeed: %s", e)
+	}
+	if price <= 0.0 {
+		return 0, fmt.Errorf("invalid price from priceFeed, expected > 0.0; was %f", price)
+	}
+
+	queryResult, e := f.netTradeFlowsQuery.QueryRow()
+	if e != nil {
+		return 0, fmt.Errorf("could not load net trade flows: %s", e)
+	}
+	flows, ok := queryResult.(*queries.NetTradeFlows)
+	if !ok {
+		return 0, fmt.Errorf("incorrect type returned from NetTradeFlows query, expecting '*queries.NetTradeFlows' but was '%T'", queryResult)
+	}
+	return f.capital + flows.PnL(price), nil
+}
+
+// loadPeakEquity loads the peak equity from the db on the first call
+func (f *drawdownFilter) loadPeakEquity() error {
+	if f.peakEquity != nil {
+		return nil
+	}
+
+	result, e := f.peakEquityQuery.QueryRow()
+	if e != nil {
+		return fmt.Errorf("could not load peak equity from db: %s", e)
+	}
+	mark, ok := result.(*queries.TrailingStopMark)
+	if !ok {
+		return fmt.Errorf("incorrect type returned from TrailingStopHighWaterMark query, expecting '*queries.TrailingStopMark' but was '%T'", result)
+	}
+	if mark != nil {
+		log.Printf("drawdownFilter: loaded peak equity from db (market_id=%s, key=%s): %.10f\n", f.marketID, f.peakKey, mark.HighWaterMark)
+		peakEquity := mark.HighWaterMark
+		f.peakEquity = &peakEquity
+	}
+	return nil
+}
+
+func (f *drawdownFilter) savePeakEquity(now time.Time) error {
+	sqlUpsert := fmt.Sprintf(kelpdb.SqlTrailingStopMarksUpsertTemplate,
+		f.marketID,
+		f.peakKey,
+		*f.peakEquity,
+		now.UTC().Format(postgresdb.TimestampFormatString),
+	)
+	_, e := f.db.Exec(sqlUpsert)
+	if e != nil {
+		return fmt.Errorf("could not execute sql upsert statement (%s): %s", sqlUpsert, e)
+	}
+	return nil
+}
+
+// deleteAllOffers drops all ops and deletes all existing offers
+func (f *drawdownFilter) deleteAllOffers(ops []txnbuild.Operation, sellingOffers []hProtocol.Offer, buyingOffers []hProtocol.Offer) ([]txnbuild.Operation, error) {
+	ops, e := filterOps(f.name, f.baseAsset, f.quoteAsset, sellingOffers, buyingOffers, ops, f.drawdownFilterFn)
+	if e != nil {
+		return nil, fmt.Errorf("could not apply filter: %s", e)
+	}
+	return ops, nil
+}
+
+func (f *drawdownFilter) drawdownFilterFn(op *txnbuild.ManageSellOffer) (*txnbuild.ManageSellOffer, error) {
+	return nil, nil
+}
+
+// String is the Stringer method
+func (f *drawdownFilter) String() string {
+	return f.configValue
+}
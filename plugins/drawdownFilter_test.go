@@ -0,0 +1,129 @@
+package plugins
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/queries"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// fixedNetTradeFlowsQuery is a NetTradeFlows query that returns fixed values without a db
+type fixedNetTradeFlowsQuery struct {
+	flows queries.NetTradeFlows
+}
+
+var _ api.Query = &fixedNetTradeFlowsQuery{}
+
+// Name impl.
+func (q *fixedNetTradeFlowsQuery) Name() string {
+	return "fixedNetTradeFlowsQuery"
+}
+
+// QueryRow impl.
+func (q *fixedNetTradeFlowsQuery) QueryRow(args ...interface{}) (interface{}, error) {
+	flows := q.flows
+	return &flows, nil
+}
+
+func TestComputeDrawdown(t *testing.T) {
+	testCases := []struct {
+		peakEquity float64
+		equity     float64
+		want       float64
+	}{
+		{peakEquity: 100.0, equity: 100.0, want: 0.0},
+		{peakEquity: 100.0, equity: 110.0, want: 0.0},
+		{peakEquity: 100.0, equity: 90.0, want: 0.1},
+		{peakEquity: 100.0, equity: -10.0, want: 1.1},
+		{peakEquity: 0.0, equity: 10.0, want: 1.0},
+	}
+
+	for _, k := range testCases {
+		assert.InDelta(t, k.want, computeDrawdown(k.peakEquity, k.equity), 0.0000001)
+	}
+}
+
+func TestDrawdownFilterUpdate(t *testing.T) {
+	start := time.Date(2020, 5, 21, 15, 0, 0, 0, time.UTC)
+	f := &drawdownFilter{
+		maxDrawdownPercent: 0.10,
+		cooldown:           time.Hour,
+	}
+
+	// the first equity becomes the peak
+	isTripped, isPeakChanged := f.update(start, 1000.0)
+	assert.False(t, isTripped)
+	assert.True(t, isPeakChanged)
+
+	// a new peak
+	isTripped, isPeakChanged = f.update(start.Add(time.Minute), 1100.0)
+	assert.False(t, isTripped)
+	assert.True(t, isPeakChanged)
+	assert.Equal(t, 1100.0, *f.peakEquity)
+
+	// within the max drawdown
+	isTripped, isPeakChanged = f.update(start.Add(2*time.Minute), 1000.0)
+	assert.False(t, isTripped)
+	assert.False(t, isPeakChanged)
+
+	// exceeds the max drawdown of 10% below 1100
+	isTripped, isPeakChanged = f.update(start.Add(3*time.Minute), 980.0)
+	assert.True(t, isTripped)
+	assert.False(t, isPeakChanged)
+	assert.Equal(t, start.Add(63*time.Minute), *f.haltedUntil)
+
+	// the first update after the cool-down period resets the peak
+	isTripped, isPeakChanged = f.update(start.Add(63*time.Minute), 950.0)
+	assert.False(t, isTripped)
+	assert.True(t, isPeakChanged)
+	assert.Nil(t, f.haltedUntil)
+	assert.Equal(t, 950.0, *f.peakEquity)
+}
+
+func TestDrawdownFilterApply(t *testing.T) {
+	clock := MakeManualClock(time.Date(2020, 5, 21, 15, 0, 0, 0, time.UTC))
+	alert := &countingAlert{}
+	peakEquity := 1100.0
+	f := &drawdownFilter{
+		name:               "drawdownFilter",
+		baseAsset:          utils.Asset2Asset2(testBaseAsset),
+		quoteAsset:         utils.Asset2Asset2(testQuoteAsset),
+		pf:                 &fixedFeed{price: 0.5},
+		maxDrawdownPercent: 0.10,
+		cooldown:           time.Hour,
+		capital:            1000.0,
+		// holding 60 base worth 30 quote after spending 100 quote gives an equity of 1000 - 100 + 30 = 930
+		netTradeFlowsQuery: &fixedNetTradeFlowsQuery{flows: queries.NetTradeFlows{BaseVolume: 60.0, QuoteVolume: -100.0}},
+		alert:              alert,
+		clock:              clock,
+		peakEquity:         &peakEquity,
+	}
+	ops := []txnbuild.Operation{
+		&txnbuild.ManageSellOffer{Selling: testBaseAsset, Buying: testQuoteAsset, Amount: "10.0", Price: "2.0"},
+	}
+	sellingOffers := []hProtocol.Offer{}
+	buyingOffers := []hProtocol.Offer{}
+
+	actual, e := f.Apply(ops, sellingOffers, buyingOffers)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, 0, len(actual))
+	assert.Equal(t, 1, alert.numTriggers)
+
+	// still halted during the cool-down period even if the equity recovers, without triggering another alert
+	f.netTradeFlowsQuery = &fixedNetTradeFlowsQuery{flows: queries.NetTradeFlows{}}
+	clock.Advance(30 * time.Minute)
+	actual, e = f.Apply(ops, sellingOffers, buyingOffers)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, 0, len(actual))
+	assert.Equal(t, 1, alert.numTriggers)
+}
@@ -3,69 +3,354 @@ package plugins
 import (
 	"fmt"
 	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/stellar/kelp/api"
 	"github.com/stellar/kelp/model"
 )
 
+// obiModifierPrefix is the modifier for the price adjusted by the orderbook imbalance, which can take the depth as a suffix, e.g. "obi:10"
+const obiModifierPrefix = "obi"
+
+// defaultObiDepth is the number of levels on each side of the orderbook used for the "obi" modifier when the depth is not specified
+const defaultObiDepth = 5
+
+// bookVwapModifierPrefix is the modifier for the volume-weighted average price of the resting orders in the orderbook, which can take the
+// depth as a suffix, e.g. "book-vwap-10". It is not the VWAP of the traded volume
+const bookVwapModifierPrefix = "book-vwap"
+
+// defaultBookVwapDepth is the number of levels on each side of the orderbook used for the "book-vwap" modifier when the depth is not specified
+const defaultBookVwapDepth = 5
+
+// ExchangeFeedSource is the market data that a modifier of an exchange feed can use to compute its price
+type ExchangeFeedSource struct {
+	Name             string
+	Pair             *model.TradingPair
+	TickerAPI        api.TickerAPI
+	OrderbookFetcher api.OrderbookFetcher // nil for feeds that only have a ticker, such as stream feeds
+}
+
+// ExchangeFeedModifier computes the price of an exchange feed and the time of the price
+type ExchangeFeedModifier func(src *ExchangeFeedSource) (float64, time.Time, error)
+
+// ExchangeFeedModifierFactory makes the modifier from its full text in the URL, such as "book-vwap-10", so it can parse its own arguments, and
+// from the source of the feed so it can reject a source that does not have the market data it needs
+type ExchangeFeedModifierFactory func(modifier string, src *ExchangeFeedSource) (ExchangeFeedModifier, error)
+
+var exchangeFeedModifiersLock = &sync.Mutex{}
+
+// exchangeFeedModifiers are the modifiers of exchange feeds keyed by their name, modifiers that take an argument are written as
+// <name>:<arg> or <name>-<arg>
+var exchangeFeedModifiers = map[string]ExchangeFeedModifierFactory{
+	"mid":  makeTickerModifier("mid"),
+	"ask":  makeTickerModifier("ask"),
+	"bid":  makeTickerModifier("bid"),
+	"last": makeTickerModifier("last"),
+	obiModifierPrefix: func(modifier string, src *ExchangeFeedSource) (ExchangeFeedModifier, error) {
+		depth, e := parseObiDepth(modifier)
+		if e != nil {
+			return nil, e
+		}
+		e = src.requireOrderbook()
+		if e != nil {
+			return nil, e
+		}
+		return makeObiModifier(obiModifierPrefix, depth), nil
+	},
+	// the mid price weighted by the volume at the top of the book on the opposite side, which is the orderbook imbalance price at a depth of 1
+	"weighted-mid": func(modifier string, src *ExchangeFeedSource) (ExchangeFeedModifier, error) {
+		e := src.requireOrderbook()
+		if e != nil {
+			return nil, e
+		}
+		return makeObiModifier("weighted-mid", 1), nil
+	},
+	bookVwapModifierPrefix: func(modifier string, src *ExchangeFeedSource) (ExchangeFeedModifier, error) {
+		depth, e := parseModifierDepth(modifier, bookVwapModifierPrefix, defaultBookVwapDepth)
+		if e != nil {
+			return nil, e
+		}
+		e = src.requireOrderbook()
+		if e != nil {
+			return nil, e
+		}
+		return makeBookVwapModifier(depth), nil
+	},
+}
+
+// RegisterExchangeFeedModifier adds a modifier that can be used in the URL of exchange feeds, the argument of a modifier is separated from
+// its name by the last ':' or '-' so the name can contain them too
+func RegisterExchangeFeedModifier(name string, factory ExchangeFeedModifierFactory) error {
+	exchangeFeedModifiersLock.Lock()
+	defer exchangeFeedModifiersLock.Unlock()
+
+	if _, ok := exchangeFeedModifiers[name]; ok {
+		return fmt.Errorf("exchange feed modifier '%s' is already registered", name)
+	}
+	exchangeFeedModifiers[name] = factory
+	return nil
+}
+
+// makeExchangeFeedModifier looks up the factory of the modifier by its full text first and then by the longest name before a ':' or '-'
+func makeExchangeFeedModifier(modifier string, src *ExchangeFeedSource) (ExchangeFeedModifier, error) {
+	exchangeFeedModifiersLock.Lock()
+	factory, ok := exchangeFeedModifiers[modifier]
+	for name := modifier; !ok; {
+		i := strings.LastIndexAny(name, ":-")
+		if i <= 0 {
+			break
+		}
+		name = name[:i]
+		factory, ok = exchangeFeedModifiers[name]
+	}
+	exchangeFeedModifiersLock.Unlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unsupported modifier '%s' on exchange type URL", modifier)
+	}
+	modifierFn, e := factory(modifier, src)
+	if e != nil {
+		return nil, fmt.Errorf("invalid modifier '%s' on exchange type URL: %s", modifier, e)
+	}
+	return modifierFn, nil
+}
+
 // encapsulates a priceFeed from a tickerAPI
 type exchangeFeed struct {
-	name      string
-	tickerAPI *api.TickerAPI
-	pairs     []model.TradingPair
-	modifier  string
+	source     *ExchangeFeedSource
+	modifier   string
+	modifierFn ExchangeFeedModifier
 }
 
-// ensure that it implements PriceFeed
-var _ api.PriceFeed = &exchangeFeed{}
+// ensure that it implements TimestampedPriceFeed
+var _ api.TimestampedPriceFeed = &exchangeFeed{}
 
-func newExchangeFeed(name string, tickerAPI *api.TickerAPI, pair *model.TradingPair, modifier string) (*exchangeFeed, error) {
-	if modifier != "mid" && modifier != "ask" && modifier != "bid" && modifier != "last" {
-		return nil, fmt.Errorf("unsupported modifier '%s' on exchange type URL", modifier)
+func newExchangeFeed(name string, tickerAPI *api.TickerAPI, orderbookFetcher api.OrderbookFetcher, pair *model.TradingPair, modifier string) (*exchangeFeed, error) {
+	source := &ExchangeFeedSource{
+		Name:             name,
+		Pair:             pair,
+		TickerAPI:        *tickerAPI,
+		OrderbookFetcher: orderbookFetcher,
+	}
+	modifierFn, e := makeExchangeFeedModifier(modifier, source)
+	if e != nil {
+		return nil, e
 	}
 
 	return &exchangeFeed{
-		name:      name,
-		tickerAPI: tickerAPI,
-		pairs:     []model.TradingPair{*pair},
-		modifier:  modifier,
+		source:     source,
+		modifier:   modifier,
+		modifierFn: modifierFn,
 	}, nil
 }
 
+// parseObiDepth parses the depth from a modifier of the form "obi" or "obi:<depth>"
+func parseObiDepth(modifier string) (int32, error) {
+	return parseModifierDepth(modifier, obiModifierPrefix, defaultObiDepth)
+}
+
+// parseModifierDepth parses the depth from a modifier of the form "<name>", "<name>:<depth>", or "<name>-<depth>"
+func parseModifierDepth(modifier string, name string, defaultDepth int32) (int32, error) {
+	if modifier == name {
+		return defaultDepth, nil
+	}
+	if len(modifier) <= len(name)+1 || !strings.HasPrefix(modifier, name) || !strings.ContainsAny(modifier[len(name):len(name)+1], ":-") {
+		return 0, fmt.Errorf("needs to be of the form '%s' or '%s:<depth>'", name, name)
+	}
+	depthString := modifier[len(name)+1:]
+	depth, e := strconv.ParseInt(depthString, 10, 32)
+	if e != nil {
+		return 0, fmt.Errorf("could not parse depth '%s' as an integer: %s", depthString, e)
+	}
+	if depth <= 0 {
+		return 0, fmt.Errorf("depth needs to be > 0 but was %d", depth)
+	}
+	return int32(depth), nil
+}
+
 // GetPrice impl
 func (f *exchangeFeed) GetPrice() (float64, error) {
-	tickerAPI := *f.tickerAPI
-	m, e := tickerAPI.GetTickerPrice(f.pairs)
+	price, _, e := f.GetPriceWithTimestamp()
+	return price, e
+}
+
+// GetPriceWithTimestamp impl, the timestamp is the time of the ticker when the exchange returns it and is the current time otherwise
+func (f *exchangeFeed) GetPriceWithTimestamp() (float64, time.Time, error) {
+	return f.modifierFn(f.source)
+}
+
+// makeTickerModifier makes a modifier that reads the price from the ticker, which is one of mid, ask, bid, or last
+func makeTickerModifier(modifier string) ExchangeFeedModifierFactory {
+	return func(string, *ExchangeFeedSource) (ExchangeFeedModifier, error) {
+		return func(src *ExchangeFeedSource) (float64, time.Time, error) {
+			m, e := src.TickerAPI.GetTickerPrice([]model.TradingPair{*src.Pair})
+			if e != nil {
+				return 0, time.Time{}, fmt.Errorf("error while getting price from exchange feed: %s", e)
+			}
+
+			p, ok := m[*src.Pair]
+			if !ok {
+				return 0, time.Time{}, fmt.Errorf("could not get price for trading pair: %s", src.Pair.String())
+			}
+			ts := time.Now()
+			if p.Timestamp != nil {
+				ts = time.Unix(0, p.Timestamp.AsInt64()*int64(time.Millisecond))
+			}
+
+			midPrice := p.BidPrice.Add(*p.AskPrice).Scale(0.5)
+			var price *model.Number
+			if modifier == "ask" {
+				price = p.AskPrice
+			} else if modifier == "bid" {
+				price = p.BidPrice
+			} else if modifier == "last" {
+				price = p.LastPrice
+			} else {
+				// LOH-2 - support backward-compatible case of defaulting to "mid" price when left unspecified
+				price = midPrice
+			}
+
+			log.Printf("(modifier: %s) price from exchange feed (%s): bidPrice=%s, askPrice=%s, midPrice=%s, lastTradePrice=%s; price=%s",
+				modifier,
+				src.Name,
+				p.BidPrice.AsString(),
+				p.AskPrice.AsString(),
+				midPrice.AsString(),
+				p.LastPrice.AsString(),
+				price.AsString(),
+			)
+			return price.AsFloat(), ts, nil
+		}, nil
+	}
+}
+
+// requireOrderbook is called by the factories of modifiers that need more than the ticker, so feeds that cannot fetch the orderbook (such as
+// stream feeds) are rejected when they are made instead of failing to get every price
+func (src *ExchangeFeedSource) requireOrderbook() error {
+	if src.OrderbookFetcher == nil {
+		return fmt.Errorf("exchange feed (%s) cannot fetch the orderbook", src.Name)
+	}
+	return nil
+}
+
+// getOrderBook fetches the orderbook for modifiers that need more than the ticker
+func (src *ExchangeFeedSource) getOrderBook(depth int32) (*model.OrderBook, error) {
+	ob, e := src.OrderbookFetcher.GetOrderBook(src.Pair, depth)
 	if e != nil {
-		return 0, fmt.Errorf("error while getting price from exchange feed: %s", e)
+		return nil, fmt.Errorf("error while getting orderbook from exchange feed: %s", e)
 	}
+	return ob, nil
+}
 
-	p, ok := m[f.pairs[0]]
-	if !ok {
-		return 0, fmt.Errorf("could not get price for trading pair: %s", f.pairs[0].String())
-	}
-
-	midPrice := p.BidPrice.Add(*p.AskPrice).Scale(0.5)
-	var price *model.Number
-	if f.modifier == "ask" {
-		price = p.AskPrice
-	} else if f.modifier == "bid" {
-		price = p.BidPrice
-	} else if f.modifier == "last" {
-		price = p.LastPrice
-	} else {
-		// LOH-2 - support backward-compatible case of defaulting to "mid" price when left unspecified
-		price = midPrice
-	}
-
-	log.Printf("(modifier: %s) price from exchange feed (%s): bidPrice=%s, askPrice=%s, midPrice=%s, lastTradePrice=%s; price=%s",
-		f.modifier,
-		f.name,
-		p.BidPrice.AsString(),
-		p.AskPrice.AsString(),
-		midPrice.AsString(),
-		p.LastPrice.AsString(),
-		price.AsString(),
-	)
-	return price.AsFloat(), nil
+// makeObiModifier makes a modifier that computes the price adjusted by the orderbook imbalance at the depth
+func makeObiModifier(modifier string, depth int32) ExchangeFeedModifier {
+	return func(src *ExchangeFeedSource) (float64, time.Time, error) {
+		ob, e := src.getOrderBook(depth)
+		if e != nil {
+			return 0, time.Time{}, e
+		}
+
+		price, imbalance, e := computeObiPrice(ob, depth)
+		if e != nil {
+			return 0, time.Time{}, fmt.Errorf("could not compute orderbook imbalance price for trading pair %s: %s", src.Pair.String(), e)
+		}
+
+		log.Printf("(modifier: %s, depth: %d) price from exchange feed (%s): bidPrice=%s, askPrice=%s, imbalance=%.4f; price=%.8f",
+			modifier,
+			depth,
+			src.Name,
+			ob.TopBid().Price.AsString(),
+			ob.TopAsk().Price.AsString(),
+			imbalance,
+			price,
+		)
+		return price, time.Now(), nil
+	}
+}
+
+// makeBookVwapModifier makes a modifier that computes the volume-weighted average price of the orders in the top depth levels of both sides
+func makeBookVwapModifier(depth int32) ExchangeFeedModifier {
+	return func(src *ExchangeFeedSource) (float64, time.Time, error) {
+		ob, e := src.getOrderBook(depth)
+		if e != nil {
+			return 0, time.Time{}, e
+		}
+
+		price, e := computeBookVwapPrice(ob, depth)
+		if e != nil {
+			return 0, time.Time{}, fmt.Errorf("could not compute volume-weighted average price of the orderbook for trading pair %s: %s", src.Pair.String(), e)
+		}
+
+		log.Printf("(modifier: %s, depth: %d) price from exchange feed (%s): bidPrice=%s, askPrice=%s; price=%.8f",
+			bookVwapModifierPrefix,
+			depth,
+			src.Name,
+			ob.TopBid().Price.AsString(),
+			ob.TopAsk().Price.AsString(),
+			price,
+		)
+		return price, time.Now(), nil
+	}
+}
+
+// computeBookVwapPrice computes the volume-weighted average price of the resting orders in the top depth levels on each side of the orderbook.
+// The price moves towards the side with more volume, which is the opposite of the orderbook imbalance price (computeObiPrice) that moves
+// away from it, and it does not include any traded volume
+func computeBookVwapPrice(ob *model.OrderBook, depth int32) (float64, error) {
+	if ob.TopBid() == nil || ob.TopAsk() == nil {
+		return 0, fmt.Errorf("orderbook needs to have both bids and asks")
+	}
+
+	totalVolume := 0.0
+	totalQuote := 0.0
+	for _, orders := range [][]model.Order{ob.Bids(), ob.Asks()} {
+		for i, o := range orders {
+			if int32(i) >= depth {
+				break
+			}
+			totalVolume += o.Volume.AsFloat()
+			totalQuote += o.Volume.AsFloat() * o.Price.AsFloat()
+		}
+	}
+	if totalVolume == 0 {
+		return 0, fmt.Errorf("orderbook has no volume in the top %d levels", depth)
+	}
+	return totalQuote / totalVolume, nil
+}
+
+// computeObiPrice computes the mid price adjusted by the imbalance of the volume in the top depth levels on each side of the orderbook.
+// The imbalance is (bidVolume - askVolume) / (bidVolume + askVolume) which is in the range [-1, 1], and the price is shifted from the mid
+// price by imbalance * halfSpread so it moves towards the ask when the bids are heavier and towards the bid when the asks are heavier.
+// This is the same as weighting the top bid and ask prices by the volume on the opposite side (also known as the micro-price).
+func computeObiPrice(ob *model.OrderBook, depth int32) (float64, float64, error) {
+	if ob.TopBid() == nil || ob.TopAsk() == nil {
+		return 0, 0, fmt.Errorf("orderbook needs to have both bids and asks")
+	}
+
+	bidVolume := sumVolume(ob.Bids(), depth)
+	askVolume := sumVolume(ob.Asks(), depth)
+	bidPrice := ob.TopBid().Price.AsFloat()
+	askPrice := ob.TopAsk().Price.AsFloat()
+	midPrice := (bidPrice + askPrice) / 2
+	if bidVolume+askVolume == 0 {
+		return midPrice, 0, nil
+	}
+
+	imbalance := (bidVolume - askVolume) / (bidVolume + askVolume)
+	return midPrice + imbalance*(askPrice-bidPrice)/2, imbalance, nil
+}
+
+// sumVolume sums the volume of the first depth orders
+func sumVolume(orders []model.Order, depth int32) float64 {
+	total := 0.0
+	for i, o := range orders {
+		if int32(i) >= depth {
+			break
+		}
+		total += o.Volume.AsFloat()
+	}
+	return total
 }
@@ -0,0 +1,179 @@
+package plugins
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func makeTestObiOrders(action model.OrderAction, prices []float64, volumes []float64) []model.Order {
+	orders := []model.Order{}
+	for i := range prices {
+		orders = append(orders, model.Order{
+			OrderAction: action,
+			Price:       model.NumberFromFloat(prices[i], 7),
+			Volume:      model.NumberFromFloat(volumes[i], 7),
+		})
+	}
+	return orders
+}
+
+func TestComputeObiPrice(t *testing.T) {
+	pair := model.MakeTradingPair(model.XLM, model.USDT)
+	testCases := []struct {
+		name          string
+		bidVolumes    []float64
+		askVolumes    []float64
+		depth         int32
+		wantPrice     float64
+		wantImbalance float64
+	}{
+		{
+			name:          "balanced",
+			bidVolumes:    []float64{10, 10},
+			askVolumes:    []float64{10, 10},
+			depth:         2,
+			wantPrice:     1.0,
+			wantImbalance: 0.0,
+		}, {
+			name:          "heavier bids",
+			bidVolumes:    []float64{30, 30},
+			askVolumes:    []float64{10, 10},
+			depth:         2,
+			wantPrice:     1.005,
+			wantImbalance: 0.5,
+		}, {
+			name:          "heavier asks",
+			bidVolumes:    []float64{10, 10},
+			askVolumes:    []float64{30, 30},
+			depth:         2,
+			wantPrice:     0.995,
+			wantImbalance: -0.5,
+		}, {
+			// only the first level is counted so the bids are heavier
+			name:          "depth limits levels",
+			bidVolumes:    []float64{30, 0},
+			askVolumes:    []float64{10, 50},
+			depth:         1,
+			wantPrice:     1.005,
+			wantImbalance: 0.5,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			ob := model.MakeOrderBook(
+				pair,
+				makeTestObiOrders(model.OrderActionSell, []float64{1.01, 1.02}, k.askVolumes),
+				makeTestObiOrders(model.OrderActionBuy, []float64{0.99, 0.98}, k.bidVolumes),
+			)
+			price, imbalance, e := computeObiPrice(ob, k.depth)
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.InDelta(t, k.wantPrice, price, 0.0000001)
+			assert.InDelta(t, k.wantImbalance, imbalance, 0.0000001)
+		})
+	}
+
+	_, _, e := computeObiPrice(model.MakeOrderBook(pair, []model.Order{}, makeTestObiOrders(model.OrderActionBuy, []float64{0.99}, []float64{1})), 5)
+	assert.Error(t, e)
+}
+
+func TestParseObiDepth(t *testing.T) {
+	depth, e := parseObiDepth("obi")
+	if assert.NoError(t, e) {
+		assert.Equal(t, int32(defaultObiDepth), depth)
+	}
+	depth, e = parseObiDepth("obi:10")
+	if assert.NoError(t, e) {
+		assert.Equal(t, int32(10), depth)
+	}
+	_, e = parseObiDepth("obi:0")
+	assert.Error(t, e)
+	_, e = parseObiDepth("obi:abc")
+	assert.Error(t, e)
+	_, e = parseObiDepth("obi:1:2")
+	assert.Error(t, e)
+}
+
+func TestComputeBookVwapPrice(t *testing.T) {
+	pair := model.MakeTradingPair(model.XLM, model.USDT)
+	ob := model.MakeOrderBook(
+		pair,
+		makeTestObiOrders(model.OrderActionSell, []float64{1.01, 1.02}, []float64{10, 30}),
+		makeTestObiOrders(model.OrderActionBuy, []float64{0.99, 0.98}, []float64{30, 10}),
+	)
+
+	price, e := computeBookVwapPrice(ob, 1)
+	if assert.NoError(t, e) {
+		assert.InDelta(t, (1.01*10+0.99*30)/40, price, 0.0000001)
+	}
+	price, e = computeBookVwapPrice(ob, 2)
+	if assert.NoError(t, e) {
+		assert.InDelta(t, (1.01*10+1.02*30+0.99*30+0.98*10)/80, price, 0.0000001)
+	}
+
+	_, e = computeBookVwapPrice(model.MakeOrderBook(pair, []model.Order{}, makeTestObiOrders(model.OrderActionBuy, []float64{0.99}, []float64{1})), 5)
+	assert.Error(t, e)
+}
+
+// testOrderbookFetcher returns an empty orderbook
+type testOrderbookFetcher struct{}
+
+// GetOrderBook impl
+func (f testOrderbookFetcher) GetOrderBook(pair *model.TradingPair, maxCount int32) (*model.OrderBook, error) {
+	return model.MakeOrderBook(pair, []model.Order{}, []model.Order{}), nil
+}
+
+func TestMakeExchangeFeedModifier(t *testing.T) {
+	src := &ExchangeFeedSource{Name: "test", OrderbookFetcher: testOrderbookFetcher{}}
+	for _, modifier := range []string{"mid", "ask", "bid", "last", "obi", "obi:10", "weighted-mid", "book-vwap", "book-vwap-10", "book-vwap:3"} {
+		_, e := makeExchangeFeedModifier(modifier, src)
+		assert.NoError(t, e, modifier)
+	}
+	for _, modifier := range []string{"", "unknown", "vwap", "vwap-10", "book-vwap-0", "book-vwap-abc", "obi:1:2", "midpoint"} {
+		_, e := makeExchangeFeedModifier(modifier, src)
+		assert.Error(t, e, modifier)
+	}
+
+	// feeds that cannot fetch the orderbook, such as stream feeds, are rejected when the modifier needs it
+	tickerOnlySrc := &ExchangeFeedSource{Name: "test"}
+	for _, modifier := range []string{"mid", "ask", "bid", "last"} {
+		_, e := makeExchangeFeedModifier(modifier, tickerOnlySrc)
+		assert.NoError(t, e, modifier)
+	}
+	for _, modifier := range []string{"obi", "obi:10", "weighted-mid", "book-vwap", "book-vwap-10"} {
+		_, e := makeExchangeFeedModifier(modifier, tickerOnlySrc)
+		assert.Error(t, e, modifier)
+	}
+
+	e := RegisterExchangeFeedModifier("test-fixed", func(modifier string, src *ExchangeFeedSource) (ExchangeFeedModifier, error) {
+		return func(src *ExchangeFeedSource) (float64, time.Time, error) {
+			return 1.5, time.Unix(100, 0), nil
+		}, nil
+	})
+	if !assert.NoError(t, e) {
+		return
+	}
+	defer func() {
+		exchangeFeedModifiersLock.Lock()
+		delete(exchangeFeedModifiers, "test-fixed")
+		exchangeFeedModifiersLock.Unlock()
+	}()
+	assert.Error(t, RegisterExchangeFeedModifier("book-vwap", nil))
+
+	var tickerAPI api.TickerAPI
+	feed, e := newExchangeFeed("test", &tickerAPI, nil, &model.TradingPair{Base: model.XLM, Quote: model.USDT}, "test-fixed")
+	if !assert.NoError(t, e) {
+		return
+	}
+	price, ts, e := feed.GetPriceWithTimestamp()
+	if assert.NoError(t, e) {
+		assert.Equal(t, 1.5, price)
+		assert.Equal(t, time.Unix(100, 0), ts)
+	}
+}
@@ -0,0 +1,171 @@
+package plugins
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strconv"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/queries"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// exposureFilter caps the cumulative net position in the base asset, computed from all trades in the trades table. Sells are limited once
+// the short exposure would exceed maxShortBase and buys are limited once the long exposure would exceed maxLongBase, independent of any
+// volume caps on the amount traded in a window
+type exposureFilter struct {
+	name                 string
+	configValue          string
+	baseAsset            hProtocol.Asset
+	quoteAsset           hProtocol.Asset
+	maxLongBase          float64
+	maxShortBase         float64
+	netBasePositionQuery api.Query
+}
+
+// makeFilterExposure makes a submit filter that limits the net position in the base asset to [-maxShortBase, maxLongBase]
+func makeFilterExposure(
+	configValue string,
+	exchangeName string,
+	tradingPair *model.TradingPair,
+	assetDisplayFn model.AssetDisplayFn,
+	baseAsset hProtocol.Asset,
+	quoteAsset hProtocol.Asset,
+	db *sql.DB,
+	maxLongBase float64,
+	maxShortBase float64,
+	additionalMarketIDs []string,
+	optionalAccountIDs []string,
+) (SubmitFilter, error) {
+	if maxLongBase < 0.0 || maxShortBase < 0.0 {
+		return nil, fmt.Errorf("invalid exposure caps, expected maxLongBase >= 0.0 and maxShortBase >= 0.0; was maxLongBase=%f, maxShortBase=%f", maxLongBase, maxShortBase)
+	}
+
+	// use assetDisplayFn to make baseAssetString and quoteAssetString because it is issuer independent for non-sdex exchanges keeping a consistent marketID
+	baseAssetString, e := assetDisplayFn(tradingPair.Base)
+	if e != nil {
+		return nil, fmt.Errorf("could not convert base asset (%s) from trading pair via the passed in assetDisplayFn: %s", string(tradingPair.Base), e)
+	}
+	quoteAssetString, e := assetDisplayFn(tradingPair.Quote)
+	if e != nil {
+		return nil, fmt.Errorf("could not convert quote asset (%s) from trading pair via the passed in assetDisplayFn: %s", string(tradingPair.Quote), e)
+	}
+
+	marketID := MakeMarketID(exchangeName, baseAssetString, quoteAssetString)
+	// note that append(s, nil) is valid
+	marketIDs := utils.Dedupe(append([]string{marketID}, additionalMarketIDs...))
+	netBasePositionQuery, e := queries.MakeNetBasePositionForMarketIds(db, marketIDs, optionalAccountIDs)
+	if e != nil {
+		return nil, fmt.Errorf("could not make net base position Query: %s", e)
+	}
+
+	return &exposureFilter{
+		name:                 "exposureFilter",
+		configValue:          configValue,
+		baseAsset:            baseAsset,
+		quoteAsset:           quoteAsset,
+		maxLongBase:          maxLongBase,
+		maxShortBase:         maxShortBase,
+		netBasePositionQuery: netBasePositionQuery,
+	}, nil
+}
+
+var _ SubmitFilter = &exposureFilter{}
+var _ OrderedSubmitFilter = &exposureFilter{}
+
+// FilterOrder impl.
+func (f *exposureFilter) FilterOrder() FilterOrder {
+	return FilterOrder{
+		ID:       filterIDExposure,
+		Priority: FilterPriorityAmount,
+		After:    priceAndOrderSizeFilterIDs,
+	}
+}
+
+// Apply impl.
+func (f *exposureFilter) Apply(ops []txnbuild.Operation, sellingOffers []hProtocol.Offer, buyingOffers []hProtocol.Offer) ([]txnbuild.Operation, error) {
+	queryResult, e := f.netBasePositionQuery.QueryRow()
+	if e != nil {
+		return nil, fmt.Errorf("could not load net base position: %s", e)
+	}
+	netBasePosition, ok := queryResult.(*float64)
+	if !ok {
+		return nil, fmt.Errorf("incorrect type returned from NetBasePosition query, expecting '*float64' but was '%T'", queryResult)
+	}
+	log.Printf("exposureFilter: netBasePosition=%.8f %s, maxLongBase=%.8f, maxShortBase=%.8f\n", *netBasePosition, utils.Asset2String(f.baseAsset), f.maxLongBase, f.maxShortBase)
+
+	// to-be-booked amounts of the base asset start out as empty and accumulate the values of the operations
+	tbbBuyBase := 0.0
+	tbbSellBase := 0.0
+	innerFn := func(op *txnbuild.ManageSellOffer) (*txnbuild.ManageSellOffer, error) {
+		return f.exposureFilterFn(*netBasePosition, &tbbBuyBase, &tbbSellBase, op)
+	}
+	ops, e = filterOps(f.name, f.baseAsset, f.quoteAsset, sellingOffers, buyingOffers, ops, innerFn)
+	if e != nil {
+		return nil, fmt.Errorf("could not apply filter: %s", e)
+	}
+	return ops, nil
+}
+
+func (f *exposureFilter) exposureFilterFn(netBasePosition float64, tbbBuyBase *float64, tbbSellBase *float64, op *txnbuild.ManageSellOffer) (*txnbuild.ManageSellOffer, error) {
+	isSell, e := utils.IsSelling(f.baseAsset, f.quoteAsset, op.Selling, op.Buying)
+	if e != nil {
+		return nil, fmt.Errorf("error when running the isSelling check for offer '%+v': %s", *op, e)
+	}
+
+	opPrice, e := strconv.ParseFloat(op.Price, 64)
+	if e != nil {
+		return nil, fmt.Errorf("could not convert price (%s) to float: %s", op.Price, e)
+	}
+	opAmount, e := strconv.ParseFloat(op.Amount, 64)
+	if e != nil {
+		return nil, fmt.Errorf("could not convert amount (%s) to float: %s", op.Amount, e)
+	}
+
+	// a buy op has amount = baseAmount * price and price = 1/price, so we convert it back to the amount of the base asset
+	baseAmount := opAmount
+	if !isSell {
+		baseAmount = opAmount * opPrice
+	}
+
+	// remaining is the amount of the base asset that can still be sold (or bought) before the short (or long) exposure exceeds the cap
+	var remaining float64
+	var tbb *float64
+	if isSell {
+		tbb = tbbSellBase
+		remaining = f.maxShortBase + netBasePosition - *tbb
+	} else {
+		tbb = tbbBuyBase
+		remaining = f.maxLongBase - netBasePosition - *tbb
+	}
+
+	if baseAmount <= remaining {
+		*tbb += baseAmount
+		log.Printf("exposureFilter: isSell=%v, baseAmount=%.10f <= remaining=%.10f; keep=true", isSell, baseAmount, remaining)
+		return op, nil
+	}
+
+	if remaining <= 0 {
+		log.Printf("exposureFilter: isSell=%v, baseAmount=%.10f, remaining=%.10f <= 0; keep=false", isSell, baseAmount, remaining)
+		return nil, nil
+	}
+
+	// reduce the op to the remaining capacity, converting back to the quote units of a buy op
+	*tbb += remaining
+	newOpAmount := remaining
+	if !isSell {
+		newOpAmount = remaining / opPrice
+	}
+	op.Amount = fmt.Sprintf("%.7f", newOpAmount)
+	log.Printf("exposureFilter: isSell=%v, baseAmount=%.10f > remaining=%.10f, newOpAmount=%s; keep=true", isSell, baseAmount, remaining, op.Amount)
+	return op, nil
+}
+
+// String is the Stringer method
+func (f *exposureFilter) String() string {
+	return f.configValue
+}
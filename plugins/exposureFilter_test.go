@@ -0,0 +1,97 @@
+package plugins
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/kelp/support/utils"
+)
+
+func TestExposureFilterFn(t *testing.T) {
+	testCases := []struct {
+		name            string
+		netBasePosition float64
+		tbbBuyBase      float64
+		tbbSellBase     float64
+		op              *txnbuild.ManageSellOffer
+		wantOp          *txnbuild.ManageSellOffer
+		wantTbbBuyBase  float64
+		wantTbbSellBase float64
+	}{
+		{
+			name:            "sell within short cap",
+			netBasePosition: 0.0,
+			op:              &txnbuild.ManageSellOffer{Selling: testBaseAsset, Buying: testQuoteAsset, Amount: "10.0", Price: "2.0"},
+			wantOp:          &txnbuild.ManageSellOffer{Selling: testBaseAsset, Buying: testQuoteAsset, Amount: "10.0", Price: "2.0"},
+			wantTbbSellBase: 10.0,
+		}, {
+			name:            "sell reduced to short cap",
+			netBasePosition: 0.0,
+			op:              &txnbuild.ManageSellOffer{Selling: testBaseAsset, Buying: testQuoteAsset, Amount: "60.0", Price: "2.0"},
+			wantOp:          &txnbuild.ManageSellOffer{Selling: testBaseAsset, Buying: testQuoteAsset, Amount: "50.0000000", Price: "2.0"},
+			wantTbbSellBase: 50.0,
+		}, {
+			name:            "sell reduced by earlier ops",
+			netBasePosition: 0.0,
+			tbbSellBase:     30.0,
+			op:              &txnbuild.ManageSellOffer{Selling: testBaseAsset, Buying: testQuoteAsset, Amount: "30.0", Price: "2.0"},
+			wantOp:          &txnbuild.ManageSellOffer{Selling: testBaseAsset, Buying: testQuoteAsset, Amount: "20.0000000", Price: "2.0"},
+			wantTbbSellBase: 50.0,
+		}, {
+			name:            "sell dropped at short cap",
+			netBasePosition: -50.0,
+			op:              &txnbuild.ManageSellOffer{Selling: testBaseAsset, Buying: testQuoteAsset, Amount: "10.0", Price: "2.0"},
+			wantOp:          nil,
+		}, {
+			name:            "sell that reduces long exposure",
+			netBasePosition: 100.0,
+			op:              &txnbuild.ManageSellOffer{Selling: testBaseAsset, Buying: testQuoteAsset, Amount: "100.0", Price: "2.0"},
+			wantOp:          &txnbuild.ManageSellOffer{Selling: testBaseAsset, Buying: testQuoteAsset, Amount: "100.0", Price: "2.0"},
+			wantTbbSellBase: 100.0,
+		}, {
+			// buy op at a price of 1/0.5 = 2.0 quote per base, buying 10 base for 20 quote
+			name:            "buy reduced to long cap",
+			netBasePosition: 95.0,
+			op:              &txnbuild.ManageSellOffer{Selling: testQuoteAsset, Buying: testBaseAsset, Amount: "20.0", Price: "0.5"},
+			wantOp:          &txnbuild.ManageSellOffer{Selling: testQuoteAsset, Buying: testBaseAsset, Amount: "10.0000000", Price: "0.5"},
+			wantTbbBuyBase:  5.0,
+		}, {
+			name:            "buy dropped at long cap",
+			netBasePosition: 100.0,
+			op:              &txnbuild.ManageSellOffer{Selling: testQuoteAsset, Buying: testBaseAsset, Amount: "20.0", Price: "0.5"},
+			wantOp:          nil,
+		}, {
+			name:            "buy that reduces short exposure",
+			netBasePosition: -50.0,
+			tbbSellBase:     5.0,
+			op:              &txnbuild.ManageSellOffer{Selling: testQuoteAsset, Buying: testBaseAsset, Amount: "20.0", Price: "0.5"},
+			wantOp:          &txnbuild.ManageSellOffer{Selling: testQuoteAsset, Buying: testBaseAsset, Amount: "20.0", Price: "0.5"},
+			wantTbbBuyBase:  10.0,
+			wantTbbSellBase: 5.0,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			f := &exposureFilter{
+				name:         "exposureFilter",
+				baseAsset:    utils.Asset2Asset2(testBaseAsset),
+				quoteAsset:   utils.Asset2Asset2(testQuoteAsset),
+				maxLongBase:  100.0,
+				maxShortBase: 50.0,
+			}
+
+			tbbBuyBase := k.tbbBuyBase
+			tbbSellBase := k.tbbSellBase
+			actual, e := f.exposureFilterFn(k.netBasePosition, &tbbBuyBase, &tbbSellBase, k.op)
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, k.wantOp, actual)
+			assert.InDelta(t, k.wantTbbBuyBase, tbbBuyBase, 0.0000001)
+			assert.InDelta(t, k.wantTbbSellBase, tbbSellBase, 0.0000001)
+		})
+	}
+}
@@ -6,9 +6,9 @@ import (
 	"log"
 
 	hProtocol "github.com/stellar/go/protocols/horizon"
-	"github.com/stellar/go/support/config"
 	"github.com/stellar/kelp/api"
 	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/support/nonce"
 	"github.com/stellar/kelp/support/sdk"
 	"github.com/stellar/kelp/support/utils"
 )
@@ -23,11 +23,13 @@ type strategyFactoryData struct {
 	assetBase       *hProtocol.Asset
 	assetQuote      *hProtocol.Asset
 	marketID        string
+	strategy        string
 	stratConfigPath string
 	simMode         bool
 	isTradingSdex   bool
 	filterFactory   *FilterFactory
 	db              *sql.DB
+	clock           api.Clock
 }
 
 // StrategyContainer contains the strategy factory method along with some metadata
@@ -43,6 +45,32 @@ var ccxtExchangeSpecificParamFactoryMap = map[string]ccxtExchangeSpecificParamFa
 	"ccxt-coinbasepro": &ccxtExchangeSpecificParamFactoryCoinbasepro{},
 	"ccxt-binance":     makeCcxtExchangeSpecificParamFactoryBinance(),
 	"ccxt-bitstamp":    &ccxtExchangeSpecificParamFactoryBitstamp{},
+	"ccxt-mexc":        &ccxtExchangeSpecificParamFactoryMexc{},
+	"ccxt-gateio":      &ccxtExchangeSpecificParamFactoryGateio{},
+}
+
+// ccxtOrderConstraintOverridesMap contains the order constraints for exchanges where CCXT reports precision as tick sizes instead of
+// decimal places, which cannot be read from the loaded markets
+var ccxtOrderConstraintOverridesMap = map[string]map[model.TradingPair]model.OrderConstraints{
+	"ccxt-mexc": {
+		*model.MakeTradingPair(model.XLM, model.USDT): *model.MakeOrderConstraintsWithCost(5, 2, 1.0, 5.0),
+		*model.MakeTradingPair(model.XLM, model.BTC):  *model.MakeOrderConstraintsWithCost(9, 2, 1.0, 0.0001),
+	},
+	"ccxt-gateio": {
+		*model.MakeTradingPair(model.XLM, model.USDT): *model.MakeOrderConstraintsWithCost(5, 2, 1.0, 3.0),
+		*model.MakeTradingPair(model.XLM, model.BTC):  *model.MakeOrderConstraintsWithCost(10, 2, 1.0, 0.0001),
+	},
+}
+
+// ccxtAssetConverterMap contains the asset converters for exchanges where CCXT renames some of the exchange's asset codes
+var ccxtAssetConverterMap = map[string]model.AssetConverterInterface{
+	"ccxt-mexc":   model.CcxtAssetConverterMexc,
+	"ccxt-gateio": model.CcxtAssetConverterGateio,
+}
+
+// readStrategyConfig reads the config file of the strategy being made, migrating it to the current version of its schema
+func readStrategyConfig(strategyFactoryData strategyFactoryData, dest interface{}) error {
+	return ReadStrategyConfig(strategyFactoryData.strategy, strategyFactoryData.stratConfigPath, dest)
 }
 
 // strategies is a map of all the strategies available
@@ -54,10 +82,18 @@ var strategies = map[string]StrategyContainer{
 		Complexity:  "Beginner",
 		makeFn: func(strategyFactoryData strategyFactoryData) (api.Strategy, error) {
 			var cfg BuySellConfig
-			err := config.Read(strategyFactoryData.stratConfigPath, &cfg)
+			err := readStrategyConfig(strategyFactoryData, &cfg)
 			utils.CheckConfigError(cfg, err, strategyFactoryData.stratConfigPath)
 			utils.LogConfig(cfg)
-			s, e := makeBuySellStrategy(strategyFactoryData.sdex, strategyFactoryData.tradingPair, strategyFactoryData.ieif, strategyFactoryData.assetBase, strategyFactoryData.assetQuote, &cfg)
+			levelStats, e := maybeMakeLevelStatsRecorder(cfg.TrackLevelStats, strategyFactoryData)
+			if e != nil {
+				return nil, fmt.Errorf("makeFn failed: %s", e)
+			}
+			bootstrap, e := maybeMakeBootstrapSpread(&cfg, strategyFactoryData)
+			if e != nil {
+				return nil, fmt.Errorf("makeFn failed: %s", e)
+			}
+			s, e := makeBuySellStrategy(strategyFactoryData.sdex, strategyFactoryData.tradingPair, strategyFactoryData.ieif, strategyFactoryData.assetBase, strategyFactoryData.assetQuote, &cfg, levelStats, bootstrap, strategyFactoryData.db, strategyFactoryData.marketID)
 			if e != nil {
 				return nil, fmt.Errorf("makeFn failed: %s", e)
 			}
@@ -71,10 +107,31 @@ var strategies = map[string]StrategyContainer{
 		Complexity:  "Advanced",
 		makeFn: func(strategyFactoryData strategyFactoryData) (api.Strategy, error) {
 			var cfg mirrorConfig
-			err := config.Read(strategyFactoryData.stratConfigPath, &cfg)
+			err := readStrategyConfig(strategyFactoryData, &cfg)
 			utils.CheckConfigError(cfg, err, strategyFactoryData.stratConfigPath)
 			utils.LogConfig(cfg)
-			s, e := makeMirrorStrategy(strategyFactoryData.sdex, strategyFactoryData.ieif, strategyFactoryData.tradingPair, strategyFactoryData.assetBase, strategyFactoryData.assetQuote, strategyFactoryData.marketID, &cfg, strategyFactoryData.db, strategyFactoryData.simMode)
+			s, e := makeMirrorStrategy(strategyFactoryData.sdex, strategyFactoryData.ieif, strategyFactoryData.tradingPair, strategyFactoryData.assetBase, strategyFactoryData.assetQuote, strategyFactoryData.marketID, &cfg, strategyFactoryData.db, strategyFactoryData.simMode, strategyFactoryData.clock)
+			if e != nil {
+				return nil, fmt.Errorf("makeFn failed: %s", e)
+			}
+			return s, nil
+		},
+	},
+	"reverse_mirror": {
+		SortOrder:   12,
+		Description: "Mirrors the Stellar orderbook onto a centralized exchange and offsets trades back onto Stellar",
+		NeedsConfig: true,
+		Complexity:  "Advanced",
+		makeFn: func(strategyFactoryData strategyFactoryData) (api.Strategy, error) {
+			if strategyFactoryData.isTradingSdex {
+				return nil, fmt.Errorf("makeFn failed: the reverse_mirror strategy places orders on a centralized exchange, TRADING_EXCHANGE needs to be set in the trader config file")
+			}
+
+			var cfg reverseMirrorConfig
+			err := readStrategyConfig(strategyFactoryData, &cfg)
+			utils.CheckConfigError(cfg, err, strategyFactoryData.stratConfigPath)
+			utils.LogConfig(cfg)
+			s, e := makeReverseMirrorStrategy(strategyFactoryData.sdex, strategyFactoryData.exchangeShim, strategyFactoryData.ieif, strategyFactoryData.tradingPair, strategyFactoryData.assetBase, strategyFactoryData.assetQuote, &cfg, strategyFactoryData.simMode)
 			if e != nil {
 				return nil, fmt.Errorf("makeFn failed: %s", e)
 			}
@@ -88,10 +145,14 @@ var strategies = map[string]StrategyContainer{
 		Complexity:  "Beginner",
 		makeFn: func(strategyFactoryData strategyFactoryData) (api.Strategy, error) {
 			var cfg sellConfig
-			err := config.Read(strategyFactoryData.stratConfigPath, &cfg)
+			err := readStrategyConfig(strategyFactoryData, &cfg)
 			utils.CheckConfigError(cfg, err, strategyFactoryData.stratConfigPath)
 			utils.LogConfig(cfg)
-			s, e := makeSellStrategy(strategyFactoryData.sdex, strategyFactoryData.tradingPair, strategyFactoryData.ieif, strategyFactoryData.assetBase, strategyFactoryData.assetQuote, &cfg)
+			levelStats, e := maybeMakeLevelStatsRecorder(cfg.TrackLevelStats, strategyFactoryData)
+			if e != nil {
+				return nil, fmt.Errorf("makeFn failed: %s", e)
+			}
+			s, e := makeSellStrategy(strategyFactoryData.sdex, strategyFactoryData.tradingPair, strategyFactoryData.ieif, strategyFactoryData.assetBase, strategyFactoryData.assetQuote, &cfg, levelStats, strategyFactoryData.db, strategyFactoryData.marketID)
 			if e != nil {
 				return nil, fmt.Errorf("makeFn failed: %s", e)
 			}
@@ -105,7 +166,7 @@ var strategies = map[string]StrategyContainer{
 		Complexity:  "Intermediate",
 		makeFn: func(strategyFactoryData strategyFactoryData) (api.Strategy, error) {
 			var cfg balancedConfig
-			err := config.Read(strategyFactoryData.stratConfigPath, &cfg)
+			err := readStrategyConfig(strategyFactoryData, &cfg)
 			utils.CheckConfigError(cfg, err, strategyFactoryData.stratConfigPath)
 			utils.LogConfig(cfg)
 			return makeBalancedStrategy(strategyFactoryData.sdex, strategyFactoryData.tradingPair, strategyFactoryData.ieif, strategyFactoryData.assetBase, strategyFactoryData.assetQuote, &cfg), nil
@@ -113,11 +174,23 @@ var strategies = map[string]StrategyContainer{
 	},
 	"delete": {
 		SortOrder:   3,
-		Description: "Deletes all orders for the configured orderbook",
+		Description: "Deletes all orders for the configured orderbook, or only those on one side or within a price range",
 		NeedsConfig: false,
 		Complexity:  "Beginner",
 		makeFn: func(strategyFactoryData strategyFactoryData) (api.Strategy, error) {
-			return makeDeleteStrategy(strategyFactoryData.sdex, strategyFactoryData.assetBase, strategyFactoryData.assetQuote), nil
+			// the config is optional, all orders are deleted when it is not provided
+			var cfg *deleteConfig
+			if strategyFactoryData.stratConfigPath != "" {
+				cfg = &deleteConfig{}
+				err := readStrategyConfig(strategyFactoryData, cfg)
+				utils.CheckConfigError(*cfg, err, strategyFactoryData.stratConfigPath)
+				utils.LogConfig(*cfg)
+			}
+			s, e := makeDeleteStrategy(strategyFactoryData.sdex, strategyFactoryData.assetBase, strategyFactoryData.assetQuote, cfg)
+			if e != nil {
+				return nil, fmt.Errorf("unable to make delete strategy: %s", e)
+			}
+			return s, nil
 		},
 	},
 	"pendulum": {
@@ -127,7 +200,7 @@ var strategies = map[string]StrategyContainer{
 		Complexity:  "Beginner",
 		makeFn: func(strategyFactoryData strategyFactoryData) (api.Strategy, error) {
 			var cfg pendulumConfig
-			err := config.Read(strategyFactoryData.stratConfigPath, &cfg)
+			err := readStrategyConfig(strategyFactoryData, &cfg)
 			utils.CheckConfigError(cfg, err, strategyFactoryData.stratConfigPath)
 			utils.LogConfig(cfg)
 			return makePendulumStrategy(
@@ -150,7 +223,7 @@ var strategies = map[string]StrategyContainer{
 		Complexity:  "Intermediate",
 		makeFn: func(strategyFactoryData strategyFactoryData) (api.Strategy, error) {
 			var cfg sellTwapConfig
-			err := config.Read(strategyFactoryData.stratConfigPath, &cfg)
+			err := readStrategyConfig(strategyFactoryData, &cfg)
 			utils.CheckConfigError(cfg, err, strategyFactoryData.stratConfigPath)
 			utils.LogConfig(cfg)
 			s, e := makeSellTwapStrategy(
@@ -161,6 +234,7 @@ var strategies = map[string]StrategyContainer{
 				strategyFactoryData.assetQuote,
 				strategyFactoryData.filterFactory,
 				&cfg,
+				strategyFactoryData.clock,
 			)
 			if e != nil {
 				return nil, fmt.Errorf("makeFn failed: %s", e)
@@ -176,7 +250,7 @@ var strategies = map[string]StrategyContainer{
 		makeFn: func(strategyFactoryData strategyFactoryData) (api.Strategy, error) {
 			// reuse the sellTwapConfig struct since we need the same info for buyTwap
 			var cfg sellTwapConfig
-			err := config.Read(strategyFactoryData.stratConfigPath, &cfg)
+			err := readStrategyConfig(strategyFactoryData, &cfg)
 			utils.CheckConfigError(cfg, err, strategyFactoryData.stratConfigPath)
 			utils.LogConfig(cfg)
 			s, e := makeBuyTwapStrategy(
@@ -187,6 +261,7 @@ var strategies = map[string]StrategyContainer{
 				strategyFactoryData.assetQuote,
 				strategyFactoryData.filterFactory,
 				&cfg,
+				strategyFactoryData.clock,
 			)
 			if e != nil {
 				return nil, fmt.Errorf("make Fn failed: %s", e)
@@ -194,6 +269,158 @@ var strategies = map[string]StrategyContainer{
 			return s, nil
 		},
 	},
+	"vwap": {
+		SortOrder:   8,
+		Description: "Creates sell offers by distributing orders over time for a given day weighted by the historical intraday volume on a reference exchange",
+		NeedsConfig: true,
+		Complexity:  "Advanced",
+		makeFn: func(strategyFactoryData strategyFactoryData) (api.Strategy, error) {
+			var cfg vwapConfig
+			err := readStrategyConfig(strategyFactoryData, &cfg)
+			utils.CheckConfigError(cfg, err, strategyFactoryData.stratConfigPath)
+			utils.LogConfig(cfg)
+			s, e := makeVwapStrategy(
+				strategyFactoryData.sdex,
+				strategyFactoryData.tradingPair,
+				strategyFactoryData.ieif,
+				strategyFactoryData.assetBase,
+				strategyFactoryData.assetQuote,
+				strategyFactoryData.filterFactory,
+				&cfg,
+				strategyFactoryData.clock,
+			)
+			if e != nil {
+				return nil, fmt.Errorf("makeFn failed: %s", e)
+			}
+			return s, nil
+		},
+	},
+	"inventory_skew": {
+		SortOrder:   9,
+		Description: "Creates buy and sell offers around a reference price, skewing spreads based on current inventory relative to a target ratio",
+		NeedsConfig: true,
+		Complexity:  "Intermediate",
+		makeFn: func(strategyFactoryData strategyFactoryData) (api.Strategy, error) {
+			var cfg inventorySkewConfig
+			err := readStrategyConfig(strategyFactoryData, &cfg)
+			utils.CheckConfigError(cfg, err, strategyFactoryData.stratConfigPath)
+			utils.LogConfig(cfg)
+			s, e := makeInventorySkewStrategy(strategyFactoryData.sdex, strategyFactoryData.tradingPair, strategyFactoryData.ieif, strategyFactoryData.assetBase, strategyFactoryData.assetQuote, &cfg)
+			if e != nil {
+				return nil, fmt.Errorf("makeFn failed: %s", e)
+			}
+			return s, nil
+		},
+	},
+	"signal": {
+		SortOrder:   10,
+		Description: "Creates buy and sell offers around a reference price, adjusting spreads or pausing quotes based on technical indicator thresholds",
+		NeedsConfig: true,
+		Complexity:  "Advanced",
+		makeFn: func(strategyFactoryData strategyFactoryData) (api.Strategy, error) {
+			var cfg signalConfig
+			err := readStrategyConfig(strategyFactoryData, &cfg)
+			utils.CheckConfigError(cfg, err, strategyFactoryData.stratConfigPath)
+			utils.LogConfig(cfg)
+			s, e := makeSignalStrategy(strategyFactoryData.sdex, strategyFactoryData.tradingPair, strategyFactoryData.ieif, strategyFactoryData.assetBase, strategyFactoryData.assetQuote, &cfg)
+			if e != nil {
+				return nil, fmt.Errorf("makeFn failed: %s", e)
+			}
+			return s, nil
+		},
+	},
+	"liquidity_pool": {
+		SortOrder:   13,
+		Description: "Deposits into and withdraws from the liquidity pool of the market to keep a target fraction of the balances in the pool",
+		NeedsConfig: true,
+		Complexity:  "Intermediate",
+		makeFn: func(strategyFactoryData strategyFactoryData) (api.Strategy, error) {
+			var cfg liquidityPoolConfig
+			err := readStrategyConfig(strategyFactoryData, &cfg)
+			utils.CheckConfigError(cfg, err, strategyFactoryData.stratConfigPath)
+			utils.LogConfig(cfg)
+			s, e := makeLiquidityPoolStrategy(strategyFactoryData.sdex, strategyFactoryData.assetBase, strategyFactoryData.assetQuote, &cfg)
+			if e != nil {
+				return nil, fmt.Errorf("makeFn failed: %s", e)
+			}
+			return s, nil
+		},
+	},
+	"pool_arbitrage": {
+		SortOrder:   14,
+		Description: "Arbitrages the liquidity pool of the market against the orderbook with path payments when their prices diverge by more than the fees",
+		NeedsConfig: true,
+		Complexity:  "Advanced",
+		makeFn: func(strategyFactoryData strategyFactoryData) (api.Strategy, error) {
+			var cfg poolArbitrageConfig
+			err := readStrategyConfig(strategyFactoryData, &cfg)
+			utils.CheckConfigError(cfg, err, strategyFactoryData.stratConfigPath)
+			utils.LogConfig(cfg)
+			s, e := makePoolArbitrageStrategy(strategyFactoryData.sdex, strategyFactoryData.tradingPair, strategyFactoryData.assetBase, strategyFactoryData.assetQuote, strategyFactoryData.filterFactory, &cfg)
+			if e != nil {
+				return nil, fmt.Errorf("makeFn failed: %s", e)
+			}
+			return s, nil
+		},
+	},
+}
+
+func init() {
+	// the composite strategy is registered here instead of in the strategies map because it uses MakeStrategy to make its child strategies
+	strategies["composite"] = StrategyContainer{
+		SortOrder:   11,
+		Description: "Runs an ordered list of child strategies on the same market, where each child strategy manages only its own offers",
+		NeedsConfig: true,
+		Complexity:  "Advanced",
+		makeFn: func(strategyFactoryData strategyFactoryData) (api.Strategy, error) {
+			var cfg compositeConfig
+			err := readStrategyConfig(strategyFactoryData, &cfg)
+			utils.CheckConfigError(cfg, err, strategyFactoryData.stratConfigPath)
+			utils.LogConfig(cfg)
+
+			balanceShares, e := computeCompositeBalanceShares(cfg.Strategies)
+			if e != nil {
+				return nil, fmt.Errorf("makeFn failed: %s", e)
+			}
+
+			names := []string{}
+			children := []api.Strategy{}
+			for i, c := range cfg.Strategies {
+				if c.Strategy == "composite" {
+					return nil, fmt.Errorf("makeFn failed: child strategy at index %d cannot be a composite strategy", i)
+				}
+
+				child, e := MakeStrategy(
+					strategyFactoryData.sdex,
+					strategyFactoryData.exchangeShim,
+					strategyFactoryData.tradeFetcher,
+					strategyFactoryData.ieif,
+					strategyFactoryData.tradingPair,
+					strategyFactoryData.assetBase,
+					strategyFactoryData.assetQuote,
+					strategyFactoryData.marketID,
+					c.Strategy,
+					c.ConfigPath,
+					strategyFactoryData.simMode,
+					strategyFactoryData.isTradingSdex,
+					strategyFactoryData.filterFactory,
+					strategyFactoryData.db,
+					strategyFactoryData.clock,
+				)
+				if e != nil {
+					return nil, fmt.Errorf("makeFn failed: could not make child strategy at index %d: %s", i, e)
+				}
+				names = append(names, fmt.Sprintf("%s:%s", c.Strategy, c.ConfigPath))
+				children = append(children, child)
+			}
+
+			s, e := makeCompositeStrategy(strategyFactoryData.sdex, strategyFactoryData.assetBase, strategyFactoryData.assetQuote, names, children, balanceShares)
+			if e != nil {
+				return nil, fmt.Errorf("makeFn failed: %s", e)
+			}
+			return s, nil
+		},
+	}
 }
 
 // MakeStrategy makes a strategy
@@ -212,6 +439,7 @@ func MakeStrategy(
 	isTradingSdex bool,
 	filterFactory *FilterFactory,
 	db *sql.DB,
+	clock api.Clock,
 ) (api.Strategy, error) {
 	log.Printf("Making strategy: %s\n", strategy)
 	if s, ok := strategies[strategy]; ok {
@@ -228,11 +456,13 @@ func MakeStrategy(
 			assetBase:       assetBase,
 			assetQuote:      assetQuote,
 			marketID:        marketID,
+			strategy:        strategy,
 			stratConfigPath: stratConfigPath,
 			simMode:         simMode,
 			isTradingSdex:   isTradingSdex,
 			filterFactory:   filterFactory,
 			db:              db,
+			clock:           clock,
 		})
 		if e != nil {
 			return nil, fmt.Errorf("cannot make '%s' strategy: %s", strategy, e)
@@ -285,17 +515,23 @@ func loadExchanges() {
 		"poloniex":    true,
 		"coinbasepro": true,
 		"bitstamp":    true,
+		"mexc":        true,
+		"gateio":      true,
 	}
 
 	// marked as atomicPostOnly if key exists in this map (regardless of bool value)
 	atomicPostOnlyCcxtExchanges := map[string]bool{
 		"kraken":      true,
 		"coinbasepro": true,
+		"mexc":        true,
+		"gateio":      true,
 	}
 
 	// marked as tradeHasOrderId if key exists in this map (regardless of bool value)
 	tradeHasOrderIdCcxtExchanges := map[string]bool{
 		"binance": true,
+		"mexc":    true,
+		"gateio":  true,
 	}
 
 	exchanges = &map[string]ExchangeContainer{
@@ -325,6 +561,8 @@ func loadExchanges() {
 			_, tradeHasOrderId := tradeHasOrderIdCcxtExchanges[exchangeName]
 			// maybeEsParamFactory can be nil
 			maybeEsParamFactory := ccxtExchangeSpecificParamFactoryMap[key]
+			// maybeOrderConstraintOverrides can be nil
+			maybeOrderConstraintOverrides := ccxtOrderConstraintOverridesMap[key]
 			(*exchanges)[key] = ExchangeContainer{
 				SortOrder:       uint16(sortOrderIndex),
 				Description:     exchangeName + " is automatically added via ccxt-rest",
@@ -335,7 +573,7 @@ func loadExchanges() {
 				makeFn: func(exchangeFactoryData exchangeFactoryData) (api.Exchange, error) {
 					return makeCcxtExchange(
 						boundExchangeName,
-						nil,
+						maybeOrderConstraintOverrides,
 						exchangeFactoryData.apiKeys,
 						exchangeFactoryData.exchangeParams,
 						exchangeFactoryData.headers,
@@ -360,7 +598,7 @@ func MakeExchange(exchangeType string, simMode bool) (api.Exchange, error) {
 		if e != nil {
 			return nil, fmt.Errorf("error when making the '%s' exchange: %s", exchangeType, e)
 		}
-		return x, nil
+		return maybeWrapFaultInjectingExchange(exchangeType, x), nil
 	}
 
 	return nil, fmt.Errorf("invalid exchange type: %s", exchangeType)
@@ -386,6 +624,8 @@ func MakeTradingExchange(exchangeType string, apiKeys []api.ExchangeAPIKey, exch
 		if e != nil {
 			return nil, fmt.Errorf("error when making the '%s' exchange: %s", exchangeType, e)
 		}
+		x = maybeWrapPairWhitelistExchange(exchangeType, apiKeys, maybeWrapFaultInjectingExchange(exchangeType, x))
+		maybeTrackTradingExchange(exchangeType, x)
 		return x, nil
 	}
 
@@ -396,3 +636,12 @@ func MakeTradingExchange(exchangeType string, apiKeys []api.ExchangeAPIKey, exch
 func Exchanges() map[string]ExchangeContainer {
 	return getExchanges()
 }
+
+// exchangeNonceRegistry hands out the nonces of the authenticated exchange APIs, so all the clients of an API key share its nonces
+var exchangeNonceRegistry = nonce.MakeMemoryRegistry()
+
+// SetExchangeNonceRegistry sets the registry of the nonces used by every exchange that is made by MakeExchange and MakeTradingExchange after
+// this is called, which can persist the nonces so they never regress across restarts
+func SetExchangeNonceRegistry(r *nonce.Registry) {
+	exchangeNonceRegistry = r
+}
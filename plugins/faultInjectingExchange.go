@@ -0,0 +1,180 @@
+package plugins
+
+import (
+	"fmt"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/support/networking"
+)
+
+// exchangeFaultInjector is used to wrap every exchange made by the factory methods when set
+var exchangeFaultInjector *networking.FaultInjector
+
+// SetExchangeFaultInjector injects faults into the calls made to every exchange that is made by MakeExchange and MakeTradingExchange after
+// this is called, passing nil turns fault injection off for exchanges made after this call
+func SetExchangeFaultInjector(fi *networking.FaultInjector) {
+	exchangeFaultInjector = fi
+}
+
+// maybeWrapFaultInjectingExchange wraps the exchange when fault injection is enabled
+func maybeWrapFaultInjectingExchange(exchangeType string, x api.Exchange) api.Exchange {
+	if exchangeFaultInjector == nil {
+		return x
+	}
+	return makeFaultInjectingExchange(exchangeType, x, exchangeFaultInjector)
+}
+
+// faultInjectingExchange injects faults into every call to the inner exchange that makes a request to the exchange, the methods that
+// do not make requests are passed through by the embedded api.Exchange
+type faultInjectingExchange struct {
+	api.Exchange
+	exchangeType string
+	fi           *networking.FaultInjector
+}
+
+// faultInjectingFeeExchange is used when the inner exchange can fetch fees so the wrapper keeps satisfying api.TradingFeeFetcher
+type faultInjectingFeeExchange struct {
+	*faultInjectingExchange
+	feeFetcher api.TradingFeeFetcher
+}
+
+var _ api.Exchange = &faultInjectingExchange{}
+var _ api.APIKeyPermissionsFetcher = &faultInjectingExchange{}
+var _ api.TradingFeeFetcher = &faultInjectingFeeExchange{}
+
+// makeFaultInjectingExchange is a factory method
+func makeFaultInjectingExchange(exchangeType string, inner api.Exchange, fi *networking.FaultInjector) api.Exchange {
+	x := &faultInjectingExchange{
+		Exchange:     inner,
+		exchangeType: exchangeType,
+		fi:           fi,
+	}
+	if feeFetcher, ok := inner.(api.TradingFeeFetcher); ok {
+		return &faultInjectingFeeExchange{
+			faultInjectingExchange: x,
+			feeFetcher:             feeFetcher,
+		}
+	}
+	return x
+}
+
+func (x *faultInjectingExchange) inject(method string) error {
+	return x.fi.Inject(fmt.Sprintf("%s %s", x.exchangeType, method))
+}
+
+// GetAPIKeyPermissions impl.
+func (x *faultInjectingExchange) GetAPIKeyPermissions() ([]api.APIKeyPermissions, error) {
+	fetcher, ok := x.Exchange.(api.APIKeyPermissionsFetcher)
+	if !ok {
+		return nil, fmt.Errorf("the %s exchange cannot report the permissions of its API keys", x.exchangeType)
+	}
+	if e := x.inject("GetAPIKeyPermissions"); e != nil {
+		return nil, e
+	}
+	return fetcher.GetAPIKeyPermissions()
+}
+
+// GetAccountBalances impl.
+func (x *faultInjectingExchange) GetAccountBalances(assetList []interface{}) (map[interface{}]model.Number, error) {
+	if e := x.inject("GetAccountBalances"); e != nil {
+		return nil, e
+	}
+	return x.Exchange.GetAccountBalances(assetList)
+}
+
+// GetTickerPrice impl.
+func (x *faultInjectingExchange) GetTickerPrice(pairs []model.TradingPair) (map[model.TradingPair]api.Ticker, error) {
+	if e := x.inject("GetTickerPrice"); e != nil {
+		return nil, e
+	}
+	return x.Exchange.GetTickerPrice(pairs)
+}
+
+// GetOrderBook impl.
+func (x *faultInjectingExchange) GetOrderBook(pair *model.TradingPair, maxCount int32) (*model.OrderBook, error) {
+	if e := x.inject("GetOrderBook"); e != nil {
+		return nil, e
+	}
+	return x.Exchange.GetOrderBook(pair, maxCount)
+}
+
+// GetTrades impl.
+func (x *faultInjectingExchange) GetTrades(pair *model.TradingPair, maybeCursor interface{}) (*api.TradesResult, error) {
+	if e := x.inject("GetTrades"); e != nil {
+		return nil, e
+	}
+	return x.Exchange.GetTrades(pair, maybeCursor)
+}
+
+// GetTradeHistory impl.
+func (x *faultInjectingExchange) GetTradeHistory(pair model.TradingPair, maybeCursorStart interface{}, maybeCursorEnd interface{}) (*api.TradeHistoryResult, error) {
+	if e := x.inject("GetTradeHistory"); e != nil {
+		return nil, e
+	}
+	return x.Exchange.GetTradeHistory(pair, maybeCursorStart, maybeCursorEnd)
+}
+
+// GetLatestTradeCursor impl.
+func (x *faultInjectingExchange) GetLatestTradeCursor() (interface{}, error) {
+	if e := x.inject("GetLatestTradeCursor"); e != nil {
+		return nil, e
+	}
+	return x.Exchange.GetLatestTradeCursor()
+}
+
+// GetOpenOrders impl.
+func (x *faultInjectingExchange) GetOpenOrders(pairs []*model.TradingPair) (map[model.TradingPair][]model.OpenOrder, error) {
+	if e := x.inject("GetOpenOrders"); e != nil {
+		return nil, e
+	}
+	return x.Exchange.GetOpenOrders(pairs)
+}
+
+// AddOrder impl.
+func (x *faultInjectingExchange) AddOrder(order *model.Order, submitMode api.SubmitMode) (*model.TransactionID, error) {
+	if e := x.inject("AddOrder"); e != nil {
+		return nil, e
+	}
+	return x.Exchange.AddOrder(order, submitMode)
+}
+
+// CancelOrder impl.
+func (x *faultInjectingExchange) CancelOrder(txID *model.TransactionID, pair model.TradingPair) (model.CancelOrderResult, error) {
+	if e := x.inject("CancelOrder"); e != nil {
+		return model.CancelResultFailed, e
+	}
+	return x.Exchange.CancelOrder(txID, pair)
+}
+
+// PrepareDeposit impl.
+func (x *faultInjectingExchange) PrepareDeposit(asset model.Asset, amount *model.Number) (*api.PrepareDepositResult, error) {
+	if e := x.inject("PrepareDeposit"); e != nil {
+		return nil, e
+	}
+	return x.Exchange.PrepareDeposit(asset, amount)
+}
+
+// GetWithdrawInfo impl.
+func (x *faultInjectingExchange) GetWithdrawInfo(asset model.Asset, amountToWithdraw *model.Number, address string) (*api.WithdrawInfo, error) {
+	if e := x.inject("GetWithdrawInfo"); e != nil {
+		return nil, e
+	}
+	return x.Exchange.GetWithdrawInfo(asset, amountToWithdraw, address)
+}
+
+// WithdrawFunds impl.
+func (x *faultInjectingExchange) WithdrawFunds(asset model.Asset, amountToWithdraw *model.Number, address string, memo string) (*api.WithdrawFunds, error) {
+	if e := x.inject("WithdrawFunds"); e != nil {
+		return nil, e
+	}
+	return x.Exchange.WithdrawFunds(asset, amountToWithdraw, address, memo)
+}
+
+// GetTakerFee impl.
+func (x *faultInjectingFeeExchange) GetTakerFee(pair *model.TradingPair) (float64, error) {
+	if e := x.inject("GetTakerFee"); e != nil {
+		return 0, e
+	}
+	return x.feeFetcher.GetTakerFee(pair)
+}
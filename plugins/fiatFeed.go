@@ -3,6 +3,8 @@ package plugins
 import (
 	"fmt"
 	"net/http"
+	neturl "net/url"
+	"strings"
 	"time"
 
 	"github.com/stellar/go/support/errors"
@@ -45,16 +47,29 @@ type fiatAPIReturn struct {
 }
 
 type fiatFeed struct {
-	url    string
-	client http.Client
+	url      string
+	quoteKey string
+	client   http.Client
 }
 
 // ensure that it implements PriceFeed
 var _ api.PriceFeed = &fiatFeed{}
 
+// newFiatFeed makes the feed, the quote that is used is the key in the fragment of the URL (e.g. #USDNGN) or else the source followed by the
+// single value of the currencies parameter; when neither is set the response must contain exactly one quote
 func newFiatFeed(url string) *fiatFeed {
 	m := new(fiatFeed)
-	m.url = url
+	m.url, m.quoteKey = splitFiatQuoteKey(url, func(query neturl.Values) string {
+		currencies := strings.Split(query.Get("currencies"), ",")
+		if len(currencies) != 1 || currencies[0] == "" {
+			return ""
+		}
+		source := query.Get("source")
+		if source == "" {
+			source = "USD"
+		}
+		return strings.ToUpper(source + currencies[0])
+	})
 	m.client = http.Client{Timeout: 10 * time.Second}
 
 	return m
@@ -72,6 +87,14 @@ func (f *fiatFeed) GetPrice() (float64, error) {
 		return -1, errors.Wrap(ret.Error, "call to get price from fiat feed failed")
 	}
 
+	if f.quoteKey != "" {
+		price, ok := ret.Quotes[f.quoteKey]
+		if !ok {
+			return 0, fmt.Errorf("quote '%s' was not returned by fiat feed, returned %d quotes", f.quoteKey, len(ret.Quotes))
+		}
+		return (1.0 / price), nil
+	}
+
 	if len(ret.Quotes) != 1 {
 		return 0, fmt.Errorf("incorrect number of quotes returned (%d), was expecting only 1", len(ret.Quotes))
 	}
@@ -81,3 +104,18 @@ func (f *fiatFeed) GetPrice() (float64, error) {
 	}
 	return -1, fmt.Errorf("unexpected error, should not have reached here")
 }
+
+// splitFiatQuoteKey removes the fragment from the URL and returns it as the key of the quote to use, falling back to the key inferred from the
+// query parameters of the URL (empty when it cannot be inferred)
+func splitFiatQuoteKey(rawURL string, inferKey func(query neturl.Values) string) (string, string) {
+	parsed, e := neturl.Parse(rawURL)
+	if e != nil {
+		return rawURL, ""
+	}
+	if parsed.Fragment != "" {
+		key := strings.ToUpper(parsed.Fragment)
+		parsed.Fragment = ""
+		return parsed.String(), key
+	}
+	return rawURL, inferKey(parsed.Query())
+}
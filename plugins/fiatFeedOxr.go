@@ -4,12 +4,15 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	neturl "net/url"
+	"strings"
 	"time"
 )
 
 type fiatFeedOxr struct {
-	url    string
-	client http.Client
+	url      string
+	quoteKey string
+	client   http.Client
 }
 
 type oxrRates struct {
@@ -39,10 +42,20 @@ var oxrErrorCodeMsg = map[int]string{
 	400: "invalid_base",
 }
 
+// newFiatFeedOxr makes the feed, the rate that is used is the currency in the fragment of the URL (e.g. #NGN) or else the single value of the
+// symbols parameter; when neither is set the response must contain exactly one rate
 func newFiatFeedOxr(url string) *fiatFeedOxr {
+	url, quoteKey := splitFiatQuoteKey(url, func(query neturl.Values) string {
+		symbols := strings.Split(query.Get("symbols"), ",")
+		if len(symbols) != 1 {
+			return ""
+		}
+		return strings.ToUpper(symbols[0])
+	})
 	return &fiatFeedOxr{
-		url:    url,
-		client: http.Client{Timeout: 10 * time.Second},
+		url:      url,
+		quoteKey: quoteKey,
+		client:   http.Client{Timeout: 10 * time.Second},
 	}
 }
 
@@ -66,6 +79,14 @@ func (f *fiatFeedOxr) GetPrice() (float64, error) {
 		return 0, fmt.Errorf("oxr: error %w", err)
 	}
 
+	if f.quoteKey != "" {
+		v, ok := rates.Rates[f.quoteKey]
+		if !ok {
+			return 0, fmt.Errorf("oxr: error rate for %s not found in %d rates", f.quoteKey, len(rates.Rates))
+		}
+		return v, nil
+	}
+
 	if len(rates.Rates) != 1 {
 		return 0, fmt.Errorf("oxr: error rates must contain single value found len %d", len(rates.Rates))
 	}
@@ -0,0 +1,315 @@
+package plugins
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stellar/kelp/api"
+)
+
+// names of the fiat providers used in the URL of a "fiat" price feed and as the keys of FIAT_API_KEYS
+const (
+	fiatProviderExchangeRateHost = "exchangeratehost"
+	fiatProviderOxr              = "oxr"
+	fiatProviderEcb              = "ecb"
+)
+
+// fiatProviderDefaultOrder is the order in which the fiat providers are tried when the URL does not list them, providers that need an API
+// key are skipped when no key is set
+var fiatProviderDefaultOrder = []string{fiatProviderExchangeRateHost, fiatProviderOxr, fiatProviderEcb}
+
+// base URLs of the fiat providers, these are variables so they can be pointed at a test server
+var (
+	fiatExchangeRateHostBaseURL = "https://api.exchangerate.host"
+	fiatOxrBaseURL              = "https://openexchangerates.org/api"
+	fiatEcbDailyURL             = "https://www.ecb.europa.eu/stats/eurofxref/eurofxref-daily.xml"
+)
+
+var fiatConfigLock = &sync.Mutex{}
+
+// fiatAPIKeys are the API keys of the fiat providers keyed by the provider name, they are used by every "fiat" price feed that is made
+// after they are set with SetFiatConfig
+var fiatAPIKeys map[string]string
+
+// SetFiatConfig sets the API keys of the fiat providers used by the "fiat" price feeds, keyed by the name of the provider
+// (exchangeratehost or oxr, ecb does not need a key)
+func SetFiatConfig(apiKeys map[string]string) {
+	fiatConfigLock.Lock()
+	defer fiatConfigLock.Unlock()
+
+	fiatAPIKeys = apiKeys
+}
+
+// fiatRatesProvider fetches the exchange rates of fiat currencies from one provider
+type fiatRatesProvider interface {
+	// fetchRates returns the base currency of the provider and the rates of the currencies in units of each currency per unit of the base
+	// currency, the base currency does not need to be in the returned rates
+	fetchRates(currencies []string) (string, map[string]float64, error)
+}
+
+// fiatProvidersFeed returns the price of a fiat currency in units of another fiat currency from the first provider that returns both
+// rates, so the feed keeps working when a provider is down or does not list one of the currencies
+type fiatProvidersFeed struct {
+	asset     string
+	quote     string
+	names     []string
+	providers []fiatRatesProvider
+
+	// uninitialized
+	activeIndex int
+}
+
+// ensure that it implements PriceFeed
+var _ api.PriceFeed = &fiatProvidersFeed{}
+
+// makeFiatProvidersFeed makes the feed from the URL of a "fiat" price feed in the format <asset>/<quote>[/<provider>|<provider>...]
+// e.g. NGN/USD/oxr|ecb, where the providers are tried in order
+func makeFiatProvidersFeed(feedURL string) (*fiatProvidersFeed, error) {
+	urlParts := strings.Split(feedURL, "/")
+	if len(urlParts) < 2 || len(urlParts) > 3 {
+		return nil, fmt.Errorf("invalid format of fiat URL, needs to be <asset>/<quote>[/<provider>|<provider>...] but was '%s'", feedURL)
+	}
+	asset := strings.ToUpper(strings.TrimSpace(urlParts[0]))
+	quote := strings.ToUpper(strings.TrimSpace(urlParts[1]))
+	if asset == "" || quote == "" || asset == quote {
+		return nil, fmt.Errorf("the asset and quote currencies of fiat URL '%s' need to be set and different", feedURL)
+	}
+
+	fiatConfigLock.Lock()
+	apiKeys := fiatAPIKeys
+	fiatConfigLock.Unlock()
+
+	names := []string{}
+	if len(urlParts) == 3 {
+		for _, name := range strings.Split(urlParts[2], "|") {
+			names = append(names, strings.ToLower(strings.TrimSpace(name)))
+		}
+	} else {
+		for _, name := range fiatProviderDefaultOrder {
+			if name == fiatProviderEcb || apiKeys[name] != "" {
+				names = append(names, name)
+			}
+		}
+	}
+
+	client := http.Client{Timeout: 10 * time.Second}
+	providers := []fiatRatesProvider{}
+	for _, name := range names {
+		switch name {
+		case fiatProviderExchangeRateHost, fiatProviderOxr:
+			apiKey := apiKeys[name]
+			if apiKey == "" {
+				return nil, fmt.Errorf("fiat provider '%s' needs an API key in FIAT_API_KEYS of the trader config", name)
+			}
+			if name == fiatProviderOxr {
+				providers = append(providers, &fiatOxrProvider{appID: apiKey, client: client})
+			} else {
+				providers = append(providers, &fiatExchangeRateHostProvider{accessKey: apiKey, client: client})
+			}
+		case fiatProviderEcb:
+			providers = append(providers, &fiatEcbProvider{client: client})
+		default:
+			return nil, fmt.Errorf("unknown fiat provider '%s', needs to be one of %v", name, fiatProviderDefaultOrder)
+		}
+	}
+
+	return &fiatProvidersFeed{
+		asset:     asset,
+		quote:     quote,
+		names:     names,
+		providers: providers,
+	}, nil
+}
+
+// GetPrice impl, returns the price of one unit of the asset in units of the quote
+func (f *fiatProvidersFeed) GetPrice() (float64, error) {
+	failures := []string{}
+	for i, p := range f.providers {
+		price, e := f.crossRate(p)
+		if e != nil {
+			failures = append(failures, fmt.Sprintf("%s: %s", f.names[i], e))
+			continue
+		}
+
+		f.activate(i, failures)
+		return price, nil
+	}
+	return 0, fmt.Errorf("all %d fiat providers failed to return the price of %s in %s: %v", len(f.providers), f.asset, f.quote, failures)
+}
+
+// crossRate computes the price of the asset in units of the quote from the rates of the provider, which are relative to its own base
+func (f *fiatProvidersFeed) crossRate(p fiatRatesProvider) (float64, error) {
+	base, rates, e := p.fetchRates([]string{f.asset, f.quote})
+	if e != nil {
+		return 0, e
+	}
+	rateOf := func(currency string) (float64, error) {
+		if currency == base {
+			return 1.0, nil
+		}
+		rate, ok := rates[currency]
+		if !ok {
+			return 0, fmt.Errorf("no rate for %s", currency)
+		}
+		if rate <= 0 {
+			return 0, fmt.Errorf("rate for %s was <= 0.0 (%.10f)", currency, rate)
+		}
+		return rate, nil
+	}
+
+	assetRate, e := rateOf(f.asset)
+	if e != nil {
+		return 0, e
+	}
+	quoteRate, e := rateOf(f.quote)
+	if e != nil {
+		return 0, e
+	}
+	return quoteRate / assetRate, nil
+}
+
+// activate marks the provider at index i as the one in use and raises an alert whenever we fall back to a different provider
+func (f *fiatProvidersFeed) activate(i int, failures []string) {
+	if i == f.activeIndex {
+		return
+	}
+	f.activeIndex = i
+	if i == 0 {
+		log.Printf("fiat feed %s/%s recovered, using fiat provider '%s' again\n", f.asset, f.quote, f.names[0])
+		return
+	}
+
+	description := fmt.Sprintf("fiat feed %s/%s is using fiat provider '%s'", f.asset, f.quote, f.names[i])
+	log.Printf("%s because of the following failures: %v\n", description, failures)
+	if priceFeedAlert == nil {
+		return
+	}
+	e := priceFeedAlert.Trigger(description, failures)
+	if e != nil {
+		log.Printf("unable to trigger alert for fiat provider fallback: %s\n", e)
+	}
+}
+
+// fiatExchangeRateHostProvider fetches rates from exchangerate.host, which returns them in the same format as the currencylayer API
+type fiatExchangeRateHostProvider struct {
+	accessKey string
+	client    http.Client
+}
+
+// fetchRates impl.
+func (p *fiatExchangeRateHostProvider) fetchRates(currencies []string) (string, map[string]float64, error) {
+	const source = "USD"
+	u := fmt.Sprintf("%s/live?access_key=%s&source=%s&currencies=%s",
+		fiatExchangeRateHostBaseURL, url.QueryEscape(p.accessKey), source, url.QueryEscape(strings.Join(currencies, ",")))
+	var ret fiatAPIReturn
+	e := fiatGetJSON(p.client, u, &ret)
+	if e != nil {
+		return "", nil, e
+	}
+	if !ret.Success {
+		return "", nil, ret.Error
+	}
+
+	// quotes are keyed by the source followed by the currency, e.g. USDNGN
+	rates := map[string]float64{}
+	for key, rate := range ret.Quotes {
+		rates[strings.TrimPrefix(key, source)] = rate
+	}
+	return source, rates, nil
+}
+
+// fiatOxrProvider fetches rates from Open Exchange Rates, the free plan only allows USD as the base currency
+type fiatOxrProvider struct {
+	appID  string
+	client http.Client
+}
+
+// fetchRates impl.
+func (p *fiatOxrProvider) fetchRates(currencies []string) (string, map[string]float64, error) {
+	u := fmt.Sprintf("%s/latest.json?app_id=%s&symbols=%s", fiatOxrBaseURL, url.QueryEscape(p.appID), url.QueryEscape(strings.Join(currencies, ",")))
+	res, e := p.client.Get(u)
+	if e != nil {
+		return "", nil, e
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		var oxrErr oxrError
+		if e := json.NewDecoder(res.Body).Decode(&oxrErr); e != nil {
+			return "", nil, fmt.Errorf("status code %d", res.StatusCode)
+		}
+		return "", nil, oxrErr
+	}
+
+	var rates oxrRates
+	if e := json.NewDecoder(res.Body).Decode(&rates); e != nil {
+		return "", nil, e
+	}
+	return rates.Base, rates.Rates, nil
+}
+
+// fiatEcbProvider fetches the daily reference rates of the European Central Bank, which does not need an API key but only lists about 30
+// currencies and is updated once per business day
+type fiatEcbProvider struct {
+	client http.Client
+}
+
+// ecbEnvelope is the XML document of the daily reference rates, which are in units of each currency per EUR
+type ecbEnvelope struct {
+	Cube struct {
+		Cube struct {
+			Time  string `xml:"time,attr"`
+			Rates []struct {
+				Currency string `xml:"currency,attr"`
+				Rate     string `xml:"rate,attr"`
+			} `xml:"Cube"`
+		} `xml:"Cube"`
+	} `xml:"Cube"`
+}
+
+// fetchRates impl.
+func (p *fiatEcbProvider) fetchRates(currencies []string) (string, map[string]float64, error) {
+	res, e := p.client.Get(fiatEcbDailyURL)
+	if e != nil {
+		return "", nil, e
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return "", nil, fmt.Errorf("status code %d", res.StatusCode)
+	}
+
+	var envelope ecbEnvelope
+	if e := xml.NewDecoder(res.Body).Decode(&envelope); e != nil {
+		return "", nil, fmt.Errorf("could not decode the ECB reference rates: %s", e)
+	}
+	rates := map[string]float64{}
+	for _, r := range envelope.Cube.Cube.Rates {
+		rate, e := strconv.ParseFloat(r.Rate, 64)
+		if e != nil {
+			return "", nil, fmt.Errorf("could not parse the ECB rate '%s' of %s: %s", r.Rate, r.Currency, e)
+		}
+		rates[r.Currency] = rate
+	}
+	return "EUR", rates, nil
+}
+
+// fiatGetJSON fetches the URL and decodes the JSON response, failing on a status code other than 200
+func fiatGetJSON(client http.Client, u string, target interface{}) error {
+	res, e := client.Get(u)
+	if e != nil {
+		return e
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("status code %d", res.StatusCode)
+	}
+	return json.NewDecoder(res.Body).Decode(target)
+}
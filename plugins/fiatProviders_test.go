@@ -0,0 +1,176 @@
+package plugins
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testEcbResponse = `<?xml version="1.0" encoding="UTF-8"?>
+<gesmes:Envelope xmlns:gesmes="http://www.gesmes.org/xml/2002-08-01" xmlns="http://www.ecb.int/vocabulary/2002-08-01/eurofxref">
+	<gesmes:subject>Reference rates</gesmes:subject>
+	<Cube>
+		<Cube time="2020-01-02">
+			<Cube currency="USD" rate="1.25"/>
+			<Cube currency="GBP" rate="0.8"/>
+		</Cube>
+	</Cube>
+</gesmes:Envelope>`
+
+func TestMakeFiatProvidersFeed(t *testing.T) {
+	SetFiatConfig(map[string]string{"oxr": "key"})
+	defer SetFiatConfig(nil)
+
+	testCases := []struct {
+		url       string
+		wantNames []string
+		wantErr   bool
+	}{
+		{url: "usd/ngn", wantNames: []string{"oxr", "ecb"}},
+		{url: "USD/NGN/ecb|oxr", wantNames: []string{"ecb", "oxr"}},
+		{url: "USD/NGN/exchangeratehost", wantErr: true},
+		{url: "USD/NGN/unknown", wantErr: true},
+		{url: "USD/USD", wantErr: true},
+		{url: "USD", wantErr: true},
+		{url: "USD/NGN/oxr/ecb", wantErr: true},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.url, func(t *testing.T) {
+			feed, e := makeFiatProvidersFeed(k.url)
+			if k.wantErr {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, "USD", feed.asset)
+			assert.Equal(t, "NGN", feed.quote)
+			assert.Equal(t, k.wantNames, feed.names)
+		})
+	}
+}
+
+func TestFiatProvidersFeed_Fallback(t *testing.T) {
+	oxrCalls := 0
+	oxr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		oxrCalls++
+		assert.Equal(t, "key", r.URL.Query().Get("app_id"))
+		assert.Equal(t, "GBP,USD", r.URL.Query().Get("symbols"))
+		if oxrCalls == 1 {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"error": true, "status": 401, "message": "invalid_app_id", "description": "invalid"}`)
+			return
+		}
+		fmt.Fprint(w, `{"base": "USD", "rates": {"GBP": 0.5}}`)
+	}))
+	defer oxr.Close()
+	ecb := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, testEcbResponse)
+	}))
+	defer ecb.Close()
+
+	defer func(oxrURL, ecbURL string) {
+		fiatOxrBaseURL, fiatEcbDailyURL = oxrURL, ecbURL
+	}(fiatOxrBaseURL, fiatEcbDailyURL)
+	fiatOxrBaseURL, fiatEcbDailyURL = oxr.URL, ecb.URL
+	SetFiatConfig(map[string]string{"oxr": "key"})
+	defer SetFiatConfig(nil)
+
+	feed, e := makeFiatProvidersFeed("GBP/USD")
+	if !assert.NoError(t, e) {
+		return
+	}
+
+	// oxr fails so the price is the cross rate of the ecb rates: 1.25 USD/EUR / 0.8 GBP/EUR
+	price, e := feed.GetPrice()
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.InDelta(t, 1.5625, price, 1e-9)
+	assert.Equal(t, 1, feed.activeIndex)
+
+	// oxr recovers, its base is USD so the price is 1 / 0.5
+	price, e = feed.GetPrice()
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.InDelta(t, 2.0, price, 1e-9)
+	assert.Equal(t, 0, feed.activeIndex)
+}
+
+func TestFiatProvidersFeed_AllFail(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"success": true, "quotes": {"USDEUR": 0.8}}`)
+	}))
+	defer ts.Close()
+
+	defer func(u string) { fiatExchangeRateHostBaseURL = u }(fiatExchangeRateHostBaseURL)
+	fiatExchangeRateHostBaseURL = ts.URL
+	SetFiatConfig(map[string]string{"exchangeratehost": "key"})
+	defer SetFiatConfig(nil)
+
+	feed, e := makeFiatProvidersFeed("EUR/NGN/exchangeratehost")
+	if !assert.NoError(t, e) {
+		return
+	}
+	_, e = feed.GetPrice()
+	if assert.Error(t, e) {
+		assert.Contains(t, e.Error(), "exchangeratehost: no rate for NGN")
+	}
+}
+
+func TestFiatFeed_QuoteKey(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "", r.URL.Fragment)
+		fmt.Fprint(w, `{"success": true, "quotes": {"USDNGN": 400.0, "USDEUR": 0.8}}`)
+	}))
+	defer ts.Close()
+
+	testCases := []struct {
+		url       string
+		wantPrice float64
+		wantErr   bool
+	}{
+		{url: ts.URL + "/live?currencies=NGN", wantPrice: 0.0025},
+		{url: ts.URL + "/live?currencies=NGN,EUR#usdeur", wantPrice: 1.25},
+		{url: ts.URL + "/live?source=GBP&currencies=NGN", wantErr: true},
+		{url: ts.URL + "/live?currencies=NGN,EUR", wantErr: true},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.url, func(t *testing.T) {
+			price, e := newFiatFeed(k.url).GetPrice()
+			if k.wantErr {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.InDelta(t, k.wantPrice, price, 1e-9)
+		})
+	}
+}
+
+func TestFiatFeedOxr_QuoteKey(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"base": "USD", "rates": {"NGN": 400.0, "EUR": 0.8}}`)
+	}))
+	defer ts.Close()
+
+	price, e := newFiatFeedOxr(ts.URL + "/latest.json?symbols=eur").GetPrice()
+	if assert.NoError(t, e) {
+		assert.Equal(t, 0.8, price)
+	}
+	price, e = newFiatFeedOxr(ts.URL + "/latest.json#NGN").GetPrice()
+	if assert.NoError(t, e) {
+		assert.Equal(t, 400.0, price)
+	}
+	_, e = newFiatFeedOxr(ts.URL + "/latest.json#GBP").GetPrice()
+	assert.Error(t, e)
+}
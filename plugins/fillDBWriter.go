@@ -179,6 +179,12 @@ func registerMarket(db *sql.DB, market *tradingMarket) error {
 
 // HandleFill impl.
 func (f *FillDBWriter) HandleFill(trade model.Trade) error {
+	_, e := f.InsertFill(trade)
+	return e
+}
+
+// InsertFill writes the trade to the db and returns false when the trade was already in the db
+func (f *FillDBWriter) InsertFill(trade model.Trade) (bool, error) {
 	txid := utils.CheckedString(trade.TransactionID)
 	timeSeconds := trade.Timestamp.AsInt64() / 1000
 	date := time.Unix(timeSeconds, 0).UTC()
@@ -186,7 +192,7 @@ func (f *FillDBWriter) HandleFill(trade model.Trade) error {
 
 	market, e := f.fetchOrRegisterMarket(trade)
 	if e != nil {
-		return fmt.Errorf("cannot fetch or register market for trade (txid=%s): %s", txid, e)
+		return false, fmt.Errorf("cannot fetch or register market for trade (txid=%s): %s", txid, e)
 	}
 
 	sqlInsert := fmt.Sprintf(kelpdb.SqlTradesInsertTemplate,
@@ -206,15 +212,15 @@ func (f *FillDBWriter) HandleFill(trade model.Trade) error {
 	if e != nil {
 		if strings.Contains(e.Error(), "duplicate key value violates unique constraint \"trades_pkey\"") {
 			log.Printf("trying to reinsert trade (txid=%s) to db, ignore and continue\n", txid)
-			return nil
+			return false, nil
 		}
 
 		// return an error on any other errors
-		return fmt.Errorf("could not execute sql insert values statement (%s): %s", sqlInsert, e)
+		return false, fmt.Errorf("could not execute sql insert values statement (%s): %s", sqlInsert, e)
 	}
 
 	log.Printf("wrote trade (txid=%s) to db\n", txid)
-	return nil
+	return true, nil
 }
 
 func (f *FillDBWriter) checkedFloat(n *model.Number) interface{} {
@@ -28,7 +28,15 @@ type FillTracker struct {
 	isRunningInBackground   bool
 
 	// uninitialized
-	handlers []api.FillHandler
+	handlers          []api.FillHandler
+	additionalMarkets []*fillTrackerMarket
+}
+
+// fillTrackerMarket is another market of the trading account whose fills are passed to the same handlers as the fills of the trading pair
+type fillTrackerMarket struct {
+	name          string
+	fillTrackable api.FillTrackable
+	lastCursor    interface{}
 }
 
 // enforce FillTracker implementing api.FillTracker
@@ -42,7 +50,7 @@ func MakeFillTracker(
 	fillTrackerSleepMillis uint32,
 	fillTrackerDeleteCyclesThreshold int64,
 	lastCursor interface{},
-) api.FillTracker {
+) *FillTracker {
 	return &FillTracker{
 		pair:                             pair,
 		threadTracker:                    threadTracker,
@@ -111,9 +119,30 @@ func (f *FillTracker) FillTrackSingleIteration() ([]model.Trade, error) {
 	f.lockFill.Lock()
 	defer f.lockFill.Unlock()
 
-	tradeHistoryResult, e := f.fillTrackable.GetTradeHistory(*f.GetPair(), f.lastCursor, nil)
+	trades, lastCursor, e := f.trackMarketFills(f.fillTrackable, f.lastCursor)
+	if e != nil {
+		return nil, e
+	}
+	f.lastCursor = lastCursor
+
+	for _, m := range f.additionalMarkets {
+		marketTrades, marketCursor, e := f.trackMarketFills(m.fillTrackable, m.lastCursor)
+		if e != nil {
+			return nil, fmt.Errorf("error when tracking fills of the %s market: %s", m.name, e)
+		}
+		m.lastCursor = marketCursor
+		trades = append(trades, marketTrades...)
+	}
+
+	f.fillTrackerDeleteCycles = 0
+	return trades, nil
+}
+
+// trackMarketFills passes the trades after lastCursor to the handlers and returns the trades and the updated cursor, the caller should hold lockFill
+func (f *FillTracker) trackMarketFills(fillTrackable api.FillTrackable, lastCursor interface{}) ([]model.Trade, interface{}, error) {
+	tradeHistoryResult, e := fillTrackable.GetTradeHistory(*f.GetPair(), lastCursor, nil)
 	if e != nil {
-		return nil, fmt.Errorf("error when fetching trades: %s", e)
+		return nil, nil, fmt.Errorf("error when fetching trades: %s", e)
 	}
 
 	if len(tradeHistoryResult.Trades) > 0 {
@@ -144,27 +173,25 @@ func (f *FillTracker) FillTrackSingleIteration() ([]model.Trade, error) {
 
 		// now check for errors in triggering the goroutines
 		if e != nil {
-			return nil, fmt.Errorf("error spawning fill handler: %s", e)
+			return nil, nil, fmt.Errorf("error spawning fill handler: %s", e)
 		}
 
 		// check result of goroutine calls
 		select {
 		case e := <-ech:
 			// always return an error if any of the fill handlers returns an error
-			return nil, fmt.Errorf("caught an error when tracking fills: %s", e)
+			return nil, nil, fmt.Errorf("caught an error when tracking fills: %s", e)
 		default:
 			// do nothing
 		}
 
 		// only update lastCursor if there were trades
-		f.lastCursor = tradeHistoryResult.Cursor
-		log.Printf("updated lastCursor value to %v\n", f.lastCursor)
+		lastCursor = tradeHistoryResult.Cursor
+		log.Printf("updated lastCursor value to %v\n", lastCursor)
 	} else {
-		log.Printf("there were no trades, leaving lastCursor value as %v\n", f.lastCursor)
+		log.Printf("there were no trades, leaving lastCursor value as %v\n", lastCursor)
 	}
-
-	f.fillTrackerDeleteCycles = 0
-	return tradeHistoryResult.Trades, nil
+	return tradeHistoryResult.Trades, lastCursor, nil
 }
 
 func (f *FillTracker) sleep() {
@@ -185,6 +212,16 @@ func (f *FillTracker) RegisterHandler(handler api.FillHandler) {
 	f.handlers = append(f.handlers, handler)
 }
 
+// AddMarket tracks the fills of another market of the trading account after lastCursor, such as the market of the bids when they are quoted in
+// a different asset. The fills are passed to the registered handlers after the fills of the trading pair
+func (f *FillTracker) AddMarket(name string, fillTrackable api.FillTrackable, lastCursor interface{}) {
+	f.additionalMarkets = append(f.additionalMarkets, &fillTrackerMarket{
+		name:          name,
+		fillTrackable: fillTrackable,
+		lastCursor:    lastCursor,
+	})
+}
+
 // NumHandlers impl
 func (f *FillTracker) NumHandlers() uint8 {
 	return uint8(len(f.handlers))
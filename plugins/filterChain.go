@@ -0,0 +1,249 @@
+package plugins
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+)
+
+// FilterPriority orders the filters in the chain that do not depend on each other, lower values are applied first
+type FilterPriority int
+
+// type of FilterPriority
+const (
+	FilterPriorityFirst       FilterPriority = 0    // filters that refuse ops outright, such as the pair whitelist
+	FilterPriorityRisk        FilterPriority = 100  // filters that stop all trading, such as the trailing stop and the circuit breakers
+	FilterPriorityPrice       FilterPriority = 200  // filters that check or change the price of ops
+	FilterPriorityAmount      FilterPriority = 300  // filters that clamp the amount of ops, such as the volume filter
+	FilterPriorityDefault     FilterPriority = 500  // filters that do not declare a FilterOrder
+	FilterPriorityConstraints FilterPriority = 900  // filters that enforce the constraints of the exchange on the final ops
+	FilterPriorityLast        FilterPriority = 1000 // filters that only observe the ops that are submitted, such as the trade tap
+)
+
+// IDs of the filters used in the After list of a FilterOrder
+const (
+	filterIDPairWhitelist    = "pairWhitelist"
+	filterIDMakerMode        = "makerMode"
+	filterIDTradingHours     = "tradingHours"
+	filterIDTradeCount       = "tradeCount"
+	filterIDTrailingStop     = "trailingStop"
+	filterIDDrawdown         = "drawdown"
+	filterIDVolatility       = "volatility"
+	filterIDPriceBand        = "priceBand"
+	filterIDMinPrice         = "minPrice"
+	filterIDMaxPrice         = "maxPrice"
+	filterIDPriceFeed        = "priceFeed"
+	filterIDVolume           = "volume"
+	filterIDExposure         = "exposure"
+	filterIDPortfolioBudget  = "portfolioBudget"
+	filterIDOrderSize        = "orderSize"
+	filterIDBotCoordination  = "botCoordination"
+	filterIDOrderConstraints = "orderConstraints"
+	filterIDTradeTap         = "tradeTap"
+)
+
+// priceFilterIDs are the filters that can change or drop ops based on their price, which need to run before the filters that clamp amounts
+var priceFilterIDs = []string{filterIDMakerMode, filterIDPriceBand, filterIDMinPrice, filterIDMaxPrice, filterIDPriceFeed}
+
+// priceAndOrderSizeFilterIDs are the filters that need to run before the filters that clamp the total amount of the ops, so the totals are
+// computed from the ops that will be submitted
+var priceAndOrderSizeFilterIDs = append(append([]string{}, priceFilterIDs...), filterIDOrderSize)
+
+// FilterOrder declares where a filter is applied in the filter chain
+type FilterOrder struct {
+	ID       string         // identifies the filter in the After list of other filters, multiple filters can share an ID
+	Priority FilterPriority // orders the filters that do not depend on each other
+	After    []string       // IDs of the filters that need to be applied before this filter, ignored when they are not in the chain
+}
+
+// OrderedSubmitFilter is an optional interface that a SubmitFilter can implement to declare its position in the filter chain
+type OrderedSubmitFilter interface {
+	SubmitFilter
+	FilterOrder() FilterOrder
+}
+
+// getFilterOrder returns the FilterOrder of a filter, or the default order when the filter does not implement OrderedSubmitFilter
+func getFilterOrder(filter SubmitFilter) FilterOrder {
+	if f, ok := filter.(OrderedSubmitFilter); ok {
+		return f.FilterOrder()
+	}
+	return FilterOrder{
+		ID:       fmt.Sprintf("%T", filter),
+		Priority: FilterPriorityDefault,
+	}
+}
+
+// configuredFilter is a filter declared in a FILTER table of the trader config, which can have a name and a different priority
+type configuredFilter struct {
+	SubmitFilter
+	name     string
+	priority *FilterPriority
+}
+
+// MakeConfiguredFilter wraps a filter so it is labelled with name in the logs and metrics, and is ordered by priority in the filter chain
+// instead of the priority declared by the filter. An empty name or a nil priority keeps the label or priority of the filter
+func MakeConfiguredFilter(filter SubmitFilter, name string, priority *int) SubmitFilter {
+	var p *FilterPriority
+	if priority != nil {
+		fp := FilterPriority(*priority)
+		p = &fp
+	}
+	return &configuredFilter{
+		SubmitFilter: filter,
+		name:         name,
+		priority:     p,
+	}
+}
+
+var _ OrderedSubmitFilter = &configuredFilter{}
+
+// FilterOrder impl.
+func (f *configuredFilter) FilterOrder() FilterOrder {
+	order := getFilterOrder(f.SubmitFilter)
+	if f.priority != nil {
+		order.Priority = *f.priority
+	}
+	return order
+}
+
+// String is the Stringer method
+func (f *configuredFilter) String() string {
+	if f.name != "" {
+		return f.name
+	}
+	return filterLabel(f.SubmitFilter)
+}
+
+// Unwrap impl.
+func (f *configuredFilter) Unwrap() SubmitFilter {
+	return f.SubmitFilter
+}
+
+// wrappedSubmitFilter is implemented by the filters that wrap another filter to add a concern such as metrics or tracing
+type wrappedSubmitFilter interface {
+	Unwrap() SubmitFilter
+}
+
+// OfferGroupingFilter is an optional interface for a SubmitFilter that places more than one offer for a single op of the strategy. The
+// strategy needs to see each group as one offer, otherwise it prunes the extra offers of the group on every update
+type OfferGroupingFilter interface {
+	// GroupOffers replaces each group of offers with a single offer holding the total amount of the group, and returns the other offers of
+	// the groups separately
+	GroupOffers(sellingOffers []hProtocol.Offer, buyingOffers []hProtocol.Offer) ([]hProtocol.Offer /* selling */, []hProtocol.Offer /* buying */, []hProtocol.Offer /* grouped */)
+}
+
+// SubmitListener is an optional interface for a SubmitFilter that needs to know which ops were submitted after all the filters were applied
+type SubmitListener interface {
+	// Submitted is called with the ops that were left after all the filters and the offers that the filters were applied on, once the ops
+	// are submitted or when there are no ops to submit. e is the error when the submission failed, in which case the offers are unchanged.
+	// It can be called from the goroutine of the async submit callback
+	Submitted(ops []txnbuild.Operation, sellingOffers []hProtocol.Offer, buyingOffers []hProtocol.Offer, e error)
+}
+
+// FindOfferGroupingFilters returns the filters in the chain that implement OfferGroupingFilter, looking through the filters that wrap them
+func FindOfferGroupingFilters(filters []SubmitFilter) []OfferGroupingFilter {
+	groupingFilters := []OfferGroupingFilter{}
+	for _, filter := range filters {
+		if g, ok := unwrapFilterUntil(filter, func(f SubmitFilter) bool {
+			_, ok := f.(OfferGroupingFilter)
+			return ok
+		}).(OfferGroupingFilter); ok {
+			groupingFilters = append(groupingFilters, g)
+		}
+	}
+	return groupingFilters
+}
+
+// FindSubmitListeners returns the filters in the chain that implement SubmitListener, looking through the filters that wrap them
+func FindSubmitListeners(filters []SubmitFilter) []SubmitListener {
+	listeners := []SubmitListener{}
+	for _, filter := range filters {
+		if l, ok := unwrapFilterUntil(filter, func(f SubmitFilter) bool {
+			_, ok := f.(SubmitListener)
+			return ok
+		}).(SubmitListener); ok {
+			listeners = append(listeners, l)
+		}
+	}
+	return listeners
+}
+
+// unwrapFilterUntil returns the first filter in the chain of wrapped filters starting at filter that matches, or nil if none of them match
+func unwrapFilterUntil(filter SubmitFilter, matches func(f SubmitFilter) bool) SubmitFilter {
+	for filter != nil {
+		if matches(filter) {
+			return filter
+		}
+		w, ok := filter.(wrappedSubmitFilter)
+		if !ok {
+			return nil
+		}
+		filter = w.Unwrap()
+	}
+	return nil
+}
+
+// ResolveFilterChain orders the filters so that every filter is applied after the filters it depends on. Filters that do not depend on
+// each other are ordered by priority and then by their position in the input, so the order is deterministic. Returns an error if the
+// dependencies have a cycle
+func ResolveFilterChain(filters []SubmitFilter) ([]SubmitFilter, error) {
+	orders := []FilterOrder{}
+	idxByID := map[string][]int{}
+	for i, filter := range filters {
+		order := getFilterOrder(filter)
+		orders = append(orders, order)
+		idxByID[order.ID] = append(idxByID[order.ID], i)
+	}
+
+	// dependents[j] lists the filters that need to run after filter j, inDegree[i] is the number of filters that need to run before filter i
+	dependents := make([][]int, len(filters))
+	inDegree := make([]int, len(filters))
+	for i, order := range orders {
+		for _, afterID := range order.After {
+			for _, j := range idxByID[afterID] {
+				dependents[j] = append(dependents[j], i)
+				inDegree[i]++
+			}
+		}
+	}
+
+	resolved := []SubmitFilter{}
+	done := make([]bool, len(filters))
+	for len(resolved) < len(filters) {
+		next := -1
+		for i := range filters {
+			if done[i] || inDegree[i] > 0 {
+				continue
+			}
+			if next == -1 || orders[i].Priority < orders[next].Priority {
+				next = i
+			}
+		}
+
+		if next == -1 {
+			cycleIDs := []string{}
+			for i, order := range orders {
+				if !done[i] {
+					cycleIDs = append(cycleIDs, order.ID)
+				}
+			}
+			return nil, fmt.Errorf("cycle in the dependencies between the filters: %s", strings.Join(cycleIDs, ", "))
+		}
+
+		done[next] = true
+		resolved = append(resolved, filters[next])
+		for _, i := range dependents[next] {
+			inDegree[i]--
+		}
+	}
+
+	resolvedIDs := []string{}
+	for _, filter := range resolved {
+		resolvedIDs = append(resolvedIDs, getFilterOrder(filter).ID)
+	}
+	log.Printf("resolved filter chain: [%s]\n", strings.Join(resolvedIDs, ", "))
+	return resolved, nil
+}
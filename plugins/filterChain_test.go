@@ -0,0 +1,137 @@
+package plugins
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+)
+
+// testOrderedFilter is a no-op filter with a configurable FilterOrder
+type testOrderedFilter struct {
+	order FilterOrder
+}
+
+var _ OrderedSubmitFilter = &testOrderedFilter{}
+
+// Apply impl.
+func (f *testOrderedFilter) Apply(ops []txnbuild.Operation, sellingOffers []hProtocol.Offer, buyingOffers []hProtocol.Offer) ([]txnbuild.Operation, error) {
+	return ops, nil
+}
+
+// FilterOrder impl.
+func (f *testOrderedFilter) FilterOrder() FilterOrder {
+	return f.order
+}
+
+// testUnorderedFilter is a no-op filter that does not declare a FilterOrder
+type testUnorderedFilter struct{}
+
+// Apply impl.
+func (f *testUnorderedFilter) Apply(ops []txnbuild.Operation, sellingOffers []hProtocol.Offer, buyingOffers []hProtocol.Offer) ([]txnbuild.Operation, error) {
+	return ops, nil
+}
+
+func makeTestOrderedFilter(id string, priority FilterPriority, after ...string) *testOrderedFilter {
+	return &testOrderedFilter{order: FilterOrder{ID: id, Priority: priority, After: after}}
+}
+
+func TestResolveFilterChain(t *testing.T) {
+	whitelist := makeTestOrderedFilter(filterIDPairWhitelist, FilterPriorityFirst)
+	volume1 := makeTestOrderedFilter(filterIDVolume, FilterPriorityAmount, priceFilterIDs...)
+	volume2 := makeTestOrderedFilter(filterIDVolume, FilterPriorityAmount, priceFilterIDs...)
+	priceBand := makeTestOrderedFilter(filterIDPriceBand, FilterPriorityPrice, filterIDMakerMode)
+	constraints := makeTestOrderedFilter(filterIDOrderConstraints, FilterPriorityConstraints)
+	tap := makeTestOrderedFilter(filterIDTradeTap, FilterPriorityLast, filterIDOrderConstraints)
+	unordered := &testUnorderedFilter{}
+	// a dependency overrides the priority
+	early := makeTestOrderedFilter("early", FilterPriorityFirst, filterIDTradeTap)
+
+	testCases := []struct {
+		name    string
+		filters []SubmitFilter
+		want    []SubmitFilter
+	}{
+		{
+			name:    "empty",
+			filters: []SubmitFilter{},
+			want:    []SubmitFilter{},
+		}, {
+			name:    "already ordered",
+			filters: []SubmitFilter{whitelist, priceBand, volume1, constraints, tap},
+			want:    []SubmitFilter{whitelist, priceBand, volume1, constraints, tap},
+		}, {
+			name:    "price band before volume clamping",
+			filters: []SubmitFilter{whitelist, volume1, priceBand, volume2, constraints},
+			want:    []SubmitFilter{whitelist, priceBand, volume1, volume2, constraints},
+		}, {
+			name:    "stable for equal priorities",
+			filters: []SubmitFilter{volume2, volume1},
+			want:    []SubmitFilter{volume2, volume1},
+		}, {
+			name:    "unordered filter uses the default priority",
+			filters: []SubmitFilter{tap, unordered, constraints, volume1},
+			want:    []SubmitFilter{volume1, unordered, constraints, tap},
+		}, {
+			name:    "dependency overrides priority",
+			filters: []SubmitFilter{early, tap, whitelist, constraints},
+			want:    []SubmitFilter{whitelist, constraints, tap, early},
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			actual, e := ResolveFilterChain(k.filters)
+			if !assert.NoError(t, e) {
+				return
+			}
+			if !assert.Equal(t, len(k.want), len(actual)) {
+				return
+			}
+			// compare by identity since filters with the same FilterOrder are deeply equal
+			for i := range k.want {
+				assert.True(t, k.want[i] == actual[i], "unexpected filter at index %d: %s", i, getFilterOrder(actual[i]).ID)
+			}
+		})
+	}
+}
+
+func TestResolveFilterChain_Cycle(t *testing.T) {
+	a := makeTestOrderedFilter("a", FilterPriorityDefault, "b")
+	b := makeTestOrderedFilter("b", FilterPriorityDefault, "a")
+	independent := makeTestOrderedFilter("c", FilterPriorityDefault)
+
+	_, e := ResolveFilterChain([]SubmitFilter{independent, a, b})
+	if !assert.Error(t, e) {
+		return
+	}
+	assert.Contains(t, e.Error(), "a, b")
+}
+
+func TestResolveFilterChain_ConfiguredFilter(t *testing.T) {
+	whitelist := makeTestOrderedFilter(filterIDPairWhitelist, FilterPriorityFirst)
+	priceBand := makeTestOrderedFilter(filterIDPriceBand, FilterPriorityPrice, filterIDMakerMode)
+	volume := makeTestOrderedFilter(filterIDVolume, FilterPriorityAmount, priceFilterIDs...)
+	// a lower priority does not move the volume filter before the price band that it depends on
+	early := -1
+	configuredVolume := MakeConfiguredFilter(volume, "daily cap", &early)
+	configuredPriceBand := MakeConfiguredFilter(priceBand, "", nil)
+
+	actual, e := ResolveFilterChain([]SubmitFilter{whitelist, configuredVolume, configuredPriceBand})
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, []SubmitFilter{whitelist, configuredPriceBand, configuredVolume}, actual)
+
+	// without the price band the volume filter moves before the whitelist
+	actual, e = ResolveFilterChain([]SubmitFilter{whitelist, configuredVolume})
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, []SubmitFilter{configuredVolume, whitelist}, actual)
+	assert.Equal(t, "daily cap", filterLabel(configuredVolume))
+	assert.Equal(t, filterIDPriceBand, filterLabel(configuredPriceBand))
+	assert.Equal(t, FilterOrder{ID: filterIDPriceBand, Priority: FilterPriorityPrice, After: []string{filterIDMakerMode}}, getFilterOrder(configuredPriceBand))
+}
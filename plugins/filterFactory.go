@@ -6,8 +6,10 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/kelp/api"
 	"github.com/stellar/kelp/model"
 	"github.com/stellar/kelp/queries"
 )
@@ -23,9 +25,19 @@ func init() {
 }
 
 var filterMap = map[string]func(f *FilterFactory, configInput string) (SubmitFilter, error){
-	"volume":    filterVolume,
-	"price":     filterPrice,
-	"priceFeed": filterPriceFeed,
+	"volume":          filterVolume,
+	"price":           filterPrice,
+	"priceFeed":       filterPriceFeed,
+	"trailingStop":    filterTrailingStop,
+	"priceBand":       filterPriceBand,
+	"exposure":        filterExposure,
+	"drawdown":        filterDrawdown,
+	"volatility":      filterVolatility,
+	"orderSize":       filterOrderSize,
+	"tradingHours":    filterTradingHours,
+	"botCoordination": filterBotCoordination,
+	"tradeCount":      filterTradeCount,
+	"portfolioBudget": filterPortfolioBudget,
 }
 
 // FilterFactory is a struct that handles creating all the filters
@@ -36,6 +48,8 @@ type FilterFactory struct {
 	BaseAsset      hProtocol.Asset
 	QuoteAsset     hProtocol.Asset
 	DB             *sql.DB
+	AccountID      string    // the account_id of the trades written to the DB by this bot, can be empty
+	Alert          api.Alert // can be nil
 }
 
 // MakeFilter is the function that makes the required filters
@@ -59,6 +73,13 @@ func filterVolume(f *FilterFactory, configInput string) (SubmitFilter, error) {
 	if e != nil {
 		return nil, fmt.Errorf("could not make VolumeFilterConfig for configInput (%s): %s", configInput, e)
 	}
+	if config.accountScope && len(config.optionalAccountIDs) == 0 {
+		// the account scope defaults to the account of this bot
+		if f.AccountID == "" {
+			return nil, fmt.Errorf("the account scope of the volume filter (%s) needs DB_OVERRIDE__ACCOUNT_ID to be set or the account_ids modifier", configInput)
+		}
+		config.optionalAccountIDs = []string{f.AccountID}
+	}
 
 	return makeFilterVolume(
 		configInput,
@@ -77,6 +98,7 @@ func makeRawVolumeFilterConfig(
 	baseAssetCapInQuoteUnits *float64,
 	action queries.DailyVolumeAction,
 	mode volumeFilterMode,
+	window volumeFilterWindow,
 	additionalMarketIDs []string,
 	optionalAccountIDs []string,
 ) *VolumeFilterConfig {
@@ -85,6 +107,7 @@ func makeRawVolumeFilterConfig(
 		BaseAssetCapInQuoteUnits: baseAssetCapInQuoteUnits,
 		action:                   action,
 		mode:                     mode,
+		window:                   window,
 		additionalMarketIDs:      additionalMarketIDs,
 		optionalAccountIDs:       optionalAccountIDs,
 	}
@@ -103,9 +126,11 @@ func makeVolumeFilterConfig(configInput string) (*VolumeFilterConfig, error) {
 	config := &VolumeFilterConfig{mode: mode}
 
 	limitWindowParts := strings.Split(parts[1], ":")
-	if limitWindowParts[0] != "daily" {
-		return nil, fmt.Errorf("invalid input (%s), the second part needs to equal or start with \"daily\"", configInput)
+	window, e := parseVolumeFilterWindow(limitWindowParts[0])
+	if e != nil {
+		return nil, fmt.Errorf("invalid input (%s), the second part needs to equal or start with \"daily\", \"rolling7d\", \"weekly\" or \"monthly\": %s", configInput, e)
 	}
+	config.window = window
 
 	action, e := queries.ParseDailyVolumeAction(parts[2])
 	if e != nil {
@@ -113,7 +138,7 @@ func makeVolumeFilterConfig(configInput string) (*VolumeFilterConfig, error) {
 	}
 	config.action = action
 
-	errInvalid := fmt.Errorf("invalid input (%s), the modifier for \"daily\" can be either \"market_ids\" or \"account_ids\" like so 'daily:market_ids=[4c19915f47,db4531d586]' or 'daily:account_ids=[account1,account2]' or 'daily:market_ids=[4c19915f47,db4531d586]:account_ids=[account1,account2]'", configInput)
+	errInvalid := fmt.Errorf("invalid input (%s), the modifier for the window can be either \"market_ids\" or \"account_ids\" or \"scope\" like so 'daily:market_ids=[4c19915f47,db4531d586]' or 'daily:account_ids=[account1,account2]' or 'daily:market_ids=[4c19915f47,db4531d586]:account_ids=[account1,account2]' or 'daily:scope=account'", configInput)
 	if len(limitWindowParts) == 2 {
 		e = addModifierToConfig(config, limitWindowParts[1])
 		if e != nil {
@@ -130,7 +155,7 @@ func makeVolumeFilterConfig(configInput string) (*VolumeFilterConfig, error) {
 			return nil, fmt.Errorf("%s: could not addModifierToConfig for %s: %s", errInvalid, limitWindowParts[2], e)
 		}
 	} else if len(limitWindowParts) != 1 {
-		return nil, fmt.Errorf("invalid input (%s), the second part needs to be \"daily\" and can have only one modifier \"market_ids\" like so 'daily:market_ids=[4c19915f47,db4531d586]'", configInput)
+		return nil, fmt.Errorf("invalid input (%s), the second part needs to be the window and can have at most one \"market_ids\" and one \"account_ids\" modifier like so 'daily:market_ids=[4c19915f47,db4531d586]:account_ids=[account1,account2]'", configInput)
 	}
 
 	limit, e := strconv.ParseFloat(parts[4], 64)
@@ -152,6 +177,14 @@ func makeVolumeFilterConfig(configInput string) (*VolumeFilterConfig, error) {
 }
 
 func addModifierToConfig(config *VolumeFilterConfig, modifierMapping string) error {
+	if strings.HasPrefix(modifierMapping, "scope=") {
+		if modifierMapping != "scope=account" {
+			return fmt.Errorf("invalid scope modifier '%s', the only supported scope is \"account\"", modifierMapping)
+		}
+		config.accountScope = true
+		return nil
+	}
+
 	ids, modifierType, e := parseVolumeFilterModifier(modifierMapping)
 	if e != nil {
 		return fmt.Errorf("could not parseVolumeFilterModifier: %s", e)
@@ -265,3 +298,392 @@ func filterPriceFeed(f *FilterFactory, configInput string) (SubmitFilter, error)
 
 	return filter, nil
 }
+
+func filterTrailingStop(f *FilterFactory, configInput string) (SubmitFilter, error) {
+	// parts[0] = "trailingStop", parts[1] = stopPercent, parts[2] = feedDataType, parts[3] = feedURL which can have more "/" chars
+	parts := strings.Split(configInput, "/")
+	if len(parts) < 4 {
+		return nil, fmt.Errorf("\"trailingStop\" filter needs at least 4 parts separated by the '/' delimiter (trailingStop/<stopPercent>/<feedDataType>/<feedURL>) but we received %s", configInput)
+	}
+
+	stopPercent, e := strconv.ParseFloat(parts[1], 64)
+	if e != nil {
+		return nil, fmt.Errorf("could not parse the second part as a float value from config value (%s): %s", configInput, e)
+	}
+	feedType := parts[2]
+	feedURL := strings.Join(parts[3:len(parts)], "/")
+	pf, e := MakePriceFeed(feedType, feedURL)
+	if e != nil {
+		return nil, fmt.Errorf("could not make price feed for config input string '%s': %s", configInput, e)
+	}
+
+	filter, e := makeFilterTrailingStop(
+		configInput,
+		f.ExchangeName,
+		f.TradingPair,
+		f.AssetDisplayFn,
+		f.BaseAsset,
+		f.QuoteAsset,
+		f.DB,
+		stopPercent,
+		feedType+"/"+feedURL,
+		pf,
+	)
+	if e != nil {
+		return nil, fmt.Errorf("could not make trailing stop filter for config input string '%s': %s", configInput, e)
+	}
+
+	return filter, nil
+}
+
+func filterPriceBand(f *FilterFactory, configInput string) (SubmitFilter, error) {
+	// parts[0] = "priceBand", parts[1] = action, parts[2] = "abs" or "feed"
+	// for "abs": parts[3] = minPrice, parts[4] = maxPrice
+	// for "feed": parts[3] = bandPercent, parts[4] = feedDataType, parts[5] = feedURL which can have more "/" chars
+	parts := strings.Split(configInput, "/")
+	if len(parts) < 5 {
+		return nil, fmt.Errorf("\"priceBand\" filter needs at least 5 parts separated by the '/' delimiter (priceBand/<action>/abs/<minPrice>/<maxPrice> or priceBand/<action>/feed/<bandPercent>/<feedDataType>/<feedURL>) but we received %s", configInput)
+	}
+
+	action, e := ParsePriceBandAction(parts[1])
+	if e != nil {
+		return nil, fmt.Errorf("could not parse the second part of config value (%s): %s", configInput, e)
+	}
+
+	if parts[2] == "abs" {
+		if len(parts) != 5 {
+			return nil, fmt.Errorf("invalid input (%s), \"priceBand\" filter with absolute bounds needs 5 parts separated by the delimiter (/)", configInput)
+		}
+		minPrice, e := strconv.ParseFloat(parts[3], 64)
+		if e != nil {
+			return nil, fmt.Errorf("could not parse the fourth part as a float value from config value (%s): %s", configInput, e)
+		}
+		maxPrice, e := strconv.ParseFloat(parts[4], 64)
+		if e != nil {
+			return nil, fmt.Errorf("could not parse the fifth part as a float value from config value (%s): %s", configInput, e)
+		}
+
+		filter, e := makeFilterPriceBandAbsolute(configInput, f.BaseAsset, f.QuoteAsset, action, minPrice, maxPrice)
+		if e != nil {
+			return nil, fmt.Errorf("could not make price band filter for config input string '%s': %s", configInput, e)
+		}
+		return filter, nil
+	} else if parts[2] != "feed" {
+		return nil, fmt.Errorf("invalid price band type in third argument, needs to be either \"abs\" or \"feed\" (%s)", configInput)
+	}
+
+	if len(parts) < 6 {
+		return nil, fmt.Errorf("invalid input (%s), \"priceBand\" filter with a price feed needs at least 6 parts separated by the delimiter (/)", configInput)
+	}
+	bandPercent, e := strconv.ParseFloat(parts[3], 64)
+	if e != nil {
+		return nil, fmt.Errorf("could not parse the fourth part as a float value from config value (%s): %s", configInput, e)
+	}
+	feedType := parts[4]
+	feedURL := strings.Join(parts[5:len(parts)], "/")
+	pf, e := MakePriceFeed(feedType, feedURL)
+	if e != nil {
+		return nil, fmt.Errorf("could not make price feed for config input string '%s': %s", configInput, e)
+	}
+
+	filter, e := makeFilterPriceBandFeed(configInput, f.BaseAsset, f.QuoteAsset, action, bandPercent, pf)
+	if e != nil {
+		return nil, fmt.Errorf("could not make price band filter for config input string '%s': %s", configInput, e)
+	}
+	return filter, nil
+}
+
+func filterExposure(f *FilterFactory, configInput string) (SubmitFilter, error) {
+	// parts[0] = "exposure", parts[1] = maxLongBase, parts[2] = maxShortBase, followed by an optional "market_ids" and an optional "account_ids" modifier
+	parts := strings.Split(configInput, "/")
+	if len(parts) < 3 || len(parts) > 5 {
+		return nil, fmt.Errorf("\"exposure\" filter needs 3 to 5 parts separated by the '/' delimiter (exposure/<maxLongBase>/<maxShortBase>[/market_ids=[...]][/account_ids=[...]]) but we received %s", configInput)
+	}
+
+	maxLongBase, e := strconv.ParseFloat(parts[1], 64)
+	if e != nil {
+		return nil, fmt.Errorf("could not parse the second part as a float value from config value (%s): %s", configInput, e)
+	}
+	maxShortBase, e := strconv.ParseFloat(parts[2], 64)
+	if e != nil {
+		return nil, fmt.Errorf("could not parse the third part as a float value from config value (%s): %s", configInput, e)
+	}
+
+	var additionalMarketIDs []string
+	var optionalAccountIDs []string
+	for _, modifier := range parts[3:] {
+		ids, modifierType, e := parseVolumeFilterModifier(modifier)
+		if e != nil {
+			return nil, fmt.Errorf("invalid input (%s), could not parse modifier '%s': %s", configInput, modifier, e)
+		}
+		if modifierType == "market_ids" && additionalMarketIDs == nil {
+			additionalMarketIDs = ids
+		} else if modifierType == "account_ids" && optionalAccountIDs == nil {
+			optionalAccountIDs = ids
+		} else {
+			return nil, fmt.Errorf("invalid input (%s), can have at most one \"market_ids\" and one \"account_ids\" modifier", configInput)
+		}
+	}
+
+	filter, e := makeFilterExposure(
+		configInput,
+		f.ExchangeName,
+		f.TradingPair,
+		f.AssetDisplayFn,
+		f.BaseAsset,
+		f.QuoteAsset,
+		f.DB,
+		maxLongBase,
+		maxShortBase,
+		additionalMarketIDs,
+		optionalAccountIDs,
+	)
+	if e != nil {
+		return nil, fmt.Errorf("could not make exposure filter for config input string '%s': %s", configInput, e)
+	}
+	return filter, nil
+}
+
+func filterDrawdown(f *FilterFactory, configInput string) (SubmitFilter, error) {
+	// parts[0] = "drawdown", parts[1] = maxDrawdownPercent, parts[2] = cooldownMinutes, parts[3] = capital, parts[4] = feedDataType, parts[5] = feedURL which can have more "/" chars
+	parts := strings.Split(configInput, "/")
+	if len(parts) < 6 {
+		return nil, fmt.Errorf("\"drawdown\" filter needs at least 6 parts separated by the '/' delimiter (drawdown/<maxDrawdownPercent>/<cooldownMinutes>/<capital>/<feedDataType>/<feedURL>) but we received %s", configInput)
+	}
+
+	maxDrawdownPercent, e := strconv.ParseFloat(parts[1], 64)
+	if e != nil {
+		return nil, fmt.Errorf("could not parse the second part as a float value from config value (%s): %s", configInput, e)
+	}
+	cooldownMinutes, e := strconv.ParseFloat(parts[2], 64)
+	if e != nil {
+		return nil, fmt.Errorf("could not parse the third part as a float value from config value (%s): %s", configInput, e)
+	}
+	capital, e := strconv.ParseFloat(parts[3], 64)
+	if e != nil {
+		return nil, fmt.Errorf("could not parse the fourth part as a float value from config value (%s): %s", configInput, e)
+	}
+	feedType := parts[4]
+	feedURL := strings.Join(parts[5:len(parts)], "/")
+	pf, e := MakePriceFeed(feedType, feedURL)
+	if e != nil {
+		return nil, fmt.Errorf("could not make price feed for config input string '%s': %s", configInput, e)
+	}
+
+	filter, e := makeFilterDrawdown(
+		configInput,
+		f.ExchangeName,
+		f.TradingPair,
+		f.AssetDisplayFn,
+		f.BaseAsset,
+		f.QuoteAsset,
+		f.DB,
+		f.Alert,
+		maxDrawdownPercent,
+		time.Duration(cooldownMinutes*float64(time.Minute)),
+		capital,
+		feedType+"/"+feedURL,
+		pf,
+	)
+	if e != nil {
+		return nil, fmt.Errorf("could not make drawdown filter for config input string '%s': %s", configInput, e)
+	}
+	return filter, nil
+}
+
+func filterVolatility(f *FilterFactory, configInput string) (SubmitFilter, error) {
+	// parts[0] = "volatility", parts[1] = numSamples, parts[2] = maxVolatilityPercent, parts[3] = action ("pause" or "widen=<spreadMultiplier>"),
+	// parts[4] = feedDataType, parts[5] = feedURL which can have more "/" chars
+	parts := strings.Split(configInput, "/")
+	if len(parts) < 6 {
+		return nil, fmt.Errorf("\"volatility\" filter needs at least 6 parts separated by the '/' delimiter (volatility/<numSamples>/<maxVolatilityPercent>/<pause|widen=spreadMultiplier>/<feedDataType>/<feedURL>) but we received %s", configInput)
+	}
+
+	numSamples, e := strconv.Atoi(parts[1])
+	if e != nil {
+		return nil, fmt.Errorf("could not parse the second part as an int value from config value (%s): %s", configInput, e)
+	}
+	maxVolatilityPercent, e := strconv.ParseFloat(parts[2], 64)
+	if e != nil {
+		return nil, fmt.Errorf("could not parse the third part as a float value from config value (%s): %s", configInput, e)
+	}
+
+	action := VolatilityAction(parts[3])
+	spreadMultiplier := 0.0
+	widenPrefix := string(VolatilityActionWiden) + "="
+	if strings.HasPrefix(parts[3], widenPrefix) {
+		action = VolatilityActionWiden
+		spreadMultiplier, e = strconv.ParseFloat(strings.TrimPrefix(parts[3], widenPrefix), 64)
+		if e != nil {
+			return nil, fmt.Errorf("could not parse the spread multiplier in the fourth part as a float value from config value (%s): %s", configInput, e)
+		}
+	} else if action != VolatilityActionPause {
+		return nil, fmt.Errorf("invalid action in fourth argument, needs to be either \"%s\" or \"%s\" (%s)", VolatilityActionPause, widenPrefix+"<spreadMultiplier>", configInput)
+	}
+
+	feedType := parts[4]
+	feedURL := strings.Join(parts[5:len(parts)], "/")
+	pf, e := MakePriceFeed(feedType, feedURL)
+	if e != nil {
+		return nil, fmt.Errorf("could not make price feed for config input string '%s': %s", configInput, e)
+	}
+
+	filter, e := makeFilterVolatility(
+		configInput,
+		f.BaseAsset,
+		f.QuoteAsset,
+		f.Alert,
+		numSamples,
+		maxVolatilityPercent,
+		action,
+		spreadMultiplier,
+		pf,
+	)
+	if e != nil {
+		return nil, fmt.Errorf("could not make volatility filter for config input string '%s': %s", configInput, e)
+	}
+	return filter, nil
+}
+
+func filterOrderSize(f *FilterFactory, configInput string) (SubmitFilter, error) {
+	// parts[0] = "orderSize", parts[1] = minBaseAmount, parts[2] = maxBaseAmount, parts[3] = action ("cap" or "split")
+	parts := strings.Split(configInput, "/")
+	if len(parts) != 4 {
+		return nil, fmt.Errorf("\"orderSize\" filter needs 4 parts separated by the '/' delimiter (orderSize/<minBaseAmount>/<maxBaseAmount>/<cap|split>) but we received %s", configInput)
+	}
+
+	minBaseAmount, e := strconv.ParseFloat(parts[1], 64)
+	if e != nil {
+		return nil, fmt.Errorf("could not parse the second part as a float value from config value (%s): %s", configInput, e)
+	}
+	maxBaseAmount, e := strconv.ParseFloat(parts[2], 64)
+	if e != nil {
+		return nil, fmt.Errorf("could not parse the third part as a float value from config value (%s): %s", configInput, e)
+	}
+
+	filter, e := makeFilterOrderSize(configInput, f.BaseAsset, f.QuoteAsset, minBaseAmount, maxBaseAmount, OrderSizeAction(parts[3]))
+	if e != nil {
+		return nil, fmt.Errorf("could not make order size filter for config input string '%s': %s", configInput, e)
+	}
+	return filter, nil
+}
+
+func filterTradingHours(f *FilterFactory, configInput string) (SubmitFilter, error) {
+	// parts[0] = "tradingHours", parts[1] = comma-separated list of windows, parts[2] = timezone which can have more "/" chars
+	parts := strings.Split(configInput, "/")
+	if len(parts) < 3 {
+		return nil, fmt.Errorf("\"tradingHours\" filter needs at least 3 parts separated by the '/' delimiter (tradingHours/<windows>/<timezone>) but we received %s", configInput)
+	}
+
+	windows := []tradingWindow{}
+	for _, windowString := range strings.Split(parts[1], ",") {
+		w, e := parseTradingWindow(windowString)
+		if e != nil {
+			return nil, fmt.Errorf("could not parse the second part as a list of trading windows from config value (%s): %s", configInput, e)
+		}
+		windows = append(windows, *w)
+	}
+
+	timezone := strings.Join(parts[2:len(parts)], "/")
+	location, e := time.LoadLocation(timezone)
+	if e != nil {
+		return nil, fmt.Errorf("could not load timezone '%s' from config value (%s): %s", timezone, configInput, e)
+	}
+
+	filter, e := makeFilterTradingHours(configInput, f.BaseAsset, f.QuoteAsset, windows, location, MakeSystemClock())
+	if e != nil {
+		return nil, fmt.Errorf("could not make trading hours filter for config input string '%s': %s", configInput, e)
+	}
+	return filter, nil
+}
+
+func filterBotCoordination(f *FilterFactory, configInput string) (SubmitFilter, error) {
+	parts := strings.Split(configInput, "/")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("\"botCoordination\" filter needs 3 parts separated by the '/' delimiter (botCoordination/<botID>/<staleSeconds>) but we received %s", configInput)
+	}
+
+	botID := parts[1]
+	if botID == "" {
+		return nil, fmt.Errorf("the second part (botID) cannot be empty in config value (%s)", configInput)
+	}
+	staleSeconds, e := strconv.ParseInt(parts[2], 10, 64)
+	if e != nil {
+		return nil, fmt.Errorf("could not parse the third part as an int value from config value (%s): %s", configInput, e)
+	}
+
+	filter, e := makeFilterBotCoordination(configInput, botID, f.BaseAsset, f.QuoteAsset, time.Duration(staleSeconds)*time.Second, f.DB, MakeSystemClock())
+	if e != nil {
+		return nil, fmt.Errorf("could not make bot coordination filter for config input string '%s': %s", configInput, e)
+	}
+	return filter, nil
+}
+
+func filterPortfolioBudget(f *FilterFactory, configInput string) (SubmitFilter, error) {
+	parts := strings.Split(configInput, "/")
+	if len(parts) != 2 && len(parts) != 3 {
+		return nil, fmt.Errorf("\"portfolioBudget\" filter needs 2 or 3 parts separated by the '/' delimiter (portfolioBudget/<botID>[/<maxAgeSeconds>]) but we received %s", configInput)
+	}
+
+	maxAge := defaultPortfolioBudgetMaxAge
+	if len(parts) == 3 {
+		maxAgeSeconds, e := strconv.ParseInt(parts[2], 10, 64)
+		if e != nil {
+			return nil, fmt.Errorf("could not parse the third part as an int value from config value (%s): %s", configInput, e)
+		}
+		maxAge = time.Duration(maxAgeSeconds) * time.Second
+	}
+
+	filter, e := makeFilterPortfolioBudget(configInput, parts[1], maxAge, f, MakeSystemClock())
+	if e != nil {
+		return nil, fmt.Errorf("could not make portfolio budget filter for config input string '%s': %s", configInput, e)
+	}
+	return filter, nil
+}
+
+func filterTradeCount(f *FilterFactory, configInput string) (SubmitFilter, error) {
+	// parts[0] = "tradeCount", parts[1] = maxTradesPerDay, followed by an optional "market_ids" and an optional "account_ids" modifier
+	parts := strings.Split(configInput, "/")
+	if len(parts) < 2 || len(parts) > 4 {
+		return nil, fmt.Errorf("\"tradeCount\" filter needs 2 to 4 parts separated by the '/' delimiter (tradeCount/<maxTradesPerDay>[/market_ids=[...]][/account_ids=[...]]) but we received %s", configInput)
+	}
+
+	maxTradesPerDay, e := strconv.ParseInt(parts[1], 10, 64)
+	if e != nil {
+		return nil, fmt.Errorf("could not parse the second part as an int value from config value (%s): %s", configInput, e)
+	}
+
+	var additionalMarketIDs []string
+	var optionalAccountIDs []string
+	for _, modifier := range parts[2:] {
+		ids, modifierType, e := parseVolumeFilterModifier(modifier)
+		if e != nil {
+			return nil, fmt.Errorf("invalid input (%s), could not parse modifier '%s': %s", configInput, modifier, e)
+		}
+		if modifierType == "market_ids" && additionalMarketIDs == nil {
+			additionalMarketIDs = ids
+		} else if modifierType == "account_ids" && optionalAccountIDs == nil {
+			optionalAccountIDs = ids
+		} else {
+			return nil, fmt.Errorf("invalid input (%s), can have at most one \"market_ids\" and one \"account_ids\" modifier", configInput)
+		}
+	}
+
+	filter, e := makeFilterTradeCount(
+		configInput,
+		f.ExchangeName,
+		f.TradingPair,
+		f.AssetDisplayFn,
+		f.BaseAsset,
+		f.QuoteAsset,
+		f.DB,
+		maxTradesPerDay,
+		additionalMarketIDs,
+		optionalAccountIDs,
+		MakeSystemClock(),
+	)
+	if e != nil {
+		return nil, fmt.Errorf("could not make trade count filter for config input string '%s': %s", configInput, e)
+	}
+	return filter, nil
+}
@@ -1,11 +1,15 @@
 package plugins
 
 import (
+	"database/sql"
 	"fmt"
 	"testing"
 
 	"github.com/openlyinc/pointy"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/kelp/model"
 	"github.com/stellar/kelp/queries"
+	"github.com/stellar/kelp/support/utils"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -115,6 +119,9 @@ func TestAddModifierToConfig(t *testing.T) {
 		}, {
 			modifierMapping: "account_ids=[accountX]",
 			wantConfig:      &VolumeFilterConfig{optionalAccountIDs: []string{"accountX"}},
+		}, {
+			modifierMapping: "scope=account",
+			wantConfig:      &VolumeFilterConfig{accountScope: true},
 		},
 	}
 
@@ -141,6 +148,7 @@ func TestMakeVolumeFilterConfig(t *testing.T) {
 		{
 			configInput: "volume/daily/%s/base/3500.0/%s",
 			wantConfig: &VolumeFilterConfig{
+				window:                   volumeFilterWindowDaily,
 				BaseAssetCapInBaseUnits:  pointy.Float64(3500.0),
 				BaseAssetCapInQuoteUnits: nil,
 				additionalMarketIDs:      nil,
@@ -149,6 +157,7 @@ func TestMakeVolumeFilterConfig(t *testing.T) {
 		}, {
 			configInput: "volume/daily/%s/quote/4000.0/%s",
 			wantConfig: &VolumeFilterConfig{
+				window:                   volumeFilterWindowDaily,
 				BaseAssetCapInBaseUnits:  nil,
 				BaseAssetCapInQuoteUnits: pointy.Float64(4000.0),
 				additionalMarketIDs:      nil,
@@ -158,6 +167,7 @@ func TestMakeVolumeFilterConfig(t *testing.T) {
 		{
 			configInput: "volume/daily/%s/base/3500.0/%s",
 			wantConfig: &VolumeFilterConfig{
+				window:                   volumeFilterWindowDaily,
 				BaseAssetCapInBaseUnits:  pointy.Float64(3500.0),
 				BaseAssetCapInQuoteUnits: nil,
 				additionalMarketIDs:      nil,
@@ -166,6 +176,7 @@ func TestMakeVolumeFilterConfig(t *testing.T) {
 		}, {
 			configInput: "volume/daily/%s/quote/1000.0/%s",
 			wantConfig: &VolumeFilterConfig{
+				window:                   volumeFilterWindowDaily,
 				BaseAssetCapInBaseUnits:  nil,
 				BaseAssetCapInQuoteUnits: pointy.Float64(1000.0),
 				additionalMarketIDs:      nil,
@@ -174,6 +185,7 @@ func TestMakeVolumeFilterConfig(t *testing.T) {
 		}, {
 			configInput: "volume/daily:market_ids=[4c19915f47,db4531d586]/%s/base/3500.0/%s",
 			wantConfig: &VolumeFilterConfig{
+				window:                   volumeFilterWindowDaily,
 				BaseAssetCapInBaseUnits:  pointy.Float64(3500.0),
 				BaseAssetCapInQuoteUnits: nil,
 				additionalMarketIDs:      []string{"4c19915f47", "db4531d586"},
@@ -182,6 +194,7 @@ func TestMakeVolumeFilterConfig(t *testing.T) {
 		}, {
 			configInput: "volume/daily:account_ids=[account1,account2]/%s/base/3500.0/%s",
 			wantConfig: &VolumeFilterConfig{
+				window:                   volumeFilterWindowDaily,
 				BaseAssetCapInBaseUnits:  pointy.Float64(3500.0),
 				BaseAssetCapInQuoteUnits: nil,
 				additionalMarketIDs:      nil,
@@ -190,11 +203,59 @@ func TestMakeVolumeFilterConfig(t *testing.T) {
 		}, {
 			configInput: "volume/daily:market_ids=[4c19915f47,db4531d586]:account_ids=[account1,account2]/%s/base/3500.0/%s",
 			wantConfig: &VolumeFilterConfig{
+				window:                   volumeFilterWindowDaily,
 				BaseAssetCapInBaseUnits:  pointy.Float64(3500.0),
 				BaseAssetCapInQuoteUnits: nil,
 				additionalMarketIDs:      []string{"4c19915f47", "db4531d586"},
 				optionalAccountIDs:       []string{"account1", "account2"},
 			},
+		}, {
+			configInput: "volume/rolling7d/%s/base/3500.0/%s",
+			wantConfig: &VolumeFilterConfig{
+				window:                   volumeFilterWindowRolling7d,
+				BaseAssetCapInBaseUnits:  pointy.Float64(3500.0),
+				BaseAssetCapInQuoteUnits: nil,
+				additionalMarketIDs:      nil,
+				optionalAccountIDs:       nil,
+			},
+		}, {
+			configInput: "volume/weekly:account_ids=[account1,account2]/%s/quote/1000.0/%s",
+			wantConfig: &VolumeFilterConfig{
+				window:                   volumeFilterWindowWeekly,
+				BaseAssetCapInBaseUnits:  nil,
+				BaseAssetCapInQuoteUnits: pointy.Float64(1000.0),
+				additionalMarketIDs:      nil,
+				optionalAccountIDs:       []string{"account1", "account2"},
+			},
+		}, {
+			configInput: "volume/monthly:market_ids=[4c19915f47,db4531d586]/%s/base/3500.0/%s",
+			wantConfig: &VolumeFilterConfig{
+				window:                   volumeFilterWindowMonthly,
+				BaseAssetCapInBaseUnits:  pointy.Float64(3500.0),
+				BaseAssetCapInQuoteUnits: nil,
+				additionalMarketIDs:      []string{"4c19915f47", "db4531d586"},
+				optionalAccountIDs:       nil,
+			},
+		}, {
+			configInput: "volume/daily:scope=account/%s/quote/1000.0/%s",
+			wantConfig: &VolumeFilterConfig{
+				window:                   volumeFilterWindowDaily,
+				BaseAssetCapInBaseUnits:  nil,
+				BaseAssetCapInQuoteUnits: pointy.Float64(1000.0),
+				additionalMarketIDs:      nil,
+				optionalAccountIDs:       nil,
+				accountScope:             true,
+			},
+		}, {
+			configInput: "volume/weekly:scope=account:account_ids=[account1,account2]/%s/base/3500.0/%s",
+			wantConfig: &VolumeFilterConfig{
+				window:                   volumeFilterWindowWeekly,
+				BaseAssetCapInBaseUnits:  pointy.Float64(3500.0),
+				BaseAssetCapInQuoteUnits: nil,
+				additionalMarketIDs:      nil,
+				optionalAccountIDs:       []string{"account1", "account2"},
+				accountScope:             true,
+			},
 		},
 	}
 
@@ -233,7 +294,58 @@ func assertVolumeFilterConfigEqual(t *testing.T, want *VolumeFilterConfig, actua
 		assert.Equal(t, want.BaseAssetCapInQuoteUnits, actual.BaseAssetCapInQuoteUnits)
 		assert.Equal(t, want.action, actual.action)
 		assert.Equal(t, want.mode, actual.mode)
+		assert.Equal(t, want.window, actual.window)
 		assert.Equal(t, want.additionalMarketIDs, actual.additionalMarketIDs)
 		assert.Equal(t, want.optionalAccountIDs, actual.optionalAccountIDs)
+		assert.Equal(t, want.accountScope, actual.accountScope)
+	}
+}
+
+func TestMakeVolumeFilterConfig_AccountScopeErrors(t *testing.T) {
+	for _, configInput := range []string{
+		"volume/daily:scope=market/sell/base/3500.0/exact",
+		"volume/daily:scope=account:market_ids=[4c19915f47]/sell/base/3500.0/exact",
+	} {
+		t.Run(configInput, func(t *testing.T) {
+			_, e := makeVolumeFilterConfig(configInput)
+			assert.Error(t, e)
+		})
+	}
+}
+
+func TestFilterVolume_AccountScope(t *testing.T) {
+	factory := &FilterFactory{
+		ExchangeName:   "sdex",
+		TradingPair:    &model.TradingPair{Base: "XLM", Quote: "XLM"},
+		AssetDisplayFn: model.MakeSdexMappedAssetDisplayFn(map[model.Asset]hProtocol.Asset{model.Asset("XLM"): utils.NativeAsset}),
+		BaseAsset:      utils.NativeAsset,
+		QuoteAsset:     utils.NativeAsset,
+		DB:             &sql.DB{},
+	}
+
+	// the account scope needs an account
+	_, e := factory.MakeFilter("volume/daily:scope=account/sell/base/3500.0/exact")
+	if !assert.Error(t, e) {
+		return
+	}
+
+	factory.AccountID = "account1"
+	filter, e := factory.MakeFilter("volume/daily:scope=account/sell/base/3500.0/exact")
+	if !assert.NoError(t, e) {
+		return
+	}
+	// only the markets of the same base and quote asset are counted
+	baseString, e := factory.AssetDisplayFn(factory.TradingPair.Base)
+	if !assert.NoError(t, e) {
+		return
+	}
+	quoteString, e := factory.AssetDisplayFn(factory.TradingPair.Quote)
+	if !assert.NoError(t, e) {
+		return
+	}
+	want, e := queries.MakeDailyVolumeByDateForAccountIdsAction(&sql.DB{}, []string{"account1"}, baseString, quoteString, queries.DailyVolumeActionSell)
+	if !assert.NoError(t, e) {
+		return
 	}
+	assert.Equal(t, want, filter.(*volumeFilter).dailyVolumeByDateQuery)
 }
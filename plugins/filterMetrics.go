@@ -0,0 +1,258 @@
+package plugins
+
+import (
+	"fmt"
+	"log"
+	"sync"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/kelp/support/monitoring"
+)
+
+// filterStatsMetricsKey is the key under which the stats of the filters are published on the /metrics endpoint
+const filterStatsMetricsKey = "filter_stats"
+
+// FilterStats counts what a filter did to the ops passed through it
+type FilterStats struct {
+	NumApplied  uint64 `json:"num_applied"`
+	NumOpsIn    uint64 `json:"num_ops_in"`
+	NumKept     uint64 `json:"num_kept"`     // ops that were passed through unchanged
+	NumModified uint64 `json:"num_modified"` // ops that were passed through with a different price or amount
+	NumDropped  uint64 `json:"num_dropped"`  // ops that were removed
+	NumAdded    uint64 `json:"num_added"`    // ops that were added, such as the delete ops for existing offers or the pieces of a split offer
+}
+
+func (s *FilterStats) add(other FilterStats) {
+	s.NumApplied += other.NumApplied
+	s.NumOpsIn += other.NumOpsIn
+	s.NumKept += other.NumKept
+	s.NumModified += other.NumModified
+	s.NumDropped += other.NumDropped
+	s.NumAdded += other.NumAdded
+}
+
+// String is the Stringer method
+func (s FilterStats) String() string {
+	return fmt.Sprintf("in=%d, kept=%d, modified=%d, dropped=%d, added=%d", s.NumOpsIn, s.NumKept, s.NumModified, s.NumDropped, s.NumAdded)
+}
+
+// FilterMetrics keeps the total stats of every filter in the filter chain and publishes them to the metrics of the monitoring server
+type FilterMetrics struct {
+	metrics monitoring.Metrics // nil when the monitoring server is not running
+
+	mutex  *sync.Mutex
+	totals map[string]*FilterStats
+}
+
+// MakeFilterMetrics is a factory method, metrics can be nil
+func MakeFilterMetrics(metrics monitoring.Metrics) *FilterMetrics {
+	return &FilterMetrics{
+		metrics: metrics,
+		mutex:   &sync.Mutex{},
+		totals:  map[string]*FilterStats{},
+	}
+}
+
+// Wrap wraps every filter in the resolved filter chain so the ops kept, modified, dropped and added by the filter are counted and logged on
+// every update. The filters are labelled with their position in the chain and their name, so this should be called after ResolveFilterChain
+func (m *FilterMetrics) Wrap(filters []SubmitFilter) []SubmitFilter {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	wrapped := []SubmitFilter{}
+	for i, filter := range filters {
+		label := fmt.Sprintf("%d:%s", i, filterLabel(filter))
+		m.totals[label] = &FilterStats{}
+		wrapped = append(wrapped, &meteredFilter{
+			inner:   filter,
+			label:   label,
+			metrics: m,
+		})
+	}
+	return wrapped
+}
+
+// Totals returns a copy of the total stats of every filter keyed by the label of the filter
+func (m *FilterMetrics) Totals() map[string]FilterStats {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.copyTotals()
+}
+
+// record adds the stats of one update of a filter and publishes the totals, the caller should not hold the mutex
+func (m *FilterMetrics) record(label string, stats FilterStats) {
+	m.mutex.Lock()
+	m.totals[label].add(stats)
+	totals := m.copyTotals()
+	m.mutex.Unlock()
+
+	if m.metrics != nil {
+		m.metrics.UpdateMetrics(map[string]interface{}{
+			filterStatsMetricsKey: totals,
+		})
+	}
+}
+
+// copyTotals needs to be called while holding the mutex
+func (m *FilterMetrics) copyTotals() map[string]FilterStats {
+	totals := map[string]FilterStats{}
+	for label, stats := range m.totals {
+		totals[label] = *stats
+	}
+	return totals
+}
+
+// filterLabel returns the name of a filter, which is its config string when it has one or else its ID in the filter chain
+func filterLabel(filter SubmitFilter) string {
+	if s, ok := filter.(fmt.Stringer); ok && s.String() != "" {
+		return s.String()
+	}
+	return getFilterOrder(filter).ID
+}
+
+// meteredFilter counts the changes that the inner filter makes to the ops
+type meteredFilter struct {
+	inner   SubmitFilter
+	label   string
+	metrics *FilterMetrics
+}
+
+var _ SubmitFilter = &meteredFilter{}
+var _ OrderedSubmitFilter = &meteredFilter{}
+
+// FilterOrder impl.
+func (f *meteredFilter) FilterOrder() FilterOrder {
+	return getFilterOrder(f.inner)
+}
+
+// Unwrap impl.
+func (f *meteredFilter) Unwrap() SubmitFilter {
+	return f.inner
+}
+
+// Apply impl.
+func (f *meteredFilter) Apply(ops []txnbuild.Operation, sellingOffers []hProtocol.Offer, buyingOffers []hProtocol.Offer) ([]txnbuild.Operation, error) {
+	filteredOps, e := f.inner.Apply(ops, sellingOffers, buyingOffers)
+	if e != nil {
+		return nil, e
+	}
+
+	stats := diffOps(ops, filteredOps)
+	log.Printf("filter stats for \"%s\": %s\n", f.label, stats)
+	f.metrics.record(f.label, stats)
+	return filteredOps, nil
+}
+
+// String is the Stringer method
+func (f *meteredFilter) String() string {
+	return f.label
+}
+
+// diffOps compares the ops passed into a filter with the ops returned by it and counts the ops that were kept, modified, dropped and added
+func diffOps(before []txnbuild.Operation, after []txnbuild.Operation) FilterStats {
+	m := matchOps(before, after)
+	return FilterStats{
+		NumApplied:  1,
+		NumOpsIn:    uint64(len(before)),
+		NumKept:     uint64(len(m.kept)),
+		NumModified: uint64(len(m.modified)),
+		NumDropped:  uint64(len(m.dropped)),
+		NumAdded:    uint64(len(m.added)),
+	}
+}
+
+// opMatches maps the indices of the ops returned by a filter to the indices of the ops passed in
+type opMatches struct {
+	kept     map[int]int // index of the op returned -> index of the same op passed in
+	modified map[int]int // index of the op returned -> index of the op passed in that it was changed from
+	added    []int       // indices of the ops returned that do not match any op passed in
+	dropped  []int       // indices of the ops passed in that do not match any op returned
+}
+
+// matchOps matches the ops returned by a filter to the ops passed in. Ops that are in both lists are kept. An op that was returned with
+// changes is matched to the op passed in by its OfferID, or in the order of the lists for new offers, and counted as modified.
+// The remaining ops that were passed in were dropped and the remaining ops that were returned were added
+func matchOps(before []txnbuild.Operation, after []txnbuild.Operation) *opMatches {
+	m := &opMatches{
+		kept:     map[int]int{},
+		modified: map[int]int{},
+		added:    []int{},
+		dropped:  []int{},
+	}
+
+	unmatchedBefore := map[string][]int{}
+	for i, op := range before {
+		k := opKey(op)
+		unmatchedBefore[k] = append(unmatchedBefore[k], i)
+	}
+	unmatchedAfter := []int{}
+	for j, op := range after {
+		k := opKey(op)
+		if len(unmatchedBefore[k]) > 0 {
+			m.kept[j] = unmatchedBefore[k][0]
+			unmatchedBefore[k] = unmatchedBefore[k][1:]
+			continue
+		}
+		unmatchedAfter = append(unmatchedAfter, j)
+	}
+
+	// collect the offers of the ops passed in that were not kept, by OfferID for existing offers and in order for new offers
+	isChanged := map[int]bool{}
+	for _, indices := range unmatchedBefore {
+		for _, i := range indices {
+			isChanged[i] = true
+		}
+	}
+	changedOfferIDs := map[int64][]int{}
+	changedNewOffers := []int{}
+	isDropped := map[int]bool{}
+	for i, op := range before {
+		if !isChanged[i] {
+			continue
+		}
+		isDropped[i] = true
+		if mso, ok := op.(*txnbuild.ManageSellOffer); ok {
+			if mso.OfferID != 0 {
+				changedOfferIDs[mso.OfferID] = append(changedOfferIDs[mso.OfferID], i)
+			} else {
+				changedNewOffers = append(changedNewOffers, i)
+			}
+		}
+	}
+
+	for _, j := range unmatchedAfter {
+		mso, ok := after[j].(*txnbuild.ManageSellOffer)
+		if ok && mso.OfferID != 0 && len(changedOfferIDs[mso.OfferID]) > 0 {
+			i := changedOfferIDs[mso.OfferID][0]
+			changedOfferIDs[mso.OfferID] = changedOfferIDs[mso.OfferID][1:]
+			m.modified[j] = i
+			delete(isDropped, i)
+			continue
+		}
+		if ok && mso.OfferID == 0 && len(changedNewOffers) > 0 {
+			i := changedNewOffers[0]
+			changedNewOffers = changedNewOffers[1:]
+			m.modified[j] = i
+			delete(isDropped, i)
+			continue
+		}
+		m.added = append(m.added, j)
+	}
+
+	for i := range before {
+		if isDropped[i] {
+			m.dropped = append(m.dropped, i)
+		}
+	}
+	return m
+}
+
+// opKey returns a value that is the same for two ops only when they are the same op
+func opKey(op txnbuild.Operation) string {
+	if mso, ok := op.(*txnbuild.ManageSellOffer); ok {
+		return fmt.Sprintf("%T%+v", mso, *mso)
+	}
+	return fmt.Sprintf("%T%+v", op, op)
+}
@@ -0,0 +1,134 @@
+package plugins
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/kelp/support/monitoring"
+)
+
+// testFnFilter is a filter that applies a function to the ops
+type testFnFilter struct {
+	fn func(ops []txnbuild.Operation) []txnbuild.Operation
+}
+
+// Apply impl.
+func (f *testFnFilter) Apply(ops []txnbuild.Operation, sellingOffers []hProtocol.Offer, buyingOffers []hProtocol.Offer) ([]txnbuild.Operation, error) {
+	return f.fn(ops), nil
+}
+
+func makeTestSellOp(offerID int64, amount string, price string) *txnbuild.ManageSellOffer {
+	return &txnbuild.ManageSellOffer{Selling: testBaseAsset, Buying: testQuoteAsset, Amount: amount, Price: price, OfferID: offerID}
+}
+
+func TestDiffOps(t *testing.T) {
+	testCases := []struct {
+		name   string
+		before []txnbuild.Operation
+		after  []txnbuild.Operation
+		want   FilterStats
+	}{
+		{
+			name:   "empty",
+			before: []txnbuild.Operation{},
+			after:  []txnbuild.Operation{},
+			want:   FilterStats{NumApplied: 1},
+		}, {
+			name:   "kept",
+			before: []txnbuild.Operation{makeTestSellOp(1, "10.0", "1.0"), makeTestSellOp(0, "10.0", "1.1")},
+			after:  []txnbuild.Operation{makeTestSellOp(1, "10.0", "1.0"), makeTestSellOp(0, "10.0", "1.1")},
+			want:   FilterStats{NumApplied: 1, NumOpsIn: 2, NumKept: 2},
+		}, {
+			name:   "modified existing and new offers",
+			before: []txnbuild.Operation{makeTestSellOp(1, "10.0", "1.0"), makeTestSellOp(0, "10.0", "1.1")},
+			after:  []txnbuild.Operation{makeTestSellOp(1, "5.0", "1.0"), makeTestSellOp(0, "5.0", "1.1")},
+			want:   FilterStats{NumApplied: 1, NumOpsIn: 2, NumModified: 2},
+		}, {
+			name:   "dropped",
+			before: []txnbuild.Operation{makeTestSellOp(0, "10.0", "1.0"), makeTestSellOp(0, "10.0", "1.1")},
+			after:  []txnbuild.Operation{makeTestSellOp(0, "10.0", "1.1")},
+			want:   FilterStats{NumApplied: 1, NumOpsIn: 2, NumKept: 1, NumDropped: 1},
+		}, {
+			name:   "added delete op for existing offer",
+			before: []txnbuild.Operation{makeTestSellOp(0, "10.0", "1.0")},
+			after:  []txnbuild.Operation{makeTestSellOp(2, "0", "1.0")},
+			want:   FilterStats{NumApplied: 1, NumOpsIn: 1, NumDropped: 1, NumAdded: 1},
+		}, {
+			name:   "split",
+			before: []txnbuild.Operation{makeTestSellOp(1, "30.0", "1.0")},
+			after:  []txnbuild.Operation{makeTestSellOp(1, "10.0", "1.0"), makeTestSellOp(0, "10.0", "1.0"), makeTestSellOp(0, "10.0", "1.0")},
+			want:   FilterStats{NumApplied: 1, NumOpsIn: 1, NumModified: 1, NumAdded: 2},
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			assert.Equal(t, k.want, diffOps(k.before, k.after))
+		})
+	}
+}
+
+func TestFilterMetricsWrap(t *testing.T) {
+	metrics, e := monitoring.MakeMetricsRecorder(nil)
+	if !assert.NoError(t, e) {
+		return
+	}
+	filterMetrics := MakeFilterMetrics(metrics)
+
+	dropFirst := &testFnFilter{fn: func(ops []txnbuild.Operation) []txnbuild.Operation {
+		return ops[1:]
+	}}
+	filters := filterMetrics.Wrap([]SubmitFilter{makeTestOrderedFilter(filterIDPairWhitelist, FilterPriorityFirst), dropFirst})
+	// the wrapped filters keep the order of the filters they wrap
+	assert.Equal(t, FilterOrder{ID: filterIDPairWhitelist, Priority: FilterPriorityFirst}, getFilterOrder(filters[0]))
+
+	for i := 0; i < 2; i++ {
+		ops := []txnbuild.Operation{makeTestSellOp(0, "10.0", "1.0"), makeTestSellOp(0, "10.0", "1.1")}
+		for _, f := range filters {
+			ops, e = f.Apply(ops, []hProtocol.Offer{}, []hProtocol.Offer{})
+			if !assert.NoError(t, e) {
+				return
+			}
+		}
+		assert.Equal(t, 1, len(ops))
+	}
+
+	dropFirstLabel := fmt.Sprintf("1:%T", dropFirst)
+	want := map[string]FilterStats{
+		"0:" + filterIDPairWhitelist: {NumApplied: 2, NumOpsIn: 4, NumKept: 4},
+		dropFirstLabel:               {NumApplied: 2, NumOpsIn: 4, NumKept: 2, NumDropped: 2},
+	}
+	assert.Equal(t, want, filterMetrics.Totals())
+
+	metricsJSON, e := metrics.MarshalJSON()
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Contains(t, string(metricsJSON), `"filter_stats"`)
+	assert.Contains(t, string(metricsJSON), `"num_dropped":2`)
+}
+
+func TestMatchOps(t *testing.T) {
+	before := []txnbuild.Operation{
+		makeTestSellOp(1, "10.0", "1.0"), // kept
+		makeTestSellOp(2, "10.0", "1.1"), // modified, matched by OfferID
+		makeTestSellOp(0, "10.0", "1.2"), // modified, new offers are matched in order
+		makeTestSellOp(0, "10.0", "1.3"), // dropped
+	}
+	after := []txnbuild.Operation{
+		makeTestSellOp(0, "5.0", "1.3"),
+		makeTestSellOp(1, "10.0", "1.0"),
+		makeTestSellOp(2, "5.0", "1.1"),
+		makeTestSellOp(3, "0", "1.4"), // added
+	}
+
+	m := matchOps(before, after)
+	assert.Equal(t, map[int]int{1: 0}, m.kept)
+	assert.Equal(t, map[int]int{0: 2, 2: 1}, m.modified)
+	assert.Equal(t, []int{3}, m.added)
+	assert.Equal(t, []int{3}, m.dropped)
+}
@@ -33,16 +33,30 @@ func makeFunctionPriceFeed(url string) (api.PriceFeed, error) {
 	}
 
 	f, ok := fnFactoryMap[name]
-	if !ok {
+	paramF, hasParams := paramFnFactoryMap[name]
+	if !ok && !hasParams {
 		return nil, fmt.Errorf("the passed in URL does not have the registered function '%s'", name)
 	}
 
-	feeds, e := makeFeedsArray(argsString)
+	feedsString, params, e := extractFunctionParams(argsString)
+	if e != nil {
+		return nil, fmt.Errorf("unable to extract params of function '%s': %s", name, e)
+	}
+	if !hasParams && len(params) > 0 {
+		return nil, fmt.Errorf("the function '%s' does not take any params but found %v", name, params)
+	}
+
+	feeds, e := makeFeedsArray(feedsString)
 	if e != nil {
 		return nil, fmt.Errorf("error when makings feeds array: %s", e)
 	}
 
-	pf, e := f(feeds)
+	var pf api.PriceFeed
+	if hasParams {
+		pf, e = paramF(feeds, params)
+	} else {
+		pf, e = f(feeds)
+	}
 	if e != nil {
 		return nil, fmt.Errorf("error when invoking price feed function '%s': %s", name, e)
 	}
@@ -50,6 +64,53 @@ func makeFunctionPriceFeed(url string) (api.PriceFeed, error) {
 	return pf, nil
 }
 
+// extractFunctionParams splits the trailing key=value args of a function from the feeds, where a feed always contains a '/' between its
+// type and URL and a param does not, for example "exchange/ccxt-kraken/XLM/USD/mid,max_deviation=0.02" has the param max_deviation=0.02
+func extractFunctionParams(argsString string) (feedsString string, params map[string]string, e error) {
+	parts := splitFunctionArgs(argsString)
+	params = map[string]string{}
+	feedParts := []string{}
+	for _, argPart := range parts {
+		if strings.Contains(argPart, "/") || !strings.Contains(argPart, "=") {
+			if len(params) > 0 {
+				return "", nil, fmt.Errorf("params need to come after the feeds but found the feed '%s' after a param", argPart)
+			}
+			feedParts = append(feedParts, argPart)
+			continue
+		}
+
+		kv := strings.SplitN(argPart, "=", 2)
+		key := strings.TrimSpace(kv[0])
+		if _, ok := params[key]; ok {
+			return "", nil, fmt.Errorf("param '%s' is specified more than once", key)
+		}
+		params[key] = strings.TrimSpace(kv[1])
+	}
+	return strings.Join(feedParts, ","), params, nil
+}
+
+// splitFunctionArgs splits the args of a function on the commas that are not inside the parentheses of a nested function feed, so
+// "function/invert(fixed/2.0),fixed/3.0" is split into "function/invert(fixed/2.0)" and "fixed/3.0", and trims the spaces around each arg
+func splitFunctionArgs(argsString string) []string {
+	args := []string{}
+	depth := 0
+	start := 0
+	for i, c := range argsString {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				args = append(args, strings.TrimSpace(argsString[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	return append(args, strings.TrimSpace(argsString[start:]))
+}
+
 func extractFunctionParts(url string) (name string, args string, e error) {
 	fnNameRegex, e := regexp.Compile("^([a-zA-Z]+)\\((.*)\\)$")
 	if e != nil {
@@ -65,7 +126,7 @@ func extractFunctionParts(url string) (name string, args string, e error) {
 }
 
 func makeFeedsArray(feedsStringCSV string) ([]api.PriceFeed, error) {
-	parts := strings.Split(feedsStringCSV, ",")
+	parts := splitFunctionArgs(feedsStringCSV)
 	arr := []api.PriceFeed{}
 
 	for _, argPart := range parts {
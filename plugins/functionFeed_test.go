@@ -39,3 +39,81 @@ func TestExtractFunctionParts(t *testing.T) {
 		})
 	}
 }
+
+func TestExtractFunctionParams(t *testing.T) {
+	testCases := []struct {
+		inputArgs  string
+		wantFeeds  string
+		wantParams map[string]string
+		wantError  bool
+	}{
+		{
+			inputArgs:  "fixed/0.02,fixed/0.03",
+			wantFeeds:  "fixed/0.02,fixed/0.03",
+			wantParams: map[string]string{},
+		}, {
+			inputArgs:  "fixed/0.02,fixed/0.03,fixed/0.04,max_deviation=0.05,min_feeds=2",
+			wantFeeds:  "fixed/0.02,fixed/0.03,fixed/0.04",
+			wantParams: map[string]string{"max_deviation": "0.05", "min_feeds": "2"},
+		}, {
+			// a feed URL with a query string is not a param
+			inputArgs:  "fiat/http://apilayer.net/api/live?access_key=abc&currencies=NGN,max_deviation=0.05",
+			wantFeeds:  "fiat/http://apilayer.net/api/live?access_key=abc&currencies=NGN",
+			wantParams: map[string]string{"max_deviation": "0.05"},
+		}, {
+			// commas of nested function feeds do not split the args
+			inputArgs:  "function/median(fixed/0.02,fixed/0.03,fixed/0.04,max_deviation=0.05),fixed/0.03,min_weight=0.5",
+			wantFeeds:  "function/median(fixed/0.02,fixed/0.03,fixed/0.04,max_deviation=0.05),fixed/0.03",
+			wantParams: map[string]string{"min_weight": "0.5"},
+		}, {
+			inputArgs: "fixed/0.02,max_deviation=0.05,fixed/0.03",
+			wantError: true,
+		}, {
+			inputArgs: "fixed/0.02,max_deviation=0.05,max_deviation=0.06",
+			wantError: true,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.inputArgs, func(t *testing.T) {
+			feeds, params, e := extractFunctionParams(k.inputArgs)
+			if k.wantError {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+
+			assert.Equal(t, k.wantFeeds, feeds)
+			assert.Equal(t, k.wantParams, params)
+		})
+	}
+}
+
+func TestSplitFunctionArgs(t *testing.T) {
+	testCases := []struct {
+		inputArgs string
+		wantArgs  []string
+	}{
+		{
+			inputArgs: "fixed/0.02",
+			wantArgs:  []string{"fixed/0.02"},
+		}, {
+			inputArgs: "fixed/0.02, fixed/0.03",
+			wantArgs:  []string{"fixed/0.02", "fixed/0.03"},
+		}, {
+			inputArgs: "function/invert(fixed/2.0),fixed/3.0",
+			wantArgs:  []string{"function/invert(fixed/2.0)", "fixed/3.0"},
+		}, {
+			inputArgs: "compose/divide(fixed/1.0,function/max(fixed/2.0,fixed/3.0)),fixed/4.0,weights=1:1",
+			wantArgs:  []string{"compose/divide(fixed/1.0,function/max(fixed/2.0,fixed/3.0))", "fixed/4.0", "weights=1:1"},
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.inputArgs, func(t *testing.T) {
+			assert.Equal(t, k.wantArgs, splitFunctionArgs(k.inputArgs))
+		})
+	}
+}
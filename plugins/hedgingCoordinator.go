@@ -0,0 +1,217 @@
+package plugins
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/stellar/kelp/kelpdb"
+	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/support/postgresdb"
+)
+
+// nettedBackingOrderID is the backing_order_id recorded in the strategy_mirror_trade_triggers table for trades that were netted against
+// the trades of other bots in the same netting group instead of being offset with a dedicated order on the backing exchange
+const nettedBackingOrderID = "netted"
+
+// hedgingCoordinator nets the base volume that needs to be offset on a backing exchange across all the bots in the same netting group.
+// The net position is shared via the database so it works across bots running in separate processes, and opposing offsets from different
+// bots cancel out without placing any orders on the backing exchange.
+type hedgingCoordinator struct {
+	db                 *sql.DB
+	nettingGroup       string
+	backingMarketID    string
+	backingConstraints *model.OrderConstraints
+}
+
+// makeHedgingCoordinator is a factory method
+func makeHedgingCoordinator(db *sql.DB, nettingGroup string, backingMarketID string, backingConstraints *model.OrderConstraints) (*hedgingCoordinator, error) {
+	if db == nil {
+		return nil, fmt.Errorf("db should not be nil when using a hedging coordinator")
+	}
+	if nettingGroup == "" {
+		return nil, fmt.Errorf("netting group should not be empty when using a hedging coordinator")
+	}
+
+	return &hedgingCoordinator{
+		db:                 db,
+		nettingGroup:       nettingGroup,
+		backingMarketID:    backingMarketID,
+		backingConstraints: backingConstraints,
+	}, nil
+}
+
+// addAndClaim adds the signed base volume (positive to buy on the backing exchange, negative to sell) at the price of the trade to the shared
+// net position and claims the netted volume that should be offset now, returning 0.0 if the net position is too small to offset. The claim
+// is priced at the average price of the netted surplus, which is what the bots in the netting group actually traded at.
+func (c *hedgingCoordinator) addAndClaim(signedBaseVolume float64, price float64) (float64 /*claimedSignedBaseVolume*/, float64 /*claimedPrice*/, error) {
+	tx, e := c.db.Begin()
+	if e != nil {
+		return 0.0, 0.0, fmt.Errorf("could not begin db transaction: %s", e)
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback()
+		}
+	}()
+
+	// locking the row until the transaction is committed serializes concurrent bots in the same netting group
+	position, e := c.lock(tx)
+	if e != nil {
+		return 0.0, 0.0, fmt.Errorf("could not lock net position: %s", e)
+	}
+	position = position.add(signedBaseVolume, price)
+
+	claimedSignedBaseVolume, ok := computeNettedClaim(position.baseVolume, c.backingConstraints.MinBaseVolume.AsFloat(), c.backingConstraints.VolumePrecision)
+	claimedPrice := position.price(price)
+	if ok {
+		position = position.add(-claimedSignedBaseVolume, claimedPrice)
+	}
+
+	e = c.update(tx, position)
+	if e != nil {
+		return 0.0, 0.0, fmt.Errorf("could not update net position: %s", e)
+	}
+
+	e = tx.Commit()
+	if e != nil {
+		return 0.0, 0.0, fmt.Errorf("could not commit db transaction: %s", e)
+	}
+	committed = true
+
+	log.Printf("hedgingCoordinator: nettingGroup=%s | backingMarketID=%s | addedSignedBaseVolume=%f | addedPrice=%f | claimedSignedBaseVolume=%f | claimedPrice=%f | remainingNetBaseVolume=%f\n",
+		c.nettingGroup, c.backingMarketID, signedBaseVolume, price, claimedSignedBaseVolume, claimedPrice, position.baseVolume)
+	return claimedSignedBaseVolume, claimedPrice, nil
+}
+
+// release returns a previously claimed signed base volume at its claimed price to the shared net position, used when the offset order could
+// not be placed
+func (c *hedgingCoordinator) release(claimedSignedBaseVolume float64, claimedPrice float64) error {
+	tx, e := c.db.Begin()
+	if e != nil {
+		return fmt.Errorf("could not begin db transaction: %s", e)
+	}
+
+	position, e := c.lock(tx)
+	if e != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("could not lock net position: %s", e)
+	}
+	position = position.add(claimedSignedBaseVolume, claimedPrice)
+
+	e = c.update(tx, position)
+	if e != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("could not release %f to net position: %s", claimedSignedBaseVolume, e)
+	}
+
+	e = tx.Commit()
+	if e != nil {
+		return fmt.Errorf("could not commit db transaction: %s", e)
+	}
+
+	log.Printf("hedgingCoordinator: nettingGroup=%s | backingMarketID=%s | releasedSignedBaseVolume=%f | releasedPrice=%f | remainingNetBaseVolume=%f\n",
+		c.nettingGroup, c.backingMarketID, claimedSignedBaseVolume, claimedPrice, position.baseVolume)
+	return nil
+}
+
+func (c *hedgingCoordinator) lock(tx *sql.Tx) (nettingPosition, error) {
+	sqlLock := fmt.Sprintf(kelpdb.SqlStrategyMirrorNettingPositionsLockTemplate,
+		c.nettingGroup,
+		c.backingMarketID,
+		time.Now().UTC().Format(postgresdb.TimestampFormatString),
+	)
+
+	var position nettingPosition
+	e := tx.QueryRow(sqlLock).Scan(&position.baseVolume, &position.quoteVolume)
+	if e != nil {
+		return nettingPosition{}, fmt.Errorf("could not execute sql upsert statement (%s): %s", sqlLock, e)
+	}
+	return position, nil
+}
+
+func (c *hedgingCoordinator) update(tx *sql.Tx, position nettingPosition) error {
+	sqlUpdate := fmt.Sprintf(kelpdb.SqlStrategyMirrorNettingPositionsUpdateTemplate,
+		position.baseVolume,
+		position.quoteVolume,
+		time.Now().UTC().Format(postgresdb.TimestampFormatString),
+		c.nettingGroup,
+		c.backingMarketID,
+	)
+
+	_, e := tx.Exec(sqlUpdate)
+	if e != nil {
+		return fmt.Errorf("could not execute sql update statement (%s): %s", sqlUpdate, e)
+	}
+	return nil
+}
+
+// nettingPosition is the signed base volume of a net position along with the signed quote volume it was accumulated at
+type nettingPosition struct {
+	baseVolume  float64
+	quoteVolume float64
+}
+
+// price returns the average price of the net position, or the fallback price when the position is flat or its quote volume is unknown
+// (positions recorded before the quote volume was tracked)
+func (p nettingPosition) price(fallback float64) float64 {
+	if p.baseVolume == 0.0 {
+		return fallback
+	}
+	avgPrice := p.quoteVolume / p.baseVolume
+	if avgPrice <= 0.0 {
+		return fallback
+	}
+	return avgPrice
+}
+
+// add returns the net position after adding the signed base volume at the price. Adding to the position averages the price, reducing the
+// position keeps its average price, and flipping the position to the other side starts it over at the price of what was added.
+func (p nettingPosition) add(signedBaseVolume float64, price float64) nettingPosition {
+	newBaseVolume := p.baseVolume + signedBaseVolume
+	if p.baseVolume == 0.0 || (p.baseVolume > 0) == (signedBaseVolume > 0) {
+		return nettingPosition{
+			baseVolume:  newBaseVolume,
+			quoteVolume: p.quoteVolume + signedBaseVolume*price,
+		}
+	}
+
+	if newBaseVolume == 0.0 || (newBaseVolume > 0) == (p.baseVolume > 0) {
+		return nettingPosition{
+			baseVolume:  newBaseVolume,
+			quoteVolume: newBaseVolume * p.price(price),
+		}
+	}
+
+	return nettingPosition{
+		baseVolume:  newBaseVolume,
+		quoteVolume: newBaseVolume * price,
+	}
+}
+
+// computeNettedClaim returns the signed base volume to offset given the net position, using the same thresholds as the mirror strategy
+// does for a single bot: skip when the net position is less than half the minBaseVolume, otherwise offset at least the minBaseVolume
+func computeNettedClaim(netBaseVolume float64, minBaseVolume float64, volumePrecision int8) (float64, bool) {
+	absNetBaseVolume := math.Abs(netBaseVolume)
+	if absNetBaseVolume < minBaseVolume*0.5 {
+		return 0.0, false
+	}
+
+	claim := absNetBaseVolume
+	if claim < minBaseVolume {
+		// we want to offset the minBaseVolume and take a deficit in the net position on success
+		claim = minBaseVolume
+	}
+	claim = model.NumberFromFloatRoundTruncate(claim, volumePrecision).AsFloat()
+	if claim <= 0.0 {
+		return 0.0, false
+	}
+
+	if netBaseVolume < 0 {
+		return -claim, true
+	}
+	return claim, true
+}
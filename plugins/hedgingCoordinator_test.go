@@ -0,0 +1,97 @@
+package plugins
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComputeNettedClaim(t *testing.T) {
+	testCases := []struct {
+		netBaseVolume   float64
+		minBaseVolume   float64
+		volumePrecision int8
+		wantClaim       float64
+		wantOk          bool
+	}{
+		// opposing offsets that fully cancel out do not place an order
+		{netBaseVolume: 0.0, minBaseVolume: 1.0, volumePrecision: 2, wantClaim: 0.0, wantOk: false},
+		// less than half the min base volume is left pending
+		{netBaseVolume: 0.4, minBaseVolume: 1.0, volumePrecision: 2, wantClaim: 0.0, wantOk: false},
+		{netBaseVolume: -0.4, minBaseVolume: 1.0, volumePrecision: 2, wantClaim: 0.0, wantOk: false},
+		// between half and the full min base volume rounds up to the min base volume
+		{netBaseVolume: 0.6, minBaseVolume: 1.0, volumePrecision: 2, wantClaim: 1.0, wantOk: true},
+		{netBaseVolume: -0.6, minBaseVolume: 1.0, volumePrecision: 2, wantClaim: -1.0, wantOk: true},
+		// more than the min base volume claims the entire net position
+		{netBaseVolume: 5.5, minBaseVolume: 1.0, volumePrecision: 2, wantClaim: 5.5, wantOk: true},
+		{netBaseVolume: -5.5, minBaseVolume: 1.0, volumePrecision: 2, wantClaim: -5.5, wantOk: true},
+		// claim is truncated to the volume precision so the remainder stays in the net position
+		{netBaseVolume: 5.559, minBaseVolume: 1.0, volumePrecision: 2, wantClaim: 5.55, wantOk: true},
+		{netBaseVolume: -5.559, minBaseVolume: 1.0, volumePrecision: 2, wantClaim: -5.55, wantOk: true},
+	}
+
+	for _, k := range testCases {
+		t.Run(fmt.Sprintf("%f_%f_%d", k.netBaseVolume, k.minBaseVolume, k.volumePrecision), func(t *testing.T) {
+			claim, ok := computeNettedClaim(k.netBaseVolume, k.minBaseVolume, k.volumePrecision)
+			assert.Equal(t, k.wantOk, ok)
+			assert.InDelta(t, k.wantClaim, claim, 0.0000001)
+		})
+	}
+}
+
+func TestNettingPositionAdd(t *testing.T) {
+	testCases := []struct {
+		name      string
+		position  nettingPosition
+		addBase   float64
+		addPrice  float64
+		wantBase  float64
+		wantPrice float64
+	}{
+		{
+			name:      "open from flat",
+			position:  nettingPosition{},
+			addBase:   2.0,
+			addPrice:  1.5,
+			wantBase:  2.0,
+			wantPrice: 1.5,
+		}, {
+			name:      "adding averages the price",
+			position:  nettingPosition{baseVolume: 2.0, quoteVolume: 2.0},
+			addBase:   2.0,
+			addPrice:  2.0,
+			wantBase:  4.0,
+			wantPrice: 1.5,
+		}, {
+			name:      "reducing keeps the price",
+			position:  nettingPosition{baseVolume: -4.0, quoteVolume: -6.0},
+			addBase:   1.0,
+			addPrice:  3.0,
+			wantBase:  -3.0,
+			wantPrice: 1.5,
+		}, {
+			name:      "flipping starts over at the added price",
+			position:  nettingPosition{baseVolume: 2.0, quoteVolume: 2.0},
+			addBase:   -5.0,
+			addPrice:  3.0,
+			wantBase:  -3.0,
+			wantPrice: 3.0,
+		}, {
+			name:      "unknown quote volume uses the added price",
+			position:  nettingPosition{baseVolume: 2.0, quoteVolume: 0.0},
+			addBase:   -1.0,
+			addPrice:  3.0,
+			wantBase:  1.0,
+			wantPrice: 3.0,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			p := k.position.add(k.addBase, k.addPrice)
+			assert.InDelta(t, k.wantBase, p.baseVolume, 0.0000001)
+			assert.InDelta(t, k.wantPrice, p.price(0.0), 0.0000001)
+		})
+	}
+}
@@ -0,0 +1,286 @@
+package plugins
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/stellar/go/support/config"
+)
+
+// holidayDateFormat is the format of the dates in a holiday calendar file
+const holidayDateFormat = "2006-01-02"
+
+// icalDateFormat and icalDateTimeFormat are the formats of DTSTART and DTEND in an iCal file
+const icalDateFormat = "20060102"
+const icalDateTimeFormat = "20060102T150405"
+
+// icalRecurrenceYears is how many years into the future we expand recurring events that do not have a COUNT or UNTIL
+const icalRecurrenceYears = 5
+
+// holidayCalendarFile is the toml representation of a holiday calendar file
+type holidayCalendarFile struct {
+	Holidays []struct {
+		Date          string   `valid:"-" toml:"DATE"`
+		Name          string   `valid:"-" toml:"NAME"`
+		CapMultiplier *float64 `valid:"-" toml:"CAP_MULTIPLIER"` // defaults to the HOLIDAY_CAP_MULTIPLIER of the strategy config
+	} `valid:"-" toml:"HOLIDAYS"`
+}
+
+// holidayCalendar adjusts the daily caps of the twap strategies on specific dates (in UTC), which is needed for assets that are tied to the
+// hours of traditional markets
+type holidayCalendar struct {
+	source         string
+	capMultipliers map[string]float64 // keyed by date in holidayDateFormat
+}
+
+// makeHolidayCalendar loads the holidays from source, which can be an http(s) URL to an iCal calendar, a path to an iCal (.ics) file, or a
+// path to a toml file with a list of HOLIDAYS. The holidays from an iCal calendar, and the holidays in a toml file without a CAP_MULTIPLIER,
+// use defaultCapMultiplier. An empty source returns a nil calendar
+func makeHolidayCalendar(source string, defaultCapMultiplier float64) (*holidayCalendar, error) {
+	if source == "" {
+		return nil, nil
+	}
+	if defaultCapMultiplier < 0.0 {
+		return nil, fmt.Errorf("invalid default cap multiplier, expected >= 0.0; was %f", defaultCapMultiplier)
+	}
+
+	var capMultipliers map[string]float64
+	var e error
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		capMultipliers, e = fetchICalHolidays(source, defaultCapMultiplier)
+	} else if strings.HasSuffix(strings.ToLower(source), ".ics") {
+		capMultipliers, e = readICalHolidaysFile(source, defaultCapMultiplier)
+	} else {
+		capMultipliers, e = readHolidayCalendarFile(source, defaultCapMultiplier)
+	}
+	if e != nil {
+		return nil, fmt.Errorf("could not load holiday calendar from '%s': %s", source, e)
+	}
+
+	log.Printf("loaded %d holidays from the holiday calendar '%s'\n", len(capMultipliers), source)
+	return &holidayCalendar{
+		source:         source,
+		capMultipliers: capMultipliers,
+	}, nil
+}
+
+// capMultiplier returns the multiplier for the daily cap on the date of t in UTC, which is 1.0 when the date is not a holiday
+func (c *holidayCalendar) capMultiplier(t time.Time) float64 {
+	if c == nil {
+		return 1.0
+	}
+	if m, ok := c.capMultipliers[t.UTC().Format(holidayDateFormat)]; ok {
+		return m
+	}
+	return 1.0
+}
+
+func readHolidayCalendarFile(filename string, defaultCapMultiplier float64) (map[string]float64, error) {
+	var file holidayCalendarFile
+	e := config.Read(filename, &file)
+	if e != nil {
+		return nil, fmt.Errorf("could not read file: %s", e)
+	}
+
+	capMultipliers := map[string]float64{}
+	for _, h := range file.Holidays {
+		date, e := time.Parse(holidayDateFormat, h.Date)
+		if e != nil {
+			return nil, fmt.Errorf("invalid DATE '%s' for holiday '%s', needs to be in the format YYYY-MM-DD: %s", h.Date, h.Name, e)
+		}
+		m := defaultCapMultiplier
+		if h.CapMultiplier != nil {
+			m = *h.CapMultiplier
+		}
+		if m < 0.0 {
+			return nil, fmt.Errorf("invalid CAP_MULTIPLIER for holiday '%s' on %s, expected >= 0.0; was %f", h.Name, h.Date, m)
+		}
+		capMultipliers[date.Format(holidayDateFormat)] = m
+	}
+	return capMultipliers, nil
+}
+
+// icalRecurrenceHorizon is the last date that recurring events without a COUNT or UNTIL are expanded to
+func icalRecurrenceHorizon() time.Time {
+	return floorDate(time.Now().UTC()).AddDate(icalRecurrenceYears, 0, 0)
+}
+
+func readICalHolidaysFile(filename string, defaultCapMultiplier float64) (map[string]float64, error) {
+	f, e := os.Open(filename)
+	if e != nil {
+		return nil, fmt.Errorf("could not open file: %s", e)
+	}
+	defer f.Close()
+
+	return parseICalHolidays(f, defaultCapMultiplier, icalRecurrenceHorizon())
+}
+
+func fetchICalHolidays(url string, defaultCapMultiplier float64) (map[string]float64, error) {
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, e := client.Get(url)
+	if e != nil {
+		return nil, fmt.Errorf("could not fetch calendar: %s", e)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return nil, fmt.Errorf("could not fetch calendar, status code %d: %s", resp.StatusCode, string(body))
+	}
+	return parseICalHolidays(resp.Body, defaultCapMultiplier, icalRecurrenceHorizon())
+}
+
+// icalEvent is a VEVENT of an iCal calendar with its dates converted to the date in UTC
+type icalEvent struct {
+	uid          string
+	start        *time.Time
+	end          *time.Time
+	rrule        *icalRecurrenceRule
+	exdates      map[string]bool // keyed by date in holidayDateFormat
+	recurrenceID *time.Time      // set when the event replaces one occurrence of the recurring event with the same uid
+}
+
+// parseICalHolidays reads the dates of the VEVENT entries in an iCal calendar. An event covers the dates from DTSTART up until DTEND
+// (exclusive), or only the date of DTSTART when there is no DTEND. Times are converted to the date in UTC. Recurring events (RRULE) are
+// expanded up until horizon when they do not end on their own, without the occurrences that are excluded with EXDATE or that are replaced
+// by an event with a RECURRENCE-ID
+func parseICalHolidays(r io.Reader, capMultiplier float64, horizon time.Time) (map[string]float64, error) {
+	lines, e := unfoldICalLines(r)
+	if e != nil {
+		return nil, fmt.Errorf("could not read lines: %s", e)
+	}
+
+	events := []*icalEvent{}
+	var event *icalEvent
+	for i, line := range lines {
+		name, value := splitICalLine(line)
+		switch {
+		case name == "BEGIN" && value == "VEVENT":
+			event = &icalEvent{exdates: map[string]bool{}}
+		case name == "END" && value == "VEVENT":
+			if event == nil || event.start == nil {
+				return nil, fmt.Errorf("event ending on line %d does not have a DTSTART", i+1)
+			}
+			events = append(events, event)
+			event = nil
+		case event != nil && name == "UID":
+			event.uid = value
+		case event != nil && name == "RRULE":
+			event.rrule, e = parseICalRecurrenceRule(value)
+			if e != nil {
+				return nil, fmt.Errorf("invalid RRULE on line %d: %s", i+1, e)
+			}
+		case event != nil && name == "EXDATE":
+			// an EXDATE line can hold a list of dates
+			params := strings.SplitN(line, ":", 2)[0]
+			for _, v := range strings.Split(value, ",") {
+				d, e := parseICalDate(params + ":" + v)
+				if e != nil {
+					return nil, fmt.Errorf("invalid EXDATE on line %d: %s", i+1, e)
+				}
+				event.exdates[d.Format(holidayDateFormat)] = true
+			}
+		case event != nil && (name == "DTSTART" || name == "DTEND" || name == "RECURRENCE-ID"):
+			d, e := parseICalDate(line)
+			if e != nil {
+				return nil, fmt.Errorf("invalid %s on line %d: %s", name, i+1, e)
+			}
+			if name == "DTSTART" {
+				event.start = &d
+			} else if name == "DTEND" {
+				event.end = &d
+			} else {
+				event.recurrenceID = &d
+			}
+		}
+	}
+
+	replaced := map[string]bool{}
+	for _, ev := range events {
+		if ev.recurrenceID != nil {
+			replaced[ev.uid+"/"+ev.recurrenceID.Format(holidayDateFormat)] = true
+		}
+	}
+
+	capMultipliers := map[string]float64{}
+	for _, ev := range events {
+		numDays := 1
+		if ev.end != nil && ev.end.After(*ev.start) {
+			numDays = int(ev.end.Sub(*ev.start).Hours()/24 + 0.5)
+		}
+
+		occurrences := []time.Time{*ev.start}
+		if ev.rrule != nil && ev.recurrenceID == nil {
+			occurrences = ev.rrule.expand(*ev.start, horizon)
+		}
+		for _, occurrence := range occurrences {
+			date := occurrence.Format(holidayDateFormat)
+			if ev.exdates[date] || (ev.recurrenceID == nil && replaced[ev.uid+"/"+date]) {
+				continue
+			}
+			for d := 0; d < numDays; d++ {
+				capMultipliers[occurrence.AddDate(0, 0, d).Format(holidayDateFormat)] = capMultiplier
+			}
+		}
+	}
+	return capMultipliers, nil
+}
+
+// unfoldICalLines joins the lines that are folded onto the next line, which start with a space or a tab
+func unfoldICalLines(r io.Reader) ([]string, error) {
+	lines := []string{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(lines) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+// splitICalLine returns the property name without its parameters, and the value, of a line such as "DTSTART;VALUE=DATE:20201225"
+func splitICalLine(line string) (string, string) {
+	parts := strings.SplitN(line, ":", 2)
+	if len(parts) != 2 {
+		return "", ""
+	}
+	name := strings.SplitN(parts[0], ";", 2)[0]
+	return strings.ToUpper(strings.TrimSpace(name)), strings.TrimSpace(parts[1])
+}
+
+// parseICalDate parses the value of a DTSTART or DTEND line into the date in UTC
+func parseICalDate(line string) (time.Time, error) {
+	parts := strings.SplitN(line, ":", 2)
+	value := strings.TrimSpace(parts[1])
+
+	if len(value) == len(icalDateFormat) {
+		return time.Parse(icalDateFormat, value)
+	}
+
+	loc := time.UTC
+	if strings.HasSuffix(value, "Z") {
+		value = strings.TrimSuffix(value, "Z")
+	} else if i := strings.Index(parts[0], "TZID="); i >= 0 {
+		tzid := strings.SplitN(parts[0][i+len("TZID="):], ";", 2)[0]
+		l, e := time.LoadLocation(strings.Trim(tzid, "\""))
+		if e != nil {
+			return time.Time{}, fmt.Errorf("unknown TZID '%s': %s", tzid, e)
+		}
+		loc = l
+	}
+	t, e := time.ParseInLocation(icalDateTimeFormat, value, loc)
+	if e != nil {
+		return time.Time{}, e
+	}
+	return floorDate(t.UTC()), nil
+}
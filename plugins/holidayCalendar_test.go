@@ -0,0 +1,160 @@
+package plugins
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseICalHolidays(t *testing.T) {
+	ical := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"BEGIN:VEVENT",
+		"DTSTART;VALUE=DATE:20201225",
+		"DTEND;VALUE=DATE:20201226",
+		"SUMMARY:Christmas Day",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		// multi-day event, DTEND is exclusive
+		"DTSTART;VALUE=DATE:20201231",
+		"DTEND;VALUE=DATE:20210102",
+		"SUMMARY:New Year",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		// folded line and no DTEND
+		"DTSTART:20200704T",
+		" 100000Z",
+		"SUMMARY:Independence Day",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		// 20:00 in New York is the next day in UTC
+		"DTSTART;TZID=America/New_York:20201126T200000",
+		"SUMMARY:Thanksgiving evening",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n")
+
+	capMultipliers, e := parseICalHolidays(strings.NewReader(ical), 0.5, time.Date(2021, 12, 31, 0, 0, 0, 0, time.UTC))
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, map[string]float64{
+		"2020-12-25": 0.5,
+		"2020-12-31": 0.5,
+		"2021-01-01": 0.5,
+		"2020-07-04": 0.5,
+		"2020-11-27": 0.5,
+	}, capMultipliers)
+
+	_, e = parseICalHolidays(strings.NewReader("BEGIN:VEVENT\r\nSUMMARY:no start\r\nEND:VEVENT\r\n"), 0.0, time.Now())
+	assert.Error(t, e)
+}
+
+func TestParseICalHolidays_Recurring(t *testing.T) {
+	ical := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"BEGIN:VEVENT",
+		// fourth thursday of november, expanded up until the horizon
+		"UID:thanksgiving",
+		"DTSTART;VALUE=DATE:20201126",
+		"DTEND;VALUE=DATE:20201127",
+		"RRULE:FREQ=YEARLY;BYMONTH=11;BYDAY=4TH",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		// last monday of may
+		"UID:memorial",
+		"DTSTART;VALUE=DATE:20200525",
+		"RRULE:FREQ=YEARLY;BYMONTH=5;BYDAY=-1MO;COUNT=2",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		// two day event where the 2021 occurrence is excluded and the 2022 occurrence is moved
+		"UID:christmas",
+		"DTSTART;VALUE=DATE:20201225",
+		"DTEND;VALUE=DATE:20201227",
+		"RRULE:FREQ=YEARLY;UNTIL=20221231",
+		"EXDATE;VALUE=DATE:20211225",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:christmas",
+		"RECURRENCE-ID;VALUE=DATE:20221225",
+		"DTSTART;VALUE=DATE:20221226",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n")
+
+	capMultipliers, e := parseICalHolidays(strings.NewReader(ical), 0.0, time.Date(2022, 12, 31, 0, 0, 0, 0, time.UTC))
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, map[string]float64{
+		"2020-11-26": 0.0,
+		"2021-11-25": 0.0,
+		"2022-11-24": 0.0,
+		"2020-05-25": 0.0,
+		"2021-05-31": 0.0,
+		"2020-12-25": 0.0,
+		"2020-12-26": 0.0,
+		"2022-12-26": 0.0,
+	}, capMultipliers)
+
+	_, e = parseICalHolidays(strings.NewReader("BEGIN:VEVENT\r\nDTSTART;VALUE=DATE:20201225\r\nRRULE:FREQ=YEARLY;BYSETPOS=1\r\nEND:VEVENT\r\n"), 0.0, time.Now())
+	assert.Error(t, e)
+}
+
+func TestReadHolidayCalendarFile(t *testing.T) {
+	f, e := ioutil.TempFile("", "holidays*.cfg")
+	if !assert.NoError(t, e) {
+		return
+	}
+	defer os.Remove(f.Name())
+
+	_, e = f.WriteString(`
+[[HOLIDAYS]]
+DATE="2020-12-25"
+NAME="Christmas Day"
+
+[[HOLIDAYS]]
+DATE="2020-12-24"
+NAME="Christmas Eve (half day)"
+CAP_MULTIPLIER=0.5
+`)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.NoError(t, f.Close())
+
+	calendar, e := makeHolidayCalendar(f.Name(), 0.0)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, 0.0, calendar.capMultiplier(time.Date(2020, 12, 25, 12, 0, 0, 0, time.UTC)))
+	assert.Equal(t, 0.5, calendar.capMultiplier(time.Date(2020, 12, 24, 23, 59, 59, 0, time.UTC)))
+	assert.Equal(t, 1.0, calendar.capMultiplier(time.Date(2020, 12, 26, 0, 0, 0, 0, time.UTC)))
+}
+
+func TestHolidayCalendarCapMultiplier_Nil(t *testing.T) {
+	calendar, e := makeHolidayCalendar("", 0.0)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Nil(t, calendar)
+	assert.Equal(t, 1.0, calendar.capMultiplier(time.Date(2020, 12, 25, 0, 0, 0, 0, time.UTC)))
+}
+
+func TestGetLevelsOnClosedHoliday(t *testing.T) {
+	p := makeTestSellTwapLevelProvider(0)
+	// the manual clock of the test level provider is set to 2020-03-14
+	p.holidayCalendar = &holidayCalendar{capMultipliers: map[string]float64{"2020-03-14": 0.0}}
+
+	levels, e := p.GetLevels(1000.0, 1000.0)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, 0, len(levels))
+	assert.Nil(t, p.activeBucket)
+}
@@ -0,0 +1,299 @@
+package plugins
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// icalWeekdays maps the weekdays of the BYDAY and WKST parts of an RRULE
+var icalWeekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// icalByDay is an entry of the BYDAY part of an RRULE, such as MO (every monday) or -1MO (the last monday of the month or year)
+type icalByDay struct {
+	ordinal int // 0 means every such weekday in the period
+	weekday time.Weekday
+}
+
+// icalRecurrenceRule is the RRULE of an event, limited to the parts that select dates since a holiday calendar only works with dates in UTC
+type icalRecurrenceRule struct {
+	freq       string
+	interval   int
+	count      int        // 0 means the rule does not have a COUNT
+	until      *time.Time // inclusive
+	byMonth    []time.Month
+	byMonthDay []int
+	byDay      []icalByDay
+	weekStart  time.Weekday
+}
+
+// parseICalRecurrenceRule parses the value of an RRULE line, such as "FREQ=YEARLY;BYMONTH=11;BYDAY=4TH"
+func parseICalRecurrenceRule(value string) (*icalRecurrenceRule, error) {
+	r := &icalRecurrenceRule{
+		interval:  1,
+		weekStart: time.Monday,
+	}
+	for _, part := range strings.Split(value, ";") {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid part '%s', needs to be of the form NAME=VALUE", part)
+		}
+		name, v := strings.ToUpper(strings.TrimSpace(kv[0])), strings.ToUpper(strings.TrimSpace(kv[1]))
+
+		var e error
+		switch name {
+		case "FREQ":
+			if v != "DAILY" && v != "WEEKLY" && v != "MONTHLY" && v != "YEARLY" {
+				return nil, fmt.Errorf("unsupported FREQ '%s', needs to be one of DAILY, WEEKLY, MONTHLY or YEARLY", v)
+			}
+			r.freq = v
+		case "INTERVAL":
+			r.interval, e = strconv.Atoi(v)
+			if e != nil || r.interval <= 0 {
+				return nil, fmt.Errorf("invalid INTERVAL '%s', needs to be a positive integer", v)
+			}
+		case "COUNT":
+			r.count, e = strconv.Atoi(v)
+			if e != nil || r.count <= 0 {
+				return nil, fmt.Errorf("invalid COUNT '%s', needs to be a positive integer", v)
+			}
+		case "UNTIL":
+			until, e := parseICalDate("UNTIL:" + v)
+			if e != nil {
+				return nil, fmt.Errorf("invalid UNTIL '%s': %s", v, e)
+			}
+			r.until = &until
+		case "BYMONTH":
+			for _, s := range strings.Split(v, ",") {
+				m, e := strconv.Atoi(s)
+				if e != nil || m < 1 || m > 12 {
+					return nil, fmt.Errorf("invalid BYMONTH '%s', needs to be between 1 and 12", s)
+				}
+				r.byMonth = append(r.byMonth, time.Month(m))
+			}
+		case "BYMONTHDAY":
+			for _, s := range strings.Split(v, ",") {
+				d, e := strconv.Atoi(s)
+				if e != nil || d == 0 || d < -31 || d > 31 {
+					return nil, fmt.Errorf("invalid BYMONTHDAY '%s', needs to be between 1 and 31 or between -31 and -1", s)
+				}
+				r.byMonthDay = append(r.byMonthDay, d)
+			}
+		case "BYDAY":
+			for _, s := range strings.Split(v, ",") {
+				if len(s) < 2 {
+					return nil, fmt.Errorf("invalid BYDAY '%s'", s)
+				}
+				weekday, ok := icalWeekdays[s[len(s)-2:]]
+				if !ok {
+					return nil, fmt.Errorf("invalid weekday in BYDAY '%s'", s)
+				}
+				ordinal := 0
+				if len(s) > 2 {
+					ordinal, e = strconv.Atoi(s[:len(s)-2])
+					if e != nil || ordinal == 0 || ordinal < -53 || ordinal > 53 {
+						return nil, fmt.Errorf("invalid ordinal in BYDAY '%s'", s)
+					}
+				}
+				r.byDay = append(r.byDay, icalByDay{ordinal: ordinal, weekday: weekday})
+			}
+		case "WKST":
+			weekday, ok := icalWeekdays[v]
+			if !ok {
+				return nil, fmt.Errorf("invalid WKST '%s'", v)
+			}
+			r.weekStart = weekday
+		default:
+			// the other parts (such as BYSETPOS or BYHOUR) would change which dates the rule selects, so we cannot ignore them
+			return nil, fmt.Errorf("unsupported part '%s'", name)
+		}
+	}
+
+	if r.freq == "" {
+		return nil, fmt.Errorf("missing FREQ")
+	}
+	if r.count > 0 && r.until != nil {
+		return nil, fmt.Errorf("cannot have both COUNT and UNTIL")
+	}
+	return r, nil
+}
+
+// expand returns the dates of the occurrences of the rule for an event starting on the date start, up until the date horizon for rules
+// that do not end on their own
+func (r *icalRecurrenceRule) expand(start time.Time, horizon time.Time) []time.Time {
+	occurrences := []time.Time{}
+	for period := 0; ; period++ {
+		periodStart := r.periodStart(start, period)
+		if periodStart.After(horizon) || (r.until != nil && periodStart.After(*r.until)) {
+			return occurrences
+		}
+
+		for _, d := range r.periodDates(start, periodStart) {
+			if d.Before(start) {
+				continue
+			}
+			if d.After(horizon) || (r.until != nil && d.After(*r.until)) {
+				return occurrences
+			}
+			occurrences = append(occurrences, d)
+			if r.count > 0 && len(occurrences) >= r.count {
+				return occurrences
+			}
+		}
+	}
+}
+
+// periodStart returns the first date of the nth period (day, week, month or year) of the rule
+func (r *icalRecurrenceRule) periodStart(start time.Time, n int) time.Time {
+	switch r.freq {
+	case "DAILY":
+		return start.AddDate(0, 0, n*r.interval)
+	case "WEEKLY":
+		daysSinceWeekStart := (int(start.Weekday()) - int(r.weekStart) + 7) % 7
+		return start.AddDate(0, 0, n*r.interval*7-daysSinceWeekStart)
+	case "MONTHLY":
+		return time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, n*r.interval, 0)
+	default:
+		return time.Date(start.Year(), time.January, 1, 0, 0, 0, 0, time.UTC).AddDate(n*r.interval, 0, 0)
+	}
+}
+
+// periodDates returns the sorted dates selected by the rule in the period starting on periodStart
+func (r *icalRecurrenceRule) periodDates(start time.Time, periodStart time.Time) []time.Time {
+	candidates := []time.Time{}
+	switch r.freq {
+	case "DAILY":
+		candidates = append(candidates, periodStart)
+	case "WEEKLY":
+		for i := 0; i < 7; i++ {
+			d := periodStart.AddDate(0, 0, i)
+			if (len(r.byDay) == 0 && d.Weekday() == start.Weekday()) || r.matchesWeekday(d) {
+				candidates = append(candidates, d)
+			}
+		}
+	case "MONTHLY":
+		candidates = r.monthDates(start, periodStart.Year(), periodStart.Month())
+	default:
+		if len(r.byMonth) == 0 && len(r.byMonthDay) == 0 && len(r.byDay) > 0 {
+			// the ordinals of BYDAY count the weekdays of the year when there is no BYMONTH
+			candidates = r.byDayDates(periodStart, periodStart.AddDate(1, 0, 0))
+		} else {
+			months := r.byMonth
+			if len(months) == 0 {
+				months = []time.Month{start.Month()}
+			}
+			for _, m := range months {
+				candidates = append(candidates, r.monthDates(start, periodStart.Year(), m)...)
+			}
+		}
+	}
+
+	dates := []time.Time{}
+	for _, d := range candidates {
+		if len(r.byMonth) > 0 && !containsMonth(r.byMonth, d.Month()) {
+			continue
+		}
+		if r.freq == "DAILY" && (!r.matchesMonthDay(d) || (len(r.byDay) > 0 && !r.matchesWeekday(d))) {
+			continue
+		}
+		dates = append(dates, d)
+	}
+	sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+	return dates
+}
+
+// monthDates returns the dates selected by BYMONTHDAY and BYDAY in a month, or the day of the month of start when neither is set
+func (r *icalRecurrenceRule) monthDates(start time.Time, year int, month time.Month) []time.Time {
+	first := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	next := first.AddDate(0, 1, 0)
+	daysInMonth := next.AddDate(0, 0, -1).Day()
+
+	if len(r.byMonthDay) > 0 {
+		dates := []time.Time{}
+		for _, day := range r.byMonthDay {
+			if day < 0 {
+				day = daysInMonth + day + 1
+			}
+			if day < 1 || day > daysInMonth {
+				continue
+			}
+			d := first.AddDate(0, 0, day-1)
+			// BYDAY limits the days of the month when both are set, such as every friday the 13th
+			if len(r.byDay) == 0 || r.matchesWeekday(d) {
+				dates = append(dates, d)
+			}
+		}
+		return dates
+	}
+	if len(r.byDay) > 0 {
+		return r.byDayDates(first, next)
+	}
+	if start.Day() > daysInMonth {
+		return []time.Time{}
+	}
+	return []time.Time{first.AddDate(0, 0, start.Day()-1)}
+}
+
+// byDayDates returns the dates from first up until next (exclusive) that are selected by BYDAY, where the ordinals count the weekdays
+// in that range from the start (positive) or from the end (negative)
+func (r *icalRecurrenceRule) byDayDates(first time.Time, next time.Time) []time.Time {
+	dates := []time.Time{}
+	for _, bd := range r.byDay {
+		matching := []time.Time{}
+		for d := first; d.Before(next); d = d.AddDate(0, 0, 1) {
+			if d.Weekday() == bd.weekday {
+				matching = append(matching, d)
+			}
+		}
+
+		if bd.ordinal == 0 {
+			dates = append(dates, matching...)
+		} else if bd.ordinal > 0 && bd.ordinal <= len(matching) {
+			dates = append(dates, matching[bd.ordinal-1])
+		} else if bd.ordinal < 0 && -bd.ordinal <= len(matching) {
+			dates = append(dates, matching[len(matching)+bd.ordinal])
+		}
+	}
+	return dates
+}
+
+func (r *icalRecurrenceRule) matchesWeekday(d time.Time) bool {
+	for _, bd := range r.byDay {
+		if bd.weekday == d.Weekday() {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *icalRecurrenceRule) matchesMonthDay(d time.Time) bool {
+	if len(r.byMonthDay) == 0 {
+		return true
+	}
+	daysInMonth := time.Date(d.Year(), d.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	for _, day := range r.byMonthDay {
+		if day == d.Day() || daysInMonth+day+1 == d.Day() {
+			return true
+		}
+	}
+	return false
+}
+
+func containsMonth(months []time.Month, m time.Month) bool {
+	for _, month := range months {
+		if month == m {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+package plugins
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestICalRecurrenceRuleExpand(t *testing.T) {
+	horizon := time.Date(2020, 12, 31, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		rrule string
+		start string
+		want  []string
+	}{
+		{
+			// the occurrences count from DTSTART
+			rrule: "FREQ=DAILY;INTERVAL=3;COUNT=3",
+			start: "2020-01-30",
+			want:  []string{"2020-01-30", "2020-02-02", "2020-02-05"},
+		}, {
+			// weeks start on monday, so the first period only has the weekend after DTSTART
+			rrule: "FREQ=WEEKLY;INTERVAL=2;BYDAY=SA,SU;COUNT=5",
+			start: "2020-01-01",
+			want:  []string{"2020-01-04", "2020-01-05", "2020-01-18", "2020-01-19", "2020-02-01"},
+		}, {
+			// months that do not have the day of DTSTART are skipped
+			rrule: "FREQ=MONTHLY;COUNT=3",
+			start: "2020-01-31",
+			want:  []string{"2020-01-31", "2020-03-31", "2020-05-31"},
+		}, {
+			rrule: "FREQ=MONTHLY;BYMONTHDAY=-1;UNTIL=20200401",
+			start: "2020-01-31",
+			want:  []string{"2020-01-31", "2020-02-29", "2020-03-31"},
+		}, {
+			// first monday of september, expanded up until the horizon
+			rrule: "FREQ=YEARLY;BYMONTH=9;BYDAY=1MO",
+			start: "2019-09-02",
+			want:  []string{"2019-09-02", "2020-09-07"},
+		}, {
+			// the ordinal counts the weekdays of the year without BYMONTH
+			rrule: "FREQ=YEARLY;BYDAY=-1FR;COUNT=2",
+			start: "2019-12-27",
+			want:  []string{"2019-12-27", "2020-12-25"},
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.rrule, func(t *testing.T) {
+			r, e := parseICalRecurrenceRule(k.rrule)
+			if !assert.NoError(t, e) {
+				return
+			}
+			start, e := time.Parse(holidayDateFormat, k.start)
+			if !assert.NoError(t, e) {
+				return
+			}
+
+			got := []string{}
+			for _, d := range r.expand(start, horizon) {
+				got = append(got, d.Format(holidayDateFormat))
+			}
+			assert.Equal(t, k.want, got)
+		})
+	}
+}
+
+func TestParseICalRecurrenceRule_Invalid(t *testing.T) {
+	for _, rrule := range []string{
+		"",
+		"INTERVAL=2",
+		"FREQ=HOURLY",
+		"FREQ=YEARLY;COUNT=0",
+		"FREQ=YEARLY;BYMONTH=13",
+		"FREQ=YEARLY;BYDAY=XX",
+		"FREQ=YEARLY;COUNT=2;UNTIL=20201231",
+		"FREQ=MONTHLY;BYSETPOS=-1;BYDAY=MO,TU,WE,TH,FR",
+	} {
+		_, e := parseICalRecurrenceRule(rrule)
+		assert.Error(t, e, rrule)
+	}
+}
@@ -0,0 +1,230 @@
+package plugins
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/kelpdb"
+	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/queries"
+	"github.com/stellar/kelp/support/postgresdb"
+)
+
+// icebergLevelProvider wraps a LevelProvider so that only a visible amount of each level is placed as an offer while the rest of the
+// level's amount is kept as a hidden reserve. Fills on a level are drawn from its reserve and the offer is replenished to the visible
+// amount on the next update until the reserve is used up, after which the level is no longer placed.
+// When a db is set the fills are persisted so the reserves are not refilled when the bot is restarted.
+type icebergLevelProvider struct {
+	inner            api.LevelProvider
+	visibleAmount    float64 // in units of the base asset
+	isBuySide        bool
+	orderConstraints *model.OrderConstraints
+	db               *sql.DB // can be nil, in which case the reserves start out full on every restart
+	marketID         string
+
+	// uninitialized
+	lock         *sync.Mutex
+	filledAmount []float64   // amount of the base asset filled on each level of the inner provider
+	lastLevels   []api.Level // levels of the inner provider from the last update, used to match fills to levels
+}
+
+// ensure it implements LevelProvider, FillHandler and postUpdateLevelProvider
+var _ api.LevelProvider = &icebergLevelProvider{}
+var _ api.FillHandler = &icebergLevelProvider{}
+var _ postUpdateLevelProvider = &icebergLevelProvider{}
+
+// makeIcebergLevelProvider is a factory method
+func makeIcebergLevelProvider(
+	inner api.LevelProvider,
+	visibleAmount float64,
+	isBuySide bool,
+	orderConstraints *model.OrderConstraints,
+	db *sql.DB,
+	marketID string,
+) (api.LevelProvider, error) {
+	if visibleAmount <= 0 {
+		return nil, fmt.Errorf("visible amount of iceberg levels needs to be > 0 but was %f", visibleAmount)
+	}
+	if visibleAmount < orderConstraints.MinBaseVolume.AsFloat() {
+		return nil, fmt.Errorf("visible amount of iceberg levels (%f) needs to be >= the min base volume (%s)", visibleAmount, orderConstraints.MinBaseVolume.AsString())
+	}
+
+	p := &icebergLevelProvider{
+		inner:            inner,
+		visibleAmount:    visibleAmount,
+		isBuySide:        isBuySide,
+		orderConstraints: orderConstraints,
+		db:               db,
+		marketID:         marketID,
+		lock:             &sync.Mutex{},
+		filledAmount:     []float64{},
+		lastLevels:       []api.Level{},
+	}
+	if db != nil {
+		e := p.loadFilledAmounts()
+		if e != nil {
+			return nil, fmt.Errorf("unable to load the filled amounts of the iceberg levels: %s", e)
+		}
+	}
+	return p, nil
+}
+
+func (p *icebergLevelProvider) side() string {
+	if p.isBuySide {
+		return model.OrderActionBuy.String()
+	}
+	return model.OrderActionSell.String()
+}
+
+// loadFilledAmounts restores the amounts that were drawn from the reserves of the levels before the bot was restarted
+func (p *icebergLevelProvider) loadFilledAmounts() error {
+	query, e := queries.MakeStrategyIcebergFills(p.db, p.marketID, p.side())
+	if e != nil {
+		return fmt.Errorf("unable to make strategyIcebergFills query: %s", e)
+	}
+	queryResult, e := query.QueryRow()
+	if e != nil {
+		return fmt.Errorf("error while executing strategyIcebergFills query: %s", e)
+	}
+	filledByLevel, ok := queryResult.(map[int]float64)
+	if !ok {
+		return fmt.Errorf("unable to convert result of strategyIcebergFills query to map[int]float64: %v (type=%T)", queryResult, queryResult)
+	}
+
+	for levelIdx, filled := range filledByLevel {
+		for len(p.filledAmount) <= levelIdx {
+			p.filledAmount = append(p.filledAmount, 0.0)
+		}
+		p.filledAmount[levelIdx] = filled
+		log.Printf("loaded filled amount of iceberg level %d (side=%s): %.8f\n", levelIdx+1, p.side(), filled)
+	}
+	return nil
+}
+
+// insertFill persists a fill on a level and returns false if the fill was already counted
+func (p *icebergLevelProvider) insertFill(trade model.Trade, levelIdx int) (bool, error) {
+	sqlInsert := fmt.Sprintf(kelpdb.SqlStrategyIcebergFillsInsertTemplate,
+		p.marketID,
+		trade.TransactionID.String(),
+		p.side(),
+		levelIdx,
+		trade.Volume.AsFloat(),
+		time.Now().UTC().Format(postgresdb.TimestampFormatString),
+	)
+	result, e := p.db.Exec(sqlInsert)
+	if e != nil {
+		return false, fmt.Errorf("could not execute sql insert values statement (%s): %s", sqlInsert, e)
+	}
+	numRows, e := result.RowsAffected()
+	if e != nil {
+		return false, fmt.Errorf("could not read the number of rows inserted by the sql statement (%s): %s", sqlInsert, e)
+	}
+	return numRows > 0, nil
+}
+
+// GetLevels impl.
+func (p *icebergLevelProvider) GetLevels(maxAssetBase float64, maxAssetQuote float64) ([]api.Level, error) {
+	innerLevels, e := p.inner.GetLevels(maxAssetBase, maxAssetQuote)
+	if e != nil {
+		return nil, fmt.Errorf("unable to get levels of the inner level provider: %s", e)
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for len(p.filledAmount) < len(innerLevels) {
+		p.filledAmount = append(p.filledAmount, 0.0)
+	}
+	p.lastLevels = innerLevels
+
+	levels := []api.Level{}
+	for i, l := range innerLevels {
+		reserve := l.Amount.AsFloat() - p.filledAmount[i]
+		if reserve < p.orderConstraints.MinBaseVolume.AsFloat() || reserve <= 0 {
+			log.Printf("iceberg level %d is used up (amount=%.8f, filled=%.8f), not placing it\n", i+1, l.Amount.AsFloat(), p.filledAmount[i])
+			continue
+		}
+
+		visible := math.Min(p.visibleAmount, reserve)
+		log.Printf("iceberg level %d: visible=%.8f, hiddenReserve=%.8f\n", i+1, visible, reserve-visible)
+		levels = append(levels, api.Level{
+			Price:  l.Price,
+			Amount: *model.NumberFromFloat(visible, p.orderConstraints.VolumePrecision),
+		})
+	}
+	return levels, nil
+}
+
+// PostUpdate passes the call on to the inner provider since it is wrapped
+func (p *icebergLevelProvider) PostUpdate() error {
+	if inner, ok := p.inner.(postUpdateLevelProvider); ok {
+		return inner.PostUpdate()
+	}
+	return nil
+}
+
+// GetFillHandlers impl
+func (p *icebergLevelProvider) GetFillHandlers() ([]api.FillHandler, error) {
+	innerHandlers, e := p.inner.GetFillHandlers()
+	if e != nil {
+		return nil, fmt.Errorf("unable to get fill handlers of the inner level provider: %s", e)
+	}
+	return append([]api.FillHandler{p}, innerHandlers...), nil
+}
+
+// HandleFill impl
+func (p *icebergLevelProvider) HandleFill(trade model.Trade) error {
+	if trade.OrderAction.IsBuy() != p.isBuySide {
+		// the fill was on the other side of the orderbook
+		return nil
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	levelIdx := closestLevelIndex(p.lastLevels, p.isBuySide, trade.Price.AsFloat())
+	if levelIdx == -1 {
+		log.Printf("iceberg could not match fill to a level since no levels were placed yet, ignoring fill: %s\n", trade)
+		return nil
+	}
+	if p.db != nil {
+		// persisted before the in-memory amount is updated so a fill that cannot be saved is retried by the fill tracker
+		isNewFill, e := p.insertFill(trade, levelIdx)
+		if e != nil {
+			return fmt.Errorf("unable to persist fill on iceberg level %d: %s", levelIdx+1, e)
+		}
+		if !isNewFill {
+			log.Printf("iceberg fill was already counted, ignoring fill: %s\n", trade)
+			return nil
+		}
+	}
+	p.filledAmount[levelIdx] += trade.Volume.AsFloat()
+	log.Printf("iceberg level %d was filled by %.8f units of the base asset (total filled=%.8f of %.8f), replenishing on the next update\n",
+		levelIdx+1, trade.Volume.AsFloat(), p.filledAmount[levelIdx], p.lastLevels[levelIdx].Amount.AsFloat())
+	return nil
+}
+
+// maybeWrapIcebergLevelProvider wraps the level provider in an icebergLevelProvider when a visible amount is configured (non-zero)
+func maybeWrapIcebergLevelProvider(
+	inner api.LevelProvider,
+	visibleAmount float64,
+	levelAmountUnit amountUnit,
+	isBuySide bool,
+	orderConstraints *model.OrderConstraints,
+	db *sql.DB,
+	marketID string,
+) (api.LevelProvider, error) {
+	if visibleAmount == 0 {
+		return inner, nil
+	}
+	if levelAmountUnit == amountUnitBaseBalancePercent {
+		// the level amounts shrink as the balance is depleted by fills, which would count the fills against the hidden reserve twice
+		return nil, fmt.Errorf("ICEBERG_VISIBLE_AMOUNT cannot be used with AMOUNT_UNIT '%s'", levelAmountUnit)
+	}
+	return makeIcebergLevelProvider(inner, visibleAmount, isBuySide, orderConstraints, db, marketID)
+}
@@ -0,0 +1,114 @@
+package plugins
+
+import (
+	"testing"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+	"github.com/stretchr/testify/assert"
+)
+
+// fixedLevelProvider always returns the same levels
+type fixedLevelProvider struct {
+	levels []api.Level
+}
+
+// GetLevels impl.
+func (p *fixedLevelProvider) GetLevels(maxAssetBase float64, maxAssetQuote float64) ([]api.Level, error) {
+	return p.levels, nil
+}
+
+// GetFillHandlers impl
+func (p *fixedLevelProvider) GetFillHandlers() ([]api.FillHandler, error) {
+	return nil, nil
+}
+
+func makeTestIcebergTrade(action model.OrderAction, price float64, volume float64) model.Trade {
+	return model.Trade{
+		Order: model.Order{
+			OrderAction: action,
+			Price:       model.NumberFromFloat(price, 7),
+			Volume:      model.NumberFromFloat(volume, 7),
+		},
+	}
+}
+
+func TestIcebergLevelProvider(t *testing.T) {
+	orderConstraints := model.MakeOrderConstraints(7, 7, 1.0)
+	inner := &fixedLevelProvider{levels: []api.Level{
+		{Price: *model.NumberFromFloat(1.0, 7), Amount: *model.NumberFromFloat(100.0, 7)},
+		{Price: *model.NumberFromFloat(1.1, 7), Amount: *model.NumberFromFloat(30.0, 7)},
+	}}
+	p, e := makeIcebergLevelProvider(inner, 20.0, false, orderConstraints, nil, "")
+	if !assert.NoError(t, e) {
+		return
+	}
+
+	levels, e := p.GetLevels(1000.0, 1000.0)
+	if !assert.NoError(t, e) || !assert.Equal(t, 2, len(levels)) {
+		return
+	}
+	assert.Equal(t, 20.0, levels[0].Amount.AsFloat())
+	assert.Equal(t, 20.0, levels[1].Amount.AsFloat())
+
+	// fills on the other side are ignored, fills on this side are matched to the level with the closest price
+	fillHandler := p.(api.FillHandler)
+	assert.NoError(t, fillHandler.HandleFill(makeTestIcebergTrade(model.OrderActionBuy, 1.1, 25.0)))
+	assert.NoError(t, fillHandler.HandleFill(makeTestIcebergTrade(model.OrderActionSell, 1.09, 15.0)))
+	levels, e = p.GetLevels(1000.0, 1000.0)
+	if !assert.NoError(t, e) || !assert.Equal(t, 2, len(levels)) {
+		return
+	}
+	assert.Equal(t, 20.0, levels[0].Amount.AsFloat())
+	assert.Equal(t, 15.0, levels[1].Amount.AsFloat())
+
+	// a level is no longer placed once its hidden reserve is used up
+	assert.NoError(t, fillHandler.HandleFill(makeTestIcebergTrade(model.OrderActionSell, 1.1, 15.0)))
+	levels, e = p.GetLevels(1000.0, 1000.0)
+	if !assert.NoError(t, e) || !assert.Equal(t, 1, len(levels)) {
+		return
+	}
+	assert.Equal(t, 1.0, levels[0].Price.AsFloat())
+	assert.Equal(t, 20.0, levels[0].Amount.AsFloat())
+}
+
+func TestIcebergLevelProviderBuySide(t *testing.T) {
+	orderConstraints := model.MakeOrderConstraints(7, 7, 1.0)
+	// the buy side quotes prices inverted, so these levels are at 2.0 and 4.0 units of quote per base
+	inner := &fixedLevelProvider{levels: []api.Level{
+		{Price: *model.NumberFromFloat(0.5, 7), Amount: *model.NumberFromFloat(50.0, 7)},
+		{Price: *model.NumberFromFloat(0.25, 7), Amount: *model.NumberFromFloat(50.0, 7)},
+	}}
+	p, e := makeIcebergLevelProvider(inner, 10.0, true, orderConstraints, nil, "")
+	if !assert.NoError(t, e) {
+		return
+	}
+	_, e = p.GetLevels(1000.0, 1000.0)
+	if !assert.NoError(t, e) {
+		return
+	}
+
+	assert.NoError(t, p.(api.FillHandler).HandleFill(makeTestIcebergTrade(model.OrderActionBuy, 3.9, 45.0)))
+	levels, e := p.GetLevels(1000.0, 1000.0)
+	if !assert.NoError(t, e) || !assert.Equal(t, 2, len(levels)) {
+		return
+	}
+	assert.Equal(t, 10.0, levels[0].Amount.AsFloat())
+	assert.Equal(t, 5.0, levels[1].Amount.AsFloat())
+}
+
+func TestMaybeWrapIcebergLevelProvider(t *testing.T) {
+	orderConstraints := model.MakeOrderConstraints(7, 7, 1.0)
+	inner := &fixedLevelProvider{}
+
+	p, e := maybeWrapIcebergLevelProvider(inner, 0.0, amountUnitBase, false, orderConstraints, nil, "")
+	if assert.NoError(t, e) {
+		assert.Equal(t, inner, p)
+	}
+
+	_, e = maybeWrapIcebergLevelProvider(inner, 10.0, amountUnitBaseBalancePercent, false, orderConstraints, nil, "")
+	assert.Error(t, e)
+
+	_, e = maybeWrapIcebergLevelProvider(inner, 0.5, amountUnitBase, false, orderConstraints, nil, "")
+	assert.Error(t, e)
+}
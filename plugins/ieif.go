@@ -101,6 +101,39 @@ func (ieif *IEIF) ResetCachedLiabilities(assetBase hProtocol.Asset, assetQuote h
 	return nil
 }
 
+// ResetCachedPairLiabilities removes the liabilities of offers between the two assets from the cache without clearing the rest of it,
+// used when a strategy updates the offers of a second market in addition to the trading pair reset by ResetCachedLiabilities
+func (ieif *IEIF) ResetCachedPairLiabilities(assetBase hProtocol.Asset, assetQuote hProtocol.Asset) error {
+	// fetch before recomputing below since _liabilities overwrites the cached value for the base asset
+	cachedBaseLiabilities, e := ieif.assetLiabilities(assetBase)
+	if e != nil {
+		return fmt.Errorf("could not get liabilities for base asset: %s", e)
+	}
+
+	offers, e := ieif.exchangeShim.LoadOffersHack()
+	if e != nil {
+		return fmt.Errorf("cannot load offers when trying to reset cached pair liabilities: %s", e)
+	}
+	_, basePairLiabilities, e := ieif.pairLiabilities(offers, assetBase, assetQuote)
+	if e != nil {
+		return fmt.Errorf("could not get pairLiabilities for base asset: %s", e)
+	}
+	quoteLiabilities, quotePairLiabilities, e := ieif.pairLiabilities(offers, assetQuote, assetBase)
+	if e != nil {
+		return fmt.Errorf("could not get pairLiabilities for quote asset: %s", e)
+	}
+
+	ieif.cachedLiabilities[assetBase] = Liabilities{
+		Buying:  cachedBaseLiabilities.Buying - basePairLiabilities.Buying,
+		Selling: cachedBaseLiabilities.Selling - basePairLiabilities.Selling,
+	}
+	ieif.cachedLiabilities[assetQuote] = Liabilities{
+		Buying:  quoteLiabilities.Buying - quotePairLiabilities.Buying,
+		Selling: quoteLiabilities.Selling - quotePairLiabilities.Selling,
+	}
+	return nil
+}
+
 // willOversellNative returns willOversellNative, error
 func (ieif *IEIF) willOversellNative(incrementalNativeAmount float64) (bool, error) {
 	nativeBalance, e := ieif.assetBalance(utils.NativeAsset)
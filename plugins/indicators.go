@@ -0,0 +1,302 @@
+package plugins
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stellar/go/clients/horizonclient"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/support/sdk"
+)
+
+// indicator computes a single value from a series of close prices ordered from oldest to newest
+type indicator interface {
+	// compute returns the value of the indicator for the latest close price
+	compute(closes []float64) (float64, error)
+	// minCloses is the minimum number of close prices needed to compute the indicator
+	minCloses() int
+}
+
+// closePriceFetcher fetches the close prices of the latest limit candles ordered from oldest to newest
+type closePriceFetcher func(limit int) ([]float64, error)
+
+// sdexTradeAggregationResolutions are the resolutions that horizon supports for trade aggregations
+var sdexTradeAggregationResolutions = map[int]bool{
+	60:            true,
+	5 * 60:        true,
+	15 * 60:       true,
+	secondsInHour: true,
+	secondsInDay:  true,
+}
+
+// makeIndicator parses an indicator from a config string, the supported formats are rsi/<period>,
+// macd/<fastPeriod>/<slowPeriod>/<signalPeriod>, and bollinger/<period>/<numStdDev>
+func makeIndicator(spec string) (indicator, error) {
+	parts := strings.Split(spec, "/")
+	name := parts[0]
+	args := parts[1:]
+
+	switch name {
+	case "rsi":
+		ints, e := parseIndicatorInts(spec, args, 1)
+		if e != nil {
+			return nil, e
+		}
+		return &rsiIndicator{period: ints[0]}, nil
+	case "macd":
+		ints, e := parseIndicatorInts(spec, args, 3)
+		if e != nil {
+			return nil, e
+		}
+		if ints[0] >= ints[1] {
+			return nil, fmt.Errorf("invalid indicator '%s', fastPeriod (%d) needs to be less than slowPeriod (%d)", spec, ints[0], ints[1])
+		}
+		return &macdIndicator{fastPeriod: ints[0], slowPeriod: ints[1], signalPeriod: ints[2]}, nil
+	case "bollinger":
+		if len(args) != 2 {
+			return nil, fmt.Errorf("invalid indicator '%s', expected format bollinger/<period>/<numStdDev>", spec)
+		}
+		ints, e := parseIndicatorInts(spec, args[:1], 1)
+		if e != nil {
+			return nil, e
+		}
+		numStdDev, e := strconv.ParseFloat(args[1], 64)
+		if e != nil || numStdDev <= 0.0 {
+			return nil, fmt.Errorf("invalid indicator '%s', numStdDev needs to be a positive number", spec)
+		}
+		return &bollingerIndicator{period: ints[0], numStdDev: numStdDev}, nil
+	default:
+		return nil, fmt.Errorf("unrecognized indicator '%s', supported indicators are rsi, macd, and bollinger", name)
+	}
+}
+
+func parseIndicatorInts(spec string, args []string, numArgs int) ([]int, error) {
+	if len(args) != numArgs {
+		return nil, fmt.Errorf("invalid indicator '%s', expected %d arguments but found %d", spec, numArgs, len(args))
+	}
+
+	ints := []int{}
+	for _, a := range args {
+		i, e := strconv.Atoi(a)
+		if e != nil || i <= 0 {
+			return nil, fmt.Errorf("invalid indicator '%s', arguments need to be positive integers", spec)
+		}
+		ints = append(ints, i)
+	}
+	return ints, nil
+}
+
+// rsiIndicator is the Relative Strength Index using Wilder's smoothing, in the range [0, 100]
+type rsiIndicator struct {
+	period int
+}
+
+var _ indicator = &rsiIndicator{}
+
+func (i *rsiIndicator) minCloses() int {
+	return i.period + 1
+}
+
+func (i *rsiIndicator) compute(closes []float64) (float64, error) {
+	if len(closes) < i.minCloses() {
+		return 0.0, fmt.Errorf("need at least %d close prices to compute rsi but found %d", i.minCloses(), len(closes))
+	}
+
+	avgGain, avgLoss := 0.0, 0.0
+	for j := 1; j <= i.period; j++ {
+		change := closes[j] - closes[j-1]
+		if change > 0 {
+			avgGain += change
+		} else {
+			avgLoss -= change
+		}
+	}
+	avgGain /= float64(i.period)
+	avgLoss /= float64(i.period)
+
+	for j := i.period + 1; j < len(closes); j++ {
+		change := closes[j] - closes[j-1]
+		gain, loss := 0.0, 0.0
+		if change > 0 {
+			gain = change
+		} else {
+			loss = -change
+		}
+		avgGain = (avgGain*float64(i.period-1) + gain) / float64(i.period)
+		avgLoss = (avgLoss*float64(i.period-1) + loss) / float64(i.period)
+	}
+
+	if avgLoss == 0.0 {
+		if avgGain == 0.0 {
+			return 50.0, nil
+		}
+		return 100.0, nil
+	}
+	rs := avgGain / avgLoss
+	return 100.0 - (100.0 / (1.0 + rs)), nil
+}
+
+// macdIndicator is the MACD histogram, i.e. the difference between the MACD line and its signal line
+type macdIndicator struct {
+	fastPeriod   int
+	slowPeriod   int
+	signalPeriod int
+}
+
+var _ indicator = &macdIndicator{}
+
+func (i *macdIndicator) minCloses() int {
+	return i.slowPeriod + i.signalPeriod - 1
+}
+
+func (i *macdIndicator) compute(closes []float64) (float64, error) {
+	if len(closes) < i.minCloses() {
+		return 0.0, fmt.Errorf("need at least %d close prices to compute macd but found %d", i.minCloses(), len(closes))
+	}
+
+	fastEMA := computeEMA(closes, i.fastPeriod)
+	slowEMA := computeEMA(closes, i.slowPeriod)
+	// the slow EMA is only defined starting from index slowPeriod-1
+	macdLine := []float64{}
+	for j := i.slowPeriod - 1; j < len(closes); j++ {
+		macdLine = append(macdLine, fastEMA[j]-slowEMA[j])
+	}
+	signalLine := computeEMA(macdLine, i.signalPeriod)
+
+	last := len(macdLine) - 1
+	return macdLine[last] - signalLine[last], nil
+}
+
+// bollingerIndicator is the Bollinger %B, which is 0 at the lower band, 0.5 at the middle band, and 1 at the upper band
+type bollingerIndicator struct {
+	period    int
+	numStdDev float64
+}
+
+var _ indicator = &bollingerIndicator{}
+
+func (i *bollingerIndicator) minCloses() int {
+	return i.period
+}
+
+func (i *bollingerIndicator) compute(closes []float64) (float64, error) {
+	if len(closes) < i.minCloses() {
+		return 0.0, fmt.Errorf("need at least %d close prices to compute bollinger bands but found %d", i.minCloses(), len(closes))
+	}
+
+	window := closes[len(closes)-i.period:]
+	mean := 0.0
+	for _, c := range window {
+		mean += c
+	}
+	mean /= float64(i.period)
+
+	variance := 0.0
+	for _, c := range window {
+		variance += (c - mean) * (c - mean)
+	}
+	stdDev := math.Sqrt(variance / float64(i.period))
+	if stdDev == 0.0 {
+		return 0.5, nil
+	}
+
+	lower := mean - i.numStdDev*stdDev
+	upper := mean + i.numStdDev*stdDev
+	return (closes[len(closes)-1] - lower) / (upper - lower), nil
+}
+
+// computeEMA returns the exponential moving average for each index of the series, seeded with the simple moving average of the first
+// period values. Values before index period-1 are not defined and are set to 0.0
+func computeEMA(series []float64, period int) []float64 {
+	ema := make([]float64, len(series))
+	if len(series) < period {
+		return ema
+	}
+
+	sma := 0.0
+	for j := 0; j < period; j++ {
+		sma += series[j]
+	}
+	ema[period-1] = sma / float64(period)
+
+	k := 2.0 / float64(period+1)
+	for j := period; j < len(series); j++ {
+		ema[j] = series[j]*k + ema[j-1]*(1-k)
+	}
+	return ema
+}
+
+// makeCcxtClosePriceFetcher makes a closePriceFetcher that fetches candles from a ccxt exchange, exchangeName is specified as "ccxt-<name>"
+func makeCcxtClosePriceFetcher(exchangeName string, tradingPair string, timeframe string) (closePriceFetcher, error) {
+	if !strings.HasPrefix(exchangeName, "ccxt-") {
+		return nil, fmt.Errorf("candles can only be fetched from ccxt exchanges (ccxt-<name>) or sdex, was '%s'", exchangeName)
+	}
+	_, e := parseTimeframeSeconds(timeframe)
+	if e != nil {
+		return nil, fmt.Errorf("could not parse timeframe: %s", e)
+	}
+
+	c, e := sdk.MakeInitializedCcxtExchange(strings.TrimPrefix(exchangeName, "ccxt-"), api.ExchangeAPIKey{}, nil, nil)
+	if e != nil {
+		return nil, fmt.Errorf("error making a ccxt exchange to fetch candles: %s", e)
+	}
+
+	return func(limit int) ([]float64, error) {
+		candles, e := c.FetchOHLCV(tradingPair, timeframe, nil, &limit)
+		if e != nil {
+			return nil, fmt.Errorf("could not fetch candles: %s", e)
+		}
+
+		closes := []float64{}
+		for _, candle := range candles {
+			closes = append(closes, candle.Close)
+		}
+		return closes, nil
+	}, nil
+}
+
+// makeSdexClosePriceFetcher makes a closePriceFetcher that uses the trade aggregations on SDEX
+func makeSdexClosePriceFetcher(client *horizonclient.Client, baseAsset *hProtocol.Asset, quoteAsset *hProtocol.Asset, timeframe string) (closePriceFetcher, error) {
+	timeframeSeconds, e := parseTimeframeSeconds(timeframe)
+	if e != nil {
+		return nil, fmt.Errorf("could not parse timeframe: %s", e)
+	}
+	if !sdexTradeAggregationResolutions[timeframeSeconds] {
+		return nil, fmt.Errorf("timeframe '%s' is not supported for sdex trade aggregations, use one of 1m, 5m, 15m, 1h, or 1d", timeframe)
+	}
+
+	return func(limit int) ([]float64, error) {
+		resolution := time.Duration(timeframeSeconds) * time.Second
+		now := time.Now()
+		page, e := client.TradeAggregations(horizonclient.TradeAggregationRequest{
+			StartTime:          now.Add(-resolution * time.Duration(limit)),
+			EndTime:            now,
+			Resolution:         resolution,
+			BaseAssetType:      horizonclient.AssetType(baseAsset.Type),
+			BaseAssetCode:      baseAsset.Code,
+			BaseAssetIssuer:    baseAsset.Issuer,
+			CounterAssetType:   horizonclient.AssetType(quoteAsset.Type),
+			CounterAssetCode:   quoteAsset.Code,
+			CounterAssetIssuer: quoteAsset.Issuer,
+			Order:              horizonclient.OrderAsc,
+			Limit:              uint(limit),
+		})
+		if e != nil {
+			return nil, fmt.Errorf("could not fetch sdex trade aggregations: %s", e)
+		}
+
+		closes := []float64{}
+		for _, record := range page.Embedded.Records {
+			c, e := strconv.ParseFloat(record.Close, 64)
+			if e != nil {
+				return nil, fmt.Errorf("could not parse close price '%s' of trade aggregation: %s", record.Close, e)
+			}
+			closes = append(closes, c)
+		}
+		return closes, nil
+	}, nil
+}
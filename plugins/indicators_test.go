@@ -0,0 +1,99 @@
+package plugins
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMakeIndicator(t *testing.T) {
+	testCases := []struct {
+		spec      string
+		want      indicator
+		wantError bool
+	}{
+		{spec: "rsi/14", want: &rsiIndicator{period: 14}},
+		{spec: "macd/12/26/9", want: &macdIndicator{fastPeriod: 12, slowPeriod: 26, signalPeriod: 9}},
+		{spec: "bollinger/20/2.5", want: &bollingerIndicator{period: 20, numStdDev: 2.5}},
+		{spec: "rsi", wantError: true},
+		{spec: "rsi/0", wantError: true},
+		{spec: "rsi/abc", wantError: true},
+		{spec: "macd/26/12/9", wantError: true},
+		{spec: "macd/12/26", wantError: true},
+		{spec: "bollinger/20", wantError: true},
+		{spec: "bollinger/20/-1", wantError: true},
+		{spec: "sma/20", wantError: true},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.spec, func(t *testing.T) {
+			actual, e := makeIndicator(k.spec)
+			if k.wantError {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, k.want, actual)
+		})
+	}
+}
+
+func TestComputeEMA(t *testing.T) {
+	actual := computeEMA([]float64{1, 2, 3, 4, 5}, 3)
+	assert.Equal(t, []float64{0, 0, 2, 3, 4}, actual)
+}
+
+func TestIndicatorCompute(t *testing.T) {
+	flat := []float64{}
+	jumpAtEnd := []float64{}
+	for i := 0; i < 40; i++ {
+		flat = append(flat, 1.0)
+		if i < 35 {
+			jumpAtEnd = append(jumpAtEnd, 1.0)
+		} else {
+			jumpAtEnd = append(jumpAtEnd, 2.0)
+		}
+	}
+
+	testCases := []struct {
+		name      string
+		indicator indicator
+		closes    []float64
+		wantValue float64
+		wantSign  int // when non-zero only check the sign of the value
+		wantError bool
+	}{
+		{name: "rsi rising", indicator: &rsiIndicator{period: 3}, closes: []float64{1, 2, 3, 4, 5}, wantValue: 100.0},
+		{name: "rsi falling", indicator: &rsiIndicator{period: 3}, closes: []float64{5, 4, 3, 2, 1}, wantValue: 0.0},
+		{name: "rsi flat", indicator: &rsiIndicator{period: 3}, closes: []float64{1, 1, 1, 1, 1}, wantValue: 50.0},
+		{name: "rsi alternating", indicator: &rsiIndicator{period: 2}, closes: []float64{1, 2, 1, 2, 1}, wantValue: 37.5},
+		{name: "rsi not enough closes", indicator: &rsiIndicator{period: 3}, closes: []float64{1, 2, 3}, wantError: true},
+		{name: "bollinger above middle", indicator: &bollingerIndicator{period: 5, numStdDev: 1.0}, closes: []float64{1, 2, 3, 4, 5}, wantValue: 1.2071068},
+		{name: "bollinger flat", indicator: &bollingerIndicator{period: 5, numStdDev: 2.0}, closes: []float64{9, 1, 1, 1, 1, 1}, wantValue: 0.5},
+		{name: "bollinger not enough closes", indicator: &bollingerIndicator{period: 5, numStdDev: 2.0}, closes: []float64{1, 1}, wantError: true},
+		{name: "macd flat", indicator: &macdIndicator{fastPeriod: 12, slowPeriod: 26, signalPeriod: 9}, closes: flat, wantValue: 0.0},
+		{name: "macd jump up", indicator: &macdIndicator{fastPeriod: 12, slowPeriod: 26, signalPeriod: 9}, closes: jumpAtEnd, wantSign: 1},
+		{name: "macd not enough closes", indicator: &macdIndicator{fastPeriod: 12, slowPeriod: 26, signalPeriod: 9}, closes: flat[:20], wantError: true},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			actual, e := k.indicator.compute(k.closes)
+			if k.wantError {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+
+			if k.wantSign > 0 {
+				assert.True(t, actual > 0.0, "expected positive value but was %f", actual)
+				return
+			}
+			assert.InDelta(t, k.wantValue, actual, 0.000001)
+		})
+	}
+}
@@ -12,21 +12,22 @@ import (
 type IntervalTimeController struct {
 	tickInterval time.Duration
 	tickDelayFn  func() time.Duration
+	clock        api.Clock
 }
 
-// MakeIntervalTimeController is a factory method
-func MakeIntervalTimeController(tickInterval time.Duration, maxTickDelayMillis int64) api.TimeController {
+// MakeIntervalTimeController is a factory method, randGen is only used when maxTickDelayMillis > 0
+func MakeIntervalTimeController(tickInterval time.Duration, maxTickDelayMillis int64, clock api.Clock, randGen *rand.Rand) api.TimeController {
 	tickDelayFn := func() time.Duration {
 		return time.Duration(0) * time.Millisecond
 	}
 	if maxTickDelayMillis > 0 {
-		randGen := rand.New(rand.NewSource(time.Now().UnixNano()))
 		tickDelayFn = makeRandomDelayMillisFn(maxTickDelayMillis, randGen)
 	}
 
 	return &IntervalTimeController{
 		tickInterval: tickInterval,
 		tickDelayFn:  tickDelayFn,
+		clock:        clock,
 	}
 }
 
@@ -43,7 +44,7 @@ func (t *IntervalTimeController) ShouldUpdate(lastUpdateTime time.Time, currentU
 // SleepTime impl
 func (t *IntervalTimeController) SleepTime(lastUpdateTime time.Time) time.Duration {
 	// use real time now because we want the start of the clock cycle to be synchronized
-	return t.sleepTimeInternal(lastUpdateTime, time.Now())
+	return t.sleepTimeInternal(lastUpdateTime, t.clock.Now())
 }
 
 // realNow is the actual current time and not the synchronized time since we want to check sleep from when this function is called
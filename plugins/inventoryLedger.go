@@ -0,0 +1,279 @@
+package plugins
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/kelpdb"
+	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/queries"
+	"github.com/stellar/kelp/support/postgresdb"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// inventoryLotDustVolume is the remaining volume below which a lot is considered fully closed, so rounding errors don't leave dust lots behind
+const inventoryLotDustVolume = 0.0000000001
+
+// InventoryLotMethod is the order in which open lots are closed when a trade reduces the inventory
+type InventoryLotMethod string
+
+// type of InventoryLotMethod
+const (
+	InventoryLotMethodFIFO InventoryLotMethod = "fifo"
+	InventoryLotMethodLIFO InventoryLotMethod = "lifo"
+)
+
+// String is the Stringer method impl
+func (m InventoryLotMethod) String() string {
+	return string(m)
+}
+
+// sortDirection is the sql sort direction of the date on which lots were opened, so the lots to be closed first are returned first
+func (m InventoryLotMethod) sortDirection() string {
+	if m == InventoryLotMethodLIFO {
+		return "DESC"
+	}
+	return "ASC"
+}
+
+// ParseInventoryLotMethod converts a string to an InventoryLotMethod
+func ParseInventoryLotMethod(method string) (InventoryLotMethod, error) {
+	if method == InventoryLotMethodFIFO.String() {
+		return InventoryLotMethodFIFO, nil
+	} else if method == InventoryLotMethodLIFO.String() {
+		return InventoryLotMethodLIFO, nil
+	}
+	return InventoryLotMethodFIFO, fmt.Errorf("invalid inventory lot method '%s', needs to be either '%s' or '%s'", method, InventoryLotMethodFIFO, InventoryLotMethodLIFO)
+}
+
+// InventoryLedger is a FillHandler that tracks the cost basis of the inventory of the base asset as lots in a SQL database.
+// Buys open long lots and sells close them in the order of the configured InventoryLotMethod, realizing the P&L of each closed lot.
+// Sells in excess of the long inventory open short lots, which are closed by buys in the same way.
+type InventoryLedger struct {
+	db             *sql.DB
+	assetDisplayFn model.AssetDisplayFn
+	exchangeName   string
+	accountID      string
+	method         InventoryLotMethod
+
+	// uninitialized
+	marketID string
+}
+
+var _ api.FillHandler = &InventoryLedger{}
+
+// MakeInventoryLedger is a factory method
+func MakeInventoryLedger(db *sql.DB, assetDisplayFn model.AssetDisplayFn, exchangeName string, accountID string, method InventoryLotMethod) (*InventoryLedger, error) {
+	if db == nil {
+		return nil, fmt.Errorf("db should not be nil when using an inventory ledger")
+	}
+	if accountID == "" {
+		return nil, fmt.Errorf("accountID should not be empty when using an inventory ledger")
+	}
+
+	return &InventoryLedger{
+		db:             db,
+		assetDisplayFn: assetDisplayFn,
+		exchangeName:   exchangeName,
+		accountID:      accountID,
+		method:         method,
+	}, nil
+}
+
+func (l *InventoryLedger) fetchMarketID(trade model.Trade) (string, error) {
+	if l.marketID != "" {
+		return l.marketID, nil
+	}
+
+	baseAssetString, e := l.assetDisplayFn(trade.Pair.Base)
+	if e != nil {
+		return "", fmt.Errorf("bot is not configured to recognize the base asset %s: %s", string(trade.Pair.Base), e)
+	}
+	quoteAssetString, e := l.assetDisplayFn(trade.Pair.Quote)
+	if e != nil {
+		return "", fmt.Errorf("bot is not configured to recognize the quote asset %s: %s", string(trade.Pair.Quote), e)
+	}
+
+	l.marketID = MakeMarketID(l.exchangeName, baseAssetString, quoteAssetString)
+	return l.marketID, nil
+}
+
+// HandleFill impl.
+func (l *InventoryLedger) HandleFill(trade model.Trade) error {
+	txid := utils.CheckedString(trade.TransactionID)
+	if trade.Price == nil || trade.Volume == nil || trade.Timestamp == nil {
+		return fmt.Errorf("trade (txid=%s) needs a price, volume, and timestamp to be recorded in the inventory ledger", txid)
+	}
+	marketID, e := l.fetchMarketID(trade)
+	if e != nil {
+		return fmt.Errorf("cannot fetch marketID for trade (txid=%s): %s", txid, e)
+	}
+	date := time.Unix(trade.Timestamp.AsInt64()/1000, 0).UTC()
+	side := queries.InventoryLotSideShort
+	if trade.OrderAction.IsBuy() {
+		side = queries.InventoryLotSideLong
+	}
+
+	tx, e := l.db.Begin()
+	if e != nil {
+		return fmt.Errorf("could not begin db transaction: %s", e)
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback()
+		}
+	}()
+
+	var processed bool
+	e = tx.QueryRow(kelpdb.SqlQueryInventoryTradeProcessed, l.accountID, marketID, txid).Scan(&processed)
+	if e != nil {
+		return fmt.Errorf("could not check whether trade (txid=%s) was already recorded in the inventory ledger: %s", txid, e)
+	}
+	if processed {
+		log.Printf("trade (txid=%s) was already recorded in the inventory ledger, ignore and continue\n", txid)
+		return nil
+	}
+
+	// selecting the open lots locks them until the transaction is committed, so bots sharing the account are serialized
+	openLots, e := l.loadOpenLots(tx, marketID, side.Opposite())
+	if e != nil {
+		return fmt.Errorf("could not load open %s lots: %s", side.Opposite(), e)
+	}
+	updatedLots, closures, newLot := matchInventoryLots(openLots, txid, date, side, trade.Price.AsFloat(), trade.Volume.AsFloat())
+
+	statements := []string{}
+	for _, lot := range updatedLots {
+		statements = append(statements, fmt.Sprintf(kelpdb.SqlInventoryLotsUpdateRemainingTemplate, lot.RemainingBaseVolume, l.accountID, marketID, lot.TxID))
+	}
+	for _, c := range closures {
+		statements = append(statements, fmt.Sprintf(kelpdb.SqlInventoryLotClosuresInsertTemplate,
+			l.accountID,
+			marketID,
+			c.LotTxID,
+			c.ClosingTxID,
+			c.DateOpenedUTC.Format(postgresdb.TimestampFormatString),
+			c.DateClosedUTC.Format(postgresdb.TimestampFormatString),
+			c.Side.String(),
+			c.BaseVolume,
+			c.OpenPrice,
+			c.ClosePrice,
+			c.RealizedPnL,
+		))
+	}
+	if newLot != nil {
+		statements = append(statements, fmt.Sprintf(kelpdb.SqlInventoryLotsInsertTemplate,
+			l.accountID,
+			marketID,
+			newLot.TxID,
+			newLot.DateOpenedUTC.Format(postgresdb.TimestampFormatString),
+			newLot.Side.String(),
+			newLot.Price,
+			newLot.BaseVolume,
+			newLot.RemainingBaseVolume,
+		))
+	}
+	for _, statement := range statements {
+		_, e = tx.Exec(statement)
+		if e != nil {
+			return fmt.Errorf("could not execute sql statement (%s): %s", statement, e)
+		}
+	}
+
+	e = tx.Commit()
+	if e != nil {
+		return fmt.Errorf("could not commit db transaction: %s", e)
+	}
+	committed = true
+
+	realizedPnL := 0.0
+	for _, c := range closures {
+		realizedPnL += c.RealizedPnL
+	}
+	log.Printf("inventoryLedger: trade (txid=%s) closed %d lots (method=%s) with realizedPnL=%.8f, opened new %s lot = %v\n", txid, len(closures), l.method, realizedPnL, side, newLot != nil)
+	return nil
+}
+
+func (l *InventoryLedger) loadOpenLots(tx *sql.Tx, marketID string, side queries.InventoryLotSide) ([]queries.InventoryLot, error) {
+	sqlQuery := fmt.Sprintf(kelpdb.SqlQueryInventoryOpenLotsForUpdateTemplate, l.method.sortDirection())
+	rows, e := tx.Query(sqlQuery, l.accountID, marketID, side.String())
+	if e != nil {
+		return nil, fmt.Errorf("could not execute sql select query (%s): %s", sqlQuery, e)
+	}
+	defer rows.Close()
+
+	lots := []queries.InventoryLot{}
+	for rows.Next() {
+		var lot queries.InventoryLot
+		e = rows.Scan(&lot.TxID, &lot.DateOpenedUTC, &lot.Side, &lot.Price, &lot.BaseVolume, &lot.RemainingBaseVolume)
+		if e != nil {
+			return nil, fmt.Errorf("could not scan row into inventory lot: %s", e)
+		}
+		lots = append(lots, lot)
+	}
+	return lots, rows.Err()
+}
+
+// matchInventoryLots closes the open lots of the opposite side (in the order they should be closed) against a trade that would open a lot
+// on the given side. It returns the lots whose remaining volume changed, the closures, and the new lot opened with any volume that was
+// left over after closing all the open lots (nil if there was no volume left over).
+func matchInventoryLots(
+	openLots []queries.InventoryLot,
+	txid string,
+	date time.Time,
+	side queries.InventoryLotSide,
+	price float64,
+	baseVolume float64,
+) ([]queries.InventoryLot /*updatedLots*/, []queries.InventoryLotClosure, *queries.InventoryLot /*newLot*/) {
+	updatedLots := []queries.InventoryLot{}
+	closures := []queries.InventoryLotClosure{}
+	remaining := baseVolume
+	for _, lot := range openLots {
+		if remaining <= inventoryLotDustVolume {
+			break
+		}
+
+		closedVolume := lot.RemainingBaseVolume
+		if closedVolume > remaining {
+			closedVolume = remaining
+		}
+		// a long lot profits when the price goes up and a short lot profits when the price goes down
+		realizedPnL := (price - lot.Price) * closedVolume
+		if lot.Side == queries.InventoryLotSideShort {
+			realizedPnL = -realizedPnL
+		}
+		closures = append(closures, queries.InventoryLotClosure{
+			LotTxID:       lot.TxID,
+			ClosingTxID:   txid,
+			DateOpenedUTC: lot.DateOpenedUTC,
+			DateClosedUTC: date,
+			Side:          lot.Side,
+			BaseVolume:    closedVolume,
+			OpenPrice:     lot.Price,
+			ClosePrice:    price,
+			RealizedPnL:   realizedPnL,
+		})
+
+		lot.RemainingBaseVolume -= closedVolume
+		if lot.RemainingBaseVolume <= inventoryLotDustVolume {
+			lot.RemainingBaseVolume = 0.0
+		}
+		updatedLots = append(updatedLots, lot)
+		remaining -= closedVolume
+	}
+
+	if remaining <= inventoryLotDustVolume {
+		return updatedLots, closures, nil
+	}
+	return updatedLots, closures, &queries.InventoryLot{
+		TxID:                txid,
+		DateOpenedUTC:       date,
+		Side:                side,
+		Price:               price,
+		BaseVolume:          remaining,
+		RemainingBaseVolume: remaining,
+	}
+}
@@ -0,0 +1,132 @@
+package plugins
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/kelp/queries"
+)
+
+func TestParseInventoryLotMethod(t *testing.T) {
+	m, e := ParseInventoryLotMethod("fifo")
+	if assert.NoError(t, e) {
+		assert.Equal(t, InventoryLotMethodFIFO, m)
+		assert.Equal(t, "ASC", m.sortDirection())
+	}
+
+	m, e = ParseInventoryLotMethod("lifo")
+	if assert.NoError(t, e) {
+		assert.Equal(t, InventoryLotMethodLIFO, m)
+		assert.Equal(t, "DESC", m.sortDirection())
+	}
+
+	_, e = ParseInventoryLotMethod("average")
+	assert.Error(t, e)
+}
+
+func TestMatchInventoryLots(t *testing.T) {
+	date := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	lotA := queries.InventoryLot{TxID: "a", Side: queries.InventoryLotSideLong, Price: 0.10, BaseVolume: 100.0, RemainingBaseVolume: 100.0}
+	lotB := queries.InventoryLot{TxID: "b", Side: queries.InventoryLotSideLong, Price: 0.12, BaseVolume: 50.0, RemainingBaseVolume: 40.0}
+	shortLot := queries.InventoryLot{TxID: "s", Side: queries.InventoryLotSideShort, Price: 0.15, BaseVolume: 30.0, RemainingBaseVolume: 30.0}
+
+	testCases := []struct {
+		name             string
+		openLots         []queries.InventoryLot
+		side             queries.InventoryLotSide
+		price            float64
+		baseVolume       float64
+		wantRemaining    []float64 // remaining volume of each updated lot
+		wantClosedVolume []float64
+		wantPnL          []float64
+		wantNewLotVolume float64 // 0.0 when no new lot is opened
+	}{
+		{
+			name:             "open long lot without inventory",
+			openLots:         []queries.InventoryLot{},
+			side:             queries.InventoryLotSideLong,
+			price:            0.10,
+			baseVolume:       100.0,
+			wantRemaining:    []float64{},
+			wantClosedVolume: []float64{},
+			wantPnL:          []float64{},
+			wantNewLotVolume: 100.0,
+		}, {
+			name:             "partially close first lot",
+			openLots:         []queries.InventoryLot{lotA, lotB},
+			side:             queries.InventoryLotSideShort,
+			price:            0.11,
+			baseVolume:       60.0,
+			wantRemaining:    []float64{40.0},
+			wantClosedVolume: []float64{60.0},
+			wantPnL:          []float64{0.6},
+			wantNewLotVolume: 0.0,
+		}, {
+			name:             "close lots in the given order",
+			openLots:         []queries.InventoryLot{lotB, lotA},
+			side:             queries.InventoryLotSideShort,
+			price:            0.11,
+			baseVolume:       60.0,
+			wantRemaining:    []float64{0.0, 80.0},
+			wantClosedVolume: []float64{40.0, 20.0},
+			wantPnL:          []float64{-0.4, 0.2},
+			wantNewLotVolume: 0.0,
+		}, {
+			name:             "close all lots and open short lot with the excess",
+			openLots:         []queries.InventoryLot{lotA, lotB},
+			side:             queries.InventoryLotSideShort,
+			price:            0.11,
+			baseVolume:       150.0,
+			wantRemaining:    []float64{0.0, 0.0},
+			wantClosedVolume: []float64{100.0, 40.0},
+			wantPnL:          []float64{1.0, -0.4},
+			wantNewLotVolume: 10.0,
+		}, {
+			name:             "buy closes short lot",
+			openLots:         []queries.InventoryLot{shortLot},
+			side:             queries.InventoryLotSideLong,
+			price:            0.10,
+			baseVolume:       30.0,
+			wantRemaining:    []float64{0.0},
+			wantClosedVolume: []float64{30.0},
+			wantPnL:          []float64{1.5},
+			wantNewLotVolume: 0.0,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			updatedLots, closures, newLot := matchInventoryLots(k.openLots, "trade1", date, k.side, k.price, k.baseVolume)
+
+			if assert.Equal(t, len(k.wantRemaining), len(updatedLots)) {
+				for i, lot := range updatedLots {
+					assert.InDelta(t, k.wantRemaining[i], lot.RemainingBaseVolume, 0.0000001)
+				}
+			}
+			if assert.Equal(t, len(k.wantClosedVolume), len(closures)) {
+				for i, c := range closures {
+					assert.Equal(t, updatedLots[i].TxID, c.LotTxID)
+					assert.Equal(t, "trade1", c.ClosingTxID)
+					assert.Equal(t, updatedLots[i].DateOpenedUTC, c.DateOpenedUTC)
+					assert.Equal(t, date, c.DateClosedUTC)
+					assert.Equal(t, k.price, c.ClosePrice)
+					assert.InDelta(t, k.wantClosedVolume[i], c.BaseVolume, 0.0000001)
+					assert.InDelta(t, k.wantPnL[i], c.RealizedPnL, 0.0000001)
+				}
+			}
+			if k.wantNewLotVolume == 0.0 {
+				assert.Nil(t, newLot)
+				return
+			}
+			if assert.NotNil(t, newLot) {
+				assert.Equal(t, "trade1", newLot.TxID)
+				assert.Equal(t, k.side, newLot.Side)
+				assert.Equal(t, k.price, newLot.Price)
+				assert.InDelta(t, k.wantNewLotVolume, newLot.BaseVolume, 0.0000001)
+				assert.InDelta(t, k.wantNewLotVolume, newLot.RemainingBaseVolume, 0.0000001)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package plugins
+
+import (
+	"fmt"
+	"log"
+	"math"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+)
+
+// inventorySkewLevelProvider provides levels around a mid price where the spreads are skewed based on the current inventory
+// relative to a target ratio, so that we quote more aggressively on the side that brings the inventory back to the target
+type inventorySkewLevelProvider struct {
+	pf                   *api.FeedPair
+	offset               rateOffset
+	orderConstraints     *model.OrderConstraints
+	targetBaseRatio      float64
+	maxSkew              float64
+	levelCount           int
+	spread               float64
+	levelSpreadIncrement float64
+	amountOfBase         float64
+	isBuySide            bool
+}
+
+// ensure it implements the LevelProvider interface
+var _ api.LevelProvider = &inventorySkewLevelProvider{}
+
+// makeInventorySkewLevelProvider is a factory method
+func makeInventorySkewLevelProvider(
+	pf *api.FeedPair,
+	offset rateOffset,
+	orderConstraints *model.OrderConstraints,
+	targetBaseRatio float64,
+	maxSkew float64,
+	levelCount int,
+	spread float64,
+	levelSpreadIncrement float64,
+	amountOfBase float64,
+	isBuySide bool,
+) (api.LevelProvider, error) {
+	if targetBaseRatio <= 0.0 || targetBaseRatio >= 1.0 {
+		return nil, fmt.Errorf("invalid target base ratio, expected 0.0 < targetBaseRatio < 1.0; was %f", targetBaseRatio)
+	}
+	if maxSkew < 0.0 {
+		return nil, fmt.Errorf("invalid max skew, expected maxSkew >= 0.0; was %f", maxSkew)
+	}
+	if levelCount <= 0 {
+		return nil, fmt.Errorf("invalid level count, expected levelCount > 0; was %d", levelCount)
+	}
+	if spread < 0.0 || levelSpreadIncrement < 0.0 {
+		return nil, fmt.Errorf("invalid spread values, expected spread >= 0.0 and levelSpreadIncrement >= 0.0; was %f and %f", spread, levelSpreadIncrement)
+	}
+	if amountOfBase <= 0.0 {
+		return nil, fmt.Errorf("invalid amount of base, expected amountOfBase > 0.0; was %f", amountOfBase)
+	}
+
+	return &inventorySkewLevelProvider{
+		pf:                   pf,
+		offset:               offset,
+		orderConstraints:     orderConstraints,
+		targetBaseRatio:      targetBaseRatio,
+		maxSkew:              maxSkew,
+		levelCount:           levelCount,
+		spread:               spread,
+		levelSpreadIncrement: levelSpreadIncrement,
+		amountOfBase:         amountOfBase,
+		isBuySide:            isBuySide,
+	}, nil
+}
+
+// GetLevels impl.
+func (p *inventorySkewLevelProvider) GetLevels(maxAssetBase float64, maxAssetQuote float64) ([]api.Level, error) {
+	return p.getLevelsWithSpreadMultiplier(maxAssetBase, maxAssetQuote, 1.0)
+}
+
+// getLevelsWithSpreadMultiplier returns the levels where the spread of each level is scaled by the spreadMultiplier before it is skewed
+func (p *inventorySkewLevelProvider) getLevelsWithSpreadMultiplier(maxAssetBase float64, maxAssetQuote float64, spreadMultiplier float64) ([]api.Level, error) {
+	// the buy side is passed balances with base and quote swapped
+	baseBalance, quoteBalance := maxAssetBase, maxAssetQuote
+	if p.isBuySide {
+		baseBalance, quoteBalance = maxAssetQuote, maxAssetBase
+	}
+
+	midPrice, e := p.pf.GetFeedPairPrice()
+	if e != nil {
+		return nil, fmt.Errorf("mid price couldn't be loaded: %s", e)
+	}
+	midPrice, wasModified := p.offset.apply(midPrice)
+	if wasModified {
+		log.Printf("mid price (adjusted): %.7f\n", midPrice)
+	}
+
+	skew := computeInventorySkew(baseBalance, quoteBalance, midPrice, p.targetBaseRatio)
+	// a positive skew means we are base-heavy so we tighten asks and widen bids to get back to the target ratio
+	sideSkew := skew * p.maxSkew
+	if p.isBuySide {
+		sideSkew = -sideSkew
+	}
+	log.Printf("inventorySkewLevelProvider: isBuySide=%v, baseBalance=%.7f, quoteBalance=%.7f, midPrice=%.7f, targetBaseRatio=%.4f, skew=%.4f, sideSkew=%.4f\n",
+		p.isBuySide, baseBalance, quoteBalance, midPrice, p.targetBaseRatio, skew, sideSkew)
+
+	levels := []api.Level{}
+	for i := 0; i < p.levelCount; i++ {
+		levelSpread := math.Max((p.spread+float64(i)*p.levelSpreadIncrement)*spreadMultiplier-sideSkew, 0.0)
+
+		price := midPrice * (1 + levelSpread)
+		if p.isBuySide {
+			// we invert the price for the buy side
+			price = 1 / (midPrice * (1 - levelSpread))
+		}
+
+		levels = append(levels, api.Level{
+			Price:  *model.NumberFromFloat(price, p.orderConstraints.PricePrecision),
+			Amount: *model.NumberFromFloat(p.amountOfBase, p.orderConstraints.VolumePrecision),
+		})
+	}
+	return levels, nil
+}
+
+// computeInventorySkew returns a value in the range [-1, 1] that represents how far the inventory is from the target ratio of base
+// value to total value, where -1 means we only hold the quote asset, 0 means we are at the target, and 1 means we only hold the base asset
+func computeInventorySkew(baseBalance float64, quoteBalance float64, price float64, targetBaseRatio float64) float64 {
+	baseValue := baseBalance * price
+	totalValue := baseValue + quoteBalance
+	if totalValue <= 0.0 {
+		return 0.0
+	}
+
+	baseRatio := baseValue / totalValue
+	if baseRatio > targetBaseRatio {
+		return (baseRatio - targetBaseRatio) / (1 - targetBaseRatio)
+	}
+	return (baseRatio - targetBaseRatio) / targetBaseRatio
+}
+
+// GetFillHandlers impl
+func (p *inventorySkewLevelProvider) GetFillHandlers() ([]api.FillHandler, error) {
+	return nil, nil
+}
@@ -0,0 +1,117 @@
+package plugins
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+)
+
+func TestComputeInventorySkew(t *testing.T) {
+	testCases := []struct {
+		baseBalance     float64
+		quoteBalance    float64
+		price           float64
+		targetBaseRatio float64
+		want            float64
+	}{
+		{baseBalance: 100, quoteBalance: 200, price: 2.0, targetBaseRatio: 0.5, want: 0.0},
+		{baseBalance: 100, quoteBalance: 0, price: 2.0, targetBaseRatio: 0.5, want: 1.0},
+		{baseBalance: 0, quoteBalance: 200, price: 2.0, targetBaseRatio: 0.5, want: -1.0},
+		{baseBalance: 150, quoteBalance: 100, price: 2.0, targetBaseRatio: 0.5, want: 0.5},
+		{baseBalance: 50, quoteBalance: 300, price: 2.0, targetBaseRatio: 0.5, want: -0.5},
+		{baseBalance: 100, quoteBalance: 100, price: 1.0, targetBaseRatio: 0.25, want: 1.0 / 3.0},
+		{baseBalance: 0, quoteBalance: 0, price: 1.0, targetBaseRatio: 0.5, want: 0.0},
+	}
+
+	for _, k := range testCases {
+		t.Run(fmt.Sprintf("%.1f_%.1f_%.1f_%.2f", k.baseBalance, k.quoteBalance, k.price, k.targetBaseRatio), func(t *testing.T) {
+			actual := computeInventorySkew(k.baseBalance, k.quoteBalance, k.price, k.targetBaseRatio)
+			assert.InDelta(t, k.want, actual, 0.0000001)
+		})
+	}
+}
+
+func TestInventorySkewLevelProviderGetLevels(t *testing.T) {
+	pf := &api.FeedPair{
+		FeedA: &fixedFeed{price: 2.0},
+		FeedB: &fixedFeed{price: 1.0},
+	}
+	orderConstraints := model.MakeOrderConstraints(7, 7, 1.0)
+
+	testCases := []struct {
+		name         string
+		isBuySide    bool
+		baseBalance  float64
+		quoteBalance float64
+		wantPrices   []float64
+	}{
+		{
+			name:         "sell side at target",
+			isBuySide:    false,
+			baseBalance:  100,
+			quoteBalance: 200,
+			wantPrices:   []float64{2.02, 2.04},
+		}, {
+			name:         "sell side base heavy tightens asks",
+			isBuySide:    false,
+			baseBalance:  150,
+			quoteBalance: 100,
+			wantPrices:   []float64{2.01, 2.03},
+		}, {
+			name:         "sell side quote heavy widens asks",
+			isBuySide:    false,
+			baseBalance:  0,
+			quoteBalance: 200,
+			wantPrices:   []float64{2.04, 2.06},
+		}, {
+			name:         "buy side at target",
+			isBuySide:    true,
+			baseBalance:  100,
+			quoteBalance: 200,
+			wantPrices:   []float64{1 / 1.98, 1 / 1.96},
+		}, {
+			name:         "buy side base heavy widens bids",
+			isBuySide:    true,
+			baseBalance:  150,
+			quoteBalance: 100,
+			wantPrices:   []float64{1 / 1.97, 1 / 1.95},
+		}, {
+			name:         "buy side only base clamps to max skew",
+			isBuySide:    true,
+			baseBalance:  100,
+			quoteBalance: 0,
+			wantPrices:   []float64{1 / 1.96, 1 / 1.94},
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			p, e := makeInventorySkewLevelProvider(pf, rateOffset{}, orderConstraints, 0.5, 0.01, 2, 0.01, 0.01, 10.0, k.isBuySide)
+			if !assert.NoError(t, e) {
+				return
+			}
+
+			// the buy side receives the balances with base and quote swapped
+			maxAssetBase, maxAssetQuote := k.baseBalance, k.quoteBalance
+			if k.isBuySide {
+				maxAssetBase, maxAssetQuote = k.quoteBalance, k.baseBalance
+			}
+			levels, e := p.GetLevels(maxAssetBase, maxAssetQuote)
+			if !assert.NoError(t, e) {
+				return
+			}
+
+			if !assert.Equal(t, len(k.wantPrices), len(levels)) {
+				return
+			}
+			for i, l := range levels {
+				assert.InDelta(t, k.wantPrices[i], l.Price.AsFloat(), 0.0000001, fmt.Sprintf("price at level %d", i))
+				assert.Equal(t, 10.0, l.Amount.AsFloat())
+			}
+		})
+	}
+}
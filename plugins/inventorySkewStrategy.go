@@ -0,0 +1,122 @@
+package plugins
+
+import (
+	"fmt"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// inventorySkewConfig contains the configuration params for this strategy
+type inventorySkewConfig struct {
+	PriceTolerance         float64 `valid:"-" toml:"PRICE_TOLERANCE"`
+	AmountTolerance        float64 `valid:"-" toml:"AMOUNT_TOLERANCE"`
+	RateOffsetPercent      float64 `valid:"-" toml:"RATE_OFFSET_PERCENT"`
+	RateOffset             float64 `valid:"-" toml:"RATE_OFFSET"`
+	RateOffsetPercentFirst bool    `valid:"-" toml:"RATE_OFFSET_PERCENT_FIRST"`
+	DataTypeA              string  `valid:"-" toml:"DATA_TYPE_A"`
+	DataFeedAURL           string  `valid:"-" toml:"DATA_FEED_A_URL"`
+	DataTypeB              string  `valid:"-" toml:"DATA_TYPE_B"`
+	DataFeedBURL           string  `valid:"-" toml:"DATA_FEED_B_URL"`
+	TargetBaseRatio        float64 `valid:"-" toml:"TARGET_BASE_RATIO"`
+	MaxSkew                float64 `valid:"-" toml:"MAX_SKEW"`
+	LevelCount             int     `valid:"-" toml:"LEVEL_COUNT"`
+	Spread                 float64 `valid:"-" toml:"SPREAD"`
+	LevelSpreadIncrement   float64 `valid:"-" toml:"LEVEL_SPREAD_INCREMENT"`
+	AmountOfABase          float64 `valid:"-" toml:"AMOUNT_OF_A_BASE"` // the size of order to keep on each level
+}
+
+// String impl.
+func (c inventorySkewConfig) String() string {
+	return utils.StructString(c, 0, nil)
+}
+
+// makeInventorySkewStrategy is a factory method
+func makeInventorySkewStrategy(
+	sdex *SDEX,
+	pair *model.TradingPair,
+	ieif *IEIF,
+	assetBase *hProtocol.Asset,
+	assetQuote *hProtocol.Asset,
+	config *inventorySkewConfig,
+) (api.Strategy, error) {
+	offset := rateOffset{
+		percent:      config.RateOffsetPercent,
+		absolute:     config.RateOffset,
+		percentFirst: config.RateOffsetPercentFirst,
+	}
+	feedPair, e := MakeFeedPair(
+		config.DataTypeA,
+		config.DataFeedAURL,
+		config.DataTypeB,
+		config.DataFeedBURL,
+	)
+	if e != nil {
+		return nil, fmt.Errorf("cannot make the inventorySkew strategy because we could not make the feed pair: %s", e)
+	}
+	orderConstraints := sdex.GetOrderConstraints(pair)
+
+	sellLevelProvider, e := makeInventorySkewLevelProvider(
+		feedPair,
+		offset,
+		orderConstraints,
+		config.TargetBaseRatio,
+		config.MaxSkew,
+		config.LevelCount,
+		config.Spread,
+		config.LevelSpreadIncrement,
+		config.AmountOfABase,
+		false,
+	)
+	if e != nil {
+		return nil, fmt.Errorf("cannot make the sell side level provider: %s", e)
+	}
+	sellSideStrategy := makeSellSideStrategy(
+		sdex,
+		orderConstraints,
+		ieif,
+		assetBase,
+		assetQuote,
+		sellLevelProvider,
+		config.PriceTolerance,
+		config.AmountTolerance,
+		false,
+	)
+
+	buyLevelProvider, e := makeInventorySkewLevelProvider(
+		feedPair,
+		offset,
+		orderConstraints,
+		config.TargetBaseRatio,
+		config.MaxSkew,
+		config.LevelCount,
+		config.Spread,
+		config.LevelSpreadIncrement,
+		config.AmountOfABase,
+		true,
+	)
+	if e != nil {
+		return nil, fmt.Errorf("cannot make the buy side level provider: %s", e)
+	}
+	// switch sides of base/quote here for buy side
+	buySideStrategy := makeSellSideStrategy(
+		sdex,
+		orderConstraints,
+		ieif,
+		assetQuote,
+		assetBase,
+		buyLevelProvider,
+		config.PriceTolerance,
+		config.AmountTolerance,
+		true,
+	)
+
+	return makeComposeStrategy(
+		assetBase,
+		assetQuote,
+		buySideStrategy,
+		sellSideStrategy,
+	), nil
+}
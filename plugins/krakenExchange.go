@@ -15,11 +15,15 @@ import (
 	"github.com/stellar/kelp/api"
 	"github.com/stellar/kelp/model"
 	"github.com/stellar/kelp/support/networking"
+	"github.com/stellar/kelp/support/nonce"
 )
 
 // ensure that krakenExchange conforms to the Exchange interface
 var _ api.Exchange = &krakenExchange{}
 
+// ensure that krakenExchange conforms to the APIKeyPermissionsFetcher interface
+var _ api.APIKeyPermissionsFetcher = &krakenExchange{}
+
 const precisionBalances = 10
 const tradesFetchSleepTimeSeconds = 60
 
@@ -28,6 +32,8 @@ type krakenExchange struct {
 	assetConverter           *model.AssetConverter
 	assetConverterOpenOrders *model.AssetConverter // kraken uses different symbols when fetching open orders!
 	apis                     []*krakenapi.KrakenApi
+	nonces                   []*nonce.Service // nonces[i] is the nonce service of the API key of apis[i]
+	maskedKeys               []string         // maskedKeys[i] is the API key of apis[i] masked so it can be logged
 	apiNextIndex             uint8
 	delimiter                string
 	ocOverridesHandler       *OrderConstraintsOverridesHandler
@@ -59,15 +65,26 @@ func makeKrakenExchange(apiKeys []api.ExchangeAPIKey, isSimulated bool) (api.Exc
 	}
 
 	krakenAPIs := []*krakenapi.KrakenApi{}
+	nonces := []*nonce.Service{}
+	maskedKeys := []string{}
 	for _, apiKey := range apiKeys {
 		krakenAPIClient := krakenapi.New(apiKey.Key, apiKey.Secret)
 		krakenAPIs = append(krakenAPIs, krakenAPIClient)
+
+		nonceService, e := exchangeNonceRegistry.Service("kraken", apiKey.Key)
+		if e != nil {
+			return nil, fmt.Errorf("could not make nonce service for kraken API key: %s", e)
+		}
+		nonces = append(nonces, nonceService)
+		maskedKeys = append(maskedKeys, maskAPIKey(apiKey.Key))
 	}
 
 	return &krakenExchange{
 		assetConverter:           model.KrakenAssetConverter,
 		assetConverterOpenOrders: model.KrakenAssetConverterOpenOrders,
 		apis:                     krakenAPIs,
+		nonces:                   nonces,
+		maskedKeys:               maskedKeys,
 		apiNextIndex:             0,
 		delimiter:                "",
 		ocOverridesHandler:       MakeEmptyOrderConstraintsOverridesHandler(),
@@ -78,11 +95,28 @@ func makeKrakenExchange(apiKeys []api.ExchangeAPIKey, isSimulated bool) (api.Exc
 
 // nextAPI rotates the API key being used so we can overcome rate limit issues
 func (k *krakenExchange) nextAPI() *krakenapi.KrakenApi {
+	api, _ := k.nextAPIIndex()
+	return api
+}
+
+// nextPrivateAPI is nextAPI for the private methods of the kraken API, which need a nonce. The kraken client makes the nonce from the clock, so
+// the nonce service of the API key is held until release is called after the request, which keeps concurrent requests and restarts from
+// reusing or regressing nonces
+func (k *krakenExchange) nextPrivateAPI() (api *krakenapi.KrakenApi, release func(), e error) {
+	api, i := k.nextAPIIndex()
+	release, e = k.nonces[i].Acquire()
+	if e != nil {
+		return nil, nil, fmt.Errorf("could not acquire nonce for kraken API key at index %d: %s", i, e)
+	}
+	return api, release, nil
+}
+
+func (k *krakenExchange) nextAPIIndex() (*krakenapi.KrakenApi, uint8) {
 	log.Printf("returning kraken API key at index %d", k.apiNextIndex)
-	api := k.apis[k.apiNextIndex]
+	i := k.apiNextIndex
 	// rotate key for the next call
 	k.apiNextIndex = (k.apiNextIndex + 1) % uint8(len(k.apis))
-	return api
+	return k.apis[i], i
 }
 
 // AddOrder impl.
@@ -113,13 +147,18 @@ func (k *krakenExchange) AddOrder(order *model.Order, submitMode api.SubmitMode)
 	}
 	log.Printf("kraken is submitting order: pair=%s, orderAction=%s, orderType=%s, volume=%s, price=%s, submitMode=%s\n",
 		pairStr, order.OrderAction.String(), order.OrderType.String(), order.Volume.AsString(), order.Price.AsString(), submitMode.String())
-	resp, e := k.nextAPI().AddOrder(
+	krakenAPI, release, e := k.nextPrivateAPI()
+	if e != nil {
+		return nil, e
+	}
+	resp, e := krakenAPI.AddOrder(
 		pairStr,
 		order.OrderAction.String(),
 		order.OrderType.String(),
 		order.Volume.AsString(),
 		args,
 	)
+	release()
 	if e != nil {
 		return nil, e
 	}
@@ -144,7 +183,12 @@ func (k *krakenExchange) CancelOrder(txID *model.TransactionID, pair model.Tradi
 	log.Printf("kraken is canceling order: ID=%s, tradingPair=%s\n", txID.String(), pair.String())
 
 	// we don't actually use the pair for kraken
-	resp, e := k.nextAPI().CancelOrder(txID.String())
+	krakenAPI, release, e := k.nextPrivateAPI()
+	if e != nil {
+		return model.CancelResultFailed, e
+	}
+	resp, e := krakenAPI.CancelOrder(txID.String())
+	release()
 	if e != nil {
 		return model.CancelResultFailed, e
 	}
@@ -167,7 +211,12 @@ func (k *krakenExchange) CancelOrder(txID *model.TransactionID, pair model.Tradi
 
 // GetAccountBalances impl.
 func (k *krakenExchange) GetAccountBalances(assetList []interface{}) (map[interface{}]model.Number, error) {
-	balanceResponse, e := k.nextAPI().Balance()
+	krakenAPI, release, e := k.nextPrivateAPI()
+	if e != nil {
+		return nil, e
+	}
+	balanceResponse, e := krakenAPI.Balance()
+	release()
 	if e != nil {
 		return nil, e
 	}
@@ -224,7 +273,12 @@ func (k *krakenExchange) GetAssetConverter() model.AssetConverterInterface {
 
 // GetOpenOrders impl.
 func (k *krakenExchange) GetOpenOrders(pairs []*model.TradingPair) (map[model.TradingPair][]model.OpenOrder, error) {
-	openOrdersResponse, e := k.nextAPI().OpenOrders(map[string]string{})
+	krakenAPI, release, e := k.nextPrivateAPI()
+	if e != nil {
+		return nil, fmt.Errorf("cannot load open orders for Kraken: %s", e)
+	}
+	openOrdersResponse, e := krakenAPI.OpenOrders(map[string]string{})
+	release()
 	if e != nil {
 		return nil, fmt.Errorf("cannot load open orders for Kraken: %s", e)
 	}
@@ -455,7 +509,12 @@ func (k *krakenExchange) getTradeHistoryFromEndAscLimit50(tradingPair model.Trad
 	}
 	log.Printf("fetching trade history from end ascending with a limit of 50 tradingPair=%s, maybeCursorStartExclusive=%s, maybeCursorEndInclusive=%s\n", tradingPair.String(), startCursorLogString, endCursorLogString)
 
-	resp, e := k.nextAPI().Query("TradesHistory", input)
+	krakenAPI, release, e := k.nextPrivateAPI()
+	if e != nil {
+		return nil, e
+	}
+	resp, e := krakenAPI.Query("TradesHistory", input)
+	release()
 	if e != nil {
 		return nil, e
 	}
@@ -611,6 +670,77 @@ func getOrderType(tInfo krakenapi.TradeInfo) (model.OrderType, error) {
 	return -1, errors.New("unidentified trade action")
 }
 
+// krakenPermissionDenied is the error returned by kraken when the API key does not have the permission needed by a method
+const krakenPermissionDenied = "EGeneral:Permission denied"
+
+// krakenPermissionProbeWithdrawKey is the name of the withdrawal key used to probe the withdraw permission, it does not need to exist
+const krakenPermissionProbeWithdrawKey = "kelp-permission-probe"
+
+// GetAPIKeyPermissions impl. Kraken does not report the permissions of an API key so they are probed by calling a private method that
+// needs each permission, in a way that cannot change anything: the order is only validated and the withdrawal key does not exist
+func (k *krakenExchange) GetAPIKeyPermissions() ([]api.APIKeyPermissions, error) {
+	result := []api.APIKeyPermissions{}
+	for i := range k.apis {
+		canQueryFunds, e := k.probePermission(i, "Balance", map[string]string{})
+		if e != nil {
+			return nil, e
+		}
+		canQueryOrders, e := k.probePermission(i, "OpenOrders", map[string]string{})
+		if e != nil {
+			return nil, e
+		}
+		canTrade, e := k.probePermission(i, "AddOrder", map[string]string{
+			"pair":      "XXBTZUSD",
+			"type":      "buy",
+			"ordertype": "limit",
+			"price":     "1",
+			"volume":    "1",
+			"validate":  "true",
+		})
+		if e != nil {
+			return nil, e
+		}
+		canWithdraw, e := k.probePermission(i, "WithdrawInfo", map[string]string{
+			"asset":  "XXBT",
+			"key":    krakenPermissionProbeWithdrawKey,
+			"amount": "1",
+		})
+		if e != nil {
+			return nil, e
+		}
+
+		result = append(result, api.APIKeyPermissions{
+			Key:         k.maskedKeys[i],
+			CanRead:     canQueryFunds && canQueryOrders,
+			CanTrade:    canTrade,
+			CanWithdraw: canWithdraw,
+		})
+	}
+	return result, nil
+}
+
+// probePermission calls the private method with the API key at index i and returns false when kraken denies the permission. Any other
+// error from the method means that the request got past the permission check, except for errors from the API itself (such as an
+// invalid key or rate limiting) which are returned because the permission cannot be known
+func (k *krakenExchange) probePermission(i int, method string, input map[string]string) (bool, error) {
+	release, e := k.nonces[i].Acquire()
+	if e != nil {
+		return false, fmt.Errorf("could not acquire nonce for kraken API key at index %d: %s", i, e)
+	}
+	_, e = k.apis[i].Query(method, input)
+	release()
+	if e == nil {
+		return true, nil
+	}
+	if strings.Contains(e.Error(), krakenPermissionDenied) {
+		return false, nil
+	}
+	if strings.Contains(e.Error(), "EAPI:") {
+		return false, fmt.Errorf("could not probe the permission of kraken API key %s with method '%s': %s", k.maskedKeys[i], method, e)
+	}
+	return true, nil
+}
+
 // GetWithdrawInfo impl.
 func (k *krakenExchange) GetWithdrawInfo(
 	asset model.Asset,
@@ -626,7 +756,11 @@ func (k *krakenExchange) GetWithdrawInfo(
 	if e != nil {
 		return nil, e
 	}
-	resp, e := k.nextAPI().Query(
+	krakenAPI, release, e := k.nextPrivateAPI()
+	if e != nil {
+		return nil, e
+	}
+	resp, e := krakenAPI.Query(
 		"WithdrawInfo",
 		map[string]string{
 			"asset":  krakenAsset,
@@ -634,6 +768,7 @@ func (k *krakenExchange) GetWithdrawInfo(
 			"amount": amountToWithdraw.AsString(),
 		},
 	)
+	release()
 	if e != nil {
 		return nil, e
 	}
@@ -764,10 +899,15 @@ type depositMethod struct {
 }
 
 func (k *krakenExchange) getDepositMethods(asset string) (*depositMethod, error) {
-	resp, e := k.nextAPI().Query(
+	krakenAPI, release, e := k.nextPrivateAPI()
+	if e != nil {
+		return nil, e
+	}
+	resp, e := krakenAPI.Query(
 		"DepositMethods",
 		map[string]string{"asset": asset},
 	)
+	release()
 	if e != nil {
 		return nil, e
 	}
@@ -800,7 +940,12 @@ func (k *krakenExchange) getDepositAddress(asset string, method string, genAddre
 		// only set "new" if it's supposed to be 'true'. If you set it to 'false' then it will be treated as true by Kraken :(
 		input["new"] = "true"
 	}
-	resp, e := k.nextAPI().Query("DepositAddresses", input)
+	krakenAPI, release, e := k.nextPrivateAPI()
+	if e != nil {
+		return []depositAddress{}, e
+	}
+	resp, e := krakenAPI.Query("DepositAddresses", input)
+	release()
 	if e != nil {
 		return []depositAddress{}, e
 	}
@@ -904,11 +1049,12 @@ func parseDepositMethods(m map[string]interface{}) (*depositMethod, error) {
 	}, nil
 }
 
-// WithdrawFunds impl.
+// WithdrawFunds impl. The memo is not passed since it is part of the withdrawal key of the address that is set up on kraken.
 func (k *krakenExchange) WithdrawFunds(
 	asset model.Asset,
 	amountToWithdraw *model.Number,
 	address string,
+	memo string,
 ) (*api.WithdrawFunds, error) {
 	krakenAsset, e := k.assetConverter.ToString(asset)
 	if e != nil {
@@ -919,7 +1065,11 @@ func (k *krakenExchange) WithdrawFunds(
 	if e != nil {
 		return nil, e
 	}
-	resp, e := k.nextAPI().Query(
+	krakenAPI, release, e := k.nextPrivateAPI()
+	if e != nil {
+		return nil, e
+	}
+	resp, e := krakenAPI.Query(
 		"Withdraw",
 		map[string]string{
 			"asset":  krakenAsset,
@@ -927,6 +1077,7 @@ func (k *krakenExchange) WithdrawFunds(
 			"amount": amountToWithdraw.AsString(),
 		},
 	)
+	release()
 	if e != nil {
 		return nil, e
 	}
@@ -11,12 +11,14 @@ import (
 
 	"github.com/stellar/kelp/api"
 	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/support/nonce"
 )
 
 var testKrakenExchange api.Exchange = &krakenExchange{
 	assetConverter:           model.KrakenAssetConverter,
 	assetConverterOpenOrders: model.KrakenAssetConverterOpenOrders,
 	apis:                     []*krakenapi.KrakenApi{krakenapi.New("", "")},
+	nonces:                   makeTestKrakenNonces(),
 	apiNextIndex:             0,
 	delimiter:                "",
 	ocOverridesHandler:       MakeEmptyOrderConstraintsOverridesHandler(),
@@ -24,6 +26,14 @@ var testKrakenExchange api.Exchange = &krakenExchange{
 	isSimulated:              true,
 }
 
+func makeTestKrakenNonces() []*nonce.Service {
+	s, e := nonce.MakeMemoryRegistry().Service("kraken", "")
+	if e != nil {
+		panic(e)
+	}
+	return []*nonce.Service{s}
+}
+
 func TestGetTickerPrice(t *testing.T) {
 	pair := model.TradingPair{Base: model.XLM, Quote: model.BTC}
 	pairs := []model.TradingPair{pair}
@@ -484,7 +494,7 @@ func TestWithdrawFunds(t *testing.T) {
 		return
 	}
 
-	result, e := testKrakenExchange.WithdrawFunds(model.XLM, model.NumberFromFloat(0.0000001, 7), "")
+	result, e := testKrakenExchange.WithdrawFunds(model.XLM, model.NumberFromFloat(0.0000001, 7), "", "")
 	if !assert.NoError(t, e) {
 		return
 	}
@@ -0,0 +1,317 @@
+package plugins
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+)
+
+const krakenWsURL = "wss://ws.kraken.com"
+
+// krakenWsMaxSilence is how long the connection can go without a message before the tickers are considered stale, kraken only pushes a
+// ticker when it changes but sends a heartbeat every second when there is nothing else to send
+const krakenWsMaxSilence = 10 * time.Second
+
+// krakenWsFirstTickerTimeout is how long GetTickerPrice waits for the first ticker of a pair after subscribing to it
+const krakenWsFirstTickerTimeout = 5 * time.Second
+
+const krakenWsMaxReconnectDelay = 30 * time.Second
+
+// krakenWsAssetMap maps the assets that are named differently on kraken's websocket API
+var krakenWsAssetMap = map[model.Asset]string{
+	"BTC":  "XBT",
+	"DOGE": "XDG",
+}
+
+// krakenTickerWs is a TickerAPI that subscribes to the ticker channel of kraken's websocket API and serves tickers from memory, it
+// subscribes to a pair the first time it is requested and reconnects when the connection drops
+type krakenTickerWs struct {
+	url  string
+	lock *sync.Mutex
+
+	// uninitialized
+	isStarted     bool
+	conn          *websocket.Conn // nil when disconnected
+	wsPairs       map[string]bool
+	tickers       map[string]api.Ticker // the timestamp of a ticker is when it was last received
+	failedPairs   map[string]string     // the error of the pairs whose subscription failed on the current connection
+	lastMessageAt time.Time
+}
+
+// ensure that it implements TickerAPI
+var _ api.TickerAPI = &krakenTickerWs{}
+
+func makeKrakenTickerWs(url string) *krakenTickerWs {
+	return &krakenTickerWs{
+		url:         url,
+		lock:        &sync.Mutex{},
+		wsPairs:     map[string]bool{},
+		tickers:     map[string]api.Ticker{},
+		failedPairs: map[string]string{},
+	}
+}
+
+// toKrakenWsPair converts the pair to the name used by kraken's websocket API, e.g. XBT/USD
+func toKrakenWsPair(pair model.TradingPair) string {
+	base := string(pair.Base)
+	if a, ok := krakenWsAssetMap[pair.Base]; ok {
+		base = a
+	}
+	quote := string(pair.Quote)
+	if a, ok := krakenWsAssetMap[pair.Quote]; ok {
+		quote = a
+	}
+	return base + "/" + quote
+}
+
+// GetTickerPrice impl, the timestamp of the tickers is the time they were last received. It waits for the first ticker of a pair after
+// subscribing to it and fails without waiting for a pair whose subscription failed
+func (k *krakenTickerWs) GetTickerPrice(pairs []model.TradingPair) (map[model.TradingPair]api.Ticker, error) {
+	k.lock.Lock()
+	if !k.isStarted {
+		k.isStarted = true
+		go k.run()
+	}
+	for _, p := range pairs {
+		wsPair := toKrakenWsPair(p)
+		if k.wsPairs[wsPair] {
+			continue
+		}
+		k.wsPairs[wsPair] = true
+		if k.conn != nil {
+			e := k.subscribe([]string{wsPair})
+			if e != nil {
+				log.Printf("error subscribing to the kraken websocket ticker of %s, will retry when reconnecting: %s\n", wsPair, e)
+				k.failedPairs[wsPair] = e.Error()
+			}
+		}
+	}
+	k.lock.Unlock()
+
+	result := map[model.TradingPair]api.Ticker{}
+	deadline := time.Now().Add(krakenWsFirstTickerTimeout)
+	for _, p := range pairs {
+		wsPair := toKrakenWsPair(p)
+		for {
+			ticker, isMissing, e := k.getTicker(wsPair, time.Now())
+			if e == nil {
+				result[p] = ticker
+				break
+			}
+			// only wait for the first ticker of a pair, a stale ticker means the connection is down so waiting would slow down the bot
+			if !isMissing || time.Now().After(deadline) {
+				return nil, e
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+	}
+	return result, nil
+}
+
+// getTicker returns the ticker of the pair, the bool is true when no ticker was received for the pair yet and it is worth waiting for one
+func (k *krakenTickerWs) getTicker(wsPair string, now time.Time) (api.Ticker, bool, error) {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+
+	if errorMessage, failed := k.failedPairs[wsPair]; failed {
+		return api.Ticker{}, false, fmt.Errorf("not subscribed to the ticker of %s on the kraken websocket: %s", wsPair, errorMessage)
+	}
+	ticker, ok := k.tickers[wsPair]
+	if !ok {
+		return api.Ticker{}, true, fmt.Errorf("no ticker received for %s on the kraken websocket", wsPair)
+	}
+	// a ticker that was not updated for a while is still current as long as the connection is alive since kraken only pushes a ticker
+	// when it changes, the tickers are cleared when reconnecting so they are never from an earlier connection
+	if now.Sub(k.lastMessageAt) > krakenWsMaxSilence {
+		return api.Ticker{}, false, fmt.Errorf("ticker for %s is stale, the last message on the kraken websocket was %s ago", wsPair, now.Sub(k.lastMessageAt))
+	}
+	return ticker, false, nil
+}
+
+// resetTickers needs to be called while holding the lock when connecting, since the tickers and subscriptions of an earlier connection
+// are not current anymore
+func (k *krakenTickerWs) resetTickers() {
+	k.tickers = map[string]api.Ticker{}
+	k.failedPairs = map[string]string{}
+}
+
+// run keeps a connection open until the process exits, reconnecting with a backoff when it drops
+func (k *krakenTickerWs) run() {
+	delay := time.Second
+	for {
+		e := k.connectAndRead()
+		log.Printf("kraken websocket disconnected, reconnecting in %s: %s\n", delay, e)
+		time.Sleep(delay)
+		delay *= 2
+		if delay > krakenWsMaxReconnectDelay {
+			delay = krakenWsMaxReconnectDelay
+		}
+	}
+}
+
+func (k *krakenTickerWs) connectAndRead() error {
+	conn, _, e := websocket.DefaultDialer.Dial(k.url, nil)
+	if e != nil {
+		return fmt.Errorf("could not connect to %s: %s", k.url, e)
+	}
+	defer conn.Close()
+
+	k.lock.Lock()
+	k.conn = conn
+	k.resetTickers()
+	wsPairs := []string{}
+	for p := range k.wsPairs {
+		wsPairs = append(wsPairs, p)
+	}
+	e = k.subscribe(wsPairs)
+	k.lock.Unlock()
+	defer func() {
+		k.lock.Lock()
+		k.conn = nil
+		k.lock.Unlock()
+	}()
+	if e != nil {
+		return e
+	}
+	log.Printf("connected to the kraken websocket at %s, subscribed to the tickers of %v\n", k.url, wsPairs)
+
+	for {
+		_, msg, e := conn.ReadMessage()
+		if e != nil {
+			return fmt.Errorf("error reading from the kraken websocket: %s", e)
+		}
+		e = k.handleMessage(msg, time.Now())
+		if e != nil {
+			log.Printf("ignoring message from the kraken websocket: %s\n", e)
+		}
+	}
+}
+
+// subscribe needs to be called while holding the lock since the connection only supports one writer at a time
+func (k *krakenTickerWs) subscribe(wsPairs []string) error {
+	if len(wsPairs) == 0 {
+		return nil
+	}
+	e := k.conn.WriteJSON(map[string]interface{}{
+		"event": "subscribe",
+		"pair":  wsPairs,
+		"subscription": map[string]string{
+			"name": "ticker",
+		},
+	})
+	if e != nil {
+		return fmt.Errorf("could not subscribe to the tickers of %v: %s", wsPairs, e)
+	}
+	return nil
+}
+
+type krakenWsEvent struct {
+	Event        string `json:"event"`
+	Status       string `json:"status"`
+	Pair         string `json:"pair"`
+	ErrorMessage string `json:"errorMessage"`
+}
+
+// krakenWsTicker is the payload of a ticker message, each price is a list that starts with the price as a string
+type krakenWsTicker struct {
+	Ask  []interface{} `json:"a"`
+	Bid  []interface{} `json:"b"`
+	Last []interface{} `json:"c"`
+}
+
+// handleMessage updates the tickers from a message, which is either an event object or a channel message of the form
+// [channelID, payload, channelName, pair]
+func (k *krakenTickerWs) handleMessage(msg []byte, now time.Time) error {
+	k.lock.Lock()
+	k.lastMessageAt = now
+	k.lock.Unlock()
+
+	trimmed := strings.TrimSpace(string(msg))
+	if strings.HasPrefix(trimmed, "{") {
+		var event krakenWsEvent
+		e := json.Unmarshal(msg, &event)
+		if e != nil {
+			return fmt.Errorf("could not parse event '%s': %s", trimmed, e)
+		}
+		if event.Event != "subscriptionStatus" {
+			return nil
+		}
+
+		k.lock.Lock()
+		defer k.lock.Unlock()
+		if event.Status == "error" {
+			k.failedPairs[event.Pair] = event.ErrorMessage
+			return fmt.Errorf("could not subscribe to the ticker of %s: %s", event.Pair, event.ErrorMessage)
+		}
+		delete(k.failedPairs, event.Pair)
+		return nil
+	}
+
+	var parts []json.RawMessage
+	e := json.Unmarshal(msg, &parts)
+	if e != nil {
+		return fmt.Errorf("could not parse message '%s': %s", trimmed, e)
+	}
+	if len(parts) < 4 {
+		return fmt.Errorf("message needs at least 4 parts but had %d: %s", len(parts), trimmed)
+	}
+	var channelName string
+	var wsPair string
+	e = json.Unmarshal(parts[len(parts)-2], &channelName)
+	if e != nil {
+		return fmt.Errorf("could not parse channel name of message '%s': %s", trimmed, e)
+	}
+	if channelName != "ticker" {
+		return nil
+	}
+	e = json.Unmarshal(parts[len(parts)-1], &wsPair)
+	if e != nil {
+		return fmt.Errorf("could not parse pair of message '%s': %s", trimmed, e)
+	}
+
+	var payload krakenWsTicker
+	e = json.Unmarshal(parts[1], &payload)
+	if e != nil {
+		return fmt.Errorf("could not parse ticker of message '%s': %s", trimmed, e)
+	}
+	ask, e := parseKrakenWsPrice(payload.Ask)
+	if e != nil {
+		return fmt.Errorf("could not parse ask of %s: %s", wsPair, e)
+	}
+	bid, e := parseKrakenWsPrice(payload.Bid)
+	if e != nil {
+		return fmt.Errorf("could not parse bid of %s: %s", wsPair, e)
+	}
+	last, e := parseKrakenWsPrice(payload.Last)
+	if e != nil {
+		return fmt.Errorf("could not parse last of %s: %s", wsPair, e)
+	}
+
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	k.tickers[wsPair] = api.Ticker{
+		AskPrice:  ask,
+		BidPrice:  bid,
+		LastPrice: last,
+		Timestamp: model.MakeTimestampFromTime(now),
+	}
+	return nil
+}
+
+func parseKrakenWsPrice(values []interface{}) (*model.Number, error) {
+	if len(values) == 0 {
+		return nil, fmt.Errorf("price is missing")
+	}
+	s, ok := values[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("price needs to be a string but was %v", values[0])
+	}
+	return model.NumberFromString(s, getPrecision(s))
+}
@@ -0,0 +1,110 @@
+package plugins
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/kelp/model"
+)
+
+func TestToKrakenWsPair(t *testing.T) {
+	assert.Equal(t, "XBT/USD", toKrakenWsPair(model.TradingPair{Base: model.BTC, Quote: model.USD}))
+	assert.Equal(t, "XLM/XBT", toKrakenWsPair(model.TradingPair{Base: model.XLM, Quote: model.BTC}))
+	assert.Equal(t, "XLM/USD", toKrakenWsPair(model.TradingPair{Base: model.XLM, Quote: model.USD}))
+}
+
+func TestKrakenTickerWs_HandleMessage(t *testing.T) {
+	now := time.Unix(10000, 0)
+	k := makeKrakenTickerWs(krakenWsURL)
+
+	_, isMissing, e := k.getTicker("XLM/USD", now)
+	assert.Error(t, e)
+	assert.True(t, isMissing)
+
+	for _, msg := range []string{
+		`{"event":"systemStatus","status":"online","version":"1.8.1"}`,
+		`{"channelID":340,"event":"subscriptionStatus","pair":"XLM/USD","status":"subscribed","subscription":{"name":"ticker"}}`,
+		`[340,{"a":["0.11010000",1050,"1050.000"],"b":["0.11000000",2000,"2000.000"],"c":["0.11005000","12.5"],"v":["1","2"]},"ticker","XLM/USD"]`,
+		// messages of other channels are ignored
+		`[341,[["0.2","1.0","1000.0","s","l",""]],"trade","XLM/EUR"]`,
+	} {
+		if !assert.NoError(t, k.handleMessage([]byte(msg), now), msg) {
+			return
+		}
+	}
+
+	ticker, _, e := k.getTicker("XLM/USD", now.Add(time.Second))
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, "0.11010000", ticker.AskPrice.AsString())
+	assert.Equal(t, "0.11000000", ticker.BidPrice.AsString())
+	assert.Equal(t, "0.11005000", ticker.LastPrice.AsString())
+	assert.Equal(t, now.UnixNano()/int64(time.Millisecond), ticker.Timestamp.AsInt64())
+
+	_, _, e = k.getTicker("XLM/EUR", now)
+	assert.Error(t, e)
+
+	// a heartbeat keeps the ticker fresh since kraken only pushes a ticker when it changes
+	later := now.Add(krakenWsMaxSilence + time.Second)
+	_, isMissing, e = k.getTicker("XLM/USD", later)
+	assert.Error(t, e)
+	assert.False(t, isMissing)
+	assert.NoError(t, k.handleMessage([]byte(`{"event":"heartbeat"}`), later))
+	ticker, _, e = k.getTicker("XLM/USD", later)
+	if !assert.NoError(t, e) {
+		return
+	}
+	// the timestamp is when the ticker was received, not when the last heartbeat was received
+	assert.Equal(t, now.UnixNano()/int64(time.Millisecond), ticker.Timestamp.AsInt64())
+
+	for _, msg := range []string{
+		`{"channelID":340,"event":"subscriptionStatus","pair":"ABC/USD","status":"error","errorMessage":"Currency pair not supported"}`,
+		`[340,{"a":[],"b":["0.1",1,"1"],"c":["0.1","1"]},"ticker","XLM/USD"]`,
+		`[340,{"a":[0.1],"b":["0.1",1,"1"],"c":["0.1","1"]},"ticker","XLM/USD"]`,
+		`[340,"ticker","XLM/USD"]`,
+		`not json`,
+	} {
+		assert.Error(t, k.handleMessage([]byte(msg), later), msg)
+	}
+}
+
+func TestKrakenTickerWs_FailedSubscription(t *testing.T) {
+	now := time.Unix(10000, 0)
+	k := makeKrakenTickerWs(krakenWsURL)
+
+	assert.Error(t, k.handleMessage([]byte(`{"channelID":340,"event":"subscriptionStatus","pair":"ABC/USD","status":"error","errorMessage":"Currency pair not supported"}`), now))
+	// the ticker of a pair whose subscription failed will never arrive so there is no point in waiting for it
+	_, isMissing, e := k.getTicker("ABC/USD", now)
+	assert.Error(t, e)
+	assert.False(t, isMissing)
+
+	// the subscription is retried when reconnecting
+	k.resetTickers()
+	_, isMissing, e = k.getTicker("ABC/USD", now)
+	assert.Error(t, e)
+	assert.True(t, isMissing)
+}
+
+func TestKrakenTickerWs_ResetTickers(t *testing.T) {
+	now := time.Unix(10000, 0)
+	k := makeKrakenTickerWs(krakenWsURL)
+
+	msg := `[340,{"a":["0.11010000",1050,"1050.000"],"b":["0.11000000",2000,"2000.000"],"c":["0.11005000","12.5"]},"ticker","XLM/USD"]`
+	if !assert.NoError(t, k.handleMessage([]byte(msg), now)) {
+		return
+	}
+	_, _, e := k.getTicker("XLM/USD", now)
+	assert.NoError(t, e)
+
+	// a ticker from before a reconnect is not served even though the new connection is alive
+	k.resetTickers()
+	if !assert.NoError(t, k.handleMessage([]byte(`{"event":"heartbeat"}`), now.Add(time.Minute))) {
+		return
+	}
+	_, isMissing, e := k.getTicker("XLM/USD", now.Add(time.Minute))
+	assert.Error(t, e)
+	assert.True(t, isMissing)
+}
@@ -0,0 +1,366 @@
+package plugins
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/kelpdb"
+	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/support/postgresdb"
+)
+
+// levelStatsSideBuy and levelStatsSideSell are the values of the side column in the level_stats table
+const (
+	levelStatsSideBuy  = "buy"
+	levelStatsSideSell = "sell"
+)
+
+// levelStatsFlushInterval is how often the stats that were collected in memory are written to the db
+const levelStatsFlushInterval = time.Minute
+
+// levelStatsRecorder writes the daily stats of each configured level of a market to the level_stats table. The stats of a level count the
+// update cycles in which the level was quoted, the fills that matched the level and the spread that the fills captured relative to the mid
+// price that the level was quoted from, so users can see which levels make money and prune the ones that only add to the reserve.
+//
+// The stats are added up in memory and written to the db in a single transaction every levelStatsFlushInterval by the task that the recorder
+// registers with registerScheduledTask, so the update cycle does not wait on the db. The stats collected since the last write are lost when
+// the bot stops
+type levelStatsRecorder struct {
+	db        *sql.DB
+	accountID string
+	marketID  string
+	clock     api.Clock
+
+	// uninitialized
+	lock    *sync.Mutex
+	pending map[levelStatsKey]*levelStatsUpdate
+}
+
+// levelStatsKey is the primary key of the level_stats table within a market
+type levelStatsKey struct {
+	date  string
+	side  string
+	level int
+}
+
+// makeLevelStatsRecorder is a factory method
+func makeLevelStatsRecorder(db *sql.DB, accountID string, marketID string, clock api.Clock) (*levelStatsRecorder, error) {
+	if db == nil {
+		return nil, fmt.Errorf("TRACK_LEVEL_STATS needs POSTGRES_DB to be set in the trader config")
+	}
+	if accountID == "" {
+		return nil, fmt.Errorf("TRACK_LEVEL_STATS needs DB_OVERRIDE__ACCOUNT_ID to be set in the trader config")
+	}
+
+	return &levelStatsRecorder{
+		db:        db,
+		accountID: accountID,
+		marketID:  marketID,
+		clock:     clock,
+		lock:      &sync.Mutex{},
+		pending:   map[levelStatsKey]*levelStatsUpdate{},
+	}, nil
+}
+
+// maybeMakeLevelStatsRecorder returns nil when the level stats are not tracked
+func maybeMakeLevelStatsRecorder(trackLevelStats bool, strategyFactoryData strategyFactoryData) (*levelStatsRecorder, error) {
+	if !trackLevelStats {
+		return nil, nil
+	}
+	r, e := makeLevelStatsRecorder(strategyFactoryData.db, strategyFactoryData.filterFactory.AccountID, strategyFactoryData.marketID, MakeSystemClock())
+	if e != nil {
+		return nil, e
+	}
+	registerScheduledTask(ScheduledTask{
+		Name:     "level_stats_flush",
+		Interval: levelStatsFlushInterval,
+		Fn:       r.flush,
+	})
+	return r, nil
+}
+
+// levelStatsUpdate is added to the stats of a level on a date
+type levelStatsUpdate struct {
+	date              time.Time
+	side              string
+	level             int // index of the level in the config, starting at 0
+	spread            float64
+	numUpdates        int
+	numFills          int
+	filledBaseVolume  float64
+	filledQuoteVolume float64
+	spreadCapture     float64 // in units of the quote asset
+}
+
+// record adds the update to the stats of the level in memory, they are written to the db with the next flush
+func (r *levelStatsRecorder) record(u *levelStatsUpdate) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.addPending(u)
+}
+
+// addPending adds the update to the stats that were not written yet, needs to hold the lock
+func (r *levelStatsRecorder) addPending(u *levelStatsUpdate) {
+	key := levelStatsKey{
+		date:  u.date.UTC().Format(postgresdb.DateFormatString),
+		side:  u.side,
+		level: u.level,
+	}
+	p, ok := r.pending[key]
+	if !ok {
+		copied := *u
+		r.pending[key] = &copied
+		return
+	}
+	// the spread is replaced since the config of the level may have changed
+	p.spread = u.spread
+	p.numUpdates += u.numUpdates
+	p.numFills += u.numFills
+	p.filledBaseVolume += u.filledBaseVolume
+	p.filledQuoteVolume += u.filledQuoteVolume
+	p.spreadCapture += u.spreadCapture
+}
+
+// flush writes the stats collected since the last flush to the db in one transaction, the stats are added back to the pending stats when
+// the write fails so they are retried with the next flush
+func (r *levelStatsRecorder) flush(now time.Time) error {
+	r.lock.Lock()
+	batch := r.pending
+	r.pending = map[levelStatsKey]*levelStatsUpdate{}
+	r.lock.Unlock()
+	if len(batch) == 0 {
+		return nil
+	}
+
+	e := r.write(batch)
+	if e != nil {
+		r.lock.Lock()
+		defer r.lock.Unlock()
+		// the stats recorded during the write are added on top of the batch so their spread is kept
+		newer := r.pending
+		r.pending = batch
+		for _, u := range newer {
+			r.addPending(u)
+		}
+		return fmt.Errorf("could not write the stats of %d levels: %s", len(batch), e)
+	}
+	return nil
+}
+
+func (r *levelStatsRecorder) write(batch map[levelStatsKey]*levelStatsUpdate) error {
+	tx, e := r.db.Begin()
+	if e != nil {
+		return fmt.Errorf("could not begin transaction: %s", e)
+	}
+	for key, u := range batch {
+		sqlUpsert := fmt.Sprintf(kelpdb.SqlLevelStatsUpsertTemplate,
+			r.accountID,
+			r.marketID,
+			key.date,
+			key.side,
+			key.level,
+			u.spread,
+			u.numUpdates,
+			u.numFills,
+			u.filledBaseVolume,
+			u.filledQuoteVolume,
+			u.spreadCapture,
+		)
+		_, e = tx.Exec(sqlUpsert)
+		if e != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("could not upsert the stats of %s level %d: %s", key.side, key.level, e)
+		}
+	}
+	e = tx.Commit()
+	if e != nil {
+		return fmt.Errorf("could not commit transaction: %s", e)
+	}
+	return nil
+}
+
+// wrap returns the inner LevelProvider wrapped so that the stats of its levels are recorded, or the inner LevelProvider when the receiver is
+// nil. staticLevels needs to be the config of the levels returned by inner, in the same order
+func (r *levelStatsRecorder) wrap(inner api.LevelProvider, staticLevels []StaticLevel, isBuySide bool) api.LevelProvider {
+	if r == nil {
+		return inner
+	}
+	return &levelStatsLevelProvider{
+		inner:        inner,
+		recorder:     r,
+		staticLevels: staticLevels,
+		isBuySide:    isBuySide,
+		lock:         &sync.Mutex{},
+		lastLevels:   []api.Level{},
+	}
+}
+
+// levelStatsLevelProvider records the stats of the levels of the wrapped LevelProvider. The levels are only counted once the ops of the
+// update are submitted, since the filters or a failed submission can keep them off the book. Fills are matched to the level with the closest
+// price in the last submitted update, so the stats are only meaningful when the levels do not overlap in price
+type levelStatsLevelProvider struct {
+	inner        api.LevelProvider
+	recorder     *levelStatsRecorder
+	staticLevels []StaticLevel
+	isBuySide    bool
+
+	// uninitialized
+	lock       *sync.Mutex
+	newLevels  []api.Level // levels of the current update, nil once they are submitted
+	lastLevels []api.Level
+}
+
+// ensure it implements LevelProvider, FillHandler and postUpdateLevelProvider
+var _ api.LevelProvider = &levelStatsLevelProvider{}
+var _ api.FillHandler = &levelStatsLevelProvider{}
+var _ postUpdateLevelProvider = &levelStatsLevelProvider{}
+
+// GetLevels impl.
+func (p *levelStatsLevelProvider) GetLevels(maxAssetBase float64, maxAssetQuote float64) ([]api.Level, error) {
+	levels, e := p.inner.GetLevels(maxAssetBase, maxAssetQuote)
+	if e != nil {
+		return nil, e
+	}
+
+	p.lock.Lock()
+	p.newLevels = append([]api.Level{}, levels...)
+	p.lock.Unlock()
+	return levels, nil
+}
+
+// PostUpdate counts the levels of the update now that its ops were submitted
+func (p *levelStatsLevelProvider) PostUpdate() error {
+	p.lock.Lock()
+	if p.newLevels == nil {
+		// GetLevels was not called since the last submitted update
+		p.lock.Unlock()
+		return nil
+	}
+	p.lastLevels = p.newLevels
+	p.newLevels = nil
+	levels := p.lastLevels
+	p.lock.Unlock()
+
+	now := p.recorder.clock.Now()
+	for i := range levels {
+		p.recorder.record(&levelStatsUpdate{
+			date:       now,
+			side:       p.side(),
+			level:      i,
+			spread:     p.spread(i),
+			numUpdates: 1,
+		})
+	}
+	return nil
+}
+
+// GetFillHandlers impl
+func (p *levelStatsLevelProvider) GetFillHandlers() ([]api.FillHandler, error) {
+	innerHandlers, e := p.inner.GetFillHandlers()
+	if e != nil {
+		return nil, fmt.Errorf("unable to get fill handlers of the inner level provider: %s", e)
+	}
+	return append([]api.FillHandler{p}, innerHandlers...), nil
+}
+
+// HandleFill impl
+func (p *levelStatsLevelProvider) HandleFill(trade model.Trade) error {
+	if trade.OrderAction.IsBuy() != p.isBuySide {
+		// the fill was on the other side of the orderbook
+		return nil
+	}
+	if trade.Price == nil || trade.Volume == nil {
+		return fmt.Errorf("level stats cannot handle a trade without a price or volume: %s", trade)
+	}
+
+	p.lock.Lock()
+	lastLevels := p.lastLevels
+	p.lock.Unlock()
+
+	tradePrice := trade.Price.AsFloat()
+	levelIdx := closestLevelIndex(lastLevels, p.isBuySide, tradePrice)
+	if levelIdx == -1 {
+		log.Printf("level stats could not match fill to a level since no levels were placed yet, ignoring fill: %s\n", trade)
+		return nil
+	}
+
+	date := p.recorder.clock.Now()
+	if trade.Timestamp != nil {
+		date = time.Unix(trade.Timestamp.AsInt64()/1000, 0)
+	}
+	spread := p.spread(levelIdx)
+	volume := trade.Volume.AsFloat()
+	midPrice := levelMidPrice(lastLevels[levelIdx].Price.AsFloat(), spread, p.isBuySide)
+	p.recorder.record(&levelStatsUpdate{
+		date:              date,
+		side:              p.side(),
+		level:             levelIdx,
+		spread:            spread,
+		numFills:          1,
+		filledBaseVolume:  volume,
+		filledQuoteVolume: volume * tradePrice,
+		spreadCapture:     spreadCapture(midPrice, tradePrice, volume, p.isBuySide),
+	})
+	return nil
+}
+
+func (p *levelStatsLevelProvider) side() string {
+	if p.isBuySide {
+		return levelStatsSideBuy
+	}
+	return levelStatsSideSell
+}
+
+// spread returns the configured spread of the level, which is 0 for levels that are not in the config
+func (p *levelStatsLevelProvider) spread(levelIdx int) float64 {
+	if levelIdx >= len(p.staticLevels) {
+		return 0
+	}
+	return p.staticLevels[levelIdx].SPREAD
+}
+
+// closestLevelIndex returns the index of the level whose price is closest to the trade price, which is in units of quote per base,
+// or -1 when there are no levels
+func closestLevelIndex(levels []api.Level, isBuySide bool, tradePrice float64) int {
+	closestIdx := -1
+	closestDiff := 0.0
+	for i, l := range levels {
+		levelPrice := l.Price.AsFloat()
+		if isBuySide {
+			// the buy side quotes the price inverted (in units of base per quote)
+			levelPrice = 1 / levelPrice
+		}
+
+		diff := math.Abs(levelPrice - tradePrice)
+		if closestIdx == -1 || diff < closestDiff {
+			closestIdx = i
+			closestDiff = diff
+		}
+	}
+	return closestIdx
+}
+
+// levelMidPrice returns the mid price in units of quote per base that a level was quoted from, where the level price is the mid price plus
+// the spread and is quoted inverted (in units of base per quote) on the buy side
+func levelMidPrice(levelPrice float64, spread float64, isBuySide bool) float64 {
+	midPrice := levelPrice / (1 + spread)
+	if isBuySide {
+		return 1 / midPrice
+	}
+	return midPrice
+}
+
+// spreadCapture returns the profit of a fill relative to the mid price in units of the quote asset, which is negative when the fill was on
+// the wrong side of the mid price
+func spreadCapture(midPrice float64, tradePrice float64, volume float64, isBuySide bool) float64 {
+	if isBuySide {
+		return (midPrice - tradePrice) * volume
+	}
+	return (tradePrice - midPrice) * volume
+}
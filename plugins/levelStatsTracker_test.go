@@ -0,0 +1,119 @@
+package plugins
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+)
+
+func TestLevelStatsFillMatching(t *testing.T) {
+	// levels quoted from a mid price of 0.1 with spreads of 1% and 2%
+	sellLevels := []api.Level{
+		{Price: *model.NumberFromFloat(0.101, 7), Amount: *model.NumberFromFloat(100, 7)},
+		{Price: *model.NumberFromFloat(0.102, 7), Amount: *model.NumberFromFloat(100, 7)},
+	}
+	// the buy side quotes the price inverted (in units of base per quote)
+	buyLevels := []api.Level{
+		{Price: *model.NumberFromFloat(10.1, 7), Amount: *model.NumberFromFloat(100, 7)},
+		{Price: *model.NumberFromFloat(10.2, 7), Amount: *model.NumberFromFloat(100, 7)},
+	}
+	spreads := []float64{0.01, 0.02}
+
+	testCases := []struct {
+		isBuySide         bool
+		tradePrice        float64
+		volume            float64
+		wantLevelIdx      int
+		wantSpreadCapture float64
+	}{
+		{false, 0.101, 10, 0, 0.01},
+		{false, 0.1021, 10, 1, 0.021},
+		// a fill below the mid price loses money
+		{false, 0.0995, 10, 0, -0.005},
+		{true, 1 / 10.1, 10, 0, 10 * (0.1 - 1/10.1)},
+		{true, 1 / 10.2, 10, 1, 10 * (0.1 - 1/10.2)},
+	}
+
+	for _, k := range testCases {
+		t.Run(fmt.Sprintf("%v/%f", k.isBuySide, k.tradePrice), func(t *testing.T) {
+			levels := sellLevels
+			if k.isBuySide {
+				levels = buyLevels
+			}
+
+			levelIdx := closestLevelIndex(levels, k.isBuySide, k.tradePrice)
+			if !assert.Equal(t, k.wantLevelIdx, levelIdx) {
+				return
+			}
+
+			midPrice := levelMidPrice(levels[levelIdx].Price.AsFloat(), spreads[levelIdx], k.isBuySide)
+			assert.InDelta(t, 0.1, midPrice, 0.0000001)
+			assert.InDelta(t, k.wantSpreadCapture, spreadCapture(midPrice, k.tradePrice, k.volume, k.isBuySide), 0.0000001)
+		})
+	}
+
+	assert.Equal(t, -1, closestLevelIndex([]api.Level{}, false, 0.1))
+}
+
+func TestMakeLevelStatsRecorder_Errors(t *testing.T) {
+	_, e := makeLevelStatsRecorder(nil, "account", "market", MakeSystemClock())
+	assert.Error(t, e)
+}
+
+func TestLevelStatsRecorderWrap_Nil(t *testing.T) {
+	var r *levelStatsRecorder
+	inner := makeStaticSpreadLevelProvider(nil, 1.0, amountUnitBase, rateOffset{}, nil, model.MakeOrderConstraints(7, 7, 1.0), false, nil)
+	assert.True(t, r.wrap(inner, nil, false) == inner)
+}
+
+func TestLevelStatsRecordedAfterSubmission(t *testing.T) {
+	clock := MakeManualClock(time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC))
+	r, e := makeLevelStatsRecorder(&sql.DB{}, "account", "market", clock)
+	if !assert.NoError(t, e) {
+		return
+	}
+	inner := &fixedLevelProvider{levels: []api.Level{
+		{Price: *model.NumberFromFloat(0.101, 7), Amount: *model.NumberFromFloat(100, 7)},
+		{Price: *model.NumberFromFloat(0.102, 7), Amount: *model.NumberFromFloat(100, 7)},
+	}}
+	p := r.wrap(inner, []StaticLevel{{SPREAD: 0.01, AMOUNT: 1.0}, {SPREAD: 0.02, AMOUNT: 1.0}}, false).(*levelStatsLevelProvider)
+	trade := model.Trade{Order: model.Order{OrderAction: model.OrderActionSell, Price: model.NumberFromFloat(0.101, 7), Volume: model.NumberFromFloat(10, 7)}}
+
+	// the levels are not counted and fills are not matched to them until the update is submitted
+	_, e = p.GetLevels(1000, 1000)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.NoError(t, p.HandleFill(trade))
+	assert.Equal(t, 0, len(r.pending))
+
+	for i := 0; i < 2; i++ {
+		_, e = p.GetLevels(1000, 1000)
+		if !assert.NoError(t, e) {
+			return
+		}
+		assert.NoError(t, p.PostUpdate())
+	}
+	assert.NoError(t, p.HandleFill(trade))
+
+	// the stats are added up in memory until they are written to the db
+	assert.Equal(t, 2, len(r.pending))
+	level0 := r.pending[levelStatsKey{date: "2021/06/01", side: levelStatsSideSell, level: 0}]
+	if assert.NotNil(t, level0) {
+		assert.Equal(t, 2, level0.numUpdates)
+		assert.Equal(t, 1, level0.numFills)
+		assert.InDelta(t, 10.0, level0.filledBaseVolume, 0.0000001)
+		assert.InDelta(t, 0.01, level0.spreadCapture, 0.0000001)
+	}
+	level1 := r.pending[levelStatsKey{date: "2021/06/01", side: levelStatsSideSell, level: 1}]
+	if assert.NotNil(t, level1) {
+		assert.Equal(t, 2, level1.numUpdates)
+		assert.Equal(t, 0, level1.numFills)
+	}
+}
@@ -0,0 +1,407 @@
+package plugins
+
+import (
+	"encoding/hex"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/price"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/stellargohorizonclientv300/build"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// liquidityPoolShareAssetType is the type of the balance that holds the shares of a liquidity pool
+const liquidityPoolShareAssetType = "liquidity_pool_shares"
+
+// liquidityPoolConfig contains the configuration params for this strategy
+type liquidityPoolConfig struct {
+	TargetAllocation   float64 `valid:"-" toml:"TARGET_ALLOCATION"`   // fraction of the total value of the base and quote assets to keep in the pool
+	RebalanceThreshold float64 `valid:"-" toml:"REBALANCE_THRESHOLD"` // deviation from the TARGET_ALLOCATION needed before depositing or withdrawing
+	MinPrice           float64 `valid:"-" toml:"MIN_PRICE"`           // withdraw everything when the pool price is below this, 0 means there is no minimum
+	MaxPrice           float64 `valid:"-" toml:"MAX_PRICE"`           // withdraw everything when the pool price is above this, 0 means there is no maximum
+	SlippageTolerance  float64 `valid:"-" toml:"SLIPPAGE_TOLERANCE"`  // allowed change in the pool price or amounts between reading the pool and applying the operation
+}
+
+// String impl.
+func (c liquidityPoolConfig) String() string {
+	return utils.StructString(c, 0, nil)
+}
+
+// liquidityPoolState is the state of the pool and of the account used to decide whether to deposit or withdraw
+type liquidityPoolState struct {
+	reserveBase  float64
+	reserveQuote float64
+	totalShares  float64
+	shares       float64 // shares held by the account
+	freeBase     float64 // balance of the base asset that is not in the pool
+	freeQuote    float64 // balance of the quote asset that is not in the pool
+}
+
+// price returns the price of the pool in units of quote per base
+func (s liquidityPoolState) price() float64 {
+	return s.reserveQuote / s.reserveBase
+}
+
+// liquidityPoolAction is a deposit or a withdrawal, at most one of them is set
+type liquidityPoolAction struct {
+	depositBase    float64
+	depositQuote   float64
+	withdrawShares float64
+}
+
+// liquidityPoolStrategy deposits into and withdraws from the constant product liquidity pool of the trading pair so that a target
+// fraction of the value of the account is held in the pool, and withdraws everything when the pool price leaves the configured range
+type liquidityPoolStrategy struct {
+	sdex       *SDEX
+	assetBase  *hProtocol.Asset
+	assetQuote *hProtocol.Asset
+	config     *liquidityPoolConfig
+	poolParams txnbuild.LiquidityPoolParameters
+	poolID     txnbuild.LiquidityPoolId
+
+	// the pool operations are submitted asynchronously, so the state loaded while a transaction is in flight does not include it yet
+	// and would make us deposit or withdraw a second time
+	pendingMutex *sync.Mutex
+	pendingTx    bool
+
+	// uninitialized
+	maxAssetBase  float64
+	maxAssetQuote float64
+}
+
+// ensure this implements api.Strategy
+var _ api.Strategy = &liquidityPoolStrategy{}
+
+// makeLiquidityPoolStrategy is a factory method
+func makeLiquidityPoolStrategy(
+	sdex *SDEX,
+	assetBase *hProtocol.Asset,
+	assetQuote *hProtocol.Asset,
+	config *liquidityPoolConfig,
+) (api.Strategy, error) {
+	if config.TargetAllocation < 0 || config.TargetAllocation > 1 {
+		return nil, fmt.Errorf("TARGET_ALLOCATION needs to be between 0 and 1 but was %f", config.TargetAllocation)
+	}
+	if config.RebalanceThreshold <= 0 || config.RebalanceThreshold >= 1 {
+		return nil, fmt.Errorf("REBALANCE_THRESHOLD needs to be > 0 and < 1 but was %f", config.RebalanceThreshold)
+	}
+	if config.SlippageTolerance <= 0 || config.SlippageTolerance >= 1 {
+		return nil, fmt.Errorf("SLIPPAGE_TOLERANCE needs to be > 0 and < 1 but was %f", config.SlippageTolerance)
+	}
+	if config.MinPrice < 0 || config.MaxPrice < 0 || (config.MaxPrice > 0 && config.MinPrice > config.MaxPrice) {
+		return nil, fmt.Errorf("invalid price range, MIN_PRICE (%f) and MAX_PRICE (%f) need to be >= 0 and MIN_PRICE needs to be <= MAX_PRICE", config.MinPrice, config.MaxPrice)
+	}
+
+	poolParams, poolID, e := makeLiquidityPoolParams(assetBase, assetQuote)
+	if e != nil {
+		return nil, fmt.Errorf("could not make liquidity pool params: %s", e)
+	}
+	log.Printf("using liquidity pool with id %s\n", hex.EncodeToString(poolID[:]))
+
+	return &liquidityPoolStrategy{
+		sdex:       sdex,
+		assetBase:  assetBase,
+		assetQuote: assetQuote,
+		config:     config,
+		poolParams: poolParams,
+		poolID:     poolID,
+
+		pendingMutex: &sync.Mutex{},
+	}, nil
+}
+
+// PruneExistingOffers impl, this strategy only provides liquidity through the pool so all offers are deleted
+func (s *liquidityPoolStrategy) PruneExistingOffers(buyingAOffers []hProtocol.Offer, sellingAOffers []hProtocol.Offer) ([]build.TransactionMutator, []hProtocol.Offer, []hProtocol.Offer) {
+	pruneOps := s.sdex.DeleteAllOffers(append(append([]hProtocol.Offer{}, buyingAOffers...), sellingAOffers...))
+	if len(pruneOps) > 0 {
+		log.Printf("liquidityPoolStrategy: deleting %d offers\n", len(pruneOps))
+	}
+	return api.ConvertOperation2TM(pruneOps), []hProtocol.Offer{}, []hProtocol.Offer{}
+}
+
+// PreUpdate impl
+func (s *liquidityPoolStrategy) PreUpdate(maxAssetBase float64, maxAssetQuote float64, trustBase float64, trustQuote float64) error {
+	s.maxAssetBase = maxAssetBase
+	s.maxAssetQuote = maxAssetQuote
+	return nil
+}
+
+// UpdateWithOps impl, the pool operations cannot be represented as offer mutators so they are submitted here in their own transaction
+func (s *liquidityPoolStrategy) UpdateWithOps(buyingAOffers []hProtocol.Offer, sellingAOffers []hProtocol.Offer) ([]build.TransactionMutator, error) {
+	if s.isPending() {
+		log.Printf("liquidityPoolStrategy: the previous liquidity pool transaction has not completed yet, skipping this update\n")
+		return []build.TransactionMutator{}, nil
+	}
+
+	state, hasTrustline, e := s.loadState()
+	if e != nil {
+		return nil, fmt.Errorf("could not load the state of the liquidity pool: %s", e)
+	}
+	if state.totalShares == 0 || state.reserveBase == 0 || state.reserveQuote == 0 {
+		// the first deposit sets the price of the pool, which should be done intentionally rather than by the bot
+		log.Printf("liquidityPoolStrategy: the liquidity pool is empty, make the first deposit manually to set the price of the pool\n")
+		return []build.TransactionMutator{}, nil
+	}
+
+	action := computeLiquidityPoolAction(state, s.config)
+	ops, e := s.makeOps(state, action, hasTrustline)
+	if e != nil {
+		return nil, fmt.Errorf("could not make liquidity pool operations: %s", e)
+	}
+	if len(ops) == 0 {
+		return []build.TransactionMutator{}, nil
+	}
+
+	s.setPending(true)
+	e = s.sdex.SubmitOperations(ops, func(hash string, e error) {
+		// the account and the pool reflect the transaction once it is in a ledger, so the next update can load the state again
+		defer s.setPending(false)
+		if e != nil {
+			log.Printf("liquidityPoolStrategy: liquidity pool transaction failed: %s\n", e)
+			return
+		}
+		log.Printf("liquidityPoolStrategy: liquidity pool transaction succeeded with hash: %s\n", hash)
+	})
+	if e != nil {
+		s.setPending(false)
+		return nil, fmt.Errorf("could not submit liquidity pool operations: %s", e)
+	}
+	return []build.TransactionMutator{}, nil
+}
+
+func (s *liquidityPoolStrategy) isPending() bool {
+	s.pendingMutex.Lock()
+	defer s.pendingMutex.Unlock()
+	return s.pendingTx
+}
+
+func (s *liquidityPoolStrategy) setPending(pending bool) {
+	s.pendingMutex.Lock()
+	defer s.pendingMutex.Unlock()
+	s.pendingTx = pending
+}
+
+// PostUpdate impl
+func (s *liquidityPoolStrategy) PostUpdate() error {
+	return nil
+}
+
+// GetFillHandlers impl
+func (s *liquidityPoolStrategy) GetFillHandlers() ([]api.FillHandler, error) {
+	return nil, nil
+}
+
+// loadState reads the reserves of the pool and the shares held by the trading account from horizon
+func (s *liquidityPoolStrategy) loadState() (liquidityPoolState, bool, error) {
+	state := liquidityPoolState{
+		freeBase:  s.maxAssetBase,
+		freeQuote: s.maxAssetQuote,
+	}
+	poolIDHex := hex.EncodeToString(s.poolID[:])
+
+	account, e := s.sdex.API.AccountDetail(horizonclient.AccountRequest{AccountID: s.sdex.TradingAccount})
+	if e != nil {
+		return state, false, fmt.Errorf("could not load trading account: %s", e)
+	}
+	hasTrustline := false
+	for _, b := range account.Balances {
+		if b.Asset.Type == liquidityPoolShareAssetType && b.LiquidityPoolId == poolIDHex {
+			hasTrustline = true
+			state.shares, e = strconv.ParseFloat(b.Balance, 64)
+			if e != nil {
+				return state, false, fmt.Errorf("could not parse pool shares '%s': %s", b.Balance, e)
+			}
+		}
+	}
+
+	reserves, e := fetchLiquidityPoolReserves(s.sdex, s.poolID, s.assetBase, s.assetQuote)
+	if e != nil {
+		return state, false, fmt.Errorf("could not fetch liquidity pool reserves: %s", e)
+	}
+	state.reserveBase = reserves.base
+	state.reserveQuote = reserves.quote
+	state.totalShares = reserves.totalShares
+	return state, hasTrustline, nil
+}
+
+// liquidityPoolReserves are the reserves and total shares of a liquidity pool, which are all 0 when the pool does not exist yet
+type liquidityPoolReserves struct {
+	base        float64
+	quote       float64
+	totalShares float64
+}
+
+// makeLiquidityPoolParams returns the params and id of the constant product liquidity pool for the two assets
+func makeLiquidityPoolParams(assetBase *hProtocol.Asset, assetQuote *hProtocol.Asset) (txnbuild.LiquidityPoolParameters, txnbuild.LiquidityPoolId, error) {
+	// the assets of a pool are ordered so the base asset can be either asset A or asset B of the pool
+	assetA := utils.Asset2Asset(*assetBase)
+	assetB := utils.Asset2Asset(*assetQuote)
+	if assetB.LessThan(assetA) {
+		assetA, assetB = assetB, assetA
+	}
+	poolParams := txnbuild.LiquidityPoolParameters{
+		AssetA: assetA,
+		AssetB: assetB,
+		Fee:    txnbuild.LiquidityPoolFeeV18,
+	}
+	poolID, e := txnbuild.NewLiquidityPoolId(assetA, assetB)
+	if e != nil {
+		return poolParams, poolID, fmt.Errorf("could not compute the id of the liquidity pool: %s", e)
+	}
+	return poolParams, poolID, nil
+}
+
+// fetchLiquidityPoolReserves reads the reserves of the pool from horizon
+func fetchLiquidityPoolReserves(sdex *SDEX, poolID txnbuild.LiquidityPoolId, assetBase *hProtocol.Asset, assetQuote *hProtocol.Asset) (*liquidityPoolReserves, error) {
+	poolIDHex := hex.EncodeToString(poolID[:])
+	pool, e := sdex.API.LiquidityPoolDetail(horizonclient.LiquidityPoolRequest{LiquidityPoolID: poolIDHex})
+	if e != nil {
+		if horizonclient.IsNotFoundError(e) {
+			// the pool is only created with the first trustline to it
+			return &liquidityPoolReserves{}, nil
+		}
+		return nil, fmt.Errorf("could not load liquidity pool '%s': %s", poolIDHex, e)
+	}
+
+	reserves := &liquidityPoolReserves{}
+	reserves.totalShares, e = strconv.ParseFloat(pool.TotalShares, 64)
+	if e != nil {
+		return nil, fmt.Errorf("could not parse total shares '%s': %s", pool.TotalShares, e)
+	}
+	for _, r := range pool.Reserves {
+		amount, e := strconv.ParseFloat(r.Amount, 64)
+		if e != nil {
+			return nil, fmt.Errorf("could not parse reserve '%s' of asset %s: %s", r.Amount, r.Asset, e)
+		}
+		switch r.Asset {
+		case utils.Asset2String(*assetBase):
+			reserves.base = amount
+		case utils.Asset2String(*assetQuote):
+			reserves.quote = amount
+		}
+	}
+	return reserves, nil
+}
+
+// computeLiquidityPoolAction decides how much to deposit into or withdraw from the pool to get back to the target allocation
+func computeLiquidityPoolAction(state liquidityPoolState, config *liquidityPoolConfig) liquidityPoolAction {
+	poolPrice := state.price()
+	target := config.TargetAllocation
+	if (config.MinPrice > 0 && poolPrice < config.MinPrice) || (config.MaxPrice > 0 && poolPrice > config.MaxPrice) {
+		log.Printf("liquidityPoolStrategy: pool price %.7f is outside the range [%.7f, %.7f], withdrawing all shares\n", poolPrice, config.MinPrice, config.MaxPrice)
+		target = 0
+	}
+
+	// value everything in units of the quote asset at the price of the pool
+	poolValue := 0.0
+	if state.totalShares > 0 {
+		poolValue = 2 * state.reserveQuote * state.shares / state.totalShares
+	}
+	totalValue := poolValue + state.freeBase*poolPrice + state.freeQuote
+	if totalValue == 0 {
+		return liquidityPoolAction{}
+	}
+	allocation := poolValue / totalValue
+	log.Printf("liquidityPoolStrategy: poolPrice=%.7f, poolValue=%.7f, totalValue=%.7f, allocation=%.4f, targetAllocation=%.4f\n", poolPrice, poolValue, totalValue, allocation, target)
+
+	if target == 0 && state.shares > 0 {
+		return liquidityPoolAction{withdrawShares: state.shares}
+	}
+	if allocation > target+config.RebalanceThreshold {
+		return liquidityPoolAction{withdrawShares: state.shares * (poolValue - target*totalValue) / poolValue}
+	}
+	if allocation < target-config.RebalanceThreshold {
+		// deposits need both assets in the ratio of the pool, which is limited by the free balance of each asset
+		depositValue := target*totalValue - poolValue
+		depositBase := depositValue / 2 / poolPrice
+		depositQuote := depositValue / 2
+		scale := 1.0
+		if depositBase > state.freeBase {
+			scale = state.freeBase / depositBase
+		}
+		if depositQuote*scale > state.freeQuote {
+			scale = state.freeQuote / depositQuote
+		}
+		return liquidityPoolAction{
+			depositBase:  depositBase * scale,
+			depositQuote: depositQuote * scale,
+		}
+	}
+	return liquidityPoolAction{}
+}
+
+// makeOps converts the action into operations, amounts are in units of the pool's asset A and asset B which are ordered by the network
+func (s *liquidityPoolStrategy) makeOps(state liquidityPoolState, action liquidityPoolAction, hasTrustline bool) ([]txnbuild.Operation, error) {
+	sourceAccount := ""
+	if s.sdex.SourceAccount != s.sdex.TradingAccount {
+		sourceAccount = s.sdex.TradingAccount
+	}
+	baseIsA := s.poolParams.AssetA.GetCode() == s.assetBase.Code && s.poolParams.AssetA.GetIssuer() == s.assetBase.Issuer
+	toAB := func(base float64, quote float64) (string, string) {
+		baseString := model.NumberFromFloatRoundTruncate(base, sdexOrderConstraints.VolumePrecision).AsString()
+		quoteString := model.NumberFromFloatRoundTruncate(quote, sdexOrderConstraints.VolumePrecision).AsString()
+		if baseIsA {
+			return baseString, quoteString
+		}
+		return quoteString, baseString
+	}
+
+	ops := []txnbuild.Operation{}
+	if action.withdrawShares > 0 {
+		// expected amounts are reduced by the slippage tolerance in case the pool is traded against before the withdrawal is applied
+		fraction := action.withdrawShares / state.totalShares * (1 - s.config.SlippageTolerance)
+		minAmountA, minAmountB := toAB(state.reserveBase*fraction, state.reserveQuote*fraction)
+		log.Printf("liquidityPoolStrategy: withdrawing %.7f shares\n", action.withdrawShares)
+		ops = append(ops, &txnbuild.LiquidityPoolWithdraw{
+			SourceAccount:   sourceAccount,
+			LiquidityPoolID: s.poolID,
+			Amount:          model.NumberFromFloatRoundTruncate(action.withdrawShares, sdexOrderConstraints.VolumePrecision).AsString(),
+			MinAmountA:      minAmountA,
+			MinAmountB:      minAmountB,
+		})
+		return ops, nil
+	}
+
+	if action.depositBase <= 0 || action.depositQuote <= 0 {
+		return ops, nil
+	}
+	if !hasTrustline {
+		log.Printf("liquidityPoolStrategy: adding a trustline to the liquidity pool shares\n")
+		ops = append(ops, &txnbuild.ChangeTrust{
+			SourceAccount: sourceAccount,
+			Line:          txnbuild.LiquidityPoolShareChangeTrustAsset{LiquidityPoolParameters: s.poolParams},
+		})
+	}
+
+	// the deposit price is in units of asset B per asset A
+	poolPriceAB := state.price()
+	if !baseIsA {
+		poolPriceAB = 1 / poolPriceAB
+	}
+	minPrice, e := price.Parse(strconv.FormatFloat(poolPriceAB*(1-s.config.SlippageTolerance), 'f', 7, 64))
+	if e != nil {
+		return nil, fmt.Errorf("could not parse min price: %s", e)
+	}
+	maxPrice, e := price.Parse(strconv.FormatFloat(poolPriceAB*(1+s.config.SlippageTolerance), 'f', 7, 64))
+	if e != nil {
+		return nil, fmt.Errorf("could not parse max price: %s", e)
+	}
+	maxAmountA, maxAmountB := toAB(action.depositBase, action.depositQuote)
+	log.Printf("liquidityPoolStrategy: depositing up to %.7f of the base asset and %.7f of the quote asset\n", action.depositBase, action.depositQuote)
+	ops = append(ops, &txnbuild.LiquidityPoolDeposit{
+		SourceAccount:   sourceAccount,
+		LiquidityPoolID: s.poolID,
+		MaxAmountA:      maxAmountA,
+		MaxAmountB:      maxAmountB,
+		MinPrice:        minPrice,
+		MaxPrice:        maxPrice,
+	})
+	return ops, nil
+}
@@ -0,0 +1,83 @@
+package plugins
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComputeLiquidityPoolAction(t *testing.T) {
+	config := &liquidityPoolConfig{
+		TargetAllocation:   0.5,
+		RebalanceThreshold: 0.05,
+		SlippageTolerance:  0.01,
+	}
+
+	testCases := []struct {
+		name   string
+		state  liquidityPoolState
+		config *liquidityPoolConfig
+		want   liquidityPoolAction
+	}{
+		{
+			// pool price is 2.0, 10% of the pool is worth 400 and the free balances are worth 400
+			name:   "within threshold",
+			state:  liquidityPoolState{reserveBase: 1000, reserveQuote: 2000, totalShares: 100, shares: 10, freeBase: 100, freeQuote: 200},
+			config: config,
+			want:   liquidityPoolAction{},
+		}, {
+			// holding 400 in the pool and 1200 outside of it, so 400 more needs to be deposited
+			name:   "deposit",
+			state:  liquidityPoolState{reserveBase: 1000, reserveQuote: 2000, totalShares: 100, shares: 10, freeBase: 300, freeQuote: 600},
+			config: config,
+			want:   liquidityPoolAction{depositBase: 100, depositQuote: 200},
+		}, {
+			// the deposit is limited by the free quote balance while keeping the ratio of the pool
+			name:   "deposit limited by balance",
+			state:  liquidityPoolState{reserveBase: 1000, reserveQuote: 2000, totalShares: 100, shares: 0, freeBase: 500, freeQuote: 100},
+			config: config,
+			want:   liquidityPoolAction{depositBase: 50, depositQuote: 100},
+		}, {
+			// holding 1200 in the pool and 400 outside of it, so half of the shares need to be withdrawn
+			name:   "withdraw",
+			state:  liquidityPoolState{reserveBase: 1000, reserveQuote: 2000, totalShares: 100, shares: 30, freeBase: 100, freeQuote: 200},
+			config: config,
+			want:   liquidityPoolAction{withdrawShares: 10},
+		}, {
+			name:  "withdraw all outside of price range",
+			state: liquidityPoolState{reserveBase: 1000, reserveQuote: 2000, totalShares: 100, shares: 10, freeBase: 100, freeQuote: 200},
+			config: &liquidityPoolConfig{
+				TargetAllocation:   0.5,
+				RebalanceThreshold: 0.05,
+				MaxPrice:           1.5,
+				SlippageTolerance:  0.01,
+			},
+			want: liquidityPoolAction{withdrawShares: 10},
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			got := computeLiquidityPoolAction(k.state, k.config)
+			assert.InDelta(t, k.want.depositBase, got.depositBase, 0.0000001)
+			assert.InDelta(t, k.want.depositQuote, got.depositQuote, 0.0000001)
+			assert.InDelta(t, k.want.withdrawShares, got.withdrawShares, 0.0000001)
+		})
+	}
+}
+
+func TestLiquidityPoolStrategySkipsUpdateWhilePending(t *testing.T) {
+	// sdex is nil so loading the state would panic if the update was not skipped
+	s := &liquidityPoolStrategy{pendingMutex: &sync.Mutex{}}
+	s.setPending(true)
+
+	ops, e := s.UpdateWithOps(nil, nil)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, 0, len(ops))
+
+	s.setPending(false)
+	assert.False(t, s.isPending())
+}
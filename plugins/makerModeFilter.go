@@ -3,6 +3,7 @@ package plugins
 import (
 	"fmt"
 	"log"
+	"math"
 	"strconv"
 
 	hProtocol "github.com/stellar/go/protocols/horizon"
@@ -12,24 +13,54 @@ import (
 	"github.com/stellar/kelp/support/utils"
 )
 
+// MakerModeCrossingAction is what the maker mode filter does with an offer that would cross the spread
+type MakerModeCrossingAction string
+
+// type of MakerModeCrossingAction
+const (
+	MakerModeCrossingActionDrop    MakerModeCrossingAction = "drop"
+	MakerModeCrossingActionReprice MakerModeCrossingAction = "reprice"
+)
+
+// ParseMakerModeCrossingAction converts a string to the MakerModeCrossingAction, defaulting to drop when empty
+func ParseMakerModeCrossingAction(action string) (MakerModeCrossingAction, error) {
+	if action == "" || action == string(MakerModeCrossingActionDrop) {
+		return MakerModeCrossingActionDrop, nil
+	} else if action == string(MakerModeCrossingActionReprice) {
+		return MakerModeCrossingActionReprice, nil
+	}
+	return MakerModeCrossingActionDrop, fmt.Errorf("invalid maker mode crossing action '%s', needs to be either '%s' or '%s'", action, MakerModeCrossingActionDrop, MakerModeCrossingActionReprice)
+}
+
 type makerModeFilter struct {
-	name         string
-	tradingPair  *model.TradingPair
-	exchangeShim api.ExchangeShim
-	sdex         *SDEX
+	name           string
+	tradingPair    *model.TradingPair
+	exchangeShim   api.ExchangeShim
+	sdex           *SDEX
+	crossingAction MakerModeCrossingAction
 }
 
 // MakeFilterMakerMode makes a submit filter based on the passed in submitMode
-func MakeFilterMakerMode(exchangeShim api.ExchangeShim, sdex *SDEX, tradingPair *model.TradingPair) SubmitFilter {
+func MakeFilterMakerMode(exchangeShim api.ExchangeShim, sdex *SDEX, tradingPair *model.TradingPair, crossingAction MakerModeCrossingAction) SubmitFilter {
 	return &makerModeFilter{
-		name:         "makeModeFilter",
-		tradingPair:  tradingPair,
-		exchangeShim: exchangeShim,
-		sdex:         sdex,
+		name:           "makeModeFilter",
+		tradingPair:    tradingPair,
+		exchangeShim:   exchangeShim,
+		sdex:           sdex,
+		crossingAction: crossingAction,
 	}
 }
 
 var _ SubmitFilter = &makerModeFilter{}
+var _ OrderedSubmitFilter = &makerModeFilter{}
+
+// FilterOrder impl.
+func (f *makerModeFilter) FilterOrder() FilterOrder {
+	return FilterOrder{
+		ID:       filterIDMakerMode,
+		Priority: FilterPriorityPrice,
+	}
+}
 
 func (f *makerModeFilter) Apply(ops []txnbuild.Operation, sellingOffers []hProtocol.Offer, buyingOffers []hProtocol.Offer) ([]txnbuild.Operation, error) {
 	ob, e := f.exchangeShim.GetOrderBook(f.tradingPair, 50)
@@ -42,17 +73,19 @@ func (f *makerModeFilter) Apply(ops []txnbuild.Operation, sellingOffers []hProto
 		return nil, fmt.Errorf("could not get assets: %s", e)
 	}
 
-	innerFn := func(op *txnbuild.ManageSellOffer) (*txnbuild.ManageSellOffer, error) {
-		topBidPrice, e := f.topOrderPriceExcludingTrader(ob.Bids(), buyingOffers, false)
-		if e != nil {
-			return nil, fmt.Errorf("could not get topOrderPriceExcludingTrader for bids: %s", e)
-		}
-		topAskPrice, e := f.topOrderPriceExcludingTrader(ob.Asks(), sellingOffers, true)
-		if e != nil {
-			return nil, fmt.Errorf("could not get topOrderPriceExcludingTrader for asks: %s", e)
-		}
+	// the top of the book is computed once per update and applied to all ops
+	topBidPrice, e := f.topOrderPriceExcludingTrader(ob.Bids(), buyingOffers, false)
+	if e != nil {
+		return nil, fmt.Errorf("could not get topOrderPriceExcludingTrader for bids: %s", e)
+	}
+	topAskPrice, e := f.topOrderPriceExcludingTrader(ob.Asks(), sellingOffers, true)
+	if e != nil {
+		return nil, fmt.Errorf("could not get topOrderPriceExcludingTrader for asks: %s", e)
+	}
+	pricePrecision := f.exchangeShim.GetOrderConstraints(f.tradingPair).PricePrecision
 
-		return f.transformOfferMakerMode(baseAsset, quoteAsset, topBidPrice, topAskPrice, op)
+	innerFn := func(op *txnbuild.ManageSellOffer) (*txnbuild.ManageSellOffer, error) {
+		return transformOfferMakerMode(baseAsset, quoteAsset, topBidPrice, topAskPrice, f.crossingAction, pricePrecision, op)
 	}
 	ops, e = filterOps(f.name, baseAsset, quoteAsset, sellingOffers, buyingOffers, ops, innerFn)
 	if e != nil {
@@ -125,11 +158,13 @@ func (f *makerModeFilter) topOrderPriceExcludingTrader(obSide []model.Order, tra
 	return nil, nil
 }
 
-func (f *makerModeFilter) transformOfferMakerMode(
+func transformOfferMakerMode(
 	baseAsset hProtocol.Asset,
 	quoteAsset hProtocol.Asset,
 	topBidPrice *model.Number,
 	topAskPrice *model.Number,
+	crossingAction MakerModeCrossingAction,
+	pricePrecision int8,
 	op *txnbuild.ManageSellOffer,
 ) (*txnbuild.ManageSellOffer, error) {
 	// delete operations should never be dropped
@@ -170,6 +205,33 @@ func (f *makerModeFilter) transformOfferMakerMode(
 		return op, nil
 	}
 
+	if crossingAction == MakerModeCrossingActionReprice {
+		return repriceOfferMakerMode(topBidPrice, topAskPrice, isSell, sellPrice, pricePrecision, op)
+	}
+
 	// we don't want to keep it so return the dropped command
 	return nil, nil
 }
+
+// repriceOfferMakerMode moves a crossing offer to one price increment outside the spread, keeping the same amount of the base asset
+func repriceOfferMakerMode(
+	topBidPrice *model.Number,
+	topAskPrice *model.Number,
+	isSell bool,
+	sellPrice float64,
+	pricePrecision int8,
+	op *txnbuild.ManageSellOffer,
+) (*txnbuild.ManageSellOffer, error) {
+	priceIncrement := math.Pow(10, -float64(pricePrecision))
+	if isSell {
+		newPrice := model.NumberFromFloat(topBidPrice.AsFloat()+priceIncrement, pricePrecision).AsFloat()
+		return repriceOfferKeepingBaseAmount("makerModeFilter", isSell, sellPrice, newPrice, op)
+	}
+
+	newBuyPrice := topAskPrice.AsFloat() - priceIncrement
+	if newBuyPrice <= 0 {
+		log.Printf("makerModeFilter:  buying, cannot reprice below the topAskPrice %.7f: keep = false", topAskPrice.AsFloat())
+		return nil, nil
+	}
+	return repriceOfferKeepingBaseAmount("makerModeFilter", isSell, 1/sellPrice, newBuyPrice, op)
+}
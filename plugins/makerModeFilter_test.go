@@ -0,0 +1,83 @@
+package plugins
+
+import (
+	"testing"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/kelp/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransformOfferMakerMode(t *testing.T) {
+	baseAsset := hProtocol.Asset{Type: "native"}
+	quoteAsset := hProtocol.Asset{Type: "credit_alphanum4", Code: "USD", Issuer: "GBMMZMK2DC4FFP4CAI6KCVNCQ7WLO5A7DQU7EC7WGHRDQBZB763X4OQI"}
+	txnBase := txnbuild.NativeAsset{}
+	txnQuote := txnbuild.CreditAsset{Code: quoteAsset.Code, Issuer: quoteAsset.Issuer}
+	topBidPrice := model.NumberFromFloat(0.099, 7)
+	topAskPrice := model.NumberFromFloat(0.101, 7)
+
+	testCases := []struct {
+		name           string
+		op             *txnbuild.ManageSellOffer
+		crossingAction MakerModeCrossingAction
+		wantOp         *txnbuild.ManageSellOffer
+	}{
+		{
+			name:           "sell outside spread is kept",
+			op:             &txnbuild.ManageSellOffer{Selling: txnBase, Buying: txnQuote, Amount: "100.0000000", Price: "0.1020000"},
+			crossingAction: MakerModeCrossingActionDrop,
+			wantOp:         &txnbuild.ManageSellOffer{Selling: txnBase, Buying: txnQuote, Amount: "100.0000000", Price: "0.1020000"},
+		}, {
+			name:           "crossing sell is dropped",
+			op:             &txnbuild.ManageSellOffer{Selling: txnBase, Buying: txnQuote, Amount: "100.0000000", Price: "0.0980000"},
+			crossingAction: MakerModeCrossingActionDrop,
+			wantOp:         nil,
+		}, {
+			name:           "crossing sell is repriced above the top bid",
+			op:             &txnbuild.ManageSellOffer{Selling: txnBase, Buying: txnQuote, Amount: "100.0000000", Price: "0.0980000"},
+			crossingAction: MakerModeCrossingActionReprice,
+			wantOp:         &txnbuild.ManageSellOffer{Selling: txnBase, Buying: txnQuote, Amount: "100.0000000", Price: "0.0990001"},
+		}, {
+			// buying 100 units of the base asset at 0.2, so the op sells 20 units of the quote asset at a price of 5
+			name:           "crossing buy is dropped",
+			op:             &txnbuild.ManageSellOffer{Selling: txnQuote, Buying: txnBase, Amount: "20.0000000", Price: "5.0000000"},
+			crossingAction: MakerModeCrossingActionDrop,
+			wantOp:         nil,
+		}, {
+			// repriced to buy 100 units of the base asset at 0.1009999
+			name:           "crossing buy is repriced below the top ask",
+			op:             &txnbuild.ManageSellOffer{Selling: txnQuote, Buying: txnBase, Amount: "20.0000000", Price: "5.0000000"},
+			crossingAction: MakerModeCrossingActionReprice,
+			wantOp:         &txnbuild.ManageSellOffer{Selling: txnQuote, Buying: txnBase, Amount: "10.0999900", Price: "9.9009999"},
+		}, {
+			name:           "delete op is kept",
+			op:             &txnbuild.ManageSellOffer{Selling: txnBase, Buying: txnQuote, Amount: "0", Price: "0.0980000", OfferID: 5},
+			crossingAction: MakerModeCrossingActionDrop,
+			wantOp:         &txnbuild.ManageSellOffer{Selling: txnBase, Buying: txnQuote, Amount: "0", Price: "0.0980000", OfferID: 5},
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			op, e := transformOfferMakerMode(baseAsset, quoteAsset, topBidPrice, topAskPrice, k.crossingAction, 7, k.op)
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, k.wantOp, op)
+		})
+	}
+}
+
+func TestParseMakerModeCrossingAction(t *testing.T) {
+	action, e := ParseMakerModeCrossingAction("")
+	if assert.NoError(t, e) {
+		assert.Equal(t, MakerModeCrossingActionDrop, action)
+	}
+	action, e = ParseMakerModeCrossingAction("reprice")
+	if assert.NoError(t, e) {
+		assert.Equal(t, MakerModeCrossingActionReprice, action)
+	}
+	_, e = ParseMakerModeCrossingAction("cross")
+	assert.Error(t, e)
+}
@@ -33,6 +33,16 @@ func MakeFilterMaxPrice(baseAsset hProtocol.Asset, quoteAsset hProtocol.Asset, c
 }
 
 var _ SubmitFilter = &maxPriceFilter{}
+var _ OrderedSubmitFilter = &maxPriceFilter{}
+
+// FilterOrder impl.
+func (f *maxPriceFilter) FilterOrder() FilterOrder {
+	return FilterOrder{
+		ID:       filterIDMaxPrice,
+		Priority: FilterPriorityPrice,
+		After:    []string{filterIDMakerMode},
+	}
+}
 
 // Validate ensures validity
 func (c *MaxPriceFilterConfig) Validate() error {
@@ -33,6 +33,16 @@ func MakeFilterMinPrice(baseAsset hProtocol.Asset, quoteAsset hProtocol.Asset, c
 }
 
 var _ SubmitFilter = &minPriceFilter{}
+var _ OrderedSubmitFilter = &minPriceFilter{}
+
+// FilterOrder impl.
+func (f *minPriceFilter) FilterOrder() FilterOrder {
+	return FilterOrder{
+		ID:       filterIDMinPrice,
+		Priority: FilterPriorityPrice,
+		After:    []string{filterIDMakerMode},
+	}
+}
 
 // Validate ensures validity
 func (c *MinPriceFilterConfig) Validate() error {
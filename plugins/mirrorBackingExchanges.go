@@ -0,0 +1,281 @@
+package plugins
+
+import (
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+)
+
+// offsetVenueQuoteDepth is the number of levels fetched from each backing exchange to compute the executable price of an offset
+const offsetVenueQuoteDepth int32 = 20
+
+// mirrorExchangeNames is the EXCHANGE config param of the mirror strategy, which can be a single exchange or a list of exchanges
+type mirrorExchangeNames []string
+
+// UnmarshalTOML impl. so EXCHANGE can be specified as either a string or a list of strings
+func (n *mirrorExchangeNames) UnmarshalTOML(data interface{}) error {
+	switch v := data.(type) {
+	case string:
+		*n = mirrorExchangeNames{v}
+		return nil
+	case []interface{}:
+		names := mirrorExchangeNames{}
+		for _, elem := range v {
+			name, ok := elem.(string)
+			if !ok {
+				return fmt.Errorf("EXCHANGE needs to be a list of strings, found element '%v' of type %T", elem, elem)
+			}
+			names = append(names, name)
+		}
+		*n = names
+		return nil
+	default:
+		return fmt.Errorf("EXCHANGE needs to be a string or a list of strings, found '%v' of type %T", data, data)
+	}
+}
+
+// mirrorBackingVenue is one of the backing exchanges when the mirror strategy is configured with multiple exchanges
+type mirrorBackingVenue struct {
+	name     string
+	exchange api.Exchange
+	pair     *model.TradingPair
+}
+
+// offsetVenueQuote is what a backing exchange can offer for an offset order
+type offsetVenueQuote struct {
+	price      float64 // volume-weighted price at which the offset would execute against the orderbook
+	executable bool    // false when the orderbook does not have enough depth to fill the offset
+	hasBalance bool    // false when the account on the exchange cannot pay for the offset
+}
+
+// consolidateOrderBooks merges the orderbooks of the backing exchanges into a single set of bids (best first) and asks (best first).
+// Levels with the same price on different exchanges are combined into one level.
+func consolidateOrderBooks(books []*model.OrderBook) ([]model.Order /*bids*/, []model.Order /*asks*/) {
+	bids := []model.Order{}
+	asks := []model.Order{}
+	for _, ob := range books {
+		bids = append(bids, ob.Bids()...)
+		asks = append(asks, ob.Asks()...)
+	}
+
+	sort.SliceStable(bids, func(i int, j int) bool {
+		return bids[i].Price.AsFloat() > bids[j].Price.AsFloat()
+	})
+	sort.SliceStable(asks, func(i int, j int) bool {
+		return asks[i].Price.AsFloat() < asks[j].Price.AsFloat()
+	})
+	return combineOrdersAtSamePrice(bids), combineOrdersAtSamePrice(asks)
+}
+
+// combineOrdersAtSamePrice combines adjacent orders with the same price, the orders need to be sorted by price
+func combineOrdersAtSamePrice(orders []model.Order) []model.Order {
+	combined := []model.Order{}
+	for _, o := range orders {
+		if len(combined) > 0 && combined[len(combined)-1].Price.AsFloat() == o.Price.AsFloat() {
+			last := &combined[len(combined)-1]
+			last.Volume = last.Volume.Add(*o.Volume)
+			continue
+		}
+		// copy the price and volume since the mirror strategy transforms them in place
+		price := *o.Price
+		volume := *o.Volume
+		o.Price = &price
+		o.Volume = &volume
+		combined = append(combined, o)
+	}
+	return combined
+}
+
+// executablePrice returns the volume-weighted price of taking baseVolume from the orders (best first), or false if there is not enough depth
+func executablePrice(orders []model.Order, baseVolume float64) (float64, bool) {
+	if baseVolume <= 0.0 {
+		return 0.0, false
+	}
+
+	remaining := baseVolume
+	quoteTotal := 0.0
+	for _, o := range orders {
+		fill := o.Volume.AsFloat()
+		if fill > remaining {
+			fill = remaining
+		}
+		quoteTotal += fill * o.Price.AsFloat()
+		remaining -= fill
+		if remaining <= 0.0 {
+			return quoteTotal / baseVolume, true
+		}
+	}
+	return 0.0, false
+}
+
+// rankOffsetVenues returns the indices of the quotes that can execute the offset, ordered from the best price to the worst price.
+// Selling is best at the highest price and buying is best at the lowest price, ties are resolved in the configured order of exchanges.
+func rankOffsetVenues(quotes []offsetVenueQuote, newOrderAction model.OrderAction) []int {
+	ranked := []int{}
+	for i, q := range quotes {
+		if q.executable && q.hasBalance {
+			ranked = append(ranked, i)
+		}
+	}
+
+	sort.SliceStable(ranked, func(i int, j int) bool {
+		if newOrderAction.IsSell() {
+			return quotes[ranked[i]].price > quotes[ranked[j]].price
+		}
+		return quotes[ranked[i]].price < quotes[ranked[j]].price
+	})
+	return ranked
+}
+
+// fetchConsolidatedOrderBook fetches the orderbooks of all backing exchanges, skipping any exchange that fails as long as one succeeds
+func (s *mirrorStrategy) fetchConsolidatedOrderBook(maxCount int32) ([]model.Order /*bids*/, []model.Order /*asks*/, error) {
+	books := []*model.OrderBook{}
+	for _, venue := range s.backingVenues {
+		ob, e := venue.exchange.GetOrderBook(venue.pair, maxCount)
+		if e != nil {
+			log.Printf("unable to fetch orderbook from backing exchange '%s', excluding it from the consolidated orderbook: %s\n", venue.name, e)
+			continue
+		}
+		books = append(books, ob)
+	}
+	if len(books) == 0 {
+		return nil, nil, fmt.Errorf("unable to fetch the orderbook from any of the %d backing exchanges", len(s.backingVenues))
+	}
+
+	bids, asks := consolidateOrderBooks(books)
+	return bids, asks, nil
+}
+
+// quoteOffset computes what the venue can offer for an offset order of baseVolume
+func (s *mirrorStrategy) quoteOffset(venue *mirrorBackingVenue, newOrderAction model.OrderAction, baseVolume float64) (offsetVenueQuote, error) {
+	ob, e := venue.exchange.GetOrderBook(venue.pair, offsetVenueQuoteDepth)
+	if e != nil {
+		return offsetVenueQuote{}, fmt.Errorf("unable to fetch orderbook: %s", e)
+	}
+	// selling takes the bids and buying takes the asks
+	orders := ob.Asks()
+	if newOrderAction.IsSell() {
+		orders = ob.Bids()
+	}
+	price, executable := executablePrice(orders, baseVolume)
+
+	balances, e := venue.exchange.GetAccountBalances([]interface{}{venue.pair.Base, venue.pair.Quote})
+	if e != nil {
+		return offsetVenueQuote{}, fmt.Errorf("unable to fetch balances: %s", e)
+	}
+	hasBalance := false
+	if newOrderAction.IsSell() {
+		baseBalance := balances[venue.pair.Base]
+		hasBalance = baseBalance.AsFloat() >= baseVolume
+	} else {
+		quoteBalance := balances[venue.pair.Quote]
+		hasBalance = quoteBalance.AsFloat() >= baseVolume*price
+	}
+
+	return offsetVenueQuote{
+		price:      price,
+		executable: executable,
+		hasBalance: hasBalance,
+	}, nil
+}
+
+// placeBestPriceOffset places the offset on the backing exchange with the best executable price that has enough balance for it,
+// falling back to the next best exchange when placing the order fails
+func (s *mirrorStrategy) placeBestPriceOffset(newOrderAction model.OrderAction, price *model.Number, baseVolume *model.Number) (*model.TransactionID, error) {
+	quotes := []offsetVenueQuote{}
+	for _, venue := range s.backingVenues {
+		q, e := s.quoteOffset(venue, newOrderAction, baseVolume.AsFloat())
+		if e != nil {
+			log.Printf("unable to quote offset on backing exchange '%s', skipping it: %s\n", venue.name, e)
+		}
+		quotes = append(quotes, q)
+	}
+
+	ranked := rankOffsetVenues(quotes, newOrderAction)
+	if len(ranked) == 0 {
+		return nil, fmt.Errorf("none of the %d backing exchanges can execute the offset with enough balance (newOrderAction=%s, baseVolume=%s)", len(s.backingVenues), newOrderAction.String(), baseVolume.AsString())
+	}
+
+	errs := []error{}
+	for _, i := range ranked {
+		venue := s.backingVenues[i]
+		v := &exchangeOffsetVenue{exchange: venue.exchange, pair: venue.pair}
+		txID, e := v.placeOffset(newOrderAction, price, baseVolume)
+		if e == nil {
+			log.Printf("placed offset on backing exchange '%s' with the best executable price (%f)\n", venue.name, quotes[i].price)
+			return txID, nil
+		}
+		log.Printf("unable to place offset on backing exchange '%s', trying the next best exchange: %s\n", venue.name, e)
+		errs = append(errs, fmt.Errorf("%s: %s", venue.name, e))
+	}
+	return nil, fmt.Errorf("unable to place offset on any of the backing exchanges: %v", errs)
+}
+
+// makeAdditionalBackingVenues makes the backing exchanges listed after the primary backing exchange in EXCHANGE, which all use the
+// same EXCHANGE_BASE and EXCHANGE_QUOTE asset codes, EXCHANGE_PARAMS, and EXCHANGE_HEADERS as the primary backing exchange
+func makeAdditionalBackingVenues(config *mirrorConfig, exchangeAPIKeys []api.ExchangeAPIKey, simMode bool) ([]*mirrorBackingVenue, error) {
+	venues := []*mirrorBackingVenue{}
+	for i, name := range config.Exchange[1:] {
+		var exchange api.Exchange
+		var e error
+		if config.OffsetTrades {
+			exchangeParams := config.ExchangeParams.ToExchangeParams()
+			exchangeHeaders := config.ExchangeHeaders.ToExchangeHeaders()
+			// the api keys are matched to the exchanges by position, index 0 belongs to the primary backing exchange
+			exchange, e = MakeTradingExchange(name, exchangeAPIKeys[i+1:i+2], exchangeParams, exchangeHeaders, simMode)
+		} else {
+			exchange, e = MakeExchange(name, simMode)
+		}
+		if e != nil {
+			return nil, fmt.Errorf("unable to make backing exchange '%s': %s", name, e)
+		}
+
+		base, e := exchange.GetAssetConverter().FromString(config.ExchangeBase)
+		if e != nil {
+			return nil, fmt.Errorf("unable to convert EXCHANGE_BASE for backing exchange '%s': %s", name, e)
+		}
+		quote, e := exchange.GetAssetConverter().FromString(config.ExchangeQuote)
+		if e != nil {
+			return nil, fmt.Errorf("unable to convert EXCHANGE_QUOTE for backing exchange '%s': %s", name, e)
+		}
+		venues = append(venues, &mirrorBackingVenue{
+			name:     name,
+			exchange: exchange,
+			pair:     &model.TradingPair{Base: base, Quote: quote},
+		})
+	}
+	return venues, nil
+}
+
+// getMaxBackingBalances returns the largest balance of each asset across the backing exchanges, since each offset is placed on a
+// single exchange we cannot offset more than the largest balance in one go
+func (s *mirrorStrategy) getMaxBackingBalances() (*model.Number /*baseBackingBalance*/, *model.Number /*quoteBackingBalance*/, error) {
+	maxBase := model.NumberConstants.Zero
+	maxQuote := model.NumberConstants.Zero
+	for _, venue := range s.backingVenues {
+		balanceMap, e := venue.exchange.GetAccountBalances([]interface{}{venue.pair.Base, venue.pair.Quote})
+		if e != nil {
+			return nil, nil, fmt.Errorf("unable to fetch balances from backing exchange '%s': %s", venue.name, e)
+		}
+
+		baseBalance, ok := balanceMap[venue.pair.Base]
+		if !ok {
+			return nil, nil, fmt.Errorf("unable to fetch balance for base asset from backing exchange '%s': %s", venue.name, string(venue.pair.Base))
+		}
+		quoteBalance, ok := balanceMap[venue.pair.Quote]
+		if !ok {
+			return nil, nil, fmt.Errorf("unable to fetch balance for quote asset from backing exchange '%s': %s", venue.name, string(venue.pair.Quote))
+		}
+
+		if baseBalance.AsFloat() > maxBase.AsFloat() {
+			maxBase = &baseBalance
+		}
+		if quoteBalance.AsFloat() > maxQuote.AsFloat() {
+			maxQuote = &quoteBalance
+		}
+	}
+	return maxBase, maxQuote, nil
+}
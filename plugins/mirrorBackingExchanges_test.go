@@ -0,0 +1,129 @@
+package plugins
+
+import (
+	"testing"
+
+	"github.com/BurntSushi/toml"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/kelp/model"
+)
+
+func TestMirrorExchangeNamesUnmarshalTOML(t *testing.T) {
+	testCases := []struct {
+		name    string
+		input   string
+		want    mirrorExchangeNames
+		wantErr bool
+	}{
+		{
+			name:  "single exchange",
+			input: `EXCHANGE="kraken"`,
+			want:  mirrorExchangeNames{"kraken"},
+		}, {
+			name:  "list of exchanges",
+			input: `EXCHANGE=["kraken", "ccxt-binance"]`,
+			want:  mirrorExchangeNames{"kraken", "ccxt-binance"},
+		}, {
+			name:    "invalid type",
+			input:   `EXCHANGE=5`,
+			wantErr: true,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			var cfg mirrorConfig
+			_, e := toml.Decode(k.input, &cfg)
+			if k.wantErr {
+				assert.Error(t, e)
+				return
+			}
+			if assert.NoError(t, e) {
+				assert.Equal(t, k.want, cfg.Exchange)
+			}
+		})
+	}
+}
+
+func makeTestOrders(prices []float64, volumes []float64) []model.Order {
+	orders := []model.Order{}
+	for i := range prices {
+		orders = append(orders, model.Order{
+			Price:  model.NumberFromFloat(prices[i], 4),
+			Volume: model.NumberFromFloat(volumes[i], 4),
+		})
+	}
+	return orders
+}
+
+func TestConsolidateOrderBooks(t *testing.T) {
+	book1 := model.MakeOrderBook(nil,
+		makeTestOrders([]float64{0.102, 0.104}, []float64{10.0, 20.0}),
+		makeTestOrders([]float64{0.100, 0.098}, []float64{10.0, 20.0}),
+	)
+	book2 := model.MakeOrderBook(nil,
+		makeTestOrders([]float64{0.101, 0.102}, []float64{5.0, 7.0}),
+		makeTestOrders([]float64{0.099, 0.098}, []float64{5.0, 7.0}),
+	)
+
+	bids, asks := consolidateOrderBooks([]*model.OrderBook{book1, book2})
+
+	wantBids := makeTestOrders([]float64{0.100, 0.099, 0.098}, []float64{10.0, 5.0, 27.0})
+	wantAsks := makeTestOrders([]float64{0.101, 0.102, 0.104}, []float64{5.0, 17.0, 20.0})
+	if assert.Equal(t, len(wantBids), len(bids)) {
+		for i, o := range bids {
+			assert.Equal(t, wantBids[i].Price.AsString(), o.Price.AsString())
+			assert.Equal(t, wantBids[i].Volume.AsString(), o.Volume.AsString())
+		}
+	}
+	if assert.Equal(t, len(wantAsks), len(asks)) {
+		for i, o := range asks {
+			assert.Equal(t, wantAsks[i].Price.AsString(), o.Price.AsString())
+			assert.Equal(t, wantAsks[i].Volume.AsString(), o.Volume.AsString())
+		}
+	}
+
+	// the source orderbooks should not be modified when the consolidated orders are transformed
+	transformOrders(bids, 0.5, 0.5, nil)
+	assert.Equal(t, "0.1000", book1.Bids()[0].Price.AsString())
+	assert.Equal(t, "20.0000", book1.Bids()[1].Volume.AsString())
+}
+
+func TestExecutablePrice(t *testing.T) {
+	orders := makeTestOrders([]float64{0.10, 0.12}, []float64{10.0, 10.0})
+	testCases := []struct {
+		name           string
+		baseVolume     float64
+		wantPrice      float64
+		wantExecutable bool
+	}{
+		{"within first level", 5.0, 0.10, true},
+		{"across levels", 15.0, (10.0*0.10 + 5.0*0.12) / 15.0, true},
+		{"entire book", 20.0, 0.11, true},
+		{"not enough depth", 25.0, 0.0, false},
+		{"zero volume", 0.0, 0.0, false},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			price, executable := executablePrice(orders, k.baseVolume)
+			assert.Equal(t, k.wantExecutable, executable)
+			assert.InDelta(t, k.wantPrice, price, 0.0000001)
+		})
+	}
+}
+
+func TestRankOffsetVenues(t *testing.T) {
+	quotes := []offsetVenueQuote{
+		{price: 0.100, executable: true, hasBalance: true},
+		{price: 0.102, executable: true, hasBalance: true},
+		{price: 0.105, executable: true, hasBalance: false},
+		{price: 0.095, executable: false, hasBalance: true},
+		{price: 0.100, executable: true, hasBalance: true},
+	}
+
+	assert.Equal(t, []int{1, 0, 4}, rankOffsetVenues(quotes, model.OrderActionSell))
+	assert.Equal(t, []int{0, 4, 1}, rankOffsetVenues(quotes, model.OrderActionBuy))
+	assert.Equal(t, []int{}, rankOffsetVenues([]offsetVenueQuote{}, model.OrderActionBuy))
+}
@@ -4,9 +4,11 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
+	"math"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/nikhilsaraf/go-tools/multithreading"
 
@@ -17,6 +19,7 @@ import (
 	"github.com/stellar/kelp/kelpdb"
 	"github.com/stellar/kelp/model"
 	"github.com/stellar/kelp/queries"
+	"github.com/stellar/kelp/support/postgresdb"
 	"github.com/stellar/kelp/support/toml"
 	"github.com/stellar/kelp/support/utils"
 )
@@ -28,27 +31,42 @@ const debugLogOffersOrders = true
 
 const maxOrderbookDepth int32 = 50
 
+// depthAggregationFetchMultiplier is how many more raw levels we fetch from the backing exchange per mirrored level when aggregating depth,
+// since many raw levels are combined into a single price band
+const depthAggregationFetchMultiplier = 5
+
 // mirrorConfig contains the configuration params for this strategy
 type mirrorConfig struct {
-	Exchange       string `valid:"-" toml:"EXCHANGE"`
-	ExchangeBase   string `valid:"-" toml:"EXCHANGE_BASE"`
-	ExchangeQuote  string `valid:"-" toml:"EXCHANGE_QUOTE"`
-	OrderbookDepth int    `valid:"-" toml:"ORDERBOOK_DEPTH"`
-	// Deprecated: use BID_VOLUME_DIVIDE_BY and ASK_VOLUME_DIVIDE_BY instead
-	VolumeDivideByDeprecated *float64 `valid:"-" toml:"VOLUME_DIVIDE_BY" deprecated:"true"`
-	BidVolumeDivideBy        *float64 `valid:"-" toml:"BID_VOLUME_DIVIDE_BY"`
-	AskVolumeDivideBy        *float64 `valid:"-" toml:"ASK_VOLUME_DIVIDE_BY"`
-	MaxOrderBaseCap          *float64 `valid:"-" toml:"MAX_ORDER_BASE_CAP"` // use a pointer here so we don't need to special case 0.0 everywhere and a nil value is clearly not user-entered
-	PerLevelSpread           float64  `valid:"-" toml:"PER_LEVEL_SPREAD"`
-	PricePrecisionOverride   *int8    `valid:"-" toml:"PRICE_PRECISION_OVERRIDE"`
-	VolumePrecisionOverride  *int8    `valid:"-" toml:"VOLUME_PRECISION_OVERRIDE"`
-	// Deprecated: use MIN_BASE_VOLUME_OVERRIDE instead
-	MinBaseVolumeDeprecated                   *float64                 `valid:"-" toml:"MIN_BASE_VOLUME" deprecated:"true"`
+	Exchange       mirrorExchangeNames `valid:"-" toml:"EXCHANGE"`
+	ExchangeBase   string              `valid:"-" toml:"EXCHANGE_BASE"`
+	ExchangeQuote  string              `valid:"-" toml:"EXCHANGE_QUOTE"`
+	OrderbookDepth int                 `valid:"-" toml:"ORDERBOOK_DEPTH"`
+	// VOLUME_DIVIDE_BY of version 1 config files is migrated to BID_VOLUME_DIVIDE_BY and ASK_VOLUME_DIVIDE_BY when loaded, see migrateMirrorConfigV1
+	BidVolumeDivideBy       *float64 `valid:"-" toml:"BID_VOLUME_DIVIDE_BY"`
+	AskVolumeDivideBy       *float64 `valid:"-" toml:"ASK_VOLUME_DIVIDE_BY"`
+	MaxOrderBaseCap         *float64 `valid:"-" toml:"MAX_ORDER_BASE_CAP"` // use a pointer here so we don't need to special case 0.0 everywhere and a nil value is clearly not user-entered
+	PerLevelMaxVolume       *float64 `valid:"-" toml:"PER_LEVEL_MAX_VOLUME"`
+	TotalMaxVolume          *float64 `valid:"-" toml:"TOTAL_MAX_VOLUME"`
+	PerLevelSpread          float64  `valid:"-" toml:"PER_LEVEL_SPREAD"`
+	PricePrecisionOverride  *int8    `valid:"-" toml:"PRICE_PRECISION_OVERRIDE"`
+	VolumePrecisionOverride *int8    `valid:"-" toml:"VOLUME_PRECISION_OVERRIDE"`
+	// MIN_BASE_VOLUME of version 1 config files is migrated to MIN_BASE_VOLUME_OVERRIDE when loaded
 	MinBaseVolumeOverride                     *float64                 `valid:"-" toml:"MIN_BASE_VOLUME_OVERRIDE"`
 	MinQuoteVolumeOverride                    *float64                 `valid:"-" toml:"MIN_QUOTE_VOLUME_OVERRIDE"`
 	OffsetTrades                              bool                     `valid:"-" toml:"OFFSET_TRADES"`
 	BackingDbOverrideAccountID                string                   `valid:"-" toml:"BACKING_DB_OVERRIDE__ACCOUNT_ID"`
 	BackingFillTrackerLastTradeCursorOverride string                   `valid:"-" toml:"BACKING_FILL_TRACKER_LAST_TRADE_CURSOR_OVERRIDE"`
+	OffsetNettingGroup                        string                   `valid:"-" toml:"OFFSET_NETTING_GROUP"`
+	OffsetFlushIntervalSeconds                int                      `valid:"-" toml:"OFFSET_FLUSH_INTERVAL_SECONDS"`
+	OffsetFlushMinBaseSurplus                 float64                  `valid:"-" toml:"OFFSET_FLUSH_MIN_BASE_SURPLUS"`
+	FeeAwareSpread                            bool                     `valid:"-" toml:"FEE_AWARE_SPREAD"`
+	SdexNetworkCostPerOfferQuote              float64                  `valid:"-" toml:"SDEX_NETWORK_COST_PER_OFFER_QUOTE"`
+	DepthAggregationBandBps                   float64                  `valid:"-" toml:"DEPTH_AGGREGATION_BAND_BPS"`
+	FxRateFeedType                            string                   `valid:"-" toml:"FX_RATE_FEED_TYPE"`
+	FxRateFeedURL                             string                   `valid:"-" toml:"FX_RATE_FEED_URL"`
+	FxRateMaxSlippage                         float64                  `valid:"-" toml:"FX_RATE_MAX_SLIPPAGE"`
+	OffsetVenues                              []offsetVenueConfig      `valid:"-" toml:"OFFSET_VENUES"`
+	OffsetRoutes                              []offsetRouteConfig      `valid:"-" toml:"OFFSET_ROUTES"`
 	ExchangeAPIKeys                           toml.ExchangeAPIKeysToml `valid:"-" toml:"EXCHANGE_API_KEYS"`
 	ExchangeParams                            toml.ExchangeParamsToml  `valid:"-" toml:"EXCHANGE_PARAMS"`
 	ExchangeHeaders                           toml.ExchangeHeadersToml `valid:"-" toml:"EXCHANGE_HEADERS"`
@@ -60,6 +78,7 @@ func (c mirrorConfig) String() string {
 		"EXCHANGE_API_KEYS": utils.Hide,
 		"EXCHANGE_PARAMS":   utils.Hide,
 		"EXCHANGE_HEADERS":  utils.Hide,
+		"OFFSET_VENUES":     utils.Hide,
 	})
 }
 
@@ -78,6 +97,12 @@ func makeAssetSurplus() *assetSurplus {
 	}
 }
 
+// pendingOffset holds the trades whose surplus is being aggregated before it is offset on the backing exchange
+type pendingOffset struct {
+	trades []model.Trade
+	since  time.Time // time when the first of the pending trades was added
+}
+
 // mirrorStrategy is a strategy to mirror the orderbook of a given exchange
 type mirrorStrategy struct {
 	sdex                                  *SDEX
@@ -93,14 +118,28 @@ type mirrorStrategy struct {
 	strategyMirrorTradeTriggerExistsQuery *queries.StrategyMirrorTradeTriggerExists
 	orderbookDepth                        int
 	perLevelSpread                        float64
+	takerFee                              float64 // taker fee of the backing exchange, 0 when FEE_AWARE_SPREAD is disabled
+	networkCostPerOfferQuote              float64
+	depthAggregationBand                  float64 // width of a price band as a fraction of the best price, 0 when depth is not aggregated
 	bidVolumeDivideBy                     float64
 	askVolumeDivideBy                     float64
 	maybeMaxOrderBaseCap                  *float64 // using a nil value makes it clear whether this value exists or not
+	maybeTotalMaxVolume                   *float64 // cap on the total base volume placed on each side, nil when uncapped
 	exchange                              api.Exchange
 	offsetTrades                          bool
 	mutex                                 *sync.Mutex
-	baseSurplus                           map[model.OrderAction]*assetSurplus // baseSurplus keeps track of any surplus we have of the base asset that needs to be offset on the backing exchange
+	baseSurplus                           map[model.OrderAction]*assetSurplus  // baseSurplus keeps track of any surplus we have of the base asset that needs to be offset on the backing exchange
+	hedgingCoordinator                    *hedgingCoordinator                  // nil when trades are not netted with other bots, in which case baseSurplus is used
+	offsetFlushInterval                   time.Duration                        // 0 disables flushing pending offsets by time
+	offsetFlushMinBaseSurplus             float64                              // 0 disables flushing pending offsets by size
+	pendingOffsets                        map[model.OrderAction]*pendingOffset // only used when offsets are aggregated
+	offsetRouter                          *offsetRouter                        // nil when all offsets are placed on the backing exchange
+	fxRateFeed                            api.PriceFeed                        // converts backing quote units to primary quote units, nil when both pairs have the same quote asset
+	fxRateMaxSlippage                     float64                              // max adverse move of the fx rate between quoting a level and offsetting its trade, 0 disables the guard
+	fxRate                                float64                              // fx rate used to quote the current levels, 1.0 when there is no fxRateFeed
+	backingVenues                         []*mirrorBackingVenue                // all backing exchanges (the first one is exchange), nil when there is only one backing exchange
 	db                                    *sql.DB
+	clock                                 api.Clock
 
 	// uninitialized
 	sellOnPrimaryBalanceCoordinator *balanceCoordinator
@@ -113,30 +152,6 @@ var _ api.Strategy = &mirrorStrategy{}
 // ensure this implements api.FillHandler
 var _ api.FillHandler = &mirrorStrategy{}
 
-func convertDeprecatedMirrorConfigValues(config *mirrorConfig) {
-	if config.MinBaseVolumeOverride != nil && config.MinBaseVolumeDeprecated != nil {
-		log.Printf("deprecation warning: cannot set both '%s' (deprecated) and '%s' in the mirror strategy config, using value from '%s'\n", "MIN_BASE_VOLUME", "MIN_BASE_VOLUME_OVERRIDE", "MIN_BASE_VOLUME_OVERRIDE")
-	} else if config.MinBaseVolumeDeprecated != nil {
-		log.Printf("deprecation warning: '%s' is deprecated, use the field '%s' in the mirror strategy config instead, see sample_mirror.cfg as an example\n", "MIN_BASE_VOLUME", "MIN_BASE_VOLUME_OVERRIDE")
-	}
-	if config.MinBaseVolumeOverride == nil {
-		config.MinBaseVolumeOverride = config.MinBaseVolumeDeprecated
-	}
-
-	if (config.BidVolumeDivideBy != nil || config.AskVolumeDivideBy != nil) && config.VolumeDivideByDeprecated != nil {
-		log.Printf("deprecation warning: cannot set both '%s' (deprecated) and ('%s' / '%s') in the mirror strategy config, overriding with values set from '%s' and '%s'\n", "VOLUME_DIVIDE_BY", "BID_VOLUME_DIVIDE_BY", "ASK_VOLUME_DIVIDE_BY", "BID_VOLUME_DIVIDE_BY", "ASK_VOLUME_DIVIDE_BY")
-	} else if config.VolumeDivideByDeprecated != nil {
-		log.Printf("deprecation warning: '%s' is deprecated, use the fields '%s' and '%s' in the mirror strategy config instead, see sample_mirror.cfg as an example\n", "VOLUME_DIVIDE_BY", "BID_VOLUME_DIVIDE_BY", "ASK_VOLUME_DIVIDE_BY")
-	}
-	// if only one is specified, we will use the deprecated value for the unspecified value right now
-	if config.BidVolumeDivideBy == nil {
-		config.BidVolumeDivideBy = config.VolumeDivideByDeprecated
-	}
-	if config.AskVolumeDivideBy == nil {
-		config.AskVolumeDivideBy = config.VolumeDivideByDeprecated
-	}
-}
-
 // makeMirrorStrategy is a factory method
 func makeMirrorStrategy(
 	sdex *SDEX,
@@ -148,8 +163,8 @@ func makeMirrorStrategy(
 	config *mirrorConfig,
 	db *sql.DB,
 	simMode bool,
+	clock api.Clock,
 ) (api.Strategy, error) {
-	convertDeprecatedMirrorConfigValues(config)
 	var bidVolumeDivideBy float64
 	var askVolumeDivideBy float64
 	if config.BidVolumeDivideBy == nil {
@@ -175,6 +190,17 @@ func makeMirrorStrategy(
 		return nil, fmt.Errorf("invalid mirror strategy config file, ASK_VOLUME_DIVIDE_BY needs to be -1.0 or > 0")
 	}
 
+	if len(config.Exchange) == 0 {
+		return nil, fmt.Errorf("invalid mirror strategy config file, EXCHANGE needs to be set")
+	}
+	// the first exchange is the primary backing exchange, which is used for everything that needs a single backing exchange (such as
+	// tracking fills on the backing exchange), additional exchanges are only used for the consolidated orderbook and to offset trades
+	exchangeName := config.Exchange[0]
+	exchangeAPIKeys := config.ExchangeAPIKeys.ToExchangeAPIKeys()
+	if len(config.Exchange) > 1 && config.OffsetTrades && len(exchangeAPIKeys) != len(config.Exchange) {
+		return nil, fmt.Errorf("invalid mirror strategy config file, need one entry in EXCHANGE_API_KEYS for each exchange in EXCHANGE (in the same order) when EXCHANGE is a list, found %d api keys for %d exchanges", len(exchangeAPIKeys), len(config.Exchange))
+	}
+
 	var exchange api.Exchange
 	var e error
 	var strategyMirrorTradeTriggerExistsQuery *queries.StrategyMirrorTradeTriggerExists
@@ -183,10 +209,15 @@ func makeMirrorStrategy(
 			return nil, fmt.Errorf("db should not be nil when OffsetTrades is enabled")
 		}
 
-		exchangeAPIKeys := config.ExchangeAPIKeys.ToExchangeAPIKeys()
+		// the primary exchange only uses the first api key when EXCHANGE is a list, the other keys are used by the additional backing
+		// exchanges so exchangeAPIKeys itself is not resliced here
+		primaryAPIKeys := exchangeAPIKeys
+		if len(config.Exchange) > 1 {
+			primaryAPIKeys = exchangeAPIKeys[:1]
+		}
 		exchangeParams := config.ExchangeParams.ToExchangeParams()
 		exchangeHeaders := config.ExchangeHeaders.ToExchangeHeaders()
-		exchange, e = MakeTradingExchange(config.Exchange, exchangeAPIKeys, exchangeParams, exchangeHeaders, simMode)
+		exchange, e = MakeTradingExchange(exchangeName, primaryAPIKeys, exchangeParams, exchangeHeaders, simMode)
 		if e != nil {
 			return nil, e
 		}
@@ -213,7 +244,7 @@ func makeMirrorStrategy(
 			return nil, fmt.Errorf("unable to create strategyMirrorTradeTriggerExistsQuery: %s", e)
 		}
 	} else {
-		exchange, e = MakeExchange(config.Exchange, simMode)
+		exchange, e = MakeExchange(exchangeName, simMode)
 		if e != nil {
 			return nil, e
 		}
@@ -246,10 +277,10 @@ func makeMirrorStrategy(
 		backingFillTracker = MakeFillTracker(backingPair, multithreading.MakeThreadTracker(), exchange, 0, 0, backingLastCursor)
 		backingFillTracker.RegisterHandler(MakeFillLogger())
 		backingAssetDisplayFn := model.MakePassthroughAssetDisplayFn()
-		if config.Exchange == "sdex" {
+		if exchangeName == "sdex" {
 			return nil, fmt.Errorf("we cannot mirror trades from SDEX for now (programmer: need to create sdexAssetMap to inject into the backingAssetDisplayFn)")
 		}
-		fillDBWriter := MakeFillDBWriter(db, backingAssetDisplayFn, config.Exchange, config.BackingDbOverrideAccountID)
+		fillDBWriter := MakeFillDBWriter(db, backingAssetDisplayFn, exchangeName, config.BackingDbOverrideAccountID)
 		backingFillTracker.RegisterHandler(fillDBWriter)
 	}
 
@@ -282,6 +313,26 @@ func makeMirrorStrategy(
 	backingConstraints := exchange.GetOrderConstraints(backingPair)
 	log.Printf("primaryPair='%s', primaryConstraints=%s\n", pair, primaryConstraints)
 	log.Printf("backingPair='%s', backingConstraints=%s\n", backingPair, backingConstraints)
+	if config.PerLevelMaxVolume != nil {
+		if *config.PerLevelMaxVolume <= 0.0 {
+			return nil, fmt.Errorf("invalid mirror strategy config file, if you set a value for PER_LEVEL_MAX_VOLUME it needs to be > 0.0, leaving it unset does not constrain the level size")
+		}
+		if *config.PerLevelMaxVolume < backingConstraints.MinBaseVolume.AsFloat() {
+			return nil, fmt.Errorf("PER_LEVEL_MAX_VOLUME (%f) cannot be less than minBaseVolume allowed on backing exchange (%s)", *config.PerLevelMaxVolume, backingConstraints.MinBaseVolume.AsString())
+		}
+		// PER_LEVEL_MAX_VOLUME and MAX_ORDER_BASE_CAP both cap the base volume of a level so the lower of the two applies
+		if config.MaxOrderBaseCap == nil || *config.PerLevelMaxVolume < *config.MaxOrderBaseCap {
+			config.MaxOrderBaseCap = config.PerLevelMaxVolume
+		}
+	}
+	if config.TotalMaxVolume != nil {
+		if *config.TotalMaxVolume < backingConstraints.MinBaseVolume.AsFloat() {
+			return nil, fmt.Errorf("TOTAL_MAX_VOLUME (%f) cannot be less than minBaseVolume allowed on backing exchange (%s)", *config.TotalMaxVolume, backingConstraints.MinBaseVolume.AsString())
+		}
+		if *config.TotalMaxVolume <= 0.0 {
+			return nil, fmt.Errorf("invalid mirror strategy config file, if you set a value for TOTAL_MAX_VOLUME it needs to be > 0.0, leaving it unset does not constrain the total volume")
+		}
+	}
 	if config.MaxOrderBaseCap != nil {
 		if *config.MaxOrderBaseCap < backingConstraints.MinBaseVolume.AsFloat() {
 			utils.PrintErrorHintf("MAX_ORDER_BASE_CAP (%f) cannot be less than minBaseVolume allowed on backing exchange (%s)", *config.MaxOrderBaseCap, backingConstraints.MinBaseVolume.AsString())
@@ -296,12 +347,59 @@ func makeMirrorStrategy(
 	// insert into database if needed
 	var backingMarketID string
 	if db != nil {
-		backingMarketID, e = FetchOrRegisterMarketID(db, config.Exchange, config.ExchangeBase, config.ExchangeQuote)
+		backingMarketID, e = FetchOrRegisterMarketID(db, exchangeName, config.ExchangeBase, config.ExchangeQuote)
 		if e != nil {
 			return nil, fmt.Errorf("error calling FetchOrRegisterMarketID: %s", e)
 		}
 	}
 
+	var hc *hedgingCoordinator
+	if config.OffsetNettingGroup != "" {
+		if !config.OffsetTrades {
+			return nil, fmt.Errorf("OFFSET_NETTING_GROUP can only be set in the mirror strategy config file when OFFSET_TRADES is enabled")
+		}
+		hc, e = makeHedgingCoordinator(db, config.OffsetNettingGroup, backingMarketID, backingConstraints)
+		if e != nil {
+			return nil, fmt.Errorf("unable to make hedging coordinator: %s", e)
+		}
+		log.Printf("netting offset trades with other bots in netting group '%s' on backing market '%s'\n", config.OffsetNettingGroup, backingMarketID)
+	}
+
+	var takerFee float64
+	if config.FeeAwareSpread {
+		feeFetcher, ok := exchange.(api.TradingFeeFetcher)
+		if !ok {
+			return nil, fmt.Errorf("FEE_AWARE_SPREAD is enabled in the mirror strategy config file but exchange '%s' cannot fetch trading fees", exchangeName)
+		}
+		takerFee, e = feeFetcher.GetTakerFee(backingPair)
+		if e != nil {
+			return nil, fmt.Errorf("unable to fetch taker fee from backing exchange for FEE_AWARE_SPREAD: %s", e)
+		}
+		if takerFee < 0.0 || takerFee >= 1.0 {
+			return nil, fmt.Errorf("invalid taker fee (%f) fetched from backing exchange, needs to be in the range [0.0, 1.0)", takerFee)
+		}
+		log.Printf("adjusting mirrored levels for the taker fee of the backing exchange: %f\n", takerFee)
+	}
+	if config.SdexNetworkCostPerOfferQuote < 0.0 {
+		return nil, fmt.Errorf("SDEX_NETWORK_COST_PER_OFFER_QUOTE in the mirror strategy config file cannot be negative")
+	}
+
+	if config.OffsetFlushIntervalSeconds < 0 {
+		return nil, fmt.Errorf("OFFSET_FLUSH_INTERVAL_SECONDS in the mirror strategy config file cannot be negative, use 0 to disable it")
+	}
+	if config.OffsetFlushMinBaseSurplus < 0.0 {
+		return nil, fmt.Errorf("OFFSET_FLUSH_MIN_BASE_SURPLUS in the mirror strategy config file cannot be negative, use 0.0 to disable it")
+	}
+	if config.OffsetFlushIntervalSeconds > 0 || config.OffsetFlushMinBaseSurplus > 0.0 {
+		if !config.OffsetTrades {
+			return nil, fmt.Errorf("OFFSET_FLUSH_INTERVAL_SECONDS and OFFSET_FLUSH_MIN_BASE_SURPLUS can only be set in the mirror strategy config file when OFFSET_TRADES is enabled")
+		}
+		if config.OffsetNettingGroup != "" {
+			return nil, fmt.Errorf("OFFSET_FLUSH_INTERVAL_SECONDS and OFFSET_FLUSH_MIN_BASE_SURPLUS cannot be used together with OFFSET_NETTING_GROUP in the mirror strategy config file")
+		}
+		log.Printf("aggregating offset trades, flushing every %d seconds (0 = disabled) or when the surplus reaches %f base units (0.0 = disabled)\n", config.OffsetFlushIntervalSeconds, config.OffsetFlushMinBaseSurplus)
+	}
+
 	// trigger fill tracking on backing exchange at creation time
 	if backingFillTracker != nil {
 		trades, e := backingFillTracker.FillTrackSingleIteration()
@@ -314,11 +412,59 @@ func makeMirrorStrategy(
 		log.Printf("backingFillTracker was nil so not loading trades at creation time\n")
 	}
 
+	var router *offsetRouter
+	if len(config.OffsetRoutes) > 0 || len(config.OffsetVenues) > 0 {
+		if !config.OffsetTrades {
+			return nil, fmt.Errorf("OFFSET_VENUES and OFFSET_ROUTES can only be set in the mirror strategy config file when OFFSET_TRADES is enabled")
+		}
+		if config.OffsetNettingGroup != "" {
+			return nil, fmt.Errorf("OFFSET_VENUES and OFFSET_ROUTES cannot be used together with OFFSET_NETTING_GROUP in the mirror strategy config file")
+		}
+		router, e = makeOffsetRouter(config.OffsetVenues, config.OffsetRoutes, &exchangeOffsetVenue{exchange: exchange, pair: backingPair}, simMode)
+		if e != nil {
+			return nil, fmt.Errorf("unable to make offset router: %s", e)
+		}
+		log.Printf("routing offset trades with %d routes across %d additional venues\n", len(config.OffsetRoutes), len(config.OffsetVenues))
+	}
+
+	var backingVenues []*mirrorBackingVenue
+	if len(config.Exchange) > 1 {
+		if config.OffsetNettingGroup != "" || len(config.OffsetVenues) > 0 || len(config.OffsetRoutes) > 0 {
+			return nil, fmt.Errorf("EXCHANGE cannot be a list in the mirror strategy config file when OFFSET_NETTING_GROUP, OFFSET_VENUES, or OFFSET_ROUTES are set")
+		}
+		backingVenues, e = makeAdditionalBackingVenues(config, exchangeAPIKeys, simMode)
+		if e != nil {
+			return nil, e
+		}
+		backingVenues = append([]*mirrorBackingVenue{&mirrorBackingVenue{name: exchangeName, exchange: exchange, pair: backingPair}}, backingVenues...)
+		log.Printf("mirroring the consolidated orderbook of %d backing exchanges: %v\n", len(backingVenues), config.Exchange)
+	}
+
+	var fxRateFeed api.PriceFeed
+	if config.FxRateFeedType != "" || config.FxRateFeedURL != "" {
+		if config.FxRateFeedType == "" || config.FxRateFeedURL == "" {
+			return nil, fmt.Errorf("both FX_RATE_FEED_TYPE and FX_RATE_FEED_URL need to be set in the mirror strategy config file to convert prices to a different backing pair")
+		}
+		fxRateFeed, e = MakePriceFeed(config.FxRateFeedType, config.FxRateFeedURL)
+		if e != nil {
+			return nil, fmt.Errorf("unable to make fx rate feed: %s", e)
+		}
+		log.Printf("converting prices between backing quote asset '%s' and primary quote asset using the '%s' fx rate feed '%s'\n", config.ExchangeQuote, config.FxRateFeedType, config.FxRateFeedURL)
+	} else if config.FxRateMaxSlippage != 0.0 {
+		return nil, fmt.Errorf("FX_RATE_MAX_SLIPPAGE can only be set in the mirror strategy config file when FX_RATE_FEED_TYPE and FX_RATE_FEED_URL are set")
+	}
+	if config.FxRateMaxSlippage < 0.0 {
+		return nil, fmt.Errorf("FX_RATE_MAX_SLIPPAGE in the mirror strategy config file cannot be negative, use 0.0 to disable it")
+	}
+
 	if config.OrderbookDepth > int(maxOrderbookDepth) {
 		return nil, fmt.Errorf("cannot construct the mirrorStrategy, ORDERBOOK_DEPTH config param should not exceed %d", maxOrderbookDepth)
 	}
+	if config.DepthAggregationBandBps < 0.0 {
+		return nil, fmt.Errorf("cannot construct the mirrorStrategy, DEPTH_AGGREGATION_BAND_BPS config param cannot be negative, use 0 to disable it")
+	}
 
-	return &mirrorStrategy{
+	s := &mirrorStrategy{
 		sdex:                                  sdex,
 		ieif:                                  ieif,
 		baseAsset:                             baseAsset,
@@ -332,9 +478,13 @@ func makeMirrorStrategy(
 		strategyMirrorTradeTriggerExistsQuery: strategyMirrorTradeTriggerExistsQuery,
 		orderbookDepth:                        config.OrderbookDepth,
 		perLevelSpread:                        config.PerLevelSpread,
+		takerFee:                              takerFee,
+		networkCostPerOfferQuote:              config.SdexNetworkCostPerOfferQuote,
+		depthAggregationBand:                  config.DepthAggregationBandBps / 10000.0,
 		bidVolumeDivideBy:                     bidVolumeDivideBy,
 		askVolumeDivideBy:                     askVolumeDivideBy,
 		maybeMaxOrderBaseCap:                  config.MaxOrderBaseCap,
+		maybeTotalMaxVolume:                   config.TotalMaxVolume,
 		exchange:                              exchange,
 		offsetTrades:                          config.OffsetTrades,
 		mutex:                                 &sync.Mutex{},
@@ -342,8 +492,69 @@ func makeMirrorStrategy(
 			model.OrderActionBuy:  makeAssetSurplus(),
 			model.OrderActionSell: makeAssetSurplus(),
 		},
-		db: db,
-	}, nil
+		hedgingCoordinator:        hc,
+		offsetFlushInterval:       time.Duration(config.OffsetFlushIntervalSeconds) * time.Second,
+		offsetFlushMinBaseSurplus: config.OffsetFlushMinBaseSurplus,
+		pendingOffsets: map[model.OrderAction]*pendingOffset{
+			model.OrderActionBuy:  &pendingOffset{},
+			model.OrderActionSell: &pendingOffset{},
+		},
+		offsetRouter:      router,
+		fxRateFeed:        fxRateFeed,
+		fxRateMaxSlippage: config.FxRateMaxSlippage,
+		fxRate:            1.0,
+		backingVenues:     backingVenues,
+		db:                db,
+		clock:             clock,
+	}
+	if s.isOffsetAggregated() {
+		e = s.loadPendingOffsets(pair)
+		if e != nil {
+			return nil, fmt.Errorf("unable to load pending offsets: %s", e)
+		}
+	}
+	return s, nil
+}
+
+// loadPendingOffsets restores the trades that were still being aggregated when the bot stopped, since the fill tracker does not deliver them again
+func (s *mirrorStrategy) loadPendingOffsets(pair *model.TradingPair) error {
+	query, e := queries.MakeStrategyMirrorPendingOffsets(s.db, s.marketID)
+	if e != nil {
+		return fmt.Errorf("unable to make strategyMirrorPendingOffsets query: %s", e)
+	}
+	queryResult, e := query.QueryRow()
+	if e != nil {
+		return fmt.Errorf("unable to fetch pending offsets: %s", e)
+	}
+	pendingTrades, ok := queryResult.([]queries.PendingOffsetTrade)
+	if !ok {
+		return fmt.Errorf("unable to convert result of strategyMirrorPendingOffsets query to []queries.PendingOffsetTrade: %v (type=%T)", queryResult, queryResult)
+	}
+
+	for _, p := range pendingTrades {
+		trade := model.Trade{
+			Order: model.Order{
+				Pair:        pair,
+				OrderAction: model.OrderActionFromString(p.Action),
+				OrderType:   model.OrderTypeLimit,
+				Price:       model.NumberFromFloat(p.Price, s.primaryConstraints.PricePrecision),
+				Volume:      model.NumberFromFloat(p.BaseVolume, s.primaryConstraints.VolumePrecision),
+			},
+			TransactionID: model.MakeTransactionID(p.TxID),
+		}
+		newOrderAction := trade.OrderAction.Reverse()
+		pending := s.pendingOffsets[newOrderAction]
+		if len(pending.trades) == 0 {
+			pending.since = p.DateAddedUTC
+		}
+		pending.trades = append(pending.trades, trade)
+		s.baseSurplus[newOrderAction].total = s.baseSurplus[newOrderAction].total.Add(*trade.Volume)
+	}
+	log.Printf("loaded %d pending offset trades (baseSurplusBuy=%f, baseSurplusSell=%f)\n",
+		len(pendingTrades),
+		s.baseSurplus[model.OrderActionBuy].total.AsFloat(),
+		s.baseSurplus[model.OrderActionSell].total.AsFloat())
+	return nil
 }
 
 // PruneExistingOffers deletes any extra offers
@@ -353,15 +564,34 @@ func (s *mirrorStrategy) PruneExistingOffers(buyingAOffers []hProtocol.Offer, se
 
 // PreUpdate changes the strategy's state in prepration for the update
 func (s *mirrorStrategy) PreUpdate(maxAssetA float64, maxAssetB float64, trustA float64, trustB float64) error {
+	// the fx rate is fetched once per update so the levels and the balance coordinators use the same conversion
+	fxRate, e := s.fetchFxRate()
+	if e != nil {
+		return fmt.Errorf("error while fetching fx rate: %s", e)
+	}
+	s.mutex.Lock()
+	s.fxRate = fxRate
+	s.mutex.Unlock()
+
 	// we don't care about or use balance coordinators if we are not offsetting trades
 	if !s.offsetTrades {
 		return nil
 	}
 
+	if s.isOffsetAggregated() {
+		e := s.flushPendingOffsets()
+		if e != nil {
+			// the surplus stays pending and is retried on the next update
+			log.Printf("error while flushing pending offsets, continuing: %s\n", e)
+		}
+	}
+
 	baseBackingBalance, quoteBackingBalance, e := s.getBackingBalances()
 	if e != nil {
 		return fmt.Errorf("error while fetching backing balances: %s", e)
 	}
+	// levels are priced in primary quote units so the backing quote balance needs to be expressed in the same units
+	quoteBackingBalance = quoteBackingBalance.Scale(fxRate)
 
 	// buyOnPrimaryBalanceCoordinator is buying on the primary exchange and selling on the backing exchange
 	// primary asset being sold here is quote and backing asset being sold is base, so constrain on those
@@ -391,6 +621,10 @@ func (s *mirrorStrategy) PreUpdate(maxAssetA float64, maxAssetB float64, trustA
 }
 
 func (s *mirrorStrategy) getBackingBalances() (*model.Number /*baseBackingBalance*/, *model.Number /*quoteBackingBalance*/, error) {
+	if len(s.backingVenues) > 0 {
+		return s.getMaxBackingBalances()
+	}
+
 	balanceMap, e := s.exchange.GetAccountBalances([]interface{}{s.backingPair.Base, s.backingPair.Quote})
 	if e != nil {
 		return nil, nil, fmt.Errorf("unable to fetch balances for assets: %s", e)
@@ -410,6 +644,65 @@ func (s *mirrorStrategy) getBackingBalances() (*model.Number /*baseBackingBalanc
 	return &baseBalance, &quoteBalance, nil
 }
 
+// fetchFxRate returns the price of one unit of the backing quote asset in units of the primary quote asset
+func (s *mirrorStrategy) fetchFxRate() (float64, error) {
+	if s.fxRateFeed == nil {
+		return 1.0, nil
+	}
+
+	fxRate, e := s.fxRateFeed.GetPrice()
+	if e != nil {
+		return 0, fmt.Errorf("unable to fetch price from fx rate feed: %s", e)
+	}
+	if fxRate <= 0.0 {
+		return 0, fmt.Errorf("invalid fx rate (%f) fetched from fx rate feed, needs to be > 0.0", fxRate)
+	}
+	return fxRate, nil
+}
+
+// fxConvertedOffsetPrice converts the price of a trade on the primary exchange to the price of the offset order on the backing exchange.
+// It returns an error when the fx rate has moved against the offset by more than maxSlippage since the level was quoted at quotedFxRate,
+// because the offset would then lose more than the spread of the level. A maxSlippage of 0.0 disables this check.
+func fxConvertedOffsetPrice(tradePrice float64, quotedFxRate float64, currentFxRate float64, newOrderAction model.OrderAction, maxSlippage float64) (float64, error) {
+	// selling on the backing exchange receives fewer backing quote units when the fx rate goes up, buying costs more when it goes down
+	slippage := currentFxRate/quotedFxRate - 1.0
+	if newOrderAction.IsBuy() {
+		slippage = quotedFxRate/currentFxRate - 1.0
+	}
+	if maxSlippage > 0.0 && slippage > maxSlippage {
+		return 0, fmt.Errorf("fx rate moved from %f to %f since the level was quoted, slippage of %f for newOrderAction=%s exceeds FX_RATE_MAX_SLIPPAGE (%f)",
+			quotedFxRate, currentFxRate, slippage, newOrderAction.String(), maxSlippage)
+	}
+	return tradePrice / currentFxRate, nil
+}
+
+// offsetPrice returns the price of the order on the backing exchange that offsets the trade, converted through the fx rate feed if needed,
+// along with the fx rate used for the conversion (nil when there is no fx rate feed) so it can be recorded with the trade
+func (s *mirrorStrategy) offsetPrice(trade model.Trade, newOrderAction model.OrderAction) (*model.Number, *float64, error) {
+	if s.fxRateFeed == nil {
+		return model.NumberByCappingPrecision(trade.Price, s.backingConstraints.PricePrecision), nil, nil
+	}
+
+	currentFxRate, e := s.fetchFxRate()
+	if e != nil {
+		return nil, nil, e
+	}
+	price, e := fxConvertedOffsetPrice(trade.Price.AsFloat(), s.fxRate, currentFxRate, newOrderAction, s.fxRateMaxSlippage)
+	if e != nil {
+		return nil, nil, e
+	}
+	return model.NumberFromFloat(price, s.backingConstraints.PricePrecision), &currentFxRate, nil
+}
+
+// quotedFxRate returns the fx rate used to quote the current levels, nil when there is no fx rate feed
+func (s *mirrorStrategy) quotedFxRate() *float64 {
+	if s.fxRateFeed == nil {
+		return nil
+	}
+	fxRate := s.fxRate
+	return &fxRate
+}
+
 // UpdateWithOps builds the operations we want performed on the account
 func (s *mirrorStrategy) UpdateWithOps(
 	buyingAOffers []hProtocol.Offer,
@@ -417,37 +710,74 @@ func (s *mirrorStrategy) UpdateWithOps(
 ) ([]build.TransactionMutator, error) {
 	// we want to fetch a few extra orders to account for potentially filtering out orders that don't meet the min base volume requirements
 	ordersToFetch := int32(s.orderbookDepth + numOrdersBufferMinVolumeFilter)
-	ob, e := s.exchange.GetOrderBook(s.backingPair, ordersToFetch)
-	if e != nil {
-		return nil, e
+	if s.depthAggregationBand > 0.0 {
+		ordersToFetch = int32(s.orderbookDepth*depthAggregationFetchMultiplier + numOrdersBufferMinVolumeFilter)
+	}
+	var bids, asks []model.Order
+	if len(s.backingVenues) > 0 {
+		var e error
+		bids, asks, e = s.fetchConsolidatedOrderBook(ordersToFetch)
+		if e != nil {
+			return nil, e
+		}
+	} else {
+		ob, e := s.exchange.GetOrderBook(s.backingPair, ordersToFetch)
+		if e != nil {
+			return nil, e
+		}
+		bids = ob.Bids()
+		asks = ob.Asks()
 	}
 
 	// limit bids and asks to max 50 operations each because of Stellar's limit of 100 ops/tx
-	bids := ob.Bids()
-	asks := ob.Asks()
 	log.Printf("backing orderbook before transformations, including %d additional buffer orders:\n", numOrdersBufferMinVolumeFilter)
 	printBidsAndAsks(bids, asks)
 
+	if s.depthAggregationBand > 0.0 {
+		// combine thin levels so we don't place dust-sized offers
+		bids = aggregateOrdersByPriceBand(bids, s.depthAggregationBand)
+		asks = aggregateOrdersByPriceBand(asks, s.depthAggregationBand)
+		log.Printf("backing orderbook after aggregating depth into price bands of %f bps:\n", s.depthAggregationBand*10000.0)
+		printBidsAndAsks(bids, asks)
+	}
+
+	s.mutex.Lock()
+	fxRate := s.fxRate
+	s.mutex.Unlock()
+	if s.fxRateFeed != nil {
+		log.Printf("converting backing orderbook prices to primary quote units using fx rate %f\n", fxRate)
+	}
+
 	// we modify the bids and ask to represent the new orders to place so we reduce unnecessary memory allocations
 	if s.bidVolumeDivideBy == -1.0 {
 		bids = []model.Order{}
 	} else {
-		transformOrders(bids, (1 - s.perLevelSpread), (1.0 / s.bidVolumeDivideBy), s.maybeMaxOrderBaseCap)
+		transformOrders(bids, (1-s.perLevelSpread)*fxRate, (1.0 / s.bidVolumeDivideBy), s.maybeMaxOrderBaseCap)
+		bids = adjustOrdersForCosts(bids, true, s.takerFee, s.networkCostPerOfferQuote)
 		// only place orders that we can fulfill on the backing exchange, to reduce surpluses needing offsetting
 		bids = filterOrdersByVolume(bids, s.backingConstraints.MinBaseVolume.AsFloat())
 		if len(bids) > s.orderbookDepth {
 			bids = bids[:s.orderbookDepth]
 		}
+		if s.maybeTotalMaxVolume != nil {
+			bids = capOrdersByTotalVolume(bids, *s.maybeTotalMaxVolume)
+			bids = filterOrdersByVolume(bids, s.backingConstraints.MinBaseVolume.AsFloat())
+		}
 	}
 	if s.askVolumeDivideBy == -1.0 {
 		asks = []model.Order{}
 	} else {
-		transformOrders(asks, (1 + s.perLevelSpread), (1.0 / s.askVolumeDivideBy), s.maybeMaxOrderBaseCap)
+		transformOrders(asks, (1+s.perLevelSpread)*fxRate, (1.0 / s.askVolumeDivideBy), s.maybeMaxOrderBaseCap)
+		asks = adjustOrdersForCosts(asks, false, s.takerFee, s.networkCostPerOfferQuote)
 		// only place orders that we can fulfill on the backing exchange, to reduce surpluses needing offsetting
 		asks = filterOrdersByVolume(asks, s.backingConstraints.MinBaseVolume.AsFloat())
 		if len(asks) > s.orderbookDepth {
 			asks = asks[:s.orderbookDepth]
 		}
+		if s.maybeTotalMaxVolume != nil {
+			asks = capOrdersByTotalVolume(asks, *s.maybeTotalMaxVolume)
+			asks = filterOrdersByVolume(asks, s.backingConstraints.MinBaseVolume.AsFloat())
+		}
 	}
 	log.Printf("new orders to be placed (after transforming and filtering orders from backing exchange):\n")
 	printBidsAndAsks(bids, asks)
@@ -520,6 +850,97 @@ func transformOrders(orders []model.Order, priceMultiplier float64, volumeMultip
 	}
 }
 
+// aggregateOrdersByPriceBand combines the orders (sorted from the best price) into bands of the given width relative to the best price.
+// Each band has the total volume of its orders at the worst price in the band, so the full volume can be offset at or better than that price.
+func aggregateOrdersByPriceBand(orders []model.Order, bandWidth float64) []model.Order {
+	if len(orders) == 0 {
+		return orders
+	}
+
+	bestPrice := orders[0].Price.AsFloat()
+	ret := []model.Order{}
+	lastBand := -1
+	for _, o := range orders {
+		band := int(math.Abs(o.Price.AsFloat()-bestPrice) / (bestPrice * bandWidth))
+		if len(ret) == 0 || band != lastBand {
+			ret = append(ret, model.Order{
+				Pair:        o.Pair,
+				OrderAction: o.OrderAction,
+				OrderType:   o.OrderType,
+				Price:       o.Price,
+				Volume:      o.Volume,
+				Timestamp:   o.Timestamp,
+			})
+			lastBand = band
+			continue
+		}
+
+		// orders are sorted from the best price so this order has the worst price in the band so far
+		last := &ret[len(ret)-1]
+		last.Price = o.Price
+		last.Volume = last.Volume.Add(*o.Volume)
+	}
+	return ret
+}
+
+// adjustOrdersForCosts moves the price of each level away from the backing price by the taker fee we pay to offset a fill on the backing
+// exchange and the network cost of the offer amortized over its volume, so a fully offset level is never executed at a net loss.
+// Bids that end up with a non-positive price are dropped.
+func adjustOrdersForCosts(orders []model.Order, isBid bool, takerFee float64, networkCostPerOfferQuote float64) []model.Order {
+	if takerFee == 0.0 && networkCostPerOfferQuote == 0.0 {
+		return orders
+	}
+
+	ret := []model.Order{}
+	for _, o := range orders {
+		price := o.Price.AsFloat()
+		networkCostPerUnit := 0.0
+		if o.Volume.AsFloat() > 0 {
+			networkCostPerUnit = networkCostPerOfferQuote / o.Volume.AsFloat()
+		}
+
+		if isBid {
+			// we sell on the backing exchange and receive the price less the fee
+			price = price*(1-takerFee) - networkCostPerUnit
+		} else {
+			// we buy on the backing exchange and need to pay for the fee on top of the price
+			price = price/(1-takerFee) + networkCostPerUnit
+		}
+		if price <= 0.0 {
+			log.Printf("dropping bid because it has a non-positive price (%f) after adjusting for costs (takerFee=%f, networkCostPerOfferQuote=%f, volume=%s)\n",
+				price, takerFee, networkCostPerOfferQuote, o.Volume.AsString())
+			continue
+		}
+
+		// round bids down and asks up so the levels never move past the break-even price because of precision
+		adjustedPrice := model.NumberFromFloatRoundTruncate(price, o.Price.Precision())
+		if !isBid && adjustedPrice.AsFloat() < price {
+			adjustedPrice = adjustedPrice.Add(*model.NumberFromFloat(math.Pow(10, -float64(o.Price.Precision())), o.Price.Precision()))
+		}
+		*o.Price = *adjustedPrice
+		ret = append(ret, o)
+	}
+	return ret
+}
+
+// capOrdersByTotalVolume keeps the orders (sorted from the best price) until their total base volume reaches maxTotalVolume, reducing the
+// volume of the last order that is kept so the total never exceeds maxTotalVolume
+func capOrdersByTotalVolume(orders []model.Order, maxTotalVolume float64) []model.Order {
+	ret := []model.Order{}
+	remaining := maxTotalVolume
+	for _, o := range orders {
+		if remaining <= 0.0 {
+			break
+		}
+		if o.Volume.AsFloat() > remaining {
+			*o.Volume = *model.NumberFromFloatRoundTruncate(remaining, o.Volume.Precision())
+		}
+		remaining -= o.Volume.AsFloat()
+		ret = append(ret, o)
+	}
+	return ret
+}
+
 func filterOrdersByVolume(orders []model.Order, minBaseVolume float64) []model.Order {
 	ret := []model.Order{}
 	for _, o := range orders {
@@ -809,14 +1230,93 @@ func (s *mirrorStrategy) HandleFill(trade model.Trade) error {
 		return nil
 	}
 
+	if s.hedgingCoordinator != nil {
+		return s.handleFillNetted(trade)
+	}
+
 	newOrderAction := trade.OrderAction.Reverse()
+	if s.isOffsetAggregated() {
+		// persist the trade before it counts towards the surplus so it is not lost when the bot restarts before it is offset
+		e = s.insertPendingOffset(trade, s.clock.Now())
+		if e != nil {
+			return fmt.Errorf("unable to persist pending offset for trade with txID=%s: %s", trade.TransactionID.String(), e)
+		}
+	}
 	// increase the baseSurplus for the additional amount that needs to be offset because of the incoming trade
 	s.baseSurplus[newOrderAction].total = s.baseSurplus[newOrderAction].total.Add(*trade.Volume)
 
+	if s.isOffsetAggregated() {
+		pending := s.pendingOffsets[newOrderAction]
+		if len(pending.trades) == 0 {
+			pending.since = s.clock.Now()
+		}
+		pending.trades = append(pending.trades, trade)
+
+		if !s.shouldFlushOffset(newOrderAction, s.clock.Now()) {
+			log.Printf("offset-deferred | tradeID=%s | tradeBaseAmt=%f | tradePriceQuote=%f | newOrderAction=%s | baseSurplusTotal=%f | baseSurplusCommitted=%f | numPendingTrades=%d\n",
+				trade.TransactionID.String(),
+				trade.Volume.AsFloat(),
+				trade.Price.AsFloat(),
+				newOrderAction.String(),
+				s.baseSurplus[newOrderAction].total.AsFloat(),
+				s.baseSurplus[newOrderAction].committed.AsFloat(),
+				len(pending.trades))
+			return nil
+		}
+	}
+	return s.offsetSurplus(trade, newOrderAction)
+}
+
+// isOffsetAggregated returns true when fills are aggregated into fewer, larger offset orders on the backing exchange
+func (s *mirrorStrategy) isOffsetAggregated() bool {
+	return s.offsetFlushInterval > 0 || s.offsetFlushMinBaseSurplus > 0.0
+}
+
+// shouldFlushOffset returns true when the pending surplus for newOrderAction should be offset now
+func (s *mirrorStrategy) shouldFlushOffset(newOrderAction model.OrderAction, now time.Time) bool {
+	pending := s.pendingOffsets[newOrderAction]
+	if len(pending.trades) == 0 {
+		return false
+	}
+
+	uncommittedBase := s.baseSurplus[newOrderAction].total.Subtract(*s.baseSurplus[newOrderAction].committed)
+	if s.offsetFlushMinBaseSurplus > 0.0 && uncommittedBase.AsFloat() >= s.offsetFlushMinBaseSurplus {
+		return true
+	}
+	return s.offsetFlushInterval > 0 && now.Sub(pending.since) >= s.offsetFlushInterval
+}
+
+// flushPendingOffsets offsets any aggregated surplus whose flush window has elapsed, so surplus does not sit around when there are no new fills
+func (s *mirrorStrategy) flushPendingOffsets() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, newOrderAction := range []model.OrderAction{model.OrderActionBuy, model.OrderActionSell} {
+		if !s.shouldFlushOffset(newOrderAction, s.clock.Now()) {
+			continue
+		}
+
+		pendingTrades := s.pendingOffsets[newOrderAction].trades
+		// use the most recent pending trade to price the offset order
+		e := s.offsetSurplus(pendingTrades[len(pendingTrades)-1], newOrderAction)
+		if e != nil {
+			return fmt.Errorf("unable to flush pending offset for newOrderAction=%s: %s", newOrderAction.String(), e)
+		}
+	}
+	return nil
+}
+
+// offsetSurplus places an order on the backing exchange for the uncommitted baseSurplus of newOrderAction, priced at the trade's price
+func (s *mirrorStrategy) offsetSurplus(trade model.Trade, newOrderAction model.OrderAction) error {
 	newVolume, ok := s.baseVolumeToOffset(trade, newOrderAction)
 	if !ok {
 		return nil
 	}
+	// the surplus stays uncommitted when we cannot price the offset so it is retried with the next fill or flush
+	price, fxRate, e := s.offsetPrice(trade, newOrderAction)
+	if e != nil {
+		return fmt.Errorf("unable to price offset for trade with txID=%s: %s", trade.TransactionID.String(), e)
+	}
 	// commit the newVolume that we are trying to use so the next handler does not double-count this amount
 	s.baseSurplus[newOrderAction].committed = s.baseSurplus[newOrderAction].committed.Add(*newVolume)
 
@@ -824,7 +1324,7 @@ func (s *mirrorStrategy) HandleFill(trade model.Trade) error {
 		Pair:        s.backingPair, // we want to offset trades on the backing exchange so use the backing exchange's trading pair
 		OrderAction: newOrderAction,
 		OrderType:   model.OrderTypeLimit,
-		Price:       model.NumberByCappingPrecision(trade.Price, s.backingConstraints.PricePrecision),
+		Price:       price,
 		Volume:      newVolume,
 		Timestamp:   nil,
 	}
@@ -841,23 +1341,48 @@ func (s *mirrorStrategy) HandleFill(trade model.Trade) error {
 		newOrder.Volume.Multiply(*newOrder.Price).AsFloat(),
 		newOrder.Price.AsFloat())
 
-	// when offsetting trades we always submit as a taker order so use api.SubmitModeBoth
-	transactionID, e := s.exchange.AddOrder(&newOrder, api.SubmitModeBoth)
+	var transactionID *model.TransactionID
+	if s.offsetRouter != nil {
+		transactionID, e = s.offsetRouter.placeOffset(newOrderAction, newOrder.Price, newOrder.Volume, s.clock.Now())
+	} else if len(s.backingVenues) > 0 {
+		transactionID, e = s.placeBestPriceOffset(newOrderAction, newOrder.Price, newOrder.Volume)
+	} else {
+		// when offsetting trades we always submit as a taker order so use api.SubmitModeBoth
+		transactionID, e = s.exchange.AddOrder(&newOrder, api.SubmitModeBoth)
+	}
 	if e != nil {
 		return fmt.Errorf("error when offsetting trade (newOrder=%s): %s", newOrder, e)
 	}
 	if transactionID == nil {
 		return fmt.Errorf("error when offsetting trade (newOrder=%s): transactionID was <nil>", newOrder)
 	}
+	// the order is placed so update the baseSurplus before anything else can fail, otherwise the next fill or flush offsets the same trades again
+	s.baseSurplus[newOrderAction].total = s.baseSurplus[newOrderAction].total.Subtract(*newVolume)
+	s.baseSurplus[newOrderAction].committed = s.baseSurplus[newOrderAction].committed.Subtract(*newVolume)
+	pendingTrades := s.pendingOffsets[newOrderAction].trades
+	s.pendingOffsets[newOrderAction].trades = nil
+	if len(pendingTrades) > 0 {
+		e = s.deletePendingOffsets(newOrderAction)
+		if e != nil {
+			return fmt.Errorf("error when deleting pending offsets that were offset by txID=%s (newOrder=%s): %s", transactionID.String(), newOrder, e)
+		}
+	}
+
 	// insert into the db immediately after placing order on backing exchange
-	e = s.insertTradeTrigger(trade.TransactionID.String(), transactionID.String())
+	e = s.insertTradeTrigger(trade.TransactionID.String(), transactionID.String(), fxRate)
 	if e != nil {
 		return fmt.Errorf("error when inserting trade trigger with txID=%s (newOrder=%s) (PK dupes not allowed): %s", transactionID.String(), newOrder, e)
 	}
-
-	// update the baseSurplus on success
-	s.baseSurplus[newOrderAction].total = s.baseSurplus[newOrderAction].total.Subtract(*newVolume)
-	s.baseSurplus[newOrderAction].committed = s.baseSurplus[newOrderAction].committed.Subtract(*newVolume)
+	// all aggregated trades were offset by this order so mark them as handled as well
+	for _, pendingTrade := range pendingTrades {
+		if pendingTrade.TransactionID.String() == trade.TransactionID.String() {
+			continue
+		}
+		e = s.insertTradeTrigger(pendingTrade.TransactionID.String(), transactionID.String(), fxRate)
+		if e != nil {
+			return fmt.Errorf("error when inserting trade trigger for pending trade with txID=%s (newOrder=%s) (PK dupes not allowed): %s", transactionID.String(), newOrder, e)
+		}
+	}
 
 	log.Printf("offset-success | tradeID=%s | tradeBaseAmt=%f | tradeQuoteAmt=%f | tradePriceQuote=%f | newOrderAction=%s | baseSurplusTotal=%f | baseSurplusCommitted=%f | minBaseVolume=%f | newOrderBaseAmt=%f | newOrderQuoteAmt=%f | newOrderPriceQuote=%f | transactionID=%s\n",
 		trade.TransactionID.String(),
@@ -883,12 +1408,140 @@ func (s *mirrorStrategy) HandleFill(trade model.Trade) error {
 	return nil
 }
 
-func (s *mirrorStrategy) insertTradeTrigger(primaryTxID string, backingTxID string) error {
+// handleFillNetted offsets the trade via the hedgingCoordinator so opposing offsets across bots in the same netting group cancel out
+func (s *mirrorStrategy) handleFillNetted(trade model.Trade) error {
+	signedBaseVolume := trade.Volume.AsFloat()
+	if trade.OrderAction.Reverse().IsSell() {
+		signedBaseVolume = -signedBaseVolume
+	}
+
+	claimedSignedBaseVolume, claimedPrice, e := s.hedgingCoordinator.addAndClaim(signedBaseVolume, trade.Price.AsFloat())
+	if e != nil {
+		return fmt.Errorf("unable to net trade with txID=%s via hedging coordinator: %s", trade.TransactionID.String(), e)
+	}
+	if claimedSignedBaseVolume == 0.0 {
+		log.Printf("offset-netted | tradeID=%s | tradeBaseAmt=%f | tradeQuoteAmt=%f | tradePriceQuote=%f | signedBaseVolume=%f\n",
+			trade.TransactionID.String(),
+			trade.Volume.AsFloat(),
+			trade.Volume.Multiply(*trade.Price).AsFloat(),
+			trade.Price.AsFloat(),
+			signedBaseVolume)
+		// mark the trade as handled since it is now accounted for in the shared net position
+		return s.insertTradeTrigger(trade.TransactionID.String(), nettedBackingOrderID, s.quotedFxRate())
+	}
+
+	// the netted order can be in the opposite direction of this trade's offset when other bots have a larger opposing position
+	newOrderAction := model.OrderActionBuy
+	if claimedSignedBaseVolume < 0 {
+		newOrderAction = model.OrderActionSell
+	}
+	// the netted surplus was accumulated by the trades of all the bots in the netting group so price the offset at the price of that surplus
+	// and not at the price of the trade that happened to trigger the claim
+	surplusTrade := trade
+	surplusTrade.Price = model.NumberFromFloat(claimedPrice, trade.Price.Precision())
+	price, fxRate, e := s.offsetPrice(surplusTrade, newOrderAction)
+	if e != nil {
+		// return the claimed volume to the net position so it is offset later
+		releaseErr := s.hedgingCoordinator.release(claimedSignedBaseVolume, claimedPrice)
+		if releaseErr != nil {
+			return fmt.Errorf("unable to price netted offset for trade with txID=%s: %s; also unable to release claimed volume back to the net position: %s", trade.TransactionID.String(), e, releaseErr)
+		}
+		return fmt.Errorf("unable to price netted offset for trade with txID=%s: %s", trade.TransactionID.String(), e)
+	}
+	newOrder := model.Order{
+		Pair:        s.backingPair, // we want to offset trades on the backing exchange so use the backing exchange's trading pair
+		OrderAction: newOrderAction,
+		OrderType:   model.OrderTypeLimit,
+		Price:       price,
+		Volume:      model.NumberFromFloat(math.Abs(claimedSignedBaseVolume), s.backingConstraints.VolumePrecision),
+		Timestamp:   nil,
+	}
+	log.Printf("offset-netted-attempt | tradeID=%s | tradeBaseAmt=%f | tradeQuoteAmt=%f | tradePriceQuote=%f | signedBaseVolume=%f | claimedSignedBaseVolume=%f | claimedPriceQuote=%f | newOrderAction=%s | newOrderBaseAmt=%f | newOrderPriceQuote=%f\n",
+		trade.TransactionID.String(),
+		trade.Volume.AsFloat(),
+		trade.Volume.Multiply(*trade.Price).AsFloat(),
+		trade.Price.AsFloat(),
+		signedBaseVolume,
+		claimedSignedBaseVolume,
+		claimedPrice,
+		newOrderAction.String(),
+		newOrder.Volume.AsFloat(),
+		newOrder.Price.AsFloat())
+
+	// when offsetting trades we always submit as a taker order so use api.SubmitModeBoth
+	transactionID, e := s.exchange.AddOrder(&newOrder, api.SubmitModeBoth)
+	if e == nil && transactionID == nil {
+		e = fmt.Errorf("transactionID was <nil>")
+	}
+	if e != nil {
+		// return the claimed volume to the net position so it is offset later
+		releaseErr := s.hedgingCoordinator.release(claimedSignedBaseVolume, claimedPrice)
+		if releaseErr != nil {
+			return fmt.Errorf("error when offsetting netted trade (newOrder=%s): %s; also unable to release claimed volume back to the net position: %s", newOrder, e, releaseErr)
+		}
+		return fmt.Errorf("error when offsetting netted trade (newOrder=%s): %s", newOrder, e)
+	}
+	// insert into the db immediately after placing order on backing exchange
+	e = s.insertTradeTrigger(trade.TransactionID.String(), transactionID.String(), fxRate)
+	if e != nil {
+		return fmt.Errorf("error when inserting trade trigger with txID=%s (newOrder=%s) (PK dupes not allowed): %s", transactionID.String(), newOrder, e)
+	}
+	log.Printf("offset-netted-success | tradeID=%s | newOrderAction=%s | newOrderBaseAmt=%f | newOrderPriceQuote=%f | transactionID=%s\n",
+		trade.TransactionID.String(),
+		newOrderAction.String(),
+		newOrder.Volume.AsFloat(),
+		newOrder.Price.AsFloat(),
+		transactionID)
+
+	// trigger fill tracking on backing exchange
+	trades, e := s.backingFillTracker.FillTrackSingleIteration()
+	if e != nil {
+		return fmt.Errorf("unable to track a single iteration of fills from the backing exchange: %s", e)
+	}
+	log.Printf("found %d trades on load from backing exchange in handleFillNetted\n", len(trades))
+
+	return nil
+}
+
+// insertPendingOffset persists a trade whose offset is being aggregated
+func (s *mirrorStrategy) insertPendingOffset(trade model.Trade, now time.Time) error {
+	sqlInsert := fmt.Sprintf(kelpdb.SqlStrategyMirrorPendingOffsetsInsertTemplate,
+		s.marketID,
+		trade.TransactionID.String(),
+		trade.OrderAction.String(),
+		trade.Price.AsFloat(),
+		trade.Volume.AsFloat(),
+		now.UTC().Format(postgresdb.TimestampFormatString),
+	)
+	_, e := s.db.Exec(sqlInsert)
+	if e != nil {
+		return fmt.Errorf("could not execute sql insert values statement (%s): %s", sqlInsert, e)
+	}
+	return nil
+}
+
+// deletePendingOffsets deletes the persisted pending trades once they were offset by an order in the direction of newOrderAction
+func (s *mirrorStrategy) deletePendingOffsets(newOrderAction model.OrderAction) error {
+	sqlDelete := fmt.Sprintf(kelpdb.SqlStrategyMirrorPendingOffsetsDeleteTemplate,
+		s.marketID,
+		newOrderAction.Reverse().String(),
+	)
+	_, e := s.db.Exec(sqlDelete)
+	if e != nil {
+		return fmt.Errorf("could not execute sql delete statement (%s): %s", sqlDelete, e)
+	}
+	return nil
+}
+
+// insertTradeTrigger records that the trade was offset by the backing order, with the fx rate that converted its price to the backing quote
+// asset so the trades of both markets can be reconciled in one currency
+func (s *mirrorStrategy) insertTradeTrigger(primaryTxID string, backingTxID string, fxRate *float64) error {
 	sqlInsert := fmt.Sprintf(kelpdb.SqlStrategyMirrorTradeTriggersInsertTemplate,
 		s.marketID,
 		primaryTxID,
 		s.backingMarketID,
 		backingTxID,
+		sqlFloatOrNull(fxRate),
 	)
 	_, e := s.db.Exec(sqlInsert)
 	if e != nil {
@@ -901,7 +1554,7 @@ func (s *mirrorStrategy) insertTradeTrigger(primaryTxID string, backingTxID stri
 		return fmt.Errorf("could not execute sql insert values statement (%s): %s", sqlInsert, e)
 	}
 
-	log.Printf("wrote trade trigger (market_id=%s, txid=%s, backing_market_id=%s, backing_txid=%s) to db\n", s.marketID, primaryTxID, s.backingMarketID, backingTxID)
+	log.Printf("wrote trade trigger (market_id=%s, txid=%s, backing_market_id=%s, backing_txid=%s, fx_rate=%s) to db\n", s.marketID, primaryTxID, s.backingMarketID, backingTxID, sqlFloatOrNull(fxRate))
 	return nil
 }
 
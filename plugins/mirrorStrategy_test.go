@@ -2,6 +2,7 @@ package plugins
 
 import (
 	"testing"
+	"time"
 
 	"github.com/openlyinc/pointy"
 	"github.com/stretchr/testify/assert"
@@ -904,3 +905,404 @@ func TestBalanceCoordinatorCheckBalance(t *testing.T) {
 		})
 	}
 }
+
+func TestShouldFlushOffset(t *testing.T) {
+	since := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		name                string
+		flushInterval       time.Duration
+		flushMinBaseSurplus float64
+		numPendingTrades    int
+		total               float64
+		committed           float64
+		now                 time.Time
+		want                bool
+	}{
+		{
+			name:                "no pending trades",
+			flushInterval:       time.Minute,
+			flushMinBaseSurplus: 1.0,
+			numPendingTrades:    0,
+			total:               5.0,
+			committed:           0.0,
+			now:                 since.Add(time.Hour),
+			want:                false,
+		}, {
+			name:                "interval not elapsed",
+			flushInterval:       time.Minute,
+			flushMinBaseSurplus: 0.0,
+			numPendingTrades:    2,
+			total:               5.0,
+			committed:           0.0,
+			now:                 since.Add(59 * time.Second),
+			want:                false,
+		}, {
+			name:                "interval elapsed",
+			flushInterval:       time.Minute,
+			flushMinBaseSurplus: 0.0,
+			numPendingTrades:    2,
+			total:               5.0,
+			committed:           0.0,
+			now:                 since.Add(time.Minute),
+			want:                true,
+		}, {
+			name:                "surplus below threshold",
+			flushInterval:       0,
+			flushMinBaseSurplus: 10.0,
+			numPendingTrades:    3,
+			total:               12.0,
+			committed:           3.0,
+			now:                 since.Add(time.Hour),
+			want:                false,
+		}, {
+			name:                "surplus reaches threshold",
+			flushInterval:       0,
+			flushMinBaseSurplus: 10.0,
+			numPendingTrades:    3,
+			total:               13.0,
+			committed:           3.0,
+			now:                 since,
+			want:                true,
+		}, {
+			name:                "surplus reaches threshold before interval",
+			flushInterval:       time.Minute,
+			flushMinBaseSurplus: 10.0,
+			numPendingTrades:    1,
+			total:               10.0,
+			committed:           0.0,
+			now:                 since.Add(time.Second),
+			want:                true,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			s := &mirrorStrategy{
+				offsetFlushInterval:       k.flushInterval,
+				offsetFlushMinBaseSurplus: k.flushMinBaseSurplus,
+				baseSurplus: map[model.OrderAction]*assetSurplus{
+					model.OrderActionBuy: &assetSurplus{
+						total:     model.NumberFromFloat(k.total, 7),
+						committed: model.NumberFromFloat(k.committed, 7),
+					},
+				},
+				pendingOffsets: map[model.OrderAction]*pendingOffset{
+					model.OrderActionBuy: &pendingOffset{
+						trades: make([]model.Trade, k.numPendingTrades),
+						since:  since,
+					},
+				},
+			}
+
+			assert.Equal(t, k.want, s.shouldFlushOffset(model.OrderActionBuy, k.now))
+		})
+	}
+}
+
+func TestAdjustOrdersForCosts(t *testing.T) {
+	testCases := []struct {
+		name                     string
+		isBid                    bool
+		takerFee                 float64
+		networkCostPerOfferQuote float64
+		inputPrices              []float64
+		inputVolumes             []float64
+		wantPrices               []float64
+	}{
+		{
+			name:                     "no costs",
+			isBid:                    true,
+			takerFee:                 0.0,
+			networkCostPerOfferQuote: 0.0,
+			inputPrices:              []float64{100.0, 99.0},
+			inputVolumes:             []float64{1.0, 2.0},
+			wantPrices:               []float64{100.0, 99.0},
+		}, {
+			name:                     "bid with taker fee",
+			isBid:                    true,
+			takerFee:                 0.001,
+			networkCostPerOfferQuote: 0.0,
+			inputPrices:              []float64{100.0, 99.0},
+			inputVolumes:             []float64{1.0, 2.0},
+			wantPrices:               []float64{99.9, 98.90},
+		}, {
+			name:                     "ask with taker fee rounds up",
+			isBid:                    false,
+			takerFee:                 0.001,
+			networkCostPerOfferQuote: 0.0,
+			inputPrices:              []float64{100.0},
+			inputVolumes:             []float64{1.0},
+			wantPrices:               []float64{100.11},
+		}, {
+			name:                     "network cost amortized over volume",
+			isBid:                    true,
+			takerFee:                 0.0,
+			networkCostPerOfferQuote: 0.5,
+			inputPrices:              []float64{100.0, 99.0},
+			inputVolumes:             []float64{2.0, 0.5},
+			wantPrices:               []float64{99.75, 98.0},
+		}, {
+			name:                     "bid dropped when costs exceed price",
+			isBid:                    true,
+			takerFee:                 0.0,
+			networkCostPerOfferQuote: 1.0,
+			inputPrices:              []float64{100.0, 0.5},
+			inputVolumes:             []float64{1.0, 1.0},
+			wantPrices:               []float64{99.0},
+		}, {
+			name:                     "ask with taker fee and network cost",
+			isBid:                    false,
+			takerFee:                 0.5,
+			networkCostPerOfferQuote: 1.0,
+			inputPrices:              []float64{10.0},
+			inputVolumes:             []float64{4.0},
+			wantPrices:               []float64{20.25},
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			orders := []model.Order{}
+			for i, p := range k.inputPrices {
+				orders = append(orders, model.Order{
+					Price:  model.NumberFromFloat(p, 2),
+					Volume: model.NumberFromFloat(k.inputVolumes[i], 2),
+				})
+			}
+
+			adjusted := adjustOrdersForCosts(orders, k.isBid, k.takerFee, k.networkCostPerOfferQuote)
+			if !assert.Equal(t, len(k.wantPrices), len(adjusted)) {
+				return
+			}
+			for i, o := range adjusted {
+				assert.Equal(t, model.NumberFromFloat(k.wantPrices[i], 2).AsString(), o.Price.AsString())
+			}
+		})
+	}
+}
+
+func TestAggregateOrdersByPriceBand(t *testing.T) {
+	testCases := []struct {
+		name         string
+		bandWidth    float64
+		inputPrices  []float64
+		inputVolumes []float64
+		wantPrices   []float64
+		wantVolumes  []float64
+	}{
+		{
+			name:         "empty",
+			bandWidth:    0.001,
+			inputPrices:  []float64{},
+			inputVolumes: []float64{},
+			wantPrices:   []float64{},
+			wantVolumes:  []float64{},
+		}, {
+			name:         "bids aggregated into 10 bps bands at the worst price",
+			bandWidth:    0.001,
+			inputPrices:  []float64{100.0, 99.95, 99.91, 99.89, 99.85, 99.7},
+			inputVolumes: []float64{1.0, 2.0, 0.5, 1.0, 1.5, 3.0},
+			wantPrices:   []float64{99.91, 99.85, 99.7},
+			wantVolumes:  []float64{3.5, 2.5, 3.0},
+		}, {
+			name:         "asks aggregated into 10 bps bands at the worst price",
+			bandWidth:    0.001,
+			inputPrices:  []float64{100.0, 100.05, 100.12, 100.5},
+			inputVolumes: []float64{1.0, 1.0, 2.0, 4.0},
+			wantPrices:   []float64{100.05, 100.12, 100.5},
+			wantVolumes:  []float64{2.0, 2.0, 4.0},
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			orders := []model.Order{}
+			for i, p := range k.inputPrices {
+				orders = append(orders, model.Order{
+					Price:  model.NumberFromFloat(p, 2),
+					Volume: model.NumberFromFloat(k.inputVolumes[i], 2),
+				})
+			}
+
+			aggregated := aggregateOrdersByPriceBand(orders, k.bandWidth)
+			if !assert.Equal(t, len(k.wantPrices), len(aggregated)) {
+				return
+			}
+			for i, o := range aggregated {
+				assert.Equal(t, model.NumberFromFloat(k.wantPrices[i], 2).AsString(), o.Price.AsString())
+				assert.Equal(t, model.NumberFromFloat(k.wantVolumes[i], 2).AsString(), o.Volume.AsString())
+			}
+		})
+	}
+}
+
+func TestCapOrdersByTotalVolume(t *testing.T) {
+	testCases := []struct {
+		name           string
+		maxTotalVolume float64
+		inputVolumes   []float64
+		wantVolumes    []float64
+	}{
+		{
+			name:           "empty",
+			maxTotalVolume: 10.0,
+			inputVolumes:   []float64{},
+			wantVolumes:    []float64{},
+		}, {
+			name:           "under cap",
+			maxTotalVolume: 10.0,
+			inputVolumes:   []float64{2.0, 3.0, 4.0},
+			wantVolumes:    []float64{2.0, 3.0, 4.0},
+		}, {
+			name:           "exactly at cap",
+			maxTotalVolume: 9.0,
+			inputVolumes:   []float64{2.0, 3.0, 4.0, 5.0},
+			wantVolumes:    []float64{2.0, 3.0, 4.0},
+		}, {
+			name:           "last level reduced",
+			maxTotalVolume: 6.5,
+			inputVolumes:   []float64{2.0, 3.0, 4.0, 5.0},
+			wantVolumes:    []float64{2.0, 3.0, 1.5},
+		}, {
+			name:           "first level reduced",
+			maxTotalVolume: 1.25,
+			inputVolumes:   []float64{2.0, 3.0},
+			wantVolumes:    []float64{1.25},
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			orders := []model.Order{}
+			for _, v := range k.inputVolumes {
+				orders = append(orders, model.Order{
+					Price:  model.NumberFromFloat(1.0, 2),
+					Volume: model.NumberFromFloat(v, 2),
+				})
+			}
+
+			capped := capOrdersByTotalVolume(orders, k.maxTotalVolume)
+			if !assert.Equal(t, len(k.wantVolumes), len(capped)) {
+				return
+			}
+			for i, o := range capped {
+				assert.Equal(t, model.NumberFromFloat(k.wantVolumes[i], 2).AsString(), o.Volume.AsString())
+			}
+		})
+	}
+}
+
+func TestFxConvertedOffsetPrice(t *testing.T) {
+	testCases := []struct {
+		name           string
+		tradePrice     float64
+		quotedFxRate   float64
+		currentFxRate  float64
+		newOrderAction model.OrderAction
+		maxSlippage    float64
+		wantPrice      float64
+		wantErr        bool
+	}{
+		{
+			name:           "unchanged rate",
+			tradePrice:     0.09,
+			quotedFxRate:   0.9,
+			currentFxRate:  0.9,
+			newOrderAction: model.OrderActionSell,
+			maxSlippage:    0.01,
+			wantPrice:      0.1,
+		}, {
+			name:           "sell with favorable move",
+			tradePrice:     0.09,
+			quotedFxRate:   0.9,
+			currentFxRate:  0.8,
+			newOrderAction: model.OrderActionSell,
+			maxSlippage:    0.01,
+			wantPrice:      0.1125,
+		}, {
+			name:           "sell with adverse move within slippage",
+			tradePrice:     0.09,
+			quotedFxRate:   0.9,
+			currentFxRate:  0.905,
+			newOrderAction: model.OrderActionSell,
+			maxSlippage:    0.01,
+			wantPrice:      0.0994475,
+		}, {
+			name:           "sell with adverse move beyond slippage",
+			tradePrice:     0.09,
+			quotedFxRate:   0.9,
+			currentFxRate:  1.0,
+			newOrderAction: model.OrderActionSell,
+			maxSlippage:    0.01,
+			wantErr:        true,
+		}, {
+			name:           "buy with favorable move",
+			tradePrice:     0.09,
+			quotedFxRate:   0.9,
+			currentFxRate:  1.0,
+			newOrderAction: model.OrderActionBuy,
+			maxSlippage:    0.01,
+			wantPrice:      0.09,
+		}, {
+			name:           "buy with adverse move beyond slippage",
+			tradePrice:     0.09,
+			quotedFxRate:   0.9,
+			currentFxRate:  0.8,
+			newOrderAction: model.OrderActionBuy,
+			maxSlippage:    0.01,
+			wantErr:        true,
+		}, {
+			name:           "guard disabled",
+			tradePrice:     0.09,
+			quotedFxRate:   0.9,
+			currentFxRate:  0.8,
+			newOrderAction: model.OrderActionBuy,
+			maxSlippage:    0.0,
+			wantPrice:      0.1125,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			price, e := fxConvertedOffsetPrice(k.tradePrice, k.quotedFxRate, k.currentFxRate, k.newOrderAction, k.maxSlippage)
+			if k.wantErr {
+				assert.Error(t, e)
+				return
+			}
+			if assert.NoError(t, e) {
+				assert.InDelta(t, k.wantPrice, price, 0.0000001)
+			}
+		})
+	}
+}
+
+func TestMirrorOffsetPriceFxRate(t *testing.T) {
+	trade := model.Trade{Order: model.Order{Price: model.NumberFromFloat(0.09, 7)}}
+	s := &mirrorStrategy{backingConstraints: model.MakeOrderConstraints(7, 7, 1.0)}
+
+	price, fxRate, e := s.offsetPrice(trade, model.OrderActionSell)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, 0.09, price.AsFloat())
+	assert.Nil(t, fxRate)
+	assert.Nil(t, s.quotedFxRate())
+
+	fxRateFeed, e := newFixedFeed("0.8")
+	if !assert.NoError(t, e) {
+		return
+	}
+	s.fxRateFeed = fxRateFeed
+	s.fxRate = 0.9
+	price, fxRate, e = s.offsetPrice(trade, model.OrderActionSell)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, 0.1125, price.AsFloat())
+	if assert.NotNil(t, fxRate) {
+		assert.Equal(t, 0.8, *fxRate)
+	}
+	if assert.NotNil(t, s.quotedFxRate()) {
+		assert.Equal(t, 0.9, *s.quotedFxRate())
+	}
+}
@@ -0,0 +1,345 @@
+package plugins
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/stellar/go/clients/horizonclient"
+	sdkNetwork "github.com/stellar/go/network"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/support/toml"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// offsetVenueNameBacking is the name of the venue for the backing exchange configured with EXCHANGE in the mirror strategy config
+const offsetVenueNameBacking = "backing"
+
+// the types of venues that trades can be offset on
+const (
+	offsetVenueTypeExchange        = "exchange"
+	offsetVenueTypeSdexPathPayment = "sdex_path_payment"
+)
+
+// offsetVenueConfig is the config for an additional venue that trades can be routed to when they are offset
+type offsetVenueConfig struct {
+	Name            string                   `valid:"-" toml:"NAME"`
+	Type            string                   `valid:"-" toml:"TYPE"`
+	Exchange        string                   `valid:"-" toml:"EXCHANGE"`
+	ExchangeBase    string                   `valid:"-" toml:"EXCHANGE_BASE"`
+	ExchangeQuote   string                   `valid:"-" toml:"EXCHANGE_QUOTE"`
+	ExchangeAPIKeys toml.ExchangeAPIKeysToml `valid:"-" toml:"EXCHANGE_API_KEYS"`
+	ExchangeParams  toml.ExchangeParamsToml  `valid:"-" toml:"EXCHANGE_PARAMS"`
+	ExchangeHeaders toml.ExchangeHeadersToml `valid:"-" toml:"EXCHANGE_HEADERS"`
+	SdexAssetCodeA  string                   `valid:"-" toml:"SDEX_ASSET_CODE_A"`
+	SdexIssuerA     string                   `valid:"-" toml:"SDEX_ISSUER_A"`
+	SdexAssetCodeB  string                   `valid:"-" toml:"SDEX_ASSET_CODE_B"`
+	SdexIssuerB     string                   `valid:"-" toml:"SDEX_ISSUER_B"`
+	SecretSeed      string                   `valid:"-" toml:"SECRET_SEED"`
+	MaxSlippage     float64                  `valid:"-" toml:"MAX_SLIPPAGE"`
+}
+
+// offsetRouteConfig is a rule that routes the offsets matching all of its conditions to an ordered list of venues
+type offsetRouteConfig struct {
+	SellingAsset  string   `valid:"-" toml:"SELLING_ASSET"`   // "base" or "quote", empty matches both
+	MinBaseVolume float64  `valid:"-" toml:"MIN_BASE_VOLUME"` // 0 matches any volume
+	MaxBaseVolume float64  `valid:"-" toml:"MAX_BASE_VOLUME"` // 0 matches any volume
+	StartHourUTC  *int     `valid:"-" toml:"START_HOUR_UTC"`  // inclusive, nil matches all day
+	EndHourUTC    *int     `valid:"-" toml:"END_HOUR_UTC"`    // exclusive, can be less than START_HOUR_UTC to wrap around midnight
+	Venues        []string `valid:"-" toml:"VENUES"`          // venues are tried in order until one of them succeeds
+}
+
+// matches returns true if the offset satisfies all the conditions of the route
+func (r offsetRouteConfig) matches(newOrderAction model.OrderAction, baseVolume float64, now time.Time) bool {
+	sellingAsset := "quote"
+	if newOrderAction.IsSell() {
+		sellingAsset = "base"
+	}
+	if r.SellingAsset != "" && r.SellingAsset != sellingAsset {
+		return false
+	}
+	if r.MinBaseVolume > 0.0 && baseVolume < r.MinBaseVolume {
+		return false
+	}
+	if r.MaxBaseVolume > 0.0 && baseVolume > r.MaxBaseVolume {
+		return false
+	}
+	if r.StartHourUTC != nil {
+		hour := now.UTC().Hour()
+		if *r.StartHourUTC <= *r.EndHourUTC {
+			return hour >= *r.StartHourUTC && hour < *r.EndHourUTC
+		}
+		return hour >= *r.StartHourUTC || hour < *r.EndHourUTC
+	}
+	return true
+}
+
+func (r offsetRouteConfig) validate(venueNames map[string]bool) error {
+	if r.SellingAsset != "" && r.SellingAsset != "base" && r.SellingAsset != "quote" {
+		return fmt.Errorf("SELLING_ASSET needs to be 'base', 'quote', or empty but was '%s'", r.SellingAsset)
+	}
+	if r.MinBaseVolume < 0.0 || r.MaxBaseVolume < 0.0 {
+		return fmt.Errorf("MIN_BASE_VOLUME and MAX_BASE_VOLUME cannot be negative")
+	}
+	if r.MaxBaseVolume > 0.0 && r.MaxBaseVolume < r.MinBaseVolume {
+		return fmt.Errorf("MAX_BASE_VOLUME (%f) cannot be less than MIN_BASE_VOLUME (%f)", r.MaxBaseVolume, r.MinBaseVolume)
+	}
+	if (r.StartHourUTC == nil) != (r.EndHourUTC == nil) {
+		return fmt.Errorf("START_HOUR_UTC and END_HOUR_UTC need to be set together")
+	}
+	if r.StartHourUTC != nil {
+		if *r.StartHourUTC < 0 || *r.StartHourUTC > 23 || *r.EndHourUTC < 0 || *r.EndHourUTC > 24 || *r.StartHourUTC == *r.EndHourUTC {
+			return fmt.Errorf("invalid hours, START_HOUR_UTC (%d) needs to be in the range [0, 23] and END_HOUR_UTC (%d) in the range [0, 24] and they cannot be equal", *r.StartHourUTC, *r.EndHourUTC)
+		}
+	}
+	if len(r.Venues) == 0 {
+		return fmt.Errorf("VENUES cannot be empty")
+	}
+	for _, name := range r.Venues {
+		if !venueNames[name] {
+			return fmt.Errorf("unknown venue '%s' in VENUES", name)
+		}
+	}
+	return nil
+}
+
+// offsetVenue is a venue where trades can be offset
+type offsetVenue interface {
+	// placeOffset places a taker order to buy or sell the volume of base units at the price or better and returns the ID of the order or transaction
+	placeOffset(newOrderAction model.OrderAction, price *model.Number, baseVolume *model.Number) (*model.TransactionID, error)
+}
+
+// exchangeOffsetVenue offsets trades by placing orders on an exchange
+type exchangeOffsetVenue struct {
+	exchange api.Exchange
+	pair     *model.TradingPair
+}
+
+// placeOffset impl.
+func (v *exchangeOffsetVenue) placeOffset(newOrderAction model.OrderAction, price *model.Number, baseVolume *model.Number) (*model.TransactionID, error) {
+	constraints := v.exchange.GetOrderConstraints(v.pair)
+	volume := model.NumberByCappingPrecision(baseVolume, constraints.VolumePrecision)
+	if volume.AsFloat() < constraints.MinBaseVolume.AsFloat() {
+		return nil, fmt.Errorf("volume (%s) is less than the minBaseVolume (%s) of the exchange", volume.AsString(), constraints.MinBaseVolume.AsString())
+	}
+
+	order := model.Order{
+		Pair:        v.pair,
+		OrderAction: newOrderAction,
+		OrderType:   model.OrderTypeLimit,
+		Price:       model.NumberByCappingPrecision(price, constraints.PricePrecision),
+		Volume:      volume,
+		Timestamp:   nil,
+	}
+	// when offsetting trades we always submit as a taker order so use api.SubmitModeBoth
+	txID, e := v.exchange.AddOrder(&order, api.SubmitModeBoth)
+	if e != nil {
+		return nil, fmt.Errorf("error placing order (%s): %s", order, e)
+	}
+	if txID == nil {
+		return nil, fmt.Errorf("error placing order (%s): transactionID was <nil>", order)
+	}
+	return txID, nil
+}
+
+// sdexPathPaymentOffsetVenue offsets trades on SDEX with a path payment from the account to itself, which takes liquidity from the
+// orderbook and liquidity pools of the pair without leaving an offer behind
+type sdexPathPaymentOffsetVenue struct {
+	client      *horizonclient.Client
+	network     string
+	secretSeed  string
+	account     string
+	baseAsset   hProtocol.Asset
+	quoteAsset  hProtocol.Asset
+	maxSlippage float64
+	simMode     bool
+}
+
+// placeOffset impl.
+func (v *sdexPathPaymentOffsetVenue) placeOffset(newOrderAction model.OrderAction, price *model.Number, baseVolume *model.Number) (*model.TransactionID, error) {
+	var op txnbuild.Operation
+	if newOrderAction.IsSell() {
+		// send exactly the base volume and receive at least the quote value at the price less the slippage
+		op = &txnbuild.PathPaymentStrictSend{
+			SendAsset:   utils.Asset2Asset(v.baseAsset),
+			SendAmount:  model.NumberFromFloatRoundTruncate(baseVolume.AsFloat(), utils.SdexPrecision).AsString(),
+			Destination: v.account,
+			DestAsset:   utils.Asset2Asset(v.quoteAsset),
+			DestMin:     model.NumberFromFloatRoundTruncate(baseVolume.AsFloat()*price.AsFloat()*(1-v.maxSlippage), utils.SdexPrecision).AsString(),
+			Path:        []txnbuild.Asset{},
+		}
+	} else {
+		// receive exactly the base volume and send at most the quote value at the price plus the slippage
+		op = &txnbuild.PathPaymentStrictReceive{
+			SendAsset:   utils.Asset2Asset(v.quoteAsset),
+			SendMax:     model.NumberFromFloat(baseVolume.AsFloat()*price.AsFloat()*(1+v.maxSlippage), utils.SdexPrecision).AsString(),
+			Destination: v.account,
+			DestAsset:   utils.Asset2Asset(v.baseAsset),
+			DestAmount:  model.NumberFromFloatRoundTruncate(baseVolume.AsFloat(), utils.SdexPrecision).AsString(),
+			Path:        []txnbuild.Asset{},
+		}
+	}
+
+	account, e := v.client.AccountDetail(horizonclient.AccountRequest{AccountID: v.account})
+	if e != nil {
+		return nil, fmt.Errorf("unable to load account details for account '%s': %s", v.account, e)
+	}
+	tx, e := txnbuild.NewTransaction(
+		txnbuild.TransactionParams{
+			SourceAccount:        &account,
+			IncrementSequenceNum: true,
+			BaseFee:              baseFeeStroops,
+			Operations:           []txnbuild.Operation{op},
+			Timebounds:           txnbuild.NewInfiniteTimeout(),
+		},
+	)
+	if e != nil {
+		return nil, fmt.Errorf("unable to make new transaction: %s", e)
+	}
+	tx, e = utils.SignWithSeed(tx, v.network, v.secretSeed)
+	if e != nil {
+		return nil, fmt.Errorf("error signing transaction: %s", e)
+	}
+	txeB64, e := tx.Base64()
+	if e != nil {
+		return nil, fmt.Errorf("unable to encode transaction: %s", e)
+	}
+
+	if v.simMode {
+		log.Printf("not submitting path payment tx XDR to network in simulation mode: %s\n", txeB64)
+		return model.MakeTransactionID("simulated"), nil
+	}
+	resp, e := v.client.SubmitTransactionXDR(txeB64)
+	if e != nil {
+		return nil, fmt.Errorf("unable to submit path payment transaction: %s", e)
+	}
+	return model.MakeTransactionID(resp.Hash), nil
+}
+
+// offsetRouter routes offsets to the venues of the first matching route, falling back to the next venue of the route when a venue fails.
+// Offsets that do not match any route are placed on the backing exchange.
+type offsetRouter struct {
+	venues map[string]offsetVenue
+	routes []offsetRouteConfig
+}
+
+// makeOffsetRouter is a factory method, backingVenue is the venue for the backing exchange of the mirror strategy
+func makeOffsetRouter(venueConfigs []offsetVenueConfig, routes []offsetRouteConfig, backingVenue offsetVenue, simMode bool) (*offsetRouter, error) {
+	venues := map[string]offsetVenue{
+		offsetVenueNameBacking: backingVenue,
+	}
+	venueNames := map[string]bool{
+		offsetVenueNameBacking: true,
+	}
+	for i, c := range venueConfigs {
+		if strings.TrimSpace(c.Name) == "" {
+			return nil, fmt.Errorf("NAME of OFFSET_VENUES at index %d cannot be empty", i)
+		}
+		if venueNames[c.Name] {
+			return nil, fmt.Errorf("duplicate NAME '%s' of OFFSET_VENUES at index %d, note that '%s' is reserved for the backing exchange", c.Name, i, offsetVenueNameBacking)
+		}
+
+		venue, e := makeOffsetVenue(c, simMode)
+		if e != nil {
+			return nil, fmt.Errorf("unable to make offset venue '%s': %s", c.Name, e)
+		}
+		venues[c.Name] = venue
+		venueNames[c.Name] = true
+	}
+
+	for i, r := range routes {
+		e := r.validate(venueNames)
+		if e != nil {
+			return nil, fmt.Errorf("invalid OFFSET_ROUTES at index %d: %s", i, e)
+		}
+	}
+
+	return &offsetRouter{
+		venues: venues,
+		routes: routes,
+	}, nil
+}
+
+func makeOffsetVenue(c offsetVenueConfig, simMode bool) (offsetVenue, error) {
+	switch c.Type {
+	case offsetVenueTypeExchange:
+		exchange, e := MakeTradingExchange(c.Exchange, c.ExchangeAPIKeys.ToExchangeAPIKeys(), c.ExchangeParams.ToExchangeParams(), c.ExchangeHeaders.ToExchangeHeaders(), simMode)
+		if e != nil {
+			return nil, e
+		}
+		return &exchangeOffsetVenue{
+			exchange: exchange,
+			pair: &model.TradingPair{
+				Base:  exchange.GetAssetConverter().MustFromString(c.ExchangeBase),
+				Quote: exchange.GetAssetConverter().MustFromString(c.ExchangeQuote),
+			},
+		}, nil
+	case offsetVenueTypeSdexPathPayment:
+		baseAsset, e := utils.ParseAsset(c.SdexAssetCodeA, c.SdexIssuerA)
+		if e != nil {
+			return nil, fmt.Errorf("unable to parse SDEX_ASSET_CODE_A and SDEX_ISSUER_A: %s", e)
+		}
+		quoteAsset, e := utils.ParseAsset(c.SdexAssetCodeB, c.SdexIssuerB)
+		if e != nil {
+			return nil, fmt.Errorf("unable to parse SDEX_ASSET_CODE_B and SDEX_ISSUER_B: %s", e)
+		}
+		account, e := utils.ParseSecret(c.SecretSeed)
+		if e != nil {
+			return nil, fmt.Errorf("unable to parse SECRET_SEED: %s", e)
+		}
+		if account == nil {
+			return nil, fmt.Errorf("SECRET_SEED needs to be set")
+		}
+		if c.MaxSlippage < 0.0 || c.MaxSlippage >= 1.0 {
+			return nil, fmt.Errorf("MAX_SLIPPAGE needs to be in the range [0.0, 1.0) but was %f", c.MaxSlippage)
+		}
+
+		client := horizonclient.DefaultPublicNetClient
+		network := sdkNetwork.PublicNetworkPassphrase
+		if privateSdexHackVar != nil {
+			client = privateSdexHackVar.API
+			network = privateSdexHackVar.Network
+		}
+		return &sdexPathPaymentOffsetVenue{
+			client:      client,
+			network:     network,
+			secretSeed:  c.SecretSeed,
+			account:     *account,
+			baseAsset:   *baseAsset,
+			quoteAsset:  *quoteAsset,
+			maxSlippage: c.MaxSlippage,
+			simMode:     simMode,
+		}, nil
+	default:
+		return nil, fmt.Errorf("invalid TYPE '%s', needs to be '%s' or '%s'", c.Type, offsetVenueTypeExchange, offsetVenueTypeSdexPathPayment)
+	}
+}
+
+// venuesFor returns the names of the venues to try in order for the offset
+func (r *offsetRouter) venuesFor(newOrderAction model.OrderAction, baseVolume float64, now time.Time) []string {
+	for _, route := range r.routes {
+		if route.matches(newOrderAction, baseVolume, now) {
+			return route.Venues
+		}
+	}
+	return []string{offsetVenueNameBacking}
+}
+
+// placeOffset places the offset on the first venue that succeeds, returning the errors of all venues if none of them succeed
+func (r *offsetRouter) placeOffset(newOrderAction model.OrderAction, price *model.Number, baseVolume *model.Number, now time.Time) (*model.TransactionID, error) {
+	errs := []string{}
+	for _, name := range r.venuesFor(newOrderAction, baseVolume.AsFloat(), now) {
+		txID, e := r.venues[name].placeOffset(newOrderAction, price, baseVolume)
+		if e == nil {
+			log.Printf("offset-routed | venue=%s | newOrderAction=%s | baseVolume=%s | price=%s | transactionID=%s\n", name, newOrderAction.String(), baseVolume.AsString(), price.AsString(), txID.String())
+			return txID, nil
+		}
+		log.Printf("offset-venue-failed | venue=%s | newOrderAction=%s | baseVolume=%s | price=%s | error=%s\n", name, newOrderAction.String(), baseVolume.AsString(), price.AsString(), e)
+		errs = append(errs, fmt.Sprintf("%s: %s", name, e))
+	}
+	return nil, fmt.Errorf("unable to offset on any venue (%s)", strings.Join(errs, "; "))
+}
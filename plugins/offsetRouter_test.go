@@ -0,0 +1,122 @@
+package plugins
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/kelp/model"
+)
+
+type fakeOffsetVenue struct {
+	txID   string
+	e      error
+	called int
+}
+
+func (v *fakeOffsetVenue) placeOffset(newOrderAction model.OrderAction, price *model.Number, baseVolume *model.Number) (*model.TransactionID, error) {
+	v.called++
+	if v.e != nil {
+		return nil, v.e
+	}
+	return model.MakeTransactionID(v.txID), nil
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func TestOffsetRouteMatches(t *testing.T) {
+	noon := time.Date(2020, 3, 14, 12, 0, 0, 0, time.UTC)
+	midnight := time.Date(2020, 3, 14, 0, 30, 0, 0, time.UTC)
+	testCases := []struct {
+		name           string
+		route          offsetRouteConfig
+		newOrderAction model.OrderAction
+		baseVolume     float64
+		now            time.Time
+		want           bool
+	}{
+		{"empty route matches everything", offsetRouteConfig{}, model.OrderActionBuy, 10.0, noon, true},
+		{"selling base matches sell", offsetRouteConfig{SellingAsset: "base"}, model.OrderActionSell, 10.0, noon, true},
+		{"selling base does not match buy", offsetRouteConfig{SellingAsset: "base"}, model.OrderActionBuy, 10.0, noon, false},
+		{"selling quote matches buy", offsetRouteConfig{SellingAsset: "quote"}, model.OrderActionBuy, 10.0, noon, true},
+		{"below min volume", offsetRouteConfig{MinBaseVolume: 100.0}, model.OrderActionBuy, 10.0, noon, false},
+		{"above max volume", offsetRouteConfig{MaxBaseVolume: 5.0}, model.OrderActionBuy, 10.0, noon, false},
+		{"within volume range", offsetRouteConfig{MinBaseVolume: 5.0, MaxBaseVolume: 10.0}, model.OrderActionBuy, 10.0, noon, true},
+		{"within hours", offsetRouteConfig{StartHourUTC: intPtr(8), EndHourUTC: intPtr(16)}, model.OrderActionBuy, 10.0, noon, true},
+		{"outside hours", offsetRouteConfig{StartHourUTC: intPtr(8), EndHourUTC: intPtr(16)}, model.OrderActionBuy, 10.0, midnight, false},
+		{"within hours wrapping midnight", offsetRouteConfig{StartHourUTC: intPtr(22), EndHourUTC: intPtr(2)}, model.OrderActionBuy, 10.0, midnight, true},
+		{"outside hours wrapping midnight", offsetRouteConfig{StartHourUTC: intPtr(22), EndHourUTC: intPtr(2)}, model.OrderActionBuy, 10.0, noon, false},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			assert.Equal(t, k.want, k.route.matches(k.newOrderAction, k.baseVolume, k.now))
+		})
+	}
+}
+
+func TestOffsetRouteValidate(t *testing.T) {
+	venueNames := map[string]bool{offsetVenueNameBacking: true, "other": true}
+	testCases := []struct {
+		name    string
+		route   offsetRouteConfig
+		wantErr bool
+	}{
+		{"valid", offsetRouteConfig{SellingAsset: "base", Venues: []string{"other", offsetVenueNameBacking}}, false},
+		{"invalid selling asset", offsetRouteConfig{SellingAsset: "XLM", Venues: []string{"other"}}, true},
+		{"max less than min", offsetRouteConfig{MinBaseVolume: 10.0, MaxBaseVolume: 5.0, Venues: []string{"other"}}, true},
+		{"only start hour", offsetRouteConfig{StartHourUTC: intPtr(8), Venues: []string{"other"}}, true},
+		{"invalid hour", offsetRouteConfig{StartHourUTC: intPtr(8), EndHourUTC: intPtr(25), Venues: []string{"other"}}, true},
+		{"no venues", offsetRouteConfig{}, true},
+		{"unknown venue", offsetRouteConfig{Venues: []string{"missing"}}, true},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			e := k.route.validate(venueNames)
+			assert.Equal(t, k.wantErr, e != nil)
+		})
+	}
+}
+
+func TestOffsetRouterPlaceOffset(t *testing.T) {
+	backing := &fakeOffsetVenue{txID: "backingTx"}
+	failing := &fakeOffsetVenue{e: fmt.Errorf("venue is down")}
+	other := &fakeOffsetVenue{txID: "otherTx"}
+	r := &offsetRouter{
+		venues: map[string]offsetVenue{
+			offsetVenueNameBacking: backing,
+			"failing":              failing,
+			"other":                other,
+		},
+		routes: []offsetRouteConfig{
+			{MinBaseVolume: 1000.0, Venues: []string{"failing", "other"}},
+			{SellingAsset: "quote", Venues: []string{"failing"}},
+		},
+	}
+	price := model.NumberFromFloat(0.1, 7)
+	now := time.Date(2020, 3, 14, 12, 0, 0, 0, time.UTC)
+
+	// large offsets fall back to the next venue of the route
+	txID, e := r.placeOffset(model.OrderActionSell, price, model.NumberFromFloat(2000.0, 7), now)
+	if assert.NoError(t, e) {
+		assert.Equal(t, "otherTx", txID.String())
+	}
+	assert.Equal(t, 1, failing.called)
+	assert.Equal(t, 1, other.called)
+
+	// offsets that don't match any route go to the backing exchange
+	txID, e = r.placeOffset(model.OrderActionSell, price, model.NumberFromFloat(10.0, 7), now)
+	if assert.NoError(t, e) {
+		assert.Equal(t, "backingTx", txID.String())
+	}
+
+	// the offset fails when all venues of the route fail
+	_, e = r.placeOffset(model.OrderActionBuy, price, model.NumberFromFloat(10.0, 7), now)
+	assert.Error(t, e)
+	assert.Equal(t, 2, failing.called)
+}
@@ -0,0 +1,210 @@
+package plugins
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/support/soroban"
+)
+
+// zeroAccount is the account the oracle calls are simulated from when no source account is set, simulating a read-only call does not
+// need a funded account
+const zeroAccount = "GAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAWHF"
+
+var oracleConfigLock = &sync.Mutex{}
+
+// oracleRPCURL and oracleSourceAccount are used by every "oracle" price feed that is made after they are set with SetOracleConfig
+var oracleRPCURL string
+var oracleSourceAccount string
+
+// SetOracleConfig sets the soroban RPC server and the account used to read the "oracle" price feeds, an empty sourceAccount uses an
+// account of all zeros
+func SetOracleConfig(rpcURL string, sourceAccount string) {
+	oracleConfigLock.Lock()
+	defer oracleConfigLock.Unlock()
+
+	oracleRPCURL = rpcURL
+	oracleSourceAccount = sourceAccount
+}
+
+// oracleCaller calls a read-only function of a contract
+type oracleCaller interface {
+	Call(contract soroban.Address, function string, args ...soroban.ScVal) (soroban.ScVal, error)
+}
+
+// oracleFeed reads the price from an on-chain oracle contract that implements the SEP-40 interface, such as Reflector
+// (https://reflector.network), so a price can be quoted against an attested on-chain reference. The price of a single asset is in the base
+// asset of the oracle (usually USD), a cross price uses the x_last_price function.
+type oracleFeed struct {
+	name       string
+	caller     oracleCaller
+	contract   soroban.Address
+	asset      soroban.ScVal
+	quoteAsset *soroban.ScVal // nil when the price is in the base asset of the oracle
+
+	// uninitialized
+	decimals *int
+}
+
+// ensure that it implements TimestampedPriceFeed
+var _ api.TimestampedPriceFeed = &oracleFeed{}
+
+// makeOracleFeed makes the feed from a URL in the format <contract>/<asset>[/<quoteAsset>], where an asset is either a symbol of an
+// off-chain asset (such as BTC) or the contract address of a Stellar asset (C...)
+func makeOracleFeed(feedURL string) (*oracleFeed, error) {
+	oracleConfigLock.Lock()
+	rpcURL := oracleRPCURL
+	sourceAccount := oracleSourceAccount
+	oracleConfigLock.Unlock()
+
+	if rpcURL == "" {
+		return nil, fmt.Errorf("the \"oracle\" price feed needs SOROBAN_RPC_URL to be set in the trader config")
+	}
+	if sourceAccount == "" {
+		sourceAccount = zeroAccount
+	}
+	source, e := soroban.ParseAddress(sourceAccount)
+	if e != nil || source.IsContract {
+		return nil, fmt.Errorf("invalid source account '%s' of the oracle price feed: %v", sourceAccount, e)
+	}
+	return makeOracleFeedWithCaller(feedURL, soroban.MakeClient(rpcURL, source))
+}
+
+func makeOracleFeedWithCaller(feedURL string, caller oracleCaller) (*oracleFeed, error) {
+	parts := strings.Split(feedURL, "/")
+	if len(parts) < 2 || len(parts) > 3 {
+		return nil, fmt.Errorf("invalid format of oracle feed URL, needs to be <contract>/<asset>[/<quoteAsset>] (such as C.../BTC): %s", feedURL)
+	}
+	contract, e := soroban.ParseAddress(parts[0])
+	if e != nil || !contract.IsContract {
+		return nil, fmt.Errorf("invalid oracle contract '%s', needs to be a contract address (C...): %v", parts[0], e)
+	}
+	asset, e := toOracleAsset(parts[1])
+	if e != nil {
+		return nil, e
+	}
+
+	f := &oracleFeed{
+		name:     feedURL,
+		caller:   caller,
+		contract: contract,
+		asset:    asset,
+	}
+	if len(parts) == 3 {
+		quoteAsset, e := toOracleAsset(parts[2])
+		if e != nil {
+			return nil, e
+		}
+		f.quoteAsset = &quoteAsset
+	}
+	return f, nil
+}
+
+// toOracleAsset converts an asset to the SEP-40 Asset enum, which is Stellar(Address) for a Stellar asset and Other(Symbol) otherwise
+func toOracleAsset(asset string) (soroban.ScVal, error) {
+	if asset == "" {
+		return soroban.ScVal{}, fmt.Errorf("asset of the oracle feed cannot be empty")
+	}
+	if strings.HasPrefix(asset, "C") && len(asset) == 56 {
+		a, e := soroban.ParseAddress(asset)
+		if e != nil {
+			return soroban.ScVal{}, fmt.Errorf("invalid contract address of asset '%s': %s", asset, e)
+		}
+		return soroban.Vec(soroban.Symbol("Stellar"), soroban.AddressVal(a)), nil
+	}
+	return soroban.Vec(soroban.Symbol("Other"), soroban.Symbol(asset)), nil
+}
+
+// GetPrice impl
+func (f *oracleFeed) GetPrice() (float64, error) {
+	price, _, e := f.GetPriceWithTimestamp()
+	return price, e
+}
+
+// GetPriceWithTimestamp impl, the timestamp is the time of the price on the oracle so FEED_MAX_STALENESS_SECONDS catches an oracle that
+// stopped updating
+func (f *oracleFeed) GetPriceWithTimestamp() (float64, time.Time, error) {
+	decimals, e := f.getDecimals()
+	if e != nil {
+		return 0, time.Time{}, fmt.Errorf("oracle: %s", e)
+	}
+
+	var result soroban.ScVal
+	if f.quoteAsset == nil {
+		result, e = f.caller.Call(f.contract, "lastprice", f.asset)
+	} else {
+		result, e = f.caller.Call(f.contract, "x_last_price", f.asset, *f.quoteAsset)
+	}
+	if e != nil {
+		return 0, time.Time{}, fmt.Errorf("oracle: %s", e)
+	}
+
+	price, ts, e := parseOraclePriceData(result, decimals)
+	if e != nil {
+		return 0, time.Time{}, fmt.Errorf("oracle: could not read the price of '%s': %s", f.name, e)
+	}
+	return price, ts, nil
+}
+
+func (f *oracleFeed) getDecimals() (int, error) {
+	if f.decimals != nil {
+		return *f.decimals, nil
+	}
+
+	result, e := f.caller.Call(f.contract, "decimals")
+	if e != nil {
+		return 0, e
+	}
+	n, e := result.AsBigInt()
+	if e != nil || !n.IsInt64() || n.Int64() < 0 || n.Int64() > 38 {
+		return 0, fmt.Errorf("decimals of the oracle needs to be an integer between 0 and 38 but was %v: %v", n, e)
+	}
+	decimals := int(n.Int64())
+	f.decimals = &decimals
+	return decimals, nil
+}
+
+// parseOraclePriceData parses the Option<PriceData> returned by the oracle, which is void when the oracle has no price and a map of
+// {price: i128, timestamp: u64} otherwise
+func parseOraclePriceData(v soroban.ScVal, decimals int) (float64, time.Time, error) {
+	if v.Type == soroban.ScvVoid {
+		return 0, time.Time{}, fmt.Errorf("the oracle has no price for the asset")
+	}
+	if v.Type != soroban.ScvMap {
+		return 0, time.Time{}, fmt.Errorf("PriceData needs to be a map but was of type %d", v.Type)
+	}
+
+	priceVal, ok := v.MapValue("price")
+	if !ok {
+		return 0, time.Time{}, fmt.Errorf("PriceData is missing the price")
+	}
+	price, e := priceVal.AsBigInt()
+	if e != nil {
+		return 0, time.Time{}, fmt.Errorf("could not read the price: %s", e)
+	}
+	if price.Sign() <= 0 {
+		return 0, time.Time{}, fmt.Errorf("price needs to be positive but was %s", price.String())
+	}
+	tsVal, ok := v.MapValue("timestamp")
+	if !ok {
+		return 0, time.Time{}, fmt.Errorf("PriceData is missing the timestamp")
+	}
+	tsInt, e := tsVal.AsBigInt()
+	if e != nil || !tsInt.IsInt64() {
+		return 0, time.Time{}, fmt.Errorf("could not read the timestamp: %v", e)
+	}
+
+	scale := new(big.Float).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil))
+	priceFloat, _ := new(big.Float).Quo(new(big.Float).SetInt(price), scale).Float64()
+
+	// the timestamp is in seconds but some oracles use milliseconds, which are told apart by their size
+	ts := tsInt.Int64()
+	if ts > 1e12 {
+		return priceFloat, time.Unix(0, ts*int64(time.Millisecond)), nil
+	}
+	return priceFloat, time.Unix(ts, 0), nil
+}
@@ -0,0 +1,110 @@
+package plugins
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/kelp/support/soroban"
+)
+
+type fakeOracleCaller struct {
+	decimals  uint64
+	priceData soroban.ScVal
+	calls     []string
+}
+
+func (c *fakeOracleCaller) Call(contract soroban.Address, function string, args ...soroban.ScVal) (soroban.ScVal, error) {
+	c.calls = append(c.calls, fmt.Sprintf("%s/%d", function, len(args)))
+	switch function {
+	case "decimals":
+		return soroban.ScVal{Type: soroban.ScvU32, Uint: c.decimals}, nil
+	case "lastprice", "x_last_price":
+		return c.priceData, nil
+	}
+	return soroban.ScVal{}, fmt.Errorf("unknown function %s", function)
+}
+
+func makeTestPriceData(price int64, timestamp uint64) soroban.ScVal {
+	return soroban.ScVal{Type: soroban.ScvMap, Map: []soroban.ScMapEntry{
+		{Key: soroban.Symbol("price"), Val: soroban.ScVal{Type: soroban.ScvI128, Big: big.NewInt(price)}},
+		{Key: soroban.Symbol("timestamp"), Val: soroban.ScVal{Type: soroban.ScvU64, Uint: timestamp}},
+	}}
+}
+
+const testOracleContract = "CAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABSC4"
+
+func TestOracleFeed(t *testing.T) {
+	caller := &fakeOracleCaller{decimals: 14, priceData: makeTestPriceData(12345000000000, 1700000000)}
+	f, e := makeOracleFeedWithCaller(testOracleContract+"/XLM", caller)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, soroban.Vec(soroban.Symbol("Other"), soroban.Symbol("XLM")), f.asset)
+
+	price, ts, e := f.GetPriceWithTimestamp()
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.InDelta(t, 0.12345, price, 0.0000001)
+	assert.Equal(t, time.Unix(1700000000, 0), ts)
+
+	// the decimals are only fetched once
+	_, e = f.GetPrice()
+	assert.NoError(t, e)
+	assert.Equal(t, []string{"decimals/0", "lastprice/1", "lastprice/1"}, caller.calls)
+
+	// timestamps in milliseconds
+	caller.priceData = makeTestPriceData(100000000000000, 1700000000123)
+	price, ts, e = f.GetPriceWithTimestamp()
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.InDelta(t, 1.0, price, 0.0000001)
+	assert.Equal(t, time.Unix(1700000000, 123000000), ts)
+
+	caller.priceData = soroban.ScVal{Type: soroban.ScvVoid}
+	_, e = f.GetPrice()
+	assert.Error(t, e)
+	caller.priceData = makeTestPriceData(0, 1700000000)
+	_, e = f.GetPrice()
+	assert.Error(t, e)
+}
+
+func TestOracleFeed_CrossPrice(t *testing.T) {
+	caller := &fakeOracleCaller{decimals: 7, priceData: makeTestPriceData(25000000, 1700000000)}
+	f, e := makeOracleFeedWithCaller(testOracleContract+"/BTC/"+testOracleContract, caller)
+	if !assert.NoError(t, e) {
+		return
+	}
+	contract, _ := soroban.ParseAddress(testOracleContract)
+	assert.Equal(t, soroban.Vec(soroban.Symbol("Stellar"), soroban.AddressVal(contract)), *f.quoteAsset)
+
+	price, e := f.GetPrice()
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.InDelta(t, 2.5, price, 0.0000001)
+	assert.Equal(t, []string{"decimals/0", "x_last_price/2"}, caller.calls)
+}
+
+func TestMakeOracleFeed_Errors(t *testing.T) {
+	for _, url := range []string{
+		testOracleContract,
+		testOracleContract + "/BTC/USD/EUR",
+		"GAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAWHF/BTC",
+		"notacontract/BTC",
+		testOracleContract + "/",
+	} {
+		_, e := makeOracleFeedWithCaller(url, &fakeOracleCaller{})
+		assert.Error(t, e, url)
+	}
+
+	defer SetOracleConfig("", "")
+	SetOracleConfig("", "")
+	_, e := makeOracleFeed(testOracleContract + "/BTC")
+	assert.Error(t, e)
+}
@@ -18,6 +18,15 @@ type orderConstraintsFilter struct {
 }
 
 var _ SubmitFilter = &orderConstraintsFilter{}
+var _ OrderedSubmitFilter = &orderConstraintsFilter{}
+
+// FilterOrder impl.
+func (f *orderConstraintsFilter) FilterOrder() FilterOrder {
+	return FilterOrder{
+		ID:       filterIDOrderConstraints,
+		Priority: FilterPriorityConstraints,
+	}
+}
 
 // MakeFilterOrderConstraints makes a submit filter based on the passed in orderConstraints
 func MakeFilterOrderConstraints(
@@ -18,6 +18,8 @@ func MakeEmptyOrderConstraintsOverridesHandler() *OrderConstraintsOverridesHandl
 func MakeOrderConstraintsOverridesHandler(inputs map[model.TradingPair]model.OrderConstraints) *OrderConstraintsOverridesHandler {
 	overrides := map[string]*model.OrderConstraintsOverride{}
 	for p, oc := range inputs {
+		// copy the loop variable since the override holds pointers into it
+		oc := oc
 		overrides[p.String()] = model.MakeOrderConstraintsOverrideFromConstraints(&oc)
 	}
 
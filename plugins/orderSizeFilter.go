@@ -0,0 +1,274 @@
+package plugins
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// OrderSizeAction is what the order size filter does with ops that are larger than the max size
+type OrderSizeAction string
+
+// type of OrderSizeAction
+const (
+	OrderSizeActionCap   OrderSizeAction = "cap"   // reduce the amount of the op to the max size
+	OrderSizeActionSplit OrderSizeAction = "split" // split the op into equal ops that are each no larger than the max size
+)
+
+// maxOrderSizeSplits limits the number of ops that a single op is split into, any amount beyond maxOrderSizeSplits * maxBaseAmount is dropped
+const maxOrderSizeSplits = 10
+
+// orderSizeFilter enforces a min and max amount of the base asset on every op from the strategy. Ops smaller than the min (dust) are dropped
+// and ops larger than the max are capped or split. Existing offers that are not updated by the ops are left as they are.
+//
+// The offers of a split op are grouped by GroupOffers on the next update, so the strategy sees them as a single offer (the offer that the
+// first piece was placed on) and does not prune the other pieces. When the strategy updates that offer again the pieces are placed on the
+// other offers of the group instead of creating new offers
+type orderSizeFilter struct {
+	name          string
+	configValue   string
+	baseAsset     hProtocol.Asset
+	quoteAsset    hProtocol.Asset
+	minBaseAmount float64 // 0 means there is no min
+	maxBaseAmount float64 // 0 means there is no max
+	action        OrderSizeAction
+
+	// uninitialized
+	splitPriceKeys map[string]bool             // offerPriceKey of the ops that were split on the last update
+	groupedOffers  map[int64][]hProtocol.Offer // the other offers of each group, keyed by the ID of the offer that the strategy sees
+}
+
+// makeFilterOrderSize makes a submit filter that limits the size of each op in units of the base asset
+func makeFilterOrderSize(
+	configValue string,
+	baseAsset hProtocol.Asset,
+	quoteAsset hProtocol.Asset,
+	minBaseAmount float64,
+	maxBaseAmount float64,
+	action OrderSizeAction,
+) (SubmitFilter, error) {
+	if minBaseAmount < 0.0 {
+		return nil, fmt.Errorf("invalid min base amount, expected >= 0.0; was %f", minBaseAmount)
+	}
+	if maxBaseAmount < 0.0 {
+		return nil, fmt.Errorf("invalid max base amount, expected >= 0.0; was %f", maxBaseAmount)
+	}
+	if maxBaseAmount > 0.0 && minBaseAmount > maxBaseAmount {
+		return nil, fmt.Errorf("invalid order size bounds, min base amount (%f) needs to be <= max base amount (%f)", minBaseAmount, maxBaseAmount)
+	}
+	if action != OrderSizeActionCap && action != OrderSizeActionSplit {
+		return nil, fmt.Errorf("invalid action, needs to be either \"%s\" or \"%s\"; was \"%s\"", OrderSizeActionCap, OrderSizeActionSplit, action)
+	}
+
+	return &orderSizeFilter{
+		name:          "orderSizeFilter",
+		configValue:   configValue,
+		baseAsset:     baseAsset,
+		quoteAsset:    quoteAsset,
+		minBaseAmount: minBaseAmount,
+		maxBaseAmount: maxBaseAmount,
+		action:        action,
+	}, nil
+}
+
+var _ SubmitFilter = &orderSizeFilter{}
+var _ OrderedSubmitFilter = &orderSizeFilter{}
+var _ OfferGroupingFilter = &orderSizeFilter{}
+
+// FilterOrder impl.
+func (f *orderSizeFilter) FilterOrder() FilterOrder {
+	return FilterOrder{
+		ID:       filterIDOrderSize,
+		Priority: FilterPriorityAmount,
+		After:    priceFilterIDs,
+	}
+}
+
+// GroupOffers impl.
+func (f *orderSizeFilter) GroupOffers(sellingOffers []hProtocol.Offer, buyingOffers []hProtocol.Offer) ([]hProtocol.Offer, []hProtocol.Offer, []hProtocol.Offer) {
+	f.groupedOffers = map[int64][]hProtocol.Offer{}
+	grouped := []hProtocol.Offer{}
+	groupSide := func(offers []hProtocol.Offer) []hProtocol.Offer {
+		groupIdx := map[string]int{}
+		result := []hProtocol.Offer{}
+		for _, offer := range offers {
+			key := offerPriceKey(offer.Selling, offer.Price)
+			if !f.splitPriceKeys[key] {
+				result = append(result, offer)
+				continue
+			}
+
+			i, ok := groupIdx[key]
+			if !ok {
+				groupIdx[key] = len(result)
+				result = append(result, offer)
+				continue
+			}
+			// the strategy sees the total amount of the group on the first offer of the group
+			first := &result[i]
+			first.Amount = strconv.FormatFloat(utils.AmountStringAsFloat(first.Amount)+utils.AmountStringAsFloat(offer.Amount), 'f', int(sdexOrderConstraints.VolumePrecision), 64)
+			f.groupedOffers[first.ID] = append(f.groupedOffers[first.ID], offer)
+			grouped = append(grouped, offer)
+		}
+		return result
+	}
+
+	sellingOffers = groupSide(sellingOffers)
+	buyingOffers = groupSide(buyingOffers)
+	if len(grouped) > 0 {
+		log.Printf("orderSizeFilter: grouped %d offers of split ops into %d offers\n", len(grouped), len(f.groupedOffers))
+	}
+	return sellingOffers, buyingOffers, grouped
+}
+
+// Apply impl.
+func (f *orderSizeFilter) Apply(ops []txnbuild.Operation, sellingOffers []hProtocol.Offer, buyingOffers []hProtocol.Offer) ([]txnbuild.Operation, error) {
+	groupedOffers := f.groupedOffers
+	f.groupedOffers = map[int64][]hProtocol.Offer{}
+	f.splitPriceKeys = map[string]bool{}
+
+	// we do not use filterOps here because it maps every op to at most one op, whereas splitting an op produces many ops
+	filteredOps := []txnbuild.Operation{}
+	numDropped := 0
+	numSplit := 0
+	updatedGroups := map[int64]bool{}
+	for _, op := range ops {
+		mso, ok := op.(*txnbuild.ManageSellOffer)
+		if !ok {
+			filteredOps = append(filteredOps, op)
+			continue
+		}
+
+		newOps, e := f.orderSizeFilterFn(mso)
+		if e != nil {
+			return nil, fmt.Errorf("could not apply filter: %s", e)
+		}
+		if len(newOps) == 0 {
+			numDropped++
+		} else if len(newOps) > 1 {
+			numSplit++
+			f.splitPriceKeys[offerPriceKey(utils.Asset2Asset2(newOps[0].Selling), newOps[0].Price)] = true
+		}
+
+		if groupOffers, ok := groupedOffers[mso.OfferID]; ok && mso.OfferID != 0 {
+			// the pieces are placed on the other offers of the group, the offers that are left over are deleted
+			updatedGroups[mso.OfferID] = true
+			isDeleted := len(newOps) == 0 || newOps[0].Amount == "0"
+			for i, groupOffer := range groupOffers {
+				if !isDeleted && i+1 < len(newOps) {
+					newOps[i+1].OfferID = groupOffer.ID
+					continue
+				}
+				deleteOp := utils.Offer2TxnBuildSellOffer(groupOffer)
+				deleteOp.Amount = "0"
+				deleteOp.SourceAccount = mso.SourceAccount
+				filteredOps = append(filteredOps, &deleteOp)
+			}
+		}
+		for _, newOp := range newOps {
+			filteredOps = append(filteredOps, newOp)
+		}
+	}
+
+	// groups that the strategy did not update are kept as they are, unless the strategy pruned the offer that it saw for the group
+	remainingOfferIDs := map[int64]bool{}
+	for _, offer := range append(append([]hProtocol.Offer{}, sellingOffers...), buyingOffers...) {
+		remainingOfferIDs[offer.ID] = true
+	}
+	for offerID, groupOffers := range groupedOffers {
+		if updatedGroups[offerID] {
+			continue
+		}
+		if remainingOfferIDs[offerID] {
+			f.groupedOffers[offerID] = groupOffers
+			f.splitPriceKeys[offerPriceKey(groupOffers[0].Selling, groupOffers[0].Price)] = true
+			continue
+		}
+		for _, groupOffer := range groupOffers {
+			deleteOp := utils.Offer2TxnBuildSellOffer(groupOffer)
+			deleteOp.Amount = "0"
+			filteredOps = append(filteredOps, &deleteOp)
+		}
+	}
+	log.Printf("filter \"%s\" result: dropped %d and split %d of the %d ops passed in, len(filteredOps) = %d\n", f.name, numDropped, numSplit, len(ops), len(filteredOps))
+	return filteredOps, nil
+}
+
+// orderSizeFilterFn returns the ops that replace the op, which is empty when the op is dropped
+func (f *orderSizeFilter) orderSizeFilterFn(op *txnbuild.ManageSellOffer) ([]*txnbuild.ManageSellOffer, error) {
+	amount, e := strconv.ParseFloat(op.Amount, 64)
+	if e != nil {
+		return nil, fmt.Errorf("could not convert amount (%s) to float: %s", op.Amount, e)
+	}
+	if amount == 0.0 {
+		// delete ops are always kept
+		return []*txnbuild.ManageSellOffer{op}, nil
+	}
+
+	isSell, e := utils.IsSelling(f.baseAsset, f.quoteAsset, op.Selling, op.Buying)
+	if e != nil {
+		return nil, fmt.Errorf("error when running the isSelling check for offer '%+v': %s", *op, e)
+	}
+	// a buy op is denominated in the quote asset with the price inverted, so the amount of the base asset is the amount times the price
+	baseAmount := amount
+	if !isSell {
+		price, e := strconv.ParseFloat(op.Price, 64)
+		if e != nil {
+			return nil, fmt.Errorf("could not convert price (%s) to float: %s", op.Price, e)
+		}
+		baseAmount = amount * price
+	}
+
+	if f.minBaseAmount > 0.0 && baseAmount < f.minBaseAmount {
+		log.Printf("orderSizeFilter: isSell=%v, baseAmount=%.7f is below the min of %.7f, keep=false", isSell, baseAmount, f.minBaseAmount)
+		if op.OfferID == 0 {
+			return []*txnbuild.ManageSellOffer{}, nil
+		}
+		// the op was going to update an existing offer so we need to delete that offer instead of dropping the op
+		deleteOp := *op
+		deleteOp.Amount = "0"
+		return []*txnbuild.ManageSellOffer{&deleteOp}, nil
+	}
+	if f.maxBaseAmount == 0.0 || baseAmount <= f.maxBaseAmount {
+		return []*txnbuild.ManageSellOffer{op}, nil
+	}
+
+	numPieces := 1
+	if f.action == OrderSizeActionSplit {
+		numPieces = int(math.Ceil(baseAmount / f.maxBaseAmount))
+		if numPieces > maxOrderSizeSplits {
+			numPieces = maxOrderSizeSplits
+		}
+	}
+	pieceBaseAmount := math.Min(baseAmount/float64(numPieces), f.maxBaseAmount)
+	// equal pieces can be smaller than the min when the min is more than half of the max, so we cap the op instead
+	if pieceBaseAmount < f.minBaseAmount {
+		numPieces = 1
+		pieceBaseAmount = f.maxBaseAmount
+	}
+	pieceAmount := strconv.FormatFloat(amount*pieceBaseAmount/baseAmount, 'f', int(sdexOrderConstraints.VolumePrecision), 64)
+	log.Printf("orderSizeFilter: isSell=%v, baseAmount=%.7f is above the max of %.7f, action=%s, numPieces=%d, pieceBaseAmount=%.7f",
+		isSell, baseAmount, f.maxBaseAmount, f.action, numPieces, pieceBaseAmount)
+
+	newOps := []*txnbuild.ManageSellOffer{}
+	for i := 0; i < numPieces; i++ {
+		newOp := *op
+		newOp.Amount = pieceAmount
+		if i > 0 {
+			// only the first piece can update the existing offer, Apply places the other pieces on the other offers of its group if it has any
+			newOp.OfferID = 0
+		}
+		newOps = append(newOps, &newOp)
+	}
+	return newOps, nil
+}
+
+// String is the Stringer method
+func (f *orderSizeFilter) String() string {
+	return f.configValue
+}
@@ -0,0 +1,220 @@
+package plugins
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/kelp/support/utils"
+)
+
+func TestOrderSizeFilterFn(t *testing.T) {
+	testCases := []struct {
+		name    string
+		action  OrderSizeAction
+		op      *txnbuild.ManageSellOffer
+		wantOps []*txnbuild.ManageSellOffer
+	}{
+		{
+			name:    "sell inside bounds",
+			action:  OrderSizeActionCap,
+			op:      &txnbuild.ManageSellOffer{Selling: testBaseAsset, Buying: testQuoteAsset, Amount: "50.0", Price: "2.0"},
+			wantOps: []*txnbuild.ManageSellOffer{{Selling: testBaseAsset, Buying: testQuoteAsset, Amount: "50.0", Price: "2.0"}},
+		}, {
+			name:    "delete op kept",
+			action:  OrderSizeActionCap,
+			op:      &txnbuild.ManageSellOffer{Selling: testBaseAsset, Buying: testQuoteAsset, Amount: "0", Price: "2.0", OfferID: 3},
+			wantOps: []*txnbuild.ManageSellOffer{{Selling: testBaseAsset, Buying: testQuoteAsset, Amount: "0", Price: "2.0", OfferID: 3}},
+		}, {
+			name:    "new dust sell dropped",
+			action:  OrderSizeActionCap,
+			op:      &txnbuild.ManageSellOffer{Selling: testBaseAsset, Buying: testQuoteAsset, Amount: "5.0", Price: "2.0"},
+			wantOps: []*txnbuild.ManageSellOffer{},
+		}, {
+			name:    "dust update deletes offer",
+			action:  OrderSizeActionCap,
+			op:      &txnbuild.ManageSellOffer{Selling: testBaseAsset, Buying: testQuoteAsset, Amount: "5.0", Price: "2.0", OfferID: 3},
+			wantOps: []*txnbuild.ManageSellOffer{{Selling: testBaseAsset, Buying: testQuoteAsset, Amount: "0", Price: "2.0", OfferID: 3}},
+		}, {
+			name:    "sell capped",
+			action:  OrderSizeActionCap,
+			op:      &txnbuild.ManageSellOffer{Selling: testBaseAsset, Buying: testQuoteAsset, Amount: "250.0", Price: "2.0", OfferID: 3},
+			wantOps: []*txnbuild.ManageSellOffer{{Selling: testBaseAsset, Buying: testQuoteAsset, Amount: "100.0000000", Price: "2.0", OfferID: 3}},
+		}, {
+			name:   "sell split",
+			action: OrderSizeActionSplit,
+			op:     &txnbuild.ManageSellOffer{Selling: testBaseAsset, Buying: testQuoteAsset, Amount: "250.0", Price: "2.0", OfferID: 3},
+			wantOps: []*txnbuild.ManageSellOffer{
+				{Selling: testBaseAsset, Buying: testQuoteAsset, Amount: "83.3333333", Price: "2.0", OfferID: 3},
+				{Selling: testBaseAsset, Buying: testQuoteAsset, Amount: "83.3333333", Price: "2.0"},
+				{Selling: testBaseAsset, Buying: testQuoteAsset, Amount: "83.3333333", Price: "2.0"},
+			},
+		}, {
+			name:   "sell split limited to max splits",
+			action: OrderSizeActionSplit,
+			op:     &txnbuild.ManageSellOffer{Selling: testBaseAsset, Buying: testQuoteAsset, Amount: "5000.0", Price: "2.0"},
+			wantOps: []*txnbuild.ManageSellOffer{
+				{Selling: testBaseAsset, Buying: testQuoteAsset, Amount: "100.0000000", Price: "2.0"},
+				{Selling: testBaseAsset, Buying: testQuoteAsset, Amount: "100.0000000", Price: "2.0"},
+				{Selling: testBaseAsset, Buying: testQuoteAsset, Amount: "100.0000000", Price: "2.0"},
+				{Selling: testBaseAsset, Buying: testQuoteAsset, Amount: "100.0000000", Price: "2.0"},
+				{Selling: testBaseAsset, Buying: testQuoteAsset, Amount: "100.0000000", Price: "2.0"},
+				{Selling: testBaseAsset, Buying: testQuoteAsset, Amount: "100.0000000", Price: "2.0"},
+				{Selling: testBaseAsset, Buying: testQuoteAsset, Amount: "100.0000000", Price: "2.0"},
+				{Selling: testBaseAsset, Buying: testQuoteAsset, Amount: "100.0000000", Price: "2.0"},
+				{Selling: testBaseAsset, Buying: testQuoteAsset, Amount: "100.0000000", Price: "2.0"},
+				{Selling: testBaseAsset, Buying: testQuoteAsset, Amount: "100.0000000", Price: "2.0"},
+			},
+		}, {
+			// buy op of 300 quote at a price of 1/0.5 = 2.0 quote per base, buying 150 base
+			name:   "buy split",
+			action: OrderSizeActionSplit,
+			op:     &txnbuild.ManageSellOffer{Selling: testQuoteAsset, Buying: testBaseAsset, Amount: "300.0", Price: "0.5"},
+			wantOps: []*txnbuild.ManageSellOffer{
+				{Selling: testQuoteAsset, Buying: testBaseAsset, Amount: "150.0000000", Price: "0.5"},
+				{Selling: testQuoteAsset, Buying: testBaseAsset, Amount: "150.0000000", Price: "0.5"},
+			},
+		}, {
+			// buy op of 15 quote at a price of 1/0.5 = 2.0 quote per base, buying 7.5 base
+			name:    "dust buy dropped",
+			action:  OrderSizeActionSplit,
+			op:      &txnbuild.ManageSellOffer{Selling: testQuoteAsset, Buying: testBaseAsset, Amount: "15.0", Price: "0.5"},
+			wantOps: []*txnbuild.ManageSellOffer{},
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			f := &orderSizeFilter{
+				name:          "orderSizeFilter",
+				baseAsset:     utils.Asset2Asset2(testBaseAsset),
+				quoteAsset:    utils.Asset2Asset2(testQuoteAsset),
+				minBaseAmount: 10.0,
+				maxBaseAmount: 100.0,
+				action:        k.action,
+			}
+
+			actual, e := f.orderSizeFilterFn(k.op)
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, k.wantOps, actual)
+		})
+	}
+}
+
+func TestOrderSizeFilterFn_SplitBelowMin(t *testing.T) {
+	// splitting 150 base into 2 pieces of 75 would make pieces below the min of 80, so the op is capped instead
+	f := &orderSizeFilter{
+		name:          "orderSizeFilter",
+		baseAsset:     utils.Asset2Asset2(testBaseAsset),
+		quoteAsset:    utils.Asset2Asset2(testQuoteAsset),
+		minBaseAmount: 80.0,
+		maxBaseAmount: 100.0,
+		action:        OrderSizeActionSplit,
+	}
+
+	actual, e := f.orderSizeFilterFn(&txnbuild.ManageSellOffer{Selling: testBaseAsset, Buying: testQuoteAsset, Amount: "150.0", Price: "2.0"})
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, []*txnbuild.ManageSellOffer{
+		{Selling: testBaseAsset, Buying: testQuoteAsset, Amount: "100.0000000", Price: "2.0"},
+	}, actual)
+}
+
+func TestMakeFilterOrderSize_Invalid(t *testing.T) {
+	base := utils.Asset2Asset2(testBaseAsset)
+	quote := utils.Asset2Asset2(testQuoteAsset)
+
+	_, e := makeFilterOrderSize("", base, quote, -1.0, 100.0, OrderSizeActionCap)
+	assert.Error(t, e)
+	_, e = makeFilterOrderSize("", base, quote, 200.0, 100.0, OrderSizeActionCap)
+	assert.Error(t, e)
+	_, e = makeFilterOrderSize("", base, quote, 10.0, 100.0, OrderSizeAction("drop"))
+	assert.Error(t, e)
+	_, e = makeFilterOrderSize("", base, quote, 200.0, 0.0, OrderSizeActionSplit)
+	assert.NoError(t, e)
+}
+
+func TestOrderSizeFilterGroupOffers(t *testing.T) {
+	f, e := makeFilterOrderSize("", utils.Asset2Asset2(testBaseAsset), utils.Asset2Asset2(testQuoteAsset), 10.0, 100.0, OrderSizeActionSplit)
+	if !assert.NoError(t, e) {
+		return
+	}
+	grouping := f.(*orderSizeFilter)
+	makeOffer := func(id int64, amount string, price string) hProtocol.Offer {
+		return hProtocol.Offer{ID: id, Selling: utils.Asset2Asset2(testBaseAsset), Buying: utils.Asset2Asset2(testQuoteAsset), Amount: amount, Price: price}
+	}
+
+	// the op is split into 3 new offers
+	actual, e := f.Apply([]txnbuild.Operation{
+		&txnbuild.ManageSellOffer{Selling: testBaseAsset, Buying: testQuoteAsset, Amount: "250.0", Price: "2.0"},
+	}, nil, nil)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, 3, len(actual))
+
+	// the strategy sees the 3 offers as the first one, the offer at another price is not grouped
+	selling, buying, grouped := grouping.GroupOffers([]hProtocol.Offer{
+		makeOffer(5, "83.3333333", "2.0000000"),
+		makeOffer(6, "83.3333333", "2.0000000"),
+		makeOffer(7, "83.3333333", "2.0000000"),
+		makeOffer(8, "50.0000000", "3.0000000"),
+	}, []hProtocol.Offer{})
+	assert.Equal(t, []hProtocol.Offer{makeOffer(5, "249.9999999", "2.0000000"), makeOffer(8, "50.0000000", "3.0000000")}, selling)
+	assert.Equal(t, []hProtocol.Offer{}, buying)
+	assert.Equal(t, []hProtocol.Offer{makeOffer(6, "83.3333333", "2.0000000"), makeOffer(7, "83.3333333", "2.0000000")}, grouped)
+
+	// updating the offer places the pieces on the offers of the group and deletes the offer that is left over
+	actual, e = f.Apply([]txnbuild.Operation{
+		&txnbuild.ManageSellOffer{Selling: testBaseAsset, Buying: testQuoteAsset, Amount: "200.0", Price: "2.0", OfferID: 5},
+	}, selling, buying)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, []txnbuild.Operation{
+		&txnbuild.ManageSellOffer{Selling: testBaseAsset, Buying: testQuoteAsset, Amount: "0", Price: "2.0000000", OfferID: 7},
+		&txnbuild.ManageSellOffer{Selling: testBaseAsset, Buying: testQuoteAsset, Amount: "100.0000000", Price: "2.0", OfferID: 5},
+		&txnbuild.ManageSellOffer{Selling: testBaseAsset, Buying: testQuoteAsset, Amount: "100.0000000", Price: "2.0", OfferID: 6},
+	}, actual)
+
+	// the group is kept while the strategy keeps the offer that it sees
+	selling, buying, grouped = grouping.GroupOffers([]hProtocol.Offer{makeOffer(5, "100.0000000", "2.0000000"), makeOffer(6, "100.0000000", "2.0000000")}, []hProtocol.Offer{})
+	assert.Equal(t, []hProtocol.Offer{makeOffer(5, "200.0000000", "2.0000000")}, selling)
+	assert.Equal(t, []hProtocol.Offer{makeOffer(6, "100.0000000", "2.0000000")}, grouped)
+	actual, e = f.Apply(nil, selling, buying)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, []txnbuild.Operation{}, actual)
+
+	// the other offers of the group are deleted once the strategy prunes the offer that it sees
+	selling, buying, _ = grouping.GroupOffers([]hProtocol.Offer{makeOffer(5, "100.0000000", "2.0000000"), makeOffer(6, "100.0000000", "2.0000000")}, []hProtocol.Offer{})
+	assert.Equal(t, 1, len(selling))
+	actual, e = f.Apply(nil, []hProtocol.Offer{}, buying)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, []txnbuild.Operation{
+		&txnbuild.ManageSellOffer{Selling: testBaseAsset, Buying: testQuoteAsset, Amount: "0", Price: "2.0000000", OfferID: 6},
+	}, actual)
+}
+
+func TestFindOfferGroupingFilters(t *testing.T) {
+	f, e := makeFilterOrderSize("", utils.Asset2Asset2(testBaseAsset), utils.Asset2Asset2(testQuoteAsset), 10.0, 100.0, OrderSizeActionSplit)
+	if !assert.NoError(t, e) {
+		return
+	}
+	volatility, e := makeFilterVolatility("", utils.Asset2Asset2(testBaseAsset), utils.Asset2Asset2(testQuoteAsset), nil, 2, 0.05, VolatilityActionWiden, 2.0, &fixedFeed{price: 1.0})
+	if !assert.NoError(t, e) {
+		return
+	}
+
+	// the filter is found through the wrapper of the filter chain, the filters that do not group offers are skipped
+	groupingFilters := FindOfferGroupingFilters([]SubmitFilter{MakeConfiguredFilter(volatility, "", nil), MakeConfiguredFilter(f, "", nil)})
+	assert.Equal(t, []OfferGroupingFilter{f.(OfferGroupingFilter)}, groupingFilters)
+}
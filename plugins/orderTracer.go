@@ -0,0 +1,567 @@
+package plugins
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/kelpdb"
+	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// the stages in the lifecycle of an offer, which are the values of the stage column in the order_traces table
+const (
+	orderTraceStageStrategy       = "strategy"        // the strategy decided on the op
+	orderTraceStageFilterModified = "filter_modified" // a filter changed the price or amount of the op
+	orderTraceStageFilterDropped  = "filter_dropped"  // a filter dropped the op
+	orderTraceStageFilterAdded    = "filter_added"    // a filter added the op, such as a delete op for an existing offer or a piece of a split
+	orderTraceStageSubmitted      = "submitted"       // the op was submitted in a transaction that succeeded
+	orderTraceStageSubmitFailed   = "submit_failed"   // the op was submitted in a transaction that failed
+	orderTraceStageOfferOpen      = "offer_open"      // the offer created by the op was seen on the orderbook
+	orderTraceStageOfferMissing   = "offer_missing"   // the offer created by the op was never seen on the orderbook
+	orderTraceStageOfferClosed    = "offer_closed"    // the offer is no longer on the orderbook because it was filled, deleted or replaced
+	orderTraceStageFill           = "fill"            // the offer was filled, fully or partially
+)
+
+// orderTraceSideBuy and orderTraceSideSell are the values of the side column in the order_traces table
+const (
+	orderTraceSideBuy  = "buy"
+	orderTraceSideSell = "sell"
+)
+
+// orderTracePendingTimeout is how long a new offer that was submitted can take to show up on the orderbook before it is reported as
+// missing, which happens when the offer was filled in full when it was created
+const orderTracePendingTimeout = 5 * time.Minute
+
+// orderTraceClosedRetention is how long the correlation ID of an offer that is no longer on the orderbook is kept to match its late fills
+const orderTraceClosedRetention = time.Hour
+
+// orderTraceFlushInterval is how often the events that were traced are written to the db and orderTraceMaxPending is how many events are
+// kept while the db cannot be reached before the oldest events are dropped
+const (
+	orderTraceFlushInterval = 5 * time.Second
+	orderTraceMaxPending    = 10000
+)
+
+// orderTracePriceTolerance is the relative difference allowed between the price of an op and the price of the offer it created, since the
+// price of an offer on the SDEX is a rational approximation of the price of the op
+const orderTracePriceTolerance = 0.0001
+
+// OrderTracer assigns a correlation ID to every op that the strategy decides on and follows it through the filters, the submission of the
+// transaction, the offer on the orderbook and the fills of the offer. Every step is logged with the correlation ID and written to the
+// order_traces table so the full lifecycle of any offer can be looked up by its correlation ID or offer ID.
+//
+// The events are written to the db in a single transaction every orderTraceFlushInterval by the task that the tracer registers with
+// registerScheduledTask, so the update cycle does not wait on the db. The events traced since the last write are lost when the bot stops
+type OrderTracer struct {
+	db         *sql.DB
+	accountID  string
+	marketID   string
+	baseAsset  hProtocol.Asset
+	quoteAsset hProtocol.Asset
+	clock      api.Clock
+
+	// uninitialized
+	mutex          *sync.Mutex
+	nextSeq        uint64
+	cycleID        string
+	currentIDs     []string                  // correlation IDs of the ops passed to the next filter, in the same order
+	pendingCreates []*orderTracePendingOffer // new offers that were submitted but not yet seen on the orderbook
+	offerIDs       map[string]string         // offer ID -> correlation ID of the offers that are or were recently on the orderbook
+	openOfferIDs   map[string]bool           // offer IDs that were on the orderbook in the last update cycle
+	closedAt       map[string]time.Time      // offer ID -> time when the offer was no longer seen on the orderbook
+	pendingEvents  []*orderTraceEvent        // events that were not written to the db yet
+}
+
+var _ api.FillHandler = &OrderTracer{}
+
+// orderTracePendingOffer is a new offer that was submitted and is matched to the offer that shows up on the orderbook by its side and price
+type orderTracePendingOffer struct {
+	correlationID string
+	side          string
+	price         float64
+	submittedAt   time.Time
+}
+
+// orderTraceEvent is a row in the order_traces table
+type orderTraceEvent struct {
+	at            time.Time
+	correlationID string
+	stage         string
+	side          string
+	offerID       string
+	price         float64 // price of the base asset in units of the quote asset
+	amount        float64 // amount of the base asset
+	detail        string
+}
+
+// MakeOrderTracer is a factory method
+func MakeOrderTracer(db *sql.DB, accountID string, marketID string, baseAsset hProtocol.Asset, quoteAsset hProtocol.Asset, clock api.Clock) (*OrderTracer, error) {
+	if db == nil {
+		return nil, fmt.Errorf("TRACE_ORDERS needs POSTGRES_DB to be set in the trader config")
+	}
+	if accountID == "" {
+		return nil, fmt.Errorf("TRACE_ORDERS needs DB_OVERRIDE__ACCOUNT_ID to be set in the trader config")
+	}
+
+	t := &OrderTracer{
+		db:         db,
+		accountID:  accountID,
+		marketID:   marketID,
+		baseAsset:  baseAsset,
+		quoteAsset: quoteAsset,
+		clock:      clock,
+		mutex:      &sync.Mutex{},
+		currentIDs: []string{},
+		offerIDs:   map[string]string{},
+		// pendingCreates, openOfferIDs, closedAt and pendingEvents are initialized lazily
+	}
+	registerScheduledTask(ScheduledTask{
+		Name:     "order_traces_flush",
+		Interval: orderTraceFlushInterval,
+		Fn:       t.flush,
+	})
+	return t, nil
+}
+
+// StartCycle assigns a new correlation ID to every op that the strategy decided on in this update cycle, it should be called with the ops
+// in the same order as they are passed to the first filter
+func (t *OrderTracer) StartCycle(msos []*txnbuild.ManageSellOffer) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.cycleID = strconv.FormatInt(t.clock.Now().UnixNano()/int64(time.Millisecond), 10)
+	t.nextSeq = 0
+	t.currentIDs = []string{}
+	for _, mso := range msos {
+		correlationID := t.newCorrelationID()
+		t.currentIDs = append(t.currentIDs, correlationID)
+
+		detail := ""
+		if mso.OfferID != 0 {
+			offerID := strconv.FormatInt(mso.OfferID, 10)
+			detail = fmt.Sprintf("updates offer %s", offerID)
+			if previousID, ok := t.offerIDs[offerID]; ok {
+				detail = fmt.Sprintf("updates offer %s of %s", offerID, previousID)
+			}
+		}
+		t.recordOp(correlationID, orderTraceStageStrategy, mso, detail)
+	}
+}
+
+// WrapFilters wraps every filter so the changes that it makes to the ops are traced, it should be called after FilterMetrics.Wrap so the
+// filters are labelled with their position in the chain
+func (t *OrderTracer) WrapFilters(filters []SubmitFilter) []SubmitFilter {
+	wrapped := []SubmitFilter{}
+	for _, filter := range filters {
+		wrapped = append(wrapped, &tracedFilter{
+			inner:  filter,
+			label:  filterLabel(filter),
+			tracer: t,
+		})
+	}
+	return wrapped
+}
+
+// applyFilter carries the correlation IDs of the ops passed into a filter over to the ops returned by it
+func (t *OrderTracer) applyFilter(label string, before []txnbuild.Operation, after []txnbuild.Operation) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if len(t.currentIDs) != len(before) {
+		// the ops were not passed through StartCycle, such as when a filter is applied outside of an update cycle
+		t.currentIDs = []string{}
+		for range before {
+			t.currentIDs = append(t.currentIDs, t.newCorrelationID())
+		}
+	}
+
+	m := matchOps(before, after)
+	newIDs := make([]string, len(after))
+	for j, i := range m.kept {
+		newIDs[j] = t.currentIDs[i]
+	}
+	for j, i := range m.modified {
+		newIDs[j] = t.currentIDs[i]
+		if mso, ok := after[j].(*txnbuild.ManageSellOffer); ok {
+			detail := fmt.Sprintf("filter %s", label)
+			if msoBefore, ok := before[i].(*txnbuild.ManageSellOffer); ok {
+				detail = fmt.Sprintf("filter %s changed price from %s to %s and amount from %s to %s", label, msoBefore.Price, mso.Price, msoBefore.Amount, mso.Amount)
+			}
+			t.recordOp(newIDs[j], orderTraceStageFilterModified, mso, detail)
+		}
+	}
+	for _, j := range m.added {
+		newIDs[j] = t.newCorrelationID()
+		if mso, ok := after[j].(*txnbuild.ManageSellOffer); ok {
+			t.recordOp(newIDs[j], orderTraceStageFilterAdded, mso, fmt.Sprintf("filter %s", label))
+		}
+	}
+	for _, i := range m.dropped {
+		if mso, ok := before[i].(*txnbuild.ManageSellOffer); ok {
+			t.recordOp(t.currentIDs[i], orderTraceStageFilterDropped, mso, fmt.Sprintf("filter %s", label))
+		}
+	}
+	t.currentIDs = newIDs
+}
+
+// Submitting should be called with the ops returned by the last filter before they are submitted, it returns the function that records
+// the result of the submission, which should be called from the callback of the submission
+func (t *OrderTracer) Submitting(ops []txnbuild.Operation) func(hash string, e error) {
+	t.mutex.Lock()
+	ids := t.currentIDs
+	if len(ids) != len(ops) {
+		ids = []string{}
+		for range ops {
+			ids = append(ids, t.newCorrelationID())
+		}
+	}
+	// the next update cycle replaces the currentIDs so the callback keeps its own copy
+	t.currentIDs = []string{}
+	t.mutex.Unlock()
+
+	return t.makeSubmitCallback(ops, ids)
+}
+
+// makeSubmitCallback returns the function that records the result of the submission of the ops with the correlation IDs
+func (t *OrderTracer) makeSubmitCallback(ops []txnbuild.Operation, ids []string) func(hash string, e error) {
+	return func(hash string, e error) {
+		t.mutex.Lock()
+		defer t.mutex.Unlock()
+
+		for i, op := range ops {
+			mso, ok := op.(*txnbuild.ManageSellOffer)
+			if !ok {
+				continue
+			}
+
+			if e != nil {
+				t.recordOp(ids[i], orderTraceStageSubmitFailed, mso, fmt.Sprintf("tx %s: %s", hash, e))
+				continue
+			}
+			t.recordOp(ids[i], orderTraceStageSubmitted, mso, fmt.Sprintf("tx %s", hash))
+
+			if mso.Amount == "0" {
+				// the offer keeps its correlation ID so it is closed under it on the next update cycle
+				continue
+			}
+			if mso.OfferID != 0 {
+				// an update keeps the ID of the offer so the offer now belongs to this correlation ID
+				t.offerIDs[strconv.FormatInt(mso.OfferID, 10)] = ids[i]
+			} else {
+				side, price, _ := t.opSidePriceAmount(mso)
+				t.pendingCreates = append(t.pendingCreates, &orderTracePendingOffer{
+					correlationID: ids[i],
+					side:          side,
+					price:         price,
+					submittedAt:   t.clock.Now(),
+				})
+			}
+		}
+	}
+}
+
+// ObserveOffers matches the offers on the orderbook to the new offers that were submitted and records the offers that are no longer on
+// the orderbook, it should be called with the offers of the account at the start of every update cycle
+func (t *OrderTracer) ObserveOffers(sellingOffers []hProtocol.Offer, buyingOffers []hProtocol.Offer) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	now := t.clock.Now()
+	open := map[string]bool{}
+	t.observeSide(sellingOffers, orderTraceSideSell, open)
+	t.observeSide(buyingOffers, orderTraceSideBuy, open)
+
+	for offerID := range t.openOfferIDs {
+		if open[offerID] {
+			continue
+		}
+		if correlationID, ok := t.offerIDs[offerID]; ok {
+			t.record(&orderTraceEvent{
+				correlationID: correlationID,
+				stage:         orderTraceStageOfferClosed,
+				offerID:       offerID,
+				detail:        "offer is no longer on the orderbook",
+			})
+		}
+		if t.closedAt == nil {
+			t.closedAt = map[string]time.Time{}
+		}
+		t.closedAt[offerID] = now
+	}
+	t.openOfferIDs = open
+
+	stillPending := []*orderTracePendingOffer{}
+	for _, p := range t.pendingCreates {
+		if now.Sub(p.submittedAt) < orderTracePendingTimeout {
+			stillPending = append(stillPending, p)
+			continue
+		}
+		t.record(&orderTraceEvent{
+			correlationID: p.correlationID,
+			stage:         orderTraceStageOfferMissing,
+			side:          p.side,
+			price:         p.price,
+			detail:        fmt.Sprintf("offer was not seen on the orderbook within %s, it may have been filled in full when it was created", orderTracePendingTimeout),
+		})
+	}
+	t.pendingCreates = stillPending
+
+	for offerID, closedAt := range t.closedAt {
+		if now.Sub(closedAt) >= orderTraceClosedRetention {
+			delete(t.offerIDs, offerID)
+			delete(t.closedAt, offerID)
+		}
+	}
+}
+
+// observeSide needs to be called while holding the mutex
+func (t *OrderTracer) observeSide(offers []hProtocol.Offer, side string, open map[string]bool) {
+	for _, offer := range offers {
+		offerID := strconv.FormatInt(offer.ID, 10)
+		open[offerID] = true
+		delete(t.closedAt, offerID)
+		if _, ok := t.offerIDs[offerID]; ok {
+			continue
+		}
+
+		price, amount := t.offerPriceAmount(offer, side)
+		p := t.popPendingCreate(side, price)
+		if p == nil {
+			// the offer was not created by an op that was traced, such as an offer from before the bot was started
+			continue
+		}
+		t.offerIDs[offerID] = p.correlationID
+		t.record(&orderTraceEvent{
+			correlationID: p.correlationID,
+			stage:         orderTraceStageOfferOpen,
+			side:          side,
+			offerID:       offerID,
+			price:         price,
+			amount:        amount,
+			detail:        fmt.Sprintf("offer seen on the orderbook %s after it was submitted", t.clock.Now().Sub(p.submittedAt).Truncate(time.Millisecond)),
+		})
+	}
+}
+
+// popPendingCreate removes and returns the oldest pending new offer on the side with the price, or nil if there is none
+func (t *OrderTracer) popPendingCreate(side string, price float64) *orderTracePendingOffer {
+	for i, p := range t.pendingCreates {
+		if p.side != side || p.price == 0 {
+			continue
+		}
+		if math.Abs(price-p.price)/p.price > orderTracePriceTolerance {
+			continue
+		}
+		t.pendingCreates = append(t.pendingCreates[:i], t.pendingCreates[i+1:]...)
+		return p
+	}
+	return nil
+}
+
+// HandleFill impl., the fill is matched to the correlation ID of the offer by the OrderID of the trade
+func (t *OrderTracer) HandleFill(trade model.Trade) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	correlationID, ok := t.offerIDs[trade.OrderID]
+	if !ok {
+		return nil
+	}
+
+	side := orderTraceSideSell
+	if trade.OrderAction.IsBuy() {
+		side = orderTraceSideBuy
+	}
+	t.record(&orderTraceEvent{
+		correlationID: correlationID,
+		stage:         orderTraceStageFill,
+		side:          side,
+		offerID:       trade.OrderID,
+		price:         trade.Price.AsFloat(),
+		amount:        trade.Volume.AsFloat(),
+		detail:        fmt.Sprintf("trade %s", utils.CheckedString(trade.TransactionID)),
+	})
+	return nil
+}
+
+// newCorrelationID needs to be called while holding the mutex, IDs are unique across restarts because they start with the time of the
+// update cycle in millis
+func (t *OrderTracer) newCorrelationID() string {
+	if t.cycleID == "" {
+		t.cycleID = strconv.FormatInt(t.clock.Now().UnixNano()/int64(time.Millisecond), 10)
+	}
+	t.nextSeq++
+	return fmt.Sprintf("%s-%d", t.cycleID, t.nextSeq)
+}
+
+// recordOp needs to be called while holding the mutex
+func (t *OrderTracer) recordOp(correlationID string, stage string, mso *txnbuild.ManageSellOffer, detail string) {
+	side, price, amount := t.opSidePriceAmount(mso)
+	offerID := ""
+	if mso.OfferID != 0 {
+		offerID = strconv.FormatInt(mso.OfferID, 10)
+	}
+	t.record(&orderTraceEvent{
+		correlationID: correlationID,
+		stage:         stage,
+		side:          side,
+		offerID:       offerID,
+		price:         price,
+		amount:        amount,
+		detail:        detail,
+	})
+}
+
+// opSidePriceAmount converts the price and amount of the op with sidePriceAmountOfOp
+func (t *OrderTracer) opSidePriceAmount(mso *txnbuild.ManageSellOffer) (string, float64, float64) {
+	return sidePriceAmountOfOp(t.baseAsset, t.quoteAsset, mso)
+}
+
+// sidePriceAmountOfOp converts the price and amount of the op to the price of the base asset in units of the quote asset and the amount
+// of the base asset, the price and amount are 0 when they cannot be parsed
+func sidePriceAmountOfOp(baseAsset hProtocol.Asset, quoteAsset hProtocol.Asset, mso *txnbuild.ManageSellOffer) (string, float64, float64) {
+	isSell, e := utils.IsSelling(baseAsset, quoteAsset, mso.Selling, mso.Buying)
+	if e != nil {
+		return "", 0, 0
+	}
+	price, _ := strconv.ParseFloat(mso.Price, 64)
+	amount, _ := strconv.ParseFloat(mso.Amount, 64)
+	if isSell {
+		return orderTraceSideSell, price, amount
+	}
+	return orderTraceSideBuy, invertOrZero(price), amount * price
+}
+
+// offerPriceAmount converts the price and amount of the offer like opSidePriceAmount
+func (t *OrderTracer) offerPriceAmount(offer hProtocol.Offer, side string) (float64, float64) {
+	price, _ := strconv.ParseFloat(offer.Price, 64)
+	amount, _ := strconv.ParseFloat(offer.Amount, 64)
+	if side == orderTraceSideSell {
+		return price, amount
+	}
+	return invertOrZero(price), amount * price
+}
+
+func invertOrZero(v float64) float64 {
+	if v == 0 {
+		return 0
+	}
+	return 1 / v
+}
+
+// record logs the event and adds it to the events that are written to the db with the next flush, it needs to be called while holding the
+// mutex
+func (t *OrderTracer) record(ev *orderTraceEvent) {
+	log.Printf("order trace %s: stage=%s, side=%s, offerID=%s, price=%.8f, amount=%.8f, %s\n", ev.correlationID, ev.stage, ev.side, ev.offerID, ev.price, ev.amount, ev.detail)
+	ev.at = t.clock.Now()
+	t.addPendingEvents([]*orderTraceEvent{ev})
+}
+
+// addPendingEvents appends the events to the events that were not written yet and drops the oldest events beyond orderTraceMaxPending, needs
+// to hold the mutex
+func (t *OrderTracer) addPendingEvents(events []*orderTraceEvent) {
+	t.pendingEvents = append(t.pendingEvents, events...)
+	if len(t.pendingEvents) > orderTraceMaxPending {
+		numDropped := len(t.pendingEvents) - orderTraceMaxPending
+		log.Printf("dropping the %d oldest order traces that could not be written to the db\n", numDropped)
+		t.pendingEvents = t.pendingEvents[numDropped:]
+	}
+}
+
+// flush writes the events traced since the last flush to the db in one transaction, the events are added back in front of the pending events
+// when the write fails so they are retried with the next flush. The error is only reported to the scheduler because tracing should never
+// stop the bot
+func (t *OrderTracer) flush(now time.Time) error {
+	t.mutex.Lock()
+	batch := t.pendingEvents
+	t.pendingEvents = nil
+	t.mutex.Unlock()
+	if len(batch) == 0 {
+		return nil
+	}
+
+	e := t.write(batch)
+	if e != nil {
+		t.mutex.Lock()
+		defer t.mutex.Unlock()
+		pending := t.pendingEvents
+		t.pendingEvents = nil
+		t.addPendingEvents(append(batch, pending...))
+		return fmt.Errorf("could not write %d order traces to the db: %s", len(batch), e)
+	}
+	return nil
+}
+
+func (t *OrderTracer) write(batch []*orderTraceEvent) error {
+	tx, e := t.db.Begin()
+	if e != nil {
+		return fmt.Errorf("could not begin transaction: %s", e)
+	}
+	for _, ev := range batch {
+		_, e = tx.Exec(kelpdb.SqlOrderTracesInsert,
+			t.accountID,
+			t.marketID,
+			ev.correlationID,
+			ev.at.UTC(),
+			ev.stage,
+			ev.side,
+			ev.offerID,
+			ev.price,
+			ev.amount,
+			ev.detail,
+		)
+		if e != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("could not insert order trace %s at stage %s: %s", ev.correlationID, ev.stage, e)
+		}
+	}
+	e = tx.Commit()
+	if e != nil {
+		return fmt.Errorf("could not commit transaction: %s", e)
+	}
+	return nil
+}
+
+// tracedFilter carries the correlation IDs of the ops through the inner filter
+type tracedFilter struct {
+	inner  SubmitFilter
+	label  string
+	tracer *OrderTracer
+}
+
+var _ SubmitFilter = &tracedFilter{}
+var _ OrderedSubmitFilter = &tracedFilter{}
+
+// FilterOrder impl.
+func (f *tracedFilter) FilterOrder() FilterOrder {
+	return getFilterOrder(f.inner)
+}
+
+// Unwrap impl.
+func (f *tracedFilter) Unwrap() SubmitFilter {
+	return f.inner
+}
+
+// Apply impl.
+func (f *tracedFilter) Apply(ops []txnbuild.Operation, sellingOffers []hProtocol.Offer, buyingOffers []hProtocol.Offer) ([]txnbuild.Operation, error) {
+	filteredOps, e := f.inner.Apply(ops, sellingOffers, buyingOffers)
+	if e != nil {
+		return nil, e
+	}
+	f.tracer.applyFilter(f.label, ops, filteredOps)
+	return filteredOps, nil
+}
+
+// String is the Stringer method
+func (f *tracedFilter) String() string {
+	return f.label
+}
@@ -0,0 +1,126 @@
+package plugins
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+)
+
+// ExchangePairWhitelist is the list of pairs that can be traded with an account on an exchange
+type ExchangePairWhitelist struct {
+	Exchange string
+	Account  string // the API key on the exchange, empty matches any account
+	Pairs    []WhitelistPair
+}
+
+// exchangePairWhitelist is checked by every exchange made by MakeTradingExchange when set, nil means there is no whitelist
+var exchangePairWhitelist []ExchangePairWhitelist
+var exchangePairWhitelistAlert api.Alert
+
+// SetExchangePairWhitelist makes every exchange that is made by MakeTradingExchange after this is called refuse orders on pairs that are not
+// whitelisted for the exchange and API key, so orders placed directly on an exchange (such as the offsets of the mirror strategy on its backing
+// exchanges) cannot get around the PAIR_WHITELIST. Passing nil turns the whitelist off for exchanges made after this call.
+func SetExchangePairWhitelist(whitelist []ExchangePairWhitelist, alert api.Alert) {
+	exchangePairWhitelist = whitelist
+	exchangePairWhitelistAlert = alert
+}
+
+// maybeWrapPairWhitelistExchange wraps the exchange when a pair whitelist is set
+func maybeWrapPairWhitelistExchange(exchangeType string, apiKeys []api.ExchangeAPIKey, x api.Exchange) api.Exchange {
+	if exchangePairWhitelist == nil {
+		return x
+	}
+
+	account := ""
+	if len(apiKeys) > 0 {
+		account = apiKeys[0].Key
+	}
+	// an exchange and account without an entry in the whitelist cannot trade any pairs, the same as the bot's own trading exchange
+	pairs := []WhitelistPair{}
+	for _, w := range exchangePairWhitelist {
+		if w.Exchange == exchangeType && (w.Account == "" || w.Account == account) {
+			pairs = append(pairs, w.Pairs...)
+		}
+	}
+	return makePairWhitelistExchange(exchangeType, x, pairs, exchangePairWhitelistAlert)
+}
+
+// pairWhitelistExchange refuses to add orders on pairs that are not in the whitelist, every other call is passed through by the embedded
+// api.Exchange. Cancelling orders is always allowed since it can only reduce our exposure.
+type pairWhitelistExchange struct {
+	api.Exchange
+	exchangeType string
+	whitelist    []WhitelistPair
+	alert        api.Alert
+}
+
+// pairWhitelistFeeExchange is used when the inner exchange can fetch fees so the wrapper keeps satisfying api.TradingFeeFetcher
+type pairWhitelistFeeExchange struct {
+	*pairWhitelistExchange
+	feeFetcher api.TradingFeeFetcher
+}
+
+var _ api.Exchange = &pairWhitelistExchange{}
+var _ api.APIKeyPermissionsFetcher = &pairWhitelistExchange{}
+var _ api.TradingFeeFetcher = &pairWhitelistFeeExchange{}
+
+// makePairWhitelistExchange is a factory method
+func makePairWhitelistExchange(exchangeType string, inner api.Exchange, whitelist []WhitelistPair, alert api.Alert) api.Exchange {
+	x := &pairWhitelistExchange{
+		Exchange:     inner,
+		exchangeType: exchangeType,
+		whitelist:    whitelist,
+		alert:        alert,
+	}
+	if feeFetcher, ok := inner.(api.TradingFeeFetcher); ok {
+		return &pairWhitelistFeeExchange{
+			pairWhitelistExchange: x,
+			feeFetcher:            feeFetcher,
+		}
+	}
+	return x
+}
+
+// GetAPIKeyPermissions impl.
+func (x *pairWhitelistExchange) GetAPIKeyPermissions() ([]api.APIKeyPermissions, error) {
+	fetcher, ok := x.Exchange.(api.APIKeyPermissionsFetcher)
+	if !ok {
+		return nil, fmt.Errorf("the %s exchange cannot report the permissions of its API keys", x.exchangeType)
+	}
+	return fetcher.GetAPIKeyPermissions()
+}
+
+// AddOrder impl.
+func (x *pairWhitelistExchange) AddOrder(order *model.Order, submitMode api.SubmitMode) (*model.TransactionID, error) {
+	if isPairCodeWhitelisted(x.whitelist, order.Pair) {
+		return x.Exchange.AddOrder(order, submitMode)
+	}
+
+	description := fmt.Sprintf("pairWhitelistExchange: refusing order on exchange '%s' because the pair %s is not in the whitelist %v", x.exchangeType, order.Pair, x.whitelist)
+	log.Println(description)
+	if x.alert != nil {
+		e := x.alert.Trigger(description, order)
+		if e != nil {
+			log.Printf("pairWhitelistExchange: unable to trigger alert: %s\n", e)
+		}
+	}
+	return nil, fmt.Errorf("%s", description)
+}
+
+// GetTakerFee impl.
+func (x *pairWhitelistFeeExchange) GetTakerFee(pair *model.TradingPair) (float64, error) {
+	return x.feeFetcher.GetTakerFee(pair)
+}
+
+// isPairCodeWhitelisted returns true if any pair in the whitelist matches the asset codes of the trading pair, issuers are not checked since
+// assets on centralized exchanges do not have issuers
+func isPairCodeWhitelisted(whitelist []WhitelistPair, pair *model.TradingPair) bool {
+	for _, p := range whitelist {
+		if p.matchesCodes(string(pair.Base), string(pair.Quote)) {
+			return true
+		}
+	}
+	return false
+}
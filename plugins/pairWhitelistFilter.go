@@ -0,0 +1,160 @@
+package plugins
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// whitelistAsset is an asset in the pair whitelist, the issuer is only checked when it is specified
+type whitelistAsset struct {
+	code   string
+	issuer string
+}
+
+func (a whitelistAsset) matches(asset hProtocol.Asset) bool {
+	if a.code != utils.Asset2CodeString(asset) {
+		return false
+	}
+	return a.issuer == "" || a.issuer == asset.Issuer
+}
+
+// WhitelistPair is a trading pair that the bot is allowed to trade
+type WhitelistPair struct {
+	base  whitelistAsset
+	quote whitelistAsset
+}
+
+// String is the stringer function
+func (p WhitelistPair) String() string {
+	return fmt.Sprintf("%s/%s", p.base, p.quote)
+}
+
+func (a whitelistAsset) String() string {
+	if a.issuer == "" {
+		return a.code
+	}
+	return fmt.Sprintf("%s:%s", a.code, a.issuer)
+}
+
+// Matches returns true if the pair of assets is the whitelisted pair, in either direction since both directions trade the same market
+func (p WhitelistPair) Matches(assetA hProtocol.Asset, assetB hProtocol.Asset) bool {
+	return (p.base.matches(assetA) && p.quote.matches(assetB)) || (p.base.matches(assetB) && p.quote.matches(assetA))
+}
+
+// matchesCodes returns true if the asset codes are the whitelisted pair in either direction, ignoring the issuers
+func (p WhitelistPair) matchesCodes(codeA string, codeB string) bool {
+	return (p.base.code == codeA && p.quote.code == codeB) || (p.base.code == codeB && p.quote.code == codeA)
+}
+
+// ParseWhitelistPairs parses pairs in the format BASE/QUOTE, where each asset is either CODE or CODE:ISSUER
+func ParseWhitelistPairs(pairs []string) ([]WhitelistPair, error) {
+	whitelist := []WhitelistPair{}
+	for _, pairString := range pairs {
+		parts := strings.Split(pairString, "/")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid whitelisted pair '%s', needs to be in the format BASE/QUOTE", pairString)
+		}
+
+		assets := []whitelistAsset{}
+		for _, part := range parts {
+			assetParts := strings.Split(strings.TrimSpace(part), ":")
+			if len(assetParts) > 2 || assetParts[0] == "" {
+				return nil, fmt.Errorf("invalid asset '%s' in whitelisted pair '%s', needs to be in the format CODE or CODE:ISSUER", part, pairString)
+			}
+
+			a := whitelistAsset{code: assetParts[0]}
+			if len(assetParts) == 2 {
+				a.issuer = assetParts[1]
+			}
+			assets = append(assets, a)
+		}
+		whitelist = append(whitelist, WhitelistPair{base: assets[0], quote: assets[1]})
+	}
+	return whitelist, nil
+}
+
+// IsPairWhitelisted returns true if any pair in the whitelist matches the pair of assets
+func IsPairWhitelisted(whitelist []WhitelistPair, assetA hProtocol.Asset, assetB hProtocol.Asset) bool {
+	for _, p := range whitelist {
+		if p.Matches(assetA, assetB) {
+			return true
+		}
+	}
+	return false
+}
+
+type pairWhitelistFilter struct {
+	whitelist []WhitelistPair
+	alert     api.Alert
+}
+
+var _ SubmitFilter = &pairWhitelistFilter{}
+var _ OrderedSubmitFilter = &pairWhitelistFilter{}
+
+// FilterOrder impl.
+func (f *pairWhitelistFilter) FilterOrder() FilterOrder {
+	return FilterOrder{
+		ID:       filterIDPairWhitelist,
+		Priority: FilterPriorityFirst,
+	}
+}
+
+// MakeFilterPairWhitelist makes a submit filter that refuses any offer on a pair that is not in the whitelist and triggers an alert
+func MakeFilterPairWhitelist(whitelist []WhitelistPair, alert api.Alert) SubmitFilter {
+	return &pairWhitelistFilter{
+		whitelist: whitelist,
+		alert:     alert,
+	}
+}
+
+// Apply impl.
+func (f *pairWhitelistFilter) Apply(
+	ops []txnbuild.Operation,
+	sellingOffers []hProtocol.Offer,
+	buyingOffers []hProtocol.Offer,
+) ([]txnbuild.Operation, error) {
+	numDropped := 0
+	filteredOps := []txnbuild.Operation{}
+	for _, op := range ops {
+		o, ok := op.(*txnbuild.ManageSellOffer)
+		if !ok {
+			filteredOps = append(filteredOps, op)
+			continue
+		}
+
+		selling := utils.Asset2Asset2(o.Selling)
+		buying := utils.Asset2Asset2(o.Buying)
+		// deleting an offer is always allowed since it can only reduce our exposure
+		if o.Amount == "0" || IsPairWhitelisted(f.whitelist, selling, buying) {
+			filteredOps = append(filteredOps, op)
+			continue
+		}
+
+		numDropped++
+		description := fmt.Sprintf("pairWhitelistFilter: refusing offer (offerID=%d) selling %s for %s because the pair is not in the whitelist %v",
+			o.OfferID, utils.Asset2String(selling), utils.Asset2String(buying), f.whitelist)
+		log.Println(description)
+		if f.alert != nil {
+			e := f.alert.Trigger(description, o)
+			if e != nil {
+				log.Printf("pairWhitelistFilter: unable to trigger alert: %s\n", e)
+			}
+		}
+
+		if o.OfferID != 0 {
+			// existing offers that are modified are deleted instead
+			opCopy := *o
+			opCopy.Amount = "0"
+			filteredOps = append(filteredOps, &opCopy)
+		}
+	}
+
+	log.Printf("pairWhitelistFilter: dropped %d ops from original %d ops, len(filteredOps) = %d\n", numDropped, len(ops), len(filteredOps))
+	return filteredOps, nil
+}
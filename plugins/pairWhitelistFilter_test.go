@@ -0,0 +1,120 @@
+package plugins
+
+import (
+	"testing"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/support/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+var whitelistTestXLM = hProtocol.Asset{Type: utils.Native}
+var whitelistTestUSD = hProtocol.Asset{Type: "credit_alphanum4", Code: "USD", Issuer: "GAXEMCEXBERNSRXOEKD4JAIKVECIXQCENHEBRVSPX2TTYZPMNEDSQCNQ"}
+var whitelistTestUSDOther = hProtocol.Asset{Type: "credit_alphanum4", Code: "USD", Issuer: "GBMMZMK2DC4FFP4CAI6KCVNCQ7WLO5A7DQU7EC7WGHRDQBZB763X4OQI"}
+var whitelistTestBTC = hProtocol.Asset{Type: "credit_alphanum4", Code: "BTC", Issuer: "GAXEMCEXBERNSRXOEKD4JAIKVECIXQCENHEBRVSPX2TTYZPMNEDSQCNQ"}
+
+func TestParseWhitelistPairs(t *testing.T) {
+	testCases := []struct {
+		pairs     []string
+		wantError bool
+		wantPairs []string
+	}{
+		{pairs: []string{"XLM/USD"}, wantPairs: []string{"XLM/USD"}},
+		{pairs: []string{"XLM/USD:GAXEMCEXBERNSRXOEKD4JAIKVECIXQCENHEBRVSPX2TTYZPMNEDSQCNQ", "BTC/USD"}, wantPairs: []string{"XLM/USD:GAXEMCEXBERNSRXOEKD4JAIKVECIXQCENHEBRVSPX2TTYZPMNEDSQCNQ", "BTC/USD"}},
+		{pairs: []string{"XLM"}, wantError: true},
+		{pairs: []string{"XLM/USD/BTC"}, wantError: true},
+		{pairs: []string{"XLM/USD:ISSUER:EXTRA"}, wantError: true},
+		{pairs: []string{"/USD"}, wantError: true},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.pairs[0], func(t *testing.T) {
+			whitelist, e := ParseWhitelistPairs(k.pairs)
+			if k.wantError {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+
+			pairStrings := []string{}
+			for _, p := range whitelist {
+				pairStrings = append(pairStrings, p.String())
+			}
+			assert.Equal(t, k.wantPairs, pairStrings)
+		})
+	}
+}
+
+func TestIsPairWhitelisted(t *testing.T) {
+	whitelist, e := ParseWhitelistPairs([]string{"XLM/USD:GAXEMCEXBERNSRXOEKD4JAIKVECIXQCENHEBRVSPX2TTYZPMNEDSQCNQ", "BTC/XLM"})
+	if !assert.NoError(t, e) {
+		return
+	}
+
+	testCases := []struct {
+		name   string
+		assetA hProtocol.Asset
+		assetB hProtocol.Asset
+		want   bool
+	}{
+		{name: "exact", assetA: whitelistTestXLM, assetB: whitelistTestUSD, want: true},
+		{name: "reversed", assetA: whitelistTestUSD, assetB: whitelistTestXLM, want: true},
+		{name: "wrong issuer", assetA: whitelistTestXLM, assetB: whitelistTestUSDOther, want: false},
+		{name: "any issuer", assetA: whitelistTestBTC, assetB: whitelistTestXLM, want: true},
+		{name: "not listed", assetA: whitelistTestBTC, assetB: whitelistTestUSD, want: false},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			assert.Equal(t, k.want, IsPairWhitelisted(whitelist, k.assetA, k.assetB))
+		})
+	}
+}
+
+func TestPairWhitelistFilter(t *testing.T) {
+	whitelist, e := ParseWhitelistPairs([]string{"XLM/USD:GAXEMCEXBERNSRXOEKD4JAIKVECIXQCENHEBRVSPX2TTYZPMNEDSQCNQ"})
+	if !assert.NoError(t, e) {
+		return
+	}
+	f := MakeFilterPairWhitelist(whitelist, nil)
+
+	ops := []txnbuild.Operation{
+		// allowed new offer
+		&txnbuild.ManageSellOffer{Selling: utils.Asset2Asset(whitelistTestXLM), Buying: utils.Asset2Asset(whitelistTestUSD), Amount: "10.0000000", Price: "0.1000000"},
+		// new offer on the wrong market is dropped
+		&txnbuild.ManageSellOffer{Selling: utils.Asset2Asset(whitelistTestXLM), Buying: utils.Asset2Asset(whitelistTestUSDOther), Amount: "10.0000000", Price: "0.1000000"},
+		// modified offer on the wrong market is deleted
+		&txnbuild.ManageSellOffer{Selling: utils.Asset2Asset(whitelistTestBTC), Buying: utils.Asset2Asset(whitelistTestXLM), Amount: "10.0000000", Price: "0.1000000", OfferID: 2},
+		// deleting an offer on the wrong market is allowed
+		&txnbuild.ManageSellOffer{Selling: utils.Asset2Asset(whitelistTestBTC), Buying: utils.Asset2Asset(whitelistTestXLM), Amount: "0", Price: "0.1000000", OfferID: 3},
+	}
+	filteredOps, e := f.Apply(ops, []hProtocol.Offer{}, []hProtocol.Offer{})
+	if !assert.NoError(t, e) {
+		return
+	}
+
+	if !assert.Equal(t, 3, len(filteredOps)) {
+		return
+	}
+	assert.Equal(t, ops[0], filteredOps[0])
+	assert.Equal(t, int64(2), filteredOps[1].(*txnbuild.ManageSellOffer).OfferID)
+	assert.Equal(t, "0", filteredOps[1].(*txnbuild.ManageSellOffer).Amount)
+	assert.Equal(t, ops[3], filteredOps[2])
+}
+
+func TestIsPairCodeWhitelisted(t *testing.T) {
+	whitelist, e := ParseWhitelistPairs([]string{"XLM/USD", "BTC/USD:GAXEMCEXBERNSRXOEKD4JAIKVECIXQCENHEBRVSPX2TTYZPMNEDSQCNQ"})
+	if !assert.NoError(t, e) {
+		return
+	}
+
+	assert.True(t, isPairCodeWhitelisted(whitelist, &model.TradingPair{Base: model.XLM, Quote: model.USD}))
+	// the pair can be traded in either direction
+	assert.True(t, isPairCodeWhitelisted(whitelist, &model.TradingPair{Base: model.USD, Quote: model.BTC}))
+	assert.False(t, isPairCodeWhitelisted(whitelist, &model.TradingPair{Base: model.XLM, Quote: model.BTC}))
+	assert.False(t, isPairCodeWhitelisted([]WhitelistPair{}, &model.TradingPair{Base: model.XLM, Quote: model.USD}))
+}
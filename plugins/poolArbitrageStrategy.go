@@ -0,0 +1,358 @@
+package plugins
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/stellargohorizonclientv300/build"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// poolArbitrageOrderbookDepth is the number of levels fetched on each side of the SDEX orderbook
+const poolArbitrageOrderbookDepth = 20
+
+// poolArbitrageConfig contains the configuration params for this strategy
+type poolArbitrageConfig struct {
+	MinProfit      float64  `valid:"-" toml:"MIN_PROFIT"`       // minimum profit as a fraction of the quote asset sent, over and above the pool fee
+	MaxTradeAmount float64  `valid:"-" toml:"MAX_TRADE_AMOUNT"` // maximum amount of the base asset traded in a single arbitrage, 0 means there is no maximum
+	VolumeFilters  []string `valid:"-" toml:"VOLUME_FILTERS"`   // volume filters that cap the daily amounts bought and sold, in the same format as the FILTERS in the trader config
+}
+
+// String impl.
+func (c poolArbitrageConfig) String() string {
+	return utils.StructString(c, 0, nil)
+}
+
+// poolArbitragePlan is a round trip of the quote asset through the base asset, buying the base asset on the cheaper venue and
+// selling it on the more expensive one
+type poolArbitragePlan struct {
+	sellOnSdex   bool // true when buying from the pool and selling to the SDEX bids, false when buying from the SDEX asks and selling to the pool
+	baseAmount   float64
+	sendQuote    float64
+	receiveQuote float64
+}
+
+// avgBuyPrice is the average price paid for the base asset in units of the quote asset
+func (p *poolArbitragePlan) avgBuyPrice() float64 {
+	return p.sendQuote / p.baseAmount
+}
+
+// avgSellPrice is the average price received for the base asset in units of the quote asset
+func (p *poolArbitragePlan) avgSellPrice() float64 {
+	return p.receiveQuote / p.baseAmount
+}
+
+// poolArbitrageStrategy compares the price of the constant product liquidity pool of the trading pair against the SDEX orderbook and
+// executes a circular path payment (quote -> base -> quote) when they diverge by more than the pool fee. The network routes each hop of a
+// path payment through whichever of the orderbook or the pool gives the better price, so the path is the same for both directions.
+type poolArbitrageStrategy struct {
+	sdex          *SDEX
+	tradingPair   *model.TradingPair
+	assetBase     *hProtocol.Asset
+	assetQuote    *hProtocol.Asset
+	config        *poolArbitrageConfig
+	poolID        txnbuild.LiquidityPoolId
+	poolFee       float64
+	volumeFilters []SubmitFilter
+
+	// uninitialized
+	maxAssetQuote float64
+}
+
+// ensure this implements api.Strategy
+var _ api.Strategy = &poolArbitrageStrategy{}
+
+// makePoolArbitrageStrategy is a factory method
+func makePoolArbitrageStrategy(
+	sdex *SDEX,
+	tradingPair *model.TradingPair,
+	assetBase *hProtocol.Asset,
+	assetQuote *hProtocol.Asset,
+	filterFactory *FilterFactory,
+	config *poolArbitrageConfig,
+) (api.Strategy, error) {
+	if config.MinProfit < 0 {
+		return nil, fmt.Errorf("MIN_PROFIT needs to be >= 0 but was %f", config.MinProfit)
+	}
+	if config.MaxTradeAmount < 0 {
+		return nil, fmt.Errorf("MAX_TRADE_AMOUNT needs to be >= 0 but was %f", config.MaxTradeAmount)
+	}
+
+	volumeFilters := []SubmitFilter{}
+	for _, filterString := range config.VolumeFilters {
+		if !strings.HasPrefix(filterString, "volume/") {
+			return nil, fmt.Errorf("only volume filters can be used in VOLUME_FILTERS but found '%s'", filterString)
+		}
+		filter, e := filterFactory.MakeFilter(filterString)
+		if e != nil {
+			return nil, fmt.Errorf("unable to make volume filter '%s': %s", filterString, e)
+		}
+		volumeFilters = append(volumeFilters, filter)
+	}
+
+	poolParams, poolID, e := makeLiquidityPoolParams(assetBase, assetQuote)
+	if e != nil {
+		return nil, fmt.Errorf("could not make liquidity pool params: %s", e)
+	}
+
+	return &poolArbitrageStrategy{
+		sdex:          sdex,
+		tradingPair:   tradingPair,
+		assetBase:     assetBase,
+		assetQuote:    assetQuote,
+		config:        config,
+		poolID:        poolID,
+		poolFee:       float64(poolParams.Fee) / 10000,
+		volumeFilters: volumeFilters,
+	}, nil
+}
+
+// PruneExistingOffers impl, all offers are deleted because a path payment fails when it crosses an offer from the same account
+func (s *poolArbitrageStrategy) PruneExistingOffers(buyingAOffers []hProtocol.Offer, sellingAOffers []hProtocol.Offer) ([]build.TransactionMutator, []hProtocol.Offer, []hProtocol.Offer) {
+	pruneOps := s.sdex.DeleteAllOffers(append(append([]hProtocol.Offer{}, buyingAOffers...), sellingAOffers...))
+	if len(pruneOps) > 0 {
+		log.Printf("poolArbitrageStrategy: deleting %d offers\n", len(pruneOps))
+	}
+	return api.ConvertOperation2TM(pruneOps), []hProtocol.Offer{}, []hProtocol.Offer{}
+}
+
+// PreUpdate impl
+func (s *poolArbitrageStrategy) PreUpdate(maxAssetBase float64, maxAssetQuote float64, trustBase float64, trustQuote float64) error {
+	s.maxAssetQuote = maxAssetQuote
+	return nil
+}
+
+// UpdateWithOps impl, path payments cannot be represented as offer mutators so they are submitted here in their own transaction
+func (s *poolArbitrageStrategy) UpdateWithOps(buyingAOffers []hProtocol.Offer, sellingAOffers []hProtocol.Offer) ([]build.TransactionMutator, error) {
+	reserves, e := fetchLiquidityPoolReserves(s.sdex, s.poolID, s.assetBase, s.assetQuote)
+	if e != nil {
+		return nil, fmt.Errorf("could not fetch liquidity pool reserves: %s", e)
+	}
+	if reserves.base == 0 || reserves.quote == 0 {
+		log.Printf("poolArbitrageStrategy: the liquidity pool is empty, nothing to arbitrage\n")
+		return []build.TransactionMutator{}, nil
+	}
+	ob, e := s.sdex.GetOrderBook(s.tradingPair, poolArbitrageOrderbookDepth)
+	if e != nil {
+		return nil, fmt.Errorf("could not fetch orderbook: %s", e)
+	}
+
+	plan := computePoolArbitrage(*reserves, ob, s.poolFee, s.config.MinProfit, s.config.MaxTradeAmount)
+	if plan == nil {
+		log.Printf("poolArbitrageStrategy: no arbitrage opportunity, poolPrice=%.7f\n", reserves.quote/reserves.base)
+		return []build.TransactionMutator{}, nil
+	}
+
+	// the quote asset is sent first in both directions so it limits the size of the trade, the cost grows at least linearly with the amount
+	if plan.sendQuote > s.maxAssetQuote {
+		if s.maxAssetQuote <= 0 {
+			log.Printf("poolArbitrageStrategy: no balance of the quote asset to arbitrage with\n")
+			return []build.TransactionMutator{}, nil
+		}
+		log.Printf("poolArbitrageStrategy: reducing trade to fit the balance of the quote asset (%.7f), needed %.7f\n", s.maxAssetQuote, plan.sendQuote)
+		plan = computePoolArbitrage(*reserves, ob, s.poolFee, s.config.MinProfit, plan.baseAmount*s.maxAssetQuote/plan.sendQuote)
+		if plan == nil {
+			return []build.TransactionMutator{}, nil
+		}
+	}
+
+	cappedBaseAmount, e := s.applyVolumeFilters(plan)
+	if e != nil {
+		return nil, fmt.Errorf("could not apply volume filters: %s", e)
+	}
+	if cappedBaseAmount <= 0 {
+		log.Printf("poolArbitrageStrategy: the volume filters do not allow any more trades today\n")
+		return []build.TransactionMutator{}, nil
+	}
+	if cappedBaseAmount < plan.baseAmount {
+		log.Printf("poolArbitrageStrategy: reducing trade from %.7f to %.7f units of the base asset because of the volume filters\n", plan.baseAmount, cappedBaseAmount)
+		plan = computePoolArbitrage(*reserves, ob, s.poolFee, s.config.MinProfit, cappedBaseAmount)
+		if plan == nil {
+			return []build.TransactionMutator{}, nil
+		}
+	}
+
+	op := s.makePathPaymentOp(plan)
+	log.Printf("poolArbitrageStrategy: sellOnSdex=%v, baseAmount=%.7f, avgBuyPrice=%.7f, avgSellPrice=%.7f, sendQuote=%s, destMin=%s\n",
+		plan.sellOnSdex, plan.baseAmount, plan.avgBuyPrice(), plan.avgSellPrice(), op.SendAmount, op.DestMin)
+	e = s.sdex.SubmitOperations([]txnbuild.Operation{op}, func(hash string, e error) {
+		if e != nil {
+			log.Printf("poolArbitrageStrategy: arbitrage transaction failed: %s\n", e)
+			return
+		}
+		log.Printf("poolArbitrageStrategy: arbitrage transaction succeeded with hash: %s\n", hash)
+	})
+	if e != nil {
+		return nil, fmt.Errorf("could not submit arbitrage operation: %s", e)
+	}
+	return []build.TransactionMutator{}, nil
+}
+
+// PostUpdate impl
+func (s *poolArbitrageStrategy) PostUpdate() error {
+	return nil
+}
+
+// GetFillHandlers impl
+func (s *poolArbitrageStrategy) GetFillHandlers() ([]api.FillHandler, error) {
+	return nil, nil
+}
+
+// applyVolumeFilters runs the buy and sell legs of the plan through the volume filters as offers and returns the amount of the base asset
+// that is allowed by all of them
+func (s *poolArbitrageStrategy) applyVolumeFilters(plan *poolArbitragePlan) (float64, error) {
+	if len(s.volumeFilters) == 0 {
+		return plan.baseAmount, nil
+	}
+
+	txnBase := utils.Asset2Asset(*s.assetBase)
+	txnQuote := utils.Asset2Asset(*s.assetQuote)
+	// a buy leg is represented in the same way as a buy offer, i.e. selling the quote asset with the price inverted
+	ops := []txnbuild.Operation{
+		&txnbuild.ManageSellOffer{
+			Selling: txnBase,
+			Buying:  txnQuote,
+			Amount:  model.NumberFromFloat(plan.baseAmount, sdexOrderConstraints.VolumePrecision).AsString(),
+			Price:   model.NumberFromFloat(plan.avgSellPrice(), sdexOrderConstraints.PricePrecision).AsString(),
+		},
+		&txnbuild.ManageSellOffer{
+			Selling: txnQuote,
+			Buying:  txnBase,
+			Amount:  model.NumberFromFloat(plan.sendQuote, sdexOrderConstraints.VolumePrecision).AsString(),
+			Price:   model.NumberFromFloat(1/plan.avgBuyPrice(), sdexOrderConstraints.PricePrecision).AsString(),
+		},
+	}
+
+	var e error
+	for _, f := range s.volumeFilters {
+		ops, e = f.Apply(ops, []hProtocol.Offer{}, []hProtocol.Offer{})
+		if e != nil {
+			return 0, fmt.Errorf("error in volume filter %s: %s", f, e)
+		}
+	}
+
+	sellAmount := 0.0
+	buyAmount := 0.0
+	for _, op := range ops {
+		mso, ok := op.(*txnbuild.ManageSellOffer)
+		if !ok {
+			continue
+		}
+		amount, e := strconv.ParseFloat(mso.Amount, 64)
+		if e != nil {
+			return 0, fmt.Errorf("could not parse amount '%s': %s", mso.Amount, e)
+		}
+		if mso.Selling == txnBase {
+			sellAmount = amount
+		} else {
+			buyAmount = amount / plan.avgBuyPrice()
+		}
+	}
+	return math.Min(sellAmount, buyAmount), nil
+}
+
+func (s *poolArbitrageStrategy) makePathPaymentOp(plan *poolArbitragePlan) *txnbuild.PathPaymentStrictSend {
+	sourceAccount := ""
+	if s.sdex.SourceAccount != s.sdex.TradingAccount {
+		sourceAccount = s.sdex.TradingAccount
+	}
+	txnQuote := utils.Asset2Asset(*s.assetQuote)
+	destMin := plan.sendQuote * (1 + s.config.MinProfit)
+	return &txnbuild.PathPaymentStrictSend{
+		SourceAccount: sourceAccount,
+		SendAsset:     txnQuote,
+		SendAmount:    model.NumberFromFloatRoundTruncate(plan.sendQuote, sdexOrderConstraints.VolumePrecision).AsString(),
+		Destination:   s.sdex.TradingAccount,
+		DestAsset:     txnQuote,
+		// round up so the profit is never less than MIN_PROFIT
+		DestMin: model.NumberFromFloat(destMin+math.Pow(10, -float64(sdexOrderConstraints.VolumePrecision)), sdexOrderConstraints.VolumePrecision).AsString(),
+		Path:    []txnbuild.Asset{utils.Asset2Asset(*s.assetBase)},
+	}
+}
+
+// computePoolArbitrage returns the arbitrage between the pool and the orderbook that makes at least minProfit at the margin, or nil when
+// there is none. poolFee is a fraction of the amount sent to the pool, and maxBaseAmount caps the amount of the base asset traded when > 0.
+func computePoolArbitrage(pool liquidityPoolReserves, ob *model.OrderBook, poolFee float64, minProfit float64, maxBaseAmount float64) *poolArbitragePlan {
+	x := pool.base
+	y := pool.quote
+	k := x * y
+	if x <= 0 || y <= 0 {
+		return nil
+	}
+
+	// buy from the pool and sell to the bids while the bids are more than the marginal price of buying from the pool
+	baseAmount := walkLevels(ob.Bids(), func(p float64) float64 {
+		// amount bought from the pool until its marginal price, y'/x'/(1-fee) = k/x'^2/(1-fee), reaches p/(1+minProfit)
+		return x - math.Sqrt(k/(p/(1+minProfit)*(1-poolFee)))
+	})
+	if baseAmount > 0 {
+		baseAmount = capPoolArbitrageAmount(baseAmount, maxBaseAmount)
+		return makePoolArbitragePlan(true, baseAmount, (k/(x-baseAmount)-y)/(1-poolFee), levelsValue(ob.Bids(), baseAmount), minProfit)
+	}
+
+	// buy from the asks and sell to the pool while the asks are less than the marginal price of selling to the pool
+	baseAmount = walkLevels(ob.Asks(), func(p float64) float64 {
+		// amount sold to the pool until its marginal price, y'/x'*(1-fee) = k/x'^2*(1-fee), reaches p*(1+minProfit)
+		return (math.Sqrt(k*(1-poolFee)/(p*(1+minProfit))) - x) / (1 - poolFee)
+	})
+	if baseAmount > 0 {
+		baseAmount = capPoolArbitrageAmount(baseAmount, maxBaseAmount)
+		return makePoolArbitragePlan(false, baseAmount, levelsValue(ob.Asks(), baseAmount), y-k/(x+baseAmount*(1-poolFee)), minProfit)
+	}
+	return nil
+}
+
+func makePoolArbitragePlan(sellOnSdex bool, baseAmount float64, sendQuote float64, receiveQuote float64, minProfit float64) *poolArbitragePlan {
+	if baseAmount <= 0 || receiveQuote < sendQuote*(1+minProfit) {
+		return nil
+	}
+	return &poolArbitragePlan{
+		sellOnSdex:   sellOnSdex,
+		baseAmount:   baseAmount,
+		sendQuote:    sendQuote,
+		receiveQuote: receiveQuote,
+	}
+}
+
+// walkLevels returns the amount of the base asset that can be traded against the orderbook levels, where poolCapacityFn returns the
+// amount that can be traded with the pool before the pool's marginal price reaches the price of a level
+func walkLevels(levels []model.Order, poolCapacityFn func(price float64) float64) float64 {
+	amount := 0.0
+	for _, l := range levels {
+		capacity := poolCapacityFn(l.Price.AsFloat())
+		if capacity <= amount {
+			break
+		}
+		amount = math.Min(amount+l.Volume.AsFloat(), capacity)
+	}
+	return amount
+}
+
+// levelsValue returns the value in units of the quote asset of trading the amount of the base asset against the orderbook levels
+func levelsValue(levels []model.Order, baseAmount float64) float64 {
+	value := 0.0
+	remaining := baseAmount
+	for _, l := range levels {
+		if remaining <= 0 {
+			break
+		}
+		amount := math.Min(remaining, l.Volume.AsFloat())
+		value += amount * l.Price.AsFloat()
+		remaining -= amount
+	}
+	return value
+}
+
+func capPoolArbitrageAmount(amount float64, maxAmount float64) float64 {
+	if maxAmount > 0 && amount > maxAmount {
+		return maxAmount
+	}
+	return amount
+}
@@ -0,0 +1,74 @@
+package plugins
+
+import (
+	"testing"
+
+	"github.com/stellar/kelp/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func makeTestPoolArbitrageOrderBook(asks [][2]float64, bids [][2]float64) *model.OrderBook {
+	toOrders := func(action model.OrderAction, levels [][2]float64) []model.Order {
+		orders := []model.Order{}
+		for _, l := range levels {
+			orders = append(orders, model.Order{
+				OrderAction: action,
+				Price:       model.NumberFromFloat(l[0], 7),
+				Volume:      model.NumberFromFloat(l[1], 7),
+			})
+		}
+		return orders
+	}
+	return model.MakeOrderBook(
+		model.MakeTradingPair(model.XLM, model.USDT),
+		toOrders(model.OrderActionSell, asks),
+		toOrders(model.OrderActionBuy, bids),
+	)
+}
+
+func TestComputePoolArbitrage(t *testing.T) {
+	pool := liquidityPoolReserves{base: 1000, quote: 1000, totalShares: 1000}
+	testCases := []struct {
+		name          string
+		ob            *model.OrderBook
+		maxBaseAmount float64
+		wantPlan      *poolArbitragePlan
+	}{
+		{
+			name:     "no divergence beyond fees",
+			ob:       makeTestPoolArbitrageOrderBook([][2]float64{{1.002, 100}}, [][2]float64{{0.998, 100}}),
+			wantPlan: nil,
+		}, {
+			// the first bid is filled and the second bid is filled until the pool price catches up
+			name:     "sell on sdex",
+			ob:       makeTestPoolArbitrageOrderBook([][2]float64{{1.2, 100}}, [][2]float64{{1.1, 10}, {1.05, 100}}),
+			wantPlan: &poolArbitragePlan{sellOnSdex: true, baseAmount: 22.1442135, sendQuote: 22.7138259, receiveQuote: 23.7514242},
+		}, {
+			name:     "buy on sdex",
+			ob:       makeTestPoolArbitrageOrderBook([][2]float64{{0.9, 5}, {0.95, 100}}, [][2]float64{{0.8, 100}}),
+			wantPlan: &poolArbitragePlan{sellOnSdex: false, baseAmount: 23.9983885, sendQuote: 22.5484691, receiveQuote: 23.3672982},
+		}, {
+			name:          "capped",
+			ob:            makeTestPoolArbitrageOrderBook([][2]float64{{1.2, 100}}, [][2]float64{{1.1, 10}, {1.05, 100}}),
+			maxBaseAmount: 5,
+			wantPlan:      &poolArbitragePlan{sellOnSdex: true, baseAmount: 5, sendQuote: 5.0402464, receiveQuote: 5.5},
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			plan := computePoolArbitrage(pool, k.ob, 0.003, 0.001, k.maxBaseAmount)
+			if k.wantPlan == nil {
+				assert.Nil(t, plan)
+				return
+			}
+			if !assert.NotNil(t, plan) {
+				return
+			}
+			assert.Equal(t, k.wantPlan.sellOnSdex, plan.sellOnSdex)
+			assert.InDelta(t, k.wantPlan.baseAmount, plan.baseAmount, 0.000001)
+			assert.InDelta(t, k.wantPlan.sendQuote, plan.sendQuote, 0.000001)
+			assert.InDelta(t, k.wantPlan.receiveQuote, plan.receiveQuote, 0.000001)
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package plugins
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/queries"
+)
+
+// portfolioBudgetFilter enforces the budget that the portfolio manager (kelp portfolio) assigned to this bot. The budget is converted by the
+// manager to a cap on the net position in the base asset, which is enforced with an exposure filter, and a daily cap on the volume traded on
+// each side in units of the quote asset, which is enforced with volume filters. The filters are remade whenever the manager changes the budget.
+// A budget that the manager has not updated within maxAge is not enforced, instead the bot stops placing offers and deletes its offers.
+type portfolioBudgetFilter struct {
+	name        string
+	configValue string
+	botID       string
+	maxAge      time.Duration
+	factory     *FilterFactory
+	budgetQuery api.Query
+	clock       api.Clock
+
+	// uninitialized
+	appliedBudget *queries.PortfolioBudget
+	innerFilters  []SubmitFilter
+}
+
+// defaultPortfolioBudgetMaxAge is the max age of the budget when the filter does not set it, 3 updates at the default UPDATE_INTERVAL_SECONDS
+const defaultPortfolioBudgetMaxAge = 3 * time.Hour
+
+// makeFilterPortfolioBudget makes a submit filter that enforces the portfolio budget of the bot with the botID
+func makeFilterPortfolioBudget(configValue string, botID string, maxAge time.Duration, factory *FilterFactory, clock api.Clock) (SubmitFilter, error) {
+	if botID == "" {
+		return nil, fmt.Errorf("botID cannot be empty")
+	}
+	if maxAge <= 0 {
+		return nil, fmt.Errorf("maxAge needs to be greater than 0, was %s", maxAge)
+	}
+
+	budgetQuery, e := queries.MakePortfolioBudgetQuery(factory.DB, botID)
+	if e != nil {
+		return nil, fmt.Errorf("could not make portfolio budget query: %s", e)
+	}
+
+	return &portfolioBudgetFilter{
+		name:        "portfolioBudgetFilter",
+		configValue: configValue,
+		botID:       botID,
+		maxAge:      maxAge,
+		factory:     factory,
+		budgetQuery: budgetQuery,
+		clock:       clock,
+	}, nil
+}
+
+var _ SubmitFilter = &portfolioBudgetFilter{}
+var _ OrderedSubmitFilter = &portfolioBudgetFilter{}
+
+// FilterOrder impl.
+func (f *portfolioBudgetFilter) FilterOrder() FilterOrder {
+	return FilterOrder{
+		ID:       filterIDPortfolioBudget,
+		Priority: FilterPriorityAmount,
+		After:    priceAndOrderSizeFilterIDs,
+	}
+}
+
+// Apply impl.
+func (f *portfolioBudgetFilter) Apply(ops []txnbuild.Operation, sellingOffers []hProtocol.Offer, buyingOffers []hProtocol.Offer) ([]txnbuild.Operation, error) {
+	result, e := f.budgetQuery.QueryRow()
+	if e != nil {
+		return nil, fmt.Errorf("could not load the portfolio budget of bot '%s': %s", f.botID, e)
+	}
+	budget, ok := result.(*queries.PortfolioBudget)
+	if !ok {
+		return nil, fmt.Errorf("incorrect type returned from PortfolioBudgetQuery query, expecting '*queries.PortfolioBudget' but was '%T'", result)
+	}
+	if budget == nil {
+		return nil, fmt.Errorf("no portfolio budget has been assigned to bot '%s', run the portfolio manager (kelp portfolio) with this bot in its config", f.botID)
+	}
+
+	age := f.clock.Now().Sub(budget.DateUpdatedUTC)
+	if age > f.maxAge {
+		log.Printf("portfolioBudgetFilter: the budget of bot '%s' was last updated at %s (%s ago, max age is %s), the portfolio manager may not be running so deleting all offers\n",
+			f.botID, budget.DateUpdatedUTC.Format("2006-01-02T15:04:05Z"), age, f.maxAge)
+		return f.deleteAllOffers(ops, sellingOffers, buyingOffers)
+	}
+
+	if f.appliedBudget == nil || f.appliedBudget.MaxExposureBase != budget.MaxExposureBase || f.appliedBudget.MaxDailyVolumeQuote != budget.MaxDailyVolumeQuote {
+		innerFilters, e := f.makeInnerFilters(budget)
+		if e != nil {
+			return nil, fmt.Errorf("could not make the filters for the portfolio budget of bot '%s': %s", f.botID, e)
+		}
+		log.Printf("portfolioBudgetFilter: bot '%s' has a budget of %.7f (updated at %s), maxExposureBase=%.7f, maxDailyVolumeQuote=%.7f\n",
+			f.botID, budget.Budget, budget.DateUpdatedUTC.Format("2006-01-02T15:04:05Z"), budget.MaxExposureBase, budget.MaxDailyVolumeQuote)
+		f.innerFilters = innerFilters
+		f.appliedBudget = budget
+	}
+
+	for _, filter := range f.innerFilters {
+		ops, e = filter.Apply(ops, sellingOffers, buyingOffers)
+		if e != nil {
+			return nil, fmt.Errorf("could not apply %s for the portfolio budget: %s", filter, e)
+		}
+	}
+	return ops, nil
+}
+
+// deleteAllOffers drops all ops and deletes all existing offers
+func (f *portfolioBudgetFilter) deleteAllOffers(ops []txnbuild.Operation, sellingOffers []hProtocol.Offer, buyingOffers []hProtocol.Offer) ([]txnbuild.Operation, error) {
+	ops, e := filterOps(f.name, f.factory.BaseAsset, f.factory.QuoteAsset, sellingOffers, buyingOffers, ops, func(op *txnbuild.ManageSellOffer) (*txnbuild.ManageSellOffer, error) {
+		return nil, nil
+	})
+	if e != nil {
+		return nil, fmt.Errorf("could not apply filter: %s", e)
+	}
+	return ops, nil
+}
+
+// makeInnerFilters makes the exposure and volume filters for the limits of the budget, scoped to the account of this bot when it is set
+func (f *portfolioBudgetFilter) makeInnerFilters(budget *queries.PortfolioBudget) ([]SubmitFilter, error) {
+	var optionalAccountIDs []string
+	if f.factory.AccountID != "" {
+		optionalAccountIDs = []string{f.factory.AccountID}
+	}
+
+	exposureFilter, e := makeFilterExposure(
+		f.configValue,
+		f.factory.ExchangeName,
+		f.factory.TradingPair,
+		f.factory.AssetDisplayFn,
+		f.factory.BaseAsset,
+		f.factory.QuoteAsset,
+		f.factory.DB,
+		budget.MaxExposureBase,
+		budget.MaxExposureBase,
+		nil,
+		optionalAccountIDs,
+	)
+	if e != nil {
+		return nil, fmt.Errorf("could not make exposure filter: %s", e)
+	}
+	filters := []SubmitFilter{exposureFilter}
+
+	for _, action := range []queries.DailyVolumeAction{queries.DailyVolumeActionSell, queries.DailyVolumeActionBuy} {
+		maxDailyVolumeQuote := budget.MaxDailyVolumeQuote
+		volumeFilter, e := makeFilterVolume(
+			f.configValue,
+			f.factory.ExchangeName,
+			f.factory.TradingPair,
+			f.factory.AssetDisplayFn,
+			f.factory.BaseAsset,
+			f.factory.QuoteAsset,
+			f.factory.DB,
+			makeRawVolumeFilterConfig(nil, &maxDailyVolumeQuote, action, volumeFilterModeExact, volumeFilterWindowDaily, nil, optionalAccountIDs),
+		)
+		if e != nil {
+			return nil, fmt.Errorf("could not make %s volume filter: %s", action, e)
+		}
+		filters = append(filters, volumeFilter)
+	}
+	return filters, nil
+}
+
+// String is the Stringer method
+func (f *portfolioBudgetFilter) String() string {
+	return f.configValue
+}
@@ -0,0 +1,75 @@
+package plugins
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/protocols/horizon/base"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/queries"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// fixedPortfolioBudgetQuery is a PortfolioBudget query that returns a fixed budget without a db
+type fixedPortfolioBudgetQuery struct {
+	budget queries.PortfolioBudget
+}
+
+var _ api.Query = &fixedPortfolioBudgetQuery{}
+
+// Name impl.
+func (q *fixedPortfolioBudgetQuery) Name() string {
+	return "fixedPortfolioBudgetQuery"
+}
+
+// QueryRow impl.
+func (q *fixedPortfolioBudgetQuery) QueryRow(args ...interface{}) (interface{}, error) {
+	budget := q.budget
+	return &budget, nil
+}
+
+func TestPortfolioBudgetFilterApply_StaleBudget(t *testing.T) {
+	now := time.Date(2020, 5, 21, 15, 0, 0, 0, time.UTC)
+	f := &portfolioBudgetFilter{
+		name:   "portfolioBudgetFilter",
+		botID:  "a",
+		maxAge: time.Hour,
+		factory: &FilterFactory{
+			BaseAsset:  utils.Asset2Asset2(testBaseAsset),
+			QuoteAsset: utils.Asset2Asset2(testQuoteAsset),
+		},
+		budgetQuery: &fixedPortfolioBudgetQuery{budget: queries.PortfolioBudget{
+			Budget:              1000.0,
+			MaxExposureBase:     100.0,
+			MaxDailyVolumeQuote: 500.0,
+			DateUpdatedUTC:      now.Add(-2 * time.Hour),
+		}},
+		clock: MakeManualClock(now),
+	}
+	ops := []txnbuild.Operation{makeSellOpAmtPrice(10.0, 2.0)}
+	sellingOffers := []hProtocol.Offer{{
+		ID:      1,
+		Selling: utils.Asset2Asset2(testBaseAsset),
+		Buying:  utils.Asset2Asset2(testQuoteAsset),
+		Amount:  "5.0000000",
+		Price:   "2.5000000",
+		PriceR:  base.Price{N: 5, D: 2},
+	}}
+
+	// the new offer is dropped and the existing offer is deleted
+	actual, e := f.Apply(ops, sellingOffers, []hProtocol.Offer{})
+	if !assert.NoError(t, e) {
+		return
+	}
+	if !assert.Equal(t, 1, len(actual)) {
+		return
+	}
+	mso := actual[0].(*txnbuild.ManageSellOffer)
+	assert.Equal(t, int64(1), mso.OfferID)
+	assert.Equal(t, "0", mso.Amount)
+	assert.Nil(t, f.appliedBudget)
+}
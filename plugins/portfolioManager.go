@@ -0,0 +1,345 @@
+package plugins
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/kelpdb"
+	"github.com/stellar/kelp/queries"
+	"github.com/stellar/kelp/support/postgresdb"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// PortfolioConfig is the config of the portfolio manager, which splits the capital of a portfolio into budgets for the bots that trade it
+type PortfolioConfig struct {
+	TotalCapital            float64              `valid:"-" toml:"TOTAL_CAPITAL"`             // in units of the portfolio, such as USD
+	UpdateIntervalSeconds   int64                `valid:"-" toml:"UPDATE_INTERVAL_SECONDS"`   // how often the budgets are recomputed
+	PerformanceLookbackDays int64                `valid:"-" toml:"PERFORMANCE_LOOKBACK_DAYS"` // window of the realized P&L used to adjust the budgets
+	PerformanceSensitivity  float64              `valid:"-" toml:"PERFORMANCE_SENSITIVITY"`   // change of the weight of a bot per unit of return on its budget, 0 disables
+	MinPerformanceFactor    float64              `valid:"-" toml:"MIN_PERFORMANCE_FACTOR"`    // lower bound of the multiplier applied to the weight of a bot
+	MaxPerformanceFactor    float64              `valid:"-" toml:"MAX_PERFORMANCE_FACTOR"`    // upper bound of the multiplier applied to the weight of a bot
+	PostgresDbConfig        *postgresdb.Config   `valid:"-" toml:"POSTGRES_DB"`
+	Bots                    []PortfolioBotConfig `valid:"-" toml:"BOT"`
+}
+
+// PortfolioBotConfig is a bot that is managed by the portfolio manager, the bot enforces its budget with the portfolioBudget/<BOT_ID> filter
+type PortfolioBotConfig struct {
+	BotID              string  `valid:"-" toml:"BOT_ID"`
+	AccountID          string  `valid:"-" toml:"ACCOUNT_ID"`            // DB_OVERRIDE__ACCOUNT_ID of the bot
+	MarketID           string  `valid:"-" toml:"MARKET_ID"`             // market_id of the trades of the bot in the db
+	Weight             float64 `valid:"-" toml:"WEIGHT"`                // share of the total capital before the performance adjustment
+	MinBudget          float64 `valid:"-" toml:"MIN_BUDGET"`            // in units of the portfolio
+	MaxBudget          float64 `valid:"-" toml:"MAX_BUDGET"`            // in units of the portfolio, 0 means there is no maximum
+	ExposureFraction   float64 `valid:"-" toml:"EXPOSURE_FRACTION"`     // fraction of the budget that can be held as a net position in the base asset
+	DailyTurnover      float64 `valid:"-" toml:"DAILY_TURNOVER"`        // volume that can be traded on each side per day as a multiple of the budget
+	BasePriceFeedType  string  `valid:"-" toml:"BASE_PRICE_FEED_TYPE"`  // price of the base asset in units of the portfolio
+	BasePriceFeedURL   string  `valid:"-" toml:"BASE_PRICE_FEED_URL"`   // price of the base asset in units of the portfolio
+	QuotePriceFeedType string  `valid:"-" toml:"QUOTE_PRICE_FEED_TYPE"` // price of the quote asset in units of the portfolio, defaults to 1.0
+	QuotePriceFeedURL  string  `valid:"-" toml:"QUOTE_PRICE_FEED_URL"`  // price of the quote asset in units of the portfolio, defaults to 1.0
+}
+
+// String impl.
+func (c PortfolioConfig) String() string {
+	return utils.StructString(c, 0, nil)
+}
+
+// Validate checks the config of the portfolio and of its bots
+func (c *PortfolioConfig) Validate() error {
+	if c.TotalCapital <= 0 {
+		return fmt.Errorf("TOTAL_CAPITAL needs to be > 0, was %f", c.TotalCapital)
+	}
+	if c.UpdateIntervalSeconds <= 0 {
+		return fmt.Errorf("UPDATE_INTERVAL_SECONDS needs to be > 0, was %d", c.UpdateIntervalSeconds)
+	}
+	if c.PerformanceSensitivity < 0 {
+		return fmt.Errorf("PERFORMANCE_SENSITIVITY cannot be negative, was %f", c.PerformanceSensitivity)
+	}
+	if c.PerformanceSensitivity > 0 {
+		if c.PerformanceLookbackDays <= 0 {
+			return fmt.Errorf("PERFORMANCE_LOOKBACK_DAYS needs to be > 0 when PERFORMANCE_SENSITIVITY is set, was %d", c.PerformanceLookbackDays)
+		}
+		if c.MinPerformanceFactor <= 0 || c.MaxPerformanceFactor < c.MinPerformanceFactor {
+			return fmt.Errorf("need 0 < MIN_PERFORMANCE_FACTOR <= MAX_PERFORMANCE_FACTOR when PERFORMANCE_SENSITIVITY is set, was %f and %f", c.MinPerformanceFactor, c.MaxPerformanceFactor)
+		}
+	}
+	if c.PostgresDbConfig == nil {
+		return fmt.Errorf("POSTGRES_DB needs to be set since the budgets are shared with the bots through the db")
+	}
+	if len(c.Bots) == 0 {
+		return fmt.Errorf("need at least one BOT")
+	}
+
+	botIDs := map[string]bool{}
+	totalMinBudget := 0.0
+	for _, b := range c.Bots {
+		if b.BotID == "" {
+			return fmt.Errorf("BOT_ID cannot be empty")
+		}
+		if botIDs[b.BotID] {
+			return fmt.Errorf("BOT_ID '%s' is used by more than one BOT", b.BotID)
+		}
+		botIDs[b.BotID] = true
+
+		if b.Weight <= 0 {
+			return fmt.Errorf("WEIGHT of bot '%s' needs to be > 0, was %f", b.BotID, b.Weight)
+		}
+		if b.MinBudget < 0 || (b.MaxBudget != 0 && b.MaxBudget < b.MinBudget) {
+			return fmt.Errorf("need 0 <= MIN_BUDGET <= MAX_BUDGET for bot '%s', was %f and %f", b.BotID, b.MinBudget, b.MaxBudget)
+		}
+		if b.ExposureFraction <= 0 {
+			return fmt.Errorf("EXPOSURE_FRACTION of bot '%s' needs to be > 0, was %f", b.BotID, b.ExposureFraction)
+		}
+		if b.DailyTurnover <= 0 {
+			return fmt.Errorf("DAILY_TURNOVER of bot '%s' needs to be > 0, was %f", b.BotID, b.DailyTurnover)
+		}
+		if b.BasePriceFeedType == "" || b.BasePriceFeedURL == "" {
+			return fmt.Errorf("BASE_PRICE_FEED_TYPE and BASE_PRICE_FEED_URL need to be set for bot '%s'", b.BotID)
+		}
+		if c.PerformanceSensitivity > 0 && (b.AccountID == "" || b.MarketID == "") {
+			return fmt.Errorf("ACCOUNT_ID and MARKET_ID need to be set for bot '%s' to load its realized P&L when PERFORMANCE_SENSITIVITY is set", b.BotID)
+		}
+		totalMinBudget += b.MinBudget
+	}
+	if totalMinBudget > c.TotalCapital {
+		return fmt.Errorf("the MIN_BUDGET of all bots adds up to %f, which is more than the TOTAL_CAPITAL of %f", totalMinBudget, c.TotalCapital)
+	}
+	return nil
+}
+
+// portfolioBotPerformance is what the budget of a bot is adjusted with, in units of the portfolio
+type portfolioBotPerformance struct {
+	previousBudget float64 // 0 when the bot did not have a budget yet
+	realizedPnL    float64
+}
+
+// computePortfolioBudgets splits the total capital between the bots in proportion to their weights, after multiplying the weight of every bot by
+// 1 + sensitivity * (realized P&L / previous budget) bounded by the min and max performance factors, so bots that make money get more capital.
+// The budgets are bounded by the min and max budget of each bot: a bot that is below its min budget gets its min budget, and the capital that
+// is left is split again between the other bots, then the same is done for bots that are above their max budget. Capital that is cut off by
+// a max budget is only left unassigned when all bots are at their max budget.
+func computePortfolioBudgets(config *PortfolioConfig, performances []portfolioBotPerformance) []float64 {
+	totalWeight := 0.0
+	for _, b := range config.Bots {
+		totalWeight += b.Weight
+	}
+
+	adjustedWeights := make([]float64, len(config.Bots))
+	for i, b := range config.Bots {
+		factor := 1.0
+		if config.PerformanceSensitivity > 0 {
+			budget := performances[i].previousBudget
+			if budget <= 0 {
+				budget = config.TotalCapital * b.Weight / totalWeight
+			}
+			factor = 1 + config.PerformanceSensitivity*performances[i].realizedPnL/budget
+			factor = math.Max(config.MinPerformanceFactor, math.Min(config.MaxPerformanceFactor, factor))
+		}
+		adjustedWeights[i] = b.Weight * factor
+	}
+
+	budgets := make([]float64, len(config.Bots))
+	fixed := make([]bool, len(config.Bots))
+	for {
+		// split the capital that is not assigned to bots at their min or max budget between the other bots
+		remainingCapital := config.TotalCapital
+		remainingWeight := 0.0
+		for i := range config.Bots {
+			if fixed[i] {
+				remainingCapital -= budgets[i]
+			} else {
+				remainingWeight += adjustedWeights[i]
+			}
+		}
+		if remainingWeight == 0 {
+			return budgets
+		}
+		for i := range config.Bots {
+			if !fixed[i] {
+				budgets[i] = math.Max(remainingCapital, 0) * adjustedWeights[i] / remainingWeight
+			}
+		}
+
+		// the min budgets are fixed first since they take capital away from the other bots, which can only push them below their min budget
+		changed := false
+		for i, b := range config.Bots {
+			if !fixed[i] && budgets[i] < b.MinBudget {
+				budgets[i], fixed[i], changed = b.MinBudget, true, true
+			}
+		}
+		if changed {
+			continue
+		}
+		for i, b := range config.Bots {
+			if !fixed[i] && b.MaxBudget > 0 && budgets[i] > b.MaxBudget {
+				budgets[i], fixed[i], changed = b.MaxBudget, true, true
+			}
+		}
+		if !changed {
+			return budgets
+		}
+	}
+}
+
+type portfolioBot struct {
+	config        PortfolioBotConfig
+	baseFeed      api.PriceFeed
+	quoteFeed     api.PriceFeed
+	budgetQuery   api.Query
+	closuresQuery api.Query // nil when the budgets are not adjusted by performance
+}
+
+// PortfolioManager periodically assigns budgets to the bots of a portfolio and writes them to the portfolio_budgets table, where the
+// portfolioBudget filter of each bot picks them up
+type PortfolioManager struct {
+	db     *sql.DB
+	config *PortfolioConfig
+	bots   []*portfolioBot
+}
+
+// MakePortfolioManager is a factory method
+func MakePortfolioManager(db *sql.DB, config *PortfolioConfig) (*PortfolioManager, error) {
+	e := config.Validate()
+	if e != nil {
+		return nil, fmt.Errorf("invalid portfolio config: %s", e)
+	}
+
+	bots := []*portfolioBot{}
+	for _, c := range config.Bots {
+		bot := &portfolioBot{config: c}
+		bot.baseFeed, e = MakePriceFeed(c.BasePriceFeedType, c.BasePriceFeedURL)
+		if e != nil {
+			return nil, fmt.Errorf("could not make base price feed of bot '%s': %s", c.BotID, e)
+		}
+		quoteFeedType, quoteFeedURL := c.QuotePriceFeedType, c.QuotePriceFeedURL
+		if quoteFeedType == "" {
+			quoteFeedType, quoteFeedURL = "fixed", "1.0"
+		}
+		bot.quoteFeed, e = MakePriceFeed(quoteFeedType, quoteFeedURL)
+		if e != nil {
+			return nil, fmt.Errorf("could not make quote price feed of bot '%s': %s", c.BotID, e)
+		}
+		bot.budgetQuery, e = queries.MakePortfolioBudgetQuery(db, c.BotID)
+		if e != nil {
+			return nil, fmt.Errorf("could not make budget query of bot '%s': %s", c.BotID, e)
+		}
+		if config.PerformanceSensitivity > 0 {
+			bot.closuresQuery, e = queries.MakeInventoryLotClosures(db, c.AccountID, c.MarketID)
+			if e != nil {
+				return nil, fmt.Errorf("could not make inventory lot closures query of bot '%s': %s", c.BotID, e)
+			}
+		}
+		bots = append(bots, bot)
+	}
+
+	return &PortfolioManager{
+		db:     db,
+		config: config,
+		bots:   bots,
+	}, nil
+}
+
+// Run updates the budgets every UPDATE_INTERVAL_SECONDS, it never returns
+func (m *PortfolioManager) Run() {
+	for {
+		_, e := m.Update(time.Now())
+		if e != nil {
+			log.Printf("error updating the portfolio budgets, keeping the previous budgets until the next update: %s\n", e)
+		}
+		time.Sleep(time.Duration(m.config.UpdateIntervalSeconds) * time.Second)
+	}
+}
+
+// Update recomputes the budgets of all the bots and writes them to the db, no budget is written when the data of any bot cannot be loaded
+func (m *PortfolioManager) Update(now time.Time) ([]queries.PortfolioBudget, error) {
+	performances := []portfolioBotPerformance{}
+	basePrices := []float64{}
+	quotePrices := []float64{}
+	for _, bot := range m.bots {
+		basePrice, e := bot.baseFeed.GetPrice()
+		if e != nil {
+			return nil, fmt.Errorf("could not get base price of bot '%s': %s", bot.config.BotID, e)
+		}
+		quotePrice, e := bot.quoteFeed.GetPrice()
+		if e != nil {
+			return nil, fmt.Errorf("could not get quote price of bot '%s': %s", bot.config.BotID, e)
+		}
+		if basePrice <= 0 || quotePrice <= 0 {
+			return nil, fmt.Errorf("prices of bot '%s' need to be > 0, base price was %f and quote price was %f", bot.config.BotID, basePrice, quotePrice)
+		}
+		basePrices = append(basePrices, basePrice)
+		quotePrices = append(quotePrices, quotePrice)
+
+		performance, e := m.loadPerformance(bot, quotePrice, now)
+		if e != nil {
+			return nil, fmt.Errorf("could not load performance of bot '%s': %s", bot.config.BotID, e)
+		}
+		performances = append(performances, performance)
+	}
+
+	budgets := computePortfolioBudgets(m.config, performances)
+	result := []queries.PortfolioBudget{}
+	for i, bot := range m.bots {
+		budget := queries.PortfolioBudget{
+			BotID:               bot.config.BotID,
+			Budget:              budgets[i],
+			RealizedPnL:         performances[i].realizedPnL,
+			MaxExposureBase:     budgets[i] * bot.config.ExposureFraction / basePrices[i],
+			MaxDailyVolumeQuote: budgets[i] * bot.config.DailyTurnover / quotePrices[i],
+			DateUpdatedUTC:      now.UTC(),
+		}
+		sqlInsert := fmt.Sprintf(kelpdb.SqlPortfolioBudgetsUpsertTemplate,
+			budget.BotID,
+			budget.Budget,
+			budget.RealizedPnL,
+			budget.MaxExposureBase,
+			budget.MaxDailyVolumeQuote,
+			budget.DateUpdatedUTC.Format(postgresdb.TimestampFormatString),
+		)
+		_, e := m.db.Exec(sqlInsert)
+		if e != nil {
+			return nil, fmt.Errorf("could not write budget of bot '%s': %s", bot.config.BotID, e)
+		}
+		log.Printf("portfolio manager: bot '%s' has a budget of %.7f (realized P&L %.7f), maxExposureBase=%.7f, maxDailyVolumeQuote=%.7f\n",
+			budget.BotID, budget.Budget, budget.RealizedPnL, budget.MaxExposureBase, budget.MaxDailyVolumeQuote)
+		result = append(result, budget)
+	}
+	return result, nil
+}
+
+// loadPerformance loads the previous budget of the bot and its realized P&L over the lookback window converted to units of the portfolio
+func (m *PortfolioManager) loadPerformance(bot *portfolioBot, quotePrice float64, now time.Time) (portfolioBotPerformance, error) {
+	performance := portfolioBotPerformance{}
+	result, e := bot.budgetQuery.QueryRow()
+	if e != nil {
+		return performance, e
+	}
+	previousBudget, ok := result.(*queries.PortfolioBudget)
+	if !ok {
+		return performance, fmt.Errorf("incorrect type returned from PortfolioBudgetQuery query, expecting '*queries.PortfolioBudget' but was '%T'", result)
+	}
+	if previousBudget != nil {
+		performance.previousBudget = previousBudget.Budget
+	}
+
+	if bot.closuresQuery == nil {
+		return performance, nil
+	}
+	start := now.Add(-time.Duration(m.config.PerformanceLookbackDays) * 24 * time.Hour)
+	result, e = bot.closuresQuery.QueryRow(start, now)
+	if e != nil {
+		return performance, e
+	}
+	closures, ok := result.([]queries.InventoryLotClosure)
+	if !ok {
+		return performance, fmt.Errorf("incorrect type returned from InventoryLotClosures query, expecting '[]queries.InventoryLotClosure' but was '%T'", result)
+	}
+	for _, c := range closures {
+		// the realized P&L of a lot is in units of the quote asset of the market
+		performance.realizedPnL += c.RealizedPnL * quotePrice
+	}
+	return performance, nil
+}
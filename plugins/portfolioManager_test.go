@@ -0,0 +1,143 @@
+package plugins
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/kelp/support/postgresdb"
+)
+
+func makeTestPortfolioConfig(sensitivity float64) *PortfolioConfig {
+	bot := func(botID string, weight float64, minBudget float64, maxBudget float64) PortfolioBotConfig {
+		return PortfolioBotConfig{
+			BotID:             botID,
+			AccountID:         "account",
+			MarketID:          "market_" + botID,
+			Weight:            weight,
+			MinBudget:         minBudget,
+			MaxBudget:         maxBudget,
+			ExposureFraction:  0.5,
+			DailyTurnover:     1.0,
+			BasePriceFeedType: "fixed",
+			BasePriceFeedURL:  "0.1",
+		}
+	}
+	return &PortfolioConfig{
+		TotalCapital:            1000.0,
+		UpdateIntervalSeconds:   60,
+		PerformanceLookbackDays: 7,
+		PerformanceSensitivity:  sensitivity,
+		MinPerformanceFactor:    0.5,
+		MaxPerformanceFactor:    2.0,
+		PostgresDbConfig:        &postgresdb.Config{},
+		Bots: []PortfolioBotConfig{
+			bot("a", 3.0, 0, 0),
+			bot("b", 1.0, 0, 0),
+		},
+	}
+}
+
+func TestComputePortfolioBudgets(t *testing.T) {
+	testCases := []struct {
+		name         string
+		sensitivity  float64
+		minBudgetB   float64
+		maxBudgetA   float64
+		performances []portfolioBotPerformance
+		wantBudgets  []float64
+	}{
+		{
+			name:         "split by weight",
+			sensitivity:  0,
+			performances: []portfolioBotPerformance{{}, {}},
+			wantBudgets:  []float64{750, 250},
+		}, {
+			name:         "performance is ignored without sensitivity",
+			sensitivity:  0,
+			performances: []portfolioBotPerformance{{previousBudget: 750, realizedPnL: -75}, {previousBudget: 250, realizedPnL: 25}},
+			wantBudgets:  []float64{750, 250},
+		}, {
+			// a loses 10% of its budget (factor 0.5) and b makes 10% of its budget (factor 1.5): weights 1.5 and 1.5
+			name:         "performance moves capital to the better bot",
+			sensitivity:  5,
+			performances: []portfolioBotPerformance{{previousBudget: 750, realizedPnL: -75}, {previousBudget: 250, realizedPnL: 25}},
+			wantBudgets:  []float64{500, 500},
+		}, {
+			// b makes 100% of its budget, which is capped by the max factor of 2: weights 3 and 2
+			name:         "factor is bounded",
+			sensitivity:  5,
+			performances: []portfolioBotPerformance{{previousBudget: 750}, {previousBudget: 250, realizedPnL: 250}},
+			wantBudgets:  []float64{600, 400},
+		}, {
+			// without a previous budget the return is computed on the share of the capital by weight
+			name:         "first budget",
+			sensitivity:  1,
+			performances: []portfolioBotPerformance{{}, {realizedPnL: 125}},
+			wantBudgets:  []float64{3000.0 / 4.5, 1500.0 / 4.5},
+		}, {
+			name:         "min and max budgets",
+			sensitivity:  0,
+			minBudgetB:   300,
+			maxBudgetA:   600,
+			performances: []portfolioBotPerformance{{}, {}},
+			wantBudgets:  []float64{600, 300},
+		}, {
+			name:         "min budget is taken from the other bots",
+			sensitivity:  0,
+			minBudgetB:   400,
+			performances: []portfolioBotPerformance{{}, {}},
+			wantBudgets:  []float64{600, 400},
+		}, {
+			name:         "capital cut off by a max budget goes to the other bots",
+			sensitivity:  0,
+			maxBudgetA:   500,
+			performances: []portfolioBotPerformance{{}, {}},
+			wantBudgets:  []float64{500, 500},
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			config := makeTestPortfolioConfig(k.sensitivity)
+			config.Bots[0].MaxBudget = k.maxBudgetA
+			config.Bots[1].MinBudget = k.minBudgetB
+			budgets := computePortfolioBudgets(config, k.performances)
+			if !assert.Equal(t, len(k.wantBudgets), len(budgets)) {
+				return
+			}
+			for i := range budgets {
+				assert.InDelta(t, k.wantBudgets[i], budgets[i], 0.0000001, config.Bots[i].BotID)
+			}
+		})
+	}
+}
+
+func TestPortfolioConfigValidate(t *testing.T) {
+	assert.NoError(t, makeTestPortfolioConfig(5).Validate())
+
+	invalidFns := map[string]func(c *PortfolioConfig){
+		"no capital":                func(c *PortfolioConfig) { c.TotalCapital = 0 },
+		"no db":                     func(c *PortfolioConfig) { c.PostgresDbConfig = nil },
+		"no bots":                   func(c *PortfolioConfig) { c.Bots = nil },
+		"duplicate bot id":          func(c *PortfolioConfig) { c.Bots[1].BotID = c.Bots[0].BotID },
+		"zero weight":               func(c *PortfolioConfig) { c.Bots[0].Weight = 0 },
+		"min above max":             func(c *PortfolioConfig) { c.Bots[0].MinBudget, c.Bots[0].MaxBudget = 10, 5 },
+		"no base feed":              func(c *PortfolioConfig) { c.Bots[0].BasePriceFeedURL = "" },
+		"no market for pnl":         func(c *PortfolioConfig) { c.Bots[0].MarketID = "" },
+		"inverted factors":          func(c *PortfolioConfig) { c.MinPerformanceFactor, c.MaxPerformanceFactor = 2, 1 },
+		"no lookback window":        func(c *PortfolioConfig) { c.PerformanceLookbackDays = 0 },
+		"min budgets above capital": func(c *PortfolioConfig) { c.Bots[0].MinBudget, c.Bots[1].MinBudget = 600, 500 },
+	}
+	for name, fn := range invalidFns {
+		config := makeTestPortfolioConfig(5)
+		fn(config)
+		assert.Error(t, config.Validate(), name)
+	}
+
+	// the market is only needed to load the realized P&L
+	config := makeTestPortfolioConfig(0)
+	config.Bots[0].MarketID = ""
+	config.PerformanceLookbackDays = 0
+	assert.NoError(t, config.Validate())
+}
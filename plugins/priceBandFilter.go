@@ -0,0 +1,203 @@
+package plugins
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// PriceBandAction is what the priceBandFilter does with an operation whose price is outside the band
+type PriceBandAction string
+
+// type of PriceBandAction
+const (
+	PriceBandActionReject  PriceBandAction = "reject"
+	PriceBandActionReprice PriceBandAction = "reprice"
+)
+
+// ParsePriceBandAction converts a string to the PriceBandAction
+func ParsePriceBandAction(action string) (PriceBandAction, error) {
+	if action == string(PriceBandActionReject) {
+		return PriceBandActionReject, nil
+	} else if action == string(PriceBandActionReprice) {
+		return PriceBandActionReprice, nil
+	}
+	return PriceBandActionReject, fmt.Errorf("invalid price band action '%s', needs to be either '%s' or '%s'", action, PriceBandActionReject, PriceBandActionReprice)
+}
+
+// priceBandFilter guards against fat-finger configs and bad feed readings by rejecting or repricing any operation whose price is
+// outside of either absolute bounds or a percentage band around a reference price feed
+type priceBandFilter struct {
+	name        string
+	configValue string
+	baseAsset   hProtocol.Asset
+	quoteAsset  hProtocol.Asset
+	action      PriceBandAction
+	minPrice    float64       // used when pf is nil
+	maxPrice    float64       // used when pf is nil
+	bandPercent float64       // used when pf is non-nil
+	pf          api.PriceFeed // reference price feed, nil when using absolute bounds
+}
+
+// makeFilterPriceBandAbsolute makes a submit filter that keeps the price of operations within [minPrice, maxPrice]
+func makeFilterPriceBandAbsolute(
+	configValue string,
+	baseAsset hProtocol.Asset,
+	quoteAsset hProtocol.Asset,
+	action PriceBandAction,
+	minPrice float64,
+	maxPrice float64,
+) (SubmitFilter, error) {
+	if minPrice < 0.0 || maxPrice <= minPrice {
+		return nil, fmt.Errorf("invalid price band, expected 0.0 <= minPrice < maxPrice; was minPrice=%f, maxPrice=%f", minPrice, maxPrice)
+	}
+
+	return &priceBandFilter{
+		name:        "priceBandFilter",
+		configValue: configValue,
+		baseAsset:   baseAsset,
+		quoteAsset:  quoteAsset,
+		action:      action,
+		minPrice:    minPrice,
+		maxPrice:    maxPrice,
+	}, nil
+}
+
+// makeFilterPriceBandFeed makes a submit filter that keeps the price of operations within bandPercent of the price from the price feed
+func makeFilterPriceBandFeed(
+	configValue string,
+	baseAsset hProtocol.Asset,
+	quoteAsset hProtocol.Asset,
+	action PriceBandAction,
+	bandPercent float64,
+	pf api.PriceFeed,
+) (SubmitFilter, error) {
+	if bandPercent <= 0.0 || bandPercent >= 1.0 {
+		return nil, fmt.Errorf("invalid band percent, expected 0.0 < bandPercent < 1.0; was %f", bandPercent)
+	}
+
+	return &priceBandFilter{
+		name:        "priceBandFilter",
+		configValue: configValue,
+		baseAsset:   baseAsset,
+		quoteAsset:  quoteAsset,
+		action:      action,
+		bandPercent: bandPercent,
+		pf:          pf,
+	}, nil
+}
+
+var _ SubmitFilter = &priceBandFilter{}
+var _ OrderedSubmitFilter = &priceBandFilter{}
+
+// FilterOrder impl.
+func (f *priceBandFilter) FilterOrder() FilterOrder {
+	return FilterOrder{
+		ID:       filterIDPriceBand,
+		Priority: FilterPriorityPrice,
+		After:    []string{filterIDMakerMode},
+	}
+}
+
+// Apply impl.
+func (f *priceBandFilter) Apply(ops []txnbuild.Operation, sellingOffers []hProtocol.Offer, buyingOffers []hProtocol.Offer) ([]txnbuild.Operation, error) {
+	lowerPrice, upperPrice, e := f.bounds()
+	if e != nil {
+		return nil, fmt.Errorf("could not compute price band: %s", e)
+	}
+	log.Printf("priceBandFilter: lowerPrice=%.10f, upperPrice=%.10f, action=%s\n", lowerPrice, upperPrice, f.action)
+
+	innerFn := func(op *txnbuild.ManageSellOffer) (*txnbuild.ManageSellOffer, error) {
+		return f.priceBandFilterFn(lowerPrice, upperPrice, op)
+	}
+	ops, e = filterOps(f.name, f.baseAsset, f.quoteAsset, sellingOffers, buyingOffers, ops, innerFn)
+	if e != nil {
+		return nil, fmt.Errorf("could not apply filter: %s", e)
+	}
+	return ops, nil
+}
+
+// bounds returns the band of allowed prices in units of the quote asset, which is fetched from the price feed once per Apply
+func (f *priceBandFilter) bounds() (float64, float64, error) {
+	if f.pf == nil {
+		return f.minPrice, f.maxPrice, nil
+	}
+
+	referencePrice, e := f.pf.GetPrice()
+	if e != nil {
+		return 0, 0, fmt.Errorf("could not get price from priceFeed: %s", e)
+	}
+	if referencePrice <= 0.0 {
+		return 0, 0, fmt.Errorf("invalid reference price from priceFeed, expected > 0.0; was %f", referencePrice)
+	}
+	return referencePrice * (1 - f.bandPercent), referencePrice * (1 + f.bandPercent), nil
+}
+
+func (f *priceBandFilter) priceBandFilterFn(lowerPrice float64, upperPrice float64, op *txnbuild.ManageSellOffer) (*txnbuild.ManageSellOffer, error) {
+	isSell, e := utils.IsSelling(f.baseAsset, f.quoteAsset, op.Selling, op.Buying)
+	if e != nil {
+		return nil, fmt.Errorf("error when running the isSelling check for offer '%+v': %s", *op, e)
+	}
+
+	sellPrice, e := strconv.ParseFloat(op.Price, 64)
+	if e != nil {
+		return nil, fmt.Errorf("could not convert price (%s) to float: %s", op.Price, e)
+	}
+
+	// reorient price to be in the context of the bot's base and quote asset, in quote units
+	price := sellPrice
+	if !isSell {
+		// invert price for buy side
+		price = 1 / sellPrice
+	}
+
+	if price >= lowerPrice && price <= upperPrice {
+		log.Printf("priceBandFilter: isSell=%v, price=%.10f, keep=true", isSell, price)
+		return op, nil
+	}
+
+	if f.action == PriceBandActionReject {
+		log.Printf("priceBandFilter: isSell=%v, price=%.10f is outside the band, keep=false", isSell, price)
+		return nil, nil
+	}
+
+	newPrice := lowerPrice
+	if price > upperPrice {
+		newPrice = upperPrice
+	}
+	if newPrice <= 0.0 {
+		log.Printf("priceBandFilter: isSell=%v, price=%.10f cannot be repriced to %.10f, keep=false", isSell, price, newPrice)
+		return nil, nil
+	}
+	return repriceOfferKeepingBaseAmount(f.name, isSell, price, newPrice, op)
+}
+
+// repriceOfferKeepingBaseAmount moves an offer to newPrice (in quote units), keeping the same amount of the base asset
+func repriceOfferKeepingBaseAmount(filterName string, isSell bool, price float64, newPrice float64, op *txnbuild.ManageSellOffer) (*txnbuild.ManageSellOffer, error) {
+	newOp := *op
+	if isSell {
+		newOp.Price = strconv.FormatFloat(newPrice, 'f', int(sdexOrderConstraints.PricePrecision), 64)
+		log.Printf("%s: isSell=true, repriced from %.10f to %s", filterName, price, newOp.Price)
+		return &newOp, nil
+	}
+
+	// a buy op is denominated in the quote asset with the price inverted, so the amount is scaled to keep the same amount of the base asset
+	amount, e := strconv.ParseFloat(op.Amount, 64)
+	if e != nil {
+		return nil, fmt.Errorf("could not convert amount (%s) to float: %s", op.Amount, e)
+	}
+	newOp.Price = strconv.FormatFloat(1/newPrice, 'f', int(sdexOrderConstraints.PricePrecision), 64)
+	newOp.Amount = strconv.FormatFloat(amount*newPrice/price, 'f', int(sdexOrderConstraints.VolumePrecision), 64)
+	log.Printf("%s: isSell=false, repriced from %.10f to %.10f (op price = %s, op amount = %s)", filterName, price, newPrice, newOp.Price, newOp.Amount)
+	return &newOp, nil
+}
+
+// String is the Stringer method
+func (f *priceBandFilter) String() string {
+	return f.configValue
+}
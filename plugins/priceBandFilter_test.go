@@ -0,0 +1,130 @@
+package plugins
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/kelp/support/utils"
+)
+
+func TestPriceBandFilterFn(t *testing.T) {
+	testCases := []struct {
+		name       string
+		action     PriceBandAction
+		op         *txnbuild.ManageSellOffer
+		wantOp     *txnbuild.ManageSellOffer
+		wantKeepOp bool
+	}{
+		{
+			name:       "sell inside band",
+			action:     PriceBandActionReject,
+			op:         &txnbuild.ManageSellOffer{Selling: testBaseAsset, Buying: testQuoteAsset, Amount: "10.0", Price: "1.05"},
+			wantOp:     &txnbuild.ManageSellOffer{Selling: testBaseAsset, Buying: testQuoteAsset, Amount: "10.0", Price: "1.05"},
+			wantKeepOp: true,
+		}, {
+			name:       "sell above band rejected",
+			action:     PriceBandActionReject,
+			op:         &txnbuild.ManageSellOffer{Selling: testBaseAsset, Buying: testQuoteAsset, Amount: "10.0", Price: "1.5"},
+			wantKeepOp: false,
+		}, {
+			name:       "sell above band repriced",
+			action:     PriceBandActionReprice,
+			op:         &txnbuild.ManageSellOffer{Selling: testBaseAsset, Buying: testQuoteAsset, Amount: "10.0", Price: "1.5"},
+			wantOp:     &txnbuild.ManageSellOffer{Selling: testBaseAsset, Buying: testQuoteAsset, Amount: "10.0", Price: "1.1000000"},
+			wantKeepOp: true,
+		}, {
+			// buy op at a price of 1/0.5 = 2.0 quote per base, buying 10 base for 20 quote
+			name:       "buy above band rejected",
+			action:     PriceBandActionReject,
+			op:         &txnbuild.ManageSellOffer{Selling: testQuoteAsset, Buying: testBaseAsset, Amount: "20.0", Price: "0.5"},
+			wantKeepOp: false,
+		}, {
+			// buy op at a price of 1/0.5 = 2.0 quote per base is moved to 1.1 quote per base, still buying 10 base
+			name:       "buy above band repriced",
+			action:     PriceBandActionReprice,
+			op:         &txnbuild.ManageSellOffer{Selling: testQuoteAsset, Buying: testBaseAsset, Amount: "20.0", Price: "0.5"},
+			wantOp:     &txnbuild.ManageSellOffer{Selling: testQuoteAsset, Buying: testBaseAsset, Amount: "11.0000000", Price: "0.9090909"},
+			wantKeepOp: true,
+		}, {
+			// buy op at a price of 1/2.0 = 0.5 quote per base is moved to 0.9 quote per base, still buying 10 base
+			name:       "buy below band repriced",
+			action:     PriceBandActionReprice,
+			op:         &txnbuild.ManageSellOffer{Selling: testQuoteAsset, Buying: testBaseAsset, Amount: "5.0", Price: "2.0"},
+			wantOp:     &txnbuild.ManageSellOffer{Selling: testQuoteAsset, Buying: testBaseAsset, Amount: "9.0000000", Price: "1.1111111"},
+			wantKeepOp: true,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			f := &priceBandFilter{
+				name:       "priceBandFilter",
+				baseAsset:  utils.Asset2Asset2(testBaseAsset),
+				quoteAsset: utils.Asset2Asset2(testQuoteAsset),
+				action:     k.action,
+			}
+
+			actual, e := f.priceBandFilterFn(0.9, 1.1, k.op)
+			if !assert.NoError(t, e) {
+				return
+			}
+			if !k.wantKeepOp {
+				assert.Nil(t, actual)
+				return
+			}
+			assert.Equal(t, k.wantOp, actual)
+		})
+	}
+}
+
+func TestPriceBandFilterBounds(t *testing.T) {
+	f, e := makeFilterPriceBandFeed("", utils.Asset2Asset2(testBaseAsset), utils.Asset2Asset2(testQuoteAsset), PriceBandActionReject, 0.05, &fixedFeed{price: 2.0})
+	if !assert.NoError(t, e) {
+		return
+	}
+	lower, upper, e := f.(*priceBandFilter).bounds()
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.InDelta(t, 1.9, lower, 0.0000001)
+	assert.InDelta(t, 2.1, upper, 0.0000001)
+
+	// a bad reading from the feed should not let any ops through
+	f, e = makeFilterPriceBandFeed("", utils.Asset2Asset2(testBaseAsset), utils.Asset2Asset2(testQuoteAsset), PriceBandActionReject, 0.05, &fixedFeed{price: 0.0})
+	if !assert.NoError(t, e) {
+		return
+	}
+	_, e = f.Apply([]txnbuild.Operation{}, nil, nil)
+	assert.Error(t, e)
+}
+
+func TestMakeFilterPriceBand(t *testing.T) {
+	testCases := []struct {
+		configInput string
+		wantError   bool
+	}{
+		{configInput: "priceBand/reject/abs/0.05/0.20", wantError: false},
+		{configInput: "priceBand/reprice/abs/0/0.20", wantError: false},
+		{configInput: "priceBand/reject/feed/0.05/fixed/1.0", wantError: false},
+		{configInput: "priceBand/reject/abs/0.20/0.05", wantError: true},
+		{configInput: "priceBand/reject/abs/0.05", wantError: true},
+		{configInput: "priceBand/reject/abs/0.05/0.20/0.30", wantError: true},
+		{configInput: "priceBand/delete/abs/0.05/0.20", wantError: true},
+		{configInput: "priceBand/reject/feed/1.5/fixed/1.0", wantError: true},
+		{configInput: "priceBand/reject/feed/0.05/fixed", wantError: true},
+		{configInput: "priceBand/reject/band/0.05/0.20", wantError: true},
+	}
+
+	factory := &FilterFactory{
+		BaseAsset:  utils.Asset2Asset2(testBaseAsset),
+		QuoteAsset: utils.Asset2Asset2(testQuoteAsset),
+	}
+	for _, k := range testCases {
+		t.Run(k.configInput, func(t *testing.T) {
+			_, e := factory.MakeFilter(k.configInput)
+			assert.Equal(t, k.wantError, e != nil, e)
+		})
+	}
+}
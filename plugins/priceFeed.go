@@ -33,19 +33,59 @@ func SetPrivateSdexHack(api *horizonclient.Client, ieif *IEIF, network string) e
 	return nil
 }
 
-// MakePriceFeed makes a PriceFeed
+// priceFeedAlert is used by price feeds to raise alerts, such as when a fallback feed is activated
+var priceFeedAlert api.Alert
+
+// SetPriceFeedAlert sets the alert that is triggered by price feeds, such as when a fallback feed is activated
+func SetPriceFeedAlert(alert api.Alert) {
+	priceFeedAlert = alert
+}
+
+// MakePriceFeed makes a PriceFeed, which is shared with the feeds of the same type and url when SetPriceFeedCacheTTL is set, validated with
+// the config set by SetFeedValidationConfig, and reports its prices to the recorder set by SetDecisionRecorder
 func MakePriceFeed(feedType string, url string) (api.PriceFeed, error) {
+	pf, e := makeCachedPriceFeed(feedType, url)
+	if e != nil {
+		return nil, e
+	}
+	return withDecisionRecorder(feedType, url, withFeedValidation(feedType, url, pf)), nil
+}
+
+func makePriceFeed(feedType string, url string) (api.PriceFeed, error) {
 	switch feedType {
 	case "crypto":
 		return newCMCFeed(url), nil
+	case "cmc":
+		// [0] = asset (a symbol, a key of CMC_SYMBOL_MAP or "id:<id>"), [1] = quote currency
+		cmcFeed, e := makeCmcProFeed(url)
+		if e != nil {
+			return nil, fmt.Errorf("error while making the cmc feed for URL '%s': %s", url, e)
+		}
+		return cmcFeed, nil
+	case "oracle":
+		// [0] = contract of a SEP-40 oracle, [1] = asset, [2] = quote asset (optional)
+		oracleFeed, e := makeOracleFeed(url)
+		if e != nil {
+			return nil, fmt.Errorf("error while making the oracle feed for URL '%s': %s", url, e)
+		}
+		return oracleFeed, nil
 	case "fiat":
-		return newFiatFeed(url), nil
+		if strings.HasPrefix(url, "http://") || strings.HasPrefix(url, "https://") {
+			// backward-compatible case of a currencylayer URL
+			return newFiatFeed(url), nil
+		}
+		// [0] = asset, [1] = quote, [2] = providers separated by '|' (optional), e.g. NGN/USD/oxr|ecb
+		fiatFeed, e := makeFiatProvidersFeed(url)
+		if e != nil {
+			return nil, fmt.Errorf("error while making the fiat feed for URL '%s': %s", url, e)
+		}
+		return fiatFeed, nil
 	case "fiat-oxr":
 		return newFiatFeedOxr(url), nil
 	case "fixed":
 		return newFixedFeed(url)
 	case "exchange":
-		// [0] = exchangeType, [1] = base, [2] = quote, [3] = modifier (optional)
+		// [0] = exchangeType, [1] = base, [2] = quote, [3] = modifier (optional), where the modifier can be mid, ask, bid, last, or obi[:depth]
 		urlParts := strings.Split(url, "/")
 		if len(urlParts) < 3 || len(urlParts) > 4 {
 			return nil, fmt.Errorf("invalid format of exchange type URL, needs either 3 or 4 parts after splitting URL by '/', has %d: %s", len(urlParts), url)
@@ -74,14 +114,29 @@ func MakePriceFeed(feedType string, url string) (api.PriceFeed, error) {
 			Quote: quoteAsset,
 		}
 		tickerAPI := api.TickerAPI(exchange)
-		return newExchangeFeed(url, &tickerAPI, &tradingPair, exchangeModifier)
+		return newExchangeFeed(url, &tickerAPI, exchange, &tradingPair, exchangeModifier)
+	case "stream":
+		// [0] = exchange (binance or kraken), [1] = base, [2] = quote, [3] = modifier (optional), one of mid, ask, bid, or last
+		streamFeed, e := makeStreamFeed(url)
+		if e != nil {
+			return nil, fmt.Errorf("error while making the stream feed for URL '%s': %s", url, e)
+		}
+		return streamFeed, nil
 	case "sdex":
 		sdex, e := makeSDEXFeed(url)
 		if e != nil {
 			return nil, fmt.Errorf("error occurred while making the SDEX price feed: %s", e)
 		}
 		return sdex, nil
-	case "function":
+	case "amm":
+		// same url format as the sdex feed, the price is the ratio of the reserves of the liquidity pool of the two assets
+		amm, e := makeAMMFeed(url)
+		if e != nil {
+			return nil, fmt.Errorf("error occurred while making the AMM price feed: %s", e)
+		}
+		return amm, nil
+	case "function", "compose":
+		// "compose" is an alias that reads better for arithmetic on feeds, e.g. compose/divide(feedA,feedB)
 		fnFeed, e := makeFunctionPriceFeed(url)
 		if e != nil {
 			return nil, fmt.Errorf("error while making function feed for URL '%s': %s", url, e)
@@ -65,6 +65,16 @@ func MakeFilterPriceFeed(baseAsset hProtocol.Asset, quoteAsset hProtocol.Asset,
 }
 
 var _ SubmitFilter = &priceFeedFilter{}
+var _ OrderedSubmitFilter = &priceFeedFilter{}
+
+// FilterOrder impl.
+func (f *priceFeedFilter) FilterOrder() FilterOrder {
+	return FilterOrder{
+		ID:       filterIDPriceFeed,
+		Priority: FilterPriorityPrice,
+		After:    []string{filterIDMakerMode},
+	}
+}
 
 func (f *priceFeedFilter) Apply(ops []txnbuild.Operation, sellingOffers []hProtocol.Offer, buyingOffers []hProtocol.Offer) ([]txnbuild.Operation, error) {
 	ops, e := filterOps(f.name, f.baseAsset, f.quoteAsset, sellingOffers, buyingOffers, ops, f.priceFeedFilterFn)
@@ -2,15 +2,45 @@ package plugins
 
 import (
 	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/stellar/kelp/api"
 )
 
+// fallbackStaleParamPrefix is the prefix of the params of the 'fallback' function that opt a feed into the stale check, for example
+// stale_seconds_0=600 considers the feed at index 0 to be stale when its price has not changed for 10 minutes
+const fallbackStaleParamPrefix = "stale_seconds_"
+
+// medianDefaultMaxDeviation is the default max_deviation of the 'median' function, as a fraction of the median of all the feeds
+const medianDefaultMaxDeviation = 0.02
+
+// weightedDefaultMinWeight is the default min_weight of the 'weighted' function, which needs all the feeds to return a price
+const weightedDefaultMinWeight = 1.0
+
 type fnFactory func(feeds []api.PriceFeed) (api.PriceFeed, error)
 
 var fnFactoryMap = map[string]fnFactory{
-	"max":    max,
-	"invert": invert,
+	"max":      max,
+	"invert":   invert,
+	"multiply": multiply,
+	"divide":   divide,
+}
+
+// paramFnFactory is a fnFactory for functions that also take params, which are passed as key=value args after the feeds
+type paramFnFactory func(feeds []api.PriceFeed, params map[string]string) (api.PriceFeed, error)
+
+var paramFnFactoryMap = map[string]paramFnFactory{
+	"fallback": fallback,
+	"median":   median,
+	"weighted": weighted,
+	"sma":      sma,
+	"ema":      ema,
 }
 
 func max(feeds []api.PriceFeed) (api.PriceFeed, error) {
@@ -56,3 +86,481 @@ func invert(feeds []api.PriceFeed) (api.PriceFeed, error) {
 		return 1 / innerPrice, nil
 	}), nil
 }
+
+// multiply returns the product of the prices of the feeds, which chains rates such as XLM/BTC * BTC/USD = XLM/USD
+func multiply(feeds []api.PriceFeed) (api.PriceFeed, error) {
+	if len(feeds) < 2 {
+		return nil, fmt.Errorf("need to provide at least 2 price feeds to the 'multiply' price feed function but found only %d price feeds", len(feeds))
+	}
+
+	return makeFunctionFeed(func() (float64, error) {
+		product := 1.0
+		for i, f := range feeds {
+			innerPrice, e := f.GetPrice()
+			if e != nil {
+				return 0.0, fmt.Errorf("error fetching price from feed (index=%d) in 'multiply' function feed: %s", i, e)
+			}
+
+			if innerPrice <= 0.0 {
+				return 0.0, fmt.Errorf("inner price of feed at index %d was <= 0.0 (%.10f)", i, innerPrice)
+			}
+
+			product *= innerPrice
+		}
+		return product, nil
+	}), nil
+}
+
+// divide returns the price of the first feed divided by the price of the second feed, which derives a cross rate from two feeds with the
+// same quote asset such as BTC/USD / EUR/USD = BTC/EUR
+func divide(feeds []api.PriceFeed) (api.PriceFeed, error) {
+	if len(feeds) != 2 {
+		return nil, fmt.Errorf("need to provide exactly 2 price feeds to the 'divide' function but found %d price feeds", len(feeds))
+	}
+
+	return makeFunctionFeed(func() (float64, error) {
+		numerator, e := feeds[0].GetPrice()
+		if e != nil {
+			return 0.0, fmt.Errorf("error fetching price from numerator feed in 'divide' function feed: %s", e)
+		}
+		if numerator <= 0.0 {
+			return 0.0, fmt.Errorf("price of numerator feed was <= 0.0 (%.10f)", numerator)
+		}
+
+		denominator, e := feeds[1].GetPrice()
+		if e != nil {
+			return 0.0, fmt.Errorf("error fetching price from denominator feed in 'divide' function feed: %s", e)
+		}
+		if denominator <= 0.0 {
+			return 0.0, fmt.Errorf("price of denominator feed was <= 0.0 (%.10f)", denominator)
+		}
+
+		return numerator / denominator, nil
+	}), nil
+}
+
+// fallback returns the price of the first feed that does not error. A feed is only checked for a price that has stopped changing when it
+// opts in with a stale_seconds_<index> param, since some feeds (such as fixed feeds or illiquid markets) legitimately keep the same price.
+func fallback(feeds []api.PriceFeed, params map[string]string) (api.PriceFeed, error) {
+	if len(feeds) < 2 {
+		return nil, fmt.Errorf("need to provide at least 2 price feeds to the 'fallback' price feed function but found only %d price feeds", len(feeds))
+	}
+
+	staleDurations := make([]time.Duration, len(feeds))
+	for k, v := range params {
+		if !strings.HasPrefix(k, fallbackStaleParamPrefix) {
+			return nil, fmt.Errorf("unknown param '%s' of the 'fallback' function, needs to be of the form %s<feed index>", k, fallbackStaleParamPrefix)
+		}
+		i, e := strconv.Atoi(strings.TrimPrefix(k, fallbackStaleParamPrefix))
+		if e != nil || i < 0 || i >= len(feeds) {
+			return nil, fmt.Errorf("param '%s' of the 'fallback' function needs to end with the index of one of the %d feeds", k, len(feeds))
+		}
+		seconds, e := strconv.Atoi(v)
+		if e != nil || seconds <= 0 {
+			return nil, fmt.Errorf("param '%s' of the 'fallback' function needs to be an integer number of seconds > 0 but was '%s'", k, v)
+		}
+		staleDurations[i] = time.Duration(seconds) * time.Second
+	}
+
+	chain := makeFallbackChain(feeds, staleDurations, time.Now)
+	return makeFunctionFeed(chain.getPrice), nil
+}
+
+// fallbackChain returns the price from the first feed in the chain that neither errors nor is stale
+type fallbackChain struct {
+	feeds          []api.PriceFeed
+	staleDurations []time.Duration // a zero duration means the feed is never considered stale
+	nowFn          func() time.Time
+
+	// uninitialized
+	lastPrices       []float64
+	lastPriceChanges []time.Time
+	activeIndex      int
+}
+
+func makeFallbackChain(feeds []api.PriceFeed, staleDurations []time.Duration, nowFn func() time.Time) *fallbackChain {
+	return &fallbackChain{
+		feeds:            feeds,
+		staleDurations:   staleDurations,
+		nowFn:            nowFn,
+		lastPrices:       make([]float64, len(feeds)),
+		lastPriceChanges: make([]time.Time, len(feeds)),
+		activeIndex:      0,
+	}
+}
+
+func (c *fallbackChain) getPrice() (float64, error) {
+	failures := []string{}
+	for i, f := range c.feeds {
+		innerPrice, e := f.GetPrice()
+		if e != nil {
+			failures = append(failures, fmt.Sprintf("feed at index %d errored: %s", i, e))
+			continue
+		}
+
+		if innerPrice <= 0.0 {
+			failures = append(failures, fmt.Sprintf("inner price of feed at index %d was <= 0.0 (%.10f)", i, innerPrice))
+			continue
+		}
+
+		if c.isStale(i, innerPrice) {
+			failures = append(failures, fmt.Sprintf("feed at index %d is stale, price (%.10f) has not changed since %s", i, innerPrice, c.lastPriceChanges[i].Format(time.RFC3339)))
+			continue
+		}
+
+		c.activate(i, failures)
+		return innerPrice, nil
+	}
+
+	return 0.0, fmt.Errorf("all %d feeds in 'fallback' function feed failed: %v", len(c.feeds), failures)
+}
+
+// isStale records the price fetched from the feed at index i and returns true if it has not changed for longer than the stale duration
+// of that feed, feeds without a stale duration are never stale
+func (c *fallbackChain) isStale(i int, price float64) bool {
+	if c.staleDurations[i] == 0 {
+		return false
+	}
+
+	now := c.nowFn()
+	if c.lastPriceChanges[i].IsZero() || price != c.lastPrices[i] {
+		c.lastPrices[i] = price
+		c.lastPriceChanges[i] = now
+		return false
+	}
+	return now.Sub(c.lastPriceChanges[i]) > c.staleDurations[i]
+}
+
+// activate marks the feed at index i as the active feed and raises an alert whenever we fall back to a different secondary feed
+func (c *fallbackChain) activate(i int, failures []string) {
+	if i == 0 {
+		if c.activeIndex != 0 {
+			log.Printf("'fallback' function feed recovered, using primary feed again (previously using feed at index %d)\n", c.activeIndex)
+		}
+		c.activeIndex = 0
+		return
+	}
+
+	description := fmt.Sprintf("'fallback' function feed is using the feed at index %d", i)
+	log.Printf("%s because of the following failures: %v\n", description, failures)
+	if c.activeIndex == i {
+		// only alert when a fallback feed is activated, not on every price fetch while it remains active
+		return
+	}
+	c.activeIndex = i
+
+	if priceFeedAlert == nil {
+		return
+	}
+	e := priceFeedAlert.Trigger(description, failures)
+	if e != nil {
+		log.Printf("unable to trigger alert for fallback activation: %s\n", e)
+	}
+}
+
+// median returns the median of the feeds after discarding the feeds whose price deviates from the median of all the feeds by more than
+// max_deviation (a fraction, defaults to medianDefaultMaxDeviation), so a single bad feed cannot move the price. Feeds that error are
+// discarded as well and the price is only returned when at least min_feeds feeds are left (defaults to a majority of the feeds).
+func median(feeds []api.PriceFeed, params map[string]string) (api.PriceFeed, error) {
+	if len(feeds) < 3 {
+		return nil, fmt.Errorf("need to provide at least 3 price feeds to the 'median' price feed function so an outlier can be identified but found only %d price feeds", len(feeds))
+	}
+
+	maxDeviation := medianDefaultMaxDeviation
+	minFeeds := len(feeds)/2 + 1
+	for k, v := range params {
+		switch k {
+		case "max_deviation":
+			f, e := strconv.ParseFloat(v, 64)
+			if e != nil || f <= 0 {
+				return nil, fmt.Errorf("max_deviation of the 'median' function needs to be a decimal > 0 but was '%s'", v)
+			}
+			maxDeviation = f
+		case "min_feeds":
+			n, e := strconv.Atoi(v)
+			if e != nil || n < 1 || n > len(feeds) {
+				return nil, fmt.Errorf("min_feeds of the 'median' function needs to be an integer between 1 and the number of feeds (%d) but was '%s'", len(feeds), v)
+			}
+			minFeeds = n
+		default:
+			return nil, fmt.Errorf("unknown param '%s' of the 'median' function, needs to be one of max_deviation or min_feeds", k)
+		}
+	}
+
+	m := &medianFeed{
+		feeds:        feeds,
+		maxDeviation: maxDeviation,
+		minFeeds:     minFeeds,
+	}
+	return makeFunctionFeed(m.getPrice), nil
+}
+
+// medianFeed fetches all its feeds concurrently and returns the median of the prices that are not outliers
+type medianFeed struct {
+	feeds        []api.PriceFeed
+	maxDeviation float64
+	minFeeds     int
+}
+
+func (m *medianFeed) getPrice() (float64, error) {
+	prices, errs := fetchPricesConcurrently(m.feeds)
+	failures := []string{}
+	validPrices := []float64{}
+	validIndexes := []int{}
+	for i, p := range prices {
+		if errs[i] != nil {
+			failures = append(failures, fmt.Sprintf("feed at index %d errored: %s", i, errs[i]))
+		} else if p <= 0.0 {
+			failures = append(failures, fmt.Sprintf("inner price of feed at index %d was <= 0.0 (%.10f)", i, p))
+		} else {
+			validPrices = append(validPrices, p)
+			validIndexes = append(validIndexes, i)
+		}
+	}
+	if len(validPrices) < m.minFeeds {
+		return 0.0, fmt.Errorf("only %d of the %d feeds in 'median' function feed returned a price, need at least %d: %v", len(validPrices), len(m.feeds), m.minFeeds, failures)
+	}
+
+	center := medianOf(validPrices)
+	keptPrices := []float64{}
+	for j, p := range validPrices {
+		deviation := math.Abs(p-center) / center
+		if deviation > m.maxDeviation {
+			failures = append(failures, fmt.Sprintf("feed at index %d is an outlier, price (%.10f) deviates by %.4f from the median (%.10f)", validIndexes[j], p, deviation, center))
+			continue
+		}
+		keptPrices = append(keptPrices, p)
+	}
+	if len(failures) > 0 {
+		log.Printf("'median' function feed discarded %d of %d feeds: %s\n", len(failures), len(m.feeds), strings.Join(failures, "; "))
+	}
+	if len(keptPrices) < m.minFeeds {
+		return 0.0, fmt.Errorf("only %d of the %d feeds in 'median' function feed are within %.4f of the median, need at least %d: %v", len(keptPrices), len(m.feeds), m.maxDeviation, m.minFeeds, failures)
+	}
+	return medianOf(keptPrices), nil
+}
+
+// medianOf returns the median of a non-empty list of prices, which is the mean of the two middle prices when there is an even number of prices
+func medianOf(prices []float64) float64 {
+	sorted := append([]float64{}, prices...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+// fetchPricesConcurrently fetches the prices of all the feeds at the same time so a slow feed does not delay the others, the price and error
+// of each feed are at the index of the feed
+func fetchPricesConcurrently(feeds []api.PriceFeed) ([]float64, []error) {
+	prices := make([]float64, len(feeds))
+	errs := make([]error, len(feeds))
+	var wg sync.WaitGroup
+	for i, f := range feeds {
+		wg.Add(1)
+		go func(i int, f api.PriceFeed) {
+			defer wg.Done()
+			prices[i], errs[i] = f.GetPrice()
+		}(i, f)
+	}
+	wg.Wait()
+	return prices, errs
+}
+
+// weighted returns the weighted average of the feeds, which is useful when one venue leads the price. The weights param has one weight per
+// feed separated by ':' (e.g. weights=0.7:0.3) and the weights are normalized so they do not need to add up to 1. By default every feed needs
+// to return a price, min_weight (a fraction of the total weight) allows the feeds that error to be left out and the remaining weights to be
+// renormalized as long as the feeds that returned a price hold at least min_weight of the total weight.
+func weighted(feeds []api.PriceFeed, params map[string]string) (api.PriceFeed, error) {
+	if len(feeds) < 2 {
+		return nil, fmt.Errorf("need to provide at least 2 price feeds to the 'weighted' price feed function but found only %d price feeds", len(feeds))
+	}
+
+	var weights []float64
+	minWeight := weightedDefaultMinWeight
+	for k, v := range params {
+		switch k {
+		case "weights":
+			parts := strings.Split(v, ":")
+			if len(parts) != len(feeds) {
+				return nil, fmt.Errorf("weights of the 'weighted' function needs one weight per feed (%d) separated by ':' but was '%s'", len(feeds), v)
+			}
+			weights = []float64{}
+			for _, part := range parts {
+				w, e := strconv.ParseFloat(part, 64)
+				if e != nil || w <= 0 {
+					return nil, fmt.Errorf("every weight of the 'weighted' function needs to be a decimal > 0 but found '%s' in '%s'", part, v)
+				}
+				weights = append(weights, w)
+			}
+		case "min_weight":
+			f, e := strconv.ParseFloat(v, 64)
+			if e != nil || f <= 0 || f > 1 {
+				return nil, fmt.Errorf("min_weight of the 'weighted' function needs to be a decimal in the range (0, 1] but was '%s'", v)
+			}
+			minWeight = f
+		default:
+			return nil, fmt.Errorf("unknown param '%s' of the 'weighted' function, needs to be one of weights or min_weight", k)
+		}
+	}
+	if weights == nil {
+		return nil, fmt.Errorf("the 'weighted' function needs the weights param with one weight per feed separated by ':', e.g. weights=0.7:0.3")
+	}
+
+	totalWeight := 0.0
+	for _, w := range weights {
+		totalWeight += w
+	}
+	normalized := []float64{}
+	for _, w := range weights {
+		normalized = append(normalized, w/totalWeight)
+	}
+
+	w := &weightedFeed{
+		feeds:     feeds,
+		weights:   normalized,
+		minWeight: minWeight,
+	}
+	return makeFunctionFeed(w.getPrice), nil
+}
+
+// weightedFeed fetches all its feeds concurrently and returns the weighted average of their prices, weights add up to 1
+type weightedFeed struct {
+	feeds     []api.PriceFeed
+	weights   []float64
+	minWeight float64
+}
+
+func (w *weightedFeed) getPrice() (float64, error) {
+	prices, errs := fetchPricesConcurrently(w.feeds)
+
+	failures := []string{}
+	weightedSum := 0.0
+	availableWeight := 0.0
+	for i, p := range prices {
+		if errs[i] != nil {
+			failures = append(failures, fmt.Sprintf("feed at index %d errored: %s", i, errs[i]))
+		} else if p <= 0.0 {
+			failures = append(failures, fmt.Sprintf("inner price of feed at index %d was <= 0.0 (%.10f)", i, p))
+		} else {
+			weightedSum += w.weights[i] * p
+			availableWeight += w.weights[i]
+		}
+	}
+	// allow for rounding errors when all the feeds returned a price
+	if availableWeight < w.minWeight-1e-9 || availableWeight == 0.0 {
+		return 0.0, fmt.Errorf("the feeds in 'weighted' function feed that returned a price only hold %.4f of the total weight, need at least %.4f: %v", availableWeight, w.minWeight, failures)
+	}
+	if len(failures) > 0 {
+		log.Printf("'weighted' function feed renormalized the weights of the feeds that returned a price (%.4f of the total weight): %s\n", availableWeight, strings.Join(failures, "; "))
+	}
+	return weightedSum / availableWeight, nil
+}
+
+// sma returns the simple moving average of the last window prices of the feed, which damps a noisy ticker
+func sma(feeds []api.PriceFeed, params map[string]string) (api.PriceFeed, error) {
+	return makeMovingAverage("sma", false, feeds, params)
+}
+
+// ema returns the exponential moving average of the prices of the feed with a smoothing factor of 2/(window+1), which follows the price
+// more closely than the sma with the same window since the latest prices have more weight
+func ema(feeds []api.PriceFeed, params map[string]string) (api.PriceFeed, error) {
+	return makeMovingAverage("ema", true, feeds, params)
+}
+
+// makeMovingAverage makes the 'sma' and 'ema' functions, which take the window param (the number of prices, required) and the min_samples
+// param (the number of prices needed before a price is returned, defaults to 1). A price is sampled every time the price of the function
+// feed is fetched, which is once per update cycle for most strategies, so the window covers window * TICK_INTERVAL_SECONDS of time.
+func makeMovingAverage(name string, isExponential bool, feeds []api.PriceFeed, params map[string]string) (api.PriceFeed, error) {
+	if len(feeds) != 1 {
+		return nil, fmt.Errorf("need to provide exactly 1 price feed to the '%s' function but found %d price feeds", name, len(feeds))
+	}
+
+	window := 0
+	minSamples := 1
+	for k, v := range params {
+		switch k {
+		case "window":
+			n, e := strconv.Atoi(v)
+			if e != nil || n < 1 {
+				return nil, fmt.Errorf("window of the '%s' function needs to be an integer >= 1 but was '%s'", name, v)
+			}
+			window = n
+		case "min_samples":
+			n, e := strconv.Atoi(v)
+			if e != nil || n < 1 {
+				return nil, fmt.Errorf("min_samples of the '%s' function needs to be an integer >= 1 but was '%s'", name, v)
+			}
+			minSamples = n
+		default:
+			return nil, fmt.Errorf("unknown param '%s' of the '%s' function, needs to be one of window or min_samples", k, name)
+		}
+	}
+	if window == 0 {
+		return nil, fmt.Errorf("the '%s' function needs the window param with the number of prices to average, e.g. window=10", name)
+	}
+	if minSamples > window {
+		return nil, fmt.Errorf("min_samples (%d) of the '%s' function cannot be greater than the window (%d)", minSamples, name, window)
+	}
+
+	m := &movingAverageFeed{
+		name:          name,
+		feed:          feeds[0],
+		window:        window,
+		minSamples:    minSamples,
+		isExponential: isExponential,
+		samples:       []float64{},
+	}
+	return makeFunctionFeed(m.getPrice), nil
+}
+
+// movingAverageFeed keeps the last window prices of its feed and returns their simple or exponential moving average
+type movingAverageFeed struct {
+	name          string
+	feed          api.PriceFeed
+	window        int
+	minSamples    int
+	isExponential bool
+
+	// uninitialized
+	samples    []float64
+	numSamples int
+	ema        float64
+}
+
+func (m *movingAverageFeed) getPrice() (float64, error) {
+	price, e := m.feed.GetPrice()
+	if e != nil {
+		// the failed fetch is not sampled so the average is not pulled towards 0
+		return 0.0, fmt.Errorf("error fetching price from feed in '%s' function feed: %s", m.name, e)
+	}
+	if price <= 0.0 {
+		return 0.0, fmt.Errorf("inner price of feed was <= 0.0 (%.10f)", price)
+	}
+
+	m.numSamples++
+	m.samples = append(m.samples, price)
+	if len(m.samples) > m.window {
+		m.samples = m.samples[len(m.samples)-m.window:]
+	}
+	if m.numSamples == 1 {
+		m.ema = price
+	} else {
+		alpha := 2.0 / float64(m.window+1)
+		m.ema = alpha*price + (1-alpha)*m.ema
+	}
+
+	if len(m.samples) < m.minSamples {
+		return 0.0, fmt.Errorf("'%s' function feed has %d of the %d prices needed before it returns a price", m.name, len(m.samples), m.minSamples)
+	}
+	if m.isExponential {
+		return m.ema, nil
+	}
+
+	sum := 0.0
+	for _, p := range m.samples {
+		sum += p
+	}
+	return sum / float64(len(m.samples)), nil
+}
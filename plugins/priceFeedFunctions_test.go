@@ -0,0 +1,502 @@
+package plugins
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/kelp/api"
+)
+
+type countingAlert struct {
+	numTriggers int
+}
+
+func (a *countingAlert) Trigger(description string, details interface{}) error {
+	a.numTriggers++
+	return nil
+}
+
+func TestFallbackChain(t *testing.T) {
+	errorFeed := makeFunctionFeed(func() (float64, error) {
+		return 0.0, fmt.Errorf("feed is down")
+	})
+
+	testCases := []struct {
+		name      string
+		feeds     []api.PriceFeed
+		wantPrice float64
+		wantError bool
+	}{
+		{
+			name:      "primary ok",
+			feeds:     []api.PriceFeed{&fixedFeed{price: 1.0}, &fixedFeed{price: 2.0}},
+			wantPrice: 1.0,
+		}, {
+			name:      "primary errors",
+			feeds:     []api.PriceFeed{errorFeed, &fixedFeed{price: 2.0}, &fixedFeed{price: 3.0}},
+			wantPrice: 2.0,
+		}, {
+			name:      "primary and secondary error",
+			feeds:     []api.PriceFeed{errorFeed, errorFeed, &fixedFeed{price: 3.0}},
+			wantPrice: 3.0,
+		}, {
+			name:      "primary returns zero",
+			feeds:     []api.PriceFeed{&fixedFeed{price: 0.0}, &fixedFeed{price: 2.0}},
+			wantPrice: 2.0,
+		}, {
+			name:      "all error",
+			feeds:     []api.PriceFeed{errorFeed, errorFeed},
+			wantError: true,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			c := makeFallbackChain(k.feeds, make([]time.Duration, len(k.feeds)), time.Now)
+			price, e := c.getPrice()
+			if k.wantError {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, k.wantPrice, price)
+		})
+	}
+}
+
+func TestFallbackChainStaleAndAlerts(t *testing.T) {
+	alert := &countingAlert{}
+	priceFeedAlert = alert
+	defer func() { priceFeedAlert = nil }()
+
+	now := time.Unix(1600000000, 0)
+	nowFn := func() time.Time { return now }
+	primaryPrice := 1.0
+	primary := makeFunctionFeed(func() (float64, error) {
+		return primaryPrice, nil
+	})
+	secondaryPrice := 2.0
+	secondary := makeFunctionFeed(func() (float64, error) {
+		return secondaryPrice, nil
+	})
+	c := makeFallbackChain([]api.PriceFeed{primary, secondary}, []time.Duration{time.Minute, 0}, nowFn)
+
+	price, e := c.getPrice()
+	assert.NoError(t, e)
+	assert.Equal(t, 1.0, price)
+	assert.Equal(t, 0, alert.numTriggers)
+
+	// primary price unchanged for longer than the stale duration so we fall back to the secondary
+	now = now.Add(2 * time.Minute)
+	price, e = c.getPrice()
+	assert.NoError(t, e)
+	assert.Equal(t, 2.0, price)
+	assert.Equal(t, 1, alert.numTriggers)
+
+	// remaining on the secondary does not trigger another alert, and the secondary did not opt into the stale check
+	now = now.Add(10 * time.Second)
+	price, e = c.getPrice()
+	assert.NoError(t, e)
+	assert.Equal(t, 2.0, price)
+	assert.Equal(t, 1, alert.numTriggers)
+
+	// primary recovers once its price changes
+	primaryPrice = 1.1
+	price, e = c.getPrice()
+	assert.NoError(t, e)
+	assert.Equal(t, 1.1, price)
+	assert.Equal(t, 1, alert.numTriggers)
+
+	// primary becomes stale again which is a new fallback activation
+	now = now.Add(2 * time.Minute)
+	price, e = c.getPrice()
+	assert.NoError(t, e)
+	assert.Equal(t, 2.0, price)
+	assert.Equal(t, 2, alert.numTriggers)
+}
+
+func TestFallbackParams(t *testing.T) {
+	feeds := []api.PriceFeed{&fixedFeed{price: 1.0}, &fixedFeed{price: 2.0}}
+
+	_, e := fallback(feeds, map[string]string{})
+	assert.NoError(t, e)
+	_, e = fallback(feeds, map[string]string{"stale_seconds_1": "600"})
+	assert.NoError(t, e)
+
+	for _, params := range []map[string]string{
+		{"stale_seconds_2": "600"},
+		{"stale_seconds_x": "600"},
+		{"stale_seconds_0": "0"},
+		{"stale_seconds": "600"},
+	} {
+		_, e = fallback(feeds, params)
+		assert.Error(t, e, "params %v", params)
+	}
+}
+
+func TestMedian(t *testing.T) {
+	errorFeed := makeFunctionFeed(func() (float64, error) {
+		return 0.0, fmt.Errorf("feed is down")
+	})
+
+	testCases := []struct {
+		name      string
+		feeds     []api.PriceFeed
+		params    map[string]string
+		wantPrice float64
+		wantError bool
+	}{
+		{
+			name:      "odd number of feeds",
+			feeds:     []api.PriceFeed{&fixedFeed{price: 1.01}, &fixedFeed{price: 1.0}, &fixedFeed{price: 0.99}},
+			wantPrice: 1.0,
+		}, {
+			name:      "even number of feeds",
+			feeds:     []api.PriceFeed{&fixedFeed{price: 1.01}, &fixedFeed{price: 1.0}, &fixedFeed{price: 0.99}, &fixedFeed{price: 1.005}},
+			wantPrice: 1.0025,
+		}, {
+			name:      "outlier is discarded",
+			feeds:     []api.PriceFeed{&fixedFeed{price: 1.0}, &fixedFeed{price: 1.01}, &fixedFeed{price: 5.0}, &fixedFeed{price: 1.02}},
+			wantPrice: 1.01,
+		}, {
+			name:      "error and zero price are discarded",
+			feeds:     []api.PriceFeed{errorFeed, &fixedFeed{price: 1.0}, &fixedFeed{price: 0.0}, &fixedFeed{price: 1.01}, &fixedFeed{price: 1.02}},
+			wantPrice: 1.01,
+		}, {
+			name:      "wider max_deviation keeps the outlier",
+			feeds:     []api.PriceFeed{&fixedFeed{price: 1.0}, &fixedFeed{price: 1.1}, &fixedFeed{price: 1.2}},
+			params:    map[string]string{"max_deviation": "0.1"},
+			wantPrice: 1.1,
+		}, {
+			name:      "too few feeds without a majority",
+			feeds:     []api.PriceFeed{errorFeed, errorFeed, &fixedFeed{price: 1.0}},
+			wantError: true,
+		}, {
+			name:      "too few feeds within the max deviation",
+			feeds:     []api.PriceFeed{&fixedFeed{price: 1.0}, &fixedFeed{price: 1.1}, &fixedFeed{price: 1.2}},
+			wantError: true,
+		}, {
+			name:      "lower min_feeds",
+			feeds:     []api.PriceFeed{errorFeed, errorFeed, &fixedFeed{price: 1.0}},
+			params:    map[string]string{"min_feeds": "1"},
+			wantPrice: 1.0,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			pf, e := median(k.feeds, k.params)
+			if !assert.NoError(t, e) {
+				return
+			}
+
+			price, e := pf.GetPrice()
+			if k.wantError {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.InDelta(t, k.wantPrice, price, 0.0000001)
+		})
+	}
+}
+
+func TestMedian_Errors(t *testing.T) {
+	feeds := []api.PriceFeed{&fixedFeed{price: 1.0}, &fixedFeed{price: 1.0}, &fixedFeed{price: 1.0}}
+	for _, k := range []struct {
+		feeds  []api.PriceFeed
+		params map[string]string
+	}{
+		{feeds[:2], nil},
+		{feeds, map[string]string{"max_deviation": "0"}},
+		{feeds, map[string]string{"max_deviation": "abc"}},
+		{feeds, map[string]string{"min_feeds": "4"}},
+		{feeds, map[string]string{"min_feeds": "0"}},
+		{feeds, map[string]string{"unknown": "1"}},
+	} {
+		_, e := median(k.feeds, k.params)
+		assert.Error(t, e, fmt.Sprintf("%v", k.params))
+	}
+}
+
+func TestWeighted(t *testing.T) {
+	errorFeed := makeFunctionFeed(func() (float64, error) {
+		return 0.0, fmt.Errorf("feed is down")
+	})
+
+	testCases := []struct {
+		name      string
+		feeds     []api.PriceFeed
+		params    map[string]string
+		wantPrice float64
+		wantError bool
+	}{
+		{
+			name:      "weights add up to 1",
+			feeds:     []api.PriceFeed{&fixedFeed{price: 1.0}, &fixedFeed{price: 2.0}},
+			params:    map[string]string{"weights": "0.7:0.3"},
+			wantPrice: 1.3,
+		}, {
+			name:      "weights are normalized",
+			feeds:     []api.PriceFeed{&fixedFeed{price: 1.0}, &fixedFeed{price: 2.0}, &fixedFeed{price: 4.0}},
+			params:    map[string]string{"weights": "2:1:1"},
+			wantPrice: 2.0,
+		}, {
+			name:      "all feeds are needed by default",
+			feeds:     []api.PriceFeed{&fixedFeed{price: 1.0}, errorFeed},
+			params:    map[string]string{"weights": "0.7:0.3"},
+			wantError: true,
+		}, {
+			name:      "zero price is a failure",
+			feeds:     []api.PriceFeed{&fixedFeed{price: 1.0}, &fixedFeed{price: 0.0}},
+			params:    map[string]string{"weights": "0.7:0.3"},
+			wantError: true,
+		}, {
+			name:      "failed feed is left out when the rest holds min_weight",
+			feeds:     []api.PriceFeed{&fixedFeed{price: 1.0}, &fixedFeed{price: 2.0}, errorFeed},
+			params:    map[string]string{"weights": "0.5:0.3:0.2", "min_weight": "0.8"},
+			wantPrice: (0.5*1.0 + 0.3*2.0) / 0.8,
+		}, {
+			name:      "failed feed holds too much weight",
+			feeds:     []api.PriceFeed{errorFeed, &fixedFeed{price: 2.0}, &fixedFeed{price: 1.0}},
+			params:    map[string]string{"weights": "0.5:0.3:0.2", "min_weight": "0.8"},
+			wantError: true,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			pf, e := weighted(k.feeds, k.params)
+			if !assert.NoError(t, e) {
+				return
+			}
+
+			price, e := pf.GetPrice()
+			if k.wantError {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.InDelta(t, k.wantPrice, price, 0.0000001)
+		})
+	}
+}
+
+func TestWeighted_Errors(t *testing.T) {
+	feeds := []api.PriceFeed{&fixedFeed{price: 1.0}, &fixedFeed{price: 1.0}}
+	for _, k := range []struct {
+		feeds  []api.PriceFeed
+		params map[string]string
+	}{
+		{feeds[:1], map[string]string{"weights": "1"}},
+		{feeds, nil},
+		{feeds, map[string]string{"weights": "0.7"}},
+		{feeds, map[string]string{"weights": "0.7:0.2:0.1"}},
+		{feeds, map[string]string{"weights": "0.7:0"}},
+		{feeds, map[string]string{"weights": "0.7:abc"}},
+		{feeds, map[string]string{"weights": "0.7:0.3", "min_weight": "0"}},
+		{feeds, map[string]string{"weights": "0.7:0.3", "min_weight": "1.5"}},
+		{feeds, map[string]string{"weights": "0.7:0.3", "unknown": "1"}},
+	} {
+		_, e := weighted(k.feeds, k.params)
+		assert.Error(t, e, fmt.Sprintf("%v", k.params))
+	}
+}
+
+func TestMultiplyDivide(t *testing.T) {
+	errorFeed := makeFunctionFeed(func() (float64, error) {
+		return 0.0, fmt.Errorf("feed is down")
+	})
+
+	testCases := []struct {
+		name      string
+		fn        fnFactory
+		feeds     []api.PriceFeed
+		wantPrice float64
+		wantError bool
+	}{
+		{
+			name:      "multiply chains rates",
+			fn:        multiply,
+			feeds:     []api.PriceFeed{&fixedFeed{price: 0.000005}, &fixedFeed{price: 20000.0}},
+			wantPrice: 0.1,
+		}, {
+			name:      "multiply more than 2 feeds",
+			fn:        multiply,
+			feeds:     []api.PriceFeed{&fixedFeed{price: 2.0}, &fixedFeed{price: 3.0}, &fixedFeed{price: 0.5}},
+			wantPrice: 3.0,
+		}, {
+			name:      "multiply with a failed feed",
+			fn:        multiply,
+			feeds:     []api.PriceFeed{&fixedFeed{price: 2.0}, errorFeed},
+			wantError: true,
+		}, {
+			name:      "divide derives a cross rate",
+			fn:        divide,
+			feeds:     []api.PriceFeed{&fixedFeed{price: 30000.0}, &fixedFeed{price: 1.2}},
+			wantPrice: 25000.0,
+		}, {
+			name:      "divide by zero",
+			fn:        divide,
+			feeds:     []api.PriceFeed{&fixedFeed{price: 1.0}, &fixedFeed{price: 0.0}},
+			wantError: true,
+		}, {
+			name:      "divide with a failed feed",
+			fn:        divide,
+			feeds:     []api.PriceFeed{errorFeed, &fixedFeed{price: 1.0}},
+			wantError: true,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			pf, e := k.fn(k.feeds)
+			if !assert.NoError(t, e) {
+				return
+			}
+
+			price, e := pf.GetPrice()
+			if k.wantError {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.InDelta(t, k.wantPrice, price, 0.0000001)
+		})
+	}
+
+	feed := &fixedFeed{price: 1.0}
+	_, e := multiply([]api.PriceFeed{feed})
+	assert.Error(t, e)
+	_, e = divide([]api.PriceFeed{feed})
+	assert.Error(t, e)
+	_, e = divide([]api.PriceFeed{feed, feed, feed})
+	assert.Error(t, e)
+}
+
+func TestMovingAverage(t *testing.T) {
+	testCases := []struct {
+		name       string
+		fn         paramFnFactory
+		params     map[string]string
+		prices     []float64
+		wantPrices []float64
+	}{
+		{
+			name:       "sma",
+			fn:         sma,
+			params:     map[string]string{"window": "3"},
+			prices:     []float64{1.0, 2.0, 3.0, 4.0, 8.0},
+			wantPrices: []float64{1.0, 1.5, 2.0, 3.0, 5.0},
+		}, {
+			name:       "sma of window 1",
+			fn:         sma,
+			params:     map[string]string{"window": "1"},
+			prices:     []float64{1.0, 2.0, 3.0},
+			wantPrices: []float64{1.0, 2.0, 3.0},
+		}, {
+			// alpha = 2/(3+1) = 0.5
+			name:       "ema",
+			fn:         ema,
+			params:     map[string]string{"window": "3"},
+			prices:     []float64{1.0, 2.0, 3.0, 4.0, 8.0},
+			wantPrices: []float64{1.0, 1.5, 2.25, 3.125, 5.5625},
+		}, {
+			// a wantPrice of 0 means an error is expected while warming up
+			name:       "sma with min_samples",
+			fn:         sma,
+			params:     map[string]string{"window": "3", "min_samples": "2"},
+			prices:     []float64{1.0, 2.0, 3.0},
+			wantPrices: []float64{0.0, 1.5, 2.0},
+		}, {
+			name:       "ema with min_samples",
+			fn:         ema,
+			params:     map[string]string{"window": "3", "min_samples": "3"},
+			prices:     []float64{1.0, 2.0, 3.0},
+			wantPrices: []float64{0.0, 0.0, 2.25},
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			inner := &fixedFeed{}
+			pf, e := k.fn([]api.PriceFeed{inner}, k.params)
+			if !assert.NoError(t, e) {
+				return
+			}
+
+			for i, p := range k.prices {
+				inner.price = p
+				price, e := pf.GetPrice()
+				if k.wantPrices[i] == 0.0 {
+					assert.Error(t, e, fmt.Sprintf("price index %d", i))
+					continue
+				}
+				if !assert.NoError(t, e, fmt.Sprintf("price index %d", i)) {
+					return
+				}
+				assert.InDelta(t, k.wantPrices[i], price, 0.0000001, fmt.Sprintf("price index %d", i))
+			}
+		})
+	}
+}
+
+func TestMovingAverage_FailedFetch(t *testing.T) {
+	isDown := false
+	inner := makeFunctionFeed(func() (float64, error) {
+		if isDown {
+			return 0.0, fmt.Errorf("feed is down")
+		}
+		return 2.0, nil
+	})
+	pf, e := sma([]api.PriceFeed{inner}, map[string]string{"window": "2"})
+	if !assert.NoError(t, e) {
+		return
+	}
+
+	_, e = pf.GetPrice()
+	assert.NoError(t, e)
+	isDown = true
+	_, e = pf.GetPrice()
+	assert.Error(t, e)
+
+	// the failed fetch is not sampled
+	isDown = false
+	price, e := pf.GetPrice()
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.InDelta(t, 2.0, price, 0.0000001)
+}
+
+func TestMovingAverage_Errors(t *testing.T) {
+	feeds := []api.PriceFeed{&fixedFeed{price: 1.0}, &fixedFeed{price: 1.0}}
+	for _, k := range []struct {
+		feeds  []api.PriceFeed
+		params map[string]string
+	}{
+		{feeds, map[string]string{"window": "3"}},
+		{feeds[:1], nil},
+		{feeds[:1], map[string]string{"window": "0"}},
+		{feeds[:1], map[string]string{"window": "abc"}},
+		{feeds[:1], map[string]string{"window": "3", "min_samples": "0"}},
+		{feeds[:1], map[string]string{"window": "3", "min_samples": "4"}},
+		{feeds[:1], map[string]string{"window": "3", "unknown": "1"}},
+	} {
+		_, e := sma(k.feeds, k.params)
+		assert.Error(t, e, fmt.Sprintf("%v", k.params))
+		_, e = ema(k.feeds, k.params)
+		assert.Error(t, e, fmt.Sprintf("%v", k.params))
+	}
+}
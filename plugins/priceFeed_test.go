@@ -70,6 +70,21 @@ func TestMakePriceFeed(t *testing.T) {
 			url:                    "invert(fixed/0.02)",
 			wantLowerOrEqualBound:  50.0,
 			wantHigherOrEqualBound: 50.0,
+		}, {
+			typ:                    "function",
+			url:                    "fallback(fixed/1.0,fixed/1.4)",
+			wantLowerOrEqualBound:  1.0,
+			wantHigherOrEqualBound: 1.0,
+		}, {
+			typ:                    "compose",
+			url:                    "divide(fixed/30000.0, fixed/1.2)",
+			wantLowerOrEqualBound:  25000.0,
+			wantHigherOrEqualBound: 25000.0,
+		}, {
+			typ:                    "compose",
+			url:                    "multiply(function/invert(fixed/4.0),function/max(fixed/1.0,fixed/2.0))",
+			wantLowerOrEqualBound:  0.5,
+			wantHigherOrEqualBound: 0.5,
 		},
 		// disable ccxt-kraken based tests for now because of the 403 Forbidden Security check API error
 		// {
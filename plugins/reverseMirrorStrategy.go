@@ -0,0 +1,365 @@
+package plugins
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sync"
+
+	"github.com/stellar/go/clients/horizonclient"
+	sdkNetwork "github.com/stellar/go/network"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/stellargohorizonclientv300/build"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// reverseMirrorConfig contains the configuration params for this strategy
+type reverseMirrorConfig struct {
+	SdexAssetCodeA          string   `valid:"-" toml:"SDEX_ASSET_CODE_A"`
+	SdexIssuerA             string   `valid:"-" toml:"SDEX_ISSUER_A"`
+	SdexAssetCodeB          string   `valid:"-" toml:"SDEX_ASSET_CODE_B"`
+	SdexIssuerB             string   `valid:"-" toml:"SDEX_ISSUER_B"`
+	OrderbookDepth          int      `valid:"-" toml:"ORDERBOOK_DEPTH"`
+	BidVolumeDivideBy       *float64 `valid:"-" toml:"BID_VOLUME_DIVIDE_BY"`
+	AskVolumeDivideBy       *float64 `valid:"-" toml:"ASK_VOLUME_DIVIDE_BY"`
+	MaxOrderBaseCap         *float64 `valid:"-" toml:"MAX_ORDER_BASE_CAP"`
+	PerLevelSpread          float64  `valid:"-" toml:"PER_LEVEL_SPREAD"`
+	OffsetTrades            bool     `valid:"-" toml:"OFFSET_TRADES"`
+	OffsetSourceSecretSeed  string   `valid:"-" toml:"OFFSET_SOURCE_SECRET_SEED"`
+	OffsetTradingSecretSeed string   `valid:"-" toml:"OFFSET_TRADING_SECRET_SEED"`
+}
+
+// String impl.
+func (c reverseMirrorConfig) String() string {
+	return utils.StructString(c, 0, map[string]func(interface{}) interface{}{
+		"OFFSET_SOURCE_SECRET_SEED":  utils.SecretKey2PublicKey,
+		"OFFSET_TRADING_SECRET_SEED": utils.SecretKey2PublicKey,
+	})
+}
+
+// reverseMirrorStrategy is a strategy to mirror the SDEX orderbook onto a centralized exchange, it is the inverse of the mirrorStrategy.
+// The bot trades on the centralized exchange (TRADING_EXCHANGE) so the ops returned here are converted into orders on that exchange,
+// and fills are offset back onto SDEX using a separate Stellar account.
+type reverseMirrorStrategy struct {
+	sdex               *SDEX // trades on the centralized exchange via the exchangeShim
+	backingSdex        *SDEX // reads the SDEX orderbook and offsets trades on SDEX
+	backingIeif        *IEIF
+	backingBaseAsset   hProtocol.Asset
+	backingQuoteAsset  hProtocol.Asset
+	primaryConstraints *model.OrderConstraints
+	backingConstraints *model.OrderConstraints
+	orderbookDepth     int
+	perLevelSpread     float64
+	bidVolumeDivideBy  float64
+	askVolumeDivideBy  float64
+	maxOrderBaseCap    *float64
+	offsetTrades       bool
+	mutex              *sync.Mutex
+	baseSurplus        map[model.OrderAction]*model.Number // base units of fills on the centralized exchange that still need to be offset on SDEX
+
+	// levels reuses the level management of the mirror strategy to place the mirrored orders on the centralized exchange
+	levels *mirrorStrategy
+}
+
+// ensure this implements api.Strategy
+var _ api.Strategy = &reverseMirrorStrategy{}
+
+// ensure this implements api.FillHandler
+var _ api.FillHandler = &reverseMirrorStrategy{}
+
+func makeReverseMirrorStrategy(
+	sdex *SDEX,
+	exchangeShim api.ExchangeShim,
+	ieif *IEIF,
+	pair *model.TradingPair,
+	baseAsset *hProtocol.Asset,
+	quoteAsset *hProtocol.Asset,
+	config *reverseMirrorConfig,
+	simMode bool,
+) (api.Strategy, error) {
+	bidVolumeDivideBy := 1.0
+	if config.BidVolumeDivideBy != nil {
+		bidVolumeDivideBy = *config.BidVolumeDivideBy
+	}
+	askVolumeDivideBy := 1.0
+	if config.AskVolumeDivideBy != nil {
+		askVolumeDivideBy = *config.AskVolumeDivideBy
+	}
+	if bidVolumeDivideBy == -1.0 && askVolumeDivideBy == -1.0 {
+		return nil, fmt.Errorf("invalid reverse_mirror strategy config file, cannot set both BID_VOLUME_DIVIDE_BY and ASK_VOLUME_DIVIDE_BY to -1.0")
+	}
+	if bidVolumeDivideBy != -1.0 && bidVolumeDivideBy <= 0 {
+		return nil, fmt.Errorf("invalid reverse_mirror strategy config file, BID_VOLUME_DIVIDE_BY needs to be -1.0 or > 0")
+	}
+	if askVolumeDivideBy != -1.0 && askVolumeDivideBy <= 0 {
+		return nil, fmt.Errorf("invalid reverse_mirror strategy config file, ASK_VOLUME_DIVIDE_BY needs to be -1.0 or > 0")
+	}
+	if config.MaxOrderBaseCap != nil && *config.MaxOrderBaseCap <= 0.0 {
+		return nil, fmt.Errorf("invalid reverse_mirror strategy config file, if you set a value for MAX_ORDER_BASE_CAP it needs to be > 0.0")
+	}
+	if config.OrderbookDepth <= 0 || config.OrderbookDepth > int(maxOrderbookDepth) {
+		return nil, fmt.Errorf("invalid reverse_mirror strategy config file, ORDERBOOK_DEPTH needs to be in the range [1, %d]", maxOrderbookDepth)
+	}
+
+	backingBaseAsset, e := utils.ParseAsset(config.SdexAssetCodeA, config.SdexIssuerA)
+	if e != nil {
+		return nil, fmt.Errorf("unable to parse SDEX_ASSET_CODE_A and SDEX_ISSUER_A in reverse_mirror strategy config file: %s", e)
+	}
+	backingQuoteAsset, e := utils.ParseAsset(config.SdexAssetCodeB, config.SdexIssuerB)
+	if e != nil {
+		return nil, fmt.Errorf("unable to parse SDEX_ASSET_CODE_B and SDEX_ISSUER_B in reverse_mirror strategy config file: %s", e)
+	}
+
+	var offsetTradingAccount, offsetSourceAccount string
+	if config.OffsetTrades {
+		tradingAccount, e := utils.ParseSecret(config.OffsetTradingSecretSeed)
+		if e != nil {
+			return nil, fmt.Errorf("unable to parse OFFSET_TRADING_SECRET_SEED in reverse_mirror strategy config file: %s", e)
+		}
+		if tradingAccount == nil {
+			return nil, fmt.Errorf("OFFSET_TRADING_SECRET_SEED needs to be set in the reverse_mirror strategy config file when OFFSET_TRADES is enabled")
+		}
+		offsetTradingAccount = *tradingAccount
+
+		sourceAccount, e := utils.ParseSecret(config.OffsetSourceSecretSeed)
+		if e != nil {
+			return nil, fmt.Errorf("unable to parse OFFSET_SOURCE_SECRET_SEED in reverse_mirror strategy config file: %s", e)
+		}
+		if sourceAccount != nil {
+			offsetSourceAccount = *sourceAccount
+		}
+	}
+
+	backingPair := &model.TradingPair{
+		Base:  model.Asset(utils.Asset2CodeString(*backingBaseAsset)),
+		Quote: model.Asset(utils.Asset2CodeString(*backingQuoteAsset)),
+	}
+	sdexAssetMap := map[model.Asset]hProtocol.Asset{
+		backingPair.Base:  *backingBaseAsset,
+		backingPair.Quote: *backingQuoteAsset,
+	}
+
+	var client *horizonclient.Client
+	var network string
+	if privateSdexHackVar != nil {
+		client = privateSdexHackVar.API
+		network = privateSdexHackVar.Network
+	} else {
+		// use production network by default
+		client = horizonclient.DefaultPublicNetClient
+		network = sdkNetwork.PublicNetworkPassphrase
+	}
+
+	// the backing SDEX needs its own IEIF since it checks balances of the offset account and not of the account on the centralized exchange
+	backingIeif := MakeIEIF(true)
+	backingSdex := MakeSDEX(
+		client,
+		backingIeif,
+		nil,
+		config.OffsetSourceSecretSeed,
+		config.OffsetTradingSecretSeed,
+		offsetSourceAccount,
+		offsetTradingAccount,
+		network,
+		nil,
+		0,
+		0,
+		simMode,
+		backingPair,
+		sdexAssetMap,
+		SdexFixedFeeFn(baseFeeStroops),
+	)
+
+	primaryConstraints := exchangeShim.GetOrderConstraints(pair)
+	backingConstraints := backingSdex.GetOrderConstraints(backingPair)
+	log.Printf("primaryPair='%s', primaryConstraints=%s\n", pair, primaryConstraints)
+	log.Printf("backingPair='%s', backingConstraints=%s\n", backingPair, backingConstraints)
+
+	return &reverseMirrorStrategy{
+		sdex:               sdex,
+		backingSdex:        backingSdex,
+		backingIeif:        backingIeif,
+		backingBaseAsset:   *backingBaseAsset,
+		backingQuoteAsset:  *backingQuoteAsset,
+		primaryConstraints: primaryConstraints,
+		backingConstraints: backingConstraints,
+		orderbookDepth:     config.OrderbookDepth,
+		perLevelSpread:     config.PerLevelSpread,
+		bidVolumeDivideBy:  bidVolumeDivideBy,
+		askVolumeDivideBy:  askVolumeDivideBy,
+		maxOrderBaseCap:    config.MaxOrderBaseCap,
+		offsetTrades:       config.OffsetTrades,
+		mutex:              &sync.Mutex{},
+		baseSurplus: map[model.OrderAction]*model.Number{
+			model.OrderActionBuy:  model.NumberConstants.Zero,
+			model.OrderActionSell: model.NumberConstants.Zero,
+		},
+		levels: &mirrorStrategy{
+			sdex:               sdex,
+			ieif:               ieif,
+			baseAsset:          baseAsset,
+			quoteAsset:         quoteAsset,
+			primaryConstraints: primaryConstraints,
+			backingConstraints: backingConstraints,
+			offsetTrades:       false, // balances on SDEX are checked when offsetting and not when placing levels
+		},
+	}, nil
+}
+
+// PruneExistingOffers deletes any extra offers
+func (s *reverseMirrorStrategy) PruneExistingOffers(buyingAOffers []hProtocol.Offer, sellingAOffers []hProtocol.Offer) ([]build.TransactionMutator, []hProtocol.Offer, []hProtocol.Offer) {
+	return []build.TransactionMutator{}, buyingAOffers, sellingAOffers
+}
+
+// PreUpdate changes the strategy's state in prepration for the update
+func (s *reverseMirrorStrategy) PreUpdate(maxAssetA float64, maxAssetB float64, trustA float64, trustB float64) error {
+	return nil
+}
+
+// UpdateWithOps builds the operations we want performed on the account
+func (s *reverseMirrorStrategy) UpdateWithOps(
+	buyingAOffers []hProtocol.Offer,
+	sellingAOffers []hProtocol.Offer,
+) ([]build.TransactionMutator, error) {
+	ob, e := s.backingSdex.GetOrderBook(s.backingSdex.pair, int32(s.orderbookDepth+numOrdersBufferMinVolumeFilter))
+	if e != nil {
+		return nil, fmt.Errorf("unable to fetch SDEX orderbook: %s", e)
+	}
+
+	bids := ob.Bids()
+	asks := ob.Asks()
+	log.Printf("SDEX orderbook before transformations, including %d additional buffer orders:\n", numOrdersBufferMinVolumeFilter)
+	printBidsAndAsks(bids, asks)
+
+	// the mirrored orders need to meet the minimums of the centralized exchange and the offset orders need to meet the minimums of SDEX
+	minBaseVolume := math.Max(s.primaryConstraints.MinBaseVolume.AsFloat(), s.backingConstraints.MinBaseVolume.AsFloat())
+	if s.bidVolumeDivideBy == -1.0 {
+		bids = []model.Order{}
+	} else {
+		transformOrders(bids, (1 - s.perLevelSpread), (1.0 / s.bidVolumeDivideBy), s.maxOrderBaseCap)
+		bids = filterOrdersByVolume(bids, minBaseVolume)
+		if len(bids) > s.orderbookDepth {
+			bids = bids[:s.orderbookDepth]
+		}
+	}
+	if s.askVolumeDivideBy == -1.0 {
+		asks = []model.Order{}
+	} else {
+		transformOrders(asks, (1 + s.perLevelSpread), (1.0 / s.askVolumeDivideBy), s.maxOrderBaseCap)
+		asks = filterOrdersByVolume(asks, minBaseVolume)
+		if len(asks) > s.orderbookDepth {
+			asks = asks[:s.orderbookDepth]
+		}
+	}
+	log.Printf("new orders to be placed on the centralized exchange (after transforming and filtering orders from SDEX):\n")
+	printBidsAndAsks(bids, asks)
+
+	deleteBuyOps, buyOps, e := s.levels.updateLevels(buyingAOffers, bids, s.sdex.ModifyBuyOffer, s.sdex.CreateBuyOffer, true, nil)
+	if e != nil {
+		return nil, e
+	}
+	deleteSellOps, sellOps, e := s.levels.updateLevels(sellingAOffers, asks, s.sdex.ModifySellOffer, s.sdex.CreateSellOffer, false, nil)
+	if e != nil {
+		return nil, e
+	}
+	log.Printf("num. buyOps in this update: %d, num. sellOps in this update: %d\n", len(buyOps), len(sellOps))
+
+	ops := []txnbuild.Operation{}
+	// delete first so we free up balance on the centralized exchange to place the new and modified orders
+	ops = append(ops, deleteBuyOps...)
+	ops = append(ops, deleteSellOps...)
+	ops = append(ops, buyOps...)
+	ops = append(ops, sellOps...)
+	return api.ConvertOperation2TM(ops), nil
+}
+
+// PostUpdate changes the strategy's state after the update has taken place
+func (s *reverseMirrorStrategy) PostUpdate() error {
+	return nil
+}
+
+// GetFillHandlers impl
+func (s *reverseMirrorStrategy) GetFillHandlers() ([]api.FillHandler, error) {
+	if s.offsetTrades {
+		return []api.FillHandler{s}, nil
+	}
+	return nil, nil
+}
+
+// HandleFill impl, offsets a fill on the centralized exchange by placing a crossing offer on SDEX at the price of the fill
+func (s *reverseMirrorStrategy) HandleFill(trade model.Trade) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	newOrderAction := trade.OrderAction.Reverse()
+	s.baseSurplus[newOrderAction] = s.baseSurplus[newOrderAction].Add(*trade.Volume)
+
+	newVolume := model.NumberByCappingPrecision(s.baseSurplus[newOrderAction], s.backingConstraints.VolumePrecision)
+	if newVolume.AsFloat() < s.backingConstraints.MinBaseVolume.AsFloat() {
+		log.Printf("offset-skip | tradeID=%s | tradeBaseAmt=%f | newOrderAction=%s | baseSurplus=%f | minBaseVolume=%f\n",
+			trade.TransactionID.String(),
+			trade.Volume.AsFloat(),
+			newOrderAction.String(),
+			s.baseSurplus[newOrderAction].AsFloat(),
+			s.backingConstraints.MinBaseVolume.AsFloat())
+		return nil
+	}
+	newPrice := model.NumberByCappingPrecision(trade.Price, s.backingConstraints.PricePrecision)
+
+	// refresh the balances and liabilities of the offset account so the IEIF checks are done against the latest state
+	s.backingIeif.ResetCachedBalances()
+	e := s.backingIeif.ResetCachedLiabilities(s.backingBaseAsset, s.backingQuoteAsset)
+	if e != nil {
+		return fmt.Errorf("unable to reset cached liabilities of the SDEX offset account: %s", e)
+	}
+
+	incrementalNativeAmountRaw := s.backingSdex.ComputeIncrementalNativeAmountRaw(true)
+	var op *txnbuild.ManageSellOffer
+	if newOrderAction.IsBuy() {
+		op, e = s.backingSdex.CreateBuyOffer(s.backingBaseAsset, s.backingQuoteAsset, newPrice.AsFloat(), newVolume.AsFloat(), incrementalNativeAmountRaw)
+	} else {
+		op, e = s.backingSdex.CreateSellOffer(s.backingBaseAsset, s.backingQuoteAsset, newPrice.AsFloat(), newVolume.AsFloat(), incrementalNativeAmountRaw)
+	}
+	if e != nil {
+		return fmt.Errorf("error when making SDEX offer to offset trade (newOrderAction=%s, price=%s, volume=%s): %s", newOrderAction.String(), newPrice.AsString(), newVolume.AsString(), e)
+	}
+	if op == nil {
+		return fmt.Errorf("not enough balance in the SDEX offset account to offset trade (newOrderAction=%s, price=%s, volume=%s)", newOrderAction.String(), newPrice.AsString(), newVolume.AsString())
+	}
+	log.Printf("offset-attempt | tradeID=%s | tradeBaseAmt=%f | tradePriceQuote=%f | newOrderAction=%s | baseSurplus=%f | newOrderBaseAmt=%f | newOrderPriceQuote=%f\n",
+		trade.TransactionID.String(),
+		trade.Volume.AsFloat(),
+		trade.Price.AsFloat(),
+		newOrderAction.String(),
+		s.baseSurplus[newOrderAction].AsFloat(),
+		newVolume.AsFloat(),
+		newPrice.AsFloat())
+
+	var txHash string
+	var submitErr error
+	e = s.backingSdex.SubmitOpsSynch(api.ConvertOperation2TM([]txnbuild.Operation{op}), api.SubmitModeBoth, func(hash string, e error) {
+		txHash = hash
+		submitErr = e
+	})
+	if e != nil {
+		return fmt.Errorf("error when submitting SDEX offer to offset trade (newOrderAction=%s): %s", newOrderAction.String(), e)
+	}
+	if submitErr != nil {
+		return fmt.Errorf("SDEX transaction to offset trade (newOrderAction=%s) failed: %s", newOrderAction.String(), submitErr)
+	}
+
+	// update the baseSurplus on success
+	s.baseSurplus[newOrderAction] = s.baseSurplus[newOrderAction].Subtract(*newVolume)
+
+	log.Printf("offset-success | tradeID=%s | newOrderAction=%s | baseSurplus=%f | newOrderBaseAmt=%f | newOrderPriceQuote=%f | txHash=%s\n",
+		trade.TransactionID.String(),
+		newOrderAction.String(),
+		s.baseSurplus[newOrderAction].AsFloat(),
+		newVolume.AsFloat(),
+		newPrice.AsFloat(),
+		txHash)
+	return nil
+}
@@ -0,0 +1,65 @@
+package plugins
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMakeReverseMirrorStrategyInvalidConfig(t *testing.T) {
+	negativeOne := -1.0
+	zero := 0.0
+	validConfig := func() reverseMirrorConfig {
+		return reverseMirrorConfig{
+			SdexAssetCodeA: "XLM",
+			SdexAssetCodeB: "USD",
+			SdexIssuerB:    "GBMMZMK2DC4FFP4CAI6KCVNCQ7WLO5A7DQU7EC7WGHRDQBZB763X4OQI",
+			OrderbookDepth: 5,
+		}
+	}
+
+	testCases := []struct {
+		name   string
+		update func(c *reverseMirrorConfig)
+	}{
+		{
+			name: "both sides disabled",
+			update: func(c *reverseMirrorConfig) {
+				c.BidVolumeDivideBy = &negativeOne
+				c.AskVolumeDivideBy = &negativeOne
+			},
+		}, {
+			name:   "zero bid volume divide by",
+			update: func(c *reverseMirrorConfig) { c.BidVolumeDivideBy = &zero },
+		}, {
+			name:   "zero max order base cap",
+			update: func(c *reverseMirrorConfig) { c.MaxOrderBaseCap = &zero },
+		}, {
+			name:   "orderbook depth too large",
+			update: func(c *reverseMirrorConfig) { c.OrderbookDepth = int(maxOrderbookDepth) + 1 },
+		}, {
+			name:   "invalid sdex asset",
+			update: func(c *reverseMirrorConfig) { c.SdexIssuerB = "" },
+		}, {
+			name:   "offset trades without seed",
+			update: func(c *reverseMirrorConfig) { c.OffsetTrades = true },
+		}, {
+			name: "offset trades with invalid seed",
+			update: func(c *reverseMirrorConfig) {
+				c.OffsetTrades = true
+				c.OffsetTradingSecretSeed = "invalid"
+			},
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			config := validConfig()
+			k.update(&config)
+
+			s, e := makeReverseMirrorStrategy(nil, nil, nil, nil, nil, nil, &config, true)
+			assert.Error(t, e)
+			assert.Nil(t, s)
+		})
+	}
+}
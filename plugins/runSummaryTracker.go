@@ -0,0 +1,201 @@
+package plugins
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+)
+
+// error categories tracked in the run summary
+const (
+	RunSummaryErrorUpdateCycle    = "update_cycle"
+	RunSummaryErrorSubmitAsync    = "submit_async"
+	RunSummaryErrorBalanceAnomaly = "balance_anomaly"
+)
+
+// SideVolume is the volume traded on one side of the market
+type SideVolume struct {
+	NumFills  int     `json:"num_fills"`
+	BaseVol   float64 `json:"base_volume"`
+	QuoteCost float64 `json:"quote_cost"`
+}
+
+// RunSummary is the accountable report of a single run of the trade command
+type RunSummary struct {
+	StartTime           time.Time      `json:"start_time"`
+	EndTime             time.Time      `json:"end_time"`
+	DurationSeconds     float64        `json:"duration_seconds"`
+	ExitReason          string         `json:"exit_reason"`
+	NumCycles           int            `json:"num_cycles"`
+	NumSuccessfulCycles int            `json:"num_successful_cycles"`
+	NumPruneOps         int            `json:"num_prune_ops"`
+	NumUpdateOpsDelete  int            `json:"num_update_ops_delete"`
+	NumUpdateOpsUpdate  int            `json:"num_update_ops_update"`
+	NumUpdateOpsCreate  int            `json:"num_update_ops_create"`
+	NumFills            int            `json:"num_fills"`
+	Buy                 SideVolume     `json:"buy"`
+	Sell                SideVolume     `json:"sell"`
+	TotalFees           float64        `json:"total_fees"`
+	NetworkFees         float64        `json:"network_fees"` // in XLM, charged by the network for transactions submitted to SDEX
+	ErrorCounts         map[string]int `json:"error_counts"`
+}
+
+// RunSummaryTracker accumulates the stats of a run so we can report a RunSummary on exit
+type RunSummaryTracker struct {
+	httpClient *http.Client
+	filepath   string // empty string does not write to a file
+	webhookURL string // empty string does not post to a webhook
+
+	// initialized runtime vars
+	mutex   *sync.Mutex
+	summary *RunSummary
+	written bool
+}
+
+var _ api.FillHandler = &RunSummaryTracker{}
+
+// MakeRunSummaryTracker is a factory method
+func MakeRunSummaryTracker(httpClient *http.Client, startTime time.Time, filepath string, webhookURL string) *RunSummaryTracker {
+	return &RunSummaryTracker{
+		httpClient: httpClient,
+		filepath:   filepath,
+		webhookURL: webhookURL,
+		mutex:      &sync.Mutex{},
+		summary: &RunSummary{
+			StartTime:   startTime,
+			ErrorCounts: map[string]int{},
+		},
+	}
+}
+
+// RecordUpdate adds the result of one update cycle to the summary
+func (r *RunSummaryTracker) RecordUpdate(updateResult UpdateLoopResult) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.summary.NumCycles++
+	if updateResult.Success {
+		r.summary.NumSuccessfulCycles++
+	} else {
+		r.summary.ErrorCounts[RunSummaryErrorUpdateCycle]++
+	}
+	r.summary.NumPruneOps += updateResult.NumPruneOps
+	r.summary.NumUpdateOpsDelete += updateResult.NumUpdateOpsDelete
+	r.summary.NumUpdateOpsUpdate += updateResult.NumUpdateOpsUpdate
+	r.summary.NumUpdateOpsCreate += updateResult.NumUpdateOpsCreate
+}
+
+// RecordError increments the error count for the given category
+func (r *RunSummaryTracker) RecordError(category string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.summary.ErrorCounts[category]++
+}
+
+// RecordNetworkFee adds the network fee charged for a transaction submitted to SDEX
+func (r *RunSummaryTracker) RecordNetworkFee(feeChargedStroops int64) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.summary.NetworkFees += float64(feeChargedStroops) / 1e7
+}
+
+// Snapshot returns a copy of the summary accumulated so far
+func (r *RunSummaryTracker) Snapshot() RunSummary {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	snapshot := *r.summary
+	snapshot.ErrorCounts = map[string]int{}
+	for k, v := range r.summary.ErrorCounts {
+		snapshot.ErrorCounts[k] = v
+	}
+	return snapshot
+}
+
+// HandleFill impl.
+func (r *RunSummaryTracker) HandleFill(trade model.Trade) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	side := &r.summary.Sell
+	if trade.OrderAction.IsBuy() {
+		side = &r.summary.Buy
+	}
+	side.NumFills++
+	if trade.Volume != nil {
+		side.BaseVol += trade.Volume.AsFloat()
+	}
+	if trade.Cost != nil {
+		side.QuoteCost += trade.Cost.AsFloat()
+	}
+	if trade.Fee != nil {
+		r.summary.TotalFees += trade.Fee.AsFloat()
+	}
+	r.summary.NumFills++
+	return nil
+}
+
+// Finish writes the summary to the log, the file, and the webhook. Only the first call has any effect so it is safe to call
+// this from every exit path.
+func (r *RunSummaryTracker) Finish(exitReason string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.written {
+		return
+	}
+	r.written = true
+
+	r.summary.EndTime = time.Now()
+	r.summary.DurationSeconds = r.summary.EndTime.Sub(r.summary.StartTime).Seconds()
+	r.summary.ExitReason = exitReason
+
+	summaryBytes, e := json.MarshalIndent(r.summary, "", "  ")
+	if e != nil {
+		log.Printf("could not marshal run summary: %s\n", e)
+		return
+	}
+	log.Printf("run summary:\n%s\n", string(summaryBytes))
+
+	if r.filepath != "" {
+		e = ioutil.WriteFile(r.filepath, summaryBytes, 0644)
+		if e != nil {
+			log.Printf("could not write run summary to file '%s': %s\n", r.filepath, e)
+		} else {
+			log.Printf("wrote run summary to file: %s\n", r.filepath)
+		}
+	}
+
+	if r.webhookURL != "" {
+		e = r.postToWebhook(summaryBytes)
+		if e != nil {
+			log.Printf("could not post run summary to webhook: %s\n", e)
+		} else {
+			log.Printf("posted run summary to webhook\n")
+		}
+	}
+}
+
+func (r *RunSummaryTracker) postToWebhook(summaryBytes []byte) error {
+	resp, e := r.httpClient.Post(r.webhookURL, "application/json", bytes.NewReader(summaryBytes))
+	if e != nil {
+		return fmt.Errorf("could not execute http request: %s", e)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("webhook responded with status code %d: %s", resp.StatusCode, string(body))
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package plugins
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/kelp/model"
+)
+
+func TestRunSummaryTracker(t *testing.T) {
+	dir, e := ioutil.TempDir("", "kelp_run_summary")
+	if !assert.NoError(t, e) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	summaryFile := filepath.Join(dir, "summary.json")
+
+	r := MakeRunSummaryTracker(http.DefaultClient, time.Now().Add(-time.Minute), summaryFile, "")
+	r.RecordUpdate(UpdateLoopResult{Success: true, NumPruneOps: 1, NumUpdateOpsCreate: 2})
+	r.RecordUpdate(UpdateLoopResult{Success: false, NumUpdateOpsUpdate: 3, NumUpdateOpsDelete: 4})
+	r.RecordError(RunSummaryErrorSubmitAsync)
+	for _, trade := range []model.Trade{
+		{
+			Order: model.Order{OrderAction: model.OrderActionBuy, Volume: model.NumberFromFloat(10.0, 7)},
+			Cost:  model.NumberFromFloat(2.5, 7),
+			Fee:   model.NumberFromFloat(0.1, 7),
+		}, {
+			Order: model.Order{OrderAction: model.OrderActionSell, Volume: model.NumberFromFloat(4.0, 7)},
+			Cost:  model.NumberFromFloat(1.5, 7),
+		}, {
+			Order: model.Order{OrderAction: model.OrderActionSell, Volume: model.NumberFromFloat(6.0, 7)},
+			Cost:  model.NumberFromFloat(2.0, 7),
+			Fee:   model.NumberFromFloat(0.2, 7),
+		},
+	} {
+		if !assert.NoError(t, r.HandleFill(trade)) {
+			return
+		}
+	}
+	r.Finish("test")
+	// calling Finish again should not overwrite the summary
+	r.Finish("test again")
+
+	summaryBytes, e := ioutil.ReadFile(summaryFile)
+	if !assert.NoError(t, e) {
+		return
+	}
+	var summary RunSummary
+	if !assert.NoError(t, json.Unmarshal(summaryBytes, &summary)) {
+		return
+	}
+
+	assert.Equal(t, "test", summary.ExitReason)
+	assert.True(t, summary.DurationSeconds >= 60)
+	assert.Equal(t, 2, summary.NumCycles)
+	assert.Equal(t, 1, summary.NumSuccessfulCycles)
+	assert.Equal(t, 1, summary.NumPruneOps)
+	assert.Equal(t, 4, summary.NumUpdateOpsDelete)
+	assert.Equal(t, 3, summary.NumUpdateOpsUpdate)
+	assert.Equal(t, 2, summary.NumUpdateOpsCreate)
+	assert.Equal(t, 3, summary.NumFills)
+	assert.Equal(t, SideVolume{NumFills: 1, BaseVol: 10.0, QuoteCost: 2.5}, summary.Buy)
+	assert.Equal(t, SideVolume{NumFills: 2, BaseVol: 10.0, QuoteCost: 3.5}, summary.Sell)
+	assert.InDelta(t, 0.3, summary.TotalFees, 0.0000001)
+	assert.Equal(t, map[string]int{RunSummaryErrorUpdateCycle: 1, RunSummaryErrorSubmitAsync: 1}, summary.ErrorCounts)
+}
@@ -0,0 +1,56 @@
+package plugins
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/stellar/kelp/support/scheduler"
+)
+
+// ScheduledTask is a periodic task of a plugin, such as writing the stats collected in memory to the db, that runs on the maintenance
+// scheduler of the bot instead of on a loop of its own
+type ScheduledTask struct {
+	Name     string
+	Interval time.Duration
+	Fn       scheduler.TaskFn
+}
+
+var scheduledTasksLock = &sync.Mutex{}
+
+// scheduledTasks are the tasks of the plugins that were made so far, in the order they were registered
+var scheduledTasks = []ScheduledTask{}
+
+// registerScheduledTask adds the task of a plugin so it is picked up by ScheduledTasks, a number is added to the name of the task when
+// another plugin of the same kind already registered it (e.g. the level stats of two strategies that are composed)
+func registerScheduledTask(task ScheduledTask) {
+	scheduledTasksLock.Lock()
+	defer scheduledTasksLock.Unlock()
+
+	name := task.Name
+	for i := 2; hasScheduledTask(name); i++ {
+		name = fmt.Sprintf("%s_%d", task.Name, i)
+	}
+	task.Name = name
+	scheduledTasks = append(scheduledTasks, task)
+}
+
+// hasScheduledTask needs to hold scheduledTasksLock
+func hasScheduledTask(name string) bool {
+	for _, t := range scheduledTasks {
+		if t.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ScheduledTasks returns the tasks that the plugins registered, they need to be run for the plugins to write their data to the db
+func ScheduledTasks() []ScheduledTask {
+	scheduledTasksLock.Lock()
+	defer scheduledTasksLock.Unlock()
+
+	tasks := make([]ScheduledTask, len(scheduledTasks))
+	copy(tasks, scheduledTasks)
+	return tasks
+}
@@ -0,0 +1,27 @@
+package plugins
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisterScheduledTask(t *testing.T) {
+	defer func() {
+		scheduledTasks = []ScheduledTask{}
+	}()
+
+	noop := func(now time.Time) error { return nil }
+	registerScheduledTask(ScheduledTask{Name: "level_stats_flush", Interval: time.Minute, Fn: noop})
+	registerScheduledTask(ScheduledTask{Name: "order_traces_flush", Interval: 5 * time.Second, Fn: noop})
+	// a second plugin of the same kind gets a numbered name so both are scheduled
+	registerScheduledTask(ScheduledTask{Name: "level_stats_flush", Interval: time.Minute, Fn: noop})
+	registerScheduledTask(ScheduledTask{Name: "level_stats_flush", Interval: time.Minute, Fn: noop})
+
+	names := []string{}
+	for _, task := range ScheduledTasks() {
+		names = append(names, task.Name)
+	}
+	assert.Equal(t, []string{"level_stats_flush", "order_traces_flush", "level_stats_flush_2", "level_stats_flush_3"}, names)
+}
@@ -19,6 +19,7 @@ import (
 	"github.com/stellar/go/clients/horizonclient"
 	hProtocol "github.com/stellar/go/protocols/horizon"
 	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/go/xdr"
 	"github.com/stellar/kelp/api"
 	"github.com/stellar/kelp/model"
 	"github.com/stellar/kelp/support/networking"
@@ -53,11 +54,17 @@ type SDEX struct {
 	opFeeStroopsFn                OpFeeStroops
 	tradingOnSdex                 bool
 
+	// initialized runtime vars
+	seqNumMutex *sync.Mutex // guards seqNum and reloadSeqNum since transactions are built and their results handled on different goroutines
+
 	// uninitialized
-	seqNum             uint64
-	reloadSeqNum       bool
-	ieif               *IEIF
-	ocOverridesHandler *OrderConstraintsOverridesHandler
+	seqNum               uint64
+	reloadSeqNum         bool
+	ieif                 *IEIF
+	ocOverridesHandler   *OrderConstraintsOverridesHandler
+	feeChargedHandler    func(feeChargedStroops int64)
+	offersCreatedHandler func(offers []xdr.OfferEntry)
+	txNotionalGuard      *TxNotionalGuard
 }
 
 // enforce SDEX implements api.Constrainable
@@ -108,6 +115,7 @@ func MakeSDEX(
 		opFeeStroopsFn:                opFeeStroopsFn,
 		tradingOnSdex:                 exchangeShim == nil,
 		ocOverridesHandler:            MakeEmptyOrderConstraintsOverridesHandler(),
+		seqNumMutex:                   &sync.Mutex{},
 	}
 
 	if exchangeShim == nil {
@@ -128,6 +136,90 @@ func MakeSDEX(
 	return sdex
 }
 
+// SetFeeChargedHandler sets the function that is called with the network fee charged for every transaction that made it into a ledger,
+// which includes transactions that failed with tx_failed
+func (sdex *SDEX) SetFeeChargedHandler(handler func(feeChargedStroops int64)) {
+	sdex.feeChargedHandler = handler
+}
+
+// SetOffersCreatedHandler sets the function that is called with the offers created by every successful transaction, which is how the IDs
+// of new offers are known before the offers are reloaded
+func (sdex *SDEX) SetOffersCreatedHandler(handler func(offers []xdr.OfferEntry)) {
+	sdex.offersCreatedHandler = handler
+}
+
+// SetTxNotionalGuard sets the guard that checks the notional of every transaction before it is submitted
+func (sdex *SDEX) SetTxNotionalGuard(guard *TxNotionalGuard) {
+	sdex.txNotionalGuard = guard
+}
+
+func (sdex *SDEX) recordFeeCharged(feeChargedStroops int64) {
+	if sdex.feeChargedHandler == nil {
+		return
+	}
+	sdex.feeChargedHandler(feeChargedStroops)
+}
+
+// feeChargedFromResultXDR reads the fee charged from the result of a transaction, encoded as base64 XDR
+func feeChargedFromResultXDR(resultXDR string) (int64, error) {
+	var txResult xdr.TransactionResult
+	e := xdr.SafeUnmarshalBase64(resultXDR, &txResult)
+	if e != nil {
+		return 0, fmt.Errorf("could not unmarshal transaction result: %s", e)
+	}
+	return int64(txResult.FeeCharged), nil
+}
+
+func (sdex *SDEX) recordOffersCreated(resultXDR string) {
+	if sdex.offersCreatedHandler == nil {
+		return
+	}
+
+	offers, e := offersCreatedFromResultXDR(resultXDR)
+	if e != nil {
+		log.Printf("unable to read offers created by transaction: %s\n", e)
+		return
+	}
+	if len(offers) > 0 {
+		sdex.offersCreatedHandler(offers)
+	}
+}
+
+// offersCreatedFromResultXDR reads the offers created by the manage offer operations from the result of a transaction, encoded as base64 XDR
+func offersCreatedFromResultXDR(resultXDR string) ([]xdr.OfferEntry, error) {
+	var txResult xdr.TransactionResult
+	e := xdr.SafeUnmarshalBase64(resultXDR, &txResult)
+	if e != nil {
+		return nil, fmt.Errorf("could not unmarshal transaction result: %s", e)
+	}
+
+	opResults, ok := txResult.OperationResults()
+	if !ok {
+		return nil, nil
+	}
+
+	offers := []xdr.OfferEntry{}
+	for _, opResult := range opResults {
+		tr, ok := opResult.GetTr()
+		if !ok {
+			continue
+		}
+		msoResult, ok := tr.GetManageSellOfferResult()
+		if !ok {
+			continue
+		}
+		success, ok := msoResult.GetSuccess()
+		if !ok || success.Offer.Effect != xdr.ManageOfferEffectManageOfferCreated {
+			continue
+		}
+		offer, ok := success.Offer.GetOffer()
+		if ok {
+			offers = append(offers, offer)
+		}
+	}
+	return offers, nil
+}
+
 // IEIF exoses the ieif var
 func (sdex *SDEX) IEIF() *IEIF {
 	return sdex.ieif
@@ -159,24 +251,37 @@ func (sdex *SDEX) GetAssetConverter() model.AssetConverterInterface {
 	return model.Display
 }
 
-func (sdex *SDEX) incrementSeqNum() {
+// incrementSeqNum returns the sequence number to use for the next transaction
+func (sdex *SDEX) incrementSeqNum() uint64 {
+	sdex.seqNumMutex.Lock()
+	defer sdex.seqNumMutex.Unlock()
+
 	if sdex.reloadSeqNum {
 		log.Println("reloading sequence number")
 		acctReq := horizonclient.AccountRequest{AccountID: sdex.SourceAccount}
 		accountDetail, err := sdex.API.AccountDetail(acctReq)
 		if err != nil {
 			log.Printf("error loading account detail: %s\n", err)
-			return
+			return sdex.seqNum
 		}
 		seqNum, err := accountDetail.GetSequenceNumber()
 		if err != nil {
 			log.Printf("error getting seq num: %s\n", err)
-			return
+			return sdex.seqNum
 		}
 		sdex.seqNum = uint64(seqNum)
 		sdex.reloadSeqNum = false
 	}
 	sdex.seqNum++
+	return sdex.seqNum
+}
+
+// setReloadSeqNum reloads the sequence number from the network before the next transaction is built
+func (sdex *SDEX) setReloadSeqNum() {
+	sdex.seqNumMutex.Lock()
+	defer sdex.seqNumMutex.Unlock()
+
+	sdex.reloadSeqNum = true
 }
 
 // GetOrderConstraints impl
@@ -224,8 +329,25 @@ func (sdex *SDEX) CreateSellOffer(base hProtocol.Asset, counter hProtocol.Asset,
 	return sdex.createModifySellOffer(nil, base, counter, price, amount, incrementalNativeAmountRaw)
 }
 
-func (sdex *SDEX) minReserve(subentries int32) float64 {
-	return float64(2+subentries) * baseReserve
+func (sdex *SDEX) minReserve(account hProtocol.Account) float64 {
+	return SdexAccountMinReserve(account)
+}
+
+// SdexMinReserve returns the minimum balance of XLM that an account with the given number of subentries needs to maintain
+func SdexMinReserve(subentries int32) float64 {
+	return SdexSponsoredMinReserve(subentries, 0, 0)
+}
+
+// SdexSponsoredMinReserve returns the minimum balance of XLM that an account needs to maintain when it takes part in sponsorship (CAP-33).
+// The account pays the reserve of every entry that it sponsors for other accounts, which includes the claimable balances that it created,
+// and does not pay the reserve of its own entries that are sponsored by another account
+func SdexSponsoredMinReserve(subentries int32, numSponsoring uint32, numSponsored uint32) float64 {
+	return float64(2+int64(subentries)+int64(numSponsoring)-int64(numSponsored)) * baseReserve
+}
+
+// SdexAccountMinReserve returns the minimum balance of XLM that the account needs to maintain, taking sponsored reserves into account
+func SdexAccountMinReserve(account hProtocol.Account) float64 {
+	return SdexSponsoredMinReserve(account.SubentryCount, account.NumSponsoring, account.NumSponsored)
 }
 
 // assetBalance returns asset balance, asset trust limit, reserve balance (zero for non-XLM), error
@@ -246,7 +368,7 @@ func (sdex *SDEX) _assetBalance(asset hProtocol.Asset) (*api.Balance, error) {
 				return &api.Balance{
 					Balance: b,
 					Trust:   maxLumenTrust,
-					Reserve: sdex.minReserve(account.SubentryCount) + sdex.operationalBuffer,
+					Reserve: sdex.minReserve(account) + sdex.operationalBuffer,
 				}, nil
 			}
 
@@ -368,24 +490,53 @@ func (sdex *SDEX) SubmitOps(ops []build.TransactionMutator, submitMode api.Submi
 	return sdex.submitOps(ops, asyncCallback, true)
 }
 
+// SubmitOpsWithOpFee submits the passed in operations to the network asynchronously in a single transaction using the passed in fee per
+// operation instead of computing it, which is used to resubmit a transaction with a higher fee when it failed because of tx_insufficient_fee
+func (sdex *SDEX) SubmitOpsWithOpFee(ops []build.TransactionMutator, opFee uint64, asyncCallback func(hash string, e error)) error {
+	return sdex.submitOpsWithOpFee(ops, opFee, asyncCallback, true)
+}
+
 // submitOps submits the passed in operations to the network in a single transaction. Asynchronous or not based on flag.
 func (sdex *SDEX) submitOps(opsOld []build.TransactionMutator, asyncCallback func(hash string, e error), asyncMode bool) error {
-	ops := api.ConvertTM2Operation(opsOld)
-
 	// compute fee per operation
 	opFee, e := sdex.opFeeStroopsFn()
 	if e != nil {
 		return fmt.Errorf("SubmitOps error when computing op fee: %s", e)
 	}
+	return sdex.submitOpsWithOpFee(opsOld, opFee, asyncCallback, asyncMode)
+}
+
+// SubmitOperations submits operations that cannot be represented as offer mutators, such as liquidity pool operations, to the network
+// asynchronously in a single transaction
+func (sdex *SDEX) SubmitOperations(ops []txnbuild.Operation, asyncCallback func(hash string, e error)) error {
+	opFee, e := sdex.opFeeStroopsFn()
+	if e != nil {
+		return fmt.Errorf("SubmitOperations error when computing op fee: %s", e)
+	}
+	return sdex.submitOperationsWithOpFee(ops, opFee, asyncCallback, true)
+}
+
+func (sdex *SDEX) submitOpsWithOpFee(opsOld []build.TransactionMutator, opFee uint64, asyncCallback func(hash string, e error), asyncMode bool) error {
+	return sdex.submitOperationsWithOpFee(api.ConvertTM2Operation(opsOld), opFee, asyncCallback, asyncMode)
+}
 
-	sdex.incrementSeqNum()
+func (sdex *SDEX) submitOperationsWithOpFee(ops []txnbuild.Operation, opFee uint64, asyncCallback func(hash string, e error), asyncMode bool) error {
+	if sdex.txNotionalGuard != nil {
+		// checked before the sequence number is incremented since the transaction is never built
+		e := sdex.txNotionalGuard.Check(ops)
+		if e != nil {
+			return e
+		}
+	}
+
+	seqNum := sdex.incrementSeqNum()
 	tx, e := txnbuild.NewTransaction(
 		txnbuild.TransactionParams{
 			// sequence number is decremented here because Transaction.Build will increment sequence number
 			// I have not tested with not decrementing here and setting IncrementSequenceNum=false so leaving this way
 			SourceAccount: &txnbuild.SimpleAccount{
 				AccountID: sdex.SourceAccount,
-				Sequence:  int64(sdex.seqNum - 1),
+				Sequence:  int64(seqNum - 1),
 			},
 			BaseFee: int64(opFee),
 			// If IncrementSequenceNum is true, NewTransaction() will call `sourceAccount.IncrementSequenceNumber()`
@@ -413,14 +564,14 @@ func (sdex *SDEX) submitOps(opsOld []build.TransactionMutator, asyncCallback fun
 		if asyncMode {
 			log.Println("submitting tx XDR to network (async)")
 			e = sdex.threadTracker.TriggerGoroutine(func(inputs []interface{}) {
-				sdex.submit(txeB64, asyncCallback, true)
+				sdex.submit(txeB64, opFee, asyncCallback, true)
 			}, nil)
 			if e != nil {
 				return fmt.Errorf("unable to trigger goroutine to submit tx XDR to network asynchronously: %s", e)
 			}
 		} else {
 			log.Println("submitting tx XDR to network (synch)")
-			sdex.submit(txeB64, asyncCallback, false)
+			sdex.submit(txeB64, opFee, asyncCallback, false)
 		}
 	} else {
 		log.Println("not submitting tx XDR to network in simulation mode, calling asyncCallback with empty hash value")
@@ -448,7 +599,9 @@ func (sdex *SDEX) sign(tx *txnbuild.Transaction) (string, error) {
 	return tx.Base64()
 }
 
-func (sdex *SDEX) submit(txeB64 string, asyncCallback func(hash string, e error), asyncMode bool) {
+// submit submits the transaction, when horizon rejects it with result codes the asyncCallback receives a *SubmitError so the caller can
+// react to the category of the failure
+func (sdex *SDEX) submit(txeB64 string, opFee uint64, asyncCallback func(hash string, e error), asyncMode bool) {
 	resp, e := sdex.API.SubmitTransactionXDR(txeB64)
 	if e != nil {
 		if herr, ok := errors.Cause(e).(*horizonclient.Error); ok {
@@ -459,11 +612,29 @@ func (sdex *SDEX) submit(txeB64 string, asyncCallback func(hash string, e error)
 				sdex.invokeAsyncCallback(asyncCallback, "", e2, asyncMode)
 				return
 			}
-			if rcs.TransactionCode == "tx_bad_seq" {
-				log.Println("(async) error: tx_bad_seq, setting flag to reload seq number")
-				sdex.reloadSeqNum = true
+			if !isSeqNumConsumed(rcs.TransactionCode) {
+				// the sequence number of a transaction that did not make it into a ledger is not used, so the next transaction (such as one
+				// resubmitted with a bumped fee) would skip it and fail with tx_bad_seq unless we reload it
+				log.Printf("(async) error: %s, setting flag to reload seq number\n", rcs.TransactionCode)
+				sdex.setReloadSeqNum()
+			}
+			if rcs.TransactionCode == "tx_failed" {
+				// failed transactions are included in the ledger so the network still charges a fee for them
+				var feeCharged int64
+				resultXDR, e2 := herr.ResultString()
+				if e2 == nil {
+					feeCharged, e2 = feeChargedFromResultXDR(resultXDR)
+				}
+				if e2 != nil {
+					log.Printf("(async) error: unable to read fee charged for failed transaction: %s\n", e2)
+				} else {
+					sdex.recordFeeCharged(feeCharged)
+				}
 			}
-			log.Println("(async) error: result code details: tx code =", rcs.TransactionCode, ", opcodes =", rcs.OperationCodes)
+			submitError := makeSubmitError(rcs, opFee, e)
+			log.Println("(async) error: result code details: tx code =", rcs.TransactionCode, ", opcodes =", rcs.OperationCodes, ", category =", submitError.Category)
+			sdex.invokeAsyncCallback(asyncCallback, "", submitError, asyncMode)
+			return
 		} else {
 			log.Printf("(async) error: tx failed for unknown reason, error message: %s\n", e)
 		}
@@ -476,6 +647,8 @@ func (sdex *SDEX) submit(txeB64 string, asyncCallback func(hash string, e error)
 		modeString = "(async)"
 	}
 	log.Printf("%s tx confirmation hash: %s\n", modeString, resp.Hash)
+	sdex.recordFeeCharged(resp.FeeCharged)
+	sdex.recordOffersCreated(resp.ResultXdr)
 	sdex.invokeAsyncCallback(asyncCallback, resp.Hash, nil, asyncMode)
 }
 
@@ -526,7 +699,11 @@ func (sdex *SDEX) GetTradeHistory(pair model.TradingPair, maybeCursorStart inter
 	if e != nil {
 		return nil, fmt.Errorf("error while converting pair to base and quote asset: %s", e)
 	}
+	return sdex.getTradeHistory(baseAsset, quoteAsset, maybeCursorStart, maybeCursorEnd)
+}
 
+// getTradeHistory fetches trades of the trading account between baseAsset and quoteAsset, which can be different from the assets of sdex.pair
+func (sdex *SDEX) getTradeHistory(baseAsset hProtocol.Asset, quoteAsset hProtocol.Asset, maybeCursorStart interface{}, maybeCursorEnd interface{}) (*api.TradeHistoryResult, error) {
 	var cursorStart string
 	if maybeCursorStart != nil {
 		var ok bool
@@ -859,6 +1036,11 @@ func (sdex *SDEX) tradesPage2TradeHistoryResult(baseAsset hProtocol.Asset, quote
 		}
 		floatPrice, _ := big.NewRat(t.Price.N, t.Price.D).Float64()
 		price := model.NumberFromFloat(floatPrice, sdexOrderConstraints.PricePrecision)
+		// the order ID is the ID of the offer of the trading account in this trade
+		orderID := t.CounterOfferID
+		if t.BaseAccount == sdex.TradingAccount {
+			orderID = t.BaseOfferID
+		}
 
 		trades = append(trades, model.Trade{
 			Order: model.Order{
@@ -872,7 +1054,7 @@ func (sdex *SDEX) tradesPage2TradeHistoryResult(baseAsset hProtocol.Asset, quote
 			TransactionID: model.MakeTransactionID(t.ID),
 			Cost:          price.Multiply(*vol),
 			Fee:           model.NumberFromFloat(baseFee, sdexOrderConstraints.PricePrecision),
-			// OrderID unavailable?
+			OrderID:       orderID,
 		})
 
 		if cursor == cursorEnd {
@@ -895,7 +1077,11 @@ func (sdex *SDEX) GetLatestTradeCursor() (interface{}, error) {
 	if e != nil {
 		return nil, fmt.Errorf("error while converting pair to base and quote asset: %s", e)
 	}
+	return sdex.getLatestTradeCursor(baseAsset, quoteAsset)
+}
 
+// getLatestTradeCursor fetches the cursor of the latest trade between baseAsset and quoteAsset
+func (sdex *SDEX) getLatestTradeCursor(baseAsset hProtocol.Asset, quoteAsset hProtocol.Asset) (interface{}, error) {
 	tradeReq := horizonclient.TradeRequest{
 		BaseAssetType:      horizonclient.AssetType(baseAsset.Type),
 		BaseAssetCode:      baseAsset.Code,
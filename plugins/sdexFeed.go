@@ -2,6 +2,7 @@ package plugins
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/stellar/go/clients/horizonclient"
@@ -17,14 +18,28 @@ type sdexFeed struct {
 	sdex       *SDEX
 	assetBase  *hProtocol.Asset
 	assetQuote *hProtocol.Asset
+	// depthQuote is the amount of the quote asset to buy and sell against the orderbook for a depth-weighted mid price, 0 uses the top of book
+	depthQuote float64
 }
 
 // ensure that it implements PriceFeed
 var _ api.PriceFeed = &sdexFeed{}
 
-// makeSDEXFeed creates a price feed from buysell's url fields
+// makeSDEXFeed creates a price feed from buysell's url fields: CODE:ISSUER/CODE:ISSUER[/depth=<quoteAmount>]
 func makeSDEXFeed(url string) (*sdexFeed, error) {
 	urlParts := strings.Split(url, "/")
+	if len(urlParts) < 2 || len(urlParts) > 3 {
+		return nil, fmt.Errorf("sdex feed url needs 2 or 3 parts separated by the '/' delimiter (CODE:ISSUER/CODE:ISSUER[/depth=<quoteAmount>]) but was '%s'", url)
+	}
+
+	depthQuote := 0.0
+	if len(urlParts) == 3 {
+		var e error
+		depthQuote, e = parseSDEXFeedDepth(urlParts[2])
+		if e != nil {
+			return nil, e
+		}
+	}
 
 	baseAsset, e := parseHorizonAsset(urlParts[0])
 	if e != nil {
@@ -80,9 +95,24 @@ func makeSDEXFeed(url string) (*sdexFeed, error) {
 		sdex:       sdex,
 		assetBase:  baseAsset,
 		assetQuote: quoteAsset,
+		depthQuote: depthQuote,
 	}, nil
 }
 
+func parseSDEXFeedDepth(depthString string) (float64, error) {
+	if !strings.HasPrefix(depthString, "depth=") {
+		return 0, fmt.Errorf("the third part of the sdex feed url needs to be of the form depth=<quoteAmount> but was '%s'", depthString)
+	}
+	depthQuote, e := strconv.ParseFloat(strings.TrimPrefix(depthString, "depth="), 64)
+	if e != nil {
+		return 0, fmt.Errorf("could not parse depth of sdex feed from '%s': %s", depthString, e)
+	}
+	if depthQuote <= 0 {
+		return 0, fmt.Errorf("depth of sdex feed needs to be > 0 but was %f", depthQuote)
+	}
+	return depthQuote, nil
+}
+
 func parseHorizonAsset(assetString string) (*hProtocol.Asset, error) {
 	parts := strings.Split(assetString, ":")
 	code := parts[0]
@@ -96,8 +126,20 @@ func parseHorizonAsset(assetString string) (*hProtocol.Asset, error) {
 	return asset, e
 }
 
-// GetPrice returns the SDEX mid price for the trading pair
+// GetPrice returns the SDEX mid price for the trading pair, or the depth-weighted mid price when a depth is set
 func (s *sdexFeed) GetPrice() (float64, error) {
+	if s.depthQuote > 0 {
+		orderBook, e := s.sdex.GetOrderBook(s.sdex.pair, maxPageLimit)
+		if e != nil {
+			return 0, fmt.Errorf("unable to get sdex price: %s", e)
+		}
+		midPrice, e := computeDepthWeightedMidPrice(orderBook, s.depthQuote)
+		if e != nil {
+			return 0, fmt.Errorf("unable to get depth-weighted sdex price: %s", e)
+		}
+		return midPrice, nil
+	}
+
 	orderBook, e := s.sdex.GetOrderBook(s.sdex.pair, 1)
 	if e != nil {
 		return 0, fmt.Errorf("unable to get sdex price: %s", e)
@@ -119,3 +161,38 @@ func (s *sdexFeed) GetPrice() (float64, error) {
 	midPrice := topBidPrice.Add(*topAskPrice).Scale(0.5).AsFloat()
 	return midPrice, nil
 }
+
+// computeDepthWeightedMidPrice is the average of the prices at which depthQuote of the quote asset would be bought from the bids and sold to
+// the asks, walking down each side of the orderbook. This moves much less than the top of book mid price when small offers are placed at
+// the top of a thin market.
+func computeDepthWeightedMidPrice(ob *model.OrderBook, depthQuote float64) (float64, error) {
+	bidPrice, e := computeDepthPrice(ob.Bids(), depthQuote)
+	if e != nil {
+		return 0, fmt.Errorf("bids: %s", e)
+	}
+	askPrice, e := computeDepthPrice(ob.Asks(), depthQuote)
+	if e != nil {
+		return 0, fmt.Errorf("asks: %s", e)
+	}
+	return (bidPrice + askPrice) / 2, nil
+}
+
+// computeDepthPrice is the average price of filling depthQuote of the quote asset against the orders, which are sorted from the best price
+func computeDepthPrice(orders []model.Order, depthQuote float64) (float64, error) {
+	remainingQuote := depthQuote
+	filledBase := 0.0
+	for _, o := range orders {
+		price := o.Price.AsFloat()
+		if price <= 0 {
+			continue
+		}
+		levelQuote := o.Volume.AsFloat() * price
+		if levelQuote >= remainingQuote {
+			filledBase += remainingQuote / price
+			return depthQuote / filledBase, nil
+		}
+		filledBase += o.Volume.AsFloat()
+		remainingQuote -= levelQuote
+	}
+	return 0, fmt.Errorf("orderbook only has %.7f of the %.7f quote units of depth needed", depthQuote-remainingQuote, depthQuote)
+}
@@ -0,0 +1,52 @@
+package plugins
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/kelp/model"
+)
+
+func TestComputeDepthWeightedMidPrice(t *testing.T) {
+	pair := model.MakeTradingPair(model.XLM, model.USDT)
+	ob := model.MakeOrderBook(
+		pair,
+		makeTestObiOrders(model.OrderActionSell, []float64{1.0, 2.0}, []float64{10, 100}),
+		makeTestObiOrders(model.OrderActionBuy, []float64{0.5, 0.4}, []float64{100, 100}),
+	)
+
+	// a small depth is filled by the top of book
+	price, e := computeDepthWeightedMidPrice(ob, 5)
+	if assert.NoError(t, e) {
+		assert.InDelta(t, (1.0+0.5)/2, price, 0.0000001)
+	}
+
+	// buying 30 of the quote asset fills 10 base at 1.0 and 10 base at 2.0, selling 30 of the quote asset fills 60 base at 0.5
+	price, e = computeDepthWeightedMidPrice(ob, 30)
+	if assert.NoError(t, e) {
+		assert.InDelta(t, (30.0/20+0.5)/2, price, 0.0000001)
+	}
+
+	// selling 70 of the quote asset fills 100 base at 0.5 and 50 base at 0.4
+	price, e = computeDepthPrice(ob.Bids(), 70)
+	if assert.NoError(t, e) {
+		assert.InDelta(t, 70.0/150, price, 0.0000001)
+	}
+
+	_, e = computeDepthWeightedMidPrice(ob, 1000)
+	assert.Error(t, e)
+	_, e = computeDepthWeightedMidPrice(model.MakeOrderBook(pair, []model.Order{}, ob.Bids()), 5)
+	assert.Error(t, e)
+}
+
+func TestParseSDEXFeedDepth(t *testing.T) {
+	depth, e := parseSDEXFeedDepth("depth=1000")
+	if assert.NoError(t, e) {
+		assert.Equal(t, 1000.0, depth)
+	}
+	for _, s := range []string{"1000", "depth=", "depth=abc", "depth=0", "depth=-5"} {
+		_, e = parseSDEXFeedDepth(s)
+		assert.Error(t, e, s)
+	}
+}
@@ -0,0 +1,134 @@
+package plugins
+
+import (
+	"fmt"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+)
+
+// SubmitErrorCategory groups the horizon result codes of a failed transaction by how the trader should react to the failure
+type SubmitErrorCategory string
+
+// type of SubmitErrorCategory
+const (
+	// SubmitErrorCategoryUnknown is a failure that the trader cannot handle specifically, it counts towards the deleteCyclesThreshold
+	SubmitErrorCategoryUnknown SubmitErrorCategory = "unknown"
+	// SubmitErrorCategorySkipOps is a failure of individual operations, the next update recomputes the operations
+	SubmitErrorCategorySkipOps SubmitErrorCategory = "skip_ops"
+	// SubmitErrorCategoryFeeBump is a failure because the fee was too low, the transaction can be resubmitted with a higher fee
+	SubmitErrorCategoryFeeBump SubmitErrorCategory = "fee_bump"
+	// SubmitErrorCategoryPause is a failure that needs the operator to fix the account, the bot should stop placing offers until then
+	SubmitErrorCategoryPause SubmitErrorCategory = "pause"
+)
+
+// String is the Stringer method impl
+func (c SubmitErrorCategory) String() string {
+	return string(c)
+}
+
+const opResultCodeSuccess = "op_success"
+
+// skipOpResultCodes are operation result codes that only affect the operation that failed
+var skipOpResultCodes = map[string]bool{
+	"op_underfunded":     true,
+	"op_line_full":       true,
+	"op_cross_self":      true,
+	"op_offer_not_found": true,
+	"op_low_reserve":     true,
+}
+
+// pauseTxResultCodes are transaction result codes that will keep failing until the operator fixes the account
+var pauseTxResultCodes = map[string]bool{
+	"tx_insufficient_balance": true,
+	"tx_bad_auth":             true,
+	"tx_bad_auth_extra":       true,
+	"tx_no_source_account":    true,
+}
+
+// pauseOpResultCodes are operation result codes that will keep failing until the operator fixes the account or its trustlines
+var pauseOpResultCodes = map[string]bool{
+	"op_bad_auth":            true,
+	"op_no_source_account":   true,
+	"op_sell_no_trust":       true,
+	"op_buy_no_trust":        true,
+	"op_sell_not_authorized": true,
+	"op_buy_not_authorized":  true,
+	"op_sell_no_issuer":      true,
+	"op_buy_no_issuer":       true,
+}
+
+// SubmitError is the error passed to the asyncCallback when horizon rejects a transaction with result codes
+type SubmitError struct {
+	TransactionCode string
+	OperationCodes  []string
+	OpFeeStroops    uint64 // fee per operation that the transaction was submitted with
+	Category        SubmitErrorCategory
+	cause           error
+}
+
+// makeSubmitError is a factory method
+func makeSubmitError(rcs *hProtocol.TransactionResultCodes, opFeeStroops uint64, cause error) *SubmitError {
+	return &SubmitError{
+		TransactionCode: rcs.TransactionCode,
+		OperationCodes:  rcs.OperationCodes,
+		OpFeeStroops:    opFeeStroops,
+		Category:        categorizeResultCodes(rcs.TransactionCode, rcs.OperationCodes),
+		cause:           cause,
+	}
+}
+
+// Error impl.
+func (e *SubmitError) Error() string {
+	return fmt.Sprintf("transaction failed with tx code = %s, opcodes = %v, category = %s: %s", e.TransactionCode, e.OperationCodes, e.Category, e.cause)
+}
+
+// FailedOpIndices returns the indices of the operations in the transaction that did not succeed
+func (e *SubmitError) FailedOpIndices() []int {
+	indices := []int{}
+	for i, code := range e.OperationCodes {
+		if code != opResultCodeSuccess {
+			indices = append(indices, i)
+		}
+	}
+	return indices
+}
+
+// isSeqNumConsumed returns true when a transaction that failed with txCode was included in a ledger, which is the only case where the
+// sequence number of the transaction is used up
+func isSeqNumConsumed(txCode string) bool {
+	return txCode == "tx_failed"
+}
+
+// categorizeResultCodes picks the category of the most severe failure, so a transaction that needs the bot to be paused is never retried
+func categorizeResultCodes(txCode string, opCodes []string) SubmitErrorCategory {
+	if pauseTxResultCodes[txCode] {
+		return SubmitErrorCategoryPause
+	}
+	for _, code := range opCodes {
+		if pauseOpResultCodes[code] {
+			return SubmitErrorCategoryPause
+		}
+	}
+
+	if txCode == "tx_insufficient_fee" {
+		return SubmitErrorCategoryFeeBump
+	}
+
+	if txCode != "tx_failed" {
+		return SubmitErrorCategoryUnknown
+	}
+	numFailed := 0
+	for _, code := range opCodes {
+		if code == opResultCodeSuccess {
+			continue
+		}
+		if !skipOpResultCodes[code] {
+			return SubmitErrorCategoryUnknown
+		}
+		numFailed++
+	}
+	if numFailed == 0 {
+		return SubmitErrorCategoryUnknown
+	}
+	return SubmitErrorCategorySkipOps
+}
@@ -0,0 +1,52 @@
+package plugins
+
+import (
+	"fmt"
+	"testing"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCategorizeResultCodes(t *testing.T) {
+	testCases := []struct {
+		txCode  string
+		opCodes []string
+		want    SubmitErrorCategory
+	}{
+		{txCode: "tx_failed", opCodes: []string{"op_success", "op_underfunded"}, want: SubmitErrorCategorySkipOps},
+		{txCode: "tx_failed", opCodes: []string{"op_line_full", "op_cross_self"}, want: SubmitErrorCategorySkipOps},
+		{txCode: "tx_insufficient_fee", opCodes: nil, want: SubmitErrorCategoryFeeBump},
+		{txCode: "tx_insufficient_balance", opCodes: nil, want: SubmitErrorCategoryPause},
+		// a failure that needs the bot to be paused takes precedence over ops that could be skipped
+		{txCode: "tx_failed", opCodes: []string{"op_underfunded", "op_sell_no_trust"}, want: SubmitErrorCategoryPause},
+		{txCode: "tx_failed", opCodes: []string{"op_underfunded", "op_malformed"}, want: SubmitErrorCategoryUnknown},
+		{txCode: "tx_failed", opCodes: []string{"op_success"}, want: SubmitErrorCategoryUnknown},
+		{txCode: "tx_bad_seq", opCodes: nil, want: SubmitErrorCategoryUnknown},
+	}
+
+	for _, k := range testCases {
+		t.Run(fmt.Sprintf("%s_%v", k.txCode, k.opCodes), func(t *testing.T) {
+			assert.Equal(t, k.want, categorizeResultCodes(k.txCode, k.opCodes))
+		})
+	}
+}
+
+func TestSubmitErrorFailedOpIndices(t *testing.T) {
+	submitError := makeSubmitError(&hProtocol.TransactionResultCodes{
+		TransactionCode: "tx_failed",
+		OperationCodes:  []string{"op_underfunded", "op_success", "op_cross_self"},
+	}, 200, fmt.Errorf("horizon error"))
+
+	assert.Equal(t, SubmitErrorCategorySkipOps, submitError.Category)
+	assert.Equal(t, uint64(200), submitError.OpFeeStroops)
+	assert.Equal(t, []int{0, 2}, submitError.FailedOpIndices())
+}
+
+func TestIsSeqNumConsumed(t *testing.T) {
+	// only transactions that were included in a ledger use up their sequence number
+	assert.True(t, isSeqNumConsumed("tx_failed"))
+	assert.False(t, isSeqNumConsumed("tx_insufficient_fee"))
+	assert.False(t, isSeqNumConsumed("tx_bad_seq"))
+	assert.False(t, isSeqNumConsumed("tx_bad_auth"))
+}
@@ -334,8 +334,17 @@ func (s *sellSideStrategy) UpdateWithOps(offers []hProtocol.Offer) (opsOld []bui
 	return api.ConvertOperation2TM(ops), newTopOffer, nil
 }
 
+// postUpdateLevelProvider is an optional interface for a LevelProvider that needs to know when the ops for the levels of its last GetLevels
+// call were submitted
+type postUpdateLevelProvider interface {
+	PostUpdate() error
+}
+
 // PostUpdate impl
 func (s *sellSideStrategy) PostUpdate() error {
+	if p, ok := s.levelsProvider.(postUpdateLevelProvider); ok {
+		return p.PostUpdate()
+	}
 	return nil
 }
 
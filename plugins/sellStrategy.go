@@ -1,6 +1,7 @@
 package plugins
 
 import (
+	"database/sql"
 	"fmt"
 
 	"github.com/stellar/kelp/model"
@@ -19,9 +20,12 @@ type sellConfig struct {
 	PriceTolerance         float64       `valid:"-" toml:"PRICE_TOLERANCE"`
 	AmountTolerance        float64       `valid:"-" toml:"AMOUNT_TOLERANCE"`
 	AmountOfABase          float64       `valid:"-" toml:"AMOUNT_OF_A_BASE"` // the size of order
+	AmountUnit             string        `valid:"-" toml:"AMOUNT_UNIT"`
 	RateOffsetPercent      float64       `valid:"-" toml:"RATE_OFFSET_PERCENT"`
 	RateOffset             float64       `valid:"-" toml:"RATE_OFFSET"`
 	RateOffsetPercentFirst bool          `valid:"-" toml:"RATE_OFFSET_PERCENT_FIRST"`
+	IcebergVisibleAmount   float64       `valid:"-" toml:"ICEBERG_VISIBLE_AMOUNT"`
+	TrackLevelStats        bool          `valid:"-" toml:"TRACK_LEVEL_STATS"`
 	Levels                 []StaticLevel `valid:"-" toml:"LEVELS"`
 }
 
@@ -38,6 +42,9 @@ func makeSellStrategy(
 	assetBase *hProtocol.Asset,
 	assetQuote *hProtocol.Asset,
 	config *sellConfig,
+	levelStats *levelStatsRecorder,
+	db *sql.DB,
+	marketID string,
 ) (api.Strategy, error) {
 	pf, e := MakeFeedPair(
 		config.DataTypeA,
@@ -49,19 +56,45 @@ func makeSellStrategy(
 		return nil, fmt.Errorf("cannot make the sell strategy because we could not make the feed pair: %s", e)
 	}
 
+	levelAmountUnit, e := parseAmountUnit(config.AmountUnit)
+	if e != nil {
+		return nil, fmt.Errorf("cannot make the sell strategy: %s", e)
+	}
+
 	orderConstraints := sdex.GetOrderConstraints(pair)
 	offset := rateOffset{
 		percent:      config.RateOffsetPercent,
 		absolute:     config.RateOffset,
 		percentFirst: config.RateOffsetPercentFirst,
 	}
+	feeds := map[string]*api.FeedPair{defaultLevelFeed: pf}
+	e = validateLevelFeeds(config.Levels, feeds)
+	if e != nil {
+		return nil, fmt.Errorf("cannot make the sell strategy, named price feeds are only supported by the buysell strategy: %s", e)
+	}
+	levelsProvider, e := maybeWrapIcebergLevelProvider(
+		levelStats.wrap(
+			makeStaticSpreadLevelProvider(config.Levels, config.AmountOfABase, levelAmountUnit, offset, feeds, orderConstraints, false, nil),
+			config.Levels,
+			false,
+		),
+		config.IcebergVisibleAmount,
+		levelAmountUnit,
+		false,
+		orderConstraints,
+		db,
+		marketID,
+	)
+	if e != nil {
+		return nil, fmt.Errorf("cannot make the sell strategy: %s", e)
+	}
 	sellSideStrategy := makeSellSideStrategy(
 		sdex,
 		orderConstraints,
 		ieif,
 		assetBase,
 		assetQuote,
-		makeStaticSpreadLevelProvider(config.Levels, config.AmountOfABase, offset, pf, orderConstraints),
+		levelsProvider,
 		config.PriceTolerance,
 		config.AmountTolerance,
 		false,
@@ -30,11 +30,17 @@ type sellTwapLevelProvider struct {
 	exponentialSmoothingFactor                            float64
 	minChildOrderSizePercentOfParent                      float64
 	random                                                *rand.Rand
+	clock                                                 api.Clock
 	isBuySide                                             bool
+	volumeProfile                                         bucketVolumeProfile // nil distributes capacity uniformly over buckets
+	carrySurplusAcrossDays                                bool
+	holidayCalendar                                       *holidayCalendar // nil does not adjust the daily caps
+	dailyVolumeFn                                         func(volFilter volumeFilter, date string) (*queries.DailyVolume, error)
 
 	// uninitialized
-	activeBucket    *bucketInfo
-	previousRoundID *roundID
+	activeBucket       *bucketInfo
+	previousRoundID    *roundID
+	carriedBaseSurplus float64 // unsold capacity carried over from the previous day, only set when carrySurplusAcrossDays is true
 }
 
 // ensure it implements the LevelProvider interface
@@ -51,8 +57,12 @@ func makeSellTwapLevelProvider(
 	distributeSurplusOverRemainingIntervalsPercentCeiling float64,
 	exponentialSmoothingFactor float64,
 	minChildOrderSizePercentOfParent float64,
-	randSeed int64,
+	random *rand.Rand,
+	clock api.Clock,
 	isBuySide bool,
+	volumeProfile bucketVolumeProfile,
+	carrySurplusAcrossDays bool,
+	holidayCalendar *holidayCalendar,
 ) (api.LevelProvider, error) {
 	if numHoursToSell <= 0 || numHoursToSell > 24 {
 		return nil, fmt.Errorf("invalid number of hours to sell, expected 0 < numHoursToSell <= 24; was %d", numHoursToSell)
@@ -84,7 +94,6 @@ func makeSellTwapLevelProvider(
 		}
 	}
 
-	random := rand.New(rand.NewSource(randSeed))
 	return &sellTwapLevelProvider{
 		startPf:                 startPf,
 		offset:                  offset,
@@ -96,10 +105,28 @@ func makeSellTwapLevelProvider(
 		exponentialSmoothingFactor:                            exponentialSmoothingFactor,
 		minChildOrderSizePercentOfParent:                      minChildOrderSizePercentOfParent,
 		random:                                                random,
+		clock:                                                 clock,
 		isBuySide:                                             isBuySide,
+		volumeProfile:                                         volumeProfile,
+		carrySurplusAcrossDays:                                carrySurplusAcrossDays,
+		holidayCalendar:                                       holidayCalendar,
+		dailyVolumeFn:                                         queryDailyVolume,
 	}, nil
 }
 
+// queryDailyVolume fetches the volume sold on the date from the db with the query of the volume filter
+func queryDailyVolume(volFilter volumeFilter, date string) (*queries.DailyVolume, error) {
+	queryResult, e := volFilter.dailyVolumeByDateQuery.QueryRow(date)
+	if e != nil {
+		return nil, e
+	}
+	dailyVolumeValues, ok := queryResult.(*queries.DailyVolume)
+	if !ok {
+		return nil, fmt.Errorf("could not cast query result from dailyValuesByDateQuery as a *queries.DailyVolume, was type '%T'", queryResult)
+	}
+	return dailyVolumeValues, nil
+}
+
 type bucketID int64
 
 type dynamicBucketValues struct {
@@ -248,9 +275,15 @@ func (r *roundInfo) String() string {
 
 // GetLevels impl.
 func (p *sellTwapLevelProvider) GetLevels(maxAssetBase float64, maxAssetQuote float64) ([]api.Level, error) {
-	now := time.Now().UTC()
+	now := p.clock.Now().UTC()
 	log.Printf("GetLevels, unix timestamp for 'now' in UTC = %d (%s)\n", now.Unix(), now)
 
+	// skip the day entirely when the market is closed so the bucket state, and any surplus carried across days, moves on to the next trading day
+	if p.holidayCalendar.capMultiplier(now) == 0.0 {
+		log.Printf("holiday calendar: the daily cap for %s is zero, not placing any offers\n", now.Format(postgresdb.DateFormatString))
+		return []api.Level{}, nil
+	}
+
 	volFilter := p.dowFilter[now.Weekday()]
 	log.Printf("volumeFilter = %s\n", volFilter.String())
 
@@ -309,20 +342,37 @@ func (p *sellTwapLevelProvider) makeFirstBucketFrame(
 	// the total surplus remaining up until this point gets distributed over the remaining buckets
 	averageBaseCapacity := float64(dayBaseCapacity) / float64(totalBucketsToSell)
 	numPreviousBuckets := bID // buckets are 0-indexed, so bucketID is equal to numbers of previous buckets
+	bucketBaseCapacity := averageBaseCapacity
 	expectedSold := averageBaseCapacity * float64(numPreviousBuckets)
 	// we have special logic for buckets after selling hours to ensure we don't expect a larger amount sold
 	if int64(numPreviousBuckets) >= totalBucketsToSell {
 		expectedSold = dayBaseCapacity
+	} else if p.volumeProfile != nil {
+		// weight the capacity of each bucket by the volume profile instead of using uniform buckets
+		weights, e := p.volumeProfile.getWeights(now, totalBucketsToSell, p.parentBucketSizeSeconds)
+		if e != nil {
+			return nil, fmt.Errorf("could not get weights from volume profile: %s", e)
+		}
+		if int64(len(weights)) != totalBucketsToSell {
+			return nil, fmt.Errorf("volume profile returned %d weights but expected %d (totalBucketsToSell)", len(weights), totalBucketsToSell)
+		}
+
+		cumulativeWeight := 0.0
+		for _, w := range weights[:numPreviousBuckets] {
+			cumulativeWeight += w
+		}
+		bucketBaseCapacity = dayBaseCapacity * weights[numPreviousBuckets]
+		expectedSold = dayBaseCapacity * cumulativeWeight
 	}
-	totalBaseSurplusStart := expectedSold - dayBaseSoldStart
+	totalBaseSurplusStart := expectedSold - dayBaseSoldStart + p.carriedBaseSurplus
 	remainingBucketsToSell := totalBucketsToSell - int64(numPreviousBuckets)
 	baseSurplusIncluded := p.firstDistributionOfBaseSurplus(totalBaseSurplusStart, remainingBucketsToSell)
 	baseCapacity := baseSurplusIncluded
 	if remainingBucketsToSell > 0 {
-		// only include the averageBaseCapacity if we are within the number of total buckets to sell
+		// only include the bucketBaseCapacity if we are within the number of total buckets to sell
 		// else we are in a state where there is no "new" capacity for every bucket and we are only
 		// trying to get rid of past surplus values
-		baseCapacity += averageBaseCapacity
+		baseCapacity += bucketBaseCapacity
 	}
 	minOrderSizeBase := p.minChildOrderSizePercentOfParent * baseCapacity
 	// upon instantiation the first bucket frame does not have anything sold beyond the starting values
@@ -385,14 +435,15 @@ func (p *sellTwapLevelProvider) makeActiveBucket(now time.Time, volFilter volume
 	if e != nil {
 		return nil, nil, fmt.Errorf("could not fetch base asset cap in base units: %s", e)
 	}
-	queryResult, e := volFilter.dailyVolumeByDateQuery.QueryRow(now.Format(postgresdb.DateFormatString))
+	if capMultiplier := p.holidayCalendar.capMultiplier(now); capMultiplier != 1.0 {
+		log.Printf("holiday calendar: adjusting the daily cap for %s by a multiplier of %.4f from %.8f to %.8f\n",
+			now.Format(postgresdb.DateFormatString), capMultiplier, dayBaseCapacity, dayBaseCapacity*capMultiplier)
+		dayBaseCapacity = dayBaseCapacity * capMultiplier
+	}
+	dailyVolumeValues, e := p.dailyVolumeFn(volFilter, now.Format(postgresdb.DateFormatString))
 	if e != nil {
 		return nil, nil, fmt.Errorf("could not fetch daily values for today: %s", e)
 	}
-	dailyVolumeValues, ok := queryResult.(*queries.DailyVolume)
-	if !ok {
-		return nil, nil, fmt.Errorf("could not cast query result from dailyValuesByDateQuery as a *queries.DailyVolume, was type '%T'", queryResult)
-	}
 
 	// bucket on bot load
 	if p.activeBucket == nil {
@@ -403,6 +454,32 @@ func (p *sellTwapLevelProvider) makeActiveBucket(now time.Time, volFilter volume
 		return nil, bucket, nil
 	}
 
+	// the previous bucket belongs to the previous day when we cross midnight UTC
+	if !floorDate(p.activeBucket.startTime).Equal(dayStartTime) {
+		oldBucket, e := p.finalizePreviousDayBucket(rID)
+		if e != nil {
+			return nil, nil, fmt.Errorf("could not finalize bucket (ID=%d) from the previous day: %s", p.activeBucket.ID, e)
+		}
+
+		carriedBaseSurplus := 0.0
+		if p.carrySurplusAcrossDays {
+			carriedBaseSurplus = unsoldDayBaseCapacity(oldBucket, p.carriedBaseSurplus)
+		}
+		p.carriedBaseSurplus = carriedBaseSurplus
+		log.Printf("day rollover from %s to %s, carrying over base surplus of %.8f from the previous day (carrySurplusAcrossDays=%v)\n",
+			floorDate(oldBucket.startTime).Format(postgresdb.DateFormatString),
+			dayStartTime.Format(postgresdb.DateFormatString),
+			p.carriedBaseSurplus,
+			p.carrySurplusAcrossDays,
+		)
+
+		newBucket, e := p.makeFirstBucketFrame(now, startTime, endTime, bID, rID, dayBaseCapacity, dailyVolumeValues)
+		if e != nil {
+			return nil, nil, fmt.Errorf("unable to make first bucket frame for the new day (ID=%d): %s", bID, e)
+		}
+		return oldBucket, newBucket, nil
+	}
+
 	// always update existing bucket with latest volume numbers
 	bucket, e := p.updateExistingBucket(now, dailyVolumeValues, rID)
 	if e != nil {
@@ -426,6 +503,30 @@ func (p *sellTwapLevelProvider) makeActiveBucket(now time.Time, volFilter volume
 	return oldBucket, newBucket, nil
 }
 
+// finalizePreviousDayBucket updates the active bucket with the final volume numbers of its own day, since the volume numbers of the new day
+// would make it look like we had sold a negative amount in the last bucket of the previous day
+func (p *sellTwapLevelProvider) finalizePreviousDayBucket(rID roundID) (*bucketInfo, error) {
+	previousDay := p.activeBucket.startTime
+	volFilter := p.dowFilter[previousDay.Weekday()]
+	dailyVolumeValues, e := p.dailyVolumeFn(volFilter, previousDay.Format(postgresdb.DateFormatString))
+	if e != nil {
+		return nil, fmt.Errorf("could not fetch daily values for the previous day: %s", e)
+	}
+
+	// use the end of the bucket as the time so the final values are attributed to the previous day
+	bucket, e := p.updateExistingBucket(p.activeBucket.endTime, dailyVolumeValues, rID)
+	if e != nil {
+		return nil, fmt.Errorf("could not update existing bucket: %s", e)
+	}
+	return finalizeBucket(bucket), nil
+}
+
+// unsoldDayBaseCapacity is the amount of the day's capacity, including any surplus carried into the day, that was not sold by the end of the day.
+// Overselling is not carried over as a negative surplus because the daily cap for the new day is independent of the previous day.
+func unsoldDayBaseCapacity(lastBucketOfDay *bucketInfo, carriedBaseSurplus float64) float64 {
+	return math.Max(0.0, lastBucketOfDay.dayBaseCapacity+carriedBaseSurplus-lastBucketOfDay.dynamicValues.dayBaseSold)
+}
+
 /*
 Using a geometric series calculation:
 Sn = a * (r^n - 1) / (r - 1)
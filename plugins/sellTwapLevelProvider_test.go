@@ -1,6 +1,7 @@
 package plugins
 
 import (
+	"math/rand"
 	"testing"
 	"time"
 
@@ -91,8 +92,12 @@ func makeTestSellTwapLevelProvider2(
 		0.05,
 		0.5,
 		minChildOrderSizePercentOfParent,
-		seed,
+		rand.New(rand.NewSource(seed)),
+		MakeManualClock(time.Date(2020, 3, 14, 0, 0, 0, 0, time.UTC)),
+		false,
+		nil,
 		false,
+		nil,
 	)
 	if e != nil {
 		panic(e)
@@ -495,6 +500,74 @@ func TestUpdateExistingBucket(t *testing.T) {
 	assert.Equal(t, int64(120), updatedBucketInfo.totalBucketsToSell)
 }
 
+func TestUnsoldDayBaseCapacity(t *testing.T) {
+	testCases := []struct {
+		name               string
+		dayBaseSold        float64
+		carriedBaseSurplus float64
+		want               float64
+	}{
+		{
+			name:               "undersold",
+			dayBaseSold:        600.0,
+			carriedBaseSurplus: 0.0,
+			want:               400.0,
+		}, {
+			name:               "undersold with carried surplus",
+			dayBaseSold:        600.0,
+			carriedBaseSurplus: 50.0,
+			want:               450.0,
+		}, {
+			name:               "sold out",
+			dayBaseSold:        1000.0,
+			carriedBaseSurplus: 0.0,
+			want:               0.0,
+		}, {
+			name:               "oversold",
+			dayBaseSold:        1200.0,
+			carriedBaseSurplus: 0.0,
+			want:               0.0,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			bucket := &bucketInfo{
+				dayBaseCapacity: 1000.0,
+				dynamicValues:   &dynamicBucketValues{dayBaseSold: k.dayBaseSold},
+			}
+			assert.Equal(t, k.want, unsoldDayBaseCapacity(bucket, k.carriedBaseSurplus))
+		})
+	}
+}
+
+func TestMakeFirstBucketFrameWithCarriedSurplus(t *testing.T) {
+	now, _ := time.Parse(time.RFC3339, "2020-05-22T00:00:10Z")
+	p := makeTestSellTwapLevelProvider(0)
+	p.carriedBaseSurplus = 120.0
+	bucket, e := p.makeFirstBucketFrame(
+		now,
+		floorDate(now),
+		floorDate(now).Add(time.Minute-time.Nanosecond),
+		bucketID(0),
+		roundID(0),
+		1000.0,
+		&queries.DailyVolume{
+			BaseVol:  0.0,
+			QuoteVol: 0.0,
+		},
+	)
+	if !assert.NoError(t, e) {
+		return
+	}
+
+	// the surplus carried over from the previous day is distributed starting with the first bucket of the new day
+	assert.Equal(t, 120.0, bucket.totalBaseSurplusStart)
+	assert.Equal(t, p.firstDistributionOfBaseSurplus(120.0, 120), bucket.baseSurplusIncluded)
+	assert.Equal(t, 1000.0/120.0+bucket.baseSurplusIncluded, bucket.baseCapacity)
+	assert.Equal(t, 0.0, bucket.dayBaseSoldStart)
+}
+
 func TestFirstDistributionOfBaseSurplus(t *testing.T) {
 	testCases := []struct {
 		name                   string
@@ -739,3 +812,71 @@ func TestFinalizeBucket(t *testing.T) {
 		" DynamicBucketValues[isNew=true, isLast=true, roundID=16, dayBaseSold=5.00000000, dayBaseRemaining=995.00000000, baseSold=0.00000000, baseRemaining=8.33333333, bucketProgress=0.00%, bucketTimeElapsed=50.00%]]"
 	assert.Equal(t, wantString, bucket.String())
 }
+
+func TestGetLevelsWithManualClock(t *testing.T) {
+	dayBaseCapacity := 2400.0
+	f := volumeFilter{configValue: "/sell/base/", config: &VolumeFilterConfig{BaseAssetCapInBaseUnits: &dayBaseCapacity}}
+	startPf, _ := newFixedFeed("10.0")
+	clock := MakeManualClock(time.Date(2020, 3, 14, 22, 30, 0, 0, time.UTC))
+	lp, e := makeSellTwapLevelProvider(
+		startPf,
+		rateOffset{percentFirst: true},
+		model.MakeOrderConstraints(7, 7, 0.1),
+		[7]volumeFilter{f, f, f, f, f, f, f},
+		24,
+		3600,
+		0.05,
+		0.5,
+		0.1,
+		rand.New(rand.NewSource(1)),
+		clock,
+		false,
+		nil,
+		true,
+		nil,
+	)
+	if !assert.NoError(t, e) {
+		return
+	}
+	p := lp.(*sellTwapLevelProvider)
+	daySold := map[string]float64{}
+	p.dailyVolumeFn = func(volFilter volumeFilter, date string) (*queries.DailyVolume, error) {
+		return &queries.DailyVolume{BaseVol: daySold[date]}, nil
+	}
+
+	_, e = p.GetLevels(10000.0, 10000.0)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, bucketID(22), p.activeBucket.ID)
+
+	// a later round in the same bucket keeps the bucket
+	clock.Advance(20 * time.Minute)
+	_, e = p.GetLevels(10000.0, 10000.0)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, bucketID(22), p.activeBucket.ID)
+	assert.False(t, p.activeBucket.dynamicValues.isNew)
+
+	// bucket cutover at the top of the hour
+	clock.Set(time.Date(2020, 3, 14, 23, 0, 0, 0, time.UTC))
+	_, e = p.GetLevels(10000.0, 10000.0)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, bucketID(23), p.activeBucket.ID)
+	assert.True(t, p.activeBucket.dynamicValues.isNew)
+	assert.Equal(t, time.Date(2020, 3, 14, 23, 0, 0, 0, time.UTC), p.activeBucket.startTime)
+
+	// day boundary, the capacity that was not sold on the previous day is carried over to the new day
+	daySold["2020/03/14"] = 600.0
+	clock.Set(time.Date(2020, 3, 15, 0, 0, 1, 0, time.UTC))
+	_, e = p.GetLevels(10000.0, 10000.0)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, bucketID(0), p.activeBucket.ID)
+	assert.Equal(t, time.Date(2020, 3, 15, 0, 0, 0, 0, time.UTC), p.activeBucket.startTime)
+	assert.Equal(t, 1800.0, p.carriedBaseSurplus)
+}
@@ -2,7 +2,7 @@ package plugins
 
 import (
 	"fmt"
-	"time"
+	"math/rand"
 
 	hProtocol "github.com/stellar/go/protocols/horizon"
 	"github.com/stellar/kelp/api"
@@ -37,6 +37,9 @@ type sellTwapConfig struct {
 	DistributeSurplusOverRemainingIntervalsPercentCeiling float64               `valid:"-" toml:"DISTRIBUTE_SURPLUS_OVER_REMAINING_INTERVALS_PERCENT_CEILING"`
 	ExponentialSmoothingFactor                            float64               `valid:"-" toml:"EXPONENTIAL_SMOOTHING_FACTOR"`
 	MinChildOrderSizePercentOfParent                      float64               `valid:"-" toml:"MIN_CHILD_ORDER_SIZE_PERCENT_OF_PARENT"`
+	CarrySurplusAcrossDays                                bool                  `valid:"-" toml:"CARRY_SURPLUS_ACROSS_DAYS"`
+	HolidayCalendar                                       string                `valid:"-" toml:"HOLIDAY_CALENDAR"`
+	HolidayCapMultiplier                                  float64               `valid:"-" toml:"HOLIDAY_CAP_MULTIPLIER"`
 }
 
 // String impl.
@@ -53,6 +56,7 @@ func makeSellTwapStrategy(
 	assetQuote *hProtocol.Asset,
 	filterFactory *FilterFactory,
 	config *sellTwapConfig,
+	clock api.Clock,
 ) (api.Strategy, error) {
 	startPf, e := MakePriceFeed(config.StartAskFeedType, config.StartAskFeedURL)
 	if e != nil {
@@ -69,6 +73,10 @@ func makeSellTwapStrategy(
 	if e != nil {
 		return nil, fmt.Errorf("error when making dowFilter: %s", e)
 	}
+	holidayCalendar, e := makeHolidayCalendar(config.HolidayCalendar, config.HolidayCapMultiplier)
+	if e != nil {
+		return nil, fmt.Errorf("error when making the holiday calendar: %s", e)
+	}
 	levelProvider, e := makeSellTwapLevelProvider(
 		startPf,
 		offset,
@@ -79,8 +87,12 @@ func makeSellTwapStrategy(
 		config.DistributeSurplusOverRemainingIntervalsPercentCeiling,
 		config.ExponentialSmoothingFactor,
 		config.MinChildOrderSizePercentOfParent,
-		time.Now().UnixNano(),
+		rand.New(rand.NewSource(clock.Now().UnixNano())),
+		clock,
 		false,
+		nil,
+		config.CarrySurplusAcrossDays,
+		holidayCalendar,
 	)
 	if e != nil {
 		return nil, fmt.Errorf("error when making a sellTwapLevelProvider: %s", e)
@@ -155,6 +167,9 @@ func makeDowFilter(filterFactory *FilterFactory, dowDailyCap DayOfWeekFilterConf
 		if !ok {
 			return dowVolumeFilters, fmt.Errorf("could not cast %d-th filter to a volumeFilter", i)
 		}
+		if vf.config.getWindow() != volumeFilterWindowDaily {
+			return dowVolumeFilters, fmt.Errorf("the %d-th filter needs to use the \"daily\" window but was \"%s\"", i, vf.config.getWindow())
+		}
 		dowVolumeFilters[i] = *vf
 	}
 
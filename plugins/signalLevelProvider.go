@@ -0,0 +1,197 @@
+package plugins
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+)
+
+// signalCacheDuration is how long the evaluated signals are reused so both sides of the book use the same indicator values in an update cycle
+const signalCacheDuration = 30 * time.Second
+
+// signalRule adjusts the quotes on one or both sides when the value of an indicator crosses a threshold
+type signalRule struct {
+	spec             string
+	indicator        indicator
+	isAbove          bool
+	threshold        float64
+	applyToBuy       bool
+	applyToSell      bool
+	isPause          bool
+	spreadMultiplier float64
+}
+
+// matches returns true if the value of the indicator satisfies the condition of the rule
+func (r *signalRule) matches(value float64) bool {
+	if r.isAbove {
+		return value > r.threshold
+	}
+	return value < r.threshold
+}
+
+// signalAdjustment is the combined effect of all matching rules on one side of the book
+type signalAdjustment struct {
+	spreadMultiplier float64
+	paused           bool
+}
+
+// signalEvaluator computes the indicators from the latest candles and evaluates the rules
+type signalEvaluator struct {
+	fetcher   closePriceFetcher
+	rules     []*signalRule
+	numCloses int
+	nowFn     func() time.Time
+
+	// uninitialized
+	cachedAt   time.Time
+	cachedBuy  signalAdjustment
+	cachedSell signalAdjustment
+}
+
+// makeSignalEvaluator is a factory method
+func makeSignalEvaluator(fetcher closePriceFetcher, rules []*signalRule, nowFn func() time.Time) *signalEvaluator {
+	numCloses := 0
+	for _, r := range rules {
+		if r.indicator.minCloses() > numCloses {
+			numCloses = r.indicator.minCloses()
+		}
+	}
+	// fetch more history than the bare minimum so the smoothed averages used by the indicators converge
+	numCloses *= 3
+
+	return &signalEvaluator{
+		fetcher:   fetcher,
+		rules:     rules,
+		numCloses: numCloses,
+		nowFn:     nowFn,
+	}
+}
+
+// getAdjustment returns the adjustment for the requested side, fetching new candles when the cached values have expired
+func (s *signalEvaluator) getAdjustment(isBuySide bool) (signalAdjustment, error) {
+	now := s.nowFn()
+	if s.cachedAt.IsZero() || now.Sub(s.cachedAt) > signalCacheDuration {
+		closes, e := s.fetcher(s.numCloses)
+		if e != nil {
+			return signalAdjustment{}, fmt.Errorf("could not fetch close prices: %s", e)
+		}
+
+		buy, sell, e := s.evaluate(closes)
+		if e != nil {
+			return signalAdjustment{}, fmt.Errorf("could not evaluate signals: %s", e)
+		}
+		s.cachedAt = now
+		s.cachedBuy = buy
+		s.cachedSell = sell
+	}
+
+	if isBuySide {
+		return s.cachedBuy, nil
+	}
+	return s.cachedSell, nil
+}
+
+// evaluate applies all rules to the close prices and returns the adjustments for the buy and sell sides
+func (s *signalEvaluator) evaluate(closes []float64) (signalAdjustment /*buy*/, signalAdjustment /*sell*/, error) {
+	buy := signalAdjustment{spreadMultiplier: 1.0}
+	sell := signalAdjustment{spreadMultiplier: 1.0}
+	for _, r := range s.rules {
+		value, e := r.indicator.compute(closes)
+		if e != nil {
+			return signalAdjustment{}, signalAdjustment{}, fmt.Errorf("could not compute indicator '%s': %s", r.spec, e)
+		}
+
+		isMatch := r.matches(value)
+		log.Printf("signal: indicator=%s, value=%.8f, isAbove=%v, threshold=%.8f, isMatch=%v\n", r.spec, value, r.isAbove, r.threshold, isMatch)
+		if !isMatch {
+			continue
+		}
+
+		for _, a := range []struct {
+			apply      bool
+			adjustment *signalAdjustment
+		}{{r.applyToBuy, &buy}, {r.applyToSell, &sell}} {
+			if !a.apply {
+				continue
+			}
+			if r.isPause {
+				a.adjustment.paused = true
+			} else {
+				a.adjustment.spreadMultiplier *= r.spreadMultiplier
+			}
+		}
+	}
+	return buy, sell, nil
+}
+
+// signalLevelProvider provides levels around a mid price where the spreads are scaled, or quoting is paused, based on indicator signals.
+// It uses an inventorySkewLevelProvider without any skew to place the levels.
+type signalLevelProvider struct {
+	levels    *inventorySkewLevelProvider
+	evaluator *signalEvaluator
+	isBuySide bool
+}
+
+// ensure it implements the LevelProvider interface
+var _ api.LevelProvider = &signalLevelProvider{}
+
+// signalTargetBaseRatio is the target base ratio of the underlying inventorySkewLevelProvider, it has no effect since there is no skew
+const signalTargetBaseRatio = 0.5
+
+// makeSignalLevelProvider is a factory method
+func makeSignalLevelProvider(
+	pf *api.FeedPair,
+	offset rateOffset,
+	orderConstraints *model.OrderConstraints,
+	evaluator *signalEvaluator,
+	levelCount int,
+	spread float64,
+	levelSpreadIncrement float64,
+	amountOfBase float64,
+	isBuySide bool,
+) (api.LevelProvider, error) {
+	levels, e := makeInventorySkewLevelProvider(
+		pf,
+		offset,
+		orderConstraints,
+		signalTargetBaseRatio,
+		0.0,
+		levelCount,
+		spread,
+		levelSpreadIncrement,
+		amountOfBase,
+		isBuySide,
+	)
+	if e != nil {
+		return nil, e
+	}
+
+	return &signalLevelProvider{
+		levels:    levels.(*inventorySkewLevelProvider),
+		evaluator: evaluator,
+		isBuySide: isBuySide,
+	}, nil
+}
+
+// GetLevels impl.
+func (p *signalLevelProvider) GetLevels(maxAssetBase float64, maxAssetQuote float64) ([]api.Level, error) {
+	adjustment, e := p.evaluator.getAdjustment(p.isBuySide)
+	if e != nil {
+		return nil, fmt.Errorf("could not get signal adjustment: %s", e)
+	}
+	log.Printf("signalLevelProvider: isBuySide=%v, paused=%v, spreadMultiplier=%.4f\n", p.isBuySide, adjustment.paused, adjustment.spreadMultiplier)
+	if adjustment.paused {
+		// returning no levels removes all existing offers on this side
+		return []api.Level{}, nil
+	}
+
+	return p.levels.getLevelsWithSpreadMultiplier(maxAssetBase, maxAssetQuote, adjustment.spreadMultiplier)
+}
+
+// GetFillHandlers impl
+func (p *signalLevelProvider) GetFillHandlers() ([]api.FillHandler, error) {
+	return nil, nil
+}
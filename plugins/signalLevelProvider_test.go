@@ -0,0 +1,131 @@
+package plugins
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+)
+
+func TestSignalEvaluatorEvaluate(t *testing.T) {
+	rising := []float64{1, 2, 3, 4, 5}
+	testCases := []struct {
+		name     string
+		rules    []signalRuleConfig
+		wantBuy  signalAdjustment
+		wantSell signalAdjustment
+	}{
+		{
+			name: "no matching rules",
+			rules: []signalRuleConfig{
+				{Indicator: "rsi/3", Condition: "below", Threshold: 30.0, Side: "both", Action: "pause"},
+			},
+			wantBuy:  signalAdjustment{spreadMultiplier: 1.0},
+			wantSell: signalAdjustment{spreadMultiplier: 1.0},
+		}, {
+			name: "pause buy side",
+			rules: []signalRuleConfig{
+				{Indicator: "rsi/3", Condition: "above", Threshold: 70.0, Side: "buy", Action: "pause"},
+			},
+			wantBuy:  signalAdjustment{spreadMultiplier: 1.0, paused: true},
+			wantSell: signalAdjustment{spreadMultiplier: 1.0},
+		}, {
+			name: "multipliers are combined",
+			rules: []signalRuleConfig{
+				{Indicator: "rsi/3", Condition: "above", Threshold: 70.0, Side: "both", Action: "scale_spread", SpreadMultiplier: 2.0},
+				{Indicator: "bollinger/5/1", Condition: "above", Threshold: 1.0, Side: "sell", Action: "scale_spread", SpreadMultiplier: 0.25},
+			},
+			wantBuy:  signalAdjustment{spreadMultiplier: 2.0},
+			wantSell: signalAdjustment{spreadMultiplier: 0.5},
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			rules, e := makeSignalRules(k.rules)
+			if !assert.NoError(t, e) {
+				return
+			}
+			s := makeSignalEvaluator(nil, rules, time.Now)
+
+			buy, sell, e := s.evaluate(rising)
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, k.wantBuy, buy)
+			assert.Equal(t, k.wantSell, sell)
+		})
+	}
+}
+
+func TestMakeSignalRulesInvalid(t *testing.T) {
+	testCases := []struct {
+		name  string
+		rules []signalRuleConfig
+	}{
+		{name: "no rules", rules: []signalRuleConfig{}},
+		{name: "bad indicator", rules: []signalRuleConfig{{Indicator: "foo/1", Condition: "above", Side: "both", Action: "pause"}}},
+		{name: "bad condition", rules: []signalRuleConfig{{Indicator: "rsi/14", Condition: "equal", Side: "both", Action: "pause"}}},
+		{name: "bad side", rules: []signalRuleConfig{{Indicator: "rsi/14", Condition: "above", Side: "none", Action: "pause"}}},
+		{name: "bad action", rules: []signalRuleConfig{{Indicator: "rsi/14", Condition: "above", Side: "both", Action: "stop"}}},
+		{name: "missing multiplier", rules: []signalRuleConfig{{Indicator: "rsi/14", Condition: "above", Side: "both", Action: "scale_spread"}}},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			_, e := makeSignalRules(k.rules)
+			assert.Error(t, e)
+		})
+	}
+}
+
+func TestSignalLevelProviderGetLevels(t *testing.T) {
+	pf := &api.FeedPair{
+		FeedA: &fixedFeed{price: 2.0},
+		FeedB: &fixedFeed{price: 1.0},
+	}
+	orderConstraints := model.MakeOrderConstraints(7, 7, 1.0)
+	rules, e := makeSignalRules([]signalRuleConfig{
+		{Indicator: "rsi/3", Condition: "above", Threshold: 70.0, Side: "buy", Action: "pause"},
+		{Indicator: "rsi/3", Condition: "above", Threshold: 70.0, Side: "sell", Action: "scale_spread", SpreadMultiplier: 2.0},
+	})
+	if !assert.NoError(t, e) {
+		return
+	}
+	numFetches := 0
+	fetcher := func(limit int) ([]float64, error) {
+		numFetches++
+		return []float64{1, 2, 3, 4, 5}, nil
+	}
+	evaluator := makeSignalEvaluator(fetcher, rules, time.Now)
+
+	sellProvider, e := makeSignalLevelProvider(pf, rateOffset{}, orderConstraints, evaluator, 2, 0.01, 0.01, 10.0, false)
+	if !assert.NoError(t, e) {
+		return
+	}
+	levels, e := sellProvider.GetLevels(100.0, 100.0)
+	if !assert.NoError(t, e) {
+		return
+	}
+	if assert.Equal(t, 2, len(levels)) {
+		assert.InDelta(t, 2.04, levels[0].Price.AsFloat(), 0.0000001)
+		assert.InDelta(t, 2.08, levels[1].Price.AsFloat(), 0.0000001)
+		assert.Equal(t, 10.0, levels[0].Amount.AsFloat())
+	}
+
+	buyProvider, e := makeSignalLevelProvider(pf, rateOffset{}, orderConstraints, evaluator, 2, 0.01, 0.01, 10.0, true)
+	if !assert.NoError(t, e) {
+		return
+	}
+	levels, e = buyProvider.GetLevels(100.0, 100.0)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, 0, len(levels))
+
+	// both sides use the cached signals within the same update cycle
+	assert.Equal(t, 1, numFetches)
+}
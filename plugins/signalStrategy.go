@@ -0,0 +1,206 @@
+package plugins
+
+import (
+	"fmt"
+	"time"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// signalRuleConfig contains the configuration params for a single rule of the signal strategy
+type signalRuleConfig struct {
+	Indicator        string  `valid:"-" toml:"INDICATOR"`
+	Condition        string  `valid:"-" toml:"CONDITION"`
+	Threshold        float64 `valid:"-" toml:"THRESHOLD"`
+	Side             string  `valid:"-" toml:"SIDE"`
+	Action           string  `valid:"-" toml:"ACTION"`
+	SpreadMultiplier float64 `valid:"-" toml:"SPREAD_MULTIPLIER"`
+}
+
+// signalConfig contains the configuration params for this strategy
+type signalConfig struct {
+	PriceTolerance         float64            `valid:"-" toml:"PRICE_TOLERANCE"`
+	AmountTolerance        float64            `valid:"-" toml:"AMOUNT_TOLERANCE"`
+	RateOffsetPercent      float64            `valid:"-" toml:"RATE_OFFSET_PERCENT"`
+	RateOffset             float64            `valid:"-" toml:"RATE_OFFSET"`
+	RateOffsetPercentFirst bool               `valid:"-" toml:"RATE_OFFSET_PERCENT_FIRST"`
+	DataTypeA              string             `valid:"-" toml:"DATA_TYPE_A"`
+	DataFeedAURL           string             `valid:"-" toml:"DATA_FEED_A_URL"`
+	DataTypeB              string             `valid:"-" toml:"DATA_TYPE_B"`
+	DataFeedBURL           string             `valid:"-" toml:"DATA_FEED_B_URL"`
+	CandleSource           string             `valid:"-" toml:"CANDLE_SOURCE"`
+	CandleTradingPair      string             `valid:"-" toml:"CANDLE_TRADING_PAIR"`
+	CandleTimeframe        string             `valid:"-" toml:"CANDLE_TIMEFRAME"`
+	LevelCount             int                `valid:"-" toml:"LEVEL_COUNT"`
+	Spread                 float64            `valid:"-" toml:"SPREAD"`
+	LevelSpreadIncrement   float64            `valid:"-" toml:"LEVEL_SPREAD_INCREMENT"`
+	AmountOfABase          float64            `valid:"-" toml:"AMOUNT_OF_A_BASE"` // the size of order to keep on each level
+	Rules                  []signalRuleConfig `valid:"-" toml:"RULES"`
+}
+
+// String impl.
+func (c signalConfig) String() string {
+	return utils.StructString(c, 0, nil)
+}
+
+// makeSignalRules converts the rules from the config into signalRules
+func makeSignalRules(configs []signalRuleConfig) ([]*signalRule, error) {
+	if len(configs) == 0 {
+		return nil, fmt.Errorf("need to specify at least one rule in the RULES section of the signal strategy config")
+	}
+
+	rules := []*signalRule{}
+	for i, c := range configs {
+		ind, e := makeIndicator(c.Indicator)
+		if e != nil {
+			return nil, fmt.Errorf("invalid INDICATOR for rule at index %d: %s", i, e)
+		}
+
+		r := &signalRule{
+			spec:             c.Indicator,
+			indicator:        ind,
+			threshold:        c.Threshold,
+			spreadMultiplier: c.SpreadMultiplier,
+		}
+
+		switch c.Condition {
+		case "above":
+			r.isAbove = true
+		case "below":
+			r.isAbove = false
+		default:
+			return nil, fmt.Errorf("invalid CONDITION '%s' for rule at index %d, needs to be either 'above' or 'below'", c.Condition, i)
+		}
+
+		switch c.Side {
+		case "buy":
+			r.applyToBuy = true
+		case "sell":
+			r.applyToSell = true
+		case "both":
+			r.applyToBuy = true
+			r.applyToSell = true
+		default:
+			return nil, fmt.Errorf("invalid SIDE '%s' for rule at index %d, needs to be one of 'buy', 'sell', or 'both'", c.Side, i)
+		}
+
+		switch c.Action {
+		case "pause":
+			r.isPause = true
+		case "scale_spread":
+			if c.SpreadMultiplier <= 0.0 {
+				return nil, fmt.Errorf("invalid SPREAD_MULTIPLIER for rule at index %d, needs to be > 0.0 when ACTION is 'scale_spread'; was %f", i, c.SpreadMultiplier)
+			}
+		default:
+			return nil, fmt.Errorf("invalid ACTION '%s' for rule at index %d, needs to be either 'scale_spread' or 'pause'", c.Action, i)
+		}
+
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+// makeSignalStrategy is a factory method
+func makeSignalStrategy(
+	sdex *SDEX,
+	pair *model.TradingPair,
+	ieif *IEIF,
+	assetBase *hProtocol.Asset,
+	assetQuote *hProtocol.Asset,
+	config *signalConfig,
+) (api.Strategy, error) {
+	offset := rateOffset{
+		percent:      config.RateOffsetPercent,
+		absolute:     config.RateOffset,
+		percentFirst: config.RateOffsetPercentFirst,
+	}
+	feedPair, e := MakeFeedPair(
+		config.DataTypeA,
+		config.DataFeedAURL,
+		config.DataTypeB,
+		config.DataFeedBURL,
+	)
+	if e != nil {
+		return nil, fmt.Errorf("cannot make the signal strategy because we could not make the feed pair: %s", e)
+	}
+
+	rules, e := makeSignalRules(config.Rules)
+	if e != nil {
+		return nil, fmt.Errorf("cannot make the signal strategy because of invalid rules: %s", e)
+	}
+
+	var fetcher closePriceFetcher
+	if config.CandleSource == "sdex" {
+		fetcher, e = makeSdexClosePriceFetcher(sdex.API, assetBase, assetQuote, config.CandleTimeframe)
+	} else {
+		fetcher, e = makeCcxtClosePriceFetcher(config.CandleSource, config.CandleTradingPair, config.CandleTimeframe)
+	}
+	if e != nil {
+		return nil, fmt.Errorf("cannot make the signal strategy because we could not make the candle source: %s", e)
+	}
+	evaluator := makeSignalEvaluator(fetcher, rules, time.Now)
+	orderConstraints := sdex.GetOrderConstraints(pair)
+
+	sellLevelProvider, e := makeSignalLevelProvider(
+		feedPair,
+		offset,
+		orderConstraints,
+		evaluator,
+		config.LevelCount,
+		config.Spread,
+		config.LevelSpreadIncrement,
+		config.AmountOfABase,
+		false,
+	)
+	if e != nil {
+		return nil, fmt.Errorf("cannot make the sell side level provider: %s", e)
+	}
+	sellSideStrategy := makeSellSideStrategy(
+		sdex,
+		orderConstraints,
+		ieif,
+		assetBase,
+		assetQuote,
+		sellLevelProvider,
+		config.PriceTolerance,
+		config.AmountTolerance,
+		false,
+	)
+
+	buyLevelProvider, e := makeSignalLevelProvider(
+		feedPair,
+		offset,
+		orderConstraints,
+		evaluator,
+		config.LevelCount,
+		config.Spread,
+		config.LevelSpreadIncrement,
+		config.AmountOfABase,
+		true,
+	)
+	if e != nil {
+		return nil, fmt.Errorf("cannot make the buy side level provider: %s", e)
+	}
+	// switch sides of base/quote here for buy side
+	buySideStrategy := makeSellSideStrategy(
+		sdex,
+		orderConstraints,
+		ieif,
+		assetQuote,
+		assetBase,
+		buyLevelProvider,
+		config.PriceTolerance,
+		config.AmountTolerance,
+		true,
+	)
+
+	return makeComposeStrategy(
+		assetBase,
+		assetQuote,
+		buySideStrategy,
+		sellSideStrategy,
+	), nil
+}
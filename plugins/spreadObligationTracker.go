@@ -0,0 +1,214 @@
+package plugins
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/kelpdb"
+	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/support/postgresdb"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// spreadObligationOrderbookDepth is the number of levels fetched on each side of the orderbook, which needs to be deeper than the levels
+// that only hold our own offers so the reference price can be computed from the other participants
+const spreadObligationOrderbookDepth = 50
+
+// SpreadObligationSample is the depth that the bot quoted within the allowed spread of the reference price at a point in time
+type SpreadObligationSample struct {
+	ReferencePrice float64
+	BidDepth       float64 // base volume of the buy offers priced at or above referencePrice * (1 - maxSpreadBps/10000)
+	AskDepth       float64 // base volume of the sell offers priced at or below referencePrice * (1 + maxSpreadBps/10000)
+}
+
+// String is the Stringer method
+func (s *SpreadObligationSample) String() string {
+	return fmt.Sprintf("SpreadObligationSample[referencePrice=%.8f, bidDepth=%.8f, askDepth=%.8f]", s.ReferencePrice, s.BidDepth, s.AskDepth)
+}
+
+// SpreadObligationTracker measures how much of the time the bot keeps quotes that meet the terms of a market-making agreement, where each
+// side of the book needs at least minDepth of the base asset quoted within maxSpreadBps of the reference price (the mid price of the
+// orderbook). Every update cycle writes a sample to the SQL database that covers the time since the previous sample, so the share of time
+// that the terms were met can be reported for any time range.
+type SpreadObligationTracker struct {
+	db                *sql.DB
+	orderbookFetcher  api.OrderbookFetcher
+	pair              *model.TradingPair
+	accountID         string
+	marketID          string
+	maxSpreadBps      float64
+	minDepth          float64
+	maxSampleDuration time.Duration // caps the time covered by a sample so the time the bot was not running is not counted as covered
+
+	// uninitialized
+	lastSampleTime *time.Time
+}
+
+// MakeSpreadObligationTracker is a factory method
+func MakeSpreadObligationTracker(
+	db *sql.DB,
+	orderbookFetcher api.OrderbookFetcher,
+	pair *model.TradingPair,
+	accountID string,
+	marketID string,
+	maxSpreadBps float64,
+	minDepth float64,
+	maxSampleDuration time.Duration,
+) (*SpreadObligationTracker, error) {
+	if db == nil {
+		return nil, fmt.Errorf("db should not be nil when tracking spread obligations")
+	}
+	if accountID == "" {
+		return nil, fmt.Errorf("accountID should not be empty when tracking spread obligations")
+	}
+	if maxSpreadBps <= 0.0 {
+		return nil, fmt.Errorf("invalid max spread, expected maxSpreadBps > 0.0; was %f", maxSpreadBps)
+	}
+	if minDepth < 0.0 {
+		return nil, fmt.Errorf("invalid min depth, expected minDepth >= 0.0; was %f", minDepth)
+	}
+	if maxSampleDuration <= 0 {
+		return nil, fmt.Errorf("invalid max sample duration, expected maxSampleDuration > 0; was %s", maxSampleDuration)
+	}
+
+	return &SpreadObligationTracker{
+		db:                db,
+		orderbookFetcher:  orderbookFetcher,
+		pair:              pair,
+		accountID:         accountID,
+		marketID:          marketID,
+		maxSpreadBps:      maxSpreadBps,
+		minDepth:          minDepth,
+		maxSampleDuration: maxSampleDuration,
+	}, nil
+}
+
+// Record measures the bot's offers against the terms and writes a sample covering the time since the previous sample
+func (t *SpreadObligationTracker) Record(now time.Time, sellingAOffers []hProtocol.Offer, buyingAOffers []hProtocol.Offer) error {
+	duration := sampleDuration(t.lastSampleTime, now, t.maxSampleDuration)
+	t.lastSampleTime = &now
+
+	ob, e := t.orderbookFetcher.GetOrderBook(t.pair, spreadObligationOrderbookDepth)
+	if e != nil {
+		return fmt.Errorf("could not fetch orderbook to get the reference price: %s", e)
+	}
+	// our own offers are excluded so the reference price does not move with our quotes
+	topBid := topPriceExcludingOwnVolume(ob.Bids(), ownBidVolumeByPrice(buyingAOffers))
+	topAsk := topPriceExcludingOwnVolume(ob.Asks(), ownAskVolumeByPrice(sellingAOffers))
+	referencePrice := 0.0
+	if topBid != nil && topAsk != nil {
+		referencePrice = (*topBid + *topAsk) / 2
+	} else {
+		// without a mid price the sample is recorded with zero depth, so the terms are not met
+		log.Printf("spread obligation: orderbook does not have both a bid and an ask from other accounts, cannot compute the reference price\n")
+	}
+
+	sample := computeSpreadObligationSample(referencePrice, sellingAOffers, buyingAOffers, t.maxSpreadBps)
+	bidMet := sample.BidDepth >= t.minDepth && referencePrice > 0
+	askMet := sample.AskDepth >= t.minDepth && referencePrice > 0
+	log.Printf("spread obligation: %s, bidMet=%v, askMet=%v, covering %s\n", sample, bidMet, askMet, duration)
+
+	sqlInsert := fmt.Sprintf(kelpdb.SqlSpreadObligationSamplesInsertTemplate,
+		t.accountID,
+		t.marketID,
+		now.UTC().Format(postgresdb.TimestampFormatString),
+		duration.Seconds(),
+		sample.ReferencePrice,
+		t.maxSpreadBps,
+		t.minDepth,
+		sample.BidDepth,
+		sample.AskDepth,
+		bidMet,
+		askMet,
+	)
+	_, e = t.db.Exec(sqlInsert)
+	if e != nil {
+		return fmt.Errorf("could not insert spread obligation sample: %s", e)
+	}
+	return nil
+}
+
+// topPriceExcludingOwnVolume returns the price of the first level that has volume left after removing our own volume at that price, nil
+// when every level only holds our own offers
+func topPriceExcludingOwnVolume(levels []model.Order, ownVolumeByPrice map[string]float64) *float64 {
+	for _, level := range levels {
+		remaining := level.Volume.AsFloat() - ownVolumeByPrice[level.Price.AsString()]
+		// the volumes are rounded to the precision of the network so leave room for rounding errors
+		if remaining > math.Pow(10, -float64(sdexOrderConstraints.VolumePrecision)) {
+			price := level.Price.AsFloat()
+			return &price
+		}
+	}
+	return nil
+}
+
+// ownAskVolumeByPrice sums the base volume of our sell offers keyed by the price of the orderbook level that they are in
+func ownAskVolumeByPrice(sellingAOffers []hProtocol.Offer) map[string]float64 {
+	m := map[string]float64{}
+	for _, o := range sellingAOffers {
+		price := model.NumberFromFloat(utils.PriceAsFloat(o.Price), sdexOrderConstraints.PricePrecision).AsString()
+		m[price] += utils.AmountStringAsFloat(o.Amount)
+	}
+	return m
+}
+
+// ownBidVolumeByPrice sums the base volume of our buy offers keyed by the price of the orderbook level that they are in, the offers are
+// priced in units of the base asset (the inverse price) and their amount is in units of the quote asset
+func ownBidVolumeByPrice(buyingAOffers []hProtocol.Offer) map[string]float64 {
+	m := map[string]float64{}
+	for _, o := range buyingAOffers {
+		invertedPrice := utils.PriceAsFloat(o.Price)
+		if invertedPrice <= 0 {
+			continue
+		}
+		price := model.NumberFromFloat(1/invertedPrice, sdexOrderConstraints.PricePrecision).AsString()
+		m[price] += utils.AmountStringAsFloat(o.Amount) * invertedPrice
+	}
+	return m
+}
+
+// sampleDuration is the time covered by a sample, the first sample of a run covers no time because we do not know how long the offers
+// that we find at startup have been on the book
+func sampleDuration(lastSampleTime *time.Time, now time.Time, maxSampleDuration time.Duration) time.Duration {
+	if lastSampleTime == nil || now.Before(*lastSampleTime) {
+		return 0
+	}
+	duration := now.Sub(*lastSampleTime)
+	if duration > maxSampleDuration {
+		return maxSampleDuration
+	}
+	return duration
+}
+
+// computeSpreadObligationSample sums the base volume of the offers that are priced within maxSpreadBps of the reference price, where
+// sellingAOffers are priced in units of the quote asset and buyingAOffers are priced in units of the base asset (the inverse price)
+func computeSpreadObligationSample(referencePrice float64, sellingAOffers []hProtocol.Offer, buyingAOffers []hProtocol.Offer, maxSpreadBps float64) *SpreadObligationSample {
+	sample := &SpreadObligationSample{ReferencePrice: referencePrice}
+	if referencePrice <= 0 {
+		return sample
+	}
+
+	maxAskPrice := referencePrice * (1 + maxSpreadBps/10000)
+	minBidPrice := referencePrice * (1 - maxSpreadBps/10000)
+	for _, o := range sellingAOffers {
+		if utils.PriceAsFloat(o.Price) <= maxAskPrice {
+			sample.AskDepth += utils.AmountStringAsFloat(o.Amount)
+		}
+	}
+	for _, o := range buyingAOffers {
+		invertedPrice := utils.PriceAsFloat(o.Price)
+		if invertedPrice <= 0 {
+			continue
+		}
+		// the amount of a buy offer is in units of the quote asset that it is selling
+		if 1/invertedPrice >= minBidPrice {
+			sample.BidDepth += utils.AmountStringAsFloat(o.Amount) * invertedPrice
+		}
+	}
+	return sample
+}
@@ -0,0 +1,143 @@
+package plugins
+
+import (
+	"testing"
+	"time"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/kelp/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComputeSpreadObligationSample(t *testing.T) {
+	// buying offers are priced in units of the base asset and their amount is in units of the quote asset
+	sellingAOffers := []hProtocol.Offer{
+		{Price: "0.1004", Amount: "600"},
+		{Price: "0.1010", Amount: "1000"},
+	}
+	buyingAOffers := []hProtocol.Offer{
+		{Price: "10", Amount: "50"},   // buys 500 base at 0.1
+		{Price: "12.5", Amount: "40"}, // buys 500 base at 0.08
+	}
+
+	testCases := []struct {
+		name           string
+		referencePrice float64
+		maxSpreadBps   float64
+		wantBidDepth   float64
+		wantAskDepth   float64
+	}{
+		{
+			name:           "offers inside and outside the spread",
+			referencePrice: 0.1,
+			maxSpreadBps:   50,
+			wantBidDepth:   500,
+			wantAskDepth:   600,
+		}, {
+			name:           "wide spread includes all offers",
+			referencePrice: 0.1,
+			maxSpreadBps:   2500,
+			wantBidDepth:   1000,
+			wantAskDepth:   1600,
+		}, {
+			name:           "reference price moved up",
+			referencePrice: 0.102,
+			maxSpreadBps:   50,
+			wantBidDepth:   0,
+			wantAskDepth:   1600,
+		}, {
+			name:           "no reference price",
+			referencePrice: 0,
+			maxSpreadBps:   50,
+			wantBidDepth:   0,
+			wantAskDepth:   0,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			sample := computeSpreadObligationSample(k.referencePrice, sellingAOffers, buyingAOffers, k.maxSpreadBps)
+			assert.Equal(t, k.referencePrice, sample.ReferencePrice)
+			assert.InDelta(t, k.wantBidDepth, sample.BidDepth, 0.0000001)
+			assert.InDelta(t, k.wantAskDepth, sample.AskDepth, 0.0000001)
+		})
+	}
+}
+
+func TestSampleDuration(t *testing.T) {
+	last := time.Unix(1000, 0)
+	maxSampleDuration := 10 * time.Second
+
+	testCases := []struct {
+		name           string
+		lastSampleTime *time.Time
+		now            time.Time
+		want           time.Duration
+	}{
+		{
+			name:           "first sample",
+			lastSampleTime: nil,
+			now:            last,
+			want:           0,
+		}, {
+			name:           "within max",
+			lastSampleTime: &last,
+			now:            last.Add(5 * time.Second),
+			want:           5 * time.Second,
+		}, {
+			name:           "capped at max",
+			lastSampleTime: &last,
+			now:            last.Add(30 * time.Second),
+			want:           maxSampleDuration,
+		}, {
+			name:           "clock moved backwards",
+			lastSampleTime: &last,
+			now:            last.Add(-5 * time.Second),
+			want:           0,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			assert.Equal(t, k.want, sampleDuration(k.lastSampleTime, k.now, maxSampleDuration))
+		})
+	}
+}
+
+func TestTopPriceExcludingOwnVolume(t *testing.T) {
+	asks := []model.Order{
+		{Price: model.NumberFromFloat(0.1004, 7), Volume: model.NumberFromFloat(600, 7)},
+		{Price: model.NumberFromFloat(0.1010, 7), Volume: model.NumberFromFloat(1500, 7)},
+	}
+	bids := []model.Order{
+		{Price: model.NumberFromFloat(0.1, 7), Volume: model.NumberFromFloat(500, 7)},
+		{Price: model.NumberFromFloat(0.08, 7), Volume: model.NumberFromFloat(700, 7)},
+	}
+	sellingAOffers := []hProtocol.Offer{
+		{Price: "0.1004", Amount: "600"},
+		{Price: "0.1010", Amount: "1000"},
+	}
+	buyingAOffers := []hProtocol.Offer{
+		{Price: "10", Amount: "50"},   // buys 500 base at 0.1
+		{Price: "12.5", Amount: "40"}, // buys 500 base at 0.08
+	}
+
+	// the top levels only hold our own offers so the next levels are used
+	topAsk := topPriceExcludingOwnVolume(asks, ownAskVolumeByPrice(sellingAOffers))
+	if assert.NotNil(t, topAsk) {
+		assert.InDelta(t, 0.1010, *topAsk, 0.0000001)
+	}
+	topBid := topPriceExcludingOwnVolume(bids, ownBidVolumeByPrice(buyingAOffers))
+	if assert.NotNil(t, topBid) {
+		assert.InDelta(t, 0.08, *topBid, 0.0000001)
+	}
+
+	// without our offers the top levels are used
+	topAsk = topPriceExcludingOwnVolume(asks, ownAskVolumeByPrice(nil))
+	if assert.NotNil(t, topAsk) {
+		assert.InDelta(t, 0.1004, *topAsk, 0.0000001)
+	}
+
+	// nil when every level only holds our own offers
+	assert.Nil(t, topPriceExcludingOwnVolume(bids[:1], ownBidVolumeByPrice(buyingAOffers)))
+}
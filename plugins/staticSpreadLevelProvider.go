@@ -13,6 +13,20 @@ import (
 type StaticLevel struct {
 	SPREAD float64 `valid:"-" json:"spread"`
 	AMOUNT float64 `valid:"-" json:"amount"`
+	FEED   string  `valid:"-" toml:"FEED,omitempty" json:"feed,omitempty"` // name of the price feed that the spread is applied to, empty uses the default feed
+}
+
+// defaultLevelFeed is the name of the price feed used by levels that do not set a FEED
+const defaultLevelFeed = ""
+
+// validateLevelFeeds returns an error if a level references a price feed that is not in feeds
+func validateLevelFeeds(levels []StaticLevel, feeds map[string]*api.FeedPair) error {
+	for i, sl := range levels {
+		if _, ok := feeds[sl.FEED]; !ok {
+			return fmt.Errorf("level %d references the price feed '%s' which is not defined in FEEDS", i, sl.FEED)
+		}
+	}
+	return nil
 }
 
 // how much to offset your rates by. Can use percent and offset together.
@@ -33,54 +47,152 @@ type rateOffset struct {
 	invert bool
 }
 
+// amountUnit is the unit in which the AMOUNT of each StaticLevel is specified
+type amountUnit string
+
+// type of amountUnit
+const (
+	// amountUnitBase is a multiple of AMOUNT_OF_A_BASE in units of the base asset
+	amountUnitBase amountUnit = "base"
+	// amountUnitQuote is a multiple of AMOUNT_OF_A_BASE in units of the quote asset, converted to the base asset at the price of each level
+	amountUnitQuote amountUnit = "quote"
+	// amountUnitBaseBalancePercent is a fraction of the current balance of the base asset specified as a decimal (ex: 0.05 = 5%)
+	amountUnitBaseBalancePercent amountUnit = "base_balance_percent"
+)
+
+// String is the Stringer method impl
+func (u amountUnit) String() string {
+	return string(u)
+}
+
 // staticSpreadLevelProvider provides a fixed number of levels using a static percentage spread
 type staticSpreadLevelProvider struct {
 	staticLevels     []StaticLevel
 	amountOfBase     float64
+	amountUnit       amountUnit
 	offset           rateOffset
-	pf               *api.FeedPair
+	feeds            map[string]*api.FeedPair // keyed by the FEED of the levels, the default feed is keyed by defaultLevelFeed
 	orderConstraints *model.OrderConstraints
+	isBuySide        bool
+	bootstrap        *bootstrapSpread // widens the spreads on a new market, can be nil
 }
 
 // ensure it implements the LevelProvider interface
 var _ api.LevelProvider = &staticSpreadLevelProvider{}
 
-// makeStaticSpreadLevelProvider is a factory method
-func makeStaticSpreadLevelProvider(staticLevels []StaticLevel, amountOfBase float64, offset rateOffset, pf *api.FeedPair, orderConstraints *model.OrderConstraints) api.LevelProvider {
+// makeStaticSpreadLevelProvider is a factory method, levelAmountUnit specifies how the level amounts are denominated and feeds needs to
+// contain the price feed of every level (see validateLevelFeeds), bootstrap can be nil
+func makeStaticSpreadLevelProvider(
+	staticLevels []StaticLevel,
+	amountOfBase float64,
+	levelAmountUnit amountUnit,
+	offset rateOffset,
+	feeds map[string]*api.FeedPair,
+	orderConstraints *model.OrderConstraints,
+	isBuySide bool,
+	bootstrap *bootstrapSpread,
+) api.LevelProvider {
 	return &staticSpreadLevelProvider{
 		staticLevels:     staticLevels,
 		amountOfBase:     amountOfBase,
+		amountUnit:       levelAmountUnit,
 		offset:           offset,
-		pf:               pf,
+		feeds:            feeds,
 		orderConstraints: orderConstraints,
+		isBuySide:        isBuySide,
+		bootstrap:        bootstrap,
+	}
+}
+
+// parseAmountUnit converts a string to an amountUnit, defaulting to the base asset when unset
+func parseAmountUnit(unit string) (amountUnit, error) {
+	switch unit {
+	case "", amountUnitBase.String():
+		return amountUnitBase, nil
+	case amountUnitQuote.String():
+		return amountUnitQuote, nil
+	case amountUnitBaseBalancePercent.String():
+		return amountUnitBaseBalancePercent, nil
+	default:
+		return amountUnitBase, fmt.Errorf("invalid AMOUNT_UNIT '%s', needs to be one of '%s', '%s', or '%s'", unit, amountUnitBase, amountUnitQuote, amountUnitBaseBalancePercent)
 	}
 }
 
 // GetLevels impl.
 func (p *staticSpreadLevelProvider) GetLevels(maxAssetBase float64, maxAssetQuote float64) ([]api.Level, error) {
-	midPrice, e := p.pf.GetFeedPairPrice()
-	if e != nil {
-		return nil, fmt.Errorf("mid price couldn't be loaded: %s", e)
-	}
-	midPrice, wasModified := p.offset.apply(midPrice)
-	if wasModified {
-		log.Printf("mid price (adjusted): %.7f\n", midPrice)
+	// each feed is only loaded once per update even when it is used by many levels
+	midPrices := map[string]float64{}
+	spreadMultiplier := p.bootstrap.multiplier()
+	if spreadMultiplier != 1.0 {
+		log.Printf("bootstrap: multiplying the spread of every level by %.4f\n", spreadMultiplier)
 	}
-
 	levels := []api.Level{}
 	for _, sl := range p.staticLevels {
-		absoluteSpread := midPrice * sl.SPREAD
+		midPrice, ok := midPrices[sl.FEED]
+		if !ok {
+			var e error
+			midPrice, e = p.getMidPrice(sl.FEED)
+			if e != nil {
+				return nil, e
+			}
+			midPrices[sl.FEED] = midPrice
+		}
+
+		absoluteSpread := midPrice * sl.SPREAD * spreadMultiplier
+		// we always add here because it is only used in the context of selling so we always charge a higher price to include a spread
+		price := midPrice + absoluteSpread
+		amount := sl.AMOUNT * p.amountOfBase
+		if p.amountUnit == amountUnitBaseBalancePercent {
+			// maxAssetBase is the balance as of this update cycle so the levels shrink as the balance is depleted
+			amount = sl.AMOUNT * maxAssetBase
+		} else if p.amountUnit == amountUnitQuote {
+			// convert to base units at the price of this level so the value of each level stays the same when the price of the base asset moves.
+			// the buy side quotes the price inverted (in units of base per quote) so we multiply instead of divide
+			if p.isBuySide {
+				amount = amount * price
+			} else {
+				amount = amount / price
+			}
+		}
+
 		levels = append(levels, api.Level{
-			// we always add here because it is only used in the context of selling so we always charge a higher price to include a spread
-			Price:  *model.NumberFromFloat(midPrice+absoluteSpread, p.orderConstraints.PricePrecision),
-			Amount: *model.NumberFromFloat(sl.AMOUNT*p.amountOfBase, p.orderConstraints.VolumePrecision),
+			Price:  *model.NumberFromFloat(price, p.orderConstraints.PricePrecision),
+			Amount: *model.NumberFromFloat(amount, p.orderConstraints.VolumePrecision),
 		})
 	}
 	return levels, nil
 }
 
+// getMidPrice returns the price of the named feed adjusted by the rate offset
+func (p *staticSpreadLevelProvider) getMidPrice(feedName string) (float64, error) {
+	pf, ok := p.feeds[feedName]
+	if !ok {
+		return 0, fmt.Errorf("no price feed named '%s'", feedName)
+	}
+	midPrice, e := pf.GetFeedPairPrice()
+	if e != nil {
+		if feedName != defaultLevelFeed {
+			return 0, fmt.Errorf("mid price of feed '%s' couldn't be loaded: %s", feedName, e)
+		}
+		return 0, fmt.Errorf("mid price couldn't be loaded: %s", e)
+	}
+	midPrice, wasModified := p.offset.apply(midPrice)
+	if wasModified {
+		if feedName != defaultLevelFeed {
+			log.Printf("mid price of feed '%s' (adjusted): %.7f\n", feedName, midPrice)
+		} else {
+			log.Printf("mid price (adjusted): %.7f\n", midPrice)
+		}
+	}
+	return midPrice, nil
+}
+
 // GetFillHandlers impl
 func (p *staticSpreadLevelProvider) GetFillHandlers() ([]api.FillHandler, error) {
+	// the bootstrapSpread is shared by both sides so only the sell side registers it, otherwise every fill would be counted twice
+	if p.bootstrap != nil && !p.isBuySide {
+		return []api.FillHandler{p.bootstrap}, nil
+	}
 	return nil, nil
 }
 
@@ -0,0 +1,169 @@
+package plugins
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStaticSpreadLevelProviderAmountUnit(t *testing.T) {
+	testCases := []struct {
+		name       string
+		feedPrice  string
+		amountUnit amountUnit
+		isBuySide  bool
+		wantPrice  float64
+		wantAmount float64
+	}{
+		{
+			name:       "base",
+			feedPrice:  "0.5",
+			amountUnit: amountUnitBase,
+			isBuySide:  false,
+			wantPrice:  0.55,
+			wantAmount: 100.0,
+		}, {
+			name:       "quote_sell",
+			feedPrice:  "0.5",
+			amountUnit: amountUnitQuote,
+			isBuySide:  false,
+			wantPrice:  0.55,
+			wantAmount: 181.81818,
+		}, {
+			name:       "quote_sell_price_moved",
+			feedPrice:  "5.0",
+			amountUnit: amountUnitQuote,
+			isBuySide:  false,
+			wantPrice:  5.5,
+			wantAmount: 18.18182,
+		}, {
+			// the buy side feed is inverted so a price of 2.0 here is 0.5 quote per base
+			name:       "quote_buy",
+			feedPrice:  "2.0",
+			amountUnit: amountUnitQuote,
+			isBuySide:  true,
+			wantPrice:  2.2,
+			wantAmount: 220.0,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			pf, e := MakeFeedPair("fixed", k.feedPrice, "fixed", "1.0")
+			if !assert.NoError(t, e) {
+				return
+			}
+
+			p := makeStaticSpreadLevelProvider(
+				[]StaticLevel{{SPREAD: 0.1, AMOUNT: 10.0}},
+				10.0,
+				k.amountUnit,
+				rateOffset{},
+				map[string]*api.FeedPair{defaultLevelFeed: pf},
+				model.MakeOrderConstraints(7, 5, 1.0),
+				k.isBuySide,
+				nil,
+			)
+			levels, e := p.GetLevels(1000.0, 1000.0)
+			if !assert.NoError(t, e) {
+				return
+			}
+			if !assert.Equal(t, 1, len(levels)) {
+				return
+			}
+			assert.Equal(t, fmt.Sprintf("%.7f", k.wantPrice), levels[0].Price.AsString())
+			assert.Equal(t, fmt.Sprintf("%.5f", k.wantAmount), levels[0].Amount.AsString())
+		})
+	}
+}
+
+func TestStaticSpreadLevelProviderBaseBalancePercent(t *testing.T) {
+	pf, e := MakeFeedPair("fixed", "0.5", "fixed", "1.0")
+	if !assert.NoError(t, e) {
+		return
+	}
+	p := makeStaticSpreadLevelProvider(
+		[]StaticLevel{{SPREAD: 0.1, AMOUNT: 0.1}, {SPREAD: 0.2, AMOUNT: 0.05}},
+		10.0,
+		amountUnitBaseBalancePercent,
+		rateOffset{},
+		map[string]*api.FeedPair{defaultLevelFeed: pf},
+		model.MakeOrderConstraints(7, 5, 1.0),
+		false,
+		nil,
+	)
+
+	// the amounts are re-evaluated against the balance on every call so they scale down as the balance is depleted
+	for _, k := range []struct {
+		maxAssetBase float64
+		wantAmounts  []string
+	}{
+		{maxAssetBase: 1000.0, wantAmounts: []string{"100.00000", "50.00000"}},
+		{maxAssetBase: 200.0, wantAmounts: []string{"20.00000", "10.00000"}},
+		{maxAssetBase: 0.0, wantAmounts: []string{"0.00000", "0.00000"}},
+	} {
+		levels, e := p.GetLevels(k.maxAssetBase, 1000.0)
+		if !assert.NoError(t, e) || !assert.Equal(t, len(k.wantAmounts), len(levels)) {
+			return
+		}
+		for i, l := range levels {
+			assert.Equal(t, k.wantAmounts[i], l.Amount.AsString())
+		}
+	}
+}
+
+func TestStaticSpreadLevelProviderFeeds(t *testing.T) {
+	defaultFeed, e := MakeFeedPair("fixed", "0.5", "fixed", "1.0")
+	if !assert.NoError(t, e) {
+		return
+	}
+	fastFeed, e := MakeFeedPair("fixed", "0.6", "fixed", "1.0")
+	if !assert.NoError(t, e) {
+		return
+	}
+	feeds := map[string]*api.FeedPair{defaultLevelFeed: defaultFeed, "fast": fastFeed}
+	staticLevels := []StaticLevel{{SPREAD: 0.1, AMOUNT: 1.0, FEED: "fast"}, {SPREAD: 0.2, AMOUNT: 1.0}, {SPREAD: 0.3, AMOUNT: 1.0, FEED: "fast"}}
+	if !assert.NoError(t, validateLevelFeeds(staticLevels, feeds)) {
+		return
+	}
+
+	p := makeStaticSpreadLevelProvider(staticLevels, 10.0, amountUnitBase, rateOffset{}, feeds, model.MakeOrderConstraints(7, 5, 1.0), false, nil)
+	levels, e := p.GetLevels(1000.0, 1000.0)
+	if !assert.NoError(t, e) || !assert.Equal(t, 3, len(levels)) {
+		return
+	}
+	assert.Equal(t, "0.6600000", levels[0].Price.AsString())
+	assert.Equal(t, "0.6000000", levels[1].Price.AsString())
+	assert.Equal(t, "0.7800000", levels[2].Price.AsString())
+
+	assert.Error(t, validateLevelFeeds([]StaticLevel{{SPREAD: 0.1, AMOUNT: 1.0, FEED: "slow"}}, feeds))
+}
+
+func TestParseAmountUnit(t *testing.T) {
+	for _, k := range []struct {
+		amountUnit string
+		want       amountUnit
+		wantError  bool
+	}{
+		{amountUnit: "", want: amountUnitBase},
+		{amountUnit: "base", want: amountUnitBase},
+		{amountUnit: "quote", want: amountUnitQuote},
+		{amountUnit: "base_balance_percent", want: amountUnitBaseBalancePercent},
+		{amountUnit: "usd", wantError: true},
+	} {
+		t.Run(k.amountUnit, func(t *testing.T) {
+			unit, e := parseAmountUnit(k.amountUnit)
+			if k.wantError {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, k.want, unit)
+		})
+	}
+}
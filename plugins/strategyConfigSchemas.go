@@ -0,0 +1,57 @@
+package plugins
+
+import (
+	"fmt"
+
+	"github.com/stellar/kelp/support/toml"
+)
+
+// strategyConfigSchemas holds the schema of each strategy config that has changed since it was first released, bump the version and
+// add a migration from the previous version whenever a field is renamed or changes units so existing config files keep working.
+// Strategies that are not listed here are at version 1.
+var strategyConfigSchemas = map[string]toml.Schema{
+	"mirror": {
+		Name:    "mirror",
+		Version: 2,
+		Migrations: []toml.Migration{
+			{FromVersion: 1, Migrate: migrateMirrorConfigV1},
+		},
+	},
+}
+
+// StrategyConfigSchema returns the current schema of the config of a strategy
+func StrategyConfigSchema(strategy string) toml.Schema {
+	if s, ok := strategyConfigSchemas[strategy]; ok {
+		return s
+	}
+	return toml.Schema{Name: strategy, Version: 1}
+}
+
+// ReadStrategyConfig reads the config file of a strategy into dest, migrating files written for an older version of the config
+func ReadStrategyConfig(strategy string, filePath string, dest interface{}) error {
+	return toml.ReadVersionedConfig(filePath, StrategyConfigSchema(strategy), dest)
+}
+
+// WriteStrategyConfig writes the config of a strategy stamped with its current version
+func WriteStrategyConfig(strategy string, filePath string, v interface{}) error {
+	return toml.WriteVersionedFile(filePath, StrategyConfigSchema(strategy), v)
+}
+
+// migrateMirrorConfigV1 replaces VOLUME_DIVIDE_BY with the per-side BID_VOLUME_DIVIDE_BY and ASK_VOLUME_DIVIDE_BY and renames
+// MIN_BASE_VOLUME to MIN_BASE_VOLUME_OVERRIDE
+func migrateMirrorConfigV1(raw map[string]interface{}) ([]string, error) {
+	warnings := toml.RenameField(raw, "MIN_BASE_VOLUME", "MIN_BASE_VOLUME_OVERRIDE")
+
+	if v, ok := raw["VOLUME_DIVIDE_BY"]; ok {
+		delete(raw, "VOLUME_DIVIDE_BY")
+		for _, k := range []string{"BID_VOLUME_DIVIDE_BY", "ASK_VOLUME_DIVIDE_BY"} {
+			if _, exists := raw[k]; exists {
+				warnings = append(warnings, fmt.Sprintf("'VOLUME_DIVIDE_BY' was replaced by '%s' which is also set, ignoring the value of 'VOLUME_DIVIDE_BY' for it", k))
+				continue
+			}
+			raw[k] = v
+			warnings = append(warnings, fmt.Sprintf("'VOLUME_DIVIDE_BY' was replaced by '%s'", k))
+		}
+	}
+	return warnings, nil
+}
@@ -0,0 +1,59 @@
+package plugins
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/kelp/support/toml"
+)
+
+func TestMigrateMirrorConfigV1(t *testing.T) {
+	testCases := []struct {
+		name         string
+		raw          map[string]interface{}
+		wantRaw      map[string]interface{}
+		wantWarnings int
+	}{
+		{
+			name:         "deprecated fields",
+			raw:          map[string]interface{}{"VOLUME_DIVIDE_BY": 4.0, "MIN_BASE_VOLUME": 30.0},
+			wantRaw:      map[string]interface{}{"BID_VOLUME_DIVIDE_BY": 4.0, "ASK_VOLUME_DIVIDE_BY": 4.0, "MIN_BASE_VOLUME_OVERRIDE": 30.0},
+			wantWarnings: 3,
+		}, {
+			name:         "new fields are kept",
+			raw:          map[string]interface{}{"VOLUME_DIVIDE_BY": 4.0, "ASK_VOLUME_DIVIDE_BY": 5.0, "MIN_BASE_VOLUME": 30.0, "MIN_BASE_VOLUME_OVERRIDE": 10.0},
+			wantRaw:      map[string]interface{}{"BID_VOLUME_DIVIDE_BY": 4.0, "ASK_VOLUME_DIVIDE_BY": 5.0, "MIN_BASE_VOLUME_OVERRIDE": 10.0},
+			wantWarnings: 3,
+		}, {
+			name:         "nothing to migrate",
+			raw:          map[string]interface{}{"BID_VOLUME_DIVIDE_BY": 4.0},
+			wantRaw:      map[string]interface{}{"BID_VOLUME_DIVIDE_BY": 4.0},
+			wantWarnings: 0,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			warnings, e := migrateMirrorConfigV1(k.raw)
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, k.wantRaw, k.raw)
+			assert.Equal(t, k.wantWarnings, len(warnings))
+		})
+	}
+}
+
+func TestStrategyConfigSchema(t *testing.T) {
+	assert.Equal(t, 2, StrategyConfigSchema("mirror").Version)
+	assert.Equal(t, 1, StrategyConfigSchema("buysell").Version)
+
+	// every schema has a migration from each of its older versions
+	for name, s := range strategyConfigSchemas {
+		for v := 1; v < s.Version; v++ {
+			_, e := s.Upgrade(map[string]interface{}{toml.VersionKey: int64(v)})
+			assert.NoError(t, e, name)
+		}
+	}
+}
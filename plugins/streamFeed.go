@@ -0,0 +1,84 @@
+package plugins
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+)
+
+// streamTickerSources makes the TickerAPI of each exchange supported by the "stream" feed type, which serves tickers pushed over a
+// websocket from memory
+var streamTickerSources = map[string]func() (api.TickerAPI, error){
+	"binance": func() (api.TickerAPI, error) {
+		return makeBinanceWs()
+	},
+	"kraken": func() (api.TickerAPI, error) {
+		return makeKrakenTickerWs(krakenWsURL), nil
+	},
+}
+
+var streamTickersLock = &sync.Mutex{}
+
+// streamTickers holds one TickerAPI per exchange so all the stream feeds of an exchange share a single connection
+var streamTickers = map[string]api.TickerAPI{}
+
+func getStreamTicker(exchangeName string) (api.TickerAPI, error) {
+	streamTickersLock.Lock()
+	defer streamTickersLock.Unlock()
+
+	if tickerAPI, ok := streamTickers[exchangeName]; ok {
+		return tickerAPI, nil
+	}
+	makeFn, ok := streamTickerSources[exchangeName]
+	if !ok {
+		names := []string{}
+		for n := range streamTickerSources {
+			names = append(names, n)
+		}
+		return nil, fmt.Errorf("exchange '%s' does not support streaming tickers, needs to be one of %v", exchangeName, names)
+	}
+	tickerAPI, e := makeFn()
+	if e != nil {
+		return nil, fmt.Errorf("could not make the streaming ticker of exchange '%s': %s", exchangeName, e)
+	}
+	streamTickers[exchangeName] = tickerAPI
+	return tickerAPI, nil
+}
+
+// makeStreamFeed makes a feed that serves the price from the last ticker pushed by the exchange over a websocket instead of polling the
+// REST API every update, the URL has the format <exchange>/<base>/<quote>[/<modifier>] where the modifier is one of mid (default), ask,
+// bid or last
+func makeStreamFeed(url string) (api.PriceFeed, error) {
+	urlParts := strings.Split(url, "/")
+	if len(urlParts) < 3 || len(urlParts) > 4 {
+		return nil, fmt.Errorf("invalid format of stream type URL, needs either 3 or 4 parts after splitting URL by '/', has %d: %s", len(urlParts), url)
+	}
+	modifier := "mid"
+	if len(urlParts) == 4 {
+		modifier = urlParts[3]
+	}
+	if modifier != "mid" && modifier != "ask" && modifier != "bid" && modifier != "last" {
+		return nil, fmt.Errorf("unsupported modifier '%s' on stream type URL, needs to be one of mid, ask, bid or last", modifier)
+	}
+
+	tickerAPI, e := getStreamTicker(urlParts[0])
+	if e != nil {
+		return nil, e
+	}
+	baseAsset, e := model.CcxtAssetConverter.FromString(urlParts[1])
+	if e != nil {
+		return nil, fmt.Errorf("cannot make stream feed because of an error when converting the base asset: %s", e)
+	}
+	quoteAsset, e := model.CcxtAssetConverter.FromString(urlParts[2])
+	if e != nil {
+		return nil, fmt.Errorf("cannot make stream feed because of an error when converting the quote asset: %s", e)
+	}
+	tradingPair := &model.TradingPair{
+		Base:  baseAsset,
+		Quote: quoteAsset,
+	}
+	return newExchangeFeed(url, &tickerAPI, nil, tradingPair, modifier)
+}
@@ -0,0 +1,130 @@
+package plugins
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/queries"
+	"github.com/stellar/kelp/support/postgresdb"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// tradeCountFilter limits the number of fills per day (UTC), which some venues and anchors cap. It counts the fills of the day in the trades
+// table and once the count reaches maxTradesPerDay it stops placing offers and deletes the existing offers until the next day, since any offer
+// left on the book could still be filled
+type tradeCountFilter struct {
+	name                 string
+	configValue          string
+	baseAsset            hProtocol.Asset
+	quoteAsset           hProtocol.Asset
+	maxTradesPerDay      int64
+	dailyTradeCountQuery api.Query
+	clock                api.Clock
+
+	// uninitialized
+	wasLimited *bool
+}
+
+// makeFilterTradeCount makes a submit filter that stops trading once the number of fills of the day reaches maxTradesPerDay
+func makeFilterTradeCount(
+	configValue string,
+	exchangeName string,
+	tradingPair *model.TradingPair,
+	assetDisplayFn model.AssetDisplayFn,
+	baseAsset hProtocol.Asset,
+	quoteAsset hProtocol.Asset,
+	db *sql.DB,
+	maxTradesPerDay int64,
+	additionalMarketIDs []string,
+	optionalAccountIDs []string,
+	clock api.Clock,
+) (SubmitFilter, error) {
+	if maxTradesPerDay <= 0 {
+		return nil, fmt.Errorf("maxTradesPerDay needs to be greater than 0, was %d", maxTradesPerDay)
+	}
+
+	// use assetDisplayFn to make baseAssetString and quoteAssetString because it is issuer independent for non-sdex exchanges keeping a consistent marketID
+	baseAssetString, e := assetDisplayFn(tradingPair.Base)
+	if e != nil {
+		return nil, fmt.Errorf("could not convert base asset (%s) from trading pair via the passed in assetDisplayFn: %s", string(tradingPair.Base), e)
+	}
+	quoteAssetString, e := assetDisplayFn(tradingPair.Quote)
+	if e != nil {
+		return nil, fmt.Errorf("could not convert quote asset (%s) from trading pair via the passed in assetDisplayFn: %s", string(tradingPair.Quote), e)
+	}
+
+	marketID := MakeMarketID(exchangeName, baseAssetString, quoteAssetString)
+	// note that append(s, nil) is valid
+	marketIDs := utils.Dedupe(append([]string{marketID}, additionalMarketIDs...))
+	dailyTradeCountQuery, e := queries.MakeDailyTradeCountForMarketIds(db, marketIDs, optionalAccountIDs)
+	if e != nil {
+		return nil, fmt.Errorf("could not make daily trade count Query: %s", e)
+	}
+
+	return &tradeCountFilter{
+		name:                 "tradeCountFilter",
+		configValue:          configValue,
+		baseAsset:            baseAsset,
+		quoteAsset:           quoteAsset,
+		maxTradesPerDay:      maxTradesPerDay,
+		dailyTradeCountQuery: dailyTradeCountQuery,
+		clock:                clock,
+	}, nil
+}
+
+var _ SubmitFilter = &tradeCountFilter{}
+var _ OrderedSubmitFilter = &tradeCountFilter{}
+
+// FilterOrder impl.
+func (f *tradeCountFilter) FilterOrder() FilterOrder {
+	return FilterOrder{
+		ID:       filterIDTradeCount,
+		Priority: FilterPriorityRisk,
+	}
+}
+
+// Apply impl.
+func (f *tradeCountFilter) Apply(ops []txnbuild.Operation, sellingOffers []hProtocol.Offer, buyingOffers []hProtocol.Offer) ([]txnbuild.Operation, error) {
+	dateString := f.clock.Now().UTC().Format(postgresdb.DateFormatString)
+	queryResult, e := f.dailyTradeCountQuery.QueryRow(dateString)
+	if e != nil {
+		return nil, fmt.Errorf("could not load the trade count for date %s: %s", dateString, e)
+	}
+	numTrades, ok := queryResult.(*int64)
+	if !ok {
+		return nil, fmt.Errorf("incorrect type returned from DailyTradeCount query, expecting '*int64' but was '%T'", queryResult)
+	}
+
+	isLimited := *numTrades >= f.maxTradesPerDay
+	if f.wasLimited == nil || *f.wasLimited != isLimited {
+		if isLimited {
+			log.Printf("tradeCountFilter: reached %d of %d trades on %s, deleting all offers until the next day (UTC)\n", *numTrades, f.maxTradesPerDay, dateString)
+		} else {
+			log.Printf("tradeCountFilter: %d of %d trades on %s, trading is allowed\n", *numTrades, f.maxTradesPerDay, dateString)
+		}
+	}
+	f.wasLimited = &isLimited
+	if !isLimited {
+		return ops, nil
+	}
+
+	ops, e = filterOps(f.name, f.baseAsset, f.quoteAsset, sellingOffers, buyingOffers, ops, f.tradeCountFilterFn)
+	if e != nil {
+		return nil, fmt.Errorf("could not apply filter: %s", e)
+	}
+	return ops, nil
+}
+
+func (f *tradeCountFilter) tradeCountFilterFn(op *txnbuild.ManageSellOffer) (*txnbuild.ManageSellOffer, error) {
+	return nil, nil
+}
+
+// String is the Stringer method
+func (f *tradeCountFilter) String() string {
+	return f.configValue
+}
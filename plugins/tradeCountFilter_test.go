@@ -0,0 +1,91 @@
+package plugins
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// fixedDailyTradeCountQuery is a DailyTradeCount query that returns a fixed count for every date without a db
+type fixedDailyTradeCountQuery struct {
+	counts map[string]int64
+}
+
+var _ api.Query = &fixedDailyTradeCountQuery{}
+
+// Name impl.
+func (q *fixedDailyTradeCountQuery) Name() string {
+	return "fixedDailyTradeCountQuery"
+}
+
+// QueryRow impl.
+func (q *fixedDailyTradeCountQuery) QueryRow(args ...interface{}) (interface{}, error) {
+	numTrades := q.counts[args[0].(string)]
+	return &numTrades, nil
+}
+
+func TestTradeCountFilterApply(t *testing.T) {
+	clock := MakeManualClock(time.Date(2020, 5, 18, 23, 30, 0, 0, time.UTC))
+	f := &tradeCountFilter{
+		name:            "tradeCountFilter",
+		baseAsset:       utils.Asset2Asset2(testBaseAsset),
+		quoteAsset:      utils.Asset2Asset2(testQuoteAsset),
+		maxTradesPerDay: 10,
+		dailyTradeCountQuery: &fixedDailyTradeCountQuery{counts: map[string]int64{
+			"2020/05/17": 10,
+			"2020/05/18": 9,
+			"2020/05/19": 10,
+		}},
+		clock: clock,
+	}
+
+	ops := []txnbuild.Operation{
+		&txnbuild.ManageSellOffer{Selling: testBaseAsset, Buying: testQuoteAsset, Amount: "10.0", Price: "1.1"},
+		&txnbuild.ManageSellOffer{Selling: testQuoteAsset, Buying: testBaseAsset, Amount: "10.0", Price: "1.2"},
+	}
+	sellingOffers := []hProtocol.Offer{}
+	buyingOffers := []hProtocol.Offer{}
+
+	// below the limit
+	actual, e := f.Apply(ops, sellingOffers, buyingOffers)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, ops, actual)
+
+	// the limit is reached on the next day (UTC)
+	clock.Set(time.Date(2020, 5, 19, 0, 30, 0, 0, time.UTC))
+	actual, e = f.Apply(ops, sellingOffers, buyingOffers)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, 0, len(actual))
+
+	// a new day resets the count
+	clock.Set(time.Date(2020, 5, 20, 0, 30, 0, 0, time.UTC))
+	actual, e = f.Apply(ops, sellingOffers, buyingOffers)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, ops, actual)
+}
+
+func TestFilterTradeCount_Errors(t *testing.T) {
+	factory := &FilterFactory{}
+	for _, configInput := range []string{
+		"tradeCount",
+		"tradeCount/abc",
+		"tradeCount/10/market_ids=[]",
+		"tradeCount/10/account_ids=[a]/account_ids=[b]",
+		"tradeCount/10/market_ids=[4c19915f47]/account_ids=[a]/extra",
+	} {
+		_, e := factory.MakeFilter(configInput)
+		assert.Error(t, e, configInput)
+	}
+}
@@ -0,0 +1,263 @@
+package plugins
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// tradeTapWriteTimeout bounds how long a slow socket consumer can block the bot
+const tradeTapWriteTimeout = 1 * time.Second
+
+const (
+	tradeTapEventTrade = "trade"
+	tradeTapEventOffer = "offer"
+)
+
+// tradeTapEvent is a single line written to the trade tap
+type tradeTapEvent struct {
+	Type    string         `json:"type"`
+	TimeUTC string         `json:"time_utc"`
+	Trade   *tradeTapTrade `json:"trade,omitempty"`
+	Offer   *tradeTapOffer `json:"offer,omitempty"`
+}
+
+type tradeTapTrade struct {
+	TransactionID string `json:"txid"`
+	OrderID       string `json:"order_id"`
+	Pair          string `json:"pair"`
+	Action        string `json:"action"`
+	Type          string `json:"type"`
+	Price         string `json:"price"`
+	Volume        string `json:"volume"`
+	Cost          string `json:"cost"`
+	Fee           string `json:"fee"`
+	TimestampMs   int64  `json:"timestamp_ms"`
+}
+
+type tradeTapOffer struct {
+	OfferID int64  `json:"offer_id"`
+	Change  string `json:"change"` // one of create, update, delete
+	Selling string `json:"selling"`
+	Buying  string `json:"buying"`
+	Amount  string `json:"amount"`
+	Price   string `json:"price"`
+}
+
+// TradeTap mirrors every recorded trade and offer event as JSON Lines to a file or a socket so external pipelines can consume them in real time
+type TradeTap struct {
+	network string // "file", "tcp", or "unix"
+	address string
+
+	mutex  *sync.Mutex
+	writer io.WriteCloser // nil when a socket is disconnected, we reconnect on the next write
+}
+
+var _ api.FillHandler = &TradeTap{}
+
+// MakeTradeTap is a factory method, the destination is one of file:<path>, tcp:<host>:<port>, or unix:<path>; a destination without a prefix is a file path
+func MakeTradeTap(destination string) (*TradeTap, error) {
+	network, address, e := parseTradeTapDestination(destination)
+	if e != nil {
+		return nil, e
+	}
+
+	t := &TradeTap{
+		network: network,
+		address: address,
+		mutex:   &sync.Mutex{},
+	}
+	// connect eagerly so a misconfigured destination fails at startup
+	e = t.connect()
+	if e != nil {
+		return nil, fmt.Errorf("unable to open trade tap destination '%s': %s", destination, e)
+	}
+	return t, nil
+}
+
+func parseTradeTapDestination(destination string) (string /*network*/, string /*address*/, error) {
+	destination = strings.TrimSpace(destination)
+	if destination == "" {
+		return "", "", fmt.Errorf("trade tap destination cannot be empty")
+	}
+
+	parts := strings.SplitN(destination, ":", 2)
+	if len(parts) == 2 {
+		switch parts[0] {
+		case "file", "tcp", "unix":
+			if parts[1] == "" {
+				return "", "", fmt.Errorf("trade tap destination '%s' is missing an address", destination)
+			}
+			return parts[0], parts[1], nil
+		}
+	}
+	return "file", destination, nil
+}
+
+func (t *TradeTap) connect() error {
+	if t.network == "file" {
+		f, e := os.OpenFile(t.address, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if e != nil {
+			return e
+		}
+		t.writer = f
+		return nil
+	}
+
+	conn, e := net.DialTimeout(t.network, t.address, tradeTapWriteTimeout)
+	if e != nil {
+		return e
+	}
+	t.writer = conn
+	return nil
+}
+
+// write serializes the event as a single line, errors are logged and not returned since the tap should never affect trading
+func (t *TradeTap) write(event tradeTapEvent) {
+	event.TimeUTC = time.Now().UTC().Format(time.RFC3339Nano)
+	line, e := json.Marshal(event)
+	if e != nil {
+		log.Printf("tradeTap: unable to marshal event of type '%s': %s\n", event.Type, e)
+		return
+	}
+	line = append(line, '\n')
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.writer == nil {
+		e = t.connect()
+		if e != nil {
+			log.Printf("tradeTap: unable to reconnect to %s:%s, dropping event of type '%s': %s\n", t.network, t.address, event.Type, e)
+			return
+		}
+	}
+	if conn, ok := t.writer.(net.Conn); ok {
+		_ = conn.SetWriteDeadline(time.Now().Add(tradeTapWriteTimeout))
+	}
+
+	_, e = t.writer.Write(line)
+	if e != nil {
+		log.Printf("tradeTap: unable to write event of type '%s' to %s:%s, will reconnect on the next event: %s\n", event.Type, t.network, t.address, e)
+		_ = t.writer.Close()
+		t.writer = nil
+	}
+}
+
+// Close closes the underlying file or socket
+func (t *TradeTap) Close() error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.writer == nil {
+		return nil
+	}
+	e := t.writer.Close()
+	t.writer = nil
+	return e
+}
+
+// HandleFill impl.
+func (t *TradeTap) HandleFill(trade model.Trade) error {
+	tt := &tradeTapTrade{
+		OrderID: trade.OrderID,
+		Action:  trade.OrderAction.String(),
+		Type:    trade.OrderType.String(),
+	}
+	if trade.TransactionID != nil {
+		tt.TransactionID = trade.TransactionID.String()
+	}
+	if trade.Pair != nil {
+		tt.Pair = trade.Pair.String()
+	}
+	if trade.Price != nil {
+		tt.Price = trade.Price.AsString()
+	}
+	if trade.Volume != nil {
+		tt.Volume = trade.Volume.AsString()
+	}
+	if trade.Cost != nil {
+		tt.Cost = trade.Cost.AsString()
+	}
+	if trade.Fee != nil {
+		tt.Fee = trade.Fee.AsString()
+	}
+	if trade.Timestamp != nil {
+		tt.TimestampMs = trade.Timestamp.AsInt64()
+	}
+
+	t.write(tradeTapEvent{
+		Type:  tradeTapEventTrade,
+		Trade: tt,
+	})
+	return nil
+}
+
+type tradeTapFilter struct {
+	tap *TradeTap
+}
+
+var _ SubmitFilter = &tradeTapFilter{}
+var _ OrderedSubmitFilter = &tradeTapFilter{}
+
+// FilterOrder impl.
+func (f *tradeTapFilter) FilterOrder() FilterOrder {
+	return FilterOrder{
+		ID:       filterIDTradeTap,
+		Priority: FilterPriorityLast,
+		After:    []string{filterIDOrderConstraints},
+	}
+}
+
+// MakeFilterTradeTap makes a submit filter that passes all ops through unchanged and writes an offer event for each of them to the trade tap,
+// it should be the last filter so it only records the ops that are submitted
+func MakeFilterTradeTap(tap *TradeTap) SubmitFilter {
+	return &tradeTapFilter{
+		tap: tap,
+	}
+}
+
+// Apply impl.
+func (f *tradeTapFilter) Apply(
+	ops []txnbuild.Operation,
+	sellingOffers []hProtocol.Offer,
+	buyingOffers []hProtocol.Offer,
+) ([]txnbuild.Operation, error) {
+	for _, op := range ops {
+		o, ok := op.(*txnbuild.ManageSellOffer)
+		if !ok {
+			continue
+		}
+
+		change := "update"
+		if o.Amount == "0" {
+			change = "delete"
+		} else if o.OfferID == 0 {
+			change = "create"
+		}
+		f.tap.write(tradeTapEvent{
+			Type: tradeTapEventOffer,
+			Offer: &tradeTapOffer{
+				OfferID: o.OfferID,
+				Change:  change,
+				Selling: utils.Asset2String(utils.Asset2Asset2(o.Selling)),
+				Buying:  utils.Asset2String(utils.Asset2Asset2(o.Buying)),
+				Amount:  o.Amount,
+				Price:   o.Price,
+			},
+		})
+	}
+	return ops, nil
+}
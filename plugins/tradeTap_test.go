@@ -0,0 +1,119 @@
+package plugins
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stellar/go/txnbuild"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/kelp/model"
+)
+
+func TestParseTradeTapDestination(t *testing.T) {
+	testCases := []struct {
+		destination string
+		wantNetwork string
+		wantAddress string
+		wantErr     bool
+	}{
+		{"file:/tmp/tap.jsonl", "file", "/tmp/tap.jsonl", false},
+		{"/tmp/tap.jsonl", "file", "/tmp/tap.jsonl", false},
+		{"tcp:localhost:9000", "tcp", "localhost:9000", false},
+		{"unix:/var/run/kelp.sock", "unix", "/var/run/kelp.sock", false},
+		{"C:\\kelp\\tap.jsonl", "file", "C:\\kelp\\tap.jsonl", false},
+		{"tcp:", "", "", true},
+		{"", "", "", true},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.destination, func(t *testing.T) {
+			network, address, e := parseTradeTapDestination(k.destination)
+			if k.wantErr {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, k.wantNetwork, network)
+			assert.Equal(t, k.wantAddress, address)
+		})
+	}
+}
+
+func TestTradeTapWritesJSONLines(t *testing.T) {
+	dir, e := ioutil.TempDir("", "tradeTap")
+	if !assert.NoError(t, e) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "tap.jsonl")
+
+	tap, e := MakeTradeTap("file:" + filename)
+	if !assert.NoError(t, e) {
+		return
+	}
+
+	ts := model.MakeTimestamp(1600000000000)
+	e = tap.HandleFill(model.Trade{
+		Order: model.Order{
+			Pair:        &model.TradingPair{Base: model.XLM, Quote: model.USD},
+			OrderAction: model.OrderActionBuy,
+			OrderType:   model.OrderTypeLimit,
+			Price:       model.NumberFromFloat(0.1, 7),
+			Volume:      model.NumberFromFloat(100.0, 7),
+			Timestamp:   ts,
+		},
+		TransactionID: model.MakeTransactionID("tx1"),
+	})
+	if !assert.NoError(t, e) {
+		return
+	}
+
+	ops := []txnbuild.Operation{
+		&txnbuild.ManageSellOffer{Selling: txnbuild.NativeAsset{}, Buying: txnbuild.CreditAsset{Code: "USD", Issuer: "GBMMZMK2DC4FFP4CAI6KCVNCQ7WLO5A7DQU7EC7WGHRDQBZB763X4OQI"}, Amount: "10", Price: "0.1"},
+		&txnbuild.ManageSellOffer{Selling: txnbuild.NativeAsset{}, Buying: txnbuild.CreditAsset{Code: "USD", Issuer: "GBMMZMK2DC4FFP4CAI6KCVNCQ7WLO5A7DQU7EC7WGHRDQBZB763X4OQI"}, Amount: "0", Price: "0.1", OfferID: 42},
+	}
+	filteredOps, e := MakeFilterTradeTap(tap).Apply(ops, nil, nil)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, ops, filteredOps)
+	assert.NoError(t, tap.Close())
+
+	f, e := os.Open(filename)
+	if !assert.NoError(t, e) {
+		return
+	}
+	defer f.Close()
+
+	events := []tradeTapEvent{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var event tradeTapEvent
+		if !assert.NoError(t, json.Unmarshal(scanner.Bytes(), &event)) {
+			return
+		}
+		events = append(events, event)
+	}
+	if !assert.Equal(t, 3, len(events)) {
+		return
+	}
+
+	assert.Equal(t, tradeTapEventTrade, events[0].Type)
+	assert.Equal(t, "tx1", events[0].Trade.TransactionID)
+	assert.Equal(t, "buy", events[0].Trade.Action)
+	assert.Equal(t, int64(1600000000000), events[0].Trade.TimestampMs)
+
+	assert.Equal(t, tradeTapEventOffer, events[1].Type)
+	assert.Equal(t, "create", events[1].Offer.Change)
+	assert.Equal(t, "native", events[1].Offer.Selling)
+	assert.Equal(t, tradeTapEventOffer, events[2].Type)
+	assert.Equal(t, "delete", events[2].Offer.Change)
+	assert.Equal(t, int64(42), events[2].Offer.OfferID)
+}
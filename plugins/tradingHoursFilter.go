@@ -0,0 +1,232 @@
+package plugins
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/kelp/api"
+)
+
+const minutesPerDay = 24 * 60
+
+// weekdayNames maps the names used in the config to the days of the week
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// tradingWindow is a period of the day during which trading is allowed on the days of the week that it opens on
+type tradingWindow struct {
+	days  [7]bool // indexed by time.Weekday
+	start int     // minutes since midnight
+	end   int     // minutes since midnight, a window with end <= start closes on the next day
+}
+
+// contains returns true if the time, which should already be in the timezone of the window, is inside the window
+func (w tradingWindow) contains(t time.Time) bool {
+	minute := t.Hour()*60 + t.Minute()
+	weekday := t.Weekday()
+	if w.start < w.end {
+		return w.days[weekday] && minute >= w.start && minute < w.end
+	}
+
+	// overnight windows belong to the day on which they open
+	previousWeekday := (weekday + 6) % 7
+	return (w.days[weekday] && minute >= w.start) || (w.days[previousWeekday] && minute < w.end)
+}
+
+// parseTradingWindow parses a window in the format <days>@<HH:MM>-<HH:MM>, where days is "daily", a day ("sat"), a range of days ("mon-fri")
+// or a list of days and ranges joined by '+' ("mon+wed+fri-sat")
+func parseTradingWindow(s string) (*tradingWindow, error) {
+	parts := strings.Split(s, "@")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("invalid trading window '%s', needs to be in the format <days>@<HH:MM>-<HH:MM>", s)
+	}
+
+	w := &tradingWindow{}
+	e := parseTradingDays(parts[0], &w.days)
+	if e != nil {
+		return nil, fmt.Errorf("invalid days in trading window '%s': %s", s, e)
+	}
+
+	times := strings.Split(parts[1], "-")
+	if len(times) != 2 {
+		return nil, fmt.Errorf("invalid times in trading window '%s', needs to be in the format <HH:MM>-<HH:MM>", s)
+	}
+	w.start, e = parseMinuteOfDay(times[0])
+	if e != nil {
+		return nil, fmt.Errorf("invalid start time in trading window '%s': %s", s, e)
+	}
+	if w.start == minutesPerDay {
+		return nil, fmt.Errorf("invalid start time in trading window '%s', cannot start at 24:00", s)
+	}
+	w.end, e = parseMinuteOfDay(times[1])
+	if e != nil {
+		return nil, fmt.Errorf("invalid end time in trading window '%s': %s", s, e)
+	}
+	if w.start == w.end {
+		return nil, fmt.Errorf("invalid trading window '%s', start and end times cannot be the same", s)
+	}
+	return w, nil
+}
+
+// parseTradingDays sets the days of the week that are in the days spec
+func parseTradingDays(spec string, days *[7]bool) error {
+	if spec == "daily" {
+		for i := range days {
+			days[i] = true
+		}
+		return nil
+	}
+
+	for _, dayRange := range strings.Split(spec, "+") {
+		bounds := strings.Split(dayRange, "-")
+		if len(bounds) > 2 {
+			return fmt.Errorf("invalid range of days '%s'", dayRange)
+		}
+		first, ok := weekdayNames[strings.ToLower(bounds[0])]
+		if !ok {
+			return fmt.Errorf("invalid day '%s', needs to be one of [sun, mon, tue, wed, thu, fri, sat] or \"daily\"", bounds[0])
+		}
+		last := first
+		if len(bounds) == 2 {
+			last, ok = weekdayNames[strings.ToLower(bounds[1])]
+			if !ok {
+				return fmt.Errorf("invalid day '%s', needs to be one of [sun, mon, tue, wed, thu, fri, sat]", bounds[1])
+			}
+		}
+
+		// ranges can wrap around the end of the week, such as "fri-mon"
+		for d := first; ; d = (d + 1) % 7 {
+			days[d] = true
+			if d == last {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// parseMinuteOfDay parses a time in the format HH:MM into the minutes since midnight, allowing 24:00
+func parseMinuteOfDay(s string) (int, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 2 {
+		return 0, fmt.Errorf("invalid time '%s', needs to be in the format HH:MM", s)
+	}
+	hour, e := strconv.Atoi(parts[0])
+	if e != nil {
+		return 0, fmt.Errorf("could not parse hour of time '%s': %s", s, e)
+	}
+	minute, e := strconv.Atoi(parts[1])
+	if e != nil {
+		return 0, fmt.Errorf("could not parse minute of time '%s': %s", s, e)
+	}
+	if hour < 0 || hour > 24 || minute < 0 || minute > 59 || (hour == 24 && minute != 0) {
+		return 0, fmt.Errorf("invalid time '%s', needs to be between 00:00 and 24:00", s)
+	}
+	return hour*60 + minute, nil
+}
+
+// tradingHoursFilter only allows trading inside the configured windows, outside of them it deletes all offers and drops all new ops
+type tradingHoursFilter struct {
+	name        string
+	configValue string
+	baseAsset   hProtocol.Asset
+	quoteAsset  hProtocol.Asset
+	windows     []tradingWindow
+	location    *time.Location
+	clock       api.Clock
+
+	// uninitialized
+	wasOpen *bool
+}
+
+// makeFilterTradingHours makes a submit filter that deletes all offers outside of the trading windows, which are in the timezone of location
+func makeFilterTradingHours(
+	configValue string,
+	baseAsset hProtocol.Asset,
+	quoteAsset hProtocol.Asset,
+	windows []tradingWindow,
+	location *time.Location,
+	clock api.Clock,
+) (SubmitFilter, error) {
+	if len(windows) == 0 {
+		return nil, fmt.Errorf("need at least one trading window")
+	}
+	if location == nil {
+		return nil, fmt.Errorf("location cannot be nil")
+	}
+
+	return &tradingHoursFilter{
+		name:        "tradingHoursFilter",
+		configValue: configValue,
+		baseAsset:   baseAsset,
+		quoteAsset:  quoteAsset,
+		windows:     windows,
+		location:    location,
+		clock:       clock,
+	}, nil
+}
+
+var _ SubmitFilter = &tradingHoursFilter{}
+var _ OrderedSubmitFilter = &tradingHoursFilter{}
+
+// FilterOrder impl.
+func (f *tradingHoursFilter) FilterOrder() FilterOrder {
+	return FilterOrder{
+		ID:       filterIDTradingHours,
+		Priority: FilterPriorityRisk,
+	}
+}
+
+// Apply impl.
+func (f *tradingHoursFilter) Apply(ops []txnbuild.Operation, sellingOffers []hProtocol.Offer, buyingOffers []hProtocol.Offer) ([]txnbuild.Operation, error) {
+	now := f.clock.Now().In(f.location)
+	isOpen := f.isOpen(now)
+	if f.wasOpen == nil || *f.wasOpen != isOpen {
+		if isOpen {
+			log.Printf("tradingHoursFilter: %s is inside the trading hours, resuming trading\n", now.Format(time.RFC3339))
+		} else {
+			log.Printf("tradingHoursFilter: %s is outside the trading hours, deleting all offers until the next trading window opens\n", now.Format(time.RFC3339))
+		}
+	}
+	f.wasOpen = &isOpen
+	if isOpen {
+		return ops, nil
+	}
+
+	ops, e := filterOps(f.name, f.baseAsset, f.quoteAsset, sellingOffers, buyingOffers, ops, f.tradingHoursFilterFn)
+	if e != nil {
+		return nil, fmt.Errorf("could not apply filter: %s", e)
+	}
+	return ops, nil
+}
+
+// isOpen returns true if the time is inside any of the trading windows
+func (f *tradingHoursFilter) isOpen(now time.Time) bool {
+	for _, w := range f.windows {
+		if w.contains(now) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *tradingHoursFilter) tradingHoursFilterFn(op *txnbuild.ManageSellOffer) (*txnbuild.ManageSellOffer, error) {
+	return nil, nil
+}
+
+// String is the Stringer method
+func (f *tradingHoursFilter) String() string {
+	return f.configValue
+}
@@ -0,0 +1,153 @@
+package plugins
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/kelp/support/utils"
+)
+
+func TestParseTradingWindow(t *testing.T) {
+	testCases := []struct {
+		window    string
+		wantDays  []time.Weekday
+		wantStart int
+		wantEnd   int
+		wantError bool
+	}{
+		{
+			window:    "mon-fri@09:00-17:30",
+			wantDays:  []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+			wantStart: 9 * 60,
+			wantEnd:   17*60 + 30,
+		}, {
+			window:    "sat@00:00-24:00",
+			wantDays:  []time.Weekday{time.Saturday},
+			wantStart: 0,
+			wantEnd:   24 * 60,
+		}, {
+			window:    "fri-mon+wed@22:00-06:00",
+			wantDays:  []time.Weekday{time.Sunday, time.Monday, time.Wednesday, time.Friday, time.Saturday},
+			wantStart: 22 * 60,
+			wantEnd:   6 * 60,
+		}, {
+			window:    "daily@08:00-20:00",
+			wantDays:  []time.Weekday{time.Sunday, time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday},
+			wantStart: 8 * 60,
+			wantEnd:   20 * 60,
+		},
+		{window: "mon-fri", wantError: true},
+		{window: "funday@09:00-17:00", wantError: true},
+		{window: "mon@09:00", wantError: true},
+		{window: "mon@09:00-09:00", wantError: true},
+		{window: "mon@24:00-09:00", wantError: true},
+		{window: "mon@09:00-24:30", wantError: true},
+		{window: "mon@9am-5pm", wantError: true},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.window, func(t *testing.T) {
+			actual, e := parseTradingWindow(k.window)
+			if k.wantError {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+
+			wantDays := [7]bool{}
+			for _, d := range k.wantDays {
+				wantDays[d] = true
+			}
+			assert.Equal(t, wantDays, actual.days)
+			assert.Equal(t, k.wantStart, actual.start)
+			assert.Equal(t, k.wantEnd, actual.end)
+		})
+	}
+}
+
+func TestTradingWindowContains(t *testing.T) {
+	weekdays, e := parseTradingWindow("mon-fri@09:00-17:00")
+	if !assert.NoError(t, e) {
+		return
+	}
+	overnight, e := parseTradingWindow("sun-thu@22:00-06:00")
+	if !assert.NoError(t, e) {
+		return
+	}
+
+	testCases := []struct {
+		name   string
+		window *tradingWindow
+		time   time.Time // 2020-05-18 is a Monday
+		want   bool
+	}{
+		{name: "weekday open", window: weekdays, time: time.Date(2020, 5, 18, 9, 0, 0, 0, time.UTC), want: true},
+		{name: "weekday before close", window: weekdays, time: time.Date(2020, 5, 22, 16, 59, 0, 0, time.UTC), want: true},
+		{name: "weekday at close", window: weekdays, time: time.Date(2020, 5, 18, 17, 0, 0, 0, time.UTC), want: false},
+		{name: "weekday before open", window: weekdays, time: time.Date(2020, 5, 18, 8, 59, 0, 0, time.UTC), want: false},
+		{name: "weekday on saturday", window: weekdays, time: time.Date(2020, 5, 23, 12, 0, 0, 0, time.UTC), want: false},
+		{name: "overnight opening day", window: overnight, time: time.Date(2020, 5, 17, 23, 0, 0, 0, time.UTC), want: true},
+		{name: "overnight next morning", window: overnight, time: time.Date(2020, 5, 22, 5, 59, 0, 0, time.UTC), want: true},
+		{name: "overnight after close", window: overnight, time: time.Date(2020, 5, 22, 6, 0, 0, 0, time.UTC), want: false},
+		{name: "overnight not opened on friday", window: overnight, time: time.Date(2020, 5, 22, 23, 0, 0, 0, time.UTC), want: false},
+		{name: "overnight not opened on saturday", window: overnight, time: time.Date(2020, 5, 24, 5, 0, 0, 0, time.UTC), want: false},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			assert.Equal(t, k.want, k.window.contains(k.time))
+		})
+	}
+}
+
+func TestTradingHoursFilterApply(t *testing.T) {
+	ny, e := time.LoadLocation("America/New_York")
+	if !assert.NoError(t, e) {
+		return
+	}
+	w, e := parseTradingWindow("mon-fri@09:00-17:00")
+	if !assert.NoError(t, e) {
+		return
+	}
+	// 2020-05-18 13:30 UTC is 09:30 on a Monday in New York
+	clock := MakeManualClock(time.Date(2020, 5, 18, 13, 30, 0, 0, time.UTC))
+	filter, e := makeFilterTradingHours("", utils.Asset2Asset2(testBaseAsset), utils.Asset2Asset2(testQuoteAsset), []tradingWindow{*w}, ny, clock)
+	if !assert.NoError(t, e) {
+		return
+	}
+
+	ops := []txnbuild.Operation{
+		&txnbuild.ManageSellOffer{Selling: testBaseAsset, Buying: testQuoteAsset, Amount: "10.0", Price: "1.1"},
+		&txnbuild.ManageSellOffer{Selling: testQuoteAsset, Buying: testBaseAsset, Amount: "10.0", Price: "1.2"},
+	}
+	sellingOffers := []hProtocol.Offer{}
+	buyingOffers := []hProtocol.Offer{}
+
+	actual, e := filter.Apply(ops, sellingOffers, buyingOffers)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, ops, actual)
+
+	// 2020-05-18 12:30 UTC is 08:30 in New York, before the window opens
+	clock.Set(time.Date(2020, 5, 18, 12, 30, 0, 0, time.UTC))
+	actual, e = filter.Apply(ops, sellingOffers, buyingOffers)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, 0, len(actual))
+
+	// 2020-05-18 21:30 UTC is 17:30 in New York, after the window closes
+	clock.Set(time.Date(2020, 5, 18, 21, 30, 0, 0, time.UTC))
+	actual, e = filter.Apply(ops, sellingOffers, buyingOffers)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, 0, len(actual))
+}
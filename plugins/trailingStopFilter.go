@@ -0,0 +1,200 @@
+package plugins
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/kelpdb"
+	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/queries"
+	"github.com/stellar/kelp/support/postgresdb"
+)
+
+// trailingStopFilter deletes all offers and stops placing new offers once the reference price falls more than
+// stopPercent below the highest reference price seen so far (the high water mark). The stop is latched in the db once it is hit so it does not
+// release when the price recovers, it is reset by deleting the row of the market from the trailing_stop_marks table.
+type trailingStopFilter struct {
+	name               string
+	configValue        string
+	baseAsset          hProtocol.Asset
+	quoteAsset         hProtocol.Asset
+	pf                 api.PriceFeed
+	stopPercent        float64
+	db                 *sql.DB
+	marketID           string
+	priceFeedKey       string
+	highWaterMarkQuery api.Query
+	persistLatchFn     func() error
+
+	// uninitialized
+	highWaterMark *float64
+	isLatched     bool
+}
+
+// makeFilterTrailingStop makes a submit filter that acts as a trailing stop-loss based on the value of the price feed
+func makeFilterTrailingStop(
+	configValue string,
+	exchangeName string,
+	tradingPair *model.TradingPair,
+	assetDisplayFn model.AssetDisplayFn,
+	baseAsset hProtocol.Asset,
+	quoteAsset hProtocol.Asset,
+	db *sql.DB,
+	stopPercent float64,
+	priceFeedKey string,
+	pf api.PriceFeed,
+) (SubmitFilter, error) {
+	if stopPercent <= 0.0 || stopPercent >= 1.0 {
+		return nil, fmt.Errorf("invalid stop percent, expected 0.0 < stopPercent < 1.0; was %f", stopPercent)
+	}
+
+	// use assetDisplayFn to make baseAssetString and quoteAssetString because it is issuer independent for non-sdex exchanges keeping a consistent marketID
+	baseAssetString, e := assetDisplayFn(tradingPair.Base)
+	if e != nil {
+		return nil, fmt.Errorf("could not convert base asset (%s) from trading pair via the passed in assetDisplayFn: %s", string(tradingPair.Base), e)
+	}
+	quoteAssetString, e := assetDisplayFn(tradingPair.Quote)
+	if e != nil {
+		return nil, fmt.Errorf("could not convert quote asset (%s) from trading pair via the passed in assetDisplayFn: %s", string(tradingPair.Quote), e)
+	}
+	marketID := MakeMarketID(exchangeName, baseAssetString, quoteAssetString)
+
+	highWaterMarkQuery, e := queries.MakeTrailingStopHighWaterMark(db, marketID, priceFeedKey)
+	if e != nil {
+		return nil, fmt.Errorf("could not make trailing stop high water mark query: %s", e)
+	}
+
+	f := &trailingStopFilter{
+		name:               "trailingStopFilter",
+		configValue:        configValue,
+		baseAsset:          baseAsset,
+		quoteAsset:         quoteAsset,
+		pf:                 pf,
+		stopPercent:        stopPercent,
+		db:                 db,
+		marketID:           marketID,
+		priceFeedKey:       priceFeedKey,
+		highWaterMarkQuery: highWaterMarkQuery,
+	}
+	f.persistLatchFn = f.persistLatch
+	return f, nil
+}
+
+var _ SubmitFilter = &trailingStopFilter{}
+var _ OrderedSubmitFilter = &trailingStopFilter{}
+
+// FilterOrder impl.
+func (f *trailingStopFilter) FilterOrder() FilterOrder {
+	return FilterOrder{
+		ID:       filterIDTrailingStop,
+		Priority: FilterPriorityRisk,
+	}
+}
+
+// Apply impl.
+func (f *trailingStopFilter) Apply(ops []txnbuild.Operation, sellingOffers []hProtocol.Offer, buyingOffers []hProtocol.Offer) ([]txnbuild.Operation, error) {
+	price, e := f.pf.GetPrice()
+	if e != nil {
+		return nil, fmt.Errorf("could not get price from priceFeed: %s", e)
+	}
+
+	e = f.updateHighWaterMark(price)
+	if e != nil {
+		return nil, fmt.Errorf("could not update high water mark: %s", e)
+	}
+
+	stopPrice := *f.highWaterMark * (1 - f.stopPercent)
+	if !f.isLatched && price < stopPrice {
+		e = f.latch()
+		if e != nil {
+			return nil, fmt.Errorf("could not latch trailing stop: %s", e)
+		}
+	}
+	log.Printf("trailingStopFilter: price=%.10f, highWaterMark=%.10f, stopPercent=%.4f, stopPrice=%.10f, isStopped=%v\n", price, *f.highWaterMark, f.stopPercent, stopPrice, f.isLatched)
+	if !f.isLatched {
+		return ops, nil
+	}
+
+	// drop all ops and delete all existing offers while the price is below the stop price
+	ops, e = filterOps(f.name, f.baseAsset, f.quoteAsset, sellingOffers, buyingOffers, ops, f.trailingStopFilterFn)
+	if e != nil {
+		return nil, fmt.Errorf("could not apply filter: %s", e)
+	}
+	return ops, nil
+}
+
+func (f *trailingStopFilter) trailingStopFilterFn(op *txnbuild.ManageSellOffer) (*txnbuild.ManageSellOffer, error) {
+	return nil, nil
+}
+
+// updateHighWaterMark loads the high water mark from the db on the first call and persists it whenever the price exceeds it, the high water
+// mark is not moved while the stop is latched
+func (f *trailingStopFilter) updateHighWaterMark(price float64) error {
+	if f.highWaterMark == nil {
+		result, e := f.highWaterMarkQuery.QueryRow()
+		if e != nil {
+			return fmt.Errorf("could not load high water mark from db: %s", e)
+		}
+		mark, ok := result.(*queries.TrailingStopMark)
+		if !ok {
+			return fmt.Errorf("incorrect type returned from TrailingStopHighWaterMark query, expecting '*queries.TrailingStopMark' but was '%T'", result)
+		}
+		if mark != nil {
+			log.Printf("trailingStopFilter: loaded high water mark from db (market_id=%s, price_feed=%s): %.10f, latched=%v\n", f.marketID, f.priceFeedKey, mark.HighWaterMark, mark.StoppedUTC != nil)
+			highWaterMark := mark.HighWaterMark
+			f.highWaterMark = &highWaterMark
+			f.isLatched = mark.StoppedUTC != nil
+		}
+	}
+
+	if f.isLatched || (f.highWaterMark != nil && price <= *f.highWaterMark) {
+		return nil
+	}
+
+	sqlUpsert := fmt.Sprintf(kelpdb.SqlTrailingStopMarksUpsertTemplate,
+		f.marketID,
+		f.priceFeedKey,
+		price,
+		time.Now().UTC().Format(postgresdb.TimestampFormatString),
+	)
+	_, e := f.db.Exec(sqlUpsert)
+	if e != nil {
+		return fmt.Errorf("could not execute sql upsert statement (%s): %s", sqlUpsert, e)
+	}
+	f.highWaterMark = &price
+	return nil
+}
+
+// latch persists that the stop was hit so it stays stopped until it is reset, even across restarts
+func (f *trailingStopFilter) latch() error {
+	e := f.persistLatchFn()
+	if e != nil {
+		return e
+	}
+	f.isLatched = true
+	log.Printf("trailingStopFilter: latched the trailing stop (market_id=%s, price_feed=%s), delete its row from the trailing_stop_marks table to reset it\n", f.marketID, f.priceFeedKey)
+	return nil
+}
+
+func (f *trailingStopFilter) persistLatch() error {
+	sqlUpdate := fmt.Sprintf(kelpdb.SqlTrailingStopMarksLatchTemplate,
+		time.Now().UTC().Format(postgresdb.TimestampFormatString),
+		f.marketID,
+		f.priceFeedKey,
+	)
+	_, e := f.db.Exec(sqlUpdate)
+	if e != nil {
+		return fmt.Errorf("could not execute sql update statement (%s): %s", sqlUpdate, e)
+	}
+	return nil
+}
+
+// String is the Stringer method
+func (f *trailingStopFilter) String() string {
+	return f.configValue
+}
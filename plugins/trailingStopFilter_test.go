@@ -0,0 +1,115 @@
+package plugins
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/kelp/support/utils"
+)
+
+func TestTrailingStopFilterApply(t *testing.T) {
+	testCases := []struct {
+		name          string
+		price         float64
+		highWaterMark float64
+		stopPercent   float64
+		wantNumOps    int
+	}{
+		{
+			name:          "price at high water mark",
+			price:         1.0,
+			highWaterMark: 1.0,
+			stopPercent:   0.10,
+			wantNumOps:    2,
+		}, {
+			name:          "price above stop price",
+			price:         0.95,
+			highWaterMark: 1.0,
+			stopPercent:   0.10,
+			wantNumOps:    2,
+		}, {
+			name:          "price at stop price",
+			price:         0.90,
+			highWaterMark: 1.0,
+			stopPercent:   0.10,
+			wantNumOps:    2,
+		}, {
+			name:          "price below stop price",
+			price:         0.85,
+			highWaterMark: 1.0,
+			stopPercent:   0.10,
+			wantNumOps:    0,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			highWaterMark := k.highWaterMark
+			f := &trailingStopFilter{
+				name:          "trailingStopFilter",
+				baseAsset:     utils.Asset2Asset2(testBaseAsset),
+				quoteAsset:    utils.Asset2Asset2(testQuoteAsset),
+				pf:            &fixedFeed{price: k.price},
+				stopPercent:   k.stopPercent,
+				highWaterMark: &highWaterMark,
+			}
+			f.persistLatchFn = func() error { return nil }
+
+			ops := []txnbuild.Operation{
+				&txnbuild.ManageSellOffer{Selling: testBaseAsset, Buying: testQuoteAsset, Amount: "10.0", Price: "1.1"},
+				&txnbuild.ManageSellOffer{Selling: testQuoteAsset, Buying: testBaseAsset, Amount: "10.0", Price: "1.2"},
+			}
+			actual, e := f.Apply(ops, nil, nil)
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, k.wantNumOps, len(actual))
+			assert.Equal(t, k.highWaterMark, *f.highWaterMark)
+		})
+	}
+}
+
+func TestTrailingStopFilterLatch(t *testing.T) {
+	highWaterMark := 1.0
+	pf := &fixedFeed{price: 0.85}
+	numLatches := 0
+	f := &trailingStopFilter{
+		name:          "trailingStopFilter",
+		baseAsset:     utils.Asset2Asset2(testBaseAsset),
+		quoteAsset:    utils.Asset2Asset2(testQuoteAsset),
+		pf:            pf,
+		stopPercent:   0.10,
+		highWaterMark: &highWaterMark,
+	}
+	f.persistLatchFn = func() error {
+		numLatches++
+		return nil
+	}
+
+	makeOps := func() []txnbuild.Operation {
+		return []txnbuild.Operation{
+			&txnbuild.ManageSellOffer{Selling: testBaseAsset, Buying: testQuoteAsset, Amount: "10.0", Price: "1.1"},
+		}
+	}
+
+	// the stop is hit
+	actual, e := f.Apply(makeOps(), nil, nil)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, 0, len(actual))
+
+	// the stop stays latched when the price recovers, including above the old high water mark which is not moved
+	for _, price := range []float64{0.95, 1.2} {
+		pf.price = price
+		actual, e = f.Apply(makeOps(), nil, nil)
+		if !assert.NoError(t, e) {
+			return
+		}
+		assert.Equal(t, 0, len(actual), price)
+	}
+	assert.Equal(t, 1.0, *f.highWaterMark)
+	assert.Equal(t, 1, numLatches)
+}
@@ -0,0 +1,217 @@
+package plugins
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// TxNotionalGuard is the last check before a transaction is submitted to horizon, it refuses transactions that move more value than a
+// ceiling so a misconfigured strategy or filter cannot place a catastrophically large order
+type TxNotionalGuard struct {
+	maxNotional float64
+	assetBase   hProtocol.Asset
+	assetQuote  hProtocol.Asset
+	override    bool
+
+	// assetFeeds has the price in units of the quote asset of the base asset and of the other assets added with AddAssetFeed, keyed by
+	// utils.Asset2String
+	assetFeeds map[string]api.PriceFeed
+}
+
+// MakeTxNotionalGuard is a factory method, maxNotional is in units of the quote asset and referenceFeed is the price of the base asset in
+// units of the quote asset. When override is set transactions above the ceiling are only logged and still submitted.
+func MakeTxNotionalGuard(
+	maxNotional float64,
+	referenceFeed api.PriceFeed,
+	assetBase hProtocol.Asset,
+	assetQuote hProtocol.Asset,
+	override bool,
+) (*TxNotionalGuard, error) {
+	if maxNotional <= 0 {
+		return nil, fmt.Errorf("max notional needs to be > 0, was %f", maxNotional)
+	}
+	if referenceFeed == nil {
+		return nil, fmt.Errorf("need a reference feed to value the base asset")
+	}
+
+	return &TxNotionalGuard{
+		maxNotional: maxNotional,
+		assetBase:   assetBase,
+		assetQuote:  assetQuote,
+		override:    override,
+		assetFeeds: map[string]api.PriceFeed{
+			utils.Asset2String(assetBase): referenceFeed,
+		},
+	}, nil
+}
+
+// AddAssetFeed values the amounts of an asset that is neither the base nor the quote asset with the feed, which gives the price of the asset
+// in units of the quote asset. Transactions that send an asset without a feed are refused
+func (g *TxNotionalGuard) AddAssetFeed(asset hProtocol.Asset, feed api.PriceFeed) error {
+	assetString := utils.Asset2String(asset)
+	if assetString == utils.Asset2String(g.assetBase) || assetString == utils.Asset2String(g.assetQuote) {
+		return fmt.Errorf("asset %s is the base or quote asset, which is already valued", assetString)
+	}
+	if _, ok := g.assetFeeds[assetString]; ok {
+		return fmt.Errorf("asset %s already has a feed", assetString)
+	}
+	if feed == nil {
+		return fmt.Errorf("need a feed to value asset %s", assetString)
+	}
+	g.assetFeeds[assetString] = feed
+	return nil
+}
+
+// String is the Stringer method
+func (g *TxNotionalGuard) String() string {
+	return fmt.Sprintf("TxNotionalGuard[maxNotional=%.7f, override=%v, numAssetFeeds=%d]", g.maxNotional, g.override, len(g.assetFeeds))
+}
+
+// Check returns an error when the total notional of the ops is above the ceiling, or when the ops cannot be valued
+func (g *TxNotionalGuard) Check(ops []txnbuild.Operation) error {
+	// the price of every asset is fetched at most once per transaction and only when the ops send it
+	prices := map[string]float64{}
+	priceFn := func(assetString string) (float64, error) {
+		if price, ok := prices[assetString]; ok {
+			return price, nil
+		}
+		feed, ok := g.assetFeeds[assetString]
+		if !ok {
+			return 0, fmt.Errorf("cannot value an amount of asset %s which is neither the base nor the quote asset and has no feed", assetString)
+		}
+		price, e := feed.GetPrice()
+		if e != nil {
+			return 0, fmt.Errorf("could not get the reference price of asset %s: %s", assetString, e)
+		}
+		if price <= 0 {
+			return 0, fmt.Errorf("the reference price of asset %s was %f", assetString, price)
+		}
+		prices[assetString] = price
+		return price, nil
+	}
+
+	notional, e := computeTxNotional(ops, g.assetBase, g.assetQuote, priceFn)
+	if e != nil {
+		return fmt.Errorf("could not compute the notional of the transaction: %s", e)
+	}
+	if notional <= g.maxNotional {
+		return nil
+	}
+
+	if g.override {
+		log.Printf("WARNING: transaction with %d ops has a notional of %.7f which is above the max notional of %.7f, submitting it anyway because the max notional is overridden\n", len(ops), notional, g.maxNotional)
+		return nil
+	}
+	return fmt.Errorf("refusing to submit transaction with %d ops because its notional of %.7f is above the max notional of %.7f (reference prices = %v)", len(ops), notional, g.maxNotional, prices)
+}
+
+// computeTxNotional sums the value of everything that the ops can send out of the account in units of the quote asset, deleting an offer
+// or withdrawing from a liquidity pool does not send anything so it has no notional. priceFn gives the price of every asset other than the
+// quote asset in units of the quote asset
+func computeTxNotional(ops []txnbuild.Operation, assetBase hProtocol.Asset, assetQuote hProtocol.Asset, priceFn func(assetString string) (float64, error)) (float64, error) {
+	quoteString := utils.Asset2String(assetQuote)
+	valueFn := func(asset txnbuild.Asset, amountString string) (float64, error) {
+		if amountString == "" {
+			return 0, nil
+		}
+		amount, e := strconv.ParseFloat(amountString, 64)
+		if e != nil {
+			return 0, fmt.Errorf("could not parse amount '%s': %s", amountString, e)
+		}
+		if amount == 0 {
+			return 0, nil
+		}
+
+		if asset == nil {
+			return 0, fmt.Errorf("amount %s has no asset", amountString)
+		}
+		assetString := utils.Asset2String(utils.Asset2Asset2(asset))
+		if assetString == quoteString {
+			return amount, nil
+		}
+		price, e := priceFn(assetString)
+		if e != nil {
+			return 0, e
+		}
+		return amount * price, nil
+	}
+
+	total := 0.0
+	for i, op := range ops {
+		var v float64
+		var e error
+		switch o := op.(type) {
+		case *txnbuild.ManageSellOffer:
+			v, e = valueFn(o.Selling, o.Amount)
+		case *txnbuild.ManageBuyOffer:
+			v, e = valueManageBuyOffer(o, valueFn)
+		case *txnbuild.CreatePassiveSellOffer:
+			v, e = valueFn(o.Selling, o.Amount)
+		case *txnbuild.Payment:
+			v, e = valueFn(o.Asset, o.Amount)
+		case *txnbuild.PathPaymentStrictSend:
+			v, e = valueFn(o.SendAsset, o.SendAmount)
+		case *txnbuild.PathPaymentStrictReceive:
+			v, e = valueFn(o.SendAsset, o.SendMax)
+		case *txnbuild.LiquidityPoolDeposit:
+			v, e = valueLiquidityPoolDeposit(o, assetBase, assetQuote, valueFn)
+		}
+		if e != nil {
+			return 0, fmt.Errorf("op at index %d (%T): %s", i, op, e)
+		}
+		total += v
+	}
+	return total, nil
+}
+
+// valueManageBuyOffer values what leaves the account, which is the selling asset. The amount of the op is of the buying asset and the price
+// is of the buying asset in units of the selling asset so the amount that is sold is amount * price
+func valueManageBuyOffer(op *txnbuild.ManageBuyOffer, valueFn func(asset txnbuild.Asset, amountString string) (float64, error)) (float64, error) {
+	if op.Amount == "" {
+		return 0, nil
+	}
+	buyAmount, e := strconv.ParseFloat(op.Amount, 64)
+	if e != nil {
+		return 0, fmt.Errorf("could not parse amount '%s': %s", op.Amount, e)
+	}
+	if buyAmount == 0 {
+		return 0, nil
+	}
+	price, e := strconv.ParseFloat(op.Price, 64)
+	if e != nil {
+		return 0, fmt.Errorf("could not parse price '%s': %s", op.Price, e)
+	}
+	return valueFn(op.Selling, strconv.FormatFloat(buyAmount*price, 'f', -1, 64))
+}
+
+// valueLiquidityPoolDeposit values the max amounts of a deposit into the liquidity pool of the base and quote assets
+func valueLiquidityPoolDeposit(
+	op *txnbuild.LiquidityPoolDeposit,
+	assetBase hProtocol.Asset,
+	assetQuote hProtocol.Asset,
+	valueFn func(asset txnbuild.Asset, amountString string) (float64, error),
+) (float64, error) {
+	poolParams, poolID, e := makeLiquidityPoolParams(&assetBase, &assetQuote)
+	if e != nil {
+		return 0, e
+	}
+	if op.LiquidityPoolID != poolID {
+		return 0, fmt.Errorf("cannot value a deposit into a liquidity pool that is not the pool of the base and quote assets")
+	}
+
+	valueA, e := valueFn(poolParams.AssetA, op.MaxAmountA)
+	if e != nil {
+		return 0, e
+	}
+	valueB, e := valueFn(poolParams.AssetB, op.MaxAmountB)
+	if e != nil {
+		return 0, e
+	}
+	return valueA + valueB, nil
+}
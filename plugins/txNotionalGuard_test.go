@@ -0,0 +1,167 @@
+package plugins
+
+import (
+	"fmt"
+	"testing"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/kelp/support/utils"
+)
+
+var txNotionalTestAssetBase = hProtocol.Asset{Type: utils.Native}
+var txNotionalTestAssetQuote = hProtocol.Asset{Type: "credit_alphanum4", Code: "USD", Issuer: "GAXEMCEXBERNSRXOEKD4JAIKVECIXQCENHEBRVSPX2TTYZPMNEDSQCNQ"}
+
+func TestComputeTxNotional(t *testing.T) {
+	base := utils.Asset2Asset(txNotionalTestAssetBase)
+	quote := utils.Asset2Asset(txNotionalTestAssetQuote)
+	other := txnbuild.CreditAsset{Code: "EUR", Issuer: "GAXEMCEXBERNSRXOEKD4JAIKVECIXQCENHEBRVSPX2TTYZPMNEDSQCNQ"}
+	unknown := txnbuild.CreditAsset{Code: "GBP", Issuer: "GAXEMCEXBERNSRXOEKD4JAIKVECIXQCENHEBRVSPX2TTYZPMNEDSQCNQ"}
+
+	testCases := []struct {
+		name         string
+		ops          []txnbuild.Operation
+		wantNotional float64
+		wantError    bool
+	}{
+		{
+			name: "sell and buy offers",
+			ops: []txnbuild.Operation{
+				// sells 100 of the base asset
+				&txnbuild.ManageSellOffer{Selling: base, Buying: quote, Amount: "100", Price: "0.11"},
+				// buys the base asset with 5 of the quote asset
+				&txnbuild.ManageSellOffer{Selling: quote, Buying: base, Amount: "5", Price: "11.1111111"},
+			},
+			wantNotional: 15.0,
+		}, {
+			name: "deleting offers has no notional",
+			ops: []txnbuild.Operation{
+				&txnbuild.ManageSellOffer{Selling: base, Buying: quote, Amount: "0", Price: "0.11", OfferID: 1},
+				&txnbuild.ManageSellOffer{Selling: quote, Buying: base, Amount: "0", Price: "11.1111111", OfferID: 2},
+			},
+			wantNotional: 0.0,
+		}, {
+			name: "payments",
+			ops: []txnbuild.Operation{
+				&txnbuild.Payment{Destination: "GAXEMCEXBERNSRXOEKD4JAIKVECIXQCENHEBRVSPX2TTYZPMNEDSQCNQ", Asset: quote, Amount: "2.5"},
+				&txnbuild.PathPaymentStrictSend{SendAsset: base, SendAmount: "10", DestAsset: quote, DestMin: "0.9"},
+			},
+			wantNotional: 3.5,
+		}, {
+			name: "ops without an amount",
+			ops: []txnbuild.Operation{
+				&txnbuild.ChangeTrust{Line: other.MustToChangeTrustAsset()},
+			},
+			wantNotional: 0.0,
+		}, {
+			name: "buy offers are valued on the selling side",
+			ops: []txnbuild.Operation{
+				// buys 100 of the base asset with 100 * 0.11 = 11 of the quote asset
+				&txnbuild.ManageBuyOffer{Selling: quote, Buying: base, Amount: "100", Price: "0.11"},
+				// buys 5 of the quote asset with 5 * 10 = 50 of the base asset, which is worth 5
+				&txnbuild.ManageBuyOffer{Selling: base, Buying: quote, Amount: "5", Price: "10"},
+			},
+			wantNotional: 16.0,
+		}, {
+			name: "asset with a feed",
+			ops: []txnbuild.Operation{
+				&txnbuild.ManageSellOffer{Selling: other, Buying: base, Amount: "4", Price: "1.0"},
+			},
+			wantNotional: 6.0,
+		}, {
+			name: "unknown asset",
+			ops: []txnbuild.Operation{
+				&txnbuild.ManageSellOffer{Selling: unknown, Buying: quote, Amount: "1", Price: "1.0"},
+			},
+			wantError: true,
+		},
+	}
+
+	prices := map[string]float64{
+		utils.Asset2String(txNotionalTestAssetBase):   0.1,
+		utils.Asset2String(utils.Asset2Asset2(other)): 1.5,
+	}
+	priceFn := func(assetString string) (float64, error) {
+		price, ok := prices[assetString]
+		if !ok {
+			return 0, fmt.Errorf("no price for asset %s", assetString)
+		}
+		return price, nil
+	}
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			notional, e := computeTxNotional(k.ops, txNotionalTestAssetBase, txNotionalTestAssetQuote, priceFn)
+			if k.wantError {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.InDelta(t, k.wantNotional, notional, 0.0000001)
+		})
+	}
+}
+
+func TestTxNotionalGuard(t *testing.T) {
+	referenceFeed, e := newFixedFeed("0.1")
+	if !assert.NoError(t, e) {
+		return
+	}
+	ops := []txnbuild.Operation{
+		&txnbuild.ManageSellOffer{Selling: utils.Asset2Asset(txNotionalTestAssetBase), Buying: utils.Asset2Asset(txNotionalTestAssetQuote), Amount: "1000", Price: "0.1"},
+	}
+
+	guard, e := MakeTxNotionalGuard(100.0, referenceFeed, txNotionalTestAssetBase, txNotionalTestAssetQuote, false)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.NoError(t, guard.Check(ops))
+
+	guard, e = MakeTxNotionalGuard(99.0, referenceFeed, txNotionalTestAssetBase, txNotionalTestAssetQuote, false)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Error(t, guard.Check(ops))
+
+	// the override submits the transaction anyway
+	guard, e = MakeTxNotionalGuard(99.0, referenceFeed, txNotionalTestAssetBase, txNotionalTestAssetQuote, true)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.NoError(t, guard.Check(ops))
+
+	_, e = MakeTxNotionalGuard(0, referenceFeed, txNotionalTestAssetBase, txNotionalTestAssetQuote, false)
+	assert.Error(t, e)
+}
+
+func TestTxNotionalGuard_AddAssetFeed(t *testing.T) {
+	referenceFeed, e := newFixedFeed("0.1")
+	if !assert.NoError(t, e) {
+		return
+	}
+	parFeed, e := newFixedFeed("1.0")
+	if !assert.NoError(t, e) {
+		return
+	}
+	// the quote asset of another issuer, such as the asset that bids are quoted in with BID_ASSET_CODE_B
+	otherQuote := hProtocol.Asset{Type: "credit_alphanum4", Code: "USD", Issuer: "GCQCXCHVJCDPCQKHE4HBTKFEPJRHXGPBYPJB7YUOQ5ANGF4SCZ6AIGVQ"}
+	ops := []txnbuild.Operation{
+		&txnbuild.ManageSellOffer{Selling: utils.Asset2Asset(otherQuote), Buying: utils.Asset2Asset(txNotionalTestAssetBase), Amount: "50", Price: "10"},
+	}
+
+	guard, e := MakeTxNotionalGuard(100.0, referenceFeed, txNotionalTestAssetBase, txNotionalTestAssetQuote, false)
+	if !assert.NoError(t, e) {
+		return
+	}
+	// an asset without a feed cannot be valued so the transaction is refused
+	assert.Error(t, guard.Check(ops))
+
+	assert.NoError(t, guard.AddAssetFeed(otherQuote, parFeed))
+	assert.NoError(t, guard.Check(ops))
+	assert.Error(t, guard.AddAssetFeed(otherQuote, parFeed))
+	assert.Error(t, guard.AddAssetFeed(txNotionalTestAssetBase, parFeed))
+	assert.Error(t, guard.AddAssetFeed(txNotionalTestAssetQuote, parFeed))
+}
@@ -0,0 +1,234 @@
+package plugins
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/stellar/kelp/api"
+)
+
+// FeedPriceBounds are the absolute bounds of the prices accepted from a price feed, a bound of 0 is not checked
+type FeedPriceBounds struct {
+	Min float64
+	Max float64
+}
+
+// FeedValidationConfig is the validation applied to every price feed so the bot skips an update instead of placing offers on a bad price
+type FeedValidationConfig struct {
+	// MaxStaleness rejects prices that were last updated longer ago than this, 0 disables the check
+	MaxStaleness time.Duration
+	// MaxChangeFraction rejects prices that are more than this fraction away from the last accepted price of the feed (0.1 = 10%), 0
+	// disables the check
+	MaxChangeFraction float64
+	// ReanchorRejections accepts a price that is too far away from the last accepted price once this many prices in a row were rejected
+	// for being too far away, so the feed recovers from a lasting move of the market
+	ReanchorRejections int
+	// ReanchorWindow accepts a price that is too far away from the last accepted price once prices were rejected for being too far away
+	// for this long, 0 disables it
+	ReanchorWindow time.Duration
+	// PriceBounds are keyed by the feed in the format <type>/<url> as written in the config, e.g. "exchange/ccxt-kraken/XLM/USD"
+	PriceBounds map[string]FeedPriceBounds
+}
+
+// defaultFeedReanchorRejections is the ReanchorRejections when neither FEED_REANCHOR_REJECTIONS nor FEED_REANCHOR_SECONDS are set, so a
+// feed with a max change does not reject the prices forever after the market moved
+const defaultFeedReanchorRejections = 10
+
+// MakeFeedValidationConfig is a factory method that validates the values from the trader config, priceBounds maps a feed to the list
+// [min, max] of its bounds
+func MakeFeedValidationConfig(
+	maxStalenessSeconds int32,
+	maxChangeFraction float64,
+	reanchorRejections int,
+	reanchorSeconds int32,
+	priceBounds map[string][]float64,
+) (*FeedValidationConfig, error) {
+	if maxStalenessSeconds < 0 {
+		return nil, fmt.Errorf("FEED_MAX_STALENESS_SECONDS cannot be negative, use 0 to disable it: %d", maxStalenessSeconds)
+	}
+	if maxChangeFraction < 0 {
+		return nil, fmt.Errorf("FEED_MAX_CHANGE_FRACTION cannot be negative, use 0 to disable it: %f", maxChangeFraction)
+	}
+	if reanchorRejections < 0 {
+		return nil, fmt.Errorf("FEED_REANCHOR_REJECTIONS cannot be negative, use 0 to disable it: %d", reanchorRejections)
+	}
+	if reanchorSeconds < 0 {
+		return nil, fmt.Errorf("FEED_REANCHOR_SECONDS cannot be negative, use 0 to disable it: %d", reanchorSeconds)
+	}
+	if maxChangeFraction > 0 && reanchorRejections == 0 && reanchorSeconds == 0 {
+		reanchorRejections = defaultFeedReanchorRejections
+	}
+
+	bounds := map[string]FeedPriceBounds{}
+	for feed, minMax := range priceBounds {
+		if len(minMax) != 2 {
+			return nil, fmt.Errorf("the FEED_PRICE_BOUNDS of feed '%s' needs to be a list of [min, max] but was %v", feed, minMax)
+		}
+		if minMax[0] < 0 || minMax[1] < 0 {
+			return nil, fmt.Errorf("the FEED_PRICE_BOUNDS of feed '%s' cannot be negative, use 0 to leave a bound unset: %v", feed, minMax)
+		}
+		if minMax[1] != 0 && minMax[0] > minMax[1] {
+			return nil, fmt.Errorf("the min of the FEED_PRICE_BOUNDS of feed '%s' cannot be greater than the max: %v", feed, minMax)
+		}
+		bounds[feed] = FeedPriceBounds{
+			Min: minMax[0],
+			Max: minMax[1],
+		}
+	}
+
+	return &FeedValidationConfig{
+		MaxStaleness:       time.Duration(maxStalenessSeconds) * time.Second,
+		MaxChangeFraction:  maxChangeFraction,
+		ReanchorRejections: reanchorRejections,
+		ReanchorWindow:     time.Duration(reanchorSeconds) * time.Second,
+		PriceBounds:        bounds,
+	}, nil
+}
+
+// String is the Stringer method
+func (c *FeedValidationConfig) String() string {
+	return fmt.Sprintf("FeedValidationConfig[MaxStaleness=%s, MaxChangeFraction=%.4f, ReanchorRejections=%d, ReanchorWindow=%s, PriceBounds=%v]",
+		c.MaxStaleness, c.MaxChangeFraction, c.ReanchorRejections, c.ReanchorWindow, c.PriceBounds)
+}
+
+var feedValidationConfigLock = &sync.Mutex{}
+
+// feedValidationConfig is applied to every price feed that is made after it is set with SetFeedValidationConfig, it is nil when disabled
+var feedValidationConfig *FeedValidationConfig
+
+// SetFeedValidationConfig sets the validation that is applied to every price feed made by MakePriceFeed, nil disables the validation
+func SetFeedValidationConfig(config *FeedValidationConfig) {
+	feedValidationConfigLock.Lock()
+	defer feedValidationConfigLock.Unlock()
+
+	feedValidationConfig = config
+}
+
+// withFeedValidation wraps the feed with the validation set by SetFeedValidationConfig, the feed is returned as-is when there is nothing
+// to validate for it
+func withFeedValidation(feedType string, url string, feed api.PriceFeed) api.PriceFeed {
+	feedValidationConfigLock.Lock()
+	config := feedValidationConfig
+	feedValidationConfigLock.Unlock()
+
+	if config == nil {
+		return feed
+	}
+	name := feedType + "/" + url
+	bounds := config.PriceBounds[name]
+	if config.MaxStaleness == 0 && config.MaxChangeFraction == 0 && bounds.Min == 0 && bounds.Max == 0 {
+		return feed
+	}
+	return makeValidatedFeed(name, feed, *config, MakeSystemClock())
+}
+
+// validatedFeed is a price feed that returns an error instead of a price that is stale, out of bounds, or too far away from the last
+// accepted price, which makes the strategy fail the update so the bot skips it. Prices that are too far away are accepted again once the
+// feed was rejected for the configured number of prices or time, and become the price that later changes are measured from
+type validatedFeed struct {
+	name   string
+	feed   api.PriceFeed
+	config FeedValidationConfig
+	bounds FeedPriceBounds
+	clock  api.Clock
+
+	// uninitialized
+	lastAcceptedPrice     *float64
+	numRejectedChanges    int
+	firstRejectedChangeAt time.Time
+}
+
+// ensure that it implements TimestampedPriceFeed so nested validated feeds keep the timestamp of the underlying feed
+var _ api.TimestampedPriceFeed = &validatedFeed{}
+
+func makeValidatedFeed(name string, feed api.PriceFeed, config FeedValidationConfig, clock api.Clock) *validatedFeed {
+	return &validatedFeed{
+		name:   name,
+		feed:   feed,
+		config: config,
+		bounds: config.PriceBounds[name],
+		clock:  clock,
+	}
+}
+
+// GetPrice impl
+func (f *validatedFeed) GetPrice() (float64, error) {
+	price, _, e := f.GetPriceWithTimestamp()
+	return price, e
+}
+
+// GetPriceWithTimestamp impl
+func (f *validatedFeed) GetPriceWithTimestamp() (float64, time.Time, error) {
+	var price float64
+	var ts time.Time
+	var e error
+	if tsFeed, ok := f.feed.(api.TimestampedPriceFeed); ok {
+		price, ts, e = tsFeed.GetPriceWithTimestamp()
+	} else {
+		price, e = f.feed.GetPrice()
+		ts = f.clock.Now()
+	}
+	if e != nil {
+		return 0, time.Time{}, e
+	}
+
+	e = f.validate(price, ts)
+	if e == nil {
+		e = f.validateChange(price)
+	}
+	if e != nil {
+		log.Printf("rejected price %.8f from feed '%s': %s\n", price, f.name, e)
+		return 0, time.Time{}, fmt.Errorf("rejected price from feed '%s': %s", f.name, e)
+	}
+	f.lastAcceptedPrice = &price
+	f.numRejectedChanges = 0
+	return price, ts, nil
+}
+
+func (f *validatedFeed) validate(price float64, ts time.Time) error {
+	if math.IsNaN(price) || math.IsInf(price, 0) {
+		return fmt.Errorf("price %f is not a number", price)
+	}
+	if f.config.MaxStaleness > 0 {
+		age := f.clock.Now().Sub(ts)
+		if age > f.config.MaxStaleness {
+			return fmt.Errorf("price %.8f was last updated %s ago at %s, which is older than the max staleness of %s", price, age, ts.UTC().Format(time.RFC3339), f.config.MaxStaleness)
+		}
+	}
+	if f.bounds.Min > 0 && price < f.bounds.Min {
+		return fmt.Errorf("price %.8f is below the min bound of %.8f", price, f.bounds.Min)
+	}
+	if f.bounds.Max > 0 && price > f.bounds.Max {
+		return fmt.Errorf("price %.8f is above the max bound of %.8f", price, f.bounds.Max)
+	}
+	return nil
+}
+
+// validateChange rejects a price that is too far away from the last accepted price, unless the feed needs to be re-anchored on the price
+func (f *validatedFeed) validateChange(price float64) error {
+	if f.config.MaxChangeFraction == 0 || f.lastAcceptedPrice == nil || *f.lastAcceptedPrice == 0 {
+		return nil
+	}
+	change := math.Abs(price-*f.lastAcceptedPrice) / *f.lastAcceptedPrice
+	if change <= f.config.MaxChangeFraction {
+		return nil
+	}
+
+	now := f.clock.Now()
+	if f.numRejectedChanges == 0 {
+		f.firstRejectedChangeAt = now
+	}
+	rejectedFor := now.Sub(f.firstRejectedChangeAt)
+	if (f.config.ReanchorRejections > 0 && f.numRejectedChanges >= f.config.ReanchorRejections) ||
+		(f.config.ReanchorWindow > 0 && f.numRejectedChanges > 0 && rejectedFor >= f.config.ReanchorWindow) {
+		log.Printf("re-anchoring feed '%s' on price %.8f, which is %.4f away from the last accepted price of %.8f, after rejecting %d prices in a row for %s\n",
+			f.name, price, change, *f.lastAcceptedPrice, f.numRejectedChanges, rejectedFor)
+		return nil
+	}
+	f.numRejectedChanges++
+	return fmt.Errorf("price %.8f is %.4f away from the last accepted price of %.8f, which is more than the max change of %.4f (rejected %d prices in a row)",
+		price, change, *f.lastAcceptedPrice, f.config.MaxChangeFraction, f.numRejectedChanges)
+}
@@ -0,0 +1,201 @@
+package plugins
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type timestampedTestFeed struct {
+	price float64
+	ts    time.Time
+}
+
+// GetPrice impl
+func (f *timestampedTestFeed) GetPrice() (float64, error) {
+	return f.price, nil
+}
+
+// GetPriceWithTimestamp impl
+func (f *timestampedTestFeed) GetPriceWithTimestamp() (float64, time.Time, error) {
+	return f.price, f.ts, nil
+}
+
+func TestValidatedFeed(t *testing.T) {
+	now := time.Unix(10000, 0)
+	testCases := []struct {
+		name         string
+		config       FeedValidationConfig
+		prices       []float64
+		ages         []time.Duration
+		wantAccepted []bool
+	}{
+		{
+			name:         "no checks",
+			prices:       []float64{1.0, 100.0},
+			ages:         []time.Duration{0, time.Hour},
+			wantAccepted: []bool{true, true},
+		}, {
+			name:         "stale",
+			config:       FeedValidationConfig{MaxStaleness: time.Minute},
+			prices:       []float64{1.0, 1.0, 1.0},
+			ages:         []time.Duration{59 * time.Second, 60 * time.Second, 61 * time.Second},
+			wantAccepted: []bool{true, true, false},
+		}, {
+			name:         "bounds",
+			config:       FeedValidationConfig{PriceBounds: map[string]FeedPriceBounds{"test/feed": {Min: 0.5, Max: 2.0}}},
+			prices:       []float64{0.4, 0.5, 2.0, 2.1},
+			ages:         []time.Duration{0, 0, 0, 0},
+			wantAccepted: []bool{false, true, true, false},
+		}, {
+			name:         "min bound only",
+			config:       FeedValidationConfig{PriceBounds: map[string]FeedPriceBounds{"test/feed": {Min: 0.5}}},
+			prices:       []float64{0.4, 1000.0},
+			ages:         []time.Duration{0, 0},
+			wantAccepted: []bool{false, true},
+		}, {
+			// the change is measured from the last accepted price so a rejected price does not move the reference
+			name:         "max change",
+			config:       FeedValidationConfig{MaxChangeFraction: 0.1},
+			prices:       []float64{1.0, 1.05, 1.3, 1.25, 1.15},
+			ages:         []time.Duration{0, 0, 0, 0, 0},
+			wantAccepted: []bool{true, true, false, false, true},
+		}, {
+			// the price after 2 rejections is accepted and later changes are measured from it
+			name:         "re-anchor after rejections",
+			config:       FeedValidationConfig{MaxChangeFraction: 0.1, ReanchorRejections: 2},
+			prices:       []float64{1.0, 1.5, 1.5, 1.5, 1.6, 1.0, 1.0, 1.5},
+			ages:         []time.Duration{0, 0, 0, 0, 0, 0, 0, 0},
+			wantAccepted: []bool{true, false, false, true, true, false, false, true},
+		}, {
+			// an accepted price resets the count of rejections
+			name:         "rejections need to be in a row",
+			config:       FeedValidationConfig{MaxChangeFraction: 0.1, ReanchorRejections: 2},
+			prices:       []float64{1.0, 1.5, 1.05, 1.5, 1.5},
+			ages:         []time.Duration{0, 0, 0, 0, 0},
+			wantAccepted: []bool{true, false, true, false, false},
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			clock := MakeManualClock(now)
+			inner := &timestampedTestFeed{}
+			f := makeValidatedFeed("test/feed", inner, k.config, clock)
+
+			for i, price := range k.prices {
+				inner.price = price
+				inner.ts = now.Add(-k.ages[i])
+				p, ts, e := f.GetPriceWithTimestamp()
+				if k.wantAccepted[i] {
+					if !assert.NoError(t, e, fmt.Sprintf("price index %d", i)) {
+						return
+					}
+					assert.Equal(t, price, p)
+					assert.Equal(t, inner.ts, ts)
+				} else {
+					assert.Error(t, e, fmt.Sprintf("price index %d", i))
+				}
+			}
+		})
+	}
+}
+
+func TestValidatedFeed_NoTimestamp(t *testing.T) {
+	clock := MakeManualClock(time.Unix(10000, 0))
+	f := makeValidatedFeed("fixed/1.5", &fixedFeed{price: 1.5}, FeedValidationConfig{MaxStaleness: time.Minute}, clock)
+
+	// feeds without a timestamp are never stale
+	p, e := f.GetPrice()
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, 1.5, p)
+}
+
+func TestValidatedFeed_ReanchorWindow(t *testing.T) {
+	now := time.Unix(10000, 0)
+	clock := MakeManualClock(now)
+	inner := &fixedFeed{price: 1.0}
+	f := makeValidatedFeed("fixed/1.0", inner, FeedValidationConfig{MaxChangeFraction: 0.1, ReanchorWindow: time.Minute}, clock)
+	_, e := f.GetPrice()
+	if !assert.NoError(t, e) {
+		return
+	}
+
+	inner.price = 2.0
+	_, e = f.GetPrice()
+	assert.Error(t, e)
+	clock.Set(now.Add(59 * time.Second))
+	_, e = f.GetPrice()
+	assert.Error(t, e)
+
+	// the feed is re-anchored on the new price once it was rejected for the window
+	clock.Set(now.Add(time.Minute))
+	p, e := f.GetPrice()
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, 2.0, p)
+	inner.price = 2.1
+	_, e = f.GetPrice()
+	assert.NoError(t, e)
+}
+
+func TestMakeFeedValidationConfig(t *testing.T) {
+	c, e := MakeFeedValidationConfig(120, 0.1, 0, 0, map[string][]float64{"fixed/1.0": {0.5, 2.0}})
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, 2*time.Minute, c.MaxStaleness)
+	assert.Equal(t, 0.1, c.MaxChangeFraction)
+	assert.Equal(t, defaultFeedReanchorRejections, c.ReanchorRejections)
+	assert.Equal(t, time.Duration(0), c.ReanchorWindow)
+	assert.Equal(t, FeedPriceBounds{Min: 0.5, Max: 2.0}, c.PriceBounds["fixed/1.0"])
+
+	// the default number of rejections is not used when the window is set
+	c, e = MakeFeedValidationConfig(0, 0.1, 0, 300, nil)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, 0, c.ReanchorRejections)
+	assert.Equal(t, 5*time.Minute, c.ReanchorWindow)
+
+	for _, k := range []struct {
+		name               string
+		staleness          int32
+		change             float64
+		reanchorRejections int
+		reanchorSeconds    int32
+		priceBounds        map[string][]float64
+	}{
+		{"negative staleness", -1, 0, 0, 0, nil},
+		{"negative change", 0, -0.1, 0, 0, nil},
+		{"negative re-anchor rejections", 0, 0.1, -1, 0, nil},
+		{"negative re-anchor seconds", 0, 0.1, 0, -1, nil},
+		{"one bound", 0, 0, 0, 0, map[string][]float64{"fixed/1.0": {0.5}}},
+		{"negative bound", 0, 0, 0, 0, map[string][]float64{"fixed/1.0": {-0.5, 2.0}}},
+		{"min greater than max", 0, 0, 0, 0, map[string][]float64{"fixed/1.0": {3.0, 2.0}}},
+	} {
+		_, e := MakeFeedValidationConfig(k.staleness, k.change, k.reanchorRejections, k.reanchorSeconds, k.priceBounds)
+		assert.Error(t, e, k.name)
+	}
+}
+
+func TestWithFeedValidation(t *testing.T) {
+	defer SetFeedValidationConfig(nil)
+
+	feed := &fixedFeed{price: 1.0}
+	SetFeedValidationConfig(nil)
+	assert.Equal(t, feed, withFeedValidation("fixed", "1.0", feed))
+
+	// bounds of other feeds do not apply
+	SetFeedValidationConfig(&FeedValidationConfig{PriceBounds: map[string]FeedPriceBounds{"fixed/2.0": {Min: 3.0}}})
+	assert.Equal(t, feed, withFeedValidation("fixed", "1.0", feed))
+
+	SetFeedValidationConfig(&FeedValidationConfig{PriceBounds: map[string]FeedPriceBounds{"fixed/1.0": {Min: 3.0}}})
+	_, e := withFeedValidation("fixed", "1.0", feed).GetPrice()
+	assert.Error(t, e)
+}
@@ -0,0 +1,286 @@
+package plugins
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// VolatilityAction is what the volatility filter does while the volatility is above the max
+type VolatilityAction string
+
+// type of VolatilityAction
+const (
+	VolatilityActionPause VolatilityAction = "pause" // delete all offers until the volatility subsides
+	VolatilityActionWiden VolatilityAction = "widen" // move the prices of the ops away from the price of the feed
+)
+
+// volatilityFilter is a circuit breaker that samples the price feed once per Apply and measures the volatility as the standard deviation
+// of the last numSamples prices relative to their mean. While the volatility is above maxVolatilityPercent it either deletes all offers or
+// widens the spread of the ops and the existing offers by spreadMultiplier, and it resumes normal quoting once the volatility is back below
+// the max
+type volatilityFilter struct {
+	name                 string
+	configValue          string
+	baseAsset            hProtocol.Asset
+	quoteAsset           hProtocol.Asset
+	pf                   api.PriceFeed
+	numSamples           int
+	maxVolatilityPercent float64
+	action               VolatilityAction
+	spreadMultiplier     float64
+	alert                api.Alert
+
+	// uninitialized
+	samples    []float64
+	isVolatile bool
+	// widenedPrices holds the prices that this filter widened ops to since the volatility went above the max, keyed by offerPriceKey,
+	// so the existing offers at those prices are not widened again on every update
+	widenedPrices map[string]bool
+}
+
+// volatilityAlertDetails is sent with the alert when the volatility goes above the max
+type volatilityAlertDetails struct {
+	Volatility           float64 `json:"volatility"`
+	MaxVolatilityPercent float64 `json:"max_volatility_percent"`
+	NumSamples           int     `json:"num_samples"`
+	Action               string  `json:"action"`
+}
+
+// makeFilterVolatility makes a submit filter that pauses quoting or widens spreads when the price feed is volatile, spreadMultiplier is only
+// used with VolatilityActionWiden
+func makeFilterVolatility(
+	configValue string,
+	baseAsset hProtocol.Asset,
+	quoteAsset hProtocol.Asset,
+	alert api.Alert,
+	numSamples int,
+	maxVolatilityPercent float64,
+	action VolatilityAction,
+	spreadMultiplier float64,
+	pf api.PriceFeed,
+) (SubmitFilter, error) {
+	if numSamples < 2 {
+		return nil, fmt.Errorf("invalid number of samples, expected >= 2; was %d", numSamples)
+	}
+	if maxVolatilityPercent <= 0.0 {
+		return nil, fmt.Errorf("invalid max volatility percent, expected > 0.0; was %f", maxVolatilityPercent)
+	}
+	if action != VolatilityActionPause && action != VolatilityActionWiden {
+		return nil, fmt.Errorf("invalid action, needs to be either \"%s\" or \"%s\"; was \"%s\"", VolatilityActionPause, VolatilityActionWiden, action)
+	}
+	if action == VolatilityActionWiden && spreadMultiplier <= 1.0 {
+		return nil, fmt.Errorf("invalid spread multiplier, expected > 1.0; was %f", spreadMultiplier)
+	}
+
+	return &volatilityFilter{
+		name:                 "volatilityFilter",
+		configValue:          configValue,
+		baseAsset:            baseAsset,
+		quoteAsset:           quoteAsset,
+		pf:                   pf,
+		numSamples:           numSamples,
+		maxVolatilityPercent: maxVolatilityPercent,
+		action:               action,
+		spreadMultiplier:     spreadMultiplier,
+		alert:                alert,
+	}, nil
+}
+
+var _ SubmitFilter = &volatilityFilter{}
+var _ OrderedSubmitFilter = &volatilityFilter{}
+
+// FilterOrder impl.
+func (f *volatilityFilter) FilterOrder() FilterOrder {
+	return FilterOrder{
+		ID:       filterIDVolatility,
+		Priority: FilterPriorityRisk,
+	}
+}
+
+// Apply impl.
+func (f *volatilityFilter) Apply(ops []txnbuild.Operation, sellingOffers []hProtocol.Offer, buyingOffers []hProtocol.Offer) ([]txnbuild.Operation, error) {
+	price, e := f.pf.GetPrice()
+	if e != nil {
+		return nil, fmt.Errorf("could not get price from priceFeed: %s", e)
+	}
+	if price <= 0.0 {
+		return nil, fmt.Errorf("invalid price from priceFeed, expected > 0.0; was %f", price)
+	}
+
+	volatility, isVolatile, hasEnoughSamples := f.update(price)
+	if !hasEnoughSamples {
+		log.Printf("volatilityFilter: collected %d of %d samples, not measuring volatility yet\n", len(f.samples), f.numSamples)
+		return ops, nil
+	}
+	log.Printf("volatilityFilter: price=%.10f, volatility=%.6f, maxVolatilityPercent=%.6f, isVolatile=%v\n", price, volatility, f.maxVolatilityPercent, isVolatile)
+	if !isVolatile {
+		return ops, nil
+	}
+
+	if f.action == VolatilityActionPause {
+		ops, e = filterOps(f.name, f.baseAsset, f.quoteAsset, sellingOffers, buyingOffers, ops, f.pauseFilterFn)
+		if e != nil {
+			return nil, fmt.Errorf("could not apply filter: %s", e)
+		}
+		return ops, nil
+	}
+
+	// the existing offers that the strategy left alone are widened too, except for the ones that are already at a price that we widened to
+	// since widening them again would push them further away on every update
+	opOfferIDs := map[int64]bool{}
+	for _, op := range ops {
+		if mso, ok := op.(*txnbuild.ManageSellOffer); ok && mso.OfferID != 0 {
+			opOfferIDs[mso.OfferID] = true
+		}
+	}
+	opsToWiden := append([]txnbuild.Operation{}, ops...)
+	offerOps := map[*txnbuild.ManageSellOffer]bool{}
+	for _, offer := range append(append([]hProtocol.Offer{}, sellingOffers...), buyingOffers...) {
+		if opOfferIDs[offer.ID] || f.widenedPrices[offerPriceKey(offer.Selling, offer.Price)] {
+			continue
+		}
+		offerOp := utils.Offer2TxnBuildSellOffer(offer)
+		opsToWiden = append(opsToWiden, &offerOp)
+		offerOps[&offerOp] = true
+	}
+
+	filteredOps := []txnbuild.Operation{}
+	for _, op := range opsToWiden {
+		mso, ok := op.(*txnbuild.ManageSellOffer)
+		if !ok || mso.Amount == "0" {
+			filteredOps = append(filteredOps, op)
+			continue
+		}
+
+		newOp, e := f.widenFilterFn(price, mso)
+		if e != nil {
+			return nil, fmt.Errorf("could not widen op: %s", e)
+		}
+		if newOp == mso && offerOps[mso] {
+			// the existing offer did not need to be widened so we do not need to modify it
+			continue
+		} else if newOp != nil {
+			f.widenedPrices[offerPriceKey(utils.Asset2Asset2(newOp.Selling), newOp.Price)] = true
+			filteredOps = append(filteredOps, newOp)
+		} else if mso.OfferID != 0 {
+			// the op was going to update an existing offer so we need to delete that offer instead of dropping the op
+			deleteOp := *mso
+			deleteOp.Amount = "0"
+			filteredOps = append(filteredOps, &deleteOp)
+		}
+	}
+	return filteredOps, nil
+}
+
+// offerPriceKey identifies the side and price of an offer or op, the price is normalized since horizon does not return the price of an offer
+// in the same format as the op that created it
+func offerPriceKey(selling hProtocol.Asset, price string) string {
+	return fmt.Sprintf("%s@%.7f", utils.Asset2String(selling), utils.PriceAsFloat(price))
+}
+
+// update adds the price to the rolling window of samples and returns the volatility and whether it is above the max. The third return
+// value is false until the window is full, in which case the volatility is not measured. Transitions in and out of the volatile state
+// are logged and an alert is triggered when the volatility goes above the max
+func (f *volatilityFilter) update(price float64) (float64 /* volatility */, bool /* isVolatile */, bool /* hasEnoughSamples */) {
+	f.samples = append(f.samples, price)
+	if len(f.samples) > f.numSamples {
+		f.samples = f.samples[len(f.samples)-f.numSamples:]
+	}
+	if len(f.samples) < f.numSamples {
+		return 0, false, false
+	}
+
+	volatility := computeVolatility(f.samples)
+	wasVolatile := f.isVolatile
+	f.isVolatile = volatility > f.maxVolatilityPercent
+	if f.isVolatile != wasVolatile {
+		f.widenedPrices = map[string]bool{}
+	}
+	if f.isVolatile && !wasVolatile {
+		description := fmt.Sprintf("volatilityFilter: volatility of %.4f%% over the last %d samples exceeds the max volatility of %.4f%%, action=%s",
+			volatility*100, f.numSamples, f.maxVolatilityPercent*100, f.action)
+		log.Println(description)
+		if f.alert != nil {
+			e := f.alert.Trigger(description, volatilityAlertDetails{
+				Volatility:           volatility,
+				MaxVolatilityPercent: f.maxVolatilityPercent,
+				NumSamples:           f.numSamples,
+				Action:               string(f.action),
+			})
+			if e != nil {
+				log.Printf("volatilityFilter: unable to trigger alert: %s\n", e)
+			}
+		}
+	} else if !f.isVolatile && wasVolatile {
+		log.Printf("volatilityFilter: volatility of %.4f%% is back below the max volatility of %.4f%%, resuming normal quoting\n", volatility*100, f.maxVolatilityPercent*100)
+	}
+	return volatility, f.isVolatile, true
+}
+
+// computeVolatility returns the standard deviation of the prices relative to their mean
+func computeVolatility(prices []float64) float64 {
+	mean := 0.0
+	for _, p := range prices {
+		mean += p
+	}
+	mean = mean / float64(len(prices))
+	if mean <= 0.0 {
+		return 0.0
+	}
+
+	variance := 0.0
+	for _, p := range prices {
+		variance += (p - mean) * (p - mean)
+	}
+	variance = variance / float64(len(prices))
+	return math.Sqrt(variance) / mean
+}
+
+func (f *volatilityFilter) pauseFilterFn(op *txnbuild.ManageSellOffer) (*txnbuild.ManageSellOffer, error) {
+	return nil, nil
+}
+
+// widenFilterFn moves the price of the op away from the mid price by spreadMultiplier, keeping the same amount of the base asset. Ops that are
+// on the wrong side of the mid price are left as they are
+func (f *volatilityFilter) widenFilterFn(midPrice float64, op *txnbuild.ManageSellOffer) (*txnbuild.ManageSellOffer, error) {
+	isSell, e := utils.IsSelling(f.baseAsset, f.quoteAsset, op.Selling, op.Buying)
+	if e != nil {
+		return nil, fmt.Errorf("error when running the isSelling check for offer '%+v': %s", *op, e)
+	}
+
+	sellPrice, e := strconv.ParseFloat(op.Price, 64)
+	if e != nil {
+		return nil, fmt.Errorf("could not convert price (%s) to float: %s", op.Price, e)
+	}
+
+	// reorient price to be in the context of the bot's base and quote asset, in quote units
+	price := sellPrice
+	if !isSell {
+		// invert price for buy side
+		price = 1 / sellPrice
+	}
+
+	if (isSell && price <= midPrice) || (!isSell && price >= midPrice) {
+		log.Printf("volatilityFilter: isSell=%v, price=%.10f is on the wrong side of midPrice=%.10f, keep=true", isSell, price, midPrice)
+		return op, nil
+	}
+
+	newPrice := midPrice + (price-midPrice)*f.spreadMultiplier
+	if newPrice <= 0.0 {
+		log.Printf("volatilityFilter: isSell=%v, price=%.10f cannot be widened to %.10f, keep=false", isSell, price, newPrice)
+		return nil, nil
+	}
+	return repriceOfferKeepingBaseAmount(f.name, isSell, price, newPrice, op)
+}
+
+// String is the Stringer method
+func (f *volatilityFilter) String() string {
+	return f.configValue
+}
@@ -0,0 +1,215 @@
+package plugins
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/kelp/support/utils"
+)
+
+func TestComputeVolatility(t *testing.T) {
+	testCases := []struct {
+		name   string
+		prices []float64
+		want   float64
+	}{
+		{name: "flat", prices: []float64{1.0, 1.0, 1.0, 1.0}, want: 0.0},
+		{name: "symmetric", prices: []float64{0.9, 1.1}, want: 0.1},
+		{name: "scale invariant", prices: []float64{90.0, 110.0}, want: 0.1},
+		{name: "zero mean", prices: []float64{0.0, 0.0}, want: 0.0},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			assert.InDelta(t, k.want, computeVolatility(k.prices), 0.0000001)
+		})
+	}
+}
+
+func TestVolatilityFilterUpdate(t *testing.T) {
+	alert := &countingAlert{}
+	f := &volatilityFilter{
+		numSamples:           3,
+		maxVolatilityPercent: 0.05,
+		action:               VolatilityActionPause,
+		alert:                alert,
+	}
+
+	// not measured until the window is full
+	_, isVolatile, hasEnoughSamples := f.update(1.0)
+	assert.False(t, isVolatile)
+	assert.False(t, hasEnoughSamples)
+	_, _, hasEnoughSamples = f.update(1.0)
+	assert.False(t, hasEnoughSamples)
+
+	_, isVolatile, hasEnoughSamples = f.update(1.0)
+	assert.False(t, isVolatile)
+	assert.True(t, hasEnoughSamples)
+
+	// a jump in the price trips the breaker and alerts once
+	_, isVolatile, _ = f.update(1.5)
+	assert.True(t, isVolatile)
+	assert.Equal(t, 1, alert.numTriggers)
+	_, isVolatile, _ = f.update(1.5)
+	assert.True(t, isVolatile)
+	assert.Equal(t, 1, alert.numTriggers)
+
+	// resumes once the jump is out of the window
+	volatility, isVolatile, _ := f.update(1.5)
+	assert.Equal(t, 3, len(f.samples))
+	assert.InDelta(t, 0.0, volatility, 0.0000001)
+	assert.False(t, isVolatile)
+	assert.Equal(t, 1, alert.numTriggers)
+}
+
+func TestVolatilityFilterWidenFn(t *testing.T) {
+	testCases := []struct {
+		name       string
+		op         *txnbuild.ManageSellOffer
+		wantOp     *txnbuild.ManageSellOffer
+		wantKeepOp bool
+	}{
+		{
+			name:       "sell widened",
+			op:         &txnbuild.ManageSellOffer{Selling: testBaseAsset, Buying: testQuoteAsset, Amount: "10.0", Price: "1.1"},
+			wantOp:     &txnbuild.ManageSellOffer{Selling: testBaseAsset, Buying: testQuoteAsset, Amount: "10.0", Price: "1.2000000"},
+			wantKeepOp: true,
+		}, {
+			name:       "sell below mid kept",
+			op:         &txnbuild.ManageSellOffer{Selling: testBaseAsset, Buying: testQuoteAsset, Amount: "10.0", Price: "0.95"},
+			wantOp:     &txnbuild.ManageSellOffer{Selling: testBaseAsset, Buying: testQuoteAsset, Amount: "10.0", Price: "0.95"},
+			wantKeepOp: true,
+		}, {
+			// buy op at a price of 1/1.25 = 0.8 quote per base is moved to 0.6 quote per base, still buying 10 base
+			name:       "buy widened",
+			op:         &txnbuild.ManageSellOffer{Selling: testQuoteAsset, Buying: testBaseAsset, Amount: "8.0", Price: "1.25"},
+			wantOp:     &txnbuild.ManageSellOffer{Selling: testQuoteAsset, Buying: testBaseAsset, Amount: "6.0000000", Price: "1.6666667"},
+			wantKeepOp: true,
+		}, {
+			// buy op at a price of 1/2.5 = 0.4 quote per base would be moved below zero
+			name:       "buy widened below zero",
+			op:         &txnbuild.ManageSellOffer{Selling: testQuoteAsset, Buying: testBaseAsset, Amount: "4.0", Price: "2.5"},
+			wantKeepOp: false,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			f := &volatilityFilter{
+				name:             "volatilityFilter",
+				baseAsset:        utils.Asset2Asset2(testBaseAsset),
+				quoteAsset:       utils.Asset2Asset2(testQuoteAsset),
+				action:           VolatilityActionWiden,
+				spreadMultiplier: 2.0,
+			}
+
+			actual, e := f.widenFilterFn(1.0, k.op)
+			if !assert.NoError(t, e) {
+				return
+			}
+			if !k.wantKeepOp {
+				assert.Nil(t, actual)
+				return
+			}
+			assert.Equal(t, k.wantOp, actual)
+		})
+	}
+}
+
+func TestVolatilityFilterApplyWiden(t *testing.T) {
+	feed := &fixedFeed{price: 1.0}
+	filter, e := makeFilterVolatility("", utils.Asset2Asset2(testBaseAsset), utils.Asset2Asset2(testQuoteAsset), nil, 2, 0.05, VolatilityActionWiden, 2.0, feed)
+	if !assert.NoError(t, e) {
+		return
+	}
+
+	ops := []txnbuild.Operation{
+		&txnbuild.ManageSellOffer{Selling: testBaseAsset, Buying: testQuoteAsset, Amount: "10.0", Price: "1.3"},
+		// updates an existing offer to a price that cannot be widened, so the existing offer is deleted
+		&txnbuild.ManageSellOffer{Selling: testQuoteAsset, Buying: testBaseAsset, Amount: "4.0", Price: "2.5", OfferID: 7},
+		&txnbuild.ManageSellOffer{Selling: testBaseAsset, Buying: testQuoteAsset, Amount: "0", Price: "1.1", OfferID: 8},
+	}
+
+	// the first sample does not fill the window
+	actual, e := filter.Apply(ops, nil, nil)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, ops, actual)
+
+	feed.price = 1.2
+	actual, e = filter.Apply(ops, nil, nil)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, []txnbuild.Operation{
+		&txnbuild.ManageSellOffer{Selling: testBaseAsset, Buying: testQuoteAsset, Amount: "10.0", Price: "1.4000000"},
+		&txnbuild.ManageSellOffer{Selling: testQuoteAsset, Buying: testBaseAsset, Amount: "0", Price: "2.5", OfferID: 7},
+		&txnbuild.ManageSellOffer{Selling: testBaseAsset, Buying: testQuoteAsset, Amount: "0", Price: "1.1", OfferID: 8},
+	}, actual)
+}
+
+func TestVolatilityFilterApplyWidenExistingOffers(t *testing.T) {
+	feed := &fixedFeed{price: 1.0}
+	filter, e := makeFilterVolatility("", utils.Asset2Asset2(testBaseAsset), utils.Asset2Asset2(testQuoteAsset), nil, 2, 0.05, VolatilityActionWiden, 2.0, feed)
+	if !assert.NoError(t, e) {
+		return
+	}
+	_, e = filter.Apply(nil, nil, nil)
+	if !assert.NoError(t, e) {
+		return
+	}
+
+	sellingOffers := []hProtocol.Offer{
+		// not updated by the strategy so it is widened by the filter
+		{ID: 9, Selling: utils.Asset2Asset2(testBaseAsset), Buying: utils.Asset2Asset2(testQuoteAsset), Amount: "5.0000000", Price: "1.2500000"},
+		// on the wrong side of the mid price so it is left as it is
+		{ID: 10, Selling: utils.Asset2Asset2(testBaseAsset), Buying: utils.Asset2Asset2(testQuoteAsset), Amount: "5.0000000", Price: "1.1000000"},
+		// updated by the strategy so only the op is widened
+		{ID: 11, Selling: utils.Asset2Asset2(testBaseAsset), Buying: utils.Asset2Asset2(testQuoteAsset), Amount: "5.0000000", Price: "1.2500000"},
+	}
+	ops := []txnbuild.Operation{
+		&txnbuild.ManageSellOffer{Selling: testBaseAsset, Buying: testQuoteAsset, Amount: "5.0", Price: "1.3", OfferID: 11},
+	}
+
+	feed.price = 1.2
+	actual, e := filter.Apply(ops, sellingOffers, nil)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, []txnbuild.Operation{
+		&txnbuild.ManageSellOffer{Selling: testBaseAsset, Buying: testQuoteAsset, Amount: "5.0", Price: "1.4000000", OfferID: 11},
+		&txnbuild.ManageSellOffer{Selling: testBaseAsset, Buying: testQuoteAsset, Amount: "5.0000000", Price: "1.3000000", OfferID: 9},
+	}, actual)
+
+	// the offers that were widened are not widened again while the volatility stays above the max, the offer that was on the wrong side is
+	// widened now that the mid price moved below it
+	feed.price = 1.0
+	sellingOffers[0].Price = "1.3000000"
+	sellingOffers[2].Price = "1.4000000"
+	actual, e = filter.Apply(nil, sellingOffers, nil)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, []txnbuild.Operation{
+		&txnbuild.ManageSellOffer{Selling: testBaseAsset, Buying: testQuoteAsset, Amount: "5.0000000", Price: "1.2000000", OfferID: 10},
+	}, actual)
+}
+
+func TestMakeFilterVolatility_Invalid(t *testing.T) {
+	feed := &fixedFeed{price: 1.0}
+	base := utils.Asset2Asset2(testBaseAsset)
+	quote := utils.Asset2Asset2(testQuoteAsset)
+
+	_, e := makeFilterVolatility("", base, quote, nil, 1, 0.05, VolatilityActionPause, 0.0, feed)
+	assert.Error(t, e)
+	_, e = makeFilterVolatility("", base, quote, nil, 10, 0.0, VolatilityActionPause, 0.0, feed)
+	assert.Error(t, e)
+	_, e = makeFilterVolatility("", base, quote, nil, 10, 0.05, VolatilityAction("stop"), 0.0, feed)
+	assert.Error(t, e)
+	_, e = makeFilterVolatility("", base, quote, nil, 10, 0.05, VolatilityActionWiden, 1.0, feed)
+	assert.Error(t, e)
+}
@@ -38,14 +38,62 @@ func parseVolumeFilterMode(mode string) (volumeFilterMode, error) {
 	return volumeFilterModeExact, fmt.Errorf("invalid input mode '%s'", mode)
 }
 
+type volumeFilterWindow string
+
+// type of volumeFilterWindow, all windows are in UTC
+const (
+	volumeFilterWindowDaily     volumeFilterWindow = "daily"     // the current day
+	volumeFilterWindowRolling7d volumeFilterWindow = "rolling7d" // the last 7 days (7 x 24 hours) until now
+	volumeFilterWindowWeekly    volumeFilterWindow = "weekly"    // the current calendar week, starting on Monday
+	volumeFilterWindowMonthly   volumeFilterWindow = "monthly"   // the current calendar month
+)
+
+// String is the Stringer method
+func (w volumeFilterWindow) String() string {
+	return string(w)
+}
+
+func parseVolumeFilterWindow(window string) (volumeFilterWindow, error) {
+	for _, w := range []volumeFilterWindow{volumeFilterWindowDaily, volumeFilterWindowRolling7d, volumeFilterWindowWeekly, volumeFilterWindowMonthly} {
+		if window == string(w) {
+			return w, nil
+		}
+	}
+	return volumeFilterWindowDaily, fmt.Errorf("invalid input window '%s'", window)
+}
+
+// bounds returns the start (inclusive) and end (exclusive) of the window that contains now
+func (w volumeFilterWindow) bounds(now time.Time) (time.Time, time.Time) {
+	now = now.UTC()
+	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	switch w {
+	case volumeFilterWindowRolling7d:
+		// trades are stored at a resolution of seconds so we include the current second in the window
+		end := now.Truncate(time.Second).Add(time.Second)
+		return now.AddDate(0, 0, -7), end
+	case volumeFilterWindowWeekly:
+		// time.Weekday starts on Sunday (0) so we shift it to make Monday the first day of the week
+		daysSinceMonday := (int(now.Weekday()) + 6) % 7
+		start := startOfDay.AddDate(0, 0, -daysSinceMonday)
+		return start, start.AddDate(0, 0, 7)
+	case volumeFilterWindowMonthly:
+		start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(0, 1, 0)
+	default:
+		return startOfDay, startOfDay.AddDate(0, 0, 1)
+	}
+}
+
 // VolumeFilterConfig ensures that any one constraint that is hit will result in deleting all offers and pausing until limits are no longer constrained
 type VolumeFilterConfig struct {
 	BaseAssetCapInBaseUnits  *float64
 	BaseAssetCapInQuoteUnits *float64
 	action                   queries.DailyVolumeAction
 	mode                     volumeFilterMode
-	additionalMarketIDs      []string // can be nil
-	optionalAccountIDs       []string // can be nil
+	window                   volumeFilterWindow // empty is treated as volumeFilterWindowDaily
+	additionalMarketIDs      []string           // can be nil
+	optionalAccountIDs       []string           // can be nil
+	accountScope             bool               // counts the volume of all the markets of the same assets traded by optionalAccountIDs instead of only the markets above
 }
 
 type limitParameters struct {
@@ -61,9 +109,10 @@ type volumeFilter struct {
 	quoteAsset             hProtocol.Asset
 	config                 *VolumeFilterConfig
 	dailyVolumeByDateQuery *queries.DailyVolumeByDate
+	volumeByDateRangeQuery *queries.VolumeByDateRange
 }
 
-// makeFilterVolume makes a submit filter that limits orders placed based on the daily volume traded
+// makeFilterVolume makes a submit filter that limits orders placed based on the volume traded in a window (daily, weekly, etc.)
 func makeFilterVolume(
 	configValue string,
 	exchangeName string,
@@ -84,19 +133,36 @@ func makeFilterVolume(
 		return nil, fmt.Errorf("could not convert quote asset (%s) from trading pair via the passed in assetDisplayFn: %s", string(tradingPair.Quote), e)
 	}
 
-	marketID := MakeMarketID(exchangeName, baseAssetString, quoteAssetString)
-	// note that append(s, nil) is valid
-	marketIDs := utils.Dedupe(append([]string{marketID}, config.additionalMarketIDs...))
-	dailyVolumeByDateQuery, e := queries.MakeDailyVolumeByDateForMarketIdsAction(db, marketIDs, config.action, config.optionalAccountIDs)
-	if e != nil {
-		return nil, fmt.Errorf("could not make daily volume by date Query: %s", e)
-	}
-
 	e = config.Validate()
 	if e != nil {
 		return nil, fmt.Errorf("invalid config: %s", e)
 	}
 
+	var dailyVolumeByDateQuery *queries.DailyVolumeByDate
+	var volumeByDateRangeQuery *queries.VolumeByDateRange
+	if config.accountScope {
+		dailyVolumeByDateQuery, e = queries.MakeDailyVolumeByDateForAccountIdsAction(db, config.optionalAccountIDs, baseAssetString, quoteAssetString, config.action)
+		if e != nil {
+			return nil, fmt.Errorf("could not make account scoped daily volume by date Query: %s", e)
+		}
+		volumeByDateRangeQuery, e = queries.MakeVolumeByDateRangeForAccountIdsAction(db, config.optionalAccountIDs, baseAssetString, quoteAssetString, config.action)
+		if e != nil {
+			return nil, fmt.Errorf("could not make account scoped volume by date range Query: %s", e)
+		}
+	} else {
+		marketID := MakeMarketID(exchangeName, baseAssetString, quoteAssetString)
+		// note that append(s, nil) is valid
+		marketIDs := utils.Dedupe(append([]string{marketID}, config.additionalMarketIDs...))
+		dailyVolumeByDateQuery, e = queries.MakeDailyVolumeByDateForMarketIdsAction(db, marketIDs, config.action, config.optionalAccountIDs)
+		if e != nil {
+			return nil, fmt.Errorf("could not make daily volume by date Query: %s", e)
+		}
+		volumeByDateRangeQuery, e = queries.MakeVolumeByDateRangeForMarketIdsAction(db, marketIDs, config.action, config.optionalAccountIDs)
+		if e != nil {
+			return nil, fmt.Errorf("could not make volume by date range Query: %s", e)
+		}
+	}
+
 	return &volumeFilter{
 		name:                   "volumeFilter",
 		configValue:            configValue,
@@ -104,10 +170,21 @@ func makeFilterVolume(
 		quoteAsset:             quoteAsset,
 		config:                 config,
 		dailyVolumeByDateQuery: dailyVolumeByDateQuery,
+		volumeByDateRangeQuery: volumeByDateRangeQuery,
 	}, nil
 }
 
 var _ SubmitFilter = &volumeFilter{}
+var _ OrderedSubmitFilter = &volumeFilter{}
+
+// FilterOrder impl.
+func (f *volumeFilter) FilterOrder() FilterOrder {
+	return FilterOrder{
+		ID:       filterIDVolume,
+		Priority: FilterPriorityAmount,
+		After:    priceAndOrderSizeFilterIDs,
+	}
+}
 
 // Validate ensures validity
 func (c *VolumeFilterConfig) Validate() error {
@@ -127,32 +204,43 @@ func (c *VolumeFilterConfig) Validate() error {
 		return fmt.Errorf("could not parse action: %s", e)
 	}
 
+	if c.window != "" {
+		if _, e := parseVolumeFilterWindow(string(c.window)); e != nil {
+			return fmt.Errorf("could not parse window: %s", e)
+		}
+	}
+
+	if c.accountScope && len(c.additionalMarketIDs) > 0 {
+		return fmt.Errorf("invalid scope: market_ids cannot be used with the account scope since it already includes all the markets of the accounts")
+	}
+
 	return nil
 }
 
 // String is the stringer method
 func (c *VolumeFilterConfig) String() string {
-	return fmt.Sprintf("VolumeFilterConfig[BaseAssetCapInBaseUnits=%s, BaseAssetCapInQuoteUnits=%s, mode=%s, action=%s, additionalMarketIDs=%v, optionalAccountIDs=%v]",
-		utils.CheckedFloatPtr(c.BaseAssetCapInBaseUnits), utils.CheckedFloatPtr(c.BaseAssetCapInQuoteUnits), c.mode, c.action, c.additionalMarketIDs, c.optionalAccountIDs)
+	return fmt.Sprintf("VolumeFilterConfig[BaseAssetCapInBaseUnits=%s, BaseAssetCapInQuoteUnits=%s, mode=%s, action=%s, window=%s, additionalMarketIDs=%v, optionalAccountIDs=%v, accountScope=%v]",
+		utils.CheckedFloatPtr(c.BaseAssetCapInBaseUnits), utils.CheckedFloatPtr(c.BaseAssetCapInQuoteUnits), c.mode, c.action, c.getWindow(), c.additionalMarketIDs, c.optionalAccountIDs, c.accountScope)
+}
+
+func (c *VolumeFilterConfig) getWindow() volumeFilterWindow {
+	if c.window == "" {
+		return volumeFilterWindowDaily
+	}
+	return c.window
 }
 
 func (f *volumeFilter) Apply(ops []txnbuild.Operation, sellingOffers []hProtocol.Offer, buyingOffers []hProtocol.Offer) ([]txnbuild.Operation, error) {
-	dateString := time.Now().UTC().Format(postgresdb.DateFormatString)
-	// TODO for flipped marketIDs
-	queryResult, e := f.dailyVolumeByDateQuery.QueryRow(dateString)
+	volumeValues, windowString, e := f.queryWindowVolume(time.Now())
 	if e != nil {
-		return nil, fmt.Errorf("could not load dailyValuesByDate for today (%s): %s", dateString, e)
-	}
-	dailyValuesBaseSold, ok := queryResult.(*queries.DailyVolume)
-	if !ok {
-		return nil, fmt.Errorf("incorrect type returned from DailyVolumeByDate query, expecting '*queries.DailyVolume' but was '%T'", queryResult)
+		return nil, e
 	}
 
-	log.Printf("dailyValuesByDate for today (%s): baseSoldUnits = %.8f %s, quoteCostUnits = %.8f %s (%s)\n",
-		dateString, dailyValuesBaseSold.BaseVol, utils.Asset2String(f.baseAsset), dailyValuesBaseSold.QuoteVol, utils.Asset2String(f.quoteAsset), f.config)
+	log.Printf("volume values for the %s window (%s): baseSoldUnits = %.8f %s, quoteCostUnits = %.8f %s (%s)\n",
+		f.config.getWindow(), windowString, volumeValues.BaseVol, utils.Asset2String(f.baseAsset), volumeValues.QuoteVol, utils.Asset2String(f.quoteAsset), f.config)
 
 	// daily on-the-books
-	dailyOTB := makeIntermediateVolumeFilterConfig(&dailyValuesBaseSold.BaseVol, &dailyValuesBaseSold.QuoteVol)
+	dailyOTB := makeIntermediateVolumeFilterConfig(&volumeValues.BaseVol, &volumeValues.QuoteVol)
 	// daily to-be-booked starts out as empty and accumulates the values of the operations
 	dailyTbbBase := 0.0
 	dailyTbbSellQuote := 0.0
@@ -173,6 +261,36 @@ func (f *volumeFilter) Apply(ops []txnbuild.Operation, sellingOffers []hProtocol
 	return ops, nil
 }
 
+// queryWindowVolume loads the volume traded in the window of the filter that contains now, along with a description of the window for logging
+func (f *volumeFilter) queryWindowVolume(now time.Time) (*queries.DailyVolume, string, error) {
+	window := f.config.getWindow()
+	if window == volumeFilterWindowDaily {
+		dateString := now.UTC().Format(postgresdb.DateFormatString)
+		// TODO for flipped marketIDs
+		queryResult, e := f.dailyVolumeByDateQuery.QueryRow(dateString)
+		if e != nil {
+			return nil, "", fmt.Errorf("could not load dailyValuesByDate for today (%s): %s", dateString, e)
+		}
+		dailyValues, ok := queryResult.(*queries.DailyVolume)
+		if !ok {
+			return nil, "", fmt.Errorf("incorrect type returned from DailyVolumeByDate query, expecting '*queries.DailyVolume' but was '%T'", queryResult)
+		}
+		return dailyValues, dateString, nil
+	}
+
+	start, end := window.bounds(now)
+	windowString := fmt.Sprintf("%s to %s", start.Format(postgresdb.TimestampFormatString), end.Format(postgresdb.TimestampFormatString))
+	queryResult, e := f.volumeByDateRangeQuery.QueryRow(start, end)
+	if e != nil {
+		return nil, "", fmt.Errorf("could not load volumeByDateRange for the %s window (%s): %s", window, windowString, e)
+	}
+	windowValues, ok := queryResult.(*queries.DailyVolume)
+	if !ok {
+		return nil, "", fmt.Errorf("incorrect type returned from VolumeByDateRange query, expecting '*queries.DailyVolume' but was '%T'", queryResult)
+	}
+	return windowValues, windowString, nil
+}
+
 func makeIntermediateVolumeFilterConfig(baseCapBaseUnits *float64, baseCapQuoteUnits *float64) *VolumeFilterConfig {
 	return &VolumeFilterConfig{
 		BaseAssetCapInBaseUnits:  baseCapBaseUnits,
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/openlyinc/pointy"
 	"github.com/stretchr/testify/assert"
@@ -24,6 +25,10 @@ func makeWantVolumeFilter(config *VolumeFilterConfig, marketIDs []string, accoun
 	if e != nil {
 		panic(e)
 	}
+	rangeQuery, e := queries.MakeVolumeByDateRangeForMarketIdsAction(&sql.DB{}, marketIDs, action, accountIDs)
+	if e != nil {
+		panic(e)
+	}
 
 	return &volumeFilter{
 		name:                   "volumeFilter",
@@ -32,6 +37,7 @@ func makeWantVolumeFilter(config *VolumeFilterConfig, marketIDs []string, accoun
 		quoteAsset:             utils.NativeAsset,
 		config:                 config,
 		dailyVolumeByDateQuery: query,
+		volumeByDateRangeQuery: rangeQuery,
 	}
 }
 
@@ -110,6 +116,7 @@ func TestMakeFilterVolume(t *testing.T) {
 					nil,
 					action,
 					m,
+					volumeFilterWindowDaily,
 					k.marketIDs,
 					k.accountIDs,
 				)
@@ -118,6 +125,7 @@ func TestMakeFilterVolume(t *testing.T) {
 					pointy.Float64(1.0),
 					action,
 					m,
+					volumeFilterWindowDaily,
 					k.marketIDs,
 					k.accountIDs,
 				)
@@ -1288,6 +1296,7 @@ func TestValidateConfig(t *testing.T) {
 		baseCapQuote *float64
 		mode         volumeFilterMode
 		action       queries.DailyVolumeAction
+		window       volumeFilterWindow
 		marketIDs    []string
 		accountIDs   []string
 		wantErr      error
@@ -1350,13 +1359,95 @@ func TestValidateConfig(t *testing.T) {
 			accountIDs:   nil,
 			wantErr:      fmt.Errorf("could not parse action: invalid action value 'hello'"),
 		},
+		{
+			name:         "success - monthly window",
+			baseCapBase:  pointy.Float64(1.0),
+			baseCapQuote: nil,
+			mode:         volumeFilterModeExact,
+			action:       queries.DailyVolumeActionSell,
+			window:       volumeFilterWindowMonthly,
+			marketIDs:    nil,
+			accountIDs:   nil,
+			wantErr:      nil,
+		},
+		{
+			name:         "failure - invalid window",
+			baseCapBase:  pointy.Float64(1.0),
+			baseCapQuote: nil,
+			mode:         volumeFilterModeExact,
+			action:       queries.DailyVolumeActionSell,
+			window:       volumeFilterWindow("hello"),
+			marketIDs:    nil,
+			accountIDs:   nil,
+			wantErr:      fmt.Errorf("could not parse window: invalid input window 'hello'"),
+		},
 	}
 
 	for _, k := range testCases {
 		t.Run(k.name, func(t *testing.T) {
-			c := makeRawVolumeFilterConfig(k.baseCapBase, k.baseCapQuote, k.action, k.mode, k.marketIDs, k.accountIDs)
+			c := makeRawVolumeFilterConfig(k.baseCapBase, k.baseCapQuote, k.action, k.mode, k.window, k.marketIDs, k.accountIDs)
 			gotErr := c.Validate()
 			assert.Equal(t, k.wantErr, gotErr)
 		})
 	}
 }
+
+func TestVolumeFilterWindowBounds(t *testing.T) {
+	// Wednesday
+	now := time.Date(2020, time.January, 15, 13, 45, 30, 500, time.UTC)
+
+	testCases := []struct {
+		window    volumeFilterWindow
+		now       time.Time
+		wantStart time.Time
+		wantEnd   time.Time
+	}{
+		{
+			window:    volumeFilterWindowDaily,
+			now:       now,
+			wantStart: time.Date(2020, time.January, 15, 0, 0, 0, 0, time.UTC),
+			wantEnd:   time.Date(2020, time.January, 16, 0, 0, 0, 0, time.UTC),
+		}, {
+			window:    volumeFilterWindowRolling7d,
+			now:       now,
+			wantStart: time.Date(2020, time.January, 8, 13, 45, 30, 500, time.UTC),
+			wantEnd:   time.Date(2020, time.January, 15, 13, 45, 31, 0, time.UTC),
+		}, {
+			window:    volumeFilterWindowWeekly,
+			now:       now,
+			wantStart: time.Date(2020, time.January, 13, 0, 0, 0, 0, time.UTC),
+			wantEnd:   time.Date(2020, time.January, 20, 0, 0, 0, 0, time.UTC),
+		}, {
+			// Sunday is the last day of the week
+			window:    volumeFilterWindowWeekly,
+			now:       time.Date(2020, time.January, 19, 23, 59, 59, 0, time.UTC),
+			wantStart: time.Date(2020, time.January, 13, 0, 0, 0, 0, time.UTC),
+			wantEnd:   time.Date(2020, time.January, 20, 0, 0, 0, 0, time.UTC),
+		}, {
+			// Monday is the first day of the week, across a month boundary
+			window:    volumeFilterWindowWeekly,
+			now:       time.Date(2020, time.February, 3, 0, 0, 0, 0, time.UTC),
+			wantStart: time.Date(2020, time.February, 3, 0, 0, 0, 0, time.UTC),
+			wantEnd:   time.Date(2020, time.February, 10, 0, 0, 0, 0, time.UTC),
+		}, {
+			window:    volumeFilterWindowMonthly,
+			now:       now,
+			wantStart: time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC),
+			wantEnd:   time.Date(2020, time.February, 1, 0, 0, 0, 0, time.UTC),
+		}, {
+			// the window is in UTC even if now is not
+			window:    volumeFilterWindowMonthly,
+			now:       time.Date(2020, time.December, 31, 20, 0, 0, 0, time.FixedZone("UTC-5", -5*60*60)),
+			wantStart: time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC),
+			wantEnd:   time.Date(2021, time.February, 1, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(fmt.Sprintf("%s_%s", k.window, k.now.Format(time.RFC3339)), func(t *testing.T) {
+			start, end := k.window.bounds(k.now)
+			assert.Equal(t, k.wantStart, start)
+			assert.Equal(t, k.wantEnd, end)
+		})
+	}
+}
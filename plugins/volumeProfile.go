@@ -0,0 +1,201 @@
+package plugins
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/support/sdk"
+)
+
+var timeframeRegex = regexp.MustCompile("^([0-9]+)([mhd])$")
+
+// bucketVolumeProfile provides the relative weights with which to distribute the daily capacity over the buckets of a day
+type bucketVolumeProfile interface {
+	// getWeights returns one weight for each of the first numBuckets buckets of the day (in UTC) that sum up to 1.0
+	getWeights(now time.Time, numBuckets int64, bucketSizeSeconds int) ([]float64, error)
+}
+
+// ohlcvFetcher fetches historical candles for a fixed market starting from the passed in time
+type ohlcvFetcher func(sinceMillis int64, limit int) ([]sdk.CcxtOHLCV, error)
+
+// ohlcvVolumeProfile computes an intraday volume profile from historical candles on a reference exchange
+type ohlcvVolumeProfile struct {
+	fetcher          ohlcvFetcher
+	timeframeSeconds int
+	lookbackDays     int
+
+	// uninitialized
+	cachedDate    string
+	cachedWeights []float64
+}
+
+// ensure it implements the bucketVolumeProfile interface
+var _ bucketVolumeProfile = &ohlcvVolumeProfile{}
+
+// makeCcxtVolumeProfile is a factory method that fetches candles from a ccxt exchange, exchangeName is specified as "ccxt-<name>"
+func makeCcxtVolumeProfile(exchangeName string, tradingPair string, timeframe string, lookbackDays int) (bucketVolumeProfile, error) {
+	timeframeSeconds, e := parseTimeframeSeconds(timeframe)
+	if e != nil {
+		return nil, fmt.Errorf("could not parse timeframe: %s", e)
+	}
+
+	if lookbackDays <= 0 {
+		return nil, fmt.Errorf("invalid number of lookback days, expected lookbackDays > 0; was %d", lookbackDays)
+	}
+
+	if !strings.HasPrefix(exchangeName, "ccxt-") {
+		return nil, fmt.Errorf("volume profiles can only be fetched from ccxt exchanges (ccxt-<name>), was '%s'", exchangeName)
+	}
+
+	c, e := sdk.MakeInitializedCcxtExchange(strings.TrimPrefix(exchangeName, "ccxt-"), api.ExchangeAPIKey{}, nil, nil)
+	if e != nil {
+		return nil, fmt.Errorf("error making a ccxt exchange to fetch the volume profile: %s", e)
+	}
+
+	return &ohlcvVolumeProfile{
+		fetcher: func(sinceMillis int64, limit int) ([]sdk.CcxtOHLCV, error) {
+			return c.FetchOHLCV(tradingPair, timeframe, &sinceMillis, &limit)
+		},
+		timeframeSeconds: timeframeSeconds,
+		lookbackDays:     lookbackDays,
+	}, nil
+}
+
+// parseTimeframeSeconds converts a ccxt timeframe such as "15m" or "1h" to the number of seconds
+func parseTimeframeSeconds(timeframe string) (int, error) {
+	matches := timeframeRegex.FindStringSubmatch(timeframe)
+	if matches == nil {
+		return 0, fmt.Errorf("invalid timeframe '%s', should be a number followed by one of 'm', 'h', or 'd' (example: '15m')", timeframe)
+	}
+
+	n, e := strconv.Atoi(matches[1])
+	if e != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid number in timeframe '%s'", timeframe)
+	}
+
+	switch matches[2] {
+	case "m":
+		return n * 60, nil
+	case "h":
+		return n * secondsInHour, nil
+	default:
+		return n * secondsInDay, nil
+	}
+}
+
+// getWeights impl, the weights are recomputed once a day
+func (p *ohlcvVolumeProfile) getWeights(now time.Time, numBuckets int64, bucketSizeSeconds int) ([]float64, error) {
+	dateString := now.UTC().Format("2006-01-02")
+	if p.cachedDate == dateString && int64(len(p.cachedWeights)) == numBuckets {
+		return p.cachedWeights, nil
+	}
+
+	candles, e := p.fetchCandles(floorDate(now.UTC()))
+	if e != nil {
+		return nil, fmt.Errorf("could not fetch candles for volume profile: %s", e)
+	}
+
+	weights := computeVolumeProfileWeights(candles, p.timeframeSeconds, numBuckets, bucketSizeSeconds, floorDate(now.UTC()))
+	log.Printf("computed volume profile weights for %s from %d candles (lookbackDays=%d): %v\n", dateString, len(candles), p.lookbackDays, weights)
+
+	p.cachedDate = dateString
+	p.cachedWeights = weights
+	return weights, nil
+}
+
+// ohlcvMaxPages bounds the number of calls made to fetch the candles of the lookback window
+const ohlcvMaxPages = 100
+
+// fetchCandles fetches the candles of the lookback window that ends at dayStart, paging through the candles because exchanges cap the number
+// of candles returned by a single call (often to 500 or 1000) which is less than a long lookback window with a short timeframe
+func (p *ohlcvVolumeProfile) fetchCandles(dayStart time.Time) ([]sdk.CcxtOHLCV, error) {
+	timeframeMillis := int64(p.timeframeSeconds) * 1000
+	sinceMillis := dayStart.AddDate(0, 0, -p.lookbackDays).UnixNano() / int64(time.Millisecond)
+	endMillis := dayStart.UnixNano() / int64(time.Millisecond)
+
+	candles := []sdk.CcxtOHLCV{}
+	for page := 0; page < ohlcvMaxPages && sinceMillis < endMillis; page++ {
+		limit := int((endMillis - sinceMillis + timeframeMillis - 1) / timeframeMillis)
+		pageCandles, e := p.fetcher(sinceMillis, limit)
+		if e != nil {
+			return nil, e
+		}
+
+		nextSinceMillis := sinceMillis
+		for _, c := range pageCandles {
+			// skip candles that were returned by the previous page
+			if c.Timestamp < sinceMillis {
+				continue
+			}
+			candles = append(candles, c)
+			if c.Timestamp+timeframeMillis > nextSinceMillis {
+				nextSinceMillis = c.Timestamp + timeframeMillis
+			}
+		}
+
+		// the exchange has no more candles when a page does not move us forward
+		if nextSinceMillis == sinceMillis {
+			break
+		}
+		sinceMillis = nextSinceMillis
+	}
+	return candles, nil
+}
+
+// computeVolumeProfileWeights distributes the volume of each candle that started before dayStart over the buckets of the day based on its
+// time of day and normalizes the first numBuckets buckets. Falls back to uniform weights when there is no volume in those buckets.
+func computeVolumeProfileWeights(candles []sdk.CcxtOHLCV, timeframeSeconds int, numBuckets int64, bucketSizeSeconds int, dayStart time.Time) []float64 {
+	bucketsInDay := int64(secondsInDay / bucketSizeSeconds)
+	volumes := make([]float64, bucketsInDay)
+	for _, c := range candles {
+		candleStartSeconds := c.Timestamp / 1000
+		// only use completed days so we don't skew the profile with the partial volume of the current day
+		if candleStartSeconds >= dayStart.Unix() {
+			continue
+		}
+
+		// spread the volume of the candle evenly over its duration so it is allocated proportionally to the buckets it overlaps
+		start := candleStartSeconds % secondsInDay
+		end := start + int64(timeframeSeconds)
+		for b := start / int64(bucketSizeSeconds); b*int64(bucketSizeSeconds) < end; b++ {
+			bucketStart := b * int64(bucketSizeSeconds)
+			bucketEnd := bucketStart + int64(bucketSizeSeconds)
+			overlap := minInt64(end, bucketEnd) - maxInt64(start, bucketStart)
+			volumes[b%bucketsInDay] += c.Volume * float64(overlap) / float64(timeframeSeconds)
+		}
+	}
+
+	total := 0.0
+	for i := int64(0); i < numBuckets; i++ {
+		total += volumes[i]
+	}
+
+	weights := make([]float64, numBuckets)
+	for i := range weights {
+		if total == 0.0 {
+			weights[i] = 1.0 / float64(numBuckets)
+		} else {
+			weights[i] = volumes[i] / total
+		}
+	}
+	return weights
+}
+
+func minInt64(a int64, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt64(a int64, b int64) int64 {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,155 @@
+package plugins
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/kelp/support/sdk"
+)
+
+func TestParseTimeframeSeconds(t *testing.T) {
+	testCases := []struct {
+		timeframe string
+		want      int
+		wantError bool
+	}{
+		{timeframe: "1m", want: 60},
+		{timeframe: "15m", want: 900},
+		{timeframe: "1h", want: 3600},
+		{timeframe: "4h", want: 14400},
+		{timeframe: "1d", want: 86400},
+		{timeframe: "0m", wantError: true},
+		{timeframe: "1w", wantError: true},
+		{timeframe: "m", wantError: true},
+		{timeframe: "", wantError: true},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.timeframe, func(t *testing.T) {
+			actual, e := parseTimeframeSeconds(k.timeframe)
+			if k.wantError {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, k.want, actual)
+		})
+	}
+}
+
+func TestComputeVolumeProfileWeights(t *testing.T) {
+	dayStart := time.Date(2020, 3, 2, 0, 0, 0, 0, time.UTC)
+	yesterdayMillis := dayStart.AddDate(0, 0, -1).Unix() * 1000
+	hourMillis := int64(secondsInHour * 1000)
+
+	testCases := []struct {
+		name              string
+		candles           []sdk.CcxtOHLCV
+		timeframeSeconds  int
+		numBuckets        int64
+		bucketSizeSeconds int
+		want              []float64
+	}{
+		{
+			name:              "no candles is uniform",
+			candles:           []sdk.CcxtOHLCV{},
+			timeframeSeconds:  secondsInHour,
+			numBuckets:        4,
+			bucketSizeSeconds: secondsInHour,
+			want:              []float64{0.25, 0.25, 0.25, 0.25},
+		}, {
+			name: "one candle per bucket",
+			candles: []sdk.CcxtOHLCV{
+				{Timestamp: yesterdayMillis, Volume: 10},
+				{Timestamp: yesterdayMillis + hourMillis, Volume: 30},
+				// outside the number of buckets so should be ignored
+				{Timestamp: yesterdayMillis + 2*hourMillis, Volume: 100},
+			},
+			timeframeSeconds:  secondsInHour,
+			numBuckets:        2,
+			bucketSizeSeconds: secondsInHour,
+			want:              []float64{0.25, 0.75},
+		}, {
+			name: "candles on the current day are ignored",
+			candles: []sdk.CcxtOHLCV{
+				{Timestamp: yesterdayMillis, Volume: 10},
+				{Timestamp: dayStart.Unix()*1000 + hourMillis, Volume: 30},
+			},
+			timeframeSeconds:  secondsInHour,
+			numBuckets:        2,
+			bucketSizeSeconds: secondsInHour,
+			want:              []float64{1.0, 0.0},
+		}, {
+			name: "candle larger than bucket is spread evenly",
+			candles: []sdk.CcxtOHLCV{
+				{Timestamp: yesterdayMillis, Volume: 40},
+			},
+			timeframeSeconds:  secondsInHour,
+			numBuckets:        4,
+			bucketSizeSeconds: 15 * 60,
+			want:              []float64{0.25, 0.25, 0.25, 0.25},
+		}, {
+			name: "multiple days are aggregated by time of day",
+			candles: []sdk.CcxtOHLCV{
+				{Timestamp: yesterdayMillis - 24*hourMillis, Volume: 10},
+				{Timestamp: yesterdayMillis - 23*hourMillis, Volume: 20},
+				{Timestamp: yesterdayMillis, Volume: 30},
+				{Timestamp: yesterdayMillis + hourMillis, Volume: 40},
+			},
+			timeframeSeconds:  secondsInHour,
+			numBuckets:        2,
+			bucketSizeSeconds: secondsInHour,
+			want:              []float64{0.4, 0.6},
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			actual := computeVolumeProfileWeights(k.candles, k.timeframeSeconds, k.numBuckets, k.bucketSizeSeconds, dayStart)
+			if !assert.Equal(t, len(k.want), len(actual)) {
+				return
+			}
+			for i := range k.want {
+				assert.InDelta(t, k.want[i], actual[i], 0.0000001, fmt.Sprintf("weight at index %d", i))
+			}
+		})
+	}
+}
+
+func TestFetchCandlesPaginates(t *testing.T) {
+	dayStart := time.Date(2020, 1, 10, 0, 0, 0, 0, time.UTC)
+	lookbackStartMillis := dayStart.AddDate(0, 0, -2).UnixNano() / int64(time.Millisecond)
+	const timeframeMillis = int64(secondsInHour * 1000)
+
+	calls := 0
+	p := &ohlcvVolumeProfile{
+		// the exchange returns at most 10 candles per call
+		fetcher: func(sinceMillis int64, limit int) ([]sdk.CcxtOHLCV, error) {
+			calls++
+			candles := []sdk.CcxtOHLCV{}
+			for ts := sinceMillis; ts < dayStart.UnixNano()/int64(time.Millisecond) && len(candles) < 10 && len(candles) < limit; ts += timeframeMillis {
+				candles = append(candles, sdk.CcxtOHLCV{Timestamp: ts, Volume: 1.0})
+			}
+			return candles, nil
+		},
+		timeframeSeconds: secondsInHour,
+		lookbackDays:     2,
+	}
+
+	candles, e := p.fetchCandles(dayStart)
+	if !assert.NoError(t, e) {
+		return
+	}
+	if !assert.Equal(t, 48, len(candles)) {
+		return
+	}
+	assert.Equal(t, 5, calls)
+	for i, c := range candles {
+		assert.Equal(t, lookbackStartMillis+int64(i)*timeframeMillis, c.Timestamp)
+	}
+}
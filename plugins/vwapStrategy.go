@@ -0,0 +1,125 @@
+package plugins
+
+import (
+	"fmt"
+	"math/rand"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// vwapConfig contains the configuration params for this Strategy
+type vwapConfig struct {
+	StartAskFeedType       string  `valid:"-" toml:"START_ASK_FEED_TYPE"`
+	StartAskFeedURL        string  `valid:"-" toml:"START_ASK_FEED_URL"`
+	PriceTolerance         float64 `valid:"-" toml:"PRICE_TOLERANCE"`
+	AmountTolerance        float64 `valid:"-" toml:"AMOUNT_TOLERANCE"`
+	RateOffsetPercent      float64 `valid:"-" toml:"RATE_OFFSET_PERCENT"`
+	RateOffset             float64 `valid:"-" toml:"RATE_OFFSET"`
+	RateOffsetPercentFirst bool    `valid:"-" toml:"RATE_OFFSET_PERCENT_FIRST"`
+	// params shared with the twap strategy
+	DayOfWeekDailyCap                                     DayOfWeekFilterConfig `valid:"-" toml:"DAY_OF_WEEK_DAILY_CAP"`
+	NumHoursToSell                                        int                   `valid:"-" toml:"NUM_HOURS_TO_SELL"`
+	ParentBucketSizeSeconds                               int                   `valid:"-" toml:"PARENT_BUCKET_SIZE_SECONDS"`
+	DistributeSurplusOverRemainingIntervalsPercentCeiling float64               `valid:"-" toml:"DISTRIBUTE_SURPLUS_OVER_REMAINING_INTERVALS_PERCENT_CEILING"`
+	ExponentialSmoothingFactor                            float64               `valid:"-" toml:"EXPONENTIAL_SMOOTHING_FACTOR"`
+	MinChildOrderSizePercentOfParent                      float64               `valid:"-" toml:"MIN_CHILD_ORDER_SIZE_PERCENT_OF_PARENT"`
+	CarrySurplusAcrossDays                                bool                  `valid:"-" toml:"CARRY_SURPLUS_ACROSS_DAYS"`
+	HolidayCalendar                                       string                `valid:"-" toml:"HOLIDAY_CALENDAR"`
+	HolidayCapMultiplier                                  float64               `valid:"-" toml:"HOLIDAY_CAP_MULTIPLIER"`
+	// new params that are specific to the vwap strategy
+	VolumeProfileExchange     string `valid:"-" toml:"VOLUME_PROFILE_EXCHANGE"`
+	VolumeProfileTradingPair  string `valid:"-" toml:"VOLUME_PROFILE_TRADING_PAIR"`
+	VolumeProfileTimeframe    string `valid:"-" toml:"VOLUME_PROFILE_TIMEFRAME"`
+	VolumeProfileLookbackDays int    `valid:"-" toml:"VOLUME_PROFILE_LOOKBACK_DAYS"`
+}
+
+// String impl.
+func (c vwapConfig) String() string {
+	return utils.StructString(c, 0, nil)
+}
+
+// makeVwapStrategy is a factory method for the vwap strategy, which sells like sellTwap but sizes buckets based on historical intraday volume
+func makeVwapStrategy(
+	sdex *SDEX,
+	pair *model.TradingPair,
+	ieif *IEIF,
+	assetBase *hProtocol.Asset,
+	assetQuote *hProtocol.Asset,
+	filterFactory *FilterFactory,
+	config *vwapConfig,
+	clock api.Clock,
+) (api.Strategy, error) {
+	startPf, e := MakePriceFeed(config.StartAskFeedType, config.StartAskFeedURL)
+	if e != nil {
+		return nil, fmt.Errorf("error when making the start priceFeed: %s", e)
+	}
+
+	volumeProfile, e := makeCcxtVolumeProfile(
+		config.VolumeProfileExchange,
+		config.VolumeProfileTradingPair,
+		config.VolumeProfileTimeframe,
+		config.VolumeProfileLookbackDays,
+	)
+	if e != nil {
+		return nil, fmt.Errorf("error when making the volume profile: %s", e)
+	}
+
+	orderConstraints := sdex.GetOrderConstraints(pair)
+	offset := rateOffset{
+		percent:      config.RateOffsetPercent,
+		absolute:     config.RateOffset,
+		percentFirst: config.RateOffsetPercentFirst,
+	}
+	dowFilter, e := makeDowFilter(filterFactory, config.DayOfWeekDailyCap)
+	if e != nil {
+		return nil, fmt.Errorf("error when making dowFilter: %s", e)
+	}
+	holidayCalendar, e := makeHolidayCalendar(config.HolidayCalendar, config.HolidayCapMultiplier)
+	if e != nil {
+		return nil, fmt.Errorf("error when making the holiday calendar: %s", e)
+	}
+	levelProvider, e := makeSellTwapLevelProvider(
+		startPf,
+		offset,
+		orderConstraints,
+		dowFilter,
+		config.NumHoursToSell,
+		config.ParentBucketSizeSeconds,
+		config.DistributeSurplusOverRemainingIntervalsPercentCeiling,
+		config.ExponentialSmoothingFactor,
+		config.MinChildOrderSizePercentOfParent,
+		rand.New(rand.NewSource(clock.Now().UnixNano())),
+		clock,
+		false,
+		volumeProfile,
+		config.CarrySurplusAcrossDays,
+		holidayCalendar,
+	)
+	if e != nil {
+		return nil, fmt.Errorf("error when making a sellTwapLevelProvider with a volume profile: %s", e)
+	}
+
+	sellSideStrategy := makeSellSideStrategy(
+		sdex,
+		orderConstraints,
+		ieif,
+		assetBase,
+		assetQuote,
+		levelProvider,
+		config.PriceTolerance,
+		config.AmountTolerance,
+		false,
+	)
+	// switch sides of base/quote here for the delete side
+	deleteSideStrategy := makeDeleteSideStrategy(sdex, assetQuote, assetBase)
+
+	return makeComposeStrategy(
+		assetBase,
+		assetQuote,
+		deleteSideStrategy,
+		sellSideStrategy,
+	), nil
+}
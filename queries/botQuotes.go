@@ -0,0 +1,92 @@
+package queries
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// sqlQueryOtherBotQuotes queries the bot_quotes table for the quotes of the other bots on a pair of assets in either direction that were updated
+// after a cutoff, so the quotes of bots that have stopped are ignored
+const sqlQueryOtherBotQuotes = "SELECT bot_id, base_asset, quote_asset, best_bid, best_ask FROM bot_quotes WHERE bot_id <> $1 AND date_updated_utc >= $2 " +
+	"AND ((base_asset = $3 AND quote_asset = $4) OR (base_asset = $4 AND quote_asset = $3))"
+
+// BotQuote is the best bid and ask of a bot, quoted in units of its quote asset per unit of its base asset
+type BotQuote struct {
+	BotID      string
+	BaseAsset  string
+	QuoteAsset string
+	BestBid    *float64 // nil when the bot has no bids
+	BestAsk    *float64 // nil when the bot has no asks
+}
+
+// OtherBotQuotes is a query that fetches the quotes of the other bots that trade the same pair of assets as a bot
+type OtherBotQuotes struct {
+	db         *sql.DB
+	sqlQuery   string
+	botID      string
+	baseAsset  string
+	quoteAsset string
+}
+
+var _ api.Query = &OtherBotQuotes{}
+
+// MakeOtherBotQuotes makes the OtherBotQuotes query
+func MakeOtherBotQuotes(db *sql.DB, botID string, baseAsset string, quoteAsset string) (*OtherBotQuotes, error) {
+	if db == nil {
+		utils.PrintErrorHintf("the provided POSTGRES_DB config in the trader.cfg file should be non-nil")
+		return nil, fmt.Errorf("the provided db should be non-nil")
+	}
+
+	return &OtherBotQuotes{
+		db:         db,
+		sqlQuery:   sqlQueryOtherBotQuotes,
+		botID:      botID,
+		baseAsset:  baseAsset,
+		quoteAsset: quoteAsset,
+	}, nil
+}
+
+// Name impl.
+func (q *OtherBotQuotes) Name() string {
+	return "OtherBotQuotes"
+}
+
+// QueryRow impl. takes the cutoff (time.Time) before which quotes are considered stale and returns a []BotQuote
+func (q *OtherBotQuotes) QueryRow(args ...interface{}) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("expected 1 arg (cutoff time.Time), but got args %v", args)
+	}
+	cutoff, ok := args[0].(time.Time)
+	if !ok {
+		return nil, fmt.Errorf("cutoff arg needs to be of type 'time.Time', but was of type '%T'", args[0])
+	}
+
+	rows, e := q.db.Query(q.sqlQuery, q.botID, cutoff.UTC(), q.baseAsset, q.quoteAsset)
+	if e != nil {
+		return nil, fmt.Errorf("could not execute OtherBotQuotes query: %s", e)
+	}
+	defer rows.Close()
+
+	quotes := []BotQuote{}
+	for rows.Next() {
+		var quote BotQuote
+		var bestBid sql.NullFloat64
+		var bestAsk sql.NullFloat64
+		e = rows.Scan(&quote.BotID, &quote.BaseAsset, &quote.QuoteAsset, &bestBid, &bestAsk)
+		if e != nil {
+			return nil, fmt.Errorf("could not read data from OtherBotQuotes query: %s", e)
+		}
+		if bestBid.Valid {
+			quote.BestBid = &bestBid.Float64
+		}
+		if bestAsk.Valid {
+			quote.BestAsk = &bestAsk.Float64
+		}
+		quotes = append(quotes, quote)
+	}
+	return quotes, rows.Err()
+}
@@ -0,0 +1,73 @@
+package queries
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// sqlQueryDailyTradeCountTemplateAllAccounts queries the trades table to get the number of fills on a given day
+const sqlQueryDailyTradeCountTemplateAllAccounts = "SELECT COUNT(*) as num_trades FROM trades WHERE market_id IN (%s) AND DATE(date_utc) = $1"
+
+// sqlQueryDailyTradeCountTemplateSpecificAccounts queries the trades table to get the number of fills on a given day filtered by specific accounts
+const sqlQueryDailyTradeCountTemplateSpecificAccounts = "SELECT COUNT(*) as num_trades FROM trades WHERE market_id IN (%s) AND account_id IN (%s) AND DATE(date_utc) = $1"
+
+// DailyTradeCount is a query that fetches the number of fills on a given day, where every row in the trades table is one fill
+type DailyTradeCount struct {
+	db       *sql.DB
+	sqlQuery string
+}
+
+var _ api.Query = &DailyTradeCount{}
+
+// MakeDailyTradeCountForMarketIds makes the DailyTradeCount query for a set of marketIds
+func MakeDailyTradeCountForMarketIds(
+	db *sql.DB,
+	marketIDs []string,
+	optionalAccountIDs []string,
+) (*DailyTradeCount, error) {
+	if db == nil {
+		utils.PrintErrorHintf("the provided POSTGRES_DB config in the trader.cfg file should be non-nil")
+		return nil, fmt.Errorf("the provided db should be non-nil")
+	}
+
+	return &DailyTradeCount{
+		db:       db,
+		sqlQuery: makeSQLQueryDailyTradeCount(marketIDs, optionalAccountIDs),
+	}, nil
+}
+
+// Name impl.
+func (q *DailyTradeCount) Name() string {
+	return "DailyTradeCount"
+}
+
+// QueryRow impl. takes the dateUTC (string) and returns a *int64 with the number of fills on that day
+func (q *DailyTradeCount) QueryRow(args ...interface{}) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("expected 1 arg (dateUTC string), but got args %v", args)
+	} else if _, ok := args[0].(string); !ok {
+		return nil, fmt.Errorf("input arg needs to be of type 'string', but was of type '%T'", args[0])
+	}
+
+	// an aggregate without a group by always returns exactly one row
+	row := q.db.QueryRow(q.sqlQuery, args[0])
+	var numTrades int64
+	e := row.Scan(&numTrades)
+	if e != nil {
+		return nil, fmt.Errorf("could not read data from DailyTradeCount query: %s", e)
+	}
+	return &numTrades, nil
+}
+
+func makeSQLQueryDailyTradeCount(marketIDs []string, optionalAccountIDs []string) string {
+	marketsInClause := makeInClause(marketIDs)
+
+	// len(a), where a is a nil array, is valid and returns 0
+	if len(optionalAccountIDs) == 0 {
+		return fmt.Sprintf(sqlQueryDailyTradeCountTemplateAllAccounts, marketsInClause)
+	}
+	return fmt.Sprintf(sqlQueryDailyTradeCountTemplateSpecificAccounts, marketsInClause, makeInClause(optionalAccountIDs))
+}
@@ -0,0 +1,34 @@
+package queries
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMakeSQLQueryDailyTradeCount(t *testing.T) {
+	testCases := []struct {
+		name               string
+		marketIDs          []string
+		optionalAccountIDs []string
+		want               string
+	}{
+		{
+			name:               "all accounts",
+			marketIDs:          []string{"marketA", "marketB"},
+			optionalAccountIDs: nil,
+			want:               "SELECT COUNT(*) as num_trades FROM trades WHERE market_id IN ('marketA', 'marketB') AND DATE(date_utc) = $1",
+		}, {
+			name:               "specific accounts",
+			marketIDs:          []string{"marketA"},
+			optionalAccountIDs: []string{"account1", "account2"},
+			want:               "SELECT COUNT(*) as num_trades FROM trades WHERE market_id IN ('marketA') AND account_id IN ('account1', 'account2') AND DATE(date_utc) = $1",
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			assert.Equal(t, k.want, makeSQLQueryDailyTradeCount(k.marketIDs, k.optionalAccountIDs))
+		})
+	}
+}
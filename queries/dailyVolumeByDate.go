@@ -15,6 +15,10 @@ const sqlQueryDailyValuesTemplateAllAccounts = "SELECT SUM(base_volume) as total
 // sqlQueryDailyValuesTemplateSpecificAccounts queries the trades table to get the values for a given day filtered by specific accounts
 const sqlQueryDailyValuesTemplateSpecificAccounts = "SELECT SUM(base_volume) as total_base_volume, SUM(counter_cost) as total_counter_volume FROM trades WHERE market_id IN (%s) AND account_id IN (%s) AND DATE(date_utc) = $1 and action = $2 group by DATE(date_utc)"
 
+// sqlQueryDailyValuesTemplateAccountScope queries the trades table to get the values for a given day across all the markets of a base and quote
+// asset traded by specific accounts
+const sqlQueryDailyValuesTemplateAccountScope = "SELECT SUM(base_volume) as total_base_volume, SUM(counter_cost) as total_counter_volume FROM trades WHERE account_id IN (%s) AND market_id IN (SELECT market_id FROM markets WHERE base = %s AND quote = %s) AND DATE(date_utc) = $1 and action = $2 group by DATE(date_utc)"
+
 // DailyVolumeAction represents either a sell or a buy
 type DailyVolumeAction string
 
@@ -84,6 +88,31 @@ func MakeDailyVolumeByDateForMarketIdsAction(
 	}, nil
 }
 
+// MakeDailyVolumeByDateForAccountIdsAction makes the DailyVolumeByDate query for all the markets of the base and quote asset (on any exchange)
+// traded by a set of accountIDs and an action. Only markets of the same assets are included since the volumes are summed as stored
+func MakeDailyVolumeByDateForAccountIdsAction(
+	db *sql.DB,
+	accountIDs []string,
+	baseAsset string,
+	quoteAsset string,
+	action DailyVolumeAction,
+) (*DailyVolumeByDate, error) {
+	if db == nil {
+		utils.PrintErrorHintf("the provided POSTGRES_DB config in the trader.cfg file should be non-nil")
+		return nil, fmt.Errorf("the provided db should be non-nil")
+	}
+	if len(accountIDs) == 0 {
+		return nil, fmt.Errorf("need at least one accountID")
+	}
+
+	sqlQuery := fmt.Sprintf(sqlQueryDailyValuesTemplateAccountScope, makeInClause(accountIDs), makeInClause([]string{baseAsset}), makeInClause([]string{quoteAsset}))
+	return &DailyVolumeByDate{
+		db:       db,
+		sqlQuery: sqlQuery,
+		action:   action,
+	}, nil
+}
+
 // Name impl.
 func (q *DailyVolumeByDate) Name() string {
 	return "DailyVolumeByDate"
@@ -127,12 +156,7 @@ func (q *DailyVolumeByDate) QueryRow(args ...interface{}) (interface{}, error) {
 
 func makeSQLQueryDailyVolume(marketIDs []string, optionalAccountIDs []string) string {
 	// add filter on marketIDs
-	marketsInClauseParts := []string{}
-	for _, mid := range marketIDs {
-		marketsInValue := fmt.Sprintf("'%s'", mid)
-		marketsInClauseParts = append(marketsInClauseParts, marketsInValue)
-	}
-	marketsInClause := strings.Join(marketsInClauseParts, ", ")
+	marketsInClause := makeInClause(marketIDs)
 
 	// len(a), where a is a nil array, is valid and returns 0
 	if len(optionalAccountIDs) == 0 {
@@ -140,11 +164,15 @@ func makeSQLQueryDailyVolume(marketIDs []string, optionalAccountIDs []string) st
 	}
 
 	// include filter on account_id
-	accountsInClauseParts := []string{}
-	for _, aid := range optionalAccountIDs {
-		accountsInValue := fmt.Sprintf("'%s'", aid)
-		accountsInClauseParts = append(accountsInClauseParts, accountsInValue)
-	}
-	accountsInClause := strings.Join(accountsInClauseParts, ", ")
+	accountsInClause := makeInClause(optionalAccountIDs)
 	return fmt.Sprintf(sqlQueryDailyValuesTemplateSpecificAccounts, marketsInClause, accountsInClause)
 }
+
+// makeInClause quotes the values and joins them so they can be used in an IN clause
+func makeInClause(values []string) string {
+	inClauseParts := []string{}
+	for _, v := range values {
+		inClauseParts = append(inClauseParts, fmt.Sprintf("'%s'", v))
+	}
+	return strings.Join(inClauseParts, ", ")
+}
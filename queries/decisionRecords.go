@@ -0,0 +1,110 @@
+package queries
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// sqlQueryDecisionRecordsLatest queries the decision_records table for the most recent records, newest first
+const sqlQueryDecisionRecordsLatest = "SELECT date_utc, seq, outcome, record FROM decision_records WHERE account_id = $1 AND market_id = $2 ORDER BY date_utc DESC, seq DESC LIMIT $3"
+
+// sqlQueryDecisionRecordsAfter queries the decision_records table for the records written after a record, oldest first. Records are
+// compared by (date_utc, seq) so a record written in the same instant as the last record that was returned is not skipped
+const sqlQueryDecisionRecordsAfter = "SELECT date_utc, seq, outcome, record FROM decision_records WHERE account_id = $1 AND market_id = $2 AND (date_utc, seq) > ($3, $4) ORDER BY date_utc ASC, seq ASC LIMIT $5"
+
+// DecisionRecordRow is the explanation of one update cycle, Record is the JSON written by the bot. Seq orders the records that were
+// written in the same instant
+type DecisionRecordRow struct {
+	DateUTC time.Time       `json:"date_utc"`
+	Seq     int64           `json:"seq"`
+	Outcome string          `json:"outcome"`
+	Record  json.RawMessage `json:"record"`
+}
+
+// DecisionRecordsQuery is a query that fetches the DecisionRecordRows of a market
+type DecisionRecordsQuery struct {
+	db        *sql.DB
+	accountID string
+	marketID  string
+}
+
+var _ api.Query = &DecisionRecordsQuery{}
+
+// MakeDecisionRecordsQuery makes the DecisionRecordsQuery query
+func MakeDecisionRecordsQuery(db *sql.DB, accountID string, marketID string) (*DecisionRecordsQuery, error) {
+	if db == nil {
+		utils.PrintErrorHintf("the provided POSTGRES_DB config in the trader.cfg file should be non-nil")
+		return nil, fmt.Errorf("the provided db should be non-nil")
+	}
+
+	return &DecisionRecordsQuery{
+		db:        db,
+		accountID: accountID,
+		marketID:  marketID,
+	}, nil
+}
+
+// Name impl.
+func (q *DecisionRecordsQuery) Name() string {
+	return "DecisionRecords"
+}
+
+// QueryRow impl. takes the date and seq of the record after which records are returned (the zero time returns the latest records) and
+// the max number of records, and returns a []DecisionRecordRow sorted by date and seq
+func (q *DecisionRecordsQuery) QueryRow(args ...interface{}) (interface{}, error) {
+	if len(args) != 3 {
+		return nil, fmt.Errorf("expected 3 args (after time.Time, afterSeq int64, limit int), but got args %v", args)
+	}
+	after, ok := args[0].(time.Time)
+	if !ok {
+		return nil, fmt.Errorf("after arg needs to be of type 'time.Time', but was of type '%T'", args[0])
+	}
+	afterSeq, ok := args[1].(int64)
+	if !ok {
+		return nil, fmt.Errorf("afterSeq arg needs to be of type 'int64', but was of type '%T'", args[1])
+	}
+	limit, ok := args[2].(int)
+	if !ok {
+		return nil, fmt.Errorf("limit arg needs to be of type 'int', but was of type '%T'", args[2])
+	}
+
+	var rows *sql.Rows
+	var e error
+	if after.IsZero() {
+		rows, e = q.db.Query(sqlQueryDecisionRecordsLatest, q.accountID, q.marketID, limit)
+	} else {
+		rows, e = q.db.Query(sqlQueryDecisionRecordsAfter, q.accountID, q.marketID, after.UTC(), afterSeq, limit)
+	}
+	if e != nil {
+		return nil, fmt.Errorf("could not execute DecisionRecords query: %s", e)
+	}
+	defer rows.Close()
+
+	records := []DecisionRecordRow{}
+	for rows.Next() {
+		var r DecisionRecordRow
+		var record string
+		e = rows.Scan(&r.DateUTC, &r.Seq, &r.Outcome, &record)
+		if e != nil {
+			return nil, fmt.Errorf("could not read data from DecisionRecords query: %s", e)
+		}
+		r.Record = json.RawMessage(record)
+		records = append(records, r)
+	}
+	if e = rows.Err(); e != nil {
+		return nil, fmt.Errorf("could not read data from DecisionRecords query: %s", e)
+	}
+
+	if after.IsZero() {
+		// the latest records are loaded newest first so they need to be reversed to be sorted by date
+		for i, j := 0, len(records)-1; i < j; i, j = i+1, j-1 {
+			records[i], records[j] = records[j], records[i]
+		}
+	}
+	return records, nil
+}
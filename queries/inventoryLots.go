@@ -0,0 +1,179 @@
+package queries
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// sqlQueryInventoryOpenLots queries the inventory_lots table for the lots of an account and market that still have a remaining volume
+const sqlQueryInventoryOpenLots = "SELECT lot_txid, date_opened_utc, side, price, base_volume, remaining_base_volume FROM inventory_lots WHERE account_id = $1 AND market_id = $2 AND remaining_base_volume > 0 ORDER BY date_opened_utc ASC, lot_txid ASC"
+
+// sqlQueryInventoryLotClosures queries the inventory_lot_closures table for the closures of an account and market in a date range
+const sqlQueryInventoryLotClosures = "SELECT lot_txid, closing_txid, date_opened_utc, date_closed_utc, side, base_volume, open_price, close_price, realized_pnl FROM inventory_lot_closures WHERE account_id = $1 AND market_id = $2 AND date_closed_utc >= $3 AND date_closed_utc < $4 ORDER BY date_closed_utc ASC, closing_txid ASC, lot_txid ASC"
+
+// InventoryLotSide is the side of the inventory that a lot represents
+type InventoryLotSide string
+
+// type of InventoryLotSide
+const (
+	// InventoryLotSideLong is a lot of the base asset that was bought and is closed by selling
+	InventoryLotSideLong InventoryLotSide = "long"
+	// InventoryLotSideShort is a lot of the base asset that was sold without any long inventory and is closed by buying
+	InventoryLotSideShort InventoryLotSide = "short"
+)
+
+// String is the Stringer method impl
+func (s InventoryLotSide) String() string {
+	return string(s)
+}
+
+// Opposite returns the side of the lots that are closed by a trade that would open a lot on this side
+func (s InventoryLotSide) Opposite() InventoryLotSide {
+	if s == InventoryLotSideLong {
+		return InventoryLotSideShort
+	}
+	return InventoryLotSideLong
+}
+
+// InventoryLot is a quantity of the base asset acquired (long) or sold short (short) by a single trade at a single price
+type InventoryLot struct {
+	TxID                string           `json:"txid"`
+	DateOpenedUTC       time.Time        `json:"date_opened_utc"`
+	Side                InventoryLotSide `json:"side"`
+	Price               float64          `json:"price"`
+	BaseVolume          float64          `json:"base_volume"`
+	RemainingBaseVolume float64          `json:"remaining_base_volume"`
+}
+
+// InventoryLotClosure is the part of a lot that was closed by a trade, along with the profit or loss realized in units of the quote asset
+type InventoryLotClosure struct {
+	LotTxID       string           `json:"lot_txid"`
+	ClosingTxID   string           `json:"closing_txid"`
+	DateOpenedUTC time.Time        `json:"date_opened_utc"`
+	DateClosedUTC time.Time        `json:"date_closed_utc"`
+	Side          InventoryLotSide `json:"side"`
+	BaseVolume    float64          `json:"base_volume"`
+	OpenPrice     float64          `json:"open_price"`
+	ClosePrice    float64          `json:"close_price"`
+	RealizedPnL   float64          `json:"realized_pnl"`
+}
+
+// InventoryOpenLots is a query that fetches the open lots of the inventory, oldest first
+type InventoryOpenLots struct {
+	db        *sql.DB
+	sqlQuery  string
+	accountID string
+	marketID  string
+}
+
+var _ api.Query = &InventoryOpenLots{}
+
+// MakeInventoryOpenLots makes the InventoryOpenLots query
+func MakeInventoryOpenLots(db *sql.DB, accountID string, marketID string) (*InventoryOpenLots, error) {
+	if db == nil {
+		utils.PrintErrorHintf("the provided POSTGRES_DB config in the trader.cfg file should be non-nil")
+		return nil, fmt.Errorf("the provided db should be non-nil")
+	}
+
+	return &InventoryOpenLots{
+		db:        db,
+		sqlQuery:  sqlQueryInventoryOpenLots,
+		accountID: accountID,
+		marketID:  marketID,
+	}, nil
+}
+
+// Name impl.
+func (q *InventoryOpenLots) Name() string {
+	return "InventoryOpenLots"
+}
+
+// QueryRow impl. returns a []InventoryLot
+func (q *InventoryOpenLots) QueryRow(args ...interface{}) (interface{}, error) {
+	if len(args) != 0 {
+		return nil, fmt.Errorf("expected 0 args, but got args %v", args)
+	}
+
+	rows, e := q.db.Query(q.sqlQuery, q.accountID, q.marketID)
+	if e != nil {
+		return nil, fmt.Errorf("could not execute InventoryOpenLots query: %s", e)
+	}
+	defer rows.Close()
+
+	lots := []InventoryLot{}
+	for rows.Next() {
+		var lot InventoryLot
+		e = rows.Scan(&lot.TxID, &lot.DateOpenedUTC, &lot.Side, &lot.Price, &lot.BaseVolume, &lot.RemainingBaseVolume)
+		if e != nil {
+			return nil, fmt.Errorf("could not read data from InventoryOpenLots query: %s", e)
+		}
+		lots = append(lots, lot)
+	}
+	return lots, rows.Err()
+}
+
+// InventoryLotClosures is a query that fetches the closed parts of lots in a date range, which is what is needed for per-lot P&L and tax exports
+type InventoryLotClosures struct {
+	db        *sql.DB
+	sqlQuery  string
+	accountID string
+	marketID  string
+}
+
+var _ api.Query = &InventoryLotClosures{}
+
+// MakeInventoryLotClosures makes the InventoryLotClosures query
+func MakeInventoryLotClosures(db *sql.DB, accountID string, marketID string) (*InventoryLotClosures, error) {
+	if db == nil {
+		utils.PrintErrorHintf("the provided POSTGRES_DB config in the trader.cfg file should be non-nil")
+		return nil, fmt.Errorf("the provided db should be non-nil")
+	}
+
+	return &InventoryLotClosures{
+		db:        db,
+		sqlQuery:  sqlQueryInventoryLotClosures,
+		accountID: accountID,
+		marketID:  marketID,
+	}, nil
+}
+
+// Name impl.
+func (q *InventoryLotClosures) Name() string {
+	return "InventoryLotClosures"
+}
+
+// QueryRow impl. takes the start (inclusive) and end (exclusive) time.Time of the range and returns a []InventoryLotClosure
+func (q *InventoryLotClosures) QueryRow(args ...interface{}) (interface{}, error) {
+	if len(args) != 2 {
+		return nil, fmt.Errorf("expected 2 args (start time.Time, end time.Time), but got args %v", args)
+	}
+	start, ok := args[0].(time.Time)
+	if !ok {
+		return nil, fmt.Errorf("start arg needs to be of type 'time.Time', but was of type '%T'", args[0])
+	}
+	end, ok := args[1].(time.Time)
+	if !ok {
+		return nil, fmt.Errorf("end arg needs to be of type 'time.Time', but was of type '%T'", args[1])
+	}
+
+	rows, e := q.db.Query(q.sqlQuery, q.accountID, q.marketID, start.UTC(), end.UTC())
+	if e != nil {
+		return nil, fmt.Errorf("could not execute InventoryLotClosures query: %s", e)
+	}
+	defer rows.Close()
+
+	closures := []InventoryLotClosure{}
+	for rows.Next() {
+		var c InventoryLotClosure
+		e = rows.Scan(&c.LotTxID, &c.ClosingTxID, &c.DateOpenedUTC, &c.DateClosedUTC, &c.Side, &c.BaseVolume, &c.OpenPrice, &c.ClosePrice, &c.RealizedPnL)
+		if e != nil {
+			return nil, fmt.Errorf("could not read data from InventoryLotClosures query: %s", e)
+		}
+		closures = append(closures, c)
+	}
+	return closures, rows.Err()
+}
@@ -0,0 +1,89 @@
+package queries
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// sqlQueryLevelStats queries the level_stats table for the daily stats of the levels of an account and market in a date range
+const sqlQueryLevelStats = "SELECT date_utc, side, level, spread, num_updates, num_fills, filled_base_volume, filled_quote_volume, spread_capture FROM level_stats WHERE account_id = $1 AND market_id = $2 AND date_utc >= $3 AND date_utc < $4 ORDER BY date_utc ASC, side ASC, level ASC"
+
+// LevelStats is the activity of a configured level of the buysell or sell strategy on a day (UTC)
+type LevelStats struct {
+	DateUTC           time.Time `json:"date_utc"`
+	Side              string    `json:"side"`  // "buy" or "sell"
+	Level             int       `json:"level"` // index of the level in the config, starting at 0
+	Spread            float64   `json:"spread"`
+	NumUpdates        int64     `json:"num_updates"` // update cycles in which the level was quoted
+	NumFills          int64     `json:"num_fills"`
+	FilledBaseVolume  float64   `json:"filled_base_volume"`
+	FilledQuoteVolume float64   `json:"filled_quote_volume"`
+	SpreadCapture     float64   `json:"spread_capture"` // profit of the fills relative to the mid price in units of the quote asset
+}
+
+// LevelStatsQuery is a query that fetches the daily LevelStats of an account and market in a date range
+type LevelStatsQuery struct {
+	db        *sql.DB
+	sqlQuery  string
+	accountID string
+	marketID  string
+}
+
+var _ api.Query = &LevelStatsQuery{}
+
+// MakeLevelStatsQuery makes the LevelStatsQuery query
+func MakeLevelStatsQuery(db *sql.DB, accountID string, marketID string) (*LevelStatsQuery, error) {
+	if db == nil {
+		utils.PrintErrorHintf("the provided POSTGRES_DB config in the trader.cfg file should be non-nil")
+		return nil, fmt.Errorf("the provided db should be non-nil")
+	}
+
+	return &LevelStatsQuery{
+		db:        db,
+		sqlQuery:  sqlQueryLevelStats,
+		accountID: accountID,
+		marketID:  marketID,
+	}, nil
+}
+
+// Name impl.
+func (q *LevelStatsQuery) Name() string {
+	return "LevelStats"
+}
+
+// QueryRow impl. takes the start (inclusive) and end (exclusive) date string of the date range in the postgresdb.DateFormatString format
+// and returns a []LevelStats
+func (q *LevelStatsQuery) QueryRow(args ...interface{}) (interface{}, error) {
+	if len(args) != 2 {
+		return nil, fmt.Errorf("expected 2 args (start date string, end date string), but got args %v", args)
+	}
+	start, ok := args[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("start arg needs to be of type 'string', but was of type '%T'", args[0])
+	}
+	end, ok := args[1].(string)
+	if !ok {
+		return nil, fmt.Errorf("end arg needs to be of type 'string', but was of type '%T'", args[1])
+	}
+
+	rows, e := q.db.Query(q.sqlQuery, q.accountID, q.marketID, start, end)
+	if e != nil {
+		return nil, fmt.Errorf("could not execute LevelStats query: %s", e)
+	}
+	defer rows.Close()
+
+	stats := []LevelStats{}
+	for rows.Next() {
+		var s LevelStats
+		e = rows.Scan(&s.DateUTC, &s.Side, &s.Level, &s.Spread, &s.NumUpdates, &s.NumFills, &s.FilledBaseVolume, &s.FilledQuoteVolume, &s.SpreadCapture)
+		if e != nil {
+			return nil, fmt.Errorf("could not read data from LevelStats query: %s", e)
+		}
+		stats = append(stats, s)
+	}
+	return stats, rows.Err()
+}
@@ -0,0 +1,71 @@
+package queries
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// sqlQueryNetBasePositionTemplateAllAccounts queries the trades table to get the net amount of the base asset bought (buys minus sells)
+const sqlQueryNetBasePositionTemplateAllAccounts = "SELECT COALESCE(SUM(CASE WHEN action = 'buy' THEN base_volume ELSE -base_volume END), 0) as net_base_position FROM trades WHERE market_id IN (%s)"
+
+// sqlQueryNetBasePositionTemplateSpecificAccounts queries the trades table to get the net amount of the base asset bought (buys minus sells) filtered by specific accounts
+const sqlQueryNetBasePositionTemplateSpecificAccounts = "SELECT COALESCE(SUM(CASE WHEN action = 'buy' THEN base_volume ELSE -base_volume END), 0) as net_base_position FROM trades WHERE market_id IN (%s) AND account_id IN (%s)"
+
+// NetBasePosition is a query that fetches the cumulative net position in the base asset from all trades, which is positive when long
+type NetBasePosition struct {
+	db       *sql.DB
+	sqlQuery string
+}
+
+var _ api.Query = &NetBasePosition{}
+
+// MakeNetBasePositionForMarketIds makes the NetBasePosition query for a set of marketIds
+func MakeNetBasePositionForMarketIds(
+	db *sql.DB,
+	marketIDs []string,
+	optionalAccountIDs []string,
+) (*NetBasePosition, error) {
+	if db == nil {
+		utils.PrintErrorHintf("the provided POSTGRES_DB config in the trader.cfg file should be non-nil")
+		return nil, fmt.Errorf("the provided db should be non-nil")
+	}
+
+	return &NetBasePosition{
+		db:       db,
+		sqlQuery: makeSQLQueryNetBasePosition(marketIDs, optionalAccountIDs),
+	}, nil
+}
+
+// Name impl.
+func (q *NetBasePosition) Name() string {
+	return "NetBasePosition"
+}
+
+// QueryRow impl. returns a *float64 with the net position in units of the base asset
+func (q *NetBasePosition) QueryRow(args ...interface{}) (interface{}, error) {
+	if len(args) != 0 {
+		return nil, fmt.Errorf("expected 0 args, but got args %v", args)
+	}
+
+	// the sum is never NULL because of the COALESCE, and an aggregate without a group by always returns exactly one row
+	row := q.db.QueryRow(q.sqlQuery)
+	var netBasePosition float64
+	e := row.Scan(&netBasePosition)
+	if e != nil {
+		return nil, fmt.Errorf("could not read data from NetBasePosition query: %s", e)
+	}
+	return &netBasePosition, nil
+}
+
+func makeSQLQueryNetBasePosition(marketIDs []string, optionalAccountIDs []string) string {
+	marketsInClause := makeInClause(marketIDs)
+
+	// len(a), where a is a nil array, is valid and returns 0
+	if len(optionalAccountIDs) == 0 {
+		return fmt.Sprintf(sqlQueryNetBasePositionTemplateAllAccounts, marketsInClause)
+	}
+	return fmt.Sprintf(sqlQueryNetBasePositionTemplateSpecificAccounts, marketsInClause, makeInClause(optionalAccountIDs))
+}
@@ -0,0 +1,34 @@
+package queries
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMakeSQLQueryNetBasePosition(t *testing.T) {
+	testCases := []struct {
+		name               string
+		marketIDs          []string
+		optionalAccountIDs []string
+		want               string
+	}{
+		{
+			name:               "all accounts",
+			marketIDs:          []string{"marketA", "marketB"},
+			optionalAccountIDs: nil,
+			want:               "SELECT COALESCE(SUM(CASE WHEN action = 'buy' THEN base_volume ELSE -base_volume END), 0) as net_base_position FROM trades WHERE market_id IN ('marketA', 'marketB')",
+		}, {
+			name:               "specific accounts",
+			marketIDs:          []string{"marketA"},
+			optionalAccountIDs: []string{"account1", "account2"},
+			want:               "SELECT COALESCE(SUM(CASE WHEN action = 'buy' THEN base_volume ELSE -base_volume END), 0) as net_base_position FROM trades WHERE market_id IN ('marketA') AND account_id IN ('account1', 'account2')",
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			assert.Equal(t, k.want, makeSQLQueryNetBasePosition(k.marketIDs, k.optionalAccountIDs))
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package queries
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// sqlQueryNetTradeFlowsTemplateAllAccounts queries the trades table to get the net amount of the base asset bought and the net amount of the quote asset received
+const sqlQueryNetTradeFlowsTemplateAllAccounts = "SELECT COALESCE(SUM(CASE WHEN action = 'buy' THEN base_volume ELSE -base_volume END), 0) as net_base_volume, COALESCE(SUM(CASE WHEN action = 'sell' THEN counter_cost ELSE -counter_cost END), 0) as net_quote_volume FROM trades WHERE market_id IN (%s)"
+
+// sqlQueryNetTradeFlowsTemplateSpecificAccounts queries the trades table to get the net amount of the base asset bought and the net amount of the quote asset received filtered by specific accounts
+const sqlQueryNetTradeFlowsTemplateSpecificAccounts = "SELECT COALESCE(SUM(CASE WHEN action = 'buy' THEN base_volume ELSE -base_volume END), 0) as net_base_volume, COALESCE(SUM(CASE WHEN action = 'sell' THEN counter_cost ELSE -counter_cost END), 0) as net_quote_volume FROM trades WHERE market_id IN (%s) AND account_id IN (%s)"
+
+// NetTradeFlows is the net change in the balances of the base and quote assets from all trades, fees are not included
+type NetTradeFlows struct {
+	BaseVolume  float64 // positive when more of the base asset was bought than sold
+	QuoteVolume float64 // positive when more of the quote asset was received from sells than spent on buys
+}
+
+// PnL returns the realized and unrealized profit or loss in units of the quote asset, marking the net base position to the given price
+func (f *NetTradeFlows) PnL(price float64) float64 {
+	return f.QuoteVolume + f.BaseVolume*price
+}
+
+// NetTradeFlowsQuery is a query that fetches the NetTradeFlows from all trades
+type NetTradeFlowsQuery struct {
+	db       *sql.DB
+	sqlQuery string
+}
+
+var _ api.Query = &NetTradeFlowsQuery{}
+
+// MakeNetTradeFlowsForMarketIds makes the NetTradeFlowsQuery for a set of marketIds
+func MakeNetTradeFlowsForMarketIds(
+	db *sql.DB,
+	marketIDs []string,
+	optionalAccountIDs []string,
+) (*NetTradeFlowsQuery, error) {
+	if db == nil {
+		utils.PrintErrorHintf("the provided POSTGRES_DB config in the trader.cfg file should be non-nil")
+		return nil, fmt.Errorf("the provided db should be non-nil")
+	}
+
+	return &NetTradeFlowsQuery{
+		db:       db,
+		sqlQuery: makeSQLQueryNetTradeFlows(marketIDs, optionalAccountIDs),
+	}, nil
+}
+
+// Name impl.
+func (q *NetTradeFlowsQuery) Name() string {
+	return "NetTradeFlows"
+}
+
+// QueryRow impl. returns a *NetTradeFlows
+func (q *NetTradeFlowsQuery) QueryRow(args ...interface{}) (interface{}, error) {
+	if len(args) != 0 {
+		return nil, fmt.Errorf("expected 0 args, but got args %v", args)
+	}
+
+	// the sums are never NULL because of the COALESCE, and an aggregate without a group by always returns exactly one row
+	row := q.db.QueryRow(q.sqlQuery)
+	var flows NetTradeFlows
+	e := row.Scan(&flows.BaseVolume, &flows.QuoteVolume)
+	if e != nil {
+		return nil, fmt.Errorf("could not read data from NetTradeFlows query: %s", e)
+	}
+	return &flows, nil
+}
+
+func makeSQLQueryNetTradeFlows(marketIDs []string, optionalAccountIDs []string) string {
+	marketsInClause := makeInClause(marketIDs)
+
+	// len(a), where a is a nil array, is valid and returns 0
+	if len(optionalAccountIDs) == 0 {
+		return fmt.Sprintf(sqlQueryNetTradeFlowsTemplateAllAccounts, marketsInClause)
+	}
+	return fmt.Sprintf(sqlQueryNetTradeFlowsTemplateSpecificAccounts, marketsInClause, makeInClause(optionalAccountIDs))
+}
@@ -0,0 +1,41 @@
+package queries
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMakeSQLQueryNetTradeFlows(t *testing.T) {
+	testCases := []struct {
+		name               string
+		marketIDs          []string
+		optionalAccountIDs []string
+		want               string
+	}{
+		{
+			name:               "all accounts",
+			marketIDs:          []string{"marketA", "marketB"},
+			optionalAccountIDs: nil,
+			want:               "SELECT COALESCE(SUM(CASE WHEN action = 'buy' THEN base_volume ELSE -base_volume END), 0) as net_base_volume, COALESCE(SUM(CASE WHEN action = 'sell' THEN counter_cost ELSE -counter_cost END), 0) as net_quote_volume FROM trades WHERE market_id IN ('marketA', 'marketB')",
+		}, {
+			name:               "specific accounts",
+			marketIDs:          []string{"marketA"},
+			optionalAccountIDs: []string{"account1"},
+			want:               "SELECT COALESCE(SUM(CASE WHEN action = 'buy' THEN base_volume ELSE -base_volume END), 0) as net_base_volume, COALESCE(SUM(CASE WHEN action = 'sell' THEN counter_cost ELSE -counter_cost END), 0) as net_quote_volume FROM trades WHERE market_id IN ('marketA') AND account_id IN ('account1')",
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			assert.Equal(t, k.want, makeSQLQueryNetTradeFlows(k.marketIDs, k.optionalAccountIDs))
+		})
+	}
+}
+
+func TestNetTradeFlowsPnL(t *testing.T) {
+	// bought 100 base for 50 quote and sold 40 base for 30 quote, so we hold 60 base and spent 20 quote
+	flows := &NetTradeFlows{BaseVolume: 60.0, QuoteVolume: -20.0}
+	assert.InDelta(t, 10.0, flows.PnL(0.5), 0.0000001)
+	assert.InDelta(t, -20.0, flows.PnL(0.0), 0.0000001)
+}
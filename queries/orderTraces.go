@@ -0,0 +1,91 @@
+package queries
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// sqlQueryOrderTracesByCorrelationID queries the order_traces table for the events of a correlation ID
+const sqlQueryOrderTracesByCorrelationID = "SELECT correlation_id, date_utc, stage, side, offer_id, price, amount, detail FROM order_traces WHERE account_id = $1 AND market_id = $2 AND correlation_id = $3 ORDER BY date_utc ASC"
+
+// sqlQueryOrderTracesByOfferID queries the order_traces table for the events of every correlation ID that touched an offer, since an offer
+// that is updated in many update cycles gets a new correlation ID in each of them
+const sqlQueryOrderTracesByOfferID = "SELECT correlation_id, date_utc, stage, side, offer_id, price, amount, detail FROM order_traces WHERE account_id = $1 AND market_id = $2 AND correlation_id IN (SELECT DISTINCT correlation_id FROM order_traces WHERE account_id = $1 AND market_id = $2 AND offer_id = $3) ORDER BY date_utc ASC"
+
+// OrderTraceEvent is an event in the lifecycle of an offer, from the strategy deciding on it to its fills
+type OrderTraceEvent struct {
+	CorrelationID string    `json:"correlation_id"`
+	DateUTC       time.Time `json:"date_utc"`
+	Stage         string    `json:"stage"`
+	Side          string    `json:"side"`
+	OfferID       string    `json:"offer_id"` // empty for new offers until they are seen on the orderbook
+	Price         float64   `json:"price"`    // price of the base asset in units of the quote asset
+	Amount        float64   `json:"amount"`   // amount of the base asset
+	Detail        string    `json:"detail"`
+}
+
+// OrderTracesQuery is a query that fetches the OrderTraceEvents of a correlation ID or of an offer ID
+type OrderTracesQuery struct {
+	db        *sql.DB
+	sqlQuery  string
+	accountID string
+	marketID  string
+}
+
+var _ api.Query = &OrderTracesQuery{}
+
+// MakeOrderTracesQuery makes the OrderTracesQuery query, byOfferID selects whether the arg of QueryRow is an offer ID or a correlation ID
+func MakeOrderTracesQuery(db *sql.DB, accountID string, marketID string, byOfferID bool) (*OrderTracesQuery, error) {
+	if db == nil {
+		utils.PrintErrorHintf("the provided POSTGRES_DB config in the trader.cfg file should be non-nil")
+		return nil, fmt.Errorf("the provided db should be non-nil")
+	}
+
+	sqlQuery := sqlQueryOrderTracesByCorrelationID
+	if byOfferID {
+		sqlQuery = sqlQueryOrderTracesByOfferID
+	}
+	return &OrderTracesQuery{
+		db:        db,
+		sqlQuery:  sqlQuery,
+		accountID: accountID,
+		marketID:  marketID,
+	}, nil
+}
+
+// Name impl.
+func (q *OrderTracesQuery) Name() string {
+	return "OrderTraces"
+}
+
+// QueryRow impl. takes the correlation ID or the offer ID and returns a []OrderTraceEvent sorted by date
+func (q *OrderTracesQuery) QueryRow(args ...interface{}) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("expected 1 arg (correlation ID or offer ID string), but got args %v", args)
+	}
+	id, ok := args[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("id arg needs to be of type 'string', but was of type '%T'", args[0])
+	}
+
+	rows, e := q.db.Query(q.sqlQuery, q.accountID, q.marketID, id)
+	if e != nil {
+		return nil, fmt.Errorf("could not execute OrderTraces query: %s", e)
+	}
+	defer rows.Close()
+
+	events := []OrderTraceEvent{}
+	for rows.Next() {
+		var ev OrderTraceEvent
+		e = rows.Scan(&ev.CorrelationID, &ev.DateUTC, &ev.Stage, &ev.Side, &ev.OfferID, &ev.Price, &ev.Amount, &ev.Detail)
+		if e != nil {
+			return nil, fmt.Errorf("could not read data from OrderTraces query: %s", e)
+		}
+		events = append(events, ev)
+	}
+	return events, rows.Err()
+}
@@ -0,0 +1,69 @@
+package queries
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// sqlQueryPortfolioBudget queries the portfolio_budgets table for the budget of a bot
+const sqlQueryPortfolioBudget = "SELECT budget, realized_pnl, max_exposure_base, max_daily_volume_quote, date_updated_utc FROM portfolio_budgets WHERE bot_id = $1"
+
+// PortfolioBudget is the budget that the portfolio manager assigned to a bot, the limits are in units of the assets of the market of the bot
+type PortfolioBudget struct {
+	BotID               string
+	Budget              float64 // in units of the portfolio
+	RealizedPnL         float64 // in units of the portfolio, over the lookback window of the manager
+	MaxExposureBase     float64
+	MaxDailyVolumeQuote float64
+	DateUpdatedUTC      time.Time
+}
+
+// PortfolioBudgetQuery is a query that fetches the budget of a bot
+type PortfolioBudgetQuery struct {
+	db       *sql.DB
+	sqlQuery string
+	botID    string
+}
+
+var _ api.Query = &PortfolioBudgetQuery{}
+
+// MakePortfolioBudgetQuery makes the PortfolioBudgetQuery query
+func MakePortfolioBudgetQuery(db *sql.DB, botID string) (*PortfolioBudgetQuery, error) {
+	if db == nil {
+		utils.PrintErrorHintf("the provided POSTGRES_DB config in the trader.cfg file should be non-nil")
+		return nil, fmt.Errorf("the provided db should be non-nil")
+	}
+
+	return &PortfolioBudgetQuery{
+		db:       db,
+		sqlQuery: sqlQueryPortfolioBudget,
+		botID:    botID,
+	}, nil
+}
+
+// Name impl.
+func (q *PortfolioBudgetQuery) Name() string {
+	return "PortfolioBudgetQuery"
+}
+
+// QueryRow impl. takes no args and returns a *PortfolioBudget, which is nil when no budget has been assigned to the bot
+func (q *PortfolioBudgetQuery) QueryRow(args ...interface{}) (interface{}, error) {
+	if len(args) != 0 {
+		return nil, fmt.Errorf("expected 0 args, but got args %v", args)
+	}
+
+	budget := PortfolioBudget{BotID: q.botID}
+	row := q.db.QueryRow(q.sqlQuery, q.botID)
+	e := row.Scan(&budget.Budget, &budget.RealizedPnL, &budget.MaxExposureBase, &budget.MaxDailyVolumeQuote, &budget.DateUpdatedUTC)
+	if e != nil {
+		if e == sql.ErrNoRows {
+			return (*PortfolioBudget)(nil), nil
+		}
+		return nil, fmt.Errorf("could not read data from PortfolioBudgetQuery query: %s", e)
+	}
+	return &budget, nil
+}
@@ -0,0 +1,82 @@
+package queries
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// sqlQuerySpreadObligationSummary sums the time covered by the spread obligation samples of an account and market in a date range,
+// along with the time when each side met the terms and the time when both sides met the terms
+const sqlQuerySpreadObligationSummary = "SELECT " +
+	"COUNT(*), " +
+	"COALESCE(SUM(duration_seconds), 0), " +
+	"COALESCE(SUM(CASE WHEN bid_met THEN duration_seconds ELSE 0 END), 0), " +
+	"COALESCE(SUM(CASE WHEN ask_met THEN duration_seconds ELSE 0 END), 0), " +
+	"COALESCE(SUM(CASE WHEN bid_met AND ask_met THEN duration_seconds ELSE 0 END), 0) " +
+	"FROM spread_obligation_samples WHERE account_id = $1 AND market_id = $2 AND date_utc >= $3 AND date_utc < $4"
+
+// SpreadObligationSummary is the time in seconds that the quotes of a bot met the terms of its spread obligation in a date range
+type SpreadObligationSummary struct {
+	NumSamples     int64   `json:"num_samples"`
+	CoveredSeconds float64 `json:"covered_seconds"` // time covered by samples, which excludes the time the bot was not running
+	BidMetSeconds  float64 `json:"bid_met_seconds"`
+	AskMetSeconds  float64 `json:"ask_met_seconds"`
+	BothMetSeconds float64 `json:"both_met_seconds"`
+}
+
+// SpreadObligationSummaryQuery is a query that fetches the SpreadObligationSummary of an account and market in a date range
+type SpreadObligationSummaryQuery struct {
+	db        *sql.DB
+	sqlQuery  string
+	accountID string
+	marketID  string
+}
+
+var _ api.Query = &SpreadObligationSummaryQuery{}
+
+// MakeSpreadObligationSummaryQuery makes the SpreadObligationSummaryQuery query
+func MakeSpreadObligationSummaryQuery(db *sql.DB, accountID string, marketID string) (*SpreadObligationSummaryQuery, error) {
+	if db == nil {
+		utils.PrintErrorHintf("the provided POSTGRES_DB config in the trader.cfg file should be non-nil")
+		return nil, fmt.Errorf("the provided db should be non-nil")
+	}
+
+	return &SpreadObligationSummaryQuery{
+		db:        db,
+		sqlQuery:  sqlQuerySpreadObligationSummary,
+		accountID: accountID,
+		marketID:  marketID,
+	}, nil
+}
+
+// Name impl.
+func (q *SpreadObligationSummaryQuery) Name() string {
+	return "SpreadObligationSummary"
+}
+
+// QueryRow impl. takes the start (inclusive) and end (exclusive) time.Time of the date range and returns a *SpreadObligationSummary
+func (q *SpreadObligationSummaryQuery) QueryRow(args ...interface{}) (interface{}, error) {
+	if len(args) != 2 {
+		return nil, fmt.Errorf("expected 2 args (start time.Time, end time.Time), but got args %v", args)
+	}
+	start, ok := args[0].(time.Time)
+	if !ok {
+		return nil, fmt.Errorf("start arg needs to be of type 'time.Time', but was of type '%T'", args[0])
+	}
+	end, ok := args[1].(time.Time)
+	if !ok {
+		return nil, fmt.Errorf("end arg needs to be of type 'time.Time', but was of type '%T'", args[1])
+	}
+
+	row := q.db.QueryRow(q.sqlQuery, q.accountID, q.marketID, start.UTC(), end.UTC())
+	var summary SpreadObligationSummary
+	e := row.Scan(&summary.NumSamples, &summary.CoveredSeconds, &summary.BidMetSeconds, &summary.AskMetSeconds, &summary.BothMetSeconds)
+	if e != nil {
+		return nil, fmt.Errorf("could not read data from SpreadObligationSummary query: %s", e)
+	}
+	return &summary, nil
+}
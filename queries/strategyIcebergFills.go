@@ -0,0 +1,67 @@
+package queries
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// sqlQueryStrategyIcebergFills sums the base volume filled on each iceberg level of one side of a market
+const sqlQueryStrategyIcebergFills = "SELECT level, SUM(base_volume) FROM strategy_iceberg_fills WHERE market_id = $1 AND side = $2 GROUP BY level"
+
+// StrategyIcebergFills is a query that fetches the base volume that was drawn from the hidden reserve of each iceberg level
+type StrategyIcebergFills struct {
+	db       *sql.DB
+	sqlQuery string
+	marketID string
+	side     string
+}
+
+var _ api.Query = &StrategyIcebergFills{}
+
+// MakeStrategyIcebergFills makes the StrategyIcebergFills query for the levels of one side (buy or sell) of a market
+func MakeStrategyIcebergFills(db *sql.DB, marketID string, side string) (*StrategyIcebergFills, error) {
+	if db == nil {
+		utils.PrintErrorHintf("the provided POSTGRES_DB config in the trader.cfg file should be non-nil")
+		return nil, fmt.Errorf("the provided db should be non-nil")
+	}
+
+	return &StrategyIcebergFills{
+		db:       db,
+		sqlQuery: sqlQueryStrategyIcebergFills,
+		marketID: marketID,
+		side:     side,
+	}, nil
+}
+
+// Name impl.
+func (q *StrategyIcebergFills) Name() string {
+	return "StrategyIcebergFills"
+}
+
+// QueryRow impl. returns a map[int]float64 of the index of the level to the filled base volume
+func (q *StrategyIcebergFills) QueryRow(args ...interface{}) (interface{}, error) {
+	if len(args) != 0 {
+		return nil, fmt.Errorf("expected 0 args, but got args %v", args)
+	}
+
+	rows, e := q.db.Query(q.sqlQuery, q.marketID, q.side)
+	if e != nil {
+		return nil, fmt.Errorf("could not execute StrategyIcebergFills query: %s", e)
+	}
+	defer rows.Close()
+
+	filledByLevel := map[int]float64{}
+	for rows.Next() {
+		var level int
+		var baseVolume float64
+		e = rows.Scan(&level, &baseVolume)
+		if e != nil {
+			return nil, fmt.Errorf("could not read data from StrategyIcebergFills query: %s", e)
+		}
+		filledByLevel[level] = baseVolume
+	}
+	return filledByLevel, rows.Err()
+}
@@ -0,0 +1,77 @@
+package queries
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// sqlQueryStrategyMirrorPendingOffsets queries the strategy_mirror_pending_offsets table for the trades of a market that are still waiting to be
+// offset, skipping trades that already have a trade trigger since they were offset before the pending row could be deleted
+const sqlQueryStrategyMirrorPendingOffsets = "SELECT p.txid, p.action, p.counter_price, p.base_volume, p.date_added_utc FROM strategy_mirror_pending_offsets p " +
+	"WHERE p.market_id = $1 AND NOT EXISTS (SELECT 1 FROM strategy_mirror_trade_triggers t WHERE t.market_id = p.market_id AND t.txid = p.txid) " +
+	"ORDER BY p.date_added_utc ASC"
+
+// PendingOffsetTrade is a trade on the primary market whose offset on the backing exchange is being aggregated with other trades
+type PendingOffsetTrade struct {
+	TxID         string
+	Action       string // action of the trade on the primary market, the offset is in the opposite direction
+	Price        float64
+	BaseVolume   float64
+	DateAddedUTC time.Time
+}
+
+// StrategyMirrorPendingOffsets is a query that fetches the trades of a market that are waiting to be offset
+type StrategyMirrorPendingOffsets struct {
+	db       *sql.DB
+	sqlQuery string
+	marketID string
+}
+
+var _ api.Query = &StrategyMirrorPendingOffsets{}
+
+// MakeStrategyMirrorPendingOffsets makes the StrategyMirrorPendingOffsets query
+func MakeStrategyMirrorPendingOffsets(db *sql.DB, marketID string) (*StrategyMirrorPendingOffsets, error) {
+	if db == nil {
+		utils.PrintErrorHintf("the provided POSTGRES_DB config in the trader.cfg file should be non-nil")
+		return nil, fmt.Errorf("the provided db should be non-nil")
+	}
+
+	return &StrategyMirrorPendingOffsets{
+		db:       db,
+		sqlQuery: sqlQueryStrategyMirrorPendingOffsets,
+		marketID: marketID,
+	}, nil
+}
+
+// Name impl.
+func (q *StrategyMirrorPendingOffsets) Name() string {
+	return "StrategyMirrorPendingOffsets"
+}
+
+// QueryRow impl. returns a []PendingOffsetTrade ordered by the time the trades were added
+func (q *StrategyMirrorPendingOffsets) QueryRow(args ...interface{}) (interface{}, error) {
+	if len(args) != 0 {
+		return nil, fmt.Errorf("expected 0 args, but got args %v", args)
+	}
+
+	rows, e := q.db.Query(q.sqlQuery, q.marketID)
+	if e != nil {
+		return nil, fmt.Errorf("could not execute StrategyMirrorPendingOffsets query: %s", e)
+	}
+	defer rows.Close()
+
+	trades := []PendingOffsetTrade{}
+	for rows.Next() {
+		var trade PendingOffsetTrade
+		e = rows.Scan(&trade.TxID, &trade.Action, &trade.Price, &trade.BaseVolume, &trade.DateAddedUTC)
+		if e != nil {
+			return nil, fmt.Errorf("could not read data from StrategyMirrorPendingOffsets query: %s", e)
+		}
+		trades = append(trades, trade)
+	}
+	return trades, rows.Err()
+}
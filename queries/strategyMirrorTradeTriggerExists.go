@@ -10,7 +10,7 @@ import (
 )
 
 // sqlQueryStrategyMirrorTradeTriggerExists queries the strategy_mirror_trade_triggers table by market_id and txid (primary key) to see if the row exists
-const sqlQueryStrategyMirrorTradeTriggerExists = "SELECT * FROM strategy_mirror_trade_triggers WHERE market_id = $1 AND txid = $2"
+const sqlQueryStrategyMirrorTradeTriggerExists = "SELECT market_id, txid, backing_market_id, backing_order_id FROM strategy_mirror_trade_triggers WHERE market_id = $1 AND txid = $2"
 
 // StrategyMirrorTradeTriggerExists is a query that fetches the row by primary key
 type StrategyMirrorTradeTriggerExists struct {
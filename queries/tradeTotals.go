@@ -0,0 +1,63 @@
+package queries
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// sqlQueryTradeTotals counts all the trades of an account on a market
+const sqlQueryTradeTotals = "SELECT COUNT(*) as num_trades, COALESCE(SUM(base_volume), 0) as total_base_volume FROM trades WHERE market_id = $1 AND account_id = $2"
+
+// TradeTotals is a query that fetches the number of trades and the total base volume traded by an account on a market
+type TradeTotals struct {
+	db        *sql.DB
+	sqlQuery  string
+	marketID  string
+	accountID string
+}
+
+var _ api.Query = &TradeTotals{}
+
+// TradeTotalsResult is the result of the TradeTotals query
+type TradeTotalsResult struct {
+	NumTrades       int
+	TotalBaseVolume float64
+}
+
+// MakeTradeTotals makes the TradeTotals query
+func MakeTradeTotals(db *sql.DB, marketID string, accountID string) (*TradeTotals, error) {
+	if db == nil {
+		utils.PrintErrorHintf("the provided POSTGRES_DB config in the trader.cfg file should be non-nil")
+		return nil, fmt.Errorf("the provided db should be non-nil")
+	}
+
+	return &TradeTotals{
+		db:        db,
+		sqlQuery:  sqlQueryTradeTotals,
+		marketID:  marketID,
+		accountID: accountID,
+	}, nil
+}
+
+// Name impl.
+func (q *TradeTotals) Name() string {
+	return "TradeTotals"
+}
+
+// QueryRow impl. returns a *TradeTotalsResult
+func (q *TradeTotals) QueryRow(args ...interface{}) (interface{}, error) {
+	if len(args) != 0 {
+		return nil, fmt.Errorf("expected 0 args, but got args %v", args)
+	}
+
+	row := q.db.QueryRow(q.sqlQuery, q.marketID, q.accountID)
+	var result TradeTotalsResult
+	e := row.Scan(&result.NumTrades, &result.TotalBaseVolume)
+	if e != nil {
+		return nil, fmt.Errorf("could not read data from TradeTotals query: %s", e)
+	}
+	return &result, nil
+}
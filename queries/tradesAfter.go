@@ -0,0 +1,96 @@
+package queries
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// sqlQueryTradesAfter fetches the trades of an account and market that come after the cursor (date_utc, txid), in the order they happened.
+// The txid breaks ties between trades with the same date_utc so that paging with the cursor does not skip or repeat any trades
+const sqlQueryTradesAfter = "SELECT txid, date_utc, action, type, counter_price, base_volume, counter_cost, fee, COALESCE(order_id, '') " +
+	"FROM trades WHERE account_id = $1 AND market_id = $2 AND (date_utc > $3 OR (date_utc = $3 AND txid > $4)) " +
+	"ORDER BY date_utc ASC, txid ASC LIMIT $5"
+
+// TradeRecord is a single row from the trades table
+type TradeRecord struct {
+	TxID         string    `json:"txid"`
+	DateUTC      time.Time `json:"date_utc"`
+	Action       string    `json:"action"`
+	Type         string    `json:"type"`
+	CounterPrice float64   `json:"counter_price"`
+	BaseVolume   float64   `json:"base_volume"`
+	CounterCost  float64   `json:"counter_cost"`
+	Fee          float64   `json:"fee"`
+	OrderID      string    `json:"order_id"` // empty for trades that were saved before order ids were tracked
+}
+
+// TradesAfter is a query that fetches a page of trades that come after a cursor, which is used to follow the trades of a bot as they happen
+type TradesAfter struct {
+	db        *sql.DB
+	sqlQuery  string
+	accountID string
+	marketID  string
+}
+
+var _ api.Query = &TradesAfter{}
+
+// MakeTradesAfter makes the TradesAfter query
+func MakeTradesAfter(db *sql.DB, accountID string, marketID string) (*TradesAfter, error) {
+	if db == nil {
+		utils.PrintErrorHintf("the provided POSTGRES_DB config in the trader.cfg file should be non-nil")
+		return nil, fmt.Errorf("the provided db should be non-nil")
+	}
+
+	return &TradesAfter{
+		db:        db,
+		sqlQuery:  sqlQueryTradesAfter,
+		accountID: accountID,
+		marketID:  marketID,
+	}, nil
+}
+
+// Name impl.
+func (q *TradesAfter) Name() string {
+	return "TradesAfter"
+}
+
+// QueryRow impl. takes the cursor as the date_utc (time.Time) and txid (string) of the last trade that was seen, along with the max number
+// of trades to return (int), and returns a []TradeRecord. Use the zero time and an empty txid to start from the first trade
+func (q *TradesAfter) QueryRow(args ...interface{}) (interface{}, error) {
+	if len(args) != 3 {
+		return nil, fmt.Errorf("expected 3 args (afterDate time.Time, afterTxID string, limit int), but got args %v", args)
+	}
+	afterDate, ok := args[0].(time.Time)
+	if !ok {
+		return nil, fmt.Errorf("afterDate arg needs to be of type 'time.Time', but was of type '%T'", args[0])
+	}
+	afterTxID, ok := args[1].(string)
+	if !ok {
+		return nil, fmt.Errorf("afterTxID arg needs to be of type 'string', but was of type '%T'", args[1])
+	}
+	limit, ok := args[2].(int)
+	if !ok {
+		return nil, fmt.Errorf("limit arg needs to be of type 'int', but was of type '%T'", args[2])
+	}
+
+	rows, e := q.db.Query(q.sqlQuery, q.accountID, q.marketID, afterDate.UTC(), afterTxID, limit)
+	if e != nil {
+		return nil, fmt.Errorf("could not execute TradesAfter query: %s", e)
+	}
+	defer rows.Close()
+
+	trades := []TradeRecord{}
+	for rows.Next() {
+		var t TradeRecord
+		e = rows.Scan(&t.TxID, &t.DateUTC, &t.Action, &t.Type, &t.CounterPrice, &t.BaseVolume, &t.CounterCost, &t.Fee, &t.OrderID)
+		if e != nil {
+			return nil, fmt.Errorf("could not read data from TradesAfter query: %s", e)
+		}
+		trades = append(trades, t)
+	}
+	return trades, rows.Err()
+}
@@ -0,0 +1,75 @@
+package queries
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// sqlQueryTrailingStopHighWaterMark queries the trailing_stop_marks table by market_id and price_feed (primary key)
+const sqlQueryTrailingStopHighWaterMark = "SELECT high_water_mark, stopped_utc FROM trailing_stop_marks WHERE market_id = $1 AND price_feed = $2"
+
+// TrailingStopMark is the persisted state of a trailing stop
+type TrailingStopMark struct {
+	HighWaterMark float64
+	// StoppedUTC is the time at which the stop was latched, nil when the stop has not been hit
+	StoppedUTC *time.Time
+}
+
+// TrailingStopHighWaterMark is a query that fetches the persisted high water mark and latch of a trailing stop
+type TrailingStopHighWaterMark struct {
+	db        *sql.DB
+	sqlQuery  string
+	marketID  string
+	priceFeed string
+}
+
+var _ api.Query = &TrailingStopHighWaterMark{}
+
+// MakeTrailingStopHighWaterMark makes the TrailingStopHighWaterMark query
+func MakeTrailingStopHighWaterMark(db *sql.DB, marketID string, priceFeed string) (*TrailingStopHighWaterMark, error) {
+	if db == nil {
+		utils.PrintErrorHintf("the provided POSTGRES_DB config in the trader.cfg file should be non-nil")
+		return nil, fmt.Errorf("the provided db should be non-nil")
+	}
+
+	return &TrailingStopHighWaterMark{
+		db:        db,
+		sqlQuery:  sqlQueryTrailingStopHighWaterMark,
+		marketID:  marketID,
+		priceFeed: priceFeed,
+	}, nil
+}
+
+// Name impl.
+func (q *TrailingStopHighWaterMark) Name() string {
+	return "TrailingStopHighWaterMark"
+}
+
+// QueryRow impl. returns a *TrailingStopMark which is nil when there is no high water mark persisted yet
+func (q *TrailingStopHighWaterMark) QueryRow(args ...interface{}) (interface{}, error) {
+	if len(args) != 0 {
+		return nil, fmt.Errorf("expected 0 args, but got args %v", args)
+	}
+
+	row := q.db.QueryRow(q.sqlQuery, q.marketID, q.priceFeed)
+	var highWaterMark float64
+	var stoppedUTC sql.NullTime
+	e := row.Scan(&highWaterMark, &stoppedUTC)
+	if e != nil {
+		if strings.Contains(e.Error(), "no rows in result set") {
+			return (*TrailingStopMark)(nil), nil
+		}
+		return nil, fmt.Errorf("could not read data from TrailingStopHighWaterMark query: %s", e)
+	}
+
+	mark := &TrailingStopMark{HighWaterMark: highWaterMark}
+	if stoppedUTC.Valid {
+		mark.StoppedUTC = &stoppedUTC.Time
+	}
+	return mark, nil
+}
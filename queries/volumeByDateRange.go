@@ -0,0 +1,114 @@
+package queries
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// sqlQueryVolumeByDateRangeTemplateAllAccounts queries the trades table to get the values for a date range
+const sqlQueryVolumeByDateRangeTemplateAllAccounts = "SELECT COALESCE(SUM(base_volume), 0) as total_base_volume, COALESCE(SUM(counter_cost), 0) as total_counter_volume FROM trades WHERE market_id IN (%s) AND date_utc >= $1 AND date_utc < $2 and action = $3"
+
+// sqlQueryVolumeByDateRangeTemplateSpecificAccounts queries the trades table to get the values for a date range filtered by specific accounts
+const sqlQueryVolumeByDateRangeTemplateSpecificAccounts = "SELECT COALESCE(SUM(base_volume), 0) as total_base_volume, COALESCE(SUM(counter_cost), 0) as total_counter_volume FROM trades WHERE market_id IN (%s) AND account_id IN (%s) AND date_utc >= $1 AND date_utc < $2 and action = $3"
+
+// sqlQueryVolumeByDateRangeTemplateAccountScope queries the trades table to get the values for a date range across all the markets of a base and
+// quote asset traded by specific accounts
+const sqlQueryVolumeByDateRangeTemplateAccountScope = "SELECT COALESCE(SUM(base_volume), 0) as total_base_volume, COALESCE(SUM(counter_cost), 0) as total_counter_volume FROM trades WHERE account_id IN (%s) AND market_id IN (SELECT market_id FROM markets WHERE base = %s AND quote = %s) AND date_utc >= $1 AND date_utc < $2 and action = $3"
+
+// VolumeByDateRange is a query that fetches the volume of sales over a date range, such as a week or a month
+type VolumeByDateRange struct {
+	db       *sql.DB
+	sqlQuery string
+	action   DailyVolumeAction
+}
+
+var _ api.Query = &VolumeByDateRange{}
+
+// MakeVolumeByDateRangeForMarketIdsAction makes the VolumeByDateRange query for a set of marketIds and an action
+func MakeVolumeByDateRangeForMarketIdsAction(
+	db *sql.DB,
+	marketIDs []string,
+	action DailyVolumeAction,
+	optionalAccountIDs []string,
+) (*VolumeByDateRange, error) {
+	if db == nil {
+		utils.PrintErrorHintf("the provided POSTGRES_DB config in the trader.cfg file should be non-nil")
+		return nil, fmt.Errorf("the provided db should be non-nil")
+	}
+
+	sqlQuery := makeSQLQueryVolumeByDateRange(marketIDs, optionalAccountIDs)
+	return &VolumeByDateRange{
+		db:       db,
+		sqlQuery: sqlQuery,
+		action:   action,
+	}, nil
+}
+
+// MakeVolumeByDateRangeForAccountIdsAction makes the VolumeByDateRange query for all the markets of the base and quote asset (on any exchange)
+// traded by a set of accountIDs and an action
+func MakeVolumeByDateRangeForAccountIdsAction(
+	db *sql.DB,
+	accountIDs []string,
+	baseAsset string,
+	quoteAsset string,
+	action DailyVolumeAction,
+) (*VolumeByDateRange, error) {
+	if db == nil {
+		utils.PrintErrorHintf("the provided POSTGRES_DB config in the trader.cfg file should be non-nil")
+		return nil, fmt.Errorf("the provided db should be non-nil")
+	}
+	if len(accountIDs) == 0 {
+		return nil, fmt.Errorf("need at least one accountID")
+	}
+
+	sqlQuery := fmt.Sprintf(sqlQueryVolumeByDateRangeTemplateAccountScope, makeInClause(accountIDs), makeInClause([]string{baseAsset}), makeInClause([]string{quoteAsset}))
+	return &VolumeByDateRange{
+		db:       db,
+		sqlQuery: sqlQuery,
+		action:   action,
+	}, nil
+}
+
+// Name impl.
+func (q *VolumeByDateRange) Name() string {
+	return "VolumeByDateRange"
+}
+
+// QueryRow impl. takes the start (inclusive) and end (exclusive) time.Time of the date range and returns a *DailyVolume
+func (q *VolumeByDateRange) QueryRow(args ...interface{}) (interface{}, error) {
+	if len(args) != 2 {
+		return nil, fmt.Errorf("expected 2 args (start time.Time, end time.Time), but got args %v", args)
+	}
+	start, ok := args[0].(time.Time)
+	if !ok {
+		return nil, fmt.Errorf("start arg needs to be of type 'time.Time', but was of type '%T'", args[0])
+	}
+	end, ok := args[1].(time.Time)
+	if !ok {
+		return nil, fmt.Errorf("end arg needs to be of type 'time.Time', but was of type '%T'", args[1])
+	}
+
+	row := q.db.QueryRow(q.sqlQuery, start.UTC(), end.UTC(), q.action.String())
+
+	// the sums are never NULL because of the COALESCE, and an aggregate without a group by always returns exactly one row
+	var volume DailyVolume
+	e := row.Scan(&volume.BaseVol, &volume.QuoteVol)
+	if e != nil {
+		return nil, fmt.Errorf("could not read data from VolumeByDateRange query: %s", e)
+	}
+	return &volume, nil
+}
+
+func makeSQLQueryVolumeByDateRange(marketIDs []string, optionalAccountIDs []string) string {
+	marketsInClause := makeInClause(marketIDs)
+
+	// len(a), where a is a nil array, is valid and returns 0
+	if len(optionalAccountIDs) == 0 {
+		return fmt.Sprintf(sqlQueryVolumeByDateRangeTemplateAllAccounts, marketsInClause)
+	}
+	return fmt.Sprintf(sqlQueryVolumeByDateRangeTemplateSpecificAccounts, marketsInClause, makeInClause(optionalAccountIDs))
+}
@@ -0,0 +1,46 @@
+package queries
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMakeSQLQueryVolumeByDateRange(t *testing.T) {
+	testCases := []struct {
+		name               string
+		marketIDs          []string
+		optionalAccountIDs []string
+		want               string
+	}{
+		{
+			name:               "all accounts",
+			marketIDs:          []string{"marketA", "marketB"},
+			optionalAccountIDs: nil,
+			want:               "SELECT COALESCE(SUM(base_volume), 0) as total_base_volume, COALESCE(SUM(counter_cost), 0) as total_counter_volume FROM trades WHERE market_id IN ('marketA', 'marketB') AND date_utc >= $1 AND date_utc < $2 and action = $3",
+		}, {
+			name:               "specific accounts",
+			marketIDs:          []string{"marketA"},
+			optionalAccountIDs: []string{"account1", "account2"},
+			want:               "SELECT COALESCE(SUM(base_volume), 0) as total_base_volume, COALESCE(SUM(counter_cost), 0) as total_counter_volume FROM trades WHERE market_id IN ('marketA') AND account_id IN ('account1', 'account2') AND date_utc >= $1 AND date_utc < $2 and action = $3",
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			assert.Equal(t, k.want, makeSQLQueryVolumeByDateRange(k.marketIDs, k.optionalAccountIDs))
+		})
+	}
+}
+
+func TestMakeVolumeByDateRangeForAccountIdsAction(t *testing.T) {
+	q, e := MakeVolumeByDateRangeForAccountIdsAction(&sql.DB{}, []string{"account1", "account2"}, "XLM", "USDC", DailyVolumeActionSell)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, "SELECT COALESCE(SUM(base_volume), 0) as total_base_volume, COALESCE(SUM(counter_cost), 0) as total_counter_volume FROM trades WHERE account_id IN ('account1', 'account2') AND market_id IN (SELECT market_id FROM markets WHERE base = 'XLM' AND quote = 'USDC') AND date_utc >= $1 AND date_utc < $2 and action = $3", q.sqlQuery)
+
+	_, e = MakeVolumeByDateRangeForAccountIdsAction(&sql.DB{}, nil, "XLM", "USDC", DailyVolumeActionSell)
+	assert.Error(t, e)
+}
@@ -9,6 +9,7 @@ function usage() {
     echo "    -n,   --nightly-gui   run a nightly build deployment of the GUI"
     echo "    -t,   --test-deploy   test prepare tar archives in build/ for your native platform only"
     echo "    -g,   --gen-ccxt      generate binary for ccxt-rest executable for to be uploaded to GitHub for use in building kelp binary, takes in arguments (linux, darwin)"
+    echo "    -p,   --gen-grpc      regenerate the checked in gRPC code in gui/backend/kelprpc from kelp.proto, needs protoc on the PATH"
     echo "    -h,   --help          show this help info"
 }
 
@@ -38,6 +39,25 @@ function generate_static_web_files() {
     echo ""
 }
 
+function generate_grpc_files() {
+    echo "generating gRPC code from gui/backend/kelprpc/kelp.proto ..."
+    if ! [ -x "$(command -v protoc)" ]
+    then
+        echo "protoc is needed to generate the gRPC code, install it from https://github.com/protocolbuffers/protobuf/releases"
+        exit 1
+    fi
+    # these versions need to match the versions in the header of the checked in *.pb.go files
+    go install google.golang.org/protobuf/cmd/protoc-gen-go@v1.27.1
+    check_build_result $?
+    go install google.golang.org/grpc/cmd/protoc-gen-go-grpc@v1.2.0
+    check_build_result $?
+
+    go generate ./gui/backend/kelprpc
+    check_build_result $?
+    echo "... finished generating gRPC code"
+    echo ""
+}
+
 # takes in the exit code ($?) of the previous command as the input
 function check_build_result() {
     if [[ $1 -ne 0 ]]
@@ -176,6 +196,11 @@ elif [[ (($# -eq 1 || $# -eq 2) && ("$1" == "-g" || "$1" == "--gen-ccxt")) ]]; t
 
     usage
     exit 1
+elif [[ ($# -eq 1 && ("$1" == "-p" || "$1" == "--gen-grpc")) ]]; then
+    generate_grpc_files
+    echo ""
+    echo "BUILD SUCCESSFUL"
+    exit 0
 elif [[ $# -eq 0 ]]; then
     ENV=dev
 else
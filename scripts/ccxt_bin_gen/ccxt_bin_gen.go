@@ -48,7 +48,7 @@ func main() {
 func checkNodeVersion(kos *kelpos.KelpOS, userID string) {
 	fmt.Printf("checking node version ... ")
 
-	version, e := kos.Blocking(userID, "node", "node -v")
+	version, e := kos.Blocking(userID, "node", kelpos.MakeCommand("node", "-v"))
 	if e != nil {
 		log.Fatal(errors.Wrap(e, "ensure that the `pkg` tool is installed correctly. You can get it from here https://github.com/zeit/pkg or by running `npm install -g pkg`"))
 	}
@@ -66,7 +66,7 @@ func checkNodeVersion(kos *kelpos.KelpOS, userID string) {
 
 func checkPkgTool(kos *kelpos.KelpOS, userID string) {
 	fmt.Printf("checking for presence of `pkg` tool ... ")
-	_, e := kos.Blocking(userID, "pkg", "pkg -v")
+	_, e := kos.Blocking(userID, "pkg", kelpos.MakeCommand("pkg", "-v"))
 	if e != nil {
 		log.Fatal(errors.Wrap(e, "ensure that the `pkg` tool is installed correctly. You can get it from here https://github.com/zeit/pkg or by running `npm install -g pkg`"))
 	}
@@ -75,7 +75,7 @@ func checkPkgTool(kos *kelpos.KelpOS, userID string) {
 
 func downloadCcxtSource(kos *kelpos.KelpOS, userID string, downloadDir string) {
 	fmt.Printf("making directory where we can download ccxt file %s ... ", downloadDir)
-	_, e := kos.Blocking(userID, "mkdir", fmt.Sprintf("mkdir -p %s", downloadDir))
+	_, e := kos.Blocking(userID, "mkdir", kelpos.MakeCommand("mkdir", "-p", downloadDir))
 	if e != nil {
 		log.Fatal(errors.Wrap(e, "could not make directory for downloadDir "+downloadDir))
 	}
@@ -90,7 +90,7 @@ func downloadCcxtSource(kos *kelpos.KelpOS, userID string, downloadDir string) {
 	fmt.Printf("done\n")
 
 	fmt.Printf("untaring file %s ... ", downloadFilePath)
-	_, e = kos.Blocking(userID, "tar", fmt.Sprintf("tar xvf %s -C %s", downloadFilePath, downloadDir))
+	_, e = kos.Blocking(userID, "tar", kelpos.MakeCommand("tar", "xvf", downloadFilePath, "-C", downloadDir))
 	if e != nil {
 		log.Fatal(errors.Wrap(e, "could not untar ccxt file"))
 	}
@@ -99,7 +99,11 @@ func downloadCcxtSource(kos *kelpos.KelpOS, userID string, downloadDir string) {
 
 func npmInstall(kos *kelpos.KelpOS, userID string, installDir string) {
 	fmt.Printf("running npm install on directory %s ... ", installDir)
-	npmCmd := fmt.Sprintf("cd %s && npm install && cd -", installDir)
+	npmCmd := &kelpos.Command{
+		Binary: "npm",
+		Args:   []string{"install"},
+		Dir:    kos.GetDotKelpWorkingDir().Join(installDir),
+	}
 	_, e := kos.Blocking(userID, "npm", npmCmd)
 	if e != nil {
 		log.Fatal(errors.Wrap(e, "failed to run npm install"))
@@ -112,7 +116,7 @@ func runPkgTool(kos *kelpos.KelpOS, userID string, sourceDir string, outDir stri
 	target := fmt.Sprintf("node8-%s-x64", pkgos)
 
 	fmt.Printf("running pkg tool on source directory %s with output directory as %s on target platform %s ... ", sourceDir, outDir, target)
-	pkgCommand := fmt.Sprintf("pkg --out-path %s --targets %s %s", outDir, target, sourceDir)
+	pkgCommand := kelpos.MakeCommand("pkg", "--out-path", outDir, "--targets", target, sourceDir)
 	outputBytes, e := kos.Blocking(userID, "pkg", pkgCommand)
 	if e != nil {
 		log.Fatal(errors.Wrap(e, "failed to run pkg tool"))
@@ -136,8 +140,7 @@ func copyDependencyFiles(kos *kelpos.KelpOS, userID string, outDir string, pkgCm
 		filename = strings.TrimSpace(strings.Replace(filename, "%1:", "", -1))
 
 		fmt.Printf("    copying file %s to the output directory %s ... ", filename, outDir)
-		cpCmd := fmt.Sprintf("cp %s %s", filename, outDir)
-		_, e := kos.Blocking(userID, "cp", cpCmd)
+		_, e := kos.Blocking(userID, "cp", kelpos.MakeCommand("cp", filename, outDir))
 		if e != nil {
 			log.Fatal(errors.Wrap(e, "failed to copy dependency file "+filename))
 		}
@@ -149,7 +152,7 @@ func copyDependencyFiles(kos *kelpos.KelpOS, userID string, outDir string, pkgCm
 
 func mkDir(kos *kelpos.KelpOS, userID string, zipDir string) {
 	fmt.Printf("making directory %s ... ", zipDir)
-	_, e := kos.Blocking(userID, "mkdir", fmt.Sprintf("mkdir -p %s", zipDir))
+	_, e := kos.Blocking(userID, "mkdir", kelpos.MakeCommand("mkdir", "-p", zipDir))
 	if e != nil {
 		log.Fatal(errors.Wrap(e, "unable to make directory "+zipDir))
 	}
@@ -159,17 +162,27 @@ func mkDir(kos *kelpos.KelpOS, userID string, zipDir string) {
 func zipOutput(kos *kelpos.KelpOS, userID string, ccxtDir string, sourceDir string, zipFoldername string, zipOutDir string) {
 	zipFilename := zipFoldername + ".zip"
 	fmt.Printf("zipping directory %s as file %s ... ", filepath.Join(ccxtDir, ccxtBinOutputDir), zipFilename)
-	zipCmd := fmt.Sprintf("cd %s && mv %s %s && zip -rq %s %s && cd - && mv %s %s", ccxtDir, ccxtBinOutputDir, zipFoldername, zipFilename, zipFoldername, filepath.Join(ccxtDir, zipFilename), zipOutDir)
-	_, e := kos.Blocking(userID, "zip", zipCmd)
-	if e != nil {
-		log.Fatal(errors.Wrap(e, "unable to zip folder with ccxt binary and dependencies"))
+	zipDirPath := filepath.Join(ccxtDir, zipFoldername)
+	zipCmds := []*kelpos.Command{
+		// rename the output directory so the zip file extracts into a directory with the name of the zip file
+		kelpos.MakeCommand("mv", filepath.Join(ccxtDir, ccxtBinOutputDir), zipDirPath),
+		{
+			Binary: "zip",
+			Args:   []string{"-rq", zipFilename, zipFoldername},
+			Dir:    kos.GetDotKelpWorkingDir().Join(ccxtDir),
+		},
+		kelpos.MakeCommand("mv", filepath.Join(ccxtDir, zipFilename), zipOutDir),
+	}
+	for _, zipCmd := range zipCmds {
+		_, e := kos.Blocking(userID, "zip", zipCmd)
+		if e != nil {
+			log.Fatal(errors.Wrap(e, "unable to zip folder with ccxt binary and dependencies"))
+		}
 	}
 	fmt.Printf("done\n")
 
-	zipDirPath := filepath.Join(ccxtDir, zipFoldername)
 	fmt.Printf("clean up zipped directory %s ... ", zipDirPath)
-	cleanupCmd := fmt.Sprintf("rm %s/* && rmdir %s", zipDirPath, zipDirPath)
-	_, e = kos.Blocking(userID, "zip", cleanupCmd)
+	_, e := kos.Blocking(userID, "zip", kelpos.MakeCommand("rm", "-r", zipDirPath))
 	if e != nil {
 		log.Fatal(errors.Wrap(e, fmt.Sprintf("unable to cleanup zip folder %s with ccxt binary and dependencies", zipDirPath)))
 	}
@@ -0,0 +1,144 @@
+// Package assetdisplay formats amounts with the display precision of each asset, which is read from the display_decimals of the asset in the
+// stellar.toml file on the home domain of its issuer (SEP-1). Amounts are formatted with the precision of the network (7 decimals) when an
+// asset does not have any metadata.
+package assetdisplay
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/stellar/go/clients/horizonclient"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// DefaultDisplayDecimals is the number of decimals used for assets without a display_decimals, which is the precision of amounts on the network
+const DefaultDisplayDecimals = 7
+
+// Info is the display metadata of an asset
+type Info struct {
+	Asset           string `json:"asset"`
+	HomeDomain      string `json:"home_domain"` // empty when the issuer does not have a home domain
+	Name            string `json:"name"`        // empty when the stellar.toml does not list the asset
+	OrgName         string `json:"org_name"`    // empty when the stellar.toml does not have an ORG_NAME
+	DisplayDecimals int    `json:"display_decimals"`
+}
+
+// FormatAmount formats the amount with the display decimals of the asset
+func (i *Info) FormatAmount(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', i.DisplayDecimals, 64)
+}
+
+// loadRetryInterval is how long we wait before trying again to load the metadata of an asset that could not be loaded
+const loadRetryInterval = 10 * time.Minute
+
+// cache holds the Info of the assets that were loaded, keyed by utils.Asset2String. It is shared by the whole process since the metadata of
+// an asset does not depend on the bot that trades it
+var cache = map[string]*Info{}
+
+// failedLoads holds the time of the last failed load of the assets that could not be loaded, keyed by utils.Asset2String
+var failedLoads = map[string]time.Time{}
+var cacheMutex = &sync.Mutex{}
+
+// makeDefaultInfo returns the Info of an asset without any metadata
+func makeDefaultInfo(asset hProtocol.Asset) *Info {
+	return &Info{
+		Asset:           utils.Asset2String(asset),
+		DisplayDecimals: DefaultDisplayDecimals,
+	}
+}
+
+// Lookup returns the Info of the asset that was loaded before, or the default Info if it was never loaded. It does not make any network calls
+func Lookup(asset hProtocol.Asset) *Info {
+	cacheMutex.Lock()
+	defer cacheMutex.Unlock()
+
+	if info, ok := cache[utils.Asset2String(asset)]; ok {
+		return info
+	}
+	return makeDefaultInfo(asset)
+}
+
+// FormatAmount formats the amount with the display decimals of the asset, see Lookup
+func FormatAmount(asset hProtocol.Asset, amount float64) string {
+	return Lookup(asset).FormatAmount(amount)
+}
+
+// Load fetches the home domain of the issuer of the asset from horizon and the display metadata of the asset from the stellar.toml on that
+// home domain. The result is cached so it is only fetched once per asset. When the metadata could not be loaded the default Info is returned
+// without caching it, and it is fetched again once loadRetryInterval has passed so a temporary failure does not stick for the life of the
+// process. Errors are logged and never returned because the metadata is only used for display
+func Load(client horizonclient.ClientInterface, asset hProtocol.Asset) *Info {
+	key := utils.Asset2String(asset)
+	cacheMutex.Lock()
+	info, ok := cache[key]
+	lastFailure, failed := failedLoads[key]
+	cacheMutex.Unlock()
+	if ok {
+		return info
+	}
+	if failed && time.Since(lastFailure) < loadRetryInterval {
+		return makeDefaultInfo(asset)
+	}
+
+	info, e := fetchInfo(client, asset)
+	cacheMutex.Lock()
+	defer cacheMutex.Unlock()
+	if e != nil {
+		log.Printf("could not load display metadata for asset %s, using %d display decimals and trying again in %s: %s\n", key, DefaultDisplayDecimals, loadRetryInterval, e)
+		failedLoads[key] = time.Now()
+		return makeDefaultInfo(asset)
+	}
+
+	log.Printf("loaded display metadata for asset %s: homeDomain='%s', name='%s', displayDecimals=%d\n", key, info.HomeDomain, info.Name, info.DisplayDecimals)
+	delete(failedLoads, key)
+	cache[key] = info
+	return info
+}
+
+func fetchInfo(client horizonclient.ClientInterface, asset hProtocol.Asset) (*Info, error) {
+	info := makeDefaultInfo(asset)
+	if asset.Type == utils.Native {
+		return info, nil
+	}
+
+	issuer, e := client.AccountDetail(horizonclient.AccountRequest{AccountID: asset.Issuer})
+	if e != nil {
+		return nil, fmt.Errorf("could not load issuer account '%s': %s", asset.Issuer, e)
+	}
+	if issuer.HomeDomain == "" {
+		return info, nil
+	}
+	info.HomeDomain = issuer.HomeDomain
+
+	st, e := fetchStellarToml(makeStellarTomlURL(issuer.HomeDomain))
+	if e != nil {
+		return nil, e
+	}
+	return applyStellarToml(info, st, asset), nil
+}
+
+// applyStellarToml sets the metadata of the asset from its entry in the stellar.toml
+func applyStellarToml(info *Info, st *stellarToml, asset hProtocol.Asset) *Info {
+	info.OrgName = st.Documentation.OrgName
+	c := st.findCurrency(asset.Code, asset.Issuer)
+	if c == nil {
+		return info
+	}
+
+	info.Name = c.Name
+	if c.DisplayDecimals != nil {
+		// amounts on the network have a precision of 7 decimals so a larger display precision would only show trailing zeros
+		decimals := *c.DisplayDecimals
+		if decimals < 0 {
+			decimals = 0
+		} else if decimals > DefaultDisplayDecimals {
+			decimals = DefaultDisplayDecimals
+		}
+		info.DisplayDecimals = decimals
+	}
+	return info
+}
@@ -0,0 +1,125 @@
+package assetdisplay
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/go/clients/horizonclient"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+)
+
+const testIssuer = "GDUKMGUGDZQK6YHYA5Z6AY2G4XDSZPSZ3SW5UN3ARVMO6QSRDWP5YLEX"
+
+const testStellarToml = `
+VERSION="2.0.0"
+
+[DOCUMENTATION]
+ORG_NAME="Example Anchor"
+
+[[CURRENCIES]]
+code="USD"
+issuer="GDUKMGUGDZQK6YHYA5Z6AY2G4XDSZPSZ3SW5UN3ARVMO6QSRDWP5YLEX"
+name="US Dollar"
+display_decimals=2
+
+[[CURRENCIES]]
+code="BTC"
+issuer="GDUKMGUGDZQK6YHYA5Z6AY2G4XDSZPSZ3SW5UN3ARVMO6QSRDWP5YLEX"
+name="Bitcoin"
+display_decimals=8
+
+[[CURRENCIES]]
+code="EUR"
+issuer="GDUKMGUGDZQK6YHYA5Z6AY2G4XDSZPSZ3SW5UN3ARVMO6QSRDWP5YLEX"
+name="Euro"
+`
+
+func TestApplyStellarToml(t *testing.T) {
+	st, e := parseStellarToml(testStellarToml)
+	if !assert.NoError(t, e) {
+		return
+	}
+
+	testCases := []struct {
+		code         string
+		wantName     string
+		wantDecimals int
+	}{
+		{code: "USD", wantName: "US Dollar", wantDecimals: 2},
+		// the display precision cannot be larger than the precision of the network
+		{code: "BTC", wantName: "Bitcoin", wantDecimals: 7},
+		{code: "EUR", wantName: "Euro", wantDecimals: DefaultDisplayDecimals},
+		{code: "GBP", wantName: "", wantDecimals: DefaultDisplayDecimals},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.code, func(t *testing.T) {
+			asset := hProtocol.Asset{Type: "credit_alphanum4", Code: k.code, Issuer: testIssuer}
+			info := applyStellarToml(makeDefaultInfo(asset), st, asset)
+			assert.Equal(t, "Example Anchor", info.OrgName)
+			assert.Equal(t, k.wantName, info.Name)
+			assert.Equal(t, k.wantDecimals, info.DisplayDecimals)
+		})
+	}
+}
+
+func TestFormatAmount(t *testing.T) {
+	usd := hProtocol.Asset{Type: "credit_alphanum4", Code: "USD", Issuer: testIssuer}
+	xlm := hProtocol.Asset{Type: "native"}
+
+	cacheMutex.Lock()
+	cache["USD:"+testIssuer] = &Info{Asset: "USD:" + testIssuer, DisplayDecimals: 2}
+	cacheMutex.Unlock()
+	defer func() {
+		cacheMutex.Lock()
+		delete(cache, "USD:"+testIssuer)
+		cacheMutex.Unlock()
+	}()
+
+	assert.Equal(t, "12.35", FormatAmount(usd, 12.345678))
+	assert.Equal(t, "12.3456780", FormatAmount(xlm, 12.345678))
+	assert.Equal(t, "0", (&Info{DisplayDecimals: 0}).FormatAmount(0.4))
+}
+
+func TestMakeStellarTomlURL(t *testing.T) {
+	assert.Equal(t, "https://example.com/.well-known/stellar.toml", makeStellarTomlURL("example.com"))
+	assert.Equal(t, "https://example.com/.well-known/stellar.toml", makeStellarTomlURL("example.com/"))
+}
+
+func TestLoadDoesNotCacheFailures(t *testing.T) {
+	usd := hProtocol.Asset{Type: "credit_alphanum4", Code: "USD", Issuer: testIssuer}
+	key := "USD:" + testIssuer
+	defer func() {
+		cacheMutex.Lock()
+		delete(cache, key)
+		delete(failedLoads, key)
+		cacheMutex.Unlock()
+	}()
+
+	client := &horizonclient.MockClient{}
+	req := horizonclient.AccountRequest{AccountID: testIssuer}
+	client.On("AccountDetail", req).Return(hProtocol.Account{}, errors.New("horizon is down")).Once()
+	assert.Equal(t, DefaultDisplayDecimals, Load(client, usd).DisplayDecimals)
+	// the failure is not retried before loadRetryInterval has passed
+	assert.Equal(t, DefaultDisplayDecimals, Load(client, usd).DisplayDecimals)
+	client.AssertNumberOfCalls(t, "AccountDetail", 1)
+
+	cacheMutex.Lock()
+	_, cached := cache[key]
+	failedLoads[key] = time.Now().Add(-loadRetryInterval)
+	cacheMutex.Unlock()
+	assert.False(t, cached)
+
+	// the issuer does not have a home domain, so the default Info is loaded and cached
+	client.On("AccountDetail", req).Return(hProtocol.Account{}, nil).Once()
+	assert.Equal(t, DefaultDisplayDecimals, Load(client, usd).DisplayDecimals)
+	assert.Equal(t, DefaultDisplayDecimals, Load(client, usd).DisplayDecimals)
+	client.AssertNumberOfCalls(t, "AccountDetail", 2)
+	cacheMutex.Lock()
+	_, cached = cache[key]
+	cacheMutex.Unlock()
+	assert.True(t, cached)
+}
@@ -0,0 +1,77 @@
+package assetdisplay
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// stellarTomlMaxBytes is the largest stellar.toml file that we read, as recommended by SEP-1
+const stellarTomlMaxBytes = 100 * 1024
+
+// stellarTomlTimeout is the timeout when fetching a stellar.toml file
+const stellarTomlTimeout = 10 * time.Second
+
+// stellarToml is the subset of the fields of a stellar.toml file (SEP-1) that we use
+type stellarToml struct {
+	Documentation struct {
+		OrgName string `toml:"ORG_NAME"`
+	} `toml:"DOCUMENTATION"`
+	Currencies []stellarTomlCurrency `toml:"CURRENCIES"`
+}
+
+// stellarTomlCurrency is an entry in the CURRENCIES list of a stellar.toml file
+type stellarTomlCurrency struct {
+	Code            string `toml:"code"`
+	Issuer          string `toml:"issuer"`
+	Name            string `toml:"name"`
+	DisplayDecimals *int   `toml:"display_decimals"`
+}
+
+// makeStellarTomlURL returns the URL of the stellar.toml file of a home domain
+func makeStellarTomlURL(homeDomain string) string {
+	return fmt.Sprintf("https://%s/.well-known/stellar.toml", strings.TrimSuffix(homeDomain, "/"))
+}
+
+// fetchStellarToml fetches and parses the stellar.toml file from the url
+func fetchStellarToml(url string) (*stellarToml, error) {
+	client := &http.Client{Timeout: stellarTomlTimeout}
+	resp, e := client.Get(url)
+	if e != nil {
+		return nil, fmt.Errorf("could not fetch stellar.toml from '%s': %s", url, e)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("could not fetch stellar.toml from '%s', status code %d", url, resp.StatusCode)
+	}
+	bodyBytes, e := ioutil.ReadAll(http.MaxBytesReader(nil, resp.Body, stellarTomlMaxBytes))
+	if e != nil {
+		return nil, fmt.Errorf("could not read stellar.toml from '%s': %s", url, e)
+	}
+	return parseStellarToml(string(bodyBytes))
+}
+
+// parseStellarToml parses the contents of a stellar.toml file
+func parseStellarToml(contents string) (*stellarToml, error) {
+	var st stellarToml
+	_, e := toml.Decode(contents, &st)
+	if e != nil {
+		return nil, fmt.Errorf("could not decode stellar.toml: %s", e)
+	}
+	return &st, nil
+}
+
+// findCurrency returns the entry in the CURRENCIES list for the asset, or nil if the asset is not listed
+func (st *stellarToml) findCurrency(code string, issuer string) *stellarTomlCurrency {
+	for i, c := range st.Currencies {
+		if c.Code == code && c.Issuer == issuer {
+			return &st.Currencies[i]
+		}
+	}
+	return nil
+}
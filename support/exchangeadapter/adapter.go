@@ -0,0 +1,167 @@
+package exchangeadapter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Market is a market in the format returned by the loadMarkets endpoint of ccxt-rest
+type Market struct {
+	ID        string          `json:"id"` // the exchange's own symbol for the market
+	Symbol    string          `json:"symbol"`
+	Base      string          `json:"base"`
+	Quote     string          `json:"quote"`
+	Active    bool            `json:"active"`
+	Limits    MarketLimits    `json:"limits"`
+	Precision MarketPrecision `json:"precision"`
+	Maker     float64         `json:"maker"`
+	Taker     float64         `json:"taker"`
+}
+
+// MarketLimits are the minimum values of an order on a market
+type MarketLimits struct {
+	Amount MinLimit `json:"amount"`
+	Price  MinLimit `json:"price"`
+	Cost   MinLimit `json:"cost"`
+}
+
+// MinLimit is a minimum value
+type MinLimit struct {
+	Min float64 `json:"min"`
+}
+
+// MarketPrecision is the number of decimal places of the price and amount of an order on a market
+type MarketPrecision struct {
+	Amount int8 `json:"amount"`
+	Price  int8 `json:"price"`
+}
+
+// Ticker is a ticker in the format returned by the fetchTicker endpoint of ccxt-rest
+type Ticker struct {
+	Symbol    string  `json:"symbol"`
+	Timestamp int64   `json:"timestamp"`
+	Datetime  string  `json:"datetime"`
+	Bid       float64 `json:"bid"`
+	Ask       float64 `json:"ask"`
+	Last      float64 `json:"last"`
+	Close     float64 `json:"close"`
+}
+
+// OrderBook is an orderbook in the format returned by the fetchOrderBook endpoint of ccxt-rest, where each order is a [price, amount] pair
+type OrderBook struct {
+	Symbol    string       `json:"symbol"`
+	Timestamp int64        `json:"timestamp"`
+	Bids      [][2]float64 `json:"bids"`
+	Asks      [][2]float64 `json:"asks"`
+}
+
+// Trade is a public trade in the format returned by the fetchTrades endpoint of ccxt-rest
+type Trade struct {
+	ID        string  `json:"id"`
+	Timestamp int64   `json:"timestamp"`
+	Datetime  string  `json:"datetime"`
+	Symbol    string  `json:"symbol"`
+	Side      string  `json:"side"` // side of the taker, either "buy" or "sell"
+	Price     float64 `json:"price"`
+	Amount    float64 `json:"amount"`
+	Cost      float64 `json:"cost"`
+}
+
+// exchangeAdapter fetches the public market data of an exchange using the exchange's own API
+type exchangeAdapter interface {
+	// URL is the URL of the exchange's API, which is returned when creating an instance
+	URL() string
+	FetchMarkets(httpClient *http.Client) ([]Market, error)
+	FetchTicker(httpClient *http.Client, market Market) (*Ticker, error)
+	FetchOrderBook(httpClient *http.Client, market Market, limit int) (*OrderBook, error)
+	FetchTrades(httpClient *http.Client, market Market) ([]Trade, error)
+}
+
+// getJSON makes a GET request and decodes the JSON response into responseData
+func getJSON(httpClient *http.Client, reqURL string, responseData interface{}) error {
+	resp, e := httpClient.Get(reqURL)
+	if e != nil {
+		return fmt.Errorf("could not execute http request to %s: %s", reqURL, e)
+	}
+	defer resp.Body.Close()
+
+	body, e := ioutil.ReadAll(resp.Body)
+	if e != nil {
+		return fmt.Errorf("could not read http response from %s: %s", reqURL, e)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("http request to %s returned status %d: %s", reqURL, resp.StatusCode, string(body))
+	}
+
+	e = json.Unmarshal(body, responseData)
+	if e != nil {
+		return fmt.Errorf("could not unmarshal response from %s: %s | body: %s", reqURL, e, string(body))
+	}
+	return nil
+}
+
+// parseFloat parses a float that an exchange returns as a string, where an empty string is 0
+func parseFloat(s string) (float64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+// parseOrders converts orders returned as [price, amount, ...] string arrays to [price, amount] pairs
+func parseOrders(orders [][]interface{}, limit int) ([][2]float64, error) {
+	parsed := [][2]float64{}
+	for i, o := range orders {
+		if limit > 0 && i >= limit {
+			break
+		}
+		if len(o) < 2 {
+			return nil, fmt.Errorf("order at index %d needs at least a price and an amount: %v", i, o)
+		}
+		price, e := parseFloat(fmt.Sprintf("%v", o[0]))
+		if e != nil {
+			return nil, fmt.Errorf("could not parse price of order at index %d: %s", i, e)
+		}
+		amount, e := parseFloat(fmt.Sprintf("%v", o[1]))
+		if e != nil {
+			return nil, fmt.Errorf("could not parse amount of order at index %d: %s", i, e)
+		}
+		parsed = append(parsed, [2]float64{price, amount})
+	}
+	return parsed, nil
+}
+
+// decimalsFromIncrement returns the number of decimal places of an increment such as "0.00100000"
+func decimalsFromIncrement(increment string) (int8, error) {
+	v, e := strconv.ParseFloat(increment, 64)
+	if e != nil {
+		return 0, fmt.Errorf("could not parse increment '%s': %s", increment, e)
+	}
+	if v <= 0 {
+		return 0, fmt.Errorf("increment needs to be > 0 but was '%s'", increment)
+	}
+	decimals := -math.Log10(v)
+	if decimals < 0 {
+		return 0, nil
+	}
+	return int8(math.Round(decimals)), nil
+}
+
+// makeSymbol makes the ccxt symbol of a market, e.g. "XLM/USDT"
+func makeSymbol(base string, quote string) string {
+	return strings.ToUpper(base) + "/" + strings.ToUpper(quote)
+}
+
+func datetime(timestampMillis int64) string {
+	return time.Unix(0, timestampMillis*int64(time.Millisecond)).UTC().Format("2006-01-02T15:04:05.000Z")
+}
+
+func nowMillis() int64 {
+	return time.Now().UnixNano() / int64(time.Millisecond)
+}
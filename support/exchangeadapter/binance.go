@@ -0,0 +1,244 @@
+package exchangeadapter
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/stellar/kelp/model"
+)
+
+// binanceAdapter uses the spot API of Binance, which is also implemented by MEXC
+type binanceAdapter struct {
+	baseURL        string
+	assetConverter model.AssetConverterInterface // converts the exchange's asset codes to the codes used by ccxt
+	maker          float64
+	taker          float64
+}
+
+var _ exchangeAdapter = &binanceAdapter{}
+
+func makeBinanceAdapter() *binanceAdapter {
+	return &binanceAdapter{
+		baseURL:        "https://api.binance.com",
+		assetConverter: nil,
+		maker:          0.001,
+		taker:          0.001,
+	}
+}
+
+func makeMexcAdapter() *binanceAdapter {
+	return &binanceAdapter{
+		baseURL:        "https://api.mexc.com",
+		assetConverter: model.CcxtAssetConverterMexc,
+		maker:          0.002,
+		taker:          0.002,
+	}
+}
+
+// URL impl
+func (a *binanceAdapter) URL() string {
+	return a.baseURL
+}
+
+type binanceExchangeInfo struct {
+	Symbols []struct {
+		Symbol             string `json:"symbol"`
+		Status             string `json:"status"`
+		BaseAsset          string `json:"baseAsset"`
+		QuoteAsset         string `json:"quoteAsset"`
+		BaseAssetPrecision int8   `json:"baseAssetPrecision"`
+		QuotePrecision     int8   `json:"quotePrecision"`
+		Filters            []struct {
+			FilterType  string `json:"filterType"`
+			TickSize    string `json:"tickSize"`
+			MinPrice    string `json:"minPrice"`
+			StepSize    string `json:"stepSize"`
+			MinQty      string `json:"minQty"`
+			MinNotional string `json:"minNotional"`
+		} `json:"filters"`
+	} `json:"symbols"`
+}
+
+// FetchMarkets impl
+func (a *binanceAdapter) FetchMarkets(httpClient *http.Client) ([]Market, error) {
+	var info binanceExchangeInfo
+	e := getJSON(httpClient, a.baseURL+"/api/v3/exchangeInfo", &info)
+	if e != nil {
+		return nil, fmt.Errorf("could not fetch exchange info: %s", e)
+	}
+
+	markets := []Market{}
+	for _, s := range info.Symbols {
+		base, e := a.ccxtCode(s.BaseAsset)
+		if e != nil {
+			return nil, e
+		}
+		quote, e := a.ccxtCode(s.QuoteAsset)
+		if e != nil {
+			return nil, e
+		}
+		m := Market{
+			ID:     s.Symbol,
+			Symbol: makeSymbol(base, quote),
+			Base:   base,
+			Quote:  quote,
+			// binance reports "TRADING" while mexc reports "1" or "ENABLED" for active markets
+			Active: s.Status == "TRADING" || s.Status == "1" || s.Status == "ENABLED",
+			Precision: MarketPrecision{
+				Amount: s.BaseAssetPrecision,
+				Price:  s.QuotePrecision,
+			},
+			Maker: a.maker,
+			Taker: a.taker,
+		}
+		// the filters are more accurate than the precision fields when they are available
+		for _, f := range s.Filters {
+			switch f.FilterType {
+			case "PRICE_FILTER":
+				if p, e := decimalsFromIncrement(f.TickSize); e == nil {
+					m.Precision.Price = p
+				}
+				m.Limits.Price.Min, _ = parseFloat(f.MinPrice)
+			case "LOT_SIZE":
+				if p, e := decimalsFromIncrement(f.StepSize); e == nil {
+					m.Precision.Amount = p
+				}
+				m.Limits.Amount.Min, _ = parseFloat(f.MinQty)
+			case "MIN_NOTIONAL", "NOTIONAL":
+				m.Limits.Cost.Min, _ = parseFloat(f.MinNotional)
+			}
+		}
+		markets = append(markets, m)
+	}
+	return markets, nil
+}
+
+func (a *binanceAdapter) ccxtCode(code string) (string, error) {
+	if a.assetConverter == nil {
+		return code, nil
+	}
+	s, e := a.assetConverter.ToString(model.Asset(code))
+	if e != nil {
+		return "", fmt.Errorf("could not convert asset code '%s': %s", code, e)
+	}
+	return s, nil
+}
+
+// FetchTicker impl
+func (a *binanceAdapter) FetchTicker(httpClient *http.Client, market Market) (*Ticker, error) {
+	var ticker struct {
+		BidPrice  string `json:"bidPrice"`
+		AskPrice  string `json:"askPrice"`
+		LastPrice string `json:"lastPrice"`
+		CloseTime int64  `json:"closeTime"`
+	}
+	e := getJSON(httpClient, a.baseURL+"/api/v3/ticker/24hr?symbol="+url.QueryEscape(market.ID), &ticker)
+	if e != nil {
+		return nil, fmt.Errorf("could not fetch ticker: %s", e)
+	}
+
+	t := &Ticker{
+		Symbol:    market.Symbol,
+		Timestamp: ticker.CloseTime,
+		Datetime:  datetime(ticker.CloseTime),
+	}
+	if t.Bid, e = parseFloat(ticker.BidPrice); e != nil {
+		return nil, fmt.Errorf("could not parse bid price: %s", e)
+	}
+	if t.Ask, e = parseFloat(ticker.AskPrice); e != nil {
+		return nil, fmt.Errorf("could not parse ask price: %s", e)
+	}
+	if t.Last, e = parseFloat(ticker.LastPrice); e != nil {
+		return nil, fmt.Errorf("could not parse last price: %s", e)
+	}
+	t.Close = t.Last
+	return t, nil
+}
+
+// FetchOrderBook impl
+func (a *binanceAdapter) FetchOrderBook(httpClient *http.Client, market Market, limit int) (*OrderBook, error) {
+	reqURL := a.baseURL + "/api/v3/depth?symbol=" + url.QueryEscape(market.ID)
+	if limit > 0 {
+		reqURL += "&limit=" + strconv.Itoa(limit)
+	}
+	var depth struct {
+		Bids [][]interface{} `json:"bids"`
+		Asks [][]interface{} `json:"asks"`
+	}
+	e := getJSON(httpClient, reqURL, &depth)
+	if e != nil {
+		return nil, fmt.Errorf("could not fetch orderbook: %s", e)
+	}
+	return makeOrderBook(market, depth.Bids, depth.Asks, limit)
+}
+
+// FetchTrades impl
+func (a *binanceAdapter) FetchTrades(httpClient *http.Client, market Market) ([]Trade, error) {
+	var trades []struct {
+		ID           int64  `json:"id"`
+		Price        string `json:"price"`
+		Qty          string `json:"qty"`
+		Time         int64  `json:"time"`
+		IsBuyerMaker bool   `json:"isBuyerMaker"`
+	}
+	e := getJSON(httpClient, a.baseURL+"/api/v3/trades?symbol="+url.QueryEscape(market.ID), &trades)
+	if e != nil {
+		return nil, fmt.Errorf("could not fetch trades: %s", e)
+	}
+
+	result := []Trade{}
+	for _, t := range trades {
+		side := "buy"
+		if t.IsBuyerMaker {
+			side = "sell"
+		}
+		trade, e := makeTrade(market, strconv.FormatInt(t.ID, 10), t.Time, side, t.Price, t.Qty)
+		if e != nil {
+			return nil, e
+		}
+		result = append(result, *trade)
+	}
+	return result, nil
+}
+
+// makeOrderBook makes an OrderBook from the bids and asks returned as string arrays
+func makeOrderBook(market Market, rawBids [][]interface{}, rawAsks [][]interface{}, limit int) (*OrderBook, error) {
+	bids, e := parseOrders(rawBids, limit)
+	if e != nil {
+		return nil, fmt.Errorf("could not parse bids: %s", e)
+	}
+	asks, e := parseOrders(rawAsks, limit)
+	if e != nil {
+		return nil, fmt.Errorf("could not parse asks: %s", e)
+	}
+	return &OrderBook{
+		Symbol:    market.Symbol,
+		Timestamp: nowMillis(),
+		Bids:      bids,
+		Asks:      asks,
+	}, nil
+}
+
+// makeTrade makes a Trade from the price and amount returned as strings
+func makeTrade(market Market, id string, timestampMillis int64, side string, priceString string, amountString string) (*Trade, error) {
+	price, e := parseFloat(priceString)
+	if e != nil {
+		return nil, fmt.Errorf("could not parse price of trade '%s': %s", id, e)
+	}
+	amount, e := parseFloat(amountString)
+	if e != nil {
+		return nil, fmt.Errorf("could not parse amount of trade '%s': %s", id, e)
+	}
+	return &Trade{
+		ID:        id,
+		Timestamp: timestampMillis,
+		Datetime:  datetime(timestampMillis),
+		Symbol:    market.Symbol,
+		Side:      side,
+		Price:     price,
+		Amount:    amount,
+		Cost:      price * amount,
+	}, nil
+}
@@ -0,0 +1,161 @@
+package exchangeadapter
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// bitstampAdapter uses the public v2 API of Bitstamp
+type bitstampAdapter struct {
+	baseURL string
+}
+
+var _ exchangeAdapter = &bitstampAdapter{}
+
+func makeBitstampAdapter() *bitstampAdapter {
+	return &bitstampAdapter{
+		baseURL: "https://www.bitstamp.net/api/v2",
+	}
+}
+
+// URL impl
+func (a *bitstampAdapter) URL() string {
+	return a.baseURL
+}
+
+// FetchMarkets impl
+func (a *bitstampAdapter) FetchMarkets(httpClient *http.Client) ([]Market, error) {
+	var pairs []struct {
+		Name            string `json:"name"`
+		URLSymbol       string `json:"url_symbol"`
+		BaseDecimals    int8   `json:"base_decimals"`
+		CounterDecimals int8   `json:"counter_decimals"`
+		MinimumOrder    string `json:"minimum_order"`
+		Trading         string `json:"trading"`
+	}
+	e := getJSON(httpClient, a.baseURL+"/trading-pairs-info/", &pairs)
+	if e != nil {
+		return nil, fmt.Errorf("could not fetch trading pairs: %s", e)
+	}
+
+	markets := []Market{}
+	for _, p := range pairs {
+		parts := strings.Split(p.Name, "/")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("trading pair name should be of the form BASE/QUOTE but was '%s'", p.Name)
+		}
+		m := Market{
+			ID:     p.URLSymbol,
+			Symbol: makeSymbol(parts[0], parts[1]),
+			Base:   strings.ToUpper(parts[0]),
+			Quote:  strings.ToUpper(parts[1]),
+			Active: p.Trading == "Enabled",
+			Precision: MarketPrecision{
+				Amount: p.BaseDecimals,
+				Price:  p.CounterDecimals,
+			},
+			Maker: 0.003,
+			Taker: 0.004,
+		}
+		// minimum_order is of the form "10.0 USD" and is denominated in the quote asset
+		minOrderParts := strings.Fields(p.MinimumOrder)
+		if len(minOrderParts) > 0 {
+			m.Limits.Cost.Min, _ = parseFloat(minOrderParts[0])
+		}
+		markets = append(markets, m)
+	}
+	return markets, nil
+}
+
+// FetchTicker impl
+func (a *bitstampAdapter) FetchTicker(httpClient *http.Client, market Market) (*Ticker, error) {
+	var ticker struct {
+		Bid       string `json:"bid"`
+		Ask       string `json:"ask"`
+		Last      string `json:"last"`
+		Timestamp string `json:"timestamp"`
+	}
+	e := getJSON(httpClient, a.baseURL+"/ticker/"+url.PathEscape(market.ID)+"/", &ticker)
+	if e != nil {
+		return nil, fmt.Errorf("could not fetch ticker: %s", e)
+	}
+
+	timestamp, e := parseUnixSecondsMillis(ticker.Timestamp)
+	if e != nil {
+		return nil, fmt.Errorf("could not parse ticker timestamp: %s", e)
+	}
+	t := &Ticker{
+		Symbol:    market.Symbol,
+		Timestamp: timestamp,
+		Datetime:  datetime(timestamp),
+	}
+	if t.Bid, e = parseFloat(ticker.Bid); e != nil {
+		return nil, fmt.Errorf("could not parse bid price: %s", e)
+	}
+	if t.Ask, e = parseFloat(ticker.Ask); e != nil {
+		return nil, fmt.Errorf("could not parse ask price: %s", e)
+	}
+	if t.Last, e = parseFloat(ticker.Last); e != nil {
+		return nil, fmt.Errorf("could not parse last price: %s", e)
+	}
+	t.Close = t.Last
+	return t, nil
+}
+
+// FetchOrderBook impl
+func (a *bitstampAdapter) FetchOrderBook(httpClient *http.Client, market Market, limit int) (*OrderBook, error) {
+	var book struct {
+		Bids [][]interface{} `json:"bids"`
+		Asks [][]interface{} `json:"asks"`
+	}
+	e := getJSON(httpClient, a.baseURL+"/order_book/"+url.PathEscape(market.ID)+"/", &book)
+	if e != nil {
+		return nil, fmt.Errorf("could not fetch orderbook: %s", e)
+	}
+	return makeOrderBook(market, book.Bids, book.Asks, limit)
+}
+
+// FetchTrades impl
+func (a *bitstampAdapter) FetchTrades(httpClient *http.Client, market Market) ([]Trade, error) {
+	var transactions []struct {
+		TID    string `json:"tid"`
+		Date   string `json:"date"`
+		Price  string `json:"price"`
+		Amount string `json:"amount"`
+		Type   string `json:"type"`
+	}
+	e := getJSON(httpClient, a.baseURL+"/transactions/"+url.PathEscape(market.ID)+"/", &transactions)
+	if e != nil {
+		return nil, fmt.Errorf("could not fetch trades: %s", e)
+	}
+
+	result := []Trade{}
+	for _, t := range transactions {
+		timestamp, e := parseUnixSecondsMillis(t.Date)
+		if e != nil {
+			return nil, fmt.Errorf("could not parse date of trade '%s': %s", t.TID, e)
+		}
+		// type 0 is a buy and type 1 is a sell
+		side := "buy"
+		if t.Type == "1" {
+			side = "sell"
+		}
+		trade, e := makeTrade(market, t.TID, timestamp, side, t.Price, t.Amount)
+		if e != nil {
+			return nil, e
+		}
+		result = append(result, *trade)
+	}
+	return result, nil
+}
+
+// parseUnixSecondsMillis parses a unix timestamp in seconds returned as a string and converts it to millis
+func parseUnixSecondsMillis(s string) (int64, error) {
+	seconds, e := parseFloat(s)
+	if e != nil {
+		return 0, e
+	}
+	return int64(seconds * 1000), nil
+}
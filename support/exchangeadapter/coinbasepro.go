@@ -0,0 +1,164 @@
+package exchangeadapter
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// coinbaseproAdapter uses the public API of Coinbase Exchange (formerly Coinbase Pro)
+type coinbaseproAdapter struct {
+	baseURL string
+}
+
+var _ exchangeAdapter = &coinbaseproAdapter{}
+
+func makeCoinbaseproAdapter() *coinbaseproAdapter {
+	return &coinbaseproAdapter{
+		baseURL: "https://api.exchange.coinbase.com",
+	}
+}
+
+// URL impl
+func (a *coinbaseproAdapter) URL() string {
+	return a.baseURL
+}
+
+// FetchMarkets impl
+func (a *coinbaseproAdapter) FetchMarkets(httpClient *http.Client) ([]Market, error) {
+	var products []struct {
+		ID              string `json:"id"`
+		BaseCurrency    string `json:"base_currency"`
+		QuoteCurrency   string `json:"quote_currency"`
+		QuoteIncrement  string `json:"quote_increment"`
+		BaseIncrement   string `json:"base_increment"`
+		MinMarketFunds  string `json:"min_market_funds"`
+		Status          string `json:"status"`
+		TradingDisabled bool   `json:"trading_disabled"`
+	}
+	e := getJSON(httpClient, a.baseURL+"/products", &products)
+	if e != nil {
+		return nil, fmt.Errorf("could not fetch products: %s", e)
+	}
+
+	markets := []Market{}
+	for _, p := range products {
+		m := Market{
+			ID:     p.ID,
+			Symbol: makeSymbol(p.BaseCurrency, p.QuoteCurrency),
+			Base:   strings.ToUpper(p.BaseCurrency),
+			Quote:  strings.ToUpper(p.QuoteCurrency),
+			Active: p.Status == "online" && !p.TradingDisabled,
+			Maker:  0.004,
+			Taker:  0.006,
+		}
+		if m.Precision.Price, e = decimalsFromIncrement(p.QuoteIncrement); e != nil {
+			return nil, fmt.Errorf("could not parse quote increment of product '%s': %s", p.ID, e)
+		}
+		if m.Precision.Amount, e = decimalsFromIncrement(p.BaseIncrement); e != nil {
+			return nil, fmt.Errorf("could not parse base increment of product '%s': %s", p.ID, e)
+		}
+		m.Limits.Price.Min, _ = parseFloat(p.QuoteIncrement)
+		m.Limits.Amount.Min, _ = parseFloat(p.BaseIncrement)
+		m.Limits.Cost.Min, _ = parseFloat(p.MinMarketFunds)
+		markets = append(markets, m)
+	}
+	return markets, nil
+}
+
+// FetchTicker impl
+func (a *coinbaseproAdapter) FetchTicker(httpClient *http.Client, market Market) (*Ticker, error) {
+	var ticker struct {
+		Bid   string `json:"bid"`
+		Ask   string `json:"ask"`
+		Price string `json:"price"`
+		Time  string `json:"time"`
+	}
+	e := getJSON(httpClient, a.baseURL+"/products/"+url.PathEscape(market.ID)+"/ticker", &ticker)
+	if e != nil {
+		return nil, fmt.Errorf("could not fetch ticker: %s", e)
+	}
+
+	timestamp, e := parseTimeMillis(ticker.Time)
+	if e != nil {
+		return nil, fmt.Errorf("could not parse ticker time: %s", e)
+	}
+	t := &Ticker{
+		Symbol:    market.Symbol,
+		Timestamp: timestamp,
+		Datetime:  datetime(timestamp),
+	}
+	if t.Bid, e = parseFloat(ticker.Bid); e != nil {
+		return nil, fmt.Errorf("could not parse bid price: %s", e)
+	}
+	if t.Ask, e = parseFloat(ticker.Ask); e != nil {
+		return nil, fmt.Errorf("could not parse ask price: %s", e)
+	}
+	if t.Last, e = parseFloat(ticker.Price); e != nil {
+		return nil, fmt.Errorf("could not parse last price: %s", e)
+	}
+	t.Close = t.Last
+	return t, nil
+}
+
+// FetchOrderBook impl
+func (a *coinbaseproAdapter) FetchOrderBook(httpClient *http.Client, market Market, limit int) (*OrderBook, error) {
+	var book struct {
+		Bids [][]interface{} `json:"bids"`
+		Asks [][]interface{} `json:"asks"`
+	}
+	e := getJSON(httpClient, a.baseURL+"/products/"+url.PathEscape(market.ID)+"/book?level=2", &book)
+	if e != nil {
+		return nil, fmt.Errorf("could not fetch orderbook: %s", e)
+	}
+	return makeOrderBook(market, book.Bids, book.Asks, limit)
+}
+
+// FetchTrades impl
+func (a *coinbaseproAdapter) FetchTrades(httpClient *http.Client, market Market) ([]Trade, error) {
+	var trades []struct {
+		TradeID int64  `json:"trade_id"`
+		Price   string `json:"price"`
+		Size    string `json:"size"`
+		Time    string `json:"time"`
+		Side    string `json:"side"`
+	}
+	e := getJSON(httpClient, a.baseURL+"/products/"+url.PathEscape(market.ID)+"/trades", &trades)
+	if e != nil {
+		return nil, fmt.Errorf("could not fetch trades: %s", e)
+	}
+
+	result := []Trade{}
+	for _, t := range trades {
+		timestamp, e := parseTimeMillis(t.Time)
+		if e != nil {
+			return nil, fmt.Errorf("could not parse time of trade '%d': %s", t.TradeID, e)
+		}
+		// coinbase reports the side of the maker so we invert it to get the side of the taker
+		side := "sell"
+		if t.Side == "sell" {
+			side = "buy"
+		}
+		trade, e := makeTrade(market, strconv.FormatInt(t.TradeID, 10), timestamp, side, t.Price, t.Size)
+		if e != nil {
+			return nil, e
+		}
+		result = append(result, *trade)
+	}
+	return result, nil
+}
+
+// parseTimeMillis parses an RFC3339 time, where an empty string is the current time
+func parseTimeMillis(s string) (int64, error) {
+	if s == "" {
+		return nowMillis(), nil
+	}
+	t, e := time.Parse(time.RFC3339Nano, s)
+	if e != nil {
+		return 0, e
+	}
+	return t.UnixNano() / int64(time.Millisecond), nil
+}
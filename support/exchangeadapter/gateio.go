@@ -0,0 +1,158 @@
+package exchangeadapter
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/stellar/kelp/model"
+)
+
+// gateioAdapter uses the public v4 spot API of Gate.io
+type gateioAdapter struct {
+	baseURL string
+}
+
+var _ exchangeAdapter = &gateioAdapter{}
+
+func makeGateioAdapter() *gateioAdapter {
+	return &gateioAdapter{
+		baseURL: "https://api.gateio.ws/api/v4",
+	}
+}
+
+// URL impl
+func (a *gateioAdapter) URL() string {
+	return a.baseURL
+}
+
+// FetchMarkets impl
+func (a *gateioAdapter) FetchMarkets(httpClient *http.Client) ([]Market, error) {
+	var pairs []struct {
+		ID              string `json:"id"`
+		Base            string `json:"base"`
+		Quote           string `json:"quote"`
+		Fee             string `json:"fee"`
+		MinBaseAmount   string `json:"min_base_amount"`
+		MinQuoteAmount  string `json:"min_quote_amount"`
+		AmountPrecision int8   `json:"amount_precision"`
+		Precision       int8   `json:"precision"`
+		TradeStatus     string `json:"trade_status"`
+	}
+	e := getJSON(httpClient, a.baseURL+"/spot/currency_pairs", &pairs)
+	if e != nil {
+		return nil, fmt.Errorf("could not fetch currency pairs: %s", e)
+	}
+
+	markets := []Market{}
+	for _, p := range pairs {
+		base, e := model.CcxtAssetConverterGateio.ToString(model.Asset(p.Base))
+		if e != nil {
+			return nil, fmt.Errorf("could not convert asset code '%s': %s", p.Base, e)
+		}
+		quote, e := model.CcxtAssetConverterGateio.ToString(model.Asset(p.Quote))
+		if e != nil {
+			return nil, fmt.Errorf("could not convert asset code '%s': %s", p.Quote, e)
+		}
+		// the fee is returned as a percentage
+		feePercent, _ := parseFloat(p.Fee)
+		m := Market{
+			ID:     p.ID,
+			Symbol: makeSymbol(base, quote),
+			Base:   base,
+			Quote:  quote,
+			Active: p.TradeStatus == "tradable",
+			Precision: MarketPrecision{
+				Amount: p.AmountPrecision,
+				Price:  p.Precision,
+			},
+			Maker: feePercent / 100,
+			Taker: feePercent / 100,
+		}
+		m.Limits.Amount.Min, _ = parseFloat(p.MinBaseAmount)
+		m.Limits.Cost.Min, _ = parseFloat(p.MinQuoteAmount)
+		markets = append(markets, m)
+	}
+	return markets, nil
+}
+
+// FetchTicker impl
+func (a *gateioAdapter) FetchTicker(httpClient *http.Client, market Market) (*Ticker, error) {
+	var tickers []struct {
+		HighestBid string `json:"highest_bid"`
+		LowestAsk  string `json:"lowest_ask"`
+		Last       string `json:"last"`
+	}
+	e := getJSON(httpClient, a.baseURL+"/spot/tickers?currency_pair="+url.QueryEscape(market.ID), &tickers)
+	if e != nil {
+		return nil, fmt.Errorf("could not fetch ticker: %s", e)
+	}
+	if len(tickers) != 1 {
+		return nil, fmt.Errorf("expected 1 ticker for currency pair '%s' but got %d", market.ID, len(tickers))
+	}
+
+	timestamp := nowMillis()
+	t := &Ticker{
+		Symbol:    market.Symbol,
+		Timestamp: timestamp,
+		Datetime:  datetime(timestamp),
+	}
+	if t.Bid, e = parseFloat(tickers[0].HighestBid); e != nil {
+		return nil, fmt.Errorf("could not parse bid price: %s", e)
+	}
+	if t.Ask, e = parseFloat(tickers[0].LowestAsk); e != nil {
+		return nil, fmt.Errorf("could not parse ask price: %s", e)
+	}
+	if t.Last, e = parseFloat(tickers[0].Last); e != nil {
+		return nil, fmt.Errorf("could not parse last price: %s", e)
+	}
+	t.Close = t.Last
+	return t, nil
+}
+
+// FetchOrderBook impl
+func (a *gateioAdapter) FetchOrderBook(httpClient *http.Client, market Market, limit int) (*OrderBook, error) {
+	reqURL := a.baseURL + "/spot/order_book?currency_pair=" + url.QueryEscape(market.ID)
+	if limit > 0 {
+		reqURL += "&limit=" + strconv.Itoa(limit)
+	}
+	var book struct {
+		Bids [][]interface{} `json:"bids"`
+		Asks [][]interface{} `json:"asks"`
+	}
+	e := getJSON(httpClient, reqURL, &book)
+	if e != nil {
+		return nil, fmt.Errorf("could not fetch orderbook: %s", e)
+	}
+	return makeOrderBook(market, book.Bids, book.Asks, limit)
+}
+
+// FetchTrades impl
+func (a *gateioAdapter) FetchTrades(httpClient *http.Client, market Market) ([]Trade, error) {
+	var trades []struct {
+		ID           string `json:"id"`
+		CreateTimeMs string `json:"create_time_ms"`
+		Side         string `json:"side"`
+		Amount       string `json:"amount"`
+		Price        string `json:"price"`
+	}
+	e := getJSON(httpClient, a.baseURL+"/spot/trades?currency_pair="+url.QueryEscape(market.ID), &trades)
+	if e != nil {
+		return nil, fmt.Errorf("could not fetch trades: %s", e)
+	}
+
+	result := []Trade{}
+	for _, t := range trades {
+		timestamp, e := parseFloat(t.CreateTimeMs)
+		if e != nil {
+			return nil, fmt.Errorf("could not parse time of trade '%s': %s", t.ID, e)
+		}
+		trade, e := makeTrade(market, t.ID, int64(timestamp), t.Side, t.Price, t.Amount)
+		if e != nil {
+			return nil, e
+		}
+		result = append(result, *trade)
+	}
+	return result, nil
+}
@@ -0,0 +1,296 @@
+package exchangeadapter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/go-chi/chi"
+)
+
+// adapterFactories are the exchanges supported by the server, keyed by the ccxt name of the exchange
+var adapterFactories = map[string]func() exchangeAdapter{
+	"binance":     func() exchangeAdapter { return makeBinanceAdapter() },
+	"coinbasepro": func() exchangeAdapter { return makeCoinbaseproAdapter() },
+	"bitstamp":    func() exchangeAdapter { return makeBitstampAdapter() },
+	"mexc":        func() exchangeAdapter { return makeMexcAdapter() },
+	"gateio":      func() exchangeAdapter { return makeGateioAdapter() },
+}
+
+// server serves the subset of the ccxt-rest API that is needed to read the public market data of an exchange.
+// Instances can be created with API keys so that existing configs continue to work, but the keys are ignored
+// since the private (trading) endpoints are not supported.
+type server struct {
+	httpClient *http.Client
+	adapters   map[string]exchangeAdapter
+
+	mutex     *sync.Mutex
+	instances map[string]map[string]bool   // exchange -> instance IDs
+	markets   map[string]map[string]Market // exchange -> symbol -> market, loaded lazily
+}
+
+// MakeServer is a factory method for an http.Handler that serves the ccxt-rest API for the supported exchanges
+func MakeServer(httpClient *http.Client) http.Handler {
+	adapters := map[string]exchangeAdapter{}
+	for name, factory := range adapterFactories {
+		adapters[name] = factory()
+	}
+	return makeServer(httpClient, adapters)
+}
+
+func makeServer(httpClient *http.Client, adapters map[string]exchangeAdapter) http.Handler {
+	s := &server{
+		httpClient: httpClient,
+		adapters:   adapters,
+		mutex:      &sync.Mutex{},
+		instances:  map[string]map[string]bool{},
+		markets:    map[string]map[string]Market{},
+	}
+
+	r := chi.NewRouter()
+	r.Get("/", http.HandlerFunc(s.root))
+	r.Get("/exchanges", http.HandlerFunc(s.listExchanges))
+	r.Get("/exchanges/{exchange}", http.HandlerFunc(s.listInstances))
+	r.Post("/exchanges/{exchange}", http.HandlerFunc(s.createInstance))
+	r.Get("/exchanges/{exchange}/{instance}", http.HandlerFunc(s.getInstance))
+	r.Post("/exchanges/{exchange}/{instance}/{method}", http.HandlerFunc(s.callMethod))
+	return r
+}
+
+func (s *server) root(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{})
+}
+
+func (s *server) listExchanges(w http.ResponseWriter, r *http.Request) {
+	names := []string{}
+	for name := range s.adapters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	writeJSON(w, http.StatusOK, names)
+}
+
+func (s *server) listInstances(w http.ResponseWriter, r *http.Request) {
+	exchange := chi.URLParam(r, "exchange")
+	if _, ok := s.adapters[exchange]; !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("exchange '%s' is not supported", exchange))
+		return
+	}
+
+	s.mutex.Lock()
+	ids := []string{}
+	for id := range s.instances[exchange] {
+		ids = append(ids, id)
+	}
+	s.mutex.Unlock()
+
+	sort.Strings(ids)
+	writeJSON(w, http.StatusOK, ids)
+}
+
+func (s *server) createInstance(w http.ResponseWriter, r *http.Request) {
+	exchange := chi.URLParam(r, "exchange")
+	adapter, ok := s.adapters[exchange]
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("exchange '%s' is not supported", exchange))
+		return
+	}
+
+	bodyBytes, e := ioutil.ReadAll(r.Body)
+	if e != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("could not read request body: %s", e))
+		return
+	}
+	var req struct {
+		ID string `json:"id"`
+	}
+	e = json.Unmarshal(bodyBytes, &req)
+	if e != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("could not unmarshal request body: %s", e))
+		return
+	}
+	if req.ID == "" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("'id' is required to create an instance"))
+		return
+	}
+
+	s.mutex.Lock()
+	if _, ok := s.instances[exchange]; !ok {
+		s.instances[exchange] = map[string]bool{}
+	}
+	s.instances[exchange][req.ID] = true
+	s.mutex.Unlock()
+
+	log.Printf("exchangeadapter: created instance '%s' for exchange '%s'\n", req.ID, exchange)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"id":   req.ID,
+		"urls": map[string]string{"api": adapter.URL()},
+	})
+}
+
+func (s *server) getInstance(w http.ResponseWriter, r *http.Request) {
+	exchange := chi.URLParam(r, "exchange")
+	if !s.checkInstance(w, exchange, chi.URLParam(r, "instance")) {
+		return
+	}
+
+	markets, e := s.loadMarkets(exchange)
+	if e != nil {
+		writeError(w, http.StatusInternalServerError, e)
+		return
+	}
+	symbols := []string{}
+	for symbol := range markets {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"id":      chi.URLParam(r, "instance"),
+		"symbols": symbols,
+	})
+}
+
+func (s *server) callMethod(w http.ResponseWriter, r *http.Request) {
+	exchange := chi.URLParam(r, "exchange")
+	if !s.checkInstance(w, exchange, chi.URLParam(r, "instance")) {
+		return
+	}
+	adapter := s.adapters[exchange]
+
+	markets, e := s.loadMarkets(exchange)
+	if e != nil {
+		writeError(w, http.StatusInternalServerError, e)
+		return
+	}
+
+	method := chi.URLParam(r, "method")
+	if method == "loadMarkets" {
+		writeJSON(w, http.StatusOK, markets)
+		return
+	}
+
+	// the remaining methods take the symbol as the first argument and an optional limit as the second argument
+	var args []string
+	bodyBytes, e := ioutil.ReadAll(r.Body)
+	if e != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("could not read request body: %s", e))
+		return
+	}
+	if len(bodyBytes) > 0 {
+		e = json.Unmarshal(bodyBytes, &args)
+		if e != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("could not unmarshal request body as an array of strings: %s", e))
+			return
+		}
+	}
+
+	var market Market
+	switch method {
+	case "fetchTicker", "fetchOrderBook", "fetchTrades", "fetchTradingFee":
+		if len(args) < 1 {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("method '%s' needs the symbol as the first argument", method))
+			return
+		}
+		var ok bool
+		market, ok = markets[args[0]]
+		if !ok {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("symbol '%s' does not exist on exchange '%s'", args[0], exchange))
+			return
+		}
+	default:
+		writeError(w, http.StatusNotImplemented, fmt.Errorf("method '%s' is not supported by the native exchange adapter, use ccxt-rest for trading on centralized exchanges", method))
+		return
+	}
+
+	var result interface{}
+	switch method {
+	case "fetchTicker":
+		result, e = adapter.FetchTicker(s.httpClient, market)
+	case "fetchOrderBook":
+		limit := 0
+		if len(args) > 1 {
+			limit, e = strconv.Atoi(args[1])
+			if e != nil {
+				writeError(w, http.StatusBadRequest, fmt.Errorf("could not parse limit '%s': %s", args[1], e))
+				return
+			}
+		}
+		result, e = adapter.FetchOrderBook(s.httpClient, market, limit)
+	case "fetchTrades":
+		result, e = adapter.FetchTrades(s.httpClient, market)
+	case "fetchTradingFee":
+		// these are the default fee rates of the market since the instance does not use the account's API keys
+		result = map[string]interface{}{
+			"symbol": market.Symbol,
+			"maker":  market.Maker,
+			"taker":  market.Taker,
+		}
+	}
+	if e != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("error calling method '%s' on exchange '%s': %s", method, exchange, e))
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// checkInstance writes an error and returns false if the instance does not exist
+func (s *server) checkInstance(w http.ResponseWriter, exchange string, instance string) bool {
+	if _, ok := s.adapters[exchange]; !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("exchange '%s' is not supported", exchange))
+		return false
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if !s.instances[exchange][instance] {
+		writeError(w, http.StatusNotFound, fmt.Errorf("instance '%s' does not exist for exchange '%s'", instance, exchange))
+		return false
+	}
+	return true
+}
+
+// loadMarkets fetches the markets of the exchange the first time it is called and then returns the cached markets
+func (s *server) loadMarkets(exchange string) (map[string]Market, error) {
+	s.mutex.Lock()
+	markets, ok := s.markets[exchange]
+	s.mutex.Unlock()
+	if ok {
+		return markets, nil
+	}
+
+	marketList, e := s.adapters[exchange].FetchMarkets(s.httpClient)
+	if e != nil {
+		return nil, fmt.Errorf("could not load markets for exchange '%s': %s", exchange, e)
+	}
+	markets = map[string]Market{}
+	for _, m := range marketList {
+		markets[m.Symbol] = m
+	}
+
+	s.mutex.Lock()
+	s.markets[exchange] = markets
+	s.mutex.Unlock()
+	return markets, nil
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, v interface{}) {
+	bytes, e := json.Marshal(v)
+	if e != nil {
+		log.Printf("exchangeadapter: could not marshal response: %s\n", e)
+		statusCode = http.StatusInternalServerError
+		bytes = []byte(`{"error":"could not marshal response"}`)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	w.Write(bytes)
+}
+
+func writeError(w http.ResponseWriter, statusCode int, e error) {
+	log.Printf("exchangeadapter: %s\n", e)
+	writeJSON(w, statusCode, map[string]string{"error": e.Error()})
+}
@@ -0,0 +1,180 @@
+package exchangeadapter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeAdapter struct {
+	lastLimit int
+}
+
+var _ exchangeAdapter = &fakeAdapter{}
+
+func (a *fakeAdapter) URL() string {
+	return "https://api.fake.exchange"
+}
+
+func (a *fakeAdapter) FetchMarkets(httpClient *http.Client) ([]Market, error) {
+	return []Market{{
+		ID:        "XLMUSDT",
+		Symbol:    "XLM/USDT",
+		Base:      "XLM",
+		Quote:     "USDT",
+		Active:    true,
+		Precision: MarketPrecision{Amount: 1, Price: 4},
+		Maker:     0.001,
+		Taker:     0.002,
+	}}, nil
+}
+
+func (a *fakeAdapter) FetchTicker(httpClient *http.Client, market Market) (*Ticker, error) {
+	return &Ticker{Symbol: market.Symbol, Bid: 0.1, Ask: 0.2, Last: 0.15, Close: 0.15}, nil
+}
+
+func (a *fakeAdapter) FetchOrderBook(httpClient *http.Client, market Market, limit int) (*OrderBook, error) {
+	a.lastLimit = limit
+	return &OrderBook{
+		Symbol: market.Symbol,
+		Bids:   [][2]float64{{0.1, 100}},
+		Asks:   [][2]float64{{0.2, 50}},
+	}, nil
+}
+
+func (a *fakeAdapter) FetchTrades(httpClient *http.Client, market Market) ([]Trade, error) {
+	return nil, fmt.Errorf("fetch trades failed")
+}
+
+func doRequest(t *testing.T, ts *httptest.Server, method string, path string, body string) (int, map[string]interface{}) {
+	req, e := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+	if !assert.NoError(t, e) {
+		return 0, nil
+	}
+	resp, e := ts.Client().Do(req)
+	if !assert.NoError(t, e) {
+		return 0, nil
+	}
+	defer resp.Body.Close()
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+
+	bodyBytes, e := ioutil.ReadAll(resp.Body)
+	if !assert.NoError(t, e) {
+		return 0, nil
+	}
+	var output interface{}
+	if !assert.NoError(t, json.Unmarshal(bodyBytes, &output)) {
+		return 0, nil
+	}
+	if m, ok := output.(map[string]interface{}); ok {
+		return resp.StatusCode, m
+	}
+	return resp.StatusCode, map[string]interface{}{"list": output}
+}
+
+func TestServer(t *testing.T) {
+	adapter := &fakeAdapter{}
+	ts := httptest.NewServer(makeServer(http.DefaultClient, map[string]exchangeAdapter{"fake": adapter}))
+	defer ts.Close()
+
+	status, _ := doRequest(t, ts, "GET", "/", "")
+	assert.Equal(t, http.StatusOK, status)
+
+	status, output := doRequest(t, ts, "GET", "/exchanges", "")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, []interface{}{"fake"}, output["list"])
+
+	// methods cannot be called before the instance is created
+	status, output = doRequest(t, ts, "POST", "/exchanges/fake/inst1/loadMarkets", "")
+	assert.Equal(t, http.StatusNotFound, status)
+	assert.Contains(t, output, "error")
+
+	status, output = doRequest(t, ts, "POST", "/exchanges/unknown", `{"id":"inst1"}`)
+	assert.Equal(t, http.StatusNotFound, status)
+	assert.Contains(t, output, "error")
+
+	status, output = doRequest(t, ts, "POST", "/exchanges/fake", `{"id":"inst1","apiKey":"k","secret":"s"}`)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Contains(t, output, "urls")
+
+	status, output = doRequest(t, ts, "GET", "/exchanges/fake", "")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, []interface{}{"inst1"}, output["list"])
+
+	status, output = doRequest(t, ts, "GET", "/exchanges/fake/inst1", "")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, []interface{}{"XLM/USDT"}, output["symbols"])
+
+	status, output = doRequest(t, ts, "POST", "/exchanges/fake/inst1/loadMarkets", "")
+	assert.Equal(t, http.StatusOK, status)
+	if assert.Contains(t, output, "XLM/USDT") {
+		market := output["XLM/USDT"].(map[string]interface{})
+		assert.Equal(t, "XLM", market["base"])
+		assert.Equal(t, map[string]interface{}{"amount": 1.0, "price": 4.0}, market["precision"])
+	}
+
+	status, output = doRequest(t, ts, "POST", "/exchanges/fake/inst1/fetchTicker", `["XLM/USDT"]`)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, 0.1, output["bid"])
+	assert.Equal(t, 0.2, output["ask"])
+	assert.Equal(t, 0.15, output["last"])
+
+	status, output = doRequest(t, ts, "POST", "/exchanges/fake/inst1/fetchOrderBook", `["XLM/USDT","20"]`)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, 20, adapter.lastLimit)
+	assert.Equal(t, []interface{}{[]interface{}{0.1, 100.0}}, output["bids"])
+	assert.Equal(t, []interface{}{[]interface{}{0.2, 50.0}}, output["asks"])
+
+	status, output = doRequest(t, ts, "POST", "/exchanges/fake/inst1/fetchTradingFee", `["XLM/USDT"]`)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, 0.001, output["maker"])
+	assert.Equal(t, 0.002, output["taker"])
+
+	status, output = doRequest(t, ts, "POST", "/exchanges/fake/inst1/fetchTicker", `["BTC/USDT"]`)
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Contains(t, output, "error")
+
+	status, output = doRequest(t, ts, "POST", "/exchanges/fake/inst1/fetchTrades", `["XLM/USDT"]`)
+	assert.Equal(t, http.StatusInternalServerError, status)
+	assert.Contains(t, output["error"], "fetch trades failed")
+
+	status, output = doRequest(t, ts, "POST", "/exchanges/fake/inst1/createOrder", `["XLM/USDT"]`)
+	assert.Equal(t, http.StatusNotImplemented, status)
+	assert.Contains(t, output, "error")
+}
+
+func TestDecimalsFromIncrement(t *testing.T) {
+	testCases := []struct {
+		increment string
+		want      int8
+		wantErr   bool
+	}{
+		{"0.00100000", 3, false},
+		{"0.0000001", 7, false},
+		{"1", 0, false},
+		{"10", 0, false},
+		{"0.5", 0, false},
+		{"0", 0, true},
+		{"abc", 0, true},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.increment, func(t *testing.T) {
+			got, e := decimalsFromIncrement(k.increment)
+			if k.wantErr {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, k.want, got)
+		})
+	}
+}
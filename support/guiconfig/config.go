@@ -11,8 +11,16 @@ type Auth0Config struct {
 	Audience     string `valid:"-" toml:"AUDIENCE"json:"audience"`
 }
 
+// ResourceLimitsConfig sets limits on the resources used by each bot process, a bot that exceeds a limit is restarted
+type ResourceLimitsConfig struct {
+	MaxCPUPercent          float64 `valid:"-" toml:"MAX_CPU_PERCENT" json:"max_cpu_percent"`                     // 0 means no limit, 100 is one full core
+	MaxMemoryMB            float64 `valid:"-" toml:"MAX_MEMORY_MB" json:"max_memory_mb"`                         // 0 means no limit
+	NumChecksBeforeRestart uint32  `valid:"-" toml:"NUM_CHECKS_BEFORE_RESTART" json:"num_checks_before_restart"` // consecutive checks over a limit before restarting
+}
+
 type GUIConfig struct {
 	Auth0Config 		*Auth0Config `valid:"-" toml:"AUTH0" json:"auth0"`
+	ResourceLimits *ResourceLimitsConfig `valid:"-" toml:"RESOURCE_LIMITS" json:"resource_limits"`
 }
 
 // String impl.
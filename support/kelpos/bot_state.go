@@ -21,6 +21,16 @@ func (bs BotState) String() string {
 	}[bs]
 }
 
+// parseBotState is the inverse of String
+func parseBotState(s string) (BotState, error) {
+	for _, bs := range []BotState{BotStateInitializing, BotStateStopped, BotStateRunning, BotStateStopping} {
+		if bs.String() == s {
+			return bs, nil
+		}
+	}
+	return InitState(), fmt.Errorf("unrecognized botState: %s", s)
+}
+
 // InitState is the first state of the bot
 func InitState() BotState {
 	return BotStateInitializing
@@ -0,0 +1,107 @@
+package kelpos
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// runsUnderBash is set on windows where the commands that are registered with kelpos keep running through bash -c like they always have,
+// since the paths of the release GUI version only work as unix paths under the bundled bash
+var runsUnderBash = runtime.GOOS == "windows"
+
+// Command is a command that is run directly instead of through a shell, so the args are passed to the binary as-is and a path with
+// spaces or special characters is never interpreted by a shell
+type Command struct {
+	// Binary is the name of a binary on the PATH or the native path to a binary
+	Binary string
+	// Args do not need to be quoted or escaped
+	Args []string
+	// Env is a list of "KEY=value" entries added to the environment inherited from the kelp process
+	Env []string
+	// Dir is the directory the command is run from, defaults to the dotKelpWorkingDir when nil
+	Dir *OSPath
+	// Timeout kills the command once it has run for this long, 0 never times out
+	Timeout time.Duration
+}
+
+// MakeCommand is a factory method for a Command that runs the binary with the args
+func MakeCommand(binary string, args ...string) *Command {
+	return &Command{
+		Binary: binary,
+		Args:   args,
+	}
+}
+
+// String is the Stringer method, it quotes the args that would need quoting in a shell so it can be copied from the logs
+func (c *Command) String() string {
+	parts := []string{quoteIfNeeded(c.Binary)}
+	for _, a := range c.Args {
+		parts = append(parts, quoteIfNeeded(a))
+	}
+	s := strings.Join(parts, " ")
+	if len(c.Env) > 0 {
+		s = strings.Join(c.Env, " ") + " " + s
+	}
+	return s
+}
+
+func quoteIfNeeded(s string) string {
+	if s == "" || strings.ContainsAny(s, " \t\n'\"\\$`|&;<>()*?[]{}!#~") {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// CommandPath returns the path to use for a path in the Binary or Args of a Command that is run with Blocking or Background, which is the
+// unix path when the command runs under bash and the native path otherwise
+func CommandPath(p *OSPath) string {
+	if runsUnderBash {
+		return p.Unix()
+	}
+	return p.Native()
+}
+
+// bashString is the command as a single string for bash -c, every part is in single quotes so it is never expanded by bash
+func (c *Command) bashString() string {
+	parts := []string{bashQuote(c.Binary)}
+	for _, a := range c.Args {
+		parts = append(parts, bashQuote(a))
+	}
+	return strings.Join(parts, " ")
+}
+
+func bashQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+// toExecCmd makes the exec.Cmd for the command, the returned cancel func releases the timeout of the command and should be called once
+// the command has finished. The command is run through bash -c when underBash is set
+func (kos *KelpOS) toExecCmd(ctx context.Context, c *Command, underBash bool) (*exec.Cmd, context.CancelFunc) {
+	cancel := func() {}
+	if c.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+	}
+
+	var cmd *exec.Cmd
+	if underBash {
+		cmd = exec.CommandContext(ctx, "bash", "-c", c.bashString())
+	} else {
+		cmd = exec.CommandContext(ctx, c.Binary, c.Args...)
+	}
+	// always execute commands from the working directory unless specified (specify as native since underlying OS handles it)
+	// using dotKelpWorkingDir as working directory since all our config files and log files are located in here and we want
+	// to have the shortest path lengths to accommodate for the 260 character file path limit in windows
+	cmd.Dir = kos.dotKelpWorkingDir.Native()
+	if c.Dir != nil {
+		cmd.Dir = c.Dir.Native()
+	}
+	if len(c.Env) > 0 {
+		cmd.Env = append(os.Environ(), c.Env...)
+	}
+	return cmd, cancel
+}
@@ -0,0 +1,107 @@
+package kelpos
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func makeTestKelpOS(t *testing.T) (*KelpOS, string) {
+	dir, e := ioutil.TempDir("", "kelpos")
+	if e != nil {
+		t.Fatal(e)
+	}
+	return &KelpOS{
+		dotKelpWorkingDir:   makeOSPath(dir, dir, false),
+		processes:           map[string]Process{},
+		processLock:         &sync.Mutex{},
+		silentRegistrations: true,
+	}, dir
+}
+
+func TestCommandString(t *testing.T) {
+	assert.Equal(t, "ls -l", MakeCommand("ls", "-l").String())
+	assert.Equal(t, `cp "my file" "$(rm -rf x)" ""`, MakeCommand("cp", "my file", "$(rm -rf x)", "").String())
+	assert.Equal(t, "PORT=3000 yarn start", (&Command{Binary: "yarn", Args: []string{"start"}, Env: []string{"PORT=3000"}}).String())
+}
+
+func TestCommandBashString(t *testing.T) {
+	assert.Equal(t, `'ls' '-l'`, MakeCommand("ls", "-l").bashString())
+	assert.Equal(t, `'cp' 'it'\''s' '$(rm -rf x)' ''`, MakeCommand("cp", "it's", "$(rm -rf x)", "").bashString())
+}
+
+func TestToExecCmd_UnderBash(t *testing.T) {
+	kos, dir := makeTestKelpOS(t)
+	defer os.RemoveAll(dir)
+
+	// the args are passed through bash -c without being split or expanded
+	cmd, cancel := kos.toExecCmd(context.Background(), MakeCommand("echo", "it's a b; echo $HOME"), true)
+	defer cancel()
+	output, e := cmd.Output()
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, "it's a b; echo $HOME\n", string(output))
+}
+
+func TestBlocking(t *testing.T) {
+	kos, dir := makeTestKelpOS(t)
+	defer os.RemoveAll(dir)
+
+	// the arg is passed as-is so it is neither split on the space nor expanded
+	output, e := kos.Blocking("_", "echo", MakeCommand("echo", "a b; echo $HOME"))
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, "a b; echo $HOME\n", string(output))
+
+	// commands run from the working directory by default
+	output, e = kos.Blocking("_", "pwd", MakeCommand("pwd"))
+	if !assert.NoError(t, e) {
+		return
+	}
+	wantDir, _ := filepath.EvalSymlinks(dir)
+	gotDir, _ := filepath.EvalSymlinks(string(output[:len(output)-1]))
+	assert.Equal(t, wantDir, gotDir)
+
+	subDir := filepath.Join(dir, "sub dir")
+	if !assert.NoError(t, os.Mkdir(subDir, 0755)) {
+		return
+	}
+	output, e = kos.Blocking("_", "env", &Command{
+		Binary: "sh",
+		Args:   []string{"-c", `echo "$KELP_TEST_VAR" && ls`},
+		Env:    []string{"KELP_TEST_VAR=value with spaces"},
+		Dir:    makeOSPath(dir, dir, false),
+	})
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, "value with spaces\nsub dir\n", string(output))
+
+	_, e = kos.Blocking("_", "false", MakeCommand("false"))
+	assert.Error(t, e)
+
+	// the namespace is unregistered when the command finishes so it can be reused
+	assert.Equal(t, 0, len(kos.RegisteredProcesses()))
+}
+
+func TestBlocking_Timeout(t *testing.T) {
+	kos, dir := makeTestKelpOS(t)
+	defer os.RemoveAll(dir)
+
+	start := time.Now()
+	_, e := kos.Blocking("_", "sleep", &Command{
+		Binary:  "sleep",
+		Args:    []string{"10"},
+		Timeout: 100 * time.Millisecond,
+	})
+	assert.Error(t, e)
+	assert.True(t, time.Since(start) < 5*time.Second)
+}
@@ -1,14 +1,17 @@
 package kelpos
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"log"
+	"os"
 	"os/exec"
 	"os/user"
 	"sync"
 
 	"github.com/stellar/go/support/errors"
+	"github.com/stellar/kelp/support/statestore"
 )
 
 const dotKelpDir = ".kelp"
@@ -24,6 +27,7 @@ type KelpOS struct {
 
 	// uninitialized
 	silentRegistrations bool
+	stateStore          statestore.Store
 }
 
 // GetBinDir accessor
@@ -41,11 +45,24 @@ func (kos *KelpOS) SetSilentRegistrations() {
 	kos.silentRegistrations = true
 }
 
+// SetStateStore persists the state of every bot in the store, so the last known state of the bots survives restarts of the server
+func (kos *KelpOS) SetStateStore(store statestore.Store) {
+	kos.stateStore = store
+}
+
+// StateStore accessor, returns nil when the state store was not set
+func (kos *KelpOS) StateStore() statestore.Store {
+	return kos.stateStore
+}
+
 // Process contains all the pieces that can be used to control a given process
 type Process struct {
 	Cmd    *exec.Cmd
 	Stdin  io.WriteCloser
 	Stdout io.ReadCloser
+
+	// cancel releases the timeout of the command
+	cancel context.CancelFunc
 }
 
 // singleton is the singleton instance of KelpOS
@@ -78,10 +95,9 @@ func makeKelpOS() *KelpOS {
 	dotKelpWorkingDir := usrHomeDir.Join(dotKelpDir)
 	log.Printf("dotKelpWorkingDir initialized: %s", dotKelpWorkingDir.AsString())
 	// manually make dotKelpWorkingDir so we can use it as the working dir for kelpos
-	mkDotKelpWorkingDir := fmt.Sprintf("mkdir -p %s", dotKelpWorkingDir.Unix())
-	e = exec.Command("bash", "-c", mkDotKelpWorkingDir).Run()
+	e = os.MkdirAll(dotKelpWorkingDir.Native(), 0755)
 	if e != nil {
-		panic(fmt.Errorf("could not run raw command 'bash -c %s': %s", mkDotKelpWorkingDir, e))
+		panic(fmt.Errorf("could not make dotKelpWorkingDir (%s): %s", dotKelpWorkingDir.AsString(), e))
 	}
 
 	// using dotKelpWorkingDir as working directory since all our config files and log files are located in here and we want
@@ -2,21 +2,30 @@ package kelpos
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"io/ioutil"
 	"log"
-	"os/exec"
+	"os"
 
 	"github.com/nikhilsaraf/go-tools/multithreading"
 )
 
-// StreamOutput runs the provided command in a streaming fashion
-func (kos *KelpOS) StreamOutput(command *exec.Cmd) error {
+// StreamOutput runs the provided command in a streaming fashion, logging every line of its output, the command is killed when the
+// context is cancelled
+func (kos *KelpOS) StreamOutput(ctx context.Context, c *Command) error {
+	command, cancel := kos.toExecCmd(ctx, c, false)
+	defer cancel()
+	log.Printf("process.StreamOutput is executing command: '%s' from directory '%s'", c.String(), command.Dir)
+
 	stdout, e := command.StdoutPipe()
 	if e != nil {
 		return fmt.Errorf("error while creating Stdout pipe: %s", e)
 	}
-	command.Start()
+	e = command.Start()
+	if e != nil {
+		return fmt.Errorf("could not start command '%s': %s", c.String(), e)
+	}
 
 	scanner := bufio.NewScanner(stdout)
 	scanner.Split(bufio.ScanLines)
@@ -27,7 +36,7 @@ func (kos *KelpOS) StreamOutput(command *exec.Cmd) error {
 
 	e = command.Wait()
 	if e != nil {
-		return fmt.Errorf("could not execute command: %s", e)
+		return fmt.Errorf("could not execute command '%s': %s", c.String(), e)
 	}
 	return nil
 }
@@ -51,18 +60,19 @@ func (kos *KelpOS) Stop(userID string, namespace string) error {
 	return fmt.Errorf("process with userID '%s' and namespace '%s' does not exist", userID, namespace)
 }
 
-// Blocking runs a bash command and blocks
-func (kos *KelpOS) Blocking(userID string, namespace string, cmd string) ([]byte, error) {
-	p, e := kos.Background(userID, namespace, cmd)
+// Blocking runs the command and blocks until it finishes, returning its output
+func (kos *KelpOS) Blocking(userID string, namespace string, c *Command) ([]byte, error) {
+	p, e := kos.Background(userID, namespace, c)
 	if e != nil {
-		return nil, fmt.Errorf("could not run bash command in background '%s': %s", cmd, e)
+		return nil, fmt.Errorf("could not run command in background '%s': %s", c.String(), e)
 	}
+	defer p.cancel()
 
 	// defer unregistration of process because regardless of whether it succeeds or fails it will not be active on the system anymore
 	defer func() {
 		eInner := kos.Unregister(userID, namespace)
 		if eInner != nil {
-			log.Fatalf("error unregistering bash command '%s': %s", cmd, eInner)
+			log.Fatalf("error unregistering command '%s': %s", c.String(), eInner)
 		}
 	}()
 
@@ -86,44 +96,49 @@ func (kos *KelpOS) Blocking(userID string, namespace string, cmd string) ([]byte
 
 	// now check for errors
 	if eWait != nil || eRead != nil {
-		return nil, fmt.Errorf("error in bash command '%s' for userID '%s' and namespace '%s': (eWait=%s, outputBytes=%s, eRead=%v)",
-			cmd, userID, namespace, eWait, string(outputBytes), eRead)
+		return nil, fmt.Errorf("error in command '%s' for userID '%s' and namespace '%s': (eWait=%s, outputBytes=%s, eRead=%v)",
+			c.String(), userID, namespace, eWait, string(outputBytes), eRead)
 	}
 
 	return outputBytes, nil
 }
 
-// Background runs the provided bash command in the background and registers the command
-func (kos *KelpOS) Background(userID string, namespace string, cmd string) (*Process, error) {
-	c := exec.Command("bash", "-c", cmd)
-	// always execute commands from the working directory (specify as native since underlying OS handles it)
-	// using dotKelpWorkingDir as working directory since all our config files and log files are located in here and we want
-	// to have the shortest path lengths to accommodate for the 260 character file path limit in windows
-	c.Dir = kos.dotKelpWorkingDir.Native()
-	log.Printf("process.Background is executing command: '%s' from directory '%s'", c.String(), c.Dir)
+// Background runs the provided command in the background and registers the command, the command is killed once it runs longer than
+// its Timeout. On windows the command is run through bash -c, see runsUnderBash
+func (kos *KelpOS) Background(userID string, namespace string, c *Command) (*Process, error) {
+	cmd, cancel := kos.toExecCmd(context.Background(), c, runsUnderBash)
+	log.Printf("process.Background is executing command: '%s' from directory '%s'", c.String(), cmd.Dir)
 
-	stdinWriter, e := c.StdinPipe()
+	stdinWriter, e := cmd.StdinPipe()
 	if e != nil {
-		return nil, fmt.Errorf("could not get Stdin pipe for bash command '%s': %s", cmd, e)
+		cancel()
+		return nil, fmt.Errorf("could not get Stdin pipe for command '%s': %s", c.String(), e)
 	}
-	stdoutReader, e := c.StdoutPipe()
+	stdoutReader, e := cmd.StdoutPipe()
 	if e != nil {
-		return nil, fmt.Errorf("could not get Stdout pipe for bash command '%s': %s", cmd, e)
+		cancel()
+		return nil, fmt.Errorf("could not get Stdout pipe for command '%s': %s", c.String(), e)
 	}
 
-	e = c.Start()
+	e = cmd.Start()
 	if e != nil {
-		return nil, fmt.Errorf("could not start bash command '%s': %s", cmd, e)
+		cancel()
+		return nil, fmt.Errorf("could not start command '%s': %s", c.String(), e)
 	}
 
 	p := &Process{
-		Cmd:    c,
+		Cmd:    cmd,
 		Stdin:  stdinWriter,
 		Stdout: stdoutReader,
+		cancel: cancel,
 	}
 	e = kos.register(userID, namespace, p)
 	if e != nil {
-		return nil, fmt.Errorf("error registering bash command '%s': %s", cmd, e)
+		// nothing can stop the command once it is not registered so kill it and release its resources
+		cancel()
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return nil, fmt.Errorf("error registering command '%s': %s", c.String(), e)
 	}
 
 	return p, nil
@@ -180,11 +195,11 @@ func (kos *KelpOS) RegisteredProcesses() []string {
 	return list
 }
 
-// Mkdir function with a neat error message
+// Mkdir function with a neat error message, it makes any missing parent directories
 func (kos *KelpOS) Mkdir(userID string, dirPath *OSPath) error {
-	_, e := kos.Blocking(userID, "mkdir", fmt.Sprintf("mkdir -p %s", dirPath.Unix()))
+	e := os.MkdirAll(dirPath.Native(), 0755)
 	if e != nil {
-		return fmt.Errorf("error running mkdir command for dir (%s): %s", dirPath.AsString(), e)
+		return fmt.Errorf("error making dir (%s) for userID '%s': %s", dirPath.AsString(), userID, e)
 	}
 	return nil
 }
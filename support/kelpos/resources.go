@@ -0,0 +1,153 @@
+package kelpos
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// ProcessResourceUsage is the resource usage of a registered process, summed over the process and all of its descendants because
+// commands are run through bash
+type ProcessResourceUsage struct {
+	UserID         string
+	Namespace      string
+	PID            int
+	NumProcesses   int
+	CPUTimeSeconds float64 // cumulative CPU time used since the processes were started
+	MemoryRSSBytes uint64
+}
+
+// psRow is a single process listed by the ps command
+type psRow struct {
+	pid            int
+	ppid           int
+	cpuTimeSeconds float64
+	rssKB          uint64
+}
+
+// RegisteredProcessesResourceUsage returns the resource usage of all registered processes using a single invocation of ps
+func (kos *KelpOS) RegisteredProcessesResourceUsage() ([]ProcessResourceUsage, error) {
+	output, e := exec.Command("ps", "-A", "-o", "pid=", "-o", "ppid=", "-o", "time=", "-o", "rss=").Output()
+	if e != nil {
+		return nil, fmt.Errorf("could not run ps command: %s", e)
+	}
+	rows, e := parsePsOutput(output)
+	if e != nil {
+		return nil, fmt.Errorf("could not parse output of ps command: %s", e)
+	}
+
+	kos.processLock.Lock()
+	defer kos.processLock.Unlock()
+
+	usages := []ProcessResourceUsage{}
+	for key, p := range kos.processes {
+		if p.Cmd == nil || p.Cmd.Process == nil {
+			continue
+		}
+		parts := strings.SplitN(key, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		usage := aggregateProcessTree(rows, p.Cmd.Process.Pid)
+		usage.UserID = parts[0]
+		usage.Namespace = parts[1]
+		usages = append(usages, usage)
+	}
+	return usages, nil
+}
+
+// parsePsOutput parses lines of the format "pid ppid time rss"
+func parsePsOutput(output []byte) ([]psRow, error) {
+	rows := []psRow{}
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 4 {
+			return nil, fmt.Errorf("expected 4 fields in line '%s' but found %d", scanner.Text(), len(fields))
+		}
+
+		pid, e := strconv.Atoi(fields[0])
+		if e != nil {
+			return nil, fmt.Errorf("invalid pid '%s': %s", fields[0], e)
+		}
+		ppid, e := strconv.Atoi(fields[1])
+		if e != nil {
+			return nil, fmt.Errorf("invalid ppid '%s': %s", fields[1], e)
+		}
+		cpuTimeSeconds, e := parsePsTime(fields[2])
+		if e != nil {
+			return nil, fmt.Errorf("invalid cpu time '%s': %s", fields[2], e)
+		}
+		rssKB, e := strconv.ParseUint(fields[3], 10, 64)
+		if e != nil {
+			return nil, fmt.Errorf("invalid rss '%s': %s", fields[3], e)
+		}
+		rows = append(rows, psRow{
+			pid:            pid,
+			ppid:           ppid,
+			cpuTimeSeconds: cpuTimeSeconds,
+			rssKB:          rssKB,
+		})
+	}
+	return rows, scanner.Err()
+}
+
+// parsePsTime parses the cumulative cpu time from ps, which is formatted as [[dd-]hh:]mm:ss[.ff]
+func parsePsTime(s string) (float64, error) {
+	days := 0.0
+	if i := strings.Index(s, "-"); i >= 0 {
+		d, e := strconv.ParseFloat(s[:i], 64)
+		if e != nil {
+			return 0, e
+		}
+		days = d
+		s = s[i+1:]
+	}
+
+	seconds := 0.0
+	for _, part := range strings.Split(s, ":") {
+		v, e := strconv.ParseFloat(part, 64)
+		if e != nil {
+			return 0, e
+		}
+		seconds = seconds*60 + v
+	}
+	return days*24*60*60 + seconds, nil
+}
+
+// aggregateProcessTree sums the usage of the process with rootPID and all of its descendants
+func aggregateProcessTree(rows []psRow, rootPID int) ProcessResourceUsage {
+	children := map[int][]psRow{}
+	var root *psRow
+	for i, r := range rows {
+		children[r.ppid] = append(children[r.ppid], r)
+		if r.pid == rootPID {
+			root = &rows[i]
+		}
+	}
+
+	usage := ProcessResourceUsage{PID: rootPID}
+	if root == nil {
+		// the process has exited
+		return usage
+	}
+
+	queue := []psRow{*root}
+	for len(queue) > 0 {
+		r := queue[0]
+		queue = queue[1:]
+
+		usage.NumProcesses++
+		usage.CPUTimeSeconds += r.cpuTimeSeconds
+		usage.MemoryRSSBytes += r.rssKB * 1024
+		queue = append(queue, children[r.pid]...)
+	}
+	return usage
+}
@@ -0,0 +1,60 @@
+package kelpos
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePsTime(t *testing.T) {
+	testCases := []struct {
+		input string
+		want  float64
+	}{
+		{input: "00:05", want: 5},
+		{input: "01:05", want: 65},
+		{input: "0:01.50", want: 1.5},
+		{input: "02:01:05", want: 7265},
+		{input: "1-00:00:10", want: 86410},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.input, func(t *testing.T) {
+			got, e := parsePsTime(k.input)
+			if assert.NoError(t, e) {
+				assert.InDelta(t, k.want, got, 0.0000001)
+			}
+		})
+	}
+
+	_, e := parsePsTime("ab:cd")
+	assert.Error(t, e)
+}
+
+func TestAggregateProcessTree(t *testing.T) {
+	output := []byte(`
+    1     0 00:00:30  1000
+  100     1 00:00:01   100
+  101   100 00:01:00  2048
+  102   101 00:00:10  1024
+  200     1 00:10:00  9999
+`)
+	rows, e := parsePsOutput(output)
+	if !assert.NoError(t, e) || !assert.Equal(t, 5, len(rows)) {
+		return
+	}
+
+	usage := aggregateProcessTree(rows, 100)
+	assert.Equal(t, 100, usage.PID)
+	assert.Equal(t, 3, usage.NumProcesses)
+	assert.InDelta(t, 71.0, usage.CPUTimeSeconds, 0.0000001)
+	assert.Equal(t, uint64(3172*1024), usage.MemoryRSSBytes)
+
+	// a process that exited has no usage
+	usage = aggregateProcessTree(rows, 300)
+	assert.Equal(t, 0, usage.NumProcesses)
+	assert.Equal(t, uint64(0), usage.MemoryRSSBytes)
+
+	_, e = parsePsOutput([]byte("1 0 00:00:01"))
+	assert.Error(t, e)
+}
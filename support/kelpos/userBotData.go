@@ -49,6 +49,13 @@ func (ubd *UserBotData) SafeUnregisterBot(botName string) {
 	} else {
 		log.Printf("no bot registered with name '%s'", botName)
 	}
+
+	if ubd.kos.stateStore != nil {
+		e := ubd.kos.stateStore.Delete(ubd.botStateKey(botName))
+		if e != nil {
+			log.Printf("unable to delete the persisted state of bot '%s': %s", botName, e)
+		}
+	}
 }
 
 // RegisterBotWithState registers a new bot with a given state, returning an error if one already exists with the same name
@@ -74,6 +81,8 @@ func (ubd *UserBotData) registerBotWithState(bot *model2.Bot, state BotState, fo
 		log.Printf("bot '%s' already registered, but re-registering with state '%s' because forceRegister was set", bot.Name, state)
 	}
 
+	// the state is not persisted here because bots are re-registered with the state of their process when they are listed, which would
+	// overwrite the state that the bot was in before the server was restarted
 	ubd.bots[bot.Name] = &BotInstance{
 		Bot:   bot,
 		State: state,
@@ -102,6 +111,7 @@ func (ubd *UserBotData) AdvanceBotState(botName string, expectedCurrentState Bot
 
 	b.State = ns
 	log.Printf("advanced bot state for bot '%s' to %s\n", botName, ns)
+	ubd.persistBotState(botName, ns)
 
 	return nil
 }
@@ -128,15 +138,22 @@ func (ubd *UserBotData) QueryBotState(botName string) (BotState, error) {
 	}
 
 	prefix := getBotNamePrefix(botName)
-	command := fmt.Sprintf("ps aux | grep trade | grep %s | grep -v grep", prefix)
-	outputBytes, e := ubd.kos.Blocking(ubd.user.ID, fmt.Sprintf("query_bot_state: %s", botName), command)
+	outputBytes, e := ubd.kos.Blocking(ubd.user.ID, fmt.Sprintf("query_bot_state: %s", botName), MakeCommand("ps", "aux"))
 	if e != nil {
-		if strings.Contains(e.Error(), "exit status 1") {
-			return BotStateStopped, nil
+		return InitState(), fmt.Errorf("error querying bot state using command 'ps aux': %s", e)
+	}
+
+	// find the process of the bot in the output instead of piping it through grep so the bot name is never passed to a shell
+	lines := []string{}
+	for _, line := range strings.Split(string(outputBytes), "\n") {
+		if strings.Contains(line, "trade") && strings.Contains(line, prefix) {
+			lines = append(lines, line)
 		}
-		return InitState(), fmt.Errorf("error querying bot state using command '%s': %s", command, e)
 	}
-	output := strings.TrimSpace(string(outputBytes))
+	output := strings.TrimSpace(strings.Join(lines, "\n"))
+	if output == "" {
+		return BotStateStopped, nil
+	}
 
 	if strings.Contains(output, "delete") {
 		return BotStateStopping, nil
@@ -153,6 +170,41 @@ func (ubd *UserBotData) RegisteredBots() []string {
 	return list
 }
 
+// PersistedBotState returns the last state that the bot was advanced to and that was persisted in the state store of KelpOS, which can be
+// from before the server was restarted, and whether a state was found
+func (ubd *UserBotData) PersistedBotState(botName string) (BotState, bool, error) {
+	if ubd.kos.stateStore == nil {
+		return InitState(), false, nil
+	}
+
+	value, found, e := ubd.kos.stateStore.Get(ubd.botStateKey(botName))
+	if e != nil || !found {
+		return InitState(), false, e
+	}
+	bs, e := parseBotState(value)
+	if e != nil {
+		return InitState(), false, fmt.Errorf("persisted state of bot '%s' is invalid: %s", botName, e)
+	}
+	return bs, true, nil
+}
+
+// persistBotState writes the state of the bot to the state store of KelpOS, errors are only logged since the state in memory is the source
+// of truth while the server is running
+func (ubd *UserBotData) persistBotState(botName string, state BotState) {
+	if ubd.kos.stateStore == nil {
+		return
+	}
+
+	e := ubd.kos.stateStore.Set(ubd.botStateKey(botName), state.String())
+	if e != nil {
+		log.Printf("unable to persist state '%s' of bot '%s': %s", state, botName, e)
+	}
+}
+
+func (ubd *UserBotData) botStateKey(botName string) string {
+	return fmt.Sprintf("bot_states/%s/%s", ubd.user.ID, botName)
+}
+
 // getBotNamePrefix returns the general prefix for filenames associated with a botName
 func getBotNamePrefix(botName string) string {
 	return strings.ToLower(strings.Replace(botName, " ", "_", -1))
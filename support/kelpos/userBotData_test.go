@@ -0,0 +1,45 @@
+package kelpos
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stellar/kelp/gui/model2"
+	"github.com/stellar/kelp/support/statestore"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPersistedBotStateSurvivesRegistration(t *testing.T) {
+	dir, e := ioutil.TempDir("", "kelpos_state")
+	if !assert.NoError(t, e) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	store := statestore.MakeFileStore(dir)
+
+	kos := &KelpOS{stateStore: store}
+	ubd := makeUserBotData(kos, MakeUser("user1"))
+	bot := &model2.Bot{Name: "Mary The Calm Whale"}
+	if !assert.NoError(t, ubd.RegisterBotWithState(bot, BotStateStopped)) {
+		return
+	}
+	if !assert.NoError(t, ubd.AdvanceBotState(bot.Name, BotStateStopped)) {
+		return
+	}
+
+	// the server restarted and lists the bot, whose process is not running anymore
+	restartedUbd := makeUserBotData(&KelpOS{stateStore: store}, MakeUser("user1"))
+	restartedUbd.RegisterBotWithStateUpsert(bot, BotStateStopped)
+	state, found, e := restartedUbd.PersistedBotState(bot.Name)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.True(t, found)
+	assert.Equal(t, BotStateRunning, state)
+
+	restartedUbd.SafeUnregisterBot(bot.Name)
+	_, found, e = restartedUbd.PersistedBotState(bot.Name)
+	assert.NoError(t, e)
+	assert.False(t, found)
+}
@@ -17,9 +17,20 @@ type Logger interface {
 	Errorf(msg string, args ...interface{})
 }
 
+// flushFns are called by Fatal before it exits the process
+var flushFns []func()
+
+// AddFlushFn registers a function that is called by Fatal before it exits the process, used by log outputs that buffer their lines
+func AddFlushFn(fn func()) {
+	flushFns = append(flushFns, fn)
+}
+
 // Fatal is a convenience method that can be used with any Logger to log a fatal error
 func Fatal(l Logger, e error) {
 	l.Info("")
 	l.Errorf("%s", e)
+	for _, fn := range flushFns {
+		fn()
+	}
 	os.Exit(1)
 }
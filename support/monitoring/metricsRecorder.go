@@ -1,9 +1,14 @@
 package monitoring
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"sync"
+)
 
 // MetricsRecorder uses a map to store metrics and implements the api.Metrics interface.
+// It is safe to update the metrics while they are being served.
 type metricsRecorder struct {
+	mutex   sync.Mutex // zero value is ready to use
 	records map[string]interface{}
 }
 
@@ -25,6 +30,9 @@ func MakeMetricsRecorder(records map[string]interface{}) (Metrics, error) {
 // UpdateMetrics updates (or adds if non-existent) metrics in the records for all key-value
 // pairs in the provided map of metrics.
 func (m *metricsRecorder) UpdateMetrics(metrics map[string]interface{}) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
 	for k, v := range metrics {
 		m.records[k] = v
 	}
@@ -32,5 +40,8 @@ func (m *metricsRecorder) UpdateMetrics(metrics map[string]interface{}) {
 
 // MarshalJSON gives the JSON representation of the records.
 func (m *metricsRecorder) MarshalJSON() ([]byte, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
 	return json.Marshal(m.records)
 }
@@ -0,0 +1,175 @@
+package networking
+
+import (
+	"fmt"
+	"log"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultInjectedTimeout is how long an injected timeout blocks when the timeout duration is not specified
+const defaultInjectedTimeout = 30 * time.Second
+
+// FaultInjectionConfig specifies the faults that are injected into the requests made to an external service
+type FaultInjectionConfig struct {
+	Latency     time.Duration // added to every request
+	Jitter      time.Duration // random extra latency in [0, Jitter) added to every request
+	Timeout     time.Duration // how long a request blocks before it fails with an injected timeout
+	TimeoutRate float64       // fraction of requests that fail with an injected timeout
+	ErrorRate   float64       // fraction of requests that fail right away with an injected error
+}
+
+// String is the Stringer method
+func (c FaultInjectionConfig) String() string {
+	return fmt.Sprintf("FaultInjectionConfig[Latency=%s, Jitter=%s, Timeout=%s, TimeoutRate=%.4f, ErrorRate=%.4f]",
+		c.Latency, c.Jitter, c.Timeout, c.TimeoutRate, c.ErrorRate)
+}
+
+// ParseFaultInjectionConfig parses a comma-separated list of key=value pairs, for example:
+// "latency=500ms,jitter=250ms,timeout=10s,timeout_rate=0.05,error_rate=0.1"
+func ParseFaultInjectionConfig(spec string) (*FaultInjectionConfig, error) {
+	config := &FaultInjectionConfig{}
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		parts := strings.Split(pair, "=")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid fault injection setting '%s', needs to be in the format key=value", pair)
+		}
+		key, value := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+
+		var e error
+		switch key {
+		case "latency":
+			config.Latency, e = time.ParseDuration(value)
+		case "jitter":
+			config.Jitter, e = time.ParseDuration(value)
+		case "timeout":
+			config.Timeout, e = time.ParseDuration(value)
+		case "timeout_rate":
+			config.TimeoutRate, e = strconv.ParseFloat(value, 64)
+		case "error_rate":
+			config.ErrorRate, e = strconv.ParseFloat(value, 64)
+		default:
+			return nil, fmt.Errorf("invalid fault injection key '%s', needs to be one of [latency, jitter, timeout, timeout_rate, error_rate]", key)
+		}
+		if e != nil {
+			return nil, fmt.Errorf("could not parse value '%s' of fault injection key '%s': %s", value, key, e)
+		}
+	}
+
+	if config.Latency < 0 || config.Jitter < 0 || config.Timeout < 0 {
+		return nil, fmt.Errorf("invalid fault injection config, durations need to be >= 0: %s", config)
+	}
+	if config.TimeoutRate < 0.0 || config.ErrorRate < 0.0 || config.TimeoutRate+config.ErrorRate > 1.0 {
+		return nil, fmt.Errorf("invalid fault injection config, rates need to be >= 0.0 and add up to <= 1.0: %s", config)
+	}
+	if config.TimeoutRate > 0.0 && config.Timeout == 0 {
+		config.Timeout = defaultInjectedTimeout
+	}
+	return config, nil
+}
+
+// FaultInjectionError is the error returned for an injected fault
+type FaultInjectionError struct {
+	label     string
+	isTimeout bool
+}
+
+// Error impl.
+func (e *FaultInjectionError) Error() string {
+	if e.isTimeout {
+		return fmt.Sprintf("injected fault: timeout on '%s'", e.label)
+	}
+	return fmt.Sprintf("injected fault: error on '%s'", e.label)
+}
+
+// Timeout returns true for injected timeouts so the error can be handled like a net.Error
+func (e *FaultInjectionError) Timeout() bool {
+	return e.isTimeout
+}
+
+// Temporary is true since injected faults do not depend on the request
+func (e *FaultInjectionError) Temporary() bool {
+	return true
+}
+
+// FaultInjector adds latency to requests and fails a fraction of them so operators can see how their bots behave under degraded infrastructure
+type FaultInjector struct {
+	config FaultInjectionConfig
+	mutex  sync.Mutex
+	random *rand.Rand
+	sleep  func(d time.Duration)
+}
+
+// MakeFaultInjector is a factory method
+func MakeFaultInjector(config FaultInjectionConfig, seed int64) *FaultInjector {
+	return &FaultInjector{
+		config: config,
+		random: rand.New(rand.NewSource(seed)),
+		sleep:  time.Sleep,
+	}
+}
+
+// Inject is called before each request with a label that identifies the request, it blocks for the injected latency and returns a
+// *FaultInjectionError when the request should fail
+func (fi *FaultInjector) Inject(label string) error {
+	// rand.Rand is not safe for concurrent use
+	fi.mutex.Lock()
+	delay := fi.config.Latency
+	if fi.config.Jitter > 0 {
+		delay += time.Duration(fi.random.Int63n(int64(fi.config.Jitter)))
+	}
+	r := fi.random.Float64()
+	fi.mutex.Unlock()
+
+	if delay > 0 {
+		fi.sleep(delay)
+	}
+
+	if r < fi.config.TimeoutRate {
+		log.Printf("fault injection: timing out '%s' after %s (latency=%s)\n", label, fi.config.Timeout, delay)
+		fi.sleep(fi.config.Timeout)
+		return &FaultInjectionError{label: label, isTimeout: true}
+	}
+	if r < fi.config.TimeoutRate+fi.config.ErrorRate {
+		log.Printf("fault injection: failing '%s' (latency=%s)\n", label, delay)
+		return &FaultInjectionError{label: label, isTimeout: false}
+	}
+	return nil
+}
+
+// faultInjectingTransport is an http.RoundTripper that injects faults before delegating to the inner RoundTripper
+type faultInjectingTransport struct {
+	inner  http.RoundTripper
+	fi     *FaultInjector
+	prefix string
+}
+
+// RoundTrip impl.
+func (t *faultInjectingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	e := t.fi.Inject(fmt.Sprintf("%s %s %s", t.prefix, req.Method, req.URL.Path))
+	if e != nil {
+		return nil, e
+	}
+	return t.inner.RoundTrip(req)
+}
+
+// MakeFaultInjectingHTTPClient makes an http.Client that injects faults into every request it makes, prefix is used in the logs to identify
+// the service that the client talks to
+func MakeFaultInjectingHTTPClient(fi *FaultInjector, prefix string) *http.Client {
+	return &http.Client{
+		Transport: &faultInjectingTransport{
+			inner:  http.DefaultTransport,
+			fi:     fi,
+			prefix: prefix,
+		},
+	}
+}
@@ -0,0 +1,127 @@
+package networking
+
+import (
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFaultInjectionConfig(t *testing.T) {
+	testCases := []struct {
+		spec      string
+		want      *FaultInjectionConfig
+		wantError bool
+	}{
+		{
+			spec: "",
+			want: &FaultInjectionConfig{},
+		}, {
+			spec: "latency=500ms, jitter=250ms,timeout=10s,timeout_rate=0.05,error_rate=0.1",
+			want: &FaultInjectionConfig{
+				Latency:     500 * time.Millisecond,
+				Jitter:      250 * time.Millisecond,
+				Timeout:     10 * time.Second,
+				TimeoutRate: 0.05,
+				ErrorRate:   0.1,
+			},
+		}, {
+			spec: "timeout_rate=0.5",
+			want: &FaultInjectionConfig{Timeout: defaultInjectedTimeout, TimeoutRate: 0.5},
+		}, {
+			spec:      "latency",
+			wantError: true,
+		}, {
+			spec:      "latency=fast",
+			wantError: true,
+		}, {
+			spec:      "drop_rate=0.1",
+			wantError: true,
+		}, {
+			spec:      "latency=-1s",
+			wantError: true,
+		}, {
+			spec:      "timeout_rate=0.6,error_rate=0.6",
+			wantError: true,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.spec, func(t *testing.T) {
+			actual, e := ParseFaultInjectionConfig(k.spec)
+			if k.wantError {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, k.want, actual)
+		})
+	}
+}
+
+func TestFaultInjectorInject(t *testing.T) {
+	testCases := []struct {
+		config      FaultInjectionConfig
+		wantError   bool
+		wantTimeout bool
+		wantSlept   time.Duration
+	}{
+		{
+			config:    FaultInjectionConfig{Latency: time.Second},
+			wantSlept: time.Second,
+		}, {
+			config:    FaultInjectionConfig{Latency: time.Second, ErrorRate: 1.0},
+			wantError: true,
+			wantSlept: time.Second,
+		}, {
+			config:      FaultInjectionConfig{Timeout: 5 * time.Second, TimeoutRate: 1.0},
+			wantError:   true,
+			wantTimeout: true,
+			wantSlept:   5 * time.Second,
+		},
+	}
+
+	for i, k := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			slept := time.Duration(0)
+			fi := MakeFaultInjector(k.config, 1)
+			fi.sleep = func(d time.Duration) {
+				slept += d
+			}
+
+			e := fi.Inject("test")
+			assert.Equal(t, k.wantSlept, slept)
+			if !k.wantError {
+				assert.NoError(t, e)
+				return
+			}
+			if !assert.Error(t, e) {
+				return
+			}
+			netError, ok := e.(net.Error)
+			if assert.True(t, ok) {
+				assert.Equal(t, k.wantTimeout, netError.Timeout())
+			}
+		})
+	}
+}
+
+func TestFaultInjectorJitter(t *testing.T) {
+	fi := MakeFaultInjector(FaultInjectionConfig{Latency: time.Second, Jitter: time.Second}, 1)
+	for i := 0; i < 100; i++ {
+		slept := time.Duration(0)
+		fi.sleep = func(d time.Duration) {
+			slept += d
+		}
+
+		e := fi.Inject("test")
+		if !assert.NoError(t, e) {
+			return
+		}
+		assert.True(t, slept >= time.Second && slept < 2*time.Second, "slept for %s", slept)
+	}
+}
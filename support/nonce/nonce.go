@@ -0,0 +1,203 @@
+package nonce
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxClockWait is the longest we wait for the clock to pass the last nonce before giving up, which only happens when the clock moved back
+const maxClockWait = 5 * time.Second
+
+// Service hands out nonces for one API key of an exchange. Nonces are in nanoseconds since the unix epoch and strictly increase across
+// concurrent requests and restarts, because the last nonce is persisted to a file and requests that use a nonce are serialized.
+//
+// Requests are only serialized within one process, the file is not locked. Separate processes that use the same API key can still send
+// requests with nonces that are out of order, so every API key should only be used by one process (use the Registry to share the
+// service within a process). load logs a warning when it finds that another process has written to the file
+type Service struct {
+	name     string
+	filePath string // empty when the nonces are only kept in memory
+	nowFn    func() time.Time
+	sleepFn  func(time.Duration)
+
+	mutex *sync.Mutex
+	last  int64
+}
+
+// makeService is a factory method, filePath can be empty to only keep the nonces in memory
+func makeService(name string, filePath string, nowFn func() time.Time, sleepFn func(time.Duration)) (*Service, error) {
+	s := &Service{
+		name:     name,
+		filePath: filePath,
+		nowFn:    nowFn,
+		sleepFn:  sleepFn,
+		mutex:    &sync.Mutex{},
+		last:     0,
+	}
+	e := s.load()
+	if e != nil {
+		return nil, e
+	}
+	return s, nil
+}
+
+// Next returns a new nonce for clients that accept the nonce as a param. The nonce follows the clock and is always greater than the last nonce
+func (s *Service) Next() (int64, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	e := s.load()
+	if e != nil {
+		return 0, e
+	}
+	n := s.nowFn().UnixNano()
+	if n <= s.last {
+		n = s.last + 1
+	}
+	e = s.save(n)
+	if e != nil {
+		return 0, e
+	}
+	return n, nil
+}
+
+// Acquire is for clients that make the nonce from the clock (in nanoseconds or a coarser unit) when they send a request, such as the kraken
+// client. It waits until the clock is past the last nonce and holds the service until release is called after the request is done, so no
+// other request of this process can use a nonce in between. release records the time at which it is called as the last nonce
+func (s *Service) Acquire() (release func(), err error) {
+	s.mutex.Lock()
+
+	e := s.load()
+	if e != nil {
+		s.mutex.Unlock()
+		return nil, e
+	}
+	wait := time.Duration(s.last - s.nowFn().UnixNano() + 1)
+	if wait > maxClockWait {
+		s.mutex.Unlock()
+		return nil, fmt.Errorf("the clock is %s behind the last nonce of '%s', check that the clock of this machine is synced", wait, s.name)
+	}
+	if wait > 0 {
+		log.Printf("waiting %s for the clock to pass the last nonce of '%s'\n", wait, s.name)
+		s.sleepFn(wait)
+	}
+
+	return func() {
+		defer s.mutex.Unlock()
+
+		e := s.save(s.nowFn().UnixNano())
+		if e != nil {
+			log.Printf("could not save the last nonce of '%s': %s\n", s.name, e)
+		}
+	}, nil
+}
+
+// load reads the last nonce from the file so a nonce that was used before a restart is not reused, needs to hold the mutex
+func (s *Service) load() error {
+	if s.filePath == "" {
+		return nil
+	}
+
+	bytes, e := ioutil.ReadFile(s.filePath)
+	if os.IsNotExist(e) {
+		return nil
+	} else if e != nil {
+		return fmt.Errorf("could not read nonce file '%s': %s", s.filePath, e)
+	}
+	last, e := strconv.ParseInt(strings.TrimSpace(string(bytes)), 10, 64)
+	if e != nil {
+		return fmt.Errorf("could not parse nonce file '%s': %s", s.filePath, e)
+	}
+	if last > s.last {
+		if s.last != 0 {
+			// we only write to the file while holding the mutex, so a greater value was written by another process
+			log.Printf("warning: the nonce file '%s' of '%s' was updated by another process, use every API key in only one process to avoid invalid nonce errors\n", s.filePath, s.name)
+		}
+		s.last = last
+	}
+	return nil
+}
+
+// save updates the last nonce and writes it to the file, replacing the file so a crash cannot leave a partial value, needs to hold the mutex
+func (s *Service) save(n int64) error {
+	if n > s.last {
+		s.last = n
+	}
+	if s.filePath == "" {
+		return nil
+	}
+
+	tmpPath := s.filePath + ".tmp"
+	e := ioutil.WriteFile(tmpPath, []byte(strconv.FormatInt(s.last, 10)), 0600)
+	if e != nil {
+		return fmt.Errorf("could not write nonce file '%s': %s", tmpPath, e)
+	}
+	e = os.Rename(tmpPath, s.filePath)
+	if e != nil {
+		return fmt.Errorf("could not rename nonce file '%s' to '%s': %s", tmpPath, s.filePath, e)
+	}
+	return nil
+}
+
+// Registry keeps one Service per exchange and API key so every client in the process that uses the same API key shares the nonces
+type Registry struct {
+	dir string // empty when the nonces are only kept in memory
+
+	mutex    *sync.Mutex
+	services map[string]*Service
+}
+
+// MakeRegistry is a factory method for a Registry that persists the nonces to files in dir
+func MakeRegistry(dir string) (*Registry, error) {
+	e := os.MkdirAll(dir, 0700)
+	if e != nil {
+		return nil, fmt.Errorf("could not make nonce dir '%s': %s", dir, e)
+	}
+	return makeRegistry(dir), nil
+}
+
+// MakeMemoryRegistry is a factory method for a Registry that only keeps the nonces in memory
+func MakeMemoryRegistry() *Registry {
+	return makeRegistry("")
+}
+
+func makeRegistry(dir string) *Registry {
+	return &Registry{
+		dir:      dir,
+		mutex:    &sync.Mutex{},
+		services: map[string]*Service{},
+	}
+}
+
+// Service returns the nonce service of the API key of an exchange
+func (r *Registry) Service(exchangeName string, apiKey string) (*Service, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	// the API key is hashed so it is not written to the file system
+	hash := sha256.Sum256([]byte(apiKey))
+	name := fmt.Sprintf("%s_%s", exchangeName, hex.EncodeToString(hash[:8]))
+	if s, ok := r.services[name]; ok {
+		return s, nil
+	}
+
+	filePath := ""
+	if r.dir != "" {
+		filePath = filepath.Join(r.dir, name+".nonce")
+	}
+	s, e := makeService(name, filePath, time.Now, time.Sleep)
+	if e != nil {
+		return nil, fmt.Errorf("could not make nonce service for exchange '%s': %s", exchangeName, e)
+	}
+	r.services[name] = s
+	return s, nil
+}
@@ -0,0 +1,148 @@
+package nonce
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeClock is a clock that only moves when it is set or when sleeping
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Sleep(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func TestNext(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	s, e := makeService("test", "", clock.Now, clock.Sleep)
+	if !assert.NoError(t, e) {
+		return
+	}
+
+	n1, e := s.Next()
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, int64(1000000000000), n1)
+
+	// the clock did not move
+	n2, e := s.Next()
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, n1+1, n2)
+
+	// the clock moved back
+	clock.now = time.Unix(999, 0)
+	n3, e := s.Next()
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, n2+1, n3)
+
+	clock.now = time.Unix(1001, 0)
+	n4, e := s.Next()
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, int64(1001000000000), n4)
+}
+
+func TestNext_Persisted(t *testing.T) {
+	dir, e := ioutil.TempDir("", "nonce")
+	if !assert.NoError(t, e) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	filePath := filepath.Join(dir, "test.nonce")
+
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	s, e := makeService("test", filePath, clock.Now, clock.Sleep)
+	if !assert.NoError(t, e) {
+		return
+	}
+	n1, e := s.Next()
+	if !assert.NoError(t, e) {
+		return
+	}
+
+	// a restart with a clock that moved back continues from the persisted nonce
+	clock.now = time.Unix(500, 0)
+	restarted, e := makeService("test", filePath, clock.Now, clock.Sleep)
+	if !assert.NoError(t, e) {
+		return
+	}
+	n2, e := restarted.Next()
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, n1+1, n2)
+}
+
+func TestAcquire(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	s, e := makeService("test", "", clock.Now, clock.Sleep)
+	if !assert.NoError(t, e) {
+		return
+	}
+
+	release, e := s.Acquire()
+	if !assert.NoError(t, e) {
+		return
+	}
+	clock.now = time.Unix(1002, 0)
+	release()
+	assert.Equal(t, int64(1002000000000), s.last)
+
+	// the clock moved back by 1s, so we wait until it is past the last nonce
+	clock.now = time.Unix(1001, 0)
+	release, e = s.Acquire()
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.True(t, clock.now.UnixNano() > int64(1002000000000))
+	release()
+
+	// the clock moved back by more than maxClockWait
+	clock.now = time.Unix(900, 0)
+	_, e = s.Acquire()
+	assert.Error(t, e)
+
+	// the service is not held after an error
+	clock.now = time.Unix(1010, 0)
+	release, e = s.Acquire()
+	if !assert.NoError(t, e) {
+		return
+	}
+	release()
+}
+
+func TestRegistry(t *testing.T) {
+	r := MakeMemoryRegistry()
+
+	s1, e := r.Service("kraken", "key1")
+	if !assert.NoError(t, e) {
+		return
+	}
+	s2, e := r.Service("kraken", "key1")
+	if !assert.NoError(t, e) {
+		return
+	}
+	s3, e := r.Service("kraken", "key2")
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.True(t, s1 == s2)
+	assert.False(t, s1 == s3)
+}
@@ -2,35 +2,41 @@ package prefs
 
 import (
 	"fmt"
-	"os"
+	"path/filepath"
+
+	"github.com/stellar/kelp/support/statestore"
 )
 
 // Preferences denotes a preferences file
 type Preferences struct {
-	filepath string
+	store statestore.Store
+	key   string
+}
+
+// Make creates a new Preferences struct that is saved in a file
+func Make(filePath string) *Preferences {
+	return MakeWithStore(statestore.MakeFileStore(filepath.Dir(filePath)), filepath.Base(filePath))
 }
 
-// Make creates a new Preferences struct
-func Make(filepath string) *Preferences {
+// MakeWithStore creates a new Preferences struct that is saved under the key in the store
+func MakeWithStore(store statestore.Store, key string) *Preferences {
 	return &Preferences{
-		filepath: filepath,
+		store: store,
+		key:   key,
 	}
 }
 
-// FirstTime checks for the existence of the file
+// FirstTime checks for the existence of the preferences, an error reading them is treated as the first time
 func (p *Preferences) FirstTime() bool {
-	if _, e := os.Stat(p.filepath); os.IsNotExist(e) {
-		return true
-	}
-	return false
+	_, found, e := p.store.Get(p.key)
+	return e != nil || !found
 }
 
-// SetNotFirstTime saves a file on the file system to denote that this is not the first time
+// SetNotFirstTime saves the preferences to denote that this is not the first time
 func (p *Preferences) SetNotFirstTime() error {
-	emptyFile, e := os.Create(p.filepath)
+	e := p.store.Set(p.key, "")
 	if e != nil {
-		return fmt.Errorf("could not create file '%s' when setting not first time prefs file: %s", p.filepath, e)
+		return fmt.Errorf("could not save prefs '%s' when setting not first time: %s", p.key, e)
 	}
-	emptyFile.Close()
 	return nil
 }
@@ -0,0 +1,203 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/stellar/kelp/support/networking"
+)
+
+// pollInterval is how often the scheduler checks for tasks that are due
+const pollInterval = time.Second
+
+// TaskFn runs a periodic task, now is the time at which the task was due
+type TaskFn func(now time.Time) error
+
+// TaskStatus is a snapshot of a task so it can be observed via the API
+type TaskStatus struct {
+	Name            string     `json:"name"`
+	IntervalSeconds float64    `json:"interval_seconds"`
+	NextRun         time.Time  `json:"next_run"`
+	LastRun         *time.Time `json:"last_run,omitempty"`
+	LastDurationMs  int64      `json:"last_duration_ms"`
+	LastError       string     `json:"last_error,omitempty"`
+	NumRuns         int        `json:"num_runs"`
+	NumFailures     int        `json:"num_failures"`
+	Running         bool       `json:"running"`
+}
+
+type task struct {
+	fn     TaskFn
+	status TaskStatus
+}
+
+// Scheduler runs periodic maintenance tasks inside the process one at a time, so they don't need an external cron that touches the files
+// and database of the bot
+type Scheduler struct {
+	lock  *sync.Mutex
+	tasks map[string]*task
+}
+
+// MakeScheduler is a factory method
+func MakeScheduler() *Scheduler {
+	return &Scheduler{
+		lock:  &sync.Mutex{},
+		tasks: map[string]*task{},
+	}
+}
+
+// Register adds a task that runs every interval, the first run is one interval after start
+func (s *Scheduler) Register(name string, interval time.Duration, start time.Time, fn TaskFn) error {
+	if interval <= 0 {
+		return fmt.Errorf("interval of task '%s' needs to be > 0, was %s", name, interval)
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, ok := s.tasks[name]; ok {
+		return fmt.Errorf("task '%s' is already registered", name)
+	}
+	s.tasks[name] = &task{
+		fn: fn,
+		status: TaskStatus{
+			Name:            name,
+			IntervalSeconds: interval.Seconds(),
+			NextRun:         start.Add(interval),
+		},
+	}
+	return nil
+}
+
+// RunNow makes the task due so it runs on the next poll of the scheduler
+func (s *Scheduler) RunNow(name string, now time.Time) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	t, ok := s.tasks[name]
+	if !ok {
+		return fmt.Errorf("task '%s' does not exist", name)
+	}
+	t.status.NextRun = now
+	return nil
+}
+
+// Statuses returns a snapshot of all the tasks sorted by name
+func (s *Scheduler) Statuses() []TaskStatus {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	statuses := []TaskStatus{}
+	for _, t := range s.tasks {
+		statuses = append(statuses, t.status)
+	}
+	sort.Slice(statuses, func(i int, j int) bool {
+		return statuses[i].Name < statuses[j].Name
+	})
+	return statuses
+}
+
+// Run runs the tasks as they become due, it never returns so it should be called in a goroutine
+func (s *Scheduler) Run() {
+	for {
+		s.runDue(time.Now())
+		time.Sleep(pollInterval)
+	}
+}
+
+// runDue runs every task that is due at now, in order of name
+func (s *Scheduler) runDue(now time.Time) {
+	for _, status := range s.Statuses() {
+		if now.Before(status.NextRun) {
+			continue
+		}
+		s.runTask(status.Name, now)
+	}
+}
+
+func (s *Scheduler) runTask(name string, now time.Time) {
+	s.lock.Lock()
+	t := s.tasks[name]
+	t.status.Running = true
+	s.lock.Unlock()
+
+	start := time.Now()
+	e := t.fn(now)
+	duration := time.Since(start)
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	t.status.Running = false
+	t.status.LastRun = &now
+	t.status.LastDurationMs = duration.Nanoseconds() / int64(time.Millisecond)
+	t.status.NumRuns++
+	t.status.LastError = ""
+	if e != nil {
+		t.status.NumFailures++
+		t.status.LastError = e.Error()
+		log.Printf("scheduled task '%s' failed, will try again in %.0fs: %s\n", name, t.status.IntervalSeconds, e)
+	}
+	// schedule from the time the task was due so a slow task does not push back the next runs
+	interval := time.Duration(t.status.IntervalSeconds * float64(time.Second))
+	t.status.NextRun = now.Add(interval)
+	for !t.status.NextRun.After(now) {
+		t.status.NextRun = t.status.NextRun.Add(interval)
+	}
+}
+
+// statusEndpoint serves the status of the tasks on GET and runs a task on POST with the task name in the "run" query param
+type statusEndpoint struct {
+	path      string
+	scheduler *Scheduler
+	authLevel networking.AuthLevel
+}
+
+// MakeStatusEndpoint makes an endpoint for the monitoring server to observe the tasks of the scheduler and trigger them
+func MakeStatusEndpoint(path string, s *Scheduler, authLevel networking.AuthLevel) networking.Endpoint {
+	return &statusEndpoint{
+		path:      path,
+		scheduler: s,
+		authLevel: authLevel,
+	}
+}
+
+// GetAuthLevel impl
+func (ep *statusEndpoint) GetAuthLevel() networking.AuthLevel {
+	return ep.authLevel
+}
+
+// GetPath impl
+func (ep *statusEndpoint) GetPath() string {
+	return ep.path
+}
+
+// GetHandlerFunc impl
+func (ep *statusEndpoint) GetHandlerFunc() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			e := ep.scheduler.RunNow(r.URL.Query().Get("run"), time.Now())
+			if e != nil {
+				http.Error(w, e.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+
+		statusesJSON, e := json.Marshal(ep.scheduler.Statuses())
+		if e != nil {
+			log.Printf("error marshalling scheduled task statuses: %s\n", e)
+			http.Error(w, e.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, e = w.Write(statusesJSON)
+		if e != nil {
+			log.Printf("error writing to the response writer: %s\n", e)
+		}
+	}
+}
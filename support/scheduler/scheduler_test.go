@@ -0,0 +1,87 @@
+package scheduler
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/kelp/support/networking"
+)
+
+func TestScheduler(t *testing.T) {
+	start := time.Unix(10000, 0)
+	s := MakeScheduler()
+	runs := []string{}
+	e := s.Register("b_fails", 10*time.Second, start, func(now time.Time) error {
+		runs = append(runs, fmt.Sprintf("b@%d", now.Unix()))
+		return fmt.Errorf("boom")
+	})
+	if !assert.NoError(t, e) {
+		return
+	}
+	e = s.Register("a_works", 5*time.Second, start, func(now time.Time) error {
+		runs = append(runs, fmt.Sprintf("a@%d", now.Unix()))
+		return nil
+	})
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Error(t, s.Register("a_works", time.Second, start, nil))
+	assert.Error(t, s.Register("zero", 0, start, nil))
+
+	s.runDue(start.Add(4 * time.Second))
+	s.runDue(start.Add(5 * time.Second))
+	s.runDue(start.Add(10 * time.Second))
+	// a late poll runs the task once and schedules it from the time it ran
+	s.runDue(start.Add(27 * time.Second))
+	assert.Equal(t, []string{"a@10005", "a@10010", "b@10010", "a@10027", "b@10027"}, runs)
+
+	statuses := s.Statuses()
+	if !assert.Equal(t, 2, len(statuses)) {
+		return
+	}
+	assert.Equal(t, "a_works", statuses[0].Name)
+	assert.Equal(t, 3, statuses[0].NumRuns)
+	assert.Equal(t, 0, statuses[0].NumFailures)
+	assert.Equal(t, start.Add(32*time.Second), statuses[0].NextRun)
+	assert.Equal(t, "b_fails", statuses[1].Name)
+	assert.Equal(t, 2, statuses[1].NumRuns)
+	assert.Equal(t, 2, statuses[1].NumFailures)
+	assert.Equal(t, "boom", statuses[1].LastError)
+	assert.Equal(t, start.Add(27*time.Second), *statuses[1].LastRun)
+
+	assert.Error(t, s.RunNow("unknown", start))
+	if !assert.NoError(t, s.RunNow("b_fails", start.Add(28*time.Second))) {
+		return
+	}
+	s.runDue(start.Add(28 * time.Second))
+	assert.Equal(t, "b@10028", runs[len(runs)-1])
+}
+
+func TestStatusEndpoint(t *testing.T) {
+	s := MakeScheduler()
+	e := s.Register("task", time.Hour, time.Now(), func(now time.Time) error { return nil })
+	if !assert.NoError(t, e) {
+		return
+	}
+	ep := MakeStatusEndpoint("/maintenance", s, networking.NoAuth)
+	assert.Equal(t, "/maintenance", ep.GetPath())
+
+	w := httptest.NewRecorder()
+	ep.GetHandlerFunc()(w, httptest.NewRequest(http.MethodGet, "/maintenance", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"name":"task"`)
+
+	w = httptest.NewRecorder()
+	ep.GetHandlerFunc()(w, httptest.NewRequest(http.MethodPost, "/maintenance?run=unknown", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	ep.GetHandlerFunc()(w, httptest.NewRequest(http.MethodPost, "/maintenance?run=task", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, s.Statuses()[0].NextRun.Before(time.Now().Add(time.Minute)))
+}
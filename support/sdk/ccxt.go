@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"math"
 	"net/http"
 	"reflect"
 	"strconv"
@@ -22,6 +23,8 @@ var ccxtBaseURL = "http://localhost:3000"
 // SetBaseURL allows setting the base URL for ccxt
 func SetBaseURL(baseURL string) error {
 	ccxtBaseURL = strings.TrimSuffix(baseURL, "/")
+	// the list of exchanges depends on the ccxt-rest server so it needs to be reloaded
+	exchangeList = nil
 	log.Printf("updated ccxtBaseURL to '%s'\n", ccxtBaseURL)
 	return nil
 }
@@ -61,10 +64,21 @@ type CcxtMarket struct {
 		Amount int8 `json:"amount"`
 		Price  int8 `json:"price"`
 	} `json:"precision"`
+	Maker float64 `json:"maker"`
+	Taker float64 `json:"taker"`
 }
 
 const pathExchanges = "/exchanges"
 
+// precisionMode values of ccxt exchanges (see https://docs.ccxt.com/en/latest/manual.html#precision-mode)
+const (
+	ccxtPrecisionModeDecimalPlaces = 2
+	ccxtPrecisionModeTickSize      = 4
+)
+
+// tickSizeEpsilon absorbs the floating point error of log10 when converting a tick size to decimal places
+const tickSizeEpsilon = 1e-9
+
 // MakeInitializedCcxtExchange constructs an instance of Ccxt that is bound to a specific exchange instance on the CCXT REST server
 func MakeInitializedCcxtExchange(exchangeName string, apiKey api.ExchangeAPIKey, params []api.ExchangeParam, headers []api.ExchangeHeader) (*Ccxt, error) {
 	if strings.HasSuffix(ccxtBaseURL, "/") {
@@ -156,6 +170,11 @@ func (c *Ccxt) initialize(apiKey api.ExchangeAPIKey, params []api.ExchangeParam,
 		return fmt.Errorf("error loading markets for exchange instance (exchange=%s, instanceName=%s): %s", c.exchangeName, c.instanceName, e)
 	}
 	// decode markets and sets it on the ccxt instance
+	precisionMode, e := c.fetchPrecisionMode()
+	if e != nil {
+		return fmt.Errorf("error loading precision mode for exchange instance (exchange=%s, instanceName=%s): %s", c.exchangeName, c.instanceName, e)
+	}
+	normalizeTickSizePrecision(marketsResponse, precisionMode)
 	var markets map[string]CcxtMarket
 	e = mapstructure.Decode(marketsResponse, &markets)
 	if e != nil {
@@ -280,6 +299,61 @@ func (c *Ccxt) symbolExists(tradingPair string) error {
 	return fmt.Errorf("trading pair '%s' does not exist in the list of %d symbols on exchange '%s'", tradingPair, len(symbolsList), c.exchangeName)
 }
 
+// fetchPrecisionMode returns the precisionMode of the exchange, which is how ccxt reports the precision of its markets
+func (c *Ccxt) fetchPrecisionMode() (int, error) {
+	url := ccxtBaseURL + pathExchanges + "/" + c.exchangeName + "/" + c.instanceName
+	var exchangeOutput map[string]interface{}
+	e := networking.JSONRequest(c.httpClient, "GET", url, "", map[string]string{}, &exchangeOutput, "error")
+	if e != nil {
+		return 0, fmt.Errorf("error fetching details of exchange instance (exchange=%s, instanceName=%s): %s", c.exchangeName, c.instanceName, e)
+	}
+
+	precisionMode, ok := exchangeOutput["precisionMode"].(float64)
+	if !ok {
+		// older versions of ccxt only report the precision as decimal places
+		return ccxtPrecisionModeDecimalPlaces, nil
+	}
+	return int(precisionMode), nil
+}
+
+// normalizeTickSizePrecision converts the precision of markets that is reported as a tick size (such as 0.001) by exchanges that use the
+// TICK_SIZE precision mode to the number of decimal places (such as 3), which would otherwise be truncated to 0 when decoding the markets.
+// The decimal places are rounded down so every step of the precision is a multiple of the tick size (a tick of 0.025 becomes 1 decimal
+// place), and ticks of 1 or more become 0 decimal places.
+func normalizeTickSizePrecision(marketsResponse interface{}, precisionMode int) {
+	if precisionMode != ccxtPrecisionModeTickSize {
+		return
+	}
+
+	markets, ok := marketsResponse.(map[string]interface{})
+	if !ok {
+		return
+	}
+	for symbol, m := range markets {
+		market, ok := m.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		precision, ok := market["precision"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for _, field := range []string{"amount", "price"} {
+			tickSize, ok := precision[field].(float64)
+			if !ok || tickSize <= 0 {
+				continue
+			}
+			// the epsilon keeps ticks such as 0.001 (where -log10 is 2.9999999999999996) at 3 decimal places
+			decimals := math.Floor(-math.Log10(tickSize) + tickSizeEpsilon)
+			if decimals < 0 {
+				log.Printf("the %s tick size of market '%s' is %f, which is rounded to whole units since a precision above 1 is not supported\n", field, symbol, tickSize)
+				decimals = 0
+			}
+			precision[field] = decimals
+		}
+	}
+}
+
 // GetMarket returns the CcxtMarket instance
 func (c *Ccxt) GetMarket(tradingPair string) *CcxtMarket {
 	if v, ok := c.markets[tradingPair]; ok {
@@ -319,6 +393,57 @@ func (c *Ccxt) FetchTicker(tradingPair string) (map[string]interface{}, error) {
 	return tickerMap, nil
 }
 
+// CcxtTradingFee represents the fee rates for a trading pair
+type CcxtTradingFee struct {
+	Symbol string  `json:"symbol"`
+	Maker  float64 `json:"maker"`
+	Taker  float64 `json:"taker"`
+}
+
+// FetchTradingFee calls the /fetchTradingFee endpoint on CCXT, trading pair is the CCXT version of the trading pair.
+// This returns the fee rates for the account of this instance, which can be lower than the default fee rates in the markets.
+func (c *Ccxt) FetchTradingFee(tradingPair string) (*CcxtTradingFee, error) {
+	e := c.symbolExists(tradingPair)
+	if e != nil {
+		return nil, fmt.Errorf("symbol does not exist: %s", e)
+	}
+
+	// marshal input data
+	data, e := json.Marshal(&[]string{tradingPair})
+	if e != nil {
+		return nil, fmt.Errorf("error marshaling tradingPair '%s' as an array for exchange '%s': %s", tradingPair, c.exchangeName, e)
+	}
+
+	// fetch trading fee for symbol
+	url := ccxtBaseURL + pathExchanges + "/" + c.exchangeName + "/" + c.instanceName + "/fetchTradingFee"
+	var output CcxtTradingFee
+	e = networking.JSONRequestDynamicHeaders(c.httpClient, "POST", url, string(data), c.headersMap, &output, "error")
+	if e != nil {
+		return nil, fmt.Errorf("error fetching trading fee for trading pair '%s': %s", tradingPair, e)
+	}
+	return &output, nil
+}
+
+// CcxtAPIRestrictions are the restrictions of the API key of an instance, as reported by the binance exchanges
+type CcxtAPIRestrictions struct {
+	IPRestrict                 bool `json:"ipRestrict"`
+	EnableReading              bool `json:"enableReading"`
+	EnableSpotAndMarginTrading bool `json:"enableSpotAndMarginTrading"`
+	EnableWithdrawals          bool `json:"enableWithdrawals"`
+}
+
+// FetchBinanceAPIRestrictions calls the implicit /sapiGetAccountApiRestrictions endpoint on CCXT, which is only available on the
+// binance exchanges
+func (c *Ccxt) FetchBinanceAPIRestrictions() (*CcxtAPIRestrictions, error) {
+	url := ccxtBaseURL + pathExchanges + "/" + c.exchangeName + "/" + c.instanceName + "/sapiGetAccountApiRestrictions"
+	var output CcxtAPIRestrictions
+	e := networking.JSONRequestDynamicHeaders(c.httpClient, "POST", url, "", c.headersMap, &output, "error")
+	if e != nil {
+		return nil, fmt.Errorf("error fetching API key restrictions: %s", e)
+	}
+	return &output, nil
+}
+
 // CcxtOrder represents an order in the orderbook
 type CcxtOrder struct {
 	Price  float64
@@ -395,17 +520,26 @@ type CcxtTrade struct {
 }
 
 // FetchTrades calls the /fetchTrades endpoint on CCXT, trading pair is the CCXT version of the trading pair
-// TODO take in since and limit values to match CCXT's API
-func (c *Ccxt) FetchTrades(tradingPair string) ([]CcxtTrade, error) {
+// maybeCursorStart is passed in as the since value and fetches all trades when nil
+func (c *Ccxt) FetchTrades(tradingPair string, maybeCursorStart interface{}) ([]CcxtTrade, error) {
 	e := c.symbolExists(tradingPair)
 	if e != nil {
 		return nil, fmt.Errorf("symbol does not exist: %s", e)
 	}
 
 	// marshal input data
-	data, e := json.Marshal(&[]string{tradingPair})
-	if e != nil {
-		return nil, fmt.Errorf("error marshaling input (tradingPair=%s) as an array for exchange '%s': %s", tradingPair, c.exchangeName, e)
+	var data []byte
+	if maybeCursorStart == nil {
+		data, e = json.Marshal(&[]string{tradingPair})
+		if e != nil {
+			return nil, fmt.Errorf("error marshaling input (tradingPair=%s) as an array for exchange '%s': %s", tradingPair, c.exchangeName, e)
+		}
+	} else {
+		cursorString := fmt.Sprintf("%v", maybeCursorStart)
+		data, e = json.Marshal(&[]string{tradingPair, cursorString})
+		if e != nil {
+			return nil, fmt.Errorf("error marshaling input (tradingPair=%s, maybeCursorStart=%v) as an array for exchange '%s': %s", tradingPair, maybeCursorStart, c.exchangeName, e)
+		}
 	}
 
 	// fetch trades for symbol
@@ -419,6 +553,82 @@ func (c *Ccxt) FetchTrades(tradingPair string) ([]CcxtTrade, error) {
 	return output, nil
 }
 
+// CcxtOHLCV represents a single candle returned by the fetchOHLCV endpoint
+type CcxtOHLCV struct {
+	Timestamp int64 // start time of the candle in unix millis
+	Open      float64
+	High      float64
+	Low       float64
+	Close     float64
+	Volume    float64 // volume in units of the base asset
+}
+
+// FetchOHLCV calls the /fetchOHLCV endpoint on CCXT, trading pair is the CCXT version of the trading pair
+func (c *Ccxt) FetchOHLCV(tradingPair string, timeframe string, maybeSinceMillis *int64, maybeLimit *int) ([]CcxtOHLCV, error) {
+	e := c.symbolExists(tradingPair)
+	if e != nil {
+		return nil, fmt.Errorf("symbol does not exist: %s", e)
+	}
+
+	// marshal input data, since needs to be passed in as null when we only want to specify the limit
+	inputData := []interface{}{tradingPair, timeframe}
+	if maybeSinceMillis != nil || maybeLimit != nil {
+		var since interface{}
+		if maybeSinceMillis != nil {
+			since = *maybeSinceMillis
+		}
+		inputData = append(inputData, since)
+	}
+	if maybeLimit != nil {
+		inputData = append(inputData, *maybeLimit)
+	}
+	data, e := json.Marshal(&inputData)
+	if e != nil {
+		return nil, fmt.Errorf("error marshaling input (%v) for exchange '%s': %s", inputData, c.exchangeName, e)
+	}
+
+	// fetch candles for symbol
+	url := ccxtBaseURL + pathExchanges + "/" + c.exchangeName + "/" + c.instanceName + "/fetchOHLCV"
+	// decode generic data (see "https://blog.golang.org/json-and-go#TOC_4.")
+	var output interface{}
+	e = networking.JSONRequestDynamicHeaders(c.httpClient, "POST", url, string(data), c.headersMap, &output, "error")
+	if e != nil {
+		return nil, fmt.Errorf("error fetching OHLCV for trading pair '%s' (timeframe=%s): %s", tradingPair, timeframe, e)
+	}
+
+	outputList, ok := output.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("could not convert the output to a []interface{}, type = %s", reflect.TypeOf(output))
+	}
+
+	result := []CcxtOHLCV{}
+	for _, elem := range outputList {
+		candle, ok := elem.([]interface{})
+		if !ok || len(candle) != 6 {
+			return nil, fmt.Errorf("could not parse candle element, expected an array of 6 values: %v", elem)
+		}
+
+		values := [6]float64{}
+		for i, v := range candle {
+			f, ok := v.(float64)
+			if !ok {
+				return nil, fmt.Errorf("could not parse value at index %d of candle as a float64: %v", i, candle)
+			}
+			values[i] = f
+		}
+
+		result = append(result, CcxtOHLCV{
+			Timestamp: int64(values[0]),
+			Open:      values[1],
+			High:      values[2],
+			Low:       values[3],
+			Close:     values[4],
+			Volume:    values[5],
+		})
+	}
+	return result, nil
+}
+
 // FetchMyTrades calls the /fetchMyTrades endpoint on CCXT, trading pair is the CCXT version of the trading pair
 func (c *Ccxt) FetchMyTrades(tradingPair string, limit int, maybeCursorStart interface{}) ([]CcxtTrade, error) {
 	e := c.symbolExists(tradingPair)
@@ -646,3 +856,81 @@ func (c *Ccxt) CancelOrder(orderID string, tradingPair string) (*CcxtOpenOrder,
 
 	return &openOrder, nil
 }
+
+// CcxtDepositAddress represents the address to deposit a currency to
+type CcxtDepositAddress struct {
+	Currency string `json:"currency"`
+	Address  string `json:"address"`
+	Tag      string `json:"tag"` // memo that needs to be attached to the deposit, empty if not needed
+}
+
+// FetchDepositAddress calls the /fetchDepositAddress endpoint on CCXT, currency is the CCXT code of the currency
+func (c *Ccxt) FetchDepositAddress(currency string) (*CcxtDepositAddress, error) {
+	// marshal input data
+	data, e := json.Marshal(&[]string{currency})
+	if e != nil {
+		return nil, fmt.Errorf("error marshaling currency '%s' as an array for exchange '%s': %s", currency, c.exchangeName, e)
+	}
+
+	url := ccxtBaseURL + pathExchanges + "/" + c.exchangeName + "/" + c.instanceName + "/fetchDepositAddress"
+	var output CcxtDepositAddress
+	e = networking.JSONRequestDynamicHeaders(c.httpClient, "POST", url, string(data), c.headersMap, &output, "error")
+	if e != nil {
+		return nil, fmt.Errorf("error fetching deposit address for currency '%s': %s", currency, e)
+	}
+	return &output, nil
+}
+
+// CcxtCurrency represents the withdrawal details of a currency returned by the fetchCurrencies endpoint
+type CcxtCurrency struct {
+	Code   string  `json:"code"`
+	Fee    float64 `json:"fee"` // withdrawal fee
+	Limits struct {
+		Withdraw struct {
+			Min float64 `json:"min"`
+			Max float64 `json:"max"`
+		} `json:"withdraw"`
+	} `json:"limits"`
+}
+
+// FetchCurrencies calls the /fetchCurrencies endpoint on CCXT, which is not supported by all exchanges
+func (c *Ccxt) FetchCurrencies() (map[string]CcxtCurrency, error) {
+	url := ccxtBaseURL + pathExchanges + "/" + c.exchangeName + "/" + c.instanceName + "/fetchCurrencies"
+	output := map[string]CcxtCurrency{}
+	e := networking.JSONRequestDynamicHeaders(c.httpClient, "POST", url, "", c.headersMap, &output, "error")
+	if e != nil {
+		return nil, fmt.Errorf("error fetching currencies: %s", e)
+	}
+	return output, nil
+}
+
+// CcxtWithdrawal represents the result of a withdraw call
+type CcxtWithdrawal struct {
+	ID string `json:"id"`
+}
+
+// Withdraw calls the /withdraw endpoint on CCXT, currency is the CCXT code of the currency. The amount is passed as a string so it is not
+// rounded by a conversion to a float, and the tag is the memo of the address, which is left out when it is empty.
+func (c *Ccxt) Withdraw(currency string, amount string, address string, tag string) (*CcxtWithdrawal, error) {
+	// marshal input data
+	inputData := []interface{}{
+		currency,
+		amount,
+		address,
+	}
+	if tag != "" {
+		inputData = append(inputData, tag)
+	}
+	data, e := json.Marshal(&inputData)
+	if e != nil {
+		return nil, fmt.Errorf("error marshaling input (%v) for exchange '%s': %s", inputData, c.exchangeName, e)
+	}
+
+	url := ccxtBaseURL + pathExchanges + "/" + c.exchangeName + "/" + c.instanceName + "/withdraw"
+	var output CcxtWithdrawal
+	e = networking.JSONRequestDynamicHeaders(c.httpClient, "POST", url, string(data), c.headersMap, &output, "error")
+	if e != nil {
+		return nil, fmt.Errorf("error withdrawing %s %s to address '%s' (tag='%s'): %s", amount, currency, address, tag, e)
+	}
+	return &output, nil
+}
@@ -6,6 +6,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/mitchellh/mapstructure"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/stellar/kelp/api"
@@ -141,6 +142,65 @@ func TestMakeInstanceName(t *testing.T) {
 	}
 }
 
+func TestNormalizeTickSizePrecision(t *testing.T) {
+	testCases := []struct {
+		name          string
+		precisionMode int
+		precision     map[string][2]float64 // symbol -> amount, price
+		want          map[string][2]int8    // symbol -> amount, price
+	}{
+		{
+			name:          "tick size",
+			precisionMode: ccxtPrecisionModeTickSize,
+			precision: map[string][2]float64{
+				"XLM/USDT": {0.1, 0.00001},
+				"BTC/USDT": {0.001, 1.0},
+				"ETH/USDT": {0.025, 0.25},
+			},
+			want: map[string][2]int8{
+				"XLM/USDT": {1, 5},
+				"BTC/USDT": {3, 0},
+				// ticks that are not a power of 10 are rounded down to decimal places whose steps are multiples of the tick
+				"ETH/USDT": {1, 0},
+			},
+		}, {
+			name:          "decimal places are unchanged",
+			precisionMode: ccxtPrecisionModeDecimalPlaces,
+			precision: map[string][2]float64{
+				"XLM/USDT": {1.0, 5.0},
+				"BTC/USDT": {6.0, 1.0},
+			},
+			want: map[string][2]int8{
+				"XLM/USDT": {1, 5},
+				"BTC/USDT": {6, 1},
+			},
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			marketsResponse := map[string]interface{}{}
+			for symbol, p := range k.precision {
+				marketsResponse[symbol] = map[string]interface{}{
+					"symbol":    symbol,
+					"precision": map[string]interface{}{"amount": p[0], "price": p[1]},
+				}
+			}
+			normalizeTickSizePrecision(marketsResponse, k.precisionMode)
+
+			var markets map[string]CcxtMarket
+			e := mapstructure.Decode(marketsResponse, &markets)
+			if !assert.NoError(t, e) {
+				return
+			}
+			for symbol, want := range k.want {
+				assert.Equal(t, want[0], markets[symbol].Precision.Amount, symbol)
+				assert.Equal(t, want[1], markets[symbol].Precision.Price, symbol)
+			}
+		})
+	}
+}
+
 func TestMakeValid(t *testing.T) {
 	if testing.Short() {
 		return
@@ -374,7 +434,7 @@ func TestFetchTrades(t *testing.T) {
 				return
 			}
 
-			trades, e := c.FetchTrades(k.tradingPair)
+			trades, e := c.FetchTrades(k.tradingPair, nil)
 			if e != nil {
 				assert.Fail(t, fmt.Sprintf("error when fetching trades: %s", e))
 				return
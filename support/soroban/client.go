@@ -0,0 +1,104 @@
+package soroban
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Client calls the read-only functions of contracts by simulating transactions on a soroban RPC server, which does not need a funded
+// account or fees since nothing is submitted
+type Client struct {
+	rpcURL     string
+	source     Address
+	httpClient *http.Client
+}
+
+// MakeClient is a factory method, the source is the account that the simulated transactions are from
+func MakeClient(rpcURL string, source Address) *Client {
+	return &Client{
+		rpcURL:     rpcURL,
+		source:     source,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+type rpcRequest struct {
+	JSONRPC string      `json:"jsonrpc"`
+	ID      int         `json:"id"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type simulateResponse struct {
+	Result *struct {
+		Error   string `json:"error"`
+		Results []struct {
+			XDR string `json:"xdr"`
+		} `json:"results"`
+		LatestLedger json.Number `json:"latestLedger"`
+	} `json:"result"`
+	Error *rpcError `json:"error"`
+}
+
+// Call simulates a call to the function of the contract and returns the value returned by the function
+func (c *Client) Call(contract Address, function string, args ...ScVal) (ScVal, error) {
+	txXDR, e := EncodeInvokeContractTx(c.source, contract, function, args)
+	if e != nil {
+		return ScVal{}, e
+	}
+
+	body, e := json.Marshal(rpcRequest{
+		JSONRPC: "2.0",
+		ID:      1,
+		Method:  "simulateTransaction",
+		Params: map[string]string{
+			"transaction": base64.StdEncoding.EncodeToString(txXDR),
+		},
+	})
+	if e != nil {
+		return ScVal{}, fmt.Errorf("could not marshal request: %s", e)
+	}
+
+	res, e := c.httpClient.Post(c.rpcURL, "application/json", bytes.NewReader(body))
+	if e != nil {
+		return ScVal{}, fmt.Errorf("could not call '%s' on contract %s: %s", function, contract.String(), e)
+	}
+	defer res.Body.Close()
+
+	var resp simulateResponse
+	e = json.NewDecoder(res.Body).Decode(&resp)
+	if e != nil {
+		return ScVal{}, fmt.Errorf("could not decode the response with status code %d when calling '%s' on contract %s: %s", res.StatusCode, function, contract.String(), e)
+	}
+	if resp.Error != nil {
+		return ScVal{}, fmt.Errorf("rpc error %d when calling '%s' on contract %s: %s", resp.Error.Code, function, contract.String(), resp.Error.Message)
+	}
+	if resp.Result == nil {
+		return ScVal{}, fmt.Errorf("empty response with status code %d when calling '%s' on contract %s", res.StatusCode, function, contract.String())
+	}
+	if resp.Result.Error != "" {
+		return ScVal{}, fmt.Errorf("simulation of '%s' on contract %s failed: %s", function, contract.String(), resp.Result.Error)
+	}
+	if len(resp.Result.Results) != 1 {
+		return ScVal{}, fmt.Errorf("simulation of '%s' on contract %s needs to have 1 result but had %d", function, contract.String(), len(resp.Result.Results))
+	}
+
+	raw, e := base64.StdEncoding.DecodeString(resp.Result.Results[0].XDR)
+	if e != nil {
+		return ScVal{}, fmt.Errorf("could not decode the base64 result of '%s' on contract %s: %s", function, contract.String(), e)
+	}
+	v, e := DecodeScVal(raw)
+	if e != nil {
+		return ScVal{}, fmt.Errorf("could not decode the result of '%s' on contract %s: %s", function, contract.String(), e)
+	}
+	return v, nil
+}
@@ -0,0 +1,45 @@
+package soroban
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientCall(t *testing.T) {
+	result, _ := EncodeScVal(ScVal{Type: ScvU32, Uint: 14})
+	var gotRequest map[string]interface{}
+	responseBody := fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"result":{"results":[{"auth":[],"xdr":"%s"}],"latestLedger":100}}`, base64.StdEncoding.EncodeToString(result))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&gotRequest)
+		w.Write([]byte(responseBody))
+	}))
+	defer server.Close()
+
+	contract := Address{IsContract: true, Key: [32]byte{1}}
+	c := MakeClient(server.URL, Address{})
+	v, e := c.Call(contract, "decimals")
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, ScVal{Type: ScvU32, Uint: 14}, v)
+	assert.Equal(t, "simulateTransaction", gotRequest["method"])
+	wantTx, _ := EncodeInvokeContractTx(Address{}, contract, "decimals", nil)
+	assert.Equal(t, map[string]interface{}{"transaction": base64.StdEncoding.EncodeToString(wantTx)}, gotRequest["params"])
+
+	for _, body := range []string{
+		`{"jsonrpc":"2.0","id":1,"result":{"error":"HostError: Error(Contract, #1)","results":[]}}`,
+		`{"jsonrpc":"2.0","id":1,"error":{"code":-32602,"message":"invalid parameters"}}`,
+		`{"jsonrpc":"2.0","id":1,"result":{"results":[]}}`,
+		`not json`,
+	} {
+		responseBody = body
+		_, e := c.Call(contract, "decimals")
+		assert.Error(t, e, body)
+	}
+}
@@ -0,0 +1,70 @@
+package soroban
+
+import (
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+)
+
+// the version bytes of the strkeys of accounts (G...) and contracts (C...), the vendored stellar/go strkey package does not know contracts
+const (
+	versionByteAccountID byte = 6 << 3
+	versionByteContract  byte = 2 << 3
+)
+
+// ParseAddress parses an account (G...) or a contract (C...) strkey
+func ParseAddress(s string) (Address, error) {
+	raw, e := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(s)
+	if e != nil {
+		return Address{}, fmt.Errorf("invalid strkey '%s': %s", s, e)
+	}
+	if len(raw) != 1+32+2 {
+		return Address{}, fmt.Errorf("invalid strkey '%s': needs to decode to 35 bytes but decoded to %d", s, len(raw))
+	}
+
+	payload := raw[:33]
+	checksum := binary.LittleEndian.Uint16(raw[33:])
+	if crc16(payload) != checksum {
+		return Address{}, fmt.Errorf("invalid strkey '%s': checksum does not match", s)
+	}
+
+	a := Address{}
+	switch raw[0] {
+	case versionByteAccountID:
+		a.IsContract = false
+	case versionByteContract:
+		a.IsContract = true
+	default:
+		return Address{}, fmt.Errorf("invalid strkey '%s': needs to be an account (G...) or a contract (C...)", s)
+	}
+	copy(a.Key[:], raw[1:33])
+	return a, nil
+}
+
+// String encodes the address as a strkey
+func (a Address) String() string {
+	payload := []byte{versionByteAccountID}
+	if a.IsContract {
+		payload[0] = versionByteContract
+	}
+	payload = append(payload, a.Key[:]...)
+	checksum := make([]byte, 2)
+	binary.LittleEndian.PutUint16(checksum, crc16(payload))
+	return base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(append(payload, checksum...))
+}
+
+// crc16 is the CRC-16/XMODEM checksum used by strkeys
+func crc16(data []byte) uint16 {
+	var crc uint16
+	for _, b := range data {
+		crc ^= uint16(b) << 8
+		for i := 0; i < 8; i++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
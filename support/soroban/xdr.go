@@ -0,0 +1,437 @@
+package soroban
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/big"
+)
+
+// the vendored stellar/go predates soroban so this package encodes the small part of the XDR that is needed to simulate a contract call
+// and decodes the values it returns, see https://github.com/stellar/stellar-xdr/blob/curr/Stellar-contract.x for the definitions
+
+// ScValType is the type of an ScVal
+type ScValType uint32
+
+// the types of ScVal that this package can encode or decode
+const (
+	ScvBool      ScValType = 0
+	ScvVoid      ScValType = 1
+	ScvU32       ScValType = 3
+	ScvI32       ScValType = 4
+	ScvU64       ScValType = 5
+	ScvI64       ScValType = 6
+	ScvTimepoint ScValType = 7
+	ScvU128      ScValType = 9
+	ScvI128      ScValType = 10
+	ScvString    ScValType = 14
+	ScvSymbol    ScValType = 15
+	ScvVec       ScValType = 16
+	ScvMap       ScValType = 17
+	ScvAddress   ScValType = 18
+)
+
+const (
+	scAddressTypeAccount  uint32 = 0
+	scAddressTypeContract uint32 = 1
+
+	envelopeTypeTx                  uint32 = 2
+	operationTypeInvokeHostFunction uint32 = 24
+	hostFunctionTypeInvokeContract  uint32 = 0
+)
+
+// ScMapEntry is an entry of an ScvMap
+type ScMapEntry struct {
+	Key ScVal
+	Val ScVal
+}
+
+// ScVal is a soroban value, only the field that matches the Type is set. Integers of up to 64 bits are kept in Int (or Uint), 128-bit
+// integers are kept in Big
+type ScVal struct {
+	Type    ScValType
+	Bool    bool
+	Int     int64
+	Uint    uint64
+	Big     *big.Int
+	Str     string // ScvString and ScvSymbol
+	Vec     []ScVal
+	Map     []ScMapEntry
+	Address *Address
+}
+
+// Address is an account or a contract
+type Address struct {
+	IsContract bool
+	Key        [32]byte
+}
+
+// Symbol makes an ScvSymbol
+func Symbol(s string) ScVal {
+	return ScVal{Type: ScvSymbol, Str: s}
+}
+
+// Vec makes an ScvVec
+func Vec(vals ...ScVal) ScVal {
+	return ScVal{Type: ScvVec, Vec: vals}
+}
+
+// AddressVal makes an ScvAddress
+func AddressVal(a Address) ScVal {
+	return ScVal{Type: ScvAddress, Address: &a}
+}
+
+// MapValue returns the value of the entry with the symbol key in an ScvMap, which is how a contract returns a struct
+func (v ScVal) MapValue(key string) (ScVal, bool) {
+	for _, entry := range v.Map {
+		if entry.Key.Type == ScvSymbol && entry.Key.Str == key {
+			return entry.Val, true
+		}
+	}
+	return ScVal{}, false
+}
+
+// AsBigInt returns the value of any integer type
+func (v ScVal) AsBigInt() (*big.Int, error) {
+	switch v.Type {
+	case ScvU32, ScvU64, ScvTimepoint:
+		return new(big.Int).SetUint64(v.Uint), nil
+	case ScvI32, ScvI64:
+		return big.NewInt(v.Int), nil
+	case ScvU128, ScvI128:
+		return new(big.Int).Set(v.Big), nil
+	}
+	return nil, fmt.Errorf("ScVal of type %d is not an integer", v.Type)
+}
+
+type xdrEncoder struct {
+	buf bytes.Buffer
+}
+
+func (enc *xdrEncoder) uint32(v uint32) {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, v)
+	enc.buf.Write(b)
+}
+
+func (enc *xdrEncoder) uint64(v uint64) {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, v)
+	enc.buf.Write(b)
+}
+
+func (enc *xdrEncoder) fixedOpaque(b []byte) {
+	enc.buf.Write(b)
+	if pad := (4 - len(b)%4) % 4; pad > 0 {
+		enc.buf.Write(make([]byte, pad))
+	}
+}
+
+func (enc *xdrEncoder) varOpaque(b []byte) {
+	enc.uint32(uint32(len(b)))
+	enc.fixedOpaque(b)
+}
+
+func (enc *xdrEncoder) address(a Address) {
+	if a.IsContract {
+		enc.uint32(scAddressTypeContract)
+	} else {
+		enc.uint32(scAddressTypeAccount)
+		// PublicKey union of type PUBLIC_KEY_TYPE_ED25519
+		enc.uint32(0)
+	}
+	enc.fixedOpaque(a.Key[:])
+}
+
+func (enc *xdrEncoder) scVal(v ScVal) error {
+	enc.uint32(uint32(v.Type))
+	switch v.Type {
+	case ScvBool:
+		if v.Bool {
+			enc.uint32(1)
+		} else {
+			enc.uint32(0)
+		}
+	case ScvVoid:
+	case ScvU32:
+		enc.uint32(uint32(v.Uint))
+	case ScvI32:
+		enc.uint32(uint32(int32(v.Int)))
+	case ScvU64, ScvTimepoint:
+		enc.uint64(v.Uint)
+	case ScvI64:
+		enc.uint64(uint64(v.Int))
+	case ScvU128, ScvI128:
+		// two's complement in 128 bits, split into the high and low halves
+		n := new(big.Int).Set(v.Big)
+		if n.Sign() < 0 {
+			n.Add(n, new(big.Int).Lsh(big.NewInt(1), 128))
+		}
+		lo := new(big.Int).And(n, new(big.Int).SetUint64(^uint64(0)))
+		enc.uint64(new(big.Int).Rsh(n, 64).Uint64())
+		enc.uint64(lo.Uint64())
+	case ScvString, ScvSymbol:
+		enc.varOpaque([]byte(v.Str))
+	case ScvVec:
+		// the vec is optional in the XDR
+		enc.uint32(1)
+		enc.uint32(uint32(len(v.Vec)))
+		for _, item := range v.Vec {
+			e := enc.scVal(item)
+			if e != nil {
+				return e
+			}
+		}
+	case ScvMap:
+		// the map is optional in the XDR
+		enc.uint32(1)
+		enc.uint32(uint32(len(v.Map)))
+		for _, entry := range v.Map {
+			e := enc.scVal(entry.Key)
+			if e != nil {
+				return e
+			}
+			e = enc.scVal(entry.Val)
+			if e != nil {
+				return e
+			}
+		}
+	case ScvAddress:
+		enc.address(*v.Address)
+	default:
+		return fmt.Errorf("encoding an ScVal of type %d is not supported", v.Type)
+	}
+	return nil
+}
+
+// EncodeInvokeContractTx encodes a transaction envelope with a single operation that calls the function of the contract, which is only
+// meant to be simulated so it has no fee, sequence number or signatures
+func EncodeInvokeContractTx(source Address, contract Address, function string, args []ScVal) ([]byte, error) {
+	enc := &xdrEncoder{}
+	enc.uint32(envelopeTypeTx)
+
+	// Transaction
+	// sourceAccount is a MuxedAccount of type KEY_TYPE_ED25519
+	enc.uint32(0)
+	enc.fixedOpaque(source.Key[:])
+	// fee
+	enc.uint32(0)
+	// seqNum
+	enc.uint64(0)
+	// cond of type PRECOND_NONE
+	enc.uint32(0)
+	// memo of type MEMO_NONE
+	enc.uint32(0)
+	// operations
+	enc.uint32(1)
+	// operation with no sourceAccount
+	enc.uint32(0)
+	enc.uint32(operationTypeInvokeHostFunction)
+	enc.uint32(hostFunctionTypeInvokeContract)
+	enc.address(contract)
+	enc.varOpaque([]byte(function))
+	enc.uint32(uint32(len(args)))
+	for _, arg := range args {
+		e := enc.scVal(arg)
+		if e != nil {
+			return nil, fmt.Errorf("could not encode arg of function '%s': %s", function, e)
+		}
+	}
+	// auth
+	enc.uint32(0)
+	// ext v0
+	enc.uint32(0)
+
+	// signatures
+	enc.uint32(0)
+	return enc.buf.Bytes(), nil
+}
+
+type xdrDecoder struct {
+	r *bytes.Reader
+}
+
+func (dec *xdrDecoder) uint32() (uint32, error) {
+	b := make([]byte, 4)
+	_, e := io.ReadFull(dec.r, b)
+	if e != nil {
+		return 0, fmt.Errorf("unexpected end of XDR")
+	}
+	return binary.BigEndian.Uint32(b), nil
+}
+
+func (dec *xdrDecoder) uint64() (uint64, error) {
+	hi, e := dec.uint32()
+	if e != nil {
+		return 0, e
+	}
+	lo, e := dec.uint32()
+	if e != nil {
+		return 0, e
+	}
+	return uint64(hi)<<32 | uint64(lo), nil
+}
+
+func (dec *xdrDecoder) fixedOpaque(n int) ([]byte, error) {
+	padded := n + (4-n%4)%4
+	if dec.r.Len() < padded {
+		return nil, fmt.Errorf("unexpected end of XDR")
+	}
+	b := make([]byte, padded)
+	_, e := io.ReadFull(dec.r, b)
+	if e != nil {
+		return nil, fmt.Errorf("unexpected end of XDR")
+	}
+	return b[:n], nil
+}
+
+func (dec *xdrDecoder) varOpaque() ([]byte, error) {
+	n, e := dec.uint32()
+	if e != nil {
+		return nil, e
+	}
+	return dec.fixedOpaque(int(n))
+}
+
+func (dec *xdrDecoder) scVal() (ScVal, error) {
+	t, e := dec.uint32()
+	if e != nil {
+		return ScVal{}, e
+	}
+	v := ScVal{Type: ScValType(t)}
+	switch v.Type {
+	case ScvBool:
+		b, e := dec.uint32()
+		if e != nil {
+			return v, e
+		}
+		v.Bool = b != 0
+	case ScvVoid:
+	case ScvU32:
+		u, e := dec.uint32()
+		if e != nil {
+			return v, e
+		}
+		v.Uint = uint64(u)
+	case ScvI32:
+		u, e := dec.uint32()
+		if e != nil {
+			return v, e
+		}
+		v.Int = int64(int32(u))
+	case ScvU64, ScvTimepoint:
+		v.Uint, e = dec.uint64()
+		if e != nil {
+			return v, e
+		}
+	case ScvI64:
+		u, e := dec.uint64()
+		if e != nil {
+			return v, e
+		}
+		v.Int = int64(u)
+	case ScvU128, ScvI128:
+		hi, e := dec.uint64()
+		if e != nil {
+			return v, e
+		}
+		lo, e := dec.uint64()
+		if e != nil {
+			return v, e
+		}
+		v.Big = new(big.Int).Lsh(new(big.Int).SetUint64(hi), 64)
+		if v.Type == ScvI128 && int64(hi) < 0 {
+			// hi is the signed high half so the value is negative: hi * 2^64 + lo - 2^128
+			v.Big.Sub(v.Big, new(big.Int).Lsh(big.NewInt(1), 128))
+		}
+		v.Big.Add(v.Big, new(big.Int).SetUint64(lo))
+	case ScvString, ScvSymbol:
+		b, e := dec.varOpaque()
+		if e != nil {
+			return v, e
+		}
+		v.Str = string(b)
+	case ScvVec:
+		present, e := dec.uint32()
+		if e != nil || present == 0 {
+			return v, e
+		}
+		n, e := dec.uint32()
+		if e != nil {
+			return v, e
+		}
+		for i := uint32(0); i < n; i++ {
+			item, e := dec.scVal()
+			if e != nil {
+				return v, e
+			}
+			v.Vec = append(v.Vec, item)
+		}
+	case ScvMap:
+		present, e := dec.uint32()
+		if e != nil || present == 0 {
+			return v, e
+		}
+		n, e := dec.uint32()
+		if e != nil {
+			return v, e
+		}
+		for i := uint32(0); i < n; i++ {
+			key, e := dec.scVal()
+			if e != nil {
+				return v, e
+			}
+			val, e := dec.scVal()
+			if e != nil {
+				return v, e
+			}
+			v.Map = append(v.Map, ScMapEntry{Key: key, Val: val})
+		}
+	case ScvAddress:
+		addressType, e := dec.uint32()
+		if e != nil {
+			return v, e
+		}
+		a := Address{IsContract: addressType == scAddressTypeContract}
+		if !a.IsContract {
+			// skip the type of the PublicKey union
+			_, e = dec.uint32()
+			if e != nil {
+				return v, e
+			}
+		}
+		key, e := dec.fixedOpaque(32)
+		if e != nil {
+			return v, e
+		}
+		copy(a.Key[:], key)
+		v.Address = &a
+	default:
+		return v, fmt.Errorf("decoding an ScVal of type %d is not supported", t)
+	}
+	return v, nil
+}
+
+// DecodeScVal decodes the XDR of an ScVal
+func DecodeScVal(b []byte) (ScVal, error) {
+	dec := &xdrDecoder{r: bytes.NewReader(b)}
+	v, e := dec.scVal()
+	if e != nil {
+		return ScVal{}, e
+	}
+	if dec.r.Len() != 0 {
+		return ScVal{}, fmt.Errorf("%d bytes left after decoding the ScVal", dec.r.Len())
+	}
+	return v, nil
+}
+
+// EncodeScVal encodes the XDR of an ScVal
+func EncodeScVal(v ScVal) ([]byte, error) {
+	enc := &xdrEncoder{}
+	e := enc.scVal(v)
+	if e != nil {
+		return nil, e
+	}
+	return enc.buf.Bytes(), nil
+}
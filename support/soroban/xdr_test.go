@@ -0,0 +1,84 @@
+package soroban
+
+import (
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAddress(t *testing.T) {
+	a, e := ParseAddress("GAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAWHF")
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.False(t, a.IsContract)
+	assert.Equal(t, [32]byte{}, a.Key)
+	assert.Equal(t, "GAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAWHF", a.String())
+
+	c, e := ParseAddress("CAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABSC4")
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.True(t, c.IsContract)
+	assert.Equal(t, "CAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABSC4", c.String())
+
+	for _, s := range []string{
+		"",
+		"GAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAWHG",
+		"SAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA",
+		"not a strkey",
+	} {
+		_, e := ParseAddress(s)
+		assert.Error(t, e, s)
+	}
+}
+
+func TestEncodeScVal(t *testing.T) {
+	b, e := EncodeScVal(Vec(Symbol("Other"), Symbol("BTC")))
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, "00000010"+"00000001"+"00000002"+
+		"0000000f"+"00000005"+"4f74686572000000"+
+		"0000000f"+"00000003"+"42544300", hex.EncodeToString(b))
+}
+
+func TestScValRoundTrip(t *testing.T) {
+	negative, _ := new(big.Int).SetString("-170141183460469231731687303715884105728", 10)
+	large, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	for _, v := range []ScVal{
+		{Type: ScvVoid},
+		{Type: ScvBool, Bool: true},
+		{Type: ScvU32, Uint: 14},
+		{Type: ScvI32, Int: -3},
+		{Type: ScvU64, Uint: 1700000000},
+		{Type: ScvI64, Int: -1700000000},
+		{Type: ScvI128, Big: big.NewInt(-1)},
+		{Type: ScvI128, Big: negative},
+		{Type: ScvU128, Big: large},
+		{Type: ScvString, Str: "hello"},
+		AddressVal(Address{IsContract: true, Key: [32]byte{1, 2, 3}}),
+		AddressVal(Address{Key: [32]byte{4, 5, 6}}),
+		{Type: ScvMap, Map: []ScMapEntry{
+			{Key: Symbol("price"), Val: ScVal{Type: ScvI128, Big: large}},
+			{Key: Symbol("timestamp"), Val: ScVal{Type: ScvU64, Uint: 1700000000}},
+		}},
+	} {
+		b, e := EncodeScVal(v)
+		if !assert.NoError(t, e) {
+			return
+		}
+		decoded, e := DecodeScVal(b)
+		if !assert.NoError(t, e) {
+			return
+		}
+		assert.Equal(t, v, decoded)
+	}
+
+	_, e := DecodeScVal([]byte{0, 0, 0, 3, 0, 0})
+	assert.Error(t, e)
+	_, e = DecodeScVal([]byte{0, 0, 0, 1, 0, 0, 0, 0})
+	assert.Error(t, e)
+}
@@ -0,0 +1,368 @@
+package statestore
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	// the DBStore needs the postgres driver
+	_ "github.com/lib/pq"
+)
+
+// Store persists small pieces of state such as preferences and bot states by key. Keys are made of segments separated by '/', e.g.
+// "bot_states/<userID>/<botName>", and values are strings
+type Store interface {
+	// Get returns the value of the key and whether the key was found
+	Get(key string) (string, bool, error)
+	// Set creates or replaces the value of the key
+	Set(key string, value string) error
+	// Delete removes the key, deleting a key that does not exist is not an error
+	Delete(key string) error
+	// List returns the keys that start with the segments of the prefix in sorted order, e.g. the prefix "bot_states/<userID>" lists the
+	// states of all the bots of the user
+	List(prefix string) ([]string, error)
+}
+
+// FileStore is a Store that keeps every key in a file inside a directory, where the segments of the key are the subdirectories
+type FileStore struct {
+	dir string
+}
+
+var _ Store = &FileStore{}
+
+// MakeFileStore is a factory method, the directory is created when the first key is set
+func MakeFileStore(dir string) *FileStore {
+	return &FileStore{
+		dir: dir,
+	}
+}
+
+// filePath converts the key to the path of its file, every segment is escaped so keys can contain any characters such as the spaces in
+// bot names or the '|' in auth0 user IDs
+func (s *FileStore) filePath(key string) (string, error) {
+	segments := strings.Split(key, "/")
+	escaped := []string{s.dir}
+	for _, segment := range segments {
+		if segment == "" || segment == "." || segment == ".." {
+			return "", fmt.Errorf("invalid key '%s', segments cannot be empty, '.' or '..'", key)
+		}
+		escaped = append(escaped, url.PathEscape(segment))
+	}
+	return filepath.Join(escaped...), nil
+}
+
+// Get impl.
+func (s *FileStore) Get(key string) (string, bool, error) {
+	path, e := s.filePath(key)
+	if e != nil {
+		return "", false, e
+	}
+
+	value, e := ioutil.ReadFile(path)
+	if os.IsNotExist(e) {
+		return "", false, nil
+	} else if e != nil {
+		return "", false, fmt.Errorf("could not read file '%s' of key '%s': %s", path, key, e)
+	}
+	return string(value), true, nil
+}
+
+// Set impl. writes to a temporary file first so the value is never left half written
+func (s *FileStore) Set(key string, value string) error {
+	path, e := s.filePath(key)
+	if e != nil {
+		return e
+	}
+
+	e = os.MkdirAll(filepath.Dir(path), 0700)
+	if e != nil {
+		return fmt.Errorf("could not make the directory of key '%s': %s", key, e)
+	}
+	tmpPath := path + ".tmp"
+	e = ioutil.WriteFile(tmpPath, []byte(value), 0600)
+	if e != nil {
+		return fmt.Errorf("could not write file '%s' of key '%s': %s", tmpPath, key, e)
+	}
+	e = os.Rename(tmpPath, path)
+	if e != nil {
+		return fmt.Errorf("could not rename file '%s' of key '%s': %s", tmpPath, key, e)
+	}
+	return nil
+}
+
+// Delete impl.
+func (s *FileStore) Delete(key string) error {
+	path, e := s.filePath(key)
+	if e != nil {
+		return e
+	}
+
+	e = os.Remove(path)
+	if e != nil && !os.IsNotExist(e) {
+		return fmt.Errorf("could not delete file '%s' of key '%s': %s", path, key, e)
+	}
+	return nil
+}
+
+// List impl.
+func (s *FileStore) List(prefix string) ([]string, error) {
+	dir, e := s.filePath(prefix)
+	if e != nil {
+		return nil, e
+	}
+
+	keys := []string{}
+	e = filepath.Walk(dir, func(path string, info os.FileInfo, e error) error {
+		if e != nil {
+			return e
+		}
+		// skip the temporary files of values that are being set
+		if info.IsDir() || strings.HasSuffix(path, ".tmp") {
+			return nil
+		}
+		rel, e := filepath.Rel(s.dir, path)
+		if e != nil {
+			return e
+		}
+		segments := strings.Split(filepath.ToSlash(rel), "/")
+		for i, segment := range segments {
+			segments[i], e = url.PathUnescape(segment)
+			if e != nil {
+				return e
+			}
+		}
+		keys = append(keys, strings.Join(segments, "/"))
+		return nil
+	})
+	if os.IsNotExist(e) {
+		return keys, nil
+	} else if e != nil {
+		return nil, fmt.Errorf("could not list the files of prefix '%s': %s", prefix, e)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// sqlStateTableCreate creates the table of the DBStore
+const sqlStateTableCreate = "CREATE TABLE IF NOT EXISTS kelp_state (key TEXT PRIMARY KEY, value TEXT NOT NULL, updated_at_utc TIMESTAMP WITHOUT TIME ZONE NOT NULL)"
+
+// sqlLogsTableCreate and sqlLogsIndexCreate create the table where the DBStore keeps the log lines of the bots and the GUI server
+const (
+	sqlLogsTableCreate = "CREATE TABLE IF NOT EXISTS kelp_logs (id BIGSERIAL PRIMARY KEY, name TEXT NOT NULL, logged_at_utc TIMESTAMP WITHOUT TIME ZONE NOT NULL, line TEXT NOT NULL)"
+	sqlLogsIndexCreate = "CREATE INDEX IF NOT EXISTS kelp_logs_name_logged_at_utc ON kelp_logs (name, logged_at_utc)"
+)
+
+// sqlStateQuery, sqlStateUpsert, sqlStateDelete, sqlStateList and sqlLogsInsert are the statements of the DBStore
+const (
+	sqlStateQuery  = "SELECT value FROM kelp_state WHERE key = $1"
+	sqlStateUpsert = "INSERT INTO kelp_state (key, value, updated_at_utc) VALUES ($1, $2, $3) ON CONFLICT (key) DO UPDATE SET value = EXCLUDED.value, updated_at_utc = EXCLUDED.updated_at_utc"
+	sqlStateDelete = "DELETE FROM kelp_state WHERE key = $1"
+	sqlStateList   = "SELECT key FROM kelp_state WHERE key LIKE $1 ESCAPE '\\' ORDER BY key"
+	sqlLogsInsert  = "INSERT INTO kelp_logs (name, logged_at_utc, line) VALUES ($1, $2, $3)"
+)
+
+// DBStore is a Store that keeps the keys in the kelp_state table of a postgres database, so deployments do not need a writable local volume
+type DBStore struct {
+	db *sql.DB
+}
+
+var _ Store = &DBStore{}
+
+// MakeDBStore is a factory method that creates the kelp_state table if it does not exist
+func MakeDBStore(db *sql.DB) (*DBStore, error) {
+	if db == nil {
+		return nil, fmt.Errorf("the provided db should be non-nil")
+	}
+
+	for _, sqlCreate := range []string{sqlStateTableCreate, sqlLogsTableCreate, sqlLogsIndexCreate} {
+		_, e := db.Exec(sqlCreate)
+		if e != nil {
+			return nil, fmt.Errorf("could not create the tables of the DBStore (%s): %s", sqlCreate, e)
+		}
+	}
+	return &DBStore{
+		db: db,
+	}, nil
+}
+
+// Get impl.
+func (s *DBStore) Get(key string) (string, bool, error) {
+	var value string
+	e := s.db.QueryRow(sqlStateQuery, key).Scan(&value)
+	if e == sql.ErrNoRows {
+		return "", false, nil
+	} else if e != nil {
+		return "", false, fmt.Errorf("could not query key '%s': %s", key, e)
+	}
+	return value, true, nil
+}
+
+// Set impl.
+func (s *DBStore) Set(key string, value string) error {
+	_, e := s.db.Exec(sqlStateUpsert, key, value, time.Now().UTC())
+	if e != nil {
+		return fmt.Errorf("could not upsert key '%s': %s", key, e)
+	}
+	return nil
+}
+
+// Delete impl.
+func (s *DBStore) Delete(key string) error {
+	_, e := s.db.Exec(sqlStateDelete, key)
+	if e != nil {
+		return fmt.Errorf("could not delete key '%s': %s", key, e)
+	}
+	return nil
+}
+
+// List impl.
+func (s *DBStore) List(prefix string) ([]string, error) {
+	if prefix == "" {
+		return nil, fmt.Errorf("invalid prefix, cannot be empty")
+	}
+	likeEscaper := strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_")
+	rows, e := s.db.Query(sqlStateList, likeEscaper.Replace(prefix)+"/%")
+	if e != nil {
+		return nil, fmt.Errorf("could not list the keys of prefix '%s': %s", prefix, e)
+	}
+	defer rows.Close()
+
+	keys := []string{}
+	for rows.Next() {
+		var key string
+		e = rows.Scan(&key)
+		if e != nil {
+			return nil, fmt.Errorf("could not scan the keys of prefix '%s': %s", prefix, e)
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// LogLine is a line of a log that is kept in the DBStore
+type LogLine struct {
+	Time time.Time
+	Line string
+}
+
+// AppendLog inserts the lines at the end of the log with the name in one transaction
+func (s *DBStore) AppendLog(name string, lines []LogLine) error {
+	tx, e := s.db.Begin()
+	if e != nil {
+		return fmt.Errorf("could not begin the transaction to append to log '%s': %s", name, e)
+	}
+	for _, l := range lines {
+		_, e = tx.Exec(sqlLogsInsert, name, l.Time.UTC(), l.Line)
+		if e != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("could not append to log '%s': %s", name, e)
+		}
+	}
+	e = tx.Commit()
+	if e != nil {
+		return fmt.Errorf("could not commit the lines of log '%s': %s", name, e)
+	}
+	return nil
+}
+
+// logFlushInterval is how often a DBLogWriter writes its lines to the db and maxPendingLogLines is how many lines it buffers while the db is
+// unavailable before it drops the oldest lines
+const (
+	logFlushInterval   = time.Second
+	maxPendingLogLines = 100000
+)
+
+// DBLogWriter is an io.Writer that appends the lines that are written to it to a log in the DBStore. The lines are written to the db in the
+// background so logging does not wait for the db, which means the lines of the last flush interval are lost if the process exits without
+// calling Flush
+type DBLogWriter struct {
+	store *DBStore
+
+	// uninitialized
+	lock      sync.Mutex
+	flushLock sync.Mutex // keeps the lines in order when a flush fails while another flush is waiting
+	name      string
+	partial   string
+	pending   []LogLine
+}
+
+var _ io.Writer = &DBLogWriter{}
+
+// MakeLogWriter returns a DBLogWriter that appends to the log with the name, it flushes the lines every logFlushInterval until the process exits
+func (s *DBStore) MakeLogWriter(name string) *DBLogWriter {
+	w := &DBLogWriter{
+		store: s,
+		name:  name,
+	}
+	go func() {
+		for range time.Tick(logFlushInterval) {
+			e := w.Flush()
+			if e != nil {
+				// the log output is this writer, so we cannot use the log package to report that it is failing
+				fmt.Fprintf(os.Stderr, "unable to flush the log lines to the db, will retry: %s\n", e)
+			}
+		}
+	}()
+	return w
+}
+
+// Write impl. buffers the complete lines of p, a line that is not terminated is kept until the rest of the line is written
+func (w *DBLogWriter) Write(p []byte) (int, error) {
+	now := time.Now()
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	lines := strings.Split(w.partial+string(p), "\n")
+	w.partial = lines[len(lines)-1]
+	for _, line := range lines[:len(lines)-1] {
+		w.pending = append(w.pending, LogLine{Time: now, Line: line})
+	}
+	if len(w.pending) > maxPendingLogLines {
+		w.pending = w.pending[len(w.pending)-maxPendingLogLines:]
+	}
+	return len(p), nil
+}
+
+// SetName flushes the lines that were written so far and appends the lines that are written from now on to the log with the new name
+func (w *DBLogWriter) SetName(name string) error {
+	e := w.Flush()
+	w.lock.Lock()
+	w.name = name
+	w.lock.Unlock()
+	return e
+}
+
+// Flush writes the buffered lines to the db, the lines are kept to be written with the next flush when the db cannot be reached
+func (w *DBLogWriter) Flush() error {
+	w.flushLock.Lock()
+	defer w.flushLock.Unlock()
+
+	w.lock.Lock()
+	name := w.name
+	lines := w.pending
+	w.pending = nil
+	w.lock.Unlock()
+	if len(lines) == 0 {
+		return nil
+	}
+
+	e := w.store.AppendLog(name, lines)
+	if e != nil {
+		w.lock.Lock()
+		w.pending = append(lines, w.pending...)
+		if len(w.pending) > maxPendingLogLines {
+			w.pending = w.pending[len(w.pending)-maxPendingLogLines:]
+		}
+		w.lock.Unlock()
+		return e
+	}
+	return nil
+}
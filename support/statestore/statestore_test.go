@@ -0,0 +1,112 @@
+package statestore
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileStore(t *testing.T) {
+	dir, e := ioutil.TempDir("", "statestore")
+	if !assert.NoError(t, e) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	s := MakeFileStore(filepath.Join(dir, "state"))
+
+	_, found, e := s.Get("kelp.prefs")
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.False(t, found)
+
+	// keys with characters that are not allowed in file names
+	key := "bot_states/auth0|user/my bot"
+	if !assert.NoError(t, s.Set(key, "running")) {
+		return
+	}
+	value, found, e := s.Get(key)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.True(t, found)
+	assert.Equal(t, "running", value)
+	assert.FileExists(t, filepath.Join(dir, "state", "bot_states", "auth0%7Cuser", "my%20bot"))
+
+	if !assert.NoError(t, s.Set(key, "stopped")) {
+		return
+	}
+	value, _, e = s.Get(key)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, "stopped", value)
+
+	if !assert.NoError(t, s.Delete(key)) {
+		return
+	}
+	_, found, e = s.Get(key)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.False(t, found)
+	assert.NoError(t, s.Delete(key))
+}
+
+func TestFileStore_List(t *testing.T) {
+	dir, e := ioutil.TempDir("", "statestore")
+	if !assert.NoError(t, e) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	s := MakeFileStore(filepath.Join(dir, "state"))
+
+	keys, e := s.List("bot_configs/auth0|user")
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, []string{}, keys)
+
+	for _, key := range []string{"bot_configs/auth0|user/b.cfg", "bot_configs/auth0|user/archived/a.cfg", "bot_configs/other/c.cfg", "kelp.prefs"} {
+		if !assert.NoError(t, s.Set(key, "value")) {
+			return
+		}
+	}
+	keys, e = s.List("bot_configs/auth0|user")
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, []string{"bot_configs/auth0|user/archived/a.cfg", "bot_configs/auth0|user/b.cfg"}, keys)
+}
+
+func TestDBLogWriter_Write(t *testing.T) {
+	w := &DBLogWriter{name: "bot.log"}
+	w.Write([]byte("first line\nsecond "))
+	w.Write([]byte("line\n"))
+	w.Write([]byte("third"))
+
+	lines := []string{}
+	for _, l := range w.pending {
+		lines = append(lines, l.Line)
+	}
+	assert.Equal(t, []string{"first line", "second line"}, lines)
+	assert.Equal(t, "third", w.partial)
+}
+
+func TestFileStore_InvalidKeys(t *testing.T) {
+	s := MakeFileStore("state")
+	for _, key := range []string{"", "a//b", "../a", "a/./b", "a/"} {
+		_, _, e := s.Get(key)
+		assert.Error(t, e, key)
+		assert.Error(t, s.Set(key, "value"), key)
+		assert.Error(t, s.Delete(key), key)
+	}
+}
+
+func TestMakeDBStore_Nil(t *testing.T) {
+	_, e := MakeDBStore(nil)
+	assert.Error(t, e)
+}
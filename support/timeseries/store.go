@@ -0,0 +1,246 @@
+package timeseries
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/stellar/kelp/kelpdb"
+	"github.com/stellar/kelp/support/postgresdb"
+)
+
+// Series names for the features that store their history in the timeseries tables, labels distinguish the instances within a series (e.g. a market)
+const (
+	SeriesPriceHistory     = "price_history"
+	SeriesSpreadAnalytics  = "spread_analytics"
+	SeriesInventoryHistory = "inventory_history"
+)
+
+// RetentionPolicy controls how long the data of a series is kept. Raw points older than RawRetention are downsampled into buckets of
+// DownsampleInterval and then deleted, and the buckets are deleted once they are older than DownsampleRetention.
+type RetentionPolicy struct {
+	RawRetention        time.Duration
+	DownsampleInterval  time.Duration // 0 deletes raw points without downsampling them
+	DownsampleRetention time.Duration // 0 keeps the downsampled buckets forever
+}
+
+// String is the stringer method
+func (p RetentionPolicy) String() string {
+	return fmt.Sprintf("RetentionPolicy[RawRetention=%s, DownsampleInterval=%s, DownsampleRetention=%s]", p.RawRetention, p.DownsampleInterval, p.DownsampleRetention)
+}
+
+func (p RetentionPolicy) validate() error {
+	if p.RawRetention <= 0 {
+		return fmt.Errorf("RawRetention needs to be positive, was %s", p.RawRetention)
+	}
+	if p.DownsampleInterval < 0 || p.DownsampleInterval%time.Second != 0 {
+		return fmt.Errorf("DownsampleInterval needs to be a non-negative whole number of seconds, was %s", p.DownsampleInterval)
+	}
+	if p.DownsampleRetention < 0 {
+		return fmt.Errorf("DownsampleRetention cannot be negative, was %s", p.DownsampleRetention)
+	}
+	if p.DownsampleRetention > 0 && p.DownsampleRetention < p.RawRetention {
+		return fmt.Errorf("DownsampleRetention (%s) cannot be less than RawRetention (%s)", p.DownsampleRetention, p.RawRetention)
+	}
+	return nil
+}
+
+// rawCutoff is the time before which raw points are downsampled and deleted. It is aligned to the start of a bucket so a bucket is always
+// downsampled from all of its points at once.
+func (p RetentionPolicy) rawCutoff(now time.Time) time.Time {
+	cutoff := now.UTC().Add(-p.RawRetention)
+	if p.DownsampleInterval > 0 {
+		cutoff = cutoff.Truncate(p.DownsampleInterval)
+	}
+	return cutoff
+}
+
+// DefaultRetentionPolicies are the retention policies used for the known series
+var DefaultRetentionPolicies = map[string]RetentionPolicy{
+	SeriesPriceHistory: RetentionPolicy{
+		RawRetention:        7 * 24 * time.Hour,
+		DownsampleInterval:  time.Hour,
+		DownsampleRetention: 365 * 24 * time.Hour,
+	},
+	SeriesSpreadAnalytics: RetentionPolicy{
+		RawRetention:        7 * 24 * time.Hour,
+		DownsampleInterval:  time.Hour,
+		DownsampleRetention: 90 * 24 * time.Hour,
+	},
+	SeriesInventoryHistory: RetentionPolicy{
+		RawRetention:        30 * 24 * time.Hour,
+		DownsampleInterval:  24 * time.Hour,
+		DownsampleRetention: 0,
+	},
+}
+
+// Point is a single value in a series
+type Point struct {
+	Time  time.Time
+	Value float64
+}
+
+// Bucket is a downsampled range of points in a series starting at Start
+type Bucket struct {
+	Start     time.Time
+	Min       float64
+	Max       float64
+	Avg       float64
+	NumPoints int
+}
+
+// Store is the storage layer for time series data in the database, shared by all features so they don't need to maintain their own tables
+type Store struct {
+	db       *sql.DB
+	policies map[string]RetentionPolicy
+}
+
+// MakeStore is a factory method
+func MakeStore(db *sql.DB, policies map[string]RetentionPolicy) (*Store, error) {
+	if db == nil {
+		return nil, fmt.Errorf("db should not be nil when making a timeseries store")
+	}
+	for series, policy := range policies {
+		e := validateName("series", series)
+		if e != nil {
+			return nil, e
+		}
+		e = policy.validate()
+		if e != nil {
+			return nil, fmt.Errorf("invalid retention policy for series '%s': %s", series, e)
+		}
+	}
+
+	return &Store{
+		db:       db,
+		policies: policies,
+	}, nil
+}
+
+// validateName ensures the series and label names can be safely used in our sql templates
+func validateName(kind string, name string) error {
+	if name == "" {
+		return fmt.Errorf("%s cannot be empty", kind)
+	}
+	if strings.ContainsAny(name, "'\\") {
+		return fmt.Errorf("%s '%s' cannot contain quotes or backslashes", kind, name)
+	}
+	return nil
+}
+
+// Append adds a point to the series under the label, replacing any existing point with the same timestamp
+func (s *Store) Append(series string, label string, t time.Time, value float64) error {
+	if _, ok := s.policies[series]; !ok {
+		return fmt.Errorf("no retention policy registered for series '%s'", series)
+	}
+	e := validateName("label", label)
+	if e != nil {
+		return e
+	}
+
+	sqlUpsert := fmt.Sprintf(kelpdb.SqlTimeseriesPointsUpsertTemplate,
+		series,
+		label,
+		t.UTC().Format(postgresdb.TimestampFormatString),
+		value,
+	)
+	_, e = s.db.Exec(sqlUpsert)
+	if e != nil {
+		return fmt.Errorf("could not execute sql upsert statement (%s): %s", sqlUpsert, e)
+	}
+	return nil
+}
+
+// QueryPoints returns the raw points of the series under the label in the range [start, end)
+func (s *Store) QueryPoints(series string, label string, start time.Time, end time.Time) ([]Point, error) {
+	rows, e := s.db.Query(kelpdb.SqlQueryTimeseriesPoints, series, label, start.UTC(), end.UTC())
+	if e != nil {
+		return nil, fmt.Errorf("could not execute sql select query (%s) for series '%s' and label '%s': %s", kelpdb.SqlQueryTimeseriesPoints, series, label, e)
+	}
+	defer rows.Close()
+
+	points := []Point{}
+	for rows.Next() {
+		var p Point
+		e = rows.Scan(&p.Time, &p.Value)
+		if e != nil {
+			return nil, fmt.Errorf("could not scan row into timeseries point: %s", e)
+		}
+		points = append(points, p)
+	}
+	return points, rows.Err()
+}
+
+// QueryBuckets returns the downsampled buckets of the series under the label in the range [start, end)
+func (s *Store) QueryBuckets(series string, label string, start time.Time, end time.Time) ([]Bucket, error) {
+	rows, e := s.db.Query(kelpdb.SqlQueryTimeseriesRollups, series, label, start.UTC(), end.UTC())
+	if e != nil {
+		return nil, fmt.Errorf("could not execute sql select query (%s) for series '%s' and label '%s': %s", kelpdb.SqlQueryTimeseriesRollups, series, label, e)
+	}
+	defer rows.Close()
+
+	buckets := []Bucket{}
+	for rows.Next() {
+		var b Bucket
+		e = rows.Scan(&b.Start, &b.Min, &b.Max, &b.Avg, &b.NumPoints)
+		if e != nil {
+			return nil, fmt.Errorf("could not scan row into timeseries bucket: %s", e)
+		}
+		buckets = append(buckets, b)
+	}
+	return buckets, rows.Err()
+}
+
+// ApplyRetention downsamples and deletes the data of every series according to its retention policy
+func (s *Store) ApplyRetention(now time.Time) error {
+	for series, policy := range s.policies {
+		e := s.applyRetention(series, policy, now)
+		if e != nil {
+			return fmt.Errorf("could not apply retention policy (%s) for series '%s': %s", policy, series, e)
+		}
+	}
+	return nil
+}
+
+func (s *Store) applyRetention(series string, policy RetentionPolicy, now time.Time) error {
+	tx, e := s.db.Begin()
+	if e != nil {
+		return fmt.Errorf("could not begin db transaction: %s", e)
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback()
+		}
+	}()
+
+	// downsampling and deleting the raw points happen in the same transaction so points are never lost or counted twice
+	rawCutoff := policy.rawCutoff(now).Format(postgresdb.TimestampFormatString)
+	statements := []string{}
+	if policy.DownsampleInterval > 0 {
+		statements = append(statements, fmt.Sprintf(kelpdb.SqlTimeseriesRollupsDownsampleTemplate, series, int64(policy.DownsampleInterval/time.Second), rawCutoff))
+	}
+	statements = append(statements, fmt.Sprintf(kelpdb.SqlTimeseriesPointsDeleteTemplate, series, rawCutoff))
+	if policy.DownsampleRetention > 0 {
+		downsampleCutoff := now.UTC().Add(-policy.DownsampleRetention).Format(postgresdb.TimestampFormatString)
+		statements = append(statements, fmt.Sprintf(kelpdb.SqlTimeseriesRollupsDeleteTemplate, series, downsampleCutoff))
+	}
+
+	for _, statement := range statements {
+		_, e = tx.Exec(statement)
+		if e != nil {
+			return fmt.Errorf("could not execute sql statement (%s): %s", statement, e)
+		}
+	}
+
+	e = tx.Commit()
+	if e != nil {
+		return fmt.Errorf("could not commit db transaction: %s", e)
+	}
+	committed = true
+
+	log.Printf("timeseries: applied retention for series '%s' with rawCutoff=%s (%s)\n", series, rawCutoff, policy)
+	return nil
+}
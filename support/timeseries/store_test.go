@@ -0,0 +1,74 @@
+package timeseries
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetentionPolicyValidate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		policy  RetentionPolicy
+		wantErr bool
+	}{
+		{
+			name:    "defaults",
+			policy:  DefaultRetentionPolicies[SeriesPriceHistory],
+			wantErr: false,
+		}, {
+			name:    "no downsampling",
+			policy:  RetentionPolicy{RawRetention: time.Hour},
+			wantErr: false,
+		}, {
+			name:    "zero raw retention",
+			policy:  RetentionPolicy{RawRetention: 0},
+			wantErr: true,
+		}, {
+			name:    "fractional downsample interval",
+			policy:  RetentionPolicy{RawRetention: time.Hour, DownsampleInterval: 1500 * time.Millisecond},
+			wantErr: true,
+		}, {
+			name:    "downsample retention shorter than raw retention",
+			policy:  RetentionPolicy{RawRetention: 2 * time.Hour, DownsampleInterval: time.Minute, DownsampleRetention: time.Hour},
+			wantErr: true,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			e := k.policy.validate()
+			assert.Equal(t, k.wantErr, e != nil)
+		})
+	}
+}
+
+func TestRetentionPolicyRawCutoff(t *testing.T) {
+	now := time.Date(2020, 3, 15, 10, 47, 31, 0, time.UTC)
+	testCases := []struct {
+		name   string
+		policy RetentionPolicy
+		want   time.Time
+	}{
+		{
+			name:   "no downsampling",
+			policy: RetentionPolicy{RawRetention: time.Hour},
+			want:   time.Date(2020, 3, 15, 9, 47, 31, 0, time.UTC),
+		}, {
+			name:   "aligned to hourly bucket",
+			policy: RetentionPolicy{RawRetention: 24 * time.Hour, DownsampleInterval: time.Hour},
+			want:   time.Date(2020, 3, 14, 10, 0, 0, 0, time.UTC),
+		}, {
+			name:   "aligned to daily bucket",
+			policy: RetentionPolicy{RawRetention: 24 * time.Hour, DownsampleInterval: 24 * time.Hour},
+			want:   time.Date(2020, 3, 14, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			assert.Equal(t, k.want, k.policy.rawCutoff(now))
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package toml
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/stellar/go/support/config"
+)
+
+// ReadConfig reads the toml config file into dest. When a profile is specified the values in the section of that profile are merged
+// over the shared values at the top level of the file, so a single file can hold the configs for more than one network, for example:
+//
+//	TRADING_SECRET_SEED="..."
+//	[testnet]
+//	HORIZON_URL="https://horizon-testnet.stellar.org"
+//	[pubnet]
+//	HORIZON_URL="https://horizon.stellar.org"
+//
+// Profile sections are the top-level tables with lowercase names, which cannot clash with the uppercase keys of the configs.
+// When the profile is empty the file is read as-is.
+func ReadConfig(filePath string, profile string, dest interface{}) error {
+	if profile == "" {
+		return config.Read(filePath, dest)
+	}
+
+	fileBytes, e := ioutil.ReadFile(filePath)
+	if e != nil {
+		return fmt.Errorf("could not read config file '%s': %s", filePath, e)
+	}
+	var raw map[string]interface{}
+	_, e = toml.Decode(string(fileBytes), &raw)
+	if e != nil {
+		return fmt.Errorf("could not decode config file '%s' as toml: %s", filePath, e)
+	}
+
+	merged, e := mergeProfile(raw, profile)
+	if e != nil {
+		return fmt.Errorf("could not use profile in config file '%s': %s", filePath, e)
+	}
+
+	// round-trip the merged values through the encoder so they are decoded into dest with the same rules as a regular config file
+	var mergedBuf bytes.Buffer
+	e = toml.NewEncoder(&mergedBuf).Encode(merged)
+	if e != nil {
+		return fmt.Errorf("error encoding merged profile '%s' as toml: %s", profile, e)
+	}
+	md, e := toml.Decode(mergedBuf.String(), dest)
+	if e != nil {
+		return fmt.Errorf("could not decode profile '%s' of config file '%s': %s", profile, filePath, e)
+	}
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		return fmt.Errorf("profile '%s' of config file '%s' has unknown fields: %v", profile, filePath, undecoded)
+	}
+	return nil
+}
+
+// isProfileName returns true for keys that are reserved for profile sections
+func isProfileName(key string) bool {
+	return key != "" && key == strings.ToLower(key)
+}
+
+// mergeProfile returns the top-level values without any of the profile sections, with the values of the profile merged over them.
+// Tables are merged key by key while all other values, including arrays of tables, are replaced.
+func mergeProfile(raw map[string]interface{}, profile string) (map[string]interface{}, error) {
+	if !isProfileName(profile) {
+		return nil, fmt.Errorf("invalid profile name '%s', profile names need to be lowercase", profile)
+	}
+
+	merged := map[string]interface{}{}
+	profiles := []string{}
+	for k, v := range raw {
+		if isProfileName(k) {
+			profiles = append(profiles, k)
+			continue
+		}
+		merged[k] = v
+	}
+	sort.Strings(profiles)
+
+	section, ok := raw[profile]
+	if !ok {
+		return nil, fmt.Errorf("profile '%s' was not found, available profiles: %v", profile, profiles)
+	}
+	sectionTable, ok := section.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("profile '%s' needs to be a table (i.e. [%s]) but was of type %T", profile, profile, section)
+	}
+	mergeTables(merged, sectionTable)
+	return merged, nil
+}
+
+// mergeTables sets the values of src on dst, recursing into tables that exist in both
+func mergeTables(dst map[string]interface{}, src map[string]interface{}) {
+	for k, srcValue := range src {
+		srcTable, srcIsTable := srcValue.(map[string]interface{})
+		dstTable, dstIsTable := dst[k].(map[string]interface{})
+		if srcIsTable && dstIsTable {
+			mergedTable := map[string]interface{}{}
+			for dk, dv := range dstTable {
+				mergedTable[dk] = dv
+			}
+			mergeTables(mergedTable, srcTable)
+			dst[k] = mergedTable
+			continue
+		}
+		dst[k] = srcValue
+	}
+}
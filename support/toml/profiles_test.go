@@ -0,0 +1,98 @@
+package toml
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testProfileDBConfig struct {
+	Host     string `toml:"HOST"`
+	Database string `toml:"DATABASE"`
+}
+
+type testProfileConfig struct {
+	TradingSecretSeed string               `toml:"TRADING_SECRET_SEED"`
+	HorizonURL        string               `toml:"HORIZON_URL"`
+	TickIntervalMs    int32                `toml:"TICK_INTERVAL_MS"`
+	PostgresDbConfig  *testProfileDBConfig `toml:"POSTGRES_DB"`
+}
+
+const testProfileFile = `
+TRADING_SECRET_SEED="SSHARED"
+TICK_INTERVAL_MS=5000
+
+[POSTGRES_DB]
+HOST="localhost"
+DATABASE="kelp"
+
+[testnet]
+HORIZON_URL="https://horizon-testnet.stellar.org"
+
+[pubnet]
+HORIZON_URL="https://horizon.stellar.org"
+TICK_INTERVAL_MS=10000
+[pubnet.POSTGRES_DB]
+DATABASE="kelp_pubnet"
+`
+
+func writeTestProfileFile(t *testing.T, dir string, filename string, content string) string {
+	filePath := filepath.Join(dir, filename)
+	e := ioutil.WriteFile(filePath, []byte(content), 0644)
+	if e != nil {
+		t.Fatal(e)
+	}
+	return filePath
+}
+
+func TestReadConfigWithProfile(t *testing.T) {
+	dir, e := ioutil.TempDir("", "profiles")
+	if !assert.NoError(t, e) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	filePath := writeTestProfileFile(t, dir, "trader.cfg", testProfileFile)
+
+	var testnet testProfileConfig
+	e = ReadConfig(filePath, "testnet", &testnet)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, "SSHARED", testnet.TradingSecretSeed)
+	assert.Equal(t, "https://horizon-testnet.stellar.org", testnet.HorizonURL)
+	assert.Equal(t, int32(5000), testnet.TickIntervalMs)
+	assert.Equal(t, &testProfileDBConfig{Host: "localhost", Database: "kelp"}, testnet.PostgresDbConfig)
+
+	var pubnet testProfileConfig
+	e = ReadConfig(filePath, "pubnet", &pubnet)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, "SSHARED", pubnet.TradingSecretSeed)
+	assert.Equal(t, "https://horizon.stellar.org", pubnet.HorizonURL)
+	assert.Equal(t, int32(10000), pubnet.TickIntervalMs)
+	// tables are merged key by key
+	assert.Equal(t, &testProfileDBConfig{Host: "localhost", Database: "kelp_pubnet"}, pubnet.PostgresDbConfig)
+}
+
+func TestReadConfigWithProfileErrors(t *testing.T) {
+	dir, e := ioutil.TempDir("", "profiles")
+	if !assert.NoError(t, e) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	filePath := writeTestProfileFile(t, dir, "trader.cfg", testProfileFile)
+
+	var cfg testProfileConfig
+	assert.Error(t, ReadConfig(filePath, "futurenet", &cfg))
+	assert.Error(t, ReadConfig(filePath, "PUBNET", &cfg))
+
+	unknownFieldPath := writeTestProfileFile(t, dir, "unknown_field.cfg", testProfileFile+"\n[testnet.UNKNOWN]\nKEY=1\n")
+	assert.Error(t, ReadConfig(unknownFieldPath, "testnet", &cfg))
+
+	notTablePath := writeTestProfileFile(t, dir, "not_table.cfg", "staging=1\n"+testProfileFile)
+	assert.Error(t, ReadConfig(notTablePath, "staging", &cfg))
+}
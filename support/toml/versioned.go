@@ -0,0 +1,171 @@
+package toml
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"log"
+
+	"github.com/BurntSushi/toml"
+	"github.com/asaskevich/govalidator"
+)
+
+// VersionKey is the top-level key that holds the schema version of a versioned config file, files without it are at version 1
+const VersionKey = "CONFIG_VERSION"
+
+// Migration upgrades the raw values of a config file from FromVersion to FromVersion+1 and returns a warning for each value it changed
+type Migration struct {
+	FromVersion int
+	Migrate     func(raw map[string]interface{}) ([]string, error)
+}
+
+// Schema is the current version of a config format together with the migrations that upgrade older files to it, so releases can
+// rename fields or change units without breaking the config files of existing users
+type Schema struct {
+	Name       string
+	Version    int
+	Migrations []Migration
+}
+
+// Upgrade migrates the raw values of a config file to the current version of the schema in place and returns the warnings of the
+// migrations that were applied. The version key is removed from raw since it is not a field of the config.
+func (s Schema) Upgrade(raw map[string]interface{}) ([]string, error) {
+	version := 1
+	if v, ok := raw[VersionKey]; ok {
+		n, ok := v.(int64)
+		if !ok {
+			return nil, fmt.Errorf("%s needs to be an integer but was of type %T", VersionKey, v)
+		}
+		version = int(n)
+	}
+	delete(raw, VersionKey)
+
+	if version < 1 {
+		return nil, fmt.Errorf("invalid %s %d, versions start at 1", VersionKey, version)
+	}
+	if version > s.Version {
+		return nil, fmt.Errorf("%s %d is newer than version %d of the %s config supported by this release, upgrade kelp to use this file", VersionKey, version, s.Version, s.Name)
+	}
+
+	warnings := []string{}
+	for ; version < s.Version; version++ {
+		m, e := s.migrationFrom(version)
+		if e != nil {
+			return nil, e
+		}
+		w, e := m.Migrate(raw)
+		if e != nil {
+			return nil, fmt.Errorf("could not migrate the %s config from version %d to %d: %s", s.Name, version, version+1, e)
+		}
+		warnings = append(warnings, w...)
+	}
+	return warnings, nil
+}
+
+func (s Schema) migrationFrom(version int) (Migration, error) {
+	for _, m := range s.Migrations {
+		if m.FromVersion == version {
+			return m, nil
+		}
+	}
+	return Migration{}, fmt.Errorf("the %s config schema has no migration from version %d", s.Name, version)
+}
+
+// ReadVersionedConfig reads the config file into dest after upgrading it to the current version of the schema, logging a warning for
+// every value that was migrated so users know how to update the file. Like config.Read from stellar/go, dest is validated with the
+// `valid` tags of its fields
+func ReadVersionedConfig(filePath string, schema Schema, dest interface{}) error {
+	fileBytes, e := ioutil.ReadFile(filePath)
+	if e != nil {
+		return fmt.Errorf("could not read config file '%s': %s", filePath, e)
+	}
+	var raw map[string]interface{}
+	_, e = toml.Decode(string(fileBytes), &raw)
+	if e != nil {
+		return fmt.Errorf("could not decode config file '%s' as toml: %s", filePath, e)
+	}
+	if raw == nil {
+		raw = map[string]interface{}{}
+	}
+
+	warnings, e := schema.Upgrade(raw)
+	if e != nil {
+		return fmt.Errorf("could not upgrade config file '%s': %s", filePath, e)
+	}
+	for _, w := range warnings {
+		log.Printf("config migration warning (%s): %s, set %s=%d after updating the file to stop this warning\n", filePath, w, VersionKey, schema.Version)
+	}
+
+	// round-trip the migrated values through the encoder so they are decoded into dest with the same rules as a regular config file
+	var migratedBuf bytes.Buffer
+	e = toml.NewEncoder(&migratedBuf).Encode(raw)
+	if e != nil {
+		return fmt.Errorf("error encoding migrated config file '%s' as toml: %s", filePath, e)
+	}
+	md, e := toml.Decode(migratedBuf.String(), dest)
+	if e != nil {
+		return fmt.Errorf("could not decode config file '%s': %s", filePath, e)
+	}
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		return fmt.Errorf("config file '%s' has unknown fields: %v", filePath, undecoded)
+	}
+
+	valid, e := govalidator.ValidateStruct(dest)
+	if !valid {
+		return fmt.Errorf("config file '%s' has invalid fields: %v", filePath, govalidator.ErrorsByField(e))
+	}
+	return nil
+}
+
+// WriteVersionedFile writes v as a toml file stamped with the current version of the schema
+func WriteVersionedFile(filePath string, schema Schema, v interface{}) error {
+	var fileBuf bytes.Buffer
+	fmt.Fprintf(&fileBuf, "%s = %d\n\n", VersionKey, schema.Version)
+	e := toml.NewEncoder(&fileBuf).Encode(v)
+	if e != nil {
+		return fmt.Errorf("error encoding file as toml: %s", e)
+	}
+	return ioutil.WriteFile(filePath, fileBuf.Bytes(), 0644)
+}
+
+// RenameField moves the value of a top-level field to its new name, the value of the new name is kept when both are set
+func RenameField(raw map[string]interface{}, from string, to string) []string {
+	v, ok := raw[from]
+	if !ok {
+		return nil
+	}
+	delete(raw, from)
+	if _, exists := raw[to]; exists {
+		return []string{fmt.Sprintf("'%s' was renamed to '%s' which is also set, ignoring the value of '%s'", from, to, from)}
+	}
+	raw[to] = v
+	return []string{fmt.Sprintf("'%s' was renamed to '%s'", from, to)}
+}
+
+// ScaleField moves the value of a top-level numeric field to its new name after multiplying it by factor, which is used when a field
+// changes units (such as seconds to milliseconds). The value of the new name is kept when both are set.
+func ScaleField(raw map[string]interface{}, from string, to string, factor float64) ([]string, error) {
+	v, ok := raw[from]
+	if !ok {
+		return nil, nil
+	}
+	delete(raw, from)
+	if _, exists := raw[to]; exists {
+		return []string{fmt.Sprintf("'%s' was replaced by '%s' which is also set, ignoring the value of '%s'", from, to, from)}, nil
+	}
+
+	switch n := v.(type) {
+	case int64:
+		scaled := float64(n) * factor
+		if scaled == float64(int64(scaled)) {
+			raw[to] = int64(scaled)
+		} else {
+			raw[to] = scaled
+		}
+	case float64:
+		raw[to] = n * factor
+	default:
+		return nil, fmt.Errorf("'%s' needs to be a number but was of type %T", from, v)
+	}
+	return []string{fmt.Sprintf("'%s' was replaced by '%s' in different units, converted %v to %v", from, to, v, raw[to])}, nil
+}
@@ -0,0 +1,179 @@
+package toml
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testVersionedConfig struct {
+	TickIntervalMillis int64   `valid:"-" toml:"TICK_INTERVAL_MILLIS"`
+	Spread             float64 `valid:"-" toml:"SPREAD"`
+}
+
+// testVersionedSchema renamed SPREAD_PERCENT to SPREAD in version 2 and replaced TICK_INTERVAL_SECONDS with TICK_INTERVAL_MILLIS in
+// version 3
+var testVersionedSchema = Schema{
+	Name:    "test",
+	Version: 3,
+	Migrations: []Migration{
+		{FromVersion: 2, Migrate: func(raw map[string]interface{}) ([]string, error) {
+			return ScaleField(raw, "TICK_INTERVAL_SECONDS", "TICK_INTERVAL_MILLIS", 1000)
+		}},
+		{FromVersion: 1, Migrate: func(raw map[string]interface{}) ([]string, error) {
+			return RenameField(raw, "SPREAD_PERCENT", "SPREAD"), nil
+		}},
+	},
+}
+
+func TestSchemaUpgrade(t *testing.T) {
+	testCases := []struct {
+		name         string
+		raw          map[string]interface{}
+		wantRaw      map[string]interface{}
+		wantWarnings int
+		wantErr      bool
+	}{
+		{
+			name:         "no version is version 1",
+			raw:          map[string]interface{}{"SPREAD_PERCENT": 0.01, "TICK_INTERVAL_SECONDS": int64(5)},
+			wantRaw:      map[string]interface{}{"SPREAD": 0.01, "TICK_INTERVAL_MILLIS": int64(5000)},
+			wantWarnings: 2,
+		}, {
+			name:         "version 2",
+			raw:          map[string]interface{}{VersionKey: int64(2), "SPREAD_PERCENT": 0.01, "TICK_INTERVAL_SECONDS": 1.5},
+			wantRaw:      map[string]interface{}{"SPREAD_PERCENT": 0.01, "TICK_INTERVAL_MILLIS": 1500.0},
+			wantWarnings: 1,
+		}, {
+			name:         "current version",
+			raw:          map[string]interface{}{VersionKey: int64(3), "SPREAD": 0.01, "TICK_INTERVAL_MILLIS": int64(100)},
+			wantRaw:      map[string]interface{}{"SPREAD": 0.01, "TICK_INTERVAL_MILLIS": int64(100)},
+			wantWarnings: 0,
+		}, {
+			name:         "new name wins",
+			raw:          map[string]interface{}{"SPREAD_PERCENT": 0.01, "SPREAD": 0.02},
+			wantRaw:      map[string]interface{}{"SPREAD": 0.02},
+			wantWarnings: 1,
+		}, {
+			name:    "newer version",
+			raw:     map[string]interface{}{VersionKey: int64(4)},
+			wantErr: true,
+		}, {
+			name:    "invalid version",
+			raw:     map[string]interface{}{VersionKey: int64(0)},
+			wantErr: true,
+		}, {
+			name:    "version is not an integer",
+			raw:     map[string]interface{}{VersionKey: "3"},
+			wantErr: true,
+		}, {
+			name:    "unit change of a non-number",
+			raw:     map[string]interface{}{VersionKey: int64(2), "TICK_INTERVAL_SECONDS": "5"},
+			wantErr: true,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			warnings, e := testVersionedSchema.Upgrade(k.raw)
+			if k.wantErr {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, k.wantRaw, k.raw)
+			assert.Equal(t, k.wantWarnings, len(warnings), fmt.Sprintf("%v", warnings))
+		})
+	}
+}
+
+func TestSchemaUpgrade_MissingMigration(t *testing.T) {
+	s := Schema{Name: "test", Version: 2}
+	_, e := s.Upgrade(map[string]interface{}{})
+	assert.Error(t, e)
+}
+
+func TestReadVersionedConfig(t *testing.T) {
+	dir, e := ioutil.TempDir("", "versioned")
+	if !assert.NoError(t, e) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	oldFile := filepath.Join(dir, "old.cfg")
+	e = ioutil.WriteFile(oldFile, []byte("SPREAD_PERCENT=0.01\nTICK_INTERVAL_SECONDS=5\n"), 0644)
+	if !assert.NoError(t, e) {
+		return
+	}
+	var cfg testVersionedConfig
+	e = ReadVersionedConfig(oldFile, testVersionedSchema, &cfg)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, testVersionedConfig{TickIntervalMillis: 5000, Spread: 0.01}, cfg)
+
+	// a written file is at the current version so it is read without any migrations
+	newFile := filepath.Join(dir, "new.cfg")
+	e = WriteVersionedFile(newFile, testVersionedSchema, &cfg)
+	if !assert.NoError(t, e) {
+		return
+	}
+	contents, e := ioutil.ReadFile(newFile)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Contains(t, string(contents), "CONFIG_VERSION = 3")
+	var readBack testVersionedConfig
+	e = ReadVersionedConfig(newFile, testVersionedSchema, &readBack)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, cfg, readBack)
+
+	unknownFile := filepath.Join(dir, "unknown.cfg")
+	e = ioutil.WriteFile(unknownFile, []byte("CONFIG_VERSION=3\nSPREAD_PERCENT=0.01\n"), 0644)
+	if !assert.NoError(t, e) {
+		return
+	}
+	e = ReadVersionedConfig(unknownFile, testVersionedSchema, &cfg)
+	assert.Error(t, e)
+}
+
+func TestReadVersionedConfig_Validates(t *testing.T) {
+	dir, e := ioutil.TempDir("", "versioned")
+	if !assert.NoError(t, e) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	type requiredConfig struct {
+		Name string `valid:"required" toml:"NAME"`
+	}
+	filePath := filepath.Join(dir, "required.cfg")
+	e = ioutil.WriteFile(filePath, []byte("NAME=\"\"\n"), 0644)
+	if !assert.NoError(t, e) {
+		return
+	}
+	var cfg requiredConfig
+	e = ReadVersionedConfig(filePath, Schema{Name: "test", Version: 1}, &cfg)
+	if !assert.Error(t, e) {
+		return
+	}
+	assert.Contains(t, e.Error(), "Name")
+
+	e = ioutil.WriteFile(filePath, []byte("NAME=\"kelp\"\n"), 0644)
+	if !assert.NoError(t, e) {
+		return
+	}
+	e = ReadVersionedConfig(filePath, Schema{Name: "test", Version: 1}, &cfg)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, "kelp", cfg.Name)
+}
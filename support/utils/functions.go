@@ -380,6 +380,26 @@ func StringSet(list []string) map[string]bool {
 	return m
 }
 
+// MaxOpsPerTransaction is the maximum number of operations allowed in a single transaction on the Stellar network
+const MaxOpsPerTransaction = 100
+
+// ChunkOps splits the ops into consecutive groups of at most chunkSize ops, preserving the order of the ops
+func ChunkOps(ops []txnbuild.Operation, chunkSize int) ([][]txnbuild.Operation, error) {
+	if chunkSize <= 0 {
+		return nil, fmt.Errorf("chunkSize needs to be > 0 but was %d", chunkSize)
+	}
+
+	chunks := [][]txnbuild.Operation{}
+	for start := 0; start < len(ops); start += chunkSize {
+		end := start + chunkSize
+		if end > len(ops) {
+			end = len(ops)
+		}
+		chunks = append(chunks, ops[start:end])
+	}
+	return chunks, nil
+}
+
 // Dedupe removes duplicates from the list
 func Dedupe(list []string) []string {
 	seen := map[string]bool{}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/stellar/go/txnbuild"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -110,6 +111,53 @@ func TestDedupe(t *testing.T) {
 	}
 }
 
+func TestChunkOps(t *testing.T) {
+	makeOps := func(n int) []txnbuild.Operation {
+		ops := []txnbuild.Operation{}
+		for i := 0; i < n; i++ {
+			ops = append(ops, &txnbuild.ManageSellOffer{OfferID: int64(i)})
+		}
+		return ops
+	}
+
+	testCases := []struct {
+		numOps         int
+		chunkSize      int
+		wantChunkSizes []int
+	}{
+		{numOps: 0, chunkSize: 100, wantChunkSizes: []int{}},
+		{numOps: 1, chunkSize: 100, wantChunkSizes: []int{1}},
+		{numOps: 100, chunkSize: 100, wantChunkSizes: []int{100}},
+		{numOps: 101, chunkSize: 100, wantChunkSizes: []int{100, 1}},
+		{numOps: 250, chunkSize: 100, wantChunkSizes: []int{100, 100, 50}},
+	}
+
+	for _, kase := range testCases {
+		t.Run(fmt.Sprintf("%d_ops_size_%d", kase.numOps, kase.chunkSize), func(t *testing.T) {
+			ops := makeOps(kase.numOps)
+			chunks, e := ChunkOps(ops, kase.chunkSize)
+			if !assert.NoError(t, e) {
+				return
+			}
+
+			chunkSizes := []int{}
+			flattened := []txnbuild.Operation{}
+			for _, c := range chunks {
+				chunkSizes = append(chunkSizes, len(c))
+				flattened = append(flattened, c...)
+			}
+			assert.Equal(t, kase.wantChunkSizes, chunkSizes)
+			// order of ops is preserved across chunks
+			assert.Equal(t, ops, flattened)
+		})
+	}
+
+	for _, chunkSize := range []int{0, -1} {
+		_, e := ChunkOps(makeOps(5), chunkSize)
+		assert.Error(t, e, "chunkSize %d", chunkSize)
+	}
+}
+
 func TestToMapStringInterface_SuccessMap(t *testing.T) {
 	success := map[string]interface{}{
 		"test": true,
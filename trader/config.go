@@ -2,6 +2,8 @@ package trader
 
 import (
 	"fmt"
+	"os"
+	"strings"
 
 	hProtocol "github.com/stellar/go/protocols/horizon"
 	"github.com/stellar/kelp/support/postgresdb"
@@ -12,6 +14,14 @@ import (
 // XLM is a constant for XLM
 const XLM = "XLM"
 
+// environment variables that supply the secret seeds when they are left empty in the config file, so the secrets can live in a separate
+// file that only the service account can read, such as the env file of a service created with the install-service command
+const (
+	EnvTradingSecretSeed = "KELP_TRADING_SECRET_SEED"
+	EnvSourceSecretSeed  = "KELP_SOURCE_SECRET_SEED"
+	EnvCmcAPIKey         = "KELP_CMC_API_KEY"
+)
+
 // FeeConfig represents input data for how to deal with network fees
 type FeeConfig struct {
 	CapacityTrigger float64 `valid:"-" toml:"CAPACITY_TRIGGER" json:"capacity_trigger"`     // trigger when "ledger_capacity_usage" in /fee_stats is >= this value
@@ -19,6 +29,27 @@ type FeeConfig struct {
 	MaxOpFeeStroops uint64  `valid:"-" toml:"MAX_OP_FEE_STROOPS" json:"max_op_fee_stroops"` // max fee in stroops per operation to use
 }
 
+// PairWhitelistConfig lists the pairs a bot is allowed to trade on an exchange, optionally restricted to a single account
+type PairWhitelistConfig struct {
+	Exchange string   `valid:"-" toml:"EXCHANGE" json:"exchange"`
+	Account  string   `valid:"-" toml:"ACCOUNT" json:"account"` // trading account on sdex or the API key on other exchanges, empty matches any account
+	Pairs    []string `valid:"-" toml:"PAIRS" json:"pairs"`
+}
+
+// FilterConfig declares a filter as a table, which allows it to be named and moved in the filter chain. The filter is the same as the
+// filter string "<TYPE>/<PARAMS[0]>/<PARAMS[1]>/..." in FILTERS
+type FilterConfig struct {
+	Type     string   `valid:"-" toml:"TYPE" json:"type"`
+	Params   []string `valid:"-" toml:"PARAMS" json:"params"`
+	Name     string   `valid:"-" toml:"NAME" json:"name"`         // label used in the logs and metrics of the filter, defaults to the filter string
+	Priority *int     `valid:"-" toml:"PRIORITY" json:"priority"` // overrides the priority of the filter in the filter chain when set
+}
+
+// FilterString returns the filter string of this filter in the format used by FILTERS
+func (f FilterConfig) FilterString() string {
+	return strings.Join(append([]string{f.Type}, f.Params...), "/")
+}
+
 // BotConfig represents the configuration params for the bot
 type BotConfig struct {
 	SourceSecretSeed  string `valid:"-" toml:"SOURCE_SECRET_SEED" json:"source_secret_seed"`
@@ -34,6 +65,7 @@ type BotConfig struct {
 	SleepMode                          string     `valid:"-" toml:"SLEEP_MODE" json:"sleep_mode"`
 	DeleteCyclesThreshold              int64      `valid:"-" toml:"DELETE_CYCLES_THRESHOLD" json:"delete_cycles_threshold"`
 	SubmitMode                         string     `valid:"-" toml:"SUBMIT_MODE" json:"submit_mode"`
+	MakerOnlyCrossingAction            string     `valid:"-" toml:"MAKER_ONLY_CROSSING_ACTION" json:"maker_only_crossing_action"`
 	FillTrackerSleepMillis             uint32     `valid:"-" toml:"FILL_TRACKER_SLEEP_MILLIS" json:"fill_tracker_sleep_millis"`
 	FillTrackerDeleteCyclesThreshold   int64      `valid:"-" toml:"FILL_TRACKER_DELETE_CYCLES_THRESHOLD" json:"fill_tracker_delete_cycles_threshold"`
 	SynchronizeStateLoadEnable         bool       `valid:"-" toml:"SYNCHRONIZE_STATE_LOAD_ENABLE"`
@@ -53,18 +85,49 @@ type BotConfig struct {
 	PostgresDbConfig                   *postgresdb.Config       `valid:"-" toml:"POSTGRES_DB" json:"postgres_db"`
 	DbOverrideAccountID                string                   `valid:"-" toml:"DB_OVERRIDE__ACCOUNT_ID" json:"db_override__account_id"`
 	Filters                            []string                 `valid:"-" toml:"FILTERS" json:"filters"`
+	FilterTables                       []FilterConfig           `valid:"-" toml:"FILTER" json:"filter"`
 	AlertType                          string                   `valid:"-" toml:"ALERT_TYPE" json:"alert_type"`
 	AlertAPIKey                        string                   `valid:"-" toml:"ALERT_API_KEY" json:"alert_api_key"`
 	MonitoringPort                     uint16                   `valid:"-" toml:"MONITORING_PORT" json:"monitoring_port"`
 	MonitoringTLSCert                  string                   `valid:"-" toml:"MONITORING_TLS_CERT" json:"monitoring_tls_cert"`
 	MonitoringTLSKey                   string                   `valid:"-" toml:"MONITORING_TLS_KEY" json:"monitoring_tls_key"`
+	RunSummaryFile                     string                   `valid:"-" toml:"RUN_SUMMARY_FILE" json:"run_summary_file"`
+	RunSummaryWebhookURL               string                   `valid:"-" toml:"RUN_SUMMARY_WEBHOOK_URL" json:"run_summary_webhook_url"`
+	TradeTap                           string                   `valid:"-" toml:"TRADE_TAP" json:"trade_tap"`
+	ChurnReportIntervalSeconds         int32                    `valid:"-" toml:"CHURN_REPORT_INTERVAL_SECONDS" json:"churn_report_interval_seconds"`
+	MaintenanceIntervalSeconds         map[string]int64         `valid:"-" toml:"MAINTENANCE_INTERVAL_SECONDS" json:"maintenance_interval_seconds"`
+	BalanceAnomalyBaseTolerance        *float64                 `valid:"-" toml:"BALANCE_ANOMALY_BASE_TOLERANCE" json:"balance_anomaly_base_tolerance"`
+	BalanceAnomalyQuoteTolerance       *float64                 `valid:"-" toml:"BALANCE_ANOMALY_QUOTE_TOLERANCE" json:"balance_anomaly_quote_tolerance"`
+	InventoryLotMethod                 string                   `valid:"-" toml:"INVENTORY_LOT_METHOD" json:"inventory_lot_method"`
+	SpreadObligationBps                float64                  `valid:"-" toml:"SPREAD_OBLIGATION_BPS" json:"spread_obligation_bps"`
+	SpreadObligationMinDepth           float64                  `valid:"-" toml:"SPREAD_OBLIGATION_MIN_DEPTH" json:"spread_obligation_min_depth"`
+	TraceOrders                        bool                     `valid:"-" toml:"TRACE_ORDERS" json:"trace_orders"`
+	ExplainDecisions                   bool                     `valid:"-" toml:"EXPLAIN_DECISIONS" json:"explain_decisions"`
 	GoogleClientID                     string                   `valid:"-" toml:"GOOGLE_CLIENT_ID" json:"google_client_id"`
 	GoogleClientSecret                 string                   `valid:"-" toml:"GOOGLE_CLIENT_SECRET" json:"google_client_secret"`
 	AcceptableEmails                   string                   `valid:"-" toml:"ACCEPTABLE_GOOGLE_EMAILS" json:"acceptable_google_emails"`
 	TradingExchange                    string                   `valid:"-" toml:"TRADING_EXCHANGE" json:"trading_exchange"`
+	APIKeyPermissionsCheck             string                   `valid:"-" toml:"API_KEY_PERMISSIONS_CHECK" json:"api_key_permissions_check"`
+	APIKeyPermissionsWithdraw          bool                     `valid:"-" toml:"API_KEY_PERMISSIONS_WITHDRAW" json:"api_key_permissions_withdraw"`
 	ExchangeAPIKeys                    toml.ExchangeAPIKeysToml `valid:"-" toml:"EXCHANGE_API_KEYS" json:"exchange_api_keys"`
 	ExchangeParams                     toml.ExchangeParamsToml  `valid:"-" toml:"EXCHANGE_PARAMS" json:"exchange_params"`
 	ExchangeHeaders                    toml.ExchangeHeadersToml `valid:"-" toml:"EXCHANGE_HEADERS" json:"exchange_headers"`
+	PairWhitelist                      []PairWhitelistConfig    `valid:"-" toml:"PAIR_WHITELIST" json:"pair_whitelist"`
+	CmcAPIKey                          string                   `valid:"-" toml:"CMC_API_KEY" json:"cmc_api_key"`
+	CmcSymbolMap                       map[string]string        `valid:"-" toml:"CMC_SYMBOL_MAP" json:"cmc_symbol_map"`
+	FiatAPIKeys                        map[string]string        `valid:"-" toml:"FIAT_API_KEYS" json:"fiat_api_keys"`
+	SorobanRPCURL                      string                   `valid:"-" toml:"SOROBAN_RPC_URL" json:"soroban_rpc_url"`
+	OracleSourceAccount                string                   `valid:"-" toml:"ORACLE_SOURCE_ACCOUNT" json:"oracle_source_account"`
+	FeedMaxStalenessSeconds            int32                    `valid:"-" toml:"FEED_MAX_STALENESS_SECONDS" json:"feed_max_staleness_seconds"`
+	FeedMaxChangeFraction              float64                  `valid:"-" toml:"FEED_MAX_CHANGE_FRACTION" json:"feed_max_change_fraction"`
+	FeedReanchorRejections             int                      `valid:"-" toml:"FEED_REANCHOR_REJECTIONS" json:"feed_reanchor_rejections"`
+	FeedReanchorSeconds                int32                    `valid:"-" toml:"FEED_REANCHOR_SECONDS" json:"feed_reanchor_seconds"`
+	FeedPriceBounds                    map[string][]float64     `valid:"-" toml:"FEED_PRICE_BOUNDS" json:"feed_price_bounds"`
+	PriceFeedCacheSeconds              float64                  `valid:"-" toml:"PRICE_FEED_CACHE_SECONDS" json:"price_feed_cache_seconds"`
+	MaxTxNotional                      float64                  `valid:"-" toml:"MAX_TX_NOTIONAL" json:"max_tx_notional"`
+	MaxTxNotionalFeedType              string                   `valid:"-" toml:"MAX_TX_NOTIONAL_FEED_TYPE" json:"max_tx_notional_feed_type"`
+	MaxTxNotionalFeedURL               string                   `valid:"-" toml:"MAX_TX_NOTIONAL_FEED_URL" json:"max_tx_notional_feed_url"`
+	MaxTxNotionalAssetFeeds            map[string]string        `valid:"-" toml:"MAX_TX_NOTIONAL_ASSET_FEEDS" json:"max_tx_notional_asset_feeds"`
 
 	// initialized later
 	tradingAccount *string
@@ -132,6 +195,8 @@ func (b BotConfig) String() string {
 		"SOURCE_SECRET_SEED":       utils.SecretKey2PublicKey,
 		"TRADING_SECRET_SEED":      utils.SecretKey2PublicKey,
 		"ALERT_API_KEY":            utils.Hide,
+		"CMC_API_KEY":              utils.Hide,
+		"FIAT_API_KEYS":            utils.Hide,
 		"GOOGLE_CLIENT_ID":         utils.Hide,
 		"GOOGLE_CLIENT_SECRET":     utils.Hide,
 		"ACCEPTABLE_GOOGLE_EMAILS": utils.Hide,
@@ -182,6 +247,55 @@ func (b *BotConfig) TradingExchangeName() string {
 	return b.TradingExchange
 }
 
+// WhitelistedPairs returns the pairs whitelisted for the trading exchange and account of this bot, and whether a whitelist is configured.
+// When a whitelist is configured but has no entry for this exchange and account then no pairs are allowed.
+func (b *BotConfig) WhitelistedPairs() ([]string, bool) {
+	if len(b.PairWhitelist) == 0 {
+		return nil, false
+	}
+
+	account := b.TradingAccount()
+	if !b.IsTradingSdex() {
+		account = ""
+		apiKeys := b.ExchangeAPIKeys.ToExchangeAPIKeys()
+		if len(apiKeys) > 0 {
+			account = apiKeys[0].Key
+		}
+	}
+
+	pairs := []string{}
+	for _, w := range b.PairWhitelist {
+		if w.Exchange != b.TradingExchangeName() {
+			continue
+		}
+		if w.Account != "" && w.Account != account {
+			continue
+		}
+		pairs = append(pairs, w.Pairs...)
+	}
+	return pairs, true
+}
+
+// HasFilters returns true if any filters are configured in FILTERS or in FILTER tables
+func (b *BotConfig) HasFilters() bool {
+	return len(b.Filters) > 0 || len(b.FilterTables) > 0
+}
+
+// LoadSecretsFromEnv fills in the secret seeds and the CoinMarketCap API key that are empty in the config file from the EnvTradingSecretSeed,
+// EnvSourceSecretSeed and EnvCmcAPIKey environment variables, it should be called before Init and only by commands that do not write the
+// config back to a file
+func (b *BotConfig) LoadSecretsFromEnv() {
+	if b.TradingSecretSeed == "" {
+		b.TradingSecretSeed = os.Getenv(EnvTradingSecretSeed)
+	}
+	if b.SourceSecretSeed == "" {
+		b.SourceSecretSeed = os.Getenv(EnvSourceSecretSeed)
+	}
+	if b.CmcAPIKey == "" {
+		b.CmcAPIKey = os.Getenv(EnvCmcAPIKey)
+	}
+}
+
 // Init initializes this config
 func (b *BotConfig) Init() error {
 	b.isTradingSdex = b.IsTradingSdex()
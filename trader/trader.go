@@ -6,6 +6,7 @@ import (
 	"math"
 	"sort"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/nikhilsaraf/go-tools/multithreading"
@@ -17,11 +18,37 @@ import (
 	"github.com/stellar/kelp/api"
 	"github.com/stellar/kelp/model"
 	"github.com/stellar/kelp/plugins"
+	"github.com/stellar/kelp/support/assetdisplay"
 	"github.com/stellar/kelp/support/utils"
 )
 
 const maxLumenTrust float64 = math.MaxFloat64
 
+// controlChanSize is the number of control commands that can be queued between two update cycles
+const controlChanSize = 10
+
+// feeBumpMultiplier is the factor by which the fee per operation is increased for the next update after a transaction failed with tx_insufficient_fee
+const feeBumpMultiplier = 2
+
+// ControlCommand is a command that changes the behavior of a running Trader without restarting it
+type ControlCommand string
+
+// ControlCommand values
+const (
+	ControlCommandPause  ControlCommand = "pause"
+	ControlCommandResume ControlCommand = "resume"
+)
+
+// ParseControlCommand converts a string into a ControlCommand
+func ParseControlCommand(command string) (ControlCommand, error) {
+	switch ControlCommand(command) {
+	case ControlCommandPause, ControlCommandResume:
+		return ControlCommand(command), nil
+	default:
+		return "", fmt.Errorf("unrecognized control command '%s', needs to be one of '%s' or '%s'", command, ControlCommandPause, ControlCommandResume)
+	}
+}
+
 // Trader represents a market making bot, which is composed of various parts include the strategy and various APIs.
 type Trader struct {
 	api                            *horizonclient.Client
@@ -42,23 +69,44 @@ type Trader struct {
 	deleteCyclesThreshold          int64
 	submitMode                     api.SubmitMode
 	submitFilters                  []plugins.SubmitFilter
+	offerGroupingFilters           []plugins.OfferGroupingFilter
+	submitListeners                []plugins.SubmitListener
+	maxOpFeeStroops                uint64 // cap when bumping the fee of a transaction, 0 disables the fee bump
 	threadTracker                  *multithreading.ThreadTracker
 	fixedIterations                *uint64
 	dataKey                        *model.BotKey
 	alert                          api.Alert
 	metricsTracker                 *plugins.MetricsTracker
+	runSummaryTracker              *plugins.RunSummaryTracker
+	balanceAnomalyDetector         *plugins.BalanceAnomalyDetector  // nil when balance anomaly detection is disabled
+	spreadObligationTracker        *plugins.SpreadObligationTracker // nil when spread obligations are not tracked
+	orderTracer                    *plugins.OrderTracer             // nil when orders are not traced
+	decisionRecorder               *plugins.DecisionRecorder        // nil when decisions are not explained
+	clock                          api.Clock
 	startTime                      time.Time
 
 	// initialized runtime vars
-	deleteCycles int64
+	deleteCycles     int64
+	controlChan      chan ControlCommand
+	bumpedOpFeeMutex *sync.Mutex
+	lastSuccessMutex *sync.Mutex
+
+	// end time of the last successful update cycle, or the start time of the bot before the first one, guarded by lastSuccessMutex
+	lastSuccessTime time.Time
+
+	// set by the async callback when a transaction failed with tx_insufficient_fee and used by the ops of the next update, 0 when unset
+	bumpedOpFeeStroops uint64
 
 	// uninitialized runtime vars
 	maxAssetA      float64
 	maxAssetB      float64
 	trustAssetA    float64
 	trustAssetB    float64
-	buyingAOffers  []hProtocol.Offer // quoted A/B
-	sellingAOffers []hProtocol.Offer // quoted B/A
+	buyingAOffers  []hProtocol.Offer       // quoted A/B
+	sellingAOffers []hProtocol.Offer       // quoted B/A
+	groupedOffers  []hProtocol.Offer       // offers hidden from the strategy by the offerGroupingFilters, both sides
+	balanceAnomaly *plugins.BalanceAnomaly // set once a balance anomaly is detected, which pauses the bot
+	isPaused       bool                    // set by ControlCommandPause and cleared by ControlCommandResume
 }
 
 // MakeTrader is the factory method for the Trader struct
@@ -81,11 +129,18 @@ func MakeTrader(
 	deleteCyclesThreshold int64,
 	submitMode api.SubmitMode,
 	submitFilters []plugins.SubmitFilter,
+	maxOpFeeStroops uint64,
 	threadTracker *multithreading.ThreadTracker,
 	fixedIterations *uint64,
 	dataKey *model.BotKey,
 	alert api.Alert,
 	metricsTracker *plugins.MetricsTracker,
+	runSummaryTracker *plugins.RunSummaryTracker,
+	balanceAnomalyDetector *plugins.BalanceAnomalyDetector,
+	spreadObligationTracker *plugins.SpreadObligationTracker,
+	orderTracer *plugins.OrderTracer,
+	decisionRecorder *plugins.DecisionRecorder,
+	clock api.Clock,
 	startTime time.Time,
 ) *Trader {
 	return &Trader{
@@ -107,14 +162,66 @@ func MakeTrader(
 		deleteCyclesThreshold:          deleteCyclesThreshold,
 		submitMode:                     submitMode,
 		submitFilters:                  submitFilters,
+		offerGroupingFilters:           plugins.FindOfferGroupingFilters(submitFilters),
+		submitListeners:                plugins.FindSubmitListeners(submitFilters),
+		maxOpFeeStroops:                maxOpFeeStroops,
 		threadTracker:                  threadTracker,
 		fixedIterations:                fixedIterations,
 		dataKey:                        dataKey,
 		alert:                          alert,
 		metricsTracker:                 metricsTracker,
+		runSummaryTracker:              runSummaryTracker,
+		balanceAnomalyDetector:         balanceAnomalyDetector,
+		spreadObligationTracker:        spreadObligationTracker,
+		orderTracer:                    orderTracer,
+		decisionRecorder:               decisionRecorder,
+		clock:                          clock,
 		startTime:                      startTime,
 		// initialized runtime vars
-		deleteCycles: 0,
+		deleteCycles:     0,
+		controlChan:      make(chan ControlCommand, controlChanSize),
+		bumpedOpFeeMutex: &sync.Mutex{},
+		lastSuccessMutex: &sync.Mutex{},
+		lastSuccessTime:  startTime,
+	}
+}
+
+// SendControlCommand queues a command that is applied at the start of the next update cycle, it is safe to call from any goroutine
+func (t *Trader) SendControlCommand(command ControlCommand) error {
+	select {
+	case t.controlChan <- command:
+		return nil
+	default:
+		return fmt.Errorf("control channel is full (size=%d), dropping command '%s'", controlChanSize, command)
+	}
+}
+
+// LastSuccessfulUpdate returns the time when the last successful update cycle ended, or the start time of the bot before the first one, it is
+// safe to call from any goroutine
+func (t *Trader) LastSuccessfulUpdate() time.Time {
+	t.lastSuccessMutex.Lock()
+	defer t.lastSuccessMutex.Unlock()
+	return t.lastSuccessTime
+}
+
+// applyControlCommands applies all queued control commands in the order they were sent
+func (t *Trader) applyControlCommands() {
+	for {
+		select {
+		case command := <-t.controlChan:
+			switch command {
+			case ControlCommandPause:
+				log.Printf("received control command '%s', bot will delete its offers and stop updating offers until it is resumed\n", command)
+				t.isPaused = true
+			case ControlCommandResume:
+				log.Printf("received control command '%s', bot will resume updating offers\n", command)
+				t.isPaused = false
+			default:
+				log.Printf("ignoring unrecognized control command '%s'\n", command)
+			}
+		default:
+			return
+		}
 	}
 }
 
@@ -139,11 +246,23 @@ func (t *Trader) Start() {
 			t.doSleep(lastUpdateEndTime)
 		}
 
-		currentUpdateTime := time.Now()
+		currentUpdateTime := t.clock.Now()
 		if updateRefTime.IsZero() || t.timeController.ShouldUpdate(updateRefTime, currentUpdateTime) {
+			if t.decisionRecorder != nil {
+				t.decisionRecorder.StartCycle()
+			}
 			updateResult := t.update()
-			millisForUpdate := time.Since(currentUpdateTime).Milliseconds()
+			if t.decisionRecorder != nil {
+				t.decisionRecorder.EndCycle(updateResult)
+			}
+			if updateResult.Success {
+				t.lastSuccessMutex.Lock()
+				t.lastSuccessTime = t.clock.Now()
+				t.lastSuccessMutex.Unlock()
+			}
+			millisForUpdate := t.clock.Now().Sub(currentUpdateTime).Milliseconds()
 			log.Printf("time taken for update loop: %d millis\n", millisForUpdate)
+			t.runSummaryTracker.RecordUpdate(updateResult)
 			if shouldSendUpdateMetric(t.startTime, currentUpdateTime, t.metricsTracker.GetUpdateEventSentTime()) {
 				e := t.threadTracker.TriggerGoroutine(func(inputs []interface{}) {
 					e := t.metricsTracker.SendUpdateEvent(currentUpdateTime, updateResult, millisForUpdate)
@@ -170,8 +289,8 @@ func (t *Trader) Start() {
 			t.threadTracker.Wait()
 			log.Println("----------------------------------------------------------------------------------------------------")
 			lastUpdateStartTime = currentUpdateTime
-			// lastUpdateEndTime uses the real time.Now() because we want to capture the actual end time
-			lastUpdateEndTime = time.Now()
+			// lastUpdateEndTime uses the real current time because we want to capture the actual end time
+			lastUpdateEndTime = t.clock.Now()
 		}
 
 		if !t.sleepMode.shouldSleepAtBeginning() {
@@ -184,7 +303,7 @@ func (t *Trader) Start() {
 func (t *Trader) doSleep(lastUpdateTime time.Time) {
 	sleepTime := t.timeController.SleepTime(lastUpdateTime)
 	log.Printf("sleeping for %s...\n", sleepTime)
-	time.Sleep(sleepTime)
+	t.clock.Sleep(sleepTime)
 }
 
 func shouldSendUpdateMetric(start time.Time, currentUpdate time.Time, lastMetricUpdate *time.Time) bool {
@@ -230,6 +349,8 @@ func (t *Trader) deleteAllOffers(isAsync bool) {
 	t.sellingAOffers = []hProtocol.Offer{}
 	dOps = append(dOps, t.sdex.DeleteAllOffers(t.buyingAOffers)...)
 	t.buyingAOffers = []hProtocol.Offer{}
+	dOps = append(dOps, t.sdex.DeleteAllOffers(t.groupedOffers)...)
+	t.groupedOffers = []hProtocol.Offer{}
 
 	// LOH-3 - we want to guarantee that the bot crashes if the errors exceed deleteCyclesThreshold, so we start a new thread with a sleep timer to crash the bot as a safety
 	defer func() {
@@ -238,6 +359,9 @@ func (t *Trader) deleteAllOffers(isAsync bool) {
 		log.Fatalf("%sbot should have crashed by now (programmer error?), crashing\n", logPrefix)
 	}()
 
+	// the bot always exits after this point so write the run summary now
+	t.runSummaryTracker.Finish(fmt.Sprintf("deleted all offers after %d continuous update cycles with errors", t.deleteCycles))
+
 	log.Printf("%screated %d operations to delete offers\n", logPrefix, len(dOps))
 	if len(dOps) > 0 {
 		e := t.threadTracker.TriggerGoroutine(func(inputs []interface{}) {
@@ -264,6 +388,118 @@ func (t *Trader) deleteAllOffers(isAsync bool) {
 	}
 }
 
+// pauseForBalanceAnomaly triggers an alert and deletes all offers for the bot, after which the bot stops updating offers until it is restarted
+func (t *Trader) pauseForBalanceAnomaly(anomaly *plugins.BalanceAnomaly) {
+	t.balanceAnomaly = anomaly
+	description := fmt.Sprintf("detected unexplained outflow of funds from trading account %s, pausing bot: %s", t.tradingAccount, anomaly)
+	log.Println(description)
+	t.runSummaryTracker.RecordError(plugins.RunSummaryErrorBalanceAnomaly)
+
+	if t.alert != nil {
+		e := t.alert.Trigger(description, anomaly)
+		if e != nil {
+			log.Printf("unable to trigger alert for balance anomaly: %s\n", e)
+		}
+	}
+
+	t.deleteOffersAndContinue("balance anomaly")
+}
+
+// deleteOffersAndContinue deletes all offers for the bot without exiting and returns the number of delete operations submitted
+func (t *Trader) deleteOffersAndContinue(reason string) int {
+	dOps := []txnbuild.Operation{}
+	dOps = append(dOps, t.sdex.DeleteAllOffers(t.sellingAOffers)...)
+	t.sellingAOffers = []hProtocol.Offer{}
+	dOps = append(dOps, t.sdex.DeleteAllOffers(t.buyingAOffers)...)
+	t.buyingAOffers = []hProtocol.Offer{}
+	dOps = append(dOps, t.sdex.DeleteAllOffers(t.groupedOffers)...)
+	t.groupedOffers = []hProtocol.Offer{}
+	log.Printf("created %d operations to delete offers because of %s\n", len(dOps), reason)
+	if len(dOps) == 0 {
+		return 0
+	}
+
+	// to delete offers the submitMode doesn't matter, so use api.SubmitModeBoth as the default
+	e := t.exchangeShim.SubmitOps(api.ConvertOperation2TM(dOps), api.SubmitModeBoth, nil)
+	if e != nil {
+		log.Printf("error submitting operations to delete offers because of %s: %s\n", reason, e)
+		return 0
+	}
+	return len(dOps)
+}
+
+// handleAsyncSubmitError reacts to a failed submission of the update ops based on the category of the horizon result codes. Errors that
+// cannot be handled count towards the delete cycles threshold.
+func (t *Trader) handleAsyncSubmitError(ops []txnbuild.Operation, e error) {
+	t.runSummaryTracker.RecordError(plugins.RunSummaryErrorSubmitAsync)
+	submitError, ok := e.(*plugins.SubmitError)
+	if !ok {
+		t.deleteAllOffers(true)
+		return
+	}
+
+	switch submitError.Category {
+	case plugins.SubmitErrorCategorySkipOps:
+		// the remaining ops are not resubmitted here since they can be stale by the time this callback runs and race with the ops of the
+		// next update on the same offers, instead the next update recomputes its ops from the offers on the book
+		log.Printf("(async) dropping the %d operations of the failed transaction, %d of which failed (opcodes=%v), the next update recomputes the operations\n",
+			len(ops), len(submitError.FailedOpIndices()), submitError.OperationCodes)
+	case plugins.SubmitErrorCategoryFeeBump:
+		bumpedOpFee, ok := bumpOpFee(submitError.OpFeeStroops, t.maxOpFeeStroops)
+		if !ok {
+			log.Printf("(async) cannot bump the fee of %d stroops per operation because it would exceed the max op fee of %d stroops\n", submitError.OpFeeStroops, t.maxOpFeeStroops)
+			t.deleteAllOffers(true)
+			return
+		}
+		// the ops are not resubmitted here since they can be stale by the time this callback runs, instead the ops of the next update
+		// are submitted with the bumped fee and the sequence number of the failed transaction
+		log.Printf("(async) the ops of the next update will be submitted with the fee bumped from %d to %d stroops per operation\n", submitError.OpFeeStroops, bumpedOpFee)
+		t.setBumpedOpFee(bumpedOpFee)
+	case plugins.SubmitErrorCategoryPause:
+		description := fmt.Sprintf("transaction from trading account %s failed in a way that needs to be fixed by the operator, pausing bot: %s", t.tradingAccount, submitError)
+		log.Println(description)
+		if t.alert != nil {
+			e = t.alert.Trigger(description, submitError)
+			if e != nil {
+				log.Printf("unable to trigger alert for failed transaction: %s\n", e)
+			}
+		}
+		e = t.SendControlCommand(ControlCommandPause)
+		if e != nil {
+			log.Printf("(async) unable to pause bot: %s\n", e)
+			t.deleteAllOffers(true)
+		}
+	default:
+		t.deleteAllOffers(true)
+	}
+}
+
+// bumpOpFee returns the increased fee per operation, capped at maxOpFee, and false when the fee cannot be increased
+func bumpOpFee(opFee uint64, maxOpFee uint64) (uint64, bool) {
+	bumpedOpFee := opFee * feeBumpMultiplier
+	if bumpedOpFee > maxOpFee {
+		bumpedOpFee = maxOpFee
+	}
+	return bumpedOpFee, bumpedOpFee > opFee
+}
+
+func (t *Trader) setBumpedOpFee(opFee uint64) {
+	t.bumpedOpFeeMutex.Lock()
+	defer t.bumpedOpFeeMutex.Unlock()
+
+	t.bumpedOpFeeStroops = opFee
+}
+
+// takeBumpedOpFee returns the bumped fee per operation for this update and clears it, 0 means the fee is computed as usual
+func (t *Trader) takeBumpedOpFee() uint64 {
+	t.bumpedOpFeeMutex.Lock()
+	defer t.bumpedOpFeeMutex.Unlock()
+
+	opFee := t.bumpedOpFeeStroops
+	t.bumpedOpFeeStroops = 0
+	return opFee
+}
+
 // synchronizeFetchBalancesOffersTrades pivots checking the balances and offers around trades, ensuring that:
 // 1) we fetch and process the latest trades and
 // 2) the balances and offers are consistent with the fetched trades
@@ -387,9 +623,22 @@ func (t *Trader) update() plugins.UpdateLoopResult {
 	numUpdateOpsUpdate := 0
 	numUpdateOpsCreate := 0
 
+	if t.balanceAnomaly != nil {
+		log.Printf("bot is paused because of a balance anomaly (%s), not updating offers; restart the bot once the anomaly has been investigated\n", t.balanceAnomaly)
+		t.explainNote("bot is paused because of a balance anomaly (%s)", t.balanceAnomaly)
+		return plugins.UpdateLoopResult{
+			Success:            false,
+			NumPruneOps:        numPruneOps,
+			NumUpdateOpsDelete: numUpdateOpsDelete,
+			NumUpdateOpsUpdate: numUpdateOpsUpdate,
+			NumUpdateOpsCreate: numUpdateOpsCreate,
+		}
+	}
+
 	e := t.synchronizeFetchBalancesOffersTrades()
 	if e != nil {
 		log.Println(e)
+		t.explainError("synchronize balances, offers and trades", e)
 		t.deleteAllOffers(false)
 		return plugins.UpdateLoopResult{
 			Success:            false,
@@ -400,6 +649,51 @@ func (t *Trader) update() plugins.UpdateLoopResult {
 		}
 	}
 
+	// the synchronized offers are the ones that were on the book since the previous update cycle
+	if t.spreadObligationTracker != nil {
+		e = t.spreadObligationTracker.Record(t.clock.Now(), t.sellingAOffers, t.buyingAOffers)
+		if e != nil {
+			// a missing sample only reduces the time covered by the spread obligation report, so we can continue
+			log.Printf("unable to record spread obligation sample: %s\n", e)
+		}
+	}
+	if t.orderTracer != nil {
+		t.orderTracer.ObserveOffers(t.sellingAOffers, t.buyingAOffers)
+	}
+	if t.decisionRecorder != nil {
+		t.decisionRecorder.RecordBalances(t.maxAssetA, t.maxAssetB)
+	}
+
+	if t.balanceAnomalyDetector != nil {
+		anomaly := t.balanceAnomalyDetector.Check(t.maxAssetA, t.maxAssetB)
+		if anomaly != nil {
+			t.explainNote("bot is paused because a balance anomaly was detected (%s)", anomaly)
+			t.pauseForBalanceAnomaly(anomaly)
+			return plugins.UpdateLoopResult{
+				Success:            false,
+				NumPruneOps:        numPruneOps,
+				NumUpdateOpsDelete: numUpdateOpsDelete,
+				NumUpdateOpsUpdate: numUpdateOpsUpdate,
+				NumUpdateOpsCreate: numUpdateOpsCreate,
+			}
+		}
+	}
+
+	// the strategy is not updated while paused so it keeps its state (such as the sellTwap buckets) for when the bot is resumed
+	t.applyControlCommands()
+	if t.isPaused {
+		log.Printf("bot is paused, deleting any remaining offers and not updating offers until the bot is resumed\n")
+		numUpdateOpsDelete = t.deleteOffersAndContinue("bot being paused")
+		t.explainNote("bot is paused, deleted %d remaining offers and did not run the strategy", numUpdateOpsDelete)
+		return plugins.UpdateLoopResult{
+			Success:            true,
+			NumPruneOps:        numPruneOps,
+			NumUpdateOpsDelete: numUpdateOpsDelete,
+			NumUpdateOpsUpdate: numUpdateOpsUpdate,
+			NumUpdateOpsCreate: numUpdateOpsCreate,
+		}
+	}
+
 	pair := &model.TradingPair{
 		Base:  model.FromHorizonAsset(t.assetBase),
 		Quote: model.FromHorizonAsset(t.assetQuote),
@@ -415,6 +709,7 @@ func (t *Trader) update() plugins.UpdateLoopResult {
 	t.sdex.IEIF().LogAllLiabilities(t.assetBase, t.assetQuote)
 	if e != nil {
 		log.Println(e)
+		t.explainError("reset liabilities", e)
 		t.deleteAllOffers(false)
 		return plugins.UpdateLoopResult{
 			Success:            false,
@@ -429,6 +724,7 @@ func (t *Trader) update() plugins.UpdateLoopResult {
 	e = t.strategy.PreUpdate(t.maxAssetA, t.maxAssetB, t.trustAssetA, t.trustAssetB)
 	if e != nil {
 		log.Println(e)
+		t.explainError("strategy PreUpdate", e)
 		t.deleteAllOffers(false)
 		return plugins.UpdateLoopResult{
 			Success:            false,
@@ -439,6 +735,13 @@ func (t *Trader) update() plugins.UpdateLoopResult {
 		}
 	}
 
+	// the strategy sees each group of offers as a single offer, the hidden offers are managed by the filter that grouped them
+	for _, f := range t.offerGroupingFilters {
+		var grouped []hProtocol.Offer
+		t.sellingAOffers, t.buyingAOffers, grouped = f.GroupOffers(t.sellingAOffers, t.buyingAOffers)
+		t.groupedOffers = append(t.groupedOffers, grouped...)
+	}
+
 	// delete excess offers
 	var pruneOps []build.TransactionMutator
 	pruneOps, t.buyingAOffers, t.sellingAOffers = t.strategy.PruneExistingOffers(t.buyingAOffers, t.sellingAOffers)
@@ -449,6 +752,7 @@ func (t *Trader) update() plugins.UpdateLoopResult {
 		e = t.exchangeShim.SubmitOps(pruneOps, api.SubmitModeBoth, nil)
 		if e != nil {
 			log.Println(e)
+			t.explainError("submit prune ops", e)
 			t.deleteAllOffers(false)
 			return plugins.UpdateLoopResult{
 				Success:            false,
@@ -468,6 +772,7 @@ func (t *Trader) update() plugins.UpdateLoopResult {
 		t.sdex.IEIF().LogAllLiabilities(t.assetBase, t.assetQuote)
 		if e != nil {
 			log.Println(e)
+			t.explainError("reset liabilities after pruning", e)
 			t.deleteAllOffers(false)
 			return plugins.UpdateLoopResult{
 				Success:            false,
@@ -484,6 +789,7 @@ func (t *Trader) update() plugins.UpdateLoopResult {
 	t.sdex.IEIF().LogAllLiabilities(t.assetBase, t.assetQuote)
 	if e != nil {
 		log.Println(e)
+		t.explainError("strategy UpdateWithOps", e)
 		log.Printf("liabilities (force recomputed) after encountering an error after a call to UpdateWithOps\n")
 		t.sdex.IEIF().RecomputeAndLogCachedLiabilities(t.assetBase, t.assetQuote)
 		t.deleteAllOffers(false)
@@ -500,6 +806,7 @@ func (t *Trader) update() plugins.UpdateLoopResult {
 	numUpdateOpsDelete, numUpdateOpsUpdate, numUpdateOpsCreate, e = countOfferChangeTypes(msos)
 	if e != nil {
 		log.Println(e)
+		t.explainError("count offer change types", e)
 		t.deleteAllOffers(false)
 		return plugins.UpdateLoopResult{
 			Success:            false,
@@ -510,6 +817,17 @@ func (t *Trader) update() plugins.UpdateLoopResult {
 		}
 	}
 
+	if t.orderTracer != nil {
+		t.orderTracer.StartCycle(msos)
+	}
+	if t.decisionRecorder != nil {
+		t.decisionRecorder.RecordStrategyOps(msos, plugins.DecisionOpsCounts{
+			NumPruneOps: numPruneOps,
+			NumDeletes:  numUpdateOpsDelete,
+			NumUpdates:  numUpdateOpsUpdate,
+			NumCreates:  numUpdateOpsCreate,
+		})
+	}
 	ops := api.ConvertMSO2Ops(msos)
 	for i, filter := range t.submitFilters {
 		ops, e = filter.Apply(ops, t.sellingAOffers, t.buyingAOffers)
@@ -527,15 +845,40 @@ func (t *Trader) update() plugins.UpdateLoopResult {
 	}
 
 	log.Printf("created %d operations to update existing offers\n", len(ops))
-	if len(ops) > 0 {
-		e = t.exchangeShim.SubmitOps(api.ConvertOperation2TM(ops), t.submitMode, func(hash string, e error) {
-			// if there is an error we want it to count towards the delete cycles threshold, so run the check
+	if t.decisionRecorder != nil {
+		t.decisionRecorder.RecordSubmitting(len(ops))
+	}
+	sellingAOffers, buyingAOffers := t.sellingAOffers, t.buyingAOffers
+	notifySubmitted := func(e error) {
+		for _, l := range t.submitListeners {
+			l.Submitted(ops, sellingAOffers, buyingAOffers, e)
+		}
+	}
+	if len(ops) == 0 {
+		notifySubmitted(nil)
+	} else {
+		var traceSubmit func(hash string, e error)
+		if t.orderTracer != nil {
+			traceSubmit = t.orderTracer.Submitting(ops)
+		}
+		submitCallback := func(hash string, e error) {
+			if traceSubmit != nil {
+				traceSubmit(hash, e)
+			}
+			notifySubmitted(e)
 			if e != nil {
-				t.deleteAllOffers(true)
+				t.handleAsyncSubmitError(ops, e)
 			}
-		})
+		}
+		if bumpedOpFee := t.takeBumpedOpFee(); bumpedOpFee != 0 {
+			log.Printf("submitting %d operations with a bumped fee of %d stroops per operation\n", len(ops), bumpedOpFee)
+			e = t.sdex.SubmitOpsWithOpFee(api.ConvertOperation2TM(ops), bumpedOpFee, submitCallback)
+		} else {
+			e = t.exchangeShim.SubmitOps(api.ConvertOperation2TM(ops), t.submitMode, submitCallback)
+		}
 		if e != nil {
 			log.Println(e)
+			t.explainError("submit ops", e)
 			t.deleteAllOffers(false)
 			return plugins.UpdateLoopResult{
 				Success:            false,
@@ -550,6 +893,7 @@ func (t *Trader) update() plugins.UpdateLoopResult {
 	e = t.strategy.PostUpdate()
 	if e != nil {
 		log.Println(e)
+		t.explainError("strategy PostUpdate", e)
 		t.deleteAllOffers(false)
 		return plugins.UpdateLoopResult{
 			Success:            false,
@@ -571,6 +915,20 @@ func (t *Trader) update() plugins.UpdateLoopResult {
 	}
 }
 
+// explainNote adds a note to the decision record of the update cycle when decisions are explained
+func (t *Trader) explainNote(format string, args ...interface{}) {
+	if t.decisionRecorder != nil {
+		t.decisionRecorder.Note(format, args...)
+	}
+}
+
+// explainError adds the error that stopped the update cycle at a stage to the decision record when decisions are explained
+func (t *Trader) explainError(stage string, e error) {
+	if t.decisionRecorder != nil {
+		t.decisionRecorder.RecordError(stage, e)
+	}
+}
+
 func (t *Trader) getBalances() (*api.Balance /*baseBalance*/, *api.Balance /*quoteBalance*/, error) {
 	baseBalance, e := t.exchangeShim.GetBalanceHack(t.assetBase)
 	if e != nil {
@@ -593,15 +951,15 @@ func (t *Trader) setBalances(baseBalance *api.Balance, quoteBalance *api.Balance
 
 	trustAString := "math.MaxFloat64"
 	if t.assetBase.Type != utils.Native {
-		trustAString = fmt.Sprintf("%.8f", t.trustAssetA)
+		trustAString = assetdisplay.FormatAmount(t.assetBase, t.trustAssetA)
 	}
 	trustBString := "math.MaxFloat64"
 	if t.assetQuote.Type != utils.Native {
-		trustBString = fmt.Sprintf("%.8f", t.trustAssetB)
+		trustBString = assetdisplay.FormatAmount(t.assetQuote, t.trustAssetB)
 	}
 
-	log.Printf(" (base) assetA=%s, maxA=%.8f, trustA=%s\n", utils.Asset2String(t.assetBase), t.maxAssetA, trustAString)
-	log.Printf("(quote) assetB=%s, maxB=%.8f, trustB=%s\n", utils.Asset2String(t.assetQuote), t.maxAssetB, trustBString)
+	log.Printf(" (base) assetA=%s, maxA=%s, trustA=%s\n", utils.Asset2String(t.assetBase), assetdisplay.FormatAmount(t.assetBase, t.maxAssetA), trustAString)
+	log.Printf("(quote) assetB=%s, maxB=%s, trustB=%s\n", utils.Asset2String(t.assetQuote), assetdisplay.FormatAmount(t.assetQuote, t.maxAssetB), trustBString)
 
 	if t.valueBaseFeed != nil && t.valueQuoteFeed != nil {
 		baseUsdPrice, e := t.valueBaseFeed.GetPrice()
@@ -641,6 +999,7 @@ func (t *Trader) getExistingOffers() ([]hProtocol.Offer /*sellingAOffers*/, []hP
 
 func (t *Trader) setExistingOffers(sellingAOffers []hProtocol.Offer, buyingAOffers []hProtocol.Offer) {
 	t.sellingAOffers, t.buyingAOffers = sellingAOffers, buyingAOffers
+	t.groupedOffers = []hProtocol.Offer{}
 }
 
 func countOfferChangeTypes(offers []*txnbuild.ManageSellOffer) (int /*numDelete*/, int /*numUpdate*/, int /*numCreate*/, error) {
@@ -2,6 +2,7 @@ package trader
 
 import (
 	"fmt"
+	"sync"
 	"testing"
 	"time"
 
@@ -11,6 +12,9 @@ import (
 	"github.com/stellar/go/txnbuild"
 	"github.com/stellar/kelp/api"
 	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/plugins"
+	"github.com/stellar/kelp/stellargohorizonclientv300/build"
+	"github.com/stellar/kelp/support/utils"
 )
 
 func TestIsStateSynchronized(t *testing.T) {
@@ -346,3 +350,284 @@ func createTestMSO(msoType string) *txnbuild.ManageSellOffer {
 	}
 	return &mso
 }
+
+func TestApplyControlCommands(t *testing.T) {
+	testCases := []struct {
+		name         string
+		commands     []ControlCommand
+		wantIsPaused bool
+	}{
+		{
+			name:         "no commands",
+			commands:     []ControlCommand{},
+			wantIsPaused: false,
+		}, {
+			name:         "pause",
+			commands:     []ControlCommand{ControlCommandPause},
+			wantIsPaused: true,
+		}, {
+			name:         "pause then resume",
+			commands:     []ControlCommand{ControlCommandPause, ControlCommandResume},
+			wantIsPaused: false,
+		}, {
+			name:         "resume then pause",
+			commands:     []ControlCommand{ControlCommandResume, ControlCommandPause},
+			wantIsPaused: true,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			trader := &Trader{controlChan: make(chan ControlCommand, controlChanSize)}
+			for _, c := range k.commands {
+				e := trader.SendControlCommand(c)
+				if !assert.NoError(t, e) {
+					return
+				}
+			}
+
+			trader.applyControlCommands()
+			assert.Equal(t, k.wantIsPaused, trader.isPaused)
+			assert.Equal(t, 0, len(trader.controlChan))
+		})
+	}
+}
+
+func TestSendControlCommand_Full(t *testing.T) {
+	trader := &Trader{controlChan: make(chan ControlCommand, 1)}
+	assert.NoError(t, trader.SendControlCommand(ControlCommandPause))
+	assert.Error(t, trader.SendControlCommand(ControlCommandResume))
+}
+
+func TestParseControlCommand(t *testing.T) {
+	c, e := ParseControlCommand("pause")
+	assert.NoError(t, e)
+	assert.Equal(t, ControlCommandPause, c)
+
+	c, e = ParseControlCommand("resume")
+	assert.NoError(t, e)
+	assert.Equal(t, ControlCommandResume, c)
+
+	_, e = ParseControlCommand("stop")
+	assert.Error(t, e)
+}
+
+// recordingExchangeShim records the offer IDs of every submission and fails the first one with failFirstWith
+type recordingExchangeShim struct {
+	api.ExchangeShim
+	failFirstWith error
+	submitted     [][]int64
+}
+
+func (s *recordingExchangeShim) SubmitOps(ops []build.TransactionMutator, submitMode api.SubmitMode, asyncCallback func(hash string, e error)) error {
+	offerIDs := []int64{}
+	for _, mso := range api.ConvertTM2MSO(ops) {
+		offerIDs = append(offerIDs, mso.OfferID)
+	}
+	s.submitted = append(s.submitted, offerIDs)
+
+	var e error
+	if len(s.submitted) == 1 {
+		e = s.failFirstWith
+	}
+	if asyncCallback != nil {
+		asyncCallback("hash", e)
+	}
+	return nil
+}
+
+func TestHandleAsyncSubmitError_SkipOpsNotResubmitted(t *testing.T) {
+	makeOp := func(offerID int64) txnbuild.Operation {
+		return &txnbuild.ManageSellOffer{
+			Selling: txnbuild.NativeAsset{},
+			Buying:  txnbuild.CreditAsset{Code: "USD", Issuer: "GBMMZMK2DC4FFP4CAI6KCVNCQ7WLO5A7DQU7EC7WGHRDQBZB763X4OQI"},
+			Amount:  "10.0000000",
+			Price:   "0.5",
+			OfferID: offerID,
+		}
+	}
+	exchangeShim := &recordingExchangeShim{
+		failFirstWith: &plugins.SubmitError{
+			TransactionCode: "tx_failed",
+			OperationCodes:  []string{"op_underfunded", "op_success"},
+			Category:        plugins.SubmitErrorCategorySkipOps,
+		},
+	}
+	trader := &Trader{
+		exchangeShim:          exchangeShim,
+		deleteCyclesThreshold: 0,
+		runSummaryTracker:     plugins.MakeRunSummaryTracker(nil, time.Now(), "", ""),
+	}
+
+	// the transaction of the first cycle fails because of the op on offer 1
+	ops := []txnbuild.Operation{makeOp(1), makeOp(2)}
+	e := trader.exchangeShim.SubmitOps(api.ConvertOperation2TM(ops), api.SubmitModeBoth, func(hash string, e error) {
+		if e != nil {
+			trader.handleAsyncSubmitError(ops, e)
+		}
+	})
+	if !assert.NoError(t, e) {
+		return
+	}
+	// the next cycle recomputes its ops for offer 2
+	e = trader.exchangeShim.SubmitOps(api.ConvertOperation2TM([]txnbuild.Operation{makeOp(2)}), api.SubmitModeBoth, nil)
+	if !assert.NoError(t, e) {
+		return
+	}
+
+	// the remaining op on offer 2 is not resubmitted by the callback so offer 2 is only submitted once after the failed transaction
+	assert.Equal(t, [][]int64{{1, 2}, {2}}, exchangeShim.submitted)
+	// skipped ops do not count towards the delete cycles threshold
+	assert.Equal(t, int64(0), trader.deleteCycles)
+}
+
+func TestBumpOpFee(t *testing.T) {
+	testCases := []struct {
+		opFee    uint64
+		maxOpFee uint64
+		wantFee  uint64
+		wantOk   bool
+	}{
+		{opFee: 100, maxOpFee: 1000, wantFee: 200, wantOk: true},
+		{opFee: 100, maxOpFee: 150, wantFee: 150, wantOk: true},
+		{opFee: 150, maxOpFee: 150, wantFee: 150, wantOk: false},
+		{opFee: 100, maxOpFee: 0, wantFee: 0, wantOk: false},
+	}
+
+	for _, k := range testCases {
+		t.Run(fmt.Sprintf("%d_%d", k.opFee, k.maxOpFee), func(t *testing.T) {
+			fee, ok := bumpOpFee(k.opFee, k.maxOpFee)
+			assert.Equal(t, k.wantFee, fee)
+			assert.Equal(t, k.wantOk, ok)
+		})
+	}
+}
+
+func TestTakeBumpedOpFee(t *testing.T) {
+	trader := &Trader{bumpedOpFeeMutex: &sync.Mutex{}}
+	assert.Equal(t, uint64(0), trader.takeBumpedOpFee())
+
+	// the bumped fee is only used by the ops of the next update
+	trader.setBumpedOpFee(200)
+	assert.Equal(t, uint64(200), trader.takeBumpedOpFee())
+	assert.Equal(t, uint64(0), trader.takeBumpedOpFee())
+}
+
+// fillLandingExchangeShim lands a sell fill on the exchange right after the first fetch of the balances, before the offers are loaded
+type fillLandingExchangeShim struct {
+	api.ExchangeShim
+	assetBase hProtocol.Asset
+	landed    bool
+}
+
+func (s *fillLandingExchangeShim) GetBalanceHack(asset hProtocol.Asset) (*api.Balance, error) {
+	balance := 100.0
+	if asset != s.assetBase {
+		balance = 1000.0
+	}
+	if s.landed {
+		// sold 10 units of the base asset at a price of 10.0
+		if asset == s.assetBase {
+			balance -= 10.0
+		} else {
+			balance += 100.0
+		}
+	}
+	return &api.Balance{Balance: balance, Trust: balance}, nil
+}
+
+func (s *fillLandingExchangeShim) LoadOffersHack() ([]hProtocol.Offer, error) {
+	s.landed = true
+	return []hProtocol.Offer{}, nil
+}
+
+// landedFillTracker hands the fill that landed on the exchange to its handlers once, like the fill tracker does
+type landedFillTracker struct {
+	api.FillTracker
+	exchange            *fillLandingExchangeShim
+	handler             api.FillHandler
+	runningInBackground bool
+	handled             bool
+}
+
+func (f *landedFillTracker) IsRunningInBackground() bool {
+	return f.runningInBackground
+}
+
+func (f *landedFillTracker) FillTrackSingleIteration() ([]model.Trade, error) {
+	if !f.exchange.landed || f.handled {
+		return []model.Trade{}, nil
+	}
+	f.handled = true
+
+	trade := model.Trade{
+		Order: model.Order{
+			OrderAction: model.OrderActionSell,
+			Price:       model.NumberFromFloat(10.0, 7),
+			Volume:      model.NumberFromFloat(10.0, 7),
+		},
+		TransactionID: model.MakeTransactionID("txid"),
+	}
+	e := f.handler.HandleFill(trade)
+	if e != nil {
+		return nil, e
+	}
+	return []model.Trade{trade}, nil
+}
+
+func TestBalanceAnomalyCheck_FillBetweenFetchAndCheck(t *testing.T) {
+	assetBase := utils.NativeAsset
+	assetQuote := hProtocol.Asset{Type: "credit_alphanum4", Code: "USD", Issuer: "GBMMZMK2DC4FFP4CAI6KCVNCQ7WLO5A7DQU7EC7WGHRDQBZB763X4OQI"}
+
+	for _, synchronizeStateLoadEnable := range []bool{true, false} {
+		t.Run(fmt.Sprintf("synchronizeStateLoadEnable=%v", synchronizeStateLoadEnable), func(t *testing.T) {
+			detector, e := plugins.MakeBalanceAnomalyDetector(0.01, 0.01)
+			if !assert.NoError(t, e) {
+				return
+			}
+			// record the balances from before the fill
+			assert.Nil(t, detector.Check(100.0, 1000.0))
+
+			exchangeShim := &fillLandingExchangeShim{assetBase: assetBase}
+			fillTracker := &landedFillTracker{
+				exchange:            exchangeShim,
+				handler:             detector,
+				runningInBackground: !synchronizeStateLoadEnable,
+			}
+			trader := &Trader{
+				assetBase:                      assetBase,
+				assetQuote:                     assetQuote,
+				sdex:                           plugins.MakeSDEX(nil, plugins.MakeIEIF(false), exchangeShim, "", "", "", "", "", nil, 0, 0, true, nil, nil, nil),
+				exchangeShim:                   exchangeShim,
+				synchronizeStateLoadEnable:     synchronizeStateLoadEnable,
+				synchronizeStateLoadMaxRetries: 2,
+				fillTracker:                    fillTracker,
+				balanceAnomalyDetector:         detector,
+			}
+
+			e = trader.synchronizeFetchBalancesOffersTrades()
+			if !assert.NoError(t, e) {
+				return
+			}
+			if !synchronizeStateLoadEnable {
+				// the background fill tracker handles the fill after the balances were fetched
+				_, e = fillTracker.FillTrackSingleIteration()
+				if !assert.NoError(t, e) {
+					return
+				}
+			}
+
+			anomaly := detector.Check(trader.maxAssetA, trader.maxAssetB)
+			if synchronizeStateLoadEnable {
+				assert.Nil(t, anomaly)
+				assert.Equal(t, 90.0, trader.maxAssetA)
+				assert.Equal(t, 1100.0, trader.maxAssetB)
+			} else {
+				// the expected proceeds of the fill are not in the stale quote balance, which is why the tolerances require synchronized loading
+				if assert.NotNil(t, anomaly) {
+					assert.InDelta(t, -100.0, anomaly.UnexplainedQuoteDelta, 1e-9)
+				}
+			}
+		})
+	}
+}